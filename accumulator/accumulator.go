@@ -0,0 +1,418 @@
+// Package accumulator implements dynamic RSA accumulators, which commit to a
+// set of elements with a single integer modulo N, along with short witnesses
+// proving that an element belongs, or doesn't belong, to the set.
+//
+// This follows Camenisch and Lysyanskaya, "Dynamic Accumulators and
+// Application to Efficient Revocation of Anonymous Credentials", for
+// membership witnesses, and Li, Li, and Xue, "Universal Accumulators with
+// Efficient Nonmembership Proofs", for non-membership witnesses.
+//
+// The accumulator of a set S is g^(∏ H(x)), for x ∈ S, where H hashes elements
+// to primes. Anyone can add elements, but deleting them requires a trapdoor,
+// the factorization of N. Each change produces an Update, which the holders of
+// witnesses apply to keep them valid, without needing to know the rest of the
+// set: this lets clients stay stateless, or a revocation list be checked by
+// only keeping track of the latest accumulator.
+//
+// The exponentiations are done with safenum, so their execution time depends
+// only on the size of the exponents, and not on the elements or trapdoor.
+// Hashing to primes, and the computation of the Bézout coefficients used by
+// witness updates, are done with math/big, and leak information about the
+// elements involved.
+package accumulator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/rand"
+	"github.com/cronokirby/safenum"
+)
+
+// primeBits is the size of the primes elements are hashed to.
+const primeBits = 256
+
+var bigOne = big.NewInt(1)
+
+// hashToPrime hashes an element to a prime of primeBits bits, by hashing it
+// with an increasing counter, until the result is prime.
+func hashToPrime(element []byte) *big.Int {
+	h := sha256.New()
+	var counter [8]byte
+	x := new(big.Int)
+	for i := uint64(0); ; i++ {
+		h.Reset()
+		h.Write([]byte("ctcrypto-accumulator-v1"))
+		binary.BigEndian.PutUint64(counter[:], i)
+		h.Write(counter[:])
+		h.Write(element)
+		digest := h.Sum(nil)
+		digest[0] |= 0x80
+		digest[len(digest)-1] |= 1
+		if x.SetBytes(digest).ProbablyPrime(20) {
+			return x
+		}
+	}
+}
+
+// Params are the public parameters of an accumulator: a modulus N, whose
+// factorization nobody but the holder of the trapdoor knows, and a generator
+// g, which should be a square modulo N.
+type Params struct {
+	n    *safenum.Modulus
+	nBig *big.Int
+	g    *safenum.Nat
+}
+
+// NewParams creates parameters from a modulus N, and a generator g, given in
+// big-endian bytes.
+//
+// N should be the product of two safe primes, and g a random square modulo N,
+// as generated by GenerateTrapdoor.
+func NewParams(n, g []byte) (*Params, error) {
+	nBig := new(big.Int).SetBytes(n)
+	gBig := new(big.Int).SetBytes(g)
+	if nBig.Bit(0) != 1 || gBig.Cmp(bigOne) <= 0 || gBig.Cmp(nBig) >= 0 || new(big.Int).GCD(nil, nil, gBig, nBig).Cmp(bigOne) != 0 {
+		return nil, errors.New("accumulator: invalid parameters")
+	}
+	return &Params{
+		n:    safenum.ModulusFromBytes(n),
+		nBig: nBig,
+		g:    new(safenum.Nat).SetBytes(g[:len(g):len(g)]),
+	}, nil
+}
+
+// N returns the modulus of the parameters.
+func (pp *Params) N() *safenum.Modulus {
+	return pp.n
+}
+
+// Generator returns the generator g, which is the accumulator of the empty set.
+func (pp *Params) Generator() *safenum.Nat {
+	return new(safenum.Nat).SetNat(pp.g)
+}
+
+// exp returns base^e mod N, for an exponent e which may be negative.
+func (pp *Params) exp(base *safenum.Nat, e *big.Int) *safenum.Nat {
+	out := new(safenum.Nat).Exp(base, natFromBig(new(big.Int).Abs(e)), pp.n)
+	if e.Sign() < 0 {
+		out.ModInverse(out, pp.n)
+	}
+	return out
+}
+
+func natFromBig(x *big.Int) *safenum.Nat {
+	return new(safenum.Nat).SetBytes(x.Bytes())
+}
+
+// Accumulator is the accumulator of a set of elements.
+type Accumulator struct {
+	params *Params
+	value  *safenum.Nat
+}
+
+// NewAccumulator returns the accumulator of the empty set.
+func NewAccumulator(pp *Params) *Accumulator {
+	return &Accumulator{params: pp, value: pp.Generator()}
+}
+
+// ParseAccumulator decodes an accumulator, as produced by Accumulator.Bytes,
+// checking that it's a unit modulo N.
+func ParseAccumulator(pp *Params, data []byte) (*Accumulator, error) {
+	v := new(big.Int).SetBytes(data)
+	if v.Sign() <= 0 || v.Cmp(pp.nBig) >= 0 || new(big.Int).GCD(nil, nil, v, pp.nBig).Cmp(bigOne) != 0 {
+		return nil, errors.New("accumulator: invalid accumulator")
+	}
+	return &Accumulator{params: pp, value: natFromBig(v)}, nil
+}
+
+// Value returns the accumulator, as an integer modulo N.
+func (acc *Accumulator) Value() *safenum.Nat {
+	return new(safenum.Nat).SetNat(acc.value)
+}
+
+// Bytes returns the big-endian encoding of the accumulator.
+func (acc *Accumulator) Bytes() []byte {
+	return acc.value.Bytes()
+}
+
+// Equal reports whether acc and other have the same value.
+func (acc *Accumulator) Equal(other *Accumulator) bool {
+	return acc.value.Cmp(other.value) == 0
+}
+
+// Update describes a change to an accumulator, which the holders of witnesses
+// must apply to them, with the Update method of the witness, for them to stay
+// valid.
+type Update struct {
+	// Element is the element added or deleted.
+	Element []byte
+	// Deleted is true if the element was deleted, and false if it was added.
+	Deleted bool
+	// Before and After are the accumulators before and after the change.
+	Before, After *Accumulator
+}
+
+// Add adds an element to the accumulator, returning the update describing the
+// change.
+//
+// Adding an element which is already in the set isn't detected, and makes it
+// necessary to delete it twice to remove it.
+func (acc *Accumulator) Add(element []byte) Update {
+	before := &Accumulator{params: acc.params, value: acc.Value()}
+	acc.value.Exp(acc.value, natFromBig(hashToPrime(element)), acc.params.n)
+	return Update{Element: element, Before: before, After: &Accumulator{params: acc.params, value: acc.Value()}}
+}
+
+// MembershipWitness proves that an element belongs to an accumulated set,
+// with a value W such that W^H(Element) is the accumulator.
+type MembershipWitness struct {
+	Element []byte
+	W       *safenum.Nat
+}
+
+// VerifyMembership reports whether w is a valid witness that w.Element
+// belongs to the set.
+func (acc *Accumulator) VerifyMembership(w *MembershipWitness) bool {
+	x := natFromBig(hashToPrime(w.Element))
+	return new(safenum.Nat).Exp(w.W, x, acc.params.n).Cmp(acc.value) == 0
+}
+
+// product returns the product of the primes of a set of elements, skipping the
+// first one equal to skip, if it isn't nil, and reporting whether it was found.
+func product(elements [][]byte, skip *big.Int) (*big.Int, bool) {
+	out := big.NewInt(1)
+	skipped := false
+	for _, e := range elements {
+		x := hashToPrime(e)
+		if skip != nil && !skipped && x.Cmp(skip) == 0 {
+			skipped = true
+			continue
+		}
+		out.Mul(out, x)
+	}
+	return out, skipped
+}
+
+// CreateMembershipWitness returns a witness that an element belongs to the
+// accumulator of a set, given all of the elements of that set, in any order.
+//
+// This takes time linear in the size of the set, but doesn't need the
+// trapdoor.
+func CreateMembershipWitness(pp *Params, members [][]byte, element []byte) (*MembershipWitness, error) {
+	u, found := product(members, hashToPrime(element))
+	if !found {
+		return nil, errors.New("accumulator: element isn't a member of the set")
+	}
+	return &MembershipWitness{Element: element, W: pp.exp(pp.g, u)}, nil
+}
+
+// Update applies a change to the accumulator to the witness.
+//
+// An error is returned if the update deletes the element of the witness,
+// which can no longer be proven to be a member.
+func (w *MembershipWitness) Update(u Update) error {
+	pp := u.After.params
+	y := hashToPrime(u.Element)
+	if !u.Deleted {
+		w.W.Exp(w.W, natFromBig(y), pp.n)
+		return nil
+	}
+	x := hashToPrime(w.Element)
+	if x.Cmp(y) == 0 {
+		return errors.New("accumulator: witness element was deleted")
+	}
+	// With a x + b y = 1, and W^x = A'^y, (W^b A'^a)^x = A'^(b y + a x) = A'.
+	a, b := new(big.Int), new(big.Int)
+	new(big.Int).GCD(a, b, x, y)
+	w.W.ModMul(pp.exp(w.W, b), pp.exp(u.After.value, a), pp.n)
+	return nil
+}
+
+// NonMembershipWitness proves that an element doesn't belong to an accumulated
+// set, with values A, and B, such that acc^A B^H(Element) = g, where
+// 0 ≤ A < H(Element).
+type NonMembershipWitness struct {
+	Element []byte
+	A       *big.Int
+	B       *safenum.Nat
+}
+
+// VerifyNonMembership reports whether w is a valid witness that w.Element
+// doesn't belong to the set.
+func (acc *Accumulator) VerifyNonMembership(w *NonMembershipWitness) bool {
+	pp := acc.params
+	x := hashToPrime(w.Element)
+	if w.A == nil || w.A.Sign() < 0 || w.A.Cmp(x) >= 0 {
+		return false
+	}
+	lhs := pp.exp(acc.value, w.A)
+	lhs.ModMul(lhs, new(safenum.Nat).Exp(w.B, natFromBig(x), pp.n), pp.n)
+	return lhs.Cmp(pp.g) == 0
+}
+
+// CreateNonMembershipWitness returns a witness that an element doesn't belong
+// to the accumulator of a set, given all of the elements of that set, in any
+// order.
+func CreateNonMembershipWitness(pp *Params, members [][]byte, element []byte) (*NonMembershipWitness, error) {
+	x := hashToPrime(element)
+	u, found := product(members, x)
+	if found {
+		return nil, errors.New("accumulator: element is a member of the set")
+	}
+	// With a u + b x = 1, (g^u)^a (g^b)^x = g.
+	a, b := new(big.Int), new(big.Int)
+	new(big.Int).GCD(a, b, u, x)
+	w := &NonMembershipWitness{Element: element, A: a, B: pp.exp(pp.g, b)}
+	w.reduce(pp, &Accumulator{params: pp, value: pp.exp(pp.g, u)})
+	return w, nil
+}
+
+// reduce replaces A by A mod x, adjusting B so that the witness stays valid
+// for acc.
+func (w *NonMembershipWitness) reduce(pp *Params, acc *Accumulator) {
+	x := hashToPrime(w.Element)
+	q, r := new(big.Int).DivMod(w.A, x, new(big.Int))
+	// acc^(q x + r) B^x = acc^r (acc^q B)^x
+	w.A = r
+	w.B.ModMul(w.B, pp.exp(acc.value, q), pp.n)
+}
+
+// Update applies a change to the accumulator to the witness.
+//
+// An error is returned if the update adds the element of the witness, which
+// can no longer be proven not to be a member.
+func (w *NonMembershipWitness) Update(u Update) error {
+	pp := u.After.params
+	x := hashToPrime(w.Element)
+	y := hashToPrime(u.Element)
+	if u.Deleted {
+		// A = A'^y, so A'^(a y) B^x = g.
+		w.A = new(big.Int).Mul(w.A, y)
+		w.reduce(pp, u.After)
+		return nil
+	}
+	if x.Cmp(y) == 0 {
+		return errors.New("accumulator: witness element was added")
+	}
+	// With α y = 1 + k x, A'^(a α) = A^(a + a k x), so
+	// A'^(a α) (B A^(-a k))^x = A^a B^x = g.
+	alpha, k := new(big.Int), new(big.Int)
+	new(big.Int).GCD(alpha, k, y, x)
+	k.Neg(k)
+	ak := new(big.Int).Mul(w.A, k)
+	w.B.ModMul(w.B, pp.exp(u.Before.value, ak.Neg(ak)), pp.n)
+	w.A = new(big.Int).Mul(w.A, alpha)
+	w.reduce(pp, u.After)
+	return nil
+}
+
+// Trapdoor contains the factorization of the modulus of the parameters, which
+// is needed to delete elements from accumulators.
+type Trapdoor struct {
+	*Params
+	// order is the order p' q' of the squares modulo N = (2 p' + 1) (2 q' + 1)
+	order *safenum.Modulus
+}
+
+// safePrime returns a random safe prime p = 2 q + 1 of the given size.
+func safePrime(random io.Reader, bits int) (*big.Int, error) {
+	for {
+		q, err := rand.Prime(random, bits-1)
+		if err != nil {
+			return nil, err
+		}
+		p := new(big.Int).Lsh(q, 1)
+		p.Add(p, bigOne)
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
+
+// GenerateTrapdoor generates parameters whose modulus has the given size, in
+// bits, which should be at least 2048, along with their trapdoor.
+//
+// Generating safe primes is slow, and can take minutes for large moduli.
+func GenerateTrapdoor(random io.Reader, bits int) (*Trapdoor, error) {
+	if bits < 16 || bits%2 != 0 {
+		return nil, errors.New("accumulator: invalid modulus size")
+	}
+	for {
+		p, err := safePrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := safePrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		n := new(big.Int).Mul(p, q)
+		if p.Cmp(q) == 0 || n.BitLen() != bits {
+			continue
+		}
+		r, err := rand.Int(random, n)
+		if err != nil {
+			return nil, err
+		}
+		g := r.Mul(r, r).Mod(r, n)
+		td, err := NewTrapdoor(p.Bytes(), q.Bytes(), g.Bytes())
+		if err != nil {
+			// g isn't a unit, or is 1, which happens with negligible
+			// probability.
+			continue
+		}
+		return td, nil
+	}
+}
+
+// NewTrapdoor creates a trapdoor from the distinct safe primes p and q, and a
+// generator g, which must be a square modulo p q, given in big-endian bytes.
+func NewTrapdoor(p, q, g []byte) (*Trapdoor, error) {
+	pBig, qBig := new(big.Int).SetBytes(p), new(big.Int).SetBytes(q)
+	pp, err := NewParams(new(big.Int).Mul(pBig, qBig).Bytes(), g)
+	if err != nil {
+		return nil, err
+	}
+	pPrime := new(big.Int).Rsh(pBig, 1)
+	qPrime := new(big.Int).Rsh(qBig, 1)
+	if pBig.Cmp(qBig) == 0 || !pBig.ProbablyPrime(20) || !qBig.ProbablyPrime(20) || !pPrime.ProbablyPrime(20) || !qPrime.ProbablyPrime(20) {
+		return nil, errors.New("accumulator: p and q must be distinct safe primes")
+	}
+	gBig := new(big.Int).SetBytes(g)
+	if big.Jacobi(gBig, pBig) != 1 || big.Jacobi(gBig, qBig) != 1 {
+		return nil, errors.New("accumulator: generator must be a square")
+	}
+	order := new(big.Int).Mul(pPrime, qPrime)
+	return &Trapdoor{Params: pp, order: safenum.ModulusFromBytes(order.Bytes())}, nil
+}
+
+// root returns the H(element)-th root of the accumulator.
+func (td *Trapdoor) root(acc *Accumulator, element []byte) *safenum.Nat {
+	x := new(safenum.Nat).Mod(natFromBig(hashToPrime(element)), td.order)
+	xInv := new(safenum.Nat).ModInverse(x, td.order)
+	return new(safenum.Nat).Exp(acc.value, xInv, td.n)
+}
+
+// Delete deletes an element from the accumulator, returning the update
+// describing the change.
+//
+// The element must belong to the set: deleting an element which doesn't makes
+// the accumulator inconsistent with it.
+func (td *Trapdoor) Delete(acc *Accumulator, element []byte) Update {
+	before := &Accumulator{params: acc.params, value: acc.Value()}
+	acc.value = td.root(acc, element)
+	return Update{Element: element, Deleted: true, Before: before, After: &Accumulator{params: acc.params, value: acc.Value()}}
+}
+
+// CreateMembershipWitness returns a witness that an element belongs to the
+// accumulator, without knowing the rest of the set.
+//
+// This produces a valid witness even if the element doesn't belong to the
+// set, so the caller must check that it does.
+func (td *Trapdoor) CreateMembershipWitness(acc *Accumulator, element []byte) *MembershipWitness {
+	return &MembershipWitness{Element: element, W: td.root(acc, element)}
+}
@@ -0,0 +1,180 @@
+package accumulator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+var (
+	testTrapdoorOnce sync.Once
+	testTrapdoor     *Trapdoor
+)
+
+// trapdoor returns a trapdoor shared between the tests, with a small modulus,
+// since generating safe primes is slow.
+func trapdoor(t *testing.T) *Trapdoor {
+	testTrapdoorOnce.Do(func() {
+		td, err := GenerateTrapdoor(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testTrapdoor = td
+	})
+	return testTrapdoor
+}
+
+func elements(n int) [][]byte {
+	out := make([][]byte, n)
+	for i := range out {
+		out[i] = []byte(fmt.Sprintf("element %d", i))
+	}
+	return out
+}
+
+func TestHashToPrime(t *testing.T) {
+	x := hashToPrime([]byte("hello"))
+	if x.BitLen() != primeBits || !x.ProbablyPrime(20) {
+		t.Errorf("%v isn't a prime of %d bits", x, primeBits)
+	}
+	if x.Cmp(hashToPrime([]byte("hello"))) != 0 {
+		t.Error("hashing isn't deterministic")
+	}
+}
+
+func TestMembership(t *testing.T) {
+	td := trapdoor(t)
+	members := elements(4)
+	acc := NewAccumulator(td.Params)
+	for _, e := range members {
+		acc.Add(e)
+	}
+	for _, e := range members {
+		w, err := CreateMembershipWitness(td.Params, members, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !acc.VerifyMembership(w) {
+			t.Errorf("valid witness for %q rejected", e)
+		}
+		if !acc.VerifyMembership(td.CreateMembershipWitness(acc, e)) {
+			t.Errorf("trapdoor witness for %q rejected", e)
+		}
+		w.Element = []byte("other")
+		if acc.VerifyMembership(w) {
+			t.Error("witness accepted for another element")
+		}
+	}
+	if _, err := CreateMembershipWitness(td.Params, members, []byte("other")); err == nil {
+		t.Error("witness created for a non-member")
+	}
+}
+
+func TestNonMembership(t *testing.T) {
+	td := trapdoor(t)
+	members := elements(4)
+	acc := NewAccumulator(td.Params)
+	for _, e := range members {
+		acc.Add(e)
+	}
+	w, err := CreateNonMembershipWitness(td.Params, members, []byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acc.VerifyNonMembership(w) {
+		t.Error("valid witness rejected")
+	}
+	w.A = new(big.Int).Add(w.A, big.NewInt(1))
+	if acc.VerifyNonMembership(w) {
+		t.Error("invalid witness accepted")
+	}
+	if _, err := CreateNonMembershipWitness(td.Params, members, members[2]); err == nil {
+		t.Error("witness created for a member")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	td := trapdoor(t)
+	members := elements(3)
+	acc := NewAccumulator(td.Params)
+	for _, e := range members {
+		acc.Add(e)
+	}
+	td.Delete(acc, members[1])
+	expected := NewAccumulator(td.Params)
+	expected.Add(members[0])
+	expected.Add(members[2])
+	if !acc.Equal(expected) {
+		t.Error("deletion doesn't match accumulating the remaining elements")
+	}
+}
+
+func TestWitnessUpdates(t *testing.T) {
+	td := trapdoor(t)
+	members := elements(3)
+	acc := NewAccumulator(td.Params)
+	for _, e := range members {
+		acc.Add(e)
+	}
+	mw, _ := CreateMembershipWitness(td.Params, members, members[0])
+	nw, _ := CreateNonMembershipWitness(td.Params, members, []byte("other"))
+	updates := []Update{
+		acc.Add([]byte("new")),
+		td.Delete(acc, members[1]),
+		acc.Add([]byte("newer")),
+		td.Delete(acc, []byte("new")),
+	}
+	for i, u := range updates {
+		if err := mw.Update(u); err != nil {
+			t.Fatal(err)
+		}
+		if err := nw.Update(u); err != nil {
+			t.Fatal(err)
+		}
+		if !acc.Equal(u.After) && i == len(updates)-1 {
+			t.Error("last update doesn't end at the accumulator")
+		}
+		if !u.After.VerifyMembership(mw) {
+			t.Errorf("membership witness invalid after update %d", i)
+		}
+		if !u.After.VerifyNonMembership(nw) {
+			t.Errorf("non-membership witness invalid after update %d", i)
+		}
+	}
+	if err := mw.Update(td.Delete(acc, members[0])); err == nil {
+		t.Error("membership witness updated after deleting its element")
+	}
+	if err := nw.Update(acc.Add([]byte("other"))); err == nil {
+		t.Error("non-membership witness updated after adding its element")
+	}
+}
+
+func TestParseAccumulator(t *testing.T) {
+	td := trapdoor(t)
+	acc := NewAccumulator(td.Params)
+	acc.Add([]byte("hello"))
+	parsed, err := ParseAccumulator(td.Params, acc.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(acc) {
+		t.Error("parsed accumulator differs")
+	}
+	if _, err := ParseAccumulator(td.Params, td.N().Bytes()); err == nil {
+		t.Error("accumulator equal to N accepted")
+	}
+}
+
+func TestNewTrapdoor(t *testing.T) {
+	if _, err := NewTrapdoor([]byte{23}, []byte{47}, []byte{4}); err != nil {
+		t.Errorf("valid trapdoor rejected: %v", err)
+	}
+	if _, err := NewTrapdoor([]byte{13}, []byte{47}, []byte{4}); err == nil {
+		t.Error("trapdoor accepted with a prime which isn't safe")
+	}
+	if _, err := NewTrapdoor([]byte{23}, []byte{47}, []byte{5}); err == nil {
+		t.Error("trapdoor accepted with a generator which isn't a square")
+	}
+}
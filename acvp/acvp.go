@@ -0,0 +1,155 @@
+// Package acvp answers the test vectors of NIST's Automated Cryptographic
+// Validation Protocol (ACVP) with the implementations of this module, so that
+// they can be tested as part of a validation.
+//
+// The supported algorithms and modes are:
+//
+//	ECDSA      keyGen, keyVer, sigGen, sigVer (FIPS 186-4)
+//	KAS-ECC    CDH-Component (SP 800-56A)
+//	RSA        sigGen, sigVer (FIPS 186-4), with PKCS #1 v1.5 and PSS
+//
+// Only the vector sets are handled: fetching them from, and submitting their
+// responses to, an ACVP server is left to a separate client, like acvpproxy.
+//
+// See https://pages.nist.gov/ACVP.
+package acvp
+
+import (
+	"crypto"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	_ "golang.org/x/crypto/sha3"
+)
+
+// header holds the fields identifying a vector set.
+type header struct {
+	VsID      int    `json:"vsId"`
+	Algorithm string `json:"algorithm"`
+	Mode      string `json:"mode,omitempty"`
+	Revision  string `json:"revision"`
+}
+
+// handler answers the test groups of a vector set, returning the test groups
+// of the response.
+type handler func(groups json.RawMessage, rand io.Reader) (interface{}, error)
+
+// handlers maps the algorithm and mode of a vector set to its handler.
+var handlers = map[string]handler{
+	"ECDSA/keyGen":          ecdsaKeyGen,
+	"ECDSA/keyVer":          ecdsaKeyVer,
+	"ECDSA/sigGen":          ecdsaSigGen,
+	"ECDSA/sigVer":          ecdsaSigVer,
+	"KAS-ECC/CDH-Component": kasECCCDH,
+	"RSA/sigGen":            rsaSigGen,
+	"RSA/sigVer":            rsaSigVer,
+}
+
+// Answer processes a vector set, as downloaded from an ACVP server, and
+// returns the response to upload, reading randomness from rand.
+//
+// The request is a JSON array, made of an object with the version of the
+// protocol, followed by the vector set, and so is the response.
+func Answer(request []byte, rand io.Reader) ([]byte, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(request, &parts); err != nil {
+		return nil, err
+	}
+	if len(parts) != 2 {
+		return nil, errors.New("acvp: request must hold a version and a vector set")
+	}
+	var version struct {
+		ACVVersion string `json:"acvVersion"`
+	}
+	if err := json.Unmarshal(parts[0], &version); err != nil {
+		return nil, err
+	}
+	var vs struct {
+		header
+		TestGroups json.RawMessage `json:"testGroups"`
+	}
+	if err := json.Unmarshal(parts[1], &vs); err != nil {
+		return nil, err
+	}
+	h, ok := handlers[vs.Algorithm+"/"+vs.Mode]
+	if !ok {
+		return nil, fmt.Errorf("acvp: unsupported algorithm %s, mode %s", vs.Algorithm, vs.Mode)
+	}
+	groups, err := h(vs.TestGroups, rand)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal([]interface{}{
+		version,
+		struct {
+			header
+			TestGroups interface{} `json:"testGroups"`
+		}{vs.header, groups},
+	})
+}
+
+// hexBytes is a byte slice, encoded as an uppercase hex string.
+type hexBytes []byte
+
+func (h hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(hex.EncodeToString(h)))
+}
+
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("acvp: invalid hex string: %v", err)
+	}
+	*h = b
+	return nil
+}
+
+// hashes maps the names of hash functions used by ACVP to their values.
+var hashes = map[string]crypto.Hash{
+	"SHA-1":        crypto.SHA1,
+	"SHA2-224":     crypto.SHA224,
+	"SHA2-256":     crypto.SHA256,
+	"SHA2-384":     crypto.SHA384,
+	"SHA2-512":     crypto.SHA512,
+	"SHA2-512/224": crypto.SHA512_224,
+	"SHA2-512/256": crypto.SHA512_256,
+	"SHA3-224":     crypto.SHA3_224,
+	"SHA3-256":     crypto.SHA3_256,
+	"SHA3-384":     crypto.SHA3_384,
+	"SHA3-512":     crypto.SHA3_512,
+}
+
+// digest hashes msg with the hash function named name.
+func digest(name string, msg []byte) ([]byte, crypto.Hash, error) {
+	h, ok := hashes[name]
+	if !ok || !h.Available() {
+		return nil, 0, fmt.Errorf("acvp: unsupported hash %q", name)
+	}
+	hh := h.New()
+	hh.Write(msg)
+	return hh.Sum(nil), h, nil
+}
+
+// testPassed is the response to a test case which only reports whether it
+// passed, like a signature verification.
+type testPassed struct {
+	TcID   int  `json:"tcId"`
+	Passed bool `json:"testPassed"`
+}
+
+// groupResponse is a response test group, without extra fields.
+type groupResponse struct {
+	TgID  int         `json:"tgId"`
+	Tests interface{} `json:"tests"`
+}
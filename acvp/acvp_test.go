@@ -0,0 +1,229 @@
+package acvp
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// answer wraps groups in a vector set, answers it, and decodes the test
+// groups of the response into out.
+func answer(t *testing.T, algorithm, mode string, groups interface{}, out interface{}) {
+	t.Helper()
+	request, err := json.Marshal([]interface{}{
+		map[string]string{"acvVersion": "1.0"},
+		map[string]interface{}{"vsId": 42, "algorithm": algorithm, "mode": mode, "revision": "1.0", "testGroups": groups},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := Answer(request, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []json.RawMessage
+	if err := json.Unmarshal(response, &parts); err != nil || len(parts) != 2 {
+		t.Fatalf("malformed response %s", response)
+	}
+	var vs struct {
+		VsID       int             `json:"vsId"`
+		Algorithm  string          `json:"algorithm"`
+		Mode       string          `json:"mode"`
+		TestGroups json.RawMessage `json:"testGroups"`
+	}
+	if err := json.Unmarshal(parts[1], &vs); err != nil {
+		t.Fatal(err)
+	}
+	if vs.VsID != 42 || vs.Algorithm != algorithm || vs.Mode != mode {
+		t.Errorf("response for vector set %d, %s %s", vs.VsID, vs.Algorithm, vs.Mode)
+	}
+	if err := json.Unmarshal(vs.TestGroups, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func h(b []byte) string {
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+func unhex(t *testing.T, s string) *big.Int {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+type passedGroup struct {
+	TgID  int `json:"tgId"`
+	Tests []struct {
+		TcID   int  `json:"tcId"`
+		Passed bool `json:"testPassed"`
+	} `json:"tests"`
+}
+
+// checkPassed checks that the test cases with even identifiers passed, and
+// the others failed.
+func checkPassed(t *testing.T, groups []passedGroup) {
+	t.Helper()
+	if len(groups) == 0 {
+		t.Fatalf("no test groups in the response")
+	}
+	for _, g := range groups {
+		for _, c := range g.Tests {
+			if c.Passed != (c.TcID%2 == 0) {
+				t.Errorf("group %d, test %d: testPassed = %v", g.TgID, c.TcID, c.Passed)
+			}
+		}
+	}
+}
+
+func TestECDSAKeyGen(t *testing.T) {
+	var groups []struct {
+		Tests []struct{ D, Qx, Qy string } `json:"tests"`
+	}
+	answer(t, "ECDSA", "keyGen", []interface{}{
+		map[string]interface{}{"tgId": 1, "curve": "P-384", "tests": []interface{}{map[string]int{"tcId": 1}}},
+	}, &groups)
+	c := elliptic.P384()
+	k := groups[0].Tests[0]
+	x, y := c.ScalarBaseMult(unhex(t, k.D).Bytes())
+	if x.Cmp(unhex(t, k.Qx)) != 0 || y.Cmp(unhex(t, k.Qy)) != 0 {
+		t.Errorf("public key doesn't match d")
+	}
+}
+
+func TestECDSASigGen(t *testing.T) {
+	msg := []byte("hello")
+	var groups []struct {
+		Qx, Qy string
+		Tests  []struct{ R, S string } `json:"tests"`
+	}
+	answer(t, "ECDSA", "sigGen", []interface{}{
+		map[string]interface{}{"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "tests": []interface{}{
+			map[string]interface{}{"tcId": 1, "message": h(msg)},
+		}},
+	}, &groups)
+	g := groups[0]
+	pub := &stdecdsa.PublicKey{Curve: elliptic.P256(), X: unhex(t, g.Qx), Y: unhex(t, g.Qy)}
+	d := sha256.Sum256(msg)
+	if !stdecdsa.Verify(pub, d[:], unhex(t, g.Tests[0].R), unhex(t, g.Tests[0].S)) {
+		t.Errorf("signature doesn't verify")
+	}
+}
+
+func TestECDSAVer(t *testing.T) {
+	priv, _ := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	msg := []byte("hello")
+	d := sha256.Sum256(msg)
+	r, s, _ := stdecdsa.Sign(rand.Reader, priv, d[:])
+	qx, qy := h(priv.X.Bytes()), h(priv.Y.Bytes())
+	var groups []passedGroup
+	answer(t, "ECDSA", "sigVer", []interface{}{
+		map[string]interface{}{"tgId": 1, "curve": "P-256", "hashAlg": "SHA2-256", "tests": []interface{}{
+			map[string]interface{}{"tcId": 1, "message": h([]byte("hellp")), "qx": qx, "qy": qy, "r": h(r.Bytes()), "s": h(s.Bytes())},
+			map[string]interface{}{"tcId": 2, "message": h(msg), "qx": qx, "qy": qy, "r": h(r.Bytes()), "s": h(s.Bytes())},
+		}},
+		map[string]interface{}{"tgId": 2, "curve": "P-256", "hashAlg": "SHA2-256", "componentTest": true, "tests": []interface{}{
+			map[string]interface{}{"tcId": 4, "message": h(d[:]), "qx": qx, "qy": qy, "r": h(r.Bytes()), "s": h(s.Bytes())},
+		}},
+	}, &groups)
+	checkPassed(t, groups)
+
+	answer(t, "ECDSA", "keyVer", []interface{}{
+		map[string]interface{}{"tgId": 1, "curve": "P-256", "tests": []interface{}{
+			map[string]interface{}{"tcId": 1, "qx": qx, "qy": h(new(big.Int).Add(priv.Y, big.NewInt(1)).Bytes())},
+			map[string]interface{}{"tcId": 2, "qx": qx, "qy": qy},
+			map[string]interface{}{"tcId": 3, "qx": h(elliptic.P256().Params().P.Bytes()), "qy": qy},
+		}},
+	}, &groups)
+	checkPassed(t, groups)
+}
+
+func TestKASECC(t *testing.T) {
+	// The server side is computed with crypto/elliptic, which is independent
+	// of this module.
+	curve := elliptic.P384()
+	d, sx, sy, err := elliptic.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var groups []struct {
+		Tests []struct {
+			IutX string `json:"publicIutX"`
+			IutY string `json:"publicIutY"`
+			Z    string `json:"z"`
+		} `json:"tests"`
+	}
+	answer(t, "KAS-ECC", "CDH-Component", []interface{}{
+		map[string]interface{}{"tgId": 1, "curve": "P-384", "testType": "AFT", "tests": []interface{}{
+			map[string]interface{}{"tcId": 1, "publicServerX": h(sx.FillBytes(make([]byte, 48))), "publicServerY": h(sy.FillBytes(make([]byte, 48)))},
+		}},
+	}, &groups)
+	c := groups[0].Tests[0]
+	ix, iy := unhex(t, c.IutX), unhex(t, c.IutY)
+	if !curve.IsOnCurve(ix, iy) {
+		t.Fatal("IUT public key isn't on the curve")
+	}
+	zx, _ := curve.ScalarMult(ix, iy, d)
+	if z := zx.FillBytes(make([]byte, 48)); h(z) != c.Z {
+		t.Errorf("z = %s, expected %s", c.Z, h(z))
+	}
+}
+
+func TestRSA(t *testing.T) {
+	msg := []byte("hello")
+	d := sha256.Sum256(msg)
+	for _, sigType := range []string{"pkcs1v1.5", "pss"} {
+		var groups []struct {
+			N, E  string
+			Tests []struct{ Signature string } `json:"tests"`
+		}
+		answer(t, "RSA", "sigGen", []interface{}{
+			map[string]interface{}{"tgId": 1, "sigType": sigType, "modulo": 2048, "hashAlg": "SHA2-256", "saltLen": 32, "tests": []interface{}{
+				map[string]interface{}{"tcId": 1, "message": h(msg)},
+			}},
+		}, &groups)
+		g := groups[0]
+		pub := &stdrsa.PublicKey{N: unhex(t, g.N), E: int(unhex(t, g.E).Int64())}
+		sig := unhex(t, g.Tests[0].Signature).FillBytes(make([]byte, 256))
+		var err error
+		if sigType == "pss" {
+			err = stdrsa.VerifyPSS(pub, crypto.SHA256, d[:], sig, &stdrsa.PSSOptions{SaltLength: 32})
+		} else {
+			err = stdrsa.VerifyPKCS1v15(pub, crypto.SHA256, d[:], sig)
+		}
+		if err != nil {
+			t.Errorf("%s: signature doesn't verify: %v", sigType, err)
+		}
+
+		var passed []passedGroup
+		answer(t, "RSA", "sigVer", []interface{}{
+			map[string]interface{}{"tgId": 1, "sigType": sigType, "hashAlg": "SHA2-256", "saltLen": 32, "n": g.N, "e": g.E, "tests": []interface{}{
+				map[string]interface{}{"tcId": 1, "message": h([]byte("hellp")), "signature": h(sig)},
+				map[string]interface{}{"tcId": 2, "message": h(msg), "signature": h(sig)},
+			}},
+		}, &passed)
+		checkPassed(t, passed)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	request := fmt.Sprintf(`[{"acvVersion": "1.0"}, {"vsId": 1, "algorithm": "%s", "mode": "%s", "testGroups": []}]`, "EDDSA", "sigGen")
+	if _, err := Answer([]byte(request), rand.Reader); err == nil {
+		t.Errorf("unsupported algorithm accepted")
+	}
+	request = `[{"acvVersion": "1.0"}, {"vsId": 1, "algorithm": "ECDSA", "mode": "keyGen", "testGroups": [{"tgId": 1, "curve": "B-233", "tests": []}]}]`
+	if _, err := Answer([]byte(request), rand.Reader); err == nil {
+		t.Errorf("unsupported curve accepted")
+	}
+}
@@ -0,0 +1,202 @@
+package acvp
+
+import (
+	"crypto/elliptic"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+// curves maps the names of curves used by ACVP to their values.
+var curves = map[string]elliptic.Curve{
+	"P-224": elliptic.P224(),
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func curve(name string) (elliptic.Curve, error) {
+	c, ok := curves[name]
+	if !ok {
+		return nil, fmt.Errorf("acvp: unsupported curve %q", name)
+	}
+	return c, nil
+}
+
+// fieldBytes encodes x as a fixed length integer of the base field of c.
+func fieldBytes(c elliptic.Curve, x *big.Int) hexBytes {
+	return x.FillBytes(make([]byte, (c.Params().BitSize+7)/8))
+}
+
+func ecdsaKeyGen(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID  int    `json:"tgId"`
+		Curve string `json:"curve"`
+		Tests []struct {
+			TcID int `json:"tcId"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	type test struct {
+		TcID int      `json:"tcId"`
+		D    hexBytes `json:"d"`
+		Qx   hexBytes `json:"qx"`
+		Qy   hexBytes `json:"qy"`
+	}
+	var out []groupResponse
+	for _, g := range groups {
+		c, err := curve(g.Curve)
+		if err != nil {
+			return nil, err
+		}
+		var tests []test
+		for _, t := range g.Tests {
+			priv, err := ecdsa.GenerateKey(c, rand)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, test{t.TcID, fieldBytes(c, priv.D), fieldBytes(c, priv.X), fieldBytes(c, priv.Y)})
+		}
+		out = append(out, groupResponse{g.TgID, tests})
+	}
+	return out, nil
+}
+
+func ecdsaKeyVer(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID  int    `json:"tgId"`
+		Curve string `json:"curve"`
+		Tests []struct {
+			TcID int      `json:"tcId"`
+			Qx   hexBytes `json:"qx"`
+			Qy   hexBytes `json:"qy"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	var out []groupResponse
+	for _, g := range groups {
+		c, err := curve(g.Curve)
+		if err != nil {
+			return nil, err
+		}
+		var tests []testPassed
+		for _, t := range g.Tests {
+			x, y := new(big.Int).SetBytes(t.Qx), new(big.Int).SetBytes(t.Qy)
+			p := c.Params().P
+			ok := x.Cmp(p) < 0 && y.Cmp(p) < 0 && c.IsOnCurve(x, y)
+			tests = append(tests, testPassed{t.TcID, ok})
+		}
+		out = append(out, groupResponse{g.TgID, tests})
+	}
+	return out, nil
+}
+
+// ecdsaMessage returns the digest of the message of an ECDSA test case, which
+// is the message itself for component tests.
+func ecdsaMessage(component bool, hashAlg string, msg []byte) ([]byte, error) {
+	if component {
+		return msg, nil
+	}
+	d, _, err := digest(hashAlg, msg)
+	return d, err
+}
+
+func ecdsaSigGen(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID      int    `json:"tgId"`
+		Curve     string `json:"curve"`
+		HashAlg   string `json:"hashAlg"`
+		Component bool   `json:"componentTest"`
+		Tests     []struct {
+			TcID    int      `json:"tcId"`
+			Message hexBytes `json:"message"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	type test struct {
+		TcID int      `json:"tcId"`
+		R    hexBytes `json:"r"`
+		S    hexBytes `json:"s"`
+	}
+	type group struct {
+		TgID  int      `json:"tgId"`
+		Qx    hexBytes `json:"qx"`
+		Qy    hexBytes `json:"qy"`
+		Tests []test   `json:"tests"`
+	}
+	var out []group
+	for _, g := range groups {
+		c, err := curve(g.Curve)
+		if err != nil {
+			return nil, err
+		}
+		// Every signature of a group must be made with the same key.
+		priv, err := ecdsa.GenerateKey(c, rand)
+		if err != nil {
+			return nil, err
+		}
+		response := group{TgID: g.TgID, Qx: fieldBytes(c, priv.X), Qy: fieldBytes(c, priv.Y)}
+		for _, t := range g.Tests {
+			d, err := ecdsaMessage(g.Component, g.HashAlg, t.Message)
+			if err != nil {
+				return nil, err
+			}
+			r, s, err := ecdsa.Sign(rand, priv, d)
+			if err != nil {
+				return nil, err
+			}
+			response.Tests = append(response.Tests, test{t.TcID, fieldBytes(c, r), fieldBytes(c, s)})
+		}
+		out = append(out, response)
+	}
+	return out, nil
+}
+
+func ecdsaSigVer(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID      int    `json:"tgId"`
+		Curve     string `json:"curve"`
+		HashAlg   string `json:"hashAlg"`
+		Component bool   `json:"componentTest"`
+		Tests     []struct {
+			TcID    int      `json:"tcId"`
+			Message hexBytes `json:"message"`
+			Qx      hexBytes `json:"qx"`
+			Qy      hexBytes `json:"qy"`
+			R       hexBytes `json:"r"`
+			S       hexBytes `json:"s"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	var out []groupResponse
+	for _, g := range groups {
+		c, err := curve(g.Curve)
+		if err != nil {
+			return nil, err
+		}
+		var tests []testPassed
+		for _, t := range g.Tests {
+			d, err := ecdsaMessage(g.Component, g.HashAlg, t.Message)
+			if err != nil {
+				return nil, err
+			}
+			pub := &ecdsa.PublicKey{Curve: c, X: new(big.Int).SetBytes(t.Qx), Y: new(big.Int).SetBytes(t.Qy)}
+			ok := c.IsOnCurve(pub.X, pub.Y) &&
+				ecdsa.Verify(pub, d, new(big.Int).SetBytes(t.R), new(big.Int).SetBytes(t.S))
+			tests = append(tests, testPassed{t.TcID, ok})
+		}
+		out = append(out, groupResponse{g.TgID, tests})
+	}
+	return out, nil
+}
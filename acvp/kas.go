@@ -0,0 +1,75 @@
+package acvp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+// ecdhCurves maps the names of curves used by ACVP to the ECDH curves of this
+// module.
+var ecdhCurves = map[string]ecdh.Curve{
+	"P-256": ecdh.P256(),
+	"P-384": ecdh.P384(),
+	"P-521": ecdh.P521(),
+}
+
+// kasECCCDH answers the tests of the ECC CDH primitive, SP 800-56A section
+// 5.7.1.2, by generating a key pair for each test, and returning its public
+// key along with the shared secret z.
+func kasECCCDH(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID  int    `json:"tgId"`
+		Curve string `json:"curve"`
+		Tests []struct {
+			TcID    int      `json:"tcId"`
+			ServerX hexBytes `json:"publicServerX"`
+			ServerY hexBytes `json:"publicServerY"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	type test struct {
+		TcID int      `json:"tcId"`
+		IutX hexBytes `json:"publicIutX"`
+		IutY hexBytes `json:"publicIutY"`
+		Z    hexBytes `json:"z"`
+	}
+	var out []groupResponse
+	for _, g := range groups {
+		c, ok := ecdhCurves[g.Curve]
+		if !ok {
+			return nil, fmt.Errorf("acvp: unsupported curve %q", g.Curve)
+		}
+		byteLen := (curves[g.Curve].Params().BitSize + 7) / 8
+		var tests []test
+		for _, t := range g.Tests {
+			if len(t.ServerX) > byteLen || len(t.ServerY) > byteLen {
+				return nil, fmt.Errorf("acvp: test %d: invalid public key", t.TcID)
+			}
+			point := make([]byte, 1+2*byteLen)
+			point[0] = 4
+			copy(point[1+byteLen-len(t.ServerX):], t.ServerX)
+			copy(point[1+2*byteLen-len(t.ServerY):], t.ServerY)
+			peer, err := c.NewPublicKey(point)
+			if err != nil {
+				return nil, fmt.Errorf("acvp: test %d: %v", t.TcID, err)
+			}
+			priv, err := c.GenerateKey(rand)
+			if err != nil {
+				return nil, err
+			}
+			z, err := priv.ECDH(peer)
+			if err != nil {
+				return nil, err
+			}
+			public := priv.PublicKey().Bytes()
+			tests = append(tests, test{t.TcID, public[1 : 1+byteLen], public[1+byteLen:], z})
+		}
+		out = append(out, groupResponse{g.TgID, tests})
+	}
+	return out, nil
+}
@@ -0,0 +1,125 @@
+package acvp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/safenum"
+)
+
+// rsaSign signs msg with the scheme sigType.
+func rsaSign(priv *rsa.PrivateKey, rand io.Reader, sigType string, saltLen int, hashAlg string, msg []byte) ([]byte, error) {
+	d, h, err := digest(hashAlg, msg)
+	if err != nil {
+		return nil, err
+	}
+	switch sigType {
+	case "pkcs1v1.5":
+		return rsa.SignPKCS1v15(priv, h, d)
+	case "pss":
+		return rsa.SignPSS(rand, priv, h, d, &rsa.PSSOptions{SaltLength: saltLen})
+	}
+	return nil, fmt.Errorf("acvp: unsupported signature type %q", sigType)
+}
+
+// rsaVerify verifies sig with the scheme sigType.
+func rsaVerify(pub *rsa.PublicKey, sigType string, saltLen int, hashAlg string, msg, sig []byte) (bool, error) {
+	d, h, err := digest(hashAlg, msg)
+	if err != nil {
+		return false, err
+	}
+	switch sigType {
+	case "pkcs1v1.5":
+		return rsa.VerifyPKCS1v15(pub, h, d, sig) == nil, nil
+	case "pss":
+		return rsa.VerifyPSS(pub, h, d, sig, &rsa.PSSOptions{SaltLength: saltLen}) == nil, nil
+	}
+	return false, fmt.Errorf("acvp: unsupported signature type %q", sigType)
+}
+
+func rsaSigGen(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID    int    `json:"tgId"`
+		SigType string `json:"sigType"`
+		Modulo  int    `json:"modulo"`
+		HashAlg string `json:"hashAlg"`
+		SaltLen int    `json:"saltLen"`
+		Tests   []struct {
+			TcID    int      `json:"tcId"`
+			Message hexBytes `json:"message"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	type test struct {
+		TcID      int      `json:"tcId"`
+		Signature hexBytes `json:"signature"`
+	}
+	type group struct {
+		TgID  int      `json:"tgId"`
+		N     hexBytes `json:"n"`
+		E     hexBytes `json:"e"`
+		Tests []test   `json:"tests"`
+	}
+	var out []group
+	for _, g := range groups {
+		// Every signature of a group must be made with the same key.
+		priv, err := rsa.GenerateKey(rand, g.Modulo)
+		if err != nil {
+			return nil, err
+		}
+		e := big.NewInt(int64(priv.E)).Bytes()
+		response := group{TgID: g.TgID, N: priv.N.Bytes(), E: e}
+		for _, t := range g.Tests {
+			sig, err := rsaSign(priv, rand, g.SigType, g.SaltLen, g.HashAlg, t.Message)
+			if err != nil {
+				return nil, err
+			}
+			response.Tests = append(response.Tests, test{t.TcID, sig})
+		}
+		out = append(out, response)
+	}
+	return out, nil
+}
+
+func rsaSigVer(raw json.RawMessage, rand io.Reader) (interface{}, error) {
+	var groups []struct {
+		TgID    int      `json:"tgId"`
+		SigType string   `json:"sigType"`
+		HashAlg string   `json:"hashAlg"`
+		SaltLen int      `json:"saltLen"`
+		N       hexBytes `json:"n"`
+		E       hexBytes `json:"e"`
+		Tests   []struct {
+			TcID      int      `json:"tcId"`
+			Message   hexBytes `json:"message"`
+			Signature hexBytes `json:"signature"`
+		} `json:"tests"`
+	}
+	if err := json.Unmarshal(raw, &groups); err != nil {
+		return nil, err
+	}
+	var out []groupResponse
+	for _, g := range groups {
+		e := new(big.Int).SetBytes(g.E)
+		if e.BitLen() > 31 {
+			return nil, fmt.Errorf("acvp: group %d: public exponent too large", g.TgID)
+		}
+		n := new(big.Int).SetBytes(g.N)
+		pub := &rsa.PublicKey{N: safenum.ModulusFromBytes(n.Bytes()), E: int(e.Int64())}
+		var tests []testPassed
+		for _, t := range g.Tests {
+			ok, err := rsaVerify(pub, g.SigType, g.SaltLen, g.HashAlg, t.Message, t.Signature)
+			if err != nil {
+				return nil, err
+			}
+			tests = append(tests, testPassed{t.TcID, ok})
+		}
+		out = append(out, groupResponse{g.TgID, tests})
+	}
+	return out, nil
+}
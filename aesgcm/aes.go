@@ -0,0 +1,314 @@
+package aesgcm
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// The AES block cipher is implemented by bitslicing, as in BearSSL's aes_ct64,
+// instead of with the usual lookup tables, whose access patterns depend on the
+// key and the data, and leak them through the cache.
+//
+// Four blocks are processed at once. The state is held in eight words, the
+// word q[p] holding bit p of every byte: bit 16*b + i of q[p] is bit p of byte
+// i of block b. Byte i of a block is in row i%4 and column i/4 of the AES
+// state, so that ShiftRows and MixColumns become shifts within each 16 bit
+// lane of the words.
+type state [8]uint64
+
+// blockCipher is AES, for encryption only, which is all that GCM needs.
+type blockCipher struct {
+	// roundKeys are the bitsliced round keys, repeated in each lane.
+	roundKeys []state
+}
+
+func newBlockCipher(key []byte) (*blockCipher, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.New("aesgcm: invalid key size")
+	}
+	nk := len(key) / 4
+	rounds := nk + 6
+	w := make([]uint32, 4*(rounds+1))
+	for i := 0; i < nk; i++ {
+		w[i] = binary.BigEndian.Uint32(key[4*i:])
+	}
+	rcon := uint32(1)
+	for i := nk; i < len(w); i++ {
+		t := w[i-1]
+		if i%nk == 0 {
+			t = subWord(t<<8|t>>24) ^ rcon<<24
+			rcon = uint32(xtime(byte(rcon)))
+		} else if nk > 6 && i%nk == 4 {
+			t = subWord(t)
+		}
+		w[i] = w[i-nk] ^ t
+	}
+
+	c := &blockCipher{roundKeys: make([]state, rounds+1)}
+	var rk [16]byte
+	for r := range c.roundKeys {
+		for j := 0; j < 4; j++ {
+			binary.BigEndian.PutUint32(rk[4*j:], w[4*r+j])
+		}
+		for b := 0; b < 4; b++ {
+			c.roundKeys[r].load(b, rk[:])
+		}
+	}
+	for i := range w {
+		w[i] = 0
+	}
+	return c, nil
+}
+
+func xtime(b byte) byte {
+	return b<<1 ^ 0x1b&-(b>>7)
+}
+
+// subWord applies the S-box to each byte of w, bitsliced like the state.
+func subWord(w uint32) uint32 {
+	var q state
+	for j := 0; j < 4; j++ {
+		for p := 0; p < 8; p++ {
+			q[p] |= uint64(w>>(8*j+p)&1) << j
+		}
+	}
+	q.subBytes()
+	var out uint32
+	for j := 0; j < 4; j++ {
+		for p := 0; p < 8; p++ {
+			out |= uint32(q[p]>>j&1) << (8*j + p)
+		}
+	}
+	return out
+}
+
+// load sets lane b of q to the 16 bytes of in.
+func (q *state) load(b int, in []byte) {
+	_ = in[15]
+	for i := 0; i < 16; i++ {
+		for p := 0; p < 8; p++ {
+			q[p] |= uint64(in[i]>>p&1) << (16*b + i)
+		}
+	}
+}
+
+// store writes lane b of q to out.
+func (q *state) store(b int, out []byte) {
+	_ = out[15]
+	for i := 0; i < 16; i++ {
+		var x byte
+		for p := 0; p < 8; p++ {
+			x |= byte(q[p]>>(16*b+i)&1) << p
+		}
+		out[i] = x
+	}
+}
+
+// encrypt encrypts the four blocks in q.
+func (c *blockCipher) encrypt(q *state) {
+	q.addRoundKey(&c.roundKeys[0])
+	last := len(c.roundKeys) - 1
+	for r := 1; r < last; r++ {
+		q.subBytes()
+		q.shiftRows()
+		q.mixColumns()
+		q.addRoundKey(&c.roundKeys[r])
+	}
+	q.subBytes()
+	q.shiftRows()
+	q.addRoundKey(&c.roundKeys[last])
+}
+
+func (q *state) addRoundKey(k *state) {
+	for p := range q {
+		q[p] ^= k[p]
+	}
+}
+
+// lanes repeats a 16 bit mask in each lane.
+func lanes(m uint64) uint64 {
+	return m * 0x0001000100010001
+}
+
+// shiftRows rotates row r left by r columns, which moves the byte at index
+// 4*c + r to index 4*(c - r) + r, so the bits of row r rotate right by 4*r
+// within each lane.
+func (q *state) shiftRows() {
+	for p, x := range q {
+		q[p] = x&lanes(0x1111) |
+			(x>>4)&lanes(0x0222) | (x<<12)&lanes(0x2000) |
+			(x>>8)&lanes(0x0044) | (x<<8)&lanes(0x4400) |
+			(x>>12)&lanes(0x0008) | (x<<4)&lanes(0x8880)
+	}
+}
+
+// rotRowsK moves the byte in row (r + K) % 4 of each column to row r.
+func rotRows1(x uint64) uint64 {
+	return (x>>1)&lanes(0x7777) | (x<<3)&lanes(0x8888)
+}
+
+func rotRows2(x uint64) uint64 {
+	return (x>>2)&lanes(0x3333) | (x<<2)&lanes(0xcccc)
+}
+
+func rotRows3(x uint64) uint64 {
+	return (x>>3)&lanes(0x1111) | (x<<1)&lanes(0xeeee)
+}
+
+// mixColumns computes each byte of a column as
+//
+//	2*a[r] + 3*a[r+1] + a[r+2] + a[r+3] = 2*(a[r] + a[r+1]) + a[r+1] + a[r+2] + a[r+3]
+//
+// where multiplication by 2 moves each bit to the next word, and reduces the
+// top bit by x^8 = x^4 + x^3 + x + 1.
+func (q *state) mixColumns() {
+	var r1, r23, t state
+	for p, x := range q {
+		r1[p] = rotRows1(x)
+		r23[p] = rotRows2(x) ^ rotRows3(x)
+		t[p] = x ^ r1[p]
+	}
+	q[0] = t[7] ^ r1[0] ^ r23[0]
+	q[1] = t[0] ^ t[7] ^ r1[1] ^ r23[1]
+	q[2] = t[1] ^ r1[2] ^ r23[2]
+	q[3] = t[2] ^ t[7] ^ r1[3] ^ r23[3]
+	q[4] = t[3] ^ t[7] ^ r1[4] ^ r23[4]
+	q[5] = t[4] ^ r1[5] ^ r23[5]
+	q[6] = t[5] ^ r1[6] ^ r23[6]
+	q[7] = t[6] ^ r1[7] ^ r23[7]
+}
+
+// subBytes applies the S-box to every byte, with the circuit of Boyar and
+// Peralta, "A depth-16 circuit for the AES S-box", as arranged in BearSSL.
+func (q *state) subBytes() {
+	x0, x1, x2, x3 := q[7], q[6], q[5], q[4]
+	x4, x5, x6, x7 := q[3], q[2], q[1], q[0]
+
+	// Top linear transformation.
+	y14 := x3 ^ x5
+	y13 := x0 ^ x6
+	y9 := x0 ^ x3
+	y8 := x0 ^ x5
+	t0 := x1 ^ x2
+	y1 := t0 ^ x7
+	y4 := y1 ^ x3
+	y12 := y13 ^ y14
+	y2 := y1 ^ x0
+	y5 := y1 ^ x6
+	y3 := y5 ^ y8
+	t1 := x4 ^ y12
+	y15 := t1 ^ x5
+	y20 := t1 ^ x1
+	y6 := y15 ^ x7
+	y10 := y15 ^ t0
+	y11 := y20 ^ y9
+	y7 := x7 ^ y11
+	y17 := y10 ^ y11
+	y19 := y10 ^ y8
+	y16 := t0 ^ y11
+	y21 := y13 ^ y16
+	y18 := x0 ^ y16
+
+	// Non-linear section.
+	t2 := y12 & y15
+	t3 := y3 & y6
+	t4 := t3 ^ t2
+	t5 := y4 & x7
+	t6 := t5 ^ t2
+	t7 := y13 & y16
+	t8 := y5 & y1
+	t9 := t8 ^ t7
+	t10 := y2 & y7
+	t11 := t10 ^ t7
+	t12 := y9 & y11
+	t13 := y14 & y17
+	t14 := t13 ^ t12
+	t15 := y8 & y10
+	t16 := t15 ^ t12
+	t17 := t4 ^ t14
+	t18 := t6 ^ t16
+	t19 := t9 ^ t14
+	t20 := t11 ^ t16
+	t21 := t17 ^ y20
+	t22 := t18 ^ y19
+	t23 := t19 ^ y21
+	t24 := t20 ^ y18
+
+	t25 := t21 ^ t22
+	t26 := t21 & t23
+	t27 := t24 ^ t26
+	t28 := t25 & t27
+	t29 := t28 ^ t22
+	t30 := t23 ^ t24
+	t31 := t22 ^ t26
+	t32 := t31 & t30
+	t33 := t32 ^ t24
+	t34 := t23 ^ t33
+	t35 := t27 ^ t33
+	t36 := t24 & t35
+	t37 := t36 ^ t34
+	t38 := t27 ^ t36
+	t39 := t29 & t38
+	t40 := t25 ^ t39
+
+	t41 := t40 ^ t37
+	t42 := t29 ^ t33
+	t43 := t29 ^ t40
+	t44 := t33 ^ t37
+	t45 := t42 ^ t41
+	z0 := t44 & y15
+	z1 := t37 & y6
+	z2 := t33 & x7
+	z3 := t43 & y16
+	z4 := t40 & y1
+	z5 := t29 & y7
+	z6 := t42 & y11
+	z7 := t45 & y17
+	z8 := t41 & y10
+	z9 := t44 & y12
+	z10 := t37 & y3
+	z11 := t33 & y4
+	z12 := t43 & y13
+	z13 := t40 & y5
+	z14 := t29 & y2
+	z15 := t42 & y9
+	z16 := t45 & y14
+	z17 := t41 & y8
+
+	// Bottom linear transformation.
+	t46 := z15 ^ z16
+	t47 := z10 ^ z11
+	t48 := z5 ^ z13
+	t49 := z9 ^ z10
+	t50 := z2 ^ z12
+	t51 := z2 ^ z5
+	t52 := z7 ^ z8
+	t53 := z0 ^ z3
+	t54 := z6 ^ z7
+	t55 := z16 ^ z17
+	t56 := z12 ^ t48
+	t57 := t50 ^ t53
+	t58 := z4 ^ t46
+	t59 := z3 ^ t54
+	t60 := t46 ^ t57
+	t61 := z14 ^ t57
+	t62 := t52 ^ t58
+	t63 := t49 ^ t58
+	t64 := z4 ^ t59
+	t65 := t61 ^ t62
+	t66 := z1 ^ t63
+	s0 := t59 ^ t63
+	s6 := t56 ^ ^t62
+	s7 := t48 ^ ^t60
+	t67 := t64 ^ t65
+	s3 := t53 ^ t66
+	s4 := t51 ^ t66
+	s5 := t47 ^ t65
+	s1 := t64 ^ ^s3
+	s2 := t55 ^ ^t67
+
+	q[7], q[6], q[5], q[4] = s0, s1, s2, s3
+	q[3], q[2], q[1], q[0] = s4, s5, s6, s7
+}
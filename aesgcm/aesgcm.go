@@ -0,0 +1,179 @@
+// Package aesgcm implements AES-GCM, as specified in NIST SP 800-38D, in
+// constant time and without assembly.
+//
+// The standard library only runs in constant time when it can use hardware
+// support, like AES-NI and CLMUL on x86. Elsewhere, it falls back to lookup
+// tables indexed by the key and the data, for both AES and GHASH. This package
+// uses a bitsliced AES and a GHASH built on integer multiplication instead,
+// which are slower, but don't leak secrets through the cache, on any platform
+// where multiplication runs in constant time.
+package aesgcm
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	isubtle "github.com/cronokirby/ctcrypto/internal/subtle"
+)
+
+const (
+	blockSize = 16
+	// NonceSize is the size of the nonces, in bytes.
+	NonceSize = 12
+	// Overhead is the size of the authentication tag, in bytes.
+	Overhead = 16
+)
+
+// maxPlaintext is the longest plaintext allowed by NIST SP 800-38D, which
+// keeps the 32 bit counter from wrapping around.
+const maxPlaintext = (1<<32 - 2) * blockSize
+
+var errOpen = errors.New("aesgcm: message authentication failed")
+
+type gcm struct {
+	cipher *blockCipher
+	// h is the hash key, the encryption of the zero block.
+	h [blockSize]byte
+}
+
+// New returns AES-GCM with the given 16, 24, or 32 byte key, selecting
+// AES-128, AES-192, or AES-256, with the standard nonce and tag sizes.
+func New(key []byte) (cipher.AEAD, error) {
+	c, err := newBlockCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	g := &gcm{cipher: c}
+	var q state
+	c.encrypt(&q)
+	q.store(0, g.h[:])
+	return g, nil
+}
+
+func (g *gcm) NonceSize() int {
+	return NonceSize
+}
+
+func (g *gcm) Overhead() int {
+	return Overhead
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+func (g *gcm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic("aesgcm: incorrect nonce length given to GCM")
+	}
+	if uint64(len(plaintext)) > maxPlaintext {
+		panic("aesgcm: message too large for GCM")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+Overhead)
+	if isubtle.InexactOverlap(out, plaintext) {
+		panic("aesgcm: invalid buffer overlap")
+	}
+	g.counterMode(out[:len(plaintext)], plaintext, nonce)
+	g.tag(out[len(plaintext):], nonce, out[:len(plaintext)], additionalData)
+	return ret
+}
+
+func (g *gcm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic("aesgcm: incorrect nonce length given to GCM")
+	}
+	if len(ciphertext) < Overhead {
+		return nil, errOpen
+	}
+	if uint64(len(ciphertext)) > maxPlaintext+Overhead {
+		return nil, errOpen
+	}
+	tag := ciphertext[len(ciphertext)-Overhead:]
+	ciphertext = ciphertext[:len(ciphertext)-Overhead]
+
+	var expected [Overhead]byte
+	g.tag(expected[:], nonce, ciphertext, additionalData)
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if isubtle.InexactOverlap(out, ciphertext) {
+		panic("aesgcm: invalid buffer overlap")
+	}
+	if subtle.ConstantTimeCompare(expected[:], tag) != 1 {
+		// The plaintext is never revealed when authentication fails.
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	g.counterMode(out, ciphertext, nonce)
+	return ret, nil
+}
+
+// counterMode xors in with the key stream, starting from the counter 2, as
+// the counter 1 masks the tag.
+func (g *gcm) counterMode(out, in, nonce []byte) {
+	var counters [4][blockSize]byte
+	for b := range counters {
+		copy(counters[b][:], nonce)
+	}
+	ctr := uint32(2)
+	var stream [4 * blockSize]byte
+	for len(in) > 0 {
+		var q state
+		for b := range counters {
+			binary.BigEndian.PutUint32(counters[b][NonceSize:], ctr)
+			ctr++
+			q.load(b, counters[b][:])
+		}
+		g.cipher.encrypt(&q)
+		for b := range counters {
+			q.store(b, stream[blockSize*b:])
+		}
+		n := len(in)
+		if n > len(stream) {
+			n = len(stream)
+		}
+		for i := 0; i < n; i++ {
+			out[i] = in[i] ^ stream[i]
+		}
+		out, in = out[n:], in[n:]
+	}
+}
+
+// tag computes the authentication tag of a ciphertext into out.
+func (g *gcm) tag(out, nonce, ciphertext, additionalData []byte) {
+	h := newGHASH(g.h[:])
+	h.update(additionalData)
+	h.update(ciphertext)
+	var lengths [blockSize]byte
+	binary.BigEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.BigEndian.PutUint64(lengths[8:], uint64(len(ciphertext))*8)
+	h.update(lengths[:])
+
+	var j0 [blockSize]byte
+	copy(j0[:], nonce)
+	j0[blockSize-1] = 1
+	var q state
+	q.load(0, j0[:])
+	g.cipher.encrypt(&q)
+	var mask [blockSize]byte
+	q.store(0, mask[:])
+
+	h.sum(out)
+	for i := range mask {
+		out[i] ^= mask[i]
+	}
+}
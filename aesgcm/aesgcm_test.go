@@ -0,0 +1,131 @@
+package aesgcm
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBlockCipher(t *testing.T) {
+	for _, keySize := range []int{16, 24, 32} {
+		key := make([]byte, keySize)
+		rand.Read(key)
+		c, err := newBlockCipher(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		std, _ := aes.NewCipher(key)
+		var in [4][16]byte
+		var q state
+		for b := range in {
+			rand.Read(in[b][:])
+			q.load(b, in[b][:])
+		}
+		c.encrypt(&q)
+		for b := range in {
+			var actual, expected [16]byte
+			q.store(b, actual[:])
+			std.Encrypt(expected[:], in[b][:])
+			if actual != expected {
+				t.Errorf("AES-%d lane %d: %X, expected %X", 8*keySize, b, actual, expected)
+			}
+		}
+	}
+}
+
+func TestVector(t *testing.T) {
+	// Test case 4 of the original GCM specification.
+	key, _ := hex.DecodeString("feffe9928665731c6d6a8f9467308308")
+	nonce, _ := hex.DecodeString("cafebabefacedbaddecaf888")
+	plaintext, _ := hex.DecodeString("d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39")
+	ad, _ := hex.DecodeString("feedfacedeadbeeffeedfacedeadbeefabaddad2")
+	expected, _ := hex.DecodeString("42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e0915bc94fbc3221a5db94fae95ae7121a47")
+	aead, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, ad)
+	if !bytes.Equal(ciphertext, expected) {
+		t.Errorf("Seal = %x, expected %x", ciphertext, expected)
+	}
+	decrypted, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Open = %x, expected %x", decrypted, plaintext)
+	}
+}
+
+func TestMatchesStandardLibrary(t *testing.T) {
+	for _, keySize := range []int{16, 24, 32} {
+		key := make([]byte, keySize)
+		rand.Read(key)
+		aead, err := New(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, _ := aes.NewCipher(key)
+		std, _ := cipher.NewGCM(block)
+		nonce := make([]byte, NonceSize)
+		for _, n := range []int{0, 1, 15, 16, 17, 63, 64, 65, 200} {
+			plaintext := make([]byte, n)
+			ad := make([]byte, n/3)
+			rand.Read(plaintext)
+			rand.Read(ad)
+			rand.Read(nonce)
+			actual := aead.Seal([]byte("prefix"), nonce, plaintext, ad)
+			expected := std.Seal([]byte("prefix"), nonce, plaintext, ad)
+			if !bytes.Equal(actual, expected) {
+				t.Fatalf("AES-%d, %d bytes: Seal = %x, expected %x", 8*keySize, n, actual, expected)
+			}
+			decrypted, err := aead.Open(nil, nonce, actual[len("prefix"):], ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("AES-%d, %d bytes: Open = %x, expected %x", 8*keySize, n, decrypted, plaintext)
+			}
+		}
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	key := make([]byte, 16)
+	nonce := make([]byte, NonceSize)
+	aead, _ := New(key)
+	ciphertext := aead.Seal(nil, nonce, []byte("attack at dawn"), []byte("header"))
+	for i := range ciphertext {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[i] ^= 1
+		if _, err := aead.Open(nil, nonce, tampered, []byte("header")); err == nil {
+			t.Errorf("Open accepted a ciphertext with byte %d flipped", i)
+		}
+	}
+	if _, err := aead.Open(nil, nonce, ciphertext, []byte("footer")); err == nil {
+		t.Error("Open accepted the wrong additional data")
+	}
+	if _, err := aead.Open(nil, nonce, ciphertext[:Overhead-1], nil); err == nil {
+		t.Error("Open accepted a truncated ciphertext")
+	}
+}
+
+func TestInvalidKeySize(t *testing.T) {
+	if _, err := New(make([]byte, 20)); err == nil {
+		t.Error("New accepted a 20 byte key")
+	}
+}
+
+func BenchmarkSeal1K(b *testing.B) {
+	aead, _ := New(make([]byte, 16))
+	nonce := make([]byte, NonceSize)
+	plaintext := make([]byte, 1024)
+	out := make([]byte, 0, len(plaintext)+Overhead)
+	b.SetBytes(int64(len(plaintext)))
+	for i := 0; i < b.N; i++ {
+		aead.Seal(out, nonce, plaintext, nil)
+	}
+}
@@ -0,0 +1,122 @@
+package aesgcm
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// ghash is the universal hash of GCM, multiplying by the key H in GF(2^128).
+//
+// The usual implementations use tables of multiples of H, indexed by the data
+// being hashed, which leak through the cache. Instead, carryless products are
+// computed with integer multiplications, as in BearSSL's ghash_ctmul64.
+type ghash struct {
+	// h1 and h0 are the high and low halves of H, with their bit reversals,
+	// and the sum of the halves used by Karatsuba multiplication.
+	h0, h1, h2    uint64
+	h0r, h1r, h2r uint64
+	// y1 and y0 are the high and low halves of the current value.
+	y0, y1 uint64
+}
+
+func newGHASH(h []byte) ghash {
+	var g ghash
+	g.h1 = binary.BigEndian.Uint64(h[:8])
+	g.h0 = binary.BigEndian.Uint64(h[8:16])
+	g.h0r = bits.Reverse64(g.h0)
+	g.h1r = bits.Reverse64(g.h1)
+	g.h2 = g.h0 ^ g.h1
+	g.h2r = g.h0r ^ g.h1r
+	return g
+}
+
+// bmul64 returns the low 64 bits of the carryless product of x and y.
+//
+// The bits of each operand are split into four sets, one every fourth bit,
+// with holes between them. An integer product of two sets adds at most 16
+// bits in each position, and only sums of 16 bits, at positions 60 and above,
+// carry into the next bit of the same set, past 64 bits; the other carries
+// land in the holes, and are masked away.
+func bmul64(x, y uint64) uint64 {
+	const (
+		m0 = 0x1111111111111111
+		m1 = 0x2222222222222222
+		m2 = 0x4444444444444444
+		m3 = 0x8888888888888888
+	)
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := (x0 * y0) ^ (x1 * y3) ^ (x2 * y2) ^ (x3 * y1)
+	z1 := (x0 * y1) ^ (x1 * y0) ^ (x2 * y3) ^ (x3 * y2)
+	z2 := (x0 * y2) ^ (x1 * y1) ^ (x2 * y0) ^ (x3 * y3)
+	z3 := (x0 * y3) ^ (x1 * y2) ^ (x2 * y1) ^ (x3 * y0)
+	return z0&m0 | z1&m1 | z2&m2 | z3&m3
+}
+
+// update hashes data, padded with zeros to a multiple of 16 bytes.
+func (g *ghash) update(data []byte) {
+	var block [16]byte
+	for len(data) > 0 {
+		src := data
+		if len(data) < 16 {
+			block = [16]byte{}
+			copy(block[:], data)
+			src = block[:]
+			data = nil
+		} else {
+			data = data[16:]
+		}
+		g.y1 ^= binary.BigEndian.Uint64(src[:8])
+		g.y0 ^= binary.BigEndian.Uint64(src[8:16])
+		g.mul()
+	}
+}
+
+// mul sets y to y * H.
+//
+// GCM reverses the order of the bits in each field element, so that the high
+// half of a product is obtained from the low half of the product of the
+// reversed operands.
+func (g *ghash) mul() {
+	y0, y1 := g.y0, g.y1
+	y0r, y1r := bits.Reverse64(y0), bits.Reverse64(y1)
+	y2, y2r := y0^y1, y0r^y1r
+
+	// Karatsuba multiplication, for the 256 bit product v.
+	z0 := bmul64(y0, g.h0)
+	z1 := bmul64(y1, g.h1)
+	z2 := bmul64(y2, g.h2)
+	z0h := bmul64(y0r, g.h0r)
+	z1h := bmul64(y1r, g.h1r)
+	z2h := bmul64(y2r, g.h2r)
+	z2 ^= z0 ^ z1
+	z2h ^= z0h ^ z1h
+	z0h = bits.Reverse64(z0h) >> 1
+	z1h = bits.Reverse64(z1h) >> 1
+	z2h = bits.Reverse64(z2h) >> 1
+
+	v0 := z0
+	v1 := z0h ^ z2
+	v2 := z1 ^ z2h
+	v3 := z1h
+
+	// The product of two reversed 128 bit values is off by one bit.
+	v3 = v3<<1 | v2>>63
+	v2 = v2<<1 | v1>>63
+	v1 = v1<<1 | v0>>63
+	v0 = v0 << 1
+
+	// Reduction modulo x^128 + x^7 + x^2 + x + 1, reversed.
+	v2 ^= v0 ^ v0>>1 ^ v0>>2 ^ v0>>7
+	v1 ^= v0<<63 ^ v0<<62 ^ v0<<57
+	v3 ^= v1 ^ v1>>1 ^ v1>>2 ^ v1>>7
+	v2 ^= v1<<63 ^ v1<<62 ^ v1<<57
+
+	g.y0, g.y1 = v2, v3
+}
+
+// sum writes the current value to out.
+func (g *ghash) sum(out []byte) {
+	binary.BigEndian.PutUint64(out[:8], g.y1)
+	binary.BigEndian.PutUint64(out[8:16], g.y0)
+}
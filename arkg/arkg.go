@@ -0,0 +1,236 @@
+// Package arkg implements asynchronous remote key generation (ARKG), following
+// the structure of draft-bradleylundberg-cfrg-arkg, over the P-256 and
+// ristretto255 groups.
+//
+// The owner of a key pair generates a seed, and publishes its public part. Any
+// third party, like a backup authenticator, or an issuer of delegated keys, can
+// then derive new public keys from the public seed, without interacting with
+// the owner, along with a key handle. Later, the owner recovers the private key
+// of a derived public key from the private seed and the key handle. Derived
+// public keys can't be linked to each other, or to the seed, without the
+// private seed.
+//
+// The scheme combines two building blocks:
+//
+//   - a key blinding scheme, where a public key pk is blinded into
+//     pk + tau G, and its private key sk into sk + tau, for a factor tau;
+//   - a key encapsulation mechanism, where the key handle is an ephemeral
+//     Diffie-Hellman share, authenticated with HMAC, from which a shared
+//     secret is derived with HKDF, then hashed to tau.
+//
+// The info parameter of derivations binds the derived keys to their context,
+// like a relying party, and must be the same when deriving the public and the
+// private key.
+package arkg
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/hkdf"
+	"github.com/cronokirby/ctcrypto/hmac"
+	"github.com/cronokirby/safenum"
+)
+
+// tagSize is the size of the MAC prefixing the Diffie-Hellman share in key
+// handles.
+const tagSize = 16
+
+// Suite is an instance of ARKG over a group, with a hash function.
+type Suite struct {
+	g    group.Group
+	hash func() hash.Hash
+	// id identifies the suite in every derivation, like "ARKG-P256".
+	id string
+}
+
+// New returns the ARKG suite over a given group.
+//
+// The P-256 group is supported with SHA-256, and the ristretto255 group with
+// SHA-512.
+func New(g group.Group) (*Suite, error) {
+	switch g.Name() {
+	case "P-256":
+		return &Suite{g: g, hash: sha256.New, id: "ARKG-P256"}, nil
+	case "ristretto255":
+		return &Suite{g: g, hash: sha512.New, id: "ARKG-ristretto255"}, nil
+	default:
+		return nil, errors.New("arkg: unsupported group " + g.Name())
+	}
+}
+
+// Group returns the group used by this suite.
+func (s *Suite) Group() group.Group {
+	return s.g
+}
+
+// PublicSeed is the public part of a seed, from which public keys are
+// derived.
+type PublicSeed struct {
+	// KEM is the public key of the key encapsulation mechanism, and Blinding
+	// the public key which is blinded into the derived public keys.
+	KEM, Blinding group.Element
+}
+
+// Bytes returns the encoding of the seed, which is the encoding of KEM
+// followed by that of Blinding.
+func (pk *PublicSeed) Bytes() []byte {
+	return append(pk.KEM.Bytes(), pk.Blinding.Bytes()...)
+}
+
+// PrivateSeed is the private part of a seed, held by its owner.
+type PrivateSeed struct {
+	// KEM and Blinding are the private keys of the public seed.
+	KEM, Blinding *safenum.Nat
+}
+
+// randomNonZero returns a random scalar which isn't zero.
+func (s *Suite) randomNonZero(rand io.Reader) (*safenum.Nat, error) {
+	for {
+		k, err := s.g.RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		if !k.EqZero() {
+			return k, nil
+		}
+	}
+}
+
+// GenerateSeed generates a seed, reading randomness from rand.
+func (s *Suite) GenerateSeed(rand io.Reader) (*PrivateSeed, *PublicSeed, error) {
+	kem, err := s.randomNonZero(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	bl, err := s.randomNonZero(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk := &PrivateSeed{KEM: kem, Blinding: bl}
+	return sk, s.PublicSeed(sk), nil
+}
+
+// PublicSeed returns the public part of a private seed.
+func (s *Suite) PublicSeed(sk *PrivateSeed) *PublicSeed {
+	return &PublicSeed{KEM: s.g.ScalarBaseMult(sk.KEM), Blinding: s.g.ScalarBaseMult(sk.Blinding)}
+}
+
+// ParsePublicSeed decodes a public seed produced by PublicSeed.Bytes.
+func (s *Suite) ParsePublicSeed(data []byte) (*PublicSeed, error) {
+	n := s.g.ElementSize()
+	if len(data) != 2*n {
+		return nil, errors.New("arkg: invalid public seed length")
+	}
+	kem, err := s.g.DecodeElement(data[:n])
+	if err != nil {
+		return nil, err
+	}
+	bl, err := s.g.DecodeElement(data[n:])
+	if err != nil {
+		return nil, err
+	}
+	return &PublicSeed{KEM: kem, Blinding: bl}, nil
+}
+
+// KeyHandleSize returns the size of the key handles of this suite.
+func (s *Suite) KeyHandleSize() int {
+	return tagSize + s.g.ElementSize()
+}
+
+// label returns "ARKG-" || name || "." || id || "." || info.
+func (s *Suite) label(name string, info []byte) []byte {
+	l := make([]byte, 0, len(name)+len(s.id)+len(info)+7)
+	l = append(l, "ARKG-"...)
+	l = append(l, name...)
+	l = append(l, '.')
+	l = append(l, s.id...)
+	l = append(l, '.')
+	return append(l, info...)
+}
+
+// kemKeys derives the MAC key of the key handle, and the shared secret, from
+// a Diffie-Hellman share.
+func (s *Suite) kemKeys(shared group.Element, info []byte) (mk, secret []byte, err error) {
+	ikm := shared.Bytes()
+	size := s.hash().Size()
+	if mk, err = hkdf.Key(s.hash, ikm, nil, s.label("KEM-HMAC-mac", info), size); err != nil {
+		return nil, nil, err
+	}
+	if secret, err = hkdf.Key(s.hash, ikm, nil, s.label("KEM-HMAC-shared", info), size); err != nil {
+		return nil, nil, err
+	}
+	return mk, secret, nil
+}
+
+// tag returns the MAC of a Diffie-Hellman share.
+func (s *Suite) tag(mk, share []byte) []byte {
+	mac := hmac.New(s.hash, mk)
+	mac.Write(share)
+	return mac.Sum(nil)[:tagSize]
+}
+
+// blindingFactor hashes the shared secret of the KEM, whose size is fixed,
+// followed by info, to the factor tau.
+func (s *Suite) blindingFactor(secret, info []byte) *safenum.Nat {
+	return s.g.HashToScalar(append(append([]byte{}, secret...), info...), []byte("ARKG-BL-EC."+s.id))
+}
+
+// DerivePublicKey derives a new public key from a public seed, for the given
+// info, reading randomness from rand. It returns the public key, and the key
+// handle with which the owner of the seed recovers its private key.
+func (s *Suite) DerivePublicKey(rand io.Reader, pk *PublicSeed, info []byte) (group.Element, []byte, error) {
+	if pk.KEM.IsIdentity() || pk.Blinding.IsIdentity() {
+		return nil, nil, errors.New("arkg: invalid public seed")
+	}
+	e, err := s.randomNonZero(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	share := s.g.ScalarBaseMult(e).Bytes()
+	mk, secret, err := s.kemKeys(pk.KEM.ScalarMult(e), info)
+	if err != nil {
+		return nil, nil, err
+	}
+	tau := s.blindingFactor(secret, info)
+	pub := pk.Blinding.Add(s.g.ScalarBaseMult(tau))
+	if pub.IsIdentity() {
+		return nil, nil, errors.New("arkg: derived the identity")
+	}
+	keyHandle := append(s.tag(mk, share), share...)
+	return pub, keyHandle, nil
+}
+
+// DerivePrivateKey recovers the private key of a public key derived from the
+// public part of sk, with the given key handle and info.
+//
+// An error is returned if the key handle wasn't produced for this seed and
+// info.
+func (s *Suite) DerivePrivateKey(sk *PrivateSeed, keyHandle, info []byte) (*safenum.Nat, error) {
+	if len(keyHandle) != s.KeyHandleSize() {
+		return nil, errors.New("arkg: invalid key handle length")
+	}
+	share := keyHandle[tagSize:]
+	E, err := s.g.DecodeElement(share)
+	if err != nil {
+		return nil, err
+	}
+	mk, secret, err := s.kemKeys(E.ScalarMult(sk.KEM), info)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(s.tag(mk, share), keyHandle[:tagSize]) != 1 {
+		return nil, errors.New("arkg: invalid key handle")
+	}
+	tau := s.blindingFactor(secret, info)
+	priv := new(safenum.Nat).ModAdd(sk.Blinding, tau, s.g.Order())
+	if priv.EqZero() {
+		return nil, errors.New("arkg: derived the identity")
+	}
+	return priv, nil
+}
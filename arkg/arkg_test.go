@@ -0,0 +1,77 @@
+package arkg
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestDeriveKeys(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.Ristretto255()} {
+		s, err := New(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sk, pk, err := s.GenerateSeed(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The public seed is published, and parsed by a third party.
+		pk, err = s.ParsePublicSeed(pk.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+
+		info := []byte("relying party")
+		pub1, kh1, err := s.DerivePublicKey(rand.Reader, pk, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub2, kh2, err := s.DerivePublicKey(rand.Reader, pk, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kh1) != s.KeyHandleSize() {
+			t.Errorf("%s: key handle of %d bytes", g.Name(), len(kh1))
+		}
+		if pub1.Equal(pub2) == 1 || pub1.Equal(pk.Blinding) == 1 {
+			t.Errorf("%s: derived public keys are linked", g.Name())
+		}
+
+		for _, d := range []struct {
+			pub group.Element
+			kh  []byte
+		}{{pub1, kh1}, {pub2, kh2}} {
+			priv, err := s.DerivePrivateKey(sk, d.kh, info)
+			if err != nil {
+				t.Fatalf("%s: %v", g.Name(), err)
+			}
+			if g.ScalarBaseMult(priv).Equal(d.pub) != 1 {
+				t.Errorf("%s: the private key doesn't match the derived public key", g.Name())
+			}
+		}
+
+		if _, err := s.DerivePrivateKey(sk, kh1, []byte("other relying party")); err == nil {
+			t.Errorf("%s: key handle accepted with the wrong info", g.Name())
+		}
+		other, _, err := s.GenerateSeed(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.DerivePrivateKey(other, kh1, info); err == nil {
+			t.Errorf("%s: key handle accepted with the wrong seed", g.Name())
+		}
+		kh1[0] ^= 1
+		if _, err := s.DerivePrivateKey(sk, kh1, info); err == nil {
+			t.Errorf("%s: tampered key handle accepted", g.Name())
+		}
+		if _, err := s.DerivePrivateKey(sk, kh1[1:], info); err == nil {
+			t.Errorf("%s: short key handle accepted", g.Name())
+		}
+	}
+
+	if _, err := New(group.P384()); err == nil {
+		t.Error("unsupported group accepted")
+	}
+}
@@ -0,0 +1,232 @@
+// Package bip39 implements the mnemonics of BIP-39, which encode random
+// entropy as a list of English words, with a checksum, and the derivation of
+// seeds from them.
+//
+// A seed from NewSeed is 64 bytes long, and can be passed to the
+// GenerateKeyFromSeed functions of the ecdsa and rsa packages, to derive the
+// same keys from a mnemonic written down as a backup.
+//
+// The words are encoded and decoded without branching on, or indexing memory
+// with, the entropy, but the lengths of the words of a mnemonic are revealed
+// by its own length.
+package bip39
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// radixBits is the number of bits encoded by a word.
+	radixBits = 11
+	// seedIterations is the number of iterations of PBKDF2 deriving seeds.
+	seedIterations = 2048
+	// SeedSize is the size of the seeds derived from mnemonics, in bytes.
+	SeedSize = 64
+)
+
+var (
+	errEntropySize = errors.New("bip39: entropy must be 128 to 256 bits long, in steps of 32")
+	errLength      = errors.New("bip39: mnemonic must have 12, 15, 18, 21, or 24 words")
+	errUnknownWord = errors.New("bip39: unknown word in mnemonic")
+	errChecksum    = errors.New("bip39: invalid mnemonic checksum")
+)
+
+// packedWords holds the words of wordlist, packed in little-endian words, to
+// be compared in constant time.
+var packedWords [len(wordlist)]uint64
+
+func init() {
+	for i, w := range wordlist {
+		for j := len(w) - 1; j >= 0; j-- {
+			packedWords[i] = packedWords[i]<<8 | uint64(w[j])
+		}
+	}
+}
+
+// ctEq64 returns 1 if a == b, and 0 otherwise.
+func ctEq64(a, b uint64) int {
+	d := a ^ b
+	return int(1 ^ (d|-d)>>63)
+}
+
+// wordIndex returns the index of word, ignoring case, and 1, or 0 and 0 if
+// word isn't in the list. Its time only depends on the length of word.
+func wordIndex(word string) (index, ok int) {
+	if len(word) < 3 || len(word) > 8 {
+		return 0, 0
+	}
+	var v uint64
+	for i := len(word) - 1; i >= 0; i-- {
+		c := word[i]
+		upper := subtle.ConstantTimeLessOrEq('A', int(c)) & subtle.ConstantTimeLessOrEq(int(c), 'Z')
+		v = v<<8 | uint64(c|byte(upper)<<5)
+	}
+	for i, p := range packedWords {
+		eq := ctEq64(p, v)
+		index |= i & -eq
+		ok |= eq
+	}
+	return index, ok
+}
+
+// word returns the word of the given index, selected among all the words in
+// constant time. Only the length of the word is revealed.
+func word(index int) string {
+	var v uint64
+	for i, p := range packedWords {
+		v |= p & -uint64(subtle.ConstantTimeEq(int32(i), int32(index)))
+	}
+	var b []byte
+	for ; v != 0; v >>= 8 {
+		b = append(b, byte(v))
+	}
+	return string(b)
+}
+
+func checkEntropySize(n int) error {
+	if n < 16 || n > 32 || n%4 != 0 {
+		return errEntropySize
+	}
+	return nil
+}
+
+// NewMnemonic returns a mnemonic of bits of entropy, read from rand, which
+// must be 128, 160, 192, 224, or 256. The mnemonic has bits / 32 * 3 words.
+//
+// If rand is nil, the health-tested Reader of the rand package of this module
+// is used.
+func NewMnemonic(bits int, rand io.Reader) (string, error) {
+	if bits%8 != 0 || checkEntropySize(bits/8) != nil {
+		return "", errEntropySize
+	}
+	entropy := make([]byte, bits/8)
+	defer zero(entropy)
+	if _, err := io.ReadFull(randutil.Or(rand), entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic encodes entropy, of 16 to 32 bytes, in steps of 4, as a
+// mnemonic of words separated by spaces, followed by the first bits of its
+// SHA-256 hash as a checksum, one for every 32 bits of entropy.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if err := checkEntropySize(len(entropy)); err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(entropy)
+	checksumBits := len(entropy) / 4
+	data := append(append(make([]byte, 0, len(entropy)+1), entropy...), h[0])
+	defer zero(data)
+
+	count := (8*len(entropy) + checksumBits) / radixBits
+	words := make([]string, 0, count)
+	var acc uint32
+	n := 0
+	for _, b := range data {
+		acc = acc<<8 | uint32(b)
+		if n += 8; n >= radixBits {
+			n -= radixBits
+			words = append(words, word(int(acc>>n&(1<<radixBits-1))))
+		}
+		if len(words) == count {
+			break
+		}
+	}
+	return strings.Join(words, " "), nil
+}
+
+// parse returns the indices of the words of mnemonic, separated by
+// whitespace, and the entropy they encode, checking its checksum.
+func parse(mnemonic string) (indices []int, entropy []byte, err error) {
+	fields := strings.Fields(mnemonic)
+	if len(fields) < 12 || len(fields) > 24 || len(fields)%3 != 0 {
+		return nil, nil, errLength
+	}
+	indices = make([]int, len(fields))
+	valid := 1
+	for i, f := range fields {
+		var ok int
+		indices[i], ok = wordIndex(f)
+		valid &= ok
+	}
+	if valid != 1 {
+		return nil, nil, errUnknownWord
+	}
+
+	// Each 3 words encode 32 bits of entropy, and a bit of checksum.
+	checksumBits := len(fields) / 3
+	entropy = make([]byte, 4*checksumBits)
+	var acc uint32
+	n, j := 0, 0
+	for _, w := range indices {
+		acc = acc<<radixBits | uint32(w)
+		for n += radixBits; n >= 8 && j < len(entropy); j++ {
+			n -= 8
+			entropy[j] = byte(acc >> n)
+		}
+	}
+	checksum := byte(acc & (1<<checksumBits - 1))
+	h := sha256.Sum256(entropy)
+	if subtle.ConstantTimeByteEq(checksum, h[0]>>(8-checksumBits)) != 1 {
+		zero(entropy)
+		return nil, nil, errChecksum
+	}
+	return indices, entropy, nil
+}
+
+// MnemonicToEntropy decodes a mnemonic of 12, 15, 18, 21, or 24 words,
+// separated by whitespace, and in any case, checking its checksum, and
+// returns the entropy it encodes.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	_, entropy, err := parse(mnemonic)
+	return entropy, err
+}
+
+// Validate checks that mnemonic is made of words of the list, and that its
+// checksum is valid.
+func Validate(mnemonic string) error {
+	_, entropy, err := parse(mnemonic)
+	zero(entropy)
+	return err
+}
+
+// NewSeed validates mnemonic, and derives a seed of SeedSize bytes from it,
+// and an optional passphrase, with PBKDF2 using HMAC-SHA-512, as in BIP-39.
+// The mnemonic is first normalized to lower case words separated by single
+// spaces, so that it gives the same seed however it is typed.
+//
+// Unlike the mnemonic, the passphrase is used as is. BIP-39 specifies its
+// NFKD normalization, which doesn't change ASCII strings, but other
+// passphrases must be normalized by the caller, for example with
+// golang.org/x/text/unicode/norm, to get the seeds of other implementations.
+func NewSeed(mnemonic string, passphrase []byte) ([]byte, error) {
+	indices, entropy, err := parse(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	zero(entropy)
+	words := make([]string, len(indices))
+	for i, w := range indices {
+		words[i] = word(w)
+	}
+	normalized := []byte(strings.Join(words, " "))
+	defer zero(normalized)
+	salt := append([]byte("mnemonic"), passphrase...)
+	defer zero(salt)
+	return pbkdf2.Key(normalized, salt, seedIterations, SeedSize, sha512.New), nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,184 @@
+package bip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	prefixes := make(map[string]bool)
+	for i, w := range wordlist {
+		if len(w) < 3 || len(w) > 8 || strings.ToLower(w) != w {
+			t.Errorf("invalid word %q", w)
+		}
+		if i > 0 && wordlist[i-1] >= w {
+			t.Errorf("%q isn't sorted", w)
+		}
+		if len(w) >= 4 {
+			prefixes[w[:4]] = true
+		} else {
+			prefixes[w] = true
+		}
+		if index, ok := wordIndex(w); ok != 1 || index != i {
+			t.Errorf("%q has index %d, %d, want %d", w, index, ok, i)
+		}
+		if got := word(i); got != w {
+			t.Errorf("word %d is %q, want %q", i, got, w)
+		}
+	}
+	if len(prefixes) != len(wordlist) {
+		t.Error("the first 4 letters of the words aren't unique")
+	}
+	for _, w := range []string{"", "ab", "abando", "abandons", "zoos", "ab\x00ndon"} {
+		if _, ok := wordIndex(w); ok != 0 {
+			t.Errorf("%q found", w)
+		}
+	}
+}
+
+// Test vectors from the reference implementation of BIP-39, whose seeds use
+// the passphrase "TREZOR".
+var vectors = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow", ""},
+	{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above", ""},
+	{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong", ""},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title",
+		"",
+	},
+	{
+		"8080808080808080808080808080808080808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless",
+		"",
+	},
+	{
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+		"",
+	},
+	{"9e885d952ad362caeb4efe34a8e91bd2", "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic", ""},
+	{"c0ba5a8e914111210f2bd131f3d5e08d", "scheme spot photo card baby mountain device kick cradle pact join borrow", ""},
+	{"f30f8c1da665478f49b001d94c5fc452", "vessel ladder alter error federal sibling chat ability sun glass valve picture", ""},
+	{"77c2b00716cec7213839159e404db50d", "jelly better achieve collect unaware mountain thought cargo oxygen act hood bridge", ""},
+	{"0460ef47585604c5660618db2e6a7e7f", "afford alter spike radar gate glance object seek swamp infant panel yellow", ""},
+	{"18ab19a9f54a9274f03e5209a2ac8a91", "board flee heavy tunnel powder denial science ski answer betray cargo cat", ""},
+	{
+		"68a79eaca2324873eacc50cb9c6eca8cc68ea5d936f98787c60c7ebc74e6ce7c",
+		"hamster diagram private dutch cause delay private meat slide toddler razor book happy fancy gospel tennis maple dilemma loan word shrug inflict delay length",
+		"",
+	},
+	{
+		"066dca1a2bb7e8a1db2832148ce9933eea0f3ac9548d793112d9a95c9407efad",
+		"all hour make first leader extend hole alien behind guard gospel lava path output census museum junior mass reopen famous sing advance salt reform",
+		"",
+	},
+	{
+		"f585c11aec520db57dd353c69554b21a89b20fb0650966fa0a9d6f74fd989d8f",
+		"void come effort suffer camp survey warrior heavy shoot primary clutch crush open amazing screen patrol group space point ten exist slush involve unfold",
+		"",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		mnemonic, err := EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != v.mnemonic {
+			t.Errorf("%s: got %q, want %q", v.entropy, mnemonic, v.mnemonic)
+		}
+		got, err := MnemonicToEntropy(v.mnemonic)
+		if err != nil {
+			t.Errorf("%s: %v", v.entropy, err)
+		} else if !bytes.Equal(got, entropy) {
+			t.Errorf("%s: decoded %x", v.entropy, got)
+		}
+		if v.seed != "" {
+			seed, err := NewSeed(v.mnemonic, []byte("TREZOR"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hex.EncodeToString(seed); got != v.seed {
+				t.Errorf("%s: seed %s, want %s", v.entropy, got, v.seed)
+			}
+		}
+	}
+}
+
+func TestNewMnemonic(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(strings.Fields(mnemonic)); n != bits/32*3 {
+			t.Errorf("%d bits: got %d words", bits, n)
+		}
+		if err := Validate(mnemonic); err != nil {
+			t.Errorf("%d bits: %v", bits, err)
+		}
+	}
+	for _, bits := range []int{0, 96, 130, 288} {
+		if _, err := NewMnemonic(bits, nil); err == nil {
+			t.Errorf("%d bits: generated", bits)
+		}
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	want, err := NewSeed(vectors[0].mnemonic, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typed := "  " + strings.ToUpper(strings.Replace(vectors[0].mnemonic, " ", " \n\t", -1)) + "\n"
+	got, err := NewSeed(typed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("the seed depends on the case and the whitespace of the mnemonic")
+	}
+	if other, _ := NewSeed(vectors[0].mnemonic, []byte("TREZOR")); bytes.Equal(other, want) {
+		t.Error("the seed doesn't depend on the passphrase")
+	}
+}
+
+func TestInvalidMnemonics(t *testing.T) {
+	for name, mnemonic := range map[string]string{
+		"checksum":     "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"unknown word": "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abou",
+		"11 words":     "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"13 words":     "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"27 words":     strings.Repeat("zoo ", 26) + "vote",
+		"swapped":      "legal winner thank year wave sausage worth useful legal winner yellow thank",
+	} {
+		if err := Validate(mnemonic); err == nil {
+			t.Errorf("%s: valid", name)
+		}
+		if _, err := NewSeed(mnemonic, nil); err == nil {
+			t.Errorf("%s: derived a seed", name)
+		}
+	}
+	for _, n := range []int{0, 12, 15, 33} {
+		if _, err := EntropyToMnemonic(make([]byte, n)); err == nil {
+			t.Errorf("%d bytes of entropy encoded", n)
+		}
+	}
+}
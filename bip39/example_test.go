@@ -0,0 +1,31 @@
+package bip39_test
+
+import (
+	"crypto/elliptic"
+	"fmt"
+
+	"github.com/cronokirby/ctcrypto/bip39"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+func Example() {
+	// A new wallet shows its mnemonic to be written down.
+	mnemonic, err := bip39.NewMnemonic(256, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// The same key is derived again from the mnemonic, and the passphrase.
+	seed, err := bip39.NewSeed(mnemonic, []byte("passphrase"))
+	if err != nil {
+		panic(err)
+	}
+	key, err := ecdsa.GenerateKeyFromSeed(elliptic.P256(), seed)
+	if err != nil {
+		panic(err)
+	}
+	seed, _ = bip39.NewSeed(mnemonic, []byte("passphrase"))
+	again, _ := ecdsa.GenerateKeyFromSeed(elliptic.P256(), seed)
+	fmt.Println(key.Equal(again))
+	// Output: true
+}
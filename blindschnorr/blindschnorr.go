@@ -0,0 +1,230 @@
+// Package blindschnorr implements blind Schnorr signatures over the groups of
+// the group package, with the clause protocol of Fuchsbauer, Plouviez, and
+// Seurin, from "Blind Schnorr Signatures and Signed ElGamal Encryption in the
+// Algebraic Group Model", Eurocrypt 2020.
+//
+// A user obtains a signature of a message from a signer, without the signer
+// learning the message, or being able to link the signature to the session
+// which produced it, as needed by e-cash and anonymous credentials. The
+// signatures are ordinary Schnorr signatures, checked with Verify.
+//
+// The plain blind Schnorr protocol is broken by the ROS attack of Benhamouda,
+// Lepoint, Loss, Orrù, and Raykova, which forges one more signature than the
+// number of sessions, once a few hundred sessions run concurrently. In the
+// clause protocol, the signer commits to two nonces in each session, the user
+// blinds both, and the signer answers for only one of them, chosen at random,
+// which makes this attack, and its known variants, fail. Each signer session
+// can only be answered once.
+package blindschnorr
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// challengeDST is the domain separation tag of challenges.
+const challengeDST = "ctcrypto-blindschnorr-v1"
+
+// appendPrefixed appends a labelled value to buf, each prefixed by its length.
+func appendPrefixed(buf []byte, label string, data []byte) []byte {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(label)))
+	buf = append(append(buf, length[:]...), label...)
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data)))
+	return append(append(buf, length[:]...), data...)
+}
+
+// challenge returns the challenge c = H(R, X, message) of a signature.
+func challenge(g group.Group, R, X group.Element, message []byte) *safenum.Nat {
+	buf := appendPrefixed(nil, "group", []byte(g.Name()))
+	buf = appendPrefixed(buf, "R", R.Bytes())
+	buf = appendPrefixed(buf, "X", X.Bytes())
+	buf = appendPrefixed(buf, "message", message)
+	return g.HashToScalar(buf, []byte(challengeDST))
+}
+
+// Signature is a Schnorr signature (R, s), such that s G = R + c X, for the
+// public key X, and the challenge c = H(R, X, message).
+type Signature struct {
+	R group.Element
+	S *safenum.Nat
+}
+
+// Bytes encodes the signature, as the encoding of R followed by the encoding
+// of s.
+func (sig *Signature) Bytes(g group.Group) []byte {
+	return append(sig.R.Bytes(), g.EncodeScalar(sig.S)...)
+}
+
+// DecodeSignature decodes a signature produced by Signature.Bytes.
+func DecodeSignature(g group.Group, data []byte) (*Signature, error) {
+	if len(data) != g.ElementSize()+g.ScalarSize() {
+		return nil, errors.New("blindschnorr: invalid signature length")
+	}
+	R, err := g.DecodeElement(data[:g.ElementSize()])
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.DecodeScalar(data[g.ElementSize():])
+	if err != nil {
+		return nil, err
+	}
+	return &Signature{R: R, S: s}, nil
+}
+
+// Verify reports whether sig is a valid signature of message by the public
+// key X.
+func Verify(g group.Group, X group.Element, message []byte, sig *Signature) bool {
+	if X.IsIdentity() || sig.R.IsIdentity() {
+		return false
+	}
+	c := challenge(g, sig.R, X, message)
+	// s G = R + c X
+	return g.ScalarBaseMult(sig.S).Equal(sig.R.Add(X.ScalarMult(c))) == 1
+}
+
+// Signer holds the private key of a signer.
+type Signer struct {
+	g group.Group
+	x *safenum.Nat
+	X group.Element
+}
+
+// GenerateKey generates a private key for a signer, reading randomness from
+// rand.
+func GenerateKey(g group.Group, rand io.Reader) (*Signer, error) {
+	for {
+		x, err := g.RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		if !x.EqZero() {
+			return NewSigner(g, x), nil
+		}
+	}
+}
+
+// NewSigner returns the signer with the private key x, which must be reduced
+// and not zero.
+func NewSigner(g group.Group, x *safenum.Nat) *Signer {
+	return &Signer{g: g, x: x, X: g.ScalarBaseMult(x)}
+}
+
+// PublicKey returns the public key X = x G of the signer.
+func (s *Signer) PublicKey() group.Element {
+	return s.X
+}
+
+// SignerSession is the state of the signer in a session.
+type SignerSession struct {
+	s *Signer
+	// r holds the two nonces, until the session is answered.
+	r [2]*safenum.Nat
+}
+
+// Commit starts a session, reading randomness from rand, and returns the two
+// commitments R_0 = r_0 G and R_1 = r_1 G to send to the user.
+func (s *Signer) Commit(rand io.Reader) (*SignerSession, [2]group.Element, error) {
+	session := &SignerSession{s: s}
+	var commitments [2]group.Element
+	for i := range session.r {
+		r, err := s.g.RandomScalar(rand)
+		if err != nil {
+			return nil, commitments, err
+		}
+		session.r[i] = r
+		commitments[i] = s.g.ScalarBaseMult(r)
+	}
+	return session, commitments, nil
+}
+
+// Respond answers the challenges of the user, reading a random bit b from
+// rand, and returns b with s = r_b + c_b x, for the user to finish the
+// signature.
+//
+// A session can only be answered once, since two answers would reveal the
+// private key.
+func (ss *SignerSession) Respond(challenges [2]*safenum.Nat, rand io.Reader) (int, *safenum.Nat, error) {
+	if ss.r[0] == nil {
+		return 0, nil, errors.New("blindschnorr: session already answered")
+	}
+	var buf [1]byte
+	if _, err := io.ReadFull(rand, buf[:]); err != nil {
+		return 0, nil, err
+	}
+	// The bit is public, so a branch on it is fine.
+	b := int(buf[0] & 1)
+	order := ss.s.g.Order()
+	c := new(safenum.Nat).Mod(challenges[b], order)
+	resp := new(safenum.Nat).ModMul(c, ss.s.x, order)
+	resp.ModAdd(resp, ss.r[b], order)
+	ss.r = [2]*safenum.Nat{}
+	return b, resp, nil
+}
+
+// UserSession is the state of the user in a session.
+type UserSession struct {
+	g       group.Group
+	X       group.Element
+	message []byte
+	// For each commitment R_i, the user keeps R_i and c_i to check the answer,
+	// and its blinding alpha_i, the blinded commitment R'_i, and its
+	// challenge, to finish the signature.
+	commitments [2]group.Element
+	challenges  [2]*safenum.Nat
+	alpha       [2]*safenum.Nat
+	blinded     [2]group.Element
+}
+
+// Blind starts the session of a user, who wants a signature of message by the
+// public key X, from the commitments of the signer, reading randomness from
+// rand. It returns the two challenges to send to the signer.
+//
+// Each commitment R_i is blinded into R'_i = R_i + alpha_i G + beta_i X, for
+// random alpha_i and beta_i, and its challenge is c_i = H(R'_i, X, message)
+// + beta_i.
+func Blind(g group.Group, X group.Element, message []byte, commitments [2]group.Element, rand io.Reader) (*UserSession, [2]*safenum.Nat, error) {
+	var challenges [2]*safenum.Nat
+	if X.IsIdentity() || commitments[0].IsIdentity() || commitments[1].IsIdentity() {
+		return nil, challenges, errors.New("blindschnorr: invalid commitment")
+	}
+	u := &UserSession{g: g, X: X, message: append([]byte{}, message...), commitments: commitments}
+	for i := range commitments {
+		alpha, err := g.RandomScalar(rand)
+		if err != nil {
+			return nil, challenges, err
+		}
+		beta, err := g.RandomScalar(rand)
+		if err != nil {
+			return nil, challenges, err
+		}
+		R := commitments[i].Add(g.ScalarBaseMult(alpha)).Add(X.ScalarMult(beta))
+		if R.IsIdentity() {
+			return nil, challenges, errors.New("blindschnorr: invalid commitment")
+		}
+		c := challenge(g, R, X, message)
+		c.ModAdd(c, beta, g.Order())
+		u.alpha[i], u.blinded[i], u.challenges[i] = alpha, R, c
+		challenges[i] = c
+	}
+	return u, challenges, nil
+}
+
+// Finish checks the answer of the signer, and returns the signature
+// (R'_b, s + alpha_b).
+func (u *UserSession) Finish(b int, s *safenum.Nat) (*Signature, error) {
+	if b != 0 && b != 1 {
+		return nil, errors.New("blindschnorr: invalid answer")
+	}
+	// s G = R_b + c_b X
+	expected := u.commitments[b].Add(u.X.ScalarMult(u.challenges[b]))
+	if u.g.ScalarBaseMult(s).Equal(expected) != 1 {
+		return nil, errors.New("blindschnorr: invalid answer")
+	}
+	sig := new(safenum.Nat).ModAdd(s, u.alpha[b], u.g.Order())
+	return &Signature{R: u.blinded[b], S: sig}, nil
+}
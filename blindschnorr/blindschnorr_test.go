@@ -0,0 +1,68 @@
+package blindschnorr
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func TestBlindSign(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.Ristretto255()} {
+		signer, err := GenerateKey(g, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		X := signer.PublicKey()
+		message := []byte("coin 1234")
+
+		session, commitments, err := signer.Commit(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user, challenges, err := Blind(g, X, message, commitments, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, s, err := session.Respond(challenges, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := session.Respond(challenges, rand.Reader); err == nil {
+			t.Errorf("%s: session answered twice", g.Name())
+		}
+		if _, err := user.Finish(b, new(safenum.Nat).ModAdd(s, new(safenum.Nat).SetUint64(1), g.Order())); err == nil {
+			t.Errorf("%s: invalid answer accepted", g.Name())
+		}
+		if _, err := user.Finish(1-b, s); err == nil {
+			t.Errorf("%s: answer accepted for the other commitment", g.Name())
+		}
+		sig, err := user.Finish(b, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !Verify(g, X, message, sig) {
+			t.Errorf("%s: signature rejected", g.Name())
+		}
+		if Verify(g, X, []byte("coin 1235"), sig) {
+			t.Errorf("%s: signature accepted for another message", g.Name())
+		}
+		// The signature isn't made with any of the commitments of the session.
+		if sig.R.Equal(commitments[0]) == 1 || sig.R.Equal(commitments[1]) == 1 {
+			t.Errorf("%s: the signature is linked to its session", g.Name())
+		}
+
+		decoded, err := DecodeSignature(g, sig.Bytes(g))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(g, X, message, decoded) {
+			t.Errorf("%s: decoded signature rejected", g.Name())
+		}
+		if _, err := DecodeSignature(g, sig.Bytes(g)[1:]); err == nil {
+			t.Errorf("%s: short signature decoded", g.Name())
+		}
+	}
+}
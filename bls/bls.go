@@ -0,0 +1,72 @@
+// Package bls implements BLS signatures over the BLS12-381 curve, along with
+// threshold signing, where any threshold of the holders of shares of a secret
+// key can produce a signature, which is indistinguishable from one produced
+// with the secret key itself.
+//
+// Public keys are points of G1, and signatures are points of G2, as in
+// Ethereum, and the "minimal-pubkey-size" variant of the BLS signatures
+// draft of the CFRG. Messages are hashed to G2 with bls12381.HashToG2, which
+// doesn't follow the standard suite of RFC 9380, so signatures produced by this
+// package can't be verified by other implementations, and vice versa.
+//
+// Secret keys, and shares of secret keys, are scalars, represented as
+// safenum.Nat values, and signing takes time independent of them.
+package bls
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// DST is the domain separation tag used to hash messages to G2.
+const DST = "BLS_SIG_" + bls12381.SuiteG2 + "NUL_"
+
+// GenerateKey returns a new secret key, along with its public key, reading
+// randomness from rand.
+func GenerateKey(rand io.Reader) (*safenum.Nat, *bls12381.G1, error) {
+	sk, err := group.BLS12381G1().RandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, PublicKey(sk), nil
+}
+
+// PublicKey returns the public key sk * G1 associated with a secret key.
+func PublicKey(sk *safenum.Nat) *bls12381.G1 {
+	return new(bls12381.G1).ScalarBaseMult(new(safenum.Nat).Mod(sk, bls12381.Order))
+}
+
+// Sign returns the signature sk * H(msg) of a message.
+func Sign(sk *safenum.Nat, msg []byte) *bls12381.G2 {
+	h := bls12381.HashToG2(msg, []byte(DST))
+	return h.ScalarMult(new(safenum.Nat).Mod(sk, bls12381.Order), h)
+}
+
+// Verify reports whether sig is a valid signature of msg by pk.
+//
+// The identity is rejected as a public key, since it would accept the
+// identity as a signature of any message.
+func Verify(pk *bls12381.G1, msg []byte, sig *bls12381.G2) bool {
+	if pk.IsIdentity() == 1 {
+		return false
+	}
+	// e(G1, sig) = e(pk, H(msg))
+	h := bls12381.HashToG2(msg, []byte(DST))
+	minusG := new(bls12381.G1).Negate(bls12381.NewG1Generator())
+	return bls12381.PairingCheck([]*bls12381.G1{minusG, pk}, []*bls12381.G2{sig, h})
+}
+
+// FromElement converts an element of group.BLS12381G1, such as a public key
+// produced by the dkg package, or a commitment produced by the vss package,
+// into a point of G1.
+func FromElement(e group.Element) (*bls12381.G1, error) {
+	p, err := new(bls12381.G1).SetBytes(e.Bytes())
+	if err != nil {
+		return nil, errors.New("bls: element isn't a point of BLS12-381 G1")
+	}
+	return p, nil
+}
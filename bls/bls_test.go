@@ -0,0 +1,49 @@
+package bls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestSignVerify(t *testing.T) {
+	sk, pk, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello")
+	sig := Sign(sk, msg)
+	if !Verify(pk, msg, sig) {
+		t.Error("valid signature rejected")
+	}
+	if Verify(pk, []byte("hellp"), sig) {
+		t.Error("signature accepted for another message")
+	}
+	_, other, _ := GenerateKey(rand.Reader)
+	if Verify(other, msg, sig) {
+		t.Error("signature accepted for another key")
+	}
+}
+
+func TestVerifyIdentity(t *testing.T) {
+	if Verify(bls12381.NewG1Identity(), []byte("hello"), bls12381.NewG2Identity()) {
+		t.Error("identity signature accepted for the identity key")
+	}
+}
+
+func TestFromElement(t *testing.T) {
+	g := group.BLS12381G1()
+	sk, pk, _ := GenerateKey(rand.Reader)
+	p, err := FromElement(g.ScalarBaseMult(sk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Equal(pk) != 1 {
+		t.Error("converted element differs from public key")
+	}
+	if _, err := FromElement(group.P256().Generator()); err == nil {
+		t.Error("P-256 element accepted")
+	}
+}
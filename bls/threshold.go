@@ -0,0 +1,78 @@
+package bls
+
+import (
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/ctcrypto/vss"
+)
+
+// This file implements threshold signing, following Boldyreva, "Threshold
+// signatures, multisignatures and blind signatures based on the
+// gap-Diffie-Hellman-group signature scheme".
+//
+// The secret key is shared with Shamir's scheme over the group
+// group.BLS12381G1, for example with vss.FeldmanDeal, or the dkg package, so
+// that the commitments to the shares give the public key of each participant.
+// Each participant signs with its share, and any threshold of these signature
+// shares are combined with Lagrange interpolation in the exponent.
+
+// SignatureShare is the signature of a message with the share of a secret key.
+type SignatureShare struct {
+	// ID is the identifier of the participant who produced the share.
+	ID uint32
+	// Signature is share.Value * H(msg).
+	Signature *bls12381.G2
+}
+
+// SignShare returns the share of a signature of msg, using the share of a
+// secret key.
+func SignShare(share vss.Share, msg []byte) SignatureShare {
+	return SignatureShare{ID: share.ID, Signature: Sign(share.Value, msg)}
+}
+
+// VerificationKey returns the public key share.Value * G1 of the participant
+// with a given identifier, from the Feldman commitments to the shared secret,
+// as returned by vss.FeldmanDeal.
+func VerificationKey(commitments []group.Element, id uint32) (*bls12381.G1, error) {
+	if id == 0 || len(commitments) == 0 {
+		return nil, errors.New("bls: invalid identifier or commitments")
+	}
+	return FromElement(vss.FeldmanPublicShare(group.BLS12381G1(), commitments, id))
+}
+
+// VerifyShare reports whether a signature share is valid for msg, given the
+// verification key of the participant who produced it.
+//
+// Checking the shares before combining them lets the combiner identify the
+// participants sending invalid shares, instead of only noticing that the
+// combined signature is invalid.
+func VerifyShare(verificationKey *bls12381.G1, msg []byte, share SignatureShare) bool {
+	return Verify(verificationKey, msg, share.Signature)
+}
+
+// Combine combines signature shares into a signature, using Lagrange
+// interpolation in the exponent.
+//
+// At least threshold valid shares are needed to produce a valid signature,
+// which can be checked with Verify against the public key of the group. An
+// error is returned if the identifiers of the shares aren't distinct and
+// non-zero.
+func Combine(shares []SignatureShare) (*bls12381.G2, error) {
+	ids := make([]uint32, len(shares))
+	for i, s := range shares {
+		ids[i] = s.ID
+	}
+	lambdas, err := shamir.LagrangeCoefficients(bls12381.Order, ids, 0)
+	if err != nil {
+		return nil, err
+	}
+	out := bls12381.NewG2Identity()
+	term := new(bls12381.G2)
+	for i, s := range shares {
+		out.Add(out, term.ScalarMult(lambdas[i], s.Signature))
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package bls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/vss"
+)
+
+func TestThresholdSign(t *testing.T) {
+	g := group.BLS12381G1()
+	sk, pk, _ := GenerateKey(rand.Reader)
+	shares, commitments, err := vss.FeldmanDeal(g, sk, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("hello")
+	sigShares := make([]SignatureShare, len(shares))
+	for i, share := range shares {
+		sigShares[i] = SignShare(share, msg)
+		vk, err := VerificationKey(commitments, share.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyShare(vk, msg, sigShares[i]) {
+			t.Errorf("valid share %d rejected", share.ID)
+		}
+	}
+	sig, err := Combine([]SignatureShare{sigShares[4], sigShares[1], sigShares[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(pk, msg, sig) {
+		t.Error("combined signature rejected")
+	}
+	if sig.Equal(Sign(sk, msg)) != 1 {
+		t.Error("combined signature differs from signature with the secret key")
+	}
+	sig, _ = Combine(sigShares[:2])
+	if Verify(pk, msg, sig) {
+		t.Error("signature from too few shares accepted")
+	}
+}
+
+func TestThresholdBadShare(t *testing.T) {
+	g := group.BLS12381G1()
+	sk, pk, _ := GenerateKey(rand.Reader)
+	shares, commitments, _ := vss.FeldmanDeal(g, sk, 2, 3, rand.Reader)
+	msg := []byte("hello")
+	good := SignShare(shares[0], msg)
+	bad := SignShare(shares[1], msg)
+	bad.Signature.Add(bad.Signature, bls12381.NewG2Generator())
+	vk, _ := VerificationKey(commitments, bad.ID)
+	if VerifyShare(vk, msg, bad) {
+		t.Error("invalid share accepted")
+	}
+	if VerifyShare(vk, msg, good) {
+		t.Error("share accepted for the wrong participant")
+	}
+	sig, _ := Combine([]SignatureShare{good, bad})
+	if Verify(pk, msg, sig) {
+		t.Error("signature combined from an invalid share accepted")
+	}
+	if _, err := Combine([]SignatureShare{good, good}); err == nil {
+		t.Error("duplicate identifiers accepted")
+	}
+}
@@ -0,0 +1,296 @@
+package bls12381
+
+import (
+	"crypto/subtle"
+	"math/big"
+	"math/bits"
+)
+
+// fe is an element of the base field, the integers modulo p, stored in
+// Montgomery form, as 6 little-endian limbs.
+//
+// The arithmetic on field elements is constant-time. It's done on fixed-size
+// limbs, rather than with safenum, since pairings need many thousands of field
+// operations.
+type fe [6]uint64
+
+// The modulus p of the base field.
+var pBig, _ = new(big.Int).SetString("1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab", 16)
+
+var (
+	// the limbs of p
+	p fe
+	// -p^-1 mod 2^64
+	pInv uint64
+	// 2^384 mod p, i.e. 1 in Montgomery form
+	rOne fe
+	// 2^768 mod p, to convert into Montgomery form
+	r2 fe
+	// 2^1152 mod p, to convert wide integers into Montgomery form
+	r3 fe
+	// p - 2, for inversion
+	pMinus2 *big.Int
+	// (p + 1) / 4, for square roots
+	pPlus1Over4 *big.Int
+	// the limbs of (p - 1) / 2, to compare field elements
+	pMinus1Over2 fe
+)
+
+func init() {
+	p = limbsFromBig(pBig)
+	// Newton's iteration doubles the number of correct bits of the inverse.
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - p[0]*inv
+	}
+	pInv = -inv
+	r := new(big.Int).Lsh(big.NewInt(1), 384)
+	rOne = limbsFromBig(new(big.Int).Mod(r, pBig))
+	r2 = limbsFromBig(new(big.Int).Mod(new(big.Int).Mul(r, r), pBig))
+	r3 = limbsFromBig(new(big.Int).Mod(new(big.Int).Exp(r, big.NewInt(3), nil), pBig))
+	pMinus2 = new(big.Int).Sub(pBig, big.NewInt(2))
+	pPlus1Over4 = new(big.Int).Rsh(new(big.Int).Add(pBig, big.NewInt(1)), 2)
+	pMinus1Over2 = limbsFromBig(new(big.Int).Rsh(new(big.Int).Sub(pBig, big.NewInt(1)), 1))
+}
+
+// limbsFromBig converts an integer smaller than 2^384 into limbs, without
+// converting it to Montgomery form.
+func limbsFromBig(x *big.Int) fe {
+	var buf [48]byte
+	x.FillBytes(buf[:])
+	return limbsFromBytes(buf[:])
+}
+
+// limbsFromBytes converts a big-endian integer of at most 48 bytes into limbs,
+// without converting it to Montgomery form.
+func limbsFromBytes(buf []byte) fe {
+	var out fe
+	for i := range buf {
+		j := len(buf) - 1 - i
+		out[j/8] |= uint64(buf[i]) << (8 * (j % 8))
+	}
+	return out
+}
+
+// feFromBig converts an integer into a field element, reducing it modulo p.
+func feFromBig(x *big.Int) *fe {
+	out := limbsFromBig(new(big.Int).Mod(x, pBig))
+	return feMul(&out, &out, &r2)
+}
+
+// feFromWideBytes converts a 64 byte big-endian integer into a field element,
+// reducing it modulo p in constant-time, as done by hash_to_field in RFC 9380.
+func feFromWideBytes(data []byte) *fe {
+	// data = hi 2^384 + lo, with lo < 2^384, so Montgomery multiplication of
+	// lo by 2^768 still reduces correctly.
+	hi := limbsFromBytes(data[:16])
+	lo := limbsFromBytes(data[16:64])
+	var out, t fe
+	feMul(&out, &lo, &r2)
+	feMul(&t, &hi, &r3)
+	return feAdd(&out, &out, &t)
+}
+
+// feFromUint64 converts a small integer into a field element.
+func feFromUint64(x uint64) *fe {
+	out := fe{x}
+	return feMul(&out, &out, &r2)
+}
+
+// canonical returns the limbs of the canonical value of x, outside of
+// Montgomery form.
+func (x *fe) canonical() fe {
+	var one fe
+	one[0] = 1
+	var out fe
+	feMul(&out, x, &one)
+	return out
+}
+
+// big returns the canonical value of a field element, as an integer.
+func (x *fe) big() *big.Int {
+	out := x.canonical()
+	var buf [48]byte
+	for i := range out {
+		for j := 0; j < 8; j++ {
+			buf[47-8*i-j] = byte(out[i] >> (8 * j))
+		}
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// feFromBytes decodes a big-endian field element, returning false if it isn't
+// reduced modulo p.
+func feFromBytes(data []byte) (*fe, bool) {
+	x := new(big.Int).SetBytes(data)
+	if x.Cmp(pBig) >= 0 {
+		return nil, false
+	}
+	return feFromBig(x), true
+}
+
+// bytes returns the 48 byte big-endian encoding of a field element.
+func (x *fe) bytes() []byte {
+	var out [48]byte
+	return x.big().FillBytes(out[:])
+}
+
+// subtractP sets z = t - p if t ≥ p, and z = t otherwise, where t is given
+// by 6 limbs, along with a top carry.
+func subtractP(z *fe, t *fe, carry uint64) {
+	var s fe
+	var borrow uint64
+	for i := range s {
+		s[i], borrow = bits.Sub64(t[i], p[i], borrow)
+	}
+	_, borrow = bits.Sub64(carry, 0, borrow)
+	// Keep t if subtracting p borrowed.
+	mask := -borrow
+	for i := range z {
+		z[i] = (t[i] & mask) | (s[i] &^ mask)
+	}
+}
+
+// feAdd sets z = x + y.
+func feAdd(z, x, y *fe) *fe {
+	var t fe
+	var carry uint64
+	for i := range t {
+		t[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	subtractP(z, &t, carry)
+	return z
+}
+
+// feSub sets z = x - y.
+func feSub(z, x, y *fe) *fe {
+	var t fe
+	var borrow uint64
+	for i := range t {
+		t[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	// Add p back if the subtraction borrowed.
+	mask := -borrow
+	var carry uint64
+	for i := range z {
+		z[i], carry = bits.Add64(t[i], p[i]&mask, carry)
+	}
+	return z
+}
+
+// feNeg sets z = -x.
+func feNeg(z, x *fe) *fe {
+	var zero fe
+	return feSub(z, &zero, x)
+}
+
+// feMul sets z = x * y, using Montgomery multiplication, with the CIOS method.
+func feMul(z, x, y *fe) *fe {
+	var t [8]uint64
+	for i := 0; i < 6; i++ {
+		// t += x * y[i]
+		var c uint64
+		for j := 0; j < 6; j++ {
+			hi, lo := bits.Mul64(x[j], y[i])
+			var c1, c2 uint64
+			lo, c1 = bits.Add64(lo, t[j], 0)
+			lo, c2 = bits.Add64(lo, c, 0)
+			t[j] = lo
+			c = hi + c1 + c2
+		}
+		var c1 uint64
+		t[6], c1 = bits.Add64(t[6], c, 0)
+		t[7] = c1
+
+		// t = (t + m p) / 2^64, where m makes the lowest limb vanish
+		m := t[0] * pInv
+		hi, lo := bits.Mul64(m, p[0])
+		_, c1 = bits.Add64(lo, t[0], 0)
+		c = hi + c1
+		for j := 1; j < 6; j++ {
+			hi, lo = bits.Mul64(m, p[j])
+			var c2 uint64
+			lo, c1 = bits.Add64(lo, t[j], 0)
+			lo, c2 = bits.Add64(lo, c, 0)
+			t[j-1] = lo
+			c = hi + c1 + c2
+		}
+		t[5], c1 = bits.Add64(t[6], c, 0)
+		t[6] = t[7] + c1
+	}
+	var out fe
+	copy(out[:], t[:6])
+	subtractP(z, &out, t[6])
+	return z
+}
+
+// feSquare sets z = x².
+func feSquare(z, x *fe) *fe {
+	return feMul(z, x, x)
+}
+
+// feExp sets z = x^e, for a public exponent e.
+func feExp(z, x *fe, e *big.Int) *fe {
+	out := rOne
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		feSquare(&out, &out)
+		if e.Bit(i) == 1 {
+			feMul(&out, &out, &base)
+		}
+	}
+	*z = out
+	return z
+}
+
+// feInvert sets z = 1 / x, or 0 if x = 0.
+func feInvert(z, x *fe) *fe {
+	return feExp(z, x, pMinus2)
+}
+
+// feEqual returns 1 if x = y, and 0 otherwise.
+func feEqual(x, y *fe) int {
+	var acc uint64
+	for i := range x {
+		acc |= x[i] ^ y[i]
+	}
+	acc |= acc >> 32
+	return subtle.ConstantTimeEq(int32(uint32(acc)), 0)
+}
+
+// feIsZero returns 1 if x = 0, and 0 otherwise.
+func feIsZero(x *fe) int {
+	var zero fe
+	return feEqual(x, &zero)
+}
+
+// feSelect sets z = a if v = 1, and z = b if v = 0.
+func feSelect(z, a, b *fe, v int) *fe {
+	mask := -uint64(v)
+	for i := range z {
+		z[i] = (a[i] & mask) | (b[i] &^ mask)
+	}
+	return z
+}
+
+// feSqrt sets z to a square root of x, returning 1 if x is a square, and 0
+// otherwise, since p ≡ 3 mod 4.
+func feSqrt(z, x *fe) int {
+	var root, check fe
+	feExp(&root, x, pPlus1Over4)
+	feSquare(&check, &root)
+	*z = root
+	return feEqual(&check, x)
+}
+
+// feIsLarge returns 1 if x > (p - 1) / 2, and 0 otherwise, which is used to
+// encode the sign of coordinates of points.
+func feIsLarge(x *fe) int {
+	c := x.canonical()
+	// (p - 1) / 2 - x borrows exactly when x is larger.
+	var borrow uint64
+	for i := range c {
+		_, borrow = bits.Sub64(pMinus1Over2[i], c[i], borrow)
+	}
+	return int(borrow)
+}
@@ -0,0 +1,152 @@
+package bls12381
+
+import "math/big"
+
+// fe12 is an element of Fp12 = Fp2[w] / (w⁶ - ξ), given by its 6 coefficients
+// in Fp2, starting with the constant term.
+//
+// This is the field containing the target group of the pairing. The tower is
+// flattened into a single sextic extension, which keeps the arithmetic simple,
+// at the cost of a few multiplications.
+type fe12 [6]fe2
+
+// gamma[i] = ξ^(i (p - 1) / 6), so that w^p = gamma[1] w.
+var gamma [6]fe2
+
+func init() {
+	e := new(big.Int).Sub(pBig, big.NewInt(1))
+	e.Div(e, big.NewInt(6))
+	xi := fe2{rOne, rOne}
+	var g fe2
+	fe2Exp(&g, &xi, e)
+	gamma[0] = *fe2One()
+	for i := 1; i < 6; i++ {
+		fe2Mul(&gamma[i], &gamma[i-1], &g)
+	}
+}
+
+func fe12One() *fe12 {
+	return &fe12{*fe2One()}
+}
+
+// fe12Mul sets z = x * y.
+func fe12Mul(z, x, y *fe12) *fe12 {
+	var t [11]fe2
+	var tmp fe2
+	for i := range x {
+		for j := range y {
+			fe2Mul(&tmp, &x[i], &y[j])
+			fe2Add(&t[i+j], &t[i+j], &tmp)
+		}
+	}
+	// w⁶ = ξ
+	for k := 10; k >= 6; k-- {
+		fe2MulXi(&tmp, &t[k])
+		fe2Add(&t[k-6], &t[k-6], &tmp)
+	}
+	copy(z[:], t[:6])
+	return z
+}
+
+func fe12Square(z, x *fe12) *fe12 {
+	return fe12Mul(z, x, x)
+}
+
+// fe12MulLine sets z = x * (l0 + l2 w² + l3 w³), which is the shape of the
+// line functions evaluated in the Miller loop.
+func fe12MulLine(z, x *fe12, l0, l2, l3 *fe2) *fe12 {
+	var t [9]fe2
+	var tmp fe2
+	for i := range x {
+		fe2Mul(&tmp, &x[i], l0)
+		fe2Add(&t[i], &t[i], &tmp)
+		fe2Mul(&tmp, &x[i], l2)
+		fe2Add(&t[i+2], &t[i+2], &tmp)
+		fe2Mul(&tmp, &x[i], l3)
+		fe2Add(&t[i+3], &t[i+3], &tmp)
+	}
+	for k := 8; k >= 6; k-- {
+		fe2MulXi(&tmp, &t[k])
+		fe2Add(&t[k-6], &t[k-6], &tmp)
+	}
+	copy(z[:], t[:6])
+	return z
+}
+
+// fe12Frobenius sets z = x^p.
+func fe12Frobenius(z, x *fe12) *fe12 {
+	for i := range x {
+		fe2Conj(&z[i], &x[i])
+		fe2Mul(&z[i], &z[i], &gamma[i])
+	}
+	return z
+}
+
+// fe12Conj sets z = x^(p⁶), which is the conjugate of x over Fp6, and the
+// inverse of x when x lies in the cyclotomic subgroup.
+func fe12Conj(z, x *fe12) *fe12 {
+	// w^(p⁶) = -w
+	for i := range x {
+		if i%2 == 1 {
+			fe2Neg(&z[i], &x[i])
+		} else {
+			z[i] = x[i]
+		}
+	}
+	return z
+}
+
+// fe12Invert sets z = 1 / x, or 0 if x = 0.
+func fe12Invert(z, x *fe12) *fe12 {
+	// The norm x x^(p²) ... x^(p¹⁰) lies in Fp2, so we can invert x by
+	// inverting its norm, and multiplying by the other conjugates.
+	var others, conj fe12
+	others = *fe12One()
+	conj = *x
+	for i := 0; i < 5; i++ {
+		fe12Frobenius(&conj, &conj)
+		fe12Frobenius(&conj, &conj)
+		fe12Mul(&others, &others, &conj)
+	}
+	var norm fe12
+	fe12Mul(&norm, x, &others)
+	var normInv fe2
+	fe2Invert(&normInv, &norm[0])
+	for i := range others {
+		fe2Mul(&z[i], &others[i], &normInv)
+	}
+	return z
+}
+
+// fe12Exp sets z = x^e, for a public exponent e.
+func fe12Exp(z, x *fe12, e *big.Int) *fe12 {
+	out := *fe12One()
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		fe12Square(&out, &out)
+		if e.Bit(i) == 1 {
+			fe12Mul(&out, &out, &base)
+		}
+	}
+	*z = out
+	return z
+}
+
+func fe12Equal(x, y *fe12) int {
+	out := 1
+	for i := range x {
+		out &= fe2Equal(&x[i], &y[i])
+	}
+	return out
+}
+
+// bytes returns the encoding of x as its 12 coefficients over Fp, each
+// encoded as 48 big-endian bytes, starting with the constant term.
+func (x *fe12) bytes() []byte {
+	out := make([]byte, 0, 12*48)
+	for i := range x {
+		out = append(out, x[i].c0.bytes()...)
+		out = append(out, x[i].c1.bytes()...)
+	}
+	return out
+}
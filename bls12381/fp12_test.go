@@ -0,0 +1,69 @@
+package bls12381
+
+import (
+	"math/big"
+	"testing"
+)
+
+func randomFe12(t *testing.T) *fe12 {
+	var x fe12
+	for i := range x {
+		x[i] = *randomFe2(t)
+	}
+	return &x
+}
+
+func TestFp12Arithmetic(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		x, y := randomFe12(t), randomFe12(t)
+		var a, b fe12
+		if fe12Equal(fe12Mul(&a, x, y), fe12Mul(&b, y, x)) != 1 {
+			t.Errorf("multiplication isn't commutative")
+		}
+		if fe12Equal(fe12Mul(&a, x, fe12Invert(&b, x)), fe12One()) != 1 {
+			t.Errorf("x / x != 1")
+		}
+		// The Frobenius map is the exponentiation by p.
+		if fe12Equal(fe12Frobenius(&a, x), fe12Exp(&b, x, pBig)) != 1 {
+			t.Errorf("frobenius(x) != x^p")
+		}
+		a = *x
+		for j := 0; j < 6; j++ {
+			fe12Frobenius(&a, &a)
+		}
+		if fe12Equal(&a, fe12Conj(&b, x)) != 1 {
+			t.Errorf("frobenius⁶(x) != conj(x)")
+		}
+		l0, l2, l3 := randomFe2(t), randomFe2(t), randomFe2(t)
+		var line fe12
+		line[0], line[2], line[3] = *l0, *l2, *l3
+		if fe12Equal(fe12MulLine(&a, x, l0, l2, l3), fe12Mul(&b, x, &line)) != 1 {
+			t.Errorf("sparse multiplication is wrong")
+		}
+	}
+}
+
+func TestParameters(t *testing.T) {
+	x := new(big.Int).SetUint64(xAbs)
+	x.Neg(x)
+	x2 := new(big.Int).Mul(x, x)
+	// r = x⁴ - x² + 1
+	r := new(big.Int).Mul(x2, x2)
+	r.Sub(r, x2)
+	r.Add(r, big.NewInt(1))
+	if r.Cmp(orderBig) != 0 {
+		t.Errorf("r != x⁴ - x² + 1")
+	}
+	// p = (x - 1)² r / 3 + x
+	p := new(big.Int).Sub(x, big.NewInt(1))
+	p.Mul(p, p)
+	p.Mul(p, r)
+	p.Div(p, big.NewInt(3))
+	p.Add(p, x)
+	if p.Cmp(pBig) != 0 {
+		t.Errorf("p != (x - 1)² r / 3 + x")
+	}
+	if new(big.Int).SetBytes(Order.Bytes()).Cmp(orderBig) != 0 {
+		t.Errorf("Order is wrong")
+	}
+}
@@ -0,0 +1,170 @@
+package bls12381
+
+import "math/big"
+
+// fe2 is an element c0 + c1 u of the quadratic extension Fp2 = Fp[u] / (u² + 1).
+type fe2 struct {
+	c0, c1 fe
+}
+
+// (p - 3) / 4, used by fe2Sqrt
+var pMinus3Over4 = new(big.Int).Rsh(new(big.Int).Sub(pBig, big.NewInt(3)), 2)
+
+func fe2One() *fe2 {
+	return &fe2{c0: rOne}
+}
+
+func fe2Add(z, x, y *fe2) *fe2 {
+	feAdd(&z.c0, &x.c0, &y.c0)
+	feAdd(&z.c1, &x.c1, &y.c1)
+	return z
+}
+
+func fe2Sub(z, x, y *fe2) *fe2 {
+	feSub(&z.c0, &x.c0, &y.c0)
+	feSub(&z.c1, &x.c1, &y.c1)
+	return z
+}
+
+func fe2Neg(z, x *fe2) *fe2 {
+	feNeg(&z.c0, &x.c0)
+	feNeg(&z.c1, &x.c1)
+	return z
+}
+
+// fe2Conj sets z to the conjugate c0 - c1 u of x, which is x^p.
+func fe2Conj(z, x *fe2) *fe2 {
+	z.c0 = x.c0
+	feNeg(&z.c1, &x.c1)
+	return z
+}
+
+// fe2Mul sets z = x * y, using Karatsuba multiplication.
+func fe2Mul(z, x, y *fe2) *fe2 {
+	var t0, t1, t2, t3 fe
+	feMul(&t0, &x.c0, &y.c0)
+	feMul(&t1, &x.c1, &y.c1)
+	feAdd(&t2, &x.c0, &x.c1)
+	feAdd(&t3, &y.c0, &y.c1)
+	feMul(&t2, &t2, &t3)
+	// (x0 + x1)(y0 + y1) - x0 y0 - x1 y1 = x0 y1 + x1 y0
+	feSub(&t2, &t2, &t0)
+	feSub(&z.c1, &t2, &t1)
+	feSub(&z.c0, &t0, &t1)
+	return z
+}
+
+func fe2Square(z, x *fe2) *fe2 {
+	return fe2Mul(z, x, x)
+}
+
+// fe2MulFe sets z = x * y, for y in Fp.
+func fe2MulFe(z, x *fe2, y *fe) *fe2 {
+	feMul(&z.c0, &x.c0, y)
+	feMul(&z.c1, &x.c1, y)
+	return z
+}
+
+// fe2MulXi sets z = x * ξ, where ξ = 1 + u is the non-residue used to build
+// the extensions above Fp2.
+func fe2MulXi(z, x *fe2) *fe2 {
+	var t fe
+	feSub(&t, &x.c0, &x.c1)
+	feAdd(&z.c1, &x.c0, &x.c1)
+	z.c0 = t
+	return z
+}
+
+// fe2Invert sets z = 1 / x, or 0 if x = 0.
+func fe2Invert(z, x *fe2) *fe2 {
+	// 1 / (c0 + c1 u) = (c0 - c1 u) / (c0² + c1²)
+	var t0, t1 fe
+	feSquare(&t0, &x.c0)
+	feSquare(&t1, &x.c1)
+	feAdd(&t0, &t0, &t1)
+	feInvert(&t0, &t0)
+	feMul(&z.c0, &x.c0, &t0)
+	feMul(&t1, &x.c1, &t0)
+	feNeg(&z.c1, &t1)
+	return z
+}
+
+// fe2Exp sets z = x^e, for a public exponent e.
+func fe2Exp(z, x *fe2, e *big.Int) *fe2 {
+	out := *fe2One()
+	base := *x
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		fe2Square(&out, &out)
+		if e.Bit(i) == 1 {
+			fe2Mul(&out, &out, &base)
+		}
+	}
+	*z = out
+	return z
+}
+
+func fe2Equal(x, y *fe2) int {
+	return feEqual(&x.c0, &y.c0) & feEqual(&x.c1, &y.c1)
+}
+
+func fe2IsZero(x *fe2) int {
+	return feIsZero(&x.c0) & feIsZero(&x.c1)
+}
+
+func fe2Select(z, a, b *fe2, v int) *fe2 {
+	feSelect(&z.c0, &a.c0, &b.c0, v)
+	feSelect(&z.c1, &a.c1, &b.c1, v)
+	return z
+}
+
+// fe2Sqrt sets z to a square root of x, returning 1 if x is a square, and 0
+// otherwise, using algorithm 9 of Adj and Rodríguez-Henríquez, "Square root
+// computation over even extension fields", for p ≡ 3 mod 4.
+func fe2Sqrt(z, x *fe2) int {
+	var a1, alpha, x0, root fe2
+	fe2Exp(&a1, x, pMinus3Over4)
+	fe2Mul(&alpha, &a1, &a1)
+	fe2Mul(&alpha, &alpha, x)
+	fe2Mul(&x0, &a1, x)
+
+	// If α = -1, the root is u x0, otherwise it's (1 + α)^((p - 1) / 2) x0.
+	var minusOne fe2
+	fe2Neg(&minusOne, fe2One())
+	isMinusOne := fe2Equal(&alpha, &minusOne)
+	var ux0, b fe2
+	feNeg(&ux0.c0, &x0.c1)
+	ux0.c1 = x0.c0
+	fe2Add(&b, &alpha, fe2One())
+	fe2Exp(&b, &b, new(big.Int).Rsh(pBig, 1))
+	fe2Mul(&b, &b, &x0)
+	fe2Select(&root, &ux0, &b, isMinusOne)
+
+	var check fe2
+	fe2Square(&check, &root)
+	*z = root
+	return fe2Equal(&check, x)
+}
+
+// fe2IsLarge returns 1 if x is lexicographically larger than -x, comparing c1
+// first, and then c0, as used to encode the sign of points, and 0 otherwise.
+func fe2IsLarge(x *fe2) int {
+	c1Zero := feIsZero(&x.c1)
+	return (c1Zero & feIsLarge(&x.c0)) | ((1 ^ c1Zero) & feIsLarge(&x.c1))
+}
+
+// fe2FromBytes decodes an element of Fp2 encoded as c1 || c0, with each
+// coefficient encoded as 48 big-endian bytes, returning false if either isn't
+// reduced modulo p.
+func fe2FromBytes(data []byte) (*fe2, bool) {
+	c1, ok1 := feFromBytes(data[:48])
+	c0, ok0 := feFromBytes(data[48:96])
+	if !ok0 || !ok1 {
+		return nil, false
+	}
+	return &fe2{*c0, *c1}, true
+}
+
+// bytes returns the 96 byte encoding c1 || c0 of x.
+func (x *fe2) bytes() []byte {
+	return append(x.c1.bytes(), x.c0.bytes()...)
+}
@@ -0,0 +1,63 @@
+package bls12381
+
+import (
+	"testing"
+)
+
+func randomFe2(t *testing.T) *fe2 {
+	c0, _ := randomFe(t)
+	c1, _ := randomFe(t)
+	return &fe2{*c0, *c1}
+}
+
+func TestFp2Arithmetic(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		x, y, w := randomFe2(t), randomFe2(t), randomFe2(t)
+		var a, b, c fe2
+		// x (y + w) = x y + x w
+		fe2Mul(&a, x, fe2Add(&c, y, w))
+		fe2Add(&b, fe2Mul(&b, x, y), fe2Mul(&c, x, w))
+		if fe2Equal(&a, &b) != 1 {
+			t.Errorf("multiplication doesn't distribute")
+		}
+		if fe2Equal(fe2Mul(&a, x, fe2Invert(&b, x)), fe2One()) != 1 {
+			t.Errorf("x / x != 1")
+		}
+		// x^p is the conjugate
+		if fe2Equal(fe2Exp(&a, x, pBig), fe2Conj(&b, x)) != 1 {
+			t.Errorf("x^p != conj(x)")
+		}
+		// x ξ = x (1 + u)
+		xi := fe2{rOne, rOne}
+		if fe2Equal(fe2MulXi(&a, x), fe2Mul(&b, x, &xi)) != 1 {
+			t.Errorf("x ξ is wrong")
+		}
+	}
+	// u² = -1
+	u := fe2{c1: rOne}
+	var a, minusOne fe2
+	fe2Neg(&minusOne, fe2One())
+	if fe2Equal(fe2Square(&a, &u), &minusOne) != 1 {
+		t.Errorf("u² != -1")
+	}
+}
+
+func TestFp2Sqrt(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		x := randomFe2(t)
+		var square, root, check fe2
+		fe2Square(&square, x)
+		if fe2Sqrt(&root, &square) != 1 {
+			t.Fatalf("square has no root")
+		}
+		if fe2Equal(fe2Square(&check, &root), &square) != 1 {
+			t.Errorf("sqrt(x²)² != x²")
+		}
+	}
+	// ξ = 1 + u is not a square.
+	xi := fe2{rOne, rOne}
+	var root fe2
+	if fe2Sqrt(&root, &xi) != 0 {
+		t.Errorf("ξ has a square root")
+	}
+}
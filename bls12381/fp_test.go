@@ -0,0 +1,125 @@
+package bls12381
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func randomFe(t *testing.T) (*fe, *big.Int) {
+	x, err := rand.Int(rand.Reader, pBig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return feFromBig(x), x
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		x, xBig := randomFe(t)
+		y, yBig := randomFe(t)
+		var z fe
+
+		expected := new(big.Int).Add(xBig, yBig)
+		if feAdd(&z, x, y).big().Cmp(expected.Mod(expected, pBig)) != 0 {
+			t.Errorf("x + y is wrong")
+		}
+		expected = new(big.Int).Sub(xBig, yBig)
+		if feSub(&z, x, y).big().Cmp(expected.Mod(expected, pBig)) != 0 {
+			t.Errorf("x - y is wrong")
+		}
+		expected = new(big.Int).Mul(xBig, yBig)
+		if feMul(&z, x, y).big().Cmp(expected.Mod(expected, pBig)) != 0 {
+			t.Errorf("x * y is wrong")
+		}
+		expected = new(big.Int).ModInverse(xBig, pBig)
+		if feInvert(&z, x).big().Cmp(expected) != 0 {
+			t.Errorf("1 / x is wrong")
+		}
+		expected = new(big.Int).Neg(xBig)
+		if feNeg(&z, x).big().Cmp(expected.Mod(expected, pBig)) != 0 {
+			t.Errorf("-x is wrong")
+		}
+	}
+}
+
+func TestFieldEdgeCases(t *testing.T) {
+	pMinus1 := feFromBig(new(big.Int).Sub(pBig, big.NewInt(1)))
+	one := feFromUint64(1)
+	var z fe
+	if feIsZero(feAdd(&z, pMinus1, one)) != 1 {
+		t.Errorf("(p - 1) + 1 != 0")
+	}
+	if feEqual(feMul(&z, pMinus1, pMinus1), one) != 1 {
+		t.Errorf("(-1)² != 1")
+	}
+	var zero fe
+	if feIsZero(feNeg(&z, &zero)) != 1 {
+		t.Errorf("-0 != 0")
+	}
+	if feIsZero(feInvert(&z, &zero)) != 1 {
+		t.Errorf("1 / 0 != 0")
+	}
+}
+
+func TestFieldSqrt(t *testing.T) {
+	squares := 0
+	for i := 0; i < 50; i++ {
+		x, xBig := randomFe(t)
+		var root, check fe
+		ok := feSqrt(&root, x)
+		isSquare := big.Jacobi(xBig, pBig) >= 0
+		if (ok == 1) != isSquare {
+			t.Fatalf("feSqrt disagrees with the Jacobi symbol")
+		}
+		if ok == 1 {
+			squares++
+			if feEqual(feSquare(&check, &root), x) != 1 {
+				t.Errorf("sqrt(x)² != x")
+			}
+		}
+	}
+	if squares == 0 {
+		t.Errorf("no squares found")
+	}
+}
+
+func TestFieldEncoding(t *testing.T) {
+	x, _ := randomFe(t)
+	decoded, ok := feFromBytes(x.bytes())
+	if !ok || feEqual(decoded, x) != 1 {
+		t.Errorf("field element doesn't round trip")
+	}
+	if _, ok := feFromBytes(pBig.Bytes()); ok {
+		t.Errorf("accepted p")
+	}
+}
+
+func TestFieldIsLarge(t *testing.T) {
+	half := new(big.Int).Rsh(pBig, 1)
+	for _, x := range []*big.Int{big.NewInt(0), big.NewInt(1), half, new(big.Int).Add(half, big.NewInt(1)), new(big.Int).Sub(pBig, big.NewInt(1))} {
+		expected := 0
+		if x.Cmp(half) > 0 {
+			expected = 1
+		}
+		if feIsLarge(feFromBig(x)) != expected {
+			t.Errorf("feIsLarge(%x) != %d", x, expected)
+		}
+	}
+}
+
+func TestFieldFromWideBytes(t *testing.T) {
+	buf := make([]byte, 64)
+	for i := 0; i < 20; i++ {
+		rand.Read(buf)
+		if i == 0 {
+			for j := range buf {
+				buf[j] = 0xff
+			}
+		}
+		expected := new(big.Int).Mod(new(big.Int).SetBytes(buf), pBig)
+		if feFromWideBytes(buf).big().Cmp(expected) != 0 {
+			t.Errorf("feFromWideBytes(%x) is wrong", buf)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package bls12381 implements the BLS12-381 pairing-friendly curve, with its
+// groups G1, G2, and GT, and the optimal ate pairing between them.
+//
+// Points of G1 and G2 use the compressed and uncompressed encodings of Zcash,
+// which are also used by Ethereum, and most other implementations. Scalars are
+// represented as safenum.Nat values, reduced modulo Order.
+//
+// Field arithmetic is done on fixed-size limbs, in constant-time, and scalar
+// multiplication doesn't leak the scalar. The pairing is meant to be used on
+// public values, like signatures and commitments.
+package bls12381
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// The sizes of the encodings of points of G1.
+const (
+	G1CompressedSize   = 48
+	G1UncompressedSize = 96
+)
+
+// The flags set in the first byte of an encoded point, following the format
+// used by Zcash, and most other implementations of BLS12-381.
+const (
+	flagCompressed = 0x80
+	flagInfinity   = 0x40
+	flagSign       = 0x20
+	flagMask       = flagCompressed | flagInfinity | flagSign
+)
+
+// G1 is a point of the subgroup of order r of the curve y² = x³ + 4 over Fp,
+// in projective coordinates (X : Y : Z), with x = X / Z, and y = Y / Z.
+//
+// The zero value is not valid, and points should be created with one of the
+// constructors, or with new(G1).Set. Like the types of math/big, methods set
+// their receiver to the result, and return it.
+type G1 struct {
+	x, y, z fe
+}
+
+var (
+	// 3 b = 12, for the addition formulas
+	g1B3 fe
+	// b = 4
+	g1B         fe
+	g1Generator G1
+)
+
+func init() {
+	g1B = *feFromUint64(4)
+	g1B3 = *feFromUint64(12)
+	data, _ := hex.DecodeString("97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb")
+	if _, err := g1Generator.SetBytes(data); err != nil {
+		panic(err)
+	}
+}
+
+// NewG1Identity returns a new point set to the identity.
+func NewG1Identity() *G1 {
+	return &G1{y: rOne}
+}
+
+// NewG1Generator returns a new point set to the standard generator of G1.
+func NewG1Generator() *G1 {
+	return new(G1).Set(&g1Generator)
+}
+
+// Set sets v = u, and returns v.
+func (v *G1) Set(u *G1) *G1 {
+	*v = *u
+	return v
+}
+
+// Add sets v = p + q, and returns v.
+func (v *G1) Add(p, q *G1) *G1 {
+	// This is algorithm 7 of Renes, Costello, and Batina, "Complete addition
+	// formulas for prime order elliptic curves", which works for any pair of
+	// points, including doublings, and the identity.
+	var t0, t1, t2, t3, t4, x3, y3, z3 fe
+	feMul(&t0, &p.x, &q.x)
+	feMul(&t1, &p.y, &q.y)
+	feMul(&t2, &p.z, &q.z)
+	feAdd(&t3, &p.x, &p.y)
+	feAdd(&t4, &q.x, &q.y)
+	feMul(&t3, &t3, &t4)
+	feAdd(&t4, &t0, &t1)
+	feSub(&t3, &t3, &t4)
+	feAdd(&t4, &p.y, &p.z)
+	feAdd(&x3, &q.y, &q.z)
+	feMul(&t4, &t4, &x3)
+	feAdd(&x3, &t1, &t2)
+	feSub(&t4, &t4, &x3)
+	feAdd(&x3, &p.x, &p.z)
+	feAdd(&y3, &q.x, &q.z)
+	feMul(&x3, &x3, &y3)
+	feAdd(&y3, &t0, &t2)
+	feSub(&y3, &x3, &y3)
+	feAdd(&x3, &t0, &t0)
+	feAdd(&t0, &x3, &t0)
+	feMul(&t2, &g1B3, &t2)
+	feAdd(&z3, &t1, &t2)
+	feSub(&t1, &t1, &t2)
+	feMul(&y3, &g1B3, &y3)
+	feMul(&x3, &t4, &y3)
+	feMul(&t2, &t3, &t1)
+	feSub(&x3, &t2, &x3)
+	feMul(&y3, &y3, &t0)
+	feMul(&t1, &t1, &z3)
+	feAdd(&y3, &t1, &y3)
+	feMul(&t0, &t0, &t3)
+	feMul(&z3, &z3, &t4)
+	feAdd(&z3, &z3, &t0)
+	v.x, v.y, v.z = x3, y3, z3
+	return v
+}
+
+// Negate sets v = -p, and returns v.
+func (v *G1) Negate(p *G1) *G1 {
+	v.x = p.x
+	feNeg(&v.y, &p.y)
+	v.z = p.z
+	return v
+}
+
+// Subtract sets v = p - q, and returns v.
+func (v *G1) Subtract(p, q *G1) *G1 {
+	neg := new(G1).Negate(q)
+	return v.Add(p, neg)
+}
+
+// Equal returns 1 if v and u are equal, and 0 otherwise, without leaking which.
+func (v *G1) Equal(u *G1) int {
+	// X1 Z2 = X2 Z1 and Y1 Z2 = Y2 Z1
+	var a, b, c, d fe
+	feMul(&a, &v.x, &u.z)
+	feMul(&b, &u.x, &v.z)
+	feMul(&c, &v.y, &u.z)
+	feMul(&d, &u.y, &v.z)
+	return feEqual(&a, &b) & feEqual(&c, &d)
+}
+
+// IsIdentity returns 1 if v is the identity, and 0 otherwise.
+func (v *G1) IsIdentity() int {
+	return feIsZero(&v.z)
+}
+
+// selectPoint sets v to a if cond == 1, and to b if cond == 0, without
+// leaking cond.
+func (v *G1) selectPoint(a, b *G1, cond int) *G1 {
+	feSelect(&v.x, &a.x, &b.x, cond)
+	feSelect(&v.y, &a.y, &b.y, cond)
+	feSelect(&v.z, &a.z, &b.z, cond)
+	return v
+}
+
+// ScalarMult sets v = s * q, and returns v.
+//
+// The scalar must be reduced modulo Order. The execution time doesn't depend
+// on the value of s.
+func (v *G1) ScalarMult(s *safenum.Nat, q *G1) *G1 {
+	k := ScalarBytes(s)
+	acc := NewG1Identity()
+	sum := new(G1)
+	base := new(G1).Set(q)
+	for i := 8*ScalarSize - 1; i >= 0; i-- {
+		acc.Add(acc, acc)
+		sum.Add(acc, base)
+		acc.selectPoint(sum, acc, int(k[ScalarSize-1-i/8]>>(i%8))&1)
+	}
+	return v.Set(acc)
+}
+
+// ScalarBaseMult sets v = s * G, where G is the standard generator, and
+// returns v.
+func (v *G1) ScalarBaseMult(s *safenum.Nat) *G1 {
+	return v.ScalarMult(s, &g1Generator)
+}
+
+// mulBig sets v = k * q, for a public, non-negative k, and returns v.
+func (v *G1) mulBig(k *big.Int, q *G1) *G1 {
+	acc := NewG1Identity()
+	base := new(G1).Set(q)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		acc.Add(acc, acc)
+		if k.Bit(i) == 1 {
+			acc.Add(acc, base)
+		}
+	}
+	return v.Set(acc)
+}
+
+// affine returns the affine coordinates of v, or (0, 0) for the identity.
+func (v *G1) affine() (x, y fe) {
+	var zInv fe
+	feInvert(&zInv, &v.z)
+	feMul(&x, &v.x, &zInv)
+	feMul(&y, &v.y, &zInv)
+	return x, y
+}
+
+// Bytes returns the compressed encoding of v, in G1CompressedSize bytes.
+func (v *G1) Bytes() []byte {
+	x, y := v.affine()
+	out := x.bytes()
+	infinity := v.IsIdentity()
+	out[0] |= flagCompressed
+	out[0] |= byte(-infinity) & flagInfinity
+	out[0] |= byte(-((1 ^ infinity) & feIsLarge(&y))) & flagSign
+	return out
+}
+
+// BytesUncompressed returns the uncompressed encoding of v, in
+// G1UncompressedSize bytes.
+func (v *G1) BytesUncompressed() []byte {
+	x, y := v.affine()
+	out := append(x.bytes(), y.bytes()...)
+	out[0] |= byte(-v.IsIdentity()) & flagInfinity
+	return out
+}
+
+// SetBytes sets v to the point encoded by data, in either the compressed or
+// the uncompressed format, and returns v.
+//
+// An error is returned if the encoding is malformed, or if it isn't a point
+// of the subgroup of order r. In that case, v is left unchanged.
+func (v *G1) SetBytes(data []byte) (*G1, error) {
+	if len(data) != G1CompressedSize && len(data) != G1UncompressedSize {
+		return nil, errors.New("bls12381: invalid G1 encoding length")
+	}
+	compressed := len(data) == G1CompressedSize
+	flags := data[0] & flagMask
+	if (flags&flagCompressed != 0) != compressed {
+		return nil, errors.New("bls12381: invalid G1 compression flag")
+	}
+	buf := append([]byte{}, data...)
+	buf[0] &^= flagMask
+
+	if flags&flagInfinity != 0 {
+		if flags&flagSign != 0 || !allZero(buf) {
+			return nil, errors.New("bls12381: invalid encoding of the identity")
+		}
+		return v.Set(NewG1Identity()), nil
+	}
+
+	x, ok := feFromBytes(buf[:48])
+	if !ok {
+		return nil, errors.New("bls12381: invalid G1 coordinate")
+	}
+	var rhs, y fe
+	feSquare(&rhs, x)
+	feMul(&rhs, &rhs, x)
+	feAdd(&rhs, &rhs, &g1B)
+	if compressed {
+		if feSqrt(&y, &rhs) != 1 {
+			return nil, errors.New("bls12381: invalid G1 point")
+		}
+		if (flags&flagSign != 0) != (feIsLarge(&y) == 1) {
+			feNeg(&y, &y)
+		}
+	} else {
+		if flags&flagSign != 0 {
+			return nil, errors.New("bls12381: invalid G1 sign flag")
+		}
+		yp, ok := feFromBytes(buf[48:])
+		if !ok {
+			return nil, errors.New("bls12381: invalid G1 coordinate")
+		}
+		y = *yp
+		var y2 fe
+		if feEqual(feSquare(&y2, &y), &rhs) != 1 {
+			return nil, errors.New("bls12381: invalid G1 point")
+		}
+	}
+
+	p := &G1{*x, y, rOne}
+	if !p.inSubgroup() {
+		return nil, errors.New("bls12381: G1 point not in the prime order subgroup")
+	}
+	return v.Set(p), nil
+}
+
+// inSubgroup reports whether v is in the subgroup of order r.
+func (v *G1) inSubgroup() bool {
+	return new(G1).mulBig(orderBig, v).IsIdentity() == 1
+}
+
+func allZero(data []byte) bool {
+	var acc byte
+	for _, b := range data {
+		acc |= b
+	}
+	return acc == 0
+}
@@ -0,0 +1,114 @@
+package bls12381
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func randomScalar(t *testing.T) *safenum.Nat {
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), Order)
+}
+
+func TestG1GeneratorEncoding(t *testing.T) {
+	expected := "97f1d3a73197d7942695638c4fa9ac0fc3688c4f9774b905a14e3a3f171bac586c55e83ff97a1aeffb3af00adb22c6bb"
+	if out := hex.EncodeToString(NewG1Generator().Bytes()); out != expected {
+		t.Errorf("generator encodes to %s, expected %s", out, expected)
+	}
+	// 2 G, from the reference implementation.
+	two := new(safenum.Nat).SetUint64(2)
+	expected = "a572cbea904d67468808c8eb50a9450c9721db309128012543902d0ac358a62ae28f75bb8f1c7c42c39a8c5529bf0f4e"
+	if out := hex.EncodeToString(new(G1).ScalarBaseMult(two).Bytes()); out != expected {
+		t.Errorf("2 G encodes to %s, expected %s", out, expected)
+	}
+	if out := hex.EncodeToString(NewG1Identity().Bytes()); out != "c0"+hex.EncodeToString(make([]byte, 47)) {
+		t.Errorf("identity encodes to %s", out)
+	}
+}
+
+func TestG1GroupLaws(t *testing.T) {
+	a, b := randomScalar(t), randomScalar(t)
+	aG := new(G1).ScalarBaseMult(a)
+	bG := new(G1).ScalarBaseMult(b)
+	sum := new(G1).ScalarBaseMult(new(safenum.Nat).ModAdd(a, b, Order))
+	if new(G1).Add(aG, bG).Equal(sum) != 1 {
+		t.Errorf("a G + b G != (a + b) G")
+	}
+	if new(G1).Add(aG, aG).Equal(new(G1).ScalarMult(new(safenum.Nat).SetUint64(2), aG)) != 1 {
+		t.Errorf("a G + a G != 2 (a G)")
+	}
+	if new(G1).Subtract(aG, aG).IsIdentity() != 1 {
+		t.Errorf("a G - a G != 0")
+	}
+	if new(G1).Add(aG, NewG1Identity()).Equal(aG) != 1 {
+		t.Errorf("a G + 0 != a G")
+	}
+	minusOne := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(1), Order)
+	if new(G1).Add(new(G1).ScalarBaseMult(minusOne), NewG1Generator()).IsIdentity() != 1 {
+		t.Errorf("(r - 1) G + G != 0")
+	}
+}
+
+func TestG1Encoding(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		p := new(G1).ScalarBaseMult(randomScalar(t))
+		if i == 0 {
+			p = NewG1Identity()
+		}
+		for _, data := range [][]byte{p.Bytes(), p.BytesUncompressed()} {
+			q, err := new(G1).SetBytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Equal(p) != 1 {
+				t.Errorf("SetBytes(%x) decoded a different point", data)
+			}
+		}
+		// Flipping the sign negates the point.
+		data := p.Bytes()
+		data[0] ^= flagSign
+		if q, err := new(G1).SetBytes(data); err == nil && q.Equal(new(G1).Negate(p)) != 1 {
+			t.Errorf("flipping the sign didn't negate the point")
+		}
+	}
+}
+
+func TestG1SetBytesRejects(t *testing.T) {
+	g := NewG1Generator()
+	compressed := g.Bytes()
+	uncompressed := g.BytesUncompressed()
+
+	bad := [][]byte{
+		nil,
+		compressed[:47],
+		// missing compression flag
+		append([]byte{compressed[0] &^ flagCompressed}, compressed[1:]...),
+		// compression flag on an uncompressed point
+		append([]byte{uncompressed[0] | flagCompressed}, uncompressed[1:]...),
+		// infinity with a non-zero coordinate
+		append([]byte{compressed[0] | flagInfinity}, compressed[1:]...),
+		// a coordinate larger than p
+		append([]byte{0x9f}, bytes.Repeat([]byte{0xff}, 47)...),
+	}
+	// a point not on the curve
+	offCurve := append([]byte{}, uncompressed...)
+	offCurve[95] ^= 1
+	bad = append(bad, offCurve)
+	// a point on the curve, but not in the subgroup: (0, 2) has order 3
+	notInSubgroup := make([]byte, 96)
+	notInSubgroup[95] = 2
+	bad = append(bad, notInSubgroup)
+
+	for _, data := range bad {
+		if _, err := new(G1).SetBytes(data); err == nil {
+			t.Errorf("SetBytes(%x) succeeded", data)
+		}
+	}
+}
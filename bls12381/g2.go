@@ -0,0 +1,275 @@
+package bls12381
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// The sizes of the encodings of points of G2.
+const (
+	G2CompressedSize   = 96
+	G2UncompressedSize = 192
+)
+
+// G2 is a point of the subgroup of order r of the curve y² = x³ + 4 (1 + u)
+// over Fp2, which is a sextic twist of the curve of G1, in projective
+// coordinates (X : Y : Z), with x = X / Z, and y = Y / Z.
+//
+// Coordinates are encoded as c1 || c0, so the flags are stored in the first
+// byte of c1.
+//
+// The zero value is not valid, and points should be created with one of the
+// constructors, or with new(G2).Set. Like the types of math/big, methods set
+// their receiver to the result, and return it.
+type G2 struct {
+	x, y, z fe2
+}
+
+var (
+	// 3 b = 12 (1 + u), for the addition formulas
+	g2B3 fe2
+	// b = 4 (1 + u)
+	g2B         fe2
+	g2Generator G2
+)
+
+func init() {
+	g2B = fe2{*feFromUint64(4), *feFromUint64(4)}
+	g2B3 = fe2{*feFromUint64(12), *feFromUint64(12)}
+	data, _ := hex.DecodeString("93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8")
+	if _, err := g2Generator.SetBytes(data); err != nil {
+		panic(err)
+	}
+}
+
+// NewG2Identity returns a new point set to the identity.
+func NewG2Identity() *G2 {
+	return &G2{y: *fe2One()}
+}
+
+// NewG2Generator returns a new point set to the standard generator of G2.
+func NewG2Generator() *G2 {
+	return new(G2).Set(&g2Generator)
+}
+
+// Set sets v = u, and returns v.
+func (v *G2) Set(u *G2) *G2 {
+	*v = *u
+	return v
+}
+
+// Add sets v = p + q, and returns v.
+func (v *G2) Add(p, q *G2) *G2 {
+	// This is algorithm 7 of Renes, Costello, and Batina, "Complete addition
+	// formulas for prime order elliptic curves", which works for any pair of
+	// points, including doublings, and the identity.
+	var t0, t1, t2, t3, t4, x3, y3, z3 fe2
+	fe2Mul(&t0, &p.x, &q.x)
+	fe2Mul(&t1, &p.y, &q.y)
+	fe2Mul(&t2, &p.z, &q.z)
+	fe2Add(&t3, &p.x, &p.y)
+	fe2Add(&t4, &q.x, &q.y)
+	fe2Mul(&t3, &t3, &t4)
+	fe2Add(&t4, &t0, &t1)
+	fe2Sub(&t3, &t3, &t4)
+	fe2Add(&t4, &p.y, &p.z)
+	fe2Add(&x3, &q.y, &q.z)
+	fe2Mul(&t4, &t4, &x3)
+	fe2Add(&x3, &t1, &t2)
+	fe2Sub(&t4, &t4, &x3)
+	fe2Add(&x3, &p.x, &p.z)
+	fe2Add(&y3, &q.x, &q.z)
+	fe2Mul(&x3, &x3, &y3)
+	fe2Add(&y3, &t0, &t2)
+	fe2Sub(&y3, &x3, &y3)
+	fe2Add(&x3, &t0, &t0)
+	fe2Add(&t0, &x3, &t0)
+	fe2Mul(&t2, &g2B3, &t2)
+	fe2Add(&z3, &t1, &t2)
+	fe2Sub(&t1, &t1, &t2)
+	fe2Mul(&y3, &g2B3, &y3)
+	fe2Mul(&x3, &t4, &y3)
+	fe2Mul(&t2, &t3, &t1)
+	fe2Sub(&x3, &t2, &x3)
+	fe2Mul(&y3, &y3, &t0)
+	fe2Mul(&t1, &t1, &z3)
+	fe2Add(&y3, &t1, &y3)
+	fe2Mul(&t0, &t0, &t3)
+	fe2Mul(&z3, &z3, &t4)
+	fe2Add(&z3, &z3, &t0)
+	v.x, v.y, v.z = x3, y3, z3
+	return v
+}
+
+// Negate sets v = -p, and returns v.
+func (v *G2) Negate(p *G2) *G2 {
+	v.x = p.x
+	fe2Neg(&v.y, &p.y)
+	v.z = p.z
+	return v
+}
+
+// Subtract sets v = p - q, and returns v.
+func (v *G2) Subtract(p, q *G2) *G2 {
+	neg := new(G2).Negate(q)
+	return v.Add(p, neg)
+}
+
+// Equal returns 1 if v and u are equal, and 0 otherwise, without leaking which.
+func (v *G2) Equal(u *G2) int {
+	// X1 Z2 = X2 Z1 and Y1 Z2 = Y2 Z1
+	var a, b, c, d fe2
+	fe2Mul(&a, &v.x, &u.z)
+	fe2Mul(&b, &u.x, &v.z)
+	fe2Mul(&c, &v.y, &u.z)
+	fe2Mul(&d, &u.y, &v.z)
+	return fe2Equal(&a, &b) & fe2Equal(&c, &d)
+}
+
+// IsIdentity returns 1 if v is the identity, and 0 otherwise.
+func (v *G2) IsIdentity() int {
+	return fe2IsZero(&v.z)
+}
+
+// selectPoint sets v to a if cond == 1, and to b if cond == 0, without
+// leaking cond.
+func (v *G2) selectPoint(a, b *G2, cond int) *G2 {
+	fe2Select(&v.x, &a.x, &b.x, cond)
+	fe2Select(&v.y, &a.y, &b.y, cond)
+	fe2Select(&v.z, &a.z, &b.z, cond)
+	return v
+}
+
+// ScalarMult sets v = s * q, and returns v.
+//
+// The scalar must be reduced modulo Order. The execution time doesn't depend
+// on the value of s.
+func (v *G2) ScalarMult(s *safenum.Nat, q *G2) *G2 {
+	k := ScalarBytes(s)
+	acc := NewG2Identity()
+	sum := new(G2)
+	base := new(G2).Set(q)
+	for i := 8*ScalarSize - 1; i >= 0; i-- {
+		acc.Add(acc, acc)
+		sum.Add(acc, base)
+		acc.selectPoint(sum, acc, int(k[ScalarSize-1-i/8]>>(i%8))&1)
+	}
+	return v.Set(acc)
+}
+
+// ScalarBaseMult sets v = s * G, where G is the standard generator, and
+// returns v.
+func (v *G2) ScalarBaseMult(s *safenum.Nat) *G2 {
+	return v.ScalarMult(s, &g2Generator)
+}
+
+// mulBig sets v = k * q, for a public, non-negative k, and returns v.
+func (v *G2) mulBig(k *big.Int, q *G2) *G2 {
+	acc := NewG2Identity()
+	base := new(G2).Set(q)
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		acc.Add(acc, acc)
+		if k.Bit(i) == 1 {
+			acc.Add(acc, base)
+		}
+	}
+	return v.Set(acc)
+}
+
+// affine returns the affine coordinates of v, or (0, 0) for the identity.
+func (v *G2) affine() (x, y fe2) {
+	var zInv fe2
+	fe2Invert(&zInv, &v.z)
+	fe2Mul(&x, &v.x, &zInv)
+	fe2Mul(&y, &v.y, &zInv)
+	return x, y
+}
+
+// Bytes returns the compressed encoding of v, in G2CompressedSize bytes.
+func (v *G2) Bytes() []byte {
+	x, y := v.affine()
+	out := x.bytes()
+	infinity := v.IsIdentity()
+	out[0] |= flagCompressed
+	out[0] |= byte(-infinity) & flagInfinity
+	out[0] |= byte(-((1 ^ infinity) & fe2IsLarge(&y))) & flagSign
+	return out
+}
+
+// BytesUncompressed returns the uncompressed encoding of v, in
+// G2UncompressedSize bytes.
+func (v *G2) BytesUncompressed() []byte {
+	x, y := v.affine()
+	out := append(x.bytes(), y.bytes()...)
+	out[0] |= byte(-v.IsIdentity()) & flagInfinity
+	return out
+}
+
+// SetBytes sets v to the point encoded by data, in either the compressed or
+// the uncompressed format, and returns v.
+//
+// An error is returned if the encoding is malformed, or if it isn't a point
+// of the subgroup of order r. In that case, v is left unchanged.
+func (v *G2) SetBytes(data []byte) (*G2, error) {
+	if len(data) != G2CompressedSize && len(data) != G2UncompressedSize {
+		return nil, errors.New("bls12381: invalid G2 encoding length")
+	}
+	compressed := len(data) == G2CompressedSize
+	flags := data[0] & flagMask
+	if (flags&flagCompressed != 0) != compressed {
+		return nil, errors.New("bls12381: invalid G2 compression flag")
+	}
+	buf := append([]byte{}, data...)
+	buf[0] &^= flagMask
+
+	if flags&flagInfinity != 0 {
+		if flags&flagSign != 0 || !allZero(buf) {
+			return nil, errors.New("bls12381: invalid encoding of the identity")
+		}
+		return v.Set(NewG2Identity()), nil
+	}
+
+	x, ok := fe2FromBytes(buf[:96])
+	if !ok {
+		return nil, errors.New("bls12381: invalid G2 coordinate")
+	}
+	var rhs, y fe2
+	fe2Square(&rhs, x)
+	fe2Mul(&rhs, &rhs, x)
+	fe2Add(&rhs, &rhs, &g2B)
+	if compressed {
+		if fe2Sqrt(&y, &rhs) != 1 {
+			return nil, errors.New("bls12381: invalid G2 point")
+		}
+		if (flags&flagSign != 0) != (fe2IsLarge(&y) == 1) {
+			fe2Neg(&y, &y)
+		}
+	} else {
+		if flags&flagSign != 0 {
+			return nil, errors.New("bls12381: invalid G2 sign flag")
+		}
+		yp, ok := fe2FromBytes(buf[96:])
+		if !ok {
+			return nil, errors.New("bls12381: invalid G2 coordinate")
+		}
+		y = *yp
+		var y2 fe2
+		if fe2Equal(fe2Square(&y2, &y), &rhs) != 1 {
+			return nil, errors.New("bls12381: invalid G2 point")
+		}
+	}
+
+	p := &G2{*x, y, *fe2One()}
+	if !p.inSubgroup() {
+		return nil, errors.New("bls12381: G2 point not in the prime order subgroup")
+	}
+	return v.Set(p), nil
+}
+
+// inSubgroup reports whether v is in the subgroup of order r.
+func (v *G2) inSubgroup() bool {
+	return new(G2).mulBig(orderBig, v).IsIdentity() == 1
+}
@@ -0,0 +1,83 @@
+package bls12381
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func TestG2GeneratorEncoding(t *testing.T) {
+	expected := "93e02b6052719f607dacd3a088274f65596bd0d09920b61ab5da61bbdc7f5049334cf11213945d57e5ac7d055d042b7e024aa2b2f08f0a91260805272dc51051c6e47ad4fa403b02b4510b647ae3d1770bac0326a805bbefd48056c8c121bdb8"
+	if out := hex.EncodeToString(NewG2Generator().Bytes()); out != expected {
+		t.Errorf("generator encodes to %s, expected %s", out, expected)
+	}
+	// 2 G, from the reference implementation.
+	two := new(safenum.Nat).SetUint64(2)
+	expected = "aa4edef9c1ed7f729f520e47730a124fd70662a904ba1074728114d1031e1572c6c886f6b57ec72a6178288c47c335771638533957d540a9d2370f17cc7ed5863bc0b995b8825e0ee1ea1e1e4d00dbae81f14b0bf3611b78c952aacab827a053"
+	if out := hex.EncodeToString(new(G2).ScalarBaseMult(two).Bytes()); out != expected {
+		t.Errorf("2 G encodes to %s, expected %s", out, expected)
+	}
+}
+
+func TestG2GroupLaws(t *testing.T) {
+	a, b := randomScalar(t), randomScalar(t)
+	aG := new(G2).ScalarBaseMult(a)
+	bG := new(G2).ScalarBaseMult(b)
+	sum := new(G2).ScalarBaseMult(new(safenum.Nat).ModAdd(a, b, Order))
+	if new(G2).Add(aG, bG).Equal(sum) != 1 {
+		t.Errorf("a G + b G != (a + b) G")
+	}
+	if new(G2).Subtract(aG, aG).IsIdentity() != 1 {
+		t.Errorf("a G - a G != 0")
+	}
+	if new(G2).Add(aG, NewG2Identity()).Equal(aG) != 1 {
+		t.Errorf("a G + 0 != a G")
+	}
+}
+
+func TestG2Encoding(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		p := new(G2).ScalarBaseMult(randomScalar(t))
+		if i == 0 {
+			p = NewG2Identity()
+		}
+		for _, data := range [][]byte{p.Bytes(), p.BytesUncompressed()} {
+			q, err := new(G2).SetBytes(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.Equal(p) != 1 {
+				t.Errorf("SetBytes(%x) decoded a different point", data)
+			}
+		}
+	}
+}
+
+func TestG2SetBytesRejects(t *testing.T) {
+	uncompressed := NewG2Generator().BytesUncompressed()
+	offCurve := append([]byte{}, uncompressed...)
+	offCurve[191] ^= 1
+	// Points of the twist with x = 1 aren't in the subgroup of order r, since
+	// the cofactor of G2 is large.
+	var notInSubgroup []byte
+	for x := uint64(1); notInSubgroup == nil; x++ {
+		data := make([]byte, 96)
+		data[95] = byte(x)
+		data[0] |= flagCompressed
+		var p G2
+		p.x = fe2{*feFromUint64(x), fe{}}
+		var rhs fe2
+		fe2Square(&rhs, &p.x)
+		fe2Mul(&rhs, &rhs, &p.x)
+		fe2Add(&rhs, &rhs, &g2B)
+		if fe2Sqrt(&p.y, &rhs) == 1 {
+			notInSubgroup = data
+		}
+	}
+	for _, data := range [][]byte{nil, uncompressed[:191], offCurve, notInSubgroup} {
+		if _, err := new(G2).SetBytes(data); err == nil {
+			t.Errorf("SetBytes(%x) succeeded", data)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package bls12381
+
+// This file implements hashing to G1 and G2.
+//
+// The standard suites of RFC 9380 for BLS12-381 map to curves isogenous to
+// those of G1 and G2, with the simplified SWU map, and then apply isogenies of
+// degree 11 and 3. Instead, we use the Shallue-van de Woestijne map of RFC
+// 9380, section 6.6.1, directly on each curve, with constants derived when the
+// package is initialized, and clear the cofactor by multiplying by it. This
+// is a valid hash to the curve, but its outputs differ from those of the
+// standard suites, so signatures built on top of it won't interoperate with
+// other implementations.
+
+import (
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+)
+
+// The identifiers of our hashing suites, in the style of RFC 9380, which
+// should be included in domain separation tags.
+const (
+	SuiteG1 = "BLS12381G1_XMD:SHA-256_SVDW_RO_"
+	SuiteG2 = "BLS12381G2_XMD:SHA-256_SVDW_RO_"
+)
+
+var (
+	// the constants c1 through c4 and Z of the map to G1
+	svdw1C1, svdw1C2, svdw1C3, svdw1C4, svdw1Z fe
+	// the same constants for the map to G2
+	svdw2C1, svdw2C2, svdw2C3, svdw2C4, svdw2Z fe2
+	// the cofactors of G1 and G2, so that #E = h r
+	cofactorG1, cofactorG2 *big.Int
+)
+
+func init() {
+	initSVDWG1()
+	initSVDWG2()
+	// h1 = (x - 1)² / 3
+	cofactorG1 = new(big.Int).SetUint64(xAbs)
+	cofactorG1.Add(cofactorG1, big.NewInt(1))
+	cofactorG1.Mul(cofactorG1, cofactorG1)
+	cofactorG1.Div(cofactorG1, big.NewInt(3))
+	cofactorG2 = twistCofactor()
+}
+
+// smallInteger returns the i-th integer in the sequence 1, -1, 2, -2, ...
+func smallInteger(i int) *fe {
+	out := feFromUint64(uint64(i/2 + 1))
+	if i%2 == 1 {
+		feNeg(out, out)
+	}
+	return out
+}
+
+// g1Polynomial returns x³ + 4.
+func g1Polynomial(x *fe) *fe {
+	var out fe
+	feSquare(&out, x)
+	feMul(&out, &out, x)
+	return feAdd(&out, &out, &g1B)
+}
+
+// g2Polynomial returns x³ + 4 (1 + u).
+func g2Polynomial(x *fe2) *fe2 {
+	var out fe2
+	fe2Square(&out, x)
+	fe2Mul(&out, &out, x)
+	return fe2Add(&out, &out, &g2B)
+}
+
+func feIsSquare(x *fe) int {
+	var root fe
+	return feSqrt(&root, x)
+}
+
+func fe2IsSquare(x *fe2) int {
+	var root fe2
+	return fe2Sqrt(&root, x)
+}
+
+// feSgn0 returns the parity of x, as defined in RFC 9380, section 4.1.
+func feSgn0(x *fe) int {
+	return int(x.canonical()[0] & 1)
+}
+
+// fe2Sgn0 returns the sign of x, as defined in RFC 9380, section 4.1.
+func fe2Sgn0(x *fe2) int {
+	return feSgn0(&x.c0) | (feIsZero(&x.c0) & feSgn0(&x.c1))
+}
+
+// initSVDWG1 finds the constants of the map to G1, following the procedure
+// of RFC 9380, appendix H.1, for A = 0.
+func initSVDWG1() {
+	four := *feFromUint64(4)
+	for i := 0; ; i++ {
+		z := smallInteger(i)
+		gz := g1Polynomial(z)
+		// -3 Z² / 4 g(Z) must be a non-zero square
+		var threeZ2, h, t fe
+		feSquare(&threeZ2, z)
+		feAdd(&t, &threeZ2, &threeZ2)
+		feAdd(&threeZ2, &t, &threeZ2)
+		feMul(&t, &four, gz)
+		feInvert(&t, &t)
+		feMul(&h, &threeZ2, &t)
+		feNeg(&h, &h)
+		var minusZOver2 fe
+		feInvert(&t, feFromUint64(2))
+		feMul(&minusZOver2, z, &t)
+		feNeg(&minusZOver2, &minusZOver2)
+		if feIsZero(gz) == 1 || feIsZero(&h) == 1 || feIsSquare(&h) == 0 {
+			continue
+		}
+		if feIsSquare(gz) == 0 && feIsSquare(g1Polynomial(&minusZOver2)) == 0 {
+			continue
+		}
+		svdw1Z = *z
+		svdw1C1 = *gz
+		svdw1C2 = minusZOver2
+		// c3 = sqrt(-g(Z) 3 Z²), with sgn0(c3) = 0
+		feMul(&t, gz, &threeZ2)
+		feNeg(&t, &t)
+		feSqrt(&svdw1C3, &t)
+		if feSgn0(&svdw1C3) == 1 {
+			feNeg(&svdw1C3, &svdw1C3)
+		}
+		// c4 = -4 g(Z) / 3 Z²
+		feInvert(&t, &threeZ2)
+		feMul(&svdw1C4, &four, gz)
+		feMul(&svdw1C4, &svdw1C4, &t)
+		feNeg(&svdw1C4, &svdw1C4)
+		return
+	}
+}
+
+// initSVDWG2 finds the constants of the map to G2, like initSVDWG1.
+func initSVDWG2() {
+	four := fe2{c0: *feFromUint64(4)}
+	var half fe
+	feInvert(&half, feFromUint64(2))
+	for i := 0; ; i++ {
+		z := fe2{c0: *smallInteger(i)}
+		gz := g2Polynomial(&z)
+		var threeZ2, h, t fe2
+		fe2Square(&threeZ2, &z)
+		fe2Add(&t, &threeZ2, &threeZ2)
+		fe2Add(&threeZ2, &t, &threeZ2)
+		fe2Mul(&t, &four, gz)
+		fe2Invert(&t, &t)
+		fe2Mul(&h, &threeZ2, &t)
+		fe2Neg(&h, &h)
+		var minusZOver2 fe2
+		fe2MulFe(&minusZOver2, &z, &half)
+		fe2Neg(&minusZOver2, &minusZOver2)
+		if fe2IsZero(gz) == 1 || fe2IsZero(&h) == 1 || fe2IsSquare(&h) == 0 {
+			continue
+		}
+		if fe2IsSquare(gz) == 0 && fe2IsSquare(g2Polynomial(&minusZOver2)) == 0 {
+			continue
+		}
+		svdw2Z = z
+		svdw2C1 = *gz
+		svdw2C2 = minusZOver2
+		fe2Mul(&t, gz, &threeZ2)
+		fe2Neg(&t, &t)
+		fe2Sqrt(&svdw2C3, &t)
+		if fe2Sgn0(&svdw2C3) == 1 {
+			fe2Neg(&svdw2C3, &svdw2C3)
+		}
+		fe2Invert(&t, &threeZ2)
+		fe2Mul(&svdw2C4, &four, gz)
+		fe2Mul(&svdw2C4, &svdw2C4, &t)
+		fe2Neg(&svdw2C4, &svdw2C4)
+		return
+	}
+}
+
+// twistCofactor returns the cofactor of G2 in the group of points of the
+// twist over Fp2.
+func twistCofactor() *big.Int {
+	// The trace of Frobenius of the curve over Fp is x + 1, and over Fp2,
+	// t2 = t² - 2 p. The sextic twists have traces (± t2 ± 3 f) / 2, or ± t2,
+	// where t2² - 4 p² = -3 f², and we pick the one whose order kills a
+	// point of the twist.
+	t := new(big.Int).SetUint64(xAbs)
+	t.Neg(t)
+	t.Add(t, big.NewInt(1))
+	p2 := new(big.Int).Mul(pBig, pBig)
+	t2 := new(big.Int).Mul(t, t)
+	t2.Sub(t2, new(big.Int).Lsh(pBig, 1))
+	f := new(big.Int).Lsh(p2, 2)
+	f.Sub(f, new(big.Int).Mul(t2, t2))
+	f.Div(f, big.NewInt(3))
+	f.Sqrt(f)
+	threeF := new(big.Int).Mul(f, big.NewInt(3))
+
+	var traces []*big.Int
+	for _, s := range []int64{1, -1} {
+		st2 := new(big.Int).Mul(t2, big.NewInt(s))
+		traces = append(traces, st2)
+		for _, u := range []int64{1, -1} {
+			tr := new(big.Int).Add(st2, new(big.Int).Mul(threeF, big.NewInt(u)))
+			traces = append(traces, tr.Rsh(tr, 1))
+		}
+	}
+	// A point of the twist, which is very unlikely to have small order.
+	x, y := mapToG2(&fe2{c0: *feFromUint64(1), c1: *feFromUint64(1)})
+	p := &G2{x, y, *fe2One()}
+	for _, tr := range traces {
+		n := new(big.Int).Add(p2, big.NewInt(1))
+		n.Sub(n, tr)
+		h, m := new(big.Int).DivMod(n, orderBig, new(big.Int))
+		if m.Sign() != 0 {
+			continue
+		}
+		if new(G2).mulBig(n, p).IsIdentity() == 1 {
+			return h
+		}
+	}
+	panic("bls12381: failed to find the cofactor of G2")
+}
+
+// mapToG1 is the Shallue-van de Woestijne map to the curve of G1, as defined
+// in RFC 9380, section 6.6.1, and returns the affine coordinates of a point.
+func mapToG1(u *fe) (x, y fe) {
+	var tv1, tv2, tv3, tv4, x1, x2, x3 fe
+	one := rOne
+	feSquare(&tv1, u)
+	feMul(&tv1, &tv1, &svdw1C1)
+	feAdd(&tv2, &one, &tv1)
+	feSub(&tv1, &one, &tv1)
+	feMul(&tv3, &tv1, &tv2)
+	feInvert(&tv3, &tv3)
+	feMul(&tv4, u, &tv1)
+	feMul(&tv4, &tv4, &tv3)
+	feMul(&tv4, &tv4, &svdw1C3)
+	feSub(&x1, &svdw1C2, &tv4)
+	gx1 := g1Polynomial(&x1)
+	e1 := feIsSquare(gx1)
+	feAdd(&x2, &svdw1C2, &tv4)
+	gx2 := g1Polynomial(&x2)
+	e2 := feIsSquare(gx2) & (1 ^ e1)
+	feSquare(&x3, &tv2)
+	feMul(&x3, &x3, &tv3)
+	feSquare(&x3, &x3)
+	feMul(&x3, &x3, &svdw1C4)
+	feAdd(&x3, &x3, &svdw1Z)
+	feSelect(&x, &x1, &x3, e1)
+	feSelect(&x, &x2, &x, e2)
+	feSqrt(&y, g1Polynomial(&x))
+	var negY fe
+	feNeg(&negY, &y)
+	feSelect(&y, &y, &negY, 1^(feSgn0(u)^feSgn0(&y)))
+	return x, y
+}
+
+// mapToG2 is the Shallue-van de Woestijne map to the twist, like mapToG1.
+func mapToG2(u *fe2) (x, y fe2) {
+	var tv1, tv2, tv3, tv4, x1, x2, x3 fe2
+	one := *fe2One()
+	fe2Square(&tv1, u)
+	fe2Mul(&tv1, &tv1, &svdw2C1)
+	fe2Add(&tv2, &one, &tv1)
+	fe2Sub(&tv1, &one, &tv1)
+	fe2Mul(&tv3, &tv1, &tv2)
+	fe2Invert(&tv3, &tv3)
+	fe2Mul(&tv4, u, &tv1)
+	fe2Mul(&tv4, &tv4, &tv3)
+	fe2Mul(&tv4, &tv4, &svdw2C3)
+	fe2Sub(&x1, &svdw2C2, &tv4)
+	gx1 := g2Polynomial(&x1)
+	e1 := fe2IsSquare(gx1)
+	fe2Add(&x2, &svdw2C2, &tv4)
+	gx2 := g2Polynomial(&x2)
+	e2 := fe2IsSquare(gx2) & (1 ^ e1)
+	fe2Square(&x3, &tv2)
+	fe2Mul(&x3, &x3, &tv3)
+	fe2Square(&x3, &x3)
+	fe2Mul(&x3, &x3, &svdw2C4)
+	fe2Add(&x3, &x3, &svdw2Z)
+	fe2Select(&x, &x1, &x3, e1)
+	fe2Select(&x, &x2, &x, e2)
+	fe2Sqrt(&y, g2Polynomial(&x))
+	var negY fe2
+	fe2Neg(&negY, &y)
+	fe2Select(&y, &y, &negY, 1^(fe2Sgn0(u)^fe2Sgn0(&y)))
+	return x, y
+}
+
+// expand returns n bytes derived from a message with expand_message_xmd and
+// SHA-256.
+func expand(msg, dst []byte, n int) []byte {
+	uniform, err := elliptic.ExpandMessageXMD(sha256.New, msg, dst, n)
+	if err != nil {
+		panic(err)
+	}
+	return uniform
+}
+
+// HashToG1 hashes an arbitrary message to a point of G1, whose discrete
+// logarithm is unknown, using dst for domain separation.
+//
+// This follows the hash_to_curve procedure of RFC 9380, with the SuiteG1
+// suite. This suite uses the Shallue-van de Woestijne map directly on the
+// curve, rather than the simplified SWU map and isogeny of the standard suites
+// for BLS12-381, so its outputs differ from those of other implementations.
+func HashToG1(msg, dst []byte) *G1 {
+	uniform := expand(msg, dst, 128)
+	out := NewG1Identity()
+	for i := 0; i < 2; i++ {
+		x, y := mapToG1(feFromWideBytes(uniform[64*i : 64*(i+1)]))
+		out.Add(out, &G1{x, y, rOne})
+	}
+	return out.mulBig(cofactorG1, out)
+}
+
+// HashToG2 hashes an arbitrary message to a point of G2, whose discrete
+// logarithm is unknown, using dst for domain separation.
+//
+// This follows the hash_to_curve procedure of RFC 9380, with the SuiteG2
+// suite. This suite uses the Shallue-van de Woestijne map directly on the
+// curve, rather than the simplified SWU map and isogeny of the standard suites
+// for BLS12-381, so its outputs differ from those of other implementations.
+func HashToG2(msg, dst []byte) *G2 {
+	uniform := expand(msg, dst, 256)
+	out := NewG2Identity()
+	for i := 0; i < 2; i++ {
+		u := uniform[128*i : 128*(i+1)]
+		x, y := mapToG2(&fe2{*feFromWideBytes(u[:64]), *feFromWideBytes(u[64:])})
+		out.Add(out, &G2{x, y, *fe2One()})
+	}
+	return out.mulBig(cofactorG2, out)
+}
@@ -0,0 +1,74 @@
+package bls12381
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCofactors(t *testing.T) {
+	// #E(Fp) = p + 1 - t, with t = x + 1
+	tr := new(big.Int).SetUint64(xAbs)
+	tr.Neg(tr)
+	tr.Add(tr, big.NewInt(1))
+	n := new(big.Int).Add(pBig, big.NewInt(1))
+	n.Sub(n, tr)
+	if new(big.Int).Mul(cofactorG1, orderBig).Cmp(n) != 0 {
+		t.Errorf("#E(Fp) != h1 r")
+	}
+	// The cofactor of G2, from the literature.
+	expected, _ := new(big.Int).SetString("5d543a95414e7f1091d50792876a202cd91de4547085abaa68a205b2e5a7ddfa628f1cb4d9e82ef21537e293a6691ae1616ec6e786f0c70cf1c38e31c7238e5", 16)
+	if cofactorG2.Cmp(expected) != 0 {
+		t.Errorf("h2 = %x, expected %x", cofactorG2, expected)
+	}
+}
+
+func TestMapsOnCurve(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		u, _ := randomFe(t)
+		x, y := mapToG1(u)
+		var y2 fe
+		if feEqual(feSquare(&y2, &y), g1Polynomial(&x)) != 1 {
+			t.Errorf("mapToG1 output isn't on the curve")
+		}
+		if feSgn0(&y) != feSgn0(u) {
+			t.Errorf("mapToG1 output has the wrong sign")
+		}
+		u2 := randomFe2(t)
+		x2, y2b := mapToG2(u2)
+		var y22 fe2
+		if fe2Equal(fe2Square(&y22, &y2b), g2Polynomial(&x2)) != 1 {
+			t.Errorf("mapToG2 output isn't on the curve")
+		}
+		if fe2Sgn0(&y2b) != fe2Sgn0(u2) {
+			t.Errorf("mapToG2 output has the wrong sign")
+		}
+	}
+	// The exceptional case, where the denominator vanishes.
+	var zero fe
+	x, y := mapToG1(&zero)
+	var y2 fe
+	if feEqual(feSquare(&y2, &y), g1Polynomial(&x)) != 1 {
+		t.Errorf("mapToG1(0) isn't on the curve")
+	}
+}
+
+func TestHashToCurve(t *testing.T) {
+	dst := []byte("test")
+	p := HashToG1([]byte("message"), dst)
+	if p.IsIdentity() == 1 || !p.inSubgroup() {
+		t.Errorf("HashToG1 output isn't in G1")
+	}
+	if p.Equal(HashToG1([]byte("message"), dst)) != 1 {
+		t.Errorf("HashToG1 isn't deterministic")
+	}
+	if p.Equal(HashToG1([]byte("other message"), dst)) == 1 {
+		t.Errorf("HashToG1 collides")
+	}
+	q := HashToG2([]byte("message"), dst)
+	if q.IsIdentity() == 1 || !q.inSubgroup() {
+		t.Errorf("HashToG2 output isn't in G2")
+	}
+	if q.Equal(HashToG2([]byte("message"), []byte("other"))) == 1 {
+		t.Errorf("HashToG2 ignores the domain separation tag")
+	}
+}
@@ -0,0 +1,224 @@
+package bls12381
+
+// GT is an element of the target group of the pairing, which is the subgroup
+// of order r of the multiplicative group of Fp12.
+//
+// Like the types of math/big, methods set their receiver to the result, and
+// return it.
+type GT struct {
+	v fe12
+}
+
+// NewGTOne returns a new element set to the identity of GT.
+func NewGTOne() *GT {
+	return &GT{*fe12One()}
+}
+
+// Mul sets v = a * b, and returns v.
+func (v *GT) Mul(a, b *GT) *GT {
+	fe12Mul(&v.v, &a.v, &b.v)
+	return v
+}
+
+// Invert sets v = 1 / a, and returns v.
+func (v *GT) Invert(a *GT) *GT {
+	// Elements of GT lie in the cyclotomic subgroup, where inversion is
+	// conjugation.
+	fe12Conj(&v.v, &a.v)
+	return v
+}
+
+// Equal returns 1 if v and u are equal, and 0 otherwise, without leaking which.
+func (v *GT) Equal(u *GT) int {
+	return fe12Equal(&v.v, &u.v)
+}
+
+// IsOne returns 1 if v is the identity of GT, and 0 otherwise.
+func (v *GT) IsOne() int {
+	return fe12Equal(&v.v, fe12One())
+}
+
+// Bytes returns an encoding of v, made of its 12 coefficients over Fp.
+//
+// This encoding isn't standardized, and is meant to be used as an input to
+// hash functions, rather than to be exchanged.
+func (v *GT) Bytes() []byte {
+	return v.v.bytes()
+}
+
+// lineDouble doubles the point (tx, ty) of the twist in place, and returns
+// the coefficients of the tangent line at that point, evaluated at (px, py).
+func lineDouble(tx, ty *fe2, px, py *fe) (l0, l2, l3 fe2) {
+	// λ = 3 x² / 2 y
+	var lambda, den fe2
+	fe2Square(&lambda, tx)
+	fe2Add(&den, &lambda, &lambda)
+	fe2Add(&lambda, &den, &lambda)
+	fe2Add(&den, ty, ty)
+	fe2Invert(&den, &den)
+	fe2Mul(&lambda, &lambda, &den)
+	lineCoefficients(&l0, &l2, &l3, &lambda, tx, ty, px, py)
+
+	// x' = λ² - 2 x, y' = λ (x - x') - y
+	var x3, y3 fe2
+	fe2Square(&x3, &lambda)
+	fe2Sub(&x3, &x3, tx)
+	fe2Sub(&x3, &x3, tx)
+	fe2Sub(&y3, tx, &x3)
+	fe2Mul(&y3, &y3, &lambda)
+	fe2Sub(&y3, &y3, ty)
+	*tx, *ty = x3, y3
+	return
+}
+
+// lineAdd sets (tx, ty) to (tx, ty) + (qx, qy) on the twist, and returns the
+// coefficients of the line through these points, evaluated at (px, py).
+func lineAdd(tx, ty, qx, qy *fe2, px, py *fe) (l0, l2, l3 fe2) {
+	// λ = (y_Q - y_T) / (x_Q - x_T)
+	var lambda, den fe2
+	fe2Sub(&lambda, qy, ty)
+	fe2Sub(&den, qx, tx)
+	fe2Invert(&den, &den)
+	fe2Mul(&lambda, &lambda, &den)
+	lineCoefficients(&l0, &l2, &l3, &lambda, tx, ty, px, py)
+
+	// x' = λ² - x_T - x_Q, y' = λ (x_T - x') - y_T
+	var x3, y3 fe2
+	fe2Square(&x3, &lambda)
+	fe2Sub(&x3, &x3, tx)
+	fe2Sub(&x3, &x3, qx)
+	fe2Sub(&y3, tx, &x3)
+	fe2Mul(&y3, &y3, &lambda)
+	fe2Sub(&y3, &y3, ty)
+	*tx, *ty = x3, y3
+	return
+}
+
+// lineCoefficients computes the line of slope λ through (tx, ty), evaluated
+// at (px, py).
+//
+// The twist is mapped to the curve by (x, y) -> (x / w², y / w³), so this
+// line is y_P - y_T / w³ - λ (x_P - x_T / w²) / w. We multiply it by w³,
+// which lies in a subfield of Fp12, and is thus erased by the final
+// exponentiation, giving (λ x_T - y_T) - λ x_P w² + y_P w³.
+func lineCoefficients(l0, l2, l3, lambda, tx, ty *fe2, px, py *fe) {
+	fe2Mul(l0, lambda, tx)
+	fe2Sub(l0, l0, ty)
+	fe2MulFe(l2, lambda, px)
+	fe2Neg(l2, l2)
+	*l3 = fe2{c0: *py}
+}
+
+// millerLoop computes the Miller loop of the optimal ate pairing of p and q.
+func millerLoop(p *G1, q *G2) *fe12 {
+	f := fe12One()
+	if p.IsIdentity() == 1 || q.IsIdentity() == 1 {
+		return f
+	}
+	px, py := p.affine()
+	qx, qy := q.affine()
+	tx, ty := qx, qy
+	for i := 62; i >= 0; i-- {
+		fe12Square(f, f)
+		l0, l2, l3 := lineDouble(&tx, &ty, &px, &py)
+		fe12MulLine(f, f, &l0, &l2, &l3)
+		if (xAbs>>uint(i))&1 == 1 {
+			l0, l2, l3 = lineAdd(&tx, &ty, &qx, &qy, &px, &py)
+			fe12MulLine(f, f, &l0, &l2, &l3)
+		}
+	}
+	// The parameter x is negative, so we need f_{-|x|, Q}, which is the
+	// inverse of f_{|x|, Q} up to a vertical line, erased by the final
+	// exponentiation. After the easy part of that exponentiation, inversion
+	// is the same as conjugation.
+	return fe12Conj(f, f)
+}
+
+// cyclotomicExpX sets z = a^x, for a in the cyclotomic subgroup, where x is
+// the (negative) parameter of the curve.
+func cyclotomicExpX(z, a *fe12) *fe12 {
+	out := *a
+	for i := 62; i >= 0; i-- {
+		fe12Square(&out, &out)
+		if (xAbs>>uint(i))&1 == 1 {
+			fe12Mul(&out, &out, a)
+		}
+	}
+	return fe12Conj(z, &out)
+}
+
+// finalExponentiation sets z = f^(3 (p¹² - 1) / r).
+//
+// Raising to 3 times the usual exponent still gives a non-degenerate bilinear
+// pairing, since 3 doesn't divide r, and lets us use a cheaper decomposition
+// of the hard part of the exponentiation.
+func finalExponentiation(z, f *fe12) *fe12 {
+	// The easy part is (p⁶ - 1) (p² + 1), and moves f into the cyclotomic
+	// subgroup, where inversion is conjugation.
+	var a, t fe12
+	fe12Conj(&a, f)
+	fe12Invert(&t, f)
+	fe12Mul(&a, &a, &t)
+	fe12Frobenius(&t, &a)
+	fe12Frobenius(&t, &t)
+	fe12Mul(&a, &a, &t)
+
+	// The hard part is 3 (p⁴ - p² + 1) / r, which is equal to
+	// (x - 1)² (x + p) (x² + p² - 1) + 3, following Hayashida, Hayasaka, and
+	// Teruya, "Efficient final exponentiation via cyclotomic structure for
+	// pairings over families of elliptic curves".
+	var b, c fe12
+	// b = a^((x - 1)²)
+	cyclotomicExpX(&b, &a)
+	fe12Mul(&b, &b, fe12Conj(&t, &a))
+	cyclotomicExpX(&c, &b)
+	fe12Mul(&b, &c, fe12Conj(&t, &b))
+	// b = b^(x + p)
+	cyclotomicExpX(&c, &b)
+	fe12Mul(&b, &c, fe12Frobenius(&t, &b))
+	// b = b^(x² + p² - 1)
+	cyclotomicExpX(&c, &b)
+	cyclotomicExpX(&c, &c)
+	fe12Frobenius(&t, &b)
+	fe12Frobenius(&t, &t)
+	fe12Mul(&c, &c, &t)
+	fe12Mul(&b, &c, fe12Conj(&t, &b))
+	// z = b a³
+	fe12Square(&t, &a)
+	fe12Mul(&t, &t, &a)
+	return fe12Mul(z, &b, &t)
+}
+
+// Pair computes the optimal ate pairing e(p, q).
+//
+// The result is the cube of the pairing as defined in most references, which
+// is just as good, but cheaper to compute. Elements of GT are thus only
+// compatible with this package, even if G1 and G2 use standard encodings.
+//
+// The pairing is bilinear, with e(a P, b Q) = e(P, Q)^(a b), and e(G1, G2) is
+// a generator of GT, for the standard generators of G1 and G2.
+//
+// The inputs are treated as public values, and the execution time depends on
+// whether or not they're the identity.
+func Pair(p *G1, q *G2) *GT {
+	out := new(GT)
+	finalExponentiation(&out.v, millerLoop(p, q))
+	return out
+}
+
+// PairingCheck reports whether e(p[0], q[0]) * ... * e(p[n-1], q[n-1]) = 1.
+//
+// This is faster than computing each pairing separately, since the final
+// exponentiation is shared between all of them. The inputs are treated as
+// public values. This function panics if p and q have different lengths.
+func PairingCheck(p []*G1, q []*G2) bool {
+	if len(p) != len(q) {
+		panic("bls12381: mismatched number of points in pairing check")
+	}
+	acc := fe12One()
+	for i := range p {
+		fe12Mul(acc, acc, millerLoop(p[i], q[i]))
+	}
+	finalExponentiation(acc, acc)
+	return fe12Equal(acc, fe12One()) == 1
+}
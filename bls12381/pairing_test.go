@@ -0,0 +1,96 @@
+package bls12381
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func TestPairingBilinear(t *testing.T) {
+	a, b := randomScalar(t), randomScalar(t)
+	e := Pair(NewG1Generator(), NewG2Generator())
+	if e.IsOne() == 1 {
+		t.Fatalf("e(G1, G2) = 1")
+	}
+	// e^r = 1
+	var power fe12
+	fe12Exp(&power, &e.v, orderBig)
+	if fe12Equal(&power, fe12One()) != 1 {
+		t.Errorf("e(G1, G2)^r != 1")
+	}
+
+	ab := new(safenum.Nat).ModMul(a, b, Order)
+	lhs := Pair(new(G1).ScalarBaseMult(a), new(G2).ScalarBaseMult(b))
+	rhs := Pair(new(G1).ScalarBaseMult(ab), NewG2Generator())
+	if lhs.Equal(rhs) != 1 {
+		t.Errorf("e(a G1, b G2) != e(a b G1, G2)")
+	}
+	var expected GT
+	fe12Exp(&expected.v, &e.v, new(big.Int).SetBytes(ab.Bytes()))
+	if lhs.Equal(&expected) != 1 {
+		t.Errorf("e(a G1, b G2) != e(G1, G2)^(a b)")
+	}
+
+	// e(P, Q) e(P, Q') = e(P, Q + Q')
+	p := new(G1).ScalarBaseMult(a)
+	q0 := new(G2).ScalarBaseMult(b)
+	q1 := NewG2Generator()
+	sum := new(G2).Add(q0, q1)
+	if new(GT).Mul(Pair(p, q0), Pair(p, q1)).Equal(Pair(p, sum)) != 1 {
+		t.Errorf("e(P, Q) e(P, Q') != e(P, Q + Q')")
+	}
+	if new(GT).Mul(Pair(p, q0), new(GT).Invert(Pair(p, q0))).IsOne() != 1 {
+		t.Errorf("e(P, Q) / e(P, Q) != 1")
+	}
+}
+
+func TestFinalExponentiation(t *testing.T) {
+	// 3 (p¹² - 1) / r
+	e := new(big.Int).Exp(pBig, big.NewInt(12), nil)
+	e.Sub(e, big.NewInt(1))
+	e.Div(e, orderBig)
+	e.Mul(e, big.NewInt(3))
+	f := randomFe12(t)
+	var expected, actual fe12
+	fe12Exp(&expected, f, e)
+	finalExponentiation(&actual, f)
+	if fe12Equal(&expected, &actual) != 1 {
+		t.Errorf("final exponentiation is wrong")
+	}
+}
+
+func TestPairingIdentity(t *testing.T) {
+	if Pair(NewG1Identity(), NewG2Generator()).IsOne() != 1 {
+		t.Errorf("e(0, G2) != 1")
+	}
+	if Pair(NewG1Generator(), NewG2Identity()).IsOne() != 1 {
+		t.Errorf("e(G1, 0) != 1")
+	}
+}
+
+func TestPairingCheck(t *testing.T) {
+	a, b := randomScalar(t), randomScalar(t)
+	ab := new(safenum.Nat).ModMul(a, b, Order)
+	// e(a G1, b G2) e(-a b G1, G2) = 1
+	p := []*G1{new(G1).ScalarBaseMult(a), new(G1).Negate(new(G1).ScalarBaseMult(ab))}
+	q := []*G2{new(G2).ScalarBaseMult(b), NewG2Generator()}
+	if !PairingCheck(p, q) {
+		t.Errorf("valid pairing check failed")
+	}
+	q[1] = new(G2).Add(q[1], q[1])
+	if PairingCheck(p, q) {
+		t.Errorf("invalid pairing check succeeded")
+	}
+	if !PairingCheck(nil, nil) {
+		t.Errorf("empty pairing check failed")
+	}
+}
+
+func BenchmarkPair(b *testing.B) {
+	p := NewG1Generator()
+	q := NewG2Generator()
+	for i := 0; i < b.N; i++ {
+		Pair(p, q)
+	}
+}
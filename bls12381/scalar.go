@@ -0,0 +1,45 @@
+package bls12381
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// ScalarSize is the size of an encoded scalar.
+const ScalarSize = 32
+
+var orderBig, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+
+// Order is the prime order r of the groups G1, G2, and GT.
+var Order = safenum.ModulusFromBytes(orderBig.Bytes())
+
+// The absolute value of the parameter x = -0xd201000000010000 from which the
+// curve is derived, with r = x⁴ - x² + 1, and p = (x - 1)² r / 3 + x.
+const xAbs uint64 = 0xd201000000010000
+
+// ScalarBytes returns the ScalarSize byte big-endian encoding of a scalar,
+// which must be reduced modulo Order.
+func ScalarBytes(s *safenum.Nat) []byte {
+	out := make([]byte, ScalarSize)
+	b := s.Bytes()
+	if len(b) > ScalarSize {
+		b = b[len(b)-ScalarSize:]
+	}
+	copy(out[ScalarSize-len(b):], b)
+	return out
+}
+
+// ScalarFromBytes decodes a ScalarSize byte big-endian scalar, returning an
+// error if it isn't reduced modulo Order.
+func ScalarFromBytes(b []byte) (*safenum.Nat, error) {
+	if len(b) != ScalarSize {
+		return nil, errors.New("bls12381: invalid scalar length")
+	}
+	s := new(safenum.Nat).SetBytes(b[:len(b):len(b)])
+	if s.CmpMod(Order) != -1 {
+		return nil, errors.New("bls12381: scalar not reduced")
+	}
+	return s, nil
+}
@@ -0,0 +1,213 @@
+// Package chacha20 implements the ChaCha20 and XChaCha20 stream ciphers, as
+// specified in RFC 8439 and draft-irtf-cfrg-xchacha-03.
+//
+// ChaCha20 only uses additions, rotations and xors, on 32 bit words, so it
+// runs in constant time without any special care.
+package chacha20
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+
+	"github.com/cronokirby/ctcrypto/internal/subtle"
+)
+
+const (
+	// KeySize is the size of the key, in bytes.
+	KeySize = 32
+	// NonceSize is the size of the nonce of ChaCha20, in bytes.
+	NonceSize = 12
+	// NonceSizeX is the size of the nonce of XChaCha20, in bytes.
+	NonceSizeX = 24
+)
+
+const blockSize = 64
+
+// The constants of the first row of the state, "expand 32-byte k".
+const (
+	j0 uint32 = 0x61707865
+	j1 uint32 = 0x3320646e
+	j2 uint32 = 0x79622d32
+	j3 uint32 = 0x6b206574
+)
+
+// Cipher is an instance of ChaCha20 or XChaCha20, with a key and nonce.
+//
+// It implements cipher.Stream.
+type Cipher struct {
+	key     [8]uint32
+	counter uint32
+	nonce   [3]uint32
+
+	// buf holds the unused part of the last block of key stream.
+	buf [blockSize]byte
+	len int
+
+	// overflow is set once the counter has wrapped around.
+	overflow bool
+}
+
+var _ cipher.Stream = (*Cipher)(nil)
+
+// NewUnauthenticatedCipher returns a stream cipher with the given key and
+// nonce. A 12 byte nonce selects ChaCha20, and a 24 byte nonce XChaCha20.
+//
+// Reusing a nonce with the same key reveals the xor of the plaintexts, and
+// ciphertexts can be modified without detection: most applications should use
+// the chacha20poly1305 package instead.
+func NewUnauthenticatedCipher(key, nonce []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20: wrong key size")
+	}
+	if len(nonce) == NonceSizeX {
+		subkey, _ := HChaCha20(key, nonce[:16])
+		cNonce := make([]byte, NonceSize)
+		copy(cNonce[4:], nonce[16:])
+		key, nonce = subkey, cNonce
+	} else if len(nonce) != NonceSize {
+		return nil, errors.New("chacha20: wrong nonce size")
+	}
+	c := new(Cipher)
+	for i := range c.key {
+		c.key[i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	for i := range c.nonce {
+		c.nonce[i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	return c, nil
+}
+
+// SetCounter sets the block counter, discarding any buffered key stream.
+//
+// It panics if the counter would be moved back past blocks already used.
+func (c *Cipher) SetCounter(counter uint32) {
+	if c.overflow || counter < c.counter {
+		panic("chacha20: SetCounter attempted to rollback counter")
+	}
+	c.counter = counter
+	c.len = 0
+}
+
+func quarterRound(a, b, c, d uint32) (uint32, uint32, uint32, uint32) {
+	a += b
+	d ^= a
+	d = bits.RotateLeft32(d, 16)
+	c += d
+	b ^= c
+	b = bits.RotateLeft32(b, 12)
+	a += b
+	d ^= a
+	d = bits.RotateLeft32(d, 8)
+	c += d
+	b ^= c
+	b = bits.RotateLeft32(b, 7)
+	return a, b, c, d
+}
+
+// rounds applies the 20 rounds of ChaCha20 to x.
+func rounds(x *[16]uint32) {
+	for i := 0; i < 10; i++ {
+		// Columns.
+		x[0], x[4], x[8], x[12] = quarterRound(x[0], x[4], x[8], x[12])
+		x[1], x[5], x[9], x[13] = quarterRound(x[1], x[5], x[9], x[13])
+		x[2], x[6], x[10], x[14] = quarterRound(x[2], x[6], x[10], x[14])
+		x[3], x[7], x[11], x[15] = quarterRound(x[3], x[7], x[11], x[15])
+		// Diagonals.
+		x[0], x[5], x[10], x[15] = quarterRound(x[0], x[5], x[10], x[15])
+		x[1], x[6], x[11], x[12] = quarterRound(x[1], x[6], x[11], x[12])
+		x[2], x[7], x[8], x[13] = quarterRound(x[2], x[7], x[8], x[13])
+		x[3], x[4], x[9], x[14] = quarterRound(x[3], x[4], x[9], x[14])
+	}
+}
+
+// block writes the next block of key stream to out.
+func (c *Cipher) block(out *[blockSize]byte) {
+	in := [16]uint32{
+		j0, j1, j2, j3,
+		c.key[0], c.key[1], c.key[2], c.key[3],
+		c.key[4], c.key[5], c.key[6], c.key[7],
+		c.counter, c.nonce[0], c.nonce[1], c.nonce[2],
+	}
+	x := in
+	rounds(&x)
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i]+in[i])
+	}
+	c.counter++
+	if c.counter == 0 {
+		c.overflow = true
+	}
+}
+
+// XORKeyStream xors each byte of src with a byte of the key stream, writing
+// the result to dst. Dst and src must overlap entirely or not at all.
+//
+// It panics if the 32 bit block counter would overflow, past 256 GiB of key
+// stream.
+func (c *Cipher) XORKeyStream(dst, src []byte) {
+	if len(src) == 0 {
+		return
+	}
+	if len(dst) < len(src) {
+		panic("chacha20: output smaller than input")
+	}
+	dst = dst[:len(src)]
+	if subtle.InexactOverlap(dst, src) {
+		panic("chacha20: invalid buffer overlap")
+	}
+
+	if c.len != 0 {
+		keyStream := c.buf[blockSize-c.len:]
+		if len(src) < len(keyStream) {
+			keyStream = keyStream[:len(src)]
+		}
+		for i, b := range keyStream {
+			dst[i] = src[i] ^ b
+		}
+		c.len -= len(keyStream)
+		dst, src = dst[len(keyStream):], src[len(keyStream):]
+	}
+
+	for len(src) > 0 {
+		if c.overflow {
+			panic("chacha20: counter overflow")
+		}
+		c.block(&c.buf)
+		n := len(src)
+		if n > blockSize {
+			n = blockSize
+		}
+		for i := 0; i < n; i++ {
+			dst[i] = src[i] ^ c.buf[i]
+		}
+		c.len = blockSize - n
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// HChaCha20 derives a 32 byte subkey from a key and the first 16 bytes of an
+// XChaCha20 nonce, as described in section 2.2 of draft-irtf-cfrg-xchacha-03.
+func HChaCha20(key, nonce []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20: wrong HChaCha20 key size")
+	}
+	if len(nonce) != 16 {
+		return nil, errors.New("chacha20: wrong HChaCha20 nonce size")
+	}
+	x := [16]uint32{j0, j1, j2, j3}
+	for i := 0; i < 8; i++ {
+		x[4+i] = binary.LittleEndian.Uint32(key[4*i:])
+	}
+	for i := 0; i < 4; i++ {
+		x[12+i] = binary.LittleEndian.Uint32(nonce[4*i:])
+	}
+	rounds(&x)
+	out := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint32(out[4*i:], x[i])
+		binary.LittleEndian.PutUint32(out[16+4*i:], x[12+i])
+	}
+	return out, nil
+}
@@ -0,0 +1,78 @@
+package chacha20
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var sunscreen = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+
+func TestXORKeyStream(t *testing.T) {
+	// Generated with golang.org/x/crypto/chacha20.
+	key := mustHex("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	nonce := mustHex("000000000000004a00000000")
+	expected := mustHex("5bef610976390c5c227c04dbce252cfb00adcc9fdd984bd624822cb04950dc6757517a20f6a1cb9c0b5871f542fc97a394575cbf41bdfbb39c581dfa0bf3cf1e0288ecfe59cc08d49beeaa539f3065be998dc189161b441f62156cc2789c847c68cde0c57b7784e7d609d1d4555549535452")
+	for _, chunk := range []int{1, 7, 64, len(sunscreen)} {
+		c, err := NewUnauthenticatedCipher(key, nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.SetCounter(1)
+		out := make([]byte, len(sunscreen))
+		for i := 0; i < len(out); i += chunk {
+			end := i + chunk
+			if end > len(out) {
+				end = len(out)
+			}
+			c.XORKeyStream(out[i:end], sunscreen[i:end])
+		}
+		if !bytes.Equal(out, expected) {
+			t.Errorf("chunks of %d: %x, expected %x", chunk, out, expected)
+		}
+	}
+}
+
+func TestHChaCha20(t *testing.T) {
+	// See draft-irtf-cfrg-xchacha-03, section 2.2.1.
+	key := mustHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	nonce := mustHex("000000090000004a0000000031415927")
+	expected := mustHex("82413b4227b27bfed30e42508a877d73a0f9e4d58a74a853c12ec41326d3ecdc")
+	subkey, err := HChaCha20(key, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(subkey, expected) {
+		t.Errorf("HChaCha20 = %x, expected %x", subkey, expected)
+	}
+}
+
+func TestInvalidSizes(t *testing.T) {
+	if _, err := NewUnauthenticatedCipher(make([]byte, 16), make([]byte, NonceSize)); err == nil {
+		t.Error("accepted a 16 byte key")
+	}
+	if _, err := NewUnauthenticatedCipher(make([]byte, KeySize), make([]byte, 8)); err == nil {
+		t.Error("accepted an 8 byte nonce")
+	}
+}
+
+func TestCounterOverflow(t *testing.T) {
+	c, _ := NewUnauthenticatedCipher(make([]byte, KeySize), make([]byte, NonceSize))
+	c.SetCounter(^uint32(0))
+	buf := make([]byte, 64)
+	c.XORKeyStream(buf, buf)
+	defer func() {
+		if recover() == nil {
+			t.Error("XORKeyStream didn't panic after the counter overflowed")
+		}
+	}()
+	c.XORKeyStream(buf[:1], buf[:1])
+}
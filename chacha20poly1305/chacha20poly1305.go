@@ -0,0 +1,165 @@
+// Package chacha20poly1305 implements the ChaCha20-Poly1305 AEAD, as specified
+// in RFC 8439, and its extended nonce variant XChaCha20-Poly1305, from
+// draft-irtf-cfrg-xchacha-03.
+//
+// It is built on the chacha20 and poly1305 packages, and runs in constant
+// time on every platform.
+package chacha20poly1305
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/chacha20"
+	"github.com/cronokirby/ctcrypto/internal/subtle"
+	"github.com/cronokirby/ctcrypto/poly1305"
+)
+
+const (
+	// KeySize is the size of the key, in bytes.
+	KeySize = chacha20.KeySize
+	// NonceSize is the size of the nonce of ChaCha20-Poly1305, in bytes.
+	NonceSize = chacha20.NonceSize
+	// NonceSizeX is the size of the nonce of XChaCha20-Poly1305, in bytes.
+	//
+	// Nonces this large can be chosen at random, for any number of messages.
+	NonceSizeX = chacha20.NonceSizeX
+	// Overhead is the size of the authentication tag, in bytes.
+	Overhead = poly1305.TagSize
+)
+
+// maxPlaintext is the size of the key stream left after the block used for
+// the Poly1305 key.
+const maxPlaintext = (1<<32 - 1) * 64
+
+var errOpen = errors.New("chacha20poly1305: message authentication failed")
+
+type aead struct {
+	key       [KeySize]byte
+	nonceSize int
+}
+
+// New returns ChaCha20-Poly1305 with the given 32 byte key.
+func New(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, NonceSize)
+}
+
+// NewX returns XChaCha20-Poly1305 with the given 32 byte key.
+func NewX(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key, NonceSizeX)
+}
+
+func newAEAD(key []byte, nonceSize int) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("chacha20poly1305: bad key length")
+	}
+	a := &aead{nonceSize: nonceSize}
+	copy(a.key[:], key)
+	return a, nil
+}
+
+func (a *aead) NonceSize() int {
+	return a.nonceSize
+}
+
+func (a *aead) Overhead() int {
+	return Overhead
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes. If the
+// original slice has sufficient capacity then no allocation is performed.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// setup returns the cipher for a nonce, at the counter 1, and the MAC keyed
+// with the first 32 bytes of the block at counter 0.
+func (a *aead) setup(nonce []byte) (*chacha20.Cipher, *poly1305.MAC) {
+	s, _ := chacha20.NewUnauthenticatedCipher(a.key[:], nonce)
+	var polyKey [poly1305.KeySize]byte
+	s.XORKeyStream(polyKey[:], polyKey[:])
+	s.SetCounter(1)
+	return s, poly1305.New(&polyKey)
+}
+
+// writeWithPadding writes b to the MAC, padded with zeros to a multiple of 16
+// bytes.
+func writeWithPadding(p *poly1305.MAC, b []byte) {
+	p.Write(b)
+	if rem := len(b) % 16; rem != 0 {
+		var padding [16]byte
+		p.Write(padding[:16-rem])
+	}
+}
+
+func writeLengths(p *poly1305.MAC, additionalData, ciphertext []byte) {
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData)))
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(ciphertext)))
+	p.Write(lengths[:])
+}
+
+func (a *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != a.nonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Seal")
+	}
+	if uint64(len(plaintext)) > maxPlaintext {
+		panic("chacha20poly1305: plaintext too large")
+	}
+	ret, out := sliceForAppend(dst, len(plaintext)+Overhead)
+	ciphertext, tag := out[:len(plaintext)], out[len(plaintext):]
+	if subtle.InexactOverlap(out, plaintext) {
+		panic("chacha20poly1305: invalid buffer overlap")
+	}
+
+	s, p := a.setup(nonce)
+	s.XORKeyStream(ciphertext, plaintext)
+	writeWithPadding(p, additionalData)
+	writeWithPadding(p, ciphertext)
+	writeLengths(p, additionalData, ciphertext)
+	p.Sum(tag[:0])
+	return ret
+}
+
+func (a *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != a.nonceSize {
+		panic("chacha20poly1305: bad nonce length passed to Open")
+	}
+	if len(ciphertext) < Overhead {
+		return nil, errOpen
+	}
+	if uint64(len(ciphertext)) > maxPlaintext+Overhead {
+		return nil, errOpen
+	}
+	tag := ciphertext[len(ciphertext)-Overhead:]
+	ciphertext = ciphertext[:len(ciphertext)-Overhead]
+
+	s, p := a.setup(nonce)
+	writeWithPadding(p, additionalData)
+	writeWithPadding(p, ciphertext)
+	writeLengths(p, additionalData, ciphertext)
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	if subtle.InexactOverlap(out, ciphertext) {
+		panic("chacha20poly1305: invalid buffer overlap")
+	}
+	if !p.Verify(tag) {
+		// The plaintext is never revealed when authentication fails.
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errOpen
+	}
+	s.XORKeyStream(out, ciphertext)
+	return ret, nil
+}
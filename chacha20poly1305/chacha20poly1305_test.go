@@ -0,0 +1,102 @@
+package chacha20poly1305
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var sunscreen = []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+
+func TestVectors(t *testing.T) {
+	tests := []struct {
+		name       string
+		nonce      string
+		ciphertext string
+	}{
+		// See RFC 8439, section 2.8.2.
+		{
+			"ChaCha20-Poly1305",
+			"070000004041424344454647",
+			"d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d63dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b3692ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc3ff4def08e4b7a9de576d26586cec64b61161ae10b594f09e26a7e902ecbd0600691",
+		},
+		// See draft-irtf-cfrg-xchacha-03, appendix A.3.1.
+		{
+			"XChaCha20-Poly1305",
+			"404142434445464748494a4b4c4d4e4f5051525354555657",
+			"bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b4522f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff921f9664c97637da9768812f615c68b13b52ec0875924c1c7987947deafd8780acf49",
+		},
+	}
+	key := mustHex("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	ad := mustHex("50515253c0c1c2c3c4c5c6c7")
+	for _, test := range tests {
+		nonce := mustHex(test.nonce)
+		expected := mustHex(test.ciphertext)
+		var aead interface {
+			Seal(dst, nonce, plaintext, additionalData []byte) []byte
+			Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error)
+		}
+		var err error
+		if len(nonce) == NonceSizeX {
+			aead, err = NewX(key)
+		} else {
+			aead, err = New(key)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext := aead.Seal(nil, nonce, sunscreen, ad)
+		if !bytes.Equal(ciphertext, expected) {
+			t.Errorf("%s: Seal = %x, expected %x", test.name, ciphertext, expected)
+		}
+		plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !bytes.Equal(plaintext, sunscreen) {
+			t.Errorf("%s: Open = %q", test.name, plaintext)
+		}
+	}
+}
+
+func TestOpenRejectsTampering(t *testing.T) {
+	aead, _ := New(make([]byte, KeySize))
+	nonce := make([]byte, NonceSize)
+	ciphertext := aead.Seal(nil, nonce, []byte("attack at dawn"), []byte("header"))
+	for i := range ciphertext {
+		tampered := append([]byte(nil), ciphertext...)
+		tampered[i] ^= 0x80
+		if _, err := aead.Open(nil, nonce, tampered, []byte("header")); err == nil {
+			t.Errorf("Open accepted a ciphertext with byte %d flipped", i)
+		}
+	}
+	if _, err := aead.Open(nil, nonce, ciphertext, nil); err == nil {
+		t.Error("Open accepted the wrong additional data")
+	}
+	if _, err := aead.Open(nil, nonce, ciphertext[:Overhead-1], nil); err == nil {
+		t.Error("Open accepted a truncated ciphertext")
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	aead, _ := NewX(make([]byte, KeySize))
+	nonce := make([]byte, NonceSizeX)
+	buf := make([]byte, 0, len(sunscreen)+Overhead)
+	buf = append(buf, sunscreen...)
+	ciphertext := aead.Seal(buf[:0], nonce, buf, nil)
+	plaintext, err := aead.Open(ciphertext[:0], nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, sunscreen) {
+		t.Errorf("Open = %q", plaintext)
+	}
+}
@@ -0,0 +1,288 @@
+// Package classgroup implements arithmetic in the class groups of imaginary
+// quadratic orders, whose elements are represented by reduced binary
+// quadratic forms.
+//
+// The order of these groups is hard to compute for large discriminants, which
+// makes them useful for encryption schemes, like Castagnos-Laguillaumie, and
+// verifiable delay functions, without needing a trusted setup.
+//
+// Unlike the rest of this module, the arithmetic is done with math/big, and
+// isn't constant-time: the time taken by an exponentiation leaks information
+// about the exponent.
+package classgroup
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	bigOne  = big.NewInt(1)
+	bigTwo  = big.NewInt(2)
+	bigFour = big.NewInt(4)
+)
+
+// Group is the class group of discriminant Δ, where Δ < 0 and Δ ≡ 1 mod 4.
+type Group struct {
+	d *big.Int
+	// the size of the encoding of a coefficient of a reduced form
+	size int
+}
+
+// NewGroup returns the class group of the given discriminant, which must be
+// negative, and congruent to 1 modulo 4.
+func NewGroup(discriminant *big.Int) (*Group, error) {
+	if discriminant.Sign() >= 0 || new(big.Int).Mod(discriminant, bigFour).Cmp(bigOne) != 0 {
+		return nil, errors.New("classgroup: discriminant must be negative, and 1 modulo 4")
+	}
+	// Reduced forms have |b| ≤ a ≤ sqrt(|Δ| / 3).
+	bound := new(big.Int).Sqrt(new(big.Int).Neg(discriminant))
+	return &Group{d: new(big.Int).Set(discriminant), size: (bound.BitLen()+7)/8 + 1}, nil
+}
+
+// Discriminant returns the discriminant of the group.
+func (g *Group) Discriminant() *big.Int {
+	return new(big.Int).Set(g.d)
+}
+
+// Form is the binary quadratic form a x² + b x y + c y², which is always
+// reduced, and represents an element of a class group.
+//
+// Forms are immutable, and operations on them return new forms.
+type Form struct {
+	a, b, c *big.Int
+}
+
+// A returns the coefficient a of the form.
+func (f *Form) A() *big.Int {
+	return new(big.Int).Set(f.a)
+}
+
+// B returns the coefficient b of the form.
+func (f *Form) B() *big.Int {
+	return new(big.Int).Set(f.b)
+}
+
+// C returns the coefficient c of the form.
+func (f *Form) C() *big.Int {
+	return new(big.Int).Set(f.c)
+}
+
+// Equal reports whether two forms are equal. Since forms are reduced, this is
+// the case exactly when they represent the same element.
+func (f *Form) Equal(other *Form) bool {
+	return f.a.Cmp(other.a) == 0 && f.b.Cmp(other.b) == 0
+}
+
+// c returns c = (b² - Δ) / 4a, which must be exact.
+func (g *Group) c(a, b *big.Int) *big.Int {
+	c := new(big.Int).Mul(b, b)
+	c.Sub(c, g.d)
+	return c.Quo(c, new(big.Int).Lsh(a, 2))
+}
+
+// normalize makes -a < b ≤ a, by applying a translation to the form.
+func normalize(f *Form) {
+	if new(big.Int).Neg(f.a).Cmp(f.b) < 0 && f.b.Cmp(f.a) <= 0 {
+		return
+	}
+	// s = ⌊(a - b) / 2a⌋
+	twoA := new(big.Int).Lsh(f.a, 1)
+	// Div rounds towards negative infinity, since 2a is positive.
+	s := new(big.Int).Sub(f.a, f.b)
+	s.Div(s, twoA)
+	// c = a s² + b s + c, b = b + 2 a s
+	as := new(big.Int).Mul(f.a, s)
+	t := new(big.Int).Add(as, f.b)
+	f.c.Add(f.c, t.Mul(t, s))
+	f.b.Add(f.b, as.Lsh(as, 1))
+}
+
+// reduce turns a form into the unique reduced form equivalent to it, with
+// |b| ≤ a ≤ c, and b ≥ 0 if |b| = a or a = c.
+func reduce(f *Form) *Form {
+	normalize(f)
+	for f.a.Cmp(f.c) > 0 {
+		f.a, f.c = f.c, f.a
+		f.b.Neg(f.b)
+		normalize(f)
+	}
+	if f.a.Cmp(f.c) == 0 && f.b.Sign() < 0 {
+		f.b.Neg(f.b)
+	}
+	return f
+}
+
+// NewForm returns the reduced form equivalent to a x² + b x y + c y², where c
+// is determined by the discriminant.
+//
+// An error is returned if a is not positive, or if there's no such form, or
+// if the form isn't primitive.
+func (g *Group) NewForm(a, b *big.Int) (*Form, error) {
+	if a.Sign() <= 0 {
+		return nil, errors.New("classgroup: a must be positive")
+	}
+	// b² - Δ must be divisible by 4a.
+	c := new(big.Int).Mul(b, b)
+	c.Sub(c, g.d)
+	fourA := new(big.Int).Lsh(a, 2)
+	c, rem := c.QuoRem(c, fourA, new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, errors.New("classgroup: no form with these coefficients")
+	}
+	gcd := new(big.Int).GCD(nil, nil, a, new(big.Int).Abs(b))
+	if gcd.GCD(nil, nil, gcd, c).Cmp(bigOne) != 0 {
+		return nil, errors.New("classgroup: form is not primitive")
+	}
+	return reduce(&Form{new(big.Int).Set(a), new(big.Int).Set(b), c}), nil
+}
+
+// Identity returns the identity element of the group, the form (1, 1, c).
+func (g *Group) Identity() *Form {
+	return &Form{big.NewInt(1), big.NewInt(1), g.c(bigOne, bigOne)}
+}
+
+// PrimeForm returns a form (p, b, c) with b > 0, for a prime p such that Δ is
+// a non-zero square modulo p.
+//
+// This returns an error if there's no such form.
+func (g *Group) PrimeForm(p *big.Int) (*Form, error) {
+	if p.Cmp(bigTwo) <= 0 {
+		return nil, errors.New("classgroup: prime must be odd")
+	}
+	d := new(big.Int).Mod(g.d, p)
+	if big.Jacobi(d, p) != 1 {
+		return nil, errors.New("classgroup: discriminant is not a square modulo the prime")
+	}
+	b := new(big.Int).ModSqrt(d, p)
+	// b must have the same parity as Δ, i.e. be odd.
+	if b.Bit(0) == 0 {
+		b.Sub(p, b)
+	}
+	return g.NewForm(p, b)
+}
+
+// Inverse returns the inverse of a form, (a, -b, c).
+func (g *Group) Inverse(f *Form) *Form {
+	return reduce(&Form{new(big.Int).Set(f.a), new(big.Int).Neg(f.b), new(big.Int).Set(f.c)})
+}
+
+// Compose returns the product of two forms, using the composition algorithm
+// of Cohen, "A Course in Computational Algebraic Number Theory", 5.4.7.
+func (g *Group) Compose(f1, f2 *Form) *Form {
+	if f1.a.Cmp(f2.a) > 0 {
+		f1, f2 = f2, f1
+	}
+	a1, b1 := f1.a, f1.b
+	a2, b2, c2 := f2.a, f2.b, f2.c
+	s := new(big.Int).Add(b1, b2)
+	s.Rsh(s, 1)
+	n := new(big.Int).Sub(b2, s)
+
+	// u a2 + v a1 = d = gcd(a2, a1)
+	var y1, d *big.Int
+	if new(big.Int).Mod(a2, a1).Sign() == 0 {
+		y1, d = new(big.Int), new(big.Int).Set(a1)
+	} else {
+		y1 = new(big.Int)
+		d = new(big.Int).GCD(y1, nil, a2, a1)
+	}
+	// x2 s + y2 d = d1 = gcd(s, d)
+	var x2, y2, d1 *big.Int
+	if new(big.Int).Mod(s, d).Sign() == 0 {
+		x2, y2, d1 = new(big.Int), big.NewInt(-1), d
+	} else {
+		x2, y2 = new(big.Int), new(big.Int)
+		d1 = gcdSigned(x2, y2, s, d)
+		y2.Neg(y2)
+	}
+
+	v1 := new(big.Int).Quo(a1, d1)
+	v2 := new(big.Int).Quo(a2, d1)
+	// r = y1 y2 n - x2 c2 mod v1
+	r := new(big.Int).Mul(y1, y2)
+	r.Mul(r, n)
+	r.Sub(r, new(big.Int).Mul(x2, c2))
+	r.Mod(r, v1)
+	b3 := new(big.Int).Mul(v2, r)
+	b3.Lsh(b3, 1)
+	b3.Add(b3, b2)
+	a3 := new(big.Int).Mul(v1, v2)
+	return reduce(&Form{a3, b3, g.c(a3, b3)})
+}
+
+// gcdSigned computes d = gcd(x, y) along with Bézout coefficients such that
+// u x + v y = d, for any signs of x and y.
+func gcdSigned(u, v, x, y *big.Int) *big.Int {
+	d := new(big.Int).GCD(u, v, new(big.Int).Abs(x), new(big.Int).Abs(y))
+	if x.Sign() < 0 {
+		u.Neg(u)
+	}
+	if y.Sign() < 0 {
+		v.Neg(v)
+	}
+	return d
+}
+
+// Square returns the square of a form.
+func (g *Group) Square(f *Form) *Form {
+	return g.Compose(f, f)
+}
+
+// Exp returns f^k, for an integer k, which may be negative.
+//
+// This uses square-and-multiply, whose running time depends on k.
+func (g *Group) Exp(f *Form, k *big.Int) *Form {
+	if k.Sign() < 0 {
+		f = g.Inverse(f)
+		k = new(big.Int).Neg(k)
+	}
+	out := g.Identity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		out = g.Square(out)
+		if k.Bit(i) == 1 {
+			out = g.Compose(out, f)
+		}
+	}
+	return out
+}
+
+// FormSize returns the size of the encoding of a form.
+func (g *Group) FormSize() int {
+	return 2 * g.size
+}
+
+// Bytes returns the encoding of a form of this group, made of a and b, in
+// big-endian two's complement, each padded to the same size.
+func (g *Group) Bytes(f *Form) []byte {
+	out := make([]byte, 2*g.size)
+	f.a.FillBytes(out[:g.size])
+	b := new(big.Int).Set(f.b)
+	if b.Sign() < 0 {
+		b.Add(b, new(big.Int).Lsh(bigOne, uint(8*g.size)))
+	}
+	b.FillBytes(out[g.size:])
+	return out
+}
+
+// DecodeForm decodes a form produced by Bytes, checking that it's a reduced
+// form of this group.
+func (g *Group) DecodeForm(data []byte) (*Form, error) {
+	if len(data) != 2*g.size {
+		return nil, errors.New("classgroup: invalid form encoding")
+	}
+	a := new(big.Int).SetBytes(data[:g.size])
+	b := new(big.Int).SetBytes(data[g.size:])
+	if data[g.size]&0x80 != 0 {
+		b.Sub(b, new(big.Int).Lsh(bigOne, uint(8*g.size)))
+	}
+	f, err := g.NewForm(a, b)
+	if err != nil {
+		return nil, err
+	}
+	// Only the canonical, reduced, representative is accepted.
+	if f.a.Cmp(a) != 0 || f.b.Cmp(b) != 0 {
+		return nil, errors.New("classgroup: form is not reduced")
+	}
+	return f, nil
+}
@@ -0,0 +1,149 @@
+package classgroup
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// smallPrimeForms returns the forms of the primes below bound, for which they
+// exist.
+func smallPrimeForms(g *Group, bound int64) []*Form {
+	var out []*Form
+	for p := int64(3); p < bound; p += 2 {
+		pBig := big.NewInt(p)
+		if !pBig.ProbablyPrime(10) {
+			continue
+		}
+		if f, err := g.PrimeForm(pBig); err == nil {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestClassNumbers(t *testing.T) {
+	tests := []struct {
+		d, h int64
+	}{
+		{-23, 3},
+		{-47, 5},
+		{-71, 7},
+		{-199, 9},
+		{-163, 1},
+		{-167, 11},
+	}
+	for _, test := range tests {
+		g, err := NewGroup(big.NewInt(test.d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		forms := smallPrimeForms(g, 50)
+		if len(forms) == 0 {
+			t.Fatalf("no prime forms for discriminant %d", test.d)
+		}
+		for _, f := range forms {
+			if !g.Exp(f, big.NewInt(test.h)).Equal(g.Identity()) {
+				t.Errorf("Δ = %d: (%v, %v)^%d is not the identity", test.d, f.a, f.b, test.h)
+			}
+		}
+	}
+}
+
+func randomGroup(t *testing.T) *Group {
+	// -p, for a prime p ≡ 3 mod 4, is a fundamental discriminant.
+	for {
+		p, err := rand.Prime(rand.Reader, 512)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Bit(1) == 1 {
+			g, err := NewGroup(p.Neg(p))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return g
+		}
+	}
+}
+
+func TestGroupLaws(t *testing.T) {
+	g := randomGroup(t)
+	forms := smallPrimeForms(g, 60)
+	if len(forms) < 3 {
+		t.Fatal("not enough prime forms")
+	}
+	a, b, c := forms[0], forms[1], forms[2]
+	if !g.Compose(g.Compose(a, b), c).Equal(g.Compose(a, g.Compose(b, c))) {
+		t.Errorf("composition is not associative")
+	}
+	if !g.Compose(a, b).Equal(g.Compose(b, a)) {
+		t.Errorf("composition is not commutative")
+	}
+	if !g.Compose(a, g.Identity()).Equal(a) {
+		t.Errorf("identity is not neutral")
+	}
+	if !g.Compose(a, g.Inverse(a)).Equal(g.Identity()) {
+		t.Errorf("a * a^-1 is not the identity")
+	}
+	x := big.NewInt(123456789)
+	y := big.NewInt(987654321)
+	xy := g.Exp(g.Exp(a, x), y)
+	if !xy.Equal(g.Exp(a, new(big.Int).Mul(x, y))) {
+		t.Errorf("(a^x)^y != a^(xy)")
+	}
+	if !g.Compose(g.Exp(a, x), g.Exp(a, y)).Equal(g.Exp(a, new(big.Int).Add(x, y))) {
+		t.Errorf("a^x a^y != a^(x + y)")
+	}
+	if !g.Exp(a, new(big.Int).Neg(x)).Equal(g.Inverse(g.Exp(a, x))) {
+		t.Errorf("a^-x != (a^x)^-1")
+	}
+}
+
+func TestReduced(t *testing.T) {
+	g := randomGroup(t)
+	f := g.Exp(smallPrimeForms(g, 20)[0], big.NewInt(1<<40+1))
+	if f.b.CmpAbs(f.a) > 0 || f.a.Cmp(f.c) > 0 {
+		t.Errorf("form is not reduced")
+	}
+	d := new(big.Int).Mul(f.b, f.b)
+	d.Sub(d, new(big.Int).Mul(big.NewInt(4), new(big.Int).Mul(f.a, f.c)))
+	if d.Cmp(g.Discriminant()) != 0 {
+		t.Errorf("form has the wrong discriminant")
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	g := randomGroup(t)
+	f := smallPrimeForms(g, 20)[0]
+	for _, f := range []*Form{g.Identity(), f, g.Inverse(f), g.Exp(f, big.NewInt(1<<50))} {
+		data := g.Bytes(f)
+		if len(data) != g.FormSize() {
+			t.Errorf("encoding has the wrong size")
+		}
+		decoded, err := g.DecodeForm(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(f) {
+			t.Errorf("form doesn't round trip")
+		}
+	}
+	// (a, b + 2a) is equivalent, but not reduced.
+	b := new(big.Int).Add(f.b, new(big.Int).Lsh(f.a, 1))
+	unreduced := &Form{f.a, b, g.c(f.a, b)}
+	if _, err := g.DecodeForm(g.Bytes(unreduced)); err == nil {
+		t.Errorf("accepted an unreduced form")
+	}
+	if _, err := g.DecodeForm(make([]byte, g.FormSize())); err == nil {
+		t.Errorf("accepted a = 0")
+	}
+}
+
+func TestNewGroupRejects(t *testing.T) {
+	for _, d := range []int64{23, -21, -22, 0} {
+		if _, err := NewGroup(big.NewInt(d)); err == nil {
+			t.Errorf("accepted discriminant %d", d)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math/big"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// field holds the constants of the Montgomery arithmetic modulo p, with limbs
+// of 64 or 32 bits, and R = 2^(word * limbs).
+//
+// If p is a Mersenne prime 2^k - 1, like the prime of P-521, elements are
+// instead held as is, and products are reduced with the Solinas reduction,
+// since 2^k = 1 mod p.
+type field struct {
+	// word is the size of the limbs, in bits.
+	word  uint
+	limbs int
+	// p holds the limbs of p, in little-endian order.
+	p []uint64
+	// pInv is -1/p mod 2^word.
+	pInv  uint64
+	r, r2 *big.Int
+	// mersenne is k if p = 2^k - 1, and 0 otherwise.
+	mersenne int
+}
+
+func newField(p *big.Int, word uint) *field {
+	f := &field{word: word, limbs: (p.BitLen() + int(word) - 1) / int(word)}
+	f.p = f.split(p)
+	r := new(big.Int).Lsh(big.NewInt(1), word*uint(f.limbs))
+	f.r = new(big.Int).Mod(r, p)
+	f.r2 = new(big.Int).Mul(f.r, f.r)
+	f.r2.Mod(f.r2, p)
+	base := new(big.Int).Lsh(big.NewInt(1), word)
+	inv := new(big.Int).ModInverse(new(big.Int).Mod(p, base), base)
+	f.pInv = new(big.Int).Sub(base, inv).Uint64()
+	if pPlus1 := new(big.Int).Add(p, big.NewInt(1)); pPlus1.And(pPlus1, p).Sign() == 0 {
+		f.mersenne = p.BitLen()
+	}
+	return f
+}
+
+// split returns the limbs of x, which is less than R, in little-endian order.
+func (f *field) split(x *big.Int) []uint64 {
+	n := int(f.word / 8)
+	buf := x.FillBytes(make([]byte, n*f.limbs))
+	out := make([]uint64, f.limbs)
+	for i := range out {
+		for _, b := range buf[len(buf)-n*(i+1) : len(buf)-n*i] {
+			out[i] = out[i]<<8 | uint64(b)
+		}
+	}
+	return out
+}
+
+// element returns the literal of x in the Montgomery domain, i.e. x * R mod p,
+// or of x itself, for Mersenne primes.
+func (f *field) element(x *big.Int, p *big.Int) string {
+	if f.mersenne != 0 {
+		return f.literal(f.split(x))
+	}
+	m := new(big.Int).Lsh(x, f.word*uint(f.limbs))
+	return f.literal(f.split(m.Mod(m, p)))
+}
+
+// literal returns the array literal of limbs.
+func (f *field) literal(limbs []uint64) string {
+	parts := make([]string, len(limbs))
+	for i, l := range limbs {
+		parts[i] = fmt.Sprintf("0x%0*x", f.word/4, l)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// typ returns the type of the limbs.
+func (f *field) typ() string {
+	return fmt.Sprintf("uint%d", f.word)
+}
+
+// op returns the name of the function of math/bits for the limbs, like Add64.
+func (f *field) op(name string) string {
+	return fmt.Sprintf("bits.%s%d", name, f.word)
+}
+
+// bytesLiteral returns a []byte literal holding b, over several lines.
+func bytesLiteral(b []byte) string {
+	var out strings.Builder
+	out.WriteString("[]byte{\n")
+	for i, c := range b {
+		fmt.Fprintf(&out, "0x%02x,", c)
+		if i%16 == 15 || i == len(b)-1 {
+			out.WriteString("\n")
+		} else {
+			out.WriteString(" ")
+		}
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// emitter accumulates the lines of an unrolled function body.
+type emitter struct {
+	bytes.Buffer
+}
+
+func (e *emitter) line(format string, args ...interface{}) {
+	fmt.Fprintf(e, format, args...)
+	e.WriteString("\n")
+}
+
+// vars returns the names prefix0 through prefix(n-1), separated by commas.
+func vars(prefix string, n int) string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s%d", prefix, i)
+	}
+	return strings.Join(names, ", ")
+}
+
+// conditionalSubtract emits the subtraction of p from the value held in the
+// variables s0 through s(limbs-1), with its top word in hi, and the
+// assignment of the result to out, unless the value was lower than p.
+func (f *field) conditionalSubtract(e *emitter, hi string) {
+	e.line("var %s, borrow "+f.typ(), vars("d", f.limbs))
+	for i, l := range f.p {
+		e.line("d%d, borrow = "+f.op("Sub")+"(s%d, %#x, borrow)", i, i, l)
+	}
+	e.line("_, borrow = "+f.op("Sub")+"(%s, 0, borrow)", hi)
+	e.line("// The value is kept if it was lower than p.")
+	e.line("mask := -borrow")
+	for i := range f.p {
+		e.line("out[%d] = d%d ^ (mask & (d%d ^ s%d))", i, i, i, i)
+	}
+}
+
+// addBody emits out = a + b mod p.
+func (f *field) addBody() string {
+	var e emitter
+	e.line("var %s, c "+f.typ(), vars("s", f.limbs))
+	for i := range f.p {
+		e.line("s%d, c = "+f.op("Add")+"(a[%d], b[%d], c)", i, i, i)
+	}
+	f.conditionalSubtract(&e, "c")
+	return e.String()
+}
+
+// lazy reports whether sums of two elements can be left unreduced when they
+// only feed multiplications, which then take inputs lower than 2p.
+//
+// For Montgomery multiplication, the accumulator then stays below 2p as long
+// as 4p <= R. For the Solinas reduction, the product only gets two more bits,
+// which the reduction folds back as well.
+func (f *field) lazy() bool {
+	return f.mersenne != 0 || f.p[f.limbs-1]>>(f.word-2) == 0
+}
+
+// addLazyBody emits out = a + b, without any reduction, for a and b lower
+// than p, whose sum fits in the limbs, as lazy reports.
+func (f *field) addLazyBody() string {
+	var e emitter
+	e.line("var c " + f.typ())
+	for i := range f.p {
+		if i == len(f.p)-1 {
+			e.line("out[%d], _ = "+f.op("Add")+"(a[%d], b[%d], c)", i, i, i)
+		} else {
+			e.line("out[%d], c = "+f.op("Add")+"(a[%d], b[%d], c)", i, i, i)
+		}
+	}
+	return e.String()
+}
+
+// subBody emits out = a - b mod p.
+func (f *field) subBody() string {
+	var e emitter
+	e.line("var %s, borrow "+f.typ(), vars("d", f.limbs))
+	for i := range f.p {
+		e.line("d%d, borrow = "+f.op("Sub")+"(a[%d], b[%d], borrow)", i, i, i)
+	}
+	e.line("// If a < b, p is added back.")
+	e.line("mask := -borrow")
+	e.line("var c " + f.typ())
+	for i, l := range f.p {
+		e.line("out[%d], c = "+f.op("Add")+"(d%d, %#x&mask, c)", i, i, l)
+	}
+	return e.String()
+}
+
+// mulBody emits out = a * b / R mod p, with the coarsely integrated operand
+// scanning method, unrolled, and specialized for the limbs of p.
+//
+// Each round adds a * b[i] to the accumulator s0 through s(limbs+1), then adds
+// the multiple m * p which makes it divisible by 2^word, and shifts it down by
+// a limb. The accumulator stays below 2p, so a final subtraction reduces it, even
+// for inputs lower than 2p, from AddLazy, since lazy checks that 4p <= R.
+func (f *field) mulBody(prefix string) string {
+	max := uint64(1)<<f.word - 1
+	n := f.limbs
+	var e emitter
+	e.line("var %s, c, m "+f.typ(), vars("s", n+2))
+	for i := 0; i < n; i++ {
+		e.line("")
+		e.line("// Round %d.", i)
+		e.line("c = 0")
+		for j := 0; j < n; j++ {
+			e.line("c, s%d = %sMulAdd(a[%d], b[%d], s%d, c)", j, prefix, j, i, j)
+		}
+		e.line("s%d, c = "+f.op("Add")+"(s%d, c, 0)", n, n)
+		e.line("s%d = c", n+1)
+
+		switch {
+		case f.p[0] == max:
+			// p = -1 mod 2^word, so m = s0, and s0 + m * p[0] = s0 * 2^word.
+			e.line("m = s0")
+			e.line("c = s0")
+		case f.pInv == 1:
+			e.line("m = s0")
+			e.line("c, _ = %sMulAdd(m, %#x, s0, 0)", prefix, f.p[0])
+		default:
+			e.line("m = s0 * %#x", f.pInv)
+			e.line("c, _ = %sMulAdd(m, %#x, s0, 0)", prefix, f.p[0])
+		}
+		for j := 1; j < n; j++ {
+			if f.p[j] == 0 {
+				e.line("s%d, c = "+f.op("Add")+"(s%d, c, 0)", j-1, j)
+			} else {
+				e.line("c, s%d = %sMulAdd(m, %#x, s%d, c)", j-1, prefix, f.p[j], j)
+			}
+		}
+		e.line("s%d, c = "+f.op("Add")+"(s%d, c, 0)", n-1, n)
+		e.line("s%d = s%d + c", n, n+1)
+	}
+	e.line("")
+	f.conditionalSubtract(&e, fmt.Sprintf("s%d", n))
+	return e.String()
+}
+
+// solinasMulBody emits out = a * b mod p, for p = 2^k - 1, with the schoolbook
+// product, unrolled, followed by the Solinas reduction: as 2^k = 1 mod p, the
+// bits of the product above k are added to those below.
+//
+// The inputs are lower than 2p, as they may come from AddLazy, so the product
+// is lower than 2^(2k+2), and the sum lower than 2^(k+3). Folding its top bits
+// back gives a value of at most p + 7, which a final subtraction reduces.
+func (f *field) solinasMulBody(prefix string) string {
+	n := f.limbs
+	top := uint(f.mersenne) - f.word*uint(n-1)
+	var e emitter
+	e.line("var %s, c "+f.typ(), vars("t", 2*n))
+	for i := 0; i < n; i++ {
+		e.line("")
+		e.line("// Row %d.", i)
+		e.line("c = 0")
+		for j := 0; j < n; j++ {
+			e.line("c, t%d = %sMulAdd(a[%d], b[%d], t%d, c)", i+j, prefix, j, i, i+j)
+		}
+		e.line("t%d = c", i+n)
+	}
+	e.line("")
+	e.line("// The product is split at bit %d, and its two halves are added.", f.mersenne)
+	e.line("var %s "+f.typ(), vars("s", n))
+	for i := 0; i < n; i++ {
+		hi := fmt.Sprintf("(t%d>>%d | t%d<<%d)", n-1+i, top, n+i, f.word-top)
+		switch {
+		case i == 0:
+			e.line("s0, c = "+f.op("Add")+"(t0, %s, 0)", hi)
+		case i < n-1:
+			e.line("s%d, c = "+f.op("Add")+"(t%d, %s, c)", i, i, hi)
+		default:
+			e.line("s%d = t%d&%#x + %s + c", i, i, uint64(1)<<top-1, hi)
+		}
+	}
+	e.line("")
+	e.line("// The bits above %d are folded back.", f.mersenne)
+	e.line("c = s%d >> %d", n-1, top)
+	e.line("s%d &= %#x", n-1, uint64(1)<<top-1)
+	// The bits can add up to more than 1, so they aren't a valid carry input.
+	e.line("s0, c = " + f.op("Add") + "(s0, c, 0)")
+	for i := 1; i < n; i++ {
+		e.line("s%d, c = "+f.op("Add")+"(s%d, 0, c)", i, i)
+	}
+	e.line("")
+	f.conditionalSubtract(&e, "c")
+	return e.String()
+}
+
+// unexported returns the name of the unexported identifiers for the curve
+// returned by fn, like p521 for P521.
+func unexported(fn string) string {
+	r, size := utf8.DecodeRuneInString(fn)
+	return string(unicode.ToLower(r)) + fn[size:]
+}
+
+// arches32 are the architectures which get the backend with 32-bit limbs, if
+// curvegen generates one: those with 32-bit registers, and WebAssembly, which
+// has no 64-bit multiplication with a 128-bit result, so that math/bits
+// emulates it.
+var arches32 = []string{"386", "arm", "mips", "mipsle", "wasm"}
+
+// generate returns the generated backend, the backend with 32-bit limbs, if
+// c.out32 is set, or nil, and their tests.
+func generate(c *config) (src, src32, test []byte, err error) {
+	data := c.templateData(newField(c.p, 64))
+	if src, err = execute(srcTemplate, data); err != nil {
+		return nil, nil, nil, err
+	}
+	if test, err = execute(testTemplate, data); err != nil {
+		return nil, nil, nil, err
+	}
+	if c.out32 == "" {
+		return src, nil, test, nil
+	}
+	if src32, err = execute(srcTemplate, c.templateData(newField(c.p, 32))); err != nil {
+		return nil, nil, nil, err
+	}
+	src = withBuildConstraint(src, "!"+strings.Join(arches32, ",!"))
+	src32 = withBuildConstraint(src32, strings.Join(arches32, " "))
+	return src, src32, test, nil
+}
+
+// templateData returns the values of the templates for the curve, over the
+// field f.
+func (c *config) templateData(f *field) map[string]interface{} {
+	byteLen := (c.p.BitLen() + 7) / 8
+	pMinus2 := new(big.Int).Sub(c.p, big.NewInt(2))
+	// The backends of the elliptic package itself can't refer to it by name,
+	// and their tests, which import elliptictest, go in elliptic_test.
+	internal := c.pkg == "elliptic"
+	qualifier, testPkg, testFunc := "elliptic.", c.pkg, c.fn
+	if internal {
+		qualifier, testPkg, testFunc = "", "elliptic_test", "elliptic."+c.fn
+	}
+	prefix := unexported(c.fn)
+	data := map[string]interface{}{
+		"Package":     c.pkg,
+		"Internal":    internal,
+		"Qualifier":   qualifier,
+		"TestPackage": testPkg,
+		"TestFunc":    testFunc,
+		"Func":        c.fn,
+		"Prefix":      prefix,
+		"Name":        c.name,
+		"BitSize":     c.p.BitLen(),
+		"ByteLen":     byteLen,
+		"Limbs":       f.limbs,
+		"Word":        f.typ(),
+		"WordBits":    f.word,
+		"WordBytes":   f.word / 8,
+		"TopBit":      f.word - 1,
+		"Uint":        fmt.Sprintf("Uint%d", f.word),
+		"PutUint":     fmt.Sprintf("PutUint%d", f.word),
+		"Mul":         f.op("Mul"),
+		"Add":         f.op("Add"),
+		"P":           bytesLiteral(c.p.Bytes()),
+		"N":           bytesLiteral(c.n.Bytes()),
+		"B":           bytesLiteral(c.b.Bytes()),
+		"Gx":          bytesLiteral(c.gx.Bytes()),
+		"Gy":          bytesLiteral(c.gy.Bytes()),
+		"PMinus2":     bytesLiteral(pMinus2.Bytes()),
+		"Montgomery":  f.mersenne == 0,
+		"One":         f.literal(f.split(f.r)),
+		"R2":          f.literal(f.split(f.r2)),
+		"BElement":    f.element(c.b, c.p),
+		"GxElement":   f.element(c.gx, c.p),
+		"GyElement":   f.element(c.gy, c.p),
+		"AddBody":     f.addBody(),
+		"SubBody":     f.subBody(),
+		"Lazy":        f.lazy(),
+		"AddLazy":     prefix + "Add",
+	}
+	if f.mersenne != 0 {
+		data["One"] = f.literal(f.split(big.NewInt(1)))
+		data["MulBody"] = f.solinasMulBody(prefix)
+	} else {
+		data["MulBody"] = f.mulBody(prefix)
+	}
+	if f.lazy() {
+		data["AddLazy"] = prefix + "AddLazy"
+		data["AddLazyBody"] = f.addLazyBody()
+	}
+	return data
+}
+
+// withBuildConstraint returns src with a build constraint after its first
+// comment. It is added after formatting, which would otherwise add a go:build
+// line as well.
+func withBuildConstraint(src []byte, constraint string) []byte {
+	i := bytes.Index(src, []byte("\n\n")) + 2
+	out := append([]byte{}, src[:i]...)
+	out = append(out, "// +build "+constraint+"\n\n"...)
+	return append(out, src[i:]...)
+}
+
+// execute executes a template, and formats the result.
+func execute(tmpl *template.Template, data interface{}) ([]byte, error) {
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("curvegen: generated invalid code: %v", err)
+	}
+	return src, nil
+}
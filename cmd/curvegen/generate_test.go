@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// directives returns the arguments of the go:generate directives of
+// curvegen in file.
+func directives(t *testing.T, file string) [][]string {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const prefix = "//go:generate go run github.com/cronokirby/ctcrypto/cmd/curvegen "
+	var out [][]string
+	for _, line := range strings.Split(string(src), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			out = append(out, strings.Fields(strings.TrimPrefix(line, prefix)))
+		}
+	}
+	if len(out) == 0 {
+		t.Fatalf("no go:generate directive in %s", file)
+	}
+	return out
+}
+
+// exampleArgs returns the arguments of the go:generate directive of the
+// example package.
+func exampleArgs(t *testing.T) []string {
+	return directives(t, "internal/example/example.go")[0]
+}
+
+// TestUpToDate checks that the backends generated by curvegen, in the example
+// package, and in the elliptic package, match what it generates now.
+func TestUpToDate(t *testing.T) {
+	for dir, file := range map[string]string{
+		"internal/example/": "internal/example/example.go",
+		"../../elliptic/":   "../../elliptic/elliptic.go",
+	} {
+		for _, args := range directives(t, file) {
+			c, err := parseArgs(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.out = dir + c.out
+			if err := c.validate(); err != nil {
+				t.Fatal(err)
+			}
+			src, src32, test, err := generate(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := map[string][]byte{c.out: src, strings.TrimSuffix(c.out, ".go") + "_test.go": test}
+			if c.out32 != "" {
+				files[dir+c.out32] = src32
+			}
+			for name, want := range files {
+				got, err := ioutil.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s is out of date, run go generate", name)
+				}
+			}
+		}
+	}
+}
+
+func TestNewField(t *testing.T) {
+	c, err := parseArgs(exampleArgs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newField(c.p, 64)
+	if f.limbs != 9 || f.pInv != 1 || f.p[0] != ^uint64(0) || f.p[8] != 0x1ff || f.mersenne != 521 {
+		t.Errorf("wrong constants for P-521: %d limbs, pInv = %#x", f.limbs, f.pInv)
+	}
+	// R = 2^576 = 2^55 * 2^521, and 2^521 = 1 mod p.
+	if f.r.Cmp(new(big.Int).Lsh(big.NewInt(1), 55)) != 0 {
+		t.Errorf("R mod p = %#x", f.r)
+	}
+	f = newField(c.p, 32)
+	if f.limbs != 17 || f.pInv != 1 || f.p[0] != 0xffffffff || f.p[16] != 0x1ff {
+		t.Errorf("wrong constants for P-521 with 32-bit limbs: %d limbs, pInv = %#x", f.limbs, f.pInv)
+	}
+	// R = 2^544 = 2^23 * 2^521.
+	if f.r.Cmp(new(big.Int).Lsh(big.NewInt(1), 23)) != 0 {
+		t.Errorf("R mod p = %#x with 32-bit limbs", f.r)
+	}
+	for p, k := range map[int64]int{23: 0, 31: 5, 127: 7, 257: 0} {
+		if f := newField(big.NewInt(p), 64); f.mersenne != k {
+			t.Errorf("newField(%d).mersenne = %d, want %d", p, f.mersenne, k)
+		}
+	}
+}
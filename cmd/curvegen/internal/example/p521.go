@@ -0,0 +1,640 @@
+// Code generated by curvegen. DO NOT EDIT.
+
+// +build !386,!arm,!mips,!mipsle,!wasm
+
+package example
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/safenum"
+)
+
+// P521 returns a Curve which implements P-521, the curve y² = x³ - 3x + b
+// of prime order over a 521-bit prime field.
+//
+// Multiple invocations of this function return the same value, so it can be
+// used for equality checks and switch statements.
+//
+// The cryptographic operations are implemented using constant-time algorithms.
+func P521() elliptic.Curve {
+	return p521
+}
+
+type p521Curve struct {
+	params *elliptic.CurveParams
+}
+
+var p521 = p521Curve{&elliptic.CurveParams{
+	P: safenum.ModulusFromBytes([]byte{
+		0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff,
+	}),
+	N: safenum.ModulusFromBytes([]byte{
+		0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xfa, 0x51, 0x86, 0x87, 0x83, 0xbf, 0x2f, 0x96, 0x6b, 0x7f, 0xcc, 0x01, 0x48, 0xf7, 0x09,
+		0xa5, 0xd0, 0x3b, 0xb5, 0xc9, 0xb8, 0x89, 0x9c, 0x47, 0xae, 0xbb, 0x6f, 0xb7, 0x1e, 0x91, 0x38,
+		0x64, 0x09,
+	}),
+	B: new(safenum.Nat).SetBytes([]byte{
+		0x51, 0x95, 0x3e, 0xb9, 0x61, 0x8e, 0x1c, 0x9a, 0x1f, 0x92, 0x9a, 0x21, 0xa0, 0xb6, 0x85, 0x40,
+		0xee, 0xa2, 0xda, 0x72, 0x5b, 0x99, 0xb3, 0x15, 0xf3, 0xb8, 0xb4, 0x89, 0x91, 0x8e, 0xf1, 0x09,
+		0xe1, 0x56, 0x19, 0x39, 0x51, 0xec, 0x7e, 0x93, 0x7b, 0x16, 0x52, 0xc0, 0xbd, 0x3b, 0xb1, 0xbf,
+		0x07, 0x35, 0x73, 0xdf, 0x88, 0x3d, 0x2c, 0x34, 0xf1, 0xef, 0x45, 0x1f, 0xd4, 0x6b, 0x50, 0x3f,
+		0x00,
+	}),
+	Gx: new(safenum.Nat).SetBytes([]byte{
+		0xc6, 0x85, 0x8e, 0x06, 0xb7, 0x04, 0x04, 0xe9, 0xcd, 0x9e, 0x3e, 0xcb, 0x66, 0x23, 0x95, 0xb4,
+		0x42, 0x9c, 0x64, 0x81, 0x39, 0x05, 0x3f, 0xb5, 0x21, 0xf8, 0x28, 0xaf, 0x60, 0x6b, 0x4d, 0x3d,
+		0xba, 0xa1, 0x4b, 0x5e, 0x77, 0xef, 0xe7, 0x59, 0x28, 0xfe, 0x1d, 0xc1, 0x27, 0xa2, 0xff, 0xa8,
+		0xde, 0x33, 0x48, 0xb3, 0xc1, 0x85, 0x6a, 0x42, 0x9b, 0xf9, 0x7e, 0x7e, 0x31, 0xc2, 0xe5, 0xbd,
+		0x66,
+	}),
+	Gy: new(safenum.Nat).SetBytes([]byte{
+		0x01, 0x18, 0x39, 0x29, 0x6a, 0x78, 0x9a, 0x3b, 0xc0, 0x04, 0x5c, 0x8a, 0x5f, 0xb4, 0x2c, 0x7d,
+		0x1b, 0xd9, 0x98, 0xf5, 0x44, 0x49, 0x57, 0x9b, 0x44, 0x68, 0x17, 0xaf, 0xbd, 0x17, 0x27, 0x3e,
+		0x66, 0x2c, 0x97, 0xee, 0x72, 0x99, 0x5e, 0xf4, 0x26, 0x40, 0xc5, 0x50, 0xb9, 0x01, 0x3f, 0xad,
+		0x07, 0x61, 0x35, 0x3c, 0x70, 0x86, 0xa2, 0x72, 0xc2, 0x40, 0x88, 0xbe, 0x94, 0x76, 0x9f, 0xd1,
+		0x66, 0x50,
+	}),
+	BitSize: 521,
+	Name:    "P-521",
+}}
+
+// p521P is the order of the field.
+var p521P = new(big.Int).SetBytes([]byte{
+	0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff,
+})
+
+func (curve p521Curve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+func (curve p521Curve) IsOnCurve(x, y *big.Int) bool {
+	var fx, fy p521Element
+	xValid := p521FromBig(&fx, x)
+	yValid := p521FromBig(&fy, y)
+	// y² = x³ - 3x + b
+	var y2, rhs p521Element
+	p521Mul(&y2, &fy, &fy)
+	p521Polynomial(&rhs, &fx)
+	return xValid && yValid && p521Equal(&y2, &rhs) == 1
+}
+
+func (curve p521Curve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p := p521FromAffine(x1, y1)
+	return p.add(p, p521FromAffine(x2, y2)).affine()
+}
+
+func (curve p521Curve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	p := p521FromAffine(x1, y1)
+	return p.double(p).affine()
+}
+
+func (curve p521Curve) ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := p521FromAffine(x1, y1)
+	return p.scalarMult(p, k).affine()
+}
+
+func (curve p521Curve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := &p521Point{p521Gx, p521Gy, p521One}
+	return p.scalarMult(p, k).affine()
+}
+
+// p521Element is an element of the field, as 9 64-bit limbs in
+// little-endian order. It is always fully reduced, except for the results of
+// p521AddLazy.
+type p521Element [9]uint64
+
+var (
+	// p521One is 1.
+	p521One = p521Element{0x0000000000000001, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000, 0x0000000000000000}
+	// p521B is the constant of the curve equation.
+	p521B = p521Element{0xef451fd46b503f00, 0x3573df883d2c34f1, 0x1652c0bd3bb1bf07, 0x56193951ec7e937b, 0xb8b489918ef109e1, 0xa2da725b99b315f3, 0x929a21a0b68540ee, 0x953eb9618e1c9a1f, 0x0000000000000051}
+	// p521Gx and p521Gy are the coordinates of the generator.
+	p521Gx = p521Element{0xf97e7e31c2e5bd66, 0x3348b3c1856a429b, 0xfe1dc127a2ffa8de, 0xa14b5e77efe75928, 0xf828af606b4d3dba, 0x9c648139053fb521, 0x9e3ecb662395b442, 0x858e06b70404e9cd, 0x00000000000000c6}
+	p521Gy = p521Element{0x88be94769fd16650, 0x353c7086a272c240, 0xc550b9013fad0761, 0x97ee72995ef42640, 0x17afbd17273e662c, 0x98f54449579b4468, 0x5c8a5fb42c7d1bd9, 0x39296a789a3bc004, 0x0000000000000118}
+)
+
+// p521FromBig sets out to x mod p, and reports whether x was in [0, p).
+func p521FromBig(out *p521Element, x *big.Int) bool {
+	reduced := x.Sign() >= 0 && x.Cmp(p521P) < 0
+	var buf [9 * 8]byte
+	new(big.Int).Mod(x, p521P).FillBytes(buf[:])
+	for i := range out {
+		out[i] = binary.BigEndian.Uint64(buf[len(buf)-8*(i+1):])
+	}
+	return reduced
+}
+
+// p521ToBig returns the integer in [0, p) which a represents.
+func p521ToBig(a *p521Element) *big.Int {
+	t := *a
+	var buf [9 * 8]byte
+	for i := range t {
+		binary.BigEndian.PutUint64(buf[len(buf)-8*(i+1):], t[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// p521MulAdd returns x * y + a + b, as two limbs.
+func p521MulAdd(x, y, a, b uint64) (hi, lo uint64) {
+	hi, lo = bits.Mul64(x, y)
+	var c uint64
+	lo, c = bits.Add64(lo, a, 0)
+	hi += c
+	lo, c = bits.Add64(lo, b, 0)
+	hi += c
+	return hi, lo
+}
+
+// p521Add sets out = a + b.
+func p521Add(out, a, b *p521Element) {
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8, c uint64
+	s0, c = bits.Add64(a[0], b[0], c)
+	s1, c = bits.Add64(a[1], b[1], c)
+	s2, c = bits.Add64(a[2], b[2], c)
+	s3, c = bits.Add64(a[3], b[3], c)
+	s4, c = bits.Add64(a[4], b[4], c)
+	s5, c = bits.Add64(a[5], b[5], c)
+	s6, c = bits.Add64(a[6], b[6], c)
+	s7, c = bits.Add64(a[7], b[7], c)
+	s8, c = bits.Add64(a[8], b[8], c)
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, borrow uint64
+	d0, borrow = bits.Sub64(s0, 0xffffffffffffffff, borrow)
+	d1, borrow = bits.Sub64(s1, 0xffffffffffffffff, borrow)
+	d2, borrow = bits.Sub64(s2, 0xffffffffffffffff, borrow)
+	d3, borrow = bits.Sub64(s3, 0xffffffffffffffff, borrow)
+	d4, borrow = bits.Sub64(s4, 0xffffffffffffffff, borrow)
+	d5, borrow = bits.Sub64(s5, 0xffffffffffffffff, borrow)
+	d6, borrow = bits.Sub64(s6, 0xffffffffffffffff, borrow)
+	d7, borrow = bits.Sub64(s7, 0xffffffffffffffff, borrow)
+	d8, borrow = bits.Sub64(s8, 0x1ff, borrow)
+	_, borrow = bits.Sub64(c, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+}
+
+// p521AddLazy sets out = a + b, without reducing it, for a and b lower
+// than p. The result, lower than 2p, must only be passed to p521Mul.
+func p521AddLazy(out, a, b *p521Element) {
+	var c uint64
+	out[0], c = bits.Add64(a[0], b[0], c)
+	out[1], c = bits.Add64(a[1], b[1], c)
+	out[2], c = bits.Add64(a[2], b[2], c)
+	out[3], c = bits.Add64(a[3], b[3], c)
+	out[4], c = bits.Add64(a[4], b[4], c)
+	out[5], c = bits.Add64(a[5], b[5], c)
+	out[6], c = bits.Add64(a[6], b[6], c)
+	out[7], c = bits.Add64(a[7], b[7], c)
+	out[8], _ = bits.Add64(a[8], b[8], c)
+}
+
+// p521Sub sets out = a - b.
+func p521Sub(out, a, b *p521Element) {
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, borrow uint64
+	d0, borrow = bits.Sub64(a[0], b[0], borrow)
+	d1, borrow = bits.Sub64(a[1], b[1], borrow)
+	d2, borrow = bits.Sub64(a[2], b[2], borrow)
+	d3, borrow = bits.Sub64(a[3], b[3], borrow)
+	d4, borrow = bits.Sub64(a[4], b[4], borrow)
+	d5, borrow = bits.Sub64(a[5], b[5], borrow)
+	d6, borrow = bits.Sub64(a[6], b[6], borrow)
+	d7, borrow = bits.Sub64(a[7], b[7], borrow)
+	d8, borrow = bits.Sub64(a[8], b[8], borrow)
+	// If a < b, p is added back.
+	mask := -borrow
+	var c uint64
+	out[0], c = bits.Add64(d0, 0xffffffffffffffff&mask, c)
+	out[1], c = bits.Add64(d1, 0xffffffffffffffff&mask, c)
+	out[2], c = bits.Add64(d2, 0xffffffffffffffff&mask, c)
+	out[3], c = bits.Add64(d3, 0xffffffffffffffff&mask, c)
+	out[4], c = bits.Add64(d4, 0xffffffffffffffff&mask, c)
+	out[5], c = bits.Add64(d5, 0xffffffffffffffff&mask, c)
+	out[6], c = bits.Add64(d6, 0xffffffffffffffff&mask, c)
+	out[7], c = bits.Add64(d7, 0xffffffffffffffff&mask, c)
+	out[8], c = bits.Add64(d8, 0x1ff&mask, c)
+}
+
+// p521Mul sets out = a * b, with the Solinas reduction for
+// p = 2^521 - 1.
+//
+// a and b may be the unreduced results of p521AddLazy.
+func p521Mul(out, a, b *p521Element) {
+	var t0, t1, t2, t3, t4, t5, t6, t7, t8, t9, t10, t11, t12, t13, t14, t15, t16, t17, c uint64
+
+	// Row 0.
+	c = 0
+	c, t0 = p521MulAdd(a[0], b[0], t0, c)
+	c, t1 = p521MulAdd(a[1], b[0], t1, c)
+	c, t2 = p521MulAdd(a[2], b[0], t2, c)
+	c, t3 = p521MulAdd(a[3], b[0], t3, c)
+	c, t4 = p521MulAdd(a[4], b[0], t4, c)
+	c, t5 = p521MulAdd(a[5], b[0], t5, c)
+	c, t6 = p521MulAdd(a[6], b[0], t6, c)
+	c, t7 = p521MulAdd(a[7], b[0], t7, c)
+	c, t8 = p521MulAdd(a[8], b[0], t8, c)
+	t9 = c
+
+	// Row 1.
+	c = 0
+	c, t1 = p521MulAdd(a[0], b[1], t1, c)
+	c, t2 = p521MulAdd(a[1], b[1], t2, c)
+	c, t3 = p521MulAdd(a[2], b[1], t3, c)
+	c, t4 = p521MulAdd(a[3], b[1], t4, c)
+	c, t5 = p521MulAdd(a[4], b[1], t5, c)
+	c, t6 = p521MulAdd(a[5], b[1], t6, c)
+	c, t7 = p521MulAdd(a[6], b[1], t7, c)
+	c, t8 = p521MulAdd(a[7], b[1], t8, c)
+	c, t9 = p521MulAdd(a[8], b[1], t9, c)
+	t10 = c
+
+	// Row 2.
+	c = 0
+	c, t2 = p521MulAdd(a[0], b[2], t2, c)
+	c, t3 = p521MulAdd(a[1], b[2], t3, c)
+	c, t4 = p521MulAdd(a[2], b[2], t4, c)
+	c, t5 = p521MulAdd(a[3], b[2], t5, c)
+	c, t6 = p521MulAdd(a[4], b[2], t6, c)
+	c, t7 = p521MulAdd(a[5], b[2], t7, c)
+	c, t8 = p521MulAdd(a[6], b[2], t8, c)
+	c, t9 = p521MulAdd(a[7], b[2], t9, c)
+	c, t10 = p521MulAdd(a[8], b[2], t10, c)
+	t11 = c
+
+	// Row 3.
+	c = 0
+	c, t3 = p521MulAdd(a[0], b[3], t3, c)
+	c, t4 = p521MulAdd(a[1], b[3], t4, c)
+	c, t5 = p521MulAdd(a[2], b[3], t5, c)
+	c, t6 = p521MulAdd(a[3], b[3], t6, c)
+	c, t7 = p521MulAdd(a[4], b[3], t7, c)
+	c, t8 = p521MulAdd(a[5], b[3], t8, c)
+	c, t9 = p521MulAdd(a[6], b[3], t9, c)
+	c, t10 = p521MulAdd(a[7], b[3], t10, c)
+	c, t11 = p521MulAdd(a[8], b[3], t11, c)
+	t12 = c
+
+	// Row 4.
+	c = 0
+	c, t4 = p521MulAdd(a[0], b[4], t4, c)
+	c, t5 = p521MulAdd(a[1], b[4], t5, c)
+	c, t6 = p521MulAdd(a[2], b[4], t6, c)
+	c, t7 = p521MulAdd(a[3], b[4], t7, c)
+	c, t8 = p521MulAdd(a[4], b[4], t8, c)
+	c, t9 = p521MulAdd(a[5], b[4], t9, c)
+	c, t10 = p521MulAdd(a[6], b[4], t10, c)
+	c, t11 = p521MulAdd(a[7], b[4], t11, c)
+	c, t12 = p521MulAdd(a[8], b[4], t12, c)
+	t13 = c
+
+	// Row 5.
+	c = 0
+	c, t5 = p521MulAdd(a[0], b[5], t5, c)
+	c, t6 = p521MulAdd(a[1], b[5], t6, c)
+	c, t7 = p521MulAdd(a[2], b[5], t7, c)
+	c, t8 = p521MulAdd(a[3], b[5], t8, c)
+	c, t9 = p521MulAdd(a[4], b[5], t9, c)
+	c, t10 = p521MulAdd(a[5], b[5], t10, c)
+	c, t11 = p521MulAdd(a[6], b[5], t11, c)
+	c, t12 = p521MulAdd(a[7], b[5], t12, c)
+	c, t13 = p521MulAdd(a[8], b[5], t13, c)
+	t14 = c
+
+	// Row 6.
+	c = 0
+	c, t6 = p521MulAdd(a[0], b[6], t6, c)
+	c, t7 = p521MulAdd(a[1], b[6], t7, c)
+	c, t8 = p521MulAdd(a[2], b[6], t8, c)
+	c, t9 = p521MulAdd(a[3], b[6], t9, c)
+	c, t10 = p521MulAdd(a[4], b[6], t10, c)
+	c, t11 = p521MulAdd(a[5], b[6], t11, c)
+	c, t12 = p521MulAdd(a[6], b[6], t12, c)
+	c, t13 = p521MulAdd(a[7], b[6], t13, c)
+	c, t14 = p521MulAdd(a[8], b[6], t14, c)
+	t15 = c
+
+	// Row 7.
+	c = 0
+	c, t7 = p521MulAdd(a[0], b[7], t7, c)
+	c, t8 = p521MulAdd(a[1], b[7], t8, c)
+	c, t9 = p521MulAdd(a[2], b[7], t9, c)
+	c, t10 = p521MulAdd(a[3], b[7], t10, c)
+	c, t11 = p521MulAdd(a[4], b[7], t11, c)
+	c, t12 = p521MulAdd(a[5], b[7], t12, c)
+	c, t13 = p521MulAdd(a[6], b[7], t13, c)
+	c, t14 = p521MulAdd(a[7], b[7], t14, c)
+	c, t15 = p521MulAdd(a[8], b[7], t15, c)
+	t16 = c
+
+	// Row 8.
+	c = 0
+	c, t8 = p521MulAdd(a[0], b[8], t8, c)
+	c, t9 = p521MulAdd(a[1], b[8], t9, c)
+	c, t10 = p521MulAdd(a[2], b[8], t10, c)
+	c, t11 = p521MulAdd(a[3], b[8], t11, c)
+	c, t12 = p521MulAdd(a[4], b[8], t12, c)
+	c, t13 = p521MulAdd(a[5], b[8], t13, c)
+	c, t14 = p521MulAdd(a[6], b[8], t14, c)
+	c, t15 = p521MulAdd(a[7], b[8], t15, c)
+	c, t16 = p521MulAdd(a[8], b[8], t16, c)
+	t17 = c
+
+	// The product is split at bit 521, and its two halves are added.
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8 uint64
+	s0, c = bits.Add64(t0, (t8>>9 | t9<<55), 0)
+	s1, c = bits.Add64(t1, (t9>>9 | t10<<55), c)
+	s2, c = bits.Add64(t2, (t10>>9 | t11<<55), c)
+	s3, c = bits.Add64(t3, (t11>>9 | t12<<55), c)
+	s4, c = bits.Add64(t4, (t12>>9 | t13<<55), c)
+	s5, c = bits.Add64(t5, (t13>>9 | t14<<55), c)
+	s6, c = bits.Add64(t6, (t14>>9 | t15<<55), c)
+	s7, c = bits.Add64(t7, (t15>>9 | t16<<55), c)
+	s8 = t8&0x1ff + (t16>>9 | t17<<55) + c
+
+	// The bits above 521 are folded back.
+	c = s8 >> 9
+	s8 &= 0x1ff
+	s0, c = bits.Add64(s0, c, 0)
+	s1, c = bits.Add64(s1, 0, c)
+	s2, c = bits.Add64(s2, 0, c)
+	s3, c = bits.Add64(s3, 0, c)
+	s4, c = bits.Add64(s4, 0, c)
+	s5, c = bits.Add64(s5, 0, c)
+	s6, c = bits.Add64(s6, 0, c)
+	s7, c = bits.Add64(s7, 0, c)
+	s8, c = bits.Add64(s8, 0, c)
+
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, borrow uint64
+	d0, borrow = bits.Sub64(s0, 0xffffffffffffffff, borrow)
+	d1, borrow = bits.Sub64(s1, 0xffffffffffffffff, borrow)
+	d2, borrow = bits.Sub64(s2, 0xffffffffffffffff, borrow)
+	d3, borrow = bits.Sub64(s3, 0xffffffffffffffff, borrow)
+	d4, borrow = bits.Sub64(s4, 0xffffffffffffffff, borrow)
+	d5, borrow = bits.Sub64(s5, 0xffffffffffffffff, borrow)
+	d6, borrow = bits.Sub64(s6, 0xffffffffffffffff, borrow)
+	d7, borrow = bits.Sub64(s7, 0xffffffffffffffff, borrow)
+	d8, borrow = bits.Sub64(s8, 0x1ff, borrow)
+	_, borrow = bits.Sub64(c, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+}
+
+// p521IsZero returns 1 if a is zero, and 0 otherwise.
+func p521IsZero(a *p521Element) uint64 {
+	var acc uint64
+	for _, l := range a {
+		acc |= l
+	}
+	// The top bit of acc | -acc is set unless acc is zero.
+	return (acc|-acc)>>63 ^ 1
+}
+
+// p521Equal returns 1 if a and b are equal, and 0 otherwise.
+func p521Equal(a, b *p521Element) uint64 {
+	var d p521Element
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return p521IsZero(&d)
+}
+
+// p521Select sets out to a if control is 1, and to b if it is 0.
+func p521Select(out, a, b *p521Element, control uint64) {
+	mask := -control
+	for i := range out {
+		out[i] = b[i] ^ (mask & (a[i] ^ b[i]))
+	}
+}
+
+// p521PMinus2 is p - 2, in big-endian order.
+var p521PMinus2 = []byte{
+	0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xfd,
+}
+
+// p521Invert sets out = 1/a, or 0 if a is 0, as a^(p-2). The exponent
+// is public, so branching on its bits doesn't leak anything about a.
+func p521Invert(out, a *p521Element) {
+	in := *a
+	r := p521One
+	for _, b := range p521PMinus2 {
+		for i := 7; i >= 0; i-- {
+			p521Mul(&r, &r, &r)
+			if b>>i&1 == 1 {
+				p521Mul(&r, &r, &in)
+			}
+		}
+	}
+	*out = r
+}
+
+// p521Polynomial sets out = x³ - 3x + b.
+func p521Polynomial(out, x *p521Element) {
+	var x3, threeX p521Element
+	p521Mul(&x3, x, x)
+	p521Mul(&x3, &x3, x)
+	p521Add(&threeX, x, x)
+	p521Add(&threeX, &threeX, x)
+	p521Sub(&x3, &x3, &threeX)
+	p521Add(out, &x3, &p521B)
+}
+
+// p521Point is a point in projective coordinates (X:Y:Z), representing
+// (X/Z, Y/Z), or the point at infinity, (0:1:0), if Z is 0.
+type p521Point struct {
+	x, y, z p521Element
+}
+
+// p521FromAffine returns the point (x, y), where (0, 0) is the point at
+// infinity, as in the elliptic package.
+func p521FromAffine(x, y *big.Int) *p521Point {
+	p := &p521Point{y: p521One}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return p
+	}
+	p521FromBig(&p.x, x)
+	p521FromBig(&p.y, y)
+	p.z = p521One
+	return p
+}
+
+// affine returns the affine coordinates of p, or (0, 0) for the point at
+// infinity.
+func (p *p521Point) affine() (x, y *big.Int) {
+	if p521IsZero(&p.z) == 1 {
+		return new(big.Int), new(big.Int)
+	}
+	var zInv, ax, ay p521Element
+	p521Invert(&zInv, &p.z)
+	p521Mul(&ax, &p.x, &zInv)
+	p521Mul(&ay, &p.y, &zInv)
+	return p521ToBig(&ax), p521ToBig(&ay)
+}
+
+// add sets q = p1 + p2, and returns q, with the complete addition formula for
+// a = -3 of Renes, Costello, and Batina, algorithm 4.
+func (q *p521Point) add(p1, p2 *p521Point) *p521Point {
+	var t0, t1, t2, t3, t4, x3, y3, z3 p521Element
+	p521Mul(&t0, &p1.x, &p2.x)
+	p521Mul(&t1, &p1.y, &p2.y)
+	p521Mul(&t2, &p1.z, &p2.z)
+	p521AddLazy(&t3, &p1.x, &p1.y)
+	p521AddLazy(&t4, &p2.x, &p2.y)
+	p521Mul(&t3, &t3, &t4)
+	p521Add(&t4, &t0, &t1)
+	p521Sub(&t3, &t3, &t4)
+	p521AddLazy(&t4, &p1.y, &p1.z)
+	p521AddLazy(&x3, &p2.y, &p2.z)
+	p521Mul(&t4, &t4, &x3)
+	p521Add(&x3, &t1, &t2)
+	p521Sub(&t4, &t4, &x3)
+	p521AddLazy(&x3, &p1.x, &p1.z)
+	p521AddLazy(&y3, &p2.x, &p2.z)
+	p521Mul(&x3, &x3, &y3)
+	p521Add(&y3, &t0, &t2)
+	p521Sub(&y3, &x3, &y3)
+	p521Mul(&z3, &p521B, &t2)
+	p521Sub(&x3, &y3, &z3)
+	p521Add(&z3, &x3, &x3)
+	p521Add(&x3, &x3, &z3)
+	p521Sub(&z3, &t1, &x3)
+	p521Add(&x3, &t1, &x3)
+	p521Mul(&y3, &p521B, &y3)
+	p521Add(&t1, &t2, &t2)
+	p521Add(&t2, &t1, &t2)
+	p521Sub(&y3, &y3, &t2)
+	p521Sub(&y3, &y3, &t0)
+	p521Add(&t1, &y3, &y3)
+	p521AddLazy(&y3, &t1, &y3)
+	p521Add(&t1, &t0, &t0)
+	p521Add(&t0, &t1, &t0)
+	p521Sub(&t0, &t0, &t2)
+	p521Mul(&t1, &t4, &y3)
+	p521Mul(&t2, &t0, &y3)
+	p521Mul(&y3, &x3, &z3)
+	p521Add(&y3, &y3, &t2)
+	p521Mul(&x3, &t3, &x3)
+	p521Sub(&x3, &x3, &t1)
+	p521Mul(&z3, &t4, &z3)
+	p521Mul(&t1, &t3, &t0)
+	p521Add(&z3, &z3, &t1)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// double sets q = 2p, and returns q, with the doubling formula for a = -3 of
+// Renes, Costello, and Batina, algorithm 6.
+func (q *p521Point) double(p *p521Point) *p521Point {
+	var t0, t1, t2, t3, x3, y3, z3 p521Element
+	p521Mul(&t0, &p.x, &p.x)
+	p521Mul(&t1, &p.y, &p.y)
+	p521Mul(&t2, &p.z, &p.z)
+	p521Mul(&t3, &p.x, &p.y)
+	p521AddLazy(&t3, &t3, &t3)
+	p521Mul(&z3, &p.x, &p.z)
+	p521Add(&z3, &z3, &z3)
+	p521Mul(&y3, &p521B, &t2)
+	p521Sub(&y3, &y3, &z3)
+	p521Add(&x3, &y3, &y3)
+	p521Add(&y3, &x3, &y3)
+	p521Sub(&x3, &t1, &y3)
+	p521AddLazy(&y3, &t1, &y3)
+	p521Mul(&y3, &x3, &y3)
+	p521Mul(&x3, &x3, &t3)
+	p521Add(&t3, &t2, &t2)
+	p521Add(&t2, &t2, &t3)
+	p521Mul(&z3, &p521B, &z3)
+	p521Sub(&z3, &z3, &t2)
+	p521Sub(&z3, &z3, &t0)
+	p521Add(&t3, &z3, &z3)
+	p521AddLazy(&z3, &z3, &t3)
+	p521Add(&t3, &t0, &t0)
+	p521Add(&t0, &t3, &t0)
+	p521Sub(&t0, &t0, &t2)
+	p521Mul(&t0, &t0, &z3)
+	p521Add(&y3, &y3, &t0)
+	p521Mul(&t0, &p.y, &p.z)
+	p521AddLazy(&t0, &t0, &t0)
+	p521Mul(&z3, &t0, &z3)
+	p521Sub(&x3, &x3, &z3)
+	p521Mul(&z3, &t0, &t1)
+	p521Add(&z3, &z3, &z3)
+	p521Add(&z3, &z3, &z3)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// selectPoint sets q to a if control is 1, and leaves it unchanged if it is 0.
+func (q *p521Point) selectPoint(a *p521Point, control uint64) {
+	p521Select(&q.x, &a.x, &q.x, control)
+	p521Select(&q.y, &a.y, &q.y, control)
+	p521Select(&q.z, &a.z, &q.z, control)
+}
+
+// scalarMult sets q = k * p, and returns q, with a fixed window of 4 bits.
+// Every window does the same doublings and addition, and reads the whole
+// table, whatever the value of k.
+func (q *p521Point) scalarMult(p *p521Point, k []byte) *p521Point {
+	var table [16]p521Point
+	table[0].y = p521One
+	table[1] = *p
+	for i := 2; i < 16; i += 2 {
+		table[i].double(&table[i/2])
+		table[i+1].add(&table[i], p)
+	}
+	out := p521Point{y: p521One}
+	var t p521Point
+	for _, b := range k {
+		for shift := 4; shift >= 0; shift -= 4 {
+			w := b >> shift & 0xf
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			t = table[0]
+			for j := 1; j < 16; j++ {
+				t.selectPoint(&table[j], uint64(subtle.ConstantTimeByteEq(uint8(j), w)))
+			}
+			out.add(&out, &t)
+		}
+	}
+	*q = out
+	return q
+}
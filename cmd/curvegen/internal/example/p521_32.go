@@ -0,0 +1,960 @@
+// Code generated by curvegen. DO NOT EDIT.
+
+// +build 386 arm mips mipsle wasm
+
+package example
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/safenum"
+)
+
+// P521 returns a Curve which implements P-521, the curve y² = x³ - 3x + b
+// of prime order over a 521-bit prime field.
+//
+// Multiple invocations of this function return the same value, so it can be
+// used for equality checks and switch statements.
+//
+// The cryptographic operations are implemented using constant-time algorithms.
+func P521() elliptic.Curve {
+	return p521
+}
+
+type p521Curve struct {
+	params *elliptic.CurveParams
+}
+
+var p521 = p521Curve{&elliptic.CurveParams{
+	P: safenum.ModulusFromBytes([]byte{
+		0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff,
+	}),
+	N: safenum.ModulusFromBytes([]byte{
+		0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xfa, 0x51, 0x86, 0x87, 0x83, 0xbf, 0x2f, 0x96, 0x6b, 0x7f, 0xcc, 0x01, 0x48, 0xf7, 0x09,
+		0xa5, 0xd0, 0x3b, 0xb5, 0xc9, 0xb8, 0x89, 0x9c, 0x47, 0xae, 0xbb, 0x6f, 0xb7, 0x1e, 0x91, 0x38,
+		0x64, 0x09,
+	}),
+	B: new(safenum.Nat).SetBytes([]byte{
+		0x51, 0x95, 0x3e, 0xb9, 0x61, 0x8e, 0x1c, 0x9a, 0x1f, 0x92, 0x9a, 0x21, 0xa0, 0xb6, 0x85, 0x40,
+		0xee, 0xa2, 0xda, 0x72, 0x5b, 0x99, 0xb3, 0x15, 0xf3, 0xb8, 0xb4, 0x89, 0x91, 0x8e, 0xf1, 0x09,
+		0xe1, 0x56, 0x19, 0x39, 0x51, 0xec, 0x7e, 0x93, 0x7b, 0x16, 0x52, 0xc0, 0xbd, 0x3b, 0xb1, 0xbf,
+		0x07, 0x35, 0x73, 0xdf, 0x88, 0x3d, 0x2c, 0x34, 0xf1, 0xef, 0x45, 0x1f, 0xd4, 0x6b, 0x50, 0x3f,
+		0x00,
+	}),
+	Gx: new(safenum.Nat).SetBytes([]byte{
+		0xc6, 0x85, 0x8e, 0x06, 0xb7, 0x04, 0x04, 0xe9, 0xcd, 0x9e, 0x3e, 0xcb, 0x66, 0x23, 0x95, 0xb4,
+		0x42, 0x9c, 0x64, 0x81, 0x39, 0x05, 0x3f, 0xb5, 0x21, 0xf8, 0x28, 0xaf, 0x60, 0x6b, 0x4d, 0x3d,
+		0xba, 0xa1, 0x4b, 0x5e, 0x77, 0xef, 0xe7, 0x59, 0x28, 0xfe, 0x1d, 0xc1, 0x27, 0xa2, 0xff, 0xa8,
+		0xde, 0x33, 0x48, 0xb3, 0xc1, 0x85, 0x6a, 0x42, 0x9b, 0xf9, 0x7e, 0x7e, 0x31, 0xc2, 0xe5, 0xbd,
+		0x66,
+	}),
+	Gy: new(safenum.Nat).SetBytes([]byte{
+		0x01, 0x18, 0x39, 0x29, 0x6a, 0x78, 0x9a, 0x3b, 0xc0, 0x04, 0x5c, 0x8a, 0x5f, 0xb4, 0x2c, 0x7d,
+		0x1b, 0xd9, 0x98, 0xf5, 0x44, 0x49, 0x57, 0x9b, 0x44, 0x68, 0x17, 0xaf, 0xbd, 0x17, 0x27, 0x3e,
+		0x66, 0x2c, 0x97, 0xee, 0x72, 0x99, 0x5e, 0xf4, 0x26, 0x40, 0xc5, 0x50, 0xb9, 0x01, 0x3f, 0xad,
+		0x07, 0x61, 0x35, 0x3c, 0x70, 0x86, 0xa2, 0x72, 0xc2, 0x40, 0x88, 0xbe, 0x94, 0x76, 0x9f, 0xd1,
+		0x66, 0x50,
+	}),
+	BitSize: 521,
+	Name:    "P-521",
+}}
+
+// p521P is the order of the field.
+var p521P = new(big.Int).SetBytes([]byte{
+	0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff,
+})
+
+func (curve p521Curve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+func (curve p521Curve) IsOnCurve(x, y *big.Int) bool {
+	var fx, fy p521Element
+	xValid := p521FromBig(&fx, x)
+	yValid := p521FromBig(&fy, y)
+	// y² = x³ - 3x + b
+	var y2, rhs p521Element
+	p521Mul(&y2, &fy, &fy)
+	p521Polynomial(&rhs, &fx)
+	return xValid && yValid && p521Equal(&y2, &rhs) == 1
+}
+
+func (curve p521Curve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p := p521FromAffine(x1, y1)
+	return p.add(p, p521FromAffine(x2, y2)).affine()
+}
+
+func (curve p521Curve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	p := p521FromAffine(x1, y1)
+	return p.double(p).affine()
+}
+
+func (curve p521Curve) ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := p521FromAffine(x1, y1)
+	return p.scalarMult(p, k).affine()
+}
+
+func (curve p521Curve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := &p521Point{p521Gx, p521Gy, p521One}
+	return p.scalarMult(p, k).affine()
+}
+
+// p521Element is an element of the field, as 17 32-bit limbs in
+// little-endian order. It is always fully reduced, except for the results of
+// p521AddLazy.
+type p521Element [17]uint32
+
+var (
+	// p521One is 1.
+	p521One = p521Element{0x00000001, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000}
+	// p521B is the constant of the curve equation.
+	p521B = p521Element{0x6b503f00, 0xef451fd4, 0x3d2c34f1, 0x3573df88, 0x3bb1bf07, 0x1652c0bd, 0xec7e937b, 0x56193951, 0x8ef109e1, 0xb8b48991, 0x99b315f3, 0xa2da725b, 0xb68540ee, 0x929a21a0, 0x8e1c9a1f, 0x953eb961, 0x00000051}
+	// p521Gx and p521Gy are the coordinates of the generator.
+	p521Gx = p521Element{0xc2e5bd66, 0xf97e7e31, 0x856a429b, 0x3348b3c1, 0xa2ffa8de, 0xfe1dc127, 0xefe75928, 0xa14b5e77, 0x6b4d3dba, 0xf828af60, 0x053fb521, 0x9c648139, 0x2395b442, 0x9e3ecb66, 0x0404e9cd, 0x858e06b7, 0x000000c6}
+	p521Gy = p521Element{0x9fd16650, 0x88be9476, 0xa272c240, 0x353c7086, 0x3fad0761, 0xc550b901, 0x5ef42640, 0x97ee7299, 0x273e662c, 0x17afbd17, 0x579b4468, 0x98f54449, 0x2c7d1bd9, 0x5c8a5fb4, 0x9a3bc004, 0x39296a78, 0x00000118}
+)
+
+// p521FromBig sets out to x mod p, and reports whether x was in [0, p).
+func p521FromBig(out *p521Element, x *big.Int) bool {
+	reduced := x.Sign() >= 0 && x.Cmp(p521P) < 0
+	var buf [17 * 4]byte
+	new(big.Int).Mod(x, p521P).FillBytes(buf[:])
+	for i := range out {
+		out[i] = binary.BigEndian.Uint32(buf[len(buf)-4*(i+1):])
+	}
+	return reduced
+}
+
+// p521ToBig returns the integer in [0, p) which a represents.
+func p521ToBig(a *p521Element) *big.Int {
+	t := *a
+	var buf [17 * 4]byte
+	for i := range t {
+		binary.BigEndian.PutUint32(buf[len(buf)-4*(i+1):], t[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// p521MulAdd returns x * y + a + b, as two limbs.
+func p521MulAdd(x, y, a, b uint32) (hi, lo uint32) {
+	hi, lo = bits.Mul32(x, y)
+	var c uint32
+	lo, c = bits.Add32(lo, a, 0)
+	hi += c
+	lo, c = bits.Add32(lo, b, 0)
+	hi += c
+	return hi, lo
+}
+
+// p521Add sets out = a + b.
+func p521Add(out, a, b *p521Element) {
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15, s16, c uint32
+	s0, c = bits.Add32(a[0], b[0], c)
+	s1, c = bits.Add32(a[1], b[1], c)
+	s2, c = bits.Add32(a[2], b[2], c)
+	s3, c = bits.Add32(a[3], b[3], c)
+	s4, c = bits.Add32(a[4], b[4], c)
+	s5, c = bits.Add32(a[5], b[5], c)
+	s6, c = bits.Add32(a[6], b[6], c)
+	s7, c = bits.Add32(a[7], b[7], c)
+	s8, c = bits.Add32(a[8], b[8], c)
+	s9, c = bits.Add32(a[9], b[9], c)
+	s10, c = bits.Add32(a[10], b[10], c)
+	s11, c = bits.Add32(a[11], b[11], c)
+	s12, c = bits.Add32(a[12], b[12], c)
+	s13, c = bits.Add32(a[13], b[13], c)
+	s14, c = bits.Add32(a[14], b[14], c)
+	s15, c = bits.Add32(a[15], b[15], c)
+	s16, c = bits.Add32(a[16], b[16], c)
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, d12, d13, d14, d15, d16, borrow uint32
+	d0, borrow = bits.Sub32(s0, 0xffffffff, borrow)
+	d1, borrow = bits.Sub32(s1, 0xffffffff, borrow)
+	d2, borrow = bits.Sub32(s2, 0xffffffff, borrow)
+	d3, borrow = bits.Sub32(s3, 0xffffffff, borrow)
+	d4, borrow = bits.Sub32(s4, 0xffffffff, borrow)
+	d5, borrow = bits.Sub32(s5, 0xffffffff, borrow)
+	d6, borrow = bits.Sub32(s6, 0xffffffff, borrow)
+	d7, borrow = bits.Sub32(s7, 0xffffffff, borrow)
+	d8, borrow = bits.Sub32(s8, 0xffffffff, borrow)
+	d9, borrow = bits.Sub32(s9, 0xffffffff, borrow)
+	d10, borrow = bits.Sub32(s10, 0xffffffff, borrow)
+	d11, borrow = bits.Sub32(s11, 0xffffffff, borrow)
+	d12, borrow = bits.Sub32(s12, 0xffffffff, borrow)
+	d13, borrow = bits.Sub32(s13, 0xffffffff, borrow)
+	d14, borrow = bits.Sub32(s14, 0xffffffff, borrow)
+	d15, borrow = bits.Sub32(s15, 0xffffffff, borrow)
+	d16, borrow = bits.Sub32(s16, 0x1ff, borrow)
+	_, borrow = bits.Sub32(c, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+	out[9] = d9 ^ (mask & (d9 ^ s9))
+	out[10] = d10 ^ (mask & (d10 ^ s10))
+	out[11] = d11 ^ (mask & (d11 ^ s11))
+	out[12] = d12 ^ (mask & (d12 ^ s12))
+	out[13] = d13 ^ (mask & (d13 ^ s13))
+	out[14] = d14 ^ (mask & (d14 ^ s14))
+	out[15] = d15 ^ (mask & (d15 ^ s15))
+	out[16] = d16 ^ (mask & (d16 ^ s16))
+}
+
+// p521AddLazy sets out = a + b, without reducing it, for a and b lower
+// than p. The result, lower than 2p, must only be passed to p521Mul.
+func p521AddLazy(out, a, b *p521Element) {
+	var c uint32
+	out[0], c = bits.Add32(a[0], b[0], c)
+	out[1], c = bits.Add32(a[1], b[1], c)
+	out[2], c = bits.Add32(a[2], b[2], c)
+	out[3], c = bits.Add32(a[3], b[3], c)
+	out[4], c = bits.Add32(a[4], b[4], c)
+	out[5], c = bits.Add32(a[5], b[5], c)
+	out[6], c = bits.Add32(a[6], b[6], c)
+	out[7], c = bits.Add32(a[7], b[7], c)
+	out[8], c = bits.Add32(a[8], b[8], c)
+	out[9], c = bits.Add32(a[9], b[9], c)
+	out[10], c = bits.Add32(a[10], b[10], c)
+	out[11], c = bits.Add32(a[11], b[11], c)
+	out[12], c = bits.Add32(a[12], b[12], c)
+	out[13], c = bits.Add32(a[13], b[13], c)
+	out[14], c = bits.Add32(a[14], b[14], c)
+	out[15], c = bits.Add32(a[15], b[15], c)
+	out[16], _ = bits.Add32(a[16], b[16], c)
+}
+
+// p521Sub sets out = a - b.
+func p521Sub(out, a, b *p521Element) {
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, d12, d13, d14, d15, d16, borrow uint32
+	d0, borrow = bits.Sub32(a[0], b[0], borrow)
+	d1, borrow = bits.Sub32(a[1], b[1], borrow)
+	d2, borrow = bits.Sub32(a[2], b[2], borrow)
+	d3, borrow = bits.Sub32(a[3], b[3], borrow)
+	d4, borrow = bits.Sub32(a[4], b[4], borrow)
+	d5, borrow = bits.Sub32(a[5], b[5], borrow)
+	d6, borrow = bits.Sub32(a[6], b[6], borrow)
+	d7, borrow = bits.Sub32(a[7], b[7], borrow)
+	d8, borrow = bits.Sub32(a[8], b[8], borrow)
+	d9, borrow = bits.Sub32(a[9], b[9], borrow)
+	d10, borrow = bits.Sub32(a[10], b[10], borrow)
+	d11, borrow = bits.Sub32(a[11], b[11], borrow)
+	d12, borrow = bits.Sub32(a[12], b[12], borrow)
+	d13, borrow = bits.Sub32(a[13], b[13], borrow)
+	d14, borrow = bits.Sub32(a[14], b[14], borrow)
+	d15, borrow = bits.Sub32(a[15], b[15], borrow)
+	d16, borrow = bits.Sub32(a[16], b[16], borrow)
+	// If a < b, p is added back.
+	mask := -borrow
+	var c uint32
+	out[0], c = bits.Add32(d0, 0xffffffff&mask, c)
+	out[1], c = bits.Add32(d1, 0xffffffff&mask, c)
+	out[2], c = bits.Add32(d2, 0xffffffff&mask, c)
+	out[3], c = bits.Add32(d3, 0xffffffff&mask, c)
+	out[4], c = bits.Add32(d4, 0xffffffff&mask, c)
+	out[5], c = bits.Add32(d5, 0xffffffff&mask, c)
+	out[6], c = bits.Add32(d6, 0xffffffff&mask, c)
+	out[7], c = bits.Add32(d7, 0xffffffff&mask, c)
+	out[8], c = bits.Add32(d8, 0xffffffff&mask, c)
+	out[9], c = bits.Add32(d9, 0xffffffff&mask, c)
+	out[10], c = bits.Add32(d10, 0xffffffff&mask, c)
+	out[11], c = bits.Add32(d11, 0xffffffff&mask, c)
+	out[12], c = bits.Add32(d12, 0xffffffff&mask, c)
+	out[13], c = bits.Add32(d13, 0xffffffff&mask, c)
+	out[14], c = bits.Add32(d14, 0xffffffff&mask, c)
+	out[15], c = bits.Add32(d15, 0xffffffff&mask, c)
+	out[16], c = bits.Add32(d16, 0x1ff&mask, c)
+}
+
+// p521Mul sets out = a * b, with the Solinas reduction for
+// p = 2^521 - 1.
+//
+// a and b may be the unreduced results of p521AddLazy.
+func p521Mul(out, a, b *p521Element) {
+	var t0, t1, t2, t3, t4, t5, t6, t7, t8, t9, t10, t11, t12, t13, t14, t15, t16, t17, t18, t19, t20, t21, t22, t23, t24, t25, t26, t27, t28, t29, t30, t31, t32, t33, c uint32
+
+	// Row 0.
+	c = 0
+	c, t0 = p521MulAdd(a[0], b[0], t0, c)
+	c, t1 = p521MulAdd(a[1], b[0], t1, c)
+	c, t2 = p521MulAdd(a[2], b[0], t2, c)
+	c, t3 = p521MulAdd(a[3], b[0], t3, c)
+	c, t4 = p521MulAdd(a[4], b[0], t4, c)
+	c, t5 = p521MulAdd(a[5], b[0], t5, c)
+	c, t6 = p521MulAdd(a[6], b[0], t6, c)
+	c, t7 = p521MulAdd(a[7], b[0], t7, c)
+	c, t8 = p521MulAdd(a[8], b[0], t8, c)
+	c, t9 = p521MulAdd(a[9], b[0], t9, c)
+	c, t10 = p521MulAdd(a[10], b[0], t10, c)
+	c, t11 = p521MulAdd(a[11], b[0], t11, c)
+	c, t12 = p521MulAdd(a[12], b[0], t12, c)
+	c, t13 = p521MulAdd(a[13], b[0], t13, c)
+	c, t14 = p521MulAdd(a[14], b[0], t14, c)
+	c, t15 = p521MulAdd(a[15], b[0], t15, c)
+	c, t16 = p521MulAdd(a[16], b[0], t16, c)
+	t17 = c
+
+	// Row 1.
+	c = 0
+	c, t1 = p521MulAdd(a[0], b[1], t1, c)
+	c, t2 = p521MulAdd(a[1], b[1], t2, c)
+	c, t3 = p521MulAdd(a[2], b[1], t3, c)
+	c, t4 = p521MulAdd(a[3], b[1], t4, c)
+	c, t5 = p521MulAdd(a[4], b[1], t5, c)
+	c, t6 = p521MulAdd(a[5], b[1], t6, c)
+	c, t7 = p521MulAdd(a[6], b[1], t7, c)
+	c, t8 = p521MulAdd(a[7], b[1], t8, c)
+	c, t9 = p521MulAdd(a[8], b[1], t9, c)
+	c, t10 = p521MulAdd(a[9], b[1], t10, c)
+	c, t11 = p521MulAdd(a[10], b[1], t11, c)
+	c, t12 = p521MulAdd(a[11], b[1], t12, c)
+	c, t13 = p521MulAdd(a[12], b[1], t13, c)
+	c, t14 = p521MulAdd(a[13], b[1], t14, c)
+	c, t15 = p521MulAdd(a[14], b[1], t15, c)
+	c, t16 = p521MulAdd(a[15], b[1], t16, c)
+	c, t17 = p521MulAdd(a[16], b[1], t17, c)
+	t18 = c
+
+	// Row 2.
+	c = 0
+	c, t2 = p521MulAdd(a[0], b[2], t2, c)
+	c, t3 = p521MulAdd(a[1], b[2], t3, c)
+	c, t4 = p521MulAdd(a[2], b[2], t4, c)
+	c, t5 = p521MulAdd(a[3], b[2], t5, c)
+	c, t6 = p521MulAdd(a[4], b[2], t6, c)
+	c, t7 = p521MulAdd(a[5], b[2], t7, c)
+	c, t8 = p521MulAdd(a[6], b[2], t8, c)
+	c, t9 = p521MulAdd(a[7], b[2], t9, c)
+	c, t10 = p521MulAdd(a[8], b[2], t10, c)
+	c, t11 = p521MulAdd(a[9], b[2], t11, c)
+	c, t12 = p521MulAdd(a[10], b[2], t12, c)
+	c, t13 = p521MulAdd(a[11], b[2], t13, c)
+	c, t14 = p521MulAdd(a[12], b[2], t14, c)
+	c, t15 = p521MulAdd(a[13], b[2], t15, c)
+	c, t16 = p521MulAdd(a[14], b[2], t16, c)
+	c, t17 = p521MulAdd(a[15], b[2], t17, c)
+	c, t18 = p521MulAdd(a[16], b[2], t18, c)
+	t19 = c
+
+	// Row 3.
+	c = 0
+	c, t3 = p521MulAdd(a[0], b[3], t3, c)
+	c, t4 = p521MulAdd(a[1], b[3], t4, c)
+	c, t5 = p521MulAdd(a[2], b[3], t5, c)
+	c, t6 = p521MulAdd(a[3], b[3], t6, c)
+	c, t7 = p521MulAdd(a[4], b[3], t7, c)
+	c, t8 = p521MulAdd(a[5], b[3], t8, c)
+	c, t9 = p521MulAdd(a[6], b[3], t9, c)
+	c, t10 = p521MulAdd(a[7], b[3], t10, c)
+	c, t11 = p521MulAdd(a[8], b[3], t11, c)
+	c, t12 = p521MulAdd(a[9], b[3], t12, c)
+	c, t13 = p521MulAdd(a[10], b[3], t13, c)
+	c, t14 = p521MulAdd(a[11], b[3], t14, c)
+	c, t15 = p521MulAdd(a[12], b[3], t15, c)
+	c, t16 = p521MulAdd(a[13], b[3], t16, c)
+	c, t17 = p521MulAdd(a[14], b[3], t17, c)
+	c, t18 = p521MulAdd(a[15], b[3], t18, c)
+	c, t19 = p521MulAdd(a[16], b[3], t19, c)
+	t20 = c
+
+	// Row 4.
+	c = 0
+	c, t4 = p521MulAdd(a[0], b[4], t4, c)
+	c, t5 = p521MulAdd(a[1], b[4], t5, c)
+	c, t6 = p521MulAdd(a[2], b[4], t6, c)
+	c, t7 = p521MulAdd(a[3], b[4], t7, c)
+	c, t8 = p521MulAdd(a[4], b[4], t8, c)
+	c, t9 = p521MulAdd(a[5], b[4], t9, c)
+	c, t10 = p521MulAdd(a[6], b[4], t10, c)
+	c, t11 = p521MulAdd(a[7], b[4], t11, c)
+	c, t12 = p521MulAdd(a[8], b[4], t12, c)
+	c, t13 = p521MulAdd(a[9], b[4], t13, c)
+	c, t14 = p521MulAdd(a[10], b[4], t14, c)
+	c, t15 = p521MulAdd(a[11], b[4], t15, c)
+	c, t16 = p521MulAdd(a[12], b[4], t16, c)
+	c, t17 = p521MulAdd(a[13], b[4], t17, c)
+	c, t18 = p521MulAdd(a[14], b[4], t18, c)
+	c, t19 = p521MulAdd(a[15], b[4], t19, c)
+	c, t20 = p521MulAdd(a[16], b[4], t20, c)
+	t21 = c
+
+	// Row 5.
+	c = 0
+	c, t5 = p521MulAdd(a[0], b[5], t5, c)
+	c, t6 = p521MulAdd(a[1], b[5], t6, c)
+	c, t7 = p521MulAdd(a[2], b[5], t7, c)
+	c, t8 = p521MulAdd(a[3], b[5], t8, c)
+	c, t9 = p521MulAdd(a[4], b[5], t9, c)
+	c, t10 = p521MulAdd(a[5], b[5], t10, c)
+	c, t11 = p521MulAdd(a[6], b[5], t11, c)
+	c, t12 = p521MulAdd(a[7], b[5], t12, c)
+	c, t13 = p521MulAdd(a[8], b[5], t13, c)
+	c, t14 = p521MulAdd(a[9], b[5], t14, c)
+	c, t15 = p521MulAdd(a[10], b[5], t15, c)
+	c, t16 = p521MulAdd(a[11], b[5], t16, c)
+	c, t17 = p521MulAdd(a[12], b[5], t17, c)
+	c, t18 = p521MulAdd(a[13], b[5], t18, c)
+	c, t19 = p521MulAdd(a[14], b[5], t19, c)
+	c, t20 = p521MulAdd(a[15], b[5], t20, c)
+	c, t21 = p521MulAdd(a[16], b[5], t21, c)
+	t22 = c
+
+	// Row 6.
+	c = 0
+	c, t6 = p521MulAdd(a[0], b[6], t6, c)
+	c, t7 = p521MulAdd(a[1], b[6], t7, c)
+	c, t8 = p521MulAdd(a[2], b[6], t8, c)
+	c, t9 = p521MulAdd(a[3], b[6], t9, c)
+	c, t10 = p521MulAdd(a[4], b[6], t10, c)
+	c, t11 = p521MulAdd(a[5], b[6], t11, c)
+	c, t12 = p521MulAdd(a[6], b[6], t12, c)
+	c, t13 = p521MulAdd(a[7], b[6], t13, c)
+	c, t14 = p521MulAdd(a[8], b[6], t14, c)
+	c, t15 = p521MulAdd(a[9], b[6], t15, c)
+	c, t16 = p521MulAdd(a[10], b[6], t16, c)
+	c, t17 = p521MulAdd(a[11], b[6], t17, c)
+	c, t18 = p521MulAdd(a[12], b[6], t18, c)
+	c, t19 = p521MulAdd(a[13], b[6], t19, c)
+	c, t20 = p521MulAdd(a[14], b[6], t20, c)
+	c, t21 = p521MulAdd(a[15], b[6], t21, c)
+	c, t22 = p521MulAdd(a[16], b[6], t22, c)
+	t23 = c
+
+	// Row 7.
+	c = 0
+	c, t7 = p521MulAdd(a[0], b[7], t7, c)
+	c, t8 = p521MulAdd(a[1], b[7], t8, c)
+	c, t9 = p521MulAdd(a[2], b[7], t9, c)
+	c, t10 = p521MulAdd(a[3], b[7], t10, c)
+	c, t11 = p521MulAdd(a[4], b[7], t11, c)
+	c, t12 = p521MulAdd(a[5], b[7], t12, c)
+	c, t13 = p521MulAdd(a[6], b[7], t13, c)
+	c, t14 = p521MulAdd(a[7], b[7], t14, c)
+	c, t15 = p521MulAdd(a[8], b[7], t15, c)
+	c, t16 = p521MulAdd(a[9], b[7], t16, c)
+	c, t17 = p521MulAdd(a[10], b[7], t17, c)
+	c, t18 = p521MulAdd(a[11], b[7], t18, c)
+	c, t19 = p521MulAdd(a[12], b[7], t19, c)
+	c, t20 = p521MulAdd(a[13], b[7], t20, c)
+	c, t21 = p521MulAdd(a[14], b[7], t21, c)
+	c, t22 = p521MulAdd(a[15], b[7], t22, c)
+	c, t23 = p521MulAdd(a[16], b[7], t23, c)
+	t24 = c
+
+	// Row 8.
+	c = 0
+	c, t8 = p521MulAdd(a[0], b[8], t8, c)
+	c, t9 = p521MulAdd(a[1], b[8], t9, c)
+	c, t10 = p521MulAdd(a[2], b[8], t10, c)
+	c, t11 = p521MulAdd(a[3], b[8], t11, c)
+	c, t12 = p521MulAdd(a[4], b[8], t12, c)
+	c, t13 = p521MulAdd(a[5], b[8], t13, c)
+	c, t14 = p521MulAdd(a[6], b[8], t14, c)
+	c, t15 = p521MulAdd(a[7], b[8], t15, c)
+	c, t16 = p521MulAdd(a[8], b[8], t16, c)
+	c, t17 = p521MulAdd(a[9], b[8], t17, c)
+	c, t18 = p521MulAdd(a[10], b[8], t18, c)
+	c, t19 = p521MulAdd(a[11], b[8], t19, c)
+	c, t20 = p521MulAdd(a[12], b[8], t20, c)
+	c, t21 = p521MulAdd(a[13], b[8], t21, c)
+	c, t22 = p521MulAdd(a[14], b[8], t22, c)
+	c, t23 = p521MulAdd(a[15], b[8], t23, c)
+	c, t24 = p521MulAdd(a[16], b[8], t24, c)
+	t25 = c
+
+	// Row 9.
+	c = 0
+	c, t9 = p521MulAdd(a[0], b[9], t9, c)
+	c, t10 = p521MulAdd(a[1], b[9], t10, c)
+	c, t11 = p521MulAdd(a[2], b[9], t11, c)
+	c, t12 = p521MulAdd(a[3], b[9], t12, c)
+	c, t13 = p521MulAdd(a[4], b[9], t13, c)
+	c, t14 = p521MulAdd(a[5], b[9], t14, c)
+	c, t15 = p521MulAdd(a[6], b[9], t15, c)
+	c, t16 = p521MulAdd(a[7], b[9], t16, c)
+	c, t17 = p521MulAdd(a[8], b[9], t17, c)
+	c, t18 = p521MulAdd(a[9], b[9], t18, c)
+	c, t19 = p521MulAdd(a[10], b[9], t19, c)
+	c, t20 = p521MulAdd(a[11], b[9], t20, c)
+	c, t21 = p521MulAdd(a[12], b[9], t21, c)
+	c, t22 = p521MulAdd(a[13], b[9], t22, c)
+	c, t23 = p521MulAdd(a[14], b[9], t23, c)
+	c, t24 = p521MulAdd(a[15], b[9], t24, c)
+	c, t25 = p521MulAdd(a[16], b[9], t25, c)
+	t26 = c
+
+	// Row 10.
+	c = 0
+	c, t10 = p521MulAdd(a[0], b[10], t10, c)
+	c, t11 = p521MulAdd(a[1], b[10], t11, c)
+	c, t12 = p521MulAdd(a[2], b[10], t12, c)
+	c, t13 = p521MulAdd(a[3], b[10], t13, c)
+	c, t14 = p521MulAdd(a[4], b[10], t14, c)
+	c, t15 = p521MulAdd(a[5], b[10], t15, c)
+	c, t16 = p521MulAdd(a[6], b[10], t16, c)
+	c, t17 = p521MulAdd(a[7], b[10], t17, c)
+	c, t18 = p521MulAdd(a[8], b[10], t18, c)
+	c, t19 = p521MulAdd(a[9], b[10], t19, c)
+	c, t20 = p521MulAdd(a[10], b[10], t20, c)
+	c, t21 = p521MulAdd(a[11], b[10], t21, c)
+	c, t22 = p521MulAdd(a[12], b[10], t22, c)
+	c, t23 = p521MulAdd(a[13], b[10], t23, c)
+	c, t24 = p521MulAdd(a[14], b[10], t24, c)
+	c, t25 = p521MulAdd(a[15], b[10], t25, c)
+	c, t26 = p521MulAdd(a[16], b[10], t26, c)
+	t27 = c
+
+	// Row 11.
+	c = 0
+	c, t11 = p521MulAdd(a[0], b[11], t11, c)
+	c, t12 = p521MulAdd(a[1], b[11], t12, c)
+	c, t13 = p521MulAdd(a[2], b[11], t13, c)
+	c, t14 = p521MulAdd(a[3], b[11], t14, c)
+	c, t15 = p521MulAdd(a[4], b[11], t15, c)
+	c, t16 = p521MulAdd(a[5], b[11], t16, c)
+	c, t17 = p521MulAdd(a[6], b[11], t17, c)
+	c, t18 = p521MulAdd(a[7], b[11], t18, c)
+	c, t19 = p521MulAdd(a[8], b[11], t19, c)
+	c, t20 = p521MulAdd(a[9], b[11], t20, c)
+	c, t21 = p521MulAdd(a[10], b[11], t21, c)
+	c, t22 = p521MulAdd(a[11], b[11], t22, c)
+	c, t23 = p521MulAdd(a[12], b[11], t23, c)
+	c, t24 = p521MulAdd(a[13], b[11], t24, c)
+	c, t25 = p521MulAdd(a[14], b[11], t25, c)
+	c, t26 = p521MulAdd(a[15], b[11], t26, c)
+	c, t27 = p521MulAdd(a[16], b[11], t27, c)
+	t28 = c
+
+	// Row 12.
+	c = 0
+	c, t12 = p521MulAdd(a[0], b[12], t12, c)
+	c, t13 = p521MulAdd(a[1], b[12], t13, c)
+	c, t14 = p521MulAdd(a[2], b[12], t14, c)
+	c, t15 = p521MulAdd(a[3], b[12], t15, c)
+	c, t16 = p521MulAdd(a[4], b[12], t16, c)
+	c, t17 = p521MulAdd(a[5], b[12], t17, c)
+	c, t18 = p521MulAdd(a[6], b[12], t18, c)
+	c, t19 = p521MulAdd(a[7], b[12], t19, c)
+	c, t20 = p521MulAdd(a[8], b[12], t20, c)
+	c, t21 = p521MulAdd(a[9], b[12], t21, c)
+	c, t22 = p521MulAdd(a[10], b[12], t22, c)
+	c, t23 = p521MulAdd(a[11], b[12], t23, c)
+	c, t24 = p521MulAdd(a[12], b[12], t24, c)
+	c, t25 = p521MulAdd(a[13], b[12], t25, c)
+	c, t26 = p521MulAdd(a[14], b[12], t26, c)
+	c, t27 = p521MulAdd(a[15], b[12], t27, c)
+	c, t28 = p521MulAdd(a[16], b[12], t28, c)
+	t29 = c
+
+	// Row 13.
+	c = 0
+	c, t13 = p521MulAdd(a[0], b[13], t13, c)
+	c, t14 = p521MulAdd(a[1], b[13], t14, c)
+	c, t15 = p521MulAdd(a[2], b[13], t15, c)
+	c, t16 = p521MulAdd(a[3], b[13], t16, c)
+	c, t17 = p521MulAdd(a[4], b[13], t17, c)
+	c, t18 = p521MulAdd(a[5], b[13], t18, c)
+	c, t19 = p521MulAdd(a[6], b[13], t19, c)
+	c, t20 = p521MulAdd(a[7], b[13], t20, c)
+	c, t21 = p521MulAdd(a[8], b[13], t21, c)
+	c, t22 = p521MulAdd(a[9], b[13], t22, c)
+	c, t23 = p521MulAdd(a[10], b[13], t23, c)
+	c, t24 = p521MulAdd(a[11], b[13], t24, c)
+	c, t25 = p521MulAdd(a[12], b[13], t25, c)
+	c, t26 = p521MulAdd(a[13], b[13], t26, c)
+	c, t27 = p521MulAdd(a[14], b[13], t27, c)
+	c, t28 = p521MulAdd(a[15], b[13], t28, c)
+	c, t29 = p521MulAdd(a[16], b[13], t29, c)
+	t30 = c
+
+	// Row 14.
+	c = 0
+	c, t14 = p521MulAdd(a[0], b[14], t14, c)
+	c, t15 = p521MulAdd(a[1], b[14], t15, c)
+	c, t16 = p521MulAdd(a[2], b[14], t16, c)
+	c, t17 = p521MulAdd(a[3], b[14], t17, c)
+	c, t18 = p521MulAdd(a[4], b[14], t18, c)
+	c, t19 = p521MulAdd(a[5], b[14], t19, c)
+	c, t20 = p521MulAdd(a[6], b[14], t20, c)
+	c, t21 = p521MulAdd(a[7], b[14], t21, c)
+	c, t22 = p521MulAdd(a[8], b[14], t22, c)
+	c, t23 = p521MulAdd(a[9], b[14], t23, c)
+	c, t24 = p521MulAdd(a[10], b[14], t24, c)
+	c, t25 = p521MulAdd(a[11], b[14], t25, c)
+	c, t26 = p521MulAdd(a[12], b[14], t26, c)
+	c, t27 = p521MulAdd(a[13], b[14], t27, c)
+	c, t28 = p521MulAdd(a[14], b[14], t28, c)
+	c, t29 = p521MulAdd(a[15], b[14], t29, c)
+	c, t30 = p521MulAdd(a[16], b[14], t30, c)
+	t31 = c
+
+	// Row 15.
+	c = 0
+	c, t15 = p521MulAdd(a[0], b[15], t15, c)
+	c, t16 = p521MulAdd(a[1], b[15], t16, c)
+	c, t17 = p521MulAdd(a[2], b[15], t17, c)
+	c, t18 = p521MulAdd(a[3], b[15], t18, c)
+	c, t19 = p521MulAdd(a[4], b[15], t19, c)
+	c, t20 = p521MulAdd(a[5], b[15], t20, c)
+	c, t21 = p521MulAdd(a[6], b[15], t21, c)
+	c, t22 = p521MulAdd(a[7], b[15], t22, c)
+	c, t23 = p521MulAdd(a[8], b[15], t23, c)
+	c, t24 = p521MulAdd(a[9], b[15], t24, c)
+	c, t25 = p521MulAdd(a[10], b[15], t25, c)
+	c, t26 = p521MulAdd(a[11], b[15], t26, c)
+	c, t27 = p521MulAdd(a[12], b[15], t27, c)
+	c, t28 = p521MulAdd(a[13], b[15], t28, c)
+	c, t29 = p521MulAdd(a[14], b[15], t29, c)
+	c, t30 = p521MulAdd(a[15], b[15], t30, c)
+	c, t31 = p521MulAdd(a[16], b[15], t31, c)
+	t32 = c
+
+	// Row 16.
+	c = 0
+	c, t16 = p521MulAdd(a[0], b[16], t16, c)
+	c, t17 = p521MulAdd(a[1], b[16], t17, c)
+	c, t18 = p521MulAdd(a[2], b[16], t18, c)
+	c, t19 = p521MulAdd(a[3], b[16], t19, c)
+	c, t20 = p521MulAdd(a[4], b[16], t20, c)
+	c, t21 = p521MulAdd(a[5], b[16], t21, c)
+	c, t22 = p521MulAdd(a[6], b[16], t22, c)
+	c, t23 = p521MulAdd(a[7], b[16], t23, c)
+	c, t24 = p521MulAdd(a[8], b[16], t24, c)
+	c, t25 = p521MulAdd(a[9], b[16], t25, c)
+	c, t26 = p521MulAdd(a[10], b[16], t26, c)
+	c, t27 = p521MulAdd(a[11], b[16], t27, c)
+	c, t28 = p521MulAdd(a[12], b[16], t28, c)
+	c, t29 = p521MulAdd(a[13], b[16], t29, c)
+	c, t30 = p521MulAdd(a[14], b[16], t30, c)
+	c, t31 = p521MulAdd(a[15], b[16], t31, c)
+	c, t32 = p521MulAdd(a[16], b[16], t32, c)
+	t33 = c
+
+	// The product is split at bit 521, and its two halves are added.
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, s14, s15, s16 uint32
+	s0, c = bits.Add32(t0, (t16>>9 | t17<<23), 0)
+	s1, c = bits.Add32(t1, (t17>>9 | t18<<23), c)
+	s2, c = bits.Add32(t2, (t18>>9 | t19<<23), c)
+	s3, c = bits.Add32(t3, (t19>>9 | t20<<23), c)
+	s4, c = bits.Add32(t4, (t20>>9 | t21<<23), c)
+	s5, c = bits.Add32(t5, (t21>>9 | t22<<23), c)
+	s6, c = bits.Add32(t6, (t22>>9 | t23<<23), c)
+	s7, c = bits.Add32(t7, (t23>>9 | t24<<23), c)
+	s8, c = bits.Add32(t8, (t24>>9 | t25<<23), c)
+	s9, c = bits.Add32(t9, (t25>>9 | t26<<23), c)
+	s10, c = bits.Add32(t10, (t26>>9 | t27<<23), c)
+	s11, c = bits.Add32(t11, (t27>>9 | t28<<23), c)
+	s12, c = bits.Add32(t12, (t28>>9 | t29<<23), c)
+	s13, c = bits.Add32(t13, (t29>>9 | t30<<23), c)
+	s14, c = bits.Add32(t14, (t30>>9 | t31<<23), c)
+	s15, c = bits.Add32(t15, (t31>>9 | t32<<23), c)
+	s16 = t16&0x1ff + (t32>>9 | t33<<23) + c
+
+	// The bits above 521 are folded back.
+	c = s16 >> 9
+	s16 &= 0x1ff
+	s0, c = bits.Add32(s0, c, 0)
+	s1, c = bits.Add32(s1, 0, c)
+	s2, c = bits.Add32(s2, 0, c)
+	s3, c = bits.Add32(s3, 0, c)
+	s4, c = bits.Add32(s4, 0, c)
+	s5, c = bits.Add32(s5, 0, c)
+	s6, c = bits.Add32(s6, 0, c)
+	s7, c = bits.Add32(s7, 0, c)
+	s8, c = bits.Add32(s8, 0, c)
+	s9, c = bits.Add32(s9, 0, c)
+	s10, c = bits.Add32(s10, 0, c)
+	s11, c = bits.Add32(s11, 0, c)
+	s12, c = bits.Add32(s12, 0, c)
+	s13, c = bits.Add32(s13, 0, c)
+	s14, c = bits.Add32(s14, 0, c)
+	s15, c = bits.Add32(s15, 0, c)
+	s16, c = bits.Add32(s16, 0, c)
+
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, d12, d13, d14, d15, d16, borrow uint32
+	d0, borrow = bits.Sub32(s0, 0xffffffff, borrow)
+	d1, borrow = bits.Sub32(s1, 0xffffffff, borrow)
+	d2, borrow = bits.Sub32(s2, 0xffffffff, borrow)
+	d3, borrow = bits.Sub32(s3, 0xffffffff, borrow)
+	d4, borrow = bits.Sub32(s4, 0xffffffff, borrow)
+	d5, borrow = bits.Sub32(s5, 0xffffffff, borrow)
+	d6, borrow = bits.Sub32(s6, 0xffffffff, borrow)
+	d7, borrow = bits.Sub32(s7, 0xffffffff, borrow)
+	d8, borrow = bits.Sub32(s8, 0xffffffff, borrow)
+	d9, borrow = bits.Sub32(s9, 0xffffffff, borrow)
+	d10, borrow = bits.Sub32(s10, 0xffffffff, borrow)
+	d11, borrow = bits.Sub32(s11, 0xffffffff, borrow)
+	d12, borrow = bits.Sub32(s12, 0xffffffff, borrow)
+	d13, borrow = bits.Sub32(s13, 0xffffffff, borrow)
+	d14, borrow = bits.Sub32(s14, 0xffffffff, borrow)
+	d15, borrow = bits.Sub32(s15, 0xffffffff, borrow)
+	d16, borrow = bits.Sub32(s16, 0x1ff, borrow)
+	_, borrow = bits.Sub32(c, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+	out[9] = d9 ^ (mask & (d9 ^ s9))
+	out[10] = d10 ^ (mask & (d10 ^ s10))
+	out[11] = d11 ^ (mask & (d11 ^ s11))
+	out[12] = d12 ^ (mask & (d12 ^ s12))
+	out[13] = d13 ^ (mask & (d13 ^ s13))
+	out[14] = d14 ^ (mask & (d14 ^ s14))
+	out[15] = d15 ^ (mask & (d15 ^ s15))
+	out[16] = d16 ^ (mask & (d16 ^ s16))
+}
+
+// p521IsZero returns 1 if a is zero, and 0 otherwise.
+func p521IsZero(a *p521Element) uint32 {
+	var acc uint32
+	for _, l := range a {
+		acc |= l
+	}
+	// The top bit of acc | -acc is set unless acc is zero.
+	return (acc|-acc)>>31 ^ 1
+}
+
+// p521Equal returns 1 if a and b are equal, and 0 otherwise.
+func p521Equal(a, b *p521Element) uint32 {
+	var d p521Element
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return p521IsZero(&d)
+}
+
+// p521Select sets out to a if control is 1, and to b if it is 0.
+func p521Select(out, a, b *p521Element, control uint32) {
+	mask := -control
+	for i := range out {
+		out[i] = b[i] ^ (mask & (a[i] ^ b[i]))
+	}
+}
+
+// p521PMinus2 is p - 2, in big-endian order.
+var p521PMinus2 = []byte{
+	0x01, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xfd,
+}
+
+// p521Invert sets out = 1/a, or 0 if a is 0, as a^(p-2). The exponent
+// is public, so branching on its bits doesn't leak anything about a.
+func p521Invert(out, a *p521Element) {
+	in := *a
+	r := p521One
+	for _, b := range p521PMinus2 {
+		for i := 7; i >= 0; i-- {
+			p521Mul(&r, &r, &r)
+			if b>>i&1 == 1 {
+				p521Mul(&r, &r, &in)
+			}
+		}
+	}
+	*out = r
+}
+
+// p521Polynomial sets out = x³ - 3x + b.
+func p521Polynomial(out, x *p521Element) {
+	var x3, threeX p521Element
+	p521Mul(&x3, x, x)
+	p521Mul(&x3, &x3, x)
+	p521Add(&threeX, x, x)
+	p521Add(&threeX, &threeX, x)
+	p521Sub(&x3, &x3, &threeX)
+	p521Add(out, &x3, &p521B)
+}
+
+// p521Point is a point in projective coordinates (X:Y:Z), representing
+// (X/Z, Y/Z), or the point at infinity, (0:1:0), if Z is 0.
+type p521Point struct {
+	x, y, z p521Element
+}
+
+// p521FromAffine returns the point (x, y), where (0, 0) is the point at
+// infinity, as in the elliptic package.
+func p521FromAffine(x, y *big.Int) *p521Point {
+	p := &p521Point{y: p521One}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return p
+	}
+	p521FromBig(&p.x, x)
+	p521FromBig(&p.y, y)
+	p.z = p521One
+	return p
+}
+
+// affine returns the affine coordinates of p, or (0, 0) for the point at
+// infinity.
+func (p *p521Point) affine() (x, y *big.Int) {
+	if p521IsZero(&p.z) == 1 {
+		return new(big.Int), new(big.Int)
+	}
+	var zInv, ax, ay p521Element
+	p521Invert(&zInv, &p.z)
+	p521Mul(&ax, &p.x, &zInv)
+	p521Mul(&ay, &p.y, &zInv)
+	return p521ToBig(&ax), p521ToBig(&ay)
+}
+
+// add sets q = p1 + p2, and returns q, with the complete addition formula for
+// a = -3 of Renes, Costello, and Batina, algorithm 4.
+func (q *p521Point) add(p1, p2 *p521Point) *p521Point {
+	var t0, t1, t2, t3, t4, x3, y3, z3 p521Element
+	p521Mul(&t0, &p1.x, &p2.x)
+	p521Mul(&t1, &p1.y, &p2.y)
+	p521Mul(&t2, &p1.z, &p2.z)
+	p521AddLazy(&t3, &p1.x, &p1.y)
+	p521AddLazy(&t4, &p2.x, &p2.y)
+	p521Mul(&t3, &t3, &t4)
+	p521Add(&t4, &t0, &t1)
+	p521Sub(&t3, &t3, &t4)
+	p521AddLazy(&t4, &p1.y, &p1.z)
+	p521AddLazy(&x3, &p2.y, &p2.z)
+	p521Mul(&t4, &t4, &x3)
+	p521Add(&x3, &t1, &t2)
+	p521Sub(&t4, &t4, &x3)
+	p521AddLazy(&x3, &p1.x, &p1.z)
+	p521AddLazy(&y3, &p2.x, &p2.z)
+	p521Mul(&x3, &x3, &y3)
+	p521Add(&y3, &t0, &t2)
+	p521Sub(&y3, &x3, &y3)
+	p521Mul(&z3, &p521B, &t2)
+	p521Sub(&x3, &y3, &z3)
+	p521Add(&z3, &x3, &x3)
+	p521Add(&x3, &x3, &z3)
+	p521Sub(&z3, &t1, &x3)
+	p521Add(&x3, &t1, &x3)
+	p521Mul(&y3, &p521B, &y3)
+	p521Add(&t1, &t2, &t2)
+	p521Add(&t2, &t1, &t2)
+	p521Sub(&y3, &y3, &t2)
+	p521Sub(&y3, &y3, &t0)
+	p521Add(&t1, &y3, &y3)
+	p521AddLazy(&y3, &t1, &y3)
+	p521Add(&t1, &t0, &t0)
+	p521Add(&t0, &t1, &t0)
+	p521Sub(&t0, &t0, &t2)
+	p521Mul(&t1, &t4, &y3)
+	p521Mul(&t2, &t0, &y3)
+	p521Mul(&y3, &x3, &z3)
+	p521Add(&y3, &y3, &t2)
+	p521Mul(&x3, &t3, &x3)
+	p521Sub(&x3, &x3, &t1)
+	p521Mul(&z3, &t4, &z3)
+	p521Mul(&t1, &t3, &t0)
+	p521Add(&z3, &z3, &t1)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// double sets q = 2p, and returns q, with the doubling formula for a = -3 of
+// Renes, Costello, and Batina, algorithm 6.
+func (q *p521Point) double(p *p521Point) *p521Point {
+	var t0, t1, t2, t3, x3, y3, z3 p521Element
+	p521Mul(&t0, &p.x, &p.x)
+	p521Mul(&t1, &p.y, &p.y)
+	p521Mul(&t2, &p.z, &p.z)
+	p521Mul(&t3, &p.x, &p.y)
+	p521AddLazy(&t3, &t3, &t3)
+	p521Mul(&z3, &p.x, &p.z)
+	p521Add(&z3, &z3, &z3)
+	p521Mul(&y3, &p521B, &t2)
+	p521Sub(&y3, &y3, &z3)
+	p521Add(&x3, &y3, &y3)
+	p521Add(&y3, &x3, &y3)
+	p521Sub(&x3, &t1, &y3)
+	p521AddLazy(&y3, &t1, &y3)
+	p521Mul(&y3, &x3, &y3)
+	p521Mul(&x3, &x3, &t3)
+	p521Add(&t3, &t2, &t2)
+	p521Add(&t2, &t2, &t3)
+	p521Mul(&z3, &p521B, &z3)
+	p521Sub(&z3, &z3, &t2)
+	p521Sub(&z3, &z3, &t0)
+	p521Add(&t3, &z3, &z3)
+	p521AddLazy(&z3, &z3, &t3)
+	p521Add(&t3, &t0, &t0)
+	p521Add(&t0, &t3, &t0)
+	p521Sub(&t0, &t0, &t2)
+	p521Mul(&t0, &t0, &z3)
+	p521Add(&y3, &y3, &t0)
+	p521Mul(&t0, &p.y, &p.z)
+	p521AddLazy(&t0, &t0, &t0)
+	p521Mul(&z3, &t0, &z3)
+	p521Sub(&x3, &x3, &z3)
+	p521Mul(&z3, &t0, &t1)
+	p521Add(&z3, &z3, &z3)
+	p521Add(&z3, &z3, &z3)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// selectPoint sets q to a if control is 1, and leaves it unchanged if it is 0.
+func (q *p521Point) selectPoint(a *p521Point, control uint32) {
+	p521Select(&q.x, &a.x, &q.x, control)
+	p521Select(&q.y, &a.y, &q.y, control)
+	p521Select(&q.z, &a.z, &q.z, control)
+}
+
+// scalarMult sets q = k * p, and returns q, with a fixed window of 4 bits.
+// Every window does the same doublings and addition, and reads the whole
+// table, whatever the value of k.
+func (q *p521Point) scalarMult(p *p521Point, k []byte) *p521Point {
+	var table [16]p521Point
+	table[0].y = p521One
+	table[1] = *p
+	for i := 2; i < 16; i += 2 {
+		table[i].double(&table[i/2])
+		table[i+1].add(&table[i], p)
+	}
+	out := p521Point{y: p521One}
+	var t p521Point
+	for _, b := range k {
+		for shift := 4; shift >= 0; shift -= 4 {
+			w := b >> shift & 0xf
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			t = table[0]
+			for j := 1; j < 16; j++ {
+				t.selectPoint(&table[j], uint32(subtle.ConstantTimeByteEq(uint8(j), w)))
+			}
+			out.add(&out, &t)
+		}
+	}
+	*q = out
+	return q
+}
@@ -0,0 +1,202 @@
+// Curvegen generates a constant-time backend for a short Weierstrass curve
+// y² = x³ - 3x + b over a prime field, implementing elliptic.Curve, along with
+// tests checking it with elliptictest.
+//
+// It is meant to be run by go generate, with a directive like
+//
+//	//go:generate go run github.com/cronokirby/ctcrypto/cmd/curvegen -package mycurve -func MyCurve -name MyCurve -p 0xffff... -b 0x5ac6... -gx 0x6b17... -gy 0x4fe3... -n 0xffff... -o mycurve.go
+//
+// which writes mycurve.go, and mycurve_test.go next to it. Numbers are decimal,
+// or hexadecimal with a 0x prefix. With -package elliptic, the backend is part
+// of the elliptic package itself, like its P-384, and the tests go in the
+// elliptic_test package, since elliptictest imports elliptic. The function
+// returning the curve is then left to the package, along with its
+// documentation, and only the unexported value it returns, like p384, is
+// generated.
+//
+// The field elements of the backend have a fixed number of 64-bit limbs, and
+// use Montgomery multiplication, unrolled, and specialized for the limbs of p,
+// or, if p is a Mersenne prime 2^k - 1, like that of P-521, the schoolbook
+// product followed by the Solinas reduction, which adds the bits above k back
+// to those below.
+//
+// With -o32 mycurve_32.go, curvegen also writes a backend whose limbs have 32
+// bits, for 386, ARM, MIPS, and WebAssembly, where math/bits would otherwise
+// emulate 64-bit multiplications. Build constraints select one of the two
+// files.
+//
+// Points use the complete projective formulas of Renes, Costello, and Batina,
+// which have no exceptional cases, and are scalar multiplied with a fixed
+// window, so that nothing depends on the value of the scalar.
+//
+// Those formulas need the curve to have no point of order 2, so curvegen only
+// accepts curves of prime order, i.e. those for which the generator has order
+// n, and no other points exist. It also checks that p and n are prime, that
+// the curve isn't singular, and that the generator is on the curve.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/safenum"
+)
+
+// config holds the flags of curvegen.
+type config struct {
+	pkg, fn, name, out, out32 string
+	p, b, gx, gy, n           *big.Int
+}
+
+// parseNumber parses a decimal or hexadecimal flag.
+func parseNumber(name, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("curvegen: missing -%s", name)
+	}
+	x, ok := new(big.Int).SetString(s, 0)
+	if !ok || x.Sign() < 0 {
+		return nil, fmt.Errorf("curvegen: invalid -%s %q", name, s)
+	}
+	return x, nil
+}
+
+// parseArgs parses the command line arguments, excluding the name of the
+// program.
+func parseArgs(args []string) (*config, error) {
+	fs := flag.NewFlagSet("curvegen", flag.ContinueOnError)
+	c := new(config)
+	fs.StringVar(&c.pkg, "package", "", "the package of the generated files")
+	fs.StringVar(&c.fn, "func", "", "the exported function returning the curve")
+	fs.StringVar(&c.name, "name", "", "the name of the curve, in its parameters")
+	fs.StringVar(&c.out, "o", "", "the generated file, which must end in .go")
+	fs.StringVar(&c.out32, "o32", "", "the generated file with 32-bit limbs, if any")
+	numbers := map[string]*string{}
+	for _, name := range []string{"p", "b", "gx", "gy", "n"} {
+		numbers[name] = fs.String(name, "", "the parameter "+name+" of the curve")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("curvegen: unexpected argument %q", fs.Arg(0))
+	}
+	if c.pkg == "" || c.fn == "" || c.name == "" {
+		return nil, errors.New("curvegen: -package, -func, and -name are required")
+	}
+	if !isExported(c.fn) {
+		return nil, fmt.Errorf("curvegen: -func %q isn't an exported identifier", c.fn)
+	}
+	if !strings.HasSuffix(c.out, ".go") || strings.HasSuffix(c.out, "_test.go") {
+		return nil, fmt.Errorf("curvegen: -o %q isn't the name of a Go file", c.out)
+	}
+	if c.out32 != "" && (!strings.HasSuffix(c.out32, ".go") || strings.HasSuffix(c.out32, "_test.go") || c.out32 == c.out) {
+		return nil, fmt.Errorf("curvegen: -o32 %q isn't the name of another Go file", c.out32)
+	}
+	var err error
+	for name, x := range map[string]**big.Int{"p": &c.p, "b": &c.b, "gx": &c.gx, "gy": &c.gy, "n": &c.n} {
+		if *x, err = parseNumber(name, *numbers[name]); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// isExported reports whether s is an exported Go identifier.
+func isExported(s string) bool {
+	for i, r := range s {
+		if i == 0 && !unicode.IsUpper(r) || !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// validate checks that the parameters describe a curve of prime order, which
+// the generated backend supports.
+func (c *config) validate() error {
+	p, b, gx, gy, n := c.p, c.b, c.gx, c.gy, c.n
+	if p.Cmp(big.NewInt(3)) <= 0 || !p.ProbablyPrime(32) {
+		return errors.New("curvegen: p isn't an odd prime")
+	}
+	if b.Cmp(p) >= 0 || gx.Cmp(p) >= 0 || gy.Cmp(p) >= 0 {
+		return errors.New("curvegen: b, gx, and gy must be reduced modulo p")
+	}
+	// With a = -3, the discriminant is zero if and only if b² = 4.
+	b2 := new(big.Int).Mul(b, b)
+	if b2.Mod(b2, p).Cmp(big.NewInt(4)) == 0 {
+		return errors.New("curvegen: the curve is singular")
+	}
+	// y² = x³ - 3x + b
+	lhs := new(big.Int).Mul(gy, gy)
+	rhs := new(big.Int).Mul(gx, gx)
+	rhs.Sub(rhs, big.NewInt(3)).Mul(rhs, gx).Add(rhs, b)
+	if lhs.Sub(lhs, rhs).Mod(lhs, p).Sign() != 0 {
+		return errors.New("curvegen: the generator isn't on the curve")
+	}
+	if !n.ProbablyPrime(32) {
+		return errors.New("curvegen: n isn't prime")
+	}
+	// By Hasse's theorem, the curve has at most p + 1 + 2√p points, so if the
+	// generator has order n > (p + 1 + 2√p) / 2, it generates the whole curve.
+	bound := new(big.Int).Sqrt(p)
+	bound.Lsh(bound, 1).Add(bound, p).Add(bound, big.NewInt(3))
+	if new(big.Int).Lsh(n, 1).Cmp(bound) <= 0 {
+		return errors.New("curvegen: the curve doesn't have prime order n")
+	}
+	x, y := c.params().ScalarMult(gx, gy, n.Bytes())
+	if x.Sign() != 0 || y.Sign() != 0 {
+		return errors.New("curvegen: the generator doesn't have order n")
+	}
+	return nil
+}
+
+// params returns the parameters of the curve, whose methods are the generic
+// implementation of the elliptic package.
+func (c *config) params() *elliptic.CurveParams {
+	return &elliptic.CurveParams{
+		P:       safenum.ModulusFromBytes(c.p.Bytes()),
+		N:       safenum.ModulusFromBytes(c.n.Bytes()),
+		B:       new(safenum.Nat).SetBytes(c.b.Bytes()),
+		Gx:      new(safenum.Nat).SetBytes(c.gx.Bytes()),
+		Gy:      new(safenum.Nat).SetBytes(c.gy.Bytes()),
+		BitSize: c.p.BitLen(),
+		Name:    c.name,
+	}
+}
+
+func run(args []string) error {
+	c, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	src, src32, test, err := generate(c)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(c.out, src, 0644); err != nil {
+		return err
+	}
+	if src32 != nil {
+		if err := ioutil.WriteFile(c.out32, src32, 0644); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(strings.TrimSuffix(c.out, ".go")+"_test.go", test, 0644)
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	valid := "-package x -func Curve -name C -p 23 -b 1 -gx 0 -gy 1 -n 0x1d -o curve.go"
+	c, err := parseArgs(strings.Fields(valid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.n.Int64() != 29 || c.p.Int64() != 23 || c.out != "curve.go" {
+		t.Error("wrong flags parsed")
+	}
+	if c, err := parseArgs(strings.Fields(valid + " -o32 curve32.go")); err != nil || c.out32 != "curve32.go" {
+		t.Errorf("-o32 not parsed: %v", err)
+	}
+	for _, args := range []string{
+		"-package x -func Curve -name C -p 23 -b 1 -gx 0 -gy 1 -o curve.go",
+		"-package x -func curve -name C -p 23 -b 1 -gx 0 -gy 1 -n 29 -o curve.go",
+		"-package x -func Curve -name C -p 23 -b 1 -gx 0 -gy 1 -n 29 -o curve_test.go",
+		"-package x -func Curve -name C -p 23 -b 1 -gx 0 -gy -1 -n 29 -o curve.go",
+		"-package x -func Curve -name C -p 0xzz -b 1 -gx 0 -gy 1 -n 29 -o curve.go",
+		"-func Curve -name C -p 23 -b 1 -gx 0 -gy 1 -n 29 -o curve.go",
+		valid + " -o32 curve.go",
+		valid + " -o32 curve32_test.go",
+		valid + " extra",
+	} {
+		if _, err := parseArgs(strings.Fields(args)); err == nil {
+			t.Errorf("%q accepted", args)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c, err := parseArgs(exampleArgs(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.validate(); err != nil {
+		t.Fatal(err)
+	}
+	one := big.NewInt(1)
+	for name, change := range map[string]func(c *config){
+		"composite p":   func(c *config) { c.p = new(big.Int).Add(c.p, one) },
+		"unreduced b":   func(c *config) { c.b = new(big.Int).Add(c.b, c.p) },
+		"singular":      func(c *config) { c.b = big.NewInt(2) },
+		"generator off": func(c *config) { c.gy = new(big.Int).Add(c.gy, one) },
+		"composite n":   func(c *config) { c.n = new(big.Int).Add(c.n, one) },
+		"small n":       func(c *config) { c.n = big.NewInt(3) },
+		"wrong order":   func(c *config) { c.n = new(big.Int).Sub(c.n, big.NewInt(2)).Add(c.n, new(big.Int).Lsh(one, 300)) },
+	} {
+		bad := *c
+		change(&bad)
+		if err := bad.validate(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+// nextPrime returns the smallest prime larger than the odd number n.
+func nextPrime(n *big.Int) *big.Int {
+	m := new(big.Int).Add(n, big.NewInt(2))
+	for !m.ProbablyPrime(20) {
+		m.Add(m, big.NewInt(2))
+	}
+	return m
+}
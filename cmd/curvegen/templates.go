@@ -0,0 +1,407 @@
+package main
+
+import "text/template"
+
+var srcTemplate = template.Must(template.New("src").Parse(`// Code generated by curvegen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+{{if not .Internal}}	"github.com/cronokirby/ctcrypto/elliptic"
+{{end}}	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/safenum"
+)
+
+{{if not .Internal}}// {{.Func}} returns a Curve which implements {{.Name}}, the curve y² = x³ - 3x + b
+// of prime order over a {{.BitSize}}-bit prime field.
+//
+// Multiple invocations of this function return the same value, so it can be
+// used for equality checks and switch statements.
+//
+// The cryptographic operations are implemented using constant-time algorithms.
+func {{.Func}}() elliptic.Curve {
+	return {{.Prefix}}
+}
+
+{{end}}type {{.Prefix}}Curve struct {
+	params *{{.Qualifier}}CurveParams
+}
+
+var {{.Prefix}} = {{.Prefix}}Curve{&{{.Qualifier}}CurveParams{
+	P: safenum.ModulusFromBytes({{.P}}),
+	N: safenum.ModulusFromBytes({{.N}}),
+	B: new(safenum.Nat).SetBytes({{.B}}),
+	Gx: new(safenum.Nat).SetBytes({{.Gx}}),
+	Gy: new(safenum.Nat).SetBytes({{.Gy}}),
+	BitSize: {{.BitSize}},
+	Name: {{printf "%q" .Name}},
+}}
+
+// {{.Prefix}}P is the order of the field.
+var {{.Prefix}}P = new(big.Int).SetBytes({{.P}})
+
+func (curve {{.Prefix}}Curve) Params() *{{.Qualifier}}CurveParams {
+	return curve.params
+}
+
+func (curve {{.Prefix}}Curve) IsOnCurve(x, y *big.Int) bool {
+	var fx, fy {{.Prefix}}Element
+	xValid := {{.Prefix}}FromBig(&fx, x)
+	yValid := {{.Prefix}}FromBig(&fy, y)
+	// y² = x³ - 3x + b
+	var y2, rhs {{.Prefix}}Element
+	{{.Prefix}}Mul(&y2, &fy, &fy)
+	{{.Prefix}}Polynomial(&rhs, &fx)
+	return xValid && yValid && {{.Prefix}}Equal(&y2, &rhs) == 1
+}
+
+func (curve {{.Prefix}}Curve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p := {{.Prefix}}FromAffine(x1, y1)
+	return p.add(p, {{.Prefix}}FromAffine(x2, y2)).affine()
+}
+
+func (curve {{.Prefix}}Curve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	p := {{.Prefix}}FromAffine(x1, y1)
+	return p.double(p).affine()
+}
+
+func (curve {{.Prefix}}Curve) ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := {{.Prefix}}FromAffine(x1, y1)
+	return p.scalarMult(p, k).affine()
+}
+
+func (curve {{.Prefix}}Curve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := &{{.Prefix}}Point{ {{.Prefix}}Gx, {{.Prefix}}Gy, {{.Prefix}}One}
+	return p.scalarMult(p, k).affine()
+}
+
+{{if .Montgomery}}// {{.Prefix}}Element is an element of the field, in the Montgomery domain, as
+// {{.Limbs}} {{.WordBits}}-bit limbs in little-endian order. It is always fully reduced{{if .Lazy}},
+// except for the results of {{.Prefix}}AddLazy{{end}}.
+{{else}}// {{.Prefix}}Element is an element of the field, as {{.Limbs}} {{.WordBits}}-bit limbs in
+// little-endian order. It is always fully reduced{{if .Lazy}}, except for the results of
+// {{.Prefix}}AddLazy{{end}}.
+{{end}}type {{.Prefix}}Element [{{.Limbs}}]{{.Word}}
+
+var (
+{{if .Montgomery}}	// {{.Prefix}}One is R mod p, representing 1.
+	{{.Prefix}}One = {{.Prefix}}Element{{.One}}
+	// {{.Prefix}}R2 is R² mod p, which converts into the Montgomery domain.
+	{{.Prefix}}R2 = {{.Prefix}}Element{{.R2}}
+{{else}}	// {{.Prefix}}One is 1.
+	{{.Prefix}}One = {{.Prefix}}Element{{.One}}
+{{end}}	// {{.Prefix}}B is the constant of the curve equation.
+	{{.Prefix}}B = {{.Prefix}}Element{{.BElement}}
+	// {{.Prefix}}Gx and {{.Prefix}}Gy are the coordinates of the generator.
+	{{.Prefix}}Gx = {{.Prefix}}Element{{.GxElement}}
+	{{.Prefix}}Gy = {{.Prefix}}Element{{.GyElement}}
+)
+
+// {{.Prefix}}FromBig sets out to x mod p, and reports whether x was in [0, p).
+func {{.Prefix}}FromBig(out *{{.Prefix}}Element, x *big.Int) bool {
+	reduced := x.Sign() >= 0 && x.Cmp({{.Prefix}}P) < 0
+	var buf [{{.Limbs}} * {{.WordBytes}}]byte
+	new(big.Int).Mod(x, {{.Prefix}}P).FillBytes(buf[:])
+	for i := range out {
+		out[i] = binary.BigEndian.{{.Uint}}(buf[len(buf)-{{.WordBytes}}*(i+1):])
+	}
+{{if .Montgomery}}	{{.Prefix}}Mul(out, out, &{{.Prefix}}R2)
+{{end}}	return reduced
+}
+
+// {{.Prefix}}ToBig returns the integer in [0, p) which a represents.
+func {{.Prefix}}ToBig(a *{{.Prefix}}Element) *big.Int {
+{{if .Montgomery}}	var t {{.Prefix}}Element
+	{{.Prefix}}Mul(&t, a, &{{.Prefix}}Element{1})
+{{else}}	t := *a
+{{end}}	var buf [{{.Limbs}} * {{.WordBytes}}]byte
+	for i := range t {
+		binary.BigEndian.{{.PutUint}}(buf[len(buf)-{{.WordBytes}}*(i+1):], t[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// {{.Prefix}}MulAdd returns x * y + a + b, as two limbs.
+func {{.Prefix}}MulAdd(x, y, a, b {{.Word}}) (hi, lo {{.Word}}) {
+	hi, lo = {{.Mul}}(x, y)
+	var c {{.Word}}
+	lo, c = {{.Add}}(lo, a, 0)
+	hi += c
+	lo, c = {{.Add}}(lo, b, 0)
+	hi += c
+	return hi, lo
+}
+
+// {{.Prefix}}Add sets out = a + b.
+func {{.Prefix}}Add(out, a, b *{{.Prefix}}Element) {
+{{.AddBody}}}
+
+{{if .Lazy}}// {{.Prefix}}AddLazy sets out = a + b, without reducing it, for a and b lower
+// than p. The result, lower than 2p, must only be passed to {{.Prefix}}Mul.
+func {{.Prefix}}AddLazy(out, a, b *{{.Prefix}}Element) {
+{{.AddLazyBody}}}
+
+{{end}}// {{.Prefix}}Sub sets out = a - b.
+func {{.Prefix}}Sub(out, a, b *{{.Prefix}}Element) {
+{{.SubBody}}}
+
+{{if .Montgomery}}// {{.Prefix}}Mul sets out = a * b, with Montgomery multiplication, which
+// computes a * b / R mod p.
+{{else}}// {{.Prefix}}Mul sets out = a * b, with the Solinas reduction for
+// p = 2^{{.BitSize}} - 1.
+{{end}}{{if .Lazy}}//
+// a and b may be the unreduced results of {{.Prefix}}AddLazy.
+{{end}}func {{.Prefix}}Mul(out, a, b *{{.Prefix}}Element) {
+{{.MulBody}}}
+
+// {{.Prefix}}IsZero returns 1 if a is zero, and 0 otherwise.
+func {{.Prefix}}IsZero(a *{{.Prefix}}Element) {{.Word}} {
+	var acc {{.Word}}
+	for _, l := range a {
+		acc |= l
+	}
+	// The top bit of acc | -acc is set unless acc is zero.
+	return (acc|-acc)>>{{.TopBit}} ^ 1
+}
+
+// {{.Prefix}}Equal returns 1 if a and b are equal, and 0 otherwise.
+func {{.Prefix}}Equal(a, b *{{.Prefix}}Element) {{.Word}} {
+	var d {{.Prefix}}Element
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return {{.Prefix}}IsZero(&d)
+}
+
+// {{.Prefix}}Select sets out to a if control is 1, and to b if it is 0.
+func {{.Prefix}}Select(out, a, b *{{.Prefix}}Element, control {{.Word}}) {
+	mask := -control
+	for i := range out {
+		out[i] = b[i] ^ (mask & (a[i] ^ b[i]))
+	}
+}
+
+// {{.Prefix}}PMinus2 is p - 2, in big-endian order.
+var {{.Prefix}}PMinus2 = {{.PMinus2}}
+
+// {{.Prefix}}Invert sets out = 1/a, or 0 if a is 0, as a^(p-2). The exponent
+// is public, so branching on its bits doesn't leak anything about a.
+func {{.Prefix}}Invert(out, a *{{.Prefix}}Element) {
+	in := *a
+	r := {{.Prefix}}One
+	for _, b := range {{.Prefix}}PMinus2 {
+		for i := 7; i >= 0; i-- {
+			{{.Prefix}}Mul(&r, &r, &r)
+			if b>>i&1 == 1 {
+				{{.Prefix}}Mul(&r, &r, &in)
+			}
+		}
+	}
+	*out = r
+}
+
+// {{.Prefix}}Polynomial sets out = x³ - 3x + b.
+func {{.Prefix}}Polynomial(out, x *{{.Prefix}}Element) {
+	var x3, threeX {{.Prefix}}Element
+	{{.Prefix}}Mul(&x3, x, x)
+	{{.Prefix}}Mul(&x3, &x3, x)
+	{{.Prefix}}Add(&threeX, x, x)
+	{{.Prefix}}Add(&threeX, &threeX, x)
+	{{.Prefix}}Sub(&x3, &x3, &threeX)
+	{{.Prefix}}Add(out, &x3, &{{.Prefix}}B)
+}
+
+// {{.Prefix}}Point is a point in projective coordinates (X:Y:Z), representing
+// (X/Z, Y/Z), or the point at infinity, (0:1:0), if Z is 0.
+type {{.Prefix}}Point struct {
+	x, y, z {{.Prefix}}Element
+}
+
+// {{.Prefix}}FromAffine returns the point (x, y), where (0, 0) is the point at
+// infinity, as in the elliptic package.
+func {{.Prefix}}FromAffine(x, y *big.Int) *{{.Prefix}}Point {
+	p := &{{.Prefix}}Point{y: {{.Prefix}}One}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return p
+	}
+	{{.Prefix}}FromBig(&p.x, x)
+	{{.Prefix}}FromBig(&p.y, y)
+	p.z = {{.Prefix}}One
+	return p
+}
+
+// affine returns the affine coordinates of p, or (0, 0) for the point at
+// infinity.
+func (p *{{.Prefix}}Point) affine() (x, y *big.Int) {
+	if {{.Prefix}}IsZero(&p.z) == 1 {
+		return new(big.Int), new(big.Int)
+	}
+	var zInv, ax, ay {{.Prefix}}Element
+	{{.Prefix}}Invert(&zInv, &p.z)
+	{{.Prefix}}Mul(&ax, &p.x, &zInv)
+	{{.Prefix}}Mul(&ay, &p.y, &zInv)
+	return {{.Prefix}}ToBig(&ax), {{.Prefix}}ToBig(&ay)
+}
+
+// add sets q = p1 + p2, and returns q, with the complete addition formula for
+// a = -3 of Renes, Costello, and Batina, algorithm 4.
+func (q *{{.Prefix}}Point) add(p1, p2 *{{.Prefix}}Point) *{{.Prefix}}Point {
+	var t0, t1, t2, t3, t4, x3, y3, z3 {{.Prefix}}Element
+	{{.Prefix}}Mul(&t0, &p1.x, &p2.x)
+	{{.Prefix}}Mul(&t1, &p1.y, &p2.y)
+	{{.Prefix}}Mul(&t2, &p1.z, &p2.z)
+	{{.AddLazy}}(&t3, &p1.x, &p1.y)
+	{{.AddLazy}}(&t4, &p2.x, &p2.y)
+	{{.Prefix}}Mul(&t3, &t3, &t4)
+	{{.Prefix}}Add(&t4, &t0, &t1)
+	{{.Prefix}}Sub(&t3, &t3, &t4)
+	{{.AddLazy}}(&t4, &p1.y, &p1.z)
+	{{.AddLazy}}(&x3, &p2.y, &p2.z)
+	{{.Prefix}}Mul(&t4, &t4, &x3)
+	{{.Prefix}}Add(&x3, &t1, &t2)
+	{{.Prefix}}Sub(&t4, &t4, &x3)
+	{{.AddLazy}}(&x3, &p1.x, &p1.z)
+	{{.AddLazy}}(&y3, &p2.x, &p2.z)
+	{{.Prefix}}Mul(&x3, &x3, &y3)
+	{{.Prefix}}Add(&y3, &t0, &t2)
+	{{.Prefix}}Sub(&y3, &x3, &y3)
+	{{.Prefix}}Mul(&z3, &{{.Prefix}}B, &t2)
+	{{.Prefix}}Sub(&x3, &y3, &z3)
+	{{.Prefix}}Add(&z3, &x3, &x3)
+	{{.Prefix}}Add(&x3, &x3, &z3)
+	{{.Prefix}}Sub(&z3, &t1, &x3)
+	{{.Prefix}}Add(&x3, &t1, &x3)
+	{{.Prefix}}Mul(&y3, &{{.Prefix}}B, &y3)
+	{{.Prefix}}Add(&t1, &t2, &t2)
+	{{.Prefix}}Add(&t2, &t1, &t2)
+	{{.Prefix}}Sub(&y3, &y3, &t2)
+	{{.Prefix}}Sub(&y3, &y3, &t0)
+	{{.Prefix}}Add(&t1, &y3, &y3)
+	{{.AddLazy}}(&y3, &t1, &y3)
+	{{.Prefix}}Add(&t1, &t0, &t0)
+	{{.Prefix}}Add(&t0, &t1, &t0)
+	{{.Prefix}}Sub(&t0, &t0, &t2)
+	{{.Prefix}}Mul(&t1, &t4, &y3)
+	{{.Prefix}}Mul(&t2, &t0, &y3)
+	{{.Prefix}}Mul(&y3, &x3, &z3)
+	{{.Prefix}}Add(&y3, &y3, &t2)
+	{{.Prefix}}Mul(&x3, &t3, &x3)
+	{{.Prefix}}Sub(&x3, &x3, &t1)
+	{{.Prefix}}Mul(&z3, &t4, &z3)
+	{{.Prefix}}Mul(&t1, &t3, &t0)
+	{{.Prefix}}Add(&z3, &z3, &t1)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// double sets q = 2p, and returns q, with the doubling formula for a = -3 of
+// Renes, Costello, and Batina, algorithm 6.
+func (q *{{.Prefix}}Point) double(p *{{.Prefix}}Point) *{{.Prefix}}Point {
+	var t0, t1, t2, t3, x3, y3, z3 {{.Prefix}}Element
+	{{.Prefix}}Mul(&t0, &p.x, &p.x)
+	{{.Prefix}}Mul(&t1, &p.y, &p.y)
+	{{.Prefix}}Mul(&t2, &p.z, &p.z)
+	{{.Prefix}}Mul(&t3, &p.x, &p.y)
+	{{.AddLazy}}(&t3, &t3, &t3)
+	{{.Prefix}}Mul(&z3, &p.x, &p.z)
+	{{.Prefix}}Add(&z3, &z3, &z3)
+	{{.Prefix}}Mul(&y3, &{{.Prefix}}B, &t2)
+	{{.Prefix}}Sub(&y3, &y3, &z3)
+	{{.Prefix}}Add(&x3, &y3, &y3)
+	{{.Prefix}}Add(&y3, &x3, &y3)
+	{{.Prefix}}Sub(&x3, &t1, &y3)
+	{{.AddLazy}}(&y3, &t1, &y3)
+	{{.Prefix}}Mul(&y3, &x3, &y3)
+	{{.Prefix}}Mul(&x3, &x3, &t3)
+	{{.Prefix}}Add(&t3, &t2, &t2)
+	{{.Prefix}}Add(&t2, &t2, &t3)
+	{{.Prefix}}Mul(&z3, &{{.Prefix}}B, &z3)
+	{{.Prefix}}Sub(&z3, &z3, &t2)
+	{{.Prefix}}Sub(&z3, &z3, &t0)
+	{{.Prefix}}Add(&t3, &z3, &z3)
+	{{.AddLazy}}(&z3, &z3, &t3)
+	{{.Prefix}}Add(&t3, &t0, &t0)
+	{{.Prefix}}Add(&t0, &t3, &t0)
+	{{.Prefix}}Sub(&t0, &t0, &t2)
+	{{.Prefix}}Mul(&t0, &t0, &z3)
+	{{.Prefix}}Add(&y3, &y3, &t0)
+	{{.Prefix}}Mul(&t0, &p.y, &p.z)
+	{{.AddLazy}}(&t0, &t0, &t0)
+	{{.Prefix}}Mul(&z3, &t0, &z3)
+	{{.Prefix}}Sub(&x3, &x3, &z3)
+	{{.Prefix}}Mul(&z3, &t0, &t1)
+	{{.Prefix}}Add(&z3, &z3, &z3)
+	{{.Prefix}}Add(&z3, &z3, &z3)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// selectPoint sets q to a if control is 1, and leaves it unchanged if it is 0.
+func (q *{{.Prefix}}Point) selectPoint(a *{{.Prefix}}Point, control {{.Word}}) {
+	{{.Prefix}}Select(&q.x, &a.x, &q.x, control)
+	{{.Prefix}}Select(&q.y, &a.y, &q.y, control)
+	{{.Prefix}}Select(&q.z, &a.z, &q.z, control)
+}
+
+// scalarMult sets q = k * p, and returns q, with a fixed window of 4 bits.
+// Every window does the same doublings and addition, and reads the whole
+// table, whatever the value of k.
+func (q *{{.Prefix}}Point) scalarMult(p *{{.Prefix}}Point, k []byte) *{{.Prefix}}Point {
+	var table [16]{{.Prefix}}Point
+	table[0].y = {{.Prefix}}One
+	table[1] = *p
+	for i := 2; i < 16; i += 2 {
+		table[i].double(&table[i/2])
+		table[i+1].add(&table[i], p)
+	}
+	out := {{.Prefix}}Point{y: {{.Prefix}}One}
+	var t {{.Prefix}}Point
+	for _, b := range k {
+		for shift := 4; shift >= 0; shift -= 4 {
+			w := b >> shift & 0xf
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			t = table[0]
+			for j := 1; j < 16; j++ {
+				t.selectPoint(&table[j], {{.Word}}(subtle.ConstantTimeByteEq(uint8(j), w)))
+			}
+			out.add(&out, &t)
+		}
+	}
+	*q = out
+	return q
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Parse(`// Code generated by curvegen. DO NOT EDIT.
+
+package {{.TestPackage}}
+
+import (
+	"testing"
+
+{{if .Internal}}	"github.com/cronokirby/ctcrypto/elliptic"
+{{end}}	"github.com/cronokirby/ctcrypto/elliptic/elliptictest"
+)
+
+func Test{{.Func}}(t *testing.T) {
+	if err := elliptictest.TestCurve({{.TestFunc}}(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test{{.Func}}Laws(t *testing.T) {
+	if err := elliptictest.TestLaws({{.TestFunc}}(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+`))
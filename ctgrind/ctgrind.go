@@ -0,0 +1,59 @@
+// Package ctgrind annotates secret memory, so that the library can be checked
+// for secret-dependent branches and memory accesses with ctgrind-style tools.
+//
+// The annotations do nothing by default. Building with the ctgrind tag, on
+// amd64, turns them into Valgrind client requests, which mark secrets as
+// undefined for Memcheck:
+//
+//	go test -tags ctgrind -c ./ecdh
+//	valgrind ./ecdh.test
+//
+// Memcheck then reports every branch or memory access depending on a secret,
+// as a use of an uninitialized value. Building with -msan instead poisons
+// secrets for MemorySanitizer, which reports their reads.
+//
+// The private keys of ecdh, the nonces of ecdsa, and the keys and nonces of
+// xeddsa are marked as secret, and the public values derived from them, like
+// public keys and signatures, are declassified.
+package ctgrind
+
+import (
+	"math/big"
+	"unsafe"
+)
+
+// MarkSecret marks the contents of b as secret.
+func MarkSecret(b []byte) {
+	if len(b) > 0 {
+		markSecret(unsafe.Pointer(&b[0]), uintptr(len(b)))
+	}
+}
+
+// Declassify marks the contents of b as public.
+func Declassify(b []byte) {
+	if len(b) > 0 {
+		declassify(unsafe.Pointer(&b[0]), uintptr(len(b)))
+	}
+}
+
+// MarkSecretInt marks the value of x as secret.
+func MarkSecretInt(x *big.Int) {
+	if bits := x.Bits(); len(bits) > 0 {
+		markSecret(unsafe.Pointer(&bits[0]), uintptr(len(bits))*unsafe.Sizeof(bits[0]))
+	}
+}
+
+// DeclassifyInt marks the value of x as public.
+func DeclassifyInt(x *big.Int) {
+	if bits := x.Bits(); len(bits) > 0 {
+		declassify(unsafe.Pointer(&bits[0]), uintptr(len(bits))*unsafe.Sizeof(bits[0]))
+	}
+}
+
+// DeclassifyAllocation marks the n bytes starting at p as public, for values
+// which aren't byte slices or integers, like the result of a comparison.
+func DeclassifyAllocation(p unsafe.Pointer, n uintptr) {
+	if n > 0 {
+		declassify(p, n)
+	}
+}
@@ -0,0 +1,34 @@
+package ctgrind
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"unsafe"
+)
+
+// Outside of Valgrind and MemorySanitizer, the annotations must not change
+// any value.
+func TestAnnotationsPreserveValues(t *testing.T) {
+	b := []byte{1, 2, 3, 4}
+	MarkSecret(b)
+	Declassify(b)
+	if !bytes.Equal(b, []byte{1, 2, 3, 4}) {
+		t.Errorf("annotations changed a byte slice")
+	}
+	x := new(big.Int).Lsh(big.NewInt(12345), 200)
+	expected := new(big.Int).Set(x)
+	MarkSecretInt(x)
+	DeclassifyInt(x)
+	if x.Cmp(expected) != 0 {
+		t.Errorf("annotations changed an integer")
+	}
+	ok := true
+	DeclassifyAllocation(unsafe.Pointer(&ok), unsafe.Sizeof(ok))
+	if !ok {
+		t.Errorf("annotations changed a bool")
+	}
+	MarkSecret(nil)
+	MarkSecretInt(new(big.Int))
+	DeclassifyAllocation(nil, 0)
+}
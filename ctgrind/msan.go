@@ -0,0 +1,24 @@
+// +build msan
+
+package ctgrind
+
+/*
+#include <stddef.h>
+
+void __msan_poison(const volatile void *a, size_t size);
+void __msan_unpoison(const volatile void *a, size_t size);
+*/
+import "C"
+
+import "unsafe"
+
+// Enabled reports whether the annotations of this package have an effect.
+const Enabled = true
+
+func markSecret(p unsafe.Pointer, n uintptr) {
+	C.__msan_poison(p, C.size_t(n))
+}
+
+func declassify(p unsafe.Pointer, n uintptr) {
+	C.__msan_unpoison(p, C.size_t(n))
+}
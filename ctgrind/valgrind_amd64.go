@@ -0,0 +1,29 @@
+// +build ctgrind,!msan
+
+package ctgrind
+
+import "unsafe"
+
+// Enabled reports whether the annotations of this package have an effect.
+const Enabled = true
+
+// The Memcheck client requests, from valgrind/memcheck.h.
+const (
+	memcheckBase          = 'M'<<24 | 'C'<<16
+	memcheckMakeUndefined = memcheckBase + 1
+	memcheckMakeDefined   = memcheckBase + 2
+)
+
+// valgrindRequest performs the Valgrind client request args[0], with the
+// arguments args[1:], and returns its result, or 0 outside of Valgrind.
+//
+//go:noescape
+func valgrindRequest(args *[6]uintptr) uintptr
+
+func markSecret(p unsafe.Pointer, n uintptr) {
+	valgrindRequest(&[6]uintptr{memcheckMakeUndefined, uintptr(p), n})
+}
+
+func declassify(p unsafe.Pointer, n uintptr) {
+	valgrindRequest(&[6]uintptr{memcheckMakeDefined, uintptr(p), n})
+}
@@ -0,0 +1,19 @@
+// +build ctgrind,!msan
+
+#include "textflag.h"
+
+// func valgrindRequest(args *[6]uintptr) uintptr
+//
+// This is the special instruction sequence of valgrind.h, which rotates DI by
+// 128 bits, and exchanges BX with itself. Valgrind recognizes it, and reads
+// the request from the array pointed to by AX, returning its result in DX.
+TEXT ·valgrindRequest(SB), NOSPLIT, $0-16
+	MOVQ args+0(FP), AX
+	XORQ DX, DX
+	BYTE $0x48; BYTE $0xc1; BYTE $0xc7; BYTE $0x03 // ROLQ $3, DI
+	BYTE $0x48; BYTE $0xc1; BYTE $0xc7; BYTE $0x0d // ROLQ $13, DI
+	BYTE $0x48; BYTE $0xc1; BYTE $0xc7; BYTE $0x3d // ROLQ $61, DI
+	BYTE $0x48; BYTE $0xc1; BYTE $0xc7; BYTE $0x33 // ROLQ $51, DI
+	BYTE $0x48; BYTE $0x87; BYTE $0xdb             // XCHGQ BX, BX
+	MOVQ DX, ret+8(FP)
+	RET
@@ -0,0 +1,138 @@
+// Package cttest measures whether the time an operation takes depends on its
+// inputs, following the methodology of dudect, from "Dude, is my code constant
+// time?", by Reparaz, Balasch, and Verbauwhede.
+//
+// The operation is run on inputs of two classes, usually a fixed input and
+// random ones, in a random order, and Welch's t-test is applied to the two
+// distributions of timings, as well as to versions of them cropped at several
+// percentiles, which removes the outliers caused by interrupts and the
+// scheduler. A large t statistic means that the two classes can be told apart,
+// while a small one only means that no difference was found with this many
+// measurements, so the test is more convincing the longer it runs.
+//
+// Go has no portable way of reading the cycle counter, so this uses the
+// monotonic clock, which is precise enough for operations taking more than a
+// microsecond, like the ones of this module.
+package cttest
+
+import (
+	"crypto/rand"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Threshold is the t statistic above which an operation is considered to
+// leak, as in dudect. Values above 10 leave little doubt.
+const Threshold = 4.5
+
+// Test describes an operation to measure.
+type Test struct {
+	// Input returns an input of class 0 or 1, which is prepared before the
+	// measurements.
+	//
+	// Class 0 is typically a fixed input, and class 1 a random one, drawn
+	// from rand.
+	Input func(class int, rand io.Reader) interface{}
+	// Run is the operation measured, applied to an input returned by Input.
+	Run func(input interface{})
+}
+
+// Result is the outcome of Measure.
+type Result struct {
+	// Measurements is the number of measurements of each class.
+	Measurements [2]int
+	// T is the largest t statistic found, in absolute value, over the
+	// cropped and uncropped distributions.
+	T float64
+}
+
+// Leaks reports whether the t statistic is above Threshold.
+func (r Result) Leaks() bool {
+	return r.T > Threshold
+}
+
+// cropCount is the number of percentiles at which the distributions are
+// cropped, in addition to the uncropped test.
+const cropCount = 10
+
+// Measure runs test on n inputs, of random classes, and returns the result of
+// the t-test on their timings.
+func Measure(test Test, n int) (Result, error) {
+	classBits := make([]byte, (n+7)/8)
+	if _, err := io.ReadFull(rand.Reader, classBits); err != nil {
+		return Result{}, err
+	}
+	classes := make([]int, n)
+	inputs := make([]interface{}, n)
+	for i := range inputs {
+		classes[i] = int(classBits[i/8]>>(i%8)) & 1
+		inputs[i] = test.Input(classes[i], rand.Reader)
+	}
+
+	timings := make([]float64, n)
+	for i, input := range inputs {
+		start := time.Now()
+		test.Run(input)
+		timings[i] = float64(time.Since(start))
+	}
+
+	sorted := append([]float64{}, timings...)
+	sort.Float64s(sorted)
+	// The same thresholds as dudect, which crop more and more aggressively.
+	thresholds := []float64{math.Inf(1)}
+	for k := 0; k < cropCount; k++ {
+		p := 1 - math.Pow(0.5, 10*float64(k+1)/cropCount)
+		thresholds = append(thresholds, sorted[int(p*float64(n-1))])
+	}
+
+	var result Result
+	for j, threshold := range thresholds {
+		var w [2]welford
+		for i, t := range timings {
+			if t <= threshold {
+				w[classes[i]].add(t)
+			}
+		}
+		if j == 0 {
+			result.Measurements = [2]int{w[0].n, w[1].n}
+		}
+		if t := math.Abs(welchT(&w[0], &w[1])); t > result.T {
+			result.T = t
+		}
+	}
+	return result, nil
+}
+
+// welford accumulates the mean and variance of a sample, with Welford's
+// algorithm.
+type welford struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+func (w *welford) add(x float64) {
+	w.n++
+	delta := x - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (x - w.mean)
+}
+
+func (w *welford) variance() float64 {
+	return w.m2 / float64(w.n-1)
+}
+
+// welchT returns Welch's t statistic for two samples, or 0 if they are too
+// small for it to be defined.
+func welchT(a, b *welford) float64 {
+	if a.n < 2 || b.n < 2 {
+		return 0
+	}
+	denominator := math.Sqrt(a.variance()/float64(a.n) + b.variance()/float64(b.n))
+	if denominator == 0 {
+		return 0
+	}
+	return (a.mean - b.mean) / denominator
+}
@@ -0,0 +1,49 @@
+package cttest
+
+import (
+	"io"
+	"testing"
+)
+
+func TestMeasureDetectsLeak(t *testing.T) {
+	var sink int
+	leaky := Test{
+		Input: func(class int, rand io.Reader) interface{} {
+			return class
+		},
+		Run: func(input interface{}) {
+			// Class 1 takes far longer than class 0.
+			for i := 0; i < 20000*input.(int); i++ {
+				sink += i
+			}
+		},
+	}
+	result, err := Measure(leaky, 2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Leaks() {
+		t.Errorf("leak not detected, t = %f", result.T)
+	}
+	if n := result.Measurements[0] + result.Measurements[1]; n != 2000 {
+		t.Errorf("%d measurements, expected 2000", n)
+	}
+}
+
+func TestWelchT(t *testing.T) {
+	var a, b welford
+	for _, x := range []float64{1, 2, 3, 4} {
+		a.add(x)
+		b.add(x + 10)
+	}
+	if a.mean != 2.5 || a.variance() != 5.0/3 {
+		t.Errorf("mean %f, variance %f", a.mean, a.variance())
+	}
+	// (2.5 - 12.5) / sqrt(2 * 5 / 12)
+	if got := welchT(&a, &b); got > -10.95 || got < -10.96 {
+		t.Errorf("t = %f", got)
+	}
+	if welchT(&a, &welford{}) != 0 {
+		t.Errorf("t defined for an empty sample")
+	}
+}
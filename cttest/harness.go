@@ -0,0 +1,76 @@
+package cttest
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+// ScalarMult returns a test of curve.ScalarMult, multiplying the base point by
+// fixed, for class 0, and by random scalars, for class 1.
+//
+// Special values of fixed, like 0, 1, or scalars with few bits set, are the
+// most likely to reveal a leak.
+func ScalarMult(curve elliptic.Curve, fixed []byte) Test {
+	params := curve.Params()
+	x, y := new(big.Int).SetBytes(params.Gx.Bytes()), new(big.Int).SetBytes(params.Gy.Bytes())
+	return Test{
+		Input: func(class int, rand io.Reader) interface{} {
+			if class == 0 {
+				return fixed
+			}
+			k := make([]byte, len(fixed))
+			io.ReadFull(rand, k)
+			return k
+		},
+		Run: func(input interface{}) {
+			curve.ScalarMult(x, y, input.([]byte))
+		},
+	}
+}
+
+// ECDSASign returns a test of ecdsa.Sign, signing the same digest with fixed,
+// for class 0, and with random keys, for class 1.
+func ECDSASign(fixed *ecdsa.PrivateKey, digest []byte) Test {
+	return Test{
+		Input: func(class int, rand io.Reader) interface{} {
+			if class == 0 {
+				return fixed
+			}
+			priv, err := ecdsa.GenerateKey(fixed.Curve, rand)
+			if err != nil {
+				panic(err)
+			}
+			return priv
+		},
+		Run: func(input interface{}) {
+			ecdsa.Sign(rand.Reader, input.(*ecdsa.PrivateKey), digest)
+		},
+	}
+}
+
+// RSADecrypt returns a test of rsa.DecryptPKCS1v15SessionKey, decrypting
+// fixed, for class 0, and random ciphertexts, for class 1, which almost never
+// have valid padding, and shouldn't take a different time.
+func RSADecrypt(priv *rsa.PrivateKey, fixed []byte) Test {
+	return Test{
+		Input: func(class int, rand io.Reader) interface{} {
+			if class == 0 {
+				return fixed
+			}
+			c := make([]byte, len(fixed))
+			io.ReadFull(rand, c)
+			// Stay below the modulus, whose top byte is non-zero.
+			c[0] = 0
+			return c
+		},
+		Run: func(input interface{}) {
+			key := make([]byte, 16)
+			rsa.DecryptPKCS1v15SessionKey(priv, input.([]byte), key)
+		},
+	}
+}
@@ -0,0 +1,43 @@
+package cttest
+
+import (
+	stdelliptic "crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+// These only check that the harnesses run: whether the operations leak can't
+// be decided reliably in the time a unit test has, on a shared machine.
+
+func TestHarnesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timing harnesses in short mode")
+	}
+	ecdsaKey, err := ecdsa.GenerateKey(stdelliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := rsa.EncryptPKCS1v15(rand.Reader, &rsaKey.PublicKey, make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range map[string]Test{
+		"ScalarMult": ScalarMult(elliptic.P256(), make([]byte, 32)),
+		"ECDSASign":  ECDSASign(ecdsaKey, make([]byte, 32)),
+		"RSADecrypt": RSADecrypt(rsaKey, ciphertext),
+	} {
+		result, err := Measure(test, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s: t = %f", name, result.T)
+	}
+}
@@ -0,0 +1,127 @@
+// Package ctutil extends crypto/subtle with more functions running in constant
+// time.
+//
+// As in crypto/subtle, conditions are passed and returned as ints which are
+// either 0 or 1, so that they can be combined with & and |, and only
+// converted to a branch once every check is done.
+package ctutil
+
+import (
+	"crypto/subtle"
+	"math/bits"
+)
+
+// Eq64 returns 1 if x == y, and 0 otherwise.
+func Eq64(x, y uint64) int {
+	d := x ^ y
+	return int(((d | -d) >> 63) ^ 1)
+}
+
+// Less64 returns 1 if x < y, and 0 otherwise.
+func Less64(x, y uint64) int {
+	_, borrow := bits.Sub64(x, y, 0)
+	return int(borrow)
+}
+
+// LessOrEq64 returns 1 if x <= y, and 0 otherwise.
+func LessOrEq64(x, y uint64) int {
+	return Less64(y, x) ^ 1
+}
+
+// Select64 returns x if v == 1, and y if v == 0. Its behavior is undefined if
+// v takes any other value.
+func Select64(v int, x, y uint64) uint64 {
+	mask := -uint64(v)
+	return x&mask | y&^mask
+}
+
+// CopyIf copies src to dst if v == 1, and leaves dst unchanged if v == 0. Its
+// behavior is undefined if v takes any other value.
+//
+// Unlike crypto/subtle.ConstantTimeCopy, src and dst can be of different
+// lengths, in which case only the first min(len(dst), len(src)) bytes are
+// copied.
+func CopyIf(v int, dst, src []byte) {
+	if len(src) < len(dst) {
+		dst = dst[:len(src)]
+	}
+	mask := -byte(v)
+	for i := range dst {
+		dst[i] ^= (dst[i] ^ src[i]) & mask
+	}
+}
+
+// SwapIf swaps the contents of x and y if v == 1, and leaves them unchanged if
+// v == 0. Its behavior is undefined if v takes any other value. It panics if
+// x and y don't have the same length.
+func SwapIf(v int, x, y []byte) {
+	if len(x) != len(y) {
+		panic("ctutil: slices have different lengths")
+	}
+	mask := -byte(v)
+	for i := range x {
+		t := (x[i] ^ y[i]) & mask
+		x[i] ^= t
+		y[i] ^= t
+	}
+}
+
+// Lookup copies table[index] to out, reading every entry of the table, so
+// that the index isn't revealed by the memory accesses. Every entry must be
+// as long as out, and index must be in range, or Lookup panics.
+func Lookup(out []byte, table [][]byte, index int) {
+	if index < 0 || index >= len(table) {
+		panic("ctutil: index out of range")
+	}
+	for i := range out {
+		out[i] = 0
+	}
+	for i, entry := range table {
+		if len(entry) != len(out) {
+			panic("ctutil: table entry of the wrong length")
+		}
+		mask := -byte(subtle.ConstantTimeEq(int32(i), int32(index)))
+		for j := range out {
+			out[j] |= entry[j] & mask
+		}
+	}
+}
+
+// LookupUint64 returns table[index], reading every entry of the table, as
+// with Lookup.
+func LookupUint64(table []uint64, index int) uint64 {
+	if index < 0 || index >= len(table) {
+		panic("ctutil: index out of range")
+	}
+	var out uint64
+	for i, entry := range table {
+		out |= entry & -uint64(subtle.ConstantTimeEq(int32(i), int32(index)))
+	}
+	return out
+}
+
+// Equal returns 1 if x and y have the same length and contents, and 0
+// otherwise.
+//
+// Unlike crypto/subtle.ConstantTimeCompare, which returns early when the
+// lengths differ, every byte up to the length of the longest slice is read,
+// so the time taken only depends on that length. The missing bytes of the
+// shorter slice are treated as zeros.
+func Equal(x, y []byte) int {
+	n := len(x)
+	if len(y) > n {
+		n = len(y)
+	}
+	var acc byte
+	for i := 0; i < n; i++ {
+		var a, b byte
+		if i < len(x) {
+			a = x[i]
+		}
+		if i < len(y) {
+			b = y[i]
+		}
+		acc |= a ^ b
+	}
+	return subtle.ConstantTimeByteEq(acc, 0) & subtle.ConstantTimeEq(int32(len(x)), int32(len(y)))
+}
@@ -0,0 +1,102 @@
+package ctutil
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestCompare64(t *testing.T) {
+	f := func(x, y uint64) bool {
+		return Eq64(x, y) == b2i(x == y) &&
+			Less64(x, y) == b2i(x < y) &&
+			LessOrEq64(x, y) == b2i(x <= y) &&
+			Eq64(x, x) == 1 && LessOrEq64(x, x) == 1 && Less64(x, x) == 0
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+	edges := []uint64{0, 1, math.MaxInt64, math.MaxInt64 + 1, math.MaxUint64}
+	for _, x := range edges {
+		for _, y := range edges {
+			if !f(x, y) {
+				t.Errorf("wrong comparison of %d and %d", x, y)
+			}
+		}
+	}
+}
+
+func TestSelect64(t *testing.T) {
+	if Select64(1, 3, 4) != 3 || Select64(0, 3, 4) != 4 {
+		t.Error("Select64 picked the wrong value")
+	}
+}
+
+func TestCopyIf(t *testing.T) {
+	dst := []byte{1, 2, 3}
+	CopyIf(0, dst, []byte{4, 5, 6})
+	if !bytes.Equal(dst, []byte{1, 2, 3}) {
+		t.Errorf("CopyIf(0) modified dst: %v", dst)
+	}
+	CopyIf(1, dst, []byte{4, 5})
+	if !bytes.Equal(dst, []byte{4, 5, 3}) {
+		t.Errorf("CopyIf(1) with a shorter src gave %v", dst)
+	}
+}
+
+func TestSwapIf(t *testing.T) {
+	x, y := []byte{1, 2}, []byte{3, 4}
+	SwapIf(0, x, y)
+	if !bytes.Equal(x, []byte{1, 2}) || !bytes.Equal(y, []byte{3, 4}) {
+		t.Error("SwapIf(0) swapped")
+	}
+	SwapIf(1, x, y)
+	if !bytes.Equal(x, []byte{3, 4}) || !bytes.Equal(y, []byte{1, 2}) {
+		t.Error("SwapIf(1) didn't swap")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	table := [][]byte{{1, 1}, {2, 2}, {3, 3}}
+	out := make([]byte, 2)
+	for i := range table {
+		Lookup(out, table, i)
+		if !bytes.Equal(out, table[i]) {
+			t.Errorf("Lookup(%d) = %v", i, out)
+		}
+	}
+	words := []uint64{10, 20, 30}
+	for i := range words {
+		if x := LookupUint64(words, i); x != words[i] {
+			t.Errorf("LookupUint64(%d) = %d", i, x)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		x, y     []byte
+		expected int
+	}{
+		{nil, nil, 1},
+		{nil, []byte{}, 1},
+		{[]byte{1, 2}, []byte{1, 2}, 1},
+		{[]byte{1, 2}, []byte{1, 3}, 0},
+		{[]byte{1, 2}, []byte{1, 2, 0}, 0},
+		{[]byte{1, 2, 0}, []byte{1, 2}, 0},
+		{nil, []byte{0}, 0},
+	}
+	for _, test := range tests {
+		if actual := Equal(test.x, test.y); actual != test.expected {
+			t.Errorf("Equal(%v, %v) = %d, expected %d", test.x, test.y, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package curve25519 implements the X25519 function, and the Elligator 2 map
+// for Curve25519, using the safenum library for constant-time arithmetic.
+//
+// The X25519 function is defined in RFC 7748, and the Elligator 2 map in
+// RFC 9380, section 6.7.1.
+package curve25519
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/ctcrypto/strict"
+	"github.com/cronokirby/safenum"
+)
+
+const (
+	// ScalarSize is the size of the scalar input to X25519.
+	ScalarSize = 32
+	// PointSize is the size of the point input to X25519.
+	PointSize = 32
+)
+
+// Basepoint is the canonical Curve25519 generator.
+var Basepoint []byte
+
+var basePoint = [32]byte{9}
+
+func init() { Basepoint = basePoint[:] }
+
+// ladder calculates the u-coordinate of scalar * u, using the Montgomery ladder
+// described in RFC 7748, section 5.
+func ladder(scalar []byte, u *safenum.Nat) *safenum.Nat {
+	var e [32]byte
+	copy(e[:], scalar)
+	e[0] &= 248
+	e[31] &= 127
+	e[31] |= 64
+
+	x1 := u
+	x2 := fe.FromUint64(1)
+	z2 := fe.FromUint64(0)
+	x3 := new(safenum.Nat).SetNat(u)
+	z3 := fe.FromUint64(1)
+
+	A := new(safenum.Nat)
+	AA := new(safenum.Nat)
+	B := new(safenum.Nat)
+	BB := new(safenum.Nat)
+	E := new(safenum.Nat)
+	C := new(safenum.Nat)
+	D := new(safenum.Nat)
+	DA := new(safenum.Nat)
+	CB := new(safenum.Nat)
+
+	swap := 0
+	for t := 254; t >= 0; t-- {
+		kt := int(e[t/8]>>(t%8)) & 1
+		swap ^= kt
+		fe.Swap(x2, x3, swap)
+		fe.Swap(z2, z3, swap)
+		swap = kt
+
+		A.ModAdd(x2, z2, fe.P)
+		AA.ModMul(A, A, fe.P)
+		B.ModSub(x2, z2, fe.P)
+		BB.ModMul(B, B, fe.P)
+		E.ModSub(AA, BB, fe.P)
+		C.ModAdd(x3, z3, fe.P)
+		D.ModSub(x3, z3, fe.P)
+		DA.ModMul(D, A, fe.P)
+		CB.ModMul(C, B, fe.P)
+
+		x3.ModAdd(DA, CB, fe.P)
+		x3.ModMul(x3, x3, fe.P)
+		z3.ModSub(DA, CB, fe.P)
+		z3.ModMul(z3, z3, fe.P)
+		z3.ModMul(z3, x1, fe.P)
+		x2.ModMul(AA, BB, fe.P)
+		z2.ModMul(a24, E, fe.P)
+		z2.ModAdd(z2, AA, fe.P)
+		z2.ModMul(z2, E, fe.P)
+	}
+	fe.Swap(x2, x3, swap)
+	fe.Swap(z2, z3, swap)
+
+	return x2.ModMul(x2, fe.Invert(z2), fe.P)
+}
+
+// X25519 returns the result of the scalar multiplication (scalar * point),
+// according to RFC 7748, Section 5. scalar, point and the return value are
+// slices of 32 bytes.
+//
+// If point is Basepoint (but not if it's a different slice with the same
+// contents) a precomputed implementation might be used for performance.
+//
+// An error is returned if the result would be the all-zero value, which
+// happens when point is of low order.
+func X25519(scalar, point []byte) ([]byte, error) {
+	if l := len(scalar); l != ScalarSize {
+		return nil, fmt.Errorf("bad scalar length: %d, expected %d", l, ScalarSize)
+	}
+	if l := len(point); l != PointSize {
+		return nil, fmt.Errorf("bad point length: %d, expected %d", l, PointSize)
+	}
+	if err := strict.Check(CanonicalPoint(point), "X25519 public key", "not reduced, or with the top bit set"); err != nil {
+		return nil, err
+	}
+	out := fe.ToBytes(ladder(scalar, feFromBytes(point)))
+	var zero [32]byte
+	if subtle.ConstantTimeCompare(out, zero[:]) == 1 {
+		return nil, errors.New("bad input point: low order point")
+	}
+	return out, nil
+}
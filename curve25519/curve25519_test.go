@@ -0,0 +1,83 @@
+package curve25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestX25519Vectors(t *testing.T) {
+	// See RFC 7748, section 5.2.
+	tests := []struct {
+		scalar, point, expected string
+	}{
+		{
+			"a546e36bf0527c9d3b16154b82465edd62144c0ac1fc5a18506a2244ba449ac4",
+			"e6db6867583030db3594c1a424b15f7c726624ec26b3353b10a903a6d0ab1c4c",
+			"c3da55379de9c6908e94ea4df28d084f32eccf03491c71f754b4075577a28552",
+		},
+		{
+			"4b66e9d4d1b4673c5ad22691957d6af5c11b6421e0ea01d42ca4169e7918ba0d",
+			"e5210f12786811d3f4b7959d0538ae2c31dbe7106fc03c3efc4cd549c715a493",
+			"95cbde9476e8907d7aade45cb4b873f88b595a68799fa152e6f8f7647aac7957",
+		},
+	}
+	for _, test := range tests {
+		scalar, _ := hex.DecodeString(test.scalar)
+		point, _ := hex.DecodeString(test.point)
+		out, err := X25519(scalar, point)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(out) != test.expected {
+			t.Errorf("X25519(%s, %s) = %x, expected %s", test.scalar, test.point, out, test.expected)
+		}
+	}
+}
+
+func TestX25519AgainstReference(t *testing.T) {
+	scalar := make([]byte, 32)
+	for i := 0; i < 10; i++ {
+		rand.Read(scalar)
+		expected, err := curve25519.X25519(scalar, curve25519.Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := X25519(scalar, Basepoint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out, expected) {
+			t.Errorf("X25519(%x, Basepoint) = %x, expected %x", scalar, out, expected)
+		}
+	}
+}
+
+func TestX25519LowOrder(t *testing.T) {
+	scalar := make([]byte, 32)
+	rand.Read(scalar)
+	zero := make([]byte, 32)
+	if _, err := X25519(scalar, zero); err == nil {
+		t.Errorf("expected an error for a low order point")
+	}
+}
+
+func TestX25519BadLengths(t *testing.T) {
+	if _, err := X25519(make([]byte, 31), Basepoint); err == nil {
+		t.Errorf("expected an error for a short scalar")
+	}
+	if _, err := X25519(make([]byte, 32), make([]byte, 33)); err == nil {
+		t.Errorf("expected an error for a long point")
+	}
+}
+
+func BenchmarkX25519(b *testing.B) {
+	scalar := make([]byte, 32)
+	rand.Read(scalar)
+	for i := 0; i < b.N; i++ {
+		X25519(scalar, Basepoint)
+	}
+}
@@ -0,0 +1,150 @@
+package curve25519
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+)
+
+// RepresentativeSize is the size of an Elligator 2 representative.
+const RepresentativeSize = 32
+
+// A representative encodes a field element r with 0 <= r <= (p - 1) / 2, which
+// fits in 254 bits. The top two bits of the last byte are thus free, and are
+// filled with random padding, so that representatives are indistinguishable
+// from uniform 32 byte strings.
+const representativePadding = 0xc0
+
+// elligator2 implements the Elligator 2 map for Curve25519, as described in
+// RFC 9380, section 6.7.1, with Z = 2.
+//
+// It returns the u and v coordinates of the resulting point.
+func elligator2(r *safenum.Nat) (u, v *safenum.Nat) {
+	one := fe.FromUint64(1)
+	zero := fe.FromUint64(0)
+	minusA := fe.Neg(curveA)
+
+	// x1 = -A / (1 + 2r²), or -A if the denominator is 0
+	x1 := new(safenum.Nat).ModMul(r, r, fe.P)
+	x1.ModAdd(x1, x1, fe.P)
+	x1.ModAdd(x1, one, fe.P)
+	x1 = fe.Invert(x1)
+	x1.ModMul(x1, minusA, fe.P)
+	x1 = fe.Select(minusA, x1, fe.Equal(x1, zero))
+	gx1 := curvePolynomial(x1)
+
+	// x2 = -x1 - A
+	x2 := new(safenum.Nat).ModSub(minusA, x1, fe.P)
+	gx2 := curvePolynomial(x2)
+
+	// If gx1 is a square, we use x1 with the odd root of gx1, otherwise we
+	// use x2 with the even root of gx2.
+	gx1Square := fe.IsSquare(gx1)
+	u = fe.Select(x1, x2, gx1Square)
+	v = fe.Sqrt(fe.Select(gx1, gx2, gx1Square))
+	v = fe.Select(fe.Neg(v), v, gx1Square)
+	return u, v
+}
+
+// curvePolynomial returns u³ + A u² + u.
+func curvePolynomial(u *safenum.Nat) *safenum.Nat {
+	// u (u (u + A) + 1)
+	out := new(safenum.Nat).ModAdd(u, curveA, fe.P)
+	out.ModMul(out, u, fe.P)
+	out.ModAdd(out, fe.FromUint64(1), fe.P)
+	return out.ModMul(out, u, fe.P)
+}
+
+// Elligator2Map maps a representative to the u-coordinate of a point on Curve25519.
+//
+// Every string of RepresentativeSize bytes is a valid representative, and the
+// top two bits of the representative are ignored. Representatives produced by
+// Elligator2Inverse will map back to the point they were created from.
+func Elligator2Map(representative []byte) ([]byte, error) {
+	if len(representative) != RepresentativeSize {
+		return nil, errors.New("curve25519: bad representative length")
+	}
+	var r [32]byte
+	copy(r[:], representative)
+	r[31] &^= representativePadding
+	u, _ := elligator2(feFromBytes(r[:]))
+	return fe.ToBytes(u), nil
+}
+
+// Elligator2Inverse returns a representative of a point on the curve, given
+// its u-coordinate, if such a representative exists.
+//
+// About half of the points on the curve have a representative. The ok result
+// reports whether the point has one.
+//
+// Each point with a representative has two of them, corresponding to the two
+// possible v-coordinates with this u-coordinate. The lowest bit of tweak selects
+// which one to use, and the top two bits of tweak are used as the padding bits
+// of the representative. For the representatives to look uniformly random, the
+// tweak should be chosen at random.
+func Elligator2Inverse(u []byte, tweak byte) (representative []byte, ok bool) {
+	if len(u) != PointSize {
+		return nil, false
+	}
+	uNat := feFromBytes(u)
+
+	// When the point came from x1 in the forward map, we have
+	//   r² = -(u + A) / 2u
+	// and when the point came from x2, we have
+	//   r² = -u / 2(u + A)
+	uPlusA := new(safenum.Nat).ModAdd(uNat, curveA, fe.P)
+	twoU := new(safenum.Nat).ModAdd(uNat, uNat, fe.P)
+	twoUPlusA := new(safenum.Nat).ModAdd(uPlusA, uPlusA, fe.P)
+	fromX1 := int(tweak & 1)
+	num := fe.Select(uPlusA, uNat, fromX1)
+	den := fe.Select(twoU, twoUPlusA, fromX1)
+	r2 := new(safenum.Nat).ModMul(fe.Neg(num), fe.Invert(den), fe.P)
+	r := fe.Sqrt(r2)
+	// Of the two roots, we use the one in [0, (p - 1) / 2], which fits in 254 bits.
+	r = fe.Select(fe.Neg(r), r, subtle.ConstantTimeEq(int32(r.Cmp(fe.PMinus1Over2)), 1))
+
+	// Rather than checking the conditions for the point to have a representative
+	// individually, we check that the forward map does produce this point. This
+	// also takes care of the exceptional cases, like u = 0, or u = -A.
+	mapped, _ := elligator2(r)
+	representable := fe.Equal(mapped, uNat)
+
+	if representable != 1 {
+		return nil, false
+	}
+	out := fe.ToBytes(r)
+	out[31] |= tweak & representativePadding
+	return out, true
+}
+
+// GenerateHiddenKey generates an X25519 key pair, along with a representative
+// of the public key, reading randomness from rand.
+//
+// The public key can be recovered from the representative with Elligator2Map,
+// and the representative can be transmitted instead of the public key, to make
+// key exchanges look like random noise.
+//
+// Note that the public keys produced by X25519 always lie in the prime order
+// subgroup of the curve, which means that an adversary that can recover the
+// points from many representatives can eventually tell them apart from random
+// points. Protocols requiring indistinguishability against such adversaries
+// need to mix low order components into their public keys.
+func GenerateHiddenKey(rand io.Reader) (private, representative []byte, err error) {
+	private = make([]byte, ScalarSize)
+	var tweak [1]byte
+	for {
+		if _, err = io.ReadFull(rand, private); err != nil {
+			return nil, nil, err
+		}
+		if _, err = io.ReadFull(rand, tweak[:]); err != nil {
+			return nil, nil, err
+		}
+		public := fe.ToBytes(ladder(private, feFromBytes(Basepoint)))
+		if representative, ok := Elligator2Inverse(public, tweak[0]); ok {
+			return private, representative, nil
+		}
+	}
+}
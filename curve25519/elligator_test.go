@@ -0,0 +1,88 @@
+package curve25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+)
+
+func TestElligator2MapOnCurve(t *testing.T) {
+	representative := make([]byte, RepresentativeSize)
+	for i := 0; i < 20; i++ {
+		rand.Read(representative)
+		u, err := Elligator2Map(representative)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fe.IsSquare(curvePolynomial(feFromBytes(u))) != 1 {
+			t.Errorf("Elligator2Map(%x) = %x is not on the curve", representative, u)
+		}
+	}
+}
+
+func TestElligator2MapIgnoresPadding(t *testing.T) {
+	representative := make([]byte, RepresentativeSize)
+	rand.Read(representative)
+	representative[31] &= 0x3f
+	u0, _ := Elligator2Map(representative)
+	representative[31] |= 0xc0
+	u1, _ := Elligator2Map(representative)
+	if !bytes.Equal(u0, u1) {
+		t.Errorf("padding bits changed the output of the map")
+	}
+}
+
+func TestElligator2RoundTrip(t *testing.T) {
+	representative := make([]byte, RepresentativeSize)
+	for i := 0; i < 20; i++ {
+		rand.Read(representative)
+		u, _ := Elligator2Map(representative)
+		for tweak := 0; tweak < 256; tweak += 0x41 {
+			r, ok := Elligator2Inverse(u, byte(tweak))
+			if !ok {
+				t.Fatalf("Elligator2Inverse(%x) failed for a point in the image of the map", u)
+			}
+			if r[31]&representativePadding != byte(tweak)&representativePadding {
+				t.Errorf("padding bits not taken from the tweak")
+			}
+			u2, _ := Elligator2Map(r)
+			if !bytes.Equal(u, u2) {
+				t.Errorf("Elligator2Map(Elligator2Inverse(%x)) = %x", u, u2)
+			}
+		}
+	}
+}
+
+func TestElligator2InverseRejects(t *testing.T) {
+	// Roughly half of the points don't have a representative, so we should
+	// find some among the first few public keys.
+	rejected := 0
+	scalar := make([]byte, ScalarSize)
+	for i := 0; i < 16; i++ {
+		rand.Read(scalar)
+		u, _ := X25519(scalar, Basepoint)
+		if _, ok := Elligator2Inverse(u, 0); !ok {
+			rejected++
+		}
+	}
+	if rejected == 0 {
+		t.Errorf("no public keys were rejected by Elligator2Inverse")
+	}
+}
+
+func TestGenerateHiddenKey(t *testing.T) {
+	private, representative, err := GenerateHiddenKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := X25519(private, Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := Elligator2Map(representative)
+	if !bytes.Equal(u, public) {
+		t.Errorf("representative maps to %x, expected public key %x", u, public)
+	}
+}
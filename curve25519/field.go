@@ -0,0 +1,38 @@
+package curve25519
+
+import (
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+)
+
+// This file contains the parts of the arithmetic in GF(2^255 - 19) which are
+// specific to Curve25519. The rest is shared with edwards25519, in internal/fe.
+
+var (
+	// the A coefficient of the curve v² = u³ + A u² + u
+	curveA = fe.FromUint64(486662)
+	// (A - 2) / 4, used in the Montgomery ladder
+	a24 = fe.FromUint64(121665)
+)
+
+// feFromBytes decodes a 32 byte little endian field element.
+//
+// As per RFC 7748, the top bit is ignored, and non-canonical values are accepted.
+func feFromBytes(in []byte) *safenum.Nat {
+	z, _ := fe.FromBytes(in)
+	return z
+}
+
+// CanonicalPoint reports whether point is the canonical encoding of a
+// u-coordinate: lower than p, with the top bit cleared. Points are public, so
+// this isn't constant-time.
+func CanonicalPoint(point []byte) bool {
+	if len(point) != PointSize || point[31]>>7 != 0 {
+		return false
+	}
+	var be [32]byte
+	for i := 0; i < 32; i++ {
+		be[i] = point[31-i]
+	}
+	return new(safenum.Nat).SetBytes(be[:]).CmpMod(fe.P) == -1
+}
@@ -0,0 +1,487 @@
+// Package dkg implements distributed key generation, following the protocol
+// of Gennaro, Jarecki, Krawczyk, and Rabin, which builds on Pedersen's
+// verifiable secret sharing.
+//
+// At the end of the protocol, each of the n participants holds a share of a
+// secret key, such that any threshold of them can use it, along with the
+// public key, and the public verification share of each participant. Nobody
+// learns the secret key, and its distribution is uniform, even if some
+// participants deviate from the protocol.
+//
+// The protocol consists of the following steps, run by each participant:
+//
+//  1. Deal: deal a random secret with Pedersen's scheme, broadcasting the
+//     commitments, and sending a share privately to each participant.
+//  2. ReceiveShares: verify the shares received, broadcasting a complaint
+//     against each dealer whose share is invalid.
+//  3. Justify: answer the complaints against this participant, by
+//     broadcasting the shares in question.
+//  4. Qualify: disqualify the dealers which didn't answer complaints
+//     correctly, and broadcast Feldman commitments to the secret dealt.
+//  5. Extract: verify the shares of the qualified dealers against their
+//     Feldman commitments, broadcasting the invalid shares as evidence.
+//  6. Reveal: broadcast the shares received from the dealers exposed by valid
+//     evidence, so that their secrets can be reconstructed.
+//  7. Finish: combine everything into a KeyShare.
+//
+// Broadcasts are assumed to be reliable, and private messages to be sent over
+// authenticated and confidential channels. Each step takes all the messages
+// broadcast in the previous step, including the ones of this participant.
+//
+// The steps verifying shares take a context, and stop, returning ctx.Err(),
+// once it is done, so that a protocol with many participants can be given a
+// deadline. A cancelled step leaves the participant in an unspecified state,
+// and the protocol must be restarted.
+package dkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/ctcrypto/vss"
+	"github.com/cronokirby/safenum"
+)
+
+// Commitment is the broadcast of a dealer, committing to the coefficients of
+// its polynomials.
+type Commitment struct {
+	// Dealer is the identifier of the participant who dealt the secret.
+	Dealer uint32
+	// Elements are the commitments, starting with the constant term.
+	Elements []group.Element
+}
+
+// Share is the share of a dealer's secret, sent to a recipient.
+type Share struct {
+	// Dealer is the identifier of the participant who dealt the secret.
+	Dealer uint32
+	// Recipient is the identifier of the participant receiving the share.
+	Recipient uint32
+	// Value is the share of the secret.
+	Value *safenum.Nat
+	// Blinding is the share of the blinding value of Pedersen's scheme.
+	Blinding *safenum.Nat
+}
+
+// Complaint is a broadcast accusing a dealer of having sent an invalid share.
+type Complaint struct {
+	// Accuser is the identifier of the participant complaining.
+	Accuser uint32
+	// Dealer is the identifier of the participant accused.
+	Dealer uint32
+	// Evidence is the share received from the dealer, which is only included
+	// for complaints made in the Extract step.
+	Evidence *Share
+}
+
+// KeyShare is the output of the protocol for a participant.
+type KeyShare struct {
+	// ID is the identifier of the participant.
+	ID uint32
+	// Secret is the share of the secret key of the participant.
+	Secret *safenum.Nat
+	// PublicKey is the public key corresponding to the secret key.
+	PublicKey group.Element
+	// VerificationShares contains Secret * G, for each participant.
+	VerificationShares map[uint32]group.Element
+	// Qualified contains the identifiers of the dealers whose secrets were
+	// used, in increasing order.
+	Qualified []uint32
+}
+
+// Participant is the state of a participant in the protocol.
+type Participant struct {
+	g         group.Group
+	id        uint32
+	threshold int
+	n         int
+
+	secret   *shamir.Polynomial
+	blinding *shamir.Polynomial
+
+	// The commitments and valid shares received, by dealer.
+	pedersen map[uint32][]group.Element
+	shares   map[uint32]*Share
+
+	qualified []uint32
+	feldman   map[uint32][]group.Element
+}
+
+// NewParticipant creates the state of the participant with identifier id, in a
+// protocol between n participants, with identifiers 1 through n, producing
+// shares of which threshold are needed to use the secret key.
+//
+// This generates the secret dealt by the participant, reading from rand.
+func NewParticipant(g group.Group, id uint32, threshold, n int, rand io.Reader) (*Participant, error) {
+	if threshold < 1 || threshold > n || uint64(n) > 0xffffffff {
+		return nil, errors.New("dkg: threshold must be between 1 and the number of participants")
+	}
+	if id == 0 || uint64(id) > uint64(n) {
+		return nil, errors.New("dkg: identifier must be between 1 and the number of participants")
+	}
+	z, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := shamir.RandomPolynomial(g.Order(), z, threshold, rand)
+	if err != nil {
+		return nil, err
+	}
+	b, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	blinding, err := shamir.RandomPolynomial(g.Order(), b, threshold, rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Participant{
+		g:         g,
+		id:        id,
+		threshold: threshold,
+		n:         n,
+		secret:    secret,
+		blinding:  blinding,
+		pedersen:  make(map[uint32][]group.Element),
+		shares:    make(map[uint32]*Share),
+		feldman:   make(map[uint32][]group.Element),
+	}, nil
+}
+
+// share returns the share dealt by this participant to a recipient.
+func (p *Participant) share(recipient uint32) *Share {
+	return &Share{
+		Dealer:    p.id,
+		Recipient: recipient,
+		Value:     p.secret.Share(recipient).Value,
+		Blinding:  p.blinding.Share(recipient).Value,
+	}
+}
+
+// Deal returns the commitment of this participant, which must be broadcast,
+// along with the share of each participant, which must be sent to them
+// privately, including the share of this participant.
+func (p *Participant) Deal() (*Commitment, []*Share) {
+	secret := p.secret.Coefficients()
+	blinding := p.blinding.Coefficients()
+	H := vss.PedersenGenerator(p.g)
+	elements := make([]group.Element, len(secret))
+	for k := range elements {
+		elements[k] = p.g.ScalarBaseMult(secret[k]).Add(H.ScalarMult(blinding[k]))
+	}
+	shares := make([]*Share, p.n)
+	for i := range shares {
+		shares[i] = p.share(uint32(i + 1))
+	}
+	return &Commitment{Dealer: p.id, Elements: elements}, shares
+}
+
+// validDealer reports whether dealer is the identifier of a participant.
+func (p *Participant) validDealer(dealer uint32) bool {
+	return dealer != 0 && uint64(dealer) <= uint64(p.n)
+}
+
+// verifyPedersen checks a share against the Pedersen commitments of its dealer.
+func (p *Participant) verifyPedersen(share *Share) bool {
+	commitments, ok := p.pedersen[share.Dealer]
+	if !ok || share.Value == nil || share.Blinding == nil {
+		return false
+	}
+	return vss.PedersenVerify(p.g, vss.PedersenShare{
+		Share:    vss.Share{ID: share.Recipient, Value: share.Value},
+		Blinding: share.Blinding,
+	}, commitments)
+}
+
+// ReceiveShares processes the commitments broadcast by the dealers, and the
+// shares they sent to this participant, returning complaints against the
+// dealers whose share is missing or invalid, which must be broadcast.
+func (p *Participant) ReceiveShares(ctx context.Context, commitments []*Commitment, shares []*Share) ([]*Complaint, error) {
+	for _, c := range commitments {
+		if !p.validDealer(c.Dealer) {
+			return nil, fmt.Errorf("dkg: commitment from unknown participant %d", c.Dealer)
+		}
+		if _, ok := p.pedersen[c.Dealer]; ok {
+			return nil, fmt.Errorf("dkg: duplicate commitment from participant %d", c.Dealer)
+		}
+		if len(c.Elements) != p.threshold {
+			// This dealer is disqualified, since everyone sees the broadcast.
+			continue
+		}
+		p.pedersen[c.Dealer] = c.Elements
+	}
+	received := make(map[uint32]*Share)
+	for _, share := range shares {
+		if share.Recipient != p.id {
+			return nil, errors.New("dkg: share meant for another participant")
+		}
+		received[share.Dealer] = share
+	}
+	var complaints []*Complaint
+	for dealer := range p.pedersen {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		share, ok := received[dealer]
+		if !ok || !p.verifyPedersen(share) {
+			complaints = append(complaints, &Complaint{Accuser: p.id, Dealer: dealer})
+			continue
+		}
+		p.shares[dealer] = share
+	}
+	sortComplaints(complaints)
+	return complaints, nil
+}
+
+// Justify answers the complaints against this participant, returning the
+// shares in question, which must be broadcast.
+func (p *Participant) Justify(complaints []*Complaint) []*Share {
+	var out []*Share
+	for _, c := range complaints {
+		if c.Dealer == p.id && p.validDealer(c.Accuser) {
+			out = append(out, p.share(c.Accuser))
+		}
+	}
+	return out
+}
+
+// Qualify processes the complaints broadcast in the ReceiveShares step, and
+// the justifications of the dealers, to determine which dealers are
+// qualified.
+//
+// A dealer is disqualified if it received more than threshold - 1 complaints,
+// or if it didn't answer a complaint with a valid share. A participant which
+// complained adopts the share revealed by the dealer.
+//
+// If this participant is qualified, this returns its Feldman commitments,
+// which must be broadcast.
+func (p *Participant) Qualify(ctx context.Context, complaints []*Complaint, justifications []*Share) (*Commitment, error) {
+	complained := make(map[uint32]map[uint32]bool)
+	for _, c := range complaints {
+		if !p.validDealer(c.Dealer) || !p.validDealer(c.Accuser) {
+			return nil, errors.New("dkg: complaint involving an unknown participant")
+		}
+		if complained[c.Dealer] == nil {
+			complained[c.Dealer] = make(map[uint32]bool)
+		}
+		complained[c.Dealer][c.Accuser] = true
+	}
+	justified := make(map[uint32]map[uint32]*Share)
+	for _, share := range justifications {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !p.verifyPedersen(share) {
+			continue
+		}
+		if justified[share.Dealer] == nil {
+			justified[share.Dealer] = make(map[uint32]*Share)
+		}
+		justified[share.Dealer][share.Recipient] = share
+	}
+
+	p.qualified = nil
+	for dealer := range p.pedersen {
+		accusers := complained[dealer]
+		if len(accusers) >= p.threshold {
+			continue
+		}
+		ok := true
+		for accuser := range accusers {
+			if justified[dealer][accuser] == nil {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		if accusers[p.id] {
+			p.shares[dealer] = justified[dealer][p.id]
+		}
+		p.qualified = append(p.qualified, dealer)
+	}
+	sort.Slice(p.qualified, func(i, j int) bool { return p.qualified[i] < p.qualified[j] })
+
+	for _, dealer := range p.qualified {
+		if dealer == p.id {
+			coefficients := p.secret.Coefficients()
+			elements := make([]group.Element, len(coefficients))
+			for k, c := range coefficients {
+				elements[k] = p.g.ScalarBaseMult(c)
+			}
+			return &Commitment{Dealer: p.id, Elements: elements}, nil
+		}
+	}
+	return nil, nil
+}
+
+// verifyFeldman checks a share against the Feldman commitments of its dealer.
+func (p *Participant) verifyFeldman(share *Share) bool {
+	commitments, ok := p.feldman[share.Dealer]
+	if !ok {
+		return false
+	}
+	return vss.FeldmanVerify(p.g, vss.Share{ID: share.Recipient, Value: share.Value}, commitments)
+}
+
+// Extract processes the Feldman commitments of the qualified dealers,
+// returning complaints against the dealers whose share doesn't match their
+// commitments, along with that share as evidence, which must be broadcast.
+func (p *Participant) Extract(ctx context.Context, commitments []*Commitment) ([]*Complaint, error) {
+	for _, c := range commitments {
+		if len(c.Elements) == p.threshold {
+			p.feldman[c.Dealer] = c.Elements
+		}
+	}
+	var complaints []*Complaint
+	for _, dealer := range p.qualified {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		share := p.shares[dealer]
+		if !p.verifyFeldman(share) {
+			complaints = append(complaints, &Complaint{Accuser: p.id, Dealer: dealer, Evidence: share})
+		}
+	}
+	return complaints, nil
+}
+
+// exposed returns the qualified dealers exposed by a valid complaint in the
+// Extract step, i.e. a share matching their Pedersen commitments, but not
+// their Feldman commitments.
+func (p *Participant) exposed(complaints []*Complaint) map[uint32]bool {
+	out := make(map[uint32]bool)
+	for _, c := range complaints {
+		e := c.Evidence
+		if e == nil || e.Dealer != c.Dealer || e.Recipient != c.Accuser {
+			continue
+		}
+		if p.verifyPedersen(e) && !p.verifyFeldman(e) {
+			out[c.Dealer] = true
+		}
+	}
+	return out
+}
+
+// Reveal processes the complaints broadcast in the Extract step, returning the
+// shares this participant received from the exposed dealers, which must be
+// broadcast, so that their secrets can be reconstructed.
+func (p *Participant) Reveal(complaints []*Complaint) []*Share {
+	exposed := p.exposed(complaints)
+	var out []*Share
+	for _, dealer := range p.qualified {
+		if exposed[dealer] {
+			out = append(out, p.shares[dealer])
+		}
+	}
+	return out
+}
+
+// Finish completes the protocol, using the complaints broadcast in the Extract
+// step, and the shares broadcast in the Reveal step.
+//
+// The secret of each exposed dealer is reconstructed from the revealed shares,
+// which fails if fewer than threshold valid shares were revealed.
+func (p *Participant) Finish(ctx context.Context, complaints []*Complaint, revealed []*Share) (*KeyShare, error) {
+	exposed := p.exposed(complaints)
+	order := p.g.Order()
+
+	// The contribution of each dealer to the verification shares, indexed by
+	// dealer, then recipient.
+	contributions := make(map[uint32]map[uint32]group.Element)
+	for _, dealer := range p.qualified {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		contributions[dealer] = make(map[uint32]group.Element, p.n)
+		if exposed[dealer] {
+			shares := p.validRevealed(dealer, revealed)
+			if len(shares) < p.threshold {
+				return nil, fmt.Errorf("dkg: not enough shares to reconstruct the secret of participant %d", dealer)
+			}
+			for id := uint32(1); uint64(id) <= uint64(p.n); id++ {
+				contributions[dealer][id] = p.g.ScalarBaseMult(interpolate(order, shares, id))
+			}
+			contributions[dealer][0] = p.g.ScalarBaseMult(interpolate(order, shares, 0))
+			continue
+		}
+		commitments, ok := p.feldman[dealer]
+		if !ok {
+			return nil, fmt.Errorf("dkg: missing commitments from participant %d", dealer)
+		}
+		for id := uint32(0); uint64(id) <= uint64(p.n); id++ {
+			contributions[dealer][id] = evaluate(p.g, commitments, id)
+		}
+	}
+
+	out := &KeyShare{
+		ID:                 p.id,
+		Secret:             new(safenum.Nat).Mod(new(safenum.Nat), order),
+		PublicKey:          p.g.Identity(),
+		VerificationShares: make(map[uint32]group.Element, p.n),
+		Qualified:          append([]uint32{}, p.qualified...),
+	}
+	for id := uint32(1); uint64(id) <= uint64(p.n); id++ {
+		out.VerificationShares[id] = p.g.Identity()
+	}
+	for _, dealer := range p.qualified {
+		out.Secret.ModAdd(out.Secret, p.shares[dealer].Value, order)
+		out.PublicKey = out.PublicKey.Add(contributions[dealer][0])
+		for id := range out.VerificationShares {
+			out.VerificationShares[id] = out.VerificationShares[id].Add(contributions[dealer][id])
+		}
+	}
+	return out, nil
+}
+
+// validRevealed returns the distinct revealed shares of a dealer which match
+// its Pedersen commitments.
+func (p *Participant) validRevealed(dealer uint32, revealed []*Share) []shamir.Share {
+	seen := make(map[uint32]bool)
+	var out []shamir.Share
+	for _, share := range revealed {
+		if share.Dealer != dealer || seen[share.Recipient] || !p.validDealer(share.Recipient) || !p.verifyPedersen(share) {
+			continue
+		}
+		seen[share.Recipient] = true
+		out = append(out, shamir.Share{ID: share.Recipient, Value: share.Value})
+	}
+	return out
+}
+
+// evaluate returns the sum of commitments[k] * x^k.
+func evaluate(g group.Group, commitments []group.Element, x uint32) group.Element {
+	xNat := new(safenum.Nat).SetUint64(uint64(x))
+	xNat.Mod(xNat, g.Order())
+	out := g.Identity()
+	for k := len(commitments) - 1; k >= 0; k-- {
+		out = out.ScalarMult(xNat).Add(commitments[k])
+	}
+	return out
+}
+
+// interpolate returns the value at x of the polynomial passing through the
+// shares, which are all public.
+func interpolate(m *safenum.Modulus, shares []shamir.Share, x uint32) *safenum.Nat {
+	ids := make([]uint32, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	// The revealed shares have distinct, valid, identifiers.
+	lambdas, _ := shamir.LagrangeCoefficients(m, ids, x)
+	out := new(safenum.Nat).Mod(new(safenum.Nat), m)
+	for i, share := range shares {
+		out.ModAdd(out, lambdas[i].ModMul(lambdas[i], share.Value, m), m)
+	}
+	return out
+}
+
+func sortComplaints(complaints []*Complaint) {
+	sort.Slice(complaints, func(i, j int) bool { return complaints[i].Dealer < complaints[j].Dealer })
+}
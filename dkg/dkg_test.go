@@ -0,0 +1,219 @@
+package dkg
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// misbehavior lets tests tamper with the messages of the protocol.
+type misbehavior struct {
+	// shares modifies the shares sent in the Deal step.
+	shares func(shares []*Share)
+	// justifications modifies the shares broadcast in the Justify step.
+	justifications func(shares []*Share) []*Share
+	// feldman modifies the commitments broadcast in the Qualify step.
+	feldman func(c *Commitment)
+}
+
+func runProtocol(t *testing.T, g group.Group, threshold, n int, cheats map[uint32]*misbehavior) []*KeyShare {
+	participants := make([]*Participant, n)
+	for i := range participants {
+		p, err := NewParticipant(g, uint32(i+1), threshold, n, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		participants[i] = p
+	}
+
+	var commitments []*Commitment
+	inboxes := make([][]*Share, n)
+	for _, p := range participants {
+		c, shares := p.Deal()
+		if m := cheats[p.id]; m != nil && m.shares != nil {
+			m.shares(shares)
+		}
+		commitments = append(commitments, c)
+		for _, share := range shares {
+			inboxes[share.Recipient-1] = append(inboxes[share.Recipient-1], share)
+		}
+	}
+
+	var complaints []*Complaint
+	for i, p := range participants {
+		c, err := p.ReceiveShares(context.Background(), commitments, inboxes[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		complaints = append(complaints, c...)
+	}
+
+	var justifications []*Share
+	for _, p := range participants {
+		j := p.Justify(complaints)
+		if m := cheats[p.id]; m != nil && m.justifications != nil {
+			j = m.justifications(j)
+		}
+		justifications = append(justifications, j...)
+	}
+
+	var feldman []*Commitment
+	for _, p := range participants {
+		c, err := p.Qualify(context.Background(), complaints, justifications)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c == nil {
+			continue
+		}
+		if m := cheats[p.id]; m != nil && m.feldman != nil {
+			m.feldman(c)
+		}
+		feldman = append(feldman, c)
+	}
+
+	var extractComplaints []*Complaint
+	for _, p := range participants {
+		c, err := p.Extract(context.Background(), feldman)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extractComplaints = append(extractComplaints, c...)
+	}
+	var revealed []*Share
+	for _, p := range participants {
+		revealed = append(revealed, p.Reveal(extractComplaints)...)
+	}
+
+	out := make([]*KeyShare, n)
+	for i, p := range participants {
+		ks, err := p.Finish(context.Background(), extractComplaints, revealed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[i] = ks
+	}
+	return out
+}
+
+func checkKeyShares(t *testing.T, g group.Group, threshold int, keyShares []*KeyShare) {
+	for _, ks := range keyShares {
+		if ks.PublicKey.Equal(keyShares[0].PublicKey) != 1 {
+			t.Errorf("participant %d has a different public key", ks.ID)
+		}
+		if len(ks.Qualified) != len(keyShares[0].Qualified) {
+			t.Errorf("participant %d has a different qualified set", ks.ID)
+		}
+		for _, other := range keyShares {
+			if ks.VerificationShares[other.ID].Equal(g.ScalarBaseMult(other.Secret)) != 1 {
+				t.Errorf("participant %d has the wrong verification share for %d", ks.ID, other.ID)
+			}
+		}
+	}
+	shares := make([]shamir.Share, threshold)
+	for i := range shares {
+		ks := keyShares[len(keyShares)-1-i]
+		shares[i] = shamir.Share{ID: ks.ID, Value: ks.Secret}
+	}
+	secret, err := shamir.Combine(g.Order(), shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ScalarBaseMult(secret).Equal(keyShares[0].PublicKey) != 1 {
+		t.Errorf("shares don't combine to the secret key")
+	}
+}
+
+func TestHonestRun(t *testing.T) {
+	g := group.P256()
+	keyShares := runProtocol(t, g, 3, 5, nil)
+	if len(keyShares[0].Qualified) != 5 {
+		t.Errorf("%d qualified dealers, expected 5", len(keyShares[0].Qualified))
+	}
+	checkKeyShares(t, g, 3, keyShares)
+}
+
+func TestHonestRunRistretto255(t *testing.T) {
+	g := group.Ristretto255()
+	checkKeyShares(t, g, 2, runProtocol(t, g, 2, 2, nil))
+}
+
+func corruptShare(recipient uint32) func([]*Share) {
+	return func(shares []*Share) {
+		for _, s := range shares {
+			if s.Recipient == recipient {
+				s.Value = new(safenum.Nat).SetUint64(1)
+			}
+		}
+	}
+}
+
+func TestJustifiedComplaint(t *testing.T) {
+	g := group.P256()
+	cheats := map[uint32]*misbehavior{2: {shares: corruptShare(4)}}
+	keyShares := runProtocol(t, g, 3, 5, cheats)
+	if len(keyShares[0].Qualified) != 5 {
+		t.Errorf("dealer with a justified complaint was disqualified")
+	}
+	checkKeyShares(t, g, 3, keyShares)
+}
+
+func TestUnjustifiedComplaint(t *testing.T) {
+	g := group.P256()
+	cheats := map[uint32]*misbehavior{3: {
+		shares:         corruptShare(1),
+		justifications: func([]*Share) []*Share { return nil },
+	}}
+	keyShares := runProtocol(t, g, 3, 5, cheats)
+	for _, ks := range keyShares {
+		for _, dealer := range ks.Qualified {
+			if dealer == 3 {
+				t.Errorf("participant %d qualified a dealer which didn't justify a complaint", ks.ID)
+			}
+		}
+	}
+	checkKeyShares(t, g, 3, keyShares)
+}
+
+func TestInvalidFeldmanCommitments(t *testing.T) {
+	g := group.P256()
+	cheats := map[uint32]*misbehavior{5: {feldman: func(c *Commitment) {
+		c.Elements[1] = c.Elements[1].Add(g.Generator())
+	}}}
+	keyShares := runProtocol(t, g, 3, 5, cheats)
+	if len(keyShares[0].Qualified) != 5 {
+		t.Errorf("exposed dealer was removed from the qualified set")
+	}
+	checkKeyShares(t, g, 3, keyShares)
+}
+
+func TestNewParticipantRejects(t *testing.T) {
+	g := group.P256()
+	if _, err := NewParticipant(g, 0, 2, 3, rand.Reader); err == nil {
+		t.Errorf("accepted identifier 0")
+	}
+	if _, err := NewParticipant(g, 4, 2, 3, rand.Reader); err == nil {
+		t.Errorf("accepted an identifier larger than the number of participants")
+	}
+	if _, err := NewParticipant(g, 1, 4, 3, rand.Reader); err == nil {
+		t.Errorf("accepted a threshold larger than the number of participants")
+	}
+}
+
+func TestReceiveSharesCancel(t *testing.T) {
+	g := group.P256()
+	p, err := NewParticipant(g, 1, 2, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, shares := p.Deal()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.ReceiveShares(ctx, []*Commitment{c}, shares[:1]); err != context.Canceled {
+		t.Errorf("got %v, expected %v", err, context.Canceled)
+	}
+}
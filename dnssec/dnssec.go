@@ -0,0 +1,473 @@
+// Package dnssec implements the key and signature encodings of DNSSEC for the
+// algorithms based on elliptic curves: ECDSAP256SHA256 and ECDSAP384SHA384, as
+// specified in RFC 6605, and ED25519, as specified in RFC 8080.
+//
+// DNSKEY and RRSIG records are handled as their RDATA, in wire format. Names
+// are given in presentation format, like "example.com.", without escapes.
+// Building the canonical form of the RDATA of the records being signed is up
+// to the caller, as it depends on their type.
+package dnssec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/internal/der"
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+)
+
+// Algorithm is a DNSSEC algorithm number, from the IANA registry.
+type Algorithm uint8
+
+const (
+	ECDSAP256SHA256 Algorithm = 13
+	ECDSAP384SHA384 Algorithm = 14
+	ED25519         Algorithm = 15
+)
+
+// Flags of a DNSKEY record, from RFC 4034, section 2.1.1.
+const (
+	// ZoneKey is set on every key used to sign a zone.
+	ZoneKey uint16 = 256
+	// SecureEntryPoint marks key signing keys.
+	SecureEntryPoint uint16 = 1
+)
+
+// protocol is the value of the protocol field of every DNSKEY record.
+const protocol = 3
+
+var (
+	errUnsupportedKey   = errors.New("dnssec: unsupported key type")
+	errUnsupportedAlg   = errors.New("dnssec: unsupported algorithm")
+	errInvalidKey       = errors.New("dnssec: invalid public key")
+	errInvalidSignature = errors.New("dnssec: invalid signature")
+	errMalformed        = errors.New("dnssec: malformed RDATA")
+	errInvalidName      = errors.New("dnssec: invalid name")
+)
+
+// ecdsaParams returns the curve, the hash function, and the size of a
+// coordinate or scalar in bytes, for an ECDSA algorithm.
+func ecdsaParams(alg Algorithm) (elliptic.Curve, crypto.Hash, int) {
+	switch alg {
+	case ECDSAP256SHA256:
+		return elliptic.P256(), crypto.SHA256, 32
+	case ECDSAP384SHA384:
+		return elliptic.P384(), crypto.SHA384, 48
+	}
+	return nil, 0, 0
+}
+
+// algorithmForKey returns the algorithm of pub, which is either an
+// *ecdsa.PublicKey over P-256 or P-384, or an ed25519.PublicKey.
+func algorithmForKey(pub crypto.PublicKey) (Algorithm, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		switch pub.Curve.Params().Name {
+		case "P-256":
+			return ECDSAP256SHA256, nil
+		case "P-384":
+			return ECDSAP384SHA384, nil
+		}
+	case ed25519.PublicKey:
+		if len(pub) == ed25519.PublicKeySize {
+			return ED25519, nil
+		}
+	}
+	return 0, errUnsupportedKey
+}
+
+func digest(h crypto.Hash, data []byte) []byte {
+	if h == crypto.SHA256 {
+		d := sha256.Sum256(data)
+		return d[:]
+	}
+	d := sha512.Sum384(data)
+	return d[:]
+}
+
+// DNSKEY is the RDATA of a DNSKEY record, as described in RFC 4034, section 2.
+type DNSKEY struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm Algorithm
+	// PublicKey is the encoded public key: the coordinates X || Y for ECDSA,
+	// or the 32 byte public key for Ed25519.
+	PublicKey []byte
+}
+
+// NewDNSKEY returns the DNSKEY RDATA of pub, with the given flags, which
+// should include ZoneKey.
+func NewDNSKEY(pub crypto.PublicKey, flags uint16) (*DNSKEY, error) {
+	alg, err := algorithmForKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	k := &DNSKEY{Flags: flags, Protocol: protocol, Algorithm: alg}
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		_, _, size := ecdsaParams(alg)
+		k.PublicKey = make([]byte, 2*size)
+		pub.X.FillBytes(k.PublicKey[:size])
+		pub.Y.FillBytes(k.PublicKey[size:])
+	case ed25519.PublicKey:
+		k.PublicKey = append([]byte{}, pub...)
+	}
+	return k, nil
+}
+
+// ParseDNSKEY decodes the RDATA of a DNSKEY record. The public key is only
+// checked by CryptoPublicKey, so that keys of other algorithms can be parsed.
+func ParseDNSKEY(rdata []byte) (*DNSKEY, error) {
+	if len(rdata) < 4 {
+		return nil, errMalformed
+	}
+	return &DNSKEY{
+		Flags:     binary.BigEndian.Uint16(rdata),
+		Protocol:  rdata[2],
+		Algorithm: Algorithm(rdata[3]),
+		PublicKey: append([]byte{}, rdata[4:]...),
+	}, nil
+}
+
+// Marshal returns the wire format of k.
+func (k *DNSKEY) Marshal() []byte {
+	b := make([]byte, 4, 4+len(k.PublicKey))
+	binary.BigEndian.PutUint16(b, k.Flags)
+	b[2] = k.Protocol
+	b[3] = byte(k.Algorithm)
+	return append(b, k.PublicKey...)
+}
+
+// KeyTag returns the key tag of k, computed as in RFC 4034, appendix B.
+func (k *DNSKEY) KeyTag() uint16 {
+	var ac uint32
+	for i, b := range k.Marshal() {
+		if i&1 == 1 {
+			ac += uint32(b)
+		} else {
+			ac += uint32(b) << 8
+		}
+	}
+	ac += ac >> 16 & 0xffff
+	return uint16(ac)
+}
+
+// CryptoPublicKey decodes the public key of k, which is an *ecdsa.PublicKey or
+// an ed25519.PublicKey.
+func (k *DNSKEY) CryptoPublicKey() (crypto.PublicKey, error) {
+	switch k.Algorithm {
+	case ECDSAP256SHA256, ECDSAP384SHA384:
+		curve, _, size := ecdsaParams(k.Algorithm)
+		if len(k.PublicKey) != 2*size {
+			return nil, errInvalidKey
+		}
+		x := new(big.Int).SetBytes(k.PublicKey[:size])
+		y := new(big.Int).SetBytes(k.PublicKey[size:])
+		if !curve.IsOnCurve(x, y) {
+			return nil, errInvalidKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case ED25519:
+		if len(k.PublicKey) != ed25519.PublicKeySize {
+			return nil, errInvalidKey
+		}
+		return ed25519.PublicKey(append([]byte{}, k.PublicKey...)), nil
+	}
+	return nil, errUnsupportedAlg
+}
+
+// RRSIG is the RDATA of an RRSIG record, as described in RFC 4034, section 3.
+type RRSIG struct {
+	TypeCovered uint16
+	Algorithm   Algorithm
+	// Labels is the number of labels of the owner name of the records, not
+	// counting the root label, and a leading wildcard.
+	Labels      uint8
+	OriginalTTL uint32
+	// Expiration and Inception are in seconds since the epoch, modulo 2^32.
+	Expiration uint32
+	Inception  uint32
+	KeyTag     uint16
+	SignerName string
+	// Signature is R || S for ECDSA, and the 64 byte signature for Ed25519.
+	Signature []byte
+}
+
+// ParseRRSIG decodes the RDATA of an RRSIG record.
+func ParseRRSIG(rdata []byte) (*RRSIG, error) {
+	if len(rdata) < 18 {
+		return nil, errMalformed
+	}
+	s := &RRSIG{
+		TypeCovered: binary.BigEndian.Uint16(rdata),
+		Algorithm:   Algorithm(rdata[2]),
+		Labels:      rdata[3],
+		OriginalTTL: binary.BigEndian.Uint32(rdata[4:]),
+		Expiration:  binary.BigEndian.Uint32(rdata[8:]),
+		Inception:   binary.BigEndian.Uint32(rdata[12:]),
+		KeyTag:      binary.BigEndian.Uint16(rdata[16:]),
+	}
+	name, rest, err := unpackName(rdata[18:])
+	if err != nil {
+		return nil, err
+	}
+	s.SignerName = name
+	s.Signature = append([]byte{}, rest...)
+	return s, nil
+}
+
+// marshalUnsigned returns the wire format of s without its signature, which
+// is the prefix of the signed data.
+func (s *RRSIG) marshalUnsigned() ([]byte, error) {
+	name, err := packName(s.SignerName)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 18, 18+len(name)+len(s.Signature))
+	binary.BigEndian.PutUint16(b, s.TypeCovered)
+	b[2] = byte(s.Algorithm)
+	b[3] = s.Labels
+	binary.BigEndian.PutUint32(b[4:], s.OriginalTTL)
+	binary.BigEndian.PutUint32(b[8:], s.Expiration)
+	binary.BigEndian.PutUint32(b[12:], s.Inception)
+	binary.BigEndian.PutUint16(b[16:], s.KeyTag)
+	return append(b, name...), nil
+}
+
+// Marshal returns the wire format of s.
+func (s *RRSIG) Marshal() ([]byte, error) {
+	b, err := s.marshalUnsigned()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, s.Signature...), nil
+}
+
+// ValidAt reports whether t is between the inception and the expiration of s,
+// using the serial number arithmetic of RFC 1982, as required by RFC 4034.
+func (s *RRSIG) ValidAt(t time.Time) bool {
+	now := uint32(t.Unix())
+	return int32(now-s.Inception) >= 0 && int32(s.Expiration-now) >= 0
+}
+
+// Sign signs rrset, the canonical form of an RRset as returned by
+// CanonicalRRset, with key, and sets the Algorithm, KeyTag and Signature of s.
+// The other fields must already be set.
+//
+// The key is either an *ecdsa.PrivateKey, an ed25519.PrivateKey, or any
+// crypto.Signer whose public key is an *ecdsa.PublicKey, like a key held by an
+// HSM. dnskey must be the DNSKEY of the key.
+func (s *RRSIG) Sign(rand io.Reader, key crypto.Signer, dnskey *DNSKEY, rrset []byte) error {
+	own, err := NewDNSKEY(key.Public(), dnskey.Flags)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(own.Marshal(), dnskey.Marshal()) {
+		return errors.New("dnssec: DNSKEY doesn't match the key")
+	}
+	s.Algorithm = dnskey.Algorithm
+	s.KeyTag = dnskey.KeyTag()
+	s.Signature = nil
+	data, err := s.marshalUnsigned()
+	if err != nil {
+		return err
+	}
+	data = append(data, rrset...)
+
+	if priv, ok := key.(ed25519.PrivateKey); ok {
+		s.Signature, err = edsig.Sign(priv, data)
+		return err
+	}
+	_, h, size := ecdsaParams(s.Algorithm)
+	hash := digest(h, data)
+	var r, ss *big.Int
+	if priv, ok := key.(*ecdsa.PrivateKey); ok {
+		if r, ss, err = ecdsa.Sign(rand, priv, hash); err != nil {
+			return err
+		}
+	} else {
+		sig, err := ecdsa.SignWithSigner(rand, key, hash, h)
+		if err != nil {
+			return err
+		}
+		if r, ss, err = parseASN1(sig); err != nil {
+			return err
+		}
+	}
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	ss.FillBytes(sig[size:])
+	s.Signature = sig
+	return nil
+}
+
+// parseASN1 decodes an ASN.1 ECDSA signature, as returned by a crypto.Signer.
+func parseASN1(sig []byte) (r, s *big.Int, err error) {
+	var (
+		rBytes, sBytes []byte
+		inner          der.Input
+	)
+	input := der.Input(sig)
+	if !input.ReadElement(&inner, der.Sequence) ||
+		!input.Empty() ||
+		!inner.ReadIntegerBytes(&rBytes) ||
+		!inner.ReadIntegerBytes(&sBytes) ||
+		!inner.Empty() {
+		return nil, nil, errInvalidSignature
+	}
+	return new(big.Int).SetBytes(rBytes), new(big.Int).SetBytes(sBytes), nil
+}
+
+// Verify checks that s is a valid signature of rrset, the canonical form of
+// an RRset as returned by CanonicalRRset, by dnskey.
+//
+// The algorithm and key tag of s must match dnskey, which must be a zone key.
+// The validity period isn't checked, see ValidAt.
+func (s *RRSIG) Verify(dnskey *DNSKEY, rrset []byte) error {
+	if dnskey.Protocol != protocol || dnskey.Flags&ZoneKey == 0 {
+		return errInvalidKey
+	}
+	if s.Algorithm != dnskey.Algorithm || s.KeyTag != dnskey.KeyTag() {
+		return errors.New("dnssec: RRSIG doesn't match the DNSKEY")
+	}
+	pub, err := dnskey.CryptoPublicKey()
+	if err != nil {
+		return err
+	}
+	data, err := s.marshalUnsigned()
+	if err != nil {
+		return err
+	}
+	data = append(data, rrset...)
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		_, h, size := ecdsaParams(s.Algorithm)
+		if len(s.Signature) != 2*size {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(s.Signature[:size])
+		ss := new(big.Int).SetBytes(s.Signature[size:])
+		if !ecdsa.Verify(pub, digest(h, data), r, ss) {
+			return errInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !edsig.Verify(pub, data, s.Signature) {
+			return errInvalidSignature
+		}
+	}
+	return nil
+}
+
+// CanonicalRRset returns the canonical form of the RRset with the given owner
+// name, type, class and original TTL, as described in RFC 4034, section 6.
+//
+// The records are sorted, and duplicates removed. Names within the RDATA of
+// the records must already be in canonical form, if their type requires it.
+func CanonicalRRset(name string, rrtype, class uint16, ttl uint32, rdatas [][]byte) ([]byte, error) {
+	owner, err := packName(name)
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([][]byte, len(rdatas))
+	copy(sorted, rdatas)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	var b []byte
+	for i, rdata := range sorted {
+		if i > 0 && bytes.Equal(rdata, sorted[i-1]) {
+			continue
+		}
+		if len(rdata) > 0xffff {
+			return nil, errMalformed
+		}
+		b = append(b, owner...)
+		var fixed [10]byte
+		binary.BigEndian.PutUint16(fixed[0:], rrtype)
+		binary.BigEndian.PutUint16(fixed[2:], class)
+		binary.BigEndian.PutUint32(fixed[4:], ttl)
+		binary.BigEndian.PutUint16(fixed[8:], uint16(len(rdata)))
+		b = append(b, fixed[:]...)
+		b = append(b, rdata...)
+	}
+	return b, nil
+}
+
+// Labels returns the value of the Labels field of an RRSIG covering records
+// owned by name.
+func Labels(name string) (uint8, error) {
+	if _, err := packName(name); err != nil {
+		return 0, err
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return 0, nil
+	}
+	labels := strings.Split(name, ".")
+	if labels[0] == "*" {
+		return uint8(len(labels) - 1), nil
+	}
+	return uint8(len(labels)), nil
+}
+
+// packName returns the canonical wire format of name, with ASCII letters in
+// lower case, as described in RFC 4034, section 6.2.
+func packName(name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	var b []byte
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 || strings.ContainsRune(label, '\\') {
+				return nil, errInvalidName
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, strings.ToLower(label)...)
+		}
+	}
+	b = append(b, 0)
+	if len(b) > 255 {
+		return nil, errInvalidName
+	}
+	return b, nil
+}
+
+// unpackName decodes an uncompressed name in wire format from the start of b,
+// and returns it in presentation format along with the rest of b.
+func unpackName(b []byte) (string, []byte, error) {
+	var labels []string
+	read := 0
+	for {
+		if len(b) == 0 {
+			return "", nil, errMalformed
+		}
+		n := int(b[0])
+		read += 1 + n
+		if n > 63 || len(b) < 1+n || read > 255 {
+			return "", nil, errMalformed
+		}
+		if n == 0 {
+			b = b[1:]
+			break
+		}
+		label := string(b[1 : 1+n])
+		if strings.ContainsAny(label, ".\\") {
+			return "", nil, errInvalidName
+		}
+		labels = append(labels, label)
+		b = b[1+n:]
+	}
+	return strings.Join(labels, ".") + ".", b, nil
+}
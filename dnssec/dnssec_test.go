@@ -0,0 +1,180 @@
+package dnssec
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+func mxRdata(t *testing.T, preference byte, exchange string) []byte {
+	name, err := packName(exchange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append([]byte{0, preference}, name...)
+}
+
+// TestRFC8080 uses the first example of RFC 8080, section 6.1.
+func TestRFC8080(t *testing.T) {
+	seed, _ := base64.StdEncoding.DecodeString("ODIyNjAzODQ2MjgwODAxMjI2NDUxOTAyMDQxNDIyNjI=")
+	priv := ed25519.NewKeyFromSeed(seed)
+	dnskey, err := NewDNSKEY(priv.Public(), ZoneKey|SecureEntryPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := base64.StdEncoding.EncodeToString(dnskey.PublicKey); got != "l02Woi0iS8Aa25FQkUd9RMzZHJpBoRQwAQEX1SxZJA4=" {
+		t.Errorf("public key %s", got)
+	}
+	if tag := dnskey.KeyTag(); tag != 3613 {
+		t.Errorf("key tag %d, expected 3613", tag)
+	}
+
+	rrset, err := CanonicalRRset("example.com.", 15, 1, 3600, [][]byte{mxRdata(t, 10, "mail.example.com.")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := Labels("example.com.")
+	if err != nil || labels != 2 {
+		t.Fatalf("got %d labels, %v", labels, err)
+	}
+	sig := &RRSIG{
+		TypeCovered: 15,
+		Labels:      labels,
+		OriginalTTL: 3600,
+		Expiration:  1440021600,
+		Inception:   1438207200,
+		SignerName:  "example.com.",
+	}
+	if err := sig.Sign(nil, priv, dnskey, rrset); err != nil {
+		t.Fatal(err)
+	}
+	want := "oL9krJun7xfBOIWcGHi7mag5/hdZrKWw15jPGrHpjQeRAvTdszaPD+QLs3fx8A4M3e23mRZ9VrbpMngwcrqNAg=="
+	if got := base64.StdEncoding.EncodeToString(sig.Signature); got != want {
+		t.Errorf("signature %s, expected %s", got, want)
+	}
+	if err := sig.Verify(dnskey, rrset); err != nil {
+		t.Error(err)
+	}
+}
+
+// remoteSigner hides the type of an ECDSA key, like a key held by an HSM.
+type remoteSigner struct{ priv *ecdsa.PrivateKey }
+
+func (s remoteSigner) Public() crypto.PublicKey { return s.priv.Public() }
+
+func (s remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.priv.Sign(rand, digest, opts)
+}
+
+func TestSignVerify(t *testing.T) {
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ed, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rrset, err := CanonicalRRset("WWW.Example.COM", 15, 1, 300, [][]byte{
+		mxRdata(t, 20, "b.example.com."),
+		mxRdata(t, 10, "a.example.com."),
+		mxRdata(t, 20, "b.example.com."),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		key  crypto.Signer
+		alg  Algorithm
+		size int
+	}{
+		{p256, ECDSAP256SHA256, 64},
+		{remoteSigner{p256}, ECDSAP256SHA256, 64},
+		{p384, ECDSAP384SHA384, 96},
+		{ed, ED25519, 64},
+	} {
+		dnskey, err := NewDNSKEY(test.key.Public(), ZoneKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := ParseDNSKEY(dnskey.Marshal())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.Algorithm != test.alg || parsed.KeyTag() != dnskey.KeyTag() {
+			t.Errorf("%d: DNSKEY didn't round trip", test.alg)
+		}
+		pub, err := parsed.CryptoPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := NewDNSKEY(pub, ZoneKey); !bytes.Equal(again.Marshal(), dnskey.Marshal()) {
+			t.Errorf("%d: public key didn't round trip", test.alg)
+		}
+
+		now := time.Now()
+		sig := &RRSIG{
+			TypeCovered: 15,
+			Labels:      3,
+			OriginalTTL: 300,
+			Expiration:  uint32(now.Add(time.Hour).Unix()),
+			Inception:   uint32(now.Add(-time.Hour).Unix()),
+			SignerName:  "example.com.",
+		}
+		if err := sig.Sign(rand.Reader, test.key, dnskey, rrset); err != nil {
+			t.Fatal(err)
+		}
+		if len(sig.Signature) != test.size {
+			t.Errorf("%d: signature is %d bytes, expected %d", test.alg, len(sig.Signature), test.size)
+		}
+		if !sig.ValidAt(now) || sig.ValidAt(now.Add(2*time.Hour)) {
+			t.Errorf("%d: wrong validity period", test.alg)
+		}
+		rdata, err := sig.Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := ParseRRSIG(rdata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.SignerName != "example.com." || decoded.KeyTag != dnskey.KeyTag() {
+			t.Errorf("%d: RRSIG didn't round trip: %+v", test.alg, decoded)
+		}
+		if err := decoded.Verify(parsed, rrset); err != nil {
+			t.Errorf("%d: %v", test.alg, err)
+		}
+		decoded.OriginalTTL++
+		if err := decoded.Verify(parsed, rrset); err == nil {
+			t.Errorf("%d: modified RRSIG accepted", test.alg)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for name, want := range map[string]uint8{".": 0, "com.": 1, "*.example.com": 2, "a.b.example.com.": 4} {
+		if got, err := Labels(name); got != want || err != nil {
+			t.Errorf("%s: got %d, %v, expected %d", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"a..com", `a\.b.com`, string(make([]byte, 64)) + ".com"} {
+		if _, err := packName(name); err == nil {
+			t.Errorf("%q accepted", name)
+		}
+	}
+	if _, err := ParseRRSIG(make([]byte, 17)); err == nil {
+		t.Error("short RRSIG accepted")
+	}
+}
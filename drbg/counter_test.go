@@ -0,0 +1,85 @@
+package drbg_test
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/drbg"
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+func TestAsRandomSource(t *testing.T) {
+	var streams [2][]byte
+	for i := range streams {
+		d, _ := drbg.NewHMAC(sha256.New, []byte("a fixed seed, for tests"), nil, nil)
+		streams[i] = make([]byte, 64)
+		d.Read(streams[i])
+	}
+	if !bytes.Equal(streams[0], streams[1]) {
+		t.Error("the same seed gave different outputs")
+	}
+	d, _ := drbg.NewHMAC(sha256.New, []byte("a fixed seed, for tests"), nil, nil)
+	if _, err := ecdh.P256().GenerateKey(d); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTestReaderStream(t *testing.T) {
+	// Reads of any size give the same stream.
+	a := make([]byte, 100)
+	drbg.NewTestReader([]byte("seed")).Read(a)
+	r := drbg.NewTestReader([]byte("seed"))
+	b := make([]byte, 0, 100)
+	for _, n := range []int{1, 31, 33, 0, 35} {
+		chunk := make([]byte, n)
+		if m, err := r.Read(chunk); m != n || err != nil {
+			t.Fatalf("Read returned %d, %v", m, err)
+		}
+		b = append(b, chunk...)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("chunked reads differ")
+	}
+
+	key := sha256.Sum256([]byte("seed"))
+	block := append(key[:], 0, 0, 0, 0, 0, 0, 0, 1)
+	want := sha256.Sum256(block)
+	if !bytes.Equal(a[32:64], want[:]) {
+		t.Errorf("second block is %x, expected %x", a[32:64], want)
+	}
+
+	c := make([]byte, 100)
+	drbg.NewTestReader([]byte("other seed")).Read(c)
+	if bytes.Equal(a, c) {
+		t.Error("different seeds gave the same stream")
+	}
+}
+
+func TestTestReaderKeys(t *testing.T) {
+	a, err := ecdh.X25519().GenerateKey(drbg.NewTestReader([]byte(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ecdh.X25519().GenerateKey(drbg.NewTestReader([]byte(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Equal(b) {
+		t.Error("ECDH keys from the same seed differ")
+	}
+
+	p, err := ecdsa.GenerateKey(elliptic.P256(), drbg.NewTestReader([]byte(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := ecdsa.GenerateKey(elliptic.P256(), drbg.NewTestReader([]byte(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.D.Cmp(q.D) != 0 {
+		t.Error("ECDSA keys from the same seed differ")
+	}
+}
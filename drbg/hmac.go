@@ -0,0 +1,152 @@
+// Package drbg implements HMAC_DRBG, the deterministic random bit generator
+// of NIST SP 800-90A Revision 1, section 10.1.2.
+//
+// An HMACDRBG is an io.Reader, so it can be passed as the source of
+// randomness of key generation or signing functions. Seeded with fixed
+// inputs, it produces a reproducible stream, which is useful for tests,
+// although, as in the standard library, most functions of this module read an
+// extra byte from their source at random, so that callers can't come to rely
+// on their outputs being deterministic. TestReader, which they don't do this
+// for, is meant for reproducible tests instead. HMAC_DRBG is also the
+// generator used by RFC 6979 to derive ECDSA and DSA nonces, from the private
+// key and the hash of the message.
+package drbg
+
+import (
+	"errors"
+	"hash"
+
+	"github.com/cronokirby/ctcrypto/hmac"
+)
+
+const (
+	// maxRequest is the largest number of bytes returned by a single call to
+	// Generate, which is 2^19 bits.
+	maxRequest = 1 << 16
+	// reseedInterval is the number of calls to Generate allowed between
+	// reseeds.
+	reseedInterval = 1 << 48
+)
+
+var (
+	// ErrReseedRequired is returned by Generate once the DRBG has produced
+	// the maximum number of outputs allowed without being reseeded.
+	ErrReseedRequired  = errors.New("drbg: reseed required")
+	errRequestTooLarge = errors.New("drbg: requested too many bytes at once")
+	errNoEntropy       = errors.New("drbg: missing entropy input")
+)
+
+// HMACDRBG is an instance of HMAC_DRBG, with a given hash function.
+type HMACDRBG struct {
+	h       func() hash.Hash
+	k, v    []byte
+	counter uint64
+}
+
+// NewHMAC instantiates HMAC_DRBG with the given hash function, following
+// section 10.1.2.3.
+//
+// The entropy input must hold at least as many bytes of entropy as the
+// security strength of the hash function, in bytes, and the nonce half as
+// many, as detailed in section 8.6.7. This isn't checked, as RFC 6979 passes a
+// private key and a message hash here instead. The personalization string is
+// optional.
+func NewHMAC(h func() hash.Hash, entropy, nonce, personalization []byte) (*HMACDRBG, error) {
+	if len(entropy) == 0 {
+		return nil, errNoEntropy
+	}
+	size := h().Size()
+	d := &HMACDRBG{
+		h: h,
+		k: make([]byte, size),
+		v: make([]byte, size),
+	}
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, nonce, personalization)
+	d.counter = 1
+	return d, nil
+}
+
+// update is HMAC_DRBG_Update, from section 10.1.2.2, with the provided data
+// given as the concatenation of its arguments.
+func (d *HMACDRBG) update(provided ...[]byte) {
+	empty := true
+	for _, p := range provided {
+		if len(p) > 0 {
+			empty = false
+		}
+	}
+	for _, b := range []byte{0x00, 0x01} {
+		// K = HMAC(K, V || b || provided)
+		mac := hmac.New(d.h, d.k)
+		mac.Write(d.v)
+		mac.Write([]byte{b})
+		for _, p := range provided {
+			mac.Write(p)
+		}
+		d.k = mac.Sum(d.k[:0])
+		// V = HMAC(K, V)
+		mac = hmac.New(d.h, d.k)
+		mac.Write(d.v)
+		d.v = mac.Sum(d.v[:0])
+		if empty {
+			break
+		}
+	}
+}
+
+// Reseed mixes fresh entropy, and optional additional input, into the state,
+// following section 10.1.2.4.
+func (d *HMACDRBG) Reseed(entropy, additional []byte) error {
+	if len(entropy) == 0 {
+		return errNoEntropy
+	}
+	d.update(entropy, additional)
+	d.counter = 1
+	return nil
+}
+
+// Generate fills out with pseudorandom bytes, following section 10.1.2.5,
+// after mixing in the optional additional input.
+//
+// At most 65536 bytes can be requested at once.
+func (d *HMACDRBG) Generate(out, additional []byte) error {
+	if len(out) > maxRequest {
+		return errRequestTooLarge
+	}
+	if d.counter > reseedInterval {
+		return ErrReseedRequired
+	}
+	if len(additional) > 0 {
+		d.update(additional)
+	}
+	mac := hmac.New(d.h, d.k)
+	for n := 0; n < len(out); {
+		mac.Reset()
+		mac.Write(d.v)
+		d.v = mac.Sum(d.v[:0])
+		n += copy(out[n:], d.v)
+	}
+	d.update(additional)
+	d.counter++
+	return nil
+}
+
+// Read fills p with pseudorandom bytes, calling Generate as many times as
+// needed, without additional input.
+func (d *HMACDRBG) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		chunk := p[n:]
+		if len(chunk) > maxRequest {
+			chunk = chunk[:maxRequest]
+		}
+		if err := d.Generate(chunk, nil); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+	}
+	return n, nil
+}
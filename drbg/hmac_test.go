@@ -0,0 +1,92 @@
+package drbg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestRFC6979Nonce(t *testing.T) {
+	// See RFC 6979, appendix A.2.5, with SHA-256 and the message "sample".
+	// The curve order is exactly 256 bits long, so the first output of the
+	// DRBG is the nonce, being smaller than the order.
+	x := mustHex("c9afa9d845ba75166b5c215767b1d6934e50c3db36e89b127b8a622b120f6721")
+	q, _ := new(big.Int).SetString("ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551", 16)
+	h := sha256.Sum256([]byte("sample"))
+	h1 := new(big.Int).SetBytes(h[:])
+	h1.Mod(h1, q)
+	nonce := make([]byte, 32)
+	h1.FillBytes(nonce)
+
+	d, err := NewHMAC(sha256.New, x, nonce, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := make([]byte, 32)
+	if err := d.Generate(k, nil); err != nil {
+		t.Fatal(err)
+	}
+	expected := mustHex("a6e3c57dd01abe90086538398355dd4c3b17aa873382b0f24d6129493d8aad60")
+	if !bytes.Equal(k, expected) {
+		t.Errorf("k = %x, expected %x", k, expected)
+	}
+}
+
+func TestReseedAndAdditionalInput(t *testing.T) {
+	// Generated with an independent implementation in Python.
+	d, err := NewHMAC(sha512.New, []byte("\x00\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10\x11\x12\x13\x14\x15\x16\x17\x18\x19\x1a\x1b\x1c\x1d\x1e\x1f\x20\x21\x22\x23\x24\x25\x26\x27\x28\x29\x2a\x2b\x2c\x2d\x2e\x2f"), []byte("defghijklmnopqrs"), []byte("personal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := make([]byte, 100)
+	if _, err := d.Read(a); err != nil {
+		t.Fatal(err)
+	}
+	expected := mustHex("7b8ed4d2a596e66a81682b3ab628ed6aecd1367e375b895605b385c7a929eeaccb1268331406616e4d538cdc5d4b919547fe1728d619b787eab28c54638fcc58bdedc5d4e88970fb2165a0b72e55a97ff1f318e821bb1e089843c7fa9808e3dbb9a91dff")
+	if !bytes.Equal(a, expected) {
+		t.Errorf("first output = %x, expected %x", a, expected)
+	}
+	if err := d.Reseed([]byte("fresh entropy"), []byte("extra")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 70)
+	if err := d.Generate(b, []byte("additional")); err != nil {
+		t.Fatal(err)
+	}
+	expected = mustHex("ef647e627dc28123f13b96d87e7589cdbba0772ef602a6dab4fdde91a5a28359627599ce4b9f44e0e3f0e3f0598d11b985b30aea720668281eb1f4bb6d270ffac0bd89befb9f")
+	if !bytes.Equal(b, expected) {
+		t.Errorf("second output = %x, expected %x", b, expected)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	if _, err := NewHMAC(sha256.New, nil, nil, nil); err == nil {
+		t.Error("NewHMAC accepted an empty entropy input")
+	}
+	d, _ := NewHMAC(sha256.New, []byte("entropy"), nil, nil)
+	if err := d.Generate(make([]byte, maxRequest+1), nil); err == nil {
+		t.Error("Generate accepted a request which is too large")
+	}
+	if _, err := d.Read(make([]byte, 3*maxRequest)); err != nil {
+		t.Errorf("Read of a large buffer failed: %v", err)
+	}
+	d.counter = reseedInterval + 1
+	if err := d.Generate(make([]byte, 1), nil); err != ErrReseedRequired {
+		t.Errorf("Generate returned %v, expected ErrReseedRequired", err)
+	}
+	d.Reseed([]byte("more entropy"), nil)
+	if err := d.Generate(make([]byte, 1), nil); err != nil {
+		t.Errorf("Generate failed after Reseed: %v", err)
+	}
+}
@@ -4,7 +4,9 @@
 
 // Package dsa implements the Digital Signature Algorithm, as defined in FIPS 186-3.
 //
-// The DSA operations in this package are not implemented using constant-time algorithms.
+// Key generation and signing use constant-time arithmetic, through safenum,
+// for the private key and the nonce. Parameter generation and verification,
+// which only handle public values, don't.
 //
 // Deprecated: DSA is a legacy algorithm, and modern alternatives such as
 // Ed25519 (implemented by package crypto/ed25519) should be used instead. Keys
@@ -14,10 +16,12 @@
 package dsa
 
 import (
+	"crypto"
 	"errors"
 	"io"
 	"math/big"
 
+	"github.com/cronokirby/ctcrypto/drbg"
 	"github.com/cronokirby/ctcrypto/internal/randutil"
 	"github.com/cronokirby/safenum"
 )
@@ -46,6 +50,8 @@ type PrivateKey struct {
 // this error must be handled.
 var ErrInvalidPublicKey = errors.New("crypto/dsa: invalid public key")
 
+var errFault = errors.New("crypto/dsa: signature failed to verify, signing was faulty")
+
 // ParameterSizes is an enumeration of the acceptable bit lengths of the primes
 // in a set of DSA parameters. See FIPS 186-3, section 4.2.
 type ParameterSizes int
@@ -207,29 +213,105 @@ func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err err
 
 	// FIPS 186-3, section 4.6
 
+	n, err := checkPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	buf := make([]byte, n)
+	nonce := func() error {
+		_, err := io.ReadFull(rand, buf)
+		return err
+	}
+	return sign(priv, buf, nonce, hash)
+}
+
+// SignDeterministic signs hash, the result of hashing a larger message with h,
+// like Sign, but derives the nonce from the private key and the hash, as
+// specified in RFC 6979, section 3.2, instead of reading it from a source of
+// randomness.
+//
+// Unlike with Sign, the hash is truncated to the byte-length of the subgroup,
+// as required by both FIPS 186-3 and RFC 6979.
+//
+// The signature is verified before being returned: since the nonce of a hash
+// is always the same, a fault during signing would give an invalid signature
+// alongside a valid one with the same nonce, from which X can be recovered.
+func SignDeterministic(priv *PrivateKey, h crypto.Hash, hash []byte) (r, s *big.Int, err error) {
+	if !h.Available() {
+		return nil, nil, errors.New("crypto/dsa: hash function not available")
+	}
+	n, err := checkPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hash) > n {
+		hash = hash[:n]
+	}
+	qMod := safenum.ModulusFromBytes(priv.Q.Bytes())
+	// int2octets(x) and bits2octets(h1), where bits2int is the identity on
+	// the truncated hash, since the bit length of Q is a multiple of 8.
+	x := natBytes(new(safenum.Nat).Mod(priv.X, qMod), n)
+	z := new(safenum.Nat).SetBytes(hash[:len(hash):len(hash)])
+	z.Mod(z, qMod)
+	// HMAC_DRBG, instantiated with these inputs, produces the candidate
+	// nonces of section 3.2, steps d. to h.
+	d, err := drbg.NewHMAC(h.New, x, natBytes(z, n), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	buf := make([]byte, n)
+	nonce := func() error {
+		return d.Generate(buf, nil)
+	}
+	r, s, err = sign(priv, buf, nonce, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !Verify(&priv.PublicKey, hash, r, s) {
+		return nil, nil, errFault
+	}
+	return r, s, nil
+}
+
+// natBytes returns the n byte big-endian encoding of x, which must be lower
+// than 2^(8n). Bytes pads its output to a whole number of limbs.
+func natBytes(x *safenum.Nat, n int) []byte {
+	b := x.Bytes()
+	if len(b) >= n {
+		return b[len(b)-n:]
+	}
+	return append(make([]byte, n-len(b)), b...)
+}
+
+// checkPrivateKey rejects degenerate private keys, and returns the byte-length
+// of the subgroup.
+func checkPrivateKey(priv *PrivateKey) (int, error) {
 	n := priv.Q.BitLen()
 	if priv.Q.Sign() <= 0 || priv.P.Sign() <= 0 || priv.G.Sign() <= 0 || priv.X.EqZero() || n%8 != 0 {
-		err = ErrInvalidPublicKey
-		return
+		return 0, ErrInvalidPublicKey
 	}
-	n >>= 3
+	return n >> 3, nil
+}
 
+// sign computes a signature of hash, calling nonce to fill buf with a
+// candidate nonce as many times as needed.
+func sign(priv *PrivateKey, buf []byte, nonce func() error, hash []byte) (r, s *big.Int, err error) {
 	qMod := safenum.ModulusFromBytes(priv.Q.Bytes())
 	pMod := safenum.ModulusFromBytes(priv.P.Bytes())
 	gNat := new(safenum.Nat)
 	gNat.SetBytes(priv.G.Bytes())
+	z := new(safenum.Nat).SetBytes(hash[:len(hash):len(hash)])
+	z.Mod(z, qMod)
 
 	var attempts int
 	for attempts = 10; attempts > 0; attempts-- {
 		k := new(safenum.Nat)
-		buf := make([]byte, n)
 		for {
-			_, err = io.ReadFull(rand, buf)
-			if err != nil {
-				return
+			if err = nonce(); err != nil {
+				return nil, nil, err
 			}
 			k.SetBytes(buf)
-			// priv.Q must be >= 128 because the test above
+			// priv.Q must be >= 128 because the test in checkPrivateKey
 			// requires it to be > 0 and that
 			//    ceil(log_2(Q)) mod 8 = 0
 			// Thus this loop will quickly terminate.
@@ -249,8 +331,6 @@ func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err err
 		}
 		r = new(big.Int).SetBytes(rNat.Bytes())
 
-		z := k.SetBytes(hash)
-
 		sNat := new(safenum.Nat).ModMul(priv.X, rNat, qMod)
 		sNat.ModAdd(sNat, z, qMod)
 		sNat.ModMul(sNat, kInv, qMod)
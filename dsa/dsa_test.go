@@ -5,7 +5,10 @@
 package dsa
 
 import (
+	"crypto"
 	"crypto/rand"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
 	"math/big"
 	"testing"
 
@@ -177,3 +180,78 @@ func BenchmarkParameterGeneration(b *testing.B) {
 		GenerateParameters(params, rand.Reader, L2048N256)
 	}
 }
+
+// TestSignDeterministic checks the nonces of the 1024 bit key of RFC 6979,
+// appendix A.2.1. The nonces only depend on Q and X, so the key is completed
+// with a P and G generated from Q, and the nonces are recovered from the
+// signatures.
+func TestSignDeterministic(t *testing.T) {
+	q := fromHex("996F967F6C8E388D9E28D01E205FBA957A5698B1")
+	x := fromHex("411602CB19A6CCC34494D79D98EF1E7ED5AF25F7")
+
+	// p = 2cq + 1, for the first c >= 2^862 which makes it prime.
+	one := big.NewInt(1)
+	c := new(big.Int).Lsh(one, 862)
+	p := new(big.Int)
+	for {
+		p.Mul(c, q)
+		p.Lsh(p, 1)
+		p.Add(p, one)
+		if p.ProbablyPrime(20) {
+			break
+		}
+		c.Add(c, one)
+	}
+	g := new(big.Int).Exp(big.NewInt(2), new(big.Int).Lsh(c, 1), p)
+	priv := &PrivateKey{
+		PublicKey: PublicKey{
+			Parameters: Parameters{P: p, Q: q, G: g},
+			Y:          new(big.Int).Exp(g, x, p),
+		},
+		X: new(safenum.Nat).SetBytes(x.Bytes()),
+	}
+
+	for _, test := range []struct {
+		h       crypto.Hash
+		message string
+		k       string
+	}{
+		{crypto.SHA1, "sample", "7BDB6B0FF756E1BB5D53583EF979082F9AD5BD5B"},
+		{crypto.SHA256, "sample", "519BA0546D0C39202A7D34D7DFA5E760B318BCFB"},
+		{crypto.SHA1, "test", "5C842DF4F9E344EE09F056838B42C7A17F4A6433"},
+		{crypto.SHA256, "test", "5A67592E8128E03A417B0484410FB72C0B630E1A"},
+	} {
+		hh := test.h.New()
+		hh.Write([]byte(test.message))
+		hashed := hh.Sum(nil)
+		r, s, err := SignDeterministic(priv, test.h, hashed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		truncated := hashed[:q.BitLen()/8]
+		if !Verify(&priv.PublicKey, truncated, r, s) {
+			t.Errorf("%v %q: signature rejected", test.h, test.message)
+		}
+		// k = (z + xr) / s mod q
+		z := new(big.Int).SetBytes(truncated)
+		k := new(big.Int).Mul(x, r)
+		k.Add(k, z)
+		k.Mul(k, new(big.Int).ModInverse(s, q))
+		k.Mod(k, q)
+		if k.Cmp(fromHex(test.k)) != 0 {
+			t.Errorf("%v %q: k = %X, expected %s", test.h, test.message, k, test.k)
+		}
+		r2, s2, err := SignDeterministic(priv, test.h, hashed)
+		if err != nil || r2.Cmp(r) != 0 || s2.Cmp(s) != 0 {
+			t.Errorf("%v %q: signature isn't deterministic", test.h, test.message)
+		}
+	}
+
+	// A fault flipping a bit of X while signing gives an invalid signature,
+	// which must not be returned.
+	faulty := *priv
+	faulty.X = new(safenum.Nat).SetBytes(new(big.Int).Xor(x, one).Bytes())
+	if _, _, err := SignDeterministic(&faulty, crypto.SHA256, make([]byte, 32)); err == nil {
+		t.Error("faulty signature returned")
+	}
+}
@@ -0,0 +1,196 @@
+// Package ecdh implements Elliptic Curve Diffie-Hellman over the NIST curves
+// and Curve25519.
+//
+// The API mirrors the one of the standard library's crypto/ecdh package, but
+// the arithmetic is done with the implementations of this module.
+package ecdh
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/lockedmem"
+)
+
+// Curve is a curve over which Diffie-Hellman can be performed.
+type Curve interface {
+	// GenerateKey generates a random private key, reading randomness from rand,
+	// or from the health-tested Reader of the rand package of this module if
+	// rand is nil.
+	GenerateKey(rand io.Reader) (*PrivateKey, error)
+	// NewPrivateKey checks that key is valid and returns a PrivateKey.
+	//
+	// For NIST curves, this follows SEC 1, Version 2.0, Section 2.3.6, which
+	// amounts to decoding the bytes as a fixed length big endian integer and
+	// checking that the result is lower than the order of the curve. The zero
+	// private key is also rejected, as the encoding of the corresponding public
+	// key would be irregular.
+	//
+	// For X25519, this only checks the scalar length.
+	NewPrivateKey(key []byte) (*PrivateKey, error)
+	// NewPublicKey checks that key is valid and returns a PublicKey.
+	//
+	// For NIST curves, this decodes an uncompressed point according to SEC 1,
+	// Version 2.0, Section 2.3.4. Compressed encodings and the point at
+	// infinity are rejected.
+	//
+	// For X25519, this only checks the u-coordinate length. Adversarially
+	// selected public keys can cause ECDH to return an error.
+	NewPublicKey(key []byte) (*PublicKey, error)
+
+	// ecdh performs an ECDH exchange and returns the shared secret. It's exposed
+	// as the PrivateKey.ECDH method.
+	ecdh(local *PrivateKey, remote *PublicKey) ([]byte, error)
+	// privateKeyToPublicKey converts a PrivateKey to a PublicKey. It's exposed
+	// as the PrivateKey.PublicKey method.
+	privateKeyToPublicKey(*PrivateKey) *PublicKey
+}
+
+// PublicKey is an ECDH public key, usually a peer's ECDH share sent over the wire.
+type PublicKey struct {
+	curve     Curve
+	publicKey []byte
+}
+
+// Bytes returns a copy of the encoding of the public key.
+func (k *PublicKey) Bytes() []byte {
+	return append([]byte{}, k.publicKey...)
+}
+
+// Equal returns whether x represents the same public key as k.
+//
+// Note that there can be equivalent public keys with different encodings which
+// would return false from this check but behave the same way as inputs to ECDH.
+func (k *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return k.curve == xx.curve &&
+		subtle.ConstantTimeCompare(k.publicKey, xx.publicKey) == 1
+}
+
+// Curve returns the curve of this public key.
+func (k *PublicKey) Curve() Curve {
+	return k.curve
+}
+
+// KeyExchanger is an ECDH private key, which may be held outside of the
+// process, like in an HSM, a KMS, or an enclave, so that the secret never
+// needs to be in memory. PrivateKey implements it for keys held in memory.
+//
+// The protocols of this module which use long-term keys, like HPKE, accept a
+// KeyExchanger.
+type KeyExchanger interface {
+	// Curve returns the curve of the key.
+	Curve() Curve
+	// PublicKey returns the public key of the key.
+	PublicKey() *PublicKey
+	// ECDH performs an ECDH exchange with a public key of the same curve,
+	// like PrivateKey.ECDH.
+	ECDH(remote *PublicKey) ([]byte, error)
+}
+
+var _ KeyExchanger = (*PrivateKey)(nil)
+
+// PrivateKey is an ECDH private key, usually kept secret.
+type PrivateKey struct {
+	curve      Curve
+	privateKey []byte
+	publicKey  *PublicKey
+	// locked holds privateKey, after LockMemory.
+	locked *lockedmem.Buffer
+}
+
+// ECDH performs an ECDH exchange and returns the shared secret. The PrivateKey
+// and PublicKey must use the same curve.
+//
+// For NIST curves, this performs ECDH as specified in SEC 1, Version 2.0,
+// Section 3.3.1, and returns the x-coordinate encoded according to SEC 1,
+// Version 2.0, Section 2.3.5. The result is never the point at infinity.
+//
+// For X25519, this performs ECDH as specified in RFC 7748, Section 6.1. If
+// the result is the all-zero value, ECDH returns an error.
+func (k *PrivateKey) ECDH(remote *PublicKey) ([]byte, error) {
+	if k.curve != remote.curve {
+		return nil, errMismatchedCurves
+	}
+	return k.curve.ecdh(k, remote)
+}
+
+// Bytes returns a copy of the encoding of the private key.
+func (k *PrivateKey) Bytes() []byte {
+	return append([]byte{}, k.privateKey...)
+}
+
+// Equal returns whether x represents the same private key as k.
+//
+// Note that there can be equivalent private keys with different encodings which
+// would return false from this check but behave the same way as inputs to ECDH.
+func (k *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	return k.curve == xx.curve &&
+		subtle.ConstantTimeCompare(k.privateKey, xx.privateKey) == 1
+}
+
+// Curve returns the curve of this private key.
+func (k *PrivateKey) Curve() Curve {
+	return k.curve
+}
+
+// PublicKey returns the public key corresponding to this private key.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	if k.publicKey == nil {
+		k.publicKey = k.curve.privateKeyToPublicKey(k)
+	}
+	return k.publicKey
+}
+
+// Public implements the implicit interface of all standard library private
+// keys. See the docs of crypto.PrivateKey.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey()
+}
+
+// LockMemory moves the private key to a lockedmem.Buffer, keeping it out of
+// swap and core dumps, and wipes its previous copy. The buffer is released by
+// Close.
+//
+// Only the encoding of the private key is protected: the values derived from
+// it during ECDH live on the Go heap.
+func (k *PrivateKey) LockMemory() error {
+	if k.locked != nil {
+		return nil
+	}
+	buf, err := lockedmem.New(len(k.privateKey))
+	if err != nil {
+		return err
+	}
+	copy(buf.Bytes(), k.privateKey)
+	for i := range k.privateKey {
+		k.privateKey[i] = 0
+	}
+	k.privateKey = buf.Bytes()
+	k.locked = buf
+	return nil
+}
+
+// Close wipes the private key, and releases its locked memory, if any. The
+// PrivateKey can't be used afterwards, except for its PublicKey.
+func (k *PrivateKey) Close() error {
+	k.PublicKey()
+	if k.locked == nil {
+		for i := range k.privateKey {
+			k.privateKey[i] = 0
+		}
+		k.privateKey = nil
+		return nil
+	}
+	err := k.locked.Close()
+	k.privateKey, k.locked = nil, nil
+	return err
+}
@@ -0,0 +1,151 @@
+package ecdh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+var curves = []Curve{P256(), P384(), P521(), X25519()}
+
+func TestECDH(t *testing.T) {
+	for _, curve := range curves {
+		alice, err := curve.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bob, err := curve.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alicePublic, err := curve.NewPublicKey(alice.PublicKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !alicePublic.Equal(alice.PublicKey()) {
+			t.Errorf("%v: public key didn't round trip", curve)
+		}
+		bobPrivate, err := curve.NewPrivateKey(bob.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bobPrivate.Equal(bob) {
+			t.Errorf("%v: private key didn't round trip", curve)
+		}
+		aliceSecret, err := alice.ECDH(bob.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		bobSecret, err := bobPrivate.ECDH(alicePublic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(aliceSecret, bobSecret) {
+			t.Errorf("%v: shared secrets differ", curve)
+		}
+	}
+}
+
+func TestX25519Vector(t *testing.T) {
+	// See RFC 7748, section 6.1.
+	alice, _ := hex.DecodeString("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	bobPublic, _ := hex.DecodeString("de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f")
+	expected := "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742"
+	priv, _ := X25519().NewPrivateKey(alice)
+	pub, _ := X25519().NewPublicKey(bobPublic)
+	secret, err := priv.ECDH(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(secret) != expected {
+		t.Errorf("shared secret = %x, expected %s", secret, expected)
+	}
+	if hex.EncodeToString(priv.PublicKey().Bytes()) != "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a" {
+		t.Errorf("public key = %x", priv.PublicKey().Bytes())
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	for _, curve := range []Curve{P256(), P384(), P521()} {
+		k, _ := curve.GenerateKey(rand.Reader)
+		if _, err := curve.NewPrivateKey(make([]byte, len(k.Bytes()))); err == nil {
+			t.Errorf("%v: zero private key was accepted", curve)
+		}
+		if _, err := curve.NewPrivateKey(bytes.Repeat([]byte{0xff}, len(k.Bytes()))); err == nil {
+			t.Errorf("%v: private key larger than the order was accepted", curve)
+		}
+		public := k.PublicKey().Bytes()
+		public[len(public)-1] ^= 1
+		if _, err := curve.NewPublicKey(public); err == nil {
+			t.Errorf("%v: public key not on the curve was accepted", curve)
+		}
+	}
+	if _, err := X25519().NewPublicKey(make([]byte, 31)); err == nil {
+		t.Errorf("short X25519 public key was accepted")
+	}
+	k, _ := X25519().GenerateKey(rand.Reader)
+	zero, _ := X25519().NewPublicKey(make([]byte, 32))
+	if _, err := k.ECDH(zero); err == nil {
+		t.Errorf("low order X25519 public key was accepted")
+	}
+}
+
+func TestDefaultRand(t *testing.T) {
+	for _, c := range curves {
+		a, err := c.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := c.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Equal(b) {
+			t.Errorf("%v: generated the same key twice", c)
+		}
+	}
+}
+
+func TestMismatchedCurves(t *testing.T) {
+	a, _ := P256().GenerateKey(rand.Reader)
+	b, _ := P384().GenerateKey(rand.Reader)
+	if _, err := a.ECDH(b.PublicKey()); err == nil {
+		t.Errorf("expected an error for mismatched curves")
+	}
+}
+
+func TestLockMemory(t *testing.T) {
+	for _, curve := range curves {
+		k, err := curve.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		peer, _ := curve.GenerateKey(rand.Reader)
+		encoded := k.Bytes()
+		expected, _ := k.ECDH(peer.PublicKey())
+		if err := k.LockMemory(); err != nil {
+			t.Skipf("can't lock memory: %v", err)
+		}
+		if !bytes.Equal(k.Bytes(), encoded) {
+			t.Errorf("%v: LockMemory changed the private key", curve)
+		}
+		secret, err := k.ECDH(peer.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(secret, expected) {
+			t.Errorf("%v: locked private key gives a different shared secret", curve)
+		}
+		public := k.PublicKey()
+		if err := k.Close(); err != nil {
+			t.Errorf("%v: Close: %v", curve, err)
+		}
+		if k.Bytes() != nil && !isZero(k.Bytes()) {
+			t.Errorf("%v: Close didn't wipe the private key", curve)
+		}
+		if !k.PublicKey().Equal(public) {
+			t.Errorf("%v: Close changed the public key", curve)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package ecdh
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	errMismatchedCurves = errors.New("ecdh: private key and public key curves do not match")
+	errInvalidPrivate   = errors.New("ecdh: invalid private key")
+	errInvalidPublic    = errors.New("ecdh: invalid public key")
+	errInfinity         = errors.New("ecdh: shared secret is the point at infinity")
+)
+
+type nistCurve struct {
+	name  string
+	curve elliptic.Curve
+}
+
+func (c *nistCurve) String() string {
+	return c.name
+}
+
+func (c *nistCurve) byteLen() int {
+	return (c.curve.Params().BitSize + 7) / 8
+}
+
+func (c *nistCurve) GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	rand = randutil.Or(rand)
+	if err := fips.CheckEntropy(rand); err != nil {
+		return nil, err
+	}
+	randutil.MaybeReadByte(rand)
+	key, err := elliptic.RandomScalar(c.curve, rand)
+	if err != nil {
+		return nil, err
+	}
+	return c.NewPrivateKey(key)
+}
+
+func (c *nistCurve) NewPrivateKey(key []byte) (*PrivateKey, error) {
+	if len(key) != c.byteLen() {
+		return nil, errInvalidPrivate
+	}
+	// SetBytes can modify its argument, if it has spare capacity.
+	k := new(safenum.Nat).SetBytes(key[:len(key):len(key)])
+	// Both checks are always done, so that rejecting a key takes the same
+	// time, whichever fails.
+	nonZero := 1 ^ subtle.ConstantTimeEq(int32(k.Cmp(new(safenum.Nat))), 0)
+	inRange := subtle.ConstantTimeEq(int32(k.CmpMod(c.curve.Params().N)), -1)
+	if nonZero&inRange != 1 {
+		return nil, errInvalidPrivate
+	}
+	privateKey := append([]byte{}, key...)
+	ctgrind.MarkSecret(privateKey)
+	return &PrivateKey{curve: c, privateKey: privateKey}, nil
+}
+
+func (c *nistCurve) privateKeyToPublicKey(key *PrivateKey) *PublicKey {
+	x, y := c.curve.ScalarBaseMult(key.privateKey)
+	ctgrind.DeclassifyInt(x)
+	ctgrind.DeclassifyInt(y)
+	return &PublicKey{curve: c, publicKey: elliptic.Marshal(c.curve, x, y)}
+}
+
+func (c *nistCurve) NewPublicKey(key []byte) (*PublicKey, error) {
+	if x, _ := elliptic.Unmarshal(c.curve, key); x == nil {
+		return nil, errInvalidPublic
+	}
+	return &PublicKey{curve: c, publicKey: append([]byte{}, key...)}, nil
+}
+
+func (c *nistCurve) ecdh(local *PrivateKey, remote *PublicKey) ([]byte, error) {
+	// The public key was checked to be on the curve when it was created, and
+	// all of the supported curves have a prime order, so the result can only
+	// be the point at infinity if the private key is 0, which was also rejected.
+	x, y := elliptic.Unmarshal(c.curve, remote.publicKey)
+	x, y = c.curve.ScalarMult(x, y, local.privateKey)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, errInfinity
+	}
+	out := make([]byte, c.byteLen())
+	return x.FillBytes(out), nil
+}
+
+var (
+	p256 = &nistCurve{name: "P-256"}
+	p384 = &nistCurve{name: "P-384"}
+	p521 = &nistCurve{name: "P-521"}
+)
+
+func init() {
+	p256.curve = elliptic.P256()
+	p384.curve = elliptic.P384()
+	p521.curve = elliptic.P521()
+}
+
+// P256 returns a Curve which implements NIST P-256 (FIPS 186-3, section D.2.3),
+// also known as secp256r1 or prime256v1.
+//
+// Multiple invocations of this function will return the same value, which can
+// be used for equality checks and switch statements.
+func P256() Curve { return p256 }
+
+// P384 returns a Curve which implements NIST P-384 (FIPS 186-3, section D.2.4),
+// also known as secp384r1.
+//
+// Multiple invocations of this function will return the same value, which can
+// be used for equality checks and switch statements.
+func P384() Curve { return p384 }
+
+// P521 returns a Curve which implements NIST P-521 (FIPS 186-3, section D.2.5),
+// also known as secp521r1.
+//
+// Multiple invocations of this function will return the same value, which can
+// be used for equality checks and switch statements.
+func P521() Curve { return p521 }
+
+// isZero reports whether b is all zeros, in constant-time.
+func isZero(b []byte) bool {
+	var acc byte
+	for _, x := range b {
+		acc |= x
+	}
+	return subtle.ConstantTimeByteEq(acc, 0) == 1
+}
@@ -0,0 +1,87 @@
+package ecdh
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/curve25519"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/strict"
+)
+
+type x25519Curve struct{}
+
+var x25519 = &x25519Curve{}
+
+// X25519 returns a Curve which implements the X25519 function over Curve25519
+// (RFC 7748, Section 5).
+//
+// Multiple invocations of this function will return the same value, so it can
+// be used for equality checks and switch statements.
+func X25519() Curve { return x25519 }
+
+func (c *x25519Curve) String() string {
+	return "X25519"
+}
+
+// checkFIPS rejects X25519 when the FIPS policy is enforced.
+func checkFIPS() error {
+	return fips.Check(false, "X25519")
+}
+
+func (c *x25519Curve) GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	rand = randutil.Or(rand)
+	if err := checkFIPS(); err != nil {
+		return nil, err
+	}
+	key := make([]byte, curve25519.ScalarSize)
+	randutil.MaybeReadByte(rand)
+	if _, err := io.ReadFull(rand, key); err != nil {
+		return nil, err
+	}
+	return c.NewPrivateKey(key)
+}
+
+func (c *x25519Curve) NewPrivateKey(key []byte) (*PrivateKey, error) {
+	if err := checkFIPS(); err != nil {
+		return nil, err
+	}
+	if len(key) != curve25519.ScalarSize {
+		return nil, errInvalidPrivate
+	}
+	privateKey := append([]byte{}, key...)
+	ctgrind.MarkSecret(privateKey)
+	return &PrivateKey{curve: c, privateKey: privateKey}, nil
+}
+
+func (c *x25519Curve) privateKeyToPublicKey(key *PrivateKey) *PublicKey {
+	public, err := curve25519.X25519(key.privateKey, curve25519.Basepoint)
+	if err != nil {
+		// The base point has prime order, so this can't happen.
+		panic(err)
+	}
+	ctgrind.Declassify(public)
+	return &PublicKey{curve: c, publicKey: public}
+}
+
+func (c *x25519Curve) NewPublicKey(key []byte) (*PublicKey, error) {
+	if err := checkFIPS(); err != nil {
+		return nil, err
+	}
+	if len(key) != curve25519.PointSize {
+		return nil, errInvalidPublic
+	}
+	if err := strict.Check(curve25519.CanonicalPoint(key), "X25519 public key", "not reduced, or with the top bit set"); err != nil {
+		return nil, err
+	}
+	return &PublicKey{curve: c, publicKey: append([]byte{}, key...)}, nil
+}
+
+func (c *x25519Curve) ecdh(local *PrivateKey, remote *PublicKey) ([]byte, error) {
+	out, err := curve25519.X25519(local.privateKey, remote.publicKey)
+	if err != nil || isZero(out) {
+		return nil, errInfinity
+	}
+	return out, nil
+}
@@ -32,14 +32,18 @@ import (
 	"crypto/cipher"
 	"crypto/elliptic"
 	"crypto/sha512"
+	"crypto/subtle"
 	"errors"
 	"io"
 	"math/big"
+	"sync/atomic"
 
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/ctcrypto/internal/der"
 	"github.com/cronokirby/ctcrypto/internal/randutil"
-
-	"golang.org/x/crypto/cryptobyte"
-	"golang.org/x/crypto/cryptobyte/asn1"
+	"github.com/cronokirby/ctcrypto/strict"
 )
 
 // A invertible implements fast inverse mod Curve.Params().N
@@ -71,17 +75,19 @@ type PublicKey struct {
 // Two keys are only considered to have the same value if they have the same Curve value.
 // Note that for example elliptic.P256() and elliptic.P256().Params() are different
 // values, as the latter is a generic not constant time implementation.
+//
+// The coordinates are compared in constant time.
 func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
 	xx, ok := x.(*PublicKey)
-	if !ok {
+	// Standard library Curve implementations are singletons, so this check
+	// will work for those. Other Curves might be equivalent even if not
+	// singletons, but there is no definitive way to check for that, and
+	// better to err on the side of safety.
+	if !ok || pub.Curve != xx.Curve {
 		return false
 	}
-	return pub.X.Cmp(xx.X) == 0 && pub.Y.Cmp(xx.Y) == 0 &&
-		// Standard library Curve implementations are singletons, so this check
-		// will work for those. Other Curves might be equivalent even if not
-		// singletons, but there is no definitive way to check for that, and
-		// better to err on the side of safety.
-		pub.Curve == xx.Curve
+	size := (pub.Curve.Params().BitSize + 7) / 8
+	return constantTimeEqual(pub.X, xx.X, size)&constantTimeEqual(pub.Y, xx.Y, size) == 1
 }
 
 // PrivateKey represents an ECDSA private key.
@@ -97,13 +103,28 @@ func (priv *PrivateKey) Public() crypto.PublicKey {
 
 // Equal reports whether priv and x have the same value.
 //
-// See PublicKey.Equal for details on how Curve is compared.
+// See PublicKey.Equal for details on how Curve is compared. The private
+// scalars are compared in constant time.
 func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
 	xx, ok := x.(*PrivateKey)
-	if !ok {
+	if !ok || !priv.PublicKey.Equal(&xx.PublicKey) {
 		return false
 	}
-	return priv.PublicKey.Equal(&xx.PublicKey) && priv.D.Cmp(xx.D) == 0
+	size := (priv.Curve.Params().N.BitLen() + 7) / 8
+	return constantTimeEqual(priv.D, xx.D, size) == 1
+}
+
+// constantTimeEqual returns 1 if a and b are equal, and 0 otherwise, in a time
+// which only depends on size, if both are non-negative, and fit in size bytes,
+// as the values of valid keys do.
+func constantTimeEqual(a, b *big.Int, size int) int {
+	if a.Sign() < 0 || b.Sign() < 0 || a.BitLen() > 8*size || b.BitLen() > 8*size {
+		if a.Cmp(b) == 0 {
+			return 1
+		}
+		return 0
+	}
+	return subtle.ConstantTimeCompare(a.FillBytes(make([]byte, size)), b.FillBytes(make([]byte, size)))
 }
 
 // Sign signs digest with priv, reading randomness from rand. The opts argument
@@ -119,12 +140,12 @@ func (priv *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOp
 		return nil, err
 	}
 
-	var b cryptobyte.Builder
-	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
-		b.AddASN1BigInt(r)
-		b.AddASN1BigInt(s)
+	var b der.Builder
+	b.AddSequence(func(b *der.Builder) {
+		b.AddIntegerBytes(r.Bytes())
+		b.AddIntegerBytes(s.Bytes())
 	})
-	return b.Bytes()
+	return b.Bytes(), nil
 }
 
 var one = new(big.Int).SetInt64(1)
@@ -146,8 +167,24 @@ func randFieldElement(c elliptic.Curve, rand io.Reader) (k *big.Int, err error)
 	return
 }
 
-// GenerateKey generates a public and private key pair.
+// checkFIPS checks that c and rand are approved, if the FIPS policy is
+// enforced.
+func checkFIPS(c elliptic.Curve, rand io.Reader) error {
+	name := c.Params().Name
+	if err := fips.Check(fips.ApprovedCurve(name), "ECDSA over "+name); err != nil {
+		return err
+	}
+	return fips.CheckEntropy(rand)
+}
+
+// GenerateKey generates a public and private key pair. If rand is nil, the
+// health-tested Reader of the rand package of this module is used.
 func GenerateKey(c elliptic.Curve, rand io.Reader) (*PrivateKey, error) {
+	defer instrument.Begin(instrument.GenerateKey, c.Params().Name)()
+	rand = randutil.Or(rand)
+	if err := checkFIPS(c, rand); err != nil {
+		return nil, err
+	}
 	k, err := randFieldElement(c, rand)
 	if err != nil {
 		return nil, err
@@ -193,12 +230,50 @@ func fermatInverse(k, N *big.Int) *big.Int {
 
 var errZeroParam = errors.New("zero parameter")
 
+// verifyAfterSign is 1 if signatures are verified before being returned.
+var verifyAfterSign int32
+
+// SetVerifyAfterSign sets whether Sign verifies every signature against the
+// public key before returning it, which is disabled by default.
+//
+// This protects against fault attacks, where a glitch during signing produces
+// an invalid signature from which the private key can be recovered, at the
+// cost of a verification per signature. The nonces used by this package are
+// randomized, which already thwarts most of these attacks, but not if rand
+// can be made to repeat its output.
+func SetVerifyAfterSign(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&verifyAfterSign, v)
+}
+
+// VerifyAfterSign reports whether Sign verifies the signatures it produces.
+func VerifyAfterSign() bool {
+	return atomic.LoadInt32(&verifyAfterSign) == 1
+}
+
+var errFault = errors.New("ecdsa: signature failed to verify, signing was faulty")
+
 // Sign signs a hash (which should be the result of hashing a larger message)
 // using the private key, priv. If the hash is longer than the bit-length of the
 // private key's curve order, the hash will be truncated to that length. It
 // returns the signature as a pair of integers. The security of the private key
-// depends on the entropy of rand.
+// depends on the entropy of rand, which defaults to the health-tested Reader
+// of the rand package of this module if nil.
 func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err error) {
+	defer instrument.Begin(instrument.Sign, priv.Curve.Params().Name)()
+	rand = randutil.Or(rand)
+	if err := checkFIPS(priv.Curve, rand); err != nil {
+		return nil, nil, err
+	}
+	// The digest must be at least as strong as the curve, which rules out
+	// signing SHA-256 digests with P-384, for example.
+	strength := fips.CurveStrength(priv.Curve.Params().BitSize)
+	if err := fips.Check(len(hash)*4 >= strength, "ECDSA with a weaker digest than the curve"); err != nil {
+		return nil, nil, err
+	}
 	randutil.MaybeReadByte(rand)
 
 	// Get min(log2(q) / 2, 256) bits of entropy from rand.
@@ -235,7 +310,19 @@ func Sign(rand io.Reader, priv *PrivateKey, hash []byte) (r, s *big.Int, err err
 
 	// See [NSA] 3.4.1
 	c := priv.PublicKey.Curve
-	return sign(priv, &csprng, c, hash)
+	r, s, err = sign(priv, &csprng, c, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	// (r, n - s) is also valid, so only the smaller s is produced when the
+	// strict policy is enforced, as it then rejects the other.
+	if strict.Enabled() && !lowS(c, s) {
+		s.Sub(c.Params().N, s)
+	}
+	if VerifyAfterSign() && !Verify(&priv.PublicKey, hash, r, s) {
+		return nil, nil, errFault
+	}
+	return r, s, nil
 }
 
 func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve, hash []byte) (r, s *big.Int, err error) {
@@ -251,6 +338,7 @@ func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve
 				r = nil
 				return
 			}
+			ctgrind.MarkSecretInt(k)
 
 			if in, ok := priv.Curve.(invertible); ok {
 				kInv = in.Inverse(k)
@@ -259,6 +347,7 @@ func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve
 			}
 
 			r, _ = priv.Curve.ScalarBaseMult(k.Bytes())
+			ctgrind.DeclassifyInt(r)
 			r.Mod(r, N)
 			if r.Sign() != 0 {
 				break
@@ -270,6 +359,7 @@ func signGeneric(priv *PrivateKey, csprng *cipher.StreamReader, c elliptic.Curve
 		s.Add(s, e)
 		s.Mul(s, kInv)
 		s.Mod(s, N) // N != 0
+		ctgrind.DeclassifyInt(s)
 		if s.Sign() != 0 {
 			break
 		}
@@ -287,11 +377,40 @@ func SignASN1(rand io.Reader, priv *PrivateKey, hash []byte) ([]byte, error) {
 	return priv.Sign(rand, hash, nil)
 }
 
+var _ crypto.Signer = (*PrivateKey)(nil)
+
+var errNotECDSA = errors.New("ecdsa: signer doesn't hold an ECDSA key")
+
+// SignWithSigner is like SignASN1, with a private key which may be held
+// outside of the process, like in an HSM, a KMS, or an enclave, so that the
+// secret never needs to be in memory. The signer's Public method must return a
+// *PublicKey, and its Sign method an ASN.1 encoded signature, like
+// PrivateKey.Sign. The opts are passed to the signer, which may need the hash
+// function.
+//
+// The signature is always verified against the public key, since it is
+// produced by code this package doesn't control.
+func SignWithSigner(rand io.Reader, signer crypto.Signer, hash []byte, opts crypto.SignerOpts) ([]byte, error) {
+	pub, ok := signer.Public().(*PublicKey)
+	if !ok {
+		return nil, errNotECDSA
+	}
+	sig, err := signer.Sign(randutil.Or(rand), hash, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !VerifyASN1(pub, hash, sig) {
+		return nil, errFault
+	}
+	return sig, nil
+}
+
 // Verify verifies the signature in r, s of hash using the public key, pub. Its
 // return value records whether the signature is valid.
 func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	// See [NSA] 3.4.2
 	c := pub.Curve
+	defer instrument.Begin(instrument.Verify, c.Params().Name)()
 	N := c.Params().N
 
 	if r.Sign() <= 0 || s.Sign() <= 0 {
@@ -300,9 +419,17 @@ func Verify(pub *PublicKey, hash []byte, r, s *big.Int) bool {
 	if r.Cmp(N) >= 0 || s.Cmp(N) >= 0 {
 		return false
 	}
+	if strict.Check(lowS(c, s), "ECDSA signature", "s is larger than half the order") != nil {
+		return false
+	}
 	return verify(pub, c, hash, r, s)
 }
 
+// lowS reports whether s is at most half the order of c.
+func lowS(c elliptic.Curve, s *big.Int) bool {
+	return s.Cmp(new(big.Int).Rsh(c.Params().N, 1)) <= 0
+}
+
 func verifyGeneric(pub *PublicKey, c elliptic.Curve, hash []byte, r, s *big.Int) bool {
 	e := hashToInt(hash, c)
 	var w *big.Int
@@ -339,18 +466,18 @@ func verifyGeneric(pub *PublicKey, c elliptic.Curve, hash []byte, r, s *big.Int)
 // public key, pub. Its return value records whether the signature is valid.
 func VerifyASN1(pub *PublicKey, hash, sig []byte) bool {
 	var (
-		r, s  = &big.Int{}, &big.Int{}
-		inner cryptobyte.String
+		rBytes, sBytes []byte
+		inner          der.Input
 	)
-	input := cryptobyte.String(sig)
-	if !input.ReadASN1(&inner, asn1.SEQUENCE) ||
+	input := der.Input(sig)
+	if !input.ReadElement(&inner, der.Sequence) ||
 		!input.Empty() ||
-		!inner.ReadASN1Integer(r) ||
-		!inner.ReadASN1Integer(s) ||
+		!inner.ReadIntegerBytes(&rBytes) ||
+		!inner.ReadIntegerBytes(&sBytes) ||
 		!inner.Empty() {
 		return false
 	}
-	return Verify(pub, hash, r, s)
+	return Verify(pub, hash, new(big.Int).SetBytes(rBytes), new(big.Int).SetBytes(sBytes))
 }
 
 type zr struct {
@@ -7,6 +7,7 @@ package ecdsa
 import (
 	"bufio"
 	"compress/bzip2"
+	"crypto"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha1"
@@ -395,3 +396,117 @@ func TestZeroHashSignature(t *testing.T) {
 		}
 	}
 }
+
+// glitchedCurve computes the next ScalarBaseMult with k + 1, like a fault
+// injected during signing would.
+type glitchedCurve struct {
+	elliptic.Curve
+	glitch bool
+}
+
+func (c *glitchedCurve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	if c.glitch {
+		c.glitch = false
+		k = new(big.Int).Add(new(big.Int).SetBytes(k), one).Bytes()
+	}
+	return c.Curve.ScalarBaseMult(k)
+}
+
+func TestVerifyAfterSign(t *testing.T) {
+	defer SetVerifyAfterSign(VerifyAfterSign())
+	c := &glitchedCurve{Curve: elliptic.P256()}
+	priv, _ := GenerateKey(c, rand.Reader)
+	hashed := []byte("testing")
+
+	SetVerifyAfterSign(false)
+	c.glitch = true
+	r, s, err := Sign(rand.Reader, priv, hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(&priv.PublicKey, hashed, r, s) {
+		t.Fatalf("glitched signature is valid, the test is broken")
+	}
+
+	SetVerifyAfterSign(true)
+	c.glitch = true
+	if _, _, err := Sign(rand.Reader, priv, hashed); err == nil {
+		t.Errorf("glitched signature returned with verify after sign enabled")
+	}
+	r, s, err = Sign(rand.Reader, priv, hashed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(&priv.PublicKey, hashed, r, s) {
+		t.Errorf("signature with verify after sign enabled is invalid")
+	}
+}
+
+// remoteSigner stands for a key held outside of the process, which can only be
+// used through crypto.Signer.
+type remoteSigner struct {
+	pub  crypto.PublicKey
+	sign func(digest []byte) ([]byte, error)
+}
+
+func (s *remoteSigner) Public() crypto.PublicKey { return s.pub }
+
+func (s *remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.sign(digest)
+}
+
+func TestSignWithSigner(t *testing.T) {
+	priv, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	other, _ := GenerateKey(elliptic.P256(), rand.Reader)
+	digest := sha256.Sum256([]byte("message"))
+	signWith := func(k *PrivateKey) func([]byte) ([]byte, error) {
+		return func(digest []byte) ([]byte, error) {
+			return SignASN1(rand.Reader, k, digest)
+		}
+	}
+
+	s := &remoteSigner{pub: &priv.PublicKey, sign: signWith(priv)}
+	sig, err := SignWithSigner(nil, s, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyASN1(&priv.PublicKey, digest[:], sig) {
+		t.Error("signature doesn't verify")
+	}
+
+	faulty := &remoteSigner{pub: &priv.PublicKey, sign: signWith(other)}
+	if _, err := SignWithSigner(nil, faulty, digest[:], crypto.SHA256); err == nil {
+		t.Error("signature by the wrong key accepted")
+	}
+	notECDSA := &remoteSigner{pub: "not a key", sign: signWith(priv)}
+	if _, err := SignWithSigner(nil, notECDSA, digest[:], crypto.SHA256); err == nil {
+		t.Error("signer without an ECDSA key accepted")
+	}
+}
+
+func TestKeyEqual(t *testing.T) {
+	priv, err := GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Copies don't share their big.Int values, which are compared by value.
+	clone := &PrivateKey{D: new(big.Int).Set(priv.D)}
+	clone.Curve = priv.Curve
+	clone.X, clone.Y = new(big.Int).Set(priv.X), new(big.Int).Set(priv.Y)
+	if !priv.Equal(clone) || !priv.PublicKey.Equal(&clone.PublicKey) {
+		t.Error("a copy of a key isn't equal to it")
+	}
+	clone.D = new(big.Int).Add(priv.D, big.NewInt(1))
+	if priv.Equal(clone) {
+		t.Error("keys with different scalars are equal")
+	}
+	// Values which don't fit the curve are still compared.
+	clone.D = new(big.Int).Lsh(priv.D, 512)
+	if priv.Equal(clone) || clone.Equal(priv) {
+		t.Error("a key with an oversized scalar is equal")
+	}
+	clone.D = new(big.Int).Neg(priv.D)
+	if priv.Equal(clone) {
+		t.Error("a key with a negative scalar is equal")
+	}
+}
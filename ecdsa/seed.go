@@ -0,0 +1,49 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"crypto/sha512"
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/drbg"
+	"github.com/cronokirby/ctcrypto/fips"
+)
+
+// MinSeedSize is the size of the smallest seed accepted by
+// GenerateKeyFromSeed, which is the security strength of HMAC_DRBG with
+// SHA-512, in bytes.
+const MinSeedSize = 32
+
+// GenerateKeyFromSeed deterministically generates a key pair on c from seed,
+// which must hold at least MinSeedSize bytes of entropy. The same seed and
+// curve always give the same key, so that a key can be regenerated from a
+// backup of its seed, for example by hardware wallets.
+//
+// The seed instantiates HMAC_DRBG with SHA-512, personalized with the name of
+// the curve, and the private scalar is derived from its output as done by
+// GenerateKey, from 64 more bits than the order, so its bias is negligible.
+//
+// Since the seed can't be checked to come from an approved source of
+// randomness, this function is rejected when the FIPS policy is enforced.
+func GenerateKeyFromSeed(c elliptic.Curve, seed []byte) (*PrivateKey, error) {
+	if err := fips.Check(false, "ECDSA key generation from a seed"); err != nil {
+		return nil, err
+	}
+	if len(seed) < MinSeedSize {
+		return nil, errors.New("ecdsa: seed too short")
+	}
+	d, err := drbg.NewHMAC(sha512.New, seed, nil, []byte("ctcrypto ECDSA key "+c.Params().Name))
+	if err != nil {
+		return nil, err
+	}
+	k, err := randFieldElement(c, d)
+	if err != nil {
+		return nil, err
+	}
+
+	priv := new(PrivateKey)
+	priv.PublicKey.Curve = c
+	priv.D = k
+	priv.PublicKey.X, priv.PublicKey.Y = c.ScalarBaseMult(k.Bytes())
+	return priv, nil
+}
@@ -0,0 +1,44 @@
+package ecdsa
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"testing"
+)
+
+func TestGenerateKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, MinSeedSize)
+	for _, c := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		name := c.Params().Name
+		priv, err := GenerateKeyFromSeed(c, seed)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !c.IsOnCurve(priv.X, priv.Y) {
+			t.Errorf("%s: public key invalid", name)
+		}
+		again, err := GenerateKeyFromSeed(c, seed)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !priv.Equal(again) {
+			t.Errorf("%s: the same seed gave different keys", name)
+		}
+		other, err := GenerateKeyFromSeed(c, append([]byte{1}, seed...))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if priv.Equal(other) {
+			t.Errorf("%s: different seeds gave the same key", name)
+		}
+	}
+	// Keys on different curves are independent.
+	p256, _ := GenerateKeyFromSeed(elliptic.P256(), seed)
+	p384, _ := GenerateKeyFromSeed(elliptic.P384(), seed)
+	if p256.D.Cmp(p384.D) == 0 {
+		t.Error("the same scalar was derived for two curves")
+	}
+	if _, err := GenerateKeyFromSeed(elliptic.P256(), seed[:MinSeedSize-1]); err == nil {
+		t.Error("short seed accepted")
+	}
+}
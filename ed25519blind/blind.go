@@ -0,0 +1,206 @@
+// Package ed25519blind implements the blinding of Ed25519 keys, as done by Tor
+// onion services, following appendix A.2 of the version 3 rendezvous
+// specification, rend-spec-v3.txt.
+//
+// A public key A is blinded by a nonce into A' = h A, where h is a clamped
+// hash of A and of the nonce, and its private key is blinded into a' = h a, so
+// that the blinded private key signs messages which verify against the
+// blinded public key with standard Ed25519 verification, like the one of
+// crypto/ed25519. Anyone knowing A and the nonce can compute A', but blinded
+// keys for different nonces can't be linked to each other, or to A, without
+// knowing A.
+//
+// Blinded keys can be blinded again, since the hash only depends on the
+// current public key, which gives hierarchies of keys, as used by key
+// rotation schemes.
+package ed25519blind
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"github.com/cronokirby/ctcrypto/fips"
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/sha3"
+)
+
+// PrivateKeySize is the size of an encoded blinded private key, which is the
+// scalar followed by the prefix used to derive nonces, as stored by Tor.
+const PrivateKeySize = 64
+
+const (
+	// blindString prefixes the hash of the blinding factor. The specification
+	// includes its terminating NUL byte.
+	blindString = "Derive temporary signing key\x00"
+	// basepointString is the description of the base point hashed into the
+	// blinding factor.
+	basepointString = "(15112221349535400772501151409588531511454012693041857206046113283949847762202, " +
+		"46316835694926478169428394003475163141307993866256225615783033603165251855960)"
+	// prefixString prefixes the hash of the blinded nonce prefix.
+	prefixString = "Derive temporary signing key hash input"
+)
+
+// blindingFactor returns the clamped factor h, as little-endian bytes, for
+// the public key A and the nonce, which is
+// SHA3-256(BLIND_STRING | A | B | N), without the optional secret.
+func blindingFactor(A, nonce []byte) []byte {
+	h := sha3.New256()
+	h.Write([]byte(blindString))
+	h.Write(A)
+	h.Write([]byte(basepointString))
+	h.Write(nonce)
+	factor := h.Sum(nil)
+	factor[0] &= 248
+	factor[31] &= 63
+	factor[31] |= 64
+	return factor
+}
+
+// TorNonce returns the nonce used by Tor to blind the identity key of an onion
+// service for a time period, whose number and length, in minutes, are given,
+// which is "key-blind" | INT_8(period) | INT_8(periodLength).
+func TorNonce(period, periodLength uint64) []byte {
+	nonce := make([]byte, 0, 9+16)
+	nonce = append(nonce, "key-blind"...)
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], period)
+	nonce = append(nonce, buf[:]...)
+	binary.BigEndian.PutUint64(buf[:], periodLength)
+	return append(nonce, buf[:]...)
+}
+
+// BlindPublicKey returns the public key pub blinded by nonce.
+//
+// Unlike the blinded private key, this is computed with variable-time
+// arithmetic, since public keys and nonces are public.
+func BlindPublicKey(pub ed25519.PublicKey, nonce []byte) (ed25519.PublicKey, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errors.New("ed25519blind: invalid public key length")
+	}
+	A, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return nil, err
+	}
+	// h is a multiple of the cofactor, so h A = (h / 8) (8 A), which is in the
+	// prime order subgroup, even if A has a small order component.
+	factor := blindingFactor(pub, nonce)
+	h := new(big.Int).SetBytes(reverse(factor))
+	h.Rsh(h, 3)
+	hBytes := h.Bytes()
+	k := new(safenum.Nat).SetBytes(hBytes[:len(hBytes):len(hBytes)])
+	k.Mod(k, edwards25519.Order)
+	A.MultByCofactor(A)
+	return ed25519.PublicKey(A.ScalarMult(k, A).Bytes()), nil
+}
+
+// PrivateKey is a blinded Ed25519 private key, which can't be represented as a
+// seed, like the keys of crypto/ed25519. It implements crypto.Signer.
+type PrivateKey struct {
+	// a is the secret scalar, and prefix the secret used to derive nonces.
+	a      *safenum.Nat
+	prefix []byte
+	// pub is the encoding of a B.
+	pub ed25519.PublicKey
+}
+
+func newPrivateKey(a *safenum.Nat, prefix []byte) *PrivateKey {
+	pub := new(edwards25519.Point).ScalarBaseMult(a).Bytes()
+	ctgrind.Declassify(pub)
+	return &PrivateKey{a: a, prefix: prefix, pub: pub}
+}
+
+// NewPrivateKey returns the unblinded private key of priv, as the root of a
+// hierarchy of blinded keys.
+func NewPrivateKey(priv ed25519.PrivateKey) (*PrivateKey, error) {
+	if err := fips.Check(false, "Ed25519 key blinding"); err != nil {
+		return nil, err
+	}
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("ed25519blind: invalid private key length")
+	}
+	a, prefix, err := edsig.ExpandSeed(priv.Seed())
+	if err != nil {
+		return nil, err
+	}
+	return newPrivateKey(a, prefix), nil
+}
+
+// BlindPrivateKey returns the private key priv blinded by nonce, whose public
+// key is BlindPublicKey(pub, nonce), for the public key pub of priv.
+func BlindPrivateKey(priv ed25519.PrivateKey, nonce []byte) (*PrivateKey, error) {
+	k, err := NewPrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return k.Blind(nonce), nil
+}
+
+// ParsePrivateKey decodes a private key of PrivateKeySize bytes, as returned
+// by Bytes: a little-endian scalar, which is reduced, followed by the prefix.
+func ParsePrivateKey(b []byte) (*PrivateKey, error) {
+	if err := fips.Check(false, "Ed25519 key blinding"); err != nil {
+		return nil, err
+	}
+	if len(b) != PrivateKeySize {
+		return nil, errors.New("ed25519blind: invalid private key length")
+	}
+	b = append([]byte{}, b...)
+	ctgrind.MarkSecret(b)
+	// The expanded keys of Tor hold a clamped scalar, which isn't reduced.
+	a, err := edwards25519.ScalarFromUniformBytes(append(b[:32:32], make([]byte, 32)...))
+	if err != nil {
+		return nil, err
+	}
+	return newPrivateKey(a, b[32:]), nil
+}
+
+// Blind returns the key blinded by nonce, whose public key is
+// BlindPublicKey(k.Public(), nonce).
+func (k *PrivateKey) Blind(nonce []byte) *PrivateKey {
+	// The factor only depends on public values.
+	h, _ := edwards25519.ScalarFromClampedBytes(blindingFactor(k.pub, nonce))
+	a := new(safenum.Nat).ModMul(h, k.a, edwards25519.Order)
+	d := sha512.New()
+	d.Write([]byte(prefixString))
+	d.Write(k.prefix)
+	return newPrivateKey(a, d.Sum(nil)[:32])
+}
+
+// Public returns the ed25519.PublicKey of k.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return append(ed25519.PublicKey{}, k.pub...)
+}
+
+// Bytes returns the encoding of k, of PrivateKeySize bytes.
+func (k *PrivateKey) Bytes() []byte {
+	out := make([]byte, 0, PrivateKeySize)
+	out = append(out, edwards25519.ScalarBytes(k.a)...)
+	return append(out, k.prefix...)
+}
+
+// Sign signs message with k, producing a standard Ed25519 signature, which
+// verifies against the public key of k. As for crypto/ed25519, opts.HashFunc
+// must return zero, since the message isn't hashed, and rand is ignored.
+func (k *PrivateKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts.HashFunc() != crypto.Hash(0) {
+		return nil, errors.New("ed25519blind: cannot sign hashed message")
+	}
+	return edsig.SignExpanded(k.a, k.prefix, k.pub, message), nil
+}
+
+// reverse returns a reversed copy of b.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package ed25519blind
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestVector checks a blinding by the Tor nonce of a time period, computed
+// with an independent implementation of the specification.
+func TestVector(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+	nonce := TorNonce(1234, 1440)
+
+	blindedPub, err := BlindPublicKey(pub, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "86a02162aa87ecefbd9b7d74f101cf842edb2d435b925b91ad33ac9f47082be6"); !bytes.Equal(blindedPub, want) {
+		t.Errorf("blinded public key %x, want %x", blindedPub, want)
+	}
+	blinded, err := BlindPrivateKey(priv, nonce)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "1896118611889c94332abad8a4f834580afcfee496dfb28585dff026ee08670fdbb1aaee717bb8359a9f104295474da8572d9c2822a8680a641e4f6494b8689e"); !bytes.Equal(blinded.Bytes(), want) {
+		t.Errorf("blinded private key %x, want %x", blinded.Bytes(), want)
+	}
+	sig, err := blinded.Sign(nil, []byte("hello"), crypto.Hash(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "550439b486899d4ce971eadab46d47d1456608b7d12e49a1acfc453e61e43d265b2e6f882a0d7a816b2e9abe9228177cbc3b12ed89a5765ae2545e2a93bef107"); !bytes.Equal(sig, want) {
+		t.Errorf("signature %x, want %x", sig, want)
+	}
+	twice, err := BlindPublicKey(blindedPub, []byte("second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, "4a5f0147d17dc6fa6f34e4c906325bd91ccd981eb47a56f7e9e93c4f7e004681"); !bytes.Equal(twice, want) {
+		t.Errorf("public key blinded twice %x, want %x", twice, want)
+	}
+}
+
+func TestBlindSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(root.Public()) {
+		t.Error("the root key has the wrong public key")
+	}
+
+	message := []byte("message")
+	key, keyPub := root, pub
+	for _, nonce := range [][]byte{TorNonce(1, 1440), TorNonce(2, 1440), {}} {
+		key = key.Blind(nonce)
+		if keyPub, err = BlindPublicKey(keyPub, nonce); err != nil {
+			t.Fatal(err)
+		}
+		if !keyPub.Equal(key.Public()) {
+			t.Fatalf("nonce %x: the public keys of the blinded keys differ", nonce)
+		}
+		if keyPub.Equal(pub) {
+			t.Errorf("nonce %x: blinding didn't change the key", nonce)
+		}
+		sig, err := key.Sign(rand.Reader, message, crypto.Hash(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ed25519.Verify(keyPub, message, sig) {
+			t.Errorf("nonce %x: signature rejected", nonce)
+		}
+		if ed25519.Verify(pub, message, sig) {
+			t.Errorf("nonce %x: signature accepted by the unblinded key", nonce)
+		}
+
+		parsed, err := ParsePrivateKey(key.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(parsed.Bytes(), key.Bytes()) || !keyPub.Equal(parsed.Public()) {
+			t.Errorf("nonce %x: the parsed key differs", nonce)
+		}
+	}
+
+	if _, err := root.Sign(nil, message, crypto.SHA512); err == nil {
+		t.Error("hashed message signed")
+	}
+	if _, err := BlindPublicKey(pub[:31], nil); err == nil {
+		t.Error("short public key accepted")
+	}
+	if _, err := ParsePrivateKey(make([]byte, PrivateKeySize-1)); err == nil {
+		t.Error("short private key accepted")
+	}
+}
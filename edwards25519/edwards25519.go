@@ -0,0 +1,346 @@
+// Package edwards25519 implements group operations on the twisted Edwards
+// curve -x² + y² = 1 + d x² y² over GF(2^255 - 19), which is birationally
+// equivalent to Curve25519, using the safenum library for constant-time
+// arithmetic.
+//
+// This is the curve used by Ed25519, and its encoding of points follows
+// RFC 8032, section 5.1.2. Scalars are represented as safenum.Nat values,
+// reduced modulo Order.
+package edwards25519
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+)
+
+// PointSize is the size of an encoded point.
+const PointSize = 32
+
+// Point is a point on the curve, in extended coordinates (X : Y : Z : T), with
+// x = X / Z, y = Y / Z, and x y = T / Z.
+//
+// The zero value is not valid, and points should be created with one of the
+// constructors, or with new(Point).Set. Like the types of math/big, methods set
+// their receiver to the result, and return it.
+type Point struct {
+	x, y, z, t *safenum.Nat
+	// scratch holds the temporaries of the in-place operations writing to
+	// this point, allocated on first use.
+	scratch *pointScratch
+}
+
+// NewIdentityPoint returns a new point set to the identity.
+func NewIdentityPoint() *Point {
+	return &Point{
+		x: fe.FromUint64(0),
+		y: fe.FromUint64(1),
+		z: fe.FromUint64(1),
+		t: fe.FromUint64(0),
+	}
+}
+
+var (
+	// the d coefficient of the curve -x² + y² = 1 + d x² y², equal to -121665 / 121666
+	curveD = fe.FromHex("52036cee2b6ffe738cc740797779e89800700a4d4141d8ab75eb4dca135978a3")
+	// 2 d
+	curveD2 = fe.FromHex("2406d9dc56dffce7198e80f2eef3d13000e0149a8283b156ebd69b9426b2f159")
+	// 0 and 1, which must not be modified
+	feZero = fe.FromUint64(0)
+	feOne  = fe.FromUint64(1)
+)
+
+var (
+	generatorX = fe.FromHex("216936d3cd6e53fec0a4e231fdd6dc5c692cc7609525a7b2c9562d608f25d51a")
+	generatorY = fe.FromHex("6666666666666666666666666666666666666666666666666666666666666658")
+)
+
+// NewGeneratorPoint returns a new point set to the canonical generator, whose
+// encoding is 0x58 followed by 31 0x66 bytes.
+func NewGeneratorPoint() *Point {
+	return &Point{
+		x: new(safenum.Nat).SetNat(generatorX),
+		y: new(safenum.Nat).SetNat(generatorY),
+		z: fe.FromUint64(1),
+		t: fe.Mul(generatorX, generatorY),
+	}
+}
+
+// Set sets v = u, and returns v.
+//
+// The coordinates are copied, since the in-place operations, like AddInto,
+// modify them.
+func (v *Point) Set(u *Point) *Point {
+	v.x = new(safenum.Nat).SetNat(u.x)
+	v.y = new(safenum.Nat).SetNat(u.y)
+	v.z = new(safenum.Nat).SetNat(u.z)
+	v.t = new(safenum.Nat).SetNat(u.t)
+	return v
+}
+
+// SetBytes sets v to the point encoded by b, as described in RFC 8032,
+// section 5.1.3, and returns v.
+//
+// Non-canonical encodings of y are rejected. If b doesn't encode a point on the
+// curve, an error is returned, and v is left unchanged.
+func (v *Point) SetBytes(b []byte) (*Point, error) {
+	if len(b) != PointSize {
+		return nil, errors.New("edwards25519: invalid point encoding length")
+	}
+	y, canonical := fe.FromBytes(b)
+	sign := int(b[31] >> 7)
+
+	// x² = (y² - 1) / (d y² + 1), where the denominator can't vanish, since d
+	// is not a square.
+	y2 := fe.Mul(y, y)
+	u := fe.Sub(y2, fe.FromUint64(1))
+	w := fe.Add(fe.Mul(curveD, y2), fe.FromUint64(1))
+	x2 := fe.Mul(u, fe.Invert(w))
+	onCurve := fe.IsSquare(x2)
+	x := fe.Sqrt(fe.Select(x2, fe.FromUint64(0), onCurve))
+	// x = 0 has no negative counterpart.
+	xIsZero := fe.Equal(x, fe.FromUint64(0))
+	x = fe.Select(fe.Neg(x), x, subtle.ConstantTimeEq(int32(fe.IsNegative(x)), int32(sign^1)))
+
+	if canonical&onCurve&^(xIsZero&sign) != 1 {
+		return nil, errors.New("edwards25519: invalid point encoding")
+	}
+	v.x, v.y, v.z, v.t = x, y, fe.FromUint64(1), fe.Mul(x, y)
+	return v, nil
+}
+
+// affine returns the affine coordinates of v.
+func (v *Point) affine() (x, y *safenum.Nat) {
+	zInv := fe.Invert(v.z)
+	return fe.Mul(v.x, zInv), fe.Mul(v.y, zInv)
+}
+
+// Bytes returns the canonical 32 byte encoding of v, as described in RFC 8032,
+// section 5.1.2.
+func (v *Point) Bytes() []byte {
+	x, y := v.affine()
+	out := fe.ToBytes(y)
+	out[31] |= byte(fe.IsNegative(x) << 7)
+	return out
+}
+
+// BytesMontgomery returns the u-coordinate of the point on Curve25519
+// corresponding to v, as used by X25519.
+//
+// The identity is mapped to u = 0.
+func (v *Point) BytesMontgomery() []byte {
+	// u = (1 + y) / (1 - y) = (Z + Y) / (Z - Y)
+	return fe.ToBytes(fe.Mul(fe.Add(v.z, v.y), fe.Invert(fe.Sub(v.z, v.y))))
+}
+
+// SetBytesMontgomery sets v to the point whose y-coordinate corresponds to the
+// u-coordinate of a point on Curve25519, and whose x-coordinate has the given
+// sign, and returns v.
+//
+// The u-coordinate is a 32 byte little-endian value, as used by X25519, whose
+// top bit is ignored. Non-canonical values are rejected, as are values which
+// don't correspond to a point on the curve, in which case v is left unchanged.
+func (v *Point) SetBytesMontgomery(u []byte, sign int) (*Point, error) {
+	if len(u) != PointSize {
+		return nil, errors.New("edwards25519: invalid u-coordinate length")
+	}
+	uFe, canonical := fe.FromBytes(u)
+	if canonical != 1 {
+		return nil, errors.New("edwards25519: non-canonical u-coordinate")
+	}
+	// y = (u - 1) / (u + 1)
+	one := fe.FromUint64(1)
+	y := fe.Mul(fe.Sub(uFe, one), fe.Invert(fe.Add(uFe, one)))
+	b := fe.ToBytes(y)
+	b[31] |= byte(sign&1) << 7
+	return v.SetBytes(b)
+}
+
+// Add sets v = p + q, and returns v.
+func (v *Point) Add(p, q *Point) *Point {
+	// This uses the complete "add-2008-hwcd-3" formulas for a = -1.
+	a := fe.Mul(fe.Sub(p.y, p.x), fe.Sub(q.y, q.x))
+	b := fe.Mul(fe.Add(p.y, p.x), fe.Add(q.y, q.x))
+	c := fe.Mul(fe.Mul(p.t, curveD2), q.t)
+	d := fe.Mul(fe.Add(p.z, p.z), q.z)
+	e := fe.Sub(b, a)
+	f := fe.Sub(d, c)
+	g := fe.Add(d, c)
+	h := fe.Add(b, a)
+	v.x, v.y, v.z, v.t = fe.Mul(e, f), fe.Mul(g, h), fe.Mul(f, g), fe.Mul(e, h)
+	return v
+}
+
+// Negate sets v = -p, and returns v.
+func (v *Point) Negate(p *Point) *Point {
+	v.x, v.y, v.z, v.t = fe.Neg(p.x), new(safenum.Nat).SetNat(p.y), new(safenum.Nat).SetNat(p.z), fe.Neg(p.t)
+	return v
+}
+
+// Subtract sets v = p - q, and returns v.
+func (v *Point) Subtract(p, q *Point) *Point {
+	return v.Add(p, new(Point).Negate(q))
+}
+
+// MultByCofactor sets v = 8 p, and returns v.
+func (v *Point) MultByCofactor(p *Point) *Point {
+	v.Add(p, p)
+	v.Add(v, v)
+	return v.Add(v, v)
+}
+
+// Equal returns 1 if v and u are equal, and 0 otherwise, without leaking which.
+func (v *Point) Equal(u *Point) int {
+	// X1 / Z1 = X2 / Z2 and Y1 / Z1 = Y2 / Z2
+	return fe.Equal(fe.Mul(v.x, u.z), fe.Mul(u.x, v.z)) & fe.Equal(fe.Mul(v.y, u.z), fe.Mul(u.y, v.z))
+}
+
+// selectPoint sets v to a if cond == 1, and to b if cond == 0, without
+// leaking cond.
+func (v *Point) selectPoint(a, b *Point, cond int) *Point {
+	v.x = fe.Select(a.x, b.x, cond)
+	v.y = fe.Select(a.y, b.y, cond)
+	v.z = fe.Select(a.z, b.z, cond)
+	v.t = fe.Select(a.t, b.t, cond)
+	return v
+}
+
+// ScalarMult sets v = s * q, and returns v.
+//
+// The execution time doesn't depend on the value of s.
+func (v *Point) ScalarMult(s *safenum.Nat, q *Point) *Point {
+	scratch := newPointScratch()
+	scalarMult(scratch, s, q)
+	acc := scratch.acc
+	v.x, v.y, v.z, v.t = acc.x, acc.y, acc.z, acc.t
+	return v
+}
+
+// ScalarBaseMult sets v = s * B, where B is the canonical generator, and
+// returns v.
+func (v *Point) ScalarBaseMult(s *safenum.Nat) *Point {
+	return v.ScalarMult(s, NewGeneratorPoint())
+}
+
+// pointScratch holds the temporaries of addInto and scalarMult.
+type pointScratch struct {
+	a, b, c, d, e, f, g, h, u *safenum.Nat
+	// acc, sum, and base are the points of the ladder in scalarMult.
+	acc, sum, base *Point
+	// aBuf and bBuf hold the encodings of the coordinates being selected
+	// between.
+	aBuf, bBuf [32]byte
+}
+
+func newPointScratch() *pointScratch {
+	return &pointScratch{
+		a: new(safenum.Nat), b: new(safenum.Nat), c: new(safenum.Nat),
+		d: new(safenum.Nat), e: new(safenum.Nat), f: new(safenum.Nat),
+		g: new(safenum.Nat), h: new(safenum.Nat), u: new(safenum.Nat),
+		acc:  NewIdentityPoint(),
+		sum:  NewIdentityPoint(),
+		base: NewIdentityPoint(),
+	}
+}
+
+// reuse makes sure that the coordinates of v and its scratch space are
+// allocated, and returns the latter.
+func (v *Point) reuse() *pointScratch {
+	if v.x == nil || v.y == nil || v.z == nil || v.t == nil {
+		v.x, v.y, v.z, v.t = new(safenum.Nat), new(safenum.Nat), new(safenum.Nat), new(safenum.Nat)
+	}
+	if v.scratch == nil {
+		v.scratch = newPointScratch()
+	}
+	return v.scratch
+}
+
+// setInto copies the coordinates of u into those of v, which must be
+// allocated.
+func (v *Point) setInto(u *Point) {
+	v.x.SetNat(u.x)
+	v.y.SetNat(u.y)
+	v.z.SetNat(u.z)
+	v.t.SetNat(u.t)
+}
+
+// addInto sets v = p + q, like Add, but reusing the coordinates of v, and
+// the temporaries in s. v may alias p or q.
+func addInto(s *pointScratch, v, p, q *Point) {
+	fe.SubInto(s.a, p.y, p.x)
+	fe.SubInto(s.u, q.y, q.x)
+	fe.MulInto(s.a, s.a, s.u)
+	fe.AddInto(s.b, p.y, p.x)
+	fe.AddInto(s.u, q.y, q.x)
+	fe.MulInto(s.b, s.b, s.u)
+	fe.MulInto(s.c, p.t, curveD2)
+	fe.MulInto(s.c, s.c, q.t)
+	fe.AddInto(s.d, p.z, p.z)
+	fe.MulInto(s.d, s.d, q.z)
+	fe.SubInto(s.e, s.b, s.a)
+	fe.SubInto(s.f, s.d, s.c)
+	fe.AddInto(s.g, s.d, s.c)
+	fe.AddInto(s.h, s.b, s.a)
+	// p and q are no longer read, so v can now be written to.
+	fe.MulInto(v.x, s.e, s.f)
+	fe.MulInto(v.y, s.g, s.h)
+	fe.MulInto(v.z, s.f, s.g)
+	fe.MulInto(v.t, s.e, s.h)
+}
+
+// selectInto sets v to a if cond == 1, and to b if cond == 0, like
+// selectPoint, but reusing the coordinates of v.
+func selectInto(s *pointScratch, v, a, b *Point, cond int) {
+	fe.SelectInto(v.x, a.x, b.x, cond, s.aBuf[:], s.bBuf[:])
+	fe.SelectInto(v.y, a.y, b.y, cond, s.aBuf[:], s.bBuf[:])
+	fe.SelectInto(v.z, a.z, b.z, cond, s.aBuf[:], s.bBuf[:])
+	fe.SelectInto(v.t, a.t, b.t, cond, s.aBuf[:], s.bBuf[:])
+}
+
+// scalarMult sets s.acc = k * q, with a double-and-add ladder working
+// entirely in the scratch space.
+func scalarMult(s *pointScratch, k *safenum.Nat, q *Point) {
+	kBytes := ScalarBytes(k)
+	s.base.setInto(q)
+	s.acc.x.SetNat(feZero)
+	s.acc.y.SetNat(feOne)
+	s.acc.z.SetNat(feOne)
+	s.acc.t.SetNat(feZero)
+	for i := 8*ScalarSize - 1; i >= 0; i-- {
+		addInto(s, s.acc, s.acc, s.acc)
+		addInto(s, s.sum, s.acc, s.base)
+		selectInto(s, s.acc, s.sum, s.acc, int(kBytes[i/8]>>(i%8))&1)
+	}
+}
+
+// AddInto sets dst = p + q, and returns dst.
+//
+// Unlike Add, this reuses the coordinates of dst, and keeps scratch space in
+// it, so that a loop adding into the same point doesn't allocate new field
+// elements for every result. dst may alias p or q, and may be a new(Point).
+func AddInto(dst, p, q *Point) *Point {
+	addInto(dst.reuse(), dst, p, q)
+	return dst
+}
+
+// ScalarMultInto sets dst = s * q, and returns dst.
+//
+// Like AddInto, this reuses the storage of dst, including the points of the
+// ladder, so repeated multiplications into the same point don't allocate. dst
+// may alias q. The execution time doesn't depend on the value of s.
+func ScalarMultInto(dst *Point, s *safenum.Nat, q *Point) *Point {
+	scratch := dst.reuse()
+	scalarMult(scratch, s, q)
+	dst.setInto(scratch.acc)
+	return dst
+}
+
+// AffineInto sets x and y to the affine coordinates of v, reusing their
+// storage.
+func AffineInto(x, y *safenum.Nat, v *Point) {
+	fe.InvertInto(x, v.z)
+	fe.MulInto(y, v.y, x)
+	fe.MulInto(x, v.x, x)
+}
@@ -0,0 +1,200 @@
+package edwards25519
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestGeneratorEncoding(t *testing.T) {
+	expected := "5866666666666666666666666666666666666666666666666666666666666666"
+	if out := hex.EncodeToString(NewGeneratorPoint().Bytes()); out != expected {
+		t.Errorf("generator encodes to %s, expected %s", out, expected)
+	}
+	if out := hex.EncodeToString(NewGeneratorPoint().BytesMontgomery()); out != hex.EncodeToString(curve25519.Basepoint) {
+		t.Errorf("generator has u-coordinate %s", out)
+	}
+}
+
+func TestPublicKeyAgainstEd25519(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := 0; i < 5; i++ {
+		rand.Read(seed)
+		expected := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		h := sha512.Sum512(seed)
+		s, err := ScalarFromClampedBytes(h[:32])
+		if err != nil {
+			t.Fatal(err)
+		}
+		A := new(Point).ScalarBaseMult(s)
+		if !bytes.Equal(A.Bytes(), expected) {
+			t.Errorf("public key %x, expected %x", A.Bytes(), []byte(expected))
+		}
+		decoded, err := new(Point).SetBytes(expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Equal(A) != 1 {
+			t.Errorf("decoded public key doesn't match")
+		}
+	}
+}
+
+func TestMontgomeryAgainstX25519(t *testing.T) {
+	scalar := make([]byte, ScalarSize)
+	rand.Read(scalar)
+	expected, err := curve25519.X25519(scalar, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := ScalarFromClampedBytes(scalar)
+	if out := new(Point).ScalarBaseMult(s).BytesMontgomery(); !bytes.Equal(out, expected) {
+		t.Errorf("BytesMontgomery() = %x, expected %x", out, expected)
+	}
+}
+
+func TestGroupLaws(t *testing.T) {
+	a := randomScalar(t)
+	b := randomScalar(t)
+	A := new(Point).ScalarBaseMult(a)
+	B := new(Point).ScalarBaseMult(b)
+	sum := new(Point).ScalarBaseMult(new(safenum.Nat).ModAdd(a, b, Order))
+	if new(Point).Add(A, B).Equal(sum) != 1 {
+		t.Errorf("a B + b B != (a + b) B")
+	}
+	if new(Point).Subtract(sum, B).Equal(A) != 1 {
+		t.Errorf("(a + b) B - b B != a B")
+	}
+	if new(Point).Add(A, new(Point).Negate(A)).Equal(NewIdentityPoint()) != 1 {
+		t.Errorf("A - A != 0")
+	}
+	ab := new(safenum.Nat).ModMul(a, b, Order)
+	if new(Point).ScalarMult(b, A).Equal(new(Point).ScalarBaseMult(ab)) != 1 {
+		t.Errorf("b (a B) != (a b) B")
+	}
+	l := new(safenum.Nat).SetBytes(Order.Bytes())
+	if new(Point).ScalarMult(l, NewGeneratorPoint()).Equal(NewIdentityPoint()) != 1 {
+		t.Errorf("l B != 0")
+	}
+}
+
+func TestSetBytesRejects(t *testing.T) {
+	// y = p is not canonical
+	nonCanonical, _ := hex.DecodeString("edffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	if _, err := new(Point).SetBytes(nonCanonical); err == nil {
+		t.Errorf("accepted a non-canonical encoding")
+	}
+	// y = 1 gives x = 0, which can't have its sign bit set
+	negativeZero := make([]byte, PointSize)
+	negativeZero[0] = 1
+	negativeZero[31] = 0x80
+	if _, err := new(Point).SetBytes(negativeZero); err == nil {
+		t.Errorf("accepted x = -0")
+	}
+	// y = 2 isn't on the curve
+	notOnCurve := make([]byte, PointSize)
+	notOnCurve[0] = 2
+	if _, err := new(Point).SetBytes(notOnCurve); err == nil {
+		t.Errorf("accepted a point not on the curve")
+	}
+}
+
+func TestScalarFromCanonicalBytes(t *testing.T) {
+	s := randomScalar(t)
+	decoded, err := ScalarFromCanonicalBytes(ScalarBytes(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Cmp(s) != 0 {
+		t.Errorf("scalar round trip failed")
+	}
+	orderBytes := Order.Bytes()
+	if _, err := ScalarFromCanonicalBytes(reverse(orderBytes[len(orderBytes)-ScalarSize:])); err == nil {
+		t.Errorf("accepted an unreduced scalar")
+	}
+}
+
+func randomScalar(t *testing.T) *safenum.Nat {
+	buf := make([]byte, 64)
+	rand.Read(buf)
+	s, err := ScalarFromUniformBytes(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMontgomeryRoundTrip(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		P := new(Point).ScalarBaseMult(randomScalar(t))
+		sign := int(P.Bytes()[31] >> 7)
+		decoded, err := new(Point).SetBytesMontgomery(P.BytesMontgomery(), sign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Equal(P) != 1 {
+			t.Errorf("SetBytesMontgomery(BytesMontgomery()) doesn't round trip")
+		}
+	}
+}
+
+func TestInPlaceOperations(t *testing.T) {
+	A := new(Point).ScalarBaseMult(randomScalar(t))
+	B := new(Point).ScalarBaseMult(randomScalar(t))
+	G := NewGeneratorPoint()
+	aBytes := A.Bytes()
+
+	dst := new(Point)
+	if AddInto(dst, A, B).Equal(new(Point).Add(A, B)) != 1 {
+		t.Errorf("AddInto(A, B) != A + B")
+	}
+	// Reusing dst, with aliased inputs, mustn't change A or B.
+	acc := new(Point).Set(A)
+	for i := 0; i < 3; i++ {
+		AddInto(acc, acc, acc)
+	}
+	if acc.Equal(new(Point).ScalarMult(new(safenum.Nat).SetUint64(8), A)) != 1 {
+		t.Errorf("doubling A in place 3 times != 8 A")
+	}
+	if !bytes.Equal(A.Bytes(), aBytes) {
+		t.Errorf("in place operations on a copy modified A")
+	}
+
+	s := randomScalar(t)
+	if ScalarMultInto(dst, s, B).Equal(new(Point).ScalarMult(s, B)) != 1 {
+		t.Errorf("ScalarMultInto(s, B) != s B")
+	}
+	neg := new(Point).Negate(G)
+	ScalarMultInto(neg, s, neg)
+	if neg.Equal(new(Point).ScalarMult(s, new(Point).Negate(G))) != 1 {
+		t.Errorf("ScalarMultInto(s, -G), in place, != s (-G)")
+	}
+	if G.Equal(NewGeneratorPoint()) != 1 || !bytes.Equal(NewGeneratorPoint().Bytes(), G.Bytes()) {
+		t.Errorf("in place operations modified the generator")
+	}
+
+	x, y := new(safenum.Nat), new(safenum.Nat)
+	AffineInto(x, y, dst)
+	expectedX, expectedY := dst.affine()
+	if fe.Equal(x, expectedX) != 1 || fe.Equal(y, expectedY) != 1 {
+		t.Errorf("AffineInto doesn't match the affine coordinates")
+	}
+}
+
+func TestInPlaceAllocations(t *testing.T) {
+	A := new(Point).ScalarBaseMult(randomScalar(t))
+	dst := new(Point)
+	AddInto(dst, A, A)
+	into := testing.AllocsPerRun(100, func() { AddInto(dst, dst, A) })
+	fresh := testing.AllocsPerRun(100, func() { dst.Add(dst, A) })
+	if into >= fresh {
+		t.Errorf("AddInto makes %v allocations, Add only %v", into, fresh)
+	}
+}
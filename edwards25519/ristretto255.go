@@ -0,0 +1,170 @@
+package edwards25519
+
+import (
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements the ristretto255 encoding of RFC 9496, which builds a
+// group of prime order from the points of the curve, by identifying points
+// which differ by an element of order 4. Each ristretto255 element is
+// represented by one of the points of its equivalence class, and only the
+// methods of this file treat points as ristretto255 elements.
+
+var (
+	// sqrt(-1)
+	sqrtM1 = fe.FromHex("2b8324804fc1df0b2b4d00993dfbd7a72f431806ad2fe478c4ee1b274a0ea0b0")
+	// sqrt(a d - 1)
+	sqrtADMinusOne = fe.FromHex("376931bf2b8348ac0f3cfcc931f5d1fdaf9d8e0c1b7854bd7e97f6a0497b2e1b")
+	// 1 / sqrt(a - d)
+	invSqrtAMinusD = fe.FromHex("786c8905cfaffca216c27b91fe01d8409d2f16175a4172be99c8fdaa805d40ea")
+	// 1 - d²
+	oneMinusDSq = fe.FromHex("029072a8b2b3e0d79994abddbe70dfe42c81a138cd5e350fe27c09c1945fc176")
+	// (d - 1)²
+	dMinusOneSq = fe.FromHex("5968b37af66c22414cdcd32f529b4eebd29e4a2cb01e199931ad5aaa44ed4d20")
+	// (p - 5) / 8, used to calculate square roots
+	pMinus5Over8 = new(safenum.Nat).SetBytes([]byte{
+		0x0f, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfd,
+	})
+)
+
+// RistrettoSize is the size of an encoded ristretto255 element.
+const RistrettoSize = 32
+
+// feAbs returns the non-negative one of x and -x.
+func feAbs(x *safenum.Nat) *safenum.Nat {
+	return fe.Select(fe.Neg(x), x, fe.IsNegative(x))
+}
+
+// feSqrtRatio returns the non-negative square root of u / v, along with 1 if
+// u / v is a square, or 0 otherwise, in which case the square root of
+// sqrt(-1) * u / v is returned instead.
+//
+// This is SQRT_RATIO_M1 in RFC 9496, section 4.2.
+func feSqrtRatio(u, v *safenum.Nat) (*safenum.Nat, int) {
+	v3 := fe.Mul(fe.Mul(v, v), v)
+	v7 := fe.Mul(fe.Mul(v3, v3), v)
+	r := fe.Mul(fe.Mul(u, v3), new(safenum.Nat).Exp(fe.Mul(u, v7), pMinus5Over8, fe.P))
+	check := fe.Mul(v, fe.Mul(r, r))
+
+	negU := fe.Neg(u)
+	correctSignSqrt := fe.Equal(check, u)
+	flippedSignSqrt := fe.Equal(check, negU)
+	flippedSignSqrtI := fe.Equal(check, fe.Mul(negU, sqrtM1))
+
+	r = fe.Select(fe.Mul(sqrtM1, r), r, flippedSignSqrt|flippedSignSqrtI)
+	return feAbs(r), correctSignSqrt | flippedSignSqrt
+}
+
+// SetRistrettoBytes sets v to a point representing the ristretto255 element
+// encoded by b, as described in RFC 9496, section 4.3.1, and returns v.
+//
+// Non-canonical encodings are rejected, in which case v is left unchanged.
+func (v *Point) SetRistrettoBytes(b []byte) (*Point, error) {
+	if len(b) != RistrettoSize {
+		return nil, errors.New("edwards25519: invalid ristretto255 encoding length")
+	}
+	s, canonical := fe.FromBytes(b)
+	// fe.FromBytes ignores the top bit, which must be 0.
+	canonical &= int(b[31]>>7) ^ 1
+	one := fe.FromUint64(1)
+
+	ss := fe.Mul(s, s)
+	u1 := fe.Sub(one, ss)
+	u2 := fe.Add(one, ss)
+	u2Sqr := fe.Mul(u2, u2)
+	// v = -(d u1²) - u2²
+	w := fe.Sub(fe.Neg(fe.Mul(curveD, fe.Mul(u1, u1))), u2Sqr)
+	invSqrt, wasSquare := feSqrtRatio(one, fe.Mul(w, u2Sqr))
+
+	denX := fe.Mul(invSqrt, u2)
+	denY := fe.Mul(fe.Mul(invSqrt, denX), w)
+	x := feAbs(fe.Mul(fe.Add(s, s), denX))
+	y := fe.Mul(u1, denY)
+	t := fe.Mul(x, y)
+
+	ok := canonical & (fe.IsNegative(s) ^ 1) & wasSquare & (fe.IsNegative(t) ^ 1) & (fe.Equal(y, fe.FromUint64(0)) ^ 1)
+	if ok != 1 {
+		return nil, errors.New("edwards25519: invalid ristretto255 encoding")
+	}
+	v.x, v.y, v.z, v.t = x, y, one, t
+	return v, nil
+}
+
+// RistrettoBytes returns the canonical ristretto255 encoding of the element
+// represented by v, as described in RFC 9496, section 4.3.2.
+func (v *Point) RistrettoBytes() []byte {
+	u1 := fe.Mul(fe.Add(v.z, v.y), fe.Sub(v.z, v.y))
+	u2 := fe.Mul(v.x, v.y)
+	invSqrt, _ := feSqrtRatio(fe.FromUint64(1), fe.Mul(u1, fe.Mul(u2, u2)))
+	den1 := fe.Mul(invSqrt, u1)
+	den2 := fe.Mul(invSqrt, u2)
+	zInv := fe.Mul(fe.Mul(den1, den2), v.t)
+
+	ix0 := fe.Mul(v.x, sqrtM1)
+	iy0 := fe.Mul(v.y, sqrtM1)
+	enchantedDenominator := fe.Mul(den1, invSqrtAMinusD)
+	rotate := fe.IsNegative(fe.Mul(v.t, zInv))
+	x := fe.Select(iy0, v.x, rotate)
+	y := fe.Select(ix0, v.y, rotate)
+	denInv := fe.Select(enchantedDenominator, den2, rotate)
+
+	y = fe.Select(fe.Neg(y), y, fe.IsNegative(fe.Mul(x, zInv)))
+	s := feAbs(fe.Mul(denInv, fe.Sub(v.z, y)))
+	return fe.ToBytes(s)
+}
+
+// RistrettoEqual returns 1 if v and u represent the same ristretto255 element,
+// and 0 otherwise, without leaking which.
+func (v *Point) RistrettoEqual(u *Point) int {
+	// x1 y2 = y1 x2, or y1 y2 = x1 x2
+	return fe.Equal(fe.Mul(v.x, u.y), fe.Mul(v.y, u.x)) | fe.Equal(fe.Mul(v.y, u.y), fe.Mul(v.x, u.x))
+}
+
+// ristrettoMap maps a field element to a point, as described in RFC 9496,
+// section 4.3.4.
+func ristrettoMap(r0 *safenum.Nat) *Point {
+	one := fe.FromUint64(1)
+	minusOne := fe.Neg(one)
+
+	r := fe.Mul(sqrtM1, fe.Mul(r0, r0))
+	u := fe.Mul(fe.Add(r, one), oneMinusDSq)
+	w := fe.Mul(fe.Sub(minusOne, fe.Mul(r, curveD)), fe.Add(r, curveD))
+	s, wasSquare := feSqrtRatio(u, w)
+	sPrime := fe.Neg(feAbs(fe.Mul(s, r0)))
+	s = fe.Select(s, sPrime, wasSquare)
+	c := fe.Select(minusOne, r, wasSquare)
+
+	n := fe.Sub(fe.Mul(fe.Mul(c, fe.Sub(r, one)), dMinusOneSq), w)
+	ss := fe.Mul(s, s)
+	w0 := fe.Mul(fe.Add(s, s), w)
+	w1 := fe.Mul(n, sqrtADMinusOne)
+	w2 := fe.Sub(one, ss)
+	w3 := fe.Add(one, ss)
+	return &Point{
+		x: fe.Mul(w0, w3),
+		y: fe.Mul(w2, w1),
+		z: fe.Mul(w1, w3),
+		t: fe.Mul(w0, w2),
+	}
+}
+
+// SetRistrettoUniformBytes sets v to a point representing the ristretto255
+// element derived from 64 uniformly random bytes, using the one-way map of
+// RFC 9496, section 4.3.4, and returns v.
+//
+// The output is uniformly distributed, and nobody knows its discrete
+// logarithm, which makes this suitable for hashing to the group.
+func (v *Point) SetRistrettoUniformBytes(b []byte) (*Point, error) {
+	if len(b) != 64 {
+		return nil, errors.New("edwards25519: invalid uniform bytes length")
+	}
+	r0, _ := fe.FromBytes(b[:32])
+	r1, _ := fe.FromBytes(b[32:])
+	return v.Add(ristrettoMap(r0), ristrettoMap(r1)), nil
+}
@@ -0,0 +1,88 @@
+package edwards25519
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/internal/fe"
+	"github.com/cronokirby/safenum"
+)
+
+func TestRistrettoGeneratorMultiples(t *testing.T) {
+	// See RFC 9496, appendix A.1.
+	expected := []string{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"e2f2ae0a6abc4e71a884a961c500515f58e30b6aa582dd8db6a65945e08d2d76",
+		"6a493210f7499cd17fecb510ae0cea23a110e8d5b901f8acadd3095c73a3b919",
+		"94741f5d5d52755ece4f23f044ee27d5d1ea1e2bd196b462166b16152a9d0259",
+	}
+	P := NewIdentityPoint()
+	for i, e := range expected {
+		if out := hex.EncodeToString(P.RistrettoBytes()); out != e {
+			t.Errorf("%d B encodes to %s, expected %s", i, out, e)
+		}
+		decoded, err := new(Point).SetRistrettoBytes(P.RistrettoBytes())
+		if err != nil {
+			t.Fatalf("%d B: %v", i, err)
+		}
+		if decoded.RistrettoEqual(P) != 1 {
+			t.Errorf("%d B doesn't round trip", i)
+		}
+		P.Add(P, NewGeneratorPoint())
+	}
+}
+
+func TestRistrettoBadEncodings(t *testing.T) {
+	// See RFC 9496, appendix A.2.
+	bad := []string{
+		// non-canonical field encodings
+		"00ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// negative field elements
+		"0100000000000000000000000000000000000000000000000000000000000000",
+		"01ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+		// non-square x²
+		"26948d35ca62e643e26a83177332e6b6afeb9d08e4268b650f1f5bbd8d81d371",
+		// negative xy value
+		"3eb858e78f5a7254d8c9731174a94f76755fd3941c0ac93735c07ba14579630e",
+		// s = -1, which causes y = 0
+		"ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f",
+	}
+	for _, b := range bad {
+		data, _ := hex.DecodeString(b)
+		if _, err := new(Point).SetRistrettoBytes(data); err == nil {
+			t.Errorf("accepted invalid encoding %s", b)
+		}
+	}
+}
+
+func TestRistrettoUniformBytes(t *testing.T) {
+	// See RFC 9496, appendix A.3.
+	h := sha512.Sum512([]byte("Ristretto is traditionally a short shot of espresso coffee"))
+	P, err := new(Point).SetRistrettoUniformBytes(h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "3066f82a1a747d45120d1740f14358531a8f04bbffe6a819f86dfe50f44a0a46"
+	if out := hex.EncodeToString(P.RistrettoBytes()); out != expected {
+		t.Errorf("SetRistrettoUniformBytes() = %s, expected %s", out, expected)
+	}
+}
+
+func TestRistrettoEquivalence(t *testing.T) {
+	// Adding a point of order 4 doesn't change the ristretto255 element.
+	torsion := &Point{x: sqrtM1, y: fe.FromUint64(0), z: fe.FromUint64(1), t: fe.FromUint64(0)}
+	s := new(safenum.Nat).SetUint64(12345)
+	P := new(Point).ScalarBaseMult(s)
+	Q := new(Point).Add(P, torsion)
+	if Q.Equal(P) == 1 {
+		t.Fatal("torsion point is the identity")
+	}
+	if Q.RistrettoEqual(P) != 1 {
+		t.Errorf("equivalent points aren't equal as ristretto255 elements")
+	}
+	if hex.EncodeToString(Q.RistrettoBytes()) != hex.EncodeToString(P.RistrettoBytes()) {
+		t.Errorf("equivalent points have different encodings")
+	}
+}
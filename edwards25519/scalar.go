@@ -0,0 +1,77 @@
+package edwards25519
+
+import (
+	"errors"
+
+	"github.com/cronokirby/safenum"
+)
+
+// ScalarSize is the size of an encoded scalar.
+const ScalarSize = 32
+
+// Order is the order of the prime order subgroup of the curve, which is
+// l = 2^252 + 27742317777372353535851937790883648493.
+var Order = safenum.ModulusFromBytes([]byte{
+	0x10, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x14, 0xde, 0xf9, 0xde, 0xa2, 0xf7, 0x9c, 0xd6,
+	0x58, 0x12, 0x63, 0x1a, 0x5c, 0xf5, 0xd3, 0xed,
+})
+
+// reverse returns a reversed copy of b, converting between little-endian and
+// big-endian encodings.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+// ScalarFromUniformBytes returns the little-endian integer encoded by b,
+// reduced modulo the order. The input must be 64 bytes long, as produced by
+// SHA-512 in Ed25519, so that the output is uniformly distributed.
+func ScalarFromUniformBytes(b []byte) (*safenum.Nat, error) {
+	if len(b) != 64 {
+		return nil, errors.New("edwards25519: invalid uniform scalar length")
+	}
+	s := new(safenum.Nat).SetBytes(reverse(b))
+	return s.Mod(s, Order), nil
+}
+
+// ScalarFromCanonicalBytes decodes a 32 byte little-endian scalar, returning
+// an error if it isn't reduced modulo the order.
+func ScalarFromCanonicalBytes(b []byte) (*safenum.Nat, error) {
+	if len(b) != ScalarSize {
+		return nil, errors.New("edwards25519: invalid scalar length")
+	}
+	s := new(safenum.Nat).SetBytes(reverse(b))
+	if s.CmpMod(Order) != -1 {
+		return nil, errors.New("edwards25519: scalar is not reduced")
+	}
+	return s.Mod(s, Order), nil
+}
+
+// ScalarFromClampedBytes decodes a 32 byte secret scalar, clamping it as
+// described in RFC 7748 and RFC 8032, and reducing it modulo the order.
+//
+// Clamping and reducing the scalar doesn't change its product with points of
+// the prime order subgroup.
+func ScalarFromClampedBytes(b []byte) (*safenum.Nat, error) {
+	if len(b) != ScalarSize {
+		return nil, errors.New("edwards25519: invalid scalar length")
+	}
+	clamped := make([]byte, ScalarSize)
+	copy(clamped, b)
+	clamped[0] &= 248
+	clamped[31] &= 63
+	clamped[31] |= 64
+	s := new(safenum.Nat).SetBytes(reverse(clamped))
+	return s.Mod(s, Order), nil
+}
+
+// ScalarBytes returns the 32 byte little-endian encoding of a scalar.
+func ScalarBytes(s *safenum.Nat) []byte {
+	be := new(safenum.Nat).Mod(s, Order).Bytes()
+	return reverse(be[len(be)-ScalarSize:])
+}
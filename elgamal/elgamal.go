@@ -0,0 +1,188 @@
+// Package elgamal implements ElGamal encryption over the safe prime groups of
+// the ffdh package, along with its hashed variant, DHIES.
+//
+// Keys are ffdh keys, and all the operations on secret values, including the
+// encoding of messages into the group, are done in constant-time.
+//
+// Plain ElGamal ciphertexts are malleable, and only secure against passive
+// attackers. Unless the homomorphic properties of the scheme are needed,
+// EncryptHashed should be used instead.
+package elgamal
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/chacha20poly1305"
+	"github.com/cronokirby/ctcrypto/ffdh"
+	"github.com/cronokirby/ctcrypto/hkdf"
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	errInvalidMessage    = errors.New("elgamal: message out of range")
+	errInvalidCiphertext = errors.New("elgamal: invalid ciphertext")
+)
+
+// params holds the values derived from the prime of a group.
+type params struct {
+	p *safenum.Modulus
+	// q = (p - 1) / 2, the order of the subgroup, and the largest message.
+	q       *safenum.Nat
+	byteLen int
+}
+
+func newParams(g *ffdh.Group) *params {
+	prime := g.Prime()
+	return &params{
+		p:       safenum.ModulusFromBytes(prime),
+		q:       new(safenum.Nat).SetBytes(g.Order()),
+		byteLen: len(prime),
+	}
+}
+
+// bytes returns the encoding of x, padded to the length of the prime.
+func (pp *params) bytes(x *safenum.Nat) []byte {
+	b := x.Bytes()
+	return b[len(b)-pp.byteLen:]
+}
+
+// encode maps a message 0 < m <= q to an element of the subgroup of order q,
+// which is m if m is a quadratic residue, and p - m otherwise.
+func (pp *params) encode(m *safenum.Nat) *safenum.Nat {
+	one := new(safenum.Nat).SetUint64(1)
+	// By Euler's criterion, m is a quadratic residue if m^q = 1.
+	residue := subtle.ConstantTimeEq(int32(new(safenum.Nat).Exp(m, pp.q, pp.p).Cmp(one)), 0)
+	out := pp.bytes(new(safenum.Nat).ModSub(new(safenum.Nat), m, pp.p))
+	subtle.ConstantTimeCopy(residue, out, pp.bytes(new(safenum.Nat).Mod(m, pp.p)))
+	return new(safenum.Nat).SetBytes(out)
+}
+
+// decode inverts encode, returning whichever of v and p - v is at most q.
+func (pp *params) decode(v *safenum.Nat) []byte {
+	above := subtle.ConstantTimeEq(int32(v.Cmp(pp.q)), 1)
+	out := pp.bytes(v)
+	subtle.ConstantTimeCopy(above, out, pp.bytes(new(safenum.Nat).ModSub(new(safenum.Nat), v, pp.p)))
+	return out
+}
+
+// Encrypt encrypts a message to pub, reading randomness from rand, or from the
+// health-tested Reader of the rand package of this module if rand is nil.
+//
+// The message is the big-endian encoding of an integer 0 < m <= q, where q is
+// the order of the subgroup, which holds any non-zero message shorter than the
+// prime. The ciphertext is the concatenation of C1 = g^r and C2 = M * y^r,
+// where M is the encoding of m in the subgroup, each as long as the prime.
+func Encrypt(rand io.Reader, pub *ffdh.PublicKey, message []byte) ([]byte, error) {
+	pp := newParams(pub.Group())
+	if len(message) > pp.byteLen {
+		return nil, errInvalidMessage
+	}
+	m := new(safenum.Nat).SetBytes(message[:len(message):len(message)])
+	if m.EqZero() || m.Cmp(pp.q) == 1 {
+		return nil, errInvalidMessage
+	}
+	eph, err := pub.Group().GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := eph.DH(pub)
+	if err != nil {
+		return nil, err
+	}
+	s := new(safenum.Nat).SetBytes(shared)
+	c2 := new(safenum.Nat).ModMul(pp.encode(m), s, pp.p)
+	return append(eph.PublicKey().Bytes(), pp.bytes(c2)...), nil
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt, and returns the message,
+// padded with zeros to the length of the prime.
+func Decrypt(priv *ffdh.PrivateKey, ciphertext []byte) ([]byte, error) {
+	pp := newParams(priv.Group())
+	if len(ciphertext) != 2*pp.byteLen {
+		return nil, errInvalidCiphertext
+	}
+	c1, err := priv.Group().NewPublicKey(ciphertext[:pp.byteLen])
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+	c2 := new(safenum.Nat).SetBytes(ciphertext[pp.byteLen:len(ciphertext):len(ciphertext)])
+	if c2.EqZero() || c2.CmpMod(pp.p) != -1 {
+		return nil, errInvalidCiphertext
+	}
+	shared, err := priv.DH(c1)
+	if err != nil {
+		return nil, err
+	}
+	s := new(safenum.Nat).SetBytes(shared)
+	sInv := new(safenum.Nat).ModInverse(s, pp.p)
+	return pp.decode(new(safenum.Nat).ModMul(c2, sInv, pp.p)), nil
+}
+
+// hashedInfo is the HKDF info string of the hashed variant.
+const hashedInfo = "ctcrypto elgamal DHIES"
+
+// hashedKey derives the AEAD key of the hashed variant from the ephemeral
+// public key and the shared secret.
+func hashedKey(ephemeral, shared []byte) ([]byte, error) {
+	return hkdf.Key(sha256.New, shared, ephemeral, []byte(hashedInfo), chacha20poly1305.KeySize)
+}
+
+// EncryptHashed encrypts a message of any length to pub, using DHIES: an
+// ephemeral Diffie-Hellman exchange with pub, whose shared secret is hashed
+// with HKDF-SHA256 into a key for ChaCha20-Poly1305.
+//
+// The ciphertext is the ephemeral public key, followed by the sealed message.
+// The additional data is authenticated, but not encrypted.
+func EncryptHashed(rand io.Reader, pub *ffdh.PublicKey, message, additionalData []byte) ([]byte, error) {
+	eph, err := pub.Group().GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := eph.DH(pub)
+	if err != nil {
+		return nil, err
+	}
+	ephemeral := eph.PublicKey().Bytes()
+	key, err := hashedKey(ephemeral, shared)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	// Every key is used once, so the nonce can be fixed.
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	return aead.Seal(ephemeral, nonce, message, additionalData), nil
+}
+
+// DecryptHashed decrypts a ciphertext produced by EncryptHashed, with the
+// same additional data.
+func DecryptHashed(priv *ffdh.PrivateKey, ciphertext, additionalData []byte) ([]byte, error) {
+	byteLen := len(priv.Group().Prime())
+	if len(ciphertext) < byteLen+chacha20poly1305.Overhead {
+		return nil, errInvalidCiphertext
+	}
+	ephemeral := ciphertext[:byteLen]
+	eph, err := priv.Group().NewPublicKey(ephemeral)
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+	shared, err := priv.DH(eph)
+	if err != nil {
+		return nil, err
+	}
+	key, err := hashedKey(ephemeral, shared)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	return aead.Open(nil, nonce, ciphertext[byteLen:], additionalData)
+}
@@ -0,0 +1,143 @@
+package elgamal
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ffdh"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	g := ffdh.FFDHE2048()
+	priv, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := new(big.Int).SetBytes(g.Prime())
+	q := new(big.Int).Rsh(p, 1)
+	byteLen := len(g.Prime())
+
+	messages := [][]byte{{1}, {2}, {3}, q.Bytes()}
+	for i := 0; i < 8; i++ {
+		m := make([]byte, byteLen-1)
+		rand.Read(m)
+		messages = append(messages, m)
+	}
+	for _, m := range messages {
+		ciphertext, err := Encrypt(rand.Reader, priv.PublicKey(), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != 2*byteLen {
+			t.Errorf("ciphertext is %d bytes", len(ciphertext))
+		}
+		out, err := Decrypt(priv, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if new(big.Int).SetBytes(out).Cmp(new(big.Int).SetBytes(m)) != 0 || len(out) != byteLen {
+			t.Errorf("Decrypt(Encrypt(%x)) = %x", m, out)
+		}
+		// C2 must be in the subgroup, whatever the message.
+		c2 := new(big.Int).SetBytes(ciphertext[byteLen:])
+		if new(big.Int).Exp(c2, q, p).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("C2 isn't in the subgroup, for %x", m)
+		}
+	}
+
+	for name, m := range map[string][]byte{
+		"0":     {0},
+		"q + 1": new(big.Int).Add(q, big.NewInt(1)).Bytes(),
+		"long":  append([]byte{1}, make([]byte, byteLen)...),
+	} {
+		if _, err := Encrypt(rand.Reader, priv.PublicKey(), m); err == nil {
+			t.Errorf("message %s accepted", name)
+		}
+	}
+
+	ciphertext, err := Encrypt(rand.Reader, priv.PublicKey(), []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range map[string][]byte{
+		"short":   ciphertext[:len(ciphertext)-1],
+		"C2 = 0":  append(append([]byte{}, ciphertext[:byteLen]...), make([]byte, byteLen)...),
+		"C2 = p":  append(append([]byte{}, ciphertext[:byteLen]...), g.Prime()...),
+		"C1 = 1":  append(big.NewInt(1).FillBytes(make([]byte, byteLen)), ciphertext[byteLen:]...),
+		"unbound": append(append([]byte{}, ciphertext[byteLen:]...), ciphertext[:byteLen]...),
+	} {
+		out, err := Decrypt(priv, c)
+		if err == nil && bytes.HasSuffix(out, []byte("message")) {
+			t.Errorf("%s: decrypted", name)
+		}
+	}
+}
+
+func TestHashed(t *testing.T) {
+	g := ffdh.FFDHE2048()
+	priv, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("a message longer than the prime would allow, if it came to that")
+	ad := []byte("context")
+	ciphertext, err := EncryptHashed(rand.Reader, priv.PublicKey(), message, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecryptHashed(priv, ciphertext, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, message) {
+		t.Errorf("got %q", out)
+	}
+	if _, err := DecryptHashed(priv, ciphertext, []byte("other")); err == nil {
+		t.Error("decrypted with other additional data")
+	}
+	if _, err := DecryptHashed(other, ciphertext, ad); err == nil {
+		t.Error("decrypted with another key")
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := DecryptHashed(priv, ciphertext, ad); err == nil {
+		t.Error("decrypted a modified ciphertext")
+	}
+	if _, err := DecryptHashed(priv, ciphertext[:10], ad); err == nil {
+		t.Error("decrypted a short ciphertext")
+	}
+}
+
+// oddPrime is a safe prime of 2052 bits, whose 257 bytes aren't a whole number
+// of limbs.
+const oddPrime = "0e8f25cc9bc6819ce2a97b74dcf8245590c59eba549afd7db73a42ac6037fedf75fb7e4468860bdd6e58780f81d00116466d3bf8d6dd42cf2ee7ee660651b18f54d3084c77a0e7247e4265de418f7d32ac39a56e44a1e3f0b32a40eff1ce8a01da595f21446037596831783c23c8b1d16d7dbb513001a6935baa16106edfeaaf08a330b828db8dbfd9a0c28abca6621385174d1345ce06e7aba99856ab7507544994092fcf87eeb0abf411e37a845ba11e58d9e7a6ab660606bb6e56f787d9f4df145939da371823fa3d84757f01d64faeca2da96af81ce7b83424785360bcf33a67dde66a670a4c7157d1fb1f49a5013d12bef050fa8926448d5bcd0d4e09d1d7"
+
+func TestDecryptKeepsSpareCapacity(t *testing.T) {
+	p, _ := hex.DecodeString(oddPrime)
+	g, err := ffdh.NewGroup(p, []byte{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := Encrypt(rand.Reader, priv.PublicKey(), []byte("message"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := append(append([]byte{}, ciphertext...), bytes.Repeat([]byte{0xaa}, 16)...)
+	out, err := Decrypt(priv, buf[:len(ciphertext)])
+	if err != nil || !bytes.HasSuffix(out, []byte("message")) {
+		t.Fatalf("Decrypt failed: %v", err)
+	}
+	if !bytes.Equal(buf[len(ciphertext):], bytes.Repeat([]byte{0xaa}, 16)) {
+		t.Errorf("Decrypt modified the spare capacity of the ciphertext: %x", buf[len(ciphertext):])
+	}
+}
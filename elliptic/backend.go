@@ -0,0 +1,163 @@
+package elliptic
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"sync/atomic"
+
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/safenum"
+)
+
+// The names of the implementations of P-256, which can be selected with
+// SetP256Backend, or the CTCRYPTO_P256_BACKEND environment variable.
+const (
+	// BackendGo is the portable implementation, with 32-bit limbs, available
+	// on every platform.
+	BackendGo = "go"
+	// BackendAssembly is the 64-bit assembly implementation, available on
+	// amd64 and arm64. There's no variant using NEON on arm64.
+	BackendAssembly = "asm"
+	// BackendADX is the assembly implementation, multiplying with the MULX,
+	// ADCX, and ADOX instructions, available on amd64 CPUs with BMI2 and ADX.
+	BackendADX = "adx"
+)
+
+// p256Preference lists the backends, from the fastest to the slowest.
+//
+// Variants using instruction set extensions go first, and are only registered
+// by initP256Arch when the CPU supports them.
+var p256Preference = []string{BackendADX, BackendAssembly, BackendGo}
+
+var (
+	// p256 is the value returned by P256, which always stays the same, and
+	// forwards the operations to the selected backend.
+	p256 p256Dispatch
+
+	p256Backends = map[string]Curve{}
+	// p256Selected holds a *p256Backend.
+	p256Selected atomic.Value
+)
+
+type p256Backend struct {
+	name  string
+	curve Curve
+}
+
+// registerP256Backend makes an implementation available, before the
+// selection of the backend.
+func registerP256Backend(name string, curve Curve) {
+	p256Backends[name] = curve
+}
+
+// storeP256Backend makes curve the backend of P256. Backends sharing their code,
+// like the assembly ones, implement activate, to switch between their
+// instructions.
+func storeP256Backend(name string, curve Curve) {
+	if a, ok := curve.(interface{ activate() }); ok {
+		a.activate()
+	}
+	p256Selected.Store(&p256Backend{name, curve})
+}
+
+// selectP256Backend selects the backend named by the CTCRYPTO_P256_BACKEND
+// environment variable, if it's set and available, and the fastest one
+// otherwise.
+func selectP256Backend() {
+	if name := os.Getenv("CTCRYPTO_P256_BACKEND"); name != "" {
+		if curve, ok := p256Backends[name]; ok {
+			storeP256Backend(name, curve)
+			return
+		}
+	}
+	for _, name := range p256Preference {
+		if curve, ok := p256Backends[name]; ok {
+			storeP256Backend(name, curve)
+			return
+		}
+	}
+}
+
+// P256Backend returns the name of the implementation of P-256 in use.
+func P256Backend() string {
+	initonce.Do(initAll)
+	return p256Selected.Load().(*p256Backend).name
+}
+
+// P256Backends returns the names of the implementations of P-256 available on
+// this platform, from the fastest to the slowest.
+func P256Backends() []string {
+	initonce.Do(initAll)
+	var names []string
+	for _, name := range p256Preference {
+		if _, ok := p256Backends[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SetP256Backend selects the implementation of P-256 used by the value that
+// P256 returns, which is mostly useful for testing an implementation against
+// the others. Every backend is constant-time.
+//
+// SetP256Backend is meant to be called before P256 is used concurrently:
+// operations running while the backend changes may use either of the two.
+//
+// An error is returned if the backend isn't available on this platform.
+func SetP256Backend(name string) error {
+	initonce.Do(initAll)
+	curve, ok := p256Backends[name]
+	if !ok {
+		return errors.New("elliptic: unavailable P-256 backend " + name)
+	}
+	storeP256Backend(name, curve)
+	return nil
+}
+
+// p256Dispatch implements P-256 with the selected backend.
+type p256Dispatch struct {
+	*CurveParams
+}
+
+func (p256Dispatch) backend() Curve {
+	return p256Selected.Load().(*p256Backend).curve
+}
+
+func (curve p256Dispatch) Params() *CurveParams {
+	return curve.CurveParams
+}
+
+func (curve p256Dispatch) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
+	return curve.backend().ScalarMult(x, y, k)
+}
+
+func (curve p256Dispatch) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
+	return curve.backend().ScalarBaseMult(k)
+}
+
+// Inverse returns the inverse of k modulo N, as used by ecdsa.
+func (curve p256Dispatch) Inverse(k *big.Int) *big.Int {
+	if in, ok := curve.backend().(interface{ Inverse(*big.Int) *big.Int }); ok {
+		return in.Inverse(k)
+	}
+	kNat := new(safenum.Nat).SetBytes(k.Bytes())
+	kNat.Mod(kNat, curve.N)
+	return new(big.Int).SetBytes(kNat.ModInverse(kNat, curve.N).Bytes())
+}
+
+// CombinedMult returns baseScalar*G + scalar*(x,y), as used by ecdsa.
+func (curve p256Dispatch) CombinedMult(x, y *big.Int, baseScalar, scalar []byte) (*big.Int, *big.Int) {
+	backend := curve.backend()
+	if opt, ok := backend.(interface {
+		CombinedMult(x, y *big.Int, baseScalar, scalar []byte) (*big.Int, *big.Int)
+	}); ok {
+		return opt.CombinedMult(x, y, baseScalar, scalar)
+	}
+	x1, y1 := backend.ScalarBaseMult(baseScalar)
+	x2, y2 := backend.ScalarMult(x, y, scalar)
+	return curve.Add(x1, y1, x2, y2)
+}
@@ -0,0 +1,97 @@
+package elliptic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"os"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/cpu"
+)
+
+func TestP256Backends(t *testing.T) {
+	curve := P256()
+	defer SetP256Backend(P256Backend())
+
+	k := make([]byte, 32)
+	rand.Read(k)
+	long := make([]byte, 40)
+	rand.Read(long)
+	_, x, y, err := GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type combined interface {
+		CombinedMult(x, y *big.Int, baseScalar, scalar []byte) (*big.Int, *big.Int)
+	}
+	type invertible interface {
+		Inverse(k *big.Int) *big.Int
+	}
+
+	var results [][]*big.Int
+	for _, name := range P256Backends() {
+		if err := SetP256Backend(name); err != nil {
+			t.Fatal(err)
+		}
+		if P256Backend() != name {
+			t.Errorf("selected %s, using %s", name, P256Backend())
+		}
+		if P256() != curve {
+			t.Errorf("P256() changed with the backend")
+		}
+		bx, by := curve.ScalarBaseMult(k)
+		sx, sy := curve.ScalarMult(x, y, k)
+		cx, cy := curve.(combined).CombinedMult(x, y, k, k)
+		inv := curve.(invertible).Inverse(new(big.Int).SetBytes(k))
+		// Scalars longer than 32 bytes, below N or not, are reduced.
+		padded := append(make([]byte, 8), k...)
+		if px, py := curve.ScalarBaseMult(padded); px.Cmp(bx) != 0 || py.Cmp(by) != 0 {
+			t.Errorf("%s: ScalarBaseMult changed with a zero-padded scalar", name)
+		}
+		if px, py := curve.ScalarMult(x, y, padded); px.Cmp(sx) != 0 || py.Cmp(sy) != 0 {
+			t.Errorf("%s: ScalarMult changed with a zero-padded scalar", name)
+		}
+		lx, ly := curve.ScalarBaseMult(long)
+		mx, my := curve.ScalarMult(x, y, long)
+		results = append(results, []*big.Int{bx, by, sx, sy, cx, cy, inv, lx, ly, mx, my})
+	}
+	for i := 1; i < len(results); i++ {
+		for j := range results[0] {
+			if results[i][j].Cmp(results[0][j]) != 0 {
+				t.Errorf("backends %s and %s disagree on result %d", P256Backends()[0], P256Backends()[i], j)
+			}
+		}
+	}
+	if err := SetP256Backend("nonexistent"); err == nil {
+		t.Errorf("unknown backend selected")
+	}
+}
+
+func TestP256BackendOverride(t *testing.T) {
+	P256()
+	defer SetP256Backend(P256Backend())
+	defer os.Setenv("CTCRYPTO_P256_BACKEND", os.Getenv("CTCRYPTO_P256_BACKEND"))
+
+	names := P256Backends()
+	for _, name := range names {
+		os.Setenv("CTCRYPTO_P256_BACKEND", name)
+		selectP256Backend()
+		if P256Backend() != name {
+			t.Errorf("CTCRYPTO_P256_BACKEND=%s selected %s", name, P256Backend())
+		}
+	}
+	os.Setenv("CTCRYPTO_P256_BACKEND", "nonexistent")
+	selectP256Backend()
+	if P256Backend() != names[0] {
+		t.Errorf("unknown CTCRYPTO_P256_BACKEND selected %s instead of %s", P256Backend(), names[0])
+	}
+
+	adx := false
+	for _, name := range names {
+		adx = adx || name == BackendADX
+	}
+	if want := runtime.GOARCH == "amd64" && cpu.X86.HasBMI2 && cpu.X86.HasADX; adx != want {
+		t.Errorf("%s available: %v, want %v", BackendADX, adx, want)
+	}
+}
@@ -0,0 +1,121 @@
+package elliptic
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Batch calls f(i) for every i between 0 and n - 1, spreading the calls over
+// at most GOMAXPROCS goroutines, and waits for them to return.
+//
+// The calls are independent, and can happen in any order. Once ctx is done,
+// no new call is made, and Batch returns ctx.Err() after the calls in progress
+// return, in which case some indices will have been skipped. Pass
+// context.Background() if the batch never needs to be cancelled.
+func Batch(ctx context.Context, n int, f func(i int)) error {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	var next int64 = -1
+	var done int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= n {
+					return
+				}
+				f(i)
+				atomic.AddInt64(&done, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	// A cancellation after the last call doesn't concern us.
+	if int(done) == n {
+		return nil
+	}
+	return ctx.Err()
+}
+
+// BatchScalarMult returns ks[i]*(xs[i],ys[i]) for every i, computed in
+// parallel, as with Batch.
+//
+// xs, ys, and ks must have the same length. On error, the results are nil.
+func BatchScalarMult(ctx context.Context, curve Curve, xs, ys []*big.Int, ks [][]byte) (outX, outY []*big.Int, err error) {
+	if len(xs) != len(ys) || len(xs) != len(ks) {
+		panic("elliptic: mismatched batch lengths")
+	}
+	outX = make([]*big.Int, len(ks))
+	outY = make([]*big.Int, len(ks))
+	err = Batch(ctx, len(ks), func(i int) {
+		outX[i], outY[i] = curve.ScalarMult(xs[i], ys[i], ks[i])
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return outX, outY, nil
+}
+
+// BatchScalarMultInto sets outX[i] and outY[i] to ks[i]*(xs[i],ys[i]) for
+// every i, computed in parallel, as with Batch, reusing their values, like
+// ScalarMultInto.
+//
+// All the slices must have the same length, and the outputs must be non-nil.
+// On error, some of the outputs are left unchanged.
+func BatchScalarMultInto(ctx context.Context, curve Curve, outX, outY, xs, ys []*big.Int, ks [][]byte) error {
+	if len(xs) != len(ys) || len(xs) != len(ks) || len(outX) != len(ks) || len(outY) != len(ks) {
+		panic("elliptic: mismatched batch lengths")
+	}
+	return Batch(ctx, len(ks), func(i int) {
+		ScalarMultInto(curve, outX[i], outY[i], xs[i], ys[i], ks[i])
+	})
+}
+
+// BatchScalarBaseMult returns ks[i]*G for every i, where G is the base point
+// of the curve, computed in parallel, as with Batch.
+//
+// On error, the results are nil.
+func BatchScalarBaseMult(ctx context.Context, curve Curve, ks [][]byte) (outX, outY []*big.Int, err error) {
+	outX = make([]*big.Int, len(ks))
+	outY = make([]*big.Int, len(ks))
+	err = Batch(ctx, len(ks), func(i int) {
+		outX[i], outY[i] = curve.ScalarBaseMult(ks[i])
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return outX, outY, nil
+}
+
+// BatchVerify reports whether verify(i) returns true for every i between 0
+// and n - 1, calling it in parallel, as with Batch.
+//
+// This stops making new calls as soon as one of them returns false, so the
+// time it takes reveals roughly which index failed first, which is fine for
+// public signatures, but not for checks involving secrets.
+func BatchVerify(ctx context.Context, n int, verify func(i int) bool) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var failed int32
+	err := Batch(ctx, n, func(i int) {
+		if !verify(i) {
+			atomic.StoreInt32(&failed, 1)
+			cancel()
+		}
+	})
+	if atomic.LoadInt32(&failed) == 1 {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
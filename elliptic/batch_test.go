@@ -0,0 +1,99 @@
+package elliptic
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBatchScalarMult(t *testing.T) {
+	curve := P256()
+	ks := make([][]byte, 20)
+	xs := make([]*big.Int, len(ks))
+	ys := make([]*big.Int, len(ks))
+	for i := range ks {
+		ks[i] = make([]byte, 32)
+		rand.Read(ks[i])
+		_, xs[i], ys[i], _ = GenerateKey(curve, rand.Reader)
+	}
+	outX, outY, err := BatchScalarMult(context.Background(), curve, xs, ys, ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseX, baseY, err := BatchScalarBaseMult(context.Background(), curve, ks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range ks {
+		x, y := curve.ScalarMult(xs[i], ys[i], ks[i])
+		if x.Cmp(outX[i]) != 0 || y.Cmp(outY[i]) != 0 {
+			t.Errorf("wrong ScalarMult result at %d", i)
+		}
+		x, y = curve.ScalarBaseMult(ks[i])
+		if x.Cmp(baseX[i]) != 0 || y.Cmp(baseY[i]) != 0 {
+			t.Errorf("wrong ScalarBaseMult result at %d", i)
+		}
+	}
+}
+
+func TestBatchScalarMultInto(t *testing.T) {
+	for _, curve := range []Curve{P256(), P224().Params()} {
+		ks := make([][]byte, 20)
+		xs := make([]*big.Int, len(ks))
+		ys := make([]*big.Int, len(ks))
+		outX := make([]*big.Int, len(ks))
+		outY := make([]*big.Int, len(ks))
+		for i := range ks {
+			ks[i] = make([]byte, 32)
+			rand.Read(ks[i])
+			_, xs[i], ys[i], _ = GenerateKey(curve, rand.Reader)
+			outX[i], outY[i] = new(big.Int), new(big.Int)
+		}
+		if err := BatchScalarMultInto(context.Background(), curve, outX, outY, xs, ys, ks); err != nil {
+			t.Fatal(err)
+		}
+		for i := range ks {
+			x, y := curve.ScalarMult(xs[i], ys[i], ks[i])
+			if x.Cmp(outX[i]) != 0 || y.Cmp(outY[i]) != 0 {
+				t.Errorf("%s: wrong result at %d", curve.Params().Name, i)
+			}
+		}
+		// The outputs may alias the inputs.
+		if err := BatchScalarMultInto(context.Background(), curve, xs, ys, xs, ys, ks); err != nil {
+			t.Fatal(err)
+		}
+		for i := range ks {
+			if xs[i].Cmp(outX[i]) != 0 || ys[i].Cmp(outY[i]) != 0 {
+				t.Errorf("%s: wrong aliased result at %d", curve.Params().Name, i)
+			}
+		}
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls int32
+	if err := Batch(ctx, 100, func(int) { atomic.AddInt32(&calls, 1) }); err != context.Canceled {
+		t.Errorf("got error %v, expected context.Canceled", err)
+	}
+	if calls != 0 {
+		t.Errorf("%d calls made after cancellation", calls)
+	}
+	if err := Batch(context.Background(), 0, func(int) { t.Error("call on empty batch") }); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	ok, err := BatchVerify(context.Background(), 1000, func(i int) bool { return true })
+	if !ok || err != nil {
+		t.Errorf("BatchVerify() = %v, %v for valid batch", ok, err)
+	}
+	ok, err = BatchVerify(context.Background(), 1000, func(i int) bool { return i != 3 })
+	if ok || err != nil {
+		t.Errorf("BatchVerify() = %v, %v for invalid batch", ok, err)
+	}
+}
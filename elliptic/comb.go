@@ -0,0 +1,180 @@
+package elliptic
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+)
+
+// This file implements the comb method of Lim and Lee, from "More Flexible
+// Exponentiation with Precomputation", for multiplying a point that is known
+// in advance.
+//
+// With t teeth, a scalar of l bits is split into t rows of d = ⌈l / t⌉ bits,
+// and the table holds, for every non-zero t bit index j, the sum of the
+// 2^(i d) P such that bit i of j is set. Column c of the scalar then selects a
+// single entry, and k P is computed with d doublings and at most d additions,
+// instead of l of each.
+
+// DefaultPrecomputationBudget is the initial value of the budget set by
+// SetPrecomputationBudget, which gives combs of 5 teeth, or 31 points, to
+// curves of up to 521 bits, making ScalarBaseMult about 3.5 times faster than
+// the ladder.
+const DefaultPrecomputationBudget = 4096
+
+var precomputationBudget int64 = DefaultPrecomputationBudget
+
+// SetPrecomputationBudget sets the maximal size, in bytes, of the comb tables
+// built by ScalarBaseMult and NewFixedPoint for the generic CurveParams
+// implementation, counting the affine coordinates of their points.
+//
+// A larger budget gives combs with more teeth, up to 8, and faster
+// multiplications, while a budget smaller than a single point disables the
+// tables, falling back to the ladder. The tables of ScalarBaseMult are rebuilt
+// on their next use when the budget changes. Dedicated implementations, like
+// P-256, have fixed tables, which this doesn't affect.
+func SetPrecomputationBudget(bytes int) {
+	atomic.StoreInt64(&precomputationBudget, int64(bytes))
+}
+
+// PrecomputationBudget returns the budget set by SetPrecomputationBudget.
+func PrecomputationBudget() int {
+	return int(atomic.LoadInt64(&precomputationBudget))
+}
+
+// combTeeth returns the largest number of teeth of a comb table for the curve
+// fitting in the budget, or 0 if none does.
+func (curve *CurveParams) combTeeth() int {
+	pointSize := 2 * ((curve.BitSize + 7) / 8)
+	budget := PrecomputationBudget()
+	teeth := 0
+	for teeth < maxCombTeeth && (1<<(teeth+1)-1)*pointSize <= budget {
+		teeth++
+	}
+	return teeth
+}
+
+// maxCombTeeth bounds the size of a comb table, at 2^maxCombTeeth - 1 points.
+const maxCombTeeth = 8
+
+// Comb is a table of precomputed multiples of a fixed point, which speeds up
+// scalar multiplications of that point, at the cost of storing
+// 2^teeth - 1 points.
+//
+// Like the rest of CurveParams, this doesn't use constant-time algorithms. A
+// Comb is safe for concurrent use.
+type Comb struct {
+	curve   *CurveParams
+	teeth   int
+	spacing int
+	// x, y, and z hold the table entries, entry j being at index j - 1, with
+	// z = 1, or z = 0 for the point at infinity.
+	x, y, z []*big.Int
+}
+
+// NewComb precomputes a comb table with the given number of teeth, between 1
+// and 8, for the point (x, y).
+//
+// The point must be in the subgroup generated by the base point, since
+// scalars are reduced modulo N, which is the case of every point on the
+// curves of this package.
+func (curve *CurveParams) NewComb(x, y *big.Int, teeth int) (*Comb, error) {
+	if teeth < 1 || teeth > maxCombTeeth {
+		return nil, errors.New("elliptic: invalid number of comb teeth")
+	}
+	bits := new(big.Int).SetBytes(curve.N.Bytes()).BitLen()
+	c := &Comb{
+		curve:   curve,
+		teeth:   teeth,
+		spacing: (bits + teeth - 1) / teeth,
+	}
+	s := curve.getScratch()
+	defer curve.putScratch(s)
+
+	// rows[i] = 2^(i d) P
+	rows := make([][3]*big.Int, teeth)
+	px, py, pz := s.ax.Set(x), s.ay.Set(y), s.az.Set(zForAffine(x, y))
+	for i := range rows {
+		rows[i] = [3]*big.Int{new(big.Int).Set(px), new(big.Int).Set(py), new(big.Int).Set(pz)}
+		for j := 0; j < c.spacing; j++ {
+			curve.doubleJacobian(s, px, py, pz, px, py, pz)
+		}
+	}
+
+	size := 1<<teeth - 1
+	c.x, c.y, c.z = make([]*big.Int, size), make([]*big.Int, size), make([]*big.Int, size)
+	jx, jy, jz := s.bx, s.by, s.bz
+	for j := 1; j <= size; j++ {
+		// Entry j is entry j without its top bit, plus the row of that bit.
+		// The entries are affine, so that the table only needs their x and y.
+		top := big.NewInt(int64(j)).BitLen() - 1
+		row := rows[top]
+		if rest := j &^ (1 << top); rest == 0 {
+			jx.Set(row[0])
+			jy.Set(row[1])
+			jz.Set(row[2])
+		} else {
+			curve.addJacobian(s, jx, jy, jz, c.x[rest-1], c.y[rest-1], c.z[rest-1], row[0], row[1], row[2])
+		}
+		c.x[j-1], c.y[j-1] = curve.affineFromJacobianInto(s, new(big.Int), new(big.Int), jx, jy, jz)
+		c.z[j-1] = zForAffine(c.x[j-1], c.y[j-1])
+	}
+	return c, nil
+}
+
+// ScalarMult returns k*(x,y), for the point (x, y) of the table, where k is an
+// integer in big-endian form.
+func (c *Comb) ScalarMult(k []byte) (x, y *big.Int) {
+	return c.ScalarMultInto(new(big.Int), new(big.Int), k)
+}
+
+// ScalarMultInto sets x and y to k*(x,y), for the point (x, y) of the table,
+// where k is an integer in big-endian form, and returns them.
+//
+// Unlike ScalarMult, the temporaries of the computation are reused across
+// calls, so that loops over many scalars don't allocate new values for every
+// result.
+func (c *Comb) ScalarMultInto(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	curve := c.curve
+	s := curve.getScratch()
+	defer curve.putScratch(s)
+
+	kInt := s.k.SetBytes(k)
+	kInt.Mod(kInt, s.n)
+
+	ax, ay, az := s.ax.SetInt64(0), s.ay.SetInt64(0), s.az.SetInt64(0)
+	for col := c.spacing - 1; col >= 0; col-- {
+		curve.doubleJacobian(s, ax, ay, az, ax, ay, az)
+		j := 0
+		for i := 0; i < c.teeth; i++ {
+			j |= int(kInt.Bit(i*c.spacing+col)) << i
+		}
+		if j != 0 {
+			curve.addJacobian(s, ax, ay, az, ax, ay, az, c.x[j-1], c.y[j-1], c.z[j-1])
+		}
+	}
+	return curve.affineFromJacobianInto(s, x, y, ax, ay, az)
+}
+
+// baseCombs holds the comb of the base point of every CurveParams used with
+// ScalarBaseMult, built the first time it's needed.
+var baseCombs sync.Map
+
+// baseComb returns the comb of the base point, or nil if the budget doesn't
+// allow for one.
+func (curve *CurveParams) baseComb() *Comb {
+	teeth := curve.combTeeth()
+	if teeth == 0 {
+		return nil
+	}
+	if c, ok := baseCombs.Load(curve); ok && c.(*Comb).teeth == teeth {
+		return c.(*Comb)
+	}
+	c, err := curve.NewComb(new(big.Int).SetBytes(curve.Gx.Bytes()), new(big.Int).SetBytes(curve.Gy.Bytes()), teeth)
+	if err != nil {
+		panic(err)
+	}
+	baseCombs.Store(curve, c)
+	return c
+}
@@ -0,0 +1,95 @@
+package elliptic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestComb(t *testing.T) {
+	for _, curve := range []*CurveParams{P384().Params(), P521().Params(), P224().Params()} {
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := new(big.Int).SetBytes(curve.N.Bytes())
+		k := make([]byte, (curve.BitSize+7)/8)
+		rand.Read(k)
+		scalars := [][]byte{
+			k,
+			{0},
+			{1},
+			n.Bytes(),
+			new(big.Int).Sub(n, big.NewInt(1)).Bytes(),
+			// longer than the order
+			append(append([]byte{}, k...), k...),
+		}
+		for _, teeth := range []int{1, 3, 5, 8} {
+			c, err := curve.NewComb(x, y, teeth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, k := range scalars {
+				expectedX, expectedY := curve.ScalarMult(x, y, k)
+				gotX, gotY := c.ScalarMult(k)
+				if gotX.Cmp(expectedX) != 0 || gotY.Cmp(expectedY) != 0 {
+					t.Errorf("%s: %d teeth, wrong result for k = %x", curve.Name, teeth, k)
+				}
+				c.ScalarMultInto(gotX, gotY, k)
+				if gotX.Cmp(expectedX) != 0 || gotY.Cmp(expectedY) != 0 {
+					t.Errorf("%s: %d teeth, wrong ScalarMultInto result for k = %x", curve.Name, teeth, k)
+				}
+			}
+		}
+		gx, gy := new(big.Int).SetBytes(curve.Gx.Bytes()), new(big.Int).SetBytes(curve.Gy.Bytes())
+		expectedX, expectedY := curve.ScalarMult(gx, gy, k)
+		if x, y := curve.ScalarBaseMult(k); x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+			t.Errorf("%s: ScalarBaseMult doesn't match ScalarMult", curve.Name)
+		}
+	}
+	if _, err := P384().Params().NewComb(big.NewInt(0), big.NewInt(0), 9); err == nil {
+		t.Errorf("comb with 9 teeth accepted")
+	}
+}
+
+func BenchmarkScalarBaseMultP384(b *testing.B) {
+	curve := P384()
+	k := make([]byte, 48)
+	rand.Read(k)
+	curve.ScalarBaseMult(k)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		curve.ScalarBaseMult(k)
+	}
+}
+
+func TestPrecomputationBudget(t *testing.T) {
+	defer SetPrecomputationBudget(DefaultPrecomputationBudget)
+	curve := P384().Params()
+	k := make([]byte, 48)
+	rand.Read(k)
+	gx, gy := new(big.Int).SetBytes(curve.Gx.Bytes()), new(big.Int).SetBytes(curve.Gy.Bytes())
+	expectedX, expectedY := curve.ScalarMult(gx, gy, k)
+
+	for _, budget := range []int{0, 96, 1 << 20, DefaultPrecomputationBudget} {
+		SetPrecomputationBudget(budget)
+		teeth := curve.combTeeth()
+		if size := (1<<teeth - 1) * 96; size > budget || (teeth < maxCombTeeth && 2*size+96 <= budget) {
+			t.Errorf("budget %d: comb of %d teeth", budget, teeth)
+		}
+		if x, y := curve.ScalarBaseMult(k); x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+			t.Errorf("budget %d: wrong ScalarBaseMult result", budget)
+		}
+		p, _ := NewFixedPoint(curve, gx, gy)
+		if (p.comb != nil) != (teeth > 0) {
+			t.Errorf("budget %d: fixed point table doesn't match the budget", budget)
+		}
+		if x, y := p.ScalarMult(k); x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+			t.Errorf("budget %d: wrong FixedPoint result", budget)
+		}
+	}
+	if teeth := curve.combTeeth(); teeth != 5 {
+		t.Errorf("default budget gives %d teeth for P-384", teeth)
+	}
+}
@@ -0,0 +1,23 @@
+package elliptic
+
+import (
+	"context"
+	"math/big"
+)
+
+// UnmarshalCompressedMany is like UnmarshalCompressed for each of data,
+// spreading the work over the available CPUs with Batch, and leaving xs[i] and
+// ys[i] nil if data[i] isn't a valid compressed point.
+//
+// As with UnmarshalCompressed, an invalid point is rejected in the same time,
+// whichever check fails.
+func UnmarshalCompressedMany(curve Curve, data [][]byte) (xs, ys []*big.Int) {
+	xs = make([]*big.Int, len(data))
+	ys = make([]*big.Int, len(data))
+	Batch(context.Background(), len(data), func(i int) {
+		if x, y := UnmarshalCompressedNat(curve, data[i]); x != nil {
+			xs[i], ys[i] = natToBig(x), natToBig(y)
+		}
+	})
+	return xs, ys
+}
@@ -0,0 +1,41 @@
+package elliptic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestUnmarshalCompressedMany(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		var data [][]byte
+		for i := 0; i < 10; i++ {
+			_, x, y, err := GenerateKey(curve, rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data = append(data, MarshalCompressed(curve, x, y))
+		}
+		// x = 0 isn't on any of the curves, and the rest is malformed.
+		byteLen := (curve.Params().BitSize + 7) / 8
+		offCurve := make([]byte, 1+byteLen)
+		offCurve[0] = 2
+		tooLarge := append([]byte{3}, new(big.Int).SetBytes(curve.Params().P.Bytes()).Bytes()...)
+		badPrefix := append([]byte{4}, data[0][1:]...)
+		data = append(data, offCurve, tooLarge, badPrefix, data[0][:byteLen])
+
+		xs, ys := UnmarshalCompressedMany(curve, data)
+		for i, d := range data {
+			expectedX, expectedY := UnmarshalCompressed(curve, d)
+			if expectedX == nil {
+				if xs[i] != nil || ys[i] != nil {
+					t.Errorf("%s: invalid point %d accepted", curve.Params().Name, i)
+				}
+				continue
+			}
+			if xs[i] == nil || xs[i].Cmp(expectedX) != 0 || ys[i].Cmp(expectedY) != 0 {
+				t.Errorf("%s: wrong result for point %d", curve.Params().Name, i)
+			}
+		}
+	}
+}
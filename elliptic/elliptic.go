@@ -14,10 +14,13 @@ package elliptic
 // reverse the transform than to operate in affine coordinates.
 
 import (
+	"crypto/subtle"
 	"io"
 	"math/big"
 	"sync"
 
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
 	"github.com/cronokirby/safenum"
 )
 
@@ -72,12 +75,14 @@ func (curve *CurveParams) polynomial(x *safenum.Nat) *safenum.Nat {
 }
 
 func (curve *CurveParams) IsOnCurve(x, y *big.Int) bool {
-	// y² = x³ - 3x + b
-	yNat := new(safenum.Nat).SetBytes(y.Bytes())
-	y2 := new(safenum.Nat).ModMul(yNat, yNat, curve.P)
-	xNat := new(safenum.Nat).SetBytes(x.Bytes())
-
-	return curve.polynomial(xNat).Cmp(y2) == 0
+	// Coordinates which aren't reduced modulo P are rejected, like by the
+	// other implementations of Curve.
+	if x.Sign() < 0 || y.Sign() < 0 {
+		return false
+	}
+	xNat, xValid := curve.fieldElement(x.Bytes())
+	yNat, yValid := curve.fieldElement(y.Bytes())
+	return xValid&yValid&curve.onCurve(xNat, yNat) == 1
 }
 
 // zForAffine returns a Jacobian Z value for the affine point (x, y). If x and
@@ -94,313 +99,526 @@ func zForAffine(x, y *big.Int) *big.Int {
 // affineFromJacobian reverses the Jacobian transform. See the comment at the
 // top of the file. If the point is ∞ it returns 0, 0.
 func (curve *CurveParams) affineFromJacobian(x, y, z *big.Int) (xOut, yOut *big.Int) {
+	return curve.affineFromJacobianInto(curve.newJacobianScratch(), new(big.Int), new(big.Int), x, y, z)
+}
+
+// affineFromJacobianInto is like affineFromJacobian, but sets xOut and yOut,
+// which may alias the inputs, using the temporaries of s, and returns them.
+func (curve *CurveParams) affineFromJacobianInto(s *jacobianScratch, xOut, yOut, x, y, z *big.Int) (*big.Int, *big.Int) {
 	if z.Sign() == 0 {
-		return new(big.Int), new(big.Int)
+		return xOut.SetInt64(0), yOut.SetInt64(0)
+	}
+
+	zinv, zinvsq := s.i, s.j
+	s.invert(zinv, z)
+	s.mul(zinvsq, zinv, zinv)
+
+	s.mul(xOut, x, zinvsq)
+	s.mul(zinvsq, zinvsq, zinv)
+	s.mul(yOut, y, zinvsq)
+	return xOut, yOut
+}
+
+// jacobianScratch holds the temporary values of addJacobian and
+// doubleJacobian, so that a scalar multiplication can reuse them for every step
+// of its ladder, instead of allocating new ones each time.
+type jacobianScratch struct {
+	p, n, pMinus2 *big.Int
+	// mu = ⌊2^(2 k) / p⌋, where p has k bits, for the reduction of mul
+	mu                                        *big.Int
+	q, t, u, k                                *big.Int
+	z1z1, z2z2, u1, u2, h, i, j, s1, s2, r, v *big.Int
+	x3, y3, z3                                *big.Int
+	// ax, ay, az, and bx, by, bz aren't used by the formulas, and hold the
+	// accumulator and the base point of the callers' loops.
+	ax, ay, az, bx, by, bz *big.Int
+}
+
+func (curve *CurveParams) newJacobianScratch() *jacobianScratch {
+	s := &jacobianScratch{
+		p: new(big.Int).SetBytes(curve.P.Bytes()),
+		n: new(big.Int).SetBytes(curve.N.Bytes()),
+	}
+	s.mu = new(big.Int).Lsh(big.NewInt(1), uint(2*s.p.BitLen()))
+	s.mu.Div(s.mu, s.p)
+	s.pMinus2 = new(big.Int).Sub(s.p, big.NewInt(2))
+	for _, t := range []**big.Int{
+		&s.q, &s.t, &s.k, &s.u, &s.z1z1, &s.z2z2, &s.u1, &s.u2, &s.h, &s.i, &s.j,
+		&s.s1, &s.s2, &s.r, &s.v, &s.x3, &s.y3, &s.z3,
+		&s.ax, &s.ay, &s.az, &s.bx, &s.by, &s.bz,
+	} {
+		*t = new(big.Int)
+	}
+	return s
+}
+
+// jacobianScratches holds a sync.Pool of jacobianScratch for every CurveParams
+// used with the Into functions, so that hot loops can reuse the temporaries of
+// their scalar multiplications, instead of allocating new ones for each.
+var jacobianScratches sync.Map
+
+// getScratch returns a jacobianScratch for the curve, which should be handed
+// back with putScratch once the caller is done with it.
+func (curve *CurveParams) getScratch() *jacobianScratch {
+	pool, ok := jacobianScratches.Load(curve)
+	if !ok {
+		pool, _ = jacobianScratches.LoadOrStore(curve, &sync.Pool{
+			New: func() interface{} { return curve.newJacobianScratch() },
+		})
+	}
+	return pool.(*sync.Pool).Get().(*jacobianScratch)
+}
+
+func (curve *CurveParams) putScratch(s *jacobianScratch) {
+	pool, _ := jacobianScratches.Load(curve)
+	pool.(*sync.Pool).Put(s)
+}
+
+// mul sets z = x * y mod p, going through s.t, since big.Int.Mul allocates
+// when its result aliases one of its operands.
+//
+// The product is reduced with Barrett's method, instead of QuoRem, which
+// allocates its temporaries on every call. x * y doesn't need to be lower
+// than p², which lets the lazy additions feed it.
+func (s *jacobianScratch) mul(z, x, y *big.Int) {
+	k := uint(s.p.BitLen())
+	s.t.Mul(x, y)
+	// q = ⌊⌊t / 2^(k-1)⌋ mu / 2^(k+1)⌋ is at most ⌊t / p⌋, and close to it.
+	s.q.Rsh(s.t, k-1)
+	s.u.Mul(s.q, s.mu)
+	s.q.Rsh(s.u, k+1)
+	s.u.Mul(s.q, s.p)
+	z.Sub(s.t, s.u)
+	for z.Cmp(s.p) >= 0 {
+		z.Sub(z, s.p)
+	}
+}
+
+// invert sets z = 1 / x mod p, for x non-zero, as x^(p - 2). z must not alias
+// x.
+func (s *jacobianScratch) invert(z, x *big.Int) {
+	z.SetInt64(1)
+	for i := s.pMinus2.BitLen() - 1; i >= 0; i-- {
+		s.mul(z, z, z)
+		if s.pMinus2.Bit(i) == 1 {
+			s.mul(z, z, x)
+		}
 	}
+}
 
-	zinv := new(big.Int).ModInverse(z, new(big.Int).SetBytes(curve.P.Bytes()))
-	zinvsq := new(big.Int).Mul(zinv, zinv)
+// add sets z = x + y mod p, for x and y reduced modulo p.
+func (s *jacobianScratch) add(z, x, y *big.Int) {
+	z.Add(x, y)
+	if z.Cmp(s.p) >= 0 {
+		z.Sub(z, s.p)
+	}
+}
 
-	xOut = new(big.Int).Mul(x, zinvsq)
-	xOut.Mod(xOut, new(big.Int).SetBytes(curve.P.Bytes()))
-	zinvsq.Mul(zinvsq, zinv)
-	yOut = new(big.Int).Mul(y, zinvsq)
-	yOut.Mod(yOut, new(big.Int).SetBytes(curve.P.Bytes()))
-	return
+// addLazy sets z = x + y, without reducing it modulo p, which can be done
+// when z is only used as an operand of mul, whose remainder reduces it anyway.
+func (s *jacobianScratch) addLazy(z, x, y *big.Int) {
+	z.Add(x, y)
+}
+
+// sub sets z = x - y mod p, for x and y reduced modulo p.
+func (s *jacobianScratch) sub(z, x, y *big.Int) {
+	z.Sub(x, y)
+	if z.Sign() < 0 {
+		z.Add(z, s.p)
+	}
 }
 
 func (curve *CurveParams) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
 	z1 := zForAffine(x1, y1)
 	z2 := zForAffine(x2, y2)
-	return curve.affineFromJacobian(curve.addJacobian(x1, y1, z1, x2, y2, z2))
+	x3, y3, z3 := new(big.Int), new(big.Int), new(big.Int)
+	s := curve.newJacobianScratch()
+	curve.addJacobian(s, x3, y3, z3, x1, y1, z1, x2, y2, z2)
+	return curve.affineFromJacobianInto(s, x3, y3, x3, y3, z3)
 }
 
 // addJacobian takes two points in Jacobian coordinates, (x1, y1, z1) and
-// (x2, y2, z2) and returns their sum, also in Jacobian form.
-func (curve *CurveParams) addJacobian(x1, y1, z1, x2, y2, z2 *big.Int) (*big.Int, *big.Int, *big.Int) {
+// (x2, y2, z2) and sets (x3, y3, z3) to their sum, also in Jacobian form. The
+// outputs may alias the inputs.
+func (curve *CurveParams) addJacobian(s *jacobianScratch, x3, y3, z3, x1, y1, z1, x2, y2, z2 *big.Int) {
 	// See https://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-3.html#addition-add-2007-bl
-	x3, y3, z3 := new(big.Int), new(big.Int), new(big.Int)
 	if z1.Sign() == 0 {
 		x3.Set(x2)
 		y3.Set(y2)
 		z3.Set(z2)
-		return x3, y3, z3
+		return
 	}
 	if z2.Sign() == 0 {
 		x3.Set(x1)
 		y3.Set(y1)
 		z3.Set(z1)
-		return x3, y3, z3
+		return
 	}
 
-	pBig := new(big.Int).SetBytes(curve.P.Bytes())
-	z1z1 := new(big.Int).Mul(z1, z1)
-	z1z1.Mod(z1z1, pBig)
-	z2z2 := new(big.Int).Mul(z2, z2)
-	z2z2.Mod(z2z2, pBig)
-
-	u1 := new(big.Int).Mul(x1, z2z2)
-	u1.Mod(u1, pBig)
-	u2 := new(big.Int).Mul(x2, z1z1)
-	u2.Mod(u2, pBig)
-	h := new(big.Int).Sub(u2, u1)
-	xEqual := h.Sign() == 0
-	if h.Sign() == -1 {
-		h.Add(h, pBig)
-	}
-	i := new(big.Int).Lsh(h, 1)
-	i.Mul(i, i)
-	j := new(big.Int).Mul(h, i)
-
-	s1 := new(big.Int).Mul(y1, z2)
-	s1.Mul(s1, z2z2)
-	s1.Mod(s1, pBig)
-	s2 := new(big.Int).Mul(y2, z1)
-	s2.Mul(s2, z1z1)
-	s2.Mod(s2, pBig)
-	r := new(big.Int).Sub(s2, s1)
-	if r.Sign() == -1 {
-		r.Add(r, pBig)
-	}
-	yEqual := r.Sign() == 0
+	s.mul(s.z1z1, z1, z1)
+	s.mul(s.z2z2, z2, z2)
+	s.mul(s.u1, x1, s.z2z2)
+	s.mul(s.u2, x2, s.z1z1)
+	s.sub(s.h, s.u2, s.u1)
+	xEqual := s.h.Sign() == 0
+	s.mul(s.s1, y1, z2)
+	s.mul(s.s1, s.s1, s.z2z2)
+	s.mul(s.s2, y2, z1)
+	s.mul(s.s2, s.s2, s.z1z1)
+	s.sub(s.r, s.s2, s.s1)
+	yEqual := s.r.Sign() == 0
 	if xEqual && yEqual {
-		return curve.doubleJacobian(x1, y1, z1)
+		curve.doubleJacobian(s, x3, y3, z3, x1, y1, z1)
+		return
 	}
-	r.Lsh(r, 1)
-	v := new(big.Int).Mul(u1, i)
-
-	x3.Set(r)
-	x3.Mul(x3, x3)
-	x3.Sub(x3, j)
-	x3.Sub(x3, v)
-	x3.Sub(x3, v)
-	x3.Mod(x3, pBig)
-
-	y3.Set(r)
-	v.Sub(v, x3)
-	y3.Mul(y3, v)
-	s1.Mul(s1, j)
-	s1.Lsh(s1, 1)
-	y3.Sub(y3, s1)
-	y3.Mod(y3, pBig)
-
-	z3.Add(z1, z2)
-	z3.Mul(z3, z3)
-	z3.Sub(z3, z1z1)
-	z3.Sub(z3, z2z2)
-	z3.Mul(z3, h)
-	z3.Mod(z3, pBig)
-
-	return x3, y3, z3
+	// r, and 2 H, are only multiplied, so they can stay unreduced.
+	s.addLazy(s.r, s.r, s.r)
+	s.addLazy(s.i, s.h, s.h)
+	s.mul(s.i, s.i, s.i)
+	s.mul(s.j, s.h, s.i)
+	s.mul(s.v, s.u1, s.i)
+
+	// X3 = r² - J - 2 V
+	s.mul(s.x3, s.r, s.r)
+	s.sub(s.x3, s.x3, s.j)
+	s.sub(s.x3, s.x3, s.v)
+	s.sub(s.x3, s.x3, s.v)
+
+	// Y3 = r (V - X3) - 2 S1 J
+	s.sub(s.v, s.v, s.x3)
+	s.mul(s.y3, s.r, s.v)
+	s.mul(s.s1, s.s1, s.j)
+	s.add(s.s1, s.s1, s.s1)
+	s.sub(s.y3, s.y3, s.s1)
+
+	// Z3 = ((Z1 + Z2)² - Z1Z1 - Z2Z2) H
+	s.addLazy(s.z3, z1, z2)
+	s.mul(s.z3, s.z3, s.z3)
+	s.sub(s.z3, s.z3, s.z1z1)
+	s.sub(s.z3, s.z3, s.z2z2)
+	s.mul(s.z3, s.z3, s.h)
+
+	x3.Set(s.x3)
+	y3.Set(s.y3)
+	z3.Set(s.z3)
 }
 
 func (curve *CurveParams) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
 	z1 := zForAffine(x1, y1)
-	return curve.affineFromJacobian(curve.doubleJacobian(x1, y1, z1))
+	x3, y3, z3 := new(big.Int), new(big.Int), new(big.Int)
+	s := curve.newJacobianScratch()
+	curve.doubleJacobian(s, x3, y3, z3, x1, y1, z1)
+	return curve.affineFromJacobianInto(s, x3, y3, x3, y3, z3)
 }
 
 // doubleJacobian takes a point in Jacobian coordinates, (x, y, z), and
-// returns its double, also in Jacobian form.
-func (curve *CurveParams) doubleJacobian(x, y, z *big.Int) (*big.Int, *big.Int, *big.Int) {
+// sets (x3, y3, z3) to its double, also in Jacobian form. The outputs may
+// alias the inputs.
+func (curve *CurveParams) doubleJacobian(s *jacobianScratch, x3, y3, z3, x, y, z *big.Int) {
 	// See https://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-3.html#doubling-dbl-2001-b
-	pBig := new(big.Int).SetBytes(curve.P.Bytes())
-	delta := new(big.Int).Mul(z, z)
-	delta.Mod(delta, pBig)
-	gamma := new(big.Int).Mul(y, y)
-	gamma.Mod(gamma, pBig)
-	alpha := new(big.Int).Sub(x, delta)
-	if alpha.Sign() == -1 {
-		alpha.Add(alpha, pBig)
-	}
-	alpha2 := new(big.Int).Add(x, delta)
-	alpha.Mul(alpha, alpha2)
-	alpha2.Set(alpha)
-	alpha.Lsh(alpha, 1)
-	alpha.Add(alpha, alpha2)
-
-	beta := alpha2.Mul(x, gamma)
-
-	x3 := new(big.Int).Mul(alpha, alpha)
-	beta8 := new(big.Int).Lsh(beta, 3)
-	beta8.Mod(beta8, pBig)
-	x3.Sub(x3, beta8)
-	if x3.Sign() == -1 {
-		x3.Add(x3, pBig)
-	}
-	x3.Mod(x3, pBig)
-
-	z3 := new(big.Int).Add(y, z)
-	z3.Mul(z3, z3)
-	z3.Sub(z3, gamma)
-	if z3.Sign() == -1 {
-		z3.Add(z3, pBig)
-	}
-	z3.Sub(z3, delta)
-	if z3.Sign() == -1 {
-		z3.Add(z3, pBig)
-	}
-	z3.Mod(z3, pBig)
-
-	beta.Lsh(beta, 2)
-	beta.Sub(beta, x3)
-	if beta.Sign() == -1 {
-		beta.Add(beta, pBig)
-	}
-	y3 := alpha.Mul(alpha, beta)
-
-	gamma.Mul(gamma, gamma)
-	gamma.Lsh(gamma, 3)
-	gamma.Mod(gamma, pBig)
+	delta, gamma, alpha, beta, t := s.z1z1, s.z2z2, s.u1, s.u2, s.h
+	s.mul(delta, z, z)
+	s.mul(gamma, y, y)
+
+	// alpha = 3 (X - delta) (X + delta), which is only multiplied, and can
+	// stay unreduced, like X + delta.
+	s.sub(alpha, x, delta)
+	s.addLazy(t, x, delta)
+	s.mul(alpha, alpha, t)
+	s.addLazy(t, alpha, alpha)
+	s.addLazy(alpha, t, alpha)
+
+	s.mul(beta, x, gamma)
+
+	// X3 = alpha² - 8 beta
+	s.add(t, beta, beta)
+	s.add(t, t, t)
+	s.add(t, t, t)
+	s.mul(s.x3, alpha, alpha)
+	s.sub(s.x3, s.x3, t)
+
+	// Z3 = (Y + Z)² - gamma - delta
+	s.addLazy(s.z3, y, z)
+	s.mul(s.z3, s.z3, s.z3)
+	s.sub(s.z3, s.z3, gamma)
+	s.sub(s.z3, s.z3, delta)
+
+	// Y3 = alpha (4 beta - X3) - 8 gamma²
+	s.add(beta, beta, beta)
+	s.add(beta, beta, beta)
+	s.sub(beta, beta, s.x3)
+	s.mul(s.y3, alpha, beta)
+	s.mul(gamma, gamma, gamma)
+	s.add(gamma, gamma, gamma)
+	s.add(gamma, gamma, gamma)
+	s.add(gamma, gamma, gamma)
+	s.sub(s.y3, s.y3, gamma)
+
+	x3.Set(s.x3)
+	y3.Set(s.y3)
+	z3.Set(s.z3)
+}
 
-	y3.Sub(y3, gamma)
-	if y3.Sign() == -1 {
-		y3.Add(y3, pBig)
-	}
-	y3.Mod(y3, pBig)
+func (curve *CurveParams) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
+	return curve.scalarMult(Bx, By, k)
+}
 
-	return x3, y3, z3
+func (curve *CurveParams) scalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	return curve.scalarMultInto(curve.newJacobianScratch(), new(big.Int), new(big.Int), Bx, By, k)
 }
 
-func (curve *CurveParams) ScalarMult(Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
-	Bz := new(big.Int).SetInt64(1)
-	x, y, z := new(big.Int), new(big.Int), new(big.Int)
+// scalarMultInto is like scalarMult, but sets x and y, which may alias Bx and
+// By, using the temporaries of s, and returns them.
+func (curve *CurveParams) scalarMultInto(s *jacobianScratch, x, y, Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	bx, by, bz := s.bx.Set(Bx), s.by.Set(By), s.bz.SetInt64(1)
+	ax, ay, az := s.ax.SetInt64(0), s.ay.SetInt64(0), s.az.SetInt64(0)
 
 	for _, byte := range k {
 		for bitNum := 0; bitNum < 8; bitNum++ {
-			x, y, z = curve.doubleJacobian(x, y, z)
+			curve.doubleJacobian(s, ax, ay, az, ax, ay, az)
 			if byte&0x80 == 0x80 {
-				x, y, z = curve.addJacobian(Bx, By, Bz, x, y, z)
+				curve.addJacobian(s, ax, ay, az, bx, by, bz, ax, ay, az)
 			}
 			byte <<= 1
 		}
 	}
 
-	return curve.affineFromJacobian(x, y, z)
+	return curve.affineFromJacobianInto(s, x, y, ax, ay, az)
+}
+
+// ScalarMultInto sets x and y to k*(Bx,By), where k is an integer in
+// big-endian form, and returns them. x and y may alias Bx and By.
+//
+// For the generic CurveParams implementation, the result is computed in
+// place, with temporaries reused across calls, so that hot loops, like batch
+// verification, don't allocate new values for every step. Other curves fall
+// back to their ScalarMult, whose result is copied into x and y.
+func ScalarMultInto(curve Curve, x, y, Bx, By *big.Int, k []byte) (*big.Int, *big.Int) {
+	params, ok := curve.(*CurveParams)
+	if !ok {
+		rx, ry := curve.ScalarMult(Bx, By, k)
+		return x.Set(rx), y.Set(ry)
+	}
+	defer instrument.Begin(instrument.ScalarMult, params.Name)()
+	s := params.getScratch()
+	defer params.putScratch(s)
+	return params.scalarMultInto(s, x, y, Bx, By, k)
 }
 
 func (curve *CurveParams) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
-	return curve.ScalarMult(new(big.Int).SetBytes(curve.Gx.Bytes()), new(big.Int).SetBytes(curve.Gy.Bytes()), k)
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
+	if c := curve.baseComb(); c != nil {
+		return c.ScalarMult(k)
+	}
+	return curve.scalarMult(new(big.Int).SetBytes(curve.Gx.Bytes()), new(big.Int).SetBytes(curve.Gy.Bytes()), k)
+}
+
+// RandomScalar returns a uniformly random scalar in [1, N-1], encoded as a
+// big-endian byte slice of the length of N.
+//
+// Twice that length is read from rand, and reduced modulo N-1 in constant
+// time, before adding one, as in FIPS 186-5, Appendix A.2.1. The result is
+// statistically indistinguishable from uniform, without the loop and timing
+// of rejection sampling, and never zero, even if rand only returns zeros.
+//
+// If rand is nil, the health-tested Reader of the rand package of this module
+// is used.
+func RandomScalar(curve Curve, rand io.Reader) ([]byte, error) {
+	N := curve.Params().N
+	byteLen := (int(N.BitLen()) + 7) / 8
+	b := make([]byte, 2*byteLen)
+	if _, err := io.ReadFull(randutil.Or(rand), b); err != nil {
+		return nil, err
+	}
+	k := new(safenum.Nat).SetBytes(b)
+	return scalarFromUniform(curve.Params(), k), nil
 }
 
-var mask = []byte{0xff, 0x1, 0x3, 0x7, 0xf, 0x1f, 0x3f, 0x7f}
+// scalarFromUniform maps k, which is close to uniform modulo N-1, to a
+// scalar in [1, N-1], encoded like the result of RandomScalar.
+func scalarFromUniform(params *CurveParams, k *safenum.Nat) []byte {
+	N := params.N
+	one := new(safenum.Nat).SetUint64(1)
+	nMinusOne := new(safenum.Nat).SetBytes(N.Bytes())
+	nMinusOne.Sub(nMinusOne, one, N.BitLen())
+	k.Mod(k, safenum.ModulusFromNat(*nMinusOne))
+	k.Add(k, one, N.BitLen())
+	return fieldBytes(k, (int(N.BitLen())+7)/8)
+}
 
 // GenerateKey returns a public/private key pair. The private key is
-// generated using the given reader, which must return random data.
+// generated using the given reader, which must return random data, with
+// RandomScalar.
+//
+// GenerateKeyPair returns a PrivateKey instead, which keeps the scalar as a
+// safenum.Nat, and can wipe it.
 func GenerateKey(curve Curve, rand io.Reader) (priv []byte, x, y *big.Int, err error) {
-	N := curve.Params().N
-	bitSize := N.BitLen()
-	byteLen := (bitSize + 7) / 8
-	priv = make([]byte, byteLen)
-
-	for x == nil {
-		_, err = io.ReadFull(rand, priv)
-		if err != nil {
-			return
-		}
-		// We have to mask off any excess bits in the case that the size of the
-		// underlying field is not a whole number of bytes.
-		priv[0] &= mask[bitSize%8]
-		// This is because, in tests, rand will return all zeros and we don't
-		// want to get the point at infinity and loop forever.
-		priv[1] ^= 0x42
-
-		// If the scalar is out of range, sample another random number.
-		if new(big.Int).SetBytes(priv).Cmp(new(big.Int).SetBytes(N.Bytes())) >= 0 {
-			continue
-		}
-
-		x, y = curve.ScalarBaseMult(priv)
+	priv, err = RandomScalar(curve, rand)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	return
+	x, y = curve.ScalarBaseMult(priv)
+	return priv, x, y, nil
 }
 
 // Marshal converts a point on the curve into the uncompressed form specified in
 // section 4.3.6 of ANSI X9.62.
+//
+// It is a wrapper around MarshalNat, which avoids converting coordinates held
+// as safenum.Nat values.
 func Marshal(curve Curve, x, y *big.Int) []byte {
-	byteLen := (curve.Params().BitSize + 7) / 8
+	return MarshalNat(curve, new(safenum.Nat).SetBytes(x.Bytes()), new(safenum.Nat).SetBytes(y.Bytes()))
+}
+
+// MarshalNat converts a point on the curve into the uncompressed form
+// specified in section 4.3.6 of ANSI X9.62, like Marshal, in constant time.
+//
+// The coordinates are reduced modulo P.
+func MarshalNat(curve Curve, x, y *safenum.Nat) []byte {
+	params := curve.Params()
+	byteLen := (params.BitSize + 7) / 8
 
 	ret := make([]byte, 1+2*byteLen)
 	ret[0] = 4 // uncompressed point
 
-	x.FillBytes(ret[1 : 1+byteLen])
-	y.FillBytes(ret[1+byteLen : 1+2*byteLen])
+	copy(ret[1:1+byteLen], fieldBytes(new(safenum.Nat).Mod(x, params.P), byteLen))
+	copy(ret[1+byteLen:], fieldBytes(new(safenum.Nat).Mod(y, params.P), byteLen))
 
 	return ret
 }
 
 // MarshalCompressed converts a point on the curve into the compressed form
 // specified in section 4.3.6 of ANSI X9.62.
+//
+// It is a wrapper around MarshalCompressedNat.
 func MarshalCompressed(curve Curve, x, y *big.Int) []byte {
-	byteLen := (curve.Params().BitSize + 7) / 8
+	return MarshalCompressedNat(curve, new(safenum.Nat).SetBytes(x.Bytes()), new(safenum.Nat).SetBytes(y.Bytes()))
+}
+
+// MarshalCompressedNat converts a point on the curve into the compressed form
+// specified in section 4.3.6 of ANSI X9.62, like MarshalCompressed, in
+// constant time.
+//
+// The coordinates are reduced modulo P.
+func MarshalCompressedNat(curve Curve, x, y *safenum.Nat) []byte {
+	params := curve.Params()
+	byteLen := (params.BitSize + 7) / 8
 	compressed := make([]byte, 1+byteLen)
-	compressed[0] = byte(y.Bit(0)) | 2
-	x.FillBytes(compressed[1:])
+	compressed[0] = byte(sgn0(new(safenum.Nat).Mod(y, params.P))) | 2
+	copy(compressed[1:], fieldBytes(new(safenum.Nat).Mod(x, params.P), byteLen))
 	return compressed
 }
 
 // Unmarshal converts a point, serialized by Marshal, into an x, y pair.
 // It is an error if the point is not in uncompressed form or is not on the curve.
 // On error, x = nil.
+//
+// An invalid point is rejected in the same time, whichever check fails, so
+// that parsing can't reveal which one did.
 func Unmarshal(curve Curve, data []byte) (x, y *big.Int) {
-	byteLen := (curve.Params().BitSize + 7) / 8
-	if len(data) != 1+2*byteLen {
-		return nil, nil
-	}
-	if data[0] != 4 { // uncompressed form
+	xNat, yNat := UnmarshalNat(curve, data)
+	if xNat == nil {
 		return nil, nil
 	}
-	p := new(big.Int).SetBytes(curve.Params().P.Bytes())
-	x = new(big.Int).SetBytes(data[1 : 1+byteLen])
-	y = new(big.Int).SetBytes(data[1+byteLen:])
-	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 {
+	return natToBig(xNat), natToBig(yNat)
+}
+
+// UnmarshalNat is like Unmarshal, but returns the coordinates as safenum.Nat
+// values, reduced modulo P.
+func UnmarshalNat(curve Curve, data []byte) (x, y *safenum.Nat) {
+	xNat, yNat, prefix, valid := curve.Params().unmarshalLong(data)
+	valid &= subtle.ConstantTimeByteEq(prefix, 4) // uncompressed form
+	if valid != 1 {
 		return nil, nil
 	}
-	if !curve.IsOnCurve(x, y) {
-		return nil, nil
+	return xNat, yNat
+}
+
+// unmarshalLong decodes a point in uncompressed or hybrid form, returning its
+// coordinates, the prefix byte, which the caller checks, and 1 if the length
+// is right, and the point is on the curve, or 0 otherwise.
+func (curve *CurveParams) unmarshalLong(data []byte) (x, y *safenum.Nat, prefix byte, valid int) {
+	byteLen := (curve.BitSize + 7) / 8
+	// Inputs of the wrong length, which is public, are checked as all zeros,
+	// to do the same work as for any other invalid input.
+	buf := make([]byte, 1+2*byteLen)
+	valid = subtle.ConstantTimeEq(int32(len(data)), int32(len(buf)))
+	if valid == 1 {
+		copy(buf, data)
 	}
-	return
+	xNat, xValid := curve.fieldElement(buf[1 : 1+byteLen])
+	yNat, yValid := curve.fieldElement(buf[1+byteLen:])
+	valid &= xValid & yValid & curve.onCurve(xNat, yNat)
+	return xNat, yNat, buf[0], valid
 }
 
 // UnmarshalCompressed converts a point, serialized by MarshalCompressed, into an x, y pair.
 // It is an error if the point is not in compressed form or is not on the curve.
 // On error, x = nil.
+//
+// An invalid point is rejected in the same time, whichever check fails, so
+// that parsing can't reveal which one did.
 func UnmarshalCompressed(curve Curve, data []byte) (x, y *big.Int) {
-	byteLen := (curve.Params().BitSize + 7) / 8
-	if len(data) != 1+byteLen {
+	xNat, yNat := UnmarshalCompressedNat(curve, data)
+	if xNat == nil {
 		return nil, nil
 	}
-	if data[0] != 2 && data[0] != 3 { // compressed form
-		return nil, nil
-	}
-	p := new(big.Int).SetBytes(curve.Params().P.Bytes())
-	x = new(big.Int).SetBytes(data[1:])
-	if x.Cmp(p) >= 0 {
-		return nil, nil
+	return natToBig(xNat), natToBig(yNat)
+}
+
+// UnmarshalCompressedNat is like UnmarshalCompressed, but returns the
+// coordinates as safenum.Nat values, reduced modulo P.
+func UnmarshalCompressedNat(curve Curve, data []byte) (x, y *safenum.Nat) {
+	params := curve.Params()
+	byteLen := (params.BitSize + 7) / 8
+	buf := make([]byte, 1+byteLen)
+	valid := subtle.ConstantTimeEq(int32(len(data)), int32(len(buf)))
+	if valid == 1 {
+		copy(buf, data)
 	}
+	valid &= subtle.ConstantTimeByteEq(buf[0]|1, 3) // compressed form
+	xNat, xValid := params.fieldElement(buf[1:])
+	valid &= xValid
 	// y² = x³ - 3x + b
-	y = new(big.Int).SetBytes(curve.Params().polynomial(new(safenum.Nat).SetBytes(x.Bytes())).Bytes())
-	y = y.ModSqrt(y, p)
-	if y == nil {
-		return nil, nil
-	}
-	if byte(y.Bit(0)) != data[0]&1 {
-		y.Neg(y).Mod(y, p)
-	}
-	if !curve.IsOnCurve(x, y) {
+	yNat := new(safenum.Nat).ModSqrt(params.polynomial(xNat), params.P)
+	valid &= params.onCurve(xNat, yNat)
+	negY := new(safenum.Nat).ModSub(new(safenum.Nat), yNat, params.P)
+	ctSelect(yNat, negY, yNat, sgn0(yNat)^int(buf[0]&1), params.P)
+	if valid != 1 {
 		return nil, nil
 	}
-	return
+	return xNat, yNat
+}
+
+// fieldElement decodes a big-endian field element, returning it reduced, and
+// 1 if it was lower than P, or 0 otherwise.
+func (curve *CurveParams) fieldElement(b []byte) (*safenum.Nat, int) {
+	// SetBytes can modify its argument, if it has spare capacity.
+	x := new(safenum.Nat).SetBytes(b[:len(b):len(b)])
+	valid := subtle.ConstantTimeEq(int32(x.CmpMod(curve.P)), -1)
+	return x.Mod(x, curve.P), valid
+}
+
+// fieldBytes encodes a reduced field element as byteLen big-endian bytes.
+func fieldBytes(x *safenum.Nat, byteLen int) []byte {
+	// Bytes is padded to a whole number of limbs.
+	b := x.Bytes()
+	return b[len(b)-byteLen:]
+}
+
+// onCurve returns 1 if the reduced x, y is on the curve, and 0 otherwise.
+func (curve *CurveParams) onCurve(x, y *safenum.Nat) int {
+	// y² = x³ - 3x + b
+	y2 := new(safenum.Nat).ModMul(y, y, curve.P)
+	return subtle.ConstantTimeEq(int32(curve.polynomial(x).Cmp(y2)), 0)
 }
 
 var initonce sync.Once
-var p384 *CurveParams
-var p521 *CurveParams
 
 func initAll() {
 	initP224()
 	initP256()
-	initP384()
-	initP521()
 }
 
 func fromString(s string, base int) (*safenum.Nat, bool) {
@@ -419,28 +637,6 @@ func modFromString(s string, base int) (*safenum.Modulus, bool) {
 	return safenum.ModulusFromBytes(x.Bytes()), true
 }
 
-func initP384() {
-	// See FIPS 186-3, section D.2.4
-	p384 = &CurveParams{Name: "P-384"}
-	p384.P, _ = modFromString("39402006196394479212279040100143613805079739270465446667948293404245721771496870329047266088258938001861606973112319", 10)
-	p384.N, _ = modFromString("39402006196394479212279040100143613805079739270465446667946905279627659399113263569398956308152294913554433653942643", 10)
-	p384.B, _ = fromString("b3312fa7e23ee7e4988e056be3f82d19181d9c6efe8141120314088f5013875ac656398d8a2ed19d2a85c8edd3ec2aef", 16)
-	p384.Gx, _ = fromString("aa87ca22be8b05378eb1c71ef320ad746e1d3b628ba79b9859f741e082542a385502f25dbf55296c3a545e3872760ab7", 16)
-	p384.Gy, _ = fromString("3617de4a96262c6f5d9e98bf9292dc29f8f41dbd289a147ce9da3113b5f0b8c00a60b1ce1d7e819d7a431d7c90ea0e5f", 16)
-	p384.BitSize = 384
-}
-
-func initP521() {
-	// See FIPS 186-3, section D.2.5
-	p521 = &CurveParams{Name: "P-521"}
-	p521.P, _ = modFromString("6864797660130609714981900799081393217269435300143305409394463459185543183397656052122559640661454554977296311391480858037121987999716643812574028291115057151", 10)
-	p521.N, _ = modFromString("6864797660130609714981900799081393217269435300143305409394463459185543183397655394245057746333217197532963996371363321113864768612440380340372808892707005449", 10)
-	p521.B, _ = fromString("051953eb9618e1c9a1f929a21a0b68540eea2da725b99b315f3b8b489918ef109e156193951ec7e937b1652c0bd3bb1bf073573df883d2c34f1ef451fd46b503f00", 16)
-	p521.Gx, _ = fromString("c6858e06b70404e9cd9e3ecb662395b4429c648139053fb521f828af606b4d3dbaa14b5e77efe75928fe1dc127a2ffa8de3348b3c1856a429bf97e7e31c2e5bd66", 16)
-	p521.Gy, _ = fromString("11839296a789a3bc0045c8a5fb42c7d1bd998f54449579b446817afbd17273e662c97ee72995ef42640c550b9013fad0761353c7086a272c24088be94769fd16650", 16)
-	p521.BitSize = 521
-}
-
 // P256 returns a Curve which implements NIST P-256 (FIPS 186-3, section D.2.3),
 // also known as secp256r1 or prime256v1. The CurveParams.Name of this Curve is
 // "P-256".
@@ -454,26 +650,32 @@ func P256() Curve {
 	return p256
 }
 
+// The backend of P384, in p384.go, is generated by curvegen, with 64-bit limbs
+// and Montgomery multiplication.
+//go:generate go run github.com/cronokirby/ctcrypto/cmd/curvegen -package elliptic -func P384 -name P-384 -p 0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000ffffffff -b 0xb3312fa7e23ee7e4988e056be3f82d19181d9c6efe8141120314088f5013875ac656398d8a2ed19d2a85c8edd3ec2aef -gx 0xaa87ca22be8b05378eb1c71ef320ad746e1d3b628ba79b9859f741e082542a385502f25dbf55296c3a545e3872760ab7 -gy 0x3617de4a96262c6f5d9e98bf9292dc29f8f41dbd289a147ce9da3113b5f0b8c00a60b1ce1d7e819d7a431d7c90ea0e5f -n 0xffffffffffffffffffffffffffffffffffffffffffffffffc7634d81f4372ddf581a0db248b0a77aecec196accc52973 -o p384.go -o32 p384_32.go
+
 // P384 returns a Curve which implements NIST P-384 (FIPS 186-3, section D.2.4),
 // also known as secp384r1. The CurveParams.Name of this Curve is "P-384".
 //
 // Multiple invocations of this function will return the same value, so it can
 // be used for equality checks and switch statements.
 //
-// The cryptographic operations do not use constant-time algorithms.
+// The cryptographic operations are implemented using constant-time algorithms.
 func P384() Curve {
-	initonce.Do(initAll)
 	return p384
 }
 
+// The backend of P521, in p521.go, is generated by curvegen too, and reduces
+// modulo the Mersenne prime 2^521 - 1 with the Solinas reduction.
+//go:generate go run github.com/cronokirby/ctcrypto/cmd/curvegen -package elliptic -func P521 -name P-521 -p 0x1ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff -b 0x51953eb9618e1c9a1f929a21a0b68540eea2da725b99b315f3b8b489918ef109e156193951ec7e937b1652c0bd3bb1bf073573df883d2c34f1ef451fd46b503f00 -gx 0xc6858e06b70404e9cd9e3ecb662395b4429c648139053fb521f828af606b4d3dbaa14b5e77efe75928fe1dc127a2ffa8de3348b3c1856a429bf97e7e31c2e5bd66 -gy 0x11839296a789a3bc0045c8a5fb42c7d1bd998f54449579b446817afbd17273e662c97ee72995ef42640c550b9013fad0761353c7086a272c24088be94769fd16650 -n 0x1fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffa51868783bf2f966b7fcc0148f709a5d03bb5c9b8899c47aebb6fb71e91386409 -o p521.go -o32 p521_32.go
+
 // P521 returns a Curve which implements NIST P-521 (FIPS 186-3, section D.2.5),
 // also known as secp521r1. The CurveParams.Name of this Curve is "P-521".
 //
 // Multiple invocations of this function will return the same value, so it can
 // be used for equality checks and switch statements.
 //
-// The cryptographic operations do not use constant-time algorithms.
+// The cryptographic operations are implemented using constant-time algorithms.
 func P521() Curve {
-	initonce.Do(initAll)
 	return p521
 }
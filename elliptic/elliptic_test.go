@@ -10,7 +10,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"runtime/debug"
 	"testing"
+
+	"github.com/cronokirby/safenum"
 )
 
 func TestOnCurve(t *testing.T) {
@@ -33,6 +36,20 @@ func TestOffCurve(t *testing.T) {
 	}
 }
 
+func TestOnCurveUnreduced(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		params := curve.Params()
+		p := new(big.Int).SetBytes(params.P.Bytes())
+		x, y := new(big.Int).SetBytes(params.Gx.Bytes()), new(big.Int).SetBytes(params.Gy.Bytes())
+		if curve.IsOnCurve(new(big.Int).Add(x, p), y) || curve.IsOnCurve(x, new(big.Int).Add(y, p)) {
+			t.Errorf("%s: unreduced coordinates are claimed to be on the curve", params.Name)
+		}
+		if curve.IsOnCurve(x, new(big.Int).Sub(y, p)) {
+			t.Errorf("%s: negative coordinates are claimed to be on the curve", params.Name)
+		}
+	}
+}
+
 type baseMultTest struct {
 	k    string
 	x, y string
@@ -583,6 +600,115 @@ func BenchmarkScalarMultP256(b *testing.B) {
 	})
 }
 
+func BenchmarkScalarMultP384(b *testing.B) {
+	p384 := P384()
+	_, x, y, _ := GenerateKey(p384, rand.Reader)
+	priv, _, _, _ := GenerateKey(p384, rand.Reader)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p384.ScalarMult(x, y, priv)
+	}
+}
+
+func BenchmarkScalarMultP521(b *testing.B) {
+	p521 := P521()
+	_, x, y, _ := GenerateKey(p521, rand.Reader)
+	priv, _, _, _ := GenerateKey(p521, rand.Reader)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p521.ScalarMult(x, y, priv)
+	}
+}
+
+func TestP521MulUnreduced(t *testing.T) {
+	// The sums of p521AddLazy reach up to 2p - 2, whose product folds several
+	// bits back in the reduction.
+	pMinus1 := new(big.Int).Sub(p521P, big.NewInt(1))
+	values := []*big.Int{pMinus1, pMinus1, new(big.Int).Rsh(p521P, 1), big.NewInt(1)}
+	for i := 0; i < 100; i++ {
+		v, _ := rand.Int(rand.Reader, p521P)
+		values = append(values, v)
+	}
+	for i := 0; i+3 < len(values); i++ {
+		a, b, c := values[i], values[i+1], values[i+3]
+		var fa, fb, fc, sum, out p521Element
+		p521FromBig(&fa, a)
+		p521FromBig(&fb, b)
+		p521FromBig(&fc, c)
+		p521AddLazy(&sum, &fa, &fb)
+		p521Mul(&out, &sum, &sum)
+		expected := new(big.Int).Add(a, b)
+		expected.Mul(expected, expected).Mod(expected, p521P)
+		if p521ToBig(&out).Cmp(expected) != 0 {
+			t.Errorf("(%x + %x)² = %x, expected %x", a, b, p521ToBig(&out), expected)
+		}
+		p521Mul(&out, &sum, &fc)
+		expected.Add(a, b).Mul(expected, c).Mod(expected, p521P)
+		if p521ToBig(&out).Cmp(expected) != 0 {
+			t.Errorf("(%x + %x) %x = %x, expected %x", a, b, c, p521ToBig(&out), expected)
+		}
+	}
+}
+
+func TestScalarMultAllocations(t *testing.T) {
+	// The generic formulas reuse their temporary values across the whole
+	// ladder, so the number of allocations doesn't grow with the scalar.
+	p384 := P384()
+	_, x, y, _ := GenerateKey(p384, rand.Reader)
+	priv, _, _, _ := GenerateKey(p384, rand.Reader)
+	allocs := testing.AllocsPerRun(10, func() {
+		p384.ScalarMult(x, y, priv)
+	})
+	if allocs > 100 {
+		t.Errorf("ScalarMult made %v allocations", allocs)
+	}
+}
+
+func TestScalarMultInto(t *testing.T) {
+	for _, curve := range []Curve{P224().Params(), P384().Params(), P256()} {
+		_, x, y, _ := GenerateKey(curve, rand.Reader)
+		priv, _, _, _ := GenerateKey(curve, rand.Reader)
+		expectedX, expectedY := curve.ScalarMult(x, y, priv)
+		outX, outY := ScalarMultInto(curve, new(big.Int), new(big.Int), x, y, priv)
+		if outX.Cmp(expectedX) != 0 || outY.Cmp(expectedY) != 0 {
+			t.Errorf("%s: ScalarMultInto doesn't match ScalarMult", curve.Params().Name)
+		}
+		ScalarMultInto(curve, x, y, x, y, priv)
+		if x.Cmp(expectedX) != 0 || y.Cmp(expectedY) != 0 {
+			t.Errorf("%s: ScalarMultInto is wrong when aliasing its inputs", curve.Params().Name)
+		}
+	}
+
+	// The generic implementation reuses its results, and the temporaries of
+	// earlier calls, so a loop doesn't allocate at all. The temporaries are
+	// pooled, so the GC must not run in between.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	p224 := P224().Params()
+	_, x, y, _ := GenerateKey(p224, rand.Reader)
+	priv, _, _, _ := GenerateKey(p224, rand.Reader)
+	outX, outY := new(big.Int), new(big.Int)
+	allocs := testing.AllocsPerRun(10, func() {
+		ScalarMultInto(p224, outX, outY, x, y, priv)
+	})
+	if allocs > 0 {
+		t.Errorf("ScalarMultInto made %v allocations", allocs)
+	}
+	c, err := p224.NewComb(x, y, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocs = testing.AllocsPerRun(10, func() {
+		c.ScalarMultInto(outX, outY, priv)
+	})
+	if allocs > 0 {
+		t.Errorf("Comb.ScalarMultInto made %v allocations", allocs)
+	}
+}
+
 func TestMarshal(t *testing.T) {
 	p224 := P224()
 	_, x, y, err := GenerateKey(p224, rand.Reader)
@@ -602,6 +728,71 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestMarshalNat(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		name := curve.Params().Name
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		xNat, yNat := new(safenum.Nat).SetBytes(x.Bytes()), new(safenum.Nat).SetBytes(y.Bytes())
+		uncompressed := MarshalNat(curve, xNat, yNat)
+		if !bytes.Equal(uncompressed, Marshal(curve, x, y)) {
+			t.Errorf("%s: MarshalNat and Marshal differ", name)
+		}
+		compressed := MarshalCompressedNat(curve, xNat, yNat)
+		if !bytes.Equal(compressed, MarshalCompressed(curve, x, y)) {
+			t.Errorf("%s: MarshalCompressedNat and MarshalCompressed differ", name)
+		}
+		if xx, yy := UnmarshalNat(curve, uncompressed); xx == nil || xx.Cmp(xNat) != 0 || yy.Cmp(yNat) != 0 {
+			t.Errorf("%s: UnmarshalNat didn't round trip", name)
+		}
+		if xx, yy := UnmarshalCompressedNat(curve, compressed); xx == nil || xx.Cmp(xNat) != 0 || yy.Cmp(yNat) != 0 {
+			t.Errorf("%s: UnmarshalCompressedNat didn't round trip", name)
+		}
+		if xx, _ := UnmarshalNat(curve, compressed); xx != nil {
+			t.Errorf("%s: UnmarshalNat accepted a compressed point", name)
+		}
+		if xx, _ := UnmarshalCompressedNat(curve, uncompressed); xx != nil {
+			t.Errorf("%s: UnmarshalCompressedNat accepted an uncompressed point", name)
+		}
+
+		// Coordinates are reduced, rather than overflowing their encoding.
+		p := new(big.Int).SetBytes(curve.Params().P.Bytes())
+		unreduced := new(safenum.Nat).SetBytes(new(big.Int).Add(y, p).Bytes())
+		if !bytes.Equal(MarshalNat(curve, xNat, unreduced), uncompressed) {
+			t.Errorf("%s: MarshalNat didn't reduce its input", name)
+		}
+	}
+}
+
+func TestRandomScalar(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		N := new(big.Int).SetBytes(curve.Params().N.Bytes())
+		byteLen := (N.BitLen() + 7) / 8
+		nMinusOne := new(big.Int).Sub(N, big.NewInt(1))
+		for _, b := range [][]byte{
+			make([]byte, 2*byteLen),
+			bytes.Repeat([]byte{0xff}, 2*byteLen),
+			nMinusOne.FillBytes(make([]byte, 2*byteLen)),
+			N.FillBytes(make([]byte, 2*byteLen)),
+		} {
+			k, err := RandomScalar(curve, bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := new(big.Int).SetBytes(b)
+			want.Mod(want, nMinusOne).Add(want, big.NewInt(1))
+			if len(k) != byteLen || new(big.Int).SetBytes(k).Cmp(want) != 0 {
+				t.Errorf("%s: RandomScalar(%x) = %x, expected %x", curve.Params().Name, b, k, want)
+			}
+		}
+		if _, err := RandomScalar(curve, bytes.NewReader(make([]byte, 2*byteLen-1))); err == nil {
+			t.Errorf("%s: short read accepted", curve.Params().Name)
+		}
+	}
+}
+
 func TestP224Overflow(t *testing.T) {
 	// This tests for a specific bug in the P224 implementation.
 	p224 := P224()
@@ -651,6 +842,59 @@ func TestUnmarshalToLargeCoordinates(t *testing.T) {
 	}
 }
 
+func TestUnmarshalRejects(t *testing.T) {
+	curve := P256()
+	_, x, y, _ := GenerateKey(curve, rand.Reader)
+	valid := Marshal(curve, x, y)
+	compressed := MarshalCompressed(curve, x, y)
+	modify := func(b []byte, i int, v byte) []byte {
+		b = append([]byte{}, b...)
+		b[i] = v
+		return b
+	}
+	p := curve.Params().P.Bytes()
+	p = p[len(p)-32:]
+	invalid := map[string][]byte{
+		"short":          valid[:64],
+		"long":           append(append([]byte{}, valid...), 0),
+		"prefix":         modify(valid, 0, 3),
+		"x out of range": append(append([]byte{4}, p...), valid[33:]...),
+		"not on curve":   modify(valid, 64, valid[64]^1),
+	}
+	invalidCompressed := map[string][]byte{
+		"short":          compressed[:32],
+		"prefix":         modify(compressed, 0, 4),
+		"x out of range": append([]byte{2}, p...),
+		// x = 2 gives a non-square x³ - 3x + b.
+		"not on curve": append([]byte{2}, make([]byte, 32)...),
+	}
+	invalidCompressed["not on curve"][32] = 2
+
+	// Every rejection should do the same work, which shows in allocations.
+	allocs := -1.0
+	for name, data := range invalid {
+		if X, Y := Unmarshal(curve, data); X != nil || Y != nil {
+			t.Errorf("Unmarshal accepted an invalid encoding: %s", name)
+		}
+		n := testing.AllocsPerRun(10, func() { Unmarshal(curve, data) })
+		if allocs >= 0 && n != allocs {
+			t.Errorf("Unmarshal rejected %s with %v allocations, not %v", name, n, allocs)
+		}
+		allocs = n
+	}
+	allocs = -1
+	for name, data := range invalidCompressed {
+		if X, Y := UnmarshalCompressed(curve, data); X != nil || Y != nil {
+			t.Errorf("UnmarshalCompressed accepted an invalid encoding: %s", name)
+		}
+		n := testing.AllocsPerRun(10, func() { UnmarshalCompressed(curve, data) })
+		if allocs >= 0 && n != allocs {
+			t.Errorf("UnmarshalCompressed rejected %s with %v allocations, not %v", name, n, allocs)
+		}
+		allocs = n
+	}
+}
+
 func TestMarshalCompressed(t *testing.T) {
 	t.Run("P-256/03", func(t *testing.T) {
 		data, _ := hex.DecodeString("031e3987d9f9ea9d7dd7155a56a86b2009e1e0ab332f962d10d8beb6406ab1ad79")
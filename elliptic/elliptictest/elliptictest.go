@@ -0,0 +1,224 @@
+// Package elliptictest checks implementations of elliptic.Curve, like the
+// backends generated by curvegen, against a reference implementation, on
+// random inputs, and on the edge cases where they usually go wrong.
+//
+// The reference is the curve of crypto/elliptic with the same name and
+// parameters, if there is one, and the generic implementation provided by the
+// methods of the CurveParams of the curve otherwise. A curve which is its own
+// CurveParams is thus only compared to itself, unless it is a standard one.
+//
+// TestLaws complements that comparison by checking properties which any
+// curve of prime order has, like the associativity of addition, or the order
+// of its points, which hold whatever the reference.
+package elliptictest
+
+import (
+	stdelliptic "crypto/elliptic"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+)
+
+// RandomInputs is the number of random scalars and points TestCurve checks,
+// in addition to the edge cases.
+const RandomInputs = 16
+
+// reference is the part of the Curve interface shared by the curves of this
+// module and those of crypto/elliptic.
+type reference interface {
+	Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int)
+	Double(x1, y1 *big.Int) (x, y *big.Int)
+	ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int)
+	ScalarBaseMult(k []byte) (x, y *big.Int)
+}
+
+// referenceFor returns the reference implementation of curve.
+func referenceFor(curve elliptic.Curve) reference {
+	params := curve.Params()
+	for _, std := range []stdelliptic.Curve{stdelliptic.P224(), stdelliptic.P256(), stdelliptic.P384(), stdelliptic.P521()} {
+		sp := std.Params()
+		if sp.Name == params.Name &&
+			sp.P.Cmp(new(big.Int).SetBytes(params.P.Bytes())) == 0 &&
+			sp.N.Cmp(new(big.Int).SetBytes(params.N.Bytes())) == 0 &&
+			sp.B.Cmp(new(big.Int).SetBytes(params.B.Bytes())) == 0 &&
+			sp.Gx.Cmp(new(big.Int).SetBytes(params.Gx.Bytes())) == 0 &&
+			sp.Gy.Cmp(new(big.Int).SetBytes(params.Gy.Bytes())) == 0 {
+			return std
+		}
+	}
+	return params
+}
+
+// checker holds the state of TestCurve.
+type checker struct {
+	curve elliptic.Curve
+	ref   reference
+	p, n  *big.Int
+	// byteLen is the length of the encoding of a scalar.
+	byteLen int
+}
+
+// TestCurve checks that curve computes the same results as its reference
+// implementation, on edge cases, and on RandomInputs random scalars and
+// points, read from rand. It returns an error describing the first
+// difference found, or nil.
+//
+// The edge cases include the scalars 0, 1, N-1, N, and N+1, scalars longer
+// than N, and additions involving the point at infinity, a point and its
+// negation, or a point and itself. TestCurve also checks that IsOnCurve
+// rejects the point at infinity, points off the curve, and points with
+// negative or unreduced coordinates.
+//
+// If rand is nil, the health-tested Reader of the rand package of this module
+// is used.
+func TestCurve(curve elliptic.Curve, rand io.Reader) error {
+	params := curve.Params()
+	c := &checker{
+		curve:   curve,
+		ref:     referenceFor(curve),
+		p:       new(big.Int).SetBytes(params.P.Bytes()),
+		n:       new(big.Int).SetBytes(params.N.Bytes()),
+		byteLen: (int(params.N.BitLen()) + 7) / 8,
+	}
+	rand = randutil.Or(rand)
+
+	gx, gy := new(big.Int).SetBytes(params.Gx.Bytes()), new(big.Int).SetBytes(params.Gy.Bytes())
+	if !curve.IsOnCurve(gx, gy) {
+		return c.errorf("the generator isn't on the curve")
+	}
+
+	scalars := c.edgeScalars()
+	for i := 0; i < RandomInputs; i++ {
+		k := make([]byte, c.byteLen)
+		if _, err := io.ReadFull(rand, k); err != nil {
+			return err
+		}
+		scalars = append(scalars, k)
+	}
+	// The points multiplied are computed by the reference, so that a wrong
+	// ScalarBaseMult doesn't hide the differences of ScalarMult.
+	x, y := c.ref.ScalarBaseMult(scalars[len(scalars)-1])
+	for _, k := range scalars {
+		rx, ry := c.curve.ScalarBaseMult(k)
+		wx, wy := c.ref.ScalarBaseMult(k)
+		if err := c.compare(fmt.Sprintf("ScalarBaseMult(%x)", k), rx, ry, wx, wy); err != nil {
+			return err
+		}
+		rx, ry = c.curve.ScalarMult(x, y, k)
+		wx, wy = c.ref.ScalarMult(x, y, k)
+		if err := c.compare(fmt.Sprintf("ScalarMult(P, %x)", k), rx, ry, wx, wy); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < len(scalars)-1; i++ {
+		x1, y1 := c.ref.ScalarBaseMult(scalars[i])
+		x2, y2 := c.ref.ScalarBaseMult(scalars[i+1])
+		if err := c.checkAdd(x1, y1, x2, y2); err != nil {
+			return err
+		}
+	}
+
+	return c.checkIsOnCurve(x, y)
+}
+
+// edgeScalars returns the scalars most likely to be mishandled.
+func (c *checker) edgeScalars() [][]byte {
+	one := big.NewInt(1)
+	long := make([]byte, c.byteLen+8)
+	long[len(long)-1] = 1
+	ones := make([]byte, c.byteLen)
+	for i := range ones {
+		ones[i] = 0xff
+	}
+	return [][]byte{
+		nil,
+		{0},
+		{1},
+		{2},
+		make([]byte, c.byteLen),
+		new(big.Int).Sub(c.n, one).FillBytes(make([]byte, c.byteLen)),
+		c.n.FillBytes(make([]byte, c.byteLen)),
+		new(big.Int).Add(c.n, one).Bytes(),
+		ones,
+		long,
+	}
+}
+
+// checkAdd compares Add and Double on the points (x1, y1) and (x2, y2), and
+// the edge cases derived from them, which must be on the curve, or the point
+// at infinity.
+func (c *checker) checkAdd(x1, y1, x2, y2 *big.Int) error {
+	zero := new(big.Int)
+	negY1 := new(big.Int).Sub(c.p, y1)
+	if y1.Sign() == 0 {
+		negY1.SetInt64(0)
+	}
+	for _, tc := range []struct {
+		name           string
+		x1, y1, x2, y2 *big.Int
+	}{
+		{"P + Q", x1, y1, x2, y2},
+		{"P + P", x1, y1, x1, y1},
+		{"P + (-P)", x1, y1, x1, negY1},
+		{"P + ∞", x1, y1, zero, zero},
+		{"∞ + P", zero, zero, x1, y1},
+		{"∞ + ∞", zero, zero, zero, zero},
+	} {
+		rx, ry := c.curve.Add(tc.x1, tc.y1, tc.x2, tc.y2)
+		wx, wy := c.ref.Add(tc.x1, tc.y1, tc.x2, tc.y2)
+		if err := c.compare(tc.name, rx, ry, wx, wy); err != nil {
+			return err
+		}
+	}
+	rx, ry := c.curve.Double(x1, y1)
+	wx, wy := c.ref.Double(x1, y1)
+	if err := c.compare("Double(P)", rx, ry, wx, wy); err != nil {
+		return err
+	}
+	rx, ry = c.curve.Double(zero, zero)
+	return c.compare("Double(∞)", rx, ry, zero, zero)
+}
+
+// checkIsOnCurve checks IsOnCurve on (x, y), which is on the curve, and on
+// variations of it which aren't.
+func (c *checker) checkIsOnCurve(x, y *big.Int) error {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil
+	}
+	if !c.curve.IsOnCurve(x, y) {
+		return c.errorf("IsOnCurve rejected (%x, %x)", x, y)
+	}
+	one := big.NewInt(1)
+	for _, tc := range []struct {
+		name string
+		x, y *big.Int
+	}{
+		{"the point at infinity", new(big.Int), new(big.Int)},
+		{"a point off the curve", x, new(big.Int).Add(y, one)},
+		{"an unreduced x", new(big.Int).Add(x, c.p), y},
+		{"an unreduced y", x, new(big.Int).Add(y, c.p)},
+		{"a negative y", x, new(big.Int).Sub(y, c.p)},
+	} {
+		if c.curve.IsOnCurve(tc.x, tc.y) {
+			return c.errorf("IsOnCurve accepted %s", tc.name)
+		}
+	}
+	return nil
+}
+
+// compare checks that the result (rx, ry) of op matches the one of the
+// reference, (wx, wy).
+func (c *checker) compare(op string, rx, ry, wx, wy *big.Int) error {
+	if rx == nil || ry == nil || rx.Cmp(wx) != 0 || ry.Cmp(wy) != 0 {
+		return c.errorf("%s = (%x, %x), expected (%x, %x)", op, rx, ry, wx, wy)
+	}
+	return nil
+}
+
+func (c *checker) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("elliptictest: %s: "+format, append([]interface{}{c.curve.Params().Name}, args...)...)
+}
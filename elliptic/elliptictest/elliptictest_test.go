@@ -0,0 +1,104 @@
+package elliptictest
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+)
+
+func TestCurves(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		if _, ok := referenceFor(curve).(*elliptic.CurveParams); ok {
+			t.Errorf("%s: not compared to crypto/elliptic", curve.Params().Name)
+		}
+		if err := TestCurve(curve, nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestP256Backends(t *testing.T) {
+	defer elliptic.SetP256Backend(elliptic.P256Backend())
+	for _, name := range elliptic.P256Backends() {
+		if err := elliptic.SetP256Backend(name); err != nil {
+			t.Fatal(err)
+		}
+		if err := TestCurve(elliptic.P256(), nil); err != nil {
+			t.Errorf("%s backend: %v", name, err)
+		}
+	}
+}
+
+// renamed is a curve whose parameters are renamed, so that it is compared to
+// the generic implementation.
+type renamed struct {
+	elliptic.Curve
+	params *elliptic.CurveParams
+}
+
+func (curve renamed) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+func rename(curve elliptic.Curve) renamed {
+	params := *curve.Params()
+	params.Name = "renamed " + params.Name
+	return renamed{curve, &params}
+}
+
+func TestGenericReference(t *testing.T) {
+	curve := rename(elliptic.P256())
+	if _, ok := referenceFor(curve).(*elliptic.CurveParams); !ok {
+		t.Fatal("renamed curve compared to crypto/elliptic")
+	}
+	if err := TestCurve(curve, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// broken is a curve with a bug, which TestCurve must find.
+type broken struct {
+	renamed
+	bug string
+}
+
+func (curve broken) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if curve.bug == "Add" && x1.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+	return curve.renamed.Add(x1, y1, x2, y2)
+}
+
+func (curve broken) ScalarMult(x, y *big.Int, k []byte) (*big.Int, *big.Int) {
+	if curve.bug == "ScalarMult" && len(k) == (int(curve.Params().N.BitLen())+7)/8 {
+		k = k[1:]
+	}
+	return curve.renamed.ScalarMult(x, y, k)
+}
+
+func (curve broken) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	if curve.bug == "ScalarBaseMult" && len(k) == 0 {
+		return new(big.Int).SetBytes(curve.Params().Gx.Bytes()), new(big.Int).SetBytes(curve.Params().Gy.Bytes())
+	}
+	return curve.renamed.ScalarBaseMult(k)
+}
+
+func (curve broken) IsOnCurve(x, y *big.Int) bool {
+	if curve.bug == "IsOnCurve" {
+		p := new(big.Int).SetBytes(curve.Params().P.Bytes())
+		x = new(big.Int).Mod(x, p)
+	}
+	return curve.renamed.IsOnCurve(x, y)
+}
+
+func TestBrokenCurves(t *testing.T) {
+	for _, bug := range []string{"Add", "ScalarMult", "ScalarBaseMult", "IsOnCurve"} {
+		curve := broken{rename(elliptic.P224()), bug}
+		if err := TestCurve(curve, nil); err == nil {
+			t.Errorf("%s bug not found", bug)
+		} else {
+			t.Log(err)
+		}
+	}
+}
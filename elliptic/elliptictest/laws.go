@@ -0,0 +1,215 @@
+package elliptictest
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+)
+
+// TestLaws checks that the points of curve form a group of order N, without
+// comparing it to another implementation: the group laws, the order of random
+// points, the round trips of Marshal and MarshalCompressed, and, for the
+// curves which HashToCurve supports, that hashing returns valid points. It
+// reads the random scalars it uses from rand, and returns an error describing
+// the first law which doesn't hold, or nil.
+//
+// If rand is nil, the health-tested Reader of the rand package of this module
+// is used.
+func TestLaws(curve elliptic.Curve, rand io.Reader) error {
+	params := curve.Params()
+	c := &checker{
+		curve:   curve,
+		p:       new(big.Int).SetBytes(params.P.Bytes()),
+		n:       new(big.Int).SetBytes(params.N.Bytes()),
+		byteLen: (int(params.N.BitLen()) + 7) / 8,
+	}
+	rand = randutil.Or(rand)
+
+	scalars := make([]*big.Int, 3)
+	points := make([][2]*big.Int, 3)
+	for i := range scalars {
+		k, err := elliptic.RandomScalar(curve, rand)
+		if err != nil {
+			return err
+		}
+		scalars[i] = new(big.Int).SetBytes(k)
+		points[i][0], points[i][1] = curve.ScalarBaseMult(k)
+		if !curve.IsOnCurve(points[i][0], points[i][1]) {
+			return c.errorf("%x * G isn't on the curve", k)
+		}
+	}
+	for _, check := range []func() error{
+		func() error { return c.checkGroupLaws(points[0], points[1], points[2]) },
+		func() error { return c.checkScalarLaws(scalars[0], scalars[1]) },
+		func() error { return c.checkOrder(points[0]) },
+		func() error { return c.checkMarshal(points[0]) },
+		c.checkHashing,
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equal reports whether (x1, y1) and (x2, y2) are the same point.
+func equal(x1, y1, x2, y2 *big.Int) bool {
+	return x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
+
+// negate returns the inverse of a point other than the point at infinity.
+func (c *checker) negate(x, y *big.Int) (*big.Int, *big.Int) {
+	return x, new(big.Int).Sub(c.p, y)
+}
+
+func (c *checker) checkGroupLaws(P, Q, R [2]*big.Int) error {
+	curve := c.curve
+	zero := new(big.Int)
+	abX, abY := curve.Add(P[0], P[1], Q[0], Q[1])
+	x1, y1 := curve.Add(abX, abY, R[0], R[1])
+	bcX, bcY := curve.Add(Q[0], Q[1], R[0], R[1])
+	x2, y2 := curve.Add(P[0], P[1], bcX, bcY)
+	if !equal(x1, y1, x2, y2) {
+		return c.errorf("addition isn't associative")
+	}
+	if x, y := curve.Add(Q[0], Q[1], P[0], P[1]); !equal(x, y, abX, abY) {
+		return c.errorf("addition isn't commutative")
+	}
+	if x, y := curve.Add(P[0], P[1], zero, zero); !equal(x, y, P[0], P[1]) {
+		return c.errorf("P + ∞ isn't P")
+	}
+	if x, y := curve.Add(zero, zero, P[0], P[1]); !equal(x, y, P[0], P[1]) {
+		return c.errorf("∞ + P isn't P")
+	}
+	negX, negY := c.negate(P[0], P[1])
+	if !curve.IsOnCurve(negX, negY) {
+		return c.errorf("-P isn't on the curve")
+	}
+	if x, y := curve.Add(P[0], P[1], negX, negY); !isInfinity(x, y) {
+		return c.errorf("P + (-P) isn't the point at infinity")
+	}
+	dX, dY := curve.Double(P[0], P[1])
+	if x, y := curve.Add(P[0], P[1], P[0], P[1]); !equal(x, y, dX, dY) {
+		return c.errorf("P + P isn't 2P")
+	}
+	if x, y := curve.Add(dX, dY, negX, negY); !equal(x, y, P[0], P[1]) {
+		return c.errorf("2P - P isn't P")
+	}
+	for _, S := range [][2]*big.Int{{abX, abY}, {x1, y1}, {dX, dY}} {
+		if !curve.IsOnCurve(S[0], S[1]) {
+			return c.errorf("a sum isn't on the curve")
+		}
+	}
+	return nil
+}
+
+// scalar encodes k, reduced modulo N.
+func (c *checker) scalar(k *big.Int) []byte {
+	return new(big.Int).Mod(k, c.n).FillBytes(make([]byte, c.byteLen))
+}
+
+func (c *checker) checkScalarLaws(a, b *big.Int) error {
+	curve := c.curve
+	aX, aY := curve.ScalarBaseMult(c.scalar(a))
+	bX, bY := curve.ScalarBaseMult(c.scalar(b))
+	sX, sY := curve.Add(aX, aY, bX, bY)
+	if x, y := curve.ScalarBaseMult(c.scalar(new(big.Int).Add(a, b))); !equal(x, y, sX, sY) {
+		return c.errorf("aG + bG isn't (a + b)G")
+	}
+	abX, abY := curve.ScalarBaseMult(c.scalar(new(big.Int).Mul(a, b)))
+	if x, y := curve.ScalarMult(aX, aY, c.scalar(b)); !equal(x, y, abX, abY) {
+		return c.errorf("b(aG) isn't (ab)G")
+	}
+	if x, y := curve.ScalarMult(bX, bY, c.scalar(a)); !equal(x, y, abX, abY) {
+		return c.errorf("a(bG) isn't (ab)G")
+	}
+	params := curve.Params()
+	gx, gy := new(big.Int).SetBytes(params.Gx.Bytes()), new(big.Int).SetBytes(params.Gy.Bytes())
+	if x, y := curve.ScalarMult(gx, gy, c.scalar(a)); !equal(x, y, aX, aY) {
+		return c.errorf("ScalarMult and ScalarBaseMult disagree")
+	}
+	if x, y := curve.ScalarMult(aX, aY, []byte{1}); !equal(x, y, aX, aY) {
+		return c.errorf("1 * P isn't P")
+	}
+	if x, y := curve.ScalarMult(aX, aY, make([]byte, c.byteLen)); !isInfinity(x, y) {
+		return c.errorf("0 * P isn't the point at infinity")
+	}
+	return nil
+}
+
+// checkOrder checks that P and G have order N.
+func (c *checker) checkOrder(P [2]*big.Int) error {
+	curve := c.curve
+	params := curve.Params()
+	G := [2]*big.Int{new(big.Int).SetBytes(params.Gx.Bytes()), new(big.Int).SetBytes(params.Gy.Bytes())}
+	nMinusOne := c.scalar(new(big.Int).Sub(c.n, big.NewInt(1)))
+	for _, Q := range [][2]*big.Int{G, P} {
+		negX, negY := c.negate(Q[0], Q[1])
+		if x, y := curve.ScalarMult(Q[0], Q[1], nMinusOne); !equal(x, y, negX, negY) {
+			return c.errorf("(N - 1)P isn't -P")
+		}
+		if x, y := curve.ScalarMult(Q[0], Q[1], c.n.Bytes()); !isInfinity(x, y) {
+			return c.errorf("N * P isn't the point at infinity")
+		}
+	}
+	negX, negY := c.negate(G[0], G[1])
+	if x, y := curve.ScalarBaseMult(nMinusOne); !equal(x, y, negX, negY) {
+		return c.errorf("(N - 1)G isn't -G")
+	}
+	return nil
+}
+
+func (c *checker) checkMarshal(P [2]*big.Int) error {
+	curve := c.curve
+	enc := elliptic.Marshal(curve, P[0], P[1])
+	if x, y := elliptic.Unmarshal(curve, enc); x == nil || !equal(x, y, P[0], P[1]) {
+		return c.errorf("Marshal didn't round trip")
+	}
+	compressed := elliptic.MarshalCompressed(curve, P[0], P[1])
+	if x, y := elliptic.UnmarshalCompressed(curve, compressed); x == nil || !equal(x, y, P[0], P[1]) {
+		return c.errorf("MarshalCompressed didn't round trip")
+	}
+	negX, negY := c.negate(P[0], P[1])
+	if bytes.Equal(elliptic.MarshalCompressed(curve, negX, negY), compressed) {
+		return c.errorf("P and -P have the same compressed encoding")
+	}
+	if x, _ := elliptic.Unmarshal(curve, elliptic.Marshal(curve, P[0], new(big.Int).Add(P[1], big.NewInt(1)))); x != nil {
+		return c.errorf("Unmarshal accepted a point off the curve")
+	}
+	if x, _ := elliptic.Unmarshal(curve, enc[1:]); x != nil {
+		return c.errorf("Unmarshal accepted a short encoding")
+	}
+	return nil
+}
+
+// checkHashing checks HashToCurve, for the curves it supports.
+func (c *checker) checkHashing() error {
+	curve := c.curve
+	switch curve.Params().Name {
+	case "P-256", "P-384", "P-521":
+	default:
+		return nil
+	}
+	msg, dst := []byte("message"), []byte("elliptictest")
+	x, y := elliptic.HashToCurve(curve, msg, dst)
+	if !curve.IsOnCurve(x, y) {
+		return c.errorf("HashToCurve returned a point off the curve")
+	}
+	if x2, y2 := elliptic.HashToCurve(curve, msg, dst); !equal(x, y, x2, y2) {
+		return c.errorf("HashToCurve isn't deterministic")
+	}
+	if x2, y2 := elliptic.HashToCurve(curve, msg, []byte("other DST")); equal(x, y, x2, y2) {
+		return c.errorf("HashToCurve doesn't depend on its inputs")
+	}
+	if x, y := curve.ScalarMult(x, y, c.n.Bytes()); !isInfinity(x, y) {
+		return c.errorf("a hashed point doesn't have order N")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package elliptictest
+
+import (
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+)
+
+func TestLawsOfCurves(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521(), rename(elliptic.P256())} {
+		if err := TestLaws(curve, nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestLawsOfBrokenCurves(t *testing.T) {
+	for _, bug := range []string{"Add", "ScalarMult"} {
+		curve := broken{rename(elliptic.P224()), bug}
+		if err := TestLaws(curve, nil); err == nil {
+			t.Errorf("%s bug not found", bug)
+		} else {
+			t.Log(err)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package elliptic
+
+import (
+	"errors"
+	"math/big"
+)
+
+// FixedPoint is a point prepared for repeated multiplications, like a
+// long-lived public key, or the generator of a commitment scheme.
+//
+// For the generic CurveParams implementation, this holds a comb table, making
+// multiplications as fast as with ScalarBaseMult, whose size is bounded by
+// the budget set with SetPrecomputationBudget. The dedicated
+// implementations, like P-256, already have fast, constant-time multiplication
+// of arbitrary points, which a generic table would be slower than, so
+// FixedPoint uses that instead.
+//
+// A FixedPoint is safe for concurrent use.
+type FixedPoint struct {
+	curve Curve
+	x, y  *big.Int
+	comb  *Comb
+}
+
+// NewFixedPoint prepares the point (x, y) of a curve for repeated
+// multiplications, returning an error if it isn't on the curve.
+func NewFixedPoint(curve Curve, x, y *big.Int) (*FixedPoint, error) {
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("elliptic: point not on curve")
+	}
+	p := &FixedPoint{curve: curve, x: new(big.Int).Set(x), y: new(big.Int).Set(y)}
+	if params, ok := curve.(*CurveParams); ok {
+		if teeth := params.combTeeth(); teeth > 0 {
+			comb, err := params.NewComb(x, y, teeth)
+			if err != nil {
+				return nil, err
+			}
+			p.comb = comb
+		}
+	}
+	return p, nil
+}
+
+// Point returns the coordinates of the point.
+func (p *FixedPoint) Point() (x, y *big.Int) {
+	return new(big.Int).Set(p.x), new(big.Int).Set(p.y)
+}
+
+// ScalarMult returns k*(x,y), where k is an integer in big-endian form.
+func (p *FixedPoint) ScalarMult(k []byte) (x, y *big.Int) {
+	if p.comb != nil {
+		return p.comb.ScalarMult(k)
+	}
+	return p.curve.ScalarMult(p.x, p.y, k)
+}
@@ -0,0 +1,35 @@
+package elliptic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestFixedPoint(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := NewFixedPoint(curve, x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			k := make([]byte, (curve.Params().BitSize+7)/8)
+			rand.Read(k)
+			expectedX, expectedY := curve.ScalarMult(x, y, k)
+			gotX, gotY := p.ScalarMult(k)
+			if gotX.Cmp(expectedX) != 0 || gotY.Cmp(expectedY) != 0 {
+				t.Errorf("%s: wrong result for k = %x", curve.Params().Name, k)
+			}
+		}
+		if px, py := p.Point(); px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+			t.Errorf("%s: Point() doesn't return the point", curve.Params().Name)
+		}
+		if _, err := NewFixedPoint(curve, x, new(big.Int).Add(y, big.NewInt(1))); err == nil {
+			t.Errorf("%s: point off the curve accepted", curve.Params().Name)
+		}
+	}
+}
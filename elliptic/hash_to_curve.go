@@ -0,0 +1,158 @@
+package elliptic
+
+// This file implements hashing to the NIST curves, following RFC 9380.
+//
+// Only the suites based on expand_message_xmd and the simplified SWU map are
+// supported, since these are the ones standardized for P-256, P-384, and P-521.
+// The field arithmetic is done with safenum, with the choices made by the
+// mapping done with constant-time selection, so that the time taken to hash
+// a message doesn't depend on the message.
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"hash"
+	"math/big"
+	"sync"
+
+	"github.com/cronokirby/safenum"
+)
+
+// The standard suite identifiers for hashing to the NIST curves, as defined
+// in RFC 9380, section 8.2.
+//
+// The _RO_ suites are used by HashToCurve, and the _NU_ suites by EncodeToCurve.
+// Applications should include the suite identifier in their domain separation
+// tag, as recommended by RFC 9380, section 3.1.
+const (
+	SuiteP256RO = "P256_XMD:SHA-256_SSWU_RO_"
+	SuiteP256NU = "P256_XMD:SHA-256_SSWU_NU_"
+	SuiteP384RO = "P384_XMD:SHA-384_SSWU_RO_"
+	SuiteP384NU = "P384_XMD:SHA-384_SSWU_NU_"
+	SuiteP521RO = "P521_XMD:SHA-512_SSWU_RO_"
+	SuiteP521NU = "P521_XMD:SHA-512_SSWU_NU_"
+)
+
+// h2cSuite contains the parameters needed to hash to a given curve.
+type h2cSuite struct {
+	// the hash function used by expand_message_xmd
+	hash func() hash.Hash
+	// the target security level, in bits, called k in RFC 9380
+	k int
+	// the (negated) Z parameter of the simplified SWU map
+	negZ uint64
+
+	// The map is created lazily, from the curve parameters.
+	once sync.Once
+	sswu *SSWU
+}
+
+var (
+	p256Suite = h2cSuite{hash: sha256.New, k: 128, negZ: 10}
+	p384Suite = h2cSuite{hash: sha512.New384, k: 192, negZ: 12}
+	p521Suite = h2cSuite{hash: sha512.New, k: 256, negZ: 4}
+)
+
+// suiteFor returns the hash-to-curve suite associated with a curve, panicking
+// if that curve isn't supported.
+func suiteFor(curve *CurveParams) *h2cSuite {
+	var suite *h2cSuite
+	switch curve.Name {
+	case "P-256":
+		suite = &p256Suite
+	case "P-384":
+		suite = &p384Suite
+	case "P-521":
+		suite = &p521Suite
+	default:
+		panic("elliptic: hashing to " + curve.Name + " is not supported")
+	}
+	suite.once.Do(func() { suite.init(curve) })
+	return suite
+}
+
+func (suite *h2cSuite) init(curve *CurveParams) {
+	// All of the NIST curves have A = -3.
+	a := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(3), curve.P)
+	z := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(suite.negZ), curve.P)
+	sswu, err := NewSSWU(curve.P, a, curve.B, z)
+	if err != nil {
+		panic(err)
+	}
+	suite.sswu = sswu
+}
+
+// shiftRight returns x >> n, for public values of x.
+func shiftRight(x *safenum.Nat, n uint, size uint) *safenum.Nat {
+	xBig := new(big.Int).SetBytes(x.Bytes())
+	xBig.Rsh(xBig, n)
+	out := make([]byte, (size+7)/8)
+	return new(safenum.Nat).SetBytes(xBig.FillBytes(out))
+}
+
+// ctSelect sets z to a if v == 1, and to b if v == 0, without leaking v.
+//
+// Both a and b must be reduced modulo m.
+func ctSelect(z, a, b *safenum.Nat, v int, m *safenum.Modulus) *safenum.Nat {
+	aBytes := a.Bytes()
+	bBytes := b.Bytes()
+	subtle.ConstantTimeCopy(v, bBytes, aBytes)
+	z.SetBytes(bBytes)
+	return z.Mod(z, m)
+}
+
+// ctEq returns 1 if a == b, and 0 otherwise, without leaking the result.
+func ctEq(a, b *safenum.Nat) int {
+	return subtle.ConstantTimeEq(int32(a.Cmp(b)), 0)
+}
+
+// sgn0 returns the parity of x, which must be reduced.
+func sgn0(x *safenum.Nat) int {
+	xBytes := x.Bytes()
+	return int(xBytes[len(xBytes)-1] & 1)
+}
+
+func natToBig(x *safenum.Nat) *big.Int {
+	return new(big.Int).SetBytes(x.Bytes())
+}
+
+// HashToCurve hashes an arbitrary message to a point on the curve, following
+// the hash_to_curve procedure of RFC 9380. The output is indistinguishable from
+// a random point, and nobody learns its discrete logarithm.
+//
+// The dst parameter is the domain separation tag, which should be unique to
+// each application, as described in RFC 9380, section 3.1.
+//
+// Only P-256, P-384, and P-521 are supported, using the SuiteP256RO,
+// SuiteP384RO and SuiteP521RO suites respectively. This function will panic
+// for any other curve.
+func HashToCurve(curve Curve, msg, dst []byte) (x, y *big.Int) {
+	params := curve.Params()
+	suite := suiteFor(params)
+	u := suite.hashToField(params.P, msg, dst, 2)
+	x0, y0 := suite.sswu.Map(u[0])
+	x1, y1 := suite.sswu.Map(u[1])
+	// All of the supported curves have a cofactor of 1, so there's no need
+	// to clear it.
+	return curve.Add(natToBig(x0), natToBig(y0), natToBig(x1), natToBig(y1))
+}
+
+// EncodeToCurve hashes an arbitrary message to a point on the curve, following
+// the encode_to_curve procedure of RFC 9380.
+//
+// This is cheaper than HashToCurve, but the output distribution is not uniform,
+// and only covers about half of the points of the curve. Unless an application
+// specifically calls for this nonuniform encoding, HashToCurve should be
+// preferred.
+//
+// Only P-256, P-384, and P-521 are supported, using the SuiteP256NU,
+// SuiteP384NU and SuiteP521NU suites respectively. This function will panic
+// for any other curve.
+func EncodeToCurve(curve Curve, msg, dst []byte) (x, y *big.Int) {
+	params := curve.Params()
+	suite := suiteFor(params)
+	u := suite.hashToField(params.P, msg, dst, 1)
+	x0, y0 := suite.sswu.Map(u[0])
+	return natToBig(x0), natToBig(y0)
+}
@@ -0,0 +1,94 @@
+package elliptic
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+type hashToCurveTest struct {
+	msg  string
+	x, y string
+}
+
+var p256HashToCurveTests = []hashToCurveTest{
+	{
+		"",
+		"2c15230b26dbc6fc9a37051158c95b79656e17a1a920b11394ca91c44247d3e4",
+		"8a7a74985cc5c776cdfe4b1f19884970453912e9d31528c060be9ab5c43e8415",
+	},
+	{
+		"abc",
+		"0bb8b87485551aa43ed54f009230450b492fead5f1cc91658775dac4a3388a0f",
+		"5c41b3d0731a27a7b14bc0bf0ccded2d8751f83493404c84a88e71ffd424212e",
+	},
+}
+
+func TestHashToCurveP256(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-" + SuiteP256RO)
+	for _, test := range p256HashToCurveTests {
+		x, y := HashToCurve(P256(), []byte(test.msg), dst)
+		if hex.EncodeToString(x.FillBytes(make([]byte, 32))) != test.x ||
+			hex.EncodeToString(y.FillBytes(make([]byte, 32))) != test.y {
+			t.Errorf("HashToCurve(%q) = (%x, %x), expected (%s, %s)", test.msg, x, y, test.x, test.y)
+		}
+	}
+}
+
+func TestEncodeToCurveP256(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-" + SuiteP256NU)
+	x, y := EncodeToCurve(P256(), []byte(""), dst)
+	expectedX := "f871caad25ea3b59c16cf87c1894902f7e7b2c822c3d3f73596c5ace8ddd14d1"
+	expectedY := "87b9ae23335bee057b99bac1e68588b18b5691af476234b8971bc4f011ddc99b"
+	if hex.EncodeToString(x.FillBytes(make([]byte, 32))) != expectedX ||
+		hex.EncodeToString(y.FillBytes(make([]byte, 32))) != expectedY {
+		t.Errorf("EncodeToCurve(\"\") = (%x, %x), expected (%s, %s)", x, y, expectedX, expectedY)
+	}
+}
+
+func TestHashToCurveOnCurve(t *testing.T) {
+	for _, curve := range []Curve{P256(), P384(), P521()} {
+		params := curve.Params()
+		for _, msg := range []string{"", "abc", "a512_aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"} {
+			x, y := HashToCurve(curve, []byte(msg), []byte("ctcrypto-test"))
+			if !params.IsOnCurve(x, y) {
+				t.Errorf("%s: HashToCurve(%q) is not on the curve", params.Name, msg)
+			}
+			x, y = EncodeToCurve(curve, []byte(msg), []byte("ctcrypto-test"))
+			if !params.IsOnCurve(x, y) {
+				t.Errorf("%s: EncodeToCurve(%q) is not on the curve", params.Name, msg)
+			}
+		}
+	}
+}
+
+func TestHashToCurveDomainSeparation(t *testing.T) {
+	x0, y0 := HashToCurve(P256(), []byte("msg"), []byte("DST-A"))
+	x1, y1 := HashToCurve(P256(), []byte("msg"), []byte("DST-B"))
+	if x0.Cmp(x1) == 0 && y0.Cmp(y1) == 0 {
+		t.Errorf("different tags produced the same point")
+	}
+	x2, y2 := HashToCurve(P256(), []byte("msg"), []byte("DST-A"))
+	if x0.Cmp(x2) != 0 || y0.Cmp(y2) != 0 {
+		t.Errorf("hashing is not deterministic")
+	}
+}
+
+func TestHashToCurveUnsupported(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic for P-224")
+		}
+	}()
+	HashToCurve(P224(), []byte("msg"), []byte("DST"))
+}
+
+func BenchmarkHashToCurveP256(b *testing.B) {
+	dst := []byte("QUUX-V01-CS02-with-" + SuiteP256RO)
+	msg := []byte("abc")
+	var x *big.Int
+	for i := 0; i < b.N; i++ {
+		x, _ = HashToCurve(P256(), msg, dst)
+	}
+	_ = x
+}
@@ -0,0 +1,163 @@
+package elliptic
+
+import (
+	"errors"
+	"hash"
+
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/sha3"
+)
+
+// ExpandMessage is a function producing length uniformly random bytes from a
+// message and a domain separation tag, as described in RFC 9380, section 5.3.
+type ExpandMessage func(msg, dst []byte, length int) ([]byte, error)
+
+var (
+	errXMDLength = errors.New("elliptic: requested too many bytes from expand_message_xmd")
+	errXOFLength = errors.New("elliptic: requested too many bytes from expand_message_xof")
+)
+
+// ExpandMessageXMD implements expand_message_xmd, as defined in RFC 9380,
+// section 5.3.1, using a Merkle-Damgård hash function, like SHA-256.
+//
+// At most 255 times the output size of the hash function can be requested, up
+// to a maximum of 65535 bytes. Domain separation tags longer than 255 bytes
+// are hashed down, as described in section 5.3.3.
+func ExpandMessageXMD(h func() hash.Hash, msg, dst []byte, length int) ([]byte, error) {
+	H := h()
+	bInBytes := H.Size()
+	sInBytes := H.BlockSize()
+
+	ell := (length + bInBytes - 1) / bInBytes
+	if ell > 255 || length > 65535 || length < 0 {
+		return nil, errXMDLength
+	}
+	if len(dst) > 255 {
+		H.Write([]byte("H2C-OVERSIZE-DST-"))
+		H.Write(dst)
+		dst = H.Sum(nil)
+		H.Reset()
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	H.Write(make([]byte, sInBytes))
+	H.Write(msg)
+	H.Write([]byte{byte(length >> 8), byte(length), 0})
+	H.Write(dstPrime)
+	b0 := H.Sum(nil)
+
+	out := make([]byte, 0, ell*bInBytes)
+	bi := make([]byte, bInBytes)
+	for i := 1; i <= ell; i++ {
+		H.Reset()
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		H.Write(bi)
+		H.Write([]byte{byte(i)})
+		H.Write(dstPrime)
+		bi = H.Sum(bi[:0])
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
+
+// ExpandMessageXOF implements expand_message_xof, as defined in RFC 9380,
+// section 5.3.2, using an extendable-output function, like SHAKE128.
+//
+// The parameter k is the target security level, in bits, which is used when
+// hashing down domain separation tags longer than 255 bytes.
+func ExpandMessageXOF(h func() sha3.ShakeHash, k int, msg, dst []byte, length int) ([]byte, error) {
+	if length > 65535 || length < 0 {
+		return nil, errXOFLength
+	}
+	H := h()
+	if len(dst) > 255 {
+		H.Write([]byte("H2C-OVERSIZE-DST-"))
+		H.Write(dst)
+		dst = make([]byte, (2*k+7)/8)
+		H.Read(dst)
+		H.Reset()
+	}
+	H.Write(msg)
+	H.Write([]byte{byte(length >> 8), byte(length)})
+	H.Write(dst)
+	H.Write([]byte{byte(len(dst))})
+	out := make([]byte, length)
+	H.Read(out)
+	return out, nil
+}
+
+// XMD returns an ExpandMessage function using expand_message_xmd with h.
+func XMD(h func() hash.Hash) ExpandMessage {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		return ExpandMessageXMD(h, msg, dst, length)
+	}
+}
+
+// XOF returns an ExpandMessage function using expand_message_xof with h, and a
+// target security level of k bits.
+func XOF(h func() sha3.ShakeHash, k int) ExpandMessage {
+	return func(msg, dst []byte, length int) ([]byte, error) {
+		return ExpandMessageXOF(h, k, msg, dst, length)
+	}
+}
+
+// HashToField implements hash_to_field, as defined in RFC 9380, section 5.2,
+// returning count integers modulo m. The modulus doesn't need to be prime, so
+// this can be used to hash to scalars as well as to field elements.
+//
+// The parameter k is the target security level, in bits. Each output is
+// derived from ⌈(⌈log2(m)⌉ + k) / 8⌉ uniform bytes, which ensures that its
+// distribution is within 2^-k of uniform.
+func HashToField(expand ExpandMessage, m *safenum.Modulus, k int, msg, dst []byte, count int) ([]*safenum.Nat, error) {
+	L := (int(m.BitLen()) + k + 7) / 8
+	uniform, err := expand(msg, dst, count*L)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*safenum.Nat, count)
+	for i := 0; i < count; i++ {
+		// SetBytes pads its argument in place when it has spare capacity, which
+		// would clobber the bytes of the next element.
+		out[i] = new(safenum.Nat).SetBytes(uniform[i*L : (i+1)*L : (i+1)*L])
+		out[i].Mod(out[i], m)
+	}
+	return out, nil
+}
+
+// hashToField hashes to the base field, or to the scalar field, of a curve,
+// using the hash function and security level of its suite.
+func (suite *h2cSuite) hashToField(m *safenum.Modulus, msg, dst []byte, count int) []*safenum.Nat {
+	out, err := HashToField(XMD(suite.hash), m, suite.k, msg, dst, count)
+	if err != nil {
+		// This can't happen, since we only ever ask for a few elements.
+		panic(err)
+	}
+	return out
+}
+
+// HashToBaseField hashes a message to count elements of the field underlying
+// the curve, following the hash_to_field procedure used by HashToCurve.
+//
+// Only P-256, P-384, and P-521 are supported, and this function will panic
+// for any other curve.
+func HashToBaseField(curve Curve, msg, dst []byte, count int) []*safenum.Nat {
+	params := curve.Params()
+	return suiteFor(params).hashToField(params.P, msg, dst, count)
+}
+
+// HashToScalar hashes a message to an integer modulo the order of the curve,
+// using hash_to_field with the hash function and security level of the curve's
+// hash-to-curve suite.
+//
+// This is useful to derive scalars deterministically, for example to generate
+// challenges in proofs of knowledge. The dst parameter should be distinct
+// from the tags used for other purposes.
+//
+// Only P-256, P-384, and P-521 are supported, and this function will panic
+// for any other curve.
+func HashToScalar(curve Curve, msg, dst []byte) *safenum.Nat {
+	params := curve.Params()
+	return suiteFor(params).hashToField(params.N, msg, dst, 1)[0]
+}
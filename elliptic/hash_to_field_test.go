@@ -0,0 +1,119 @@
+package elliptic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	tests := []struct {
+		msg      string
+		length   int
+		expected string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+	}
+	for _, test := range tests {
+		out, err := ExpandMessageXMD(sha256.New, []byte(test.msg), dst, test.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := hex.DecodeString(test.expected)
+		if !bytes.Equal(out, expected) {
+			t.Errorf("expand_message_xmd(%q) = %x, expected %s", test.msg, out, test.expected)
+		}
+	}
+}
+
+func TestExpandMessageXMDTooLong(t *testing.T) {
+	if _, err := ExpandMessageXMD(sha256.New, nil, []byte("DST"), 256*32); err == nil {
+		t.Errorf("expected an error when requesting too many bytes")
+	}
+}
+
+func TestExpandMessageXMDLongDST(t *testing.T) {
+	long := []byte(strings.Repeat("x", 300))
+	out0, err := ExpandMessageXMD(sha256.New, []byte("msg"), long, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.New()
+	h.Write([]byte("H2C-OVERSIZE-DST-"))
+	h.Write(long)
+	out1, _ := ExpandMessageXMD(sha256.New, []byte("msg"), h.Sum(nil), 32)
+	if !bytes.Equal(out0, out1) {
+		t.Errorf("long tags were not hashed down")
+	}
+}
+
+func TestExpandMessageXOF(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHAKE128")
+	out, err := ExpandMessageXOF(sha3.NewShake128, 128, nil, dst, 0x20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "86518c9cd86581486e9485aa74ab35ba150d1c75c88e26b7043e44e2acd735a2"
+	if hex.EncodeToString(out) != expected {
+		t.Errorf("expand_message_xof(\"\") = %x, expected %s", out, expected)
+	}
+}
+
+func TestHashToField(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-" + SuiteP256RO)
+	u := HashToBaseField(P256(), nil, dst, 2)
+	expected := []string{
+		"ad5342c66a6dd0ff080df1da0ea1c04b96e0330dd89406465eeba11582515009",
+		"8c0f1d43204bd6f6ea70ae8013070a1518b43873bcd850aafa0a9e220e2eea5a",
+	}
+	for i := range u {
+		if hex.EncodeToString(u[i].Bytes()) != expected[i] {
+			t.Errorf("u[%d] = %x, expected %s", i, u[i].Bytes(), expected[i])
+		}
+	}
+}
+
+func TestHashToScalar(t *testing.T) {
+	for _, curve := range []Curve{P256(), P384(), P521()} {
+		N := curve.Params().N
+		s0 := HashToScalar(curve, []byte("msg"), []byte("DST"))
+		if s0.CmpMod(N) >= 0 {
+			t.Errorf("%s: scalar is not reduced", curve.Params().Name)
+		}
+		s1 := HashToScalar(curve, []byte("msg"), []byte("DST"))
+		if s0.Cmp(s1) != 0 {
+			t.Errorf("%s: hashing to a scalar is not deterministic", curve.Params().Name)
+		}
+		s2 := HashToScalar(curve, []byte("msg"), []byte("other DST"))
+		if s0.Cmp(s2) == 0 {
+			t.Errorf("%s: tags don't separate scalars", curve.Params().Name)
+		}
+	}
+}
+
+func TestHashToFieldElementsIndependent(t *testing.T) {
+	// The elements are taken from consecutive chunks of the uniform bytes, which
+	// for P-521 aren't a whole number of limbs.
+	m := P521().Params().P
+	dst := []byte("DST")
+	u, err := HashToField(XMD(sha512.New), m, 256, []byte("msg"), dst, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uniform, _ := ExpandMessageXMD(sha512.New, []byte("msg"), dst, 2*98)
+	for i := range u {
+		expected := new(safenum.Nat).SetBytes(append([]byte{}, uniform[i*98:(i+1)*98]...))
+		expected.Mod(expected, m)
+		if u[i].Cmp(expected) != 0 {
+			t.Errorf("u[%d] doesn't match its chunk of uniform bytes", i)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package elliptic
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// PublicKey is a point of a curve, used as a public key.
+type PublicKey struct {
+	Curve
+	X, Y *big.Int
+}
+
+// Bytes returns the uncompressed encoding of the public key, from Marshal.
+func (pub *PublicKey) Bytes() []byte {
+	return Marshal(pub.Curve, pub.X, pub.Y)
+}
+
+// Equal returns whether x is the same public key as pub, comparing the
+// points in constant time.
+func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok || pub.Curve != xx.Curve {
+		return false
+	}
+	return subtle.ConstantTimeCompare(pub.Bytes(), xx.Bytes()) == 1
+}
+
+// PrivateKey is a scalar in [1, N-1], along with the matching public key.
+//
+// Unlike the byte slice returned by GenerateKey, the scalar is kept as a
+// safenum.Nat, and can be wiped with Close once it is no longer needed.
+type PrivateKey struct {
+	PublicKey
+	D *safenum.Nat
+}
+
+var (
+	errInvalidPrivateKey = errors.New("elliptic: invalid private key")
+	errShortSeed         = errors.New("elliptic: seed shorter than the security level of the curve")
+)
+
+// GenerateKeyPair generates a private key, with RandomScalar.
+func GenerateKeyPair(curve Curve, rand io.Reader) (*PrivateKey, error) {
+	k, err := RandomScalar(curve, rand)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(curve, k)
+}
+
+// NewPrivateKey decodes a private key from the big-endian encoding of its
+// scalar, of the length of N, checking that it lies in [1, N-1].
+//
+// Invalid scalars are rejected in the same time, whichever check fails.
+func NewPrivateKey(curve Curve, b []byte) (*PrivateKey, error) {
+	N := curve.Params().N
+	if len(b) != (int(N.BitLen())+7)/8 {
+		return nil, errInvalidPrivateKey
+	}
+	// SetBytes can modify its argument, if it has spare capacity.
+	d := new(safenum.Nat).SetBytes(b[:len(b):len(b)])
+	nonZero := 1 ^ subtle.ConstantTimeEq(int32(d.Cmp(new(safenum.Nat))), 0)
+	inRange := subtle.ConstantTimeEq(int32(d.CmpMod(N)), -1)
+	if nonZero&inRange != 1 {
+		return nil, errInvalidPrivateKey
+	}
+	priv := &PrivateKey{D: d.Mod(d, N)}
+	priv.Curve = curve
+	priv.X, priv.Y = curve.ScalarBaseMult(b)
+	return priv, nil
+}
+
+// DeriveKey deterministically derives a private key from a seed, and a
+// context separating the keys derived from the same seed.
+//
+// The scalar is hash_to_field(seed) modulo N-1, plus one, like RandomScalar,
+// using the hash function and security level of the hash-to-curve suite of
+// the curve, and "CTCRYPTO-DERIVE-KEY-V01-" || the name of the curve || "-" ||
+// context as the domain separation tag. The seed must have at least as many bytes of
+// entropy as the security level of the curve, and shorter seeds are rejected.
+//
+// Only P-256, P-384, and P-521 are supported.
+func DeriveKey(curve Curve, seed, context []byte) (*PrivateKey, error) {
+	params := curve.Params()
+	switch params.Name {
+	case "P-256", "P-384", "P-521":
+	default:
+		return nil, errors.New("elliptic: deriving keys over " + params.Name + " is not supported")
+	}
+	suite := suiteFor(params)
+	if len(seed) < suite.k/8 {
+		return nil, errShortSeed
+	}
+	dst := append([]byte("CTCRYPTO-DERIVE-KEY-V01-"+params.Name+"-"), context...)
+
+	// This is hash_to_field modulo N-1, done by scalarFromUniform.
+	L := (int(params.N.BitLen()) + suite.k + 7) / 8
+	uniform, err := ExpandMessageXMD(suite.hash, seed, dst, L)
+	if err != nil {
+		return nil, err
+	}
+	k := scalarFromUniform(params, new(safenum.Nat).SetBytes(uniform[:L:L]))
+	return NewPrivateKey(curve, k)
+}
+
+// Bytes returns the big-endian encoding of the scalar, of the length of N.
+func (priv *PrivateKey) Bytes() []byte {
+	N := priv.Params().N
+	return fieldBytes(priv.D, (int(N.BitLen())+7)/8)
+}
+
+// Public returns the public key matching the private key.
+func (priv *PrivateKey) Public() crypto.PublicKey {
+	return &priv.PublicKey
+}
+
+// Equal returns whether x is the same private key as priv, comparing the
+// scalars in constant time.
+func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok || priv.Curve != xx.Curve {
+		return false
+	}
+	return subtle.ConstantTimeCompare(priv.Bytes(), xx.Bytes()) == 1
+}
+
+// Close overwrites the limbs of the scalar with zeros, and drops it. The
+// PrivateKey can't be used afterwards, except for its PublicKey.
+//
+// The copies made by the operations using the scalar, like Bytes, aren't
+// wiped.
+func (priv *PrivateKey) Close() error {
+	if priv.D != nil {
+		priv.D.Sub(priv.D, priv.D, priv.D.AnnouncedLen())
+		priv.D = nil
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package elliptic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestPrivateKey(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		priv, err := GenerateKeyPair(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y := curve.ScalarBaseMult(priv.Bytes())
+		if x.Cmp(priv.X) != 0 || y.Cmp(priv.Y) != 0 {
+			t.Errorf("%s: public key doesn't match the scalar", curve.Params().Name)
+		}
+		priv2, err := NewPrivateKey(curve, priv.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !priv.Equal(priv2) || !priv.PublicKey.Equal(priv2.Public()) {
+			t.Errorf("%s: decoded key differs", curve.Params().Name)
+		}
+		if !bytes.Equal(priv.PublicKey.Bytes(), Marshal(curve, x, y)) {
+			t.Errorf("%s: wrong public key encoding", curve.Params().Name)
+		}
+		other, err := GenerateKeyPair(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if priv.Equal(other) || priv.PublicKey.Equal(&other.PublicKey) {
+			t.Errorf("%s: different keys are equal", curve.Params().Name)
+		}
+
+		d := priv.D
+		if err := priv.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if priv.D != nil || !d.EqZero() {
+			t.Errorf("%s: Close didn't wipe the scalar", curve.Params().Name)
+		}
+	}
+}
+
+func TestNewPrivateKeyRejects(t *testing.T) {
+	curve := P256()
+	N := new(big.Int).SetBytes(curve.Params().N.Bytes())
+	for _, b := range [][]byte{
+		make([]byte, 32),
+		N.FillBytes(make([]byte, 32)),
+		bytes.Repeat([]byte{0xff}, 32),
+		make([]byte, 31),
+		make([]byte, 33),
+	} {
+		if _, err := NewPrivateKey(curve, b); err == nil {
+			t.Errorf("NewPrivateKey(%x) succeeded", b)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	seed := bytes.Repeat([]byte{1}, 32)
+	// The minimum seed lengths, from the security levels of the curves.
+	for curve, k := range map[Curve]int{P256(): 16, P384(): 24, P521(): 32} {
+		seed := bytes.Repeat([]byte{1}, k)
+		a, err := DeriveKey(curve, seed, []byte("a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a2, err := DeriveKey(curve, seed, []byte("a"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !a.Equal(a2) {
+			t.Errorf("%s: DeriveKey isn't deterministic", curve.Params().Name)
+		}
+		b, err := DeriveKey(curve, seed, []byte("b"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Equal(b) {
+			t.Errorf("%s: different contexts derive the same key", curve.Params().Name)
+		}
+		if _, err := DeriveKey(curve, seed[1:], nil); err == nil {
+			t.Errorf("%s: short seed accepted", curve.Params().Name)
+		}
+	}
+
+	// The scalar is hash_to_field modulo N-1, plus one.
+	curve := P256()
+	priv, err := DeriveKey(curve, seed, []byte("context"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uniform, err := ExpandMessageXMD(sha256.New, seed, []byte("CTCRYPTO-DERIVE-KEY-V01-P-256-context"), 48)
+	if err != nil {
+		t.Fatal(err)
+	}
+	N := new(big.Int).SetBytes(curve.Params().N.Bytes())
+	want := new(big.Int).SetBytes(uniform)
+	want.Mod(want, N.Sub(N, big.NewInt(1))).Add(want, big.NewInt(1))
+	if new(big.Int).SetBytes(priv.Bytes()).Cmp(want) != 0 {
+		t.Errorf("DeriveKey = %x, expected %x", priv.Bytes(), want)
+	}
+
+	if _, err := DeriveKey(P224(), seed, nil); err == nil {
+		t.Error("DeriveKey over P-224 succeeded")
+	}
+}
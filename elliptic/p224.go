@@ -11,6 +11,8 @@ package elliptic
 
 import (
 	"math/big"
+
+	"github.com/cronokirby/ctcrypto/instrument"
 )
 
 var p224 p224Curve
@@ -48,6 +50,18 @@ func (curve p224Curve) Params() *CurveParams {
 }
 
 func (curve p224Curve) IsOnCurve(bigX, bigY *big.Int) bool {
+	// p224FromBig truncates its input, so coordinates which aren't reduced
+	// modulo P are rejected first.
+	if bigX.Sign() < 0 || bigY.Sign() < 0 {
+		return false
+	}
+	if _, valid := curve.fieldElement(bigX.Bytes()); valid != 1 {
+		return false
+	}
+	if _, valid := curve.fieldElement(bigY.Bytes()); valid != 1 {
+		return false
+	}
+
 	var x, y p224FieldElement
 	p224FromBig(&x, bigX)
 	p224FromBig(&y, bigY)
@@ -106,7 +120,8 @@ func (p224Curve) Double(bigX1, bigY1 *big.Int) (x, y *big.Int) {
 	return p224ToAffine(&x2, &y2, &z2)
 }
 
-func (p224Curve) ScalarMult(bigX1, bigY1 *big.Int, scalar []byte) (x, y *big.Int) {
+func (curve p224Curve) ScalarMult(bigX1, bigY1 *big.Int, scalar []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
 	var x1, y1, z1, x2, y2, z2 p224FieldElement
 
 	p224FromBig(&x1, bigX1)
@@ -118,6 +133,7 @@ func (p224Curve) ScalarMult(bigX1, bigY1 *big.Int, scalar []byte) (x, y *big.Int
 }
 
 func (curve p224Curve) ScalarBaseMult(scalar []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.Name)()
 	var z1, x2, y2, z2 p224FieldElement
 
 	z1[0] = 1
@@ -2,8 +2,6 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build !amd64,!arm64
-
 package elliptic
 
 // This file contains a constant-time, 32-bit implementation of P256.
@@ -12,7 +10,9 @@ import (
 	"math/big"
 )
 
-type p256Curve struct {
+// p256GoCurve is the portable implementation of P-256, used on platforms
+// without assembly, or when the "go" backend is selected.
+type p256GoCurve struct {
 	*CurveParams
 }
 
@@ -22,59 +22,64 @@ var (
 	// RInverse contains 1/R mod p - the inverse of the Montgomery constant
 	// (2**257).
 	p256RInverse *big.Int
+
+	// p256BigP and p256BigN are P and N, for the conversions to and from
+	// big.Int.
+	p256BigP, p256BigN *big.Int
 )
 
 func initP256() {
 	// See FIPS 186-3, section D.2.3
 	p256Params = &CurveParams{Name: "P-256"}
-	p256Params.P, _ = new(big.Int).SetString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
-	p256Params.N, _ = new(big.Int).SetString("115792089210356248762697446949407573529996955224135760342422259061068512044369", 10)
-	p256Params.B, _ = new(big.Int).SetString("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b", 16)
-	p256Params.Gx, _ = new(big.Int).SetString("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296", 16)
-	p256Params.Gy, _ = new(big.Int).SetString("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5", 16)
+	p256Params.P, _ = modFromString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
+	p256Params.N, _ = modFromString("115792089210356248762697446949407573529996955224135760342422259061068512044369", 10)
+	p256Params.B, _ = fromString("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b", 16)
+	p256Params.Gx, _ = fromString("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296", 16)
+	p256Params.Gy, _ = fromString("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5", 16)
 	p256Params.BitSize = 256
 
+	p256BigP = new(big.Int).SetBytes(p256Params.P.Bytes())
+	p256BigN = new(big.Int).SetBytes(p256Params.N.Bytes())
+
 	p256RInverse, _ = new(big.Int).SetString("7fffffff00000001fffffffe8000000100000000ffffffff0000000180000000", 16)
 
-	// Arch-specific initialization, i.e. let a platform dynamically pick a P256 implementation
+	p256 = p256Dispatch{p256Params}
+	registerP256Backend(BackendGo, p256GoCurve{p256Params})
+	// Arch-specific initialization, registering the faster implementations
+	// the platform has.
 	initP256Arch()
+	selectP256Backend()
 }
 
-func (curve p256Curve) Params() *CurveParams {
+func (curve p256GoCurve) Params() *CurveParams {
 	return curve.CurveParams
 }
 
-// p256GetScalar endian-swaps the big-endian scalar value from in and writes it
+// p256GoGetScalar endian-swaps the big-endian scalar value from in and writes it
 // to out. If the scalar is equal or greater than the order of the group, it's
 // reduced modulo that order.
-func p256GetScalar(out *[32]byte, in []byte) {
+func p256GoGetScalar(out *[32]byte, in []byte) {
 	n := new(big.Int).SetBytes(in)
-	var scalarBytes []byte
-
-	if n.Cmp(p256Params.N) >= 0 {
-		n.Mod(n, p256Params.N)
-		scalarBytes = n.Bytes()
-	} else {
-		scalarBytes = in
-	}
+	n.Mod(n, p256BigN)
+	scalarBytes := n.FillBytes(make([]byte, 32))
 
 	for i, v := range scalarBytes {
 		out[len(scalarBytes)-(1+i)] = v
 	}
 }
 
-func (p256Curve) ScalarBaseMult(scalar []byte) (x, y *big.Int) {
+func (p256GoCurve) ScalarBaseMult(scalar []byte) (x, y *big.Int) {
 	var scalarReversed [32]byte
-	p256GetScalar(&scalarReversed, scalar)
+	p256GoGetScalar(&scalarReversed, scalar)
 
 	var x1, y1, z1 [p256Limbs]uint32
 	p256ScalarBaseMult(&x1, &y1, &z1, &scalarReversed)
 	return p256ToAffine(&x1, &y1, &z1)
 }
 
-func (p256Curve) ScalarMult(bigX, bigY *big.Int, scalar []byte) (x, y *big.Int) {
+func (p256GoCurve) ScalarMult(bigX, bigY *big.Int, scalar []byte) (x, y *big.Int) {
 	var scalarReversed [32]byte
-	p256GetScalar(&scalarReversed, scalar)
+	p256GoGetScalar(&scalarReversed, scalar)
 
 	var px, py, x1, y1, z1 [p256Limbs]uint32
 	p256FromBig(&px, bigX)
@@ -115,7 +120,7 @@ var (
 	p2562P = [p256Limbs]uint32{0x1ffffffe, 0xfffffff, 0x1fffffff, 0x7ff, 0, 0, 0x400000, 0xe000000, 0x1fffffff}
 )
 
-// p256Precomputed contains precomputed values to aid the calculation of scalar
+// p256GoPrecomputed contains precomputed values to aid the calculation of scalar
 // multiples of the base point, G. It's actually two, equal length, tables
 // concatenated.
 //
@@ -144,7 +149,7 @@ var (
 // 2**96G, 2**160G, 2**224G.
 //
 // This is ~2KB of data.
-var p256Precomputed = [p256Limbs * 2 * 15 * 2]uint32{
+var p256GoPrecomputed = [p256Limbs * 2 * 15 * 2]uint32{
 	0x11522878, 0xe730d41, 0xdb60179, 0x4afe2ff, 0x12883add, 0xcaddd88, 0x119e7edc, 0xd4a6eab, 0x3120bee,
 	0x1d2aac15, 0xf25357c, 0x19e45cdd, 0x5c721d0, 0x1992c5a5, 0xa237487, 0x154ba21, 0x14b10bb, 0xae3fe3,
 	0xd41a576, 0x922fc51, 0x234994f, 0x60b60d3, 0x164586ae, 0xce95f18, 0x1fe49073, 0x3fa36cc, 0x5ebcd2c,
@@ -556,12 +561,12 @@ func p256Square(out, in *[p256Limbs]uint32) {
 	p256ReduceDegree(out, tmp)
 }
 
-// p256Mul sets out=in*in2.
+// p256GoMul sets out=in*in2.
 //
 // On entry: in[0,2,...] < 2**30, in[1,3,...] < 2**29 and
 //           in2[0,2,...] < 2**30, in2[1,3,...] < 2**29.
 // On exit: out[0,2,...] < 2**30, out[1,3,...] < 2**29.
-func p256Mul(out, in, in2 *[p256Limbs]uint32) {
+func p256GoMul(out, in, in2 *[p256Limbs]uint32) {
 	var tmp [17]uint64
 
 	tmp[0] = uint64(in[0]) * uint64(in2[0])
@@ -668,58 +673,58 @@ func p256Invert(out, in *[p256Limbs]uint32) {
 	var e2, e4, e8, e16, e32, e64 [p256Limbs]uint32
 
 	p256Square(&ftmp, in)     // 2^1
-	p256Mul(&ftmp, in, &ftmp) // 2^2 - 2^0
+	p256GoMul(&ftmp, in, &ftmp) // 2^2 - 2^0
 	p256Assign(&e2, &ftmp)
 	p256Square(&ftmp, &ftmp)   // 2^3 - 2^1
 	p256Square(&ftmp, &ftmp)   // 2^4 - 2^2
-	p256Mul(&ftmp, &ftmp, &e2) // 2^4 - 2^0
+	p256GoMul(&ftmp, &ftmp, &e2) // 2^4 - 2^0
 	p256Assign(&e4, &ftmp)
 	p256Square(&ftmp, &ftmp)   // 2^5 - 2^1
 	p256Square(&ftmp, &ftmp)   // 2^6 - 2^2
 	p256Square(&ftmp, &ftmp)   // 2^7 - 2^3
 	p256Square(&ftmp, &ftmp)   // 2^8 - 2^4
-	p256Mul(&ftmp, &ftmp, &e4) // 2^8 - 2^0
+	p256GoMul(&ftmp, &ftmp, &e4) // 2^8 - 2^0
 	p256Assign(&e8, &ftmp)
 	for i := 0; i < 8; i++ {
 		p256Square(&ftmp, &ftmp)
 	} // 2^16 - 2^8
-	p256Mul(&ftmp, &ftmp, &e8) // 2^16 - 2^0
+	p256GoMul(&ftmp, &ftmp, &e8) // 2^16 - 2^0
 	p256Assign(&e16, &ftmp)
 	for i := 0; i < 16; i++ {
 		p256Square(&ftmp, &ftmp)
 	} // 2^32 - 2^16
-	p256Mul(&ftmp, &ftmp, &e16) // 2^32 - 2^0
+	p256GoMul(&ftmp, &ftmp, &e16) // 2^32 - 2^0
 	p256Assign(&e32, &ftmp)
 	for i := 0; i < 32; i++ {
 		p256Square(&ftmp, &ftmp)
 	} // 2^64 - 2^32
 	p256Assign(&e64, &ftmp)
-	p256Mul(&ftmp, &ftmp, in) // 2^64 - 2^32 + 2^0
+	p256GoMul(&ftmp, &ftmp, in) // 2^64 - 2^32 + 2^0
 	for i := 0; i < 192; i++ {
 		p256Square(&ftmp, &ftmp)
 	} // 2^256 - 2^224 + 2^192
 
-	p256Mul(&ftmp2, &e64, &e32) // 2^64 - 2^0
+	p256GoMul(&ftmp2, &e64, &e32) // 2^64 - 2^0
 	for i := 0; i < 16; i++ {
 		p256Square(&ftmp2, &ftmp2)
 	} // 2^80 - 2^16
-	p256Mul(&ftmp2, &ftmp2, &e16) // 2^80 - 2^0
+	p256GoMul(&ftmp2, &ftmp2, &e16) // 2^80 - 2^0
 	for i := 0; i < 8; i++ {
 		p256Square(&ftmp2, &ftmp2)
 	} // 2^88 - 2^8
-	p256Mul(&ftmp2, &ftmp2, &e8) // 2^88 - 2^0
+	p256GoMul(&ftmp2, &ftmp2, &e8) // 2^88 - 2^0
 	for i := 0; i < 4; i++ {
 		p256Square(&ftmp2, &ftmp2)
 	} // 2^92 - 2^4
-	p256Mul(&ftmp2, &ftmp2, &e4) // 2^92 - 2^0
+	p256GoMul(&ftmp2, &ftmp2, &e4) // 2^92 - 2^0
 	p256Square(&ftmp2, &ftmp2)   // 2^93 - 2^1
 	p256Square(&ftmp2, &ftmp2)   // 2^94 - 2^2
-	p256Mul(&ftmp2, &ftmp2, &e2) // 2^94 - 2^0
+	p256GoMul(&ftmp2, &ftmp2, &e2) // 2^94 - 2^0
 	p256Square(&ftmp2, &ftmp2)   // 2^95 - 2^1
 	p256Square(&ftmp2, &ftmp2)   // 2^96 - 2^2
-	p256Mul(&ftmp2, &ftmp2, in)  // 2^96 - 3
+	p256GoMul(&ftmp2, &ftmp2, in)  // 2^96 - 3
 
-	p256Mul(out, &ftmp2, &ftmp) // 2^256 - 2^224 + 2^192 + 2^96 - 3
+	p256GoMul(out, &ftmp2, &ftmp) // 2^256 - 2^224 + 2^192 + 2^96 - 3
 }
 
 // p256Scalar3 sets out=3*out.
@@ -823,11 +828,11 @@ func p256PointDouble(xOut, yOut, zOut, x, y, z *[p256Limbs]uint32) {
 
 	p256Square(&delta, z)
 	p256Square(&gamma, y)
-	p256Mul(&beta, x, &gamma)
+	p256GoMul(&beta, x, &gamma)
 
 	p256Sum(&tmp, x, &delta)
 	p256Diff(&tmp2, x, &delta)
-	p256Mul(&alpha, &tmp, &tmp2)
+	p256GoMul(&alpha, &tmp, &tmp2)
 	p256Scalar3(&alpha)
 
 	p256Sum(&tmp, y, z)
@@ -841,7 +846,7 @@ func p256PointDouble(xOut, yOut, zOut, x, y, z *[p256Limbs]uint32) {
 	p256Diff(xOut, xOut, &beta)
 
 	p256Diff(&tmp, &beta, xOut)
-	p256Mul(&tmp, &alpha, &tmp)
+	p256GoMul(&tmp, &alpha, &tmp)
 	p256Square(&tmp2, &gamma)
 	p256Scalar8(&tmp2)
 	p256Diff(yOut, &tmp, &tmp2)
@@ -860,26 +865,26 @@ func p256PointAddMixed(xOut, yOut, zOut, x1, y1, z1, x2, y2 *[p256Limbs]uint32)
 	p256Square(&z1z1, z1)
 	p256Sum(&tmp, z1, z1)
 
-	p256Mul(&u2, x2, &z1z1)
-	p256Mul(&z1z1z1, z1, &z1z1)
-	p256Mul(&s2, y2, &z1z1z1)
+	p256GoMul(&u2, x2, &z1z1)
+	p256GoMul(&z1z1z1, z1, &z1z1)
+	p256GoMul(&s2, y2, &z1z1z1)
 	p256Diff(&h, &u2, x1)
 	p256Sum(&i, &h, &h)
 	p256Square(&i, &i)
-	p256Mul(&j, &h, &i)
+	p256GoMul(&j, &h, &i)
 	p256Diff(&r, &s2, y1)
 	p256Sum(&r, &r, &r)
-	p256Mul(&v, x1, &i)
+	p256GoMul(&v, x1, &i)
 
-	p256Mul(zOut, &tmp, &h)
+	p256GoMul(zOut, &tmp, &h)
 	p256Square(&rr, &r)
 	p256Diff(xOut, &rr, &j)
 	p256Diff(xOut, xOut, &v)
 	p256Diff(xOut, xOut, &v)
 
 	p256Diff(&tmp, &v, xOut)
-	p256Mul(yOut, &tmp, &r)
-	p256Mul(&tmp, y1, &j)
+	p256GoMul(yOut, &tmp, &r)
+	p256GoMul(&tmp, y1, &j)
 	p256Diff(yOut, yOut, &tmp)
 	p256Diff(yOut, yOut, &tmp)
 }
@@ -895,36 +900,36 @@ func p256PointAdd(xOut, yOut, zOut, x1, y1, z1, x2, y2, z2 *[p256Limbs]uint32) {
 
 	p256Square(&z1z1, z1)
 	p256Square(&z2z2, z2)
-	p256Mul(&u1, x1, &z2z2)
+	p256GoMul(&u1, x1, &z2z2)
 
 	p256Sum(&tmp, z1, z2)
 	p256Square(&tmp, &tmp)
 	p256Diff(&tmp, &tmp, &z1z1)
 	p256Diff(&tmp, &tmp, &z2z2)
 
-	p256Mul(&z2z2z2, z2, &z2z2)
-	p256Mul(&s1, y1, &z2z2z2)
+	p256GoMul(&z2z2z2, z2, &z2z2)
+	p256GoMul(&s1, y1, &z2z2z2)
 
-	p256Mul(&u2, x2, &z1z1)
-	p256Mul(&z1z1z1, z1, &z1z1)
-	p256Mul(&s2, y2, &z1z1z1)
+	p256GoMul(&u2, x2, &z1z1)
+	p256GoMul(&z1z1z1, z1, &z1z1)
+	p256GoMul(&s2, y2, &z1z1z1)
 	p256Diff(&h, &u2, &u1)
 	p256Sum(&i, &h, &h)
 	p256Square(&i, &i)
-	p256Mul(&j, &h, &i)
+	p256GoMul(&j, &h, &i)
 	p256Diff(&r, &s2, &s1)
 	p256Sum(&r, &r, &r)
-	p256Mul(&v, &u1, &i)
+	p256GoMul(&v, &u1, &i)
 
-	p256Mul(zOut, &tmp, &h)
+	p256GoMul(zOut, &tmp, &h)
 	p256Square(&rr, &r)
 	p256Diff(xOut, &rr, &j)
 	p256Diff(xOut, xOut, &v)
 	p256Diff(xOut, xOut, &v)
 
 	p256Diff(&tmp, &v, xOut)
-	p256Mul(yOut, &tmp, &r)
-	p256Mul(&tmp, &s1, &j)
+	p256GoMul(yOut, &tmp, &r)
+	p256GoMul(&tmp, &s1, &j)
 	p256Diff(yOut, yOut, &tmp)
 	p256Diff(yOut, yOut, &tmp)
 }
@@ -1037,7 +1042,7 @@ func p256ScalarBaseMult(xOut, yOut, zOut *[p256Limbs]uint32, scalar *[32]uint8)
 			bit3 := p256GetBit(scalar, 223-i+j)
 			index := bit0 | (bit1 << 1) | (bit2 << 2) | (bit3 << 3)
 
-			p256SelectAffinePoint(&px, &py, p256Precomputed[tableOffset:], index)
+			p256SelectAffinePoint(&px, &py, p256GoPrecomputed[tableOffset:], index)
 			tableOffset += 30 * p256Limbs
 
 			// Since scalar is less than the order of the group, we know that
@@ -1072,9 +1077,9 @@ func p256PointToAffine(xOut, yOut, x, y, z *[p256Limbs]uint32) {
 
 	p256Invert(&zInv, z)
 	p256Square(&zInvSq, &zInv)
-	p256Mul(xOut, x, &zInvSq)
-	p256Mul(&zInv, &zInv, &zInvSq)
-	p256Mul(yOut, y, &zInv)
+	p256GoMul(xOut, x, &zInvSq)
+	p256GoMul(&zInv, &zInv, &zInvSq)
+	p256GoMul(yOut, y, &zInv)
 }
 
 // p256ToAffine returns a pair of *big.Int containing the affine representation
@@ -1147,7 +1152,7 @@ func p256ScalarMult(xOut, yOut, zOut, x, y *[p256Limbs]uint32, scalar *[32]uint8
 // p256FromBig sets out = R*in.
 func p256FromBig(out *[p256Limbs]uint32, in *big.Int) {
 	tmp := new(big.Int).Lsh(in, 257)
-	tmp.Mod(tmp, p256Params.P)
+	tmp.Mod(tmp, p256BigP)
 
 	for i := 0; i < p256Limbs; i++ {
 		if bits := tmp.Bits(); len(bits) > 0 {
@@ -1187,6 +1192,6 @@ func p256ToBig(in *[p256Limbs]uint32) *big.Int {
 	}
 
 	result.Mul(result, p256RInverse)
-	result.Mod(result, p256Params.P)
+	result.Mod(result, p256BigP)
 	return result
 }
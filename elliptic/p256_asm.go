@@ -17,6 +17,9 @@ package elliptic
 import (
 	"math/big"
 	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sys/cpu"
 )
 
 type (
@@ -24,26 +27,42 @@ type (
 		*CurveParams
 	}
 
+	// p256ADXCurve is p256Curve, with the field multiplications of the point
+	// operations using MULX, ADCX, and ADOX.
+	p256ADXCurve struct {
+		p256Curve
+	}
+
 	p256Point struct {
 		xyz [12]uint64
 	}
 )
 
 var (
-	p256            p256Curve
 	p256Precomputed *[43][32 * 8]uint64
 	precomputeOnce  sync.Once
+
+	// p256UseADX is read by p256MulInternal and p256SqrInternal on amd64, and
+	// set atomically when the backend is selected.
+	p256UseADX uint32
 )
 
-func initP256() {
-	// See FIPS 186-3, section D.2.3
-	p256.CurveParams = &CurveParams{Name: "P-256"}
-	p256.P, _ = modFromString("115792089210356248762697446949407573530086143415290314195533631308867097853951", 10)
-	p256.N, _ = modFromString("115792089210356248762697446949407573529996955224135760342422259061068512044369", 10)
-	p256.B, _ = fromString("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b", 16)
-	p256.Gx, _ = fromString("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296", 16)
-	p256.Gy, _ = fromString("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5", 16)
-	p256.BitSize = 256
+func initP256Arch() {
+	// The assembly only uses instructions of the baseline amd64 and arm64
+	// instruction sets, so it's always available.
+	registerP256Backend(BackendAssembly, p256Curve{p256Params})
+	// cpu.X86 is left empty on arm64.
+	if cpu.X86.HasBMI2 && cpu.X86.HasADX {
+		registerP256Backend(BackendADX, p256ADXCurve{p256Curve{p256Params}})
+	}
+}
+
+func (p256Curve) activate() {
+	atomic.StoreUint32(&p256UseADX, 0)
+}
+
+func (p256ADXCurve) activate() {
+	atomic.StoreUint32(&p256UseADX, 1)
 }
 
 func (curve p256Curve) Params() *CurveParams {
@@ -1310,7 +1310,7 @@ ordSqrLoop:
 #define t3 SI
 #define hlp BP
 /* ---------------------------------------*/
-TEXT p256SubInternal(SB),NOSPLIT,$0
+TEXT p256SubInternal<>(SB),NOSPLIT,$0
 	XORQ mul0, mul0
 	SUBQ t0, acc4
 	SBBQ t1, acc5
@@ -1336,7 +1336,10 @@ TEXT p256SubInternal(SB),NOSPLIT,$0
 
 	RET
 /* ---------------------------------------*/
-TEXT p256MulInternal(SB),NOSPLIT,$8
+TEXT p256MulInternal<>(SB),NOSPLIT,$8
+	CMPL ·p256UseADX(SB), $0
+	JNE mulADX
+
 	MOVQ acc4, mul0
 	MULQ t0
 	MOVQ mul0, acc0
@@ -1449,6 +1452,7 @@ TEXT p256MulInternal(SB),NOSPLIT,$8
 	ADDQ mul0, acc6
 	ADCQ $0, mul1
 	MOVQ mul1, acc7
+mulReduce:
 	// First reduction step
 	MOVQ acc0, mul0
 	MOVQ acc0, hlp
@@ -1518,8 +1522,74 @@ TEXT p256MulInternal(SB),NOSPLIT,$8
 	CMOVQCS acc3, acc7
 
 	RET
+mulADX:
+	// The same product, with MULX, which leaves the flags alone, and ADCX and
+	// ADOX, which add the low and high halves along two carry chains.
+	MOVQ acc4, mul1
+	MULXQ t0, acc0, acc1
+	MULXQ t1, mul0, acc2
+	ADDQ mul0, acc1
+	MULXQ t2, mul0, acc3
+	ADCQ mul0, acc2
+	MULXQ t3, mul0, acc4
+	ADCQ mul0, acc3
+	ADCQ $0, acc4
+
+	MOVQ acc5, mul1
+	XORQ acc5, acc5
+	MULXQ t0, mul0, hlp
+	ADCXQ mul0, acc1
+	ADOXQ hlp, acc2
+	MULXQ t1, mul0, hlp
+	ADCXQ mul0, acc2
+	ADOXQ hlp, acc3
+	MULXQ t2, mul0, hlp
+	ADCXQ mul0, acc3
+	ADOXQ hlp, acc4
+	MULXQ t3, mul0, hlp
+	ADCXQ mul0, acc4
+	ADOXQ hlp, acc5
+	MOVQ $0, mul0
+	ADCXQ mul0, acc5
+
+	MOVQ acc6, mul1
+	XORQ acc6, acc6
+	MULXQ t0, mul0, hlp
+	ADCXQ mul0, acc2
+	ADOXQ hlp, acc3
+	MULXQ t1, mul0, hlp
+	ADCXQ mul0, acc3
+	ADOXQ hlp, acc4
+	MULXQ t2, mul0, hlp
+	ADCXQ mul0, acc4
+	ADOXQ hlp, acc5
+	MULXQ t3, mul0, hlp
+	ADCXQ mul0, acc5
+	ADOXQ hlp, acc6
+	MOVQ $0, mul0
+	ADCXQ mul0, acc6
+
+	MOVQ acc7, mul1
+	XORQ acc7, acc7
+	MULXQ t0, mul0, hlp
+	ADCXQ mul0, acc3
+	ADOXQ hlp, acc4
+	MULXQ t1, mul0, hlp
+	ADCXQ mul0, acc4
+	ADOXQ hlp, acc5
+	MULXQ t2, mul0, hlp
+	ADCXQ mul0, acc5
+	ADOXQ hlp, acc6
+	MULXQ t3, mul0, hlp
+	ADCXQ mul0, acc6
+	ADOXQ hlp, acc7
+	MOVQ $0, mul0
+	ADCXQ mul0, acc7
+	JMP mulReduce
 /* ---------------------------------------*/
-TEXT p256SqrInternal(SB),NOSPLIT,$8
+TEXT p256SqrInternal<>(SB),NOSPLIT,$8
+	CMPL ·p256UseADX(SB), $0
+	JNE sqrADX
 
 	MOVQ acc4, mul0
 	MULQ acc5
@@ -1591,6 +1661,7 @@ TEXT p256SqrInternal(SB),NOSPLIT,$8
 	ADDQ acc4, t1
 	ADCQ mul0, t2
 	ADCQ DX, t3
+sqrReduce:
 	// First reduction step
 	MOVQ acc0, mul0
 	MOVQ acc0, hlp
@@ -1660,6 +1731,56 @@ TEXT p256SqrInternal(SB),NOSPLIT,$8
 	CMOVQCS t3, acc7
 
 	RET
+sqrADX:
+	MOVQ acc4, mul1
+	MULXQ acc5, acc1, acc2
+	MULXQ acc6, mul0, acc3
+	ADDQ mul0, acc2
+	MULXQ acc7, mul0, t0
+	ADCQ mul0, acc3
+	ADCQ $0, t0
+
+	MOVQ acc5, mul1
+	XORQ t1, t1
+	MULXQ acc6, mul0, hlp
+	ADCXQ mul0, acc3
+	ADOXQ hlp, t0
+	MULXQ acc7, mul0, hlp
+	ADCXQ mul0, t0
+	ADOXQ hlp, t1
+	MOVQ $0, mul0
+	ADCXQ mul0, t1
+
+	MOVQ acc6, mul1
+	MULXQ acc7, mul0, t2
+	ADDQ mul0, t1
+	ADCQ $0, t2
+	XORQ t3, t3
+	// *2
+	ADDQ acc1, acc1
+	ADCQ acc2, acc2
+	ADCQ acc3, acc3
+	ADCQ t0, t0
+	ADCQ t1, t1
+	ADCQ t2, t2
+	ADCQ $0, t3
+	// Missing products
+	MOVQ acc4, mul1
+	MULXQ mul1, acc0, acc4
+	MOVQ acc5, mul1
+	MULXQ mul1, mul0, acc5
+	ADDQ acc4, acc1
+	ADCQ mul0, acc2
+	ADCQ acc5, acc3
+	MOVQ acc6, mul1
+	MULXQ mul1, mul0, acc6
+	ADCQ mul0, t0
+	ADCQ acc6, t1
+	MOVQ acc7, mul1
+	MULXQ mul1, mul0, acc7
+	ADCQ mul0, t2
+	ADCQ acc7, t3
+	JMP sqrReduce
 /* ---------------------------------------*/
 #define p256MulBy2Inline\
 	XORQ mul0, mul0;\
@@ -1805,57 +1926,57 @@ TEXT ·p256PointAddAffineAsm(SB),0,$512-96
 	MOVQ acc3, y2in(8*3)
 	// Begin point add
 	LDacc (z1in)
-	CALL p256SqrInternal(SB)	// z1ˆ2
+	CALL p256SqrInternal<>(SB)	// z1ˆ2
 	ST (z1sqr)
 
 	LDt (x2in)
-	CALL p256MulInternal(SB)	// x2 * z1ˆ2
+	CALL p256MulInternal<>(SB)	// x2 * z1ˆ2
 
 	LDt (x1in)
-	CALL p256SubInternal(SB)	// h = u2 - u1
+	CALL p256SubInternal<>(SB)	// h = u2 - u1
 	ST (h)
 
 	LDt (z1in)
-	CALL p256MulInternal(SB)	// z3 = h * z1
+	CALL p256MulInternal<>(SB)	// z3 = h * z1
 	ST (zout)
 
 	LDacc (z1sqr)
-	CALL p256MulInternal(SB)	// z1ˆ3
+	CALL p256MulInternal<>(SB)	// z1ˆ3
 
 	LDt (y2in)
-	CALL p256MulInternal(SB)	// s2 = y2 * z1ˆ3
+	CALL p256MulInternal<>(SB)	// s2 = y2 * z1ˆ3
 	ST (s2)
 
 	LDt (y1in)
-	CALL p256SubInternal(SB)	// r = s2 - s1
+	CALL p256SubInternal<>(SB)	// r = s2 - s1
 	ST (r)
 
-	CALL p256SqrInternal(SB)	// rsqr = rˆ2
+	CALL p256SqrInternal<>(SB)	// rsqr = rˆ2
 	ST (rsqr)
 
 	LDacc (h)
-	CALL p256SqrInternal(SB)	// hsqr = hˆ2
+	CALL p256SqrInternal<>(SB)	// hsqr = hˆ2
 	ST (hsqr)
 
 	LDt (h)
-	CALL p256MulInternal(SB)	// hcub = hˆ3
+	CALL p256MulInternal<>(SB)	// hcub = hˆ3
 	ST (hcub)
 
 	LDt (y1in)
-	CALL p256MulInternal(SB)	// y1 * hˆ3
+	CALL p256MulInternal<>(SB)	// y1 * hˆ3
 	ST (s2)
 
 	LDacc (x1in)
 	LDt (hsqr)
-	CALL p256MulInternal(SB)	// u1 * hˆ2
+	CALL p256MulInternal<>(SB)	// u1 * hˆ2
 	ST (h)
 
 	p256MulBy2Inline			// u1 * hˆ2 * 2, inline
 	LDacc (rsqr)
-	CALL p256SubInternal(SB)	// rˆ2 - u1 * hˆ2 * 2
+	CALL p256SubInternal<>(SB)	// rˆ2 - u1 * hˆ2 * 2
 
 	LDt (hcub)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	ST (xout)
 
 	MOVQ acc4, t0
@@ -1863,13 +1984,13 @@ TEXT ·p256PointAddAffineAsm(SB),0,$512-96
 	MOVQ acc6, t2
 	MOVQ acc7, t3
 	LDacc (h)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 
 	LDt (r)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 
 	LDt (s2)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	ST (yout)
 	// Load stored values from stack
 	MOVQ rptr, AX
@@ -1988,7 +2109,7 @@ TEXT ·p256PointAddAffineAsm(SB),0,$512-96
 
 // p256IsZero returns 1 in AX if [acc4..acc7] represents zero and zero
 // otherwise. It writes to [acc4..acc7], t0 and t1.
-TEXT p256IsZero(SB),NOSPLIT,$0
+TEXT p256IsZero<>(SB),NOSPLIT,$0
 	// AX contains a flag that is set if the input is zero.
 	XORQ AX, AX
 	MOVQ $1, t1
@@ -2080,79 +2201,79 @@ TEXT ·p256PointAddAsm(SB),0,$680-80
 	MOVQ AX, rptr
 	// Begin point add
 	LDacc (z2in)
-	CALL p256SqrInternal(SB)	// z2ˆ2
+	CALL p256SqrInternal<>(SB)	// z2ˆ2
 	ST (z2sqr)
 	LDt (z2in)
-	CALL p256MulInternal(SB)	// z2ˆ3
+	CALL p256MulInternal<>(SB)	// z2ˆ3
 	LDt (y1in)
-	CALL p256MulInternal(SB)	// s1 = z2ˆ3*y1
+	CALL p256MulInternal<>(SB)	// s1 = z2ˆ3*y1
 	ST (s1)
 
 	LDacc (z1in)
-	CALL p256SqrInternal(SB)	// z1ˆ2
+	CALL p256SqrInternal<>(SB)	// z1ˆ2
 	ST (z1sqr)
 	LDt (z1in)
-	CALL p256MulInternal(SB)	// z1ˆ3
+	CALL p256MulInternal<>(SB)	// z1ˆ3
 	LDt (y2in)
-	CALL p256MulInternal(SB)	// s2 = z1ˆ3*y2
+	CALL p256MulInternal<>(SB)	// s2 = z1ˆ3*y2
 	ST (s2)
 
 	LDt (s1)
-	CALL p256SubInternal(SB)	// r = s2 - s1
+	CALL p256SubInternal<>(SB)	// r = s2 - s1
 	ST (r)
-	CALL p256IsZero(SB)
+	CALL p256IsZero<>(SB)
 	MOVQ AX, points_eq
 
 	LDacc (z2sqr)
 	LDt (x1in)
-	CALL p256MulInternal(SB)	// u1 = x1 * z2ˆ2
+	CALL p256MulInternal<>(SB)	// u1 = x1 * z2ˆ2
 	ST (u1)
 	LDacc (z1sqr)
 	LDt (x2in)
-	CALL p256MulInternal(SB)	// u2 = x2 * z1ˆ2
+	CALL p256MulInternal<>(SB)	// u2 = x2 * z1ˆ2
 	ST (u2)
 
 	LDt (u1)
-	CALL p256SubInternal(SB)	// h = u2 - u1
+	CALL p256SubInternal<>(SB)	// h = u2 - u1
 	ST (h)
-	CALL p256IsZero(SB)
+	CALL p256IsZero<>(SB)
 	ANDQ points_eq, AX
 	MOVQ AX, points_eq
 
 	LDacc (r)
-	CALL p256SqrInternal(SB)	// rsqr = rˆ2
+	CALL p256SqrInternal<>(SB)	// rsqr = rˆ2
 	ST (rsqr)
 
 	LDacc (h)
-	CALL p256SqrInternal(SB)	// hsqr = hˆ2
+	CALL p256SqrInternal<>(SB)	// hsqr = hˆ2
 	ST (hsqr)
 
 	LDt (h)
-	CALL p256MulInternal(SB)	// hcub = hˆ3
+	CALL p256MulInternal<>(SB)	// hcub = hˆ3
 	ST (hcub)
 
 	LDt (s1)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 	ST (s2)
 
 	LDacc (z1in)
 	LDt (z2in)
-	CALL p256MulInternal(SB)	// z1 * z2
+	CALL p256MulInternal<>(SB)	// z1 * z2
 	LDt (h)
-	CALL p256MulInternal(SB)	// z1 * z2 * h
+	CALL p256MulInternal<>(SB)	// z1 * z2 * h
 	ST (zout)
 
 	LDacc (hsqr)
 	LDt (u1)
-	CALL p256MulInternal(SB)	// hˆ2 * u1
+	CALL p256MulInternal<>(SB)	// hˆ2 * u1
 	ST (u2)
 
 	p256MulBy2Inline	// u1 * hˆ2 * 2, inline
 	LDacc (rsqr)
-	CALL p256SubInternal(SB)	// rˆ2 - u1 * hˆ2 * 2
+	CALL p256SubInternal<>(SB)	// rˆ2 - u1 * hˆ2 * 2
 
 	LDt (hcub)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	ST (xout)
 
 	MOVQ acc4, t0
@@ -2160,13 +2281,13 @@ TEXT ·p256PointAddAsm(SB),0,$680-80
 	MOVQ acc6, t2
 	MOVQ acc7, t3
 	LDacc (u2)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 
 	LDt (r)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 
 	LDt (s2)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	ST (yout)
 
 	MOVOU xout(16*0), X0
@@ -2244,7 +2365,7 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 	MOVQ AX, rptr
 	// Begin point double
 	LDacc (z)
-	CALL p256SqrInternal(SB)
+	CALL p256SqrInternal<>(SB)
 	ST (zsqr)
 
 	LDt (x)
@@ -2253,7 +2374,7 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 
 	LDacc (z)
 	LDt (y)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 	p256MulBy2Inline
 	MOVQ rptr, AX
 	// Store z
@@ -2264,9 +2385,9 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 
 	LDacc (x)
 	LDt (zsqr)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	LDt (m)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 	ST (m)
 	// Multiply by 3
 	p256MulBy2Inline
@@ -2277,9 +2398,9 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 	LDacc (y)
 	p256MulBy2Inline
 	t2acc
-	CALL p256SqrInternal(SB)
+	CALL p256SqrInternal<>(SB)
 	ST (s)
-	CALL p256SqrInternal(SB)
+	CALL p256SqrInternal<>(SB)
 	// Divide by 2
 	XORQ mul0, mul0
 	MOVQ acc4, t0
@@ -2308,15 +2429,15 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 	/////////////////////////
 	LDacc (x)
 	LDt (s)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 	ST (s)
 	p256MulBy2Inline
 	STt (tmp)
 
 	LDacc (m)
-	CALL p256SqrInternal(SB)
+	CALL p256SqrInternal<>(SB)
 	LDt (tmp)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 
 	MOVQ rptr, AX
 	// Store x
@@ -2327,13 +2448,13 @@ TEXT ·p256PointDoubleAsm(SB),NOSPLIT,$256-48
 
 	acc2t
 	LDacc (s)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 
 	LDt (m)
-	CALL p256MulInternal(SB)
+	CALL p256MulInternal<>(SB)
 
 	LDt (y)
-	CALL p256SubInternal(SB)
+	CALL p256SubInternal<>(SB)
 	MOVQ rptr, AX
 	// Store y
 	MOVQ acc4, (16*2 + 8*0)(AX)
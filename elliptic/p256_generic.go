@@ -6,11 +6,6 @@
 
 package elliptic
 
-var (
-	p256 p256Curve
-)
-
 func initP256Arch() {
-	// Use pure Go implementation.
-	p256 = p256Curve{p256Params}
+	// Only the pure Go implementation is available.
 }
@@ -0,0 +1,863 @@
+// Code generated by curvegen. DO NOT EDIT.
+
+// +build 386 arm mips mipsle wasm
+
+package elliptic
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/safenum"
+)
+
+type p384Curve struct {
+	params *CurveParams
+}
+
+var p384 = p384Curve{&CurveParams{
+	P: safenum.ModulusFromBytes([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+		0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+	}),
+	N: safenum.ModulusFromBytes([]byte{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xc7, 0x63, 0x4d, 0x81, 0xf4, 0x37, 0x2d, 0xdf,
+		0x58, 0x1a, 0x0d, 0xb2, 0x48, 0xb0, 0xa7, 0x7a, 0xec, 0xec, 0x19, 0x6a, 0xcc, 0xc5, 0x29, 0x73,
+	}),
+	B: new(safenum.Nat).SetBytes([]byte{
+		0xb3, 0x31, 0x2f, 0xa7, 0xe2, 0x3e, 0xe7, 0xe4, 0x98, 0x8e, 0x05, 0x6b, 0xe3, 0xf8, 0x2d, 0x19,
+		0x18, 0x1d, 0x9c, 0x6e, 0xfe, 0x81, 0x41, 0x12, 0x03, 0x14, 0x08, 0x8f, 0x50, 0x13, 0x87, 0x5a,
+		0xc6, 0x56, 0x39, 0x8d, 0x8a, 0x2e, 0xd1, 0x9d, 0x2a, 0x85, 0xc8, 0xed, 0xd3, 0xec, 0x2a, 0xef,
+	}),
+	Gx: new(safenum.Nat).SetBytes([]byte{
+		0xaa, 0x87, 0xca, 0x22, 0xbe, 0x8b, 0x05, 0x37, 0x8e, 0xb1, 0xc7, 0x1e, 0xf3, 0x20, 0xad, 0x74,
+		0x6e, 0x1d, 0x3b, 0x62, 0x8b, 0xa7, 0x9b, 0x98, 0x59, 0xf7, 0x41, 0xe0, 0x82, 0x54, 0x2a, 0x38,
+		0x55, 0x02, 0xf2, 0x5d, 0xbf, 0x55, 0x29, 0x6c, 0x3a, 0x54, 0x5e, 0x38, 0x72, 0x76, 0x0a, 0xb7,
+	}),
+	Gy: new(safenum.Nat).SetBytes([]byte{
+		0x36, 0x17, 0xde, 0x4a, 0x96, 0x26, 0x2c, 0x6f, 0x5d, 0x9e, 0x98, 0xbf, 0x92, 0x92, 0xdc, 0x29,
+		0xf8, 0xf4, 0x1d, 0xbd, 0x28, 0x9a, 0x14, 0x7c, 0xe9, 0xda, 0x31, 0x13, 0xb5, 0xf0, 0xb8, 0xc0,
+		0x0a, 0x60, 0xb1, 0xce, 0x1d, 0x7e, 0x81, 0x9d, 0x7a, 0x43, 0x1d, 0x7c, 0x90, 0xea, 0x0e, 0x5f,
+	}),
+	BitSize: 384,
+	Name:    "P-384",
+}}
+
+// p384P is the order of the field.
+var p384P = new(big.Int).SetBytes([]byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+	0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+})
+
+func (curve p384Curve) Params() *CurveParams {
+	return curve.params
+}
+
+func (curve p384Curve) IsOnCurve(x, y *big.Int) bool {
+	var fx, fy p384Element
+	xValid := p384FromBig(&fx, x)
+	yValid := p384FromBig(&fy, y)
+	// y² = x³ - 3x + b
+	var y2, rhs p384Element
+	p384Mul(&y2, &fy, &fy)
+	p384Polynomial(&rhs, &fx)
+	return xValid && yValid && p384Equal(&y2, &rhs) == 1
+}
+
+func (curve p384Curve) Add(x1, y1, x2, y2 *big.Int) (x, y *big.Int) {
+	p := p384FromAffine(x1, y1)
+	return p.add(p, p384FromAffine(x2, y2)).affine()
+}
+
+func (curve p384Curve) Double(x1, y1 *big.Int) (x, y *big.Int) {
+	p := p384FromAffine(x1, y1)
+	return p.double(p).affine()
+}
+
+func (curve p384Curve) ScalarMult(x1, y1 *big.Int, k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := p384FromAffine(x1, y1)
+	return p.scalarMult(p, k).affine()
+}
+
+func (curve p384Curve) ScalarBaseMult(k []byte) (x, y *big.Int) {
+	defer instrument.Begin(instrument.ScalarMult, curve.params.Name)()
+	p := &p384Point{p384Gx, p384Gy, p384One}
+	return p.scalarMult(p, k).affine()
+}
+
+// p384Element is an element of the field, in the Montgomery domain, as
+// 12 32-bit limbs in little-endian order. It is always fully reduced.
+type p384Element [12]uint32
+
+var (
+	// p384One is R mod p, representing 1.
+	p384One = p384Element{0x00000001, 0xffffffff, 0xffffffff, 0x00000000, 0x00000001, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000}
+	// p384R2 is R² mod p, which converts into the Montgomery domain.
+	p384R2 = p384Element{0x00000001, 0xfffffffe, 0x00000000, 0x00000002, 0x00000000, 0xfffffffe, 0x00000000, 0x00000002, 0x00000001, 0x00000000, 0x00000000, 0x00000000}
+	// p384B is the constant of the curve equation.
+	p384B = p384Element{0x9d412dcc, 0x08118871, 0x7a4c32ec, 0xf729add8, 0x1920022e, 0x77f2209b, 0x94938ae2, 0xe3374bee, 0x1f022094, 0xb62b21f4, 0x604fbff9, 0xcd08114b}
+	// p384Gx and p384Gy are the coordinates of the generator.
+	p384Gx = p384Element{0x49c0b528, 0x3dd07566, 0xa0d6ce38, 0x20e378e2, 0x541b4d6e, 0x879c3afc, 0x59a30eff, 0x64548684, 0x614ede2b, 0x812ff723, 0x299e1513, 0x4d3aadc2}
+	p384Gy = p384Element{0x4b03a4fe, 0x23043dad, 0x7bb4a9ac, 0xa1bfa8bf, 0x2e83b050, 0x8bade756, 0x68f4ffd9, 0xc6c35219, 0x3969a840, 0xdd800226, 0x5a15c5e9, 0x2b78abc2}
+)
+
+// p384FromBig sets out to x mod p, and reports whether x was in [0, p).
+func p384FromBig(out *p384Element, x *big.Int) bool {
+	reduced := x.Sign() >= 0 && x.Cmp(p384P) < 0
+	var buf [12 * 4]byte
+	new(big.Int).Mod(x, p384P).FillBytes(buf[:])
+	for i := range out {
+		out[i] = binary.BigEndian.Uint32(buf[len(buf)-4*(i+1):])
+	}
+	p384Mul(out, out, &p384R2)
+	return reduced
+}
+
+// p384ToBig returns the integer in [0, p) which a represents.
+func p384ToBig(a *p384Element) *big.Int {
+	var t p384Element
+	p384Mul(&t, a, &p384Element{1})
+	var buf [12 * 4]byte
+	for i := range t {
+		binary.BigEndian.PutUint32(buf[len(buf)-4*(i+1):], t[i])
+	}
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// p384MulAdd returns x * y + a + b, as two limbs.
+func p384MulAdd(x, y, a, b uint32) (hi, lo uint32) {
+	hi, lo = bits.Mul32(x, y)
+	var c uint32
+	lo, c = bits.Add32(lo, a, 0)
+	hi += c
+	lo, c = bits.Add32(lo, b, 0)
+	hi += c
+	return hi, lo
+}
+
+// p384Add sets out = a + b.
+func p384Add(out, a, b *p384Element) {
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, c uint32
+	s0, c = bits.Add32(a[0], b[0], c)
+	s1, c = bits.Add32(a[1], b[1], c)
+	s2, c = bits.Add32(a[2], b[2], c)
+	s3, c = bits.Add32(a[3], b[3], c)
+	s4, c = bits.Add32(a[4], b[4], c)
+	s5, c = bits.Add32(a[5], b[5], c)
+	s6, c = bits.Add32(a[6], b[6], c)
+	s7, c = bits.Add32(a[7], b[7], c)
+	s8, c = bits.Add32(a[8], b[8], c)
+	s9, c = bits.Add32(a[9], b[9], c)
+	s10, c = bits.Add32(a[10], b[10], c)
+	s11, c = bits.Add32(a[11], b[11], c)
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, borrow uint32
+	d0, borrow = bits.Sub32(s0, 0xffffffff, borrow)
+	d1, borrow = bits.Sub32(s1, 0x0, borrow)
+	d2, borrow = bits.Sub32(s2, 0x0, borrow)
+	d3, borrow = bits.Sub32(s3, 0xffffffff, borrow)
+	d4, borrow = bits.Sub32(s4, 0xfffffffe, borrow)
+	d5, borrow = bits.Sub32(s5, 0xffffffff, borrow)
+	d6, borrow = bits.Sub32(s6, 0xffffffff, borrow)
+	d7, borrow = bits.Sub32(s7, 0xffffffff, borrow)
+	d8, borrow = bits.Sub32(s8, 0xffffffff, borrow)
+	d9, borrow = bits.Sub32(s9, 0xffffffff, borrow)
+	d10, borrow = bits.Sub32(s10, 0xffffffff, borrow)
+	d11, borrow = bits.Sub32(s11, 0xffffffff, borrow)
+	_, borrow = bits.Sub32(c, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+	out[9] = d9 ^ (mask & (d9 ^ s9))
+	out[10] = d10 ^ (mask & (d10 ^ s10))
+	out[11] = d11 ^ (mask & (d11 ^ s11))
+}
+
+// p384Sub sets out = a - b.
+func p384Sub(out, a, b *p384Element) {
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, borrow uint32
+	d0, borrow = bits.Sub32(a[0], b[0], borrow)
+	d1, borrow = bits.Sub32(a[1], b[1], borrow)
+	d2, borrow = bits.Sub32(a[2], b[2], borrow)
+	d3, borrow = bits.Sub32(a[3], b[3], borrow)
+	d4, borrow = bits.Sub32(a[4], b[4], borrow)
+	d5, borrow = bits.Sub32(a[5], b[5], borrow)
+	d6, borrow = bits.Sub32(a[6], b[6], borrow)
+	d7, borrow = bits.Sub32(a[7], b[7], borrow)
+	d8, borrow = bits.Sub32(a[8], b[8], borrow)
+	d9, borrow = bits.Sub32(a[9], b[9], borrow)
+	d10, borrow = bits.Sub32(a[10], b[10], borrow)
+	d11, borrow = bits.Sub32(a[11], b[11], borrow)
+	// If a < b, p is added back.
+	mask := -borrow
+	var c uint32
+	out[0], c = bits.Add32(d0, 0xffffffff&mask, c)
+	out[1], c = bits.Add32(d1, 0x0&mask, c)
+	out[2], c = bits.Add32(d2, 0x0&mask, c)
+	out[3], c = bits.Add32(d3, 0xffffffff&mask, c)
+	out[4], c = bits.Add32(d4, 0xfffffffe&mask, c)
+	out[5], c = bits.Add32(d5, 0xffffffff&mask, c)
+	out[6], c = bits.Add32(d6, 0xffffffff&mask, c)
+	out[7], c = bits.Add32(d7, 0xffffffff&mask, c)
+	out[8], c = bits.Add32(d8, 0xffffffff&mask, c)
+	out[9], c = bits.Add32(d9, 0xffffffff&mask, c)
+	out[10], c = bits.Add32(d10, 0xffffffff&mask, c)
+	out[11], c = bits.Add32(d11, 0xffffffff&mask, c)
+}
+
+// p384Mul sets out = a * b, with Montgomery multiplication, which
+// computes a * b / R mod p.
+func p384Mul(out, a, b *p384Element) {
+	var s0, s1, s2, s3, s4, s5, s6, s7, s8, s9, s10, s11, s12, s13, c, m uint32
+
+	// Round 0.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[0], s0, c)
+	c, s1 = p384MulAdd(a[1], b[0], s1, c)
+	c, s2 = p384MulAdd(a[2], b[0], s2, c)
+	c, s3 = p384MulAdd(a[3], b[0], s3, c)
+	c, s4 = p384MulAdd(a[4], b[0], s4, c)
+	c, s5 = p384MulAdd(a[5], b[0], s5, c)
+	c, s6 = p384MulAdd(a[6], b[0], s6, c)
+	c, s7 = p384MulAdd(a[7], b[0], s7, c)
+	c, s8 = p384MulAdd(a[8], b[0], s8, c)
+	c, s9 = p384MulAdd(a[9], b[0], s9, c)
+	c, s10 = p384MulAdd(a[10], b[0], s10, c)
+	c, s11 = p384MulAdd(a[11], b[0], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 1.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[1], s0, c)
+	c, s1 = p384MulAdd(a[1], b[1], s1, c)
+	c, s2 = p384MulAdd(a[2], b[1], s2, c)
+	c, s3 = p384MulAdd(a[3], b[1], s3, c)
+	c, s4 = p384MulAdd(a[4], b[1], s4, c)
+	c, s5 = p384MulAdd(a[5], b[1], s5, c)
+	c, s6 = p384MulAdd(a[6], b[1], s6, c)
+	c, s7 = p384MulAdd(a[7], b[1], s7, c)
+	c, s8 = p384MulAdd(a[8], b[1], s8, c)
+	c, s9 = p384MulAdd(a[9], b[1], s9, c)
+	c, s10 = p384MulAdd(a[10], b[1], s10, c)
+	c, s11 = p384MulAdd(a[11], b[1], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 2.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[2], s0, c)
+	c, s1 = p384MulAdd(a[1], b[2], s1, c)
+	c, s2 = p384MulAdd(a[2], b[2], s2, c)
+	c, s3 = p384MulAdd(a[3], b[2], s3, c)
+	c, s4 = p384MulAdd(a[4], b[2], s4, c)
+	c, s5 = p384MulAdd(a[5], b[2], s5, c)
+	c, s6 = p384MulAdd(a[6], b[2], s6, c)
+	c, s7 = p384MulAdd(a[7], b[2], s7, c)
+	c, s8 = p384MulAdd(a[8], b[2], s8, c)
+	c, s9 = p384MulAdd(a[9], b[2], s9, c)
+	c, s10 = p384MulAdd(a[10], b[2], s10, c)
+	c, s11 = p384MulAdd(a[11], b[2], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 3.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[3], s0, c)
+	c, s1 = p384MulAdd(a[1], b[3], s1, c)
+	c, s2 = p384MulAdd(a[2], b[3], s2, c)
+	c, s3 = p384MulAdd(a[3], b[3], s3, c)
+	c, s4 = p384MulAdd(a[4], b[3], s4, c)
+	c, s5 = p384MulAdd(a[5], b[3], s5, c)
+	c, s6 = p384MulAdd(a[6], b[3], s6, c)
+	c, s7 = p384MulAdd(a[7], b[3], s7, c)
+	c, s8 = p384MulAdd(a[8], b[3], s8, c)
+	c, s9 = p384MulAdd(a[9], b[3], s9, c)
+	c, s10 = p384MulAdd(a[10], b[3], s10, c)
+	c, s11 = p384MulAdd(a[11], b[3], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 4.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[4], s0, c)
+	c, s1 = p384MulAdd(a[1], b[4], s1, c)
+	c, s2 = p384MulAdd(a[2], b[4], s2, c)
+	c, s3 = p384MulAdd(a[3], b[4], s3, c)
+	c, s4 = p384MulAdd(a[4], b[4], s4, c)
+	c, s5 = p384MulAdd(a[5], b[4], s5, c)
+	c, s6 = p384MulAdd(a[6], b[4], s6, c)
+	c, s7 = p384MulAdd(a[7], b[4], s7, c)
+	c, s8 = p384MulAdd(a[8], b[4], s8, c)
+	c, s9 = p384MulAdd(a[9], b[4], s9, c)
+	c, s10 = p384MulAdd(a[10], b[4], s10, c)
+	c, s11 = p384MulAdd(a[11], b[4], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 5.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[5], s0, c)
+	c, s1 = p384MulAdd(a[1], b[5], s1, c)
+	c, s2 = p384MulAdd(a[2], b[5], s2, c)
+	c, s3 = p384MulAdd(a[3], b[5], s3, c)
+	c, s4 = p384MulAdd(a[4], b[5], s4, c)
+	c, s5 = p384MulAdd(a[5], b[5], s5, c)
+	c, s6 = p384MulAdd(a[6], b[5], s6, c)
+	c, s7 = p384MulAdd(a[7], b[5], s7, c)
+	c, s8 = p384MulAdd(a[8], b[5], s8, c)
+	c, s9 = p384MulAdd(a[9], b[5], s9, c)
+	c, s10 = p384MulAdd(a[10], b[5], s10, c)
+	c, s11 = p384MulAdd(a[11], b[5], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 6.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[6], s0, c)
+	c, s1 = p384MulAdd(a[1], b[6], s1, c)
+	c, s2 = p384MulAdd(a[2], b[6], s2, c)
+	c, s3 = p384MulAdd(a[3], b[6], s3, c)
+	c, s4 = p384MulAdd(a[4], b[6], s4, c)
+	c, s5 = p384MulAdd(a[5], b[6], s5, c)
+	c, s6 = p384MulAdd(a[6], b[6], s6, c)
+	c, s7 = p384MulAdd(a[7], b[6], s7, c)
+	c, s8 = p384MulAdd(a[8], b[6], s8, c)
+	c, s9 = p384MulAdd(a[9], b[6], s9, c)
+	c, s10 = p384MulAdd(a[10], b[6], s10, c)
+	c, s11 = p384MulAdd(a[11], b[6], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 7.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[7], s0, c)
+	c, s1 = p384MulAdd(a[1], b[7], s1, c)
+	c, s2 = p384MulAdd(a[2], b[7], s2, c)
+	c, s3 = p384MulAdd(a[3], b[7], s3, c)
+	c, s4 = p384MulAdd(a[4], b[7], s4, c)
+	c, s5 = p384MulAdd(a[5], b[7], s5, c)
+	c, s6 = p384MulAdd(a[6], b[7], s6, c)
+	c, s7 = p384MulAdd(a[7], b[7], s7, c)
+	c, s8 = p384MulAdd(a[8], b[7], s8, c)
+	c, s9 = p384MulAdd(a[9], b[7], s9, c)
+	c, s10 = p384MulAdd(a[10], b[7], s10, c)
+	c, s11 = p384MulAdd(a[11], b[7], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 8.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[8], s0, c)
+	c, s1 = p384MulAdd(a[1], b[8], s1, c)
+	c, s2 = p384MulAdd(a[2], b[8], s2, c)
+	c, s3 = p384MulAdd(a[3], b[8], s3, c)
+	c, s4 = p384MulAdd(a[4], b[8], s4, c)
+	c, s5 = p384MulAdd(a[5], b[8], s5, c)
+	c, s6 = p384MulAdd(a[6], b[8], s6, c)
+	c, s7 = p384MulAdd(a[7], b[8], s7, c)
+	c, s8 = p384MulAdd(a[8], b[8], s8, c)
+	c, s9 = p384MulAdd(a[9], b[8], s9, c)
+	c, s10 = p384MulAdd(a[10], b[8], s10, c)
+	c, s11 = p384MulAdd(a[11], b[8], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 9.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[9], s0, c)
+	c, s1 = p384MulAdd(a[1], b[9], s1, c)
+	c, s2 = p384MulAdd(a[2], b[9], s2, c)
+	c, s3 = p384MulAdd(a[3], b[9], s3, c)
+	c, s4 = p384MulAdd(a[4], b[9], s4, c)
+	c, s5 = p384MulAdd(a[5], b[9], s5, c)
+	c, s6 = p384MulAdd(a[6], b[9], s6, c)
+	c, s7 = p384MulAdd(a[7], b[9], s7, c)
+	c, s8 = p384MulAdd(a[8], b[9], s8, c)
+	c, s9 = p384MulAdd(a[9], b[9], s9, c)
+	c, s10 = p384MulAdd(a[10], b[9], s10, c)
+	c, s11 = p384MulAdd(a[11], b[9], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 10.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[10], s0, c)
+	c, s1 = p384MulAdd(a[1], b[10], s1, c)
+	c, s2 = p384MulAdd(a[2], b[10], s2, c)
+	c, s3 = p384MulAdd(a[3], b[10], s3, c)
+	c, s4 = p384MulAdd(a[4], b[10], s4, c)
+	c, s5 = p384MulAdd(a[5], b[10], s5, c)
+	c, s6 = p384MulAdd(a[6], b[10], s6, c)
+	c, s7 = p384MulAdd(a[7], b[10], s7, c)
+	c, s8 = p384MulAdd(a[8], b[10], s8, c)
+	c, s9 = p384MulAdd(a[9], b[10], s9, c)
+	c, s10 = p384MulAdd(a[10], b[10], s10, c)
+	c, s11 = p384MulAdd(a[11], b[10], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	// Round 11.
+	c = 0
+	c, s0 = p384MulAdd(a[0], b[11], s0, c)
+	c, s1 = p384MulAdd(a[1], b[11], s1, c)
+	c, s2 = p384MulAdd(a[2], b[11], s2, c)
+	c, s3 = p384MulAdd(a[3], b[11], s3, c)
+	c, s4 = p384MulAdd(a[4], b[11], s4, c)
+	c, s5 = p384MulAdd(a[5], b[11], s5, c)
+	c, s6 = p384MulAdd(a[6], b[11], s6, c)
+	c, s7 = p384MulAdd(a[7], b[11], s7, c)
+	c, s8 = p384MulAdd(a[8], b[11], s8, c)
+	c, s9 = p384MulAdd(a[9], b[11], s9, c)
+	c, s10 = p384MulAdd(a[10], b[11], s10, c)
+	c, s11 = p384MulAdd(a[11], b[11], s11, c)
+	s12, c = bits.Add32(s12, c, 0)
+	s13 = c
+	m = s0
+	c = s0
+	s0, c = bits.Add32(s1, c, 0)
+	s1, c = bits.Add32(s2, c, 0)
+	c, s2 = p384MulAdd(m, 0xffffffff, s3, c)
+	c, s3 = p384MulAdd(m, 0xfffffffe, s4, c)
+	c, s4 = p384MulAdd(m, 0xffffffff, s5, c)
+	c, s5 = p384MulAdd(m, 0xffffffff, s6, c)
+	c, s6 = p384MulAdd(m, 0xffffffff, s7, c)
+	c, s7 = p384MulAdd(m, 0xffffffff, s8, c)
+	c, s8 = p384MulAdd(m, 0xffffffff, s9, c)
+	c, s9 = p384MulAdd(m, 0xffffffff, s10, c)
+	c, s10 = p384MulAdd(m, 0xffffffff, s11, c)
+	s11, c = bits.Add32(s12, c, 0)
+	s12 = s13 + c
+
+	var d0, d1, d2, d3, d4, d5, d6, d7, d8, d9, d10, d11, borrow uint32
+	d0, borrow = bits.Sub32(s0, 0xffffffff, borrow)
+	d1, borrow = bits.Sub32(s1, 0x0, borrow)
+	d2, borrow = bits.Sub32(s2, 0x0, borrow)
+	d3, borrow = bits.Sub32(s3, 0xffffffff, borrow)
+	d4, borrow = bits.Sub32(s4, 0xfffffffe, borrow)
+	d5, borrow = bits.Sub32(s5, 0xffffffff, borrow)
+	d6, borrow = bits.Sub32(s6, 0xffffffff, borrow)
+	d7, borrow = bits.Sub32(s7, 0xffffffff, borrow)
+	d8, borrow = bits.Sub32(s8, 0xffffffff, borrow)
+	d9, borrow = bits.Sub32(s9, 0xffffffff, borrow)
+	d10, borrow = bits.Sub32(s10, 0xffffffff, borrow)
+	d11, borrow = bits.Sub32(s11, 0xffffffff, borrow)
+	_, borrow = bits.Sub32(s12, 0, borrow)
+	// The value is kept if it was lower than p.
+	mask := -borrow
+	out[0] = d0 ^ (mask & (d0 ^ s0))
+	out[1] = d1 ^ (mask & (d1 ^ s1))
+	out[2] = d2 ^ (mask & (d2 ^ s2))
+	out[3] = d3 ^ (mask & (d3 ^ s3))
+	out[4] = d4 ^ (mask & (d4 ^ s4))
+	out[5] = d5 ^ (mask & (d5 ^ s5))
+	out[6] = d6 ^ (mask & (d6 ^ s6))
+	out[7] = d7 ^ (mask & (d7 ^ s7))
+	out[8] = d8 ^ (mask & (d8 ^ s8))
+	out[9] = d9 ^ (mask & (d9 ^ s9))
+	out[10] = d10 ^ (mask & (d10 ^ s10))
+	out[11] = d11 ^ (mask & (d11 ^ s11))
+}
+
+// p384IsZero returns 1 if a is zero, and 0 otherwise.
+func p384IsZero(a *p384Element) uint32 {
+	var acc uint32
+	for _, l := range a {
+		acc |= l
+	}
+	// The top bit of acc | -acc is set unless acc is zero.
+	return (acc|-acc)>>31 ^ 1
+}
+
+// p384Equal returns 1 if a and b are equal, and 0 otherwise.
+func p384Equal(a, b *p384Element) uint32 {
+	var d p384Element
+	for i := range d {
+		d[i] = a[i] ^ b[i]
+	}
+	return p384IsZero(&d)
+}
+
+// p384Select sets out to a if control is 1, and to b if it is 0.
+func p384Select(out, a, b *p384Element, control uint32) {
+	mask := -control
+	for i := range out {
+		out[i] = b[i] ^ (mask & (a[i] ^ b[i]))
+	}
+}
+
+// p384PMinus2 is p - 2, in big-endian order.
+var p384PMinus2 = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+	0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xfd,
+}
+
+// p384Invert sets out = 1/a, or 0 if a is 0, as a^(p-2). The exponent
+// is public, so branching on its bits doesn't leak anything about a.
+func p384Invert(out, a *p384Element) {
+	in := *a
+	r := p384One
+	for _, b := range p384PMinus2 {
+		for i := 7; i >= 0; i-- {
+			p384Mul(&r, &r, &r)
+			if b>>i&1 == 1 {
+				p384Mul(&r, &r, &in)
+			}
+		}
+	}
+	*out = r
+}
+
+// p384Polynomial sets out = x³ - 3x + b.
+func p384Polynomial(out, x *p384Element) {
+	var x3, threeX p384Element
+	p384Mul(&x3, x, x)
+	p384Mul(&x3, &x3, x)
+	p384Add(&threeX, x, x)
+	p384Add(&threeX, &threeX, x)
+	p384Sub(&x3, &x3, &threeX)
+	p384Add(out, &x3, &p384B)
+}
+
+// p384Point is a point in projective coordinates (X:Y:Z), representing
+// (X/Z, Y/Z), or the point at infinity, (0:1:0), if Z is 0.
+type p384Point struct {
+	x, y, z p384Element
+}
+
+// p384FromAffine returns the point (x, y), where (0, 0) is the point at
+// infinity, as in the elliptic package.
+func p384FromAffine(x, y *big.Int) *p384Point {
+	p := &p384Point{y: p384One}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return p
+	}
+	p384FromBig(&p.x, x)
+	p384FromBig(&p.y, y)
+	p.z = p384One
+	return p
+}
+
+// affine returns the affine coordinates of p, or (0, 0) for the point at
+// infinity.
+func (p *p384Point) affine() (x, y *big.Int) {
+	if p384IsZero(&p.z) == 1 {
+		return new(big.Int), new(big.Int)
+	}
+	var zInv, ax, ay p384Element
+	p384Invert(&zInv, &p.z)
+	p384Mul(&ax, &p.x, &zInv)
+	p384Mul(&ay, &p.y, &zInv)
+	return p384ToBig(&ax), p384ToBig(&ay)
+}
+
+// add sets q = p1 + p2, and returns q, with the complete addition formula for
+// a = -3 of Renes, Costello, and Batina, algorithm 4.
+func (q *p384Point) add(p1, p2 *p384Point) *p384Point {
+	var t0, t1, t2, t3, t4, x3, y3, z3 p384Element
+	p384Mul(&t0, &p1.x, &p2.x)
+	p384Mul(&t1, &p1.y, &p2.y)
+	p384Mul(&t2, &p1.z, &p2.z)
+	p384Add(&t3, &p1.x, &p1.y)
+	p384Add(&t4, &p2.x, &p2.y)
+	p384Mul(&t3, &t3, &t4)
+	p384Add(&t4, &t0, &t1)
+	p384Sub(&t3, &t3, &t4)
+	p384Add(&t4, &p1.y, &p1.z)
+	p384Add(&x3, &p2.y, &p2.z)
+	p384Mul(&t4, &t4, &x3)
+	p384Add(&x3, &t1, &t2)
+	p384Sub(&t4, &t4, &x3)
+	p384Add(&x3, &p1.x, &p1.z)
+	p384Add(&y3, &p2.x, &p2.z)
+	p384Mul(&x3, &x3, &y3)
+	p384Add(&y3, &t0, &t2)
+	p384Sub(&y3, &x3, &y3)
+	p384Mul(&z3, &p384B, &t2)
+	p384Sub(&x3, &y3, &z3)
+	p384Add(&z3, &x3, &x3)
+	p384Add(&x3, &x3, &z3)
+	p384Sub(&z3, &t1, &x3)
+	p384Add(&x3, &t1, &x3)
+	p384Mul(&y3, &p384B, &y3)
+	p384Add(&t1, &t2, &t2)
+	p384Add(&t2, &t1, &t2)
+	p384Sub(&y3, &y3, &t2)
+	p384Sub(&y3, &y3, &t0)
+	p384Add(&t1, &y3, &y3)
+	p384Add(&y3, &t1, &y3)
+	p384Add(&t1, &t0, &t0)
+	p384Add(&t0, &t1, &t0)
+	p384Sub(&t0, &t0, &t2)
+	p384Mul(&t1, &t4, &y3)
+	p384Mul(&t2, &t0, &y3)
+	p384Mul(&y3, &x3, &z3)
+	p384Add(&y3, &y3, &t2)
+	p384Mul(&x3, &t3, &x3)
+	p384Sub(&x3, &x3, &t1)
+	p384Mul(&z3, &t4, &z3)
+	p384Mul(&t1, &t3, &t0)
+	p384Add(&z3, &z3, &t1)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// double sets q = 2p, and returns q, with the doubling formula for a = -3 of
+// Renes, Costello, and Batina, algorithm 6.
+func (q *p384Point) double(p *p384Point) *p384Point {
+	var t0, t1, t2, t3, x3, y3, z3 p384Element
+	p384Mul(&t0, &p.x, &p.x)
+	p384Mul(&t1, &p.y, &p.y)
+	p384Mul(&t2, &p.z, &p.z)
+	p384Mul(&t3, &p.x, &p.y)
+	p384Add(&t3, &t3, &t3)
+	p384Mul(&z3, &p.x, &p.z)
+	p384Add(&z3, &z3, &z3)
+	p384Mul(&y3, &p384B, &t2)
+	p384Sub(&y3, &y3, &z3)
+	p384Add(&x3, &y3, &y3)
+	p384Add(&y3, &x3, &y3)
+	p384Sub(&x3, &t1, &y3)
+	p384Add(&y3, &t1, &y3)
+	p384Mul(&y3, &x3, &y3)
+	p384Mul(&x3, &x3, &t3)
+	p384Add(&t3, &t2, &t2)
+	p384Add(&t2, &t2, &t3)
+	p384Mul(&z3, &p384B, &z3)
+	p384Sub(&z3, &z3, &t2)
+	p384Sub(&z3, &z3, &t0)
+	p384Add(&t3, &z3, &z3)
+	p384Add(&z3, &z3, &t3)
+	p384Add(&t3, &t0, &t0)
+	p384Add(&t0, &t3, &t0)
+	p384Sub(&t0, &t0, &t2)
+	p384Mul(&t0, &t0, &z3)
+	p384Add(&y3, &y3, &t0)
+	p384Mul(&t0, &p.y, &p.z)
+	p384Add(&t0, &t0, &t0)
+	p384Mul(&z3, &t0, &z3)
+	p384Sub(&x3, &x3, &z3)
+	p384Mul(&z3, &t0, &t1)
+	p384Add(&z3, &z3, &z3)
+	p384Add(&z3, &z3, &z3)
+	q.x, q.y, q.z = x3, y3, z3
+	return q
+}
+
+// selectPoint sets q to a if control is 1, and leaves it unchanged if it is 0.
+func (q *p384Point) selectPoint(a *p384Point, control uint32) {
+	p384Select(&q.x, &a.x, &q.x, control)
+	p384Select(&q.y, &a.y, &q.y, control)
+	p384Select(&q.z, &a.z, &q.z, control)
+}
+
+// scalarMult sets q = k * p, and returns q, with a fixed window of 4 bits.
+// Every window does the same doublings and addition, and reads the whole
+// table, whatever the value of k.
+func (q *p384Point) scalarMult(p *p384Point, k []byte) *p384Point {
+	var table [16]p384Point
+	table[0].y = p384One
+	table[1] = *p
+	for i := 2; i < 16; i += 2 {
+		table[i].double(&table[i/2])
+		table[i+1].add(&table[i], p)
+	}
+	out := p384Point{y: p384One}
+	var t p384Point
+	for _, b := range k {
+		for shift := 4; shift >= 0; shift -= 4 {
+			w := b >> shift & 0xf
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			out.double(&out)
+			t = table[0]
+			for j := 1; j < 16; j++ {
+				t.selectPoint(&table[j], uint32(subtle.ConstantTimeByteEq(uint8(j), w)))
+			}
+			out.add(&out, &t)
+		}
+	}
+	*q = out
+	return q
+}
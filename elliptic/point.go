@@ -0,0 +1,139 @@
+package elliptic
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/cronokirby/safenum"
+)
+
+// Point is a point of a curve, whose coordinates are held as safenum.Nat
+// values, reduced modulo P, so that it can be encoded without going through
+// math/big. As elsewhere in this package, (0, 0) is the point at infinity.
+type Point struct {
+	Curve
+	X, Y *safenum.Nat
+}
+
+// NewPoint returns the point (x, y) of curve. It doesn't check that the point
+// is on the curve, but reduces its coordinates modulo P.
+func NewPoint(curve Curve, x, y *big.Int) *Point {
+	P := curve.Params().P
+	return &Point{
+		Curve: curve,
+		X:     new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(x.Bytes()), P),
+		Y:     new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(y.Bytes()), P),
+	}
+}
+
+// Big returns the coordinates of the point, for the methods of Curve.
+func (p *Point) Big() (x, y *big.Int) {
+	return natToBig(p.X), natToBig(p.Y)
+}
+
+// Bytes returns the uncompressed encoding of the point, from MarshalNat.
+func (p *Point) Bytes() []byte {
+	return MarshalNat(p.Curve, p.X, p.Y)
+}
+
+// BytesCompressed returns the compressed encoding of the point, from
+// MarshalCompressedNat.
+func (p *Point) BytesCompressed() []byte {
+	return MarshalCompressedNat(p.Curve, p.X, p.Y)
+}
+
+// PointKey is the compressed encoding of a point, padded with zeros to a fixed
+// size, which can be compared with ==, and used as a map key.
+type PointKey [1 + (521+7)/8]byte
+
+// CompactKey returns the compressed encoding of the point, from
+// BytesCompressed, followed by zeros, or the zero key for the point at
+// infinity. It panics if the curve is larger than P-521.
+//
+// Keys of points on different curves of the same size can be equal, so they
+// shouldn't be mixed in the same map. Since looking up a map reveals its keys
+// through timings, they are meant for public points, like the keys of peers,
+// or commitments.
+func (p *Point) CompactKey() PointKey {
+	var key PointKey
+	compressed := p.BytesCompressed()
+	if len(compressed) > len(key) {
+		panic("elliptic: curve too large for CompactKey")
+	}
+	copy(key[:], compressed)
+	zero := new(safenum.Nat)
+	infinity := ctEq(p.X, zero) & ctEq(p.Y, zero)
+	key[0] &^= byte(-infinity)
+	return key
+}
+
+// Equal returns 1 if p and q are the same point of the same curve, and 0
+// otherwise, comparing the coordinates in constant time.
+func (p *Point) Equal(q *Point) int {
+	if p.Curve != q.Curve {
+		return 0
+	}
+	return ctEq(p.X, q.X) & ctEq(p.Y, q.Y)
+}
+
+// PointFormat is an encoding of points, from section 4.3.6 of ANSI X9.62.
+type PointFormat int
+
+const (
+	// Uncompressed points are encoded as 0x04 || x || y.
+	Uncompressed PointFormat = iota + 1
+	// Compressed points are encoded as 0x02 || x, or 0x03 || x if y is odd.
+	Compressed
+	// Hybrid points are encoded as 0x06 || x || y, or 0x07 || x || y if y is
+	// odd.
+	Hybrid
+)
+
+func (f PointFormat) String() string {
+	switch f {
+	case Uncompressed:
+		return "uncompressed"
+	case Compressed:
+		return "compressed"
+	case Hybrid:
+		return "hybrid"
+	}
+	return "PointFormat(" + strconv.Itoa(int(f)) + ")"
+}
+
+var errInvalidPoint = errors.New("elliptic: invalid point")
+
+// ParsePoint decodes a point of curve in any of the formats of ANSI X9.62,
+// which it detects from the length of data and its first byte, and returns
+// it along with its format.
+//
+// Only canonical encodings of points on the curve are accepted: coordinates
+// must be reduced modulo P, and the parity of y in the prefix of hybrid points
+// must match y. The point at infinity is rejected. Like Unmarshal, an invalid
+// point is rejected in the same time, whichever check fails, and nothing is
+// revealed about the format of an invalid point but its length.
+func ParsePoint(curve Curve, data []byte) (*Point, PointFormat, error) {
+	params := curve.Params()
+	byteLen := (params.BitSize + 7) / 8
+	// The length, which is public, tells a compressed point from the others.
+	if len(data) == 1+byteLen {
+		x, y := UnmarshalCompressedNat(curve, data)
+		if x == nil {
+			return nil, 0, errInvalidPoint
+		}
+		return &Point{Curve: curve, X: x, Y: y}, Compressed, nil
+	}
+	x, y, prefix, valid := params.unmarshalLong(data)
+	uncompressed := subtle.ConstantTimeByteEq(prefix, 4)
+	hybrid := subtle.ConstantTimeByteEq(prefix|1, 7) & subtle.ConstantTimeEq(int32(sgn0(y)), int32(prefix&1))
+	if valid&(uncompressed|hybrid) != 1 {
+		return nil, 0, errInvalidPoint
+	}
+	format := Hybrid
+	if uncompressed == 1 {
+		format = Uncompressed
+	}
+	return &Point{Curve: curve, X: x, Y: y}, format, nil
+}
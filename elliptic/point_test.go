@@ -0,0 +1,149 @@
+package elliptic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPoint(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		name := curve.Params().Name
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewPoint(curve, x, y)
+		if !bytes.Equal(p.Bytes(), Marshal(curve, x, y)) {
+			t.Errorf("%s: Bytes and Marshal differ", name)
+		}
+		if !bytes.Equal(p.BytesCompressed(), MarshalCompressed(curve, x, y)) {
+			t.Errorf("%s: BytesCompressed and MarshalCompressed differ", name)
+		}
+		if bx, by := p.Big(); bx.Cmp(x) != 0 || by.Cmp(y) != 0 {
+			t.Errorf("%s: Big didn't round trip", name)
+		}
+
+		modulus := new(big.Int).SetBytes(curve.Params().P.Bytes())
+		unreduced := NewPoint(curve, new(big.Int).Add(x, modulus), y)
+		if unreduced.X.Cmp(p.X) != 0 {
+			t.Errorf("%s: NewPoint didn't reduce its coordinates", name)
+		}
+
+		infinity := NewPoint(curve, new(big.Int), new(big.Int))
+		if bx, by := infinity.Big(); bx.Sign() != 0 || by.Sign() != 0 {
+			t.Errorf("%s: the point at infinity didn't round trip", name)
+		}
+	}
+}
+
+func TestParsePoint(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		name := curve.Params().Name
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		uncompressed := Marshal(curve, x, y)
+		hybrid := append([]byte{6 | byte(y.Bit(0))}, uncompressed[1:]...)
+		for _, tc := range []struct {
+			data   []byte
+			format PointFormat
+		}{
+			{uncompressed, Uncompressed},
+			{MarshalCompressed(curve, x, y), Compressed},
+			{hybrid, Hybrid},
+		} {
+			p, format, err := ParsePoint(curve, tc.data)
+			if err != nil {
+				t.Errorf("%s: %v point rejected: %v", name, tc.format, err)
+				continue
+			}
+			if format != tc.format {
+				t.Errorf("%s: got format %v, expected %v", name, format, tc.format)
+			}
+			if px, py := p.Big(); px.Cmp(x) != 0 || py.Cmp(y) != 0 {
+				t.Errorf("%s: %v point didn't round trip", name, tc.format)
+			}
+		}
+
+		wrongParity := append([]byte{hybrid[0] ^ 1}, hybrid[1:]...)
+		byteLen := (curve.Params().BitSize + 7) / 8
+		modulus := new(big.Int).SetBytes(curve.Params().P.Bytes())
+		unreduced := append([]byte{4}, make([]byte, 2*byteLen)...)
+		// x + P doesn't always fit, in which case P itself is used.
+		unreducedX := new(big.Int).Add(x, modulus)
+		if unreducedX.BitLen() > 8*byteLen {
+			unreducedX = modulus
+		}
+		unreducedX.FillBytes(unreduced[1 : 1+byteLen])
+		y.FillBytes(unreduced[1+byteLen:])
+		for desc, data := range map[string][]byte{
+			"hybrid point with the wrong parity": wrongParity,
+			"unreduced point":                    unreduced,
+			"point off the curve":                Marshal(curve, x, new(big.Int).Add(y, big.NewInt(1))),
+			"unknown prefix":                     append([]byte{5}, uncompressed[1:]...),
+			"point at infinity":                  {0},
+			"short point":                        uncompressed[:len(uncompressed)-1],
+			"empty point":                        nil,
+		} {
+			if _, _, err := ParsePoint(curve, data); err == nil {
+				t.Errorf("%s: %s accepted", name, desc)
+			}
+		}
+	}
+}
+
+func TestPointFormatString(t *testing.T) {
+	if s := Hybrid.String(); s != "hybrid" {
+		t.Errorf("got %q, expected %q", s, "hybrid")
+	}
+	if s := PointFormat(0).String(); s != "PointFormat(0)" {
+		t.Errorf("got %q, expected %q", s, "PointFormat(0)")
+	}
+}
+
+func TestPointEqual(t *testing.T) {
+	_, x, y, err := GenerateKey(P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NewPoint(P256(), x, y)
+	if p.Equal(NewPoint(P256(), x, y)) != 1 {
+		t.Error("a point isn't equal to itself")
+	}
+	if p.Equal(NewPoint(P256(), x, new(big.Int).Sub(new(big.Int).SetBytes(P256().Params().P.Bytes()), y))) != 0 {
+		t.Error("a point is equal to its negation")
+	}
+	if p.Equal(NewPoint(P256().Params(), x, y)) != 0 {
+		t.Error("points of different curves are equal")
+	}
+}
+
+func TestCompactKey(t *testing.T) {
+	for _, curve := range []Curve{P224(), P256(), P384(), P521()} {
+		name := curve.Params().Name
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewPoint(curve, x, y)
+		key := p.CompactKey()
+		compressed := p.BytesCompressed()
+		if !bytes.Equal(key[:len(compressed)], compressed) || !bytes.Equal(key[len(compressed):], make([]byte, len(key)-len(compressed))) {
+			t.Errorf("%s: key %x isn't the padded compressed encoding", name, key)
+		}
+		modulus := new(big.Int).SetBytes(curve.Params().P.Bytes())
+		if NewPoint(curve, new(big.Int).Add(x, modulus), y).CompactKey() != key {
+			t.Errorf("%s: equal points have different keys", name)
+		}
+		negY := new(big.Int).Sub(modulus, y)
+		if NewPoint(curve, x, negY).CompactKey() == key {
+			t.Errorf("%s: P and -P have the same key", name)
+		}
+		if NewPoint(curve, new(big.Int), new(big.Int)).CompactKey() != (PointKey{}) {
+			t.Errorf("%s: the point at infinity doesn't have the zero key", name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package elliptic
+
+// PointSet is a set of points, keyed by their CompactKey, which keeps the
+// order in which they were added, for protocols which deduplicate the keys of
+// their peers, or their commitments. Maps from points to other values can use
+// PointKey as their key type directly.
+//
+// The zero value is an empty set, ready to use. As with CompactKey, the points
+// of a set should be public, and on the same curve.
+type PointSet struct {
+	index  map[PointKey]int
+	points []*Point
+}
+
+// NewPointSet returns a set of the given points.
+func NewPointSet(points ...*Point) *PointSet {
+	s := new(PointSet)
+	for _, p := range points {
+		s.Add(p)
+	}
+	return s
+}
+
+// Add adds p to the set, and reports whether it wasn't already in it.
+func (s *PointSet) Add(p *Point) bool {
+	key := p.CompactKey()
+	if _, ok := s.index[key]; ok {
+		return false
+	}
+	if s.index == nil {
+		s.index = make(map[PointKey]int)
+	}
+	s.index[key] = len(s.points)
+	s.points = append(s.points, p)
+	return true
+}
+
+// Contains reports whether p is in the set.
+func (s *PointSet) Contains(p *Point) bool {
+	_, ok := s.index[p.CompactKey()]
+	return ok
+}
+
+// Remove removes p from the set, and reports whether it was in it.
+func (s *PointSet) Remove(p *Point) bool {
+	key := p.CompactKey()
+	i, ok := s.index[key]
+	if !ok {
+		return false
+	}
+	delete(s.index, key)
+	copy(s.points[i:], s.points[i+1:])
+	s.points[len(s.points)-1] = nil
+	s.points = s.points[:len(s.points)-1]
+	for j := i; j < len(s.points); j++ {
+		s.index[s.points[j].CompactKey()] = j
+	}
+	return true
+}
+
+// Len returns the number of points in the set.
+func (s *PointSet) Len() int {
+	return len(s.points)
+}
+
+// Points returns the points of the set, in the order in which they were
+// first added.
+func (s *PointSet) Points() []*Point {
+	return append([]*Point{}, s.points...)
+}
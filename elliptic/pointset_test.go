@@ -0,0 +1,53 @@
+package elliptic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestPointSet(t *testing.T) {
+	curve := P256()
+	points := make([]*Point, 4)
+	for i := range points {
+		_, x, y, err := GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points[i] = NewPoint(curve, x, y)
+	}
+	var s PointSet
+	if s.Contains(points[0]) || s.Remove(points[0]) || s.Len() != 0 {
+		t.Error("the zero set isn't empty")
+	}
+	for _, p := range points[:3] {
+		if !s.Add(p) {
+			t.Error("a new point was already in the set")
+		}
+	}
+	// A copy of a point, with unreduced coordinates, is the same point.
+	x, y := points[1].Big()
+	x.Add(x, new(big.Int).SetBytes(curve.Params().P.Bytes()))
+	if s.Add(NewPoint(curve, x, y)) || !s.Contains(NewPoint(curve, x, y)) {
+		t.Error("a duplicate point was added")
+	}
+	if s.Contains(points[3]) || s.Len() != 3 {
+		t.Error("wrong contents")
+	}
+
+	if !s.Remove(points[0]) || s.Remove(points[0]) || s.Contains(points[0]) {
+		t.Error("Remove failed")
+	}
+	got := s.Points()
+	if len(got) != 2 || got[0] != points[1] || got[1] != points[2] {
+		t.Error("Points didn't keep the order of insertion")
+	}
+	if !s.Remove(points[2]) || !s.Contains(points[1]) || s.Len() != 1 {
+		t.Error("Remove corrupted the set")
+	}
+
+	other := NewPointSet(points[3], points[3], points[0])
+	if other.Len() != 2 || !other.Contains(points[0]) {
+		t.Error("NewPointSet didn't deduplicate its points")
+	}
+}
@@ -0,0 +1,197 @@
+package elliptic
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// SSWU implements the simplified Shallue-van de Woestijne-Ulas map, as defined
+// in RFC 9380, section 6.6.2, to a curve y² = x³ + A x + B over a prime field.
+//
+// The map requires A and B to both be non-zero. For curves where this isn't the
+// case, such as secp256k1, the map is instead applied to an isogenous curve,
+// and the result is then sent to the target curve with an Isogeny.
+//
+// The map is computed without branching on the input, or the output.
+type SSWU struct {
+	p    *safenum.Modulus
+	a, b *safenum.Nat
+	z    *safenum.Nat
+
+	// -B / A
+	minusBOverA *safenum.Nat
+	// B / (Z * A)
+	bOverZA *safenum.Nat
+	// (p - 1) / 2, to check for squares
+	legendreExp *safenum.Nat
+	// (p + 1) / 4, to calculate square roots, when p = 3 mod 4
+	sqrtExp *safenum.Nat
+	// p - 2, to calculate inverses
+	invExp *safenum.Nat
+}
+
+// NewSSWU creates the simplified SWU map for the curve y² = x³ + A x + B over
+// the field of integers modulo p, which must be a prime.
+//
+// Z must be a non-square modulo p, such that Z ≠ -1, the polynomial
+// x³ + A x + B - Z is irreducible, and B / (Z A) yields a square when plugged
+// into the curve equation. RFC 9380, appendix H.2 describes how to find such a
+// value.
+func NewSSWU(p *safenum.Modulus, a, b, z *safenum.Nat) (*SSWU, error) {
+	m := &SSWU{
+		p: p,
+		a: new(safenum.Nat).Mod(a, p),
+		b: new(safenum.Nat).Mod(b, p),
+		z: new(safenum.Nat).Mod(z, p),
+	}
+	if m.a.EqZero() || m.b.EqZero() {
+		return nil, errors.New("elliptic: SSWU requires A and B to be non-zero")
+	}
+
+	pNat := new(safenum.Nat).SetBytes(p.Bytes())
+	one := new(safenum.Nat).SetUint64(1)
+	two := new(safenum.Nat).SetUint64(2)
+	size := p.BitLen()
+	m.legendreExp = shiftRight(new(safenum.Nat).Sub(pNat, one, size), 1, size)
+	m.sqrtExp = shiftRight(new(safenum.Nat).Add(pNat, one, size+1), 2, size+1)
+	m.invExp = new(safenum.Nat).Sub(pNat, two, size)
+
+	if m.isSquare(m.z) == 1 {
+		return nil, errors.New("elliptic: SSWU requires Z to be a non-square")
+	}
+
+	m.minusBOverA = m.inv0(m.a)
+	m.minusBOverA.ModMul(m.minusBOverA, m.b, p)
+	m.minusBOverA.ModSub(new(safenum.Nat), m.minusBOverA, p)
+	m.bOverZA = new(safenum.Nat).ModMul(m.z, m.a, p)
+	m.bOverZA = m.inv0(m.bOverZA)
+	m.bOverZA.ModMul(m.bOverZA, m.b, p)
+	return m, nil
+}
+
+// inv0 returns 1 / x, or 0 if x = 0.
+func (m *SSWU) inv0(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).Exp(x, m.invExp, m.p)
+}
+
+// isSquare returns 1 if x is a square, including 0, and 0 otherwise.
+func (m *SSWU) isSquare(x *safenum.Nat) int {
+	l := new(safenum.Nat).Exp(x, m.legendreExp, m.p)
+	return ctEq(l, new(safenum.Nat).SetUint64(1)) | ctEq(l, new(safenum.Nat))
+}
+
+// sqrt returns a square root of x, which must be a square.
+func (m *SSWU) sqrt(x *safenum.Nat) *safenum.Nat {
+	// The modulus is public, so we can branch on it.
+	if m.p.Bytes()[len(m.p.Bytes())-1]&3 == 3 {
+		return new(safenum.Nat).Exp(x, m.sqrtExp, m.p)
+	}
+	return new(safenum.Nat).ModSqrt(x, m.p)
+}
+
+// polynomial returns x³ + A x + B.
+func (m *SSWU) polynomial(x *safenum.Nat) *safenum.Nat {
+	out := new(safenum.Nat).ModMul(x, x, m.p)
+	out.ModAdd(out, m.a, m.p)
+	out.ModMul(out, x, m.p)
+	return out.ModAdd(out, m.b, m.p)
+}
+
+// Map sends a field element to a point (x, y) on the curve.
+//
+// This implements the map_to_curve function of RFC 9380. In order to hash
+// to the curve, u should be the output of a hash_to_field function.
+func (m *SSWU) Map(u *safenum.Nat) (x, y *safenum.Nat) {
+	P := m.p
+	zero := new(safenum.Nat)
+	one := new(safenum.Nat).SetUint64(1)
+	u = new(safenum.Nat).Mod(u, P)
+
+	// tv1 = inv0(Z² u⁴ + Z u²)
+	zu2 := new(safenum.Nat).ModMul(u, u, P)
+	zu2.ModMul(zu2, m.z, P)
+	tv1 := new(safenum.Nat).ModMul(zu2, zu2, P)
+	tv1.ModAdd(tv1, zu2, P)
+	tv1 = m.inv0(tv1)
+
+	// x1 = (-B / A) (1 + tv1), or B / (Z A) if tv1 = 0
+	x1 := new(safenum.Nat).ModAdd(tv1, one, P)
+	x1.ModMul(x1, m.minusBOverA, P)
+	ctSelect(x1, m.bOverZA, x1, ctEq(tv1, zero), P)
+	gx1 := m.polynomial(x1)
+
+	// x2 = Z u² x1
+	x2 := new(safenum.Nat).ModMul(zu2, x1, P)
+	gx2 := m.polynomial(x2)
+
+	gx1Square := m.isSquare(gx1)
+	x = ctSelect(new(safenum.Nat), x1, x2, gx1Square, P)
+	y = ctSelect(new(safenum.Nat), gx1, gx2, gx1Square, P)
+	y = m.sqrt(y)
+
+	negY := new(safenum.Nat).ModSub(zero, y, P)
+	ctSelect(y, y, negY, subtle.ConstantTimeEq(int32(sgn0(u)), int32(sgn0(y))), P)
+	return x, y
+}
+
+// Isogeny is a rational map between two elliptic curves over a prime field,
+// as used in RFC 9380, section 6.6.3. The map sends a point (x, y) to
+//
+//	(XNum(x) / XDen(x), y * YNum(x) / YDen(x))
+//
+// where each of the polynomials is given by its coefficients, starting with
+// the constant term.
+type Isogeny struct {
+	P                      *safenum.Modulus
+	XNum, XDen, YNum, YDen []*safenum.Nat
+}
+
+// evaluate calculates the value of a polynomial at x, using Horner's method.
+func evaluate(coefficients []*safenum.Nat, x *safenum.Nat, p *safenum.Modulus) *safenum.Nat {
+	out := new(safenum.Nat).Mod(new(safenum.Nat), p)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		out.ModMul(out, x, p)
+		out.ModAdd(out, coefficients[i], p)
+	}
+	return out
+}
+
+// Map applies the isogeny to a point (x, y).
+//
+// Points sent to the point at infinity, i.e. those for which a denominator
+// vanishes, are mapped to (0, 0), as with the other functions of this package.
+func (iso *Isogeny) Map(x, y *safenum.Nat) (xOut, yOut *safenum.Nat) {
+	P := iso.P
+	pNat := new(safenum.Nat).SetBytes(P.Bytes())
+	invExp := new(safenum.Nat).Sub(pNat, new(safenum.Nat).SetUint64(2), P.BitLen())
+
+	xDen := new(safenum.Nat).Exp(evaluate(iso.XDen, x, P), invExp, P)
+	yDen := new(safenum.Nat).Exp(evaluate(iso.YDen, x, P), invExp, P)
+	xOut = evaluate(iso.XNum, x, P)
+	xOut.ModMul(xOut, xDen, P)
+	yOut = evaluate(iso.YNum, x, P)
+	yOut.ModMul(yOut, yDen, P)
+	yOut.ModMul(yOut, y, P)
+	// When either denominator vanishes, the point is sent to infinity, which
+	// we represent as (0, 0).
+	zero := new(safenum.Nat).Mod(new(safenum.Nat), P)
+	infinity := ctEq(xDen, zero) | ctEq(yDen, zero)
+	ctSelect(xOut, zero, xOut, infinity, P)
+	ctSelect(yOut, zero, yOut, infinity, P)
+	return xOut, yOut
+}
+
+// MapToCurveSSWU applies the simplified SWU map to a field element, using the
+// parameters chosen by RFC 9380 for the given curve, returning a point on that
+// curve.
+//
+// This is the map used by HashToCurve and EncodeToCurve. Only P-256, P-384, and
+// P-521 are supported, and this function will panic for any other curve.
+func MapToCurveSSWU(curve Curve, u *safenum.Nat) (x, y *big.Int) {
+	params := curve.Params()
+	xNat, yNat := suiteFor(params).sswu.Map(u)
+	return natToBig(xNat), natToBig(yNat)
+}
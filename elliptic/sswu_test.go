@@ -0,0 +1,105 @@
+package elliptic
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func natFromHex(s string) *safenum.Nat {
+	b, _ := hex.DecodeString(s)
+	return new(safenum.Nat).SetBytes(b)
+}
+
+func TestMapToCurveSSWUP256(t *testing.T) {
+	// These are u[0] and Q0 for the empty message, from RFC 9380, appendix J.1.1.
+	u := natFromHex("ad5342c66a6dd0ff080df1da0ea1c04b96e0330dd89406465eeba11582515009")
+	x, y := MapToCurveSSWU(P256(), u)
+	expectedX := "ab640a12220d3ff283510ff3f4b1953d09fad35795140b1c5d64f313967934d5"
+	expectedY := "dccb558863804a881d4fff3455716c836cef230e5209594ddd33d85c565b19b1"
+	if hex.EncodeToString(x.FillBytes(make([]byte, 32))) != expectedX ||
+		hex.EncodeToString(y.FillBytes(make([]byte, 32))) != expectedY {
+		t.Errorf("MapToCurveSSWU(u) = (%x, %x), expected (%s, %s)", x, y, expectedX, expectedY)
+	}
+}
+
+func TestMapToCurveSSWUZero(t *testing.T) {
+	for _, curve := range []Curve{P256(), P384(), P521()} {
+		x, y := MapToCurveSSWU(curve, new(safenum.Nat))
+		if !curve.Params().IsOnCurve(x, y) {
+			t.Errorf("%s: SSWU(0) is not on the curve", curve.Params().Name)
+		}
+	}
+}
+
+func TestNewSSWURejectsBadParameters(t *testing.T) {
+	p := P256().Params().P
+	b := P256().Params().B
+	if _, err := NewSSWU(p, new(safenum.Nat), b, new(safenum.Nat).SetUint64(10)); err == nil {
+		t.Errorf("expected an error with A = 0")
+	}
+	three := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(3), p)
+	// 4 is a square, so it can't be used as Z
+	if _, err := NewSSWU(p, three, b, new(safenum.Nat).SetUint64(4)); err == nil {
+		t.Errorf("expected an error with a square Z")
+	}
+}
+
+func TestIsogenyIsomorphism(t *testing.T) {
+	// The map (x, y) -> (c² x, c³ y) sends y² = x³ + A x + B to
+	// y² = x³ + c⁴ A x + c⁶ B, which lets us check the evaluation of
+	// isogenies without needing a real one.
+	params := P256().Params()
+	P := params.P
+	c := new(safenum.Nat).SetUint64(7)
+	c2 := new(safenum.Nat).ModMul(c, c, P)
+	c3 := new(safenum.Nat).ModMul(c2, c, P)
+	c4 := new(safenum.Nat).ModMul(c2, c2, P)
+	c6 := new(safenum.Nat).ModMul(c3, c3, P)
+	one := new(safenum.Nat).SetUint64(1)
+
+	iso := &Isogeny{
+		P:    P,
+		XNum: []*safenum.Nat{new(safenum.Nat), c2},
+		XDen: []*safenum.Nat{one},
+		YNum: []*safenum.Nat{c3},
+		YDen: []*safenum.Nat{one},
+	}
+
+	a := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(3), P)
+	a2 := new(safenum.Nat).ModMul(a, c4, P)
+	b2 := new(safenum.Nat).ModMul(params.B, c6, P)
+	target, err := NewSSWU(P, a2, b2, new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(10), P))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []string{"", "abc", "hello"} {
+		xBig, yBig := HashToCurve(P256(), []byte(msg), []byte("ctcrypto-test"))
+		x, y := iso.Map(new(safenum.Nat).SetBytes(xBig.Bytes()), new(safenum.Nat).SetBytes(yBig.Bytes()))
+		y2 := new(safenum.Nat).ModMul(y, y, P)
+		if target.polynomial(x).Cmp(y2) != 0 {
+			t.Errorf("isogeny image of %q is not on the target curve", msg)
+		}
+	}
+}
+
+func TestIsogenyInfinity(t *testing.T) {
+	P := P256().Params().P
+	one := new(safenum.Nat).SetUint64(1)
+	// The denominator x - 1 vanishes at x = 1.
+	minusOne := new(safenum.Nat).ModSub(new(safenum.Nat), one, P)
+	iso := &Isogeny{
+		P:    P,
+		XNum: []*safenum.Nat{one},
+		XDen: []*safenum.Nat{minusOne, one},
+		YNum: []*safenum.Nat{one},
+		YDen: []*safenum.Nat{one},
+	}
+	x, y := iso.Map(one, new(safenum.Nat).SetUint64(5))
+	if new(big.Int).SetBytes(x.Bytes()).Sign() != 0 || new(big.Int).SetBytes(y.Bytes()).Sign() != 0 {
+		t.Errorf("expected the point at infinity, got (%x, %x)", x.Bytes(), y.Bytes())
+	}
+}
@@ -0,0 +1,84 @@
+package ffdh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/prime"
+)
+
+// The sizes of the primes of custom groups, in bits. The smallest is the one
+// of the smallest group of RFC 7919.
+const (
+	minGroupBits = 2048
+	maxGroupBits = 8192
+)
+
+// numMRTests is the number of Miller-Rabin tests done on p and q, on top of
+// the Baillie-PSW test of math/big.
+const numMRTests = 20
+
+// NewGroup checks that p and g are valid group parameters, and returns their
+// group. Both are big-endian integers.
+//
+// The prime p must be a safe prime p = 2q + 1, of 2048 to 8192 bits, and the
+// generator g must satisfy 1 < g < p - 1. Validation uses variable-time
+// arithmetic, since the parameters are public, and can take a few seconds.
+//
+// If p and g are those of a standard group, that group is returned, so that
+// groups can still be compared. Otherwise, every call returns a new group,
+// whose keys can't be used with other groups.
+func NewGroup(p, g []byte) (*Group, error) {
+	P := new(big.Int).SetBytes(p)
+	G := new(big.Int).SetBytes(g)
+	pBytes, gBytes := P.Bytes(), G.Bytes()
+	for _, std := range allGroups {
+		if bytes.Equal(std.Prime(), pBytes) && bytes.Equal(std.gBytes, gBytes) {
+			return std, nil
+		}
+	}
+
+	bits := P.BitLen()
+	if bits < minGroupBits || bits > maxGroupBits {
+		return nil, errors.New("ffdh: unsupported prime size")
+	}
+	q := new(big.Int).Rsh(P, 1)
+	if P.Bit(0) != 1 || !q.ProbablyPrime(numMRTests) || !P.ProbablyPrime(numMRTests) {
+		return nil, errors.New("ffdh: p isn't a safe prime")
+	}
+	if G.Cmp(big.NewInt(1)) != 1 || G.Cmp(new(big.Int).Sub(P, big.NewInt(1))) != -1 {
+		return nil, errors.New("ffdh: invalid generator")
+	}
+	// Exponents one bit shorter than q are always below q.
+	return makeGroup("custom"+strconv.Itoa(bits), pBytes, gBytes, q.BitLen()-1), nil
+}
+
+// GenerateGroup generates a group with a random safe prime of the given size,
+// from 2048 to 8192 bits, and the generator 2, which generates the subgroup
+// of order q. It reads randomness from rand, or from the health-tested Reader
+// of the rand package of this module if rand is nil.
+//
+// This function can take many seconds, or minutes for the largest sizes. The
+// standard groups should be preferred, unless fresh parameters are required.
+func GenerateGroup(rand io.Reader, bits int) (*Group, error) {
+	if bits < minGroupBits || bits > maxGroupBits {
+		return nil, errors.New("ffdh: unsupported prime size")
+	}
+	rand = randutil.Or(rand)
+	if err := fips.CheckEntropy(rand); err != nil {
+		return nil, err
+	}
+	// The prime is 7 mod 8, so 2 is a quadratic residue.
+	p, err := prime.GenerateSafe(context.Background(), rand, bits, nil)
+	if err != nil {
+		return nil, err
+	}
+	pBytes := new(big.Int).SetBytes(p.Bytes()).Bytes()
+	return makeGroup("custom"+strconv.Itoa(bits), pBytes, []byte{2}, bits-2), nil
+}
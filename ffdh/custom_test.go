@@ -0,0 +1,119 @@
+package ffdh
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+)
+
+func TestNewGroup(t *testing.T) {
+	for _, std := range groups {
+		g, err := NewGroup(append([]byte{0}, std.Prime()...), std.Generator())
+		if err != nil {
+			t.Fatalf("%s: %v", std, err)
+		}
+		// srp2048 has the parameters of modp2048, which comes first.
+		if g != std && !(std == SRP2048() && g == MODP2048()) {
+			t.Errorf("%s: got %s", std, g)
+		}
+	}
+
+	p := MODP2048().Prime()
+	g, err := NewGroup(p, []byte{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g == MODP2048() || g.String() != "custom2048" || !g.residue {
+		t.Errorf("wrong custom group %s", g)
+	}
+	alice, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z1, err := alice.DH(bob.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	z2, err := bob.DH(alice.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(z1, z2) {
+		t.Error("shared secrets differ")
+	}
+	other, err := MODP2048().NewPublicKey(bob.PublicKey().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := alice.DH(other); err == nil {
+		t.Error("exchange across groups accepted")
+	}
+
+	P := new(big.Int).SetBytes(p)
+	pMinusOne := new(big.Int).Sub(P, big.NewInt(1)).Bytes()
+	for name, params := range map[string][2][]byte{
+		"g = 1":     {p, {1}},
+		"g = p - 1": {p, pMinusOne},
+		"g = p":     {p, p},
+		"p + 2":     {new(big.Int).Add(P, big.NewInt(2)).Bytes(), {2}},
+		"small":     {SRP1024().Prime(), {3}},
+	} {
+		if _, err := NewGroup(params[0], params[1]); err == nil {
+			t.Errorf("%s accepted", name)
+		}
+	}
+}
+
+// oddPrime is a safe prime of 2052 bits, whose 257 bytes aren't a whole number
+// of limbs.
+const oddPrime = "0e8f25cc9bc6819ce2a97b74dcf8245590c59eba549afd7db73a42ac6037fedf75fb7e4468860bdd6e58780f81d00116466d3bf8d6dd42cf2ee7ee660651b18f54d3084c77a0e7247e4265de418f7d32ac39a56e44a1e3f0b32a40eff1ce8a01da595f21446037596831783c23c8b1d16d7dbb513001a6935baa16106edfeaaf08a330b828db8dbfd9a0c28abca6621385174d1345ce06e7aba99856ab7507544994092fcf87eeb0abf411e37a845ba11e58d9e7a6ab660606bb6e56f787d9f4df145939da371823fa3d84757f01d64faeca2da96af81ce7b83424785360bcf33a67dde66a670a4c7157d1fb1f49a5013d12bef050fa8926448d5bcd0d4e09d1d7"
+
+func TestOddSizeGroup(t *testing.T) {
+	p, _ := hex.DecodeString(oddPrime)
+	g, err := NewGroup(p, []byte{4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(g.Prime(), p) || len(g.Order()) != len(p) {
+		t.Errorf("wrong encoding of the prime %x", g.Prime())
+	}
+	alice, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := g.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z, err := alice.DH(bob.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	P := new(big.Int).SetBytes(p)
+	y := new(big.Int).SetBytes(bob.PublicKey().Bytes())
+	want := new(big.Int).Exp(y, new(big.Int).SetBytes(alice.Bytes()), P)
+	if len(z) != len(p) || new(big.Int).SetBytes(z).Cmp(want) != 0 {
+		t.Errorf("wrong shared secret %x", z)
+	}
+}
+
+func TestGenerateGroup(t *testing.T) {
+	if _, err := GenerateGroup(nil, 1024); err == nil {
+		t.Error("small group generated")
+	}
+	if testing.Short() {
+		t.Skip("skipping group generation in short mode")
+	}
+	g, err := GenerateGroup(nil, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewGroup(g.Prime(), g.Generator()); err != nil {
+		t.Errorf("generated group rejected: %v", err)
+	}
+}
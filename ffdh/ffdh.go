@@ -0,0 +1,297 @@
+// Package ffdh implements finite field Diffie-Hellman, for the protocols which
+// still require classic Diffie-Hellman, over safe prime groups: the groups of
+// RFC 7919, RFC 3526, and RFC 5054, and custom groups.
+//
+// The groups are also used by the ElGamal encryption of the elgamal package,
+// and their parameters are exposed for other protocols, such as SRP.
+//
+// The API mirrors the one of the ecdh package. Exponentiations are done with
+// safenum, in constant-time with respect to the private exponent.
+package ffdh
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	errMismatchedGroups = errors.New("ffdh: private key and public key groups do not match")
+	errInvalidPrivate   = errors.New("ffdh: invalid private key")
+	errInvalidPublic    = errors.New("ffdh: invalid public key")
+	errIdentity         = errors.New("ffdh: shared secret is the identity")
+)
+
+// Group is a safe prime group, over which Diffie-Hellman can be performed.
+//
+// Multiple invocations of the functions returning standard groups return the
+// same value, which can be used for equality checks and switch statements.
+type Group struct {
+	name string
+	p    *safenum.Modulus
+	// pMinusOne is p - 1, the largest value of the range checks.
+	pMinusOne *safenum.Nat
+	// q is (p - 1) / 2, the order of the subgroup of quadratic residues.
+	q      *safenum.Nat
+	qMod   *safenum.Modulus
+	g      *safenum.Nat
+	gBytes []byte
+	// residue is whether g is a quadratic residue, and so generates the
+	// subgroup of order q, rather than the whole group.
+	residue bool
+	byteLen int
+	// expBits is the size of the exponents of generated keys.
+	expBits int
+}
+
+func newGroup(name, pHex string, g uint64, expBits int) *Group {
+	pBytes, err := hex.DecodeString(pHex)
+	if err != nil {
+		panic("ffdh: invalid group " + name)
+	}
+	return makeGroup(name, pBytes, new(big.Int).SetUint64(g).Bytes(), expBits)
+}
+
+// makeGroup returns the group of the safe prime p, without leading zeros, and
+// the generator g, without leading zeros.
+func makeGroup(name string, pBytes, gBytes []byte, expBits int) *Group {
+	// q = (p - 1) / 2, computed by shifting p right by one bit, since p is odd.
+	qBytes := make([]byte, len(pBytes))
+	for i := range pBytes {
+		qBytes[i] = pBytes[i] >> 1
+		if i > 0 {
+			qBytes[i] |= pBytes[i-1] << 7
+		}
+	}
+	pMinusOne := append([]byte{}, pBytes...)
+	pMinusOne[len(pMinusOne)-1] &^= 1
+	gBytes = append([]byte{}, gBytes...)
+	// The parameters are public, so the Jacobi symbol can be computed in
+	// variable time.
+	residue := big.Jacobi(new(big.Int).SetBytes(gBytes), new(big.Int).SetBytes(pBytes)) == 1
+	return &Group{
+		name:      name,
+		p:         safenum.ModulusFromBytes(pBytes),
+		pMinusOne: new(safenum.Nat).SetBytes(pMinusOne),
+		q:         new(safenum.Nat).SetBytes(qBytes),
+		qMod:      safenum.ModulusFromBytes(qBytes),
+		g:         new(safenum.Nat).SetBytes(gBytes[:len(gBytes):len(gBytes)]),
+		gBytes:    gBytes,
+		residue:   residue,
+		byteLen:   len(pBytes),
+		expBits:   expBits,
+	}
+}
+
+func (g *Group) String() string {
+	return g.name
+}
+
+// Prime returns the prime modulus of the group, as a big-endian integer.
+func (g *Group) Prime() []byte {
+	p := g.p.Bytes()
+	return p[len(p)-g.byteLen:]
+}
+
+// Generator returns the generator of the group, as a big-endian integer,
+// without leading zeros.
+func (g *Group) Generator() []byte {
+	return append([]byte{}, g.gBytes...)
+}
+
+// Order returns q = (p - 1) / 2, the prime order of the subgroup of quadratic
+// residues, as a big-endian integer padded to the length of the prime.
+//
+// The generator has order q if it is a quadratic residue, as for the groups
+// of RFC 7919 and RFC 3526, and order 2q otherwise, as for most of the groups
+// of RFC 5054.
+func (g *Group) Order() []byte {
+	q := g.q.Bytes()
+	return q[len(q)-g.byteLen:]
+}
+
+// GenerateKey generates a random private key, reading randomness from rand,
+// or from the health-tested Reader of the rand package of this module if
+// rand is nil.
+//
+// The exponent is short, with the size recommended by the document defining
+// the group, which is enough for its strength, and makes exchanges faster.
+// Custom groups use exponents as long as q.
+func (g *Group) GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	rand = randutil.Or(rand)
+	if err := fips.CheckEntropy(rand); err != nil {
+		return nil, err
+	}
+	randutil.MaybeReadByte(rand)
+	key := make([]byte, (g.expBits+7)/8)
+	for {
+		if _, err := io.ReadFull(rand, key); err != nil {
+			return nil, err
+		}
+		key[0] &= byte(0xff >> (8*len(key) - g.expBits))
+		// This only leaks whether a key was rejected, which happens with
+		// negligible probability.
+		if new(safenum.Nat).SetBytes(key[:len(key):len(key)]).EqZero() {
+			continue
+		}
+		return g.NewPrivateKey(key)
+	}
+}
+
+// NewPrivateKey checks that key is valid and returns a PrivateKey.
+//
+// The key is the big-endian encoding of an exponent x, with 0 < x < q, where
+// q is the order of the subgroup. The length of the encoding, which can be up
+// to the length of the prime, is considered public, and determines the cost
+// of exchanges.
+func (g *Group) NewPrivateKey(key []byte) (*PrivateKey, error) {
+	if len(key) == 0 || len(key) > g.byteLen {
+		return nil, errInvalidPrivate
+	}
+	// SetBytes can modify its argument, if it has spare capacity.
+	x := new(safenum.Nat).SetBytes(key[:len(key):len(key)])
+	// Both checks are always done, so that rejecting a key takes the same
+	// time, whichever fails.
+	nonZero := 1 ^ subtle.ConstantTimeEq(int32(x.Cmp(new(safenum.Nat))), 0)
+	inRange := subtle.ConstantTimeEq(int32(x.CmpMod(g.qMod)), -1)
+	if nonZero&inRange != 1 {
+		return nil, errInvalidPrivate
+	}
+	privateKey := append([]byte{}, key...)
+	ctgrind.MarkSecret(privateKey)
+	return &PrivateKey{group: g, privateKey: privateKey}, nil
+}
+
+// NewPublicKey checks that key is valid and returns a PublicKey.
+//
+// The key is the big-endian encoding of y, padded to the length of the prime.
+// It is fully validated, as described in NIST SP 800-56A Rev. 3, section
+// 5.6.2.3.1: 1 < y < p - 1, and y^q = 1, so that it belongs to the subgroup.
+//
+// If the generator isn't a quadratic residue, as for most of the groups of
+// RFC 5054, only the range is checked, since keys can be in the whole group.
+// Shared secrets then leak whether they are quadratic residues, and must only
+// be used through a key derivation function.
+func (g *Group) NewPublicKey(key []byte) (*PublicKey, error) {
+	if len(key) != g.byteLen {
+		return nil, errInvalidPublic
+	}
+	y := new(safenum.Nat).SetBytes(key[:len(key):len(key)])
+	one := new(safenum.Nat).SetUint64(1)
+	if y.Cmp(one) != 1 || y.Cmp(g.pMinusOne) != -1 {
+		return nil, errInvalidPublic
+	}
+	if g.residue && new(safenum.Nat).Exp(y, g.q, g.p).Cmp(one) != 0 {
+		return nil, errInvalidPublic
+	}
+	return &PublicKey{group: g, publicKey: append([]byte{}, key...)}, nil
+}
+
+// PublicKey is a Diffie-Hellman public key, usually a peer's share sent over
+// the wire.
+type PublicKey struct {
+	group     *Group
+	publicKey []byte
+}
+
+// Bytes returns a copy of the encoding of the public key.
+func (k *PublicKey) Bytes() []byte {
+	return append([]byte{}, k.publicKey...)
+}
+
+// Equal returns whether x represents the same public key as k.
+func (k *PublicKey) Equal(x crypto.PublicKey) bool {
+	xx, ok := x.(*PublicKey)
+	if !ok {
+		return false
+	}
+	return k.group == xx.group &&
+		subtle.ConstantTimeCompare(k.publicKey, xx.publicKey) == 1
+}
+
+// Group returns the group of this public key.
+func (k *PublicKey) Group() *Group {
+	return k.group
+}
+
+// PrivateKey is a Diffie-Hellman private key, usually kept secret.
+type PrivateKey struct {
+	group      *Group
+	privateKey []byte
+	publicKey  *PublicKey
+}
+
+// exp returns base^x mod p, where x is the private exponent.
+func (k *PrivateKey) exp(base *safenum.Nat) []byte {
+	x := new(safenum.Nat).SetBytes(k.privateKey[:len(k.privateKey):len(k.privateKey)])
+	// Bytes is padded to a whole number of limbs, which the primes of custom
+	// groups needn't be, so FillBytes can't be used.
+	out := new(safenum.Nat).Exp(base, x, k.group.p).Bytes()
+	return out[len(out)-k.group.byteLen:]
+}
+
+// DH performs a Diffie-Hellman exchange and returns the shared secret. The
+// PrivateKey and PublicKey must use the same group.
+//
+// The shared secret is padded to the length of the prime, as required by
+// TLS 1.3, and by NIST SP 800-56A Rev. 3, section 5.7.1.1.
+func (k *PrivateKey) DH(remote *PublicKey) ([]byte, error) {
+	if k.group != remote.group {
+		return nil, errMismatchedGroups
+	}
+	y := new(safenum.Nat).SetBytes(remote.publicKey[:len(remote.publicKey):len(remote.publicKey)])
+	z := k.exp(y)
+	// The public key has order q or 2q, and the exponent is between 0 and q,
+	// so this can't happen with valid inputs.
+	if new(safenum.Nat).SetBytes(z).Cmp(new(safenum.Nat).SetUint64(1)) == 0 {
+		return nil, errIdentity
+	}
+	return z, nil
+}
+
+// Bytes returns a copy of the encoding of the private key.
+func (k *PrivateKey) Bytes() []byte {
+	return append([]byte{}, k.privateKey...)
+}
+
+// Equal returns whether x represents the same private key as k.
+//
+// Encodings of different lengths are considered different, even if they
+// represent the same exponent.
+func (k *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	xx, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+	return k.group == xx.group &&
+		subtle.ConstantTimeCompare(k.privateKey, xx.privateKey) == 1
+}
+
+// Group returns the group of this private key.
+func (k *PrivateKey) Group() *Group {
+	return k.group
+}
+
+// PublicKey returns the public key corresponding to this private key.
+func (k *PrivateKey) PublicKey() *PublicKey {
+	if k.publicKey == nil {
+		y := k.exp(k.group.g)
+		ctgrind.Declassify(y)
+		k.publicKey = &PublicKey{group: k.group, publicKey: y}
+	}
+	return k.publicKey
+}
+
+// Public implements the implicit interface of all standard library private
+// keys. See the docs of crypto.PrivateKey.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey()
+}
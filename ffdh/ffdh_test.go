@@ -0,0 +1,165 @@
+package ffdh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+var groups = AllGroups()
+
+func TestGroups(t *testing.T) {
+	// Some groups of RFC 5054 share their prime with those of RFC 3526.
+	checked := make(map[string]bool)
+	for i, g := range groups {
+		if testing.Short() && i > 0 {
+			break
+		}
+		p := new(big.Int).SetBytes(g.Prime())
+		q := new(big.Int).Rsh(p, 1)
+		if p.BitLen() != 8*g.byteLen {
+			t.Errorf("%s: p has the wrong size", g)
+		}
+		if !checked[string(g.Prime())] && (!p.ProbablyPrime(0) || !q.ProbablyPrime(0)) {
+			t.Errorf("%s: p isn't a safe prime", g)
+		}
+		checked[string(g.Prime())] = true
+		if !bytes.Equal(g.Order(), q.FillBytes(make([]byte, g.byteLen))) {
+			t.Errorf("%s: wrong subgroup order", g)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	for i, g := range groups {
+		if testing.Short() && i > 1 {
+			break
+		}
+		alice, err := g.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bob, err := g.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(alice.Bytes()) != (g.expBits+7)/8 {
+			t.Errorf("%s: private key is %d bytes", g, len(alice.Bytes()))
+		}
+		bobPub, err := g.NewPublicKey(bob.PublicKey().Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", g, err)
+		}
+		z1, err := alice.DH(bobPub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		z2, err := bob.DH(alice.PublicKey())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(z1, z2) || len(z1) != g.byteLen {
+			t.Errorf("%s: shared secrets differ", g)
+		}
+
+		p := new(big.Int).SetBytes(g.Prime())
+		a := new(big.Int).SetBytes(alice.Bytes())
+		b := new(big.Int).SetBytes(bob.Bytes())
+		gen := new(big.Int).SetBytes(g.Generator())
+		expected := new(big.Int).Exp(gen, new(big.Int).Mul(a, b), p)
+		if !bytes.Equal(z1, expected.FillBytes(make([]byte, g.byteLen))) {
+			t.Errorf("%s: wrong shared secret", g)
+		}
+	}
+}
+
+func TestPublicKeyValidation(t *testing.T) {
+	g := FFDHE2048()
+	p := new(big.Int).SetBytes(g.Prime())
+	for name, y := range map[string]*big.Int{
+		"0":     big.NewInt(0),
+		"1":     big.NewInt(1),
+		"p - 1": new(big.Int).Sub(p, big.NewInt(1)),
+		"p":     p,
+		// -2 is a quadratic non-residue, since p = 7 mod 8, so it isn't in the
+		// subgroup of order q.
+		"p - 2": new(big.Int).Sub(p, big.NewInt(2)),
+	} {
+		if _, err := g.NewPublicKey(y.FillBytes(make([]byte, g.byteLen))); err == nil {
+			t.Errorf("public key %s accepted", name)
+		}
+	}
+	if _, err := g.NewPublicKey(big.NewInt(4).Bytes()); err == nil {
+		t.Error("short public key accepted")
+	}
+	if _, err := g.NewPublicKey(big.NewInt(4).FillBytes(make([]byte, g.byteLen))); err != nil {
+		t.Errorf("public key 4 rejected: %v", err)
+	}
+}
+
+func TestPrivateKeyValidation(t *testing.T) {
+	g := FFDHE2048()
+	q := new(big.Int).SetBytes(g.q.Bytes())
+	for name, x := range map[string][]byte{
+		"empty": {},
+		"0":     make([]byte, 32),
+		"q":     q.FillBytes(make([]byte, g.byteLen)),
+		"long":  append([]byte{1}, make([]byte, g.byteLen)...),
+	} {
+		if _, err := g.NewPrivateKey(x); err == nil {
+			t.Errorf("private key %s accepted", name)
+		}
+	}
+	qMinusOne := new(big.Int).Sub(q, big.NewInt(1)).FillBytes(make([]byte, g.byteLen))
+	priv, err := g.NewPrivateKey(qMinusOne)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2^(q-1) = 2^-1, since 2 has order q.
+	inverse := new(big.Int).ModInverse(big.NewInt(2), new(big.Int).SetBytes(g.Prime()))
+	if !bytes.Equal(priv.PublicKey().Bytes(), inverse.FillBytes(make([]byte, g.byteLen))) {
+		t.Error("wrong public key")
+	}
+	other, err := FFDHE3072().GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.DH(other.PublicKey()); err == nil {
+		t.Error("exchange across groups accepted")
+	}
+}
+
+func TestGroupByName(t *testing.T) {
+	for _, g := range groups {
+		found, err := GroupByName(g.String())
+		if err != nil || found != g {
+			t.Errorf("%s: lookup failed", g)
+		}
+	}
+	if _, err := GroupByName("ffdhe1024"); err == nil {
+		t.Error("unknown group found")
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	for _, g := range groups {
+		// Among the standard groups, only the generator 2 is a quadratic
+		// residue, and only for primes p = 7 mod 8.
+		p := new(big.Int).SetBytes(g.Prime())
+		residue := bytes.Equal(g.Generator(), []byte{2}) && p.Bit(1) == 1 && p.Bit(2) == 1
+		if g.residue != residue {
+			t.Errorf("%s: generator misclassified", g)
+		}
+	}
+	// -2 isn't in the subgroup of order q, but is in the group generated by 5.
+	g := SRP3072()
+	p := new(big.Int).SetBytes(g.Prime())
+	y := new(big.Int).Sub(p, big.NewInt(2)).FillBytes(make([]byte, g.byteLen))
+	if _, err := g.NewPublicKey(y); err != nil {
+		t.Errorf("public key p - 2 rejected: %v", err)
+	}
+	if _, err := MODP3072().NewPublicKey(y); err == nil {
+		t.Error("public key p - 2 accepted")
+	}
+}
@@ -0,0 +1,354 @@
+package ffdh
+
+import "errors"
+
+// The primes of RFC 7919, appendix A. Each is a safe prime p = 2q + 1, and the
+// generator 2 generates the subgroup of order q.
+
+const (
+	ffdhe2048Hex = "" +
+		"ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b423861285c97ffffffffffffffff"
+	ffdhe3072Hex = "" +
+		"ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b66c62e37ffffffffffffffff"
+	ffdhe4096Hex = "" +
+		"ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e655f6affffffffffffffff"
+	ffdhe6144Hex = "" +
+		"ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e0dd9020bfd64b645036c7a" +
+		"4e677d2c38532a3a23ba4442caf53ea63bb454329b7624c8917bdd64b1c0fd4c" +
+		"b38e8c334c701c3acdad0657fccfec719b1f5c3e4e46041f388147fb4cfdb477" +
+		"a52471f7a9a96910b855322edb6340d8a00ef092350511e30abec1fff9e3a26e" +
+		"7fb29f8c183023c3587e38da0077d9b4763e4e4b94b2bbc194c6651e77caf992" +
+		"eeaac0232a281bf6b3a739c1226116820ae8db5847a67cbef9c9091b462d538c" +
+		"d72b03746ae77f5e62292c311562a846505dc82db854338ae49f5235c95b9117" +
+		"8ccf2dd5cacef403ec9d1810c6272b045b3b71f9dc6b80d63fdd4a8e9adb1e69" +
+		"62a69526d43161c1a41d570d7938dad4a40e329cd0e40e65ffffffffffffffff"
+	ffdhe8192Hex = "" +
+		"ffffffffffffffffadf85458a2bb4a9aafdc5620273d3cf1d8b9c583ce2d3695" +
+		"a9e13641146433fbcc939dce249b3ef97d2fe363630c75d8f681b202aec4617a" +
+		"d3df1ed5d5fd65612433f51f5f066ed0856365553ded1af3b557135e7f57c935" +
+		"984f0c70e0e68b77e2a689daf3efe8721df158a136ade73530acca4f483a797a" +
+		"bc0ab182b324fb61d108a94bb2c8e3fbb96adab760d7f4681d4f42a3de394df4" +
+		"ae56ede76372bb190b07a7c8ee0a6d709e02fce1cdf7e2ecc03404cd28342f61" +
+		"9172fe9ce98583ff8e4f1232eef28183c3fe3b1b4c6fad733bb5fcbc2ec22005" +
+		"c58ef1837d1683b2c6f34a26c1b2effa886b4238611fcfdcde355b3b6519035b" +
+		"bc34f4def99c023861b46fc9d6e6c9077ad91d2691f7f7ee598cb0fac186d91c" +
+		"aefe130985139270b4130c93bc437944f4fd4452e2d74dd364f2e21e71f54bff" +
+		"5cae82ab9c9df69ee86d2bc522363a0dabc521979b0deada1dbf9a42d5c4484e" +
+		"0abcd06bfa53ddef3c1b20ee3fd59d7c25e41d2b669e1ef16e6f52c3164df4fb" +
+		"7930e9e4e58857b6ac7d5f42d69f6d187763cf1d5503400487f55ba57e31cc7a" +
+		"7135c886efb4318aed6a1e012d9e6832a907600a918130c46dc778f971ad0038" +
+		"092999a333cb8b7a1a1db93d7140003c2a4ecea9f98d0acc0a8291cdcec97dcf" +
+		"8ec9b55a7f88a46b4db5a851f44182e1c68a007e5e0dd9020bfd64b645036c7a" +
+		"4e677d2c38532a3a23ba4442caf53ea63bb454329b7624c8917bdd64b1c0fd4c" +
+		"b38e8c334c701c3acdad0657fccfec719b1f5c3e4e46041f388147fb4cfdb477" +
+		"a52471f7a9a96910b855322edb6340d8a00ef092350511e30abec1fff9e3a26e" +
+		"7fb29f8c183023c3587e38da0077d9b4763e4e4b94b2bbc194c6651e77caf992" +
+		"eeaac0232a281bf6b3a739c1226116820ae8db5847a67cbef9c9091b462d538c" +
+		"d72b03746ae77f5e62292c311562a846505dc82db854338ae49f5235c95b9117" +
+		"8ccf2dd5cacef403ec9d1810c6272b045b3b71f9dc6b80d63fdd4a8e9adb1e69" +
+		"62a69526d43161c1a41d570d7938dad4a40e329ccff46aaa36ad004cf600c838" +
+		"1e425a31d951ae64fdb23fcec9509d43687feb69edd1cc5e0b8cc3bdf64b10ef" +
+		"86b63142a3ab8829555b2f747c932665cb2c0f1cc01bd70229388839d2af05e4" +
+		"54504ac78b7582822846c0ba35c35f5c59160cc046fd8251541fc68c9c86b022" +
+		"bb7099876a460e7451a8a93109703fee1c217e6c3826e52c51aa691e0e423cfc" +
+		"99e9e31650c1217b624816cdad9a95f9d5b8019488d9c0a0a1fe3075a577e231" +
+		"83f81d4a3f2fa4571efc8ce0ba8a4fe8b6855dfe72b0a66eded2fbabfbe58a30" +
+		"fafabe1c5d71a87e2f741ef8c1fe86fea6bbfde530677f0d97d11d49f7a8443d" +
+		"0822e506a9f4614e011e2a94838ff88cd68c8bb7c5c6424cffffffffffffffff"
+)
+
+// The primes of RFC 3526, which are also derived from the digits of pi, and
+// are safe primes for which 2 generates the subgroup of order q.
+
+const (
+	modp1536Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca237327ffffffffffffffff"
+	modp2048Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aacaa68ffffffffffffffff"
+	modp3072Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a93ad2caffffffffffffffff"
+	modp4096Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c934063199ffffffffffffffff"
+	modp6144Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c93402849236c3fab4d27c7026" +
+		"c1d4dcb2602646dec9751e763dba37bdf8ff9406ad9e530ee5db382f413001ae" +
+		"b06a53ed9027d831179727b0865a8918da3edbebcf9b14ed44ce6cbaced4bb1b" +
+		"db7f1447e6cc254b332051512bd7af426fb8f401378cd2bf5983ca01c64b92ec" +
+		"f032ea15d1721d03f482d7ce6e74fef6d55e702f46980c82b5a84031900b1c9e" +
+		"59e7c97fbec7e8f323a97a7e36cc88be0f1d45b7ff585ac54bd407b22b4154aa" +
+		"cc8f6d7ebf48e1d814cc5ed20f8037e0a79715eef29be32806a1d58bb7c5da76" +
+		"f550aa3d8a1fbff0eb19ccb1a313d55cda56c9ec2ef29632387fe8d76e3c0468" +
+		"043e8f663f4860ee12bf2d5b0b7474d6e694f91e6dcc4024ffffffffffffffff"
+	modp8192Hex = "" +
+		"ffffffffffffffffc90fdaa22168c234c4c6628b80dc1cd129024e088a67cc74" +
+		"020bbea63b139b22514a08798e3404ddef9519b3cd3a431b302b0a6df25f1437" +
+		"4fe1356d6d51c245e485b576625e7ec6f44c42e9a637ed6b0bff5cb6f406b7ed" +
+		"ee386bfb5a899fa5ae9f24117c4b1fe649286651ece45b3dc2007cb8a163bf05" +
+		"98da48361c55d39a69163fa8fd24cf5f83655d23dca3ad961c62f356208552bb" +
+		"9ed529077096966d670c354e4abc9804f1746c08ca18217c32905e462e36ce3b" +
+		"e39e772c180e86039b2783a2ec07a28fb5c55df06f4c52c9de2bcbf695581718" +
+		"3995497cea956ae515d2261898fa051015728e5a8aaac42dad33170d04507a33" +
+		"a85521abdf1cba64ecfb850458dbef0a8aea71575d060c7db3970f85a6e1e4c7" +
+		"abf5ae8cdb0933d71e8c94e04a25619dcee3d2261ad2ee6bf12ffa06d98a0864" +
+		"d87602733ec86a64521f2b18177b200cbbe117577a615d6c770988c0bad946e2" +
+		"08e24fa074e5ab3143db5bfce0fd108e4b82d120a92108011a723c12a787e6d7" +
+		"88719a10bdba5b2699c327186af4e23c1a946834b6150bda2583e9ca2ad44ce8" +
+		"dbbbc2db04de8ef92e8efc141fbecaa6287c59474e6bc05d99b2964fa090c3a2" +
+		"233ba186515be7ed1f612970cee2d7afb81bdd762170481cd0069127d5b05aa9" +
+		"93b4ea988d8fddc186ffb7dc90a6c08f4df435c93402849236c3fab4d27c7026" +
+		"c1d4dcb2602646dec9751e763dba37bdf8ff9406ad9e530ee5db382f413001ae" +
+		"b06a53ed9027d831179727b0865a8918da3edbebcf9b14ed44ce6cbaced4bb1b" +
+		"db7f1447e6cc254b332051512bd7af426fb8f401378cd2bf5983ca01c64b92ec" +
+		"f032ea15d1721d03f482d7ce6e74fef6d55e702f46980c82b5a84031900b1c9e" +
+		"59e7c97fbec7e8f323a97a7e36cc88be0f1d45b7ff585ac54bd407b22b4154aa" +
+		"cc8f6d7ebf48e1d814cc5ed20f8037e0a79715eef29be32806a1d58bb7c5da76" +
+		"f550aa3d8a1fbff0eb19ccb1a313d55cda56c9ec2ef29632387fe8d76e3c0468" +
+		"043e8f663f4860ee12bf2d5b0b7474d6e694f91e6dbe115974a3926f12fee5e4" +
+		"38777cb6a932df8cd8bec4d073b931ba3bc832b68d9dd300741fa7bf8afc47ed" +
+		"2576f6936ba424663aab639c5ae4f5683423b4742bf1c978238f16cbe39d652d" +
+		"e3fdb8befc848ad922222e04a4037c0713eb57a81a23f0c73473fc646cea306b" +
+		"4bcbc8862f8385ddfa9d4b7fa2c087e879683303ed5bdd3a062b3cf5b3a278a6" +
+		"6d2a13f83f44f82ddf310ee074ab6a364597e899a0255dc164f31cc50846851d" +
+		"f9ab48195ded7ea1b1d510bd7ee74d73faf36bc31ecfa268359046f4eb879f92" +
+		"4009438b481c6cd7889a002ed5ee382bc9190da6fc026e479558e4475677e9aa" +
+		"9e3050e2765694dfc81f56e880b96e7160c980dd98edd3dfffffffffffffffff"
+)
+
+// The primes of RFC 5054, appendix A, which are used by SRP. The bigger ones
+// are those of RFC 3526, with other generators. Most of the generators aren't
+// quadratic residues, and generate the whole group, of order p - 1.
+
+const (
+	srp1024Hex = "" +
+		"eeaf0ab9adb38dd69c33f80afa8fc5e86072618775ff3c0b9ea2314c9c256576" +
+		"d674df7496ea81d3383b4813d692c6e0e0d5d8e250b98be48e495c1d6089dad1" +
+		"5dc7d7b46154d6b6ce8ef4ad69b15d4982559b297bcf1885c529f566660e57ec" +
+		"68edbc3c05726cc02fd4cbf4976eaa9afd5138fe8376435b9fc61d2fc0eb06e3"
+	srp1536Hex = "" +
+		"9def3cafb939277ab1f12a8617a47bbbdba51df499ac4c80beeea9614b19cc4d" +
+		"5f4f5f556e27cbde51c6a94be4607a291558903ba0d0f84380b655bb9a22e8dc" +
+		"df028a7cec67f0d08134b1c8b97989149b609e0be3bab63d47548381dbc5b1fc" +
+		"764e3f4b53dd9da1158bfd3e2b9c8cf56edf019539349627db2fd53d24b7c486" +
+		"65772e437d6c7f8ce442734af7ccb7ae837c264ae3a9beb87f8a2fe9b8b5292e" +
+		"5a021fff5e91479e8ce7a28c2442c6f315180f93499a234dcf76e3fed135f9bb"
+)
+
+// The exponent sizes of the groups of RFC 3526 are the largest ones of its
+// section 8, and the ones of RFC 5054 are the same, and at least the 256 bits
+// required by its section 2.5.4.
+
+var (
+	ffdhe2048 = newGroup("ffdhe2048", ffdhe2048Hex, 2, 225)
+	ffdhe3072 = newGroup("ffdhe3072", ffdhe3072Hex, 2, 275)
+	ffdhe4096 = newGroup("ffdhe4096", ffdhe4096Hex, 2, 325)
+	ffdhe6144 = newGroup("ffdhe6144", ffdhe6144Hex, 2, 375)
+	ffdhe8192 = newGroup("ffdhe8192", ffdhe8192Hex, 2, 400)
+
+	modp1536 = newGroup("modp1536", modp1536Hex, 2, 240)
+	modp2048 = newGroup("modp2048", modp2048Hex, 2, 320)
+	modp3072 = newGroup("modp3072", modp3072Hex, 2, 420)
+	modp4096 = newGroup("modp4096", modp4096Hex, 2, 480)
+	modp6144 = newGroup("modp6144", modp6144Hex, 2, 540)
+	modp8192 = newGroup("modp8192", modp8192Hex, 2, 620)
+
+	srp1024 = newGroup("srp1024", srp1024Hex, 2, 256)
+	srp1536 = newGroup("srp1536", srp1536Hex, 2, 256)
+	srp2048 = newGroup("srp2048", modp2048Hex, 2, 320)
+	srp3072 = newGroup("srp3072", modp3072Hex, 5, 420)
+	srp4096 = newGroup("srp4096", modp4096Hex, 5, 480)
+	srp6144 = newGroup("srp6144", modp6144Hex, 5, 540)
+	srp8192 = newGroup("srp8192", modp8192Hex, 19, 620)
+
+	allGroups = []*Group{
+		ffdhe2048, ffdhe3072, ffdhe4096, ffdhe6144, ffdhe8192,
+		modp1536, modp2048, modp3072, modp4096, modp6144, modp8192,
+		srp1024, srp1536, srp2048, srp3072, srp4096, srp6144, srp8192,
+	}
+)
+
+// FFDHE2048 returns the ffdhe2048 group of RFC 7919, with 225 bit private
+// exponents.
+func FFDHE2048() *Group { return ffdhe2048 }
+
+// FFDHE3072 returns the ffdhe3072 group of RFC 7919, with 275 bit private
+// exponents.
+func FFDHE3072() *Group { return ffdhe3072 }
+
+// FFDHE4096 returns the ffdhe4096 group of RFC 7919, with 325 bit private
+// exponents.
+func FFDHE4096() *Group { return ffdhe4096 }
+
+// FFDHE6144 returns the ffdhe6144 group of RFC 7919, with 375 bit private
+// exponents.
+func FFDHE6144() *Group { return ffdhe6144 }
+
+// FFDHE8192 returns the ffdhe8192 group of RFC 7919, with 400 bit private
+// exponents.
+func FFDHE8192() *Group { return ffdhe8192 }
+
+// MODP1536 returns the 1536-bit MODP group of RFC 3526, with 240 bit private
+// exponents. It is too small for new protocols, and only kept for
+// interoperability.
+func MODP1536() *Group { return modp1536 }
+
+// MODP2048 returns the 2048-bit MODP group of RFC 3526, with 320 bit private
+// exponents.
+func MODP2048() *Group { return modp2048 }
+
+// MODP3072 returns the 3072-bit MODP group of RFC 3526, with 420 bit private
+// exponents.
+func MODP3072() *Group { return modp3072 }
+
+// MODP4096 returns the 4096-bit MODP group of RFC 3526, with 480 bit private
+// exponents.
+func MODP4096() *Group { return modp4096 }
+
+// MODP6144 returns the 6144-bit MODP group of RFC 3526, with 540 bit private
+// exponents.
+func MODP6144() *Group { return modp6144 }
+
+// MODP8192 returns the 8192-bit MODP group of RFC 3526, with 620 bit private
+// exponents.
+func MODP8192() *Group { return modp8192 }
+
+// SRP1024 returns the 1024-bit group of RFC 5054, with generator 2. It is too
+// small for new protocols, and only kept for interoperability.
+func SRP1024() *Group { return srp1024 }
+
+// SRP1536 returns the 1536-bit group of RFC 5054, with generator 2. It is too
+// small for new protocols, and only kept for interoperability.
+func SRP1536() *Group { return srp1536 }
+
+// SRP2048 returns the 2048-bit group of RFC 5054, with generator 2. It has
+// the same prime and generator as MODP2048, but is a different Group.
+func SRP2048() *Group { return srp2048 }
+
+// SRP3072 returns the 3072-bit group of RFC 5054, with generator 5.
+func SRP3072() *Group { return srp3072 }
+
+// SRP4096 returns the 4096-bit group of RFC 5054, with generator 5.
+func SRP4096() *Group { return srp4096 }
+
+// SRP6144 returns the 6144-bit group of RFC 5054, with generator 5.
+func SRP6144() *Group { return srp6144 }
+
+// SRP8192 returns the 8192-bit group of RFC 5054, with generator 19.
+func SRP8192() *Group { return srp8192 }
+
+// GroupByName returns the standard group with the given name, like
+// "ffdhe2048", "modp3072", or "srp4096".
+func GroupByName(name string) (*Group, error) {
+	for _, g := range allGroups {
+		if g.name == name {
+			return g, nil
+		}
+	}
+	return nil, errors.New("ffdh: unknown group " + name)
+}
+
+// AllGroups returns every standard group: those of RFC 7919, RFC 3526, and
+// RFC 5054, in that order, and by increasing size.
+func AllGroups() []*Group {
+	return append([]*Group(nil), allGroups...)
+}
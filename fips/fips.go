@@ -0,0 +1,188 @@
+// Package fips implements a policy switch restricting this module to the
+// algorithms approved by FIPS 140-3, for applications which need to stay
+// within them.
+//
+// The policy is disabled by default. Once enabled with SetEnabled, the other
+// packages of the module reject, with a *NotApprovedError:
+//
+//   - curves other than P-256, P-384, and P-521, which includes X25519, and
+//     XEdDSA signatures;
+//   - ECDSA signatures whose digest is weaker than the curve;
+//   - RSA keys shorter than 2048 bits, and signatures with hashes other than
+//     SHA-2 and SHA-3;
+//   - KDFs instantiated with hashes other than SHA-2 and SHA-3;
+//   - randomness not read from crypto/rand.Reader, or a source approved with
+//     ApproveEntropySource, when generating keys, signatures, or ciphertexts.
+//
+// Verifying signatures, and decrypting, isn't restricted, since legacy
+// algorithms may still be used to process existing data.
+//
+// This is a policy, which doesn't make the module a validated one.
+package fips
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"hash"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	_ "golang.org/x/crypto/sha3"
+)
+
+// enabled is 1 if the policy is enforced.
+var enabled int32
+
+// SetEnabled sets whether the policy is enforced.
+func SetEnabled(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&enabled, v)
+}
+
+// Enabled reports whether the policy is enforced.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// NotApprovedError is returned when an algorithm, or a parameter of one, is
+// used while the policy is enforced, but isn't approved.
+type NotApprovedError struct {
+	// Algorithm describes what was rejected, like "X25519", or "RSA-1024".
+	Algorithm string
+}
+
+func (e *NotApprovedError) Error() string {
+	return "fips: " + e.Algorithm + " is not approved"
+}
+
+// Check returns a *NotApprovedError for algorithm if the policy is enforced
+// and approved is false, and nil otherwise.
+func Check(approved bool, algorithm string) error {
+	if approved || !Enabled() {
+		return nil
+	}
+	return &NotApprovedError{algorithm}
+}
+
+// ApprovedCurve reports whether the curve named name, like "P-256", is
+// approved.
+func ApprovedCurve(name string) bool {
+	return name == "P-256" || name == "P-384" || name == "P-521"
+}
+
+// CurveStrength returns the security strength of a curve of the given size,
+// in bits.
+func CurveStrength(bitSize int) int {
+	if bitSize >= 512 {
+		return 256
+	}
+	return bitSize / 2
+}
+
+// RSAStrength returns the security strength of an RSA modulus of the given
+// size, following SP 800-57 Part 1, table 2.
+func RSAStrength(bits int) int {
+	switch {
+	case bits >= 15360:
+		return 256
+	case bits >= 7680:
+		return 192
+	case bits >= 3072:
+		return 128
+	case bits >= 2048:
+		return 112
+	}
+	return 80
+}
+
+// approvedHashes are the hashes approved for signatures and key derivation.
+var approvedHashes = []crypto.Hash{
+	crypto.SHA224, crypto.SHA256, crypto.SHA384, crypto.SHA512,
+	crypto.SHA512_224, crypto.SHA512_256,
+	crypto.SHA3_224, crypto.SHA3_256, crypto.SHA3_384, crypto.SHA3_512,
+}
+
+// ApprovedHash reports whether h is approved, and provides at least strength
+// bits of collision resistance.
+func ApprovedHash(h crypto.Hash, strength int) bool {
+	for _, a := range approvedHashes {
+		if h == a {
+			return h.Size()*4 >= strength
+		}
+	}
+	return false
+}
+
+var (
+	emptyDigestsOnce sync.Once
+	emptyDigests     [][]byte
+)
+
+// ApprovedHashFunc reports whether h constructs one of the approved hashes,
+// which is recognized from its digest of the empty string.
+func ApprovedHashFunc(h func() hash.Hash) bool {
+	emptyDigestsOnce.Do(func() {
+		for _, a := range approvedHashes {
+			emptyDigests = append(emptyDigests, a.New().Sum(nil))
+		}
+	})
+	d := h().Sum(nil)
+	for _, e := range emptyDigests {
+		if bytes.Equal(d, e) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	entropyMu      sync.RWMutex
+	entropySources []io.Reader
+)
+
+// ApproveEntropySource approves r as a source of randomness, in addition to
+// crypto/rand.Reader, like a DRBG seeded from an approved entropy source.
+//
+// r must be comparable, like a pointer.
+func ApproveEntropySource(r io.Reader) {
+	if !reflect.TypeOf(r).Comparable() {
+		panic("fips: entropy source isn't comparable")
+	}
+	entropyMu.Lock()
+	defer entropyMu.Unlock()
+	entropySources = append(entropySources, r)
+}
+
+// ApprovedEntropy reports whether r is crypto/rand.Reader, or was approved
+// with ApproveEntropySource.
+func ApprovedEntropy(r io.Reader) bool {
+	// Comparing interfaces holding values which aren't comparable panics.
+	if r == nil || !reflect.TypeOf(r).Comparable() {
+		return false
+	}
+	if r == rand.Reader {
+		return true
+	}
+	entropyMu.RLock()
+	defer entropyMu.RUnlock()
+	for _, s := range entropySources {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckEntropy returns a *NotApprovedError if the policy is enforced and r
+// isn't an approved source of randomness.
+func CheckEntropy(r io.Reader) error {
+	return Check(ApprovedEntropy(r), "entropy source")
+}
@@ -0,0 +1,144 @@
+package fips_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/kdf"
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/ctcrypto/xeddsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// enable enforces the policy until the end of the test.
+func enable(t *testing.T) {
+	fips.SetEnabled(true)
+	t.Cleanup(func() { fips.SetEnabled(false) })
+}
+
+func notApproved(t *testing.T, what string, err error) {
+	t.Helper()
+	var e *fips.NotApprovedError
+	if !errors.As(err, &e) {
+		t.Errorf("%s: got %v, expected a *NotApprovedError", what, err)
+	}
+}
+
+func approved(t *testing.T, what string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("%s: %v", what, err)
+	}
+}
+
+func TestDisabledByDefault(t *testing.T) {
+	if fips.Enabled() {
+		t.Fatalf("policy enforced by default")
+	}
+	_, err := ecdh.X25519().GenerateKey(&fixedReader{1})
+	approved(t, "X25519 with a fixed reader", err)
+}
+
+func TestCurves(t *testing.T) {
+	enable(t)
+	for _, c := range []ecdh.Curve{ecdh.P256(), ecdh.P384(), ecdh.P521()} {
+		_, err := c.GenerateKey(rand.Reader)
+		approved(t, fmt.Sprint(c), err)
+	}
+	_, err := ecdh.X25519().GenerateKey(rand.Reader)
+	notApproved(t, "X25519", err)
+	_, err = ecdh.X25519().NewPublicKey(make([]byte, 32))
+	notApproved(t, "X25519 public key", err)
+	_, err = xeddsa.Sign(make([]byte, 32), []byte("hello"), rand.Reader)
+	notApproved(t, "XEdDSA", err)
+	_, err = ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	notApproved(t, "ECDSA over P-224", err)
+}
+
+func TestECDSADigest(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enable(t)
+	_, _, err = ecdsa.Sign(rand.Reader, priv, make([]byte, 48))
+	approved(t, "P-384 with SHA-384", err)
+	_, _, err = ecdsa.Sign(rand.Reader, priv, make([]byte, 32))
+	notApproved(t, "P-384 with SHA-256", err)
+}
+
+func TestRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("hello"))
+	enable(t)
+	_, err = rsa.GenerateKey(rand.Reader, 1024)
+	notApproved(t, "RSA-1024 key generation", err)
+	_, err = rsa.GenerateMultiPrimeKey(rand.Reader, 3, 2048)
+	notApproved(t, "multi-prime RSA", err)
+	_, err = rsa.SignPKCS1v15(priv, crypto.SHA256, digest[:])
+	notApproved(t, "RSA-1024 signature", err)
+	_, err = rsa.EncryptPKCS1v15(rand.Reader, &priv.PublicKey, []byte("hello"))
+	notApproved(t, "PKCS #1 v1.5 encryption", err)
+	// Verification isn't restricted.
+	if err := rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, digest[:], make([]byte, 128)); err != rsa.ErrVerification {
+		t.Errorf("RSA-1024 verification: %v", err)
+	}
+}
+
+func TestHashes(t *testing.T) {
+	if !fips.ApprovedHash(crypto.SHA256, 112) || fips.ApprovedHash(crypto.SHA1, 80) || fips.ApprovedHash(crypto.SHA224, 128) {
+		t.Errorf("ApprovedHash doesn't follow the strength of hashes")
+	}
+	if !fips.ApprovedHashFunc(sha256.New) || !fips.ApprovedHashFunc(sha3.New384) || fips.ApprovedHashFunc(md5.New) {
+		t.Errorf("ApprovedHashFunc doesn't recognize hashes")
+	}
+	enable(t)
+	_, err := kdf.OneStep(sha256.New, []byte("z"), nil, 32)
+	approved(t, "KDF with SHA-256", err)
+	_, err = kdf.TwoStep(md5.New, []byte("z"), nil, nil, 32)
+	notApproved(t, "KDF with MD5", err)
+}
+
+type fixedReader struct{ b byte }
+
+func (r *fixedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = r.b
+	}
+	return len(p), nil
+}
+
+func TestEntropy(t *testing.T) {
+	enable(t)
+	_, err := ecdh.P256().GenerateKey(&fixedReader{1})
+	notApproved(t, "fixed reader", err)
+	approved := &fixedReader{2}
+	fips.ApproveEntropySource(approved)
+	if _, err := ecdh.P256().GenerateKey(approved); err != nil {
+		t.Errorf("approved entropy source rejected: %v", err)
+	}
+	// Readers which can't be compared mustn't panic.
+	if fips.ApprovedEntropy(struct{ *bytes.Reader }{bytes.NewReader(nil)}) {
+		t.Errorf("unknown reader approved")
+	}
+	if fips.ApprovedEntropy(readerFunc(nil)) {
+		t.Errorf("function approved")
+	}
+}
+
+type readerFunc func([]byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
@@ -5,4 +5,5 @@ go 1.16
 require (
 	github.com/cronokirby/safenum v0.12.0
 	golang.org/x/crypto v0.0.0-20210506145944-38f3c27a63bf
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68
 )
@@ -0,0 +1,138 @@
+package group
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/safenum"
+)
+
+// bls12381Group is the group G1 of the BLS12-381 curve.
+type bls12381Group struct{}
+
+var bls12381G1 = &bls12381Group{}
+
+// BLS12381G1 returns the group G1 of the BLS12-381 pairing-friendly curve,
+// which lets protocols written against this package produce keys for
+// pairing-based schemes, like threshold BLS signatures.
+//
+// Elements use the compressed encoding of Zcash, and scalars are encoded as
+// 32 byte big-endian integers. Hashing to the group uses the
+// bls12381.SuiteG1 suite, which isn't one of the standard suites of RFC 9380.
+func BLS12381G1() Group {
+	return bls12381G1
+}
+
+func (g *bls12381Group) Name() string {
+	return "BLS12-381 G1"
+}
+
+func (g *bls12381Group) Order() *safenum.Modulus {
+	return bls12381.Order
+}
+
+func (g *bls12381Group) Identity() Element {
+	return &bls12381Element{bls12381.NewG1Identity()}
+}
+
+func (g *bls12381Group) Generator() Element {
+	return &bls12381Element{bls12381.NewG1Generator()}
+}
+
+func (g *bls12381Group) ScalarBaseMult(s *safenum.Nat) Element {
+	return g.Generator().ScalarMult(s)
+}
+
+func (g *bls12381Group) RandomScalar(rand io.Reader) (*safenum.Nat, error) {
+	// Reducing 128 more bits than the size of the order makes the bias negligible.
+	buf := make([]byte, bls12381.ScalarSize+16)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), bls12381.Order), nil
+}
+
+func (g *bls12381Group) HashToElement(msg, dst []byte) Element {
+	return &bls12381Element{bls12381.HashToG1(msg, dst)}
+}
+
+func (g *bls12381Group) HashToScalar(msg, dst []byte) *safenum.Nat {
+	// This follows hash_to_field from RFC 9380, with L = 48.
+	uniform, err := elliptic.ExpandMessageXMD(sha256.New, msg, dst, 48)
+	if err != nil {
+		panic(err)
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(uniform), bls12381.Order)
+}
+
+func (g *bls12381Group) ElementSize() int {
+	return bls12381.G1CompressedSize
+}
+
+func (g *bls12381Group) DecodeElement(data []byte) (Element, error) {
+	if len(data) != bls12381.G1CompressedSize {
+		return nil, errors.New("group: invalid BLS12-381 G1 element")
+	}
+	p, err := new(bls12381.G1).SetBytes(data)
+	if err != nil || p.IsIdentity() == 1 {
+		return nil, errors.New("group: invalid BLS12-381 G1 element")
+	}
+	return &bls12381Element{p}, nil
+}
+
+func (g *bls12381Group) ScalarSize() int {
+	return bls12381.ScalarSize
+}
+
+func (g *bls12381Group) EncodeScalar(s *safenum.Nat) []byte {
+	return bls12381.ScalarBytes(s)
+}
+
+func (g *bls12381Group) DecodeScalar(data []byte) (*safenum.Nat, error) {
+	s, err := bls12381.ScalarFromBytes(data)
+	if err != nil {
+		return nil, errors.New("group: invalid BLS12-381 scalar")
+	}
+	return s, nil
+}
+
+// bls12381Element is a point of G1.
+type bls12381Element struct {
+	p *bls12381.G1
+}
+
+func (e *bls12381Element) other(b Element) *bls12381Element {
+	f, ok := b.(*bls12381Element)
+	if !ok {
+		panic("group: mismatched groups")
+	}
+	return f
+}
+
+func (e *bls12381Element) Add(b Element) Element {
+	return &bls12381Element{new(bls12381.G1).Add(e.p, e.other(b).p)}
+}
+
+func (e *bls12381Element) Negate() Element {
+	return &bls12381Element{new(bls12381.G1).Negate(e.p)}
+}
+
+func (e *bls12381Element) ScalarMult(s *safenum.Nat) Element {
+	s = new(safenum.Nat).Mod(s, bls12381.Order)
+	return &bls12381Element{new(bls12381.G1).ScalarMult(s, e.p)}
+}
+
+func (e *bls12381Element) Equal(b Element) int {
+	return e.p.Equal(e.other(b).p)
+}
+
+func (e *bls12381Element) IsIdentity() bool {
+	return e.p.IsIdentity() == 1
+}
+
+func (e *bls12381Element) Bytes() []byte {
+	return e.p.Bytes()
+}
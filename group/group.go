@@ -0,0 +1,103 @@
+// Package group provides an abstraction over cyclic groups of prime order, in
+// which the discrete logarithm problem is hard.
+//
+// Higher level protocols, like key exchanges, secret sharing, or proofs of
+// knowledge, only need a group to work in, and are written against the Group
+// and Element interfaces, so that they can be used with any of the groups
+// provided by this package.
+//
+// Scalars are represented as safenum.Nat values, reduced modulo the order of
+// the group, so that arithmetic on secret scalars can be done in constant-time.
+package group
+
+import (
+	"context"
+	"io"
+
+	"github.com/cronokirby/safenum"
+)
+
+// Group is a cyclic group of prime order, with a fixed generator.
+type Group interface {
+	// Name returns the name of this group, e.g. "P-256".
+	Name() string
+	// Order returns the order of the group, which is a prime number.
+	Order() *safenum.Modulus
+	// Identity returns the identity element of the group.
+	Identity() Element
+	// Generator returns the fixed generator of the group.
+	Generator() Element
+	// ScalarBaseMult returns s * G, where G is the generator of the group.
+	ScalarBaseMult(s *safenum.Nat) Element
+	// RandomScalar returns a uniformly random scalar, reading randomness from rand.
+	RandomScalar(rand io.Reader) (*safenum.Nat, error)
+	// HashToElement hashes a message to an element of the group, whose discrete
+	// logarithm is unknown, using dst for domain separation.
+	HashToElement(msg, dst []byte) Element
+	// HashToScalar hashes a message to a uniformly distributed scalar, using
+	// dst for domain separation.
+	HashToScalar(msg, dst []byte) *safenum.Nat
+	// ElementSize returns the size of an encoded element, other than the identity.
+	ElementSize() int
+	// DecodeElement decodes an element produced by Element.Bytes.
+	//
+	// An error is returned if the data doesn't encode an element of the group.
+	// The identity element is also rejected.
+	DecodeElement(data []byte) (Element, error)
+	// ScalarSize returns the size of an encoded scalar.
+	ScalarSize() int
+	// EncodeScalar encodes a scalar, which must be reduced modulo the order.
+	EncodeScalar(s *safenum.Nat) []byte
+	// DecodeScalar decodes a scalar produced by EncodeScalar.
+	//
+	// An error is returned if the data has the wrong length, or if the scalar
+	// is not reduced modulo the order.
+	DecodeScalar(data []byte) (*safenum.Nat, error)
+}
+
+// Element is an element of a Group.
+//
+// Elements are immutable: operations on them return new elements. Operations
+// involving multiple elements will panic if the elements belong to different
+// groups.
+type Element interface {
+	// Add returns the sum of this element and b.
+	Add(b Element) Element
+	// Negate returns the inverse of this element.
+	Negate() Element
+	// ScalarMult returns s times this element.
+	ScalarMult(s *safenum.Nat) Element
+	// Equal returns 1 if this element is equal to b, and 0 otherwise, in constant-time.
+	Equal(b Element) int
+	// IsIdentity reports whether this element is the identity.
+	IsIdentity() bool
+	// Bytes returns the canonical encoding of this element.
+	Bytes() []byte
+}
+
+// Sub returns a - b.
+func Sub(a, b Element) Element {
+	return a.Add(b.Negate())
+}
+
+// MultiScalarMult returns the sum of scalars[i] * elements[i], in the group g.
+//
+// Once ctx is done, MultiScalarMult stops, and returns ctx.Err(). The context
+// is checked before each multiplication, so that long sums, like commitments
+// to large polynomials, can be cancelled. Pass context.Background() if the
+// sum never needs to be cancelled.
+//
+// scalars and elements must have the same length.
+func MultiScalarMult(ctx context.Context, g Group, scalars []*safenum.Nat, elements []Element) (Element, error) {
+	if len(scalars) != len(elements) {
+		panic("group: mismatched multi-scalar multiplication lengths")
+	}
+	out := g.Identity()
+	for i, s := range scalars {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out = out.Add(elements[i].ScalarMult(s))
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package group
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+var groups = []Group{P256(), P384(), P521(), Ristretto255(), BLS12381G1()}
+
+func TestGroupLaws(t *testing.T) {
+	for _, g := range groups {
+		a, _ := g.RandomScalar(rand.Reader)
+		b, _ := g.RandomScalar(rand.Reader)
+		A := g.ScalarBaseMult(a)
+		B := g.ScalarBaseMult(b)
+		sum := new(safenum.Nat).ModAdd(a, b, g.Order())
+		if A.Add(B).Equal(g.ScalarBaseMult(sum)) != 1 {
+			t.Errorf("%s: aG + bG != (a + b)G", g.Name())
+		}
+		if A.Add(B).Equal(B.Add(A)) != 1 {
+			t.Errorf("%s: addition is not commutative", g.Name())
+		}
+		if A.Add(g.Identity()).Equal(A) != 1 || g.Identity().Add(A).Equal(A) != 1 {
+			t.Errorf("%s: identity is not neutral", g.Name())
+		}
+		if !A.Add(A.Negate()).IsIdentity() || !Sub(A, A).IsIdentity() {
+			t.Errorf("%s: A - A is not the identity", g.Name())
+		}
+		prod := new(safenum.Nat).ModMul(a, b, g.Order())
+		if A.ScalarMult(b).Equal(g.ScalarBaseMult(prod)) != 1 {
+			t.Errorf("%s: b(aG) != (ab)G", g.Name())
+		}
+		if g.Generator().ScalarMult(a).Equal(A) != 1 {
+			t.Errorf("%s: ScalarMult and ScalarBaseMult disagree", g.Name())
+		}
+		if !g.Identity().ScalarMult(a).IsIdentity() || !A.ScalarMult(new(safenum.Nat)).IsIdentity() {
+			t.Errorf("%s: multiplication by 0 or of the identity is not the identity", g.Name())
+		}
+		if A.Equal(B) != 0 {
+			t.Errorf("%s: distinct elements are equal", g.Name())
+		}
+	}
+}
+
+func TestElementEncoding(t *testing.T) {
+	for _, g := range groups {
+		s, _ := g.RandomScalar(rand.Reader)
+		A := g.ScalarBaseMult(s)
+		enc := A.Bytes()
+		if len(enc) != g.ElementSize() {
+			t.Errorf("%s: encoding has length %d, expected %d", g.Name(), len(enc), g.ElementSize())
+		}
+		decoded, err := g.DecodeElement(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Equal(A) != 1 {
+			t.Errorf("%s: element didn't round trip", g.Name())
+		}
+		if _, err := g.DecodeElement(g.Identity().Bytes()); err == nil {
+			t.Errorf("%s: the identity was decoded", g.Name())
+		}
+		for i := range enc {
+			enc[i] = 0xff
+		}
+		if _, err := g.DecodeElement(enc); err == nil {
+			t.Errorf("%s: invalid encoding was decoded", g.Name())
+		}
+	}
+}
+
+func TestScalarEncoding(t *testing.T) {
+	for _, g := range groups {
+		s, _ := g.RandomScalar(rand.Reader)
+		enc := g.EncodeScalar(s)
+		if len(enc) != g.ScalarSize() {
+			t.Errorf("%s: encoding has length %d, expected %d", g.Name(), len(enc), g.ScalarSize())
+		}
+		decoded, err := g.DecodeScalar(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Cmp(s) != 0 {
+			t.Errorf("%s: scalar didn't round trip", g.Name())
+		}
+		order := g.Order().Bytes()
+		order = order[len(order)-g.ScalarSize():]
+		if _, err := g.DecodeScalar(order); err == nil {
+			t.Errorf("%s: unreduced scalar was decoded", g.Name())
+		}
+		// Decoding must not modify its input, even with spare capacity.
+		withCapacity := append(make([]byte, 0, 2*len(enc)), enc...)
+		if _, err := g.DecodeScalar(withCapacity); err != nil || !bytes.Equal(withCapacity, enc) {
+			t.Errorf("%s: decoding modified its input", g.Name())
+		}
+		if _, err := g.DecodeScalar(enc[1:]); err == nil {
+			t.Errorf("%s: short scalar was decoded", g.Name())
+		}
+	}
+}
+
+func TestHashing(t *testing.T) {
+	for _, g := range groups {
+		A := g.HashToElement([]byte("msg"), []byte("DST"))
+		if A.IsIdentity() || A.Equal(g.HashToElement([]byte("msg"), []byte("other DST"))) != 0 {
+			t.Errorf("%s: hashing to an element is not domain separated", g.Name())
+		}
+		if _, err := g.DecodeElement(A.Bytes()); err != nil {
+			t.Errorf("%s: hashed element is invalid: %v", g.Name(), err)
+		}
+		s := g.HashToScalar([]byte("msg"), []byte("DST"))
+		if !bytes.Equal(g.EncodeScalar(s), g.EncodeScalar(g.HashToScalar([]byte("msg"), []byte("DST")))) {
+			t.Errorf("%s: hashing to a scalar is not deterministic", g.Name())
+		}
+	}
+}
+
+func TestMismatchedGroups(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic when adding elements of different groups")
+		}
+	}()
+	P256().Generator().Add(P384().Generator())
+}
+
+func TestMultiScalarMult(t *testing.T) {
+	for _, g := range groups {
+		scalars := make([]*safenum.Nat, 4)
+		elements := make([]Element, 4)
+		want := g.Identity()
+		for i := range scalars {
+			scalars[i], _ = g.RandomScalar(rand.Reader)
+			x, _ := g.RandomScalar(rand.Reader)
+			elements[i] = g.ScalarBaseMult(x)
+			want = want.Add(g.ScalarBaseMult(new(safenum.Nat).ModMul(scalars[i], x, g.Order())))
+		}
+		got, err := MultiScalarMult(context.Background(), g, scalars, elements)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Equal(want) != 1 {
+			t.Errorf("%s: wrong sum", g.Name())
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := MultiScalarMult(ctx, g, scalars, elements); err != context.Canceled {
+			t.Errorf("%s: got %v, expected %v", g.Name(), err, context.Canceled)
+		}
+	}
+}
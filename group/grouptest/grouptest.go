@@ -0,0 +1,247 @@
+// Package grouptest checks that implementations of group.Group behave like
+// cyclic groups of prime order, so that the protocols written against the
+// Group interface can rely on them.
+//
+// TestGroup checks the group laws on random elements, the order of the group,
+// the encodings of elements and scalars, and hashing, which are the properties
+// the groups of the group package are expected to have, whether they are
+// built into this module or defined elsewhere.
+package grouptest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/safenum"
+)
+
+// checker holds the state of TestGroup.
+type checker struct {
+	g    group.Group
+	rand io.Reader
+}
+
+// TestGroup checks that g is a cyclic group of prime order, whose elements and
+// scalars round trip through their encodings, reading the random scalars it
+// uses from rand. It returns an error describing the first property which
+// doesn't hold, or nil.
+//
+// If rand is nil, the health-tested Reader of the rand package of this module
+// is used.
+func TestGroup(g group.Group, rand io.Reader) error {
+	c := &checker{g: g, rand: randutil.Or(rand)}
+	for _, check := range []func() error{
+		c.checkLaws,
+		c.checkOrder,
+		c.checkElementEncoding,
+		c.checkScalarEncoding,
+		c.checkHashing,
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *checker) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("grouptest: %s: "+format, append([]interface{}{c.g.Name()}, args...)...)
+}
+
+// randomScalars returns n random scalars, checking that they are reduced.
+func (c *checker) randomScalars(n int) ([]*safenum.Nat, error) {
+	scalars := make([]*safenum.Nat, n)
+	for i := range scalars {
+		s, err := c.g.RandomScalar(c.rand)
+		if err != nil {
+			return nil, err
+		}
+		if s.CmpMod(c.g.Order()) != -1 {
+			return nil, c.errorf("RandomScalar returned an unreduced scalar")
+		}
+		scalars[i] = s
+	}
+	return scalars, nil
+}
+
+// equal reports whether a and b are equal, checking that Equal is symmetric.
+func equal(a, b group.Element) bool {
+	return a.Equal(b) == 1 && b.Equal(a) == 1
+}
+
+func (c *checker) checkLaws() error {
+	g := c.g
+	scalars, err := c.randomScalars(3)
+	if err != nil {
+		return err
+	}
+	a, b, s := scalars[0], scalars[1], scalars[2]
+	A, B, C := g.ScalarBaseMult(a), g.ScalarBaseMult(b), g.ScalarBaseMult(s)
+	O := g.Identity()
+
+	one := new(safenum.Nat).SetUint64(1)
+	if g.Generator().IsIdentity() || !equal(g.Generator(), g.ScalarBaseMult(one)) {
+		return c.errorf("the generator isn't 1 * G, or is the identity")
+	}
+	if !O.IsIdentity() || !equal(O, O) || A.IsIdentity() {
+		return c.errorf("IsIdentity is wrong")
+	}
+	if !equal(A.Add(B).Add(C), A.Add(B.Add(C))) {
+		return c.errorf("addition isn't associative")
+	}
+	if !equal(A.Add(B), B.Add(A)) {
+		return c.errorf("addition isn't commutative")
+	}
+	if !equal(A.Add(O), A) || !equal(O.Add(A), A) || !O.Add(O).IsIdentity() {
+		return c.errorf("the identity isn't neutral")
+	}
+	if !A.Add(A.Negate()).IsIdentity() || !equal(A.Negate().Negate(), A) || !O.Negate().IsIdentity() {
+		return c.errorf("Negate doesn't return the inverse")
+	}
+	if !equal(group.Sub(A, B).Add(B), A) {
+		return c.errorf("(A - B) + B isn't A")
+	}
+	if A.Equal(B) != 0 || A.Equal(A.Negate()) != 0 {
+		return c.errorf("distinct elements are equal")
+	}
+	if !equal(A.Add(A), A.ScalarMult(new(safenum.Nat).SetUint64(2))) {
+		return c.errorf("A + A isn't 2 * A")
+	}
+
+	sum := new(safenum.Nat).ModAdd(a, b, g.Order())
+	if !equal(A.Add(B), g.ScalarBaseMult(sum)) {
+		return c.errorf("aG + bG isn't (a + b)G")
+	}
+	prod := new(safenum.Nat).ModMul(a, b, g.Order())
+	if !equal(A.ScalarMult(b), g.ScalarBaseMult(prod)) || !equal(A.ScalarMult(b), B.ScalarMult(a)) {
+		return c.errorf("b(aG) isn't (ab)G")
+	}
+	if !equal(g.Generator().ScalarMult(a), A) {
+		return c.errorf("ScalarMult and ScalarBaseMult disagree")
+	}
+	if !equal(A.ScalarMult(one), A) {
+		return c.errorf("1 * A isn't A")
+	}
+	zero := new(safenum.Nat)
+	if !A.ScalarMult(zero).IsIdentity() || !g.ScalarBaseMult(zero).IsIdentity() || !O.ScalarMult(a).IsIdentity() {
+		return c.errorf("multiplication by 0, or of the identity, isn't the identity")
+	}
+	return nil
+}
+
+// checkOrder checks that the elements have the order of the group, through
+// (n - 1)A = -A, since scalars are reduced modulo n.
+func (c *checker) checkOrder() error {
+	g := c.g
+	nMinusOne := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(1), g.Order())
+	scalars, err := c.randomScalars(1)
+	if err != nil {
+		return err
+	}
+	for _, A := range []group.Element{g.Generator(), g.ScalarBaseMult(scalars[0]), g.HashToElement([]byte("order"), []byte("grouptest"))} {
+		if !equal(A.ScalarMult(nMinusOne), A.Negate()) || !A.ScalarMult(nMinusOne).Add(A).IsIdentity() {
+			return c.errorf("an element doesn't have the order of the group")
+		}
+	}
+	if !equal(g.ScalarBaseMult(nMinusOne), g.Generator().Negate()) {
+		return c.errorf("(n - 1)G isn't -G")
+	}
+	return nil
+}
+
+func (c *checker) checkElementEncoding() error {
+	g := c.g
+	scalars, err := c.randomScalars(1)
+	if err != nil {
+		return err
+	}
+	for _, A := range []group.Element{g.Generator(), g.ScalarBaseMult(scalars[0]), g.Generator().Negate()} {
+		enc := A.Bytes()
+		if len(enc) != g.ElementSize() {
+			return c.errorf("an element is encoded in %d bytes, expected %d", len(enc), g.ElementSize())
+		}
+		decoded, err := g.DecodeElement(enc)
+		if err != nil {
+			return c.errorf("an encoded element was rejected: %v", err)
+		}
+		if !equal(decoded, A) || !bytes.Equal(decoded.Bytes(), enc) {
+			return c.errorf("an element didn't round trip")
+		}
+		if _, err := g.DecodeElement(enc[1:]); err == nil {
+			return c.errorf("a short encoding was decoded")
+		}
+		if _, err := g.DecodeElement(append(enc, 0)); err == nil {
+			return c.errorf("a long encoding was decoded")
+		}
+	}
+	if _, err := g.DecodeElement(g.Identity().Bytes()); err == nil {
+		return c.errorf("the identity was decoded")
+	}
+	return nil
+}
+
+func (c *checker) checkScalarEncoding() error {
+	g := c.g
+	scalars, err := c.randomScalars(1)
+	if err != nil {
+		return err
+	}
+	nMinusOne := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(1), g.Order())
+	for _, s := range []*safenum.Nat{new(safenum.Nat), nMinusOne, scalars[0]} {
+		enc := g.EncodeScalar(s)
+		if len(enc) != g.ScalarSize() {
+			return c.errorf("a scalar is encoded in %d bytes, expected %d", len(enc), g.ScalarSize())
+		}
+		// Decoding must not modify its input, even with spare capacity.
+		withCapacity := append(make([]byte, 0, 2*len(enc)), enc...)
+		decoded, err := g.DecodeScalar(withCapacity)
+		if err != nil {
+			return c.errorf("an encoded scalar was rejected: %v", err)
+		}
+		if decoded.Cmp(s) != 0 || !bytes.Equal(withCapacity, enc) {
+			return c.errorf("a scalar didn't round trip")
+		}
+		if _, err := g.DecodeScalar(enc[1:]); err == nil {
+			return c.errorf("a short scalar was decoded")
+		}
+	}
+	// The order is encoded with the padding of safenum, which is trimmed.
+	order := g.Order().Bytes()
+	if _, err := g.DecodeScalar(order[len(order)-g.ScalarSize():]); err == nil {
+		return c.errorf("an unreduced scalar was decoded")
+	}
+	return nil
+}
+
+func (c *checker) checkHashing() error {
+	g := c.g
+	msg, dst := []byte("message"), []byte("grouptest")
+	H := g.HashToElement(msg, dst)
+	if H.IsIdentity() {
+		return c.errorf("HashToElement returned the identity")
+	}
+	if !equal(H, g.HashToElement(msg, dst)) {
+		return c.errorf("HashToElement isn't deterministic")
+	}
+	if H.Equal(g.HashToElement(msg, []byte("other DST"))) != 0 || H.Equal(g.HashToElement([]byte("other message"), dst)) != 0 {
+		return c.errorf("HashToElement doesn't depend on its inputs")
+	}
+	if decoded, err := g.DecodeElement(H.Bytes()); err != nil || !equal(decoded, H) {
+		return c.errorf("HashToElement returned an invalid element")
+	}
+
+	s := g.HashToScalar(msg, dst)
+	if s.CmpMod(g.Order()) != -1 {
+		return c.errorf("HashToScalar returned an unreduced scalar")
+	}
+	if s.Cmp(g.HashToScalar(msg, dst)) != 0 {
+		return c.errorf("HashToScalar isn't deterministic")
+	}
+	if s.Cmp(g.HashToScalar(msg, []byte("other DST"))) == 0 {
+		return c.errorf("HashToScalar doesn't depend on its inputs")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package grouptest
+
+import (
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func TestGroups(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521(), group.Ristretto255(), group.BLS12381G1()} {
+		if err := TestGroup(g, nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// wrongOrder is a group which reports the order of another group.
+type wrongOrder struct {
+	group.Group
+}
+
+func (g wrongOrder) Order() *safenum.Modulus {
+	return group.Ristretto255().Order()
+}
+
+// lenientScalars is a group which decodes unreduced scalars.
+type lenientScalars struct {
+	group.Group
+}
+
+func (g lenientScalars) DecodeScalar(data []byte) (*safenum.Nat, error) {
+	if len(data) != g.ScalarSize() {
+		return g.Group.DecodeScalar(data)
+	}
+	return new(safenum.Nat).SetBytes(data[:len(data):len(data)]), nil
+}
+
+func TestBrokenGroups(t *testing.T) {
+	for name, g := range map[string]group.Group{
+		"wrong order":       wrongOrder{group.P256()},
+		"unreduced scalars": lenientScalars{group.P256()},
+	} {
+		if err := TestGroup(g, nil); err == nil {
+			t.Errorf("%s not found", name)
+		} else {
+			t.Log(err)
+		}
+	}
+}
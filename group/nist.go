@@ -0,0 +1,205 @@
+package group
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/safenum"
+)
+
+// curveGroup is the group of points of a prime order elliptic curve.
+type curveGroup struct {
+	curve   elliptic.Curve
+	byteLen int
+}
+
+var (
+	initonce sync.Once
+	p256     *curveGroup
+	p384     *curveGroup
+	p521     *curveGroup
+)
+
+func initAll() {
+	p256 = newCurveGroup(elliptic.P256())
+	p384 = newCurveGroup(elliptic.P384())
+	p521 = newCurveGroup(elliptic.P521())
+}
+
+func newCurveGroup(curve elliptic.Curve) *curveGroup {
+	return &curveGroup{curve: curve, byteLen: (curve.Params().BitSize + 7) / 8}
+}
+
+// P256 returns the group of points of the NIST P-256 curve.
+//
+// Elements are encoded in compressed form, as described in SEC 1, section 2.3.3,
+// and hashing to the group uses the P256_XMD:SHA-256_SSWU_RO_ suite of RFC 9380.
+func P256() Group {
+	initonce.Do(initAll)
+	return p256
+}
+
+// P384 returns the group of points of the NIST P-384 curve.
+//
+// Elements are encoded in compressed form, as described in SEC 1, section 2.3.3,
+// and hashing to the group uses the P384_XMD:SHA-384_SSWU_RO_ suite of RFC 9380.
+func P384() Group {
+	initonce.Do(initAll)
+	return p384
+}
+
+// P521 returns the group of points of the NIST P-521 curve.
+//
+// Elements are encoded in compressed form, as described in SEC 1, section 2.3.3,
+// and hashing to the group uses the P521_XMD:SHA-512_SSWU_RO_ suite of RFC 9380.
+func P521() Group {
+	initonce.Do(initAll)
+	return p521
+}
+
+func (g *curveGroup) Name() string {
+	return g.curve.Params().Name
+}
+
+func (g *curveGroup) Order() *safenum.Modulus {
+	return g.curve.Params().N
+}
+
+func (g *curveGroup) Identity() Element {
+	return &curvePoint{g: g, x: new(big.Int), y: new(big.Int)}
+}
+
+func (g *curveGroup) Generator() Element {
+	params := g.curve.Params()
+	return &curvePoint{g: g, x: new(big.Int).SetBytes(params.Gx.Bytes()), y: new(big.Int).SetBytes(params.Gy.Bytes())}
+}
+
+// scalarBytes returns the big-endian encoding of s, reduced modulo the order.
+func (g *curveGroup) scalarBytes(s *safenum.Nat) []byte {
+	out := new(safenum.Nat).Mod(s, g.Order()).Bytes()
+	// The encoding is padded to a whole number of limbs.
+	return out[len(out)-g.ScalarSize():]
+}
+
+func (g *curveGroup) ScalarBaseMult(s *safenum.Nat) Element {
+	x, y := g.curve.ScalarBaseMult(g.scalarBytes(s))
+	return &curvePoint{g: g, x: x, y: y}
+}
+
+func (g *curveGroup) RandomScalar(rand io.Reader) (*safenum.Nat, error) {
+	// Reducing 128 more bits than the size of the order makes the bias negligible.
+	buf := make([]byte, g.ScalarSize()+16)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), g.Order()), nil
+}
+
+func (g *curveGroup) HashToElement(msg, dst []byte) Element {
+	x, y := elliptic.HashToCurve(g.curve, msg, dst)
+	return &curvePoint{g: g, x: x, y: y}
+}
+
+func (g *curveGroup) HashToScalar(msg, dst []byte) *safenum.Nat {
+	return elliptic.HashToScalar(g.curve, msg, dst)
+}
+
+func (g *curveGroup) ElementSize() int {
+	return 1 + g.byteLen
+}
+
+func (g *curveGroup) DecodeElement(data []byte) (Element, error) {
+	x, y := elliptic.UnmarshalCompressed(g.curve, data)
+	if x == nil {
+		return nil, errors.New("group: invalid " + g.Name() + " element")
+	}
+	return &curvePoint{g: g, x: x, y: y}, nil
+}
+
+func (g *curveGroup) ScalarSize() int {
+	return int(g.Order().BitLen()+7) / 8
+}
+
+func (g *curveGroup) EncodeScalar(s *safenum.Nat) []byte {
+	return g.scalarBytes(s)
+}
+
+func (g *curveGroup) DecodeScalar(data []byte) (*safenum.Nat, error) {
+	if len(data) != g.ScalarSize() {
+		return nil, errors.New("group: invalid " + g.Name() + " scalar length")
+	}
+	// SetBytes can modify its argument, if it has spare capacity.
+	s := new(safenum.Nat).SetBytes(data[:len(data):len(data)])
+	if s.CmpMod(g.Order()) != -1 {
+		return nil, errors.New("group: " + g.Name() + " scalar is not reduced")
+	}
+	return s, nil
+}
+
+// curvePoint is a point on a curve, in affine coordinates, with the identity
+// represented as (0, 0), as in the elliptic package.
+type curvePoint struct {
+	g    *curveGroup
+	x, y *big.Int
+}
+
+func (p *curvePoint) other(b Element) *curvePoint {
+	q, ok := b.(*curvePoint)
+	if !ok || q.g != p.g {
+		panic("group: mismatched groups")
+	}
+	return q
+}
+
+func (p *curvePoint) Add(b Element) Element {
+	q := p.other(b)
+	x, y := p.g.curve.Add(p.x, p.y, q.x, q.y)
+	return &curvePoint{g: p.g, x: x, y: y}
+}
+
+func (p *curvePoint) Negate() Element {
+	if p.IsIdentity() {
+		return p
+	}
+	y := new(big.Int).SetBytes(p.g.curve.Params().P.Bytes())
+	y.Sub(y, p.y)
+	return &curvePoint{g: p.g, x: p.x, y: y}
+}
+
+func (p *curvePoint) ScalarMult(s *safenum.Nat) Element {
+	// Whether or not a point is the identity is public information.
+	if p.IsIdentity() {
+		return p
+	}
+	x, y := p.g.curve.ScalarMult(p.x, p.y, p.g.scalarBytes(s))
+	return &curvePoint{g: p.g, x: x, y: y}
+}
+
+func (p *curvePoint) Equal(b Element) int {
+	q := p.other(b)
+	byteLen := p.g.byteLen
+	pBytes := make([]byte, 2*byteLen)
+	qBytes := make([]byte, 2*byteLen)
+	p.x.FillBytes(pBytes[:byteLen])
+	p.y.FillBytes(pBytes[byteLen:])
+	q.x.FillBytes(qBytes[:byteLen])
+	q.y.FillBytes(qBytes[byteLen:])
+	return subtle.ConstantTimeCompare(pBytes, qBytes)
+}
+
+func (p *curvePoint) IsIdentity() bool {
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+// Bytes returns the compressed encoding of the point, or a single 0 byte for
+// the identity, as described in SEC 1, section 2.3.3.
+func (p *curvePoint) Bytes() []byte {
+	if p.IsIdentity() {
+		return []byte{0}
+	}
+	return elliptic.MarshalCompressed(p.g.curve, p.x, p.y)
+}
@@ -0,0 +1,150 @@
+package group
+
+import (
+	"crypto/sha512"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/safenum"
+)
+
+// ristrettoGroup is the ristretto255 group of RFC 9496.
+type ristrettoGroup struct{}
+
+var ristretto255 = &ristrettoGroup{}
+
+// Ristretto255 returns the ristretto255 group, defined in RFC 9496, which is a
+// prime order group built from Curve25519.
+//
+// Elements use the canonical 32 byte encoding of RFC 9496, and scalars are
+// encoded as 32 byte little-endian integers. Hashing to the group uses
+// expand_message_xmd with SHA-512, followed by the one-way map of RFC 9496,
+// which matches the ristretto255_XMD:SHA-512_R255MAP_RO_ suite of RFC 9380.
+func Ristretto255() Group {
+	return ristretto255
+}
+
+func (g *ristrettoGroup) Name() string {
+	return "ristretto255"
+}
+
+func (g *ristrettoGroup) Order() *safenum.Modulus {
+	return edwards25519.Order
+}
+
+func (g *ristrettoGroup) Identity() Element {
+	return &ristrettoElement{edwards25519.NewIdentityPoint()}
+}
+
+func (g *ristrettoGroup) Generator() Element {
+	return &ristrettoElement{edwards25519.NewGeneratorPoint()}
+}
+
+func (g *ristrettoGroup) ScalarBaseMult(s *safenum.Nat) Element {
+	return &ristrettoElement{new(edwards25519.Point).ScalarBaseMult(s)}
+}
+
+func (g *ristrettoGroup) RandomScalar(rand io.Reader) (*safenum.Nat, error) {
+	buf := make([]byte, 64)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return edwards25519.ScalarFromUniformBytes(buf)
+}
+
+// expand returns 64 bytes derived from a message with expand_message_xmd.
+func (g *ristrettoGroup) expand(msg, dst []byte) []byte {
+	uniform, err := elliptic.ExpandMessageXMD(sha512.New, msg, dst, 64)
+	if err != nil {
+		panic(err)
+	}
+	return uniform
+}
+
+func (g *ristrettoGroup) HashToElement(msg, dst []byte) Element {
+	p, err := new(edwards25519.Point).SetRistrettoUniformBytes(g.expand(msg, dst))
+	if err != nil {
+		panic(err)
+	}
+	return &ristrettoElement{p}
+}
+
+func (g *ristrettoGroup) HashToScalar(msg, dst []byte) *safenum.Nat {
+	s, err := edwards25519.ScalarFromUniformBytes(g.expand(msg, dst))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (g *ristrettoGroup) ElementSize() int {
+	return edwards25519.RistrettoSize
+}
+
+func (g *ristrettoGroup) DecodeElement(data []byte) (Element, error) {
+	p, err := new(edwards25519.Point).SetRistrettoBytes(data)
+	if err != nil {
+		return nil, errors.New("group: invalid ristretto255 element")
+	}
+	e := &ristrettoElement{p}
+	if e.IsIdentity() {
+		return nil, errors.New("group: invalid ristretto255 element")
+	}
+	return e, nil
+}
+
+func (g *ristrettoGroup) ScalarSize() int {
+	return edwards25519.ScalarSize
+}
+
+func (g *ristrettoGroup) EncodeScalar(s *safenum.Nat) []byte {
+	return edwards25519.ScalarBytes(s)
+}
+
+func (g *ristrettoGroup) DecodeScalar(data []byte) (*safenum.Nat, error) {
+	s, err := edwards25519.ScalarFromCanonicalBytes(data)
+	if err != nil {
+		return nil, errors.New("group: invalid ristretto255 scalar")
+	}
+	return s, nil
+}
+
+// ristrettoElement is a ristretto255 element, represented by one of the points
+// of its equivalence class.
+type ristrettoElement struct {
+	p *edwards25519.Point
+}
+
+func (e *ristrettoElement) other(b Element) *ristrettoElement {
+	f, ok := b.(*ristrettoElement)
+	if !ok {
+		panic("group: mismatched groups")
+	}
+	return f
+}
+
+func (e *ristrettoElement) Add(b Element) Element {
+	return &ristrettoElement{new(edwards25519.Point).Add(e.p, e.other(b).p)}
+}
+
+func (e *ristrettoElement) Negate() Element {
+	return &ristrettoElement{new(edwards25519.Point).Negate(e.p)}
+}
+
+func (e *ristrettoElement) ScalarMult(s *safenum.Nat) Element {
+	return &ristrettoElement{new(edwards25519.Point).ScalarMult(s, e.p)}
+}
+
+func (e *ristrettoElement) Equal(b Element) int {
+	return e.p.RistrettoEqual(e.other(b).p)
+}
+
+func (e *ristrettoElement) IsIdentity() bool {
+	return e.p.RistrettoEqual(edwards25519.NewIdentityPoint()) == 1
+}
+
+func (e *ristrettoElement) Bytes() []byte {
+	return e.p.RistrettoBytes()
+}
@@ -0,0 +1,97 @@
+// Package hkdf implements the HMAC-based key derivation function HKDF, as
+// specified in RFC 5869.
+//
+// HKDF extracts a pseudorandom key from a secret, like the output of a
+// Diffie-Hellman exchange, and then expands it into as many keys as needed.
+// It is built on the hmac package.
+package hkdf
+
+import (
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/hmac"
+)
+
+var errTooLong = errors.New("hkdf: requested length is too large")
+
+// Extract returns the pseudorandom key derived from a secret and a salt,
+// which is HMAC(salt, secret). An empty salt is replaced with a block of
+// zeros, as long as the output of the hash function.
+func Extract(h func() hash.Hash, secret, salt []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, h().Size())
+	}
+	extractor := hmac.New(h, salt)
+	extractor.Write(secret)
+	return extractor.Sum(nil)
+}
+
+type expander struct {
+	mac   hash.Hash
+	info  []byte
+	prev  []byte
+	buf   []byte
+	count byte
+}
+
+func (e *expander) Read(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if len(e.buf) == 0 {
+			if e.count == 255 {
+				return n - len(p), errTooLong
+			}
+			e.count++
+			e.mac.Reset()
+			e.mac.Write(e.prev)
+			e.mac.Write(e.info)
+			e.mac.Write([]byte{e.count})
+			e.prev = e.mac.Sum(e.prev[:0])
+			e.buf = e.prev
+		}
+		k := copy(p, e.buf)
+		p, e.buf = p[k:], e.buf[k:]
+	}
+	return n, nil
+}
+
+// Expand returns a reader of the keys derived from a pseudorandom key and
+// info, which identifies the use of the keys.
+//
+// At most 255 times the size of the hash can be read, after which Read
+// returns an error.
+func Expand(h func() hash.Hash, pseudorandomKey, info []byte) io.Reader {
+	return &expander{mac: hmac.New(h, pseudorandomKey), info: info}
+}
+
+// New returns a reader of the keys derived from a secret, a salt, and info,
+// combining Extract and Expand.
+func New(h func() hash.Hash, secret, salt, info []byte) io.Reader {
+	return Expand(h, Extract(h, secret, salt), info)
+}
+
+// Key returns length bytes derived from a secret, a salt, and info, with the
+// given hash function.
+func Key(h func() hash.Hash, secret, salt, info []byte, length int) ([]byte, error) {
+	if length > 255*h().Size() {
+		return nil, errTooLong
+	}
+	out := make([]byte, length)
+	if _, err := io.ReadFull(New(h, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeriveKey returns length bytes derived from a secret, a salt, and info,
+// with HKDF-SHA256.
+//
+// It is the usual way to turn the output of ECDH into symmetric keys: the
+// salt can be empty, and info should bind the keys to the protocol and to the
+// public keys involved.
+func DeriveKey(secret, salt, info []byte, length int) ([]byte, error) {
+	return Key(sha256.New, secret, salt, info, length)
+}
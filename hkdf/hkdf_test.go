@@ -0,0 +1,93 @@
+package hkdf
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	// See RFC 5869, appendix A, test cases 1, 3 and 4.
+	tests := []struct {
+		hash            func() hash.Hash
+		ikm, salt, info string
+		prk, okm        string
+	}{
+		{
+			sha256.New,
+			"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+			"000102030405060708090a0b0c",
+			"f0f1f2f3f4f5f6f7f8f9",
+			"077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+			"3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+		},
+		{
+			sha256.New,
+			"0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+			"",
+			"",
+			"19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+			"8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+		},
+		{
+			sha1.New,
+			"0b0b0b0b0b0b0b0b0b0b0b",
+			"000102030405060708090a0b0c",
+			"f0f1f2f3f4f5f6f7f8f9",
+			"9b6c18c432a7bf8f0e71c8eb88f4b30baa2ba243",
+			"085a01ea1b10f36933068b56efa5ad81a4f14b822f5b091568a9cdd4f155fda2c22e422478d305f3f896",
+		},
+	}
+	for i, test := range tests {
+		ikm, salt, info := mustHex(test.ikm), mustHex(test.salt), mustHex(test.info)
+		prk := Extract(test.hash, ikm, salt)
+		if !bytes.Equal(prk, mustHex(test.prk)) {
+			t.Errorf("test %d: Extract = %x, expected %s", i, prk, test.prk)
+		}
+		okm, err := Key(test.hash, ikm, salt, info, len(test.okm)/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(okm, mustHex(test.okm)) {
+			t.Errorf("test %d: Key = %x, expected %s", i, okm, test.okm)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	secret := []byte("a shared secret")
+	expected, _ := Key(sha256.New, secret, nil, []byte("info"), 42)
+	actual, err := DeriveKey(secret, nil, []byte("info"), 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("DeriveKey = %x, expected %x", actual, expected)
+	}
+	if _, err := DeriveKey(secret, nil, nil, 255*32+1); err == nil {
+		t.Error("DeriveKey accepted an overly long output")
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	r := Expand(sha256.New, make([]byte, 32), nil)
+	buf := make([]byte, 255*32)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(buf[:1]); err == nil {
+		t.Error("Read past the limit didn't fail")
+	}
+}
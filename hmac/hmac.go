@@ -0,0 +1,74 @@
+// Package hmac implements HMAC, as specified in RFC 2104 and FIPS 198-1.
+//
+// The API mirrors crypto/hmac. The key is only ever accessed in full, and
+// through the hash function, so no memory access depends on it, and Equal
+// takes the same time for tags of any contents, and of any length up to the
+// longest of the two.
+package hmac
+
+import (
+	"hash"
+
+	"github.com/cronokirby/ctcrypto/ctutil"
+)
+
+type hmac struct {
+	inner, outer hash.Hash
+	// ipad and opad are the key, padded to a block, and xored with the inner
+	// and outer pads.
+	ipad, opad []byte
+}
+
+// New returns a hash.Hash computing the HMAC of its input, with the given
+// hash function and key.
+func New(h func() hash.Hash, key []byte) hash.Hash {
+	m := &hmac{inner: h(), outer: h()}
+	blockSize := m.inner.BlockSize()
+	if len(key) > blockSize {
+		// Keys longer than a block are hashed first.
+		m.outer.Write(key)
+		key = m.outer.Sum(nil)
+		m.outer.Reset()
+	}
+	m.ipad = make([]byte, blockSize)
+	m.opad = make([]byte, blockSize)
+	copy(m.ipad, key)
+	copy(m.opad, key)
+	for i := range m.ipad {
+		m.ipad[i] ^= 0x36
+		m.opad[i] ^= 0x5c
+	}
+	m.inner.Write(m.ipad)
+	return m
+}
+
+func (m *hmac) Write(p []byte) (int, error) {
+	return m.inner.Write(p)
+}
+
+func (m *hmac) Sum(b []byte) []byte {
+	n := len(b)
+	b = m.inner.Sum(b)
+	m.outer.Reset()
+	m.outer.Write(m.opad)
+	m.outer.Write(b[n:])
+	return m.outer.Sum(b[:n])
+}
+
+func (m *hmac) Reset() {
+	m.inner.Reset()
+	m.inner.Write(m.ipad)
+}
+
+func (m *hmac) Size() int {
+	return m.outer.Size()
+}
+
+func (m *hmac) BlockSize() int {
+	return m.inner.BlockSize()
+}
+
+// Equal reports whether two MACs are equal, in constant time.
+func Equal(mac1, mac2 []byte) bool {
+	return ctutil.Equal(mac1, mac2) == 1
+}
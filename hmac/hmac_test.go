@@ -0,0 +1,70 @@
+package hmac
+
+import (
+	"bytes"
+	stdhmac "crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	// See RFC 4231, test cases 2 and 6.
+	tests := []struct {
+		key, data, mac string
+	}{
+		{
+			hex.EncodeToString([]byte("Jefe")),
+			hex.EncodeToString([]byte("what do ya want for nothing?")),
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			hex.EncodeToString(bytes.Repeat([]byte{0xaa}, 131)),
+			hex.EncodeToString([]byte("Test Using Larger Than Block-Size Key - Hash Key First")),
+			"60e431591ee0b67f0d8a26aacbf5b77f8e0bc6213728c5140546040f0ee37f54",
+		},
+	}
+	for _, test := range tests {
+		key, _ := hex.DecodeString(test.key)
+		data, _ := hex.DecodeString(test.data)
+		h := New(sha256.New, key)
+		h.Write(data)
+		if mac := hex.EncodeToString(h.Sum(nil)); mac != test.mac {
+			t.Errorf("HMAC-SHA256 = %s, expected %s", mac, test.mac)
+		}
+	}
+}
+
+func TestMatchesStandardLibrary(t *testing.T) {
+	for _, h := range []func() hash.Hash{sha1.New, sha256.New, sha512.New} {
+		for _, keyLen := range []int{0, 20, 64, 128, 200} {
+			key := bytes.Repeat([]byte{byte(keyLen)}, keyLen)
+			m := New(h, key)
+			std := stdhmac.New(h, key)
+			for i := 0; i < 3; i++ {
+				m.Write([]byte("some data"))
+				std.Write([]byte("some data"))
+				if !bytes.Equal(m.Sum([]byte("prefix")), std.Sum([]byte("prefix"))) {
+					t.Fatalf("key of %d bytes: the MACs differ", keyLen)
+				}
+			}
+			m.Reset()
+			std.Reset()
+			if !bytes.Equal(m.Sum(nil), std.Sum(nil)) {
+				t.Errorf("key of %d bytes: the MACs differ after Reset", keyLen)
+			}
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if !Equal([]byte{1, 2}, []byte{1, 2}) {
+		t.Error("Equal rejected equal MACs")
+	}
+	if Equal([]byte{1, 2}, []byte{1, 3}) || Equal([]byte{1, 2}, []byte{1, 2, 0}) {
+		t.Error("Equal accepted different MACs")
+	}
+}
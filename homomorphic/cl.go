@@ -0,0 +1,292 @@
+package homomorphic
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/classgroup"
+	"github.com/cronokirby/ctcrypto/rand"
+	"github.com/cronokirby/safenum"
+)
+
+// clStatisticalBits is the number of extra bits of the exponents used by CL
+// encryption, making their distribution statistically close to uniform modulo
+// the order of the class group.
+const clStatisticalBits = 128
+
+// CLCiphertext is a ciphertext of the Castagnos-Laguillaumie scheme, made of
+// the forms C1 = g_q^r and C2 = f^m * h^r.
+type CLCiphertext struct {
+	cl     *CLPublicKey
+	c1, c2 *classgroup.Form
+}
+
+// Bytes returns the encodings of C1 and C2, concatenated together.
+func (ct *CLCiphertext) Bytes() []byte {
+	return append(ct.cl.group.Bytes(ct.c1), ct.cl.group.Bytes(ct.c2)...)
+}
+
+// CLPublicKey is a public key for the encryption scheme of Castagnos and
+// Laguillaumie, in the variant of "Two-Party ECDSA from Hash Proof Systems
+// and Efficient Instantiations", with a message space of integers modulo a
+// prime q, such as the order of an elliptic curve.
+//
+// The scheme works in the class group of discriminant Δ_q = -q³ p, which has
+// a subgroup of order q, generated by f, in which discrete logarithms are easy.
+// Unlike Paillier, the message space is exactly the integers modulo q, so
+// protocols using the scheme don't need to prove that plaintexts are small.
+//
+// The class group arithmetic isn't constant-time, see package classgroup.
+type CLPublicKey struct {
+	q    *safenum.Modulus
+	qBig *big.Int
+	// the fundamental discriminant Δ_K = -q p
+	dK *big.Int
+	// the class group of discriminant Δ_q = q² Δ_K
+	group *classgroup.Group
+	// generator of a subgroup of q-th powers, and the public key h = g_q^x
+	gq, h *classgroup.Form
+	// the size of the random exponents, in bits
+	expBits int
+}
+
+// clPrime finds a prime p of the given size, such that q p ≡ 3 mod 4, and
+// q is not a square modulo p, as required by CL.
+func clPrime(random io.Reader, q *big.Int, bits int) (*big.Int, error) {
+	for {
+		p, err := rand.Prime(random, bits)
+		if err != nil {
+			return nil, err
+		}
+		qp := new(big.Int).Mul(q, p)
+		if qp.Bit(0) == 1 && qp.Bit(1) == 1 && big.Jacobi(q, p) == -1 {
+			return p, nil
+		}
+	}
+}
+
+// newCLPublicKey creates the public parameters of CL for Δ_K = -q p, leaving
+// the public key h empty.
+func newCLPublicKey(q *safenum.Modulus, p *big.Int) (*CLPublicKey, error) {
+	qBig := new(big.Int).SetBytes(q.Bytes())
+	dK := new(big.Int).Mul(qBig, p)
+	dK.Neg(dK)
+	dq := new(big.Int).Mul(qBig, qBig)
+	dq.Mul(dq, dK)
+	group, err := classgroup.NewGroup(dq)
+	if err != nil {
+		return nil, err
+	}
+	groupK, err := classgroup.NewGroup(dK)
+	if err != nil {
+		return nil, err
+	}
+
+	// g_q is the q-th power of the lift of the square of a small prime form
+	// of Cl(Δ_K) to Cl(Δ_q), which sends (a, b, c) to (a, b q, c q²).
+	var gq *classgroup.Form
+	for r := big.NewInt(3); gq == nil; r.Add(r, bigTwo) {
+		if !r.ProbablyPrime(20) || r.Cmp(qBig) == 0 {
+			continue
+		}
+		form, err := groupK.PrimeForm(r)
+		if err != nil {
+			continue
+		}
+		form = groupK.Square(form)
+		lift, err := group.NewForm(form.A(), new(big.Int).Mul(form.B(), qBig))
+		if err != nil {
+			return nil, err
+		}
+		gq = group.Exp(lift, qBig)
+	}
+
+	// The order of the class group is about sqrt(|Δ_K|) log |Δ_K|.
+	expBits := (dK.BitLen()+1)/2 + bitLen(dK.BitLen()) + clStatisticalBits
+	return &CLPublicKey{q: q, qBig: qBig, dK: dK, group: group, gq: gq, expBits: expBits}, nil
+}
+
+var bigTwo = big.NewInt(2)
+
+// bitLen returns the number of bits needed to represent x.
+func bitLen(x int) int {
+	return big.NewInt(int64(x)).BitLen()
+}
+
+// NewCLPublicKey creates a public key for messages modulo q, from the prime p
+// of the discriminant Δ_K = -q p, and the public key h, given in the encoding
+// of PublicKeyBytes.
+func NewCLPublicKey(q *safenum.Modulus, p *big.Int, h []byte) (*CLPublicKey, error) {
+	qBig := new(big.Int).SetBytes(q.Bytes())
+	qp := new(big.Int).Mul(qBig, p)
+	if !p.ProbablyPrime(20) || qp.Bit(0) != 1 || qp.Bit(1) != 1 || big.Jacobi(qBig, p) != -1 || p.Cmp(new(big.Int).Lsh(qBig, 2)) <= 0 {
+		return nil, errors.New("homomorphic: invalid CL parameters")
+	}
+	pk, err := newCLPublicKey(q, p)
+	if err != nil {
+		return nil, err
+	}
+	pk.h, err = pk.group.DecodeForm(h)
+	if err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// Prime returns the prime p of the discriminant Δ_K = -q p.
+func (pk *CLPublicKey) Prime() *big.Int {
+	p := new(big.Int).Neg(pk.dK)
+	return p.Quo(p, pk.qBig)
+}
+
+// PublicKeyBytes returns the encoding of the public key h.
+func (pk *CLPublicKey) PublicKeyBytes() []byte {
+	return pk.group.Bytes(pk.h)
+}
+
+func (pk *CLPublicKey) other(ct Ciphertext) *CLCiphertext {
+	out, ok := ct.(*CLCiphertext)
+	if !ok {
+		panic("homomorphic: ciphertext is not a CL ciphertext")
+	}
+	return out
+}
+
+// PlaintextModulus returns the prime q.
+func (pk *CLPublicKey) PlaintextModulus() *safenum.Modulus {
+	return pk.q
+}
+
+// fPow returns f^m, where f = (q², q, ·) generates the subgroup of order q.
+//
+// This is the form (q², L q, ·), where L is the odd representative of m^-1
+// modulo q in [-q, q].
+func (pk *CLPublicKey) fPow(m *safenum.Nat) *classgroup.Form {
+	m = new(safenum.Nat).Mod(m, pk.q)
+	if m.EqZero() {
+		return pk.group.Identity()
+	}
+	l := new(big.Int).SetBytes(new(safenum.Nat).ModInverse(m, pk.q).Bytes())
+	if l.Bit(0) == 0 {
+		l.Sub(l, pk.qBig)
+	}
+	f, err := pk.group.NewForm(new(big.Int).Mul(pk.qBig, pk.qBig), l.Mul(l, pk.qBig))
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// randomExponent returns a random exponent, which is statistically close to
+// uniform modulo the order of the class group.
+func (pk *CLPublicKey) randomExponent(rand io.Reader) (*big.Int, error) {
+	buf := make([]byte, (pk.expBits+7)/8)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// Encrypt encrypts m, which is interpreted modulo q.
+func (pk *CLPublicKey) Encrypt(rand io.Reader, m *safenum.Nat) (Ciphertext, error) {
+	r, err := pk.randomExponent(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &CLCiphertext{
+		cl: pk,
+		c1: pk.group.Exp(pk.gq, r),
+		c2: pk.group.Compose(pk.fPow(m), pk.group.Exp(pk.h, r)),
+	}, nil
+}
+
+// Add returns a ciphertext encrypting the sum of the plaintexts of a and b.
+func (pk *CLPublicKey) Add(a, b Ciphertext) Ciphertext {
+	ctA, ctB := pk.other(a), pk.other(b)
+	return &CLCiphertext{
+		cl: pk,
+		c1: pk.group.Compose(ctA.c1, ctB.c1),
+		c2: pk.group.Compose(ctA.c2, ctB.c2),
+	}
+}
+
+// ScalarMul returns a ciphertext encrypting the product of the plaintext of a
+// with k.
+func (pk *CLPublicKey) ScalarMul(a Ciphertext, k *safenum.Nat) Ciphertext {
+	ct := pk.other(a)
+	kBig := new(big.Int).SetBytes(new(safenum.Nat).Mod(k, pk.q).Bytes())
+	return &CLCiphertext{cl: pk, c1: pk.group.Exp(ct.c1, kBig), c2: pk.group.Exp(ct.c2, kBig)}
+}
+
+// DecodeCiphertext decodes a ciphertext produced by CLCiphertext.Bytes.
+func (pk *CLPublicKey) DecodeCiphertext(data []byte) (Ciphertext, error) {
+	size := pk.group.FormSize()
+	if len(data) != 2*size {
+		return nil, errors.New("homomorphic: invalid CL ciphertext")
+	}
+	c1, err := pk.group.DecodeForm(data[:size])
+	if err != nil {
+		return nil, err
+	}
+	c2, err := pk.group.DecodeForm(data[size:])
+	if err != nil {
+		return nil, err
+	}
+	return &CLCiphertext{cl: pk, c1: c1, c2: c2}, nil
+}
+
+// CLPrivateKey is a private key for the Castagnos-Laguillaumie scheme.
+type CLPrivateKey struct {
+	CLPublicKey
+	x *big.Int
+}
+
+// GenerateCLKey generates a private key for messages modulo the prime q, with
+// a fundamental discriminant of the given size, in bits.
+//
+// For 128 bits of security, the discriminant should have at least 1827 bits.
+func GenerateCLKey(q *safenum.Modulus, bits int, random io.Reader) (*CLPrivateKey, error) {
+	qBits := int(q.BitLen())
+	// p > 4 q makes the forms f^m reduced.
+	if bits < 2*qBits+3 {
+		return nil, errors.New("homomorphic: CL discriminant is too small")
+	}
+	p, err := clPrime(random, new(big.Int).SetBytes(q.Bytes()), bits-qBits)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := newCLPublicKey(q, p)
+	if err != nil {
+		return nil, err
+	}
+	x, err := pk.randomExponent(random)
+	if err != nil {
+		return nil, err
+	}
+	pk.h = pk.group.Exp(pk.gq, x)
+	return &CLPrivateKey{CLPublicKey: *pk, x: x}, nil
+}
+
+// Public returns the public key of sk.
+func (sk *CLPrivateKey) Public() PublicKey {
+	return &sk.CLPublicKey
+}
+
+// Decrypt recovers f^m = C2 / C1^x, and then m, from the coefficient b = L q
+// of this form.
+func (sk *CLPrivateKey) Decrypt(ct Ciphertext) (*safenum.Nat, error) {
+	c := sk.other(ct)
+	fm := sk.group.Compose(c.c2, sk.group.Inverse(sk.group.Exp(c.c1, sk.x)))
+	if fm.Equal(sk.group.Identity()) {
+		return new(safenum.Nat).Mod(new(safenum.Nat), sk.q), nil
+	}
+	q2 := new(big.Int).Mul(sk.qBig, sk.qBig)
+	l, rem := new(big.Int).QuoRem(fm.B(), sk.qBig, new(big.Int))
+	if fm.A().Cmp(q2) != 0 || rem.Sign() != 0 {
+		return nil, errors.New("homomorphic: invalid CL ciphertext")
+	}
+	l.Mod(l, sk.qBig)
+	lNat := new(safenum.Nat).SetBytes(l.Bytes())
+	return new(safenum.Nat).ModInverse(lNat, sk.q), nil
+}
@@ -0,0 +1,55 @@
+package homomorphic
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestCLRandomPlaintexts(t *testing.T) {
+	sk := keys(t)["CL"].(*CLPrivateKey)
+	g := group.P256()
+	for i := 0; i < 3; i++ {
+		m, _ := g.RandomScalar(rand.Reader)
+		ct, err := sk.Encrypt(rand.Reader, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := sk.Decrypt(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Cmp(m) != 0 {
+			t.Errorf("Decrypt(Encrypt(m)) != m")
+		}
+	}
+}
+
+func TestCLPublicKeyEncoding(t *testing.T) {
+	sk := keys(t)["CL"].(*CLPrivateKey)
+	q := group.P256().Order()
+	pk, err := NewCLPublicKey(q, sk.Prime(), sk.PublicKeyBytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, _ := group.P256().RandomScalar(rand.Reader)
+	ct, _ := pk.Encrypt(rand.Reader, m)
+	decoded, err := sk.DecodeCiphertext(ct.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, err := sk.Decrypt(decoded); err != nil || out.Cmp(m) != 0 {
+		t.Errorf("ciphertext under the decoded public key doesn't decrypt")
+	}
+	if _, err := NewCLPublicKey(q, new(big.Int).Add(sk.Prime(), big.NewInt(2)), sk.PublicKeyBytes()); err == nil {
+		t.Errorf("accepted invalid CL parameters")
+	}
+}
+
+func TestCLDiscriminantTooSmall(t *testing.T) {
+	if _, err := GenerateCLKey(group.P256().Order(), 512, rand.Reader); err == nil {
+		t.Errorf("accepted a discriminant smaller than q²")
+	}
+}
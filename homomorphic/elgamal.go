@@ -0,0 +1,193 @@
+package homomorphic
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// ElGamalCiphertext is a ciphertext of exponential ElGamal encryption, made
+// of the elements C1 = r * G and C2 = m * G + r * Y.
+type ElGamalCiphertext struct {
+	g      group.Group
+	c1, c2 group.Element
+}
+
+// encodeElement encodes an element, using zero bytes for the identity, so
+// that all encodings have the same size.
+func encodeElement(g group.Group, e group.Element) []byte {
+	if e.IsIdentity() {
+		return make([]byte, g.ElementSize())
+	}
+	return e.Bytes()
+}
+
+// Bytes returns the encodings of C1 and C2, concatenated together. The identity
+// is encoded as zero bytes.
+func (ct *ElGamalCiphertext) Bytes() []byte {
+	return append(encodeElement(ct.g, ct.c1), encodeElement(ct.g, ct.c2)...)
+}
+
+// ElGamalPublicKey is a public key for exponential ElGamal encryption, where
+// a message m is encrypted as the element m * G, so that ciphertexts can be
+// added together.
+type ElGamalPublicKey struct {
+	g group.Group
+	// Y = x * G
+	y group.Element
+	// the maximum size of plaintexts which can be decrypted, in bits
+	maxBits int
+}
+
+// NewElGamalPublicKey creates a public key from the element Y = x * G.
+//
+// Only messages smaller than 2^maxBits can be decrypted.
+func NewElGamalPublicKey(g group.Group, y group.Element, maxBits int) (*ElGamalPublicKey, error) {
+	if maxBits < 1 || maxBits > 48 {
+		return nil, errors.New("homomorphic: ElGamal plaintexts must have between 1 and 48 bits")
+	}
+	if y.IsIdentity() {
+		return nil, errors.New("homomorphic: invalid ElGamal public key")
+	}
+	return &ElGamalPublicKey{g: g, y: y, maxBits: maxBits}, nil
+}
+
+// Element returns the element Y of this public key.
+func (pk *ElGamalPublicKey) Element() group.Element {
+	return pk.y
+}
+
+func (pk *ElGamalPublicKey) other(ct Ciphertext) *ElGamalCiphertext {
+	out, ok := ct.(*ElGamalCiphertext)
+	if !ok {
+		panic("homomorphic: ciphertext is not an ElGamal ciphertext")
+	}
+	return out
+}
+
+// PlaintextModulus returns the order of the group.
+func (pk *ElGamalPublicKey) PlaintextModulus() *safenum.Modulus {
+	return pk.g.Order()
+}
+
+// Encrypt encrypts m, which should be smaller than 2^maxBits for the
+// ciphertext to be decryptable.
+func (pk *ElGamalPublicKey) Encrypt(rand io.Reader, m *safenum.Nat) (Ciphertext, error) {
+	r, err := pk.g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	m = new(safenum.Nat).Mod(m, pk.g.Order())
+	return &ElGamalCiphertext{
+		g:  pk.g,
+		c1: pk.g.ScalarBaseMult(r),
+		c2: pk.g.ScalarBaseMult(m).Add(pk.y.ScalarMult(r)),
+	}, nil
+}
+
+// Add returns a ciphertext encrypting the sum of the plaintexts of a and b.
+func (pk *ElGamalPublicKey) Add(a, b Ciphertext) Ciphertext {
+	ctA, ctB := pk.other(a), pk.other(b)
+	return &ElGamalCiphertext{g: pk.g, c1: ctA.c1.Add(ctB.c1), c2: ctA.c2.Add(ctB.c2)}
+}
+
+// ScalarMul returns a ciphertext encrypting the product of the plaintext of a
+// with k.
+func (pk *ElGamalPublicKey) ScalarMul(a Ciphertext, k *safenum.Nat) Ciphertext {
+	ct := pk.other(a)
+	k = new(safenum.Nat).Mod(k, pk.g.Order())
+	return &ElGamalCiphertext{g: pk.g, c1: ct.c1.ScalarMult(k), c2: ct.c2.ScalarMult(k)}
+}
+
+// decodeElement decodes an element produced by encodeElement.
+func decodeElement(g group.Group, data []byte) (group.Element, error) {
+	if bytes.Equal(data, make([]byte, len(data))) {
+		return g.Identity(), nil
+	}
+	return g.DecodeElement(data)
+}
+
+// DecodeCiphertext decodes a ciphertext produced by ElGamalCiphertext.Bytes.
+func (pk *ElGamalPublicKey) DecodeCiphertext(data []byte) (Ciphertext, error) {
+	size := pk.g.ElementSize()
+	if len(data) != 2*size {
+		return nil, errors.New("homomorphic: invalid ElGamal ciphertext")
+	}
+	c1, err := decodeElement(pk.g, data[:size])
+	if err != nil {
+		return nil, err
+	}
+	c2, err := decodeElement(pk.g, data[size:])
+	if err != nil {
+		return nil, err
+	}
+	return &ElGamalCiphertext{g: pk.g, c1: c1, c2: c2}, nil
+}
+
+// ElGamalPrivateKey is a private key for exponential ElGamal encryption.
+type ElGamalPrivateKey struct {
+	ElGamalPublicKey
+	x *safenum.Nat
+
+	// The table of baby steps used to decrypt is created lazily.
+	once  sync.Once
+	table map[string]uint64
+}
+
+// GenerateElGamalKey generates a private key, which can decrypt messages
+// smaller than 2^maxBits, reading randomness from rand.
+func GenerateElGamalKey(g group.Group, maxBits int, rand io.Reader) (*ElGamalPrivateKey, error) {
+	x, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	pk, err := NewElGamalPublicKey(g, g.ScalarBaseMult(x), maxBits)
+	if err != nil {
+		return nil, err
+	}
+	return &ElGamalPrivateKey{ElGamalPublicKey: *pk, x: x}, nil
+}
+
+// Public returns the public key of sk.
+func (sk *ElGamalPrivateKey) Public() PublicKey {
+	return &sk.ElGamalPublicKey
+}
+
+// babySteps returns the number of baby steps used to decrypt.
+func (sk *ElGamalPrivateKey) babySteps() uint64 {
+	return 1 << ((sk.maxBits + 1) / 2)
+}
+
+func (sk *ElGamalPrivateKey) initTable() {
+	steps := sk.babySteps()
+	sk.table = make(map[string]uint64, steps)
+	e := sk.g.Identity()
+	for j := uint64(0); j < steps; j++ {
+		sk.table[string(encodeElement(sk.g, e))] = j
+		e = e.Add(sk.g.Generator())
+	}
+}
+
+// Decrypt recovers m * G, and then m, using the baby-step giant-step algorithm.
+//
+// Unlike the other operations, the time this takes depends on the plaintext.
+// An error is returned if the plaintext is larger than 2^maxBits.
+func (sk *ElGamalPrivateKey) Decrypt(ct Ciphertext) (*safenum.Nat, error) {
+	c := sk.other(ct)
+	m := group.Sub(c.c2, c.c1.ScalarMult(sk.x))
+	sk.once.Do(sk.initTable)
+
+	steps := sk.babySteps()
+	giant := sk.g.ScalarBaseMult(new(safenum.Nat).SetUint64(steps)).Negate()
+	for i := uint64(0); i < uint64(1)<<sk.maxBits; i += steps {
+		if j, ok := sk.table[string(encodeElement(sk.g, m))]; ok && i+j < uint64(1)<<sk.maxBits {
+			return new(safenum.Nat).SetUint64(i + j), nil
+		}
+		m = m.Add(giant)
+	}
+	return nil, errors.New("homomorphic: ElGamal plaintext is too large to decrypt")
+}
@@ -0,0 +1,52 @@
+// Package homomorphic defines an interface for additively homomorphic
+// encryption schemes, so that protocols built on top of them, like the
+// multiplicative-to-additive conversions of threshold signatures, can be
+// written once, and used with any of the schemes.
+//
+// Two schemes are provided: Paillier encryption, from the paillier package,
+// and exponential ElGamal encryption over a group.Group.
+package homomorphic
+
+import (
+	"io"
+
+	"github.com/cronokirby/safenum"
+)
+
+// Ciphertext is an encrypted message.
+//
+// Ciphertexts are immutable, and operations on ciphertexts of a given
+// PublicKey will panic if passed ciphertexts from a different scheme.
+type Ciphertext interface {
+	// Bytes returns the encoding of the ciphertext.
+	Bytes() []byte
+}
+
+// PublicKey is the public key of an additively homomorphic encryption scheme,
+// used to encrypt messages, and operate on ciphertexts.
+type PublicKey interface {
+	// PlaintextModulus returns the modulus of the plaintexts, so that messages
+	// are integers modulo this value, and operations on them wrap around it.
+	PlaintextModulus() *safenum.Modulus
+	// Encrypt encrypts a message, reading randomness from rand.
+	Encrypt(rand io.Reader, m *safenum.Nat) (Ciphertext, error)
+	// Add returns a ciphertext encrypting the sum of the plaintexts of a and b.
+	Add(a, b Ciphertext) Ciphertext
+	// ScalarMul returns a ciphertext encrypting the product of the plaintext of
+	// a with k.
+	ScalarMul(a Ciphertext, k *safenum.Nat) Ciphertext
+	// DecodeCiphertext decodes a ciphertext produced by Ciphertext.Bytes,
+	// returning an error if the data is not a valid ciphertext.
+	DecodeCiphertext(data []byte) (Ciphertext, error)
+}
+
+// PrivateKey is the private key of an additively homomorphic encryption scheme.
+type PrivateKey interface {
+	// Public returns the public key associated with this private key.
+	Public() PublicKey
+	// Decrypt returns the plaintext of a ciphertext.
+	//
+	// An error is returned if the plaintext can't be recovered, which only
+	// happens for schemes restricting the size of the plaintexts.
+	Decrypt(ct Ciphertext) (*safenum.Nat, error)
+}
@@ -0,0 +1,107 @@
+package homomorphic
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/paillier"
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     map[string]PrivateKey
+)
+
+// keys returns a private key for each scheme, shared between the tests.
+func keys(t *testing.T) map[string]PrivateKey {
+	testKeysOnce.Do(func() {
+		p, err := paillier.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := GenerateElGamalKey(group.P256(), 16, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := GenerateCLKey(group.P256().Order(), 600, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testKeys = map[string]PrivateKey{"Paillier": PaillierPrivate(p), "ElGamal": e, "CL": c}
+	})
+	return testKeys
+}
+
+func TestHomomorphism(t *testing.T) {
+	for name, sk := range keys(t) {
+		pk := sk.Public()
+		m := pk.PlaintextModulus()
+		a := new(safenum.Nat).SetUint64(1234)
+		b := new(safenum.Nat).SetUint64(4321)
+		k := new(safenum.Nat).SetUint64(7)
+		ctA, err := pk.Encrypt(rand.Reader, a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctB, _ := pk.Encrypt(rand.Reader, b)
+
+		expected := new(safenum.Nat).ModAdd(a, new(safenum.Nat).ModMul(b, k, m), m)
+		out, err := sk.Decrypt(pk.Add(ctA, pk.ScalarMul(ctB, k)))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if out.Cmp(expected) != 0 {
+			t.Errorf("%s: Decrypt(a + k b) != a + k b", name)
+		}
+	}
+}
+
+func TestCiphertextEncoding(t *testing.T) {
+	for name, sk := range keys(t) {
+		pk := sk.Public()
+		zero := new(safenum.Nat)
+		for _, m := range []*safenum.Nat{zero, new(safenum.Nat).SetUint64(42)} {
+			ct, _ := pk.Encrypt(rand.Reader, m)
+			// Multiplying by 0 encrypts 0, with the identity as C1 for ElGamal.
+			for _, ct := range []Ciphertext{ct, pk.ScalarMul(ct, zero)} {
+				decoded, err := pk.DecodeCiphertext(ct.Bytes())
+				if err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				if !bytes.Equal(decoded.Bytes(), ct.Bytes()) {
+					t.Errorf("%s: ciphertext doesn't round trip", name)
+				}
+			}
+		}
+		if _, err := pk.DecodeCiphertext(nil); err == nil {
+			t.Errorf("%s: accepted an invalid ciphertext", name)
+		}
+	}
+}
+
+func TestElGamalPlaintextTooLarge(t *testing.T) {
+	sk := keys(t)["ElGamal"]
+	ct, _ := sk.Public().Encrypt(rand.Reader, new(safenum.Nat).SetUint64(1<<16))
+	if _, err := sk.Decrypt(ct); err == nil {
+		t.Errorf("decrypted a plaintext larger than the bound")
+	}
+	ct, _ = sk.Public().Encrypt(rand.Reader, new(safenum.Nat).SetUint64(1<<16-1))
+	if m, err := sk.Decrypt(ct); err != nil || m.Cmp(new(safenum.Nat).SetUint64(1<<16-1)) != 0 {
+		t.Errorf("failed to decrypt the largest plaintext")
+	}
+}
+
+func TestMixedCiphertextsPanic(t *testing.T) {
+	k := keys(t)
+	ct, _ := k["ElGamal"].Public().Encrypt(rand.Reader, new(safenum.Nat))
+	defer func() {
+		if recover() == nil {
+			t.Errorf("mixing ciphertexts didn't panic")
+		}
+	}()
+	k["Paillier"].Public().Add(ct, ct)
+}
@@ -0,0 +1,72 @@
+package homomorphic
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/paillier"
+	"github.com/cronokirby/safenum"
+)
+
+type paillierPublicKey struct {
+	pk *paillier.PublicKey
+}
+
+// Paillier returns the PublicKey of the Paillier cryptosystem for pk, whose
+// plaintexts are integers modulo N.
+func Paillier(pk *paillier.PublicKey) PublicKey {
+	return &paillierPublicKey{pk}
+}
+
+func (pk *paillierPublicKey) other(ct Ciphertext) *paillier.Ciphertext {
+	out, ok := ct.(*paillier.Ciphertext)
+	if !ok {
+		panic("homomorphic: ciphertext is not a Paillier ciphertext")
+	}
+	return out
+}
+
+func (pk *paillierPublicKey) PlaintextModulus() *safenum.Modulus {
+	return pk.pk.N()
+}
+
+func (pk *paillierPublicKey) Encrypt(rand io.Reader, m *safenum.Nat) (Ciphertext, error) {
+	ct, _, err := pk.pk.Encrypt(rand, m)
+	if err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+func (pk *paillierPublicKey) Add(a, b Ciphertext) Ciphertext {
+	return pk.pk.Add(pk.other(a), pk.other(b))
+}
+
+func (pk *paillierPublicKey) ScalarMul(a Ciphertext, k *safenum.Nat) Ciphertext {
+	return pk.pk.MulScalar(pk.other(a), k)
+}
+
+func (pk *paillierPublicKey) DecodeCiphertext(data []byte) (Ciphertext, error) {
+	ct, err := pk.pk.NewCiphertext(data)
+	if err != nil {
+		return nil, err
+	}
+	return ct, nil
+}
+
+type paillierPrivateKey struct {
+	paillierPublicKey
+	sk *paillier.PrivateKey
+}
+
+// PaillierPrivate returns the PrivateKey of the Paillier cryptosystem for sk.
+func PaillierPrivate(sk *paillier.PrivateKey) PrivateKey {
+	return &paillierPrivateKey{paillierPublicKey{&sk.PublicKey}, sk}
+}
+
+func (sk *paillierPrivateKey) Public() PublicKey {
+	return &sk.paillierPublicKey
+}
+
+func (sk *paillierPrivateKey) Decrypt(ct Ciphertext) (*safenum.Nat, error) {
+	return sk.sk.Decrypt(sk.other(ct)), nil
+}
@@ -0,0 +1,350 @@
+// Package hpke implements Hybrid Public Key Encryption, following RFC 9180.
+//
+// HPKE combines a key encapsulation mechanism, a key derivation function, and
+// an AEAD, to encrypt messages to the holder of a private key. The base, PSK,
+// auth, and auth-PSK modes are supported, along with the secret export
+// interface.
+//
+// The key encapsulation mechanisms are instances of DHKEM, over the curves of
+// the ecdh package. The long-term private keys of the receiver, and of the
+// sender in the auth modes, are ecdh.KeyExchangers, so they can be held by an
+// HSM or a KMS.
+package hpke
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/chacha20poly1305"
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/hkdf"
+)
+
+// KDF identifies a key derivation function, as listed in RFC 9180, section 7.2.
+type KDF uint16
+
+// The supported key derivation functions.
+const (
+	KDFHKDFSHA256 KDF = 0x0001
+	KDFHKDFSHA384 KDF = 0x0002
+	KDFHKDFSHA512 KDF = 0x0003
+)
+
+func (k KDF) hash() (func() hash.Hash, error) {
+	switch k {
+	case KDFHKDFSHA256:
+		return sha256.New, nil
+	case KDFHKDFSHA384:
+		return sha512.New384, nil
+	case KDFHKDFSHA512:
+		return sha512.New, nil
+	}
+	return nil, errors.New("hpke: unsupported KDF")
+}
+
+// AEAD identifies an authenticated encryption scheme, as listed in RFC 9180,
+// section 7.3.
+type AEAD uint16
+
+// The supported authenticated encryption schemes.
+//
+// With AEADExportOnly, messages can't be encrypted, and the context can only be
+// used to export secrets.
+const (
+	AEADAES128GCM        AEAD = 0x0001
+	AEADAES256GCM        AEAD = 0x0002
+	AEADChaCha20Poly1305 AEAD = 0x0003
+	AEADExportOnly       AEAD = 0xffff
+)
+
+// keySize returns the size of the keys of the scheme.
+func (a AEAD) keySize() (int, error) {
+	switch a {
+	case AEADAES128GCM:
+		return 16, nil
+	case AEADAES256GCM, AEADChaCha20Poly1305:
+		return 32, nil
+	case AEADExportOnly:
+		return 0, nil
+	}
+	return 0, errors.New("hpke: unsupported AEAD")
+}
+
+func (a AEAD) new(key []byte) (cipher.AEAD, error) {
+	switch a {
+	case AEADAES128GCM, AEADAES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case AEADChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	}
+	return nil, nil
+}
+
+// All of the supported schemes use 12 byte nonces.
+const nonceSize = 12
+
+// mode is the mode of HPKE, as listed in RFC 9180, section 5.
+type mode byte
+
+const (
+	modeBase    mode = 0x00
+	modePSK     mode = 0x01
+	modeAuth    mode = 0x02
+	modeAuthPSK mode = 0x03
+)
+
+var (
+	errWrongCurve   = errors.New("hpke: key doesn't match the KEM")
+	errPSK          = errors.New("hpke: the PSK and its identifier must both be provided")
+	errExportOnly   = errors.New("hpke: encryption is not available with AEADExportOnly")
+	errSeqOverflow  = errors.New("hpke: message limit reached")
+	errExportLength = errors.New("hpke: requested export length is too large")
+)
+
+func hkdfExtract(h func() hash.Hash, ikm, salt []byte) []byte {
+	return hkdf.Extract(h, ikm, salt)
+}
+
+func hkdfExpand(h func() hash.Hash, prk, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(h, prk, info), out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Suite is a ciphersuite, combining a KEM, a KDF, and an AEAD.
+type Suite struct {
+	KEM  KEM
+	KDF  KDF
+	AEAD AEAD
+}
+
+func (s Suite) id() []byte {
+	return []byte{
+		'H', 'P', 'K', 'E',
+		byte(s.KEM >> 8), byte(s.KEM),
+		byte(s.KDF >> 8), byte(s.KDF),
+		byte(s.AEAD >> 8), byte(s.AEAD),
+	}
+}
+
+// context is the state shared by the sender and the receiver.
+type context struct {
+	aead           cipher.AEAD
+	baseNonce      []byte
+	seq            uint64
+	exporterSecret []byte
+	kdf            labeledKDF
+}
+
+// keySchedule derives the encryption context from the shared secret, as
+// described in RFC 9180, section 5.1.
+func (s Suite) keySchedule(m mode, sharedSecret, info, psk, pskID []byte) (*context, error) {
+	if (len(psk) == 0) != (len(pskID) == 0) {
+		return nil, errPSK
+	}
+	usesPSK := m == modePSK || m == modeAuthPSK
+	if usesPSK != (len(psk) != 0) {
+		return nil, errPSK
+	}
+	h, err := s.KDF.hash()
+	if err != nil {
+		return nil, err
+	}
+	keySize, err := s.AEAD.keySize()
+	if err != nil {
+		return nil, err
+	}
+	kdf := labeledKDF{h, s.id()}
+	pskIDHash := kdf.extract(nil, "psk_id_hash", pskID)
+	infoHash := kdf.extract(nil, "info_hash", info)
+	keyScheduleContext := append([]byte{byte(m)}, pskIDHash...)
+	keyScheduleContext = append(keyScheduleContext, infoHash...)
+	secret := kdf.extract(sharedSecret, "secret", psk)
+
+	c := &context{
+		exporterSecret: kdf.expand(secret, "exp", keyScheduleContext, h().Size()),
+		kdf:            kdf,
+	}
+	if s.AEAD != AEADExportOnly {
+		key := kdf.expand(secret, "key", keyScheduleContext, keySize)
+		c.baseNonce = kdf.expand(secret, "base_nonce", keyScheduleContext, nonceSize)
+		if c.aead, err = s.AEAD.new(key); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// nonce returns the nonce for the current sequence number.
+func (c *context) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, errExportOnly
+	}
+	if c.seq == ^uint64(0) {
+		return nil, errSeqOverflow
+	}
+	nonce := append([]byte{}, c.baseNonce...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	for i := range seq {
+		nonce[nonceSize-8+i] ^= seq[i]
+	}
+	return nonce, nil
+}
+
+// Export derives a secret of a given length from the context, bound to
+// exporterContext, as described in RFC 9180, section 5.3.
+func (c *context) Export(exporterContext []byte, length int) ([]byte, error) {
+	if length > 255*len(c.exporterSecret) {
+		return nil, errExportLength
+	}
+	return c.kdf.expand(c.exporterSecret, "sec", exporterContext, length), nil
+}
+
+// Sender is the encryption context of the sender.
+type Sender struct {
+	context
+}
+
+// Seal encrypts and authenticates a message, along with some additional data.
+//
+// The messages must be opened by the receiver in the order in which they were
+// sealed.
+func (s *Sender) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := s.nonce()
+	if err != nil {
+		return nil, err
+	}
+	s.seq++
+	return s.aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Receiver is the encryption context of the receiver.
+type Receiver struct {
+	context
+}
+
+// Open decrypts and authenticates a message, along with some additional data.
+func (r *Receiver) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := r.nonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := r.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	// The sequence number is only advanced for valid messages.
+	r.seq++
+	return plaintext, nil
+}
+
+func (s Suite) setupS(m mode, pkR *ecdh.PublicKey, info, psk, pskID []byte, skS ecdh.KeyExchanger, rand io.Reader) ([]byte, *Sender, error) {
+	skE, err := s.KEM.GenerateKeyPair(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.setupSWithEphemeral(m, pkR, info, psk, pskID, skS, skE)
+}
+
+func (s Suite) setupSWithEphemeral(m mode, pkR *ecdh.PublicKey, info, psk, pskID []byte, skS ecdh.KeyExchanger, skE *ecdh.PrivateKey) ([]byte, *Sender, error) {
+	sharedSecret, enc, err := s.KEM.encap(pkR, skS, skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := s.keySchedule(m, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &Sender{*c}, nil
+}
+
+func (s Suite) setupR(m mode, enc []byte, skR ecdh.KeyExchanger, info, psk, pskID []byte, pkS *ecdh.PublicKey) (*Receiver, error) {
+	sharedSecret, err := s.KEM.decap(enc, skR, pkS)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.keySchedule(m, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return &Receiver{*c}, nil
+}
+
+// SetupBaseS creates the encryption context of a sender, to encrypt messages
+// to the holder of the private key for pkR. The returned encapsulated key must
+// be sent to the receiver, along with the messages.
+func (s Suite) SetupBaseS(pkR *ecdh.PublicKey, info []byte, rand io.Reader) (enc []byte, sender *Sender, err error) {
+	return s.setupS(modeBase, pkR, info, nil, nil, nil, rand)
+}
+
+// SetupBaseR creates the encryption context of a receiver, from the
+// encapsulated key sent by the sender.
+func (s Suite) SetupBaseR(enc []byte, skR ecdh.KeyExchanger, info []byte) (*Receiver, error) {
+	return s.setupR(modeBase, enc, skR, info, nil, nil, nil)
+}
+
+// SetupPSKS is like SetupBaseS, but also authenticates the sender as the holder
+// of a pre-shared key, with a given identifier.
+func (s Suite) SetupPSKS(pkR *ecdh.PublicKey, info, psk, pskID []byte, rand io.Reader) (enc []byte, sender *Sender, err error) {
+	return s.setupS(modePSK, pkR, info, psk, pskID, nil, rand)
+}
+
+// SetupPSKR is like SetupBaseR, with a pre-shared key.
+func (s Suite) SetupPSKR(enc []byte, skR ecdh.KeyExchanger, info, psk, pskID []byte) (*Receiver, error) {
+	return s.setupR(modePSK, enc, skR, info, psk, pskID, nil)
+}
+
+// SetupAuthS is like SetupBaseS, but also authenticates the sender as the
+// holder of the private key skS.
+func (s Suite) SetupAuthS(pkR *ecdh.PublicKey, info []byte, skS ecdh.KeyExchanger, rand io.Reader) (enc []byte, sender *Sender, err error) {
+	return s.setupS(modeAuth, pkR, info, nil, nil, skS, rand)
+}
+
+// SetupAuthR is like SetupBaseR, authenticating the sender as the holder of
+// the private key for pkS.
+func (s Suite) SetupAuthR(enc []byte, skR ecdh.KeyExchanger, info []byte, pkS *ecdh.PublicKey) (*Receiver, error) {
+	return s.setupR(modeAuth, enc, skR, info, nil, nil, pkS)
+}
+
+// SetupAuthPSKS combines SetupAuthS and SetupPSKS.
+func (s Suite) SetupAuthPSKS(pkR *ecdh.PublicKey, info, psk, pskID []byte, skS ecdh.KeyExchanger, rand io.Reader) (enc []byte, sender *Sender, err error) {
+	return s.setupS(modeAuthPSK, pkR, info, psk, pskID, skS, rand)
+}
+
+// SetupAuthPSKR combines SetupAuthR and SetupPSKR.
+func (s Suite) SetupAuthPSKR(enc []byte, skR ecdh.KeyExchanger, info, psk, pskID []byte, pkS *ecdh.PublicKey) (*Receiver, error) {
+	return s.setupR(modeAuthPSK, enc, skR, info, psk, pskID, pkS)
+}
+
+// Seal encrypts a single message to the holder of the private key for pkR, in
+// the base mode, returning the encapsulated key and the ciphertext.
+func (s Suite) Seal(pkR *ecdh.PublicKey, info, aad, plaintext []byte, rand io.Reader) (enc, ciphertext []byte, err error) {
+	enc, sender, err := s.SetupBaseS(pkR, info, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err = sender.Seal(aad, plaintext)
+	return enc, ciphertext, err
+}
+
+// Open decrypts a single message produced by Seal.
+func (s Suite) Open(enc []byte, skR ecdh.KeyExchanger, info, aad, ciphertext []byte) ([]byte, error) {
+	receiver, err := s.SetupBaseR(enc, skR, info)
+	if err != nil {
+		return nil, err
+	}
+	return receiver.Open(aad, ciphertext)
+}
@@ -0,0 +1,299 @@
+package hpke
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+func fromHex(s string) []byte {
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+type vector struct {
+	suite          Suite
+	ikmE, ikmR     string
+	skEm, skRm     string
+	sharedSecret   string
+	key, baseNonce string
+	exporterSecret string
+	ciphertext     string
+}
+
+var vectors = []vector{
+	// See RFC 9180, appendix A.1.1.
+	{
+		suite:          Suite{KEMX25519HKDFSHA256, KDFHKDFSHA256, AEADAES128GCM},
+		ikmE:           "7268600d403fce431561aef583ee1613527cff655c1343f29812e66706df3234",
+		ikmR:           "6db9df30aa07dd42ee5e8181afdb977e538f5e1fec8a06223f33f7013e525037",
+		skEm:           "52c4a758a802cd8b936eceea314432798d5baf2d7e9235dc084ab1b9cfa2f736",
+		skRm:           "4612c550263fc8ad58375df3f557aac531d26850903e55a9f23f21d8534e8ac8",
+		sharedSecret:   "fe0e18c9f024ce43799ae393c7e8fe8fce9d218875e8227b0187c04e7d2ea1fc",
+		key:            "4531685d41d65f03dc48f6b8302c05b0",
+		baseNonce:      "56d890e5accaaf011cff4b7d",
+		exporterSecret: "45ff1c2e220db587171952c0592d5f5ebe103f1561a2614e38f2ffd47e99e3f8",
+		ciphertext:     "f938558b5d72f1a23810b4be2ab4f84331acc02fc97babc53a52ae8218a355a96d8770ac83d07bea87e13c512a",
+	},
+	// See RFC 9180, appendix A.3.1.
+	{
+		suite:        Suite{KEMP256HKDFSHA256, KDFHKDFSHA256, AEADAES128GCM},
+		ikmE:         "4270e54ffd08d79d5928020af4686d8f6b7d35dbe470265f1f5aa22816ce860e",
+		ikmR:         "668b37171f1072f3cf12ea8a236a45df23fc13b82af3609ad1e354f6ef817550",
+		skEm:         "4995788ef4b9d6132b249ce59a77281493eb39af373d236a1fe415cb0c2d7beb",
+		skRm:         "f3ce7fdae57e1a310d87f1ebbde6f328be0a99cdbcadf4d6589cf29de4b8ffd2",
+		sharedSecret: "c0d26aeab536609a572b07695d933b589dcf363ff9d93c93adea537aeabb8cb8",
+		key:          "868c066ef58aae6dc589b6cfdd18f97e",
+		baseNonce:    "4e0bc5018beba4bf004cca59",
+		ciphertext:   "5ad590bb8baa577f8619db35a36311226a896e7342a6d836d8b7bcd2f20b6c7f9076ac232e3ab2523f39513434",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	info := []byte("Ode on a Grecian Urn")
+	for _, v := range vectors {
+		skE, err := v.suite.KEM.DeriveKeyPair(fromHex(v.ikmE))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(skE.Bytes()) != v.skEm {
+			t.Errorf("%04x: skEm = %x, expected %s", v.suite.KEM, skE.Bytes(), v.skEm)
+		}
+		skR, err := v.suite.KEM.DeriveKeyPair(fromHex(v.ikmR))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(skR.Bytes()) != v.skRm {
+			t.Errorf("%04x: skRm = %x, expected %s", v.suite.KEM, skR.Bytes(), v.skRm)
+		}
+		sharedSecret, enc, err := v.suite.KEM.encap(skR.PublicKey(), nil, skE)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(sharedSecret) != v.sharedSecret {
+			t.Errorf("%04x: shared secret = %x, expected %s", v.suite.KEM, sharedSecret, v.sharedSecret)
+		}
+		c, err := v.suite.keySchedule(modeBase, sharedSecret, info, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(c.baseNonce) != v.baseNonce {
+			t.Errorf("%04x: base nonce = %x, expected %s", v.suite.KEM, c.baseNonce, v.baseNonce)
+		}
+		if v.exporterSecret != "" && hex.EncodeToString(c.exporterSecret) != v.exporterSecret {
+			t.Errorf("%04x: exporter secret = %x, expected %s", v.suite.KEM, c.exporterSecret, v.exporterSecret)
+		}
+		sender := &Sender{*c}
+		ct, err := sender.Seal([]byte("Count-0"), []byte("Beauty is truth, truth beauty"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(ct) != v.ciphertext {
+			t.Errorf("%04x: ciphertext = %x, expected %s", v.suite.KEM, ct, v.ciphertext)
+		}
+		receiver, err := v.suite.SetupBaseR(enc, skR, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, err := receiver.Open([]byte("Count-0"), ct)
+		if err != nil || string(pt) != "Beauty is truth, truth beauty" {
+			t.Errorf("%04x: failed to open the ciphertext", v.suite.KEM)
+		}
+	}
+}
+
+var suites = []Suite{
+	{KEMP256HKDFSHA256, KDFHKDFSHA256, AEADAES128GCM},
+	{KEMP384HKDFSHA384, KDFHKDFSHA384, AEADAES256GCM},
+	{KEMP521HKDFSHA512, KDFHKDFSHA512, AEADAES256GCM},
+	{KEMX25519HKDFSHA256, KDFHKDFSHA256, AEADChaCha20Poly1305},
+}
+
+func TestModes(t *testing.T) {
+	info := []byte("info")
+	psk := []byte("a pre-shared key with enough entropy")
+	pskID := []byte("psk id")
+	for _, s := range suites {
+		skR, err := s.KEM.GenerateKeyPair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		skS, _ := s.KEM.GenerateKeyPair(rand.Reader)
+		pkR, pkS := skR.PublicKey(), skS.PublicKey()
+
+		type setup func() (*Sender, *Receiver, error)
+		modes := map[string]setup{
+			"base": func() (*Sender, *Receiver, error) {
+				enc, sender, err := s.SetupBaseS(pkR, info, rand.Reader)
+				if err != nil {
+					return nil, nil, err
+				}
+				receiver, err := s.SetupBaseR(enc, skR, info)
+				return sender, receiver, err
+			},
+			"psk": func() (*Sender, *Receiver, error) {
+				enc, sender, err := s.SetupPSKS(pkR, info, psk, pskID, rand.Reader)
+				if err != nil {
+					return nil, nil, err
+				}
+				receiver, err := s.SetupPSKR(enc, skR, info, psk, pskID)
+				return sender, receiver, err
+			},
+			"auth": func() (*Sender, *Receiver, error) {
+				enc, sender, err := s.SetupAuthS(pkR, info, skS, rand.Reader)
+				if err != nil {
+					return nil, nil, err
+				}
+				receiver, err := s.SetupAuthR(enc, skR, info, pkS)
+				return sender, receiver, err
+			},
+			"authpsk": func() (*Sender, *Receiver, error) {
+				enc, sender, err := s.SetupAuthPSKS(pkR, info, psk, pskID, skS, rand.Reader)
+				if err != nil {
+					return nil, nil, err
+				}
+				receiver, err := s.SetupAuthPSKR(enc, skR, info, psk, pskID, pkS)
+				return sender, receiver, err
+			},
+		}
+		for name, f := range modes {
+			sender, receiver, err := f()
+			if err != nil {
+				t.Fatalf("%04x %s: %v", s.KEM, name, err)
+			}
+			for i := 0; i < 3; i++ {
+				ct, err := sender.Seal([]byte("aad"), []byte("message"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				pt, err := receiver.Open([]byte("aad"), ct)
+				if err != nil || string(pt) != "message" {
+					t.Errorf("%04x %s: message %d didn't round trip", s.KEM, name, i)
+				}
+			}
+			e0, _ := sender.Export([]byte("context"), 32)
+			e1, _ := receiver.Export([]byte("context"), 32)
+			if !bytes.Equal(e0, e1) {
+				t.Errorf("%04x %s: exported secrets differ", s.KEM, name)
+			}
+		}
+	}
+}
+
+// remoteKey stands for a key held outside of the process, which can only be
+// used through ECDH.
+type remoteKey struct {
+	sk    *ecdh.PrivateKey
+	calls int
+}
+
+func (k *remoteKey) Curve() ecdh.Curve          { return k.sk.Curve() }
+func (k *remoteKey) PublicKey() *ecdh.PublicKey { return k.sk.PublicKey() }
+
+func (k *remoteKey) ECDH(remote *ecdh.PublicKey) ([]byte, error) {
+	k.calls++
+	return k.sk.ECDH(remote)
+}
+
+func TestRemoteKeys(t *testing.T) {
+	s := suites[0]
+	skR, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	skS, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	remoteR, remoteS := &remoteKey{sk: skR}, &remoteKey{sk: skS}
+	enc, sender, err := s.SetupAuthS(skR.PublicKey(), nil, remoteS, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, _ := sender.Seal(nil, []byte("message"))
+	receiver, err := s.SetupAuthR(enc, remoteR, nil, skS.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := receiver.Open(nil, ct)
+	if err != nil || string(pt) != "message" {
+		t.Errorf("Open returned %q, %v", pt, err)
+	}
+	if remoteS.calls != 1 || remoteR.calls != 2 {
+		t.Errorf("remote keys used %d and %d times, expected 1 and 2", remoteS.calls, remoteR.calls)
+	}
+}
+
+func TestAuthWrongSender(t *testing.T) {
+	s := suites[0]
+	skR, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	skS, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	other, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	enc, sender, err := s.SetupAuthS(skR.PublicKey(), nil, skS, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, _ := sender.Seal(nil, []byte("message"))
+	receiver, err := s.SetupAuthR(enc, skR, nil, other.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := receiver.Open(nil, ct); err == nil {
+		t.Errorf("expected an error when authenticating the wrong sender")
+	}
+}
+
+func TestPSKInputs(t *testing.T) {
+	s := suites[0]
+	skR, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	if _, _, err := s.SetupPSKS(skR.PublicKey(), nil, []byte("psk"), nil, rand.Reader); err == nil {
+		t.Errorf("expected an error for a PSK without identifier")
+	}
+	if _, _, err := s.SetupPSKS(skR.PublicKey(), nil, nil, nil, rand.Reader); err == nil {
+		t.Errorf("expected an error for a missing PSK")
+	}
+}
+
+func TestSingleShot(t *testing.T) {
+	for _, s := range suites {
+		skR, _ := s.KEM.GenerateKeyPair(rand.Reader)
+		enc, ct, err := s.Seal(skR.PublicKey(), []byte("info"), []byte("aad"), []byte("message"), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pt, err := s.Open(enc, skR, []byte("info"), []byte("aad"), ct)
+		if err != nil || string(pt) != "message" {
+			t.Errorf("%04x: message didn't round trip", s.KEM)
+		}
+		ct[0] ^= 1
+		if _, err := s.Open(enc, skR, []byte("info"), []byte("aad"), ct); err == nil {
+			t.Errorf("%04x: tampered ciphertext was opened", s.KEM)
+		}
+	}
+}
+
+func TestExportOnly(t *testing.T) {
+	s := Suite{KEMX25519HKDFSHA256, KDFHKDFSHA256, AEADExportOnly}
+	skR, _ := s.KEM.GenerateKeyPair(rand.Reader)
+	enc, sender, err := s.SetupBaseS(skR.PublicKey(), nil, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sender.Seal(nil, []byte("message")); err == nil {
+		t.Errorf("expected an error when sealing with AEADExportOnly")
+	}
+	receiver, _ := s.SetupBaseR(enc, skR, nil)
+	e0, _ := sender.Export(nil, 64)
+	e1, _ := receiver.Export(nil, 64)
+	if !bytes.Equal(e0, e1) {
+		t.Errorf("exported secrets differ")
+	}
+}
+
+func TestWrongCurve(t *testing.T) {
+	s := suites[0]
+	skR, _ := KEMX25519HKDFSHA256.GenerateKeyPair(rand.Reader)
+	if _, _, err := s.SetupBaseS(skR.PublicKey(), nil, rand.Reader); err == nil {
+		t.Errorf("expected an error for a key on the wrong curve")
+	}
+}
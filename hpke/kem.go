@@ -0,0 +1,184 @@
+package hpke
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+// KEM identifies a key encapsulation mechanism, as listed in RFC 9180, section 7.1.
+type KEM uint16
+
+// The supported key encapsulation mechanisms, all of which are instances of DHKEM.
+const (
+	KEMP256HKDFSHA256   KEM = 0x0010
+	KEMP384HKDFSHA384   KEM = 0x0011
+	KEMP521HKDFSHA512   KEM = 0x0012
+	KEMX25519HKDFSHA256 KEM = 0x0020
+)
+
+// dhkem contains the parameters of an instance of DHKEM.
+type dhkem struct {
+	curve ecdh.Curve
+	hash  func() hash.Hash
+	// the size of the shared secret
+	secretSize int
+	// the size of an encoded private key
+	privateKeySize int
+	// the mask applied to the first byte of candidate private keys
+	bitmask byte
+}
+
+func (k KEM) params() (*dhkem, error) {
+	switch k {
+	case KEMP256HKDFSHA256:
+		return &dhkem{ecdh.P256(), sha256.New, 32, 32, 0xff}, nil
+	case KEMP384HKDFSHA384:
+		return &dhkem{ecdh.P384(), sha512.New384, 48, 48, 0xff}, nil
+	case KEMP521HKDFSHA512:
+		return &dhkem{ecdh.P521(), sha512.New, 64, 66, 0x01}, nil
+	case KEMX25519HKDFSHA256:
+		return &dhkem{ecdh.X25519(), sha256.New, 32, 32, 0}, nil
+	}
+	return nil, errors.New("hpke: unsupported KEM")
+}
+
+func (k KEM) suiteID() []byte {
+	return []byte{'K', 'E', 'M', byte(k >> 8), byte(k)}
+}
+
+// Curve returns the curve used by this KEM, which keys must belong to.
+func (k KEM) Curve() (ecdh.Curve, error) {
+	p, err := k.params()
+	if err != nil {
+		return nil, err
+	}
+	return p.curve, nil
+}
+
+// GenerateKeyPair generates a key pair for this KEM, reading randomness from rand.
+func (k KEM) GenerateKeyPair(rand io.Reader) (*ecdh.PrivateKey, error) {
+	p, err := k.params()
+	if err != nil {
+		return nil, err
+	}
+	return p.curve.GenerateKey(rand)
+}
+
+// DeriveKeyPair deterministically derives a key pair for this KEM from some
+// input keying material, as described in RFC 9180, section 7.1.3.
+//
+// The input keying material must have at least as much entropy as the private
+// keys of the KEM.
+func (k KEM) DeriveKeyPair(ikm []byte) (*ecdh.PrivateKey, error) {
+	p, err := k.params()
+	if err != nil {
+		return nil, err
+	}
+	kdf := labeledKDF{p.hash, k.suiteID()}
+	dkpPrk := kdf.extract(nil, "dkp_prk", ikm)
+	if k == KEMX25519HKDFSHA256 {
+		return p.curve.NewPrivateKey(kdf.expand(dkpPrk, "sk", nil, p.privateKeySize))
+	}
+	for counter := 0; counter < 256; counter++ {
+		candidate := kdf.expand(dkpPrk, "candidate", []byte{byte(counter)}, p.privateKeySize)
+		candidate[0] &= p.bitmask
+		if sk, err := p.curve.NewPrivateKey(candidate); err == nil {
+			return sk, nil
+		}
+	}
+	return nil, errors.New("hpke: failed to derive a key pair")
+}
+
+// extractAndExpand derives the shared secret from the output of the
+// Diffie-Hellman exchanges, and the context of the KEM.
+func (p *dhkem) extractAndExpand(suiteID []byte, dh, kemContext []byte) []byte {
+	kdf := labeledKDF{p.hash, suiteID}
+	eaePrk := kdf.extract(nil, "eae_prk", dh)
+	return kdf.expand(eaePrk, "shared_secret", kemContext, p.secretSize)
+}
+
+// encap implements Encap, or AuthEncap when skS is not nil, with a given
+// ephemeral key.
+func (k KEM) encap(pkR *ecdh.PublicKey, skS ecdh.KeyExchanger, skE *ecdh.PrivateKey) (sharedSecret, enc []byte, err error) {
+	p, err := k.params()
+	if err != nil {
+		return nil, nil, err
+	}
+	if pkR.Curve() != p.curve || (skS != nil && skS.Curve() != p.curve) {
+		return nil, nil, errWrongCurve
+	}
+	dh, err := skE.ECDH(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc = skE.PublicKey().Bytes()
+	kemContext := append(append([]byte{}, enc...), pkR.Bytes()...)
+	if skS != nil {
+		dhS, err := skS.ECDH(pkR)
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, skS.PublicKey().Bytes()...)
+	}
+	return p.extractAndExpand(k.suiteID(), dh, kemContext), enc, nil
+}
+
+// decap implements Decap, or AuthDecap when pkS is not nil.
+func (k KEM) decap(enc []byte, skR ecdh.KeyExchanger, pkS *ecdh.PublicKey) ([]byte, error) {
+	p, err := k.params()
+	if err != nil {
+		return nil, err
+	}
+	if skR.Curve() != p.curve || (pkS != nil && pkS.Curve() != p.curve) {
+		return nil, errWrongCurve
+	}
+	pkE, err := p.curve.NewPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := skR.ECDH(pkE)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), skR.PublicKey().Bytes()...)
+	if pkS != nil {
+		dhS, err := skR.ECDH(pkS)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS.Bytes()...)
+	}
+	return p.extractAndExpand(k.suiteID(), dh, kemContext), nil
+}
+
+// labeledKDF implements LabeledExtract and LabeledExpand, as defined in
+// RFC 9180, section 4, with HKDF over a given hash function.
+type labeledKDF struct {
+	hash    func() hash.Hash
+	suiteID []byte
+}
+
+func (k labeledKDF) extract(salt []byte, label string, ikm []byte) []byte {
+	labeled := append([]byte("HPKE-v1"), k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdfExtract(k.hash, labeled, salt)
+}
+
+func (k labeledKDF) expand(prk []byte, label string, info []byte, length int) []byte {
+	labeled := make([]byte, 2, 2+7+len(k.suiteID)+len(label)+len(info))
+	binary.BigEndian.PutUint16(labeled, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return hkdfExpand(k.hash, prk, labeled, length)
+}
@@ -0,0 +1,98 @@
+// Package instrument lets operators observe the expensive operations of this
+// module, to feed counters and latency histograms, or traces, without wrapping
+// every call site.
+//
+// The operations reported are scalar multiplications in the elliptic package,
+// and in the backends generated by curvegen, along with signatures,
+// verifications, and key generation in the ecdsa and rsa packages. Nested
+// operations, like the verification of a signature by ecdsa.Sign, with
+// ecdsa.SetVerifyAfterSign, are each reported on their own.
+//
+// No hooks are installed by default, and each operation then only costs an
+// atomic load. The hooks are called synchronously, from the goroutine doing
+// the operation, and must be safe for concurrent use, and fast.
+package instrument
+
+import (
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Op is a kind of operation.
+type Op int
+
+const (
+	// ScalarMult is a scalar multiplication of a point, or of the base point,
+	// whose algorithm is the name of the curve, like "P-256".
+	ScalarMult Op = iota
+	// Sign is the computation of a signature, whose algorithm is the name of
+	// the curve for ECDSA, or "RSA PKCS #1 v1.5" or "RSA-PSS".
+	Sign
+	// Verify is the verification of a signature, named like Sign.
+	Verify
+	// GenerateKey is the generation of a key pair, whose algorithm is the name
+	// of the curve for ECDSA, or "RSA".
+	GenerateKey
+)
+
+func (op Op) String() string {
+	switch op {
+	case ScalarMult:
+		return "ScalarMult"
+	case Sign:
+		return "Sign"
+	case Verify:
+		return "Verify"
+	case GenerateKey:
+		return "GenerateKey"
+	}
+	return "Op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// Hooks are the callbacks receiving the operations. Either can be nil.
+type Hooks struct {
+	// Start is called when an operation starts.
+	Start func(op Op, algorithm string)
+	// Done is called when an operation returns, with the time it took.
+	Done func(op Op, algorithm string, d time.Duration)
+}
+
+// hooks holds the installed *Hooks, which is nil if there are none.
+var hooks atomic.Value
+
+// SetHooks installs h, replacing the previous hooks. SetHooks(Hooks{}) removes
+// them.
+func SetHooks(h Hooks) {
+	if h.Start == nil && h.Done == nil {
+		hooks.Store((*Hooks)(nil))
+		return
+	}
+	hooks.Store(&h)
+}
+
+func nop() {}
+
+// Begin reports the start of an operation to the hooks, and returns a function
+// reporting its end, meant to be deferred:
+//
+//	defer instrument.Begin(instrument.Sign, name)()
+//
+// algorithm should not be built for the occasion, since that would cost an
+// allocation even without hooks.
+func Begin(op Op, algorithm string) (end func()) {
+	h, _ := hooks.Load().(*Hooks)
+	if h == nil {
+		return nop
+	}
+	if h.Start != nil {
+		h.Start(op, algorithm)
+	}
+	if h.Done == nil {
+		return nop
+	}
+	start := time.Now()
+	return func() {
+		h.Done(op, algorithm, time.Since(start))
+	}
+}
@@ -0,0 +1,128 @@
+package instrument_test
+
+import (
+	"crypto"
+	stdelliptic "crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/instrument"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+// recorder counts the operations reported to its hooks.
+type recorder struct {
+	mu      sync.Mutex
+	started map[string]int
+	done    map[string]int
+}
+
+func record(t *testing.T) *recorder {
+	r := &recorder{started: map[string]int{}, done: map[string]int{}}
+	instrument.SetHooks(instrument.Hooks{
+		Start: func(op instrument.Op, algorithm string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.started[op.String()+" "+algorithm]++
+		},
+		Done: func(op instrument.Op, algorithm string, d time.Duration) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if d < 0 {
+				t.Errorf("%v %s took %v", op, algorithm, d)
+			}
+			r.done[op.String()+" "+algorithm]++
+		},
+	})
+	t.Cleanup(func() { instrument.SetHooks(instrument.Hooks{}) })
+	return r
+}
+
+func (r *recorder) check(t *testing.T, event string, min int) {
+	t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started[event] < min || r.done[event] != r.started[event] {
+		t.Errorf("%s: %d started, %d done, expected at least %d", event, r.started[event], r.done[event], min)
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	r := record(t)
+	priv, err := ecdsa.GenerateKey(stdelliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.VerifyASN1(&priv.PublicKey, digest[:], sig) {
+		t.Fatal("signature rejected")
+	}
+	r.check(t, "GenerateKey P-256", 1)
+	r.check(t, "Sign P-256", 1)
+	r.check(t, "Verify P-256", 1)
+}
+
+func TestScalarMult(t *testing.T) {
+	r := record(t)
+	for _, c := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384()} {
+		x, y := c.ScalarBaseMult([]byte{2})
+		c.ScalarMult(x, y, []byte{3})
+		r.check(t, "ScalarMult "+c.Params().Name, 2)
+	}
+}
+
+func TestRSA(t *testing.T) {
+	r := record(t)
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("message"))
+	sig, err := rsa.SignPSS(rand.Reader, priv, crypto.SHA256, digest[:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, nil); err != nil {
+		t.Fatal(err)
+	}
+	r.check(t, "GenerateKey RSA", 1)
+	r.check(t, "Sign RSA-PSS", 1)
+	r.check(t, "Verify RSA-PSS", 1)
+}
+
+func TestRemoveHooks(t *testing.T) {
+	r := record(t)
+	instrument.SetHooks(instrument.Hooks{})
+	elliptic.P256().ScalarBaseMult([]byte{1})
+	if len(r.started) != 0 {
+		t.Error("operations reported after removing the hooks")
+	}
+	// Either hook can be missing.
+	instrument.SetHooks(instrument.Hooks{Start: func(instrument.Op, string) {}})
+	instrument.Begin(instrument.Sign, "test")()
+}
+
+func TestOpString(t *testing.T) {
+	if s := instrument.GenerateKey.String(); s != "GenerateKey" {
+		t.Errorf("got %q", s)
+	}
+	if s := instrument.Op(42).String(); s != "Op(42)" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func BenchmarkBeginWithoutHooks(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		instrument.Begin(instrument.ScalarMult, "P-256")()
+	}
+}
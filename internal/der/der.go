@@ -0,0 +1,306 @@
+// Package der implements a minimal reader and writer for the Distinguished
+// Encoding Rules of ASN.1, covering what the codecs of keys and signatures of
+// this module need, with a control flow which doesn't depend on the contents
+// of the elements it handles.
+//
+// The tags and lengths of elements are treated as public: they are the same
+// for every key of a given type and size, except for the lengths of integers,
+// which DER derives from their values, and which any parser reveals through
+// the memory it reads. The contents of integers and strings are never
+// branched on, or used as indices: integers are checked to be minimal and
+// non-negative with masks, and decoded into safenum.Nat values, without going
+// through math/big, so that private keys can be parsed from decrypted blobs
+// without leaking them.
+//
+// As with cryptobyte, reading methods report success with a bool, and only
+// consume their input on success.
+package der
+
+import (
+	"crypto/subtle"
+
+	"github.com/cronokirby/safenum"
+)
+
+// Tag is the identifier octet of an element. Only the low tag numbers, below
+// 31, are supported.
+type Tag uint8
+
+// The universal tags used by the codecs of this module.
+const (
+	Integer          Tag = 0x02
+	BitString        Tag = 0x03
+	OctetString      Tag = 0x04
+	Null             Tag = 0x05
+	ObjectIdentifier Tag = 0x06
+	Sequence         Tag = 0x30
+)
+
+// Explicit returns the tag of the context-specific, explicitly tagged element
+// [n], which must be lower than 31.
+func Explicit(n int) Tag {
+	return Tag(0xa0 | n)
+}
+
+// maxLength is the length of the longest element supported, of 16 MiB.
+const maxLength = 1<<24 - 1
+
+// Input is a DER encoded input, from which elements are read.
+type Input []byte
+
+// Empty reports whether the input has been entirely read.
+func (s *Input) Empty() bool {
+	return len(*s) == 0
+}
+
+// header parses the header of the next element, and returns its tag, the
+// length of its header, and the length of its contents.
+func (s Input) header() (tag Tag, headerLen, length int, ok bool) {
+	if len(s) < 2 {
+		return 0, 0, 0, false
+	}
+	tag = Tag(s[0])
+	if tag&0x1f == 0x1f {
+		return 0, 0, 0, false
+	}
+	headerLen, length = 2, int(s[1])
+	if length&0x80 != 0 {
+		// The long form gives the number of octets of the length, and DER
+		// requires it to be as short as possible. The indefinite form, with
+		// no octets, isn't allowed.
+		n := length & 0x7f
+		if n == 0 || n > 3 || len(s) < 2+n || s[2] == 0 {
+			return 0, 0, 0, false
+		}
+		length = 0
+		for _, b := range s[2 : 2+n] {
+			length = length<<8 | int(b)
+		}
+		if length < 0x80 {
+			return 0, 0, 0, false
+		}
+		headerLen += n
+	}
+	if len(s)-headerLen < length {
+		return 0, 0, 0, false
+	}
+	return tag, headerLen, length, true
+}
+
+// PeekTag reports whether the next element has the given tag.
+func (s *Input) PeekTag(tag Tag) bool {
+	return len(*s) > 0 && Tag((*s)[0]) == tag
+}
+
+// ReadElement reads the next element, which must have the given tag, and sets
+// out to its contents.
+func (s *Input) ReadElement(out *Input, tag Tag) bool {
+	t, headerLen, length, ok := s.header()
+	if !ok || t != tag {
+		return false
+	}
+	end := headerLen + length
+	// The capacity is cut, so that appending to out can't overwrite s.
+	*out = (*s)[headerLen:end:end]
+	*s = (*s)[end:]
+	return true
+}
+
+// ReadOptionalElement reads the next element if it has the given tag, and
+// sets out to its contents, and present to whether it was there.
+func (s *Input) ReadOptionalElement(out *Input, present *bool, tag Tag) bool {
+	*present = s.PeekTag(tag)
+	if !*present {
+		return true
+	}
+	return s.ReadElement(out, tag)
+}
+
+// checkInteger returns 1 if c is the minimal encoding of a non-negative
+// integer, and 0 otherwise, without branching on its contents.
+func checkInteger(c []byte) int {
+	if len(c) == 0 {
+		return 0
+	}
+	ok := int(c[0]>>7) ^ 1
+	if len(c) > 1 {
+		// A leading zero is only needed before an octet with its top bit
+		// set, which would otherwise make the integer negative.
+		redundant := subtle.ConstantTimeByteEq(c[0], 0) & (int(c[1]>>7) ^ 1)
+		ok &= redundant ^ 1
+	}
+	return ok
+}
+
+// ReadIntegerBytes reads a non-negative INTEGER, and sets out to its
+// big-endian encoding, which is minimal, up to a leading zero.
+func (s *Input) ReadIntegerBytes(out *[]byte) bool {
+	in := *s
+	var c Input
+	if !in.ReadElement(&c, Integer) || checkInteger(c) != 1 {
+		return false
+	}
+	*out, *s = c, in
+	return true
+}
+
+// ReadInteger reads a non-negative INTEGER into out, whose announced length
+// is the length of the encoding.
+func (s *Input) ReadInteger(out *safenum.Nat) bool {
+	var c []byte
+	if !s.ReadIntegerBytes(&c) {
+		return false
+	}
+	out.SetBytes(c[:len(c):len(c)])
+	return true
+}
+
+// ReadSmallInteger reads a non-negative INTEGER lower than 2^31, like a
+// version number. Unlike ReadInteger, its value is treated as public.
+func (s *Input) ReadSmallInteger(out *int) bool {
+	in := *s
+	var c []byte
+	if !in.ReadIntegerBytes(&c) || len(c) > 4 {
+		return false
+	}
+	v := 0
+	for _, b := range c {
+		v = v<<8 | int(b)
+	}
+	*out, *s = v, in
+	return true
+}
+
+// ReadOctetString reads an OCTET STRING, and sets out to its contents.
+func (s *Input) ReadOctetString(out *[]byte) bool {
+	var c Input
+	if !s.ReadElement(&c, OctetString) {
+		return false
+	}
+	*out = c
+	return true
+}
+
+// ReadBitString reads a BIT STRING of whole octets, and sets out to its
+// contents.
+func (s *Input) ReadBitString(out *[]byte) bool {
+	in := *s
+	var c Input
+	// The first octet is the number of unused bits of the last one.
+	if !in.ReadElement(&c, BitString) || len(c) == 0 || c[0] != 0 {
+		return false
+	}
+	*out, *s = c[1:], in
+	return true
+}
+
+// ReadNull reads a NULL.
+func (s *Input) ReadNull() bool {
+	in := *s
+	var c Input
+	if !in.ReadElement(&c, Null) || len(c) != 0 {
+		return false
+	}
+	*s = in
+	return true
+}
+
+// Builder builds a DER encoding. The zero value is ready to use.
+type Builder struct {
+	buf []byte
+}
+
+// Bytes returns the encoding built so far.
+func (b *Builder) Bytes() []byte {
+	return b.buf
+}
+
+// AddElement appends an element with the given tag and contents. It panics if
+// the contents are longer than 16 MiB.
+func (b *Builder) AddElement(tag Tag, contents []byte) {
+	n := len(contents)
+	b.buf = append(b.buf, byte(tag))
+	switch {
+	case n < 0x80:
+		b.buf = append(b.buf, byte(n))
+	case n < 0x100:
+		b.buf = append(b.buf, 0x81, byte(n))
+	case n < 0x10000:
+		b.buf = append(b.buf, 0x82, byte(n>>8), byte(n))
+	case n <= maxLength:
+		b.buf = append(b.buf, 0x83, byte(n>>16), byte(n>>8), byte(n))
+	default:
+		panic("der: element too long")
+	}
+	b.buf = append(b.buf, contents...)
+}
+
+// AddConstructed appends an element with the given tag, whose contents are
+// built by f, like a SEQUENCE, or an explicitly tagged element.
+func (b *Builder) AddConstructed(tag Tag, f func(*Builder)) {
+	var child Builder
+	f(&child)
+	b.AddElement(tag, child.buf)
+}
+
+// AddSequence appends a SEQUENCE, whose contents are built by f.
+func (b *Builder) AddSequence(f func(*Builder)) {
+	b.AddConstructed(Sequence, f)
+}
+
+// integerContents returns the minimal encoding of the non-negative integer
+// with the big-endian encoding v. Its length depends on the value, as DER
+// requires, but it is computed without branching on the octets of v.
+func integerContents(v []byte) []byte {
+	// zeros counts the leading zero octets, and first is the first non-zero
+	// octet, once seen is set.
+	zeros, seen := 0, 0
+	var first byte
+	for _, x := range v {
+		nonZero := subtle.ConstantTimeByteEq(x, 0) ^ 1
+		first = byte(subtle.ConstantTimeSelect(nonZero&^seen, int(x), int(first)))
+		seen |= nonZero
+		zeros += seen ^ 1
+	}
+	// A zero is prepended if the top bit is set, and zero is encoded as a
+	// single zero octet.
+	pad := int(first>>7) | (seen ^ 1)
+	out := make([]byte, len(v)-zeros+pad)
+	copy(out[pad:], v[zeros:])
+	return out
+}
+
+// AddIntegerBytes appends the non-negative INTEGER with the big-endian
+// encoding v, which may have leading zeros.
+func (b *Builder) AddIntegerBytes(v []byte) {
+	b.AddElement(Integer, integerContents(v))
+}
+
+// AddInteger appends the INTEGER n.
+func (b *Builder) AddInteger(n *safenum.Nat) {
+	b.AddIntegerBytes(n.Bytes())
+}
+
+// AddSmallInteger appends the non-negative INTEGER v, lower than 2^31, like a
+// version number. Unlike AddInteger, its value is treated as public.
+func (b *Builder) AddSmallInteger(v int) {
+	if v < 0 || v > 1<<31-1 {
+		panic("der: integer out of range")
+	}
+	b.AddIntegerBytes([]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// AddOctetString appends an OCTET STRING.
+func (b *Builder) AddOctetString(v []byte) {
+	b.AddElement(OctetString, v)
+}
+
+// AddBitString appends a BIT STRING of whole octets.
+func (b *Builder) AddBitString(v []byte) {
+	b.AddElement(BitString, append([]byte{0}, v...))
+}
+
+// AddNull appends a NULL.
+func (b *Builder) AddNull() {
+	b.AddElement(Null, nil)
+}
@@ -0,0 +1,167 @@
+package der
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func TestIntegerEncoding(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 500; i++ {
+		v := make([]byte, r.Intn(40))
+		r.Read(v)
+		// Leading zeros, and values with the top bit set, are the cases
+		// which change the length of the encoding.
+		for j := 0; j < len(v) && r.Intn(3) == 0; j++ {
+			v[j] = 0
+		}
+		want, err := asn1.Marshal(new(big.Int).SetBytes(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b Builder
+		b.AddIntegerBytes(v)
+		if !bytes.Equal(b.Bytes(), want) {
+			t.Fatalf("%x encoded as %x, want %x", v, b.Bytes(), want)
+		}
+
+		input := Input(want)
+		n := new(safenum.Nat)
+		if !input.ReadInteger(n) || !input.Empty() {
+			t.Fatalf("failed to read %x", want)
+		}
+		if n.Cmp(new(safenum.Nat).SetBytes(append([]byte{}, v...))) != 0 {
+			t.Fatalf("%x read as %x", want, n.Bytes())
+		}
+	}
+}
+
+func TestSmallInteger(t *testing.T) {
+	for _, v := range []int{0, 1, 127, 128, 255, 256, 1<<31 - 1} {
+		var b Builder
+		b.AddSmallInteger(v)
+		input := Input(b.Bytes())
+		var got int
+		if !input.ReadSmallInteger(&got) || !input.Empty() || got != v {
+			t.Errorf("%d read as %d", v, got)
+		}
+	}
+	input := Input(mustHex(t, "02050080000000"))
+	var got int
+	if input.ReadSmallInteger(&got) {
+		t.Error("2^31 accepted as a small integer")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	long := bytes.Repeat([]byte{0xaa}, 300)
+	var b Builder
+	b.AddSequence(func(b *Builder) {
+		b.AddSmallInteger(1)
+		b.AddOctetString(long)
+		b.AddElement(ObjectIdentifier, []byte{0x2a, 0x86, 0x48})
+		b.AddNull()
+		b.AddConstructed(Explicit(1), func(b *Builder) {
+			b.AddBitString([]byte{1, 2, 3})
+		})
+	})
+
+	input := Input(b.Bytes())
+	var (
+		seq, oid, explicit Input
+		version            int
+		octets, bits       []byte
+		present, absent    bool
+	)
+	if !input.ReadElement(&seq, Sequence) || !input.Empty() ||
+		!seq.ReadSmallInteger(&version) ||
+		!seq.ReadOctetString(&octets) ||
+		!seq.ReadElement(&oid, ObjectIdentifier) ||
+		!seq.ReadNull() ||
+		!seq.ReadOptionalElement(&explicit, &absent, Explicit(0)) ||
+		!seq.ReadOptionalElement(&explicit, &present, Explicit(1)) ||
+		!explicit.ReadBitString(&bits) || !explicit.Empty() ||
+		!seq.Empty() {
+		t.Fatalf("failed to read %x", b.Bytes())
+	}
+	if version != 1 || !bytes.Equal(octets, long) || !bytes.Equal(oid, []byte{0x2a, 0x86, 0x48}) ||
+		absent || !present || !bytes.Equal(bits, []byte{1, 2, 3}) {
+		t.Error("read values differ from the written ones")
+	}
+
+	// The contents can be appended to without overwriting what follows.
+	input = Input(b.Bytes())
+	input.ReadElement(&seq, Sequence)
+	seq.ReadSmallInteger(&version)
+	seq.ReadOctetString(&octets)
+	_ = append(octets, 0)
+	if !bytes.Equal(seq[:3], []byte{0x06, 0x03, 0x2a}) {
+		t.Error("appending to contents overwrote the input")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, der string
+	}{
+		{"empty integer", "0200"},
+		{"negative integer", "0201ff"},
+		{"redundant leading zero", "0202007f"},
+		{"redundant leading zeros", "0203000080"},
+		{"truncated", "020401"},
+		{"long form of a short length", "02810101"},
+		{"long form with a leading zero", "0282000101"},
+		{"indefinite length", "02800100"},
+		{"high tag number", "1f020101"},
+		{"wrong tag", "040101"},
+	} {
+		input := Input(mustHex(t, tc.der))
+		n := new(safenum.Nat)
+		if input.ReadInteger(n) {
+			t.Errorf("%s: %s accepted", tc.name, tc.der)
+		}
+		if len(input) != len(tc.der)/2 {
+			t.Errorf("%s: input consumed on failure", tc.name)
+		}
+	}
+
+	input := Input(mustHex(t, "030201ff"))
+	var bits []byte
+	if input.ReadBitString(&bits) {
+		t.Error("bit string with unused bits accepted")
+	}
+	input = Input(mustHex(t, "050100"))
+	if input.ReadNull() {
+		t.Error("non-empty NULL accepted")
+	}
+}
+
+func TestLongLengths(t *testing.T) {
+	for _, n := range []int{127, 128, 255, 256, 65535, 65536} {
+		var b Builder
+		b.AddOctetString(make([]byte, n))
+		input := Input(b.Bytes())
+		var got []byte
+		if !input.ReadOctetString(&got) || !input.Empty() || len(got) != n {
+			t.Errorf("failed to read an octet string of %d bytes", n)
+		}
+		if want, _ := asn1.Marshal(make([]byte, n)); !bytes.Equal(b.Bytes(), want) {
+			t.Errorf("octet string of %d bytes differs from encoding/asn1", n)
+		}
+	}
+}
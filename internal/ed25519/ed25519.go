@@ -0,0 +1,144 @@
+// Package ed25519 implements Ed25519 signatures, as specified in RFC 8032,
+// over the constant-time arithmetic of the edwards25519 package.
+//
+// Keys use the types of crypto/ed25519, so that they can be shared with it.
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"github.com/cronokirby/safenum"
+)
+
+// dom2 returns the prefix of the hashes of Ed25519ph, from RFC 8032, section
+// 2, which is empty for Ed25519.
+func dom2(phflag byte, context []byte) []byte {
+	dom := append([]byte("SigEd25519 no Ed25519 collisions"), phflag, byte(len(context)))
+	return append(dom, context...)
+}
+
+// challenge returns SHA-512(dom || R || A || M), reduced modulo the order.
+func challenge(dom, R, A, message []byte) *safenum.Nat {
+	h := sha512.New()
+	h.Write(dom)
+	h.Write(R)
+	h.Write(A)
+	h.Write(message)
+	k, _ := edwards25519.ScalarFromUniformBytes(h.Sum(nil))
+	return k
+}
+
+// Sign signs message with priv, following RFC 8032, section 5.1.6.
+//
+// The public key is derived from the seed, rather than taken from the second
+// half of priv, so that a mismatched key can't leak the secret scalar.
+func Sign(priv ed25519.PrivateKey, message []byte) ([]byte, error) {
+	return sign(priv, nil, message)
+}
+
+// SignPh signs the SHA-512 digest of a message with priv, following Ed25519ph
+// from RFC 8032, section 5.1, with a context of at most 255 bytes.
+func SignPh(priv ed25519.PrivateKey, digest []byte, context string) ([]byte, error) {
+	if len(digest) != sha512.Size {
+		return nil, errors.New("ed25519: invalid Ed25519ph digest length")
+	}
+	if len(context) > 255 {
+		return nil, errors.New("ed25519: context too long")
+	}
+	return sign(priv, dom2(1, []byte(context)), digest)
+}
+
+func sign(priv ed25519.PrivateKey, dom, message []byte) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errors.New("ed25519: invalid private key length")
+	}
+	a, prefix, err := ExpandSeed(priv.Seed())
+	if err != nil {
+		return nil, err
+	}
+	A := new(edwards25519.Point).ScalarBaseMult(a).Bytes()
+	ctgrind.Declassify(A)
+	return signExpanded(a, prefix, A, dom, message), nil
+}
+
+// ExpandSeed returns the secret scalar a, and the prefix used to derive
+// nonces, of the private key with the given seed, following RFC 8032, section
+// 5.1.5.
+func ExpandSeed(seed []byte) (a *safenum.Nat, prefix []byte, err error) {
+	seed = append([]byte{}, seed...)
+	ctgrind.MarkSecret(seed)
+	h := sha512.Sum512(seed)
+	a, err = edwards25519.ScalarFromClampedBytes(h[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	return a, h[32:], nil
+}
+
+// SignExpanded signs message with the secret scalar a and the nonce prefix of
+// an expanded private key, whose public key is A = a B.
+//
+// This is the signing procedure of RFC 8032, section 5.1.6, after the
+// expansion of the seed, which lets keys which aren't derived from a seed,
+// like blinded keys, produce standard signatures.
+func SignExpanded(a *safenum.Nat, prefix, A, message []byte) []byte {
+	return signExpanded(a, prefix, A, nil, message)
+}
+
+func signExpanded(a *safenum.Nat, prefix, A, dom, message []byte) []byte {
+	d := sha512.New()
+	d.Write(dom)
+	d.Write(prefix)
+	d.Write(message)
+	r, _ := edwards25519.ScalarFromUniformBytes(d.Sum(nil))
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+	k := challenge(dom, R, A, message)
+	s := new(safenum.Nat).ModMul(k, a, edwards25519.Order)
+	s.ModAdd(s, r, edwards25519.Order)
+
+	sig := make([]byte, 0, ed25519.SignatureSize)
+	sig = append(sig, R...)
+	sig = append(sig, edwards25519.ScalarBytes(s)...)
+	ctgrind.Declassify(sig)
+	return sig
+}
+
+// Verify reports whether sig is a valid signature of message by pub,
+// following RFC 8032, section 5.1.7, without the cofactor.
+func Verify(pub ed25519.PublicKey, message, sig []byte) bool {
+	return verify(pub, nil, message, sig)
+}
+
+// VerifyPh reports whether sig is a valid Ed25519ph signature of the SHA-512
+// digest of a message by pub, with the given context.
+func VerifyPh(pub ed25519.PublicKey, digest, sig []byte, context string) bool {
+	if len(digest) != sha512.Size || len(context) > 255 {
+		return false
+	}
+	return verify(pub, dom2(1, []byte(context)), digest, sig)
+}
+
+func verify(pub ed25519.PublicKey, dom, message, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	A, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return false
+	}
+	s, err := edwards25519.ScalarFromCanonicalBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+	k := challenge(dom, sig[:32], pub, message)
+	// R = s B - k A
+	R := new(edwards25519.Point).ScalarBaseMult(s)
+	R.Subtract(R, new(edwards25519.Point).ScalarMult(k, A))
+	// Everything here is public, so there's no need for a constant-time check.
+	return bytes.Equal(R.Bytes(), sig[:32])
+}
@@ -0,0 +1,84 @@
+package ed25519
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"testing"
+)
+
+// TestVector uses TEST 2 of RFC 8032, section 7.1.
+func TestVector(t *testing.T) {
+	seed, _ := hex.DecodeString("4ccd089b28ff96da9db6c346ec114e0f5b8a319f35aba624da8cf6ed4fb8a6fb")
+	message := []byte{0x72}
+	want, _ := hex.DecodeString("92a009a9f0d4cab8720e820b5f642540a2b27b5416503f8fb3762223ebdb69da085ac1e43e15996e458f3613d0f11d8c387b2eaeb4302aeeb00d291612bb0c00")
+	priv := ed25519.NewKeyFromSeed(seed)
+	sig, err := Sign(priv, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, want) {
+		t.Errorf("got %x, expected %x", sig, want)
+	}
+	if !Verify(priv.Public().(ed25519.PublicKey), message, sig) {
+		t.Error("signature rejected")
+	}
+}
+
+func TestCompatibility(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("message")
+	sig, err := Sign(priv, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, ed25519.Sign(priv, message)) {
+		t.Error("signature differs from crypto/ed25519")
+	}
+	if !Verify(pub, message, sig) {
+		t.Error("signature rejected")
+	}
+	if Verify(pub, []byte("other"), sig) {
+		t.Error("signature of another message accepted")
+	}
+	if _, err := Sign(priv[:32], message); err == nil {
+		t.Error("short private key accepted")
+	}
+}
+
+// TestPhVector uses the Ed25519ph test vector of RFC 8032, section 7.3, and
+// a signature with a context, computed with crypto/ed25519.
+func TestPhVector(t *testing.T) {
+	seed, _ := hex.DecodeString("833fe62409237b9d62ec77587520911e9a759cec1d19755b7da901b96dca3d42")
+	priv := ed25519.NewKeyFromSeed(seed)
+	digest := sha512.Sum512([]byte("abc"))
+	for _, tc := range []struct {
+		context, want string
+	}{
+		{"", "98a70222f0b8121aa9d30f813d683f809e462b469c7ff87639499bb94e6dae4131f85042463c2a355a2003d062adf5aaa10b8c61e636062aaad11c2a26083406"},
+		{"ctx", "4a03dc0383d97c496df4a87f9409759c649ca814180ca1d1055f45dc6b09ad4dd9d62d26602cc51ec4d40ee6184f4cac4191ad536ca54c7866086a4f134b900c"},
+	} {
+		sig, err := SignPh(priv, digest[:], tc.context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(sig) != tc.want {
+			t.Errorf("context %q: got %x, expected %s", tc.context, sig, tc.want)
+		}
+		pub := priv.Public().(ed25519.PublicKey)
+		if !VerifyPh(pub, digest[:], sig, tc.context) {
+			t.Errorf("context %q: signature rejected", tc.context)
+		}
+		if VerifyPh(pub, digest[:], sig, tc.context+"x") || Verify(pub, digest[:], sig) {
+			t.Errorf("context %q: signature accepted in another context", tc.context)
+		}
+	}
+	if _, err := SignPh(priv, digest[:32], ""); err == nil {
+		t.Error("short digest accepted")
+	}
+}
@@ -0,0 +1,167 @@
+// Package fe implements arithmetic in GF(2^255 - 19), on top of safenum, for
+// the curves which share this field, edwards25519 and Curve25519.
+//
+// Field elements are always kept reduced modulo P, which means that they all
+// have the same announced length, and can be selected between in constant-time.
+package fe
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	// P = 2^255 - 19
+	P = safenum.ModulusFromBytes(mustHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed"))
+	// PMinus1Over2 = (p - 1) / 2, used to check for squares
+	PMinus1Over2 = new(safenum.Nat).SetBytes(mustHex("3ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff6"))
+	// p - 2, used for inversion
+	pMinus2 = new(safenum.Nat).SetBytes(mustHex("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeb"))
+)
+
+func mustHex(s string) []byte {
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// FromHex returns a field element from its big-endian hex representation.
+func FromHex(s string) *safenum.Nat {
+	z := new(safenum.Nat).SetBytes(mustHex(s))
+	return z.Mod(z, P)
+}
+
+// FromUint64 returns a field element with a small value.
+func FromUint64(x uint64) *safenum.Nat {
+	z := new(safenum.Nat).SetUint64(x)
+	return z.Mod(z, P)
+}
+
+// FromBytes decodes a 32 byte little endian field element, ignoring the top
+// bit. It also returns 1 if the value was canonical, i.e. lower than p, and 0
+// otherwise.
+func FromBytes(in []byte) (*safenum.Nat, int) {
+	var be [32]byte
+	for i := 0; i < 32; i++ {
+		be[i] = in[31-i]
+	}
+	be[0] &= 0x7f
+	z := new(safenum.Nat).SetBytes(be[:])
+	canonical := subtle.ConstantTimeEq(int32(z.CmpMod(P)), -1)
+	return z.Mod(z, P), canonical
+}
+
+// ToBytes encodes a field element as 32 little endian bytes.
+func ToBytes(x *safenum.Nat) []byte {
+	be := x.Bytes()
+	out := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		out[i] = be[len(be)-1-i]
+	}
+	return out
+}
+
+// Add returns x + y.
+func Add(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModAdd(x, y, P)
+}
+
+// Sub returns x - y.
+func Sub(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModSub(x, y, P)
+}
+
+// Mul returns x * y.
+func Mul(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModMul(x, y, P)
+}
+
+// Invert returns 1 / x, or 0 if x = 0.
+func Invert(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).Exp(x, pMinus2, P)
+}
+
+// IsSquare returns 1 if x is a square, including 0, and 0 otherwise.
+func IsSquare(x *safenum.Nat) int {
+	l := new(safenum.Nat).Exp(x, PMinus1Over2, P)
+	return Equal(l, FromUint64(1)) | Equal(l, FromUint64(0))
+}
+
+// Sqrt returns a square root of x, which must be a square.
+//
+// The root returned is the one with even parity.
+func Sqrt(x *safenum.Nat) *safenum.Nat {
+	r := new(safenum.Nat).ModSqrt(x, P)
+	return Select(Neg(r), r, IsNegative(r))
+}
+
+// Neg returns -x.
+func Neg(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModSub(new(safenum.Nat), x, P)
+}
+
+// IsNegative returns the parity of x, as an integer between 0 and p - 1.
+func IsNegative(x *safenum.Nat) int {
+	xBytes := x.Bytes()
+	return int(xBytes[len(xBytes)-1] & 1)
+}
+
+// Equal returns 1 if x = y, and 0 otherwise, without leaking the result.
+func Equal(x, y *safenum.Nat) int {
+	return subtle.ConstantTimeEq(int32(x.Cmp(y)), 0)
+}
+
+// Select returns a if v == 1, and b if v == 0, without leaking v.
+func Select(a, b *safenum.Nat, v int) *safenum.Nat {
+	aBytes := a.Bytes()
+	out := b.Bytes()
+	subtle.ConstantTimeCopy(v, out, aBytes)
+	z := new(safenum.Nat).SetBytes(out)
+	return z.Mod(z, P)
+}
+
+// Swap swaps a and b if v == 1, and leaves them unchanged if v == 0, without
+// leaking v.
+func Swap(a, b *safenum.Nat, v int) {
+	newA := Select(b, a, v)
+	newB := Select(a, b, v)
+	a.SetNat(newA)
+	b.SetNat(newB)
+}
+
+// The following variants set z to the result, instead of allocating a new
+// element, so that loops can reuse their temporaries. z may alias the inputs.
+
+// AddInto sets z = x + y.
+func AddInto(z, x, y *safenum.Nat) {
+	z.ModAdd(x, y, P)
+}
+
+// SubInto sets z = x - y.
+func SubInto(z, x, y *safenum.Nat) {
+	z.ModSub(x, y, P)
+}
+
+// MulInto sets z = x * y.
+func MulInto(z, x, y *safenum.Nat) {
+	z.ModMul(x, y, P)
+}
+
+// InvertInto sets z = 1 / x, or 0 if x = 0.
+func InvertInto(z, x *safenum.Nat) {
+	z.Exp(x, pMinus2, P)
+}
+
+// SelectInto sets z to a if v == 1, and b if v == 0, without leaking v, using
+// two 32 byte buffers for the encodings of a and b.
+func SelectInto(z, a, b *safenum.Nat, v int, aBuf, bBuf []byte) {
+	a.FillBytes(aBuf)
+	b.FillBytes(bBuf)
+	subtle.ConstantTimeCopy(v, bBuf, aBuf)
+	z.SetBytes(bBuf)
+	z.Mod(z, P)
+}
@@ -0,0 +1,58 @@
+package fe
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSqrt(t *testing.T) {
+	for i := uint64(0); i < 50; i++ {
+		x := FromUint64(i)
+		x2 := Mul(x, x)
+		if IsSquare(x2) != 1 {
+			t.Fatalf("IsSquare(%d²) = 0", i)
+		}
+		r := Sqrt(x2)
+		if Equal(Mul(r, r), x2) != 1 {
+			t.Errorf("Sqrt(%d²)² != %d²", i, i)
+		}
+		if IsNegative(r) != 0 {
+			t.Errorf("Sqrt(%d²) is odd", i)
+		}
+	}
+	// 2 isn't a square, since p = 5 mod 8.
+	if IsSquare(FromUint64(2)) != 0 {
+		t.Error("IsSquare(2) = 1")
+	}
+}
+
+func TestFromBytes(t *testing.T) {
+	pBytes := ToBytes(Sub(FromUint64(0), FromUint64(1)))
+	pBytes[0]++
+	z, canonical := FromBytes(pBytes)
+	if canonical != 0 || Equal(z, FromUint64(0)) != 1 {
+		t.Errorf("FromBytes(p) = %v, %d", z, canonical)
+	}
+	in := ToBytes(FromUint64(42))
+	in[31] |= 0x80
+	z, canonical = FromBytes(in)
+	if canonical != 1 || Equal(z, FromUint64(42)) != 1 {
+		t.Errorf("FromBytes didn't ignore the top bit")
+	}
+	in[31] &= 0x7f
+	if !bytes.Equal(ToBytes(z), in) {
+		t.Errorf("ToBytes(FromBytes(x)) != x")
+	}
+}
+
+func TestSwap(t *testing.T) {
+	a, b := FromUint64(1), FromUint64(2)
+	Swap(a, b, 0)
+	if Equal(a, FromUint64(1)) != 1 {
+		t.Error("Swap(a, b, 0) swapped")
+	}
+	Swap(a, b, 1)
+	if Equal(a, FromUint64(2)) != 1 || Equal(b, FromUint64(1)) != 1 {
+		t.Error("Swap(a, b, 1) didn't swap")
+	}
+}
@@ -9,6 +9,9 @@ package randutil
 import (
 	"io"
 	"sync"
+
+	"github.com/cronokirby/ctcrypto/drbg"
+	"github.com/cronokirby/ctcrypto/rand"
 )
 
 var (
@@ -21,8 +24,12 @@ var (
 // assuming that rsa.GenerateKey is deterministic w.r.t. a given random stream.
 //
 // This does not affect tests that pass a stream of fixed bytes as the random
-// source (e.g. a zeroReader).
+// source (e.g. a zeroReader), and nothing is read from a drbg.TestReader, which
+// exists to make tests reproducible.
 func MaybeReadByte(r io.Reader) {
+	if _, ok := r.(*drbg.TestReader); ok {
+		return
+	}
 	closedChanOnce.Do(func() {
 		closedChan = make(chan struct{})
 		close(closedChan)
@@ -36,3 +43,11 @@ func MaybeReadByte(r io.Reader) {
 		r.Read(buf[:])
 	}
 }
+
+// Or returns r, or the default, health-tested rand.Reader if r is nil.
+func Or(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
@@ -0,0 +1,260 @@
+// Package jose implements the JSON Web Signature algorithms of RFC 7518 and
+// RFC 8037 which are based on elliptic curves, producing signatures in the
+// format JWT libraries expect.
+//
+// ECDSA signatures are the fixed-width concatenation R || S, rather than the
+// ASN.1 encoding of the ecdsa package, and every algorithm is tied to a single
+// curve and hash, so that a key can only ever be used with one algorithm.
+// EdDSA is only supported with Ed25519.
+package jose
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/internal/der"
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+	"github.com/cronokirby/ctcrypto/strict"
+)
+
+// Algorithm is the value of the "alg" header parameter of a JWS.
+type Algorithm string
+
+const (
+	// ES256 is ECDSA with P-256 and SHA-256.
+	ES256 Algorithm = "ES256"
+	// ES384 is ECDSA with P-384 and SHA-384.
+	ES384 Algorithm = "ES384"
+	// ES512 is ECDSA with P-521 and SHA-512.
+	ES512 Algorithm = "ES512"
+	// EdDSA is Ed25519, as registered by RFC 8037.
+	EdDSA Algorithm = "EdDSA"
+)
+
+var (
+	errUnsupportedKey   = errors.New("jose: unsupported key type")
+	errUnsupportedCurve = errors.New("jose: unsupported curve")
+	errWrongAlgorithm   = errors.New("jose: algorithm doesn't match the key")
+	errInvalidSignature = errors.New("jose: invalid signature")
+	errMalformed        = errors.New("jose: malformed JWS")
+)
+
+// ecdsaParams returns the algorithm, the hash function, and the size of R and
+// S in bytes, for an ECDSA key over curve.
+func ecdsaParams(curve elliptic.Curve) (Algorithm, crypto.Hash, int, error) {
+	switch curve.Params().Name {
+	case "P-256":
+		return ES256, crypto.SHA256, 32, nil
+	case "P-384":
+		return ES384, crypto.SHA384, 48, nil
+	case "P-521":
+		return ES512, crypto.SHA512, 66, nil
+	}
+	return "", 0, 0, errUnsupportedCurve
+}
+
+func digest(h crypto.Hash, signingInput []byte) []byte {
+	switch h {
+	case crypto.SHA256:
+		d := sha256.Sum256(signingInput)
+		return d[:]
+	case crypto.SHA384:
+		d := sha512.Sum384(signingInput)
+		return d[:]
+	default:
+		d := sha512.Sum512(signingInput)
+		return d[:]
+	}
+}
+
+// AlgorithmForKey returns the algorithm used with pub, which is either an
+// *ecdsa.PublicKey over P-256, P-384 or P-521, or an ed25519.PublicKey.
+func AlgorithmForKey(pub crypto.PublicKey) (Algorithm, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		alg, _, _, err := ecdsaParams(pub.Curve)
+		return alg, err
+	case ed25519.PublicKey:
+		return EdDSA, nil
+	}
+	return "", errUnsupportedKey
+}
+
+// Sign returns the JWS signature of signingInput, which is the encoded header
+// and payload separated by a period, with the algorithm of the key.
+//
+// The key is either an *ecdsa.PrivateKey, an ed25519.PrivateKey, or any
+// crypto.Signer whose public key is an *ecdsa.PublicKey, like a key held by an
+// HSM. Ed25519 signatures are deterministic, and don't read from rand.
+func Sign(rand io.Reader, key crypto.Signer, signingInput []byte) ([]byte, error) {
+	if priv, ok := key.(ed25519.PrivateKey); ok {
+		return edsig.Sign(priv, signingInput)
+	}
+	pub, ok := key.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errUnsupportedKey
+	}
+	_, h, size, err := ecdsaParams(pub.Curve)
+	if err != nil {
+		return nil, err
+	}
+	hash := digest(h, signingInput)
+	var r, s *big.Int
+	if priv, ok := key.(*ecdsa.PrivateKey); ok {
+		if r, s, err = ecdsa.Sign(rand, priv, hash); err != nil {
+			return nil, err
+		}
+	} else {
+		sig, err := ecdsa.SignWithSigner(rand, key, hash, h)
+		if err != nil {
+			return nil, err
+		}
+		if r, s, err = parseASN1(sig); err != nil {
+			return nil, err
+		}
+	}
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+// parseASN1 decodes an ASN.1 ECDSA signature, as returned by a crypto.Signer.
+func parseASN1(sig []byte) (r, s *big.Int, err error) {
+	var (
+		rBytes, sBytes []byte
+		inner          der.Input
+	)
+	input := der.Input(sig)
+	if !input.ReadElement(&inner, der.Sequence) ||
+		!input.Empty() ||
+		!inner.ReadIntegerBytes(&rBytes) ||
+		!inner.ReadIntegerBytes(&sBytes) ||
+		!inner.Empty() {
+		return nil, nil, errInvalidSignature
+	}
+	return new(big.Int).SetBytes(rBytes), new(big.Int).SetBytes(sBytes), nil
+}
+
+// Verify checks that sig is a valid JWS signature of signingInput by pub,
+// with the algorithm alg.
+//
+// The algorithm must be the one returned by AlgorithmForKey for pub, so that
+// a token can't pick a weaker algorithm than the one the key is meant for.
+func Verify(alg Algorithm, pub crypto.PublicKey, signingInput, sig []byte) error {
+	want, err := AlgorithmForKey(pub)
+	if err != nil {
+		return err
+	}
+	if alg != want {
+		return errWrongAlgorithm
+	}
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		_, h, size, _ := ecdsaParams(pub.Curve)
+		if len(sig) != 2*size {
+			return errInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(pub, digest(h, signingInput), r, s) {
+			return errInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if !edsig.Verify(pub, signingInput, sig) {
+			return errInvalidSignature
+		}
+	}
+	return nil
+}
+
+// SignCompact returns the JWS Compact Serialization of payload, signed with
+// key. The "alg" parameter is set to the algorithm of the key, replacing any
+// value in header, which may be nil.
+func SignCompact(rand io.Reader, key crypto.Signer, header map[string]interface{}, payload []byte) (string, error) {
+	alg, err := AlgorithmForKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	fields := map[string]interface{}{}
+	for k, v := range header {
+		fields[k] = v
+	}
+	fields["alg"] = alg
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	signingInput := base64.RawURLEncoding.EncodeToString(encoded) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	sig, err := Sign(rand, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// decodeSegment decodes a base64url segment of a JWS. Non-zero trailing bits
+// are only rejected when the strict policy is enforced.
+func decodeSegment(s string) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errMalformed
+	}
+	if strict.Enabled() {
+		_, err := base64.RawURLEncoding.Strict().DecodeString(s)
+		if err := strict.Check(err == nil, "JWS segment", "non-zero trailing bits"); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// VerifyCompact verifies a JWS in the Compact Serialization with pub, and
+// returns its decoded header and payload.
+//
+// The "alg" parameter must match the key, as in Verify. Tokens with a "crit"
+// parameter are rejected, since no extensions are understood.
+func VerifyCompact(pub crypto.PublicKey, token string) (header map[string]interface{}, payload []byte, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errMalformed
+	}
+	encoded, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(encoded, &header); err != nil || header == nil {
+		return nil, nil, errMalformed
+	}
+	if _, ok := header["crit"]; ok {
+		return nil, nil, errors.New("jose: unsupported critical header parameters")
+	}
+	alg, ok := header["alg"].(string)
+	if !ok {
+		return nil, nil, errMalformed
+	}
+	payload, err = decodeSegment(parts[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, nil, err
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	if err := Verify(Algorithm(alg), pub, signingInput, sig); err != nil {
+		return nil, nil, err
+	}
+	return header, payload, nil
+}
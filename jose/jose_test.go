@@ -0,0 +1,141 @@
+package jose
+
+import (
+	"bytes"
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+// remoteSigner hides the type of an ECDSA key, like a key held by an HSM.
+type remoteSigner struct{ priv *ecdsa.PrivateKey }
+
+func (s remoteSigner) Public() crypto.PublicKey { return s.priv.Public() }
+
+func (s remoteSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.priv.Sign(rand, digest, opts)
+}
+
+func TestECDSA(t *testing.T) {
+	for _, test := range []struct {
+		curve elliptic.Curve
+		alg   Algorithm
+		size  int
+	}{
+		{elliptic.P256(), ES256, 64},
+		{elliptic.P384(), ES384, 96},
+		{elliptic.P521(), ES512, 132},
+	} {
+		priv, err := ecdsa.GenerateKey(test.curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if alg, err := AlgorithmForKey(priv.Public()); alg != test.alg || err != nil {
+			t.Errorf("%s: got %v, %v", test.alg, alg, err)
+		}
+		for _, key := range []crypto.Signer{priv, remoteSigner{priv}} {
+			input := []byte("header.payload")
+			sig, err := Sign(rand.Reader, key, input)
+			if err != nil {
+				t.Fatalf("%s: %v", test.alg, err)
+			}
+			if len(sig) != test.size {
+				t.Errorf("%s: signature is %d bytes, expected %d", test.alg, len(sig), test.size)
+			}
+			if err := Verify(test.alg, priv.Public(), input, sig); err != nil {
+				t.Errorf("%s: %v", test.alg, err)
+			}
+			std := &stdecdsa.PublicKey{Curve: test.curve, X: priv.X, Y: priv.Y}
+			r := new(big.Int).SetBytes(sig[:test.size/2])
+			s := new(big.Int).SetBytes(sig[test.size/2:])
+			if !stdecdsa.Verify(std, hashFor(test.alg, input), r, s) {
+				t.Errorf("%s: crypto/ecdsa rejected the signature", test.alg)
+			}
+			if err := Verify(test.alg, priv.Public(), []byte("header.other"), sig); err == nil {
+				t.Errorf("%s: signature of another input accepted", test.alg)
+			}
+			if err := Verify(EdDSA, priv.Public(), input, sig); err == nil {
+				t.Errorf("%s: wrong algorithm accepted", test.alg)
+			}
+		}
+	}
+}
+
+func hashFor(alg Algorithm, input []byte) []byte {
+	switch alg {
+	case ES256:
+		return digest(crypto.SHA256, input)
+	case ES384:
+		return digest(crypto.SHA384, input)
+	}
+	return digest(crypto.SHA512, input)
+}
+
+func TestEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := []byte("header.payload")
+	sig, err := Sign(nil, priv, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig, ed25519.Sign(priv, input)) {
+		t.Error("signature differs from crypto/ed25519")
+	}
+	if err := Verify(EdDSA, pub, input, sig); err != nil {
+		t.Error(err)
+	}
+	sig[0] ^= 1
+	if err := Verify(EdDSA, pub, input, sig); err == nil {
+		t.Error("modified signature accepted")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []crypto.Signer{ecKey, edKey} {
+		token, err := SignCompact(rand.Reader, key, map[string]interface{}{"typ": "JWT", "alg": "none"}, []byte(`{"sub":"1"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, payload, err := VerifyCompact(key.Public(), token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alg, _ := AlgorithmForKey(key.Public())
+		if header["alg"] != string(alg) || header["typ"] != "JWT" || string(payload) != `{"sub":"1"}` {
+			t.Errorf("got %v, %s", header, payload)
+		}
+		parts := strings.Split(token, ".")
+		if _, _, err := VerifyCompact(key.Public(), parts[0]+"."+parts[1]+"."); err == nil {
+			t.Error("empty signature accepted")
+		}
+	}
+	if _, _, err := VerifyCompact(edKey.Public(), "not a token"); err == nil {
+		t.Error("malformed token accepted")
+	}
+	crit, err := SignCompact(rand.Reader, edKey, map[string]interface{}{"crit": []string{"exp"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := VerifyCompact(edKey.Public(), crit); err == nil {
+		t.Error("unknown critical parameter accepted")
+	}
+}
@@ -0,0 +1,126 @@
+package jubjub
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/cronokirby/safenum"
+)
+
+// This file contains helpers for arithmetic in the base field of Jubjub, which
+// is the scalar field of BLS12-381, on top of safenum.
+//
+// Field elements are always kept reduced modulo q, which means that they all
+// have the same announced length, and can be selected between in
+// constant-time.
+
+var (
+	// q = 0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001
+	q = safenum.ModulusFromBytes(mustHex("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001"))
+	// q - 2, used for inversion
+	qMinus2 = new(safenum.Nat).SetBytes(mustHex("73eda753299d7d483339d80809a1d80553bda402fffe5bfefffffffeffffffff"))
+	// (q - 1) / 2, used to check for squares
+	qMinus1Over2 = new(safenum.Nat).SetBytes(mustHex("39f6d3a994cebea4199cec0404d0ec02a9ded2017fff2dff7fffffff80000000"))
+	// the d coefficient of the curve -u² + v² = 1 + d u² v², equal to
+	// -10240 / 10241
+	curveD = feFromHex("2a9318e74bfa2b48f5fd9207e6bd7fd4292d7f6d37579d2601065fd6d6343eb1")
+	// 2 d
+	curveD2 = feFromHex("552631ce97f45691ebfb240fcd7affa8525afeda6eaf3a4c020cbfadac687d62")
+)
+
+func mustHex(s string) []byte {
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// feFromHex returns a field element from its big-endian hex representation.
+func feFromHex(s string) *safenum.Nat {
+	z := new(safenum.Nat).SetBytes(mustHex(s))
+	return z.Mod(z, q)
+}
+
+// feFromUint64 returns a field element with a small value.
+func feFromUint64(x uint64) *safenum.Nat {
+	z := new(safenum.Nat).SetUint64(x)
+	return z.Mod(z, q)
+}
+
+// feFromBytes decodes a 32 byte little endian field element, ignoring the top
+// bit. It also returns 1 if the value was canonical, i.e. lower than q, and 0
+// otherwise.
+func feFromBytes(in []byte) (*safenum.Nat, int) {
+	var be [32]byte
+	for i := 0; i < 32; i++ {
+		be[i] = in[31-i]
+	}
+	be[0] &= 0x7f
+	z := new(safenum.Nat).SetBytes(be[:])
+	canonical := subtle.ConstantTimeEq(int32(z.CmpMod(q)), -1)
+	return z.Mod(z, q), canonical
+}
+
+// feToBytes encodes a field element as 32 little endian bytes.
+func feToBytes(x *safenum.Nat) []byte {
+	be := x.Bytes()
+	out := make([]byte, 32)
+	for i := 0; i < 32; i++ {
+		out[i] = be[len(be)-1-i]
+	}
+	return out
+}
+
+func feAdd(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModAdd(x, y, q)
+}
+
+func feSub(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModSub(x, y, q)
+}
+
+func feMul(x, y *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModMul(x, y, q)
+}
+
+// feInvert returns 1 / x, or 0 if x = 0.
+func feInvert(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).Exp(x, qMinus2, q)
+}
+
+// feIsSquare returns 1 if x is a square, including 0, and 0 otherwise.
+func feIsSquare(x *safenum.Nat) int {
+	l := new(safenum.Nat).Exp(x, qMinus1Over2, q)
+	return feEqual(l, feFromUint64(1)) | feEqual(l, feFromUint64(0))
+}
+
+// feSqrt returns a square root of x, which must be a square.
+func feSqrt(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModSqrt(x, q)
+}
+
+// feNeg returns -x.
+func feNeg(x *safenum.Nat) *safenum.Nat {
+	return new(safenum.Nat).ModSub(new(safenum.Nat), x, q)
+}
+
+// feIsOdd returns the parity of x, as an integer between 0 and q - 1.
+func feIsOdd(x *safenum.Nat) int {
+	xBytes := x.Bytes()
+	return int(xBytes[len(xBytes)-1] & 1)
+}
+
+// feEqual returns 1 if x = y, and 0 otherwise, without leaking the result.
+func feEqual(x, y *safenum.Nat) int {
+	return subtle.ConstantTimeEq(int32(x.Cmp(y)), 0)
+}
+
+// feSelect returns a if v == 1, and b if v == 0, without leaking v.
+func feSelect(a, b *safenum.Nat, v int) *safenum.Nat {
+	aBytes := a.Bytes()
+	out := b.Bytes()
+	subtle.ConstantTimeCopy(v, out, aBytes)
+	z := new(safenum.Nat).SetBytes(out)
+	return z.Mod(z, q)
+}
@@ -0,0 +1,185 @@
+// Package jubjub implements group operations on Jubjub, the twisted Edwards
+// curve -u² + v² = 1 + d u² v², with d = -10240 / 10241, over the scalar field
+// of BLS12-381, using the safenum library for constant-time arithmetic.
+//
+// Jubjub is embedded in BLS12-381: its arithmetic is native to the circuits of
+// proof systems over that curve, which is why it is used by Zcash Sapling for
+// commitments and hashes which are checked in zero-knowledge proofs. The
+// encoding of points follows repr_J of the Zcash protocol specification,
+// section 5.4.9.3.
+//
+// The group of points has order 8 r, for a prime r. Scalars are represented
+// as safenum.Nat values, reduced modulo Order.
+package jubjub
+
+import (
+	"crypto/subtle"
+	"errors"
+
+	"github.com/cronokirby/safenum"
+)
+
+// PointSize is the size of an encoded point.
+const PointSize = 32
+
+// Order is the order of the prime order subgroup of the curve, which is
+// r = 0x0e7db4ea6533afa906673b0101343b00a6682093ccc81082d0970e5ed6f72cb7.
+var Order = safenum.ModulusFromBytes(mustHex("0e7db4ea6533afa906673b0101343b00a6682093ccc81082d0970e5ed6f72cb7"))
+
+// Point is a point on the curve, in extended coordinates (U : V : Z : T), with
+// u = U / Z, v = V / Z, and u v = T / Z.
+//
+// The zero value is not valid, and points should be created with
+// NewIdentityPoint, or with new(Point).Set or SetBytes. Like the types of
+// math/big, methods set their receiver to the result, and return it.
+type Point struct {
+	u, v, z, t *safenum.Nat
+}
+
+// NewIdentityPoint returns a new point set to the identity, (0, 1).
+func NewIdentityPoint() *Point {
+	return &Point{
+		u: feFromUint64(0),
+		v: feFromUint64(1),
+		z: feFromUint64(1),
+		t: feFromUint64(0),
+	}
+}
+
+// Set sets v = p, and returns v.
+func (v *Point) Set(p *Point) *Point {
+	v.u = new(safenum.Nat).SetNat(p.u)
+	v.v = new(safenum.Nat).SetNat(p.v)
+	v.z = new(safenum.Nat).SetNat(p.z)
+	v.t = new(safenum.Nat).SetNat(p.t)
+	return v
+}
+
+// SetBytes sets v to the point encoded by b, which is the little-endian
+// v-coordinate, with the parity of the u-coordinate in the top bit, and
+// returns v.
+//
+// Non-canonical encodings of v are rejected. If b doesn't encode a point on
+// the curve, an error is returned, and v is left unchanged. The point isn't
+// required to be in the prime order subgroup.
+func (v *Point) SetBytes(b []byte) (*Point, error) {
+	if len(b) != PointSize {
+		return nil, errors.New("jubjub: invalid point encoding length")
+	}
+	y, canonical := feFromBytes(b)
+	sign := int(b[31] >> 7)
+
+	// u² = (v² - 1) / (d v² + 1), where the denominator can't vanish, since d
+	// is not a square.
+	y2 := feMul(y, y)
+	num := feSub(y2, feFromUint64(1))
+	den := feAdd(feMul(curveD, y2), feFromUint64(1))
+	x2 := feMul(num, feInvert(den))
+	onCurve := feIsSquare(x2)
+	x := feSqrt(feSelect(x2, feFromUint64(0), onCurve))
+	// u = 0 has no odd counterpart.
+	xIsZero := feEqual(x, feFromUint64(0))
+	x = feSelect(feNeg(x), x, subtle.ConstantTimeEq(int32(feIsOdd(x)), int32(sign^1)))
+
+	if canonical&onCurve&^(xIsZero&sign) != 1 {
+		return nil, errors.New("jubjub: invalid point encoding")
+	}
+	v.u, v.v, v.z, v.t = x, y, feFromUint64(1), feMul(x, y)
+	return v, nil
+}
+
+// affine returns the affine coordinates of v.
+func (v *Point) affine() (u, w *safenum.Nat) {
+	zInv := feInvert(v.z)
+	return feMul(v.u, zInv), feMul(v.v, zInv)
+}
+
+// Bytes returns the canonical 32 byte encoding of v.
+func (v *Point) Bytes() []byte {
+	u, w := v.affine()
+	out := feToBytes(w)
+	out[31] |= byte(feIsOdd(u) << 7)
+	return out
+}
+
+// UBytes returns the u-coordinate of v, as 32 little-endian bytes, which is
+// Extract_J in the Zcash protocol specification.
+func (v *Point) UBytes() []byte {
+	u, _ := v.affine()
+	return feToBytes(u)
+}
+
+// Add sets v = p + q, and returns v.
+func (v *Point) Add(p, q *Point) *Point {
+	// This uses the "add-2008-hwcd-3" formulas for a = -1, which are complete
+	// on Jubjub, since -1 is a square, and d isn't.
+	a := feMul(feSub(p.v, p.u), feSub(q.v, q.u))
+	b := feMul(feAdd(p.v, p.u), feAdd(q.v, q.u))
+	c := feMul(feMul(p.t, curveD2), q.t)
+	d := feMul(feAdd(p.z, p.z), q.z)
+	e := feSub(b, a)
+	f := feSub(d, c)
+	g := feAdd(d, c)
+	h := feAdd(b, a)
+	v.u, v.v, v.z, v.t = feMul(e, f), feMul(g, h), feMul(f, g), feMul(e, h)
+	return v
+}
+
+// Negate sets v = -p, and returns v.
+func (v *Point) Negate(p *Point) *Point {
+	v.u, v.v, v.z, v.t = feNeg(p.u), new(safenum.Nat).SetNat(p.v), new(safenum.Nat).SetNat(p.z), feNeg(p.t)
+	return v
+}
+
+// Subtract sets v = p - q, and returns v.
+func (v *Point) Subtract(p, q *Point) *Point {
+	return v.Add(p, new(Point).Negate(q))
+}
+
+// MultByCofactor sets v = 8 p, and returns v.
+func (v *Point) MultByCofactor(p *Point) *Point {
+	v.Add(p, p)
+	v.Add(v, v)
+	return v.Add(v, v)
+}
+
+// Equal returns 1 if v and p are equal, and 0 otherwise, without leaking
+// which.
+func (v *Point) Equal(p *Point) int {
+	// U1 / Z1 = U2 / Z2 and V1 / Z1 = V2 / Z2
+	return feEqual(feMul(v.u, p.z), feMul(p.u, v.z)) & feEqual(feMul(v.v, p.z), feMul(p.v, v.z))
+}
+
+// Select sets v to a if cond == 1, and to b if cond == 0, without leaking
+// cond, and returns v.
+func (v *Point) Select(a, b *Point, cond int) *Point {
+	v.u = feSelect(a.u, b.u, cond)
+	v.v = feSelect(a.v, b.v, cond)
+	v.z = feSelect(a.z, b.z, cond)
+	v.t = feSelect(a.t, b.t, cond)
+	return v
+}
+
+// ScalarBytes returns the 32 byte big-endian encoding of a scalar, reduced
+// modulo the order.
+func ScalarBytes(s *safenum.Nat) []byte {
+	b := new(safenum.Nat).Mod(s, Order).Bytes()
+	return b[len(b)-32:]
+}
+
+// ScalarMult sets v = s * p, and returns v.
+//
+// The scalar is reduced modulo Order, so p should be in the prime order
+// subgroup. The execution time doesn't depend on the value of s.
+func (v *Point) ScalarMult(s *safenum.Nat, p *Point) *Point {
+	sBytes := ScalarBytes(s)
+	base := new(Point).Set(p)
+	acc := NewIdentityPoint()
+	sum := new(Point)
+	for i := 0; i < 8*len(sBytes); i++ {
+		acc.Add(acc, acc)
+		sum.Add(acc, base)
+		acc.Select(sum, acc, int(sBytes[i/8]>>(7-i%8))&1)
+	}
+	return v.Set(acc)
+}
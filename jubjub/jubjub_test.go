@@ -0,0 +1,84 @@
+package jubjub
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+// basePoint is the first generator of the Pedersen hash of Zcash Sapling.
+const basePoint = "ca3c2432d4abbf7732464ec08b2e47f95edc7e836b16c979571b52d3a2879ea8"
+
+func decodePoint(t *testing.T, s string) *Point {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := new(Point).SetBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestEncoding(t *testing.T) {
+	p := decodePoint(t, basePoint)
+	if got := hex.EncodeToString(p.Bytes()); got != basePoint {
+		t.Errorf("got %s, want %s", got, basePoint)
+	}
+	if got := NewIdentityPoint().Bytes(); !bytes.Equal(got, append([]byte{1}, make([]byte, 31)...)) {
+		t.Errorf("identity encoded as %x", got)
+	}
+
+	// q itself isn't canonical.
+	nonCanonical, _ := hex.DecodeString("01000000fffffffffe5bfeff02a4bd5305d8a10908d83933487d9d2953a7ed73")
+	if _, err := new(Point).SetBytes(nonCanonical); err == nil {
+		t.Error("non-canonical encoding accepted")
+	}
+	if _, err := new(Point).SetBytes(p.Bytes()[:31]); err == nil {
+		t.Error("short encoding accepted")
+	}
+	// u = 0 with the odd flag.
+	odd := NewIdentityPoint().Bytes()
+	odd[31] |= 0x80
+	if _, err := new(Point).SetBytes(odd); err == nil {
+		t.Error("odd zero u-coordinate accepted")
+	}
+}
+
+func TestScalarMult(t *testing.T) {
+	p := decodePoint(t, basePoint)
+	for _, tc := range []struct {
+		k    uint64
+		want string
+	}{
+		{3, "57ad4441c9f8e3a6430e9e5bb45aaf294bb5bf4d7dd0ef00965bbb1b10e71608"},
+		{12345, "1553c839c894887045e502767912e5dbaa5f0f9a0284e54b18fb6ab10edf2d9e"},
+	} {
+		got := new(Point).ScalarMult(new(safenum.Nat).SetUint64(tc.k), p)
+		if hex.EncodeToString(got.Bytes()) != tc.want {
+			t.Errorf("%d P = %x, want %s", tc.k, got.Bytes(), tc.want)
+		}
+	}
+
+	three := new(Point).Add(p, p)
+	three.Add(three, p)
+	if three.Equal(new(Point).ScalarMult(new(safenum.Nat).SetUint64(3), p)) != 1 {
+		t.Error("P + P + P != 3 P")
+	}
+	if new(Point).Subtract(three, three).Equal(NewIdentityPoint()) != 1 {
+		t.Error("3 P - 3 P isn't the identity")
+	}
+	// r P = 0, computed as (r - 1) P + P, since scalars are reduced.
+	rMinusOne := new(safenum.Nat).ModSub(new(safenum.Nat), new(safenum.Nat).SetUint64(1), Order)
+	if new(Point).Add(new(Point).ScalarMult(rMinusOne, p), p).Equal(NewIdentityPoint()) != 1 {
+		t.Error("r P isn't the identity")
+	}
+	eight := new(Point).MultByCofactor(p)
+	if eight.Equal(new(Point).ScalarMult(new(safenum.Nat).SetUint64(8), p)) != 1 {
+		t.Error("MultByCofactor differs from 8 P")
+	}
+}
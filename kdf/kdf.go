@@ -0,0 +1,143 @@
+// Package kdf implements the key derivation functions of NIST SP 800-56C
+// Revision 2, which are the ones approved for deriving keys from the shared
+// secret of a key establishment scheme from NIST SP 800-56A, such as ECDH.
+//
+// The one-step KDF is also known as the concatenation KDF, and is used by
+// JOSE, among others. The two-step KDF extracts a key derivation key from the
+// shared secret with HMAC, and then expands it with the KDF in counter mode of
+// NIST SP 800-108.
+package kdf
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash"
+
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/hmac"
+)
+
+var errInvalidLength = errors.New("kdf: invalid output length")
+
+// FixedInfo contains the context bound to a derived key, as described in
+// NIST SP 800-56A Revision 3, section 5.8.2.1.
+type FixedInfo struct {
+	// AlgorithmID identifies how the derived key will be used.
+	AlgorithmID []byte
+	// PartyUInfo and PartyVInfo contain public information about each party,
+	// such as their identifiers.
+	PartyUInfo []byte
+	PartyVInfo []byte
+	// SuppPubInfo and SuppPrivInfo contain optional supplementary data, such
+	// as the length of the derived key, which is included as is.
+	SuppPubInfo  []byte
+	SuppPrivInfo []byte
+}
+
+// appendField appends a field prefixed by its 32 bit big-endian length.
+func appendField(out, field []byte) []byte {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+	out = append(out, length[:]...)
+	return append(out, field...)
+}
+
+// Bytes encodes the FixedInfo with the concatenation format of NIST SP 800-56A
+// Revision 3, section 5.8.2.1.1.
+//
+// AlgorithmID, PartyUInfo, and PartyVInfo are each prefixed by their length,
+// as a 32 bit big-endian integer, while the supplementary fields are appended
+// as is.
+func (f *FixedInfo) Bytes() []byte {
+	var out []byte
+	out = appendField(out, f.AlgorithmID)
+	out = appendField(out, f.PartyUInfo)
+	out = appendField(out, f.PartyVInfo)
+	out = append(out, f.SuppPubInfo...)
+	return append(out, f.SuppPrivInfo...)
+}
+
+// KeyLengthInfo returns the length of a derived key, in bits, as a 32 bit
+// big-endian integer, which is commonly used as SuppPubInfo.
+func KeyLengthInfo(length int) []byte {
+	var out [4]byte
+	binary.BigEndian.PutUint32(out[:], uint32(8*length))
+	return out[:]
+}
+
+// counterMode computes PRF(1 || prefix || fixedInfo) || PRF(2 || prefix ||
+// fixedInfo) || ..., truncated to length bytes, where each counter is a 32 bit
+// big-endian integer.
+func counterMode(prf hash.Hash, prefix, fixedInfo []byte, length int) ([]byte, error) {
+	if length <= 0 || uint64(length) > uint64(prf.Size())*0xffffffff {
+		return nil, errInvalidLength
+	}
+	out := make([]byte, 0, length+prf.Size())
+	var counter [4]byte
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		prf.Reset()
+		prf.Write(counter[:])
+		prf.Write(prefix)
+		prf.Write(fixedInfo)
+		out = prf.Sum(out)
+	}
+	return out[:length], nil
+}
+
+// checkFIPS checks that h is approved, if the FIPS policy is enforced.
+func checkFIPS(h func() hash.Hash) error {
+	return fips.Check(fips.ApprovedHashFunc(h), "KDF with a hash other than SHA-2 or SHA-3")
+}
+
+// OneStep derives a key of length bytes from the shared secret z, using the
+// one-step KDF of NIST SP 800-56C Revision 2, section 4.1, with a hash
+// function as the auxiliary function.
+//
+// This computes H(1 || z || fixedInfo) || H(2 || z || fixedInfo) || ...,
+// which is also the concatenation KDF of NIST SP 800-56A.
+func OneStep(h func() hash.Hash, z, fixedInfo []byte, length int) ([]byte, error) {
+	if err := checkFIPS(h); err != nil {
+		return nil, err
+	}
+	return counterMode(h(), z, fixedInfo, length)
+}
+
+// OneStepHMAC derives a key of length bytes from the shared secret z, using
+// the one-step KDF of NIST SP 800-56C Revision 2, section 4.1, with HMAC as
+// the auxiliary function.
+//
+// If salt is empty, a string of zero bytes as long as the block size of the
+// hash function is used instead, as prescribed by the standard.
+func OneStepHMAC(h func() hash.Hash, z, salt, fixedInfo []byte, length int) ([]byte, error) {
+	if err := checkFIPS(h); err != nil {
+		return nil, err
+	}
+	if len(salt) == 0 {
+		salt = make([]byte, h().BlockSize())
+	}
+	return counterMode(hmac.New(h, salt), z, fixedInfo, length)
+}
+
+// TwoStep derives a key of length bytes from the shared secret z, using the
+// extraction-then-expansion KDF of NIST SP 800-56C Revision 2, section 5.
+//
+// The extraction step computes HMAC(salt, z), which is used as the key of the
+// KDF in counter mode of NIST SP 800-108, with HMAC as the PRF. Each block is
+// HMAC(kdk, i || fixedInfo), so fixedInfo should contain the label, context,
+// and length of the derived key expected by the application.
+//
+// If salt is empty, a string of zero bytes as long as the block size of the
+// hash function is used instead, as prescribed by the standard.
+func TwoStep(h func() hash.Hash, z, salt, fixedInfo []byte, length int) ([]byte, error) {
+	if err := checkFIPS(h); err != nil {
+		return nil, err
+	}
+	if len(salt) == 0 {
+		salt = make([]byte, h().BlockSize())
+	}
+	extract := hmac.New(h, salt)
+	extract.Write(z)
+	kdk := extract.Sum(nil)
+	return counterMode(hmac.New(h, kdk), nil, fixedInfo, length)
+}
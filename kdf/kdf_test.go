@@ -0,0 +1,112 @@
+package kdf
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+)
+
+func TestOneStepJOSE(t *testing.T) {
+	// See RFC 7518, appendix C.
+	z, _ := hex.DecodeString("9e56d91d817135d372834283bf84269cfb316ea3da806a48f6daa7798cfe90c4")
+	info := &FixedInfo{
+		AlgorithmID: []byte("A128GCM"),
+		PartyUInfo:  []byte("Alice"),
+		PartyVInfo:  []byte("Bob"),
+		SuppPubInfo: KeyLengthInfo(16),
+	}
+	out, err := OneStep(sha256.New, z, info.Bytes(), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "VqqN6vgjbSBcIijNcacQGg"
+	if encoded := base64.RawURLEncoding.EncodeToString(out); encoded != expected {
+		t.Errorf("OneStep() = %s, expected %s", encoded, expected)
+	}
+}
+
+func TestOneStepMultipleBlocks(t *testing.T) {
+	z := []byte("shared secret")
+	fixedInfo := []byte("fixed info")
+	out, err := OneStep(sha256.New, z, fixedInfo, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.New()
+	h.Write([]byte{0, 0, 0, 2})
+	h.Write(z)
+	h.Write(fixedInfo)
+	if !bytes.Equal(out[32:], h.Sum(nil)[:8]) {
+		t.Errorf("second block doesn't use a counter of 2")
+	}
+	short, _ := OneStep(sha256.New, z, fixedInfo, 20)
+	if !bytes.Equal(short, out[:20]) {
+		t.Errorf("shorter outputs aren't prefixes of longer ones")
+	}
+}
+
+func TestOneStepHMACDefaultSalt(t *testing.T) {
+	z := []byte("shared secret")
+	out1, err := OneStepHMAC(sha256.New, z, nil, []byte("info"), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out2, _ := OneStepHMAC(sha256.New, z, make([]byte, sha256.BlockSize), []byte("info"), 32)
+	if !bytes.Equal(out1, out2) {
+		t.Errorf("empty salt doesn't default to zero bytes")
+	}
+	mac := hmac.New(sha256.New, make([]byte, sha256.BlockSize))
+	mac.Write([]byte{0, 0, 0, 1})
+	mac.Write(z)
+	mac.Write([]byte("info"))
+	if !bytes.Equal(out1, mac.Sum(nil)) {
+		t.Errorf("OneStepHMAC() = %x, expected %x", out1, mac.Sum(nil))
+	}
+}
+
+func TestTwoStep(t *testing.T) {
+	z := []byte("shared secret")
+	salt := []byte("salt")
+	fixedInfo := []byte("label\x00context\x00\x00\x01\x00")
+	out, err := TwoStep(sha256.New, z, salt, fixedInfo, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(z)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write([]byte{0, 0, 0, 1})
+	expand.Write(fixedInfo)
+	if !bytes.Equal(out, expand.Sum(nil)) {
+		t.Errorf("TwoStep() = %x, expected %x", out, expand.Sum(nil))
+	}
+	other, _ := TwoStep(sha256.New, z, []byte("other salt"), fixedInfo, 32)
+	if bytes.Equal(out, other) {
+		t.Errorf("salt doesn't affect the output")
+	}
+}
+
+func TestInvalidLength(t *testing.T) {
+	if _, err := OneStep(sha256.New, []byte("z"), nil, 0); err == nil {
+		t.Errorf("accepted an empty output")
+	}
+	if _, err := TwoStep(sha256.New, []byte("z"), nil, nil, -1); err == nil {
+		t.Errorf("accepted a negative output length")
+	}
+}
+
+func TestFixedInfoBytes(t *testing.T) {
+	info := &FixedInfo{
+		AlgorithmID:  []byte{0xaa},
+		PartyUInfo:   []byte{0xbb, 0xbb},
+		SuppPubInfo:  []byte{0xcc},
+		SuppPrivInfo: []byte{0xdd},
+	}
+	expected := "00000001aa" + "00000002bbbb" + "00000000" + "cc" + "dd"
+	if out := hex.EncodeToString(info.Bytes()); out != expected {
+		t.Errorf("Bytes() = %s, expected %s", out, expected)
+	}
+}
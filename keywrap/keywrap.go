@@ -0,0 +1,90 @@
+// Package keywrap implements the AES Key Wrap algorithm, as specified in
+// RFC 3394.
+//
+// Key wrapping encrypts key material under a key encryption key, with
+// integrity protection, without requiring a nonce.
+package keywrap
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// defaultIV is the initial value of RFC 3394, section 2.2.3.1.
+var defaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+var (
+	errInvalidLength = errors.New("keywrap: input must be a multiple of 8 bytes, and at least 16 bytes long")
+	errIntegrity     = errors.New("keywrap: integrity check failed")
+)
+
+// Wrap wraps plaintext with the block cipher, which must have a 16 byte block
+// size, as with AES.
+//
+// The plaintext must be a multiple of 8 bytes long, and contain at least two
+// blocks of 8 bytes. The output is 8 bytes longer than the plaintext.
+func Wrap(block cipher.Block, plaintext []byte) ([]byte, error) {
+	if block.BlockSize() != 16 {
+		return nil, errors.New("keywrap: block size must be 16 bytes")
+	}
+	if len(plaintext)%8 != 0 || len(plaintext) < 16 {
+		return nil, errInvalidLength
+	}
+	n := len(plaintext) / 8
+	out := make([]byte, len(plaintext)+8)
+	copy(out, defaultIV)
+	copy(out[8:], plaintext)
+
+	var b [16]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			// B = AES(K, A | R[i])
+			copy(b[:8], out[:8])
+			copy(b[8:], out[8*i:8*i+8])
+			block.Encrypt(b[:], b[:])
+			// A = MSB(64, B) ^ t, where t = (n * j) + i
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(b[:8])^t)
+			// R[i] = LSB(64, B)
+			copy(out[8*i:8*i+8], b[8:])
+		}
+	}
+	return out, nil
+}
+
+// Unwrap unwraps ciphertext produced by Wrap, with the same block cipher,
+// returning an error if the ciphertext was tampered with.
+func Unwrap(block cipher.Block, ciphertext []byte) ([]byte, error) {
+	if block.BlockSize() != 16 {
+		return nil, errors.New("keywrap: block size must be 16 bytes")
+	}
+	if len(ciphertext)%8 != 0 || len(ciphertext) < 24 {
+		return nil, errInvalidLength
+	}
+	n := len(ciphertext)/8 - 1
+	a := make([]byte, 8)
+	copy(a, ciphertext[:8])
+	out := make([]byte, len(ciphertext)-8)
+	copy(out, ciphertext[8:])
+
+	var b [16]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			// B = AES-1(K, (A ^ t) | R[i]), where t = n * j + i
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], out[8*(i-1):8*i])
+			block.Decrypt(b[:], b[:])
+			// A = MSB(64, B)
+			copy(a, b[:8])
+			// R[i] = LSB(64, B)
+			copy(out[8*(i-1):8*i], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, defaultIV) != 1 {
+		return nil, errIntegrity
+	}
+	return out, nil
+}
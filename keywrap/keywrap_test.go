@@ -0,0 +1,79 @@
+package keywrap
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	// See RFC 3394, section 4.
+	tests := []struct {
+		kek, plaintext, ciphertext string
+	}{
+		{
+			"000102030405060708090A0B0C0D0E0F",
+			"00112233445566778899AABBCCDDEEFF",
+			"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		{
+			"000102030405060708090A0B0C0D0E0F1011121314151617",
+			"00112233445566778899AABBCCDDEEFF0001020304050607",
+			"031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2",
+		},
+		{
+			"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	}
+	for _, test := range tests {
+		kek, _ := hex.DecodeString(test.kek)
+		plaintext, _ := hex.DecodeString(test.plaintext)
+		expected, _ := hex.DecodeString(test.ciphertext)
+		block, err := aes.NewCipher(kek)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, err := Wrap(block, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ciphertext, expected) {
+			t.Errorf("Wrap(%s) = %X, expected %s", test.plaintext, ciphertext, test.ciphertext)
+		}
+		decrypted, err := Unwrap(block, ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Unwrap(%s) = %X, expected %s", test.ciphertext, decrypted, test.plaintext)
+		}
+	}
+}
+
+func TestUnwrapRejectsTampering(t *testing.T) {
+	block, _ := aes.NewCipher(make([]byte, 16))
+	ciphertext, err := Wrap(block, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := Unwrap(block, ciphertext); err == nil {
+		t.Errorf("accepted a tampered ciphertext")
+	}
+}
+
+func TestBadLengths(t *testing.T) {
+	block, _ := aes.NewCipher(make([]byte, 16))
+	if _, err := Wrap(block, make([]byte, 8)); err == nil {
+		t.Errorf("wrapped a single block")
+	}
+	if _, err := Wrap(block, make([]byte, 20)); err == nil {
+		t.Errorf("wrapped a partial block")
+	}
+	if _, err := Unwrap(block, make([]byte, 16)); err == nil {
+		t.Errorf("unwrapped a short ciphertext")
+	}
+}
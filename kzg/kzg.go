@@ -0,0 +1,156 @@
+// Package kzg implements the polynomial commitments of Kate, Zaverucha, and
+// Goldberg, over the BLS12-381 curve.
+//
+// A commitment to a polynomial p of degree less than n is the single point
+// p(τ) G1, computed from a structured reference string containing the powers
+// τ^i G1, for a secret τ nobody knows. The committer can later open the
+// commitment at any point z, proving that p(z) = y with a single point of G1,
+// which is checked with a pairing.
+//
+// The reference string is usually produced by a multi-party ceremony, and can
+// be loaded from the formats used by Ethereum and Zcash, with ParseEthereumSRS,
+// ReadG1Powers, and ReadG2Powers.
+//
+// Polynomials are given by their coefficients, starting with the constant
+// term, as safenum.Nat values reduced modulo bls12381.Order.
+package kzg
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/safenum"
+)
+
+// SRS is a structured reference string, containing the powers of a secret τ.
+type SRS struct {
+	// G1[i] = τ^i G1, which bounds the degree of the polynomials that can be
+	// committed to.
+	G1 []*bls12381.G1
+	// G2[i] = τ^i G2, of which only the first two are needed to verify
+	// openings.
+	G2 []*bls12381.G2
+}
+
+var (
+	errPolynomialTooLarge = errors.New("kzg: polynomial has too many coefficients for the reference string")
+	errSRSTooSmall        = errors.New("kzg: reference string needs at least 2 powers in G2")
+)
+
+// Commit returns the commitment to a polynomial, given by its coefficients.
+//
+// The execution time doesn't depend on the values of the coefficients, only
+// on their number.
+func (srs *SRS) Commit(poly []*safenum.Nat) (*bls12381.G1, error) {
+	if len(poly) > len(srs.G1) {
+		return nil, errPolynomialTooLarge
+	}
+	out := bls12381.NewG1Identity()
+	term := new(bls12381.G1)
+	for i, c := range poly {
+		out.Add(out, term.ScalarMult(c, srs.G1[i]))
+	}
+	return out, nil
+}
+
+// evaluate returns p(z), and the coefficients of the quotient
+// (p(X) - p(z)) / (X - z), using synthetic division.
+func evaluate(poly []*safenum.Nat, z *safenum.Nat) (*safenum.Nat, []*safenum.Nat) {
+	y := new(safenum.Nat).Mod(new(safenum.Nat), bls12381.Order)
+	if len(poly) == 0 {
+		return y, nil
+	}
+	quotient := make([]*safenum.Nat, len(poly)-1)
+	for i := len(poly) - 1; i >= 0; i-- {
+		y.ModMul(y, z, bls12381.Order)
+		y.ModAdd(y, poly[i], bls12381.Order)
+		if i > 0 {
+			quotient[i-1] = new(safenum.Nat).SetNat(y)
+		}
+	}
+	return y, quotient
+}
+
+// Open evaluates a polynomial at a point z, returning y = p(z), along with a
+// proof that this evaluation is consistent with the commitment to p.
+func (srs *SRS) Open(poly []*safenum.Nat, z *safenum.Nat) (y *safenum.Nat, proof *bls12381.G1, err error) {
+	if len(poly) > len(srs.G1) {
+		return nil, nil, errPolynomialTooLarge
+	}
+	y, quotient := evaluate(poly, z)
+	proof, err = srs.Commit(quotient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return y, proof, nil
+}
+
+// Verify checks that proof shows that the polynomial committed to by
+// commitment evaluates to y at z.
+func (srs *SRS) Verify(commitment *bls12381.G1, z, y *safenum.Nat, proof *bls12381.G1) (bool, error) {
+	return srs.BatchVerify(context.Background(), []Opening{{commitment, z, y, proof}}, nil)
+}
+
+// Opening is the claim that a committed polynomial evaluates to Value at
+// Point, along with its proof. Both Point and Value must be reduced modulo
+// bls12381.Order.
+type Opening struct {
+	Commitment *bls12381.G1
+	Point      *safenum.Nat
+	Value      *safenum.Nat
+	Proof      *bls12381.G1
+}
+
+// BatchVerify checks several openings at once, possibly of different
+// polynomials at different points, which is much faster than checking them
+// separately.
+//
+// The openings are combined with random weights read from rand, so that an
+// invalid opening makes the check fail, except with negligible probability.
+// If there's only one opening, rand isn't used, and may be nil.
+//
+// Once ctx is done, BatchVerify stops, and returns ctx.Err().
+func (srs *SRS) BatchVerify(ctx context.Context, openings []Opening, rand io.Reader) (bool, error) {
+	if len(srs.G2) < 2 {
+		return false, errSRSTooSmall
+	}
+	// Each opening satisfies e(C - y G1 + z π, G2) = e(π, τ G2), which follows
+	// from p(X) - y = q(X) (X - z), so we check that
+	//
+	//	e(Σ rᵢ (Cᵢ - yᵢ G1 + zᵢ πᵢ), G2) e(-Σ rᵢ πᵢ, τ G2) = 1
+	lhs := bls12381.NewG1Identity()
+	proofs := bls12381.NewG1Identity()
+	term := new(bls12381.G1)
+	g := bls12381.NewG1Generator()
+	for _, o := range openings {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		r := new(safenum.Nat).SetUint64(1)
+		if len(openings) > 1 {
+			var err error
+			if r, err = randomScalar(rand); err != nil {
+				return false, err
+			}
+		}
+		// rᵢ Cᵢ - rᵢ yᵢ G1 + rᵢ zᵢ πᵢ
+		acc := new(bls12381.G1).ScalarMult(o.Value, g)
+		acc.Subtract(o.Commitment, acc)
+		acc.Add(acc, term.ScalarMult(o.Point, o.Proof))
+		lhs.Add(lhs, acc.ScalarMult(r, acc))
+		proofs.Add(proofs, term.ScalarMult(r, o.Proof))
+	}
+	proofs.Negate(proofs)
+	return bls12381.PairingCheck([]*bls12381.G1{lhs, proofs}, []*bls12381.G2{srs.G2[0], srs.G2[1]}), nil
+}
+
+// randomScalar returns a uniformly random scalar, read from rand.
+func randomScalar(rand io.Reader) (*safenum.Nat, error) {
+	buf := make([]byte, 64)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), bls12381.Order), nil
+}
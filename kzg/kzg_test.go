@@ -0,0 +1,108 @@
+package kzg
+
+import (
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+	"github.com/cronokirby/safenum"
+)
+
+func randomScalarT(t *testing.T) *safenum.Nat {
+	s, err := randomScalar(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// testSRS returns a reference string with a known τ, which must only be used
+// for testing.
+func testSRS(t *testing.T, n1, n2 int) (*SRS, *safenum.Nat) {
+	tau := randomScalarT(t)
+	srs := &SRS{}
+	power := new(safenum.Nat).SetUint64(1)
+	for i := 0; i < n1 || i < n2; i++ {
+		if i < n1 {
+			srs.G1 = append(srs.G1, new(bls12381.G1).ScalarBaseMult(power))
+		}
+		if i < n2 {
+			srs.G2 = append(srs.G2, new(bls12381.G2).ScalarBaseMult(power))
+		}
+		power = new(safenum.Nat).ModMul(power, tau, bls12381.Order)
+	}
+	return srs, tau
+}
+
+func randomPolynomial(t *testing.T, n int) []*safenum.Nat {
+	out := make([]*safenum.Nat, n)
+	for i := range out {
+		out[i] = randomScalarT(t)
+	}
+	return out
+}
+
+func TestCommitMatchesEvaluation(t *testing.T) {
+	srs, tau := testSRS(t, 8, 2)
+	poly := randomPolynomial(t, 8)
+	c, err := srs.Commit(poly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, _ := evaluate(poly, tau)
+	if c.Equal(new(bls12381.G1).ScalarBaseMult(y)) != 1 {
+		t.Errorf("commitment isn't p(τ) G1")
+	}
+	if _, err := srs.Commit(randomPolynomial(t, 9)); err == nil {
+		t.Errorf("committed to a polynomial larger than the reference string")
+	}
+}
+
+func TestOpenVerify(t *testing.T) {
+	srs, _ := testSRS(t, 8, 2)
+	poly := randomPolynomial(t, 5)
+	c, _ := srs.Commit(poly)
+	z := randomScalarT(t)
+	y, proof, err := srs.Open(poly, z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := srs.Verify(c, z, y, proof)
+	if err != nil || !ok {
+		t.Fatalf("valid opening rejected: %v", err)
+	}
+	wrong := new(safenum.Nat).ModAdd(y, new(safenum.Nat).SetUint64(1), bls12381.Order)
+	if ok, _ := srs.Verify(c, z, wrong, proof); ok {
+		t.Errorf("opening to the wrong value accepted")
+	}
+	if ok, _ := srs.Verify(c, randomScalarT(t), y, proof); ok {
+		t.Errorf("opening at the wrong point accepted")
+	}
+}
+
+func TestBatchVerify(t *testing.T) {
+	srs, _ := testSRS(t, 4, 2)
+	var openings []Opening
+	for i := 0; i < 3; i++ {
+		poly := randomPolynomial(t, 4)
+		c, _ := srs.Commit(poly)
+		z := randomScalarT(t)
+		y, proof, _ := srs.Open(poly, z)
+		openings = append(openings, Opening{c, z, y, proof})
+	}
+	ok, err := srs.BatchVerify(context.Background(), openings, rand.Reader)
+	if err != nil || !ok {
+		t.Fatalf("valid openings rejected: %v", err)
+	}
+	openings[1].Value = new(safenum.Nat).ModAdd(openings[1].Value, new(safenum.Nat).SetUint64(1), bls12381.Order)
+	if ok, _ := srs.BatchVerify(context.Background(), openings, rand.Reader); ok {
+		t.Errorf("invalid opening accepted in batch")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := srs.BatchVerify(ctx, openings, rand.Reader); err != context.Canceled {
+		t.Errorf("got %v, expected %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,179 @@
+package kzg
+
+// This file handles loading reference strings produced by the ceremonies of
+// Ethereum and Zcash.
+//
+// Ethereum's ceremony for EIP-4844 publishes a JSON file, with the powers of τ
+// as hex encoded compressed points, under the keys "g1_monomial" and
+// "g2_monomial", or "setup_G1" and "setup_G2" in older versions. The points in
+// Lagrange form aren't used by this package.
+//
+// Zcash's "powers of tau" ceremony, and its descendants, publish binary files
+// made of points in Zcash's serialization, which is also the one used by
+// bls12381. The layout of these files varies, but they contain runs of
+// consecutive powers in G1 and G2, which can be read with ReadG1Powers and
+// ReadG2Powers, after skipping any header.
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+)
+
+// ethereumSetup is the JSON layout of Ethereum's trusted setup files.
+type ethereumSetup struct {
+	G1Monomial []string `json:"g1_monomial"`
+	G2Monomial []string `json:"g2_monomial"`
+	SetupG1    []string `json:"setup_G1"`
+	SetupG2    []string `json:"setup_G2"`
+}
+
+func decodeHexPoint(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
+
+// ParseEthereumSRS parses a reference string from the JSON format of
+// Ethereum's KZG ceremony, such as the trusted_setup.json file of EIP-4844.
+//
+// The points are checked to be valid members of G1 and G2, but the string
+// itself isn't checked to be well-formed, which can be done with Validate.
+func ParseEthereumSRS(data []byte) (*SRS, error) {
+	var setup ethereumSetup
+	if err := json.Unmarshal(data, &setup); err != nil {
+		return nil, err
+	}
+	g1, g2 := setup.G1Monomial, setup.G2Monomial
+	if len(g1) == 0 {
+		g1, g2 = setup.SetupG1, setup.SetupG2
+	}
+	if len(g1) == 0 || len(g2) == 0 {
+		return nil, errors.New("kzg: missing monomial powers in setup")
+	}
+	srs := &SRS{G1: make([]*bls12381.G1, len(g1)), G2: make([]*bls12381.G2, len(g2))}
+	for i, s := range g1 {
+		b, err := decodeHexPoint(s)
+		if err != nil {
+			return nil, err
+		}
+		if srs.G1[i], err = new(bls12381.G1).SetBytes(b); err != nil {
+			return nil, err
+		}
+	}
+	for i, s := range g2 {
+		b, err := decodeHexPoint(s)
+		if err != nil {
+			return nil, err
+		}
+		if srs.G2[i], err = new(bls12381.G2).SetBytes(b); err != nil {
+			return nil, err
+		}
+	}
+	return srs, nil
+}
+
+// ReadG1Powers reads n consecutive points of G1 from r, each in the compressed
+// or uncompressed serialization of Zcash.
+func ReadG1Powers(r io.Reader, n int, compressed bool) ([]*bls12381.G1, error) {
+	size := bls12381.G1UncompressedSize
+	if compressed {
+		size = bls12381.G1CompressedSize
+	}
+	buf := make([]byte, size)
+	out := make([]*bls12381.G1, n)
+	for i := range out {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		var err error
+		if out[i], err = new(bls12381.G1).SetBytes(buf); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// ReadG2Powers reads n consecutive points of G2 from r, each in the compressed
+// or uncompressed serialization of Zcash.
+func ReadG2Powers(r io.Reader, n int, compressed bool) ([]*bls12381.G2, error) {
+	size := bls12381.G2UncompressedSize
+	if compressed {
+		size = bls12381.G2CompressedSize
+	}
+	buf := make([]byte, size)
+	out := make([]*bls12381.G2, n)
+	for i := range out {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		var err error
+		if out[i], err = new(bls12381.G2).SetBytes(buf); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Validate checks that the reference string is well-formed, i.e. that it
+// starts with the standard generators, and that each point is τ times the
+// previous one, for the same τ in G1 and G2.
+//
+// This uses random weights read from rand to check all of the powers with a
+// few pairings. It can't check that τ was generated honestly, which is what
+// the ceremonies are for.
+//
+// Once ctx is done, Validate stops, and returns ctx.Err().
+func (srs *SRS) Validate(ctx context.Context, rand io.Reader) error {
+	errInvalid := errors.New("kzg: invalid reference string")
+	if len(srs.G2) < 2 || len(srs.G1) < 1 {
+		return errSRSTooSmall
+	}
+	if srs.G1[0].Equal(bls12381.NewG1Generator()) != 1 || srs.G2[0].Equal(bls12381.NewG2Generator()) != 1 {
+		return errInvalid
+	}
+	// With random rᵢ, Σ rᵢ τ^(i+1) G1 = τ (Σ rᵢ τ^i G1) checks all of the
+	// powers in G1 against τ G2, and similarly for the powers in G2.
+	lo, hi := bls12381.NewG1Identity(), bls12381.NewG1Identity()
+	for i := 0; i+1 < len(srs.G1); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := randomScalar(rand)
+		if err != nil {
+			return err
+		}
+		lo.Add(lo, new(bls12381.G1).ScalarMult(r, srs.G1[i]))
+		hi.Add(hi, new(bls12381.G1).ScalarMult(r, srs.G1[i+1]))
+	}
+	lo.Negate(lo)
+	if !bls12381.PairingCheck([]*bls12381.G1{hi, lo}, []*bls12381.G2{srs.G2[0], srs.G2[1]}) {
+		return errInvalid
+	}
+	if len(srs.G2) == 2 {
+		return nil
+	}
+	if len(srs.G1) < 2 {
+		return errInvalid
+	}
+	lo2, hi2 := bls12381.NewG2Identity(), bls12381.NewG2Identity()
+	for i := 0; i+1 < len(srs.G2); i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r, err := randomScalar(rand)
+		if err != nil {
+			return err
+		}
+		lo2.Add(lo2, new(bls12381.G2).ScalarMult(r, srs.G2[i]))
+		hi2.Add(hi2, new(bls12381.G2).ScalarMult(r, srs.G2[i+1]))
+	}
+	tau := new(bls12381.G1).Negate(srs.G1[1])
+	if !bls12381.PairingCheck([]*bls12381.G1{bls12381.NewG1Generator(), tau}, []*bls12381.G2{hi2, lo2}) {
+		return errInvalid
+	}
+	return nil
+}
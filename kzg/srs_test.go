@@ -0,0 +1,110 @@
+package kzg
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/bls12381"
+)
+
+func TestParseEthereumSRS(t *testing.T) {
+	srs, _ := testSRS(t, 4, 3)
+	var setup ethereumSetup
+	for _, p := range srs.G1 {
+		setup.G1Monomial = append(setup.G1Monomial, "0x"+hex.EncodeToString(p.Bytes()))
+	}
+	for _, p := range srs.G2 {
+		setup.G2Monomial = append(setup.G2Monomial, "0x"+hex.EncodeToString(p.Bytes()))
+	}
+	data, _ := json.Marshal(setup)
+	parsed, err := ParseEthereumSRS(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.G1) != 4 || len(parsed.G2) != 3 {
+		t.Fatalf("parsed %d and %d powers", len(parsed.G1), len(parsed.G2))
+	}
+	for i := range srs.G1 {
+		if parsed.G1[i].Equal(srs.G1[i]) != 1 {
+			t.Errorf("G1 power %d is wrong", i)
+		}
+	}
+	for i := range srs.G2 {
+		if parsed.G2[i].Equal(srs.G2[i]) != 1 {
+			t.Errorf("G2 power %d is wrong", i)
+		}
+	}
+
+	// The older layout of the consensus specs uses different keys.
+	old, _ := json.Marshal(map[string][]string{"setup_G1": setup.G1Monomial, "setup_G2": setup.G2Monomial})
+	if _, err := ParseEthereumSRS(old); err != nil {
+		t.Errorf("failed to parse the older layout: %v", err)
+	}
+	if _, err := ParseEthereumSRS([]byte(`{"g1_monomial": ["0x00"], "g2_monomial": []}`)); err == nil {
+		t.Errorf("parsed an invalid setup")
+	}
+}
+
+func TestReadPowers(t *testing.T) {
+	srs, _ := testSRS(t, 3, 2)
+	for _, compressed := range []bool{true, false} {
+		var buf bytes.Buffer
+		buf.WriteString("header")
+		for _, p := range srs.G1 {
+			if compressed {
+				buf.Write(p.Bytes())
+			} else {
+				buf.Write(p.BytesUncompressed())
+			}
+		}
+		for _, p := range srs.G2 {
+			if compressed {
+				buf.Write(p.Bytes())
+			} else {
+				buf.Write(p.BytesUncompressed())
+			}
+		}
+		buf.Next(len("header"))
+		g1, err := ReadG1Powers(&buf, 3, compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g2, err := ReadG2Powers(&buf, 2, compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read := &SRS{g1, g2}
+		if err := read.Validate(context.Background(), rand.Reader); err != nil {
+			t.Errorf("read an invalid reference string: %v", err)
+		}
+		if _, err := ReadG1Powers(&buf, 1, compressed); err == nil {
+			t.Errorf("read past the end of the data")
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srs, _ := testSRS(t, 4, 3)
+	if err := srs.Validate(context.Background(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	badG1 := &SRS{append([]*bls12381.G1{}, srs.G1...), srs.G2}
+	badG1.G1[2] = new(bls12381.G1).Add(badG1.G1[2], badG1.G1[0])
+	if badG1.Validate(context.Background(), rand.Reader) == nil {
+		t.Errorf("accepted an inconsistent power in G1")
+	}
+	badG2 := &SRS{srs.G1, append([]*bls12381.G2{}, srs.G2...)}
+	badG2.G2[2] = new(bls12381.G2).Add(badG2.G2[2], badG2.G2[0])
+	if badG2.Validate(context.Background(), rand.Reader) == nil {
+		t.Errorf("accepted an inconsistent power in G2")
+	}
+	other, _ := testSRS(t, 4, 3)
+	mixed := &SRS{srs.G1, other.G2}
+	if mixed.Validate(context.Background(), rand.Reader) == nil {
+		t.Errorf("accepted powers of different secrets")
+	}
+}
@@ -0,0 +1,6 @@
+package lockedmem
+
+// dontDump does nothing, since macOS has no equivalent of MADV_DONTDUMP.
+func dontDump(b []byte) error {
+	return nil
+}
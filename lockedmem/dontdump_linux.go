@@ -0,0 +1,11 @@
+package lockedmem
+
+import "syscall"
+
+// madvDontDump is MADV_DONTDUMP, which the syscall package doesn't define.
+const madvDontDump = 0x10
+
+// dontDump excludes memory from core dumps.
+func dontDump(b []byte) error {
+	return syscall.Madvise(b, madvDontDump)
+}
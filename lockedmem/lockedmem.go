@@ -0,0 +1,99 @@
+// Package lockedmem provides buffers for secrets, like private keys, which are
+// kept out of swap and core dumps, and wiped when released.
+//
+// On Linux and macOS, a buffer is mapped separately from the Go heap, locked in
+// memory with mlock, and surrounded by guard pages, which make out-of-bounds
+// accesses fault, as well as a canary, checked on Close, catching writes just
+// before the buffer. On Linux, the mapping is also excluded from core dumps.
+// Elsewhere, buffers are ordinary slices, which are only wiped on Close, as
+// Locked reports.
+//
+// This only protects the secrets stored in a buffer: the temporary values of
+// computations using them still live on the Go heap.
+package lockedmem
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"sync"
+)
+
+// canarySize is the size of the canary placed before the data of a buffer.
+const canarySize = 16
+
+var (
+	canaryOnce sync.Once
+	canary     [canarySize]byte
+)
+
+// processCanary returns the random canary shared by the buffers of this
+// process.
+func processCanary() []byte {
+	canaryOnce.Do(func() {
+		if _, err := rand.Read(canary[:]); err != nil {
+			panic("lockedmem: failed to generate canary: " + err.Error())
+		}
+	})
+	return canary[:]
+}
+
+// ErrCanary is returned by Close when the canary of a buffer was overwritten,
+// which means that the memory before the buffer was written to.
+var ErrCanary = errors.New("lockedmem: canary overwritten")
+
+// Buffer is a fixed-size buffer for secrets.
+//
+// A Buffer must be released with Close, and is not safe for concurrent use.
+type Buffer struct {
+	data   []byte
+	canary []byte
+	locked bool
+	closed bool
+	// mapping is the whole memory mapped for the buffer, if any.
+	mapping []byte
+}
+
+// New allocates a buffer of size bytes, initially zero.
+//
+// An error is returned if the memory can't be mapped or locked, for example
+// because of RLIMIT_MEMLOCK.
+func New(size int) (*Buffer, error) {
+	if size < 0 {
+		return nil, errors.New("lockedmem: negative size")
+	}
+	return newBuffer(size)
+}
+
+// Bytes returns the memory of the buffer, whose length and capacity are its
+// size, and which must not be used after Close.
+func (b *Buffer) Bytes() []byte {
+	return b.data
+}
+
+// Locked reports whether the buffer is locked in memory, and kept out of swap.
+func (b *Buffer) Locked() bool {
+	return b.locked
+}
+
+// Close wipes the buffer, checks its canary, and releases its memory.
+//
+// Calling Close more than once does nothing.
+func (b *Buffer) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	for i := range b.data {
+		b.data[i] = 0
+	}
+	var err error
+	if subtle.ConstantTimeCompare(b.canary, processCanary()) != 1 {
+		err = ErrCanary
+	}
+	if releaseErr := b.release(); err == nil {
+		err = releaseErr
+	}
+	b.data, b.canary, b.mapping = nil, nil, nil
+	return err
+}
@@ -0,0 +1,14 @@
+// +build !darwin,!linux
+
+package lockedmem
+
+// newBuffer falls back to the Go heap, which can't be locked.
+func newBuffer(size int) (*Buffer, error) {
+	b := &Buffer{data: make([]byte, size), canary: make([]byte, canarySize)}
+	copy(b.canary, processCanary())
+	return b, nil
+}
+
+func (b *Buffer) release() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package lockedmem
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newTestBuffer(t *testing.T, size int) *Buffer {
+	b, err := New(size)
+	if err != nil {
+		t.Skipf("can't allocate locked memory: %v", err)
+	}
+	return b
+}
+
+func TestBuffer(t *testing.T) {
+	for _, size := range []int{0, 1, 32, 4096, 5000} {
+		b := newTestBuffer(t, size)
+		data := b.Bytes()
+		if len(data) != size || cap(data) != size {
+			t.Errorf("New(%d): len %d, cap %d", size, len(data), cap(data))
+		}
+		if !bytes.Equal(data, make([]byte, size)) {
+			t.Errorf("New(%d): buffer isn't zero", size)
+		}
+		for i := range data {
+			data[i] = byte(i)
+		}
+		if err := b.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+		if b.Bytes() != nil {
+			t.Errorf("Bytes after Close isn't nil")
+		}
+		if err := b.Close(); err != nil {
+			t.Errorf("second Close: %v", err)
+		}
+	}
+}
+
+func TestCanary(t *testing.T) {
+	b := newTestBuffer(t, 32)
+	b.canary[len(b.canary)-1] ^= 1
+	if err := b.Close(); err != ErrCanary {
+		t.Errorf("Close with an overwritten canary returned %v", err)
+	}
+}
+
+func TestNegativeSize(t *testing.T) {
+	if _, err := New(-1); err == nil {
+		t.Errorf("New(-1) succeeded")
+	}
+}
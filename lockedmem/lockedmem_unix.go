@@ -0,0 +1,54 @@
+// +build darwin linux
+
+package lockedmem
+
+import (
+	"os"
+	"syscall"
+)
+
+// newBuffer maps a guard page, the pages holding the canary and the data, and
+// another guard page. The data ends right before the second guard page, so
+// that overflows fault, while underflows overwrite the canary.
+func newBuffer(size int) (*Buffer, error) {
+	page := os.Getpagesize()
+	inner := (canarySize + size + page - 1) / page * page
+	mapping, err := syscall.Mmap(-1, 0, inner+2*page, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	b := &Buffer{mapping: mapping}
+	fail := func(err error) (*Buffer, error) {
+		syscall.Munmap(mapping)
+		return nil, err
+	}
+	if err := syscall.Mprotect(mapping[:page], syscall.PROT_NONE); err != nil {
+		return fail(err)
+	}
+	if err := syscall.Mprotect(mapping[page+inner:], syscall.PROT_NONE); err != nil {
+		return fail(err)
+	}
+	innerPages := mapping[page : page+inner]
+	if err := syscall.Mlock(innerPages); err != nil {
+		return fail(err)
+	}
+	b.locked = true
+	if err := dontDump(innerPages); err != nil {
+		syscall.Munlock(innerPages)
+		return fail(err)
+	}
+	end := page + inner
+	b.data = mapping[end-size : end : end]
+	b.canary = mapping[end-size-canarySize : end-size : end-size]
+	copy(b.canary, processCanary())
+	return b, nil
+}
+
+func (b *Buffer) release() error {
+	page := os.Getpagesize()
+	if err := syscall.Munlock(b.mapping[page : len(b.mapping)-page]); err != nil {
+		syscall.Munmap(b.mapping)
+		return err
+	}
+	return syscall.Munmap(b.mapping)
+}
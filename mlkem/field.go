@@ -0,0 +1,280 @@
+package mlkem
+
+import (
+	"golang.org/x/crypto/sha3"
+)
+
+// q is the prime modulus of the field, and n the degree of the polynomials.
+const (
+	q = 3329
+	n = 256
+)
+
+// fieldElement is an integer modulo q, always reduced to [0, q).
+type fieldElement uint16
+
+// barrettMultiplier is floor(2^32 / q). With it, (x * barrettMultiplier) >>
+// 32 is floor(x / q), or one less, for any 32 bit x, without a division
+// instruction, whose time could depend on x.
+const barrettMultiplier = (1 << 32) / q
+
+// divq returns floor(x / q) for any 32 bit x, in constant time.
+func divq(x uint32) uint32 {
+	quotient := uint32((uint64(x) * barrettMultiplier) >> 32)
+	r := x - quotient*q
+	// The remainder is in [0, 2q), and one more is added to the quotient if
+	// it is in [q, 2q).
+	return quotient + (uint32(q-1-int32(r)) >> 31)
+}
+
+// reduceOnce returns x mod q, for x in [0, 2q).
+func reduceOnce(x uint16) fieldElement {
+	t := x - q
+	// If x < q, t wrapped around, and its top bit is set.
+	mask := -(t >> 15)
+	return fieldElement(t ^ ((x ^ t) & mask))
+}
+
+func fieldAdd(a, b fieldElement) fieldElement {
+	return reduceOnce(uint16(a + b))
+}
+
+func fieldSub(a, b fieldElement) fieldElement {
+	return reduceOnce(uint16(a - b + q))
+}
+
+// fieldReduce returns x mod q, for any 32 bit x.
+func fieldReduce(x uint32) fieldElement {
+	return fieldElement(x - divq(x)*q)
+}
+
+func fieldMul(a, b fieldElement) fieldElement {
+	return fieldReduce(uint32(a) * uint32(b))
+}
+
+// compress maps x to round(2^d / q * x) mod 2^d, as in FIPS 203, section 4.2.1.
+func compress(x fieldElement, d uint8) uint16 {
+	// round(2^d * x / q) = floor((2^d * x + floor(q / 2)) / q), since q is odd.
+	return uint16(divq(uint32(x)<<d+q/2)) & (1<<d - 1)
+}
+
+// decompress maps y to round(q / 2^d * y).
+func decompress(y uint16, d uint8) fieldElement {
+	return fieldElement((uint32(y)*q + 1<<(d-1)) >> d)
+}
+
+// ringElement is a polynomial of degree less than n, with coefficients in the
+// field, and nttElement is its representation in the NTT domain.
+type ringElement [n]fieldElement
+type nttElement [n]fieldElement
+
+func polyAdd(a, b *[n]fieldElement) (s [n]fieldElement) {
+	for i := range s {
+		s[i] = fieldAdd(a[i], b[i])
+	}
+	return s
+}
+
+func polySub(a, b *[n]fieldElement) (s [n]fieldElement) {
+	for i := range s {
+		s[i] = fieldSub(a[i], b[i])
+	}
+	return s
+}
+
+// zetas are 17^BitRev7(i) mod q, and gammas are 17^(2 BitRev7(i) + 1) mod q,
+// where 17 is a primitive 256th root of unity modulo q.
+var zetas, gammas [128]fieldElement
+
+func init() {
+	var pow [256]fieldElement
+	pow[0] = 1
+	for i := 1; i < len(pow); i++ {
+		pow[i] = fieldMul(pow[i-1], 17)
+	}
+	for i := range zetas {
+		r := 0
+		for b := 0; b < 7; b++ {
+			r |= (i >> b & 1) << (6 - b)
+		}
+		zetas[i] = pow[r]
+		gammas[i] = pow[2*r+1]
+	}
+}
+
+// ntt computes the NTT of f, following FIPS 203, Algorithm 9.
+func ntt(f ringElement) nttElement {
+	k := 1
+	for length := 128; length >= 2; length /= 2 {
+		for start := 0; start < n; start += 2 * length {
+			zeta := zetas[k]
+			k++
+			for j := start; j < start+length; j++ {
+				t := fieldMul(zeta, f[j+length])
+				f[j+length] = fieldSub(f[j], t)
+				f[j] = fieldAdd(f[j], t)
+			}
+		}
+	}
+	return nttElement(f)
+}
+
+// inverseNTT computes the inverse NTT of f, following FIPS 203, Algorithm 10.
+func inverseNTT(f nttElement) ringElement {
+	k := 127
+	for length := 2; length <= 128; length *= 2 {
+		for start := 0; start < n; start += 2 * length {
+			zeta := zetas[k]
+			k--
+			for j := start; j < start+length; j++ {
+				t := f[j]
+				f[j] = fieldAdd(t, f[j+length])
+				f[j+length] = fieldMul(zeta, fieldSub(f[j+length], t))
+			}
+		}
+	}
+	// 3303 is 128^-1 mod q.
+	for i := range f {
+		f[i] = fieldMul(f[i], 3303)
+	}
+	return ringElement(f)
+}
+
+// nttMul multiplies two NTT representations, following FIPS 203, Algorithms
+// 11 and 12.
+func nttMul(f, g *nttElement) (h nttElement) {
+	for i := 0; i < n/2; i++ {
+		a0, a1 := f[2*i], f[2*i+1]
+		b0, b1 := g[2*i], g[2*i+1]
+		h[2*i] = fieldAdd(fieldMul(a0, b0), fieldMul(fieldMul(a1, b1), gammas[i]))
+		h[2*i+1] = fieldAdd(fieldMul(a0, b1), fieldMul(a1, b0))
+	}
+	return h
+}
+
+// byteEncode appends the d bit encoding of each coefficient of f to b,
+// following FIPS 203, Algorithm 5.
+func byteEncode(b []byte, f *[n]uint16, d uint8) []byte {
+	var acc uint32
+	var bits uint8
+	for _, x := range f {
+		acc |= uint32(x) << bits
+		bits += d
+		for bits >= 8 {
+			b = append(b, byte(acc))
+			acc >>= 8
+			bits -= 8
+		}
+	}
+	return b
+}
+
+// byteDecode decodes d bit coefficients from b, following FIPS 203,
+// Algorithm 6, without reducing them.
+func byteDecode(b []byte, d uint8) (f [n]uint16) {
+	var acc uint32
+	var bits uint8
+	i := 0
+	for j := range f {
+		for bits < d {
+			acc |= uint32(b[i]) << bits
+			i++
+			bits += 8
+		}
+		f[j] = uint16(acc & (1<<d - 1))
+		acc >>= d
+		bits -= d
+	}
+	return f
+}
+
+// encode12 appends the 12 bit encoding of f to b.
+func encode12(b []byte, f *[n]fieldElement) []byte {
+	var x [n]uint16
+	for i := range f {
+		x[i] = uint16(f[i])
+	}
+	return byteEncode(b, &x, 12)
+}
+
+// decode12 decodes an element from 384 bytes, reporting whether every
+// coefficient was reduced, as checked on encapsulation keys.
+func decode12(b []byte) ([n]fieldElement, bool) {
+	x := byteDecode(b, 12)
+	var f [n]fieldElement
+	for i := range x {
+		if x[i] >= q {
+			return f, false
+		}
+		f[i] = fieldElement(x[i])
+	}
+	return f, true
+}
+
+// compressAndEncode appends the d bit compression of f to b.
+func compressAndEncode(b []byte, f *ringElement, d uint8) []byte {
+	var x [n]uint16
+	for i := range f {
+		x[i] = compress(f[i], d)
+	}
+	return byteEncode(b, &x, d)
+}
+
+// decodeAndDecompress decodes and decompresses an element from 32 d bytes.
+func decodeAndDecompress(b []byte, d uint8) (f ringElement) {
+	x := byteDecode(b, d)
+	for i := range f {
+		f[i] = decompress(x[i], d)
+	}
+	return f
+}
+
+// sampleNTT samples an element of the matrix A, from the seed rho and the
+// indices j and i, following FIPS 203, Algorithm 7.
+//
+// The rejection sampling only depends on public data.
+func sampleNTT(rho []byte, j, i byte) (a nttElement) {
+	xof := sha3.NewShake128()
+	xof.Write(rho)
+	xof.Write([]byte{j, i})
+	var buf [168]byte
+	k := 0
+	for k < n {
+		xof.Read(buf[:])
+		for off := 0; off+3 <= len(buf) && k < n; off += 3 {
+			d1 := uint16(buf[off]) | uint16(buf[off+1]&0x0f)<<8
+			d2 := uint16(buf[off+1]>>4) | uint16(buf[off+2])<<4
+			if d1 < q {
+				a[k] = fieldElement(d1)
+				k++
+			}
+			if d2 < q && k < n {
+				a[k] = fieldElement(d2)
+				k++
+			}
+		}
+	}
+	return a
+}
+
+// samplePolyCBD samples an element from the centered binomial distribution
+// with parameter eta, using PRF_eta(s, b), following FIPS 203, Algorithm 8.
+func samplePolyCBD(s []byte, b byte, eta int) (f ringElement) {
+	prf := sha3.NewShake256()
+	prf.Write(s)
+	prf.Write([]byte{b})
+	buf := make([]byte, 64*eta)
+	prf.Read(buf)
+	bit := func(i int) fieldElement {
+		return fieldElement(buf[i/8] >> (i % 8) & 1)
+	}
+	for i := range f {
+		var x, y fieldElement
+		for j := 0; j < eta; j++ {
+			x += bit(2*i*eta + j)
+			y += bit(2*i*eta + eta + j)
+		}
+		f[i] = fieldSub(x, y)
+	}
+	return f
+}
@@ -0,0 +1,329 @@
+// Package mlkem implements ML-KEM, the module-lattice-based key encapsulation
+// mechanism of FIPS 203, formerly known as Kyber, with the ML-KEM-512,
+// ML-KEM-768 and ML-KEM-1024 parameter sets.
+//
+// Unlike the key agreements of the ecdh package, ML-KEM is believed to resist
+// attacks by quantum computers. ML-KEM-768 is the usual choice.
+//
+// The arithmetic modulo q uses Barrett reductions instead of divisions, and
+// decapsulation compares and selects its result in constant time, so that no
+// branch or memory access depends on secret data. The rejection sampling of
+// the matrix A only depends on the public seed.
+package mlkem
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// SharedKeySize is the size of the shared keys, in bytes.
+	SharedKeySize = 32
+	// SeedSize is the size of the seed of a decapsulation key, in bytes.
+	SeedSize = 64
+)
+
+// Params is an ML-KEM parameter set.
+type Params struct {
+	name       string
+	k          int
+	eta1, eta2 int
+	du, dv     uint8
+}
+
+var (
+	mlkem512  = &Params{name: "ML-KEM-512", k: 2, eta1: 3, eta2: 2, du: 10, dv: 4}
+	mlkem768  = &Params{name: "ML-KEM-768", k: 3, eta1: 2, eta2: 2, du: 10, dv: 4}
+	mlkem1024 = &Params{name: "ML-KEM-1024", k: 4, eta1: 2, eta2: 2, du: 11, dv: 5}
+)
+
+// MLKEM512 returns the ML-KEM-512 parameter set, of security category 1.
+func MLKEM512() *Params { return mlkem512 }
+
+// MLKEM768 returns the ML-KEM-768 parameter set, of security category 3.
+func MLKEM768() *Params { return mlkem768 }
+
+// MLKEM1024 returns the ML-KEM-1024 parameter set, of security category 5.
+func MLKEM1024() *Params { return mlkem1024 }
+
+// String returns the name of the parameter set, like "ML-KEM-768".
+func (p *Params) String() string {
+	return p.name
+}
+
+// EncapsulationKeySize returns the size of encapsulation keys, in bytes.
+func (p *Params) EncapsulationKeySize() int {
+	return 384*p.k + 32
+}
+
+// CiphertextSize returns the size of ciphertexts, in bytes.
+func (p *Params) CiphertextSize() int {
+	return 32 * (int(p.du)*p.k + int(p.dv))
+}
+
+var (
+	errInvalidSeed   = errors.New("mlkem: invalid seed length")
+	errInvalidKey    = errors.New("mlkem: invalid encapsulation key")
+	errInvalidLength = errors.New("mlkem: invalid ciphertext length")
+)
+
+// EncapsulationKey is the public key of ML-KEM.
+type EncapsulationKey struct {
+	params *Params
+	// t is the vector t, in the NTT domain, and rho the seed of A.
+	t   []nttElement
+	rho [32]byte
+	// a is the matrix A, row by row, in the NTT domain.
+	a []nttElement
+	// encoded is the encoding of the key, and h its hash.
+	encoded []byte
+	h       [32]byte
+}
+
+// DecapsulationKey is the private key of ML-KEM.
+type DecapsulationKey struct {
+	ek EncapsulationKey
+	// d and z are the two halves of the seed.
+	d, z [32]byte
+	s    []nttElement
+}
+
+// GenerateKey generates a new decapsulation key, reading randomness from rand.
+func (p *Params) GenerateKey(rand io.Reader) (*DecapsulationKey, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, err
+	}
+	return p.NewDecapsulationKey(seed)
+}
+
+// NewDecapsulationKey derives a decapsulation key from a 64 byte seed, which
+// is the concatenation of d and z of FIPS 203, and the format returned by
+// Bytes.
+func (p *Params) NewDecapsulationKey(seed []byte) (*DecapsulationKey, error) {
+	if len(seed) != SeedSize {
+		return nil, errInvalidSeed
+	}
+	dk := &DecapsulationKey{}
+	copy(dk.d[:], seed[:32])
+	copy(dk.z[:], seed[32:])
+	dk.ek.params = p
+	p.keyGen(dk)
+	return dk, nil
+}
+
+// keyGen is K-PKE.KeyGen, from FIPS 203, Algorithm 13.
+func (p *Params) keyGen(dk *DecapsulationKey) {
+	g := sha3.New512()
+	g.Write(dk.d[:])
+	g.Write([]byte{byte(p.k)})
+	rhoSigma := g.Sum(nil)
+	copy(dk.ek.rho[:], rhoSigma[:32])
+	sigma := rhoSigma[32:]
+
+	dk.ek.a = p.expandA(dk.ek.rho[:])
+	var counter byte
+	dk.s = make([]nttElement, p.k)
+	for i := range dk.s {
+		dk.s[i] = ntt(samplePolyCBD(sigma, counter, p.eta1))
+		counter++
+	}
+	dk.ek.t = make([]nttElement, p.k)
+	for i := range dk.ek.t {
+		e := ntt(samplePolyCBD(sigma, counter, p.eta1))
+		counter++
+		for j := range dk.s {
+			prod := nttMul(&dk.ek.a[i*p.k+j], &dk.s[j])
+			e = nttElement(polyAdd((*[n]fieldElement)(&e), (*[n]fieldElement)(&prod)))
+		}
+		dk.ek.t[i] = e
+	}
+	dk.ek.encode()
+}
+
+// expandA samples the matrix A from the seed rho.
+func (p *Params) expandA(rho []byte) []nttElement {
+	a := make([]nttElement, p.k*p.k)
+	for i := 0; i < p.k; i++ {
+		for j := 0; j < p.k; j++ {
+			a[i*p.k+j] = sampleNTT(rho, byte(j), byte(i))
+		}
+	}
+	return a
+}
+
+// encode sets the encoding of an encapsulation key, and its hash.
+func (ek *EncapsulationKey) encode() {
+	b := make([]byte, 0, ek.params.EncapsulationKeySize())
+	for i := range ek.t {
+		b = encode12(b, (*[n]fieldElement)(&ek.t[i]))
+	}
+	ek.encoded = append(b, ek.rho[:]...)
+	ek.h = sha3.Sum256(ek.encoded)
+}
+
+// NewEncapsulationKey parses an encapsulation key, performing the modulus
+// check of FIPS 203, section 7.2.
+func (p *Params) NewEncapsulationKey(b []byte) (*EncapsulationKey, error) {
+	if len(b) != p.EncapsulationKeySize() {
+		return nil, errInvalidKey
+	}
+	ek := &EncapsulationKey{params: p}
+	ek.t = make([]nttElement, p.k)
+	for i := range ek.t {
+		t, ok := decode12(b[384*i : 384*(i+1)])
+		if !ok {
+			return nil, errInvalidKey
+		}
+		ek.t[i] = nttElement(t)
+	}
+	copy(ek.rho[:], b[384*p.k:])
+	ek.a = p.expandA(ek.rho[:])
+	ek.encode()
+	return ek, nil
+}
+
+// Params returns the parameter set of the key.
+func (ek *EncapsulationKey) Params() *Params {
+	return ek.params
+}
+
+// Bytes returns the encoding of the encapsulation key.
+func (ek *EncapsulationKey) Bytes() []byte {
+	return append([]byte(nil), ek.encoded...)
+}
+
+// Encapsulate generates a shared key, and a ciphertext encapsulating it for
+// the holder of the decapsulation key, reading randomness from rand.
+func (ek *EncapsulationKey) Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error) {
+	var m [32]byte
+	if _, err := io.ReadFull(rand, m[:]); err != nil {
+		return nil, nil, err
+	}
+	sharedKey, ciphertext = ek.encapsulate(&m)
+	return sharedKey, ciphertext, nil
+}
+
+// encapsulate is ML-KEM.Encaps_internal, from FIPS 203, Algorithm 17.
+func (ek *EncapsulationKey) encapsulate(m *[32]byte) (sharedKey, ciphertext []byte) {
+	g := sha3.New512()
+	g.Write(m[:])
+	g.Write(ek.h[:])
+	kr := g.Sum(nil)
+	return kr[:32], ek.encrypt(m, kr[32:])
+}
+
+// encrypt is K-PKE.Encrypt, from FIPS 203, Algorithm 14.
+func (ek *EncapsulationKey) encrypt(m *[32]byte, r []byte) []byte {
+	p := ek.params
+	var counter byte
+	y := make([]nttElement, p.k)
+	for i := range y {
+		y[i] = ntt(samplePolyCBD(r, counter, p.eta1))
+		counter++
+	}
+
+	c := make([]byte, 0, p.CiphertextSize())
+	for i := 0; i < p.k; i++ {
+		// u[i] = NTT^-1(sum A[j][i] * y[j]) + e1[i]
+		var acc nttElement
+		for j := range y {
+			prod := nttMul(&ek.a[j*p.k+i], &y[j])
+			acc = nttElement(polyAdd((*[n]fieldElement)(&acc), (*[n]fieldElement)(&prod)))
+		}
+		e1 := samplePolyCBD(r, counter, p.eta2)
+		counter++
+		u := inverseNTT(acc)
+		u = ringElement(polyAdd((*[n]fieldElement)(&u), (*[n]fieldElement)(&e1)))
+		c = compressAndEncode(c, &u, p.du)
+	}
+
+	// v = NTT^-1(sum t[i] * y[i]) + e2 + Decompress_1(m)
+	var acc nttElement
+	for i := range y {
+		prod := nttMul(&ek.t[i], &y[i])
+		acc = nttElement(polyAdd((*[n]fieldElement)(&acc), (*[n]fieldElement)(&prod)))
+	}
+	e2 := samplePolyCBD(r, counter, p.eta2)
+	v := inverseNTT(acc)
+	v = ringElement(polyAdd((*[n]fieldElement)(&v), (*[n]fieldElement)(&e2)))
+	mu := decodeAndDecompress(m[:], 1)
+	v = ringElement(polyAdd((*[n]fieldElement)(&v), (*[n]fieldElement)(&mu)))
+	return compressAndEncode(c, &v, p.dv)
+}
+
+// Params returns the parameter set of the key.
+func (dk *DecapsulationKey) Params() *Params {
+	return dk.ek.params
+}
+
+// EncapsulationKey returns the public key matching the decapsulation key.
+func (dk *DecapsulationKey) EncapsulationKey() *EncapsulationKey {
+	ek := dk.ek
+	return &ek
+}
+
+// Bytes returns the 64 byte seed of the decapsulation key.
+func (dk *DecapsulationKey) Bytes() []byte {
+	b := make([]byte, 0, SeedSize)
+	b = append(b, dk.d[:]...)
+	return append(b, dk.z[:]...)
+}
+
+// ExpandedBytes returns the decapsulation key in the expanded format of FIPS
+// 203, dk_PKE || ek || H(ek) || z, which some other implementations use.
+func (dk *DecapsulationKey) ExpandedBytes() []byte {
+	b := make([]byte, 0, 768*dk.ek.params.k+96)
+	for i := range dk.s {
+		b = encode12(b, (*[n]fieldElement)(&dk.s[i]))
+	}
+	b = append(b, dk.ek.encoded...)
+	b = append(b, dk.ek.h[:]...)
+	return append(b, dk.z[:]...)
+}
+
+// Decapsulate returns the shared key encapsulated in a ciphertext.
+//
+// As specified, an invalid ciphertext isn't reported as such, but yields a
+// pseudorandom key, derived from the seed and the ciphertext, so that the
+// failure is only detected by the protocol later on. Only ciphertexts of the
+// wrong length return an error.
+func (dk *DecapsulationKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	p := dk.ek.params
+	if len(ciphertext) != p.CiphertextSize() {
+		return nil, errInvalidLength
+	}
+	m := dk.decrypt(ciphertext)
+	sharedKey, expected := dk.ek.encapsulate(&m)
+
+	j := sha3.NewShake256()
+	j.Write(dk.z[:])
+	j.Write(ciphertext)
+	rejection := make([]byte, SharedKeySize)
+	j.Read(rejection)
+
+	valid := subtle.ConstantTimeCompare(ciphertext, expected)
+	subtle.ConstantTimeCopy(1^valid, sharedKey, rejection)
+	return sharedKey, nil
+}
+
+// decrypt is K-PKE.Decrypt, from FIPS 203, Algorithm 15.
+func (dk *DecapsulationKey) decrypt(c []byte) [32]byte {
+	p := dk.ek.params
+	uSize := 32 * int(p.du)
+	var acc nttElement
+	for i := 0; i < p.k; i++ {
+		u := ntt(decodeAndDecompress(c[uSize*i:uSize*(i+1)], p.du))
+		prod := nttMul(&dk.s[i], &u)
+		acc = nttElement(polyAdd((*[n]fieldElement)(&acc), (*[n]fieldElement)(&prod)))
+	}
+	v := decodeAndDecompress(c[uSize*p.k:], p.dv)
+	su := inverseNTT(acc)
+	w := ringElement(polySub((*[n]fieldElement)(&v), (*[n]fieldElement)(&su)))
+	var m [32]byte
+	compressAndEncode(m[:0], &w, 1)
+	return m
+}
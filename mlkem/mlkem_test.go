@@ -0,0 +1,186 @@
+package mlkem
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var allParams = []*Params{MLKEM512(), MLKEM768(), MLKEM1024()}
+
+func TestRoundTrip(t *testing.T) {
+	for _, p := range allParams {
+		dk, err := p.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ek, err := p.NewEncapsulationKey(dk.EncapsulationKey().Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ek.Bytes()) != p.EncapsulationKeySize() {
+			t.Errorf("%v: encapsulation key of %d bytes", p, len(ek.Bytes()))
+		}
+		sharedKey, ciphertext, err := ek.Encapsulate(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ciphertext) != p.CiphertextSize() {
+			t.Errorf("%v: ciphertext of %d bytes", p, len(ciphertext))
+		}
+		decapsulated, err := dk.Decapsulate(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sharedKey, decapsulated) {
+			t.Errorf("%v: the shared keys differ", p)
+		}
+
+		dk2, err := p.NewDecapsulationKey(dk.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(dk2.ExpandedBytes(), dk.ExpandedBytes()) {
+			t.Errorf("%v: the key changed after a round trip through its seed", p)
+		}
+	}
+}
+
+func TestImplicitRejection(t *testing.T) {
+	for _, p := range allParams {
+		dk, _ := p.GenerateKey(rand.Reader)
+		sharedKey, ciphertext, _ := dk.EncapsulationKey().Encapsulate(rand.Reader)
+		ciphertext[0] ^= 1
+		rejected, err := dk.Decapsulate(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(rejected, sharedKey) {
+			t.Errorf("%v: a modified ciphertext gave the same key", p)
+		}
+		// The key is J(z || c).
+		expected := make([]byte, SharedKeySize)
+		j := sha3.NewShake256()
+		j.Write(dk.Bytes()[32:])
+		j.Write(ciphertext)
+		j.Read(expected)
+		if !bytes.Equal(rejected, expected) {
+			t.Errorf("%v: the rejection key is %x, expected %x", p, rejected, expected)
+		}
+		if _, err := dk.Decapsulate(ciphertext[1:]); err == nil {
+			t.Errorf("%v: Decapsulate accepted a short ciphertext", p)
+		}
+	}
+}
+
+func TestModulusCheck(t *testing.T) {
+	p := MLKEM768()
+	dk, _ := p.GenerateKey(rand.Reader)
+	ek := dk.EncapsulationKey().Bytes()
+	// Set the first coefficient to q.
+	ek[0] = q & 0xff
+	ek[1] = ek[1]&0xf0 | q>>8
+	if _, err := p.NewEncapsulationKey(ek); err == nil {
+		t.Error("NewEncapsulationKey accepted an unreduced coefficient")
+	}
+	if _, err := p.NewEncapsulationKey(ek[:100]); err == nil {
+		t.Error("NewEncapsulationKey accepted a short key")
+	}
+}
+
+func TestStandardLibraryVectors(t *testing.T) {
+	// Generated with crypto/mlkem, which doesn't support ML-KEM-512.
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Params     string `json:"params"`
+		Seed       string `json:"seed"`
+		EKSHA256   string `json:"ek_sha256"`
+		Ciphertext string `json:"ciphertext"`
+		SharedKey  string `json:"shared_key"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		var p *Params
+		for _, candidate := range allParams {
+			if candidate.String() == v.Params {
+				p = candidate
+			}
+		}
+		seed, _ := hex.DecodeString(v.Seed)
+		dk, err := p.NewDecapsulationKey(seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.Sum256(dk.EncapsulationKey().Bytes())
+		if hex.EncodeToString(h[:]) != v.EKSHA256 {
+			t.Errorf("%v: wrong encapsulation key", p)
+		}
+		ciphertext, _ := hex.DecodeString(v.Ciphertext)
+		sharedKey, err := dk.Decapsulate(ciphertext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(sharedKey) != v.SharedKey {
+			t.Errorf("%v: shared key %x, expected %s", p, sharedKey, v.SharedKey)
+		}
+	}
+}
+
+func TestNTTMultiplication(t *testing.T) {
+	var f, g ringElement
+	for i := range f {
+		f[i] = fieldElement(i * 7 % q)
+		g[i] = fieldElement((i*i + 3) % q)
+	}
+	// Schoolbook multiplication modulo X^256 + 1.
+	var expected ringElement
+	for i := range f {
+		for j := range g {
+			prod := fieldMul(f[i], g[j])
+			if i+j < n {
+				expected[i+j] = fieldAdd(expected[i+j], prod)
+			} else {
+				expected[i+j-n] = fieldSub(expected[i+j-n], prod)
+			}
+		}
+	}
+	fHat, gHat := ntt(f), ntt(g)
+	if inverseNTT(fHat) != f {
+		t.Error("the inverse NTT didn't undo the NTT")
+	}
+	prod := nttMul(&fHat, &gHat)
+	if actual := inverseNTT(prod); actual != expected {
+		t.Error("NTT multiplication differs from schoolbook multiplication")
+	}
+}
+
+func TestCompress(t *testing.T) {
+	for _, d := range []uint8{1, 4, 5, 10, 11} {
+		for x := 0; x < q; x++ {
+			// round(2^d * x / q) mod 2^d, computed with a division.
+			expected := uint16((uint32(x)<<d + q/2) / q & (1<<d - 1))
+			if c := compress(fieldElement(x), d); c != expected {
+				t.Fatalf("compress(%d, %d) = %d, expected %d", x, d, c, expected)
+			}
+		}
+	}
+}
+
+func BenchmarkDecapsulate768(b *testing.B) {
+	dk, _ := MLKEM768().GenerateKey(rand.Reader)
+	_, ciphertext, _ := dk.EncapsulationKey().Encapsulate(rand.Reader)
+	for i := 0; i < b.N; i++ {
+		dk.Decapsulate(ciphertext)
+	}
+}
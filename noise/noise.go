@@ -0,0 +1,80 @@
+// Package noise provides Diffie-Hellman functions for the Noise Protocol
+// Framework, as described in section 4.1 of the Noise specification.
+//
+// The functions are backed by the ecdh package, and are meant to be plugged
+// into existing implementations of the framework, whose DH interfaces
+// usually mirror the one of this package.
+package noise
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+// DHKey is a key pair, as used by the Noise Protocol Framework.
+type DHKey struct {
+	Private []byte
+	Public  []byte
+}
+
+// DHFunc implements the DH functions of the Noise specification.
+type DHFunc interface {
+	// GenerateKeypair generates a new key pair, reading randomness from random.
+	// This is GENERATE_KEYPAIR in the specification.
+	GenerateKeypair(random io.Reader) (DHKey, error)
+	// DH performs a Diffie-Hellman calculation between a private key and a
+	// public key, returning an error if the public key is invalid, or if the
+	// output would be the identity.
+	DH(privkey, pubkey []byte) ([]byte, error)
+	// DHLen returns the size of public keys, which is DHLEN in the specification.
+	DHLen() int
+	// DHName returns the name of the function, for use in protocol names.
+	DHName() string
+}
+
+// dhFunc implements DHFunc over a curve of the ecdh package.
+type dhFunc struct {
+	curve  ecdh.Curve
+	name   string
+	length int
+}
+
+// DH25519 is the "25519" DH function, using X25519, as described in section
+// 12.1 of the Noise specification.
+var DH25519 DHFunc = &dhFunc{curve: ecdh.X25519(), name: "25519", length: 32}
+
+// DHP256 is a DH function over NIST P-256, which isn't part of the Noise
+// specification, but is used by some protocols for compliance reasons.
+//
+// Public keys are encoded in uncompressed form, so DHLEN is 65 bytes, while
+// the output of DH is the 32 byte x-coordinate of the shared point.
+var DHP256 DHFunc = &dhFunc{curve: ecdh.P256(), name: "P256", length: 65}
+
+func (f *dhFunc) GenerateKeypair(random io.Reader) (DHKey, error) {
+	k, err := f.curve.GenerateKey(random)
+	if err != nil {
+		return DHKey{}, err
+	}
+	return DHKey{Private: k.Bytes(), Public: k.PublicKey().Bytes()}, nil
+}
+
+func (f *dhFunc) DH(privkey, pubkey []byte) ([]byte, error) {
+	priv, err := f.curve.NewPrivateKey(privkey)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := f.curve.NewPublicKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	return priv.ECDH(pub)
+}
+
+func (f *dhFunc) DHLen() int {
+	return f.length
+}
+
+func (f *dhFunc) DHName() string {
+	return f.name
+}
@@ -0,0 +1,46 @@
+package noise
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestDH(t *testing.T) {
+	for _, f := range []DHFunc{DH25519, DHP256} {
+		a, err := f.GenerateKeypair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := f.GenerateKeypair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(a.Public) != f.DHLen() {
+			t.Errorf("%s: public key has length %d, expected %d", f.DHName(), len(a.Public), f.DHLen())
+		}
+		ab, err := f.DH(a.Private, b.Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ba, err := f.DH(b.Private, a.Public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ab, ba) {
+			t.Errorf("%s: shared secrets differ", f.DHName())
+		}
+	}
+}
+
+func TestDHRejectsInvalidKeys(t *testing.T) {
+	for _, f := range []DHFunc{DH25519, DHP256} {
+		a, _ := f.GenerateKeypair(rand.Reader)
+		if _, err := f.DH(a.Private, make([]byte, f.DHLen())); err == nil {
+			t.Errorf("%s: expected an error for an invalid public key", f.DHName())
+		}
+		if _, err := f.DH(a.Private[1:], a.Public); err == nil {
+			t.Errorf("%s: expected an error for a short private key", f.DHName())
+		}
+	}
+}
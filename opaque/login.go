@@ -0,0 +1,247 @@
+package opaque
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/hmac"
+	"github.com/cronokirby/safenum"
+)
+
+// keys contains the keys derived from the transcript of the key exchange.
+type keys struct {
+	serverMAC, clientMAC []byte
+	sessionKey           []byte
+}
+
+// expandLabel implements Expand-Label, as described in RFC 9807, section 6.4.1.
+func (p *params) expandLabel(secret []byte, label string, context []byte, length int) []byte {
+	label = "OPAQUE-" + label
+	info := []byte{byte(length >> 8), byte(length), byte(len(label))}
+	info = append(info, label...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	return p.expand(secret, info, length)
+}
+
+// deriveKeys derives the keys of the key exchange from the shared secrets and
+// the preamble, as described in RFC 9807, section 6.4.2.
+func (p *params) deriveKeys(ikm, preamble []byte) *keys {
+	prk := p.extract(nil, ikm)
+	preambleHash := p.hash(preamble)
+	handshakeSecret := p.expandLabel(prk, "HandshakeSecret", preambleHash, p.hashSize)
+	sessionKey := p.expandLabel(prk, "SessionKey", preambleHash, p.hashSize)
+	km2 := p.expandLabel(handshakeSecret, "ServerMAC", nil, p.hashSize)
+	km3 := p.expandLabel(handshakeSecret, "ClientMAC", nil, p.hashSize)
+	serverMAC := p.mac(km2, preambleHash)
+	clientMAC := p.mac(km3, p.hash(append(append([]byte{}, preamble...), serverMAC...)))
+	return &keys{serverMAC: serverMAC, clientMAC: clientMAC, sessionKey: sessionKey}
+}
+
+// preamble returns the transcript of the key exchange, up to the server's MAC.
+func (p *params) preamble(clientID, ke1, serverID, ke2 []byte) []byte {
+	out := appendPrefixed([]byte("OPAQUEv1-"), p.Context)
+	out = appendPrefixed(out, clientID)
+	out = append(out, ke1...)
+	out = appendPrefixed(out, serverID)
+	return append(out, ke2...)
+}
+
+func (p *params) credentialResponseSize() int {
+	return p.elementSize + nonceSize + p.elementSize + p.envelopeSize()
+}
+
+// ClientLogin is the state of a client logging in.
+//
+// The client sends the message returned by NewClientLogin to the server,
+// which responds with Server.StartLogin. The client then calls Finish, and
+// sends the resulting message to the server, which checks it with
+// ServerLogin.Finish.
+type ClientLogin struct {
+	params   *params
+	password []byte
+	blind    *safenum.Nat
+	secret   *safenum.Nat
+	ke1      []byte
+	done     bool
+}
+
+// NewClientLogin starts a login with a password, returning the state of the
+// client, along with the first message to send to the server.
+func NewClientLogin(cfg *Config, password []byte, rand io.Reader) (*ClientLogin, []byte, error) {
+	p, err := cfg.params()
+	if err != nil {
+		return nil, nil, err
+	}
+	blind, blinded, err := p.suite.Blind(password, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := randomBytes(rand, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed, err := randomBytes(rand, seedSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, keyshare, err := p.deriveKeyPair(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	ke1 := append(blinded.Bytes(), nonce...)
+	ke1 = append(ke1, keyshare.Bytes()...)
+	c := &ClientLogin{
+		params:   p,
+		password: append([]byte{}, password...),
+		blind:    blind,
+		secret:   secret,
+		ke1:      ke1,
+	}
+	return c, append([]byte{}, ke1...), nil
+}
+
+// ServerLogin is the state of a server processing a login.
+type ServerLogin struct {
+	clientMAC  []byte
+	sessionKey []byte
+	done       bool
+}
+
+// StartLogin processes the first message of a client, given the record and
+// credential identifier stored during registration, returning the state of
+// the server, along with the message to send to the client.
+//
+// If the client isn't registered, the server should use a record returned by
+// FakeRecord instead, so that the client can't tell the difference.
+func (s *Server) StartLogin(record, credentialID, ke1 []byte, ids *Identities, rand io.Reader) (*ServerLogin, []byte, error) {
+	p := s.params
+	if len(record) != p.recordSize() {
+		return nil, nil, errBadRecord
+	}
+	clientPublicKey, err := p.Group.DecodeElement(record[:p.elementSize])
+	if err != nil {
+		return nil, nil, errBadRecord
+	}
+	maskingKey := record[p.elementSize : p.elementSize+p.hashSize]
+	env := record[p.elementSize+p.hashSize:]
+
+	if len(ke1) != 2*p.elementSize+nonceSize {
+		return nil, nil, errBadMessage
+	}
+	clientKeyshare, err := p.Group.DecodeElement(ke1[p.elementSize+nonceSize:])
+	if err != nil {
+		return nil, nil, errBadMessage
+	}
+	evaluated, err := s.evaluate(ke1[:p.elementSize], credentialID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The public key of the server, and the envelope, are masked, so that
+	// fake records are indistinguishable from real ones.
+	maskingNonce, err := randomBytes(rand, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	pad := p.expand(maskingKey, append(append([]byte{}, maskingNonce...), "CredentialResponsePad"...), p.elementSize+p.envelopeSize())
+	masked := append(append([]byte{}, s.publicKey...), env...)
+	xorBytes(masked, masked, pad)
+
+	serverNonce, err := randomBytes(rand, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed, err := randomBytes(rand, seedSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, keyshare, err := p.deriveKeyPair(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ke2 := append(evaluated, maskingNonce...)
+	ke2 = append(ke2, masked...)
+	ke2 = append(ke2, serverNonce...)
+	ke2 = append(ke2, keyshare.Bytes()...)
+
+	clientID, serverID := ids.resolve(clientPublicKey.Bytes(), s.publicKey)
+	preamble := p.preamble(clientID, ke1, serverID, ke2)
+	ikm := tripleDH(clientKeyshare.ScalarMult(secret), clientKeyshare.ScalarMult(s.privateKey), clientPublicKey.ScalarMult(secret))
+	k := p.deriveKeys(ikm, preamble)
+	ke2 = append(ke2, k.serverMAC...)
+	return &ServerLogin{clientMAC: k.clientMAC, sessionKey: k.sessionKey}, ke2, nil
+}
+
+func tripleDH(dh1, dh2, dh3 group.Element) []byte {
+	out := append([]byte{}, dh1.Bytes()...)
+	out = append(out, dh2.Bytes()...)
+	return append(out, dh3.Bytes()...)
+}
+
+// Finish processes the response of the server, returning the final message to
+// send to the server, the session key, and the export key.
+//
+// An error is returned if the password is incorrect, or if the server couldn't
+// be authenticated.
+func (c *ClientLogin) Finish(ke2 []byte, ids *Identities) (ke3, sessionKey, exportKey []byte, err error) {
+	if c.done {
+		return nil, nil, nil, errLoginFinish
+	}
+	c.done = true
+	p := c.params
+	responseSize := p.credentialResponseSize()
+	if len(ke2) != responseSize+nonceSize+p.elementSize+p.hashSize {
+		return nil, nil, nil, errBadMessage
+	}
+	evaluated, err := p.Group.DecodeElement(ke2[:p.elementSize])
+	if err != nil {
+		return nil, nil, nil, errBadMessage
+	}
+	maskingNonce := ke2[p.elementSize : p.elementSize+nonceSize]
+	masked := ke2[p.elementSize+nonceSize : responseSize]
+	serverKeyshare, err := p.Group.DecodeElement(ke2[responseSize+nonceSize : responseSize+nonceSize+p.elementSize])
+	if err != nil {
+		return nil, nil, nil, errBadMessage
+	}
+	serverMAC := ke2[responseSize+nonceSize+p.elementSize:]
+
+	randomizedPassword := p.randomizedPassword(c.password, c.blind, evaluated)
+	maskingKey := p.expand(randomizedPassword, []byte("MaskingKey"), p.hashSize)
+	pad := p.expand(maskingKey, append(append([]byte{}, maskingNonce...), "CredentialResponsePad"...), len(masked))
+	unmasked := make([]byte, len(masked))
+	xorBytes(unmasked, masked, pad)
+	serverPublicKeyBytes := unmasked[:p.elementSize]
+	env := &envelope{nonce: unmasked[p.elementSize : p.elementSize+nonceSize], authTag: unmasked[p.elementSize+nonceSize:]}
+
+	clientPrivateKey, clientID, serverID, exportKey, err := p.recover(randomizedPassword, serverPublicKeyBytes, env, ids)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serverPublicKey, err := p.Group.DecodeElement(serverPublicKeyBytes)
+	if err != nil {
+		return nil, nil, nil, errBadMessage
+	}
+
+	preamble := p.preamble(clientID, c.ke1, serverID, ke2[:len(ke2)-p.hashSize])
+	ikm := tripleDH(serverKeyshare.ScalarMult(c.secret), serverPublicKey.ScalarMult(c.secret), serverKeyshare.ScalarMult(clientPrivateKey))
+	k := p.deriveKeys(ikm, preamble)
+	if !hmac.Equal(serverMAC, k.serverMAC) {
+		return nil, nil, nil, errServerMAC
+	}
+	return k.clientMAC, k.sessionKey, exportKey, nil
+}
+
+// Finish checks the final message of the client, returning the session key if
+// the client was successfully authenticated.
+func (s *ServerLogin) Finish(ke3 []byte) (sessionKey []byte, err error) {
+	if s.done {
+		return nil, errLoginFinish
+	}
+	s.done = true
+	if !hmac.Equal(ke3, s.clientMAC) {
+		return nil, errClientMAC
+	}
+	return s.sessionKey, nil
+}
@@ -0,0 +1,326 @@
+// Package opaque implements the OPAQUE augmented password-authenticated key
+// exchange, following RFC 9807.
+//
+// In OPAQUE, a client registers a password with a server, which only stores
+// a record derived from it. The client can later log in with the password,
+// establishing a shared key with the server. The server never learns the
+// password, even during registration, and an attacker compromising the server
+// still needs to run a dictionary attack against each record.
+//
+// The protocol is built on the OPRF of the oprf package, and uses the 3DH
+// authenticated key exchange, with HKDF and HMAC over the hash function of the
+// OPRF suite.
+package opaque
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/hkdf"
+	"github.com/cronokirby/ctcrypto/hmac"
+	"github.com/cronokirby/ctcrypto/oprf"
+	"github.com/cronokirby/safenum"
+)
+
+const (
+	// the size of nonces
+	nonceSize = 32
+	// the size of seeds used to derive key pairs
+	seedSize = 32
+)
+
+// Config contains the parameters of the protocol, which must be shared by the
+// client and the server.
+type Config struct {
+	// Group is the group used by the OPRF and the key exchange. Only P-256,
+	// P-384, and P-521 are supported.
+	Group group.Group
+	// Context is bound to the key exchange, and should identify the application.
+	Context []byte
+	// Stretch is the key stretching function applied to the output of the OPRF,
+	// like scrypt or Argon2. If nil, no stretching is applied, which is only
+	// acceptable for high-entropy passwords.
+	Stretch func(oprfOutput []byte) []byte
+}
+
+// params contains the parameters derived from a Config.
+type params struct {
+	*Config
+	suite *oprf.Suite
+	// the size of the output of the hash function, and of the MACs
+	hashSize int
+	// the size of an encoded element, or public key
+	elementSize int
+}
+
+func (c *Config) params() (*params, error) {
+	suite, err := oprf.New(c.Group, oprf.ModeOPRF)
+	if err != nil {
+		return nil, err
+	}
+	return &params{
+		Config:      c,
+		suite:       suite,
+		hashSize:    suite.Hash()().Size(),
+		elementSize: c.Group.ElementSize(),
+	}, nil
+}
+
+func (p *params) envelopeSize() int {
+	return nonceSize + p.hashSize
+}
+
+func (p *params) recordSize() int {
+	return p.elementSize + p.hashSize + p.envelopeSize()
+}
+
+func (p *params) extract(salt, ikm []byte) []byte {
+	return hkdf.Extract(p.suite.Hash(), ikm, salt)
+}
+
+func (p *params) expand(prk []byte, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(p.suite.Hash(), prk, info), out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func (p *params) mac(key []byte, msg ...[]byte) []byte {
+	h := hmac.New(p.suite.Hash(), key)
+	for _, m := range msg {
+		h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+func (p *params) hash(msg []byte) []byte {
+	h := p.suite.Hash()()
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// deriveKeyPair derives a key pair for the key exchange from a seed.
+func (p *params) deriveKeyPair(seed []byte) (*safenum.Nat, group.Element, error) {
+	return p.suite.DeriveKeyPair(seed, []byte("OPAQUE-DeriveDiffieHellmanKeyPair"))
+}
+
+// randomizedPassword combines the password with the output of the OPRF.
+func (p *params) randomizedPassword(password []byte, blind *safenum.Nat, evaluated group.Element) []byte {
+	output := p.suite.Finalize(password, blind, evaluated)
+	stretched := output
+	if p.Stretch != nil {
+		stretched = p.Stretch(output)
+	}
+	return p.extract(nil, append(append([]byte{}, output...), stretched...))
+}
+
+// appendPrefixed appends data to a buffer, prefixed by its length as a 2 byte
+// big-endian integer.
+func appendPrefixed(buf []byte, data []byte) []byte {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(data)))
+	return append(append(buf, length[:]...), data...)
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+func randomBytes(rand io.Reader, n int) ([]byte, error) {
+	out := make([]byte, n)
+	if _, err := io.ReadFull(rand, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Identities contains the identities of the client and the server, which are
+// bound to the envelope and to the key exchange.
+//
+// Empty identities default to the public keys of the parties. The same
+// identities must be used during registration and login.
+type Identities struct {
+	Client, Server []byte
+}
+
+func (ids *Identities) resolve(clientPublicKey, serverPublicKey []byte) (client, server []byte) {
+	if ids != nil {
+		client, server = ids.Client, ids.Server
+	}
+	if len(client) == 0 {
+		client = clientPublicKey
+	}
+	if len(server) == 0 {
+		server = serverPublicKey
+	}
+	return
+}
+
+var (
+	errBadMessage  = errors.New("opaque: invalid message")
+	errBadRecord   = errors.New("opaque: invalid record")
+	errEnvelope    = errors.New("opaque: failed to recover the envelope")
+	errServerMAC   = errors.New("opaque: invalid server MAC")
+	errClientMAC   = errors.New("opaque: invalid client MAC")
+	errLoginFinish = errors.New("opaque: login already finished")
+)
+
+// envelope contains the information needed by the client to recover its
+// private key, and authenticate the public key of the server.
+type envelope struct {
+	nonce   []byte
+	authTag []byte
+}
+
+// store creates the envelope of the client, as described in RFC 9807,
+// section 4.1.2, returning it along with the public key of the client, the
+// masking key, and the export key.
+func (p *params) store(randomizedPassword, serverPublicKey []byte, ids *Identities, rand io.Reader) (env *envelope, clientPublicKey, maskingKey, exportKey []byte, err error) {
+	nonce, err := randomBytes(rand, nonceSize)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	maskingKey = p.expand(randomizedPassword, []byte("MaskingKey"), p.hashSize)
+	authKey, exportKey, _, public, err := p.envelopeKeys(randomizedPassword, nonce)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	clientPublicKey = public.Bytes()
+	clientID, serverID := ids.resolve(clientPublicKey, serverPublicKey)
+	authTag := p.mac(authKey, nonce, cleartextCredentials(serverPublicKey, serverID, clientID))
+	return &envelope{nonce: nonce, authTag: authTag}, clientPublicKey, maskingKey, exportKey, nil
+}
+
+// envelopeKeys derives the keys of the envelope with a given nonce, along with
+// the key pair of the client.
+func (p *params) envelopeKeys(randomizedPassword, nonce []byte) (authKey, exportKey []byte, private *safenum.Nat, public group.Element, err error) {
+	label := func(l string) []byte { return append(append([]byte{}, nonce...), l...) }
+	authKey = p.expand(randomizedPassword, label("AuthKey"), p.hashSize)
+	exportKey = p.expand(randomizedPassword, label("ExportKey"), p.hashSize)
+	seed := p.expand(randomizedPassword, label("PrivateKey"), seedSize)
+	private, public, err = p.deriveKeyPair(seed)
+	return
+}
+
+// recover opens the envelope of the client, returning its private key, and
+// the export key, as described in RFC 9807, section 4.1.3.
+func (p *params) recover(randomizedPassword, serverPublicKey []byte, env *envelope, ids *Identities) (clientPrivateKey *safenum.Nat, clientID, serverID, exportKey []byte, err error) {
+	authKey, exportKey, clientPrivateKey, public, err := p.envelopeKeys(randomizedPassword, env.nonce)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	clientID, serverID = ids.resolve(public.Bytes(), serverPublicKey)
+	expected := p.mac(authKey, env.nonce, cleartextCredentials(serverPublicKey, serverID, clientID))
+	if !hmac.Equal(expected, env.authTag) {
+		return nil, nil, nil, nil, errEnvelope
+	}
+	return clientPrivateKey, clientID, serverID, exportKey, nil
+}
+
+func cleartextCredentials(serverPublicKey, serverID, clientID []byte) []byte {
+	out := append([]byte{}, serverPublicKey...)
+	out = appendPrefixed(out, serverID)
+	return appendPrefixed(out, clientID)
+}
+
+// Server holds the long-term secrets of an OPAQUE server.
+type Server struct {
+	params     *params
+	privateKey *safenum.Nat
+	publicKey  []byte
+	oprfSeed   []byte
+}
+
+// GenerateServerKeys generates the long-term secrets of a server: the private
+// key used in the key exchange, and the seed used to derive the OPRF keys for
+// each client.
+func GenerateServerKeys(cfg *Config, rand io.Reader) (privateKey, oprfSeed []byte, err error) {
+	p, err := cfg.params()
+	if err != nil {
+		return nil, nil, err
+	}
+	seed, err := randomBytes(rand, seedSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, _, err := p.deriveKeyPair(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	oprfSeed, err = randomBytes(rand, p.hashSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg.Group.EncodeScalar(sk), oprfSeed, nil
+}
+
+// NewServer creates a server from the secrets returned by GenerateServerKeys.
+func NewServer(cfg *Config, privateKey, oprfSeed []byte) (*Server, error) {
+	p, err := cfg.params()
+	if err != nil {
+		return nil, err
+	}
+	sk, err := cfg.Group.DecodeScalar(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if sk.EqZero() {
+		return nil, errors.New("opaque: invalid server private key")
+	}
+	return &Server{
+		params:     p,
+		privateKey: sk,
+		publicKey:  cfg.Group.ScalarBaseMult(sk).Bytes(),
+		oprfSeed:   append([]byte{}, oprfSeed...),
+	}, nil
+}
+
+// PublicKey returns the public key of the server, which the client can use to
+// authenticate the server, through the identities.
+func (s *Server) PublicKey() []byte {
+	return append([]byte{}, s.publicKey...)
+}
+
+// evaluate evaluates the OPRF on a blinded element, with the key of a given
+// client.
+func (s *Server) evaluate(blinded, credentialID []byte) ([]byte, error) {
+	p := s.params
+	element, err := p.Group.DecodeElement(blinded)
+	if err != nil {
+		return nil, errBadMessage
+	}
+	seed := p.expand(s.oprfSeed, append(append([]byte{}, credentialID...), "OprfKey"...), p.Group.ScalarSize())
+	key, _, err := p.suite.DeriveKeyPair(seed, []byte("OPAQUE-DeriveKeyPair"))
+	if err != nil {
+		return nil, err
+	}
+	return p.suite.BlindEvaluate(key, element).Bytes(), nil
+}
+
+// FakeRecord returns a random record, which can be used by the server to
+// respond to login attempts for clients which aren't registered, without
+// revealing that they aren't registered, as described in RFC 9807, section 10.9.
+//
+// The same fake record should be reused for a given credential identifier.
+func (s *Server) FakeRecord(rand io.Reader) ([]byte, error) {
+	p := s.params
+	seed, err := randomBytes(rand, seedSize)
+	if err != nil {
+		return nil, err
+	}
+	_, public, err := p.deriveKeyPair(seed)
+	if err != nil {
+		return nil, err
+	}
+	maskingKey, err := randomBytes(rand, p.hashSize)
+	if err != nil {
+		return nil, err
+	}
+	record := append(public.Bytes(), maskingKey...)
+	return append(record, make([]byte, p.envelopeSize())...), nil
+}
@@ -0,0 +1,147 @@
+package opaque
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func newServer(t *testing.T, cfg *Config) *Server {
+	sk, seed, err := GenerateServerKeys(cfg, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := NewServer(cfg, sk, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func register(t *testing.T, cfg *Config, server *Server, password, credentialID []byte, ids *Identities) (record, exportKey []byte) {
+	client, request, err := NewClientRegistration(cfg, password, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := server.RegistrationResponse(request, credentialID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, exportKey, err = client.Finalize(response, ids, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return record, exportKey
+}
+
+func login(t *testing.T, cfg *Config, server *Server, password, record, credentialID []byte, ids *Identities) (clientKey, serverKey, exportKey []byte, err error) {
+	client, ke1, err := NewClientLogin(cfg, password, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverLogin, ke2, err := server.StartLogin(record, credentialID, ke1, ids, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ke3, clientKey, exportKey, err := client.Finish(ke2, ids)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	serverKey, err = serverLogin.Finish(ke3)
+	return clientKey, serverKey, exportKey, err
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		cfg := &Config{Group: g, Context: []byte("test")}
+		server := newServer(t, cfg)
+		ids := &Identities{Client: []byte("alice"), Server: []byte("example.com")}
+		record, exportKey := register(t, cfg, server, []byte("password"), []byte("alice"), ids)
+		clientKey, serverKey, loginExportKey, err := login(t, cfg, server, []byte("password"), record, []byte("alice"), ids)
+		if err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		if !bytes.Equal(clientKey, serverKey) || len(clientKey) == 0 {
+			t.Errorf("%s: session keys differ", g.Name())
+		}
+		if !bytes.Equal(exportKey, loginExportKey) {
+			t.Errorf("%s: export keys differ", g.Name())
+		}
+	}
+}
+
+func TestDefaultIdentitiesAndStretch(t *testing.T) {
+	stretched := 0
+	cfg := &Config{Group: group.P256(), Stretch: func(x []byte) []byte {
+		stretched++
+		return append([]byte("stretched"), x...)
+	}}
+	server := newServer(t, cfg)
+	record, _ := register(t, cfg, server, []byte("password"), []byte("id"), nil)
+	if _, _, _, err := login(t, cfg, server, []byte("password"), record, []byte("id"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if stretched != 2 {
+		t.Errorf("stretching function called %d times, expected 2", stretched)
+	}
+}
+
+func TestWrongPassword(t *testing.T) {
+	cfg := &Config{Group: group.P256()}
+	server := newServer(t, cfg)
+	record, _ := register(t, cfg, server, []byte("password"), []byte("id"), nil)
+	if _, _, _, err := login(t, cfg, server, []byte("passw0rd"), record, []byte("id"), nil); err == nil {
+		t.Errorf("expected an error with the wrong password")
+	}
+}
+
+func TestWrongIdentities(t *testing.T) {
+	cfg := &Config{Group: group.P256()}
+	server := newServer(t, cfg)
+	ids := &Identities{Client: []byte("alice")}
+	record, _ := register(t, cfg, server, []byte("password"), []byte("id"), ids)
+	if _, _, _, err := login(t, cfg, server, []byte("password"), record, []byte("id"), &Identities{Client: []byte("bob")}); err == nil {
+		t.Errorf("expected an error with different identities")
+	}
+}
+
+func TestWrongCredentialID(t *testing.T) {
+	cfg := &Config{Group: group.P256()}
+	server := newServer(t, cfg)
+	record, _ := register(t, cfg, server, []byte("password"), []byte("id"), nil)
+	if _, _, _, err := login(t, cfg, server, []byte("password"), record, []byte("other"), nil); err == nil {
+		t.Errorf("expected an error with a different credential identifier")
+	}
+}
+
+func TestFakeRecord(t *testing.T) {
+	cfg := &Config{Group: group.P256()}
+	server := newServer(t, cfg)
+	fake, err := server.FakeRecord(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	real, _ := register(t, cfg, server, []byte("password"), []byte("id"), nil)
+	if len(fake) != len(real) {
+		t.Fatalf("fake record has length %d, expected %d", len(fake), len(real))
+	}
+	if _, _, _, err := login(t, cfg, server, []byte("password"), fake, []byte("id"), nil); err == nil {
+		t.Errorf("expected an error when logging in with a fake record")
+	}
+}
+
+func TestClientMACRejected(t *testing.T) {
+	cfg := &Config{Group: group.P256()}
+	server := newServer(t, cfg)
+	record, _ := register(t, cfg, server, []byte("password"), []byte("id"), nil)
+	_, ke1, _ := NewClientLogin(cfg, []byte("password"), rand.Reader)
+	serverLogin, _, err := server.StartLogin(record, []byte("id"), ke1, nil, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := serverLogin.Finish(make([]byte, 32)); err == nil {
+		t.Errorf("expected an error for an invalid client MAC")
+	}
+}
@@ -0,0 +1,74 @@
+package opaque
+
+import (
+	"io"
+
+	"github.com/cronokirby/safenum"
+)
+
+// ClientRegistration is the state of a client registering a password.
+//
+// The client sends the request returned by NewClientRegistration to the
+// server, which responds with Server.RegistrationResponse. The client then
+// calls Finalize, and sends the resulting record to the server, which stores
+// it along with the credential identifier of the client.
+type ClientRegistration struct {
+	params   *params
+	password []byte
+	blind    *safenum.Nat
+}
+
+// NewClientRegistration starts the registration of a password, returning the
+// state of the client, along with the request to send to the server.
+func NewClientRegistration(cfg *Config, password []byte, rand io.Reader) (*ClientRegistration, []byte, error) {
+	p, err := cfg.params()
+	if err != nil {
+		return nil, nil, err
+	}
+	blind, blinded, err := p.suite.Blind(password, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &ClientRegistration{params: p, password: append([]byte{}, password...), blind: blind}
+	return r, blinded.Bytes(), nil
+}
+
+// RegistrationResponse processes the registration request of a client, with
+// a given credential identifier, which must be unique to the client.
+func (s *Server) RegistrationResponse(request, credentialID []byte) ([]byte, error) {
+	evaluated, err := s.evaluate(request, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	return append(evaluated, s.publicKey...), nil
+}
+
+// Finalize processes the response of the server, returning the record to send
+// to the server, along with the export key.
+//
+// The export key is a secret known only to the client, which can be used to
+// encrypt additional data stored on the server. The same export key is
+// recovered on each successful login.
+func (r *ClientRegistration) Finalize(response []byte, ids *Identities, rand io.Reader) (record, exportKey []byte, err error) {
+	p := r.params
+	if len(response) != 2*p.elementSize {
+		return nil, nil, errBadMessage
+	}
+	evaluated, err := p.Group.DecodeElement(response[:p.elementSize])
+	if err != nil {
+		return nil, nil, errBadMessage
+	}
+	serverPublicKey := response[p.elementSize:]
+	if _, err := p.Group.DecodeElement(serverPublicKey); err != nil {
+		return nil, nil, errBadMessage
+	}
+	randomizedPassword := p.randomizedPassword(r.password, r.blind, evaluated)
+	env, clientPublicKey, maskingKey, exportKey, err := p.store(randomizedPassword, serverPublicKey, ids, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	record = append(clientPublicKey, maskingKey...)
+	record = append(record, env.nonce...)
+	record = append(record, env.authTag...)
+	return record, exportKey, nil
+}
@@ -0,0 +1,295 @@
+// Package openpgp implements the elliptic curve constructions used by
+// OpenPGP, so that OpenPGP implementations can delegate their elliptic curve
+// arithmetic to this module.
+//
+// This package doesn't parse or serialize packets, but only encodes the
+// algorithm-specific fields of those packets holding keys and signatures, and
+// performs the cryptographic operations on them.
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/keywrap"
+)
+
+// This file implements the ECDH public key encryption algorithm of RFC 6637,
+// which wraps a session key with a key derived from an ephemeral ECDH exchange.
+
+// PubKeyAlgoECDH is the public key algorithm identifier of ECDH.
+const PubKeyAlgoECDH = 18
+
+// CipherFunction is an OpenPGP symmetric key algorithm identifier, used to
+// select the algorithm used to wrap session keys.
+type CipherFunction uint8
+
+// The cipher functions which can be used to wrap session keys.
+const (
+	CipherAES128 CipherFunction = 7
+	CipherAES192 CipherFunction = 8
+	CipherAES256 CipherFunction = 9
+)
+
+// keySize returns the key size of an AES cipher function, or 0 if the
+// function is not supported.
+func (c CipherFunction) keySize() int {
+	switch c {
+	case CipherAES128:
+		return 16
+	case CipherAES192:
+		return 24
+	case CipherAES256:
+		return 32
+	default:
+		return 0
+	}
+}
+
+// hashID returns the OpenPGP identifier of a hash function, or 0 if it can't
+// be used by the ECDH KDF.
+func hashID(h crypto.Hash) byte {
+	switch h {
+	case crypto.SHA256:
+		return 8
+	case crypto.SHA384:
+		return 9
+	case crypto.SHA512:
+		return 10
+	default:
+		return 0
+	}
+}
+
+// KDFParams are the key derivation parameters of an ECDH public key, as
+// described in RFC 6637, section 9.
+type KDFParams struct {
+	// Hash is the hash function used by the KDF, one of SHA-256, SHA-384, or
+	// SHA-512.
+	Hash crypto.Hash
+	// Cipher is the algorithm used to wrap the session key.
+	Cipher CipherFunction
+}
+
+// DefaultKDFParams returns the KDF parameters recommended for keys over a
+// given curve, in RFC 6637, section 13.
+func DefaultKDFParams(curve ecdh.Curve) KDFParams {
+	switch curve {
+	case ecdh.P384():
+		return KDFParams{Hash: crypto.SHA384, Cipher: CipherAES192}
+	case ecdh.P521():
+		return KDFParams{Hash: crypto.SHA512, Cipher: CipherAES256}
+	default:
+		return KDFParams{Hash: crypto.SHA256, Cipher: CipherAES128}
+	}
+}
+
+var (
+	oidP256       = []byte{0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}
+	oidP384       = []byte{0x2b, 0x81, 0x04, 0x00, 0x22}
+	oidP521       = []byte{0x2b, 0x81, 0x04, 0x00, 0x23}
+	oidCurve25519 = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0x97, 0x55, 0x01, 0x05, 0x01}
+)
+
+// CurveOID returns the OpenPGP object identifier of a curve, without the
+// leading tag and length bytes of its DER encoding, or nil if the curve isn't
+// supported.
+func CurveOID(curve ecdh.Curve) []byte {
+	switch curve {
+	case ecdh.P256():
+		return append([]byte{}, oidP256...)
+	case ecdh.P384():
+		return append([]byte{}, oidP384...)
+	case ecdh.P521():
+		return append([]byte{}, oidP521...)
+	case ecdh.X25519():
+		return append([]byte{}, oidCurve25519...)
+	default:
+		return nil
+	}
+}
+
+// CurveFromOID returns the curve with a given OpenPGP object identifier, or
+// nil if that curve isn't supported.
+func CurveFromOID(oid []byte) ecdh.Curve {
+	switch {
+	case bytes.Equal(oid, oidP256):
+		return ecdh.P256()
+	case bytes.Equal(oid, oidP384):
+		return ecdh.P384()
+	case bytes.Equal(oid, oidP521):
+		return ecdh.P521()
+	case bytes.Equal(oid, oidCurve25519):
+		return ecdh.X25519()
+	default:
+		return nil
+	}
+}
+
+// curve25519Prefix is the byte prefixing Curve25519 points in OpenPGP, which
+// marks the native encoding of the u-coordinate.
+const curve25519Prefix = 0x40
+
+// EncodePoint returns the OpenPGP encoding of an ECDH public key, which is
+// the uncompressed SEC 1 encoding for NIST curves, and the u-coordinate
+// prefixed with 0x40 for Curve25519.
+func EncodePoint(key *ecdh.PublicKey) []byte {
+	if key.Curve() == ecdh.X25519() {
+		return append([]byte{curve25519Prefix}, key.Bytes()...)
+	}
+	return key.Bytes()
+}
+
+// DecodePoint decodes a point encoded with EncodePoint.
+func DecodePoint(curve ecdh.Curve, data []byte) (*ecdh.PublicKey, error) {
+	if curve == ecdh.X25519() {
+		if len(data) == 0 || data[0] != curve25519Prefix {
+			return nil, errors.New("openpgp: invalid Curve25519 point encoding")
+		}
+		data = data[1:]
+	}
+	return curve.NewPublicKey(data)
+}
+
+// anonymousSender is the fixed string included in the KDF parameters.
+var anonymousSender = []byte("Anonymous Sender    ")
+
+// kdfParam builds the Param input of the KDF, as described in RFC 6637,
+// section 8.
+func kdfParam(curve ecdh.Curve, params KDFParams, fingerprint []byte) []byte {
+	oid := CurveOID(curve)
+	out := make([]byte, 0, 1+len(oid)+5+len(anonymousSender)+len(fingerprint))
+	out = append(out, byte(len(oid)))
+	out = append(out, oid...)
+	out = append(out, PubKeyAlgoECDH, 3, 1, hashID(params.Hash), byte(params.Cipher))
+	out = append(out, anonymousSender...)
+	return append(out, fingerprint...)
+}
+
+// kdf derives the key encryption key from the shared secret, as described in
+// RFC 6637, section 7, computing Hash(00 00 00 01 || ZZ || Param).
+func kdf(zz, param []byte, params KDFParams) []byte {
+	h := params.Hash.New()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(zz)
+	h.Write(param)
+	return h.Sum(nil)[:params.Cipher.keySize()]
+}
+
+// wrapSize is the size to which messages are padded before wrapping, which
+// hides the size of the session key, as suggested in RFC 6637, section 8.
+const wrapSize = 40
+
+// pad pads a message with PKCS #5 padding, up to wrapSize bytes, or to the
+// next multiple of 8 bytes for longer messages.
+func pad(msg []byte) []byte {
+	padLen := wrapSize - len(msg)
+	if padLen <= 0 {
+		padLen = 8 - len(msg)%8
+	}
+	out := make([]byte, len(msg)+padLen)
+	copy(out, msg)
+	for i := len(msg); i < len(out); i++ {
+		out[i] = byte(padLen)
+	}
+	return out
+}
+
+// unpad removes the padding added by pad, or returns an error if it's
+// malformed, without leaking where the padding starts.
+func unpad(msg []byte) ([]byte, error) {
+	padLen := int(msg[len(msg)-1])
+	good := subtle.ConstantTimeLessOrEq(1, padLen) & subtle.ConstantTimeLessOrEq(padLen, len(msg))
+	for i := 1; i <= len(msg) && i <= 0xff; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, padLen)
+		matches := subtle.ConstantTimeByteEq(msg[len(msg)-i], byte(padLen))
+		good &= matches | (inPadding ^ 1)
+	}
+	if good != 1 {
+		return nil, errors.New("openpgp: invalid session key padding")
+	}
+	return msg[:len(msg)-padLen], nil
+}
+
+func checkParams(key *ecdh.PublicKey, params KDFParams) error {
+	if CurveOID(key.Curve()) == nil {
+		return errors.New("openpgp: unsupported curve")
+	}
+	if hashID(params.Hash) == 0 || !params.Hash.Available() {
+		return errors.New("openpgp: unsupported KDF hash function")
+	}
+	if params.Cipher.keySize() == 0 {
+		return errors.New("openpgp: unsupported key wrapping algorithm")
+	}
+	return nil
+}
+
+// Encrypt wraps a session key to an ECDH public key, as described in RFC 6637,
+// section 8, reading randomness from rand to generate the ephemeral key.
+//
+// The message is the formatted session key, containing the algorithm
+// identifier, the key, and its checksum. The KDF parameters and fingerprint
+// are the ones of the recipient's key.
+//
+// This returns the encoded ephemeral public key, and the wrapped session key,
+// which form the fields of the Public-Key Encrypted Session Key packet.
+func Encrypt(rand io.Reader, key *ecdh.PublicKey, params KDFParams, fingerprint, msg []byte) (ephemeral, wrapped []byte, err error) {
+	if err := checkParams(key, params); err != nil {
+		return nil, nil, err
+	}
+	eph, err := key.Curve().GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	zz, err := eph.ECDH(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	kek := kdf(zz, kdfParam(key.Curve(), params, fingerprint), params)
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapped, err = keywrap.Wrap(block, pad(msg))
+	if err != nil {
+		return nil, nil, err
+	}
+	return EncodePoint(eph.PublicKey()), wrapped, nil
+}
+
+// Decrypt unwraps a session key encrypted with Encrypt, using the private key
+// of the recipient, along with the KDF parameters and fingerprint of the
+// corresponding public key.
+//
+// This returns the formatted session key, with its padding removed.
+func Decrypt(key *ecdh.PrivateKey, params KDFParams, fingerprint, ephemeral, wrapped []byte) ([]byte, error) {
+	curve := key.Curve()
+	if err := checkParams(key.PublicKey(), params); err != nil {
+		return nil, err
+	}
+	eph, err := DecodePoint(curve, ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	zz, err := key.ECDH(eph)
+	if err != nil {
+		return nil, err
+	}
+	kek := kdf(zz, kdfParam(curve, params, fingerprint), params)
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := keywrap.Unwrap(block, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return unpad(msg)
+}
@@ -0,0 +1,100 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+var testFingerprint, _ = hex.DecodeString("0123456789abcdef0123456789abcdef01234567")
+
+func TestEncryptDecrypt(t *testing.T) {
+	curves := []ecdh.Curve{ecdh.P256(), ecdh.P384(), ecdh.P521(), ecdh.X25519()}
+	// algorithm identifier, AES-256 session key, and checksum
+	msg := make([]byte, 35)
+	rand.Read(msg)
+	for _, curve := range curves {
+		key, err := curve.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		params := DefaultKDFParams(curve)
+		ephemeral, wrapped, err := Encrypt(rand.Reader, key.PublicKey(), params, testFingerprint, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(wrapped) != wrapSize+8 {
+			t.Errorf("%v: wrapped key has length %d", curve, len(wrapped))
+		}
+		decrypted, err := Decrypt(key, params, testFingerprint, ephemeral, wrapped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decrypted, msg) {
+			t.Errorf("%v: decrypted %x, expected %x", curve, decrypted, msg)
+		}
+		otherFingerprint := append([]byte{}, testFingerprint...)
+		otherFingerprint[0] ^= 1
+		if _, err := Decrypt(key, params, otherFingerprint, ephemeral, wrapped); err == nil {
+			t.Errorf("%v: decrypted with the wrong fingerprint", curve)
+		}
+	}
+}
+
+func TestKDFParam(t *testing.T) {
+	params := KDFParams{Hash: crypto.SHA256, Cipher: CipherAES128}
+	expected := "08" + "2a8648ce3d030107" + "12" + "030108" + "07" +
+		hex.EncodeToString([]byte("Anonymous Sender    ")) + hex.EncodeToString(testFingerprint)
+	if out := hex.EncodeToString(kdfParam(ecdh.P256(), params, testFingerprint)); out != expected {
+		t.Errorf("kdfParam = %s, expected %s", out, expected)
+	}
+}
+
+func TestCurveOIDRoundTrip(t *testing.T) {
+	for _, curve := range []ecdh.Curve{ecdh.P256(), ecdh.P384(), ecdh.P521(), ecdh.X25519()} {
+		if CurveFromOID(CurveOID(curve)) != curve {
+			t.Errorf("%v doesn't round trip through its OID", curve)
+		}
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	msg := []byte{1, 2, 3}
+	padded := pad(msg)
+	if len(padded) != wrapSize {
+		t.Errorf("padded message has length %d", len(padded))
+	}
+	out, err := unpad(padded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, msg) {
+		t.Errorf("unpad(pad(%x)) = %x", msg, out)
+	}
+	padded[len(padded)-2] ^= 1
+	if _, err := unpad(padded); err == nil {
+		t.Errorf("accepted malformed padding")
+	}
+	if _, err := unpad([]byte{1, 2, 0}); err == nil {
+		t.Errorf("accepted empty padding")
+	}
+	if _, err := unpad([]byte{4, 4, 4}); err == nil {
+		t.Errorf("accepted padding longer than the message")
+	}
+}
+
+func TestRejectsUnsupportedParams(t *testing.T) {
+	key, _ := ecdh.P256().GenerateKey(rand.Reader)
+	params := KDFParams{Hash: crypto.SHA1, Cipher: CipherAES128}
+	if _, _, err := Encrypt(rand.Reader, key.PublicKey(), params, testFingerprint, []byte("msg")); err == nil {
+		t.Errorf("accepted SHA-1 as the KDF hash")
+	}
+	params = KDFParams{Hash: crypto.SHA256, Cipher: 2}
+	if _, _, err := Encrypt(rand.Reader, key.PublicKey(), params, testFingerprint, []byte("msg")); err == nil {
+		t.Errorf("accepted Triple DES for key wrapping")
+	}
+}
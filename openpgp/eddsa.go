@@ -0,0 +1,115 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+)
+
+// This file implements the legacy EdDSA algorithm of RFC 9580, section
+// 5.5.5.5, where Ed25519 keys and signatures are stored as MPIs. The native
+// Ed25519 algorithm of RFC 9580 stores them as plain octet strings, which need
+// no encoding.
+
+// PubKeyAlgoEdDSA is the public key algorithm identifier of legacy EdDSA.
+const PubKeyAlgoEdDSA = 22
+
+// oidEd25519 is the OID of Ed25519 in legacy EdDSA keys.
+var oidEd25519 = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x0f, 0x01}
+
+var errInvalidEdDSAKey = errors.New("openpgp: invalid EdDSA key")
+
+// leftPad returns x padded with leading zeros to size bytes, or nil if it's
+// longer than that.
+func leftPad(x []byte, size int) []byte {
+	if len(x) > size {
+		return nil
+	}
+	out := make([]byte, size)
+	copy(out[size-len(x):], x)
+	return out
+}
+
+// MarshalEdDSAPublicKey returns the algorithm-specific fields of a legacy
+// EdDSA public key: the OID of Ed25519, and the key prefixed with 0x40 as an
+// MPI.
+func MarshalEdDSAPublicKey(pub ed25519.PublicKey) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errInvalidEdDSAKey
+	}
+	out := append([]byte{byte(len(oidEd25519))}, oidEd25519...)
+	return append(out, EncodeMPI(append([]byte{curve25519Prefix}, pub...))...), nil
+}
+
+// ParseEdDSAPublicKey decodes the fields encoded by MarshalEdDSAPublicKey,
+// and returns the rest of data.
+func ParseEdDSAPublicKey(data []byte) (ed25519.PublicKey, []byte, error) {
+	oid, data, err := readOID(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(oid, oidEd25519) {
+		return nil, nil, errors.New("openpgp: unsupported curve")
+	}
+	point, rest, err := ReadMPI(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(point) != 1+ed25519.PublicKeySize || point[0] != curve25519Prefix {
+		return nil, nil, errInvalidEdDSAKey
+	}
+	return ed25519.PublicKey(point[1:]), rest, nil
+}
+
+// MarshalEdDSAPrivateKey returns the secret field of a legacy EdDSA private
+// key, which is its 32 byte seed as an MPI.
+func MarshalEdDSAPrivateKey(priv ed25519.PrivateKey) ([]byte, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return nil, errInvalidEdDSAKey
+	}
+	return EncodeMPI(priv.Seed()), nil
+}
+
+// ParseEdDSAPrivateKey decodes the secret field encoded by
+// MarshalEdDSAPrivateKey, and returns the rest of data.
+func ParseEdDSAPrivateKey(data []byte) (ed25519.PrivateKey, []byte, error) {
+	seed, rest, err := ReadMPI(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed = leftPad(seed, ed25519.SeedSize)
+	if seed == nil {
+		return nil, nil, errInvalidEdDSAKey
+	}
+	return ed25519.NewKeyFromSeed(seed), rest, nil
+}
+
+// SignEdDSA signs the hash of a signature packet with priv, and returns the
+// legacy EdDSA signature fields: R and S, as two MPIs.
+func SignEdDSA(priv ed25519.PrivateKey, digest []byte) ([]byte, error) {
+	sig, err := edsig.Sign(priv, digest)
+	if err != nil {
+		return nil, err
+	}
+	return append(EncodeMPI(sig[:32]), EncodeMPI(sig[32:])...), nil
+}
+
+// VerifyEdDSA reports whether sig holds valid legacy EdDSA signature fields
+// of digest by pub.
+func VerifyEdDSA(pub ed25519.PublicKey, digest, sig []byte) bool {
+	r, rest, err := ReadMPI(sig)
+	if err != nil {
+		return false
+	}
+	s, rest, err := ReadMPI(rest)
+	if err != nil || len(rest) != 0 {
+		return false
+	}
+	r, s = leftPad(r, 32), leftPad(s, 32)
+	if r == nil || s == nil {
+		return false
+	}
+	return edsig.Verify(pub, digest, append(r, s...))
+}
@@ -0,0 +1,66 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestEdDSAKeyMaterial(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := MarshalEdDSAPublicKey(pub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if public[0] != 9 || public[10] != 0x01 || public[11] != 0x07 || public[12] != 0x40 {
+			t.Errorf("unexpected encoding %x", public)
+		}
+		parsed, rest, err := ParseEdDSAPublicKey(public)
+		if err != nil || !bytes.Equal(parsed, pub) || len(rest) != 0 {
+			t.Errorf("public key didn't round trip: %v", err)
+		}
+		secret, err := MarshalEdDSAPrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsedPriv, rest, err := ParseEdDSAPrivateKey(secret)
+		if err != nil || !bytes.Equal(parsedPriv, priv) || len(rest) != 0 {
+			t.Errorf("private key didn't round trip: %v", err)
+		}
+	}
+}
+
+func TestEdDSASignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("signature packet"))
+	sig, err := SignEdDSA(priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyEdDSA(pub, digest[:], sig) {
+		t.Error("signature rejected")
+	}
+	// The fields are the halves of the signature of crypto/ed25519.
+	expected := ed25519.Sign(priv, digest[:])
+	r, rest, _ := ReadMPI(sig)
+	s, _, _ := ReadMPI(rest)
+	if !bytes.Equal(leftPad(r, 32), expected[:32]) || !bytes.Equal(leftPad(s, 32), expected[32:]) {
+		t.Error("signature differs from crypto/ed25519")
+	}
+	digest[0] ^= 1
+	if VerifyEdDSA(pub, digest[:], sig) {
+		t.Error("signature of another digest accepted")
+	}
+	if VerifyEdDSA(pub, digest[:], append(sig, 0)) {
+		t.Error("trailing data accepted")
+	}
+}
@@ -0,0 +1,195 @@
+package openpgp
+
+import (
+	"crypto"
+	"errors"
+	"math/bits"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+// This file implements the encodings of the algorithm-specific fields of
+// ECDH keys, as described in RFC 9580, sections 3.2, 5.5.5.6, and 11.5.
+
+var (
+	errMalformedMPI    = errors.New("openpgp: malformed MPI")
+	errMalformedFields = errors.New("openpgp: malformed key material")
+)
+
+// EncodeMPI returns the multiprecision integer encoding of the big-endian
+// integer x: a two byte bit length, followed by x without its leading zeros.
+func EncodeMPI(x []byte) []byte {
+	for len(x) > 0 && x[0] == 0 {
+		x = x[1:]
+	}
+	bitLen := 0
+	if len(x) > 0 {
+		bitLen = 8*(len(x)-1) + bits.Len8(x[0])
+	}
+	out := make([]byte, 2, 2+len(x))
+	out[0] = byte(bitLen >> 8)
+	out[1] = byte(bitLen)
+	return append(out, x...)
+}
+
+// ReadMPI decodes a multiprecision integer from the start of data, and returns
+// its big-endian value along with the rest of data.
+//
+// The bit length must be exact, as required by RFC 9580, so that every integer
+// has a single encoding.
+func ReadMPI(data []byte) (x, rest []byte, err error) {
+	if len(data) < 2 {
+		return nil, nil, errMalformedMPI
+	}
+	bitLen := int(data[0])<<8 | int(data[1])
+	n := (bitLen + 7) / 8
+	if len(data) < 2+n {
+		return nil, nil, errMalformedMPI
+	}
+	x = data[2 : 2+n]
+	if n > 0 && 8*(n-1)+bits.Len8(x[0]) != bitLen {
+		return nil, nil, errMalformedMPI
+	}
+	return append([]byte{}, x...), data[2+n:], nil
+}
+
+// readOID decodes the length-prefixed curve OID which starts the key material
+// of ECDH and EdDSA keys.
+func readOID(data []byte) (oid, rest []byte, err error) {
+	if len(data) < 1 || data[0] == 0 || data[0] == 0xff || len(data) < 1+int(data[0]) {
+		return nil, nil, errMalformedFields
+	}
+	return data[1 : 1+data[0]], data[1+data[0]:], nil
+}
+
+// hashFromID returns the hash function with an OpenPGP identifier, among the
+// ones allowed by the ECDH KDF, or 0.
+func hashFromID(id byte) crypto.Hash {
+	for _, h := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		if hashID(h) == id {
+			return h
+		}
+	}
+	return 0
+}
+
+// Marshal returns the encoding of the KDF parameters in an ECDH public key: a
+// length byte, the reserved value 1, and the hash and cipher identifiers.
+func (p KDFParams) Marshal() []byte {
+	return []byte{3, 1, hashID(p.Hash), byte(p.Cipher)}
+}
+
+// ParseKDFParams decodes KDF parameters encoded with KDFParams.Marshal from
+// the start of data, and returns them along with the rest of data.
+func ParseKDFParams(data []byte) (KDFParams, []byte, error) {
+	if len(data) < 4 || data[0] != 3 || data[1] != 1 {
+		return KDFParams{}, nil, errors.New("openpgp: malformed KDF parameters")
+	}
+	params := KDFParams{Hash: hashFromID(data[2]), Cipher: CipherFunction(data[3])}
+	if params.Hash == 0 {
+		return KDFParams{}, nil, errors.New("openpgp: unsupported KDF hash function")
+	}
+	if params.Cipher.keySize() == 0 {
+		return KDFParams{}, nil, errors.New("openpgp: unsupported key wrapping algorithm")
+	}
+	return params, data[4:], nil
+}
+
+// MarshalECDHPublicKey returns the algorithm-specific fields of an ECDH public
+// key: the curve OID, the point encoded with EncodePoint as an MPI, and the KDF
+// parameters.
+func MarshalECDHPublicKey(key *ecdh.PublicKey, params KDFParams) ([]byte, error) {
+	if err := checkParams(key, params); err != nil {
+		return nil, err
+	}
+	oid := CurveOID(key.Curve())
+	out := append([]byte{byte(len(oid))}, oid...)
+	out = append(out, EncodeMPI(EncodePoint(key))...)
+	return append(out, params.Marshal()...), nil
+}
+
+// ParseECDHPublicKey decodes the fields encoded by MarshalECDHPublicKey, and
+// returns the rest of data.
+func ParseECDHPublicKey(data []byte) (*ecdh.PublicKey, KDFParams, []byte, error) {
+	oid, data, err := readOID(data)
+	if err != nil {
+		return nil, KDFParams{}, nil, err
+	}
+	curve := CurveFromOID(oid)
+	if curve == nil {
+		return nil, KDFParams{}, nil, errors.New("openpgp: unsupported curve")
+	}
+	point, data, err := ReadMPI(data)
+	if err != nil {
+		return nil, KDFParams{}, nil, err
+	}
+	key, err := DecodePoint(curve, point)
+	if err != nil {
+		return nil, KDFParams{}, nil, err
+	}
+	params, data, err := ParseKDFParams(data)
+	if err != nil {
+		return nil, KDFParams{}, nil, err
+	}
+	return key, params, data, nil
+}
+
+// reverse returns a reversed copy of b.
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+// scalarSize returns the size of the private keys of a supported curve.
+func scalarSize(curve ecdh.Curve) int {
+	switch curve {
+	case ecdh.P384():
+		return 48
+	case ecdh.P521():
+		return 66
+	default:
+		return 32
+	}
+}
+
+// MarshalECDHPrivateKey returns the algorithm-specific secret field of an
+// ECDH private key, which is the scalar as an MPI.
+//
+// For Curve25519, the native little-endian scalar is stored in reverse order,
+// as required by RFC 9580, section 5.5.5.6.
+func MarshalECDHPrivateKey(key *ecdh.PrivateKey) []byte {
+	scalar := key.Bytes()
+	if key.Curve() == ecdh.X25519() {
+		scalar = reverse(scalar)
+	}
+	return EncodeMPI(scalar)
+}
+
+// ParseECDHPrivateKey decodes the secret field encoded by
+// MarshalECDHPrivateKey, for a key over curve, and returns the rest of data.
+func ParseECDHPrivateKey(curve ecdh.Curve, data []byte) (*ecdh.PrivateKey, []byte, error) {
+	if CurveOID(curve) == nil {
+		return nil, nil, errors.New("openpgp: unsupported curve")
+	}
+	scalar, rest, err := ReadMPI(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	size := scalarSize(curve)
+	if len(scalar) > size {
+		return nil, nil, errMalformedFields
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(scalar):], scalar)
+	if curve == ecdh.X25519() {
+		padded = reverse(padded)
+	}
+	key, err := curve.NewPrivateKey(padded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, rest, nil
+}
@@ -0,0 +1,102 @@
+package openpgp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+// TestMPI uses the examples of RFC 9580, section 3.2.
+func TestMPI(t *testing.T) {
+	for _, test := range []struct{ value, encoded string }{
+		{"", "0000"},
+		{"01", "000101"},
+		{"01ff", "000901ff"},
+		{"0001ff", "000901ff"},
+	} {
+		value, _ := hex.DecodeString(test.value)
+		if got := hex.EncodeToString(EncodeMPI(value)); got != test.encoded {
+			t.Errorf("EncodeMPI(%s) = %s, expected %s", test.value, got, test.encoded)
+		}
+		encoded, _ := hex.DecodeString(test.encoded + "aa")
+		x, rest, err := ReadMPI(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(x, bytes.TrimLeft(value, "\x00")) || !bytes.Equal(rest, []byte{0xaa}) {
+			t.Errorf("ReadMPI(%s) = %x, %x", test.encoded, x, rest)
+		}
+	}
+	for _, bad := range []string{"", "00", "0002", "000901", "000a01ff", "000800ff"} {
+		encoded, _ := hex.DecodeString(bad)
+		if _, _, err := ReadMPI(encoded); err == nil {
+			t.Errorf("ReadMPI accepted %s", bad)
+		}
+	}
+}
+
+func TestKDFParamsEncoding(t *testing.T) {
+	params := KDFParams{Hash: crypto.SHA384, Cipher: CipherAES192}
+	if got := hex.EncodeToString(params.Marshal()); got != "03010908" {
+		t.Errorf("Marshal = %s", got)
+	}
+	parsed, rest, err := ParseKDFParams([]byte{3, 1, 9, 8, 0xaa})
+	if err != nil || parsed != params || !bytes.Equal(rest, []byte{0xaa}) {
+		t.Errorf("got %v, %x, %v", parsed, rest, err)
+	}
+	for _, bad := range [][]byte{{3, 1, 9}, {3, 2, 9, 8}, {3, 1, 2, 8}, {3, 1, 9, 3}} {
+		if _, _, err := ParseKDFParams(bad); err == nil {
+			t.Errorf("ParseKDFParams accepted %x", bad)
+		}
+	}
+}
+
+func TestECDHKeyMaterial(t *testing.T) {
+	for _, curve := range []ecdh.Curve{ecdh.P256(), ecdh.P384(), ecdh.P521(), ecdh.X25519()} {
+		// Leading zeros are dropped from MPIs, so try many keys.
+		for i := 0; i < 50; i++ {
+			key, err := curve.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			params := DefaultKDFParams(curve)
+			public, err := MarshalECDHPublicKey(key.PublicKey(), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pub, parsedParams, rest, err := ParseECDHPublicKey(public)
+			if err != nil {
+				t.Fatalf("%v: %v", curve, err)
+			}
+			if !bytes.Equal(pub.Bytes(), key.PublicKey().Bytes()) || parsedParams != params || len(rest) != 0 {
+				t.Errorf("%v: public key didn't round trip", curve)
+			}
+			priv, rest, err := ParseECDHPrivateKey(curve, MarshalECDHPrivateKey(key))
+			if err != nil {
+				t.Fatalf("%v: %v", curve, err)
+			}
+			if !bytes.Equal(priv.Bytes(), key.Bytes()) || len(rest) != 0 {
+				t.Errorf("%v: private key didn't round trip", curve)
+			}
+		}
+	}
+}
+
+func TestCurve25519SecretOrder(t *testing.T) {
+	native := make([]byte, 32)
+	native[0] = 0x48
+	native[31] = 0x40
+	key, err := ecdh.X25519().NewPrivateKey(native)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first byte of the native scalar is the last one of the MPI.
+	encoded := MarshalECDHPrivateKey(key)
+	if encoded[0] != 0x00 || encoded[1] != 0xff || encoded[2] != 0x40 || encoded[len(encoded)-1] != 0x48 {
+		t.Errorf("got %x", encoded)
+	}
+}
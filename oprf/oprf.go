@@ -0,0 +1,165 @@
+// Package oprf implements oblivious pseudorandom functions, following RFC 9497.
+//
+// An OPRF lets a client learn the output of a pseudorandom function F(k, x),
+// keyed by a server, on an input x of its choosing, without the server learning
+// anything about x, and without the client learning anything about k.
+package oprf
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// Mode identifies the variant of the protocol, as defined in RFC 9497, section 3.1.
+type Mode byte
+
+const (
+	// ModeOPRF is the base mode of the protocol.
+	ModeOPRF Mode = 0
+)
+
+// Suite is an OPRF ciphersuite, combining a group and a hash function, used
+// in a given mode.
+type Suite struct {
+	g       group.Group
+	hash    func() hash.Hash
+	mode    Mode
+	context []byte
+}
+
+// New returns the ciphersuite over a given group, in a given mode.
+//
+// The P-256, P-384, and P-521 groups are supported, with SHA-256, SHA-384,
+// and SHA-512 respectively, as defined in RFC 9497, section 4.
+func New(g group.Group, mode Mode) (*Suite, error) {
+	var id string
+	var h func() hash.Hash
+	switch g.Name() {
+	case "P-256":
+		id, h = "P256-SHA256", sha256.New
+	case "P-384":
+		id, h = "P384-SHA384", sha512.New384
+	case "P-521":
+		id, h = "P521-SHA512", sha512.New
+	default:
+		return nil, errors.New("oprf: unsupported group " + g.Name())
+	}
+	if mode != ModeOPRF {
+		return nil, errors.New("oprf: unsupported mode")
+	}
+	context := append([]byte("OPRFV1-"), byte(mode), '-')
+	context = append(context, id...)
+	return &Suite{g: g, hash: h, mode: mode, context: context}, nil
+}
+
+// Group returns the group used by this suite.
+func (s *Suite) Group() group.Group {
+	return s.g
+}
+
+// Hash returns the hash function used by this suite.
+func (s *Suite) Hash() func() hash.Hash {
+	return s.hash
+}
+
+func (s *Suite) dst(prefix string) []byte {
+	return append([]byte(prefix), s.context...)
+}
+
+// appendPrefixed appends data to a buffer, prefixed by its length as a 2 byte
+// big-endian integer.
+func appendPrefixed(buf []byte, data []byte) []byte {
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(data)))
+	return append(append(buf, length[:]...), data...)
+}
+
+var errDeriveKeyPair = errors.New("oprf: failed to derive a key pair")
+
+// GenerateKeyPair generates a private key for the server, along with its
+// public key, reading randomness from rand.
+func (s *Suite) GenerateKeyPair(rand io.Reader) (*safenum.Nat, group.Element, error) {
+	for {
+		sk, err := s.g.RandomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !sk.EqZero() {
+			return sk, s.g.ScalarBaseMult(sk), nil
+		}
+	}
+}
+
+// DeriveKeyPair deterministically derives a private key for the server, along
+// with its public key, from a seed and some public information, as described
+// in RFC 9497, section 3.2.1.
+func (s *Suite) DeriveKeyPair(seed, info []byte) (*safenum.Nat, group.Element, error) {
+	deriveInput := appendPrefixed(append([]byte{}, seed...), info)
+	dst := s.dst("DeriveKeyPair")
+	for counter := 0; counter < 256; counter++ {
+		sk := s.g.HashToScalar(append(deriveInput, byte(counter)), dst)
+		if !sk.EqZero() {
+			return sk, s.g.ScalarBaseMult(sk), nil
+		}
+	}
+	return nil, nil, errDeriveKeyPair
+}
+
+// Blind hashes the input of the client to the group, and blinds it with a
+// random scalar, returning the scalar, and the element to send to the server.
+func (s *Suite) Blind(input []byte, rand io.Reader) (blind *safenum.Nat, blinded group.Element, err error) {
+	P := s.g.HashToElement(input, s.dst("HashToGroup-"))
+	if P.IsIdentity() {
+		return nil, nil, errors.New("oprf: invalid input")
+	}
+	for {
+		blind, err = s.g.RandomScalar(rand)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !blind.EqZero() {
+			return blind, P.ScalarMult(blind), nil
+		}
+	}
+}
+
+// BlindEvaluate evaluates the function on a blinded element, using the private
+// key of the server.
+func (s *Suite) BlindEvaluate(sk *safenum.Nat, blinded group.Element) group.Element {
+	return blinded.ScalarMult(sk)
+}
+
+// Finalize unblinds the evaluation returned by the server, and returns the
+// output of the function on the input of the client.
+func (s *Suite) Finalize(input []byte, blind *safenum.Nat, evaluated group.Element) []byte {
+	inverse := new(safenum.Nat).ModInverse(blind, s.g.Order())
+	return s.finish(input, evaluated.ScalarMult(inverse))
+}
+
+// Evaluate computes the output of the function on an input directly, using the
+// private key of the server.
+func (s *Suite) Evaluate(sk *safenum.Nat, input []byte) ([]byte, error) {
+	P := s.g.HashToElement(input, s.dst("HashToGroup-"))
+	if P.IsIdentity() {
+		return nil, errors.New("oprf: invalid input")
+	}
+	return s.finish(input, P.ScalarMult(sk)), nil
+}
+
+// finish hashes the input with its unblinded evaluation.
+func (s *Suite) finish(input []byte, unblinded group.Element) []byte {
+	var buf []byte
+	buf = appendPrefixed(buf, input)
+	buf = appendPrefixed(buf, unblinded.Bytes())
+	buf = append(buf, "Finalize"...)
+	h := s.hash()
+	h.Write(buf)
+	return h.Sum(nil)
+}
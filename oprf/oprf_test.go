@@ -0,0 +1,87 @@
+package oprf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func fromHex(s string) []byte {
+	out, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func TestP256Vector(t *testing.T) {
+	// See RFC 9497, appendix A.3.1.
+	suite, err := New(group.P256(), ModeOPRF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := suite.Group()
+	seed := bytes.Repeat([]byte{0xa3}, 32)
+	sk, _, err := suite.DeriveKeyPair(seed, []byte("test key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSk := "159749d750713afe245d2d39ccfaae8381c53ce92d098a9375ee70739c7ac0bf"
+	if hex.EncodeToString(g.EncodeScalar(sk)) != expectedSk {
+		t.Fatalf("sk = %x, expected %s", g.EncodeScalar(sk), expectedSk)
+	}
+	input := []byte{0}
+	blind, _ := g.DecodeScalar(fromHex("3338fa65ec36e0290022b48eb562889d89dbfa691d1cde91517fa222ed7ad364"))
+	blinded := g.HashToElement(input, suite.dst("HashToGroup-")).ScalarMult(blind)
+	if hex.EncodeToString(blinded.Bytes()) != "03723a1e5c09b8b9c18d1dcbca29e8007e95f14f4732d9346d490ffc195110368d" {
+		t.Errorf("blinded element = %x", blinded.Bytes())
+	}
+	evaluated := suite.BlindEvaluate(sk, blinded)
+	if hex.EncodeToString(evaluated.Bytes()) != "030de02ffec47a1fd53efcdd1c6faf5bdc270912b8749e783c7ca75bb412958832" {
+		t.Errorf("evaluated element = %x", evaluated.Bytes())
+	}
+	output := suite.Finalize(input, blind, evaluated)
+	if hex.EncodeToString(output) != "a0b34de5fa4c5b6da07e72af73cc507cceeb48981b97b7285fc375345fe495dd" {
+		t.Errorf("output = %x", output)
+	}
+}
+
+func TestOPRF(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		suite, err := New(g, ModeOPRF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sk, _, err := suite.GenerateKeyPair(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := []byte("input")
+		blind, blinded, err := suite.Blind(input, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output := suite.Finalize(input, blind, suite.BlindEvaluate(sk, blinded))
+		expected, err := suite.Evaluate(sk, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(output, expected) {
+			t.Errorf("%s: Finalize and Evaluate disagree", g.Name())
+		}
+		other, _ := suite.Evaluate(new(safenum.Nat).ModAdd(sk, new(safenum.Nat).SetUint64(1), g.Order()), input)
+		if bytes.Equal(output, other) {
+			t.Errorf("%s: output doesn't depend on the key", g.Name())
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	if _, err := New(group.P256(), Mode(7)); err == nil {
+		t.Errorf("expected an error for an unknown mode")
+	}
+}
@@ -0,0 +1,134 @@
+// Package ot implements the "simplest" oblivious transfer protocol of Chou and
+// Orlandi, in any group of the group package, such as ristretto255 or P-256.
+//
+// In each oblivious transfer, the sender obtains two random keys, and the
+// receiver obtains one of them, chosen by a secret choice bit. The sender
+// learns nothing about the choice, and the receiver nothing about the other
+// key. The keys can be used to encrypt a pair of messages, or as the base
+// oblivious transfers of an OT extension protocol.
+//
+// The protocol runs many transfers in a batch, with a single message from each
+// party: the sender speaks first, and the receiver replies, after which both
+// parties can compute their keys. The protocol is only secure against passive
+// adversaries, and should be used with a fresh Sender for each batch.
+package ot
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// KeySize is the size of the keys produced by each transfer.
+const KeySize = 32
+
+// keyDST is the domain separation string used when deriving keys.
+const keyDST = "ctcrypto-ot-simplest-v1"
+
+// deriveKey hashes the shared element of a transfer into a key, binding the
+// index of the transfer, and the messages of both parties.
+func deriveKey(g group.Group, index int, A, B, shared group.Element) []byte {
+	h := sha256.New()
+	h.Write([]byte(keyDST))
+	h.Write([]byte(g.Name()))
+	var i [8]byte
+	binary.BigEndian.PutUint64(i[:], uint64(index))
+	h.Write(i[:])
+	h.Write(A.Bytes())
+	h.Write(B.Bytes())
+	h.Write(shared.Bytes())
+	return h.Sum(nil)
+}
+
+// Sender is the sender of a batch of oblivious transfers.
+type Sender struct {
+	g group.Group
+	a *safenum.Nat
+	A group.Element
+}
+
+// NewSender creates a new sender, reading randomness from rand.
+func NewSender(g group.Group, rand io.Reader) (*Sender, error) {
+	a, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{g: g, a: a, A: g.ScalarBaseMult(a)}, nil
+}
+
+// Message returns the message of the sender, which must be sent to the receiver.
+func (s *Sender) Message() []byte {
+	return s.A.Bytes()
+}
+
+// Finish processes the message of the receiver, returning the pair of keys of
+// each transfer. The receiver knows the first key of a pair if its choice bit
+// was 0, and the second key otherwise.
+func (s *Sender) Finish(receiverMsg []byte) ([][2][]byte, error) {
+	size := s.g.ElementSize()
+	if len(receiverMsg) == 0 || len(receiverMsg)%size != 0 {
+		return nil, errors.New("ot: invalid receiver message length")
+	}
+	// a B, and a (B - A) = a B - a A
+	aA := s.A.ScalarMult(s.a)
+	keys := make([][2][]byte, len(receiverMsg)/size)
+	for i := range keys {
+		B, err := s.g.DecodeElement(receiverMsg[i*size : (i+1)*size])
+		if err != nil {
+			return nil, err
+		}
+		aB := B.ScalarMult(s.a)
+		keys[i][0] = deriveKey(s.g, i, s.A, B, aB)
+		keys[i][1] = deriveKey(s.g, i, s.A, B, group.Sub(aB, aA))
+	}
+	return keys, nil
+}
+
+// Receiver is the receiver of a batch of oblivious transfers.
+type Receiver struct {
+	msg  []byte
+	keys [][]byte
+}
+
+// NewReceiver processes the message of the sender, and creates a receiver for
+// a batch of transfers, one for each choice bit, which must be 0 or 1,
+// reading randomness from rand.
+//
+// The choice bits are only used in constant-time operations.
+func NewReceiver(g group.Group, senderMsg []byte, choices []int, rand io.Reader) (*Receiver, error) {
+	if len(choices) == 0 {
+		return nil, errors.New("ot: no choices")
+	}
+	A, err := g.DecodeElement(senderMsg)
+	if err != nil {
+		return nil, err
+	}
+	r := &Receiver{keys: make([][]byte, len(choices))}
+	for i, c := range choices {
+		b, err := g.RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		// B = b G + c A, which hides c, since b G is uniformly random.
+		cNat := new(safenum.Nat).SetUint64(uint64(subtle.ConstantTimeEq(int32(c), 1)))
+		B := g.ScalarBaseMult(b).Add(A.ScalarMult(cNat))
+		r.msg = append(r.msg, B.Bytes()...)
+		r.keys[i] = deriveKey(g, i, A, B, A.ScalarMult(b))
+	}
+	return r, nil
+}
+
+// Message returns the message of the receiver, which must be sent to the sender.
+func (r *Receiver) Message() []byte {
+	return r.msg
+}
+
+// Keys returns the key chosen by the receiver in each transfer.
+func (r *Receiver) Keys() [][]byte {
+	return r.keys
+}
@@ -0,0 +1,67 @@
+package ot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestObliviousTransfer(t *testing.T) {
+	choices := []int{0, 1, 1, 0, 1}
+	for _, g := range []group.Group{group.Ristretto255(), group.P256()} {
+		sender, err := NewSender(g, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		receiver, err := NewReceiver(g, sender.Message(), choices, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		senderKeys, err := sender.Finish(receiver.Message())
+		if err != nil {
+			t.Fatal(err)
+		}
+		receiverKeys := receiver.Keys()
+		if len(senderKeys) != len(choices) || len(receiverKeys) != len(choices) {
+			t.Fatalf("%s: wrong number of transfers", g.Name())
+		}
+		for i, c := range choices {
+			if !bytes.Equal(receiverKeys[i], senderKeys[i][c]) {
+				t.Errorf("%s: transfer %d: receiver didn't get the chosen key", g.Name(), i)
+			}
+			if bytes.Equal(receiverKeys[i], senderKeys[i][1-c]) {
+				t.Errorf("%s: transfer %d: receiver got the other key", g.Name(), i)
+			}
+			if len(receiverKeys[i]) != KeySize {
+				t.Errorf("%s: transfer %d: key has length %d", g.Name(), i, len(receiverKeys[i]))
+			}
+		}
+		if bytes.Equal(senderKeys[0][0], senderKeys[3][0]) {
+			t.Errorf("%s: keys are reused across transfers", g.Name())
+		}
+	}
+}
+
+func TestSenderRejectsInvalidMessages(t *testing.T) {
+	g := group.Ristretto255()
+	sender, _ := NewSender(g, rand.Reader)
+	if _, err := sender.Finish(make([]byte, g.ElementSize()+1)); err == nil {
+		t.Errorf("accepted a message with a partial element")
+	}
+	if _, err := sender.Finish(g.Identity().Bytes()); err == nil {
+		t.Errorf("accepted the identity")
+	}
+}
+
+func TestReceiverRejectsInvalidMessages(t *testing.T) {
+	g := group.P256()
+	if _, err := NewReceiver(g, g.Identity().Bytes(), []int{0}, rand.Reader); err == nil {
+		t.Errorf("accepted the identity")
+	}
+	sender, _ := NewSender(g, rand.Reader)
+	if _, err := NewReceiver(g, sender.Message(), nil, rand.Reader); err == nil {
+		t.Errorf("accepted an empty batch")
+	}
+}
@@ -0,0 +1,141 @@
+// Package padding implements the block cipher paddings of PKCS #7 and ISO/IEC
+// 7816-4, with checks running in constant time.
+//
+// With CBC mode, a server which reveals whether the padding of a decrypted
+// message is valid, through an error or through its timing, lets an attacker
+// decrypt any ciphertext, as shown by Vaudenay. The checks here only reveal
+// the length of their input, and the Check functions return their result as
+// a choice, so that callers can merge it with the result of a MAC check, and
+// only branch once.
+package padding
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+var errInvalidPadding = errors.New("padding: invalid padding")
+
+// checkBlockSize panics if blockSize is outside of [1, 255].
+func checkBlockSize(blockSize int) {
+	if blockSize < 1 || blockSize > 255 {
+		panic("padding: invalid block size")
+	}
+}
+
+// PadPKCS7 appends n bytes of value n to b, where n is between 1 and
+// blockSize, so that the result is a multiple of blockSize long.
+func PadPKCS7(b []byte, blockSize int) []byte {
+	checkBlockSize(blockSize)
+	n := blockSize - len(b)%blockSize
+	for i := 0; i < n; i++ {
+		b = append(b, byte(n))
+	}
+	return b
+}
+
+// CheckPKCS7 returns the length of the PKCS #7 padding at the end of b, and 1
+// if that padding is valid, or 0 otherwise, in which case the length is
+// meaningless.
+//
+// The time taken only depends on len(b) and blockSize, and not on whether the
+// padding is valid, nor on its length.
+func CheckPKCS7(b []byte, blockSize int) (n, ok int) {
+	checkBlockSize(blockSize)
+	if len(b) == 0 || len(b)%blockSize != 0 {
+		return 0, 0
+	}
+	pad := b[len(b)-1]
+	n = int(pad)
+	ok = subtle.ConstantTimeLessOrEq(1, n) & subtle.ConstantTimeLessOrEq(n, blockSize)
+	// The padding is at most one block long, so the last block is checked in
+	// full, whatever n is.
+	for i := 0; i < blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, n)
+		matches := subtle.ConstantTimeByteEq(b[len(b)-1-i], pad)
+		ok &= matches | (inPadding ^ 1)
+	}
+	return n, ok
+}
+
+// UnpadPKCS7 removes the PKCS #7 padding at the end of b, returning an error
+// if it is invalid.
+//
+// The check runs in constant time, as with CheckPKCS7, but the error itself
+// tells whether the padding is valid: most protocols should instead merge the
+// result of CheckPKCS7 with that of their integrity check.
+func UnpadPKCS7(b []byte, blockSize int) ([]byte, error) {
+	n, ok := CheckPKCS7(b, blockSize)
+	if ok != 1 {
+		return nil, errInvalidPadding
+	}
+	return b[:len(b)-n], nil
+}
+
+// PadISO7816 appends a 0x80 byte to b, followed by zeros, up to a multiple of
+// blockSize, as described in ISO/IEC 7816-4. This is also padding method 2 of
+// ISO/IEC 9797-1.
+func PadISO7816(b []byte, blockSize int) []byte {
+	checkBlockSize(blockSize)
+	b = append(b, 0x80)
+	for len(b)%blockSize != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// CheckISO7816 returns the length of the ISO/IEC 7816-4 padding at the end of
+// b, and 1 if that padding is valid, or 0 otherwise, in which case the length
+// is meaningless.
+//
+// The time taken only depends on len(b) and blockSize.
+func CheckISO7816(b []byte, blockSize int) (n, ok int) {
+	checkBlockSize(blockSize)
+	if len(b) == 0 || len(b)%blockSize != 0 {
+		return 0, 0
+	}
+	// The padding is the zeros at the end of the last block, and the byte
+	// before them, which must be 0x80.
+	found := 0
+	for i := 0; i < blockSize; i++ {
+		c := b[len(b)-1-i]
+		isZero := subtle.ConstantTimeByteEq(c, 0)
+		first := (found ^ 1) & (isZero ^ 1)
+		n = subtle.ConstantTimeSelect(first, i+1, n)
+		ok = subtle.ConstantTimeSelect(first, subtle.ConstantTimeByteEq(c, 0x80), ok)
+		found |= first
+	}
+	return n, ok & found
+}
+
+// UnpadISO7816 removes the ISO/IEC 7816-4 padding at the end of b, returning
+// an error if it is invalid.
+//
+// As with UnpadPKCS7, the error reveals whether the padding is valid.
+func UnpadISO7816(b []byte, blockSize int) ([]byte, error) {
+	n, ok := CheckISO7816(b, blockSize)
+	if ok != 1 {
+		return nil, errInvalidPadding
+	}
+	return b[:len(b)-n], nil
+}
+
+// CopyIf copies the first n bytes of src to dst if ok is 1, and leaves dst
+// unchanged if ok is 0. The other bytes of dst are never modified.
+//
+// Neither n nor ok are revealed by the time taken, which only depends on
+// len(src), so that a message can be extracted after removing a padding of
+// secret length. CopyIf panics if dst is shorter than src, or if n is larger
+// than len(src).
+func CopyIf(ok int, dst, src []byte, n int) {
+	if len(dst) < len(src) {
+		panic("padding: dst is shorter than src")
+	}
+	if n < 0 || n > len(src) {
+		panic("padding: n is out of range")
+	}
+	for i := range src {
+		mask := byte(-(ok & subtle.ConstantTimeLessOrEq(i+1, n)))
+		dst[i] ^= (dst[i] ^ src[i]) & mask
+	}
+}
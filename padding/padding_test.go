@@ -0,0 +1,95 @@
+package padding
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPKCS7RoundTrip(t *testing.T) {
+	for n := 0; n <= 33; n++ {
+		msg := bytes.Repeat([]byte{0x42}, n)
+		padded := PadPKCS7(append([]byte(nil), msg...), 16)
+		if len(padded)%16 != 0 || len(padded) <= n {
+			t.Fatalf("PadPKCS7 of %d bytes has length %d", n, len(padded))
+		}
+		unpadded, err := UnpadPKCS7(padded, 16)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(unpadded, msg) {
+			t.Errorf("%d bytes: UnpadPKCS7 = %x", n, unpadded)
+		}
+	}
+}
+
+func TestPKCS7Invalid(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		bytes.Repeat([]byte{1}, 15),
+		append(bytes.Repeat([]byte{0}, 15), 0),
+		append(bytes.Repeat([]byte{0}, 15), 17),
+		append(bytes.Repeat([]byte{0}, 13), 3, 2, 3),
+		append(bytes.Repeat([]byte{16}, 15), 0x10^0x80),
+		bytes.Repeat([]byte{16}, 32)[:31],
+	}
+	for _, b := range tests {
+		if _, ok := CheckPKCS7(b, 16); ok != 0 {
+			t.Errorf("CheckPKCS7(%x) accepted invalid padding", b)
+		}
+		if _, err := UnpadPKCS7(b, 16); err == nil {
+			t.Errorf("UnpadPKCS7(%x) accepted invalid padding", b)
+		}
+	}
+}
+
+func TestISO7816RoundTrip(t *testing.T) {
+	for n := 0; n <= 17; n++ {
+		// Trailing zeros in the message must be kept.
+		msg := append(bytes.Repeat([]byte{0x42}, n), 0, 0)
+		padded := PadISO7816(append([]byte(nil), msg...), 8)
+		unpadded, err := UnpadISO7816(padded, 8)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", n, err)
+		}
+		if !bytes.Equal(unpadded, msg) {
+			t.Errorf("%d bytes: UnpadISO7816 = %x", n, unpadded)
+		}
+	}
+}
+
+func TestISO7816Invalid(t *testing.T) {
+	tests := [][]byte{
+		nil,
+		make([]byte, 8),
+		{1, 2, 3, 4, 5, 6, 7, 8},
+		{1, 2, 3, 4, 5, 0x81, 0, 0},
+		{0x80, 0, 0, 0, 0, 0, 0},
+		// The padding can't extend past the last block.
+		{0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+	for _, b := range tests {
+		if _, ok := CheckISO7816(b, 8); ok != 0 {
+			t.Errorf("CheckISO7816(%x) accepted invalid padding", b)
+		}
+		if _, err := UnpadISO7816(b, 8); err == nil {
+			t.Errorf("UnpadISO7816(%x) accepted invalid padding", b)
+		}
+	}
+}
+
+func TestCopyIf(t *testing.T) {
+	src := []byte{1, 2, 3, 4}
+	dst := []byte{9, 9, 9, 9, 9}
+	CopyIf(0, dst, src, 3)
+	if !bytes.Equal(dst, []byte{9, 9, 9, 9, 9}) {
+		t.Errorf("CopyIf with ok = 0 modified dst: %x", dst)
+	}
+	CopyIf(1, dst, src, 3)
+	if !bytes.Equal(dst, []byte{1, 2, 3, 9, 9}) {
+		t.Errorf("CopyIf with n = 3 gave %x", dst)
+	}
+	CopyIf(1, dst, src, 4)
+	if !bytes.Equal(dst, []byte{1, 2, 3, 4, 9}) {
+		t.Errorf("CopyIf with n = 4 gave %x", dst)
+	}
+}
@@ -0,0 +1,226 @@
+// Package paillier implements the Paillier cryptosystem, which is additively
+// homomorphic: ciphertexts can be added together, and multiplied by plaintext
+// scalars, without knowing the private key.
+//
+// The arithmetic on ciphertexts, and decryption, are done with safenum, so
+// their execution time doesn't depend on the plaintexts involved. The moduli
+// generated by this package are Blum integers, i.e. the product of two primes
+// congruent to 3 modulo 4, as required by some of the proofs about them.
+package paillier
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/rand"
+	"github.com/cronokirby/safenum"
+)
+
+// PublicKey is a Paillier public key, containing the modulus N.
+type PublicKey struct {
+	n        *safenum.Modulus
+	nNat     *safenum.Nat
+	nSquared *safenum.Modulus
+}
+
+// NewPublicKey creates a public key from a modulus N, given in big-endian
+// bytes, which must be odd.
+func NewPublicKey(n []byte) (*PublicKey, error) {
+	if len(n) == 0 || n[len(n)-1]&1 == 0 {
+		return nil, errors.New("paillier: modulus must be odd")
+	}
+	nNat := new(safenum.Nat).SetBytes(n[:len(n):len(n)])
+	return newPublicKey(nNat), nil
+}
+
+func newPublicKey(n *safenum.Nat) *PublicKey {
+	bits := uint(len(n.Bytes()) * 8)
+	nSquared := new(safenum.Nat).Mul(n, n, 2*bits)
+	return &PublicKey{
+		n:        safenum.ModulusFromNat(*n),
+		nNat:     n,
+		nSquared: safenum.ModulusFromNat(*nSquared),
+	}
+}
+
+// N returns the modulus of the public key.
+func (pk *PublicKey) N() *safenum.Modulus {
+	return pk.n
+}
+
+// NSquared returns the square of the modulus, which ciphertexts are reduced by.
+func (pk *PublicKey) NSquared() *safenum.Modulus {
+	return pk.nSquared
+}
+
+// Equal reports whether pk and other have the same modulus.
+func (pk *PublicKey) Equal(other *PublicKey) bool {
+	return pk.n.Cmp(other.n) == 0
+}
+
+// Ciphertext is a Paillier ciphertext, an integer modulo N².
+type Ciphertext struct {
+	c *safenum.Nat
+}
+
+// Bytes returns the big-endian encoding of the ciphertext, as long as N².
+func (ct *Ciphertext) Bytes() []byte {
+	return ct.c.Bytes()
+}
+
+// Nat returns the ciphertext, as an integer modulo N².
+func (ct *Ciphertext) Nat() *safenum.Nat {
+	return new(safenum.Nat).SetNat(ct.c)
+}
+
+// NewCiphertext decodes a ciphertext, as produced by Ciphertext.Bytes,
+// checking that it's a non-zero integer modulo N².
+func (pk *PublicKey) NewCiphertext(data []byte) (*Ciphertext, error) {
+	c := new(safenum.Nat).SetBytes(data[:len(data):len(data)])
+	if c.CmpMod(pk.nSquared) != -1 || c.EqZero() {
+		return nil, errors.New("paillier: invalid ciphertext")
+	}
+	return &Ciphertext{c.Mod(c, pk.nSquared)}, nil
+}
+
+// RandomNonce returns a random integer modulo N, which is invertible with
+// overwhelming probability, for use as the nonce of an encryption.
+func (pk *PublicKey) RandomNonce(rand io.Reader) (*safenum.Nat, error) {
+	buf := make([]byte, (int(pk.n.BitLen())+7)/8+16)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), pk.n), nil
+}
+
+// EncryptWithNonce encrypts m, which is interpreted modulo N, with an explicit
+// nonce r, which must be invertible modulo N, producing (1 + N)^m * r^N.
+func (pk *PublicKey) EncryptWithNonce(m, r *safenum.Nat) *Ciphertext {
+	// (1 + N)^m = 1 + m N mod N²
+	c := new(safenum.Nat).Mod(m, pk.n)
+	c.ModMul(c, pk.nNat, pk.nSquared)
+	c.ModAdd(c, new(safenum.Nat).SetUint64(1), pk.nSquared)
+	rN := new(safenum.Nat).Exp(r, pk.nNat, pk.nSquared)
+	return &Ciphertext{c.ModMul(c, rN, pk.nSquared)}
+}
+
+// Encrypt encrypts m, which is interpreted modulo N, reading the nonce from
+// rand. This returns the nonce, which some proofs about the ciphertext need.
+func (pk *PublicKey) Encrypt(rand io.Reader, m *safenum.Nat) (*Ciphertext, *safenum.Nat, error) {
+	r, err := pk.RandomNonce(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pk.EncryptWithNonce(m, r), r, nil
+}
+
+// Add returns a ciphertext encrypting the sum of the plaintexts of a and b.
+func (pk *PublicKey) Add(a, b *Ciphertext) *Ciphertext {
+	return &Ciphertext{new(safenum.Nat).ModMul(a.c, b.c, pk.nSquared)}
+}
+
+// MulScalar returns a ciphertext encrypting the product of the plaintext of a
+// with k.
+func (pk *PublicKey) MulScalar(a *Ciphertext, k *safenum.Nat) *Ciphertext {
+	return &Ciphertext{new(safenum.Nat).Exp(a.c, k, pk.nSquared)}
+}
+
+// PrivateKey is a Paillier private key.
+type PrivateKey struct {
+	PublicKey
+	p, q *safenum.Nat
+	// φ(N) = (p - 1) (q - 1)
+	phi *safenum.Nat
+	// φ(N)^-1 mod N
+	phiInv *safenum.Nat
+	// N^-1 mod 2^bits, used for exact division by N
+	nInv *safenum.Nat
+	bits uint
+}
+
+// blumPrime returns a random prime of the given size, congruent to 3 mod 4.
+func blumPrime(random io.Reader, bits int) (*big.Int, error) {
+	for {
+		p, err := rand.Prime(random, bits)
+		if err != nil {
+			return nil, err
+		}
+		if p.Bit(1) == 1 {
+			return p, nil
+		}
+	}
+}
+
+// GenerateKey generates a private key whose modulus has the given size, in
+// bits, which should be at least 2048.
+func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
+	if bits < 16 || bits%2 != 0 {
+		return nil, errors.New("paillier: invalid modulus size")
+	}
+	for {
+		p, err := blumPrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := blumPrime(random, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+		return NewPrivateKey(p.Bytes(), q.Bytes())
+	}
+}
+
+// NewPrivateKey creates a private key from the prime factors of its modulus,
+// given in big-endian bytes.
+func NewPrivateKey(p, q []byte) (*PrivateKey, error) {
+	pNat := new(safenum.Nat).SetBytes(p[:len(p):len(p)])
+	qNat := new(safenum.Nat).SetBytes(q[:len(q):len(q)])
+	bits := uint(len(p)+len(q)) * 8
+	n := new(safenum.Nat).Mul(pNat, qNat, bits)
+	one := new(safenum.Nat).SetUint64(1)
+	pMinus1 := new(safenum.Nat).Sub(pNat, one, bits)
+	qMinus1 := new(safenum.Nat).Sub(qNat, one, bits)
+	phi := new(safenum.Nat).Mul(pMinus1, qMinus1, bits)
+
+	sk := &PrivateKey{PublicKey: *newPublicKey(n), p: pNat, q: qNat, phi: phi}
+	sk.bits = uint(len(sk.nNat.Bytes()) * 8)
+	sk.phiInv = new(safenum.Nat).ModInverse(phi, sk.n)
+	if new(safenum.Nat).ModMul(sk.phiInv, phi, sk.n).Cmp(new(safenum.Nat).Mod(one, sk.n)) != 0 {
+		return nil, errors.New("paillier: φ(N) is not invertible modulo N")
+	}
+	// The modulus is public, so this inverse can be computed with math/big.
+	nBig := new(big.Int).SetBytes(sk.nNat.Bytes())
+	nInv := new(big.Int).ModInverse(nBig, new(big.Int).Lsh(big.NewInt(1), sk.bits))
+	sk.nInv = new(safenum.Nat).SetBytes(nInv.Bytes())
+	return sk, nil
+}
+
+// Primes returns the prime factors of the modulus.
+func (sk *PrivateKey) Primes() (p, q *safenum.Nat) {
+	return new(safenum.Nat).SetNat(sk.p), new(safenum.Nat).SetNat(sk.q)
+}
+
+// Phi returns φ(N) = (p - 1) (q - 1).
+func (sk *PrivateKey) Phi() *safenum.Nat {
+	return new(safenum.Nat).SetNat(sk.phi)
+}
+
+// Decrypt returns the plaintext of a ciphertext, as an integer modulo N.
+func (sk *PrivateKey) Decrypt(ct *Ciphertext) *safenum.Nat {
+	// c^φ = (1 + N)^(m φ) = 1 + m φ N mod N²
+	x := new(safenum.Nat).Exp(ct.c, sk.phi, sk.nSquared)
+	// Since the division by N is exact, it's the same as multiplying by the
+	// inverse of N modulo a power of 2 larger than the quotient.
+	x.Sub(x, new(safenum.Nat).SetUint64(1), 2*sk.bits)
+	x.Mul(x, sk.nInv, sk.bits)
+	x.Mod(x, sk.n)
+	return x.ModMul(x, sk.phiInv, sk.n)
+}
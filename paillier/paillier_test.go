@@ -0,0 +1,107 @@
+package paillier
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *PrivateKey
+)
+
+// key returns a shared test key, since generating one is slow.
+func key(t *testing.T) *PrivateKey {
+	testKeyOnce.Do(func() {
+		var err error
+		testKey, err = GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	return testKey
+}
+
+func randomPlaintext(t *testing.T, pk *PublicKey) *safenum.Nat {
+	m, err := pk.RandomNonce(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	sk := key(t)
+	for i := 0; i < 5; i++ {
+		m := randomPlaintext(t, &sk.PublicKey)
+		ct, _, err := sk.Encrypt(rand.Reader, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sk.Decrypt(ct).Cmp(m) != 0 {
+			t.Errorf("Decrypt(Encrypt(m)) != m")
+		}
+	}
+	zero := new(safenum.Nat).Mod(new(safenum.Nat), sk.N())
+	ct, _, _ := sk.Encrypt(rand.Reader, zero)
+	if sk.Decrypt(ct).Cmp(zero) != 0 {
+		t.Errorf("Decrypt(Encrypt(0)) != 0")
+	}
+}
+
+func TestHomomorphism(t *testing.T) {
+	sk := key(t)
+	pk := &sk.PublicKey
+	a := randomPlaintext(t, pk)
+	b := randomPlaintext(t, pk)
+	k := randomPlaintext(t, pk)
+	ctA, _, _ := pk.Encrypt(rand.Reader, a)
+	ctB, _, _ := pk.Encrypt(rand.Reader, b)
+
+	sum := new(safenum.Nat).ModAdd(a, b, pk.N())
+	if sk.Decrypt(pk.Add(ctA, ctB)).Cmp(sum) != 0 {
+		t.Errorf("Add doesn't add plaintexts")
+	}
+	prod := new(safenum.Nat).ModMul(a, k, pk.N())
+	if sk.Decrypt(pk.MulScalar(ctA, k)).Cmp(prod) != 0 {
+		t.Errorf("MulScalar doesn't multiply plaintexts")
+	}
+}
+
+func TestCiphertextEncoding(t *testing.T) {
+	sk := key(t)
+	pk, err := NewPublicKey(sk.N().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pk.Equal(&sk.PublicKey) {
+		t.Errorf("public key doesn't round trip")
+	}
+	m := randomPlaintext(t, pk)
+	ct, _, _ := pk.Encrypt(rand.Reader, m)
+	decoded, err := pk.NewCiphertext(ct.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sk.Decrypt(decoded).Cmp(m) != 0 {
+		t.Errorf("ciphertext doesn't round trip")
+	}
+	if _, err := pk.NewCiphertext(pk.NSquared().Bytes()); err == nil {
+		t.Errorf("accepted an unreduced ciphertext")
+	}
+	if _, err := pk.NewCiphertext([]byte{0}); err == nil {
+		t.Errorf("accepted a zero ciphertext")
+	}
+}
+
+func TestBlumModulus(t *testing.T) {
+	sk := key(t)
+	p, q := sk.Primes()
+	pBytes, qBytes := p.Bytes(), q.Bytes()
+	if pBytes[len(pBytes)-1]&3 != 3 || qBytes[len(qBytes)-1]&3 != 3 {
+		t.Errorf("primes aren't congruent to 3 mod 4")
+	}
+}
@@ -0,0 +1,210 @@
+// Package pedersenhash implements the Pedersen hash over the Jubjub curve, as
+// specified in section 5.4.1.7 of the Zcash protocol specification, and the
+// Merkle tree hash of Zcash Sapling built on it.
+//
+// A message of bits is split into segments of 189 bits, and each segment into
+// 3-bit chunks, which are signed windows selecting one of ±1, ±2, ±3, or ±4
+// times a power of 16 of the generator of the segment. The result is a point,
+// whose u-coordinate is the hash. This is cheap to compute in circuits over
+// BLS12-381, and its outputs match those of circuit implementations, like the
+// one of Sapling.
+//
+// The generators are derived from a personalization string, with the group
+// hash into Jubjub of section 5.4.9.5 of the specification, which hashes to
+// the curve with BLAKE2s and try-and-increment, so that nobody knows their
+// discrete logarithms. The hash is collision resistant for messages of a fixed
+// length, under the discrete logarithm assumption.
+//
+// Messages are given as bytes, with a length in bits: bit i of the message is
+// bit i mod 8, counting from the least significant, of byte i / 8. Hashing is
+// done in constant-time with respect to the message, so that it can be used
+// to commit to secrets.
+package pedersenhash
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/jubjub"
+)
+
+const (
+	// chunksPerSegment is the number of 3-bit chunks hashed with each
+	// generator, so that the scalar of every segment is smaller than half the
+	// order of the curve.
+	chunksPerSegment = 63
+	// segmentBits is the number of bits of each segment.
+	segmentBits = 3 * chunksPerSegment
+)
+
+// urs is the uniform random string hashed into every generator, which is the
+// hex encoding of the hash of a Bitcoin block, as ASCII.
+var urs = []byte("096b36a5804bfacef1691e173c366a47ff5ba84a44f26ddd7e8d9f79d5b42df0")
+
+// groupHash hashes a message to a point of the prime order subgroup, or
+// returns nil if the hash isn't a valid point.
+func groupHash(personalization *[8]byte, msg []byte) *jubjub.Point {
+	h := blake2s(personalization, append(append([]byte{}, urs...), msg...))
+	p, err := new(jubjub.Point).SetBytes(h)
+	if err != nil {
+		return nil
+	}
+	p.MultByCofactor(p)
+	if p.Equal(jubjub.NewIdentityPoint()) == 1 {
+		return nil
+	}
+	return p
+}
+
+// findGroupHash returns the group hash of msg followed by the first byte for
+// which it is a valid point.
+func findGroupHash(personalization *[8]byte, msg []byte) (*jubjub.Point, error) {
+	for i := 0; i < 256; i++ {
+		if p := groupHash(personalization, append(append([]byte{}, msg...), byte(i))); p != nil {
+			return p, nil
+		}
+	}
+	return nil, errors.New("pedersenhash: no valid group hash")
+}
+
+// table holds the multiples of the generator of a segment used by each chunk:
+// entry k of row j is (k + 1) 16^j G.
+type table [chunksPerSegment][4]*jubjub.Point
+
+// Hasher computes Pedersen hashes with the generators of a personalization.
+// It is safe for concurrent use.
+type Hasher struct {
+	personalization [8]byte
+
+	mu sync.Mutex
+	// tables holds the tables of the generators derived so far, in order.
+	tables []*table
+}
+
+// New returns a Hasher for a personalization of at most 8 bytes, which is
+// padded with zeros, like "Zcash_PH" for Sapling.
+func New(personalization string) (*Hasher, error) {
+	if len(personalization) > 8 {
+		return nil, errors.New("pedersenhash: personalization longer than 8 bytes")
+	}
+	h := new(Hasher)
+	copy(h.personalization[:], personalization)
+	return h, nil
+}
+
+// tablesFor returns the tables of the first n generators, deriving the ones
+// missing.
+func (h *Hasher) tablesFor(n int) ([]*table, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := len(h.tables); i < n; i++ {
+		// The generator of segment i is the group hash of I2LEOSP_32(i).
+		g, err := findGroupHash(&h.personalization, []byte{byte(i), byte(i >> 8), byte(i >> 16), byte(i >> 24)})
+		if err != nil {
+			return nil, err
+		}
+		t := new(table)
+		base := g
+		for j := range t {
+			t[j][0] = base
+			for k := 1; k < 4; k++ {
+				t[j][k] = new(jubjub.Point).Add(t[j][k-1], base)
+			}
+			// 16 base = 4 (4 base)
+			next := new(jubjub.Point).Add(t[j][3], t[j][3])
+			base = next.Add(next, next)
+		}
+		h.tables = append(h.tables, t)
+	}
+	return h.tables[:n], nil
+}
+
+// HashToPoint returns the Pedersen hash of the first bitLen bits of msg, as a
+// point, which is PedersenHashToPoint in the specification.
+func (h *Hasher) HashToPoint(msg []byte, bitLen int) (*jubjub.Point, error) {
+	if bitLen <= 0 || bitLen > 8*len(msg) {
+		return nil, errors.New("pedersenhash: invalid message length")
+	}
+	bit := func(i int) int {
+		// The message is padded with zeros to a multiple of 3 bits.
+		if i >= bitLen {
+			return 0
+		}
+		return int(msg[i/8]>>(i%8)) & 1
+	}
+	tables, err := h.tablesFor((bitLen + segmentBits - 1) / segmentBits)
+	if err != nil {
+		return nil, err
+	}
+	acc := jubjub.NewIdentityPoint()
+	p, neg := new(jubjub.Point), new(jubjub.Point)
+	for i := 0; i < bitLen; i += 3 {
+		row := &tables[i/segmentBits][i%segmentBits/3]
+		// The chunk (s0, s1, s2) selects (1 - 2 s2) (1 + s0 + 2 s1).
+		index := bit(i) | bit(i+1)<<1
+		p.Set(row[0])
+		for k := 1; k < 4; k++ {
+			p.Select(row[k], p, ctEq(index, k))
+		}
+		neg.Negate(p)
+		p.Select(neg, p, bit(i+2))
+		acc.Add(acc, p)
+	}
+	return acc, nil
+}
+
+// ctEq returns 1 if x == y, and 0 otherwise, for small non-negative values,
+// without leaking which.
+func ctEq(x, y int) int {
+	return int((uint32(x^y) - 1) >> 31)
+}
+
+// Hash returns the Pedersen hash of the first bitLen bits of msg, which is the
+// u-coordinate of HashToPoint, as 32 little-endian bytes.
+func (h *Hasher) Hash(msg []byte, bitLen int) ([]byte, error) {
+	p, err := h.HashToPoint(msg, bitLen)
+	if err != nil {
+		return nil, err
+	}
+	return p.UBytes(), nil
+}
+
+// MerkleDepth is the depth of the note commitment tree of Zcash Sapling.
+const MerkleDepth = 32
+
+var (
+	saplingOnce   sync.Once
+	saplingHasher *Hasher
+)
+
+// MerkleHash returns the parent of two nodes of the note commitment tree of
+// Zcash Sapling, which is MerkleCRH^Sapling in section 5.4.1.3 of the
+// specification. The level of the children is counted from the leaves, which
+// are at level 0, and is lower than MerkleDepth.
+//
+// The nodes are 32 byte little-endian field elements, as returned by Hash, and
+// the root of the empty tree has the leaves 1.
+func MerkleHash(level int, left, right []byte) ([]byte, error) {
+	if level < 0 || level >= MerkleDepth {
+		return nil, errors.New("pedersenhash: invalid Merkle tree level")
+	}
+	if len(left) != 32 || len(right) != 32 || left[31]>>7 != 0 || right[31]>>7 != 0 {
+		return nil, errors.New("pedersenhash: invalid Merkle tree node")
+	}
+	saplingOnce.Do(func() {
+		saplingHasher, _ = New("Zcash_PH")
+	})
+	// The message is I2LEBSP_6(level) || left || right, where the nodes have
+	// 255 bits.
+	const bitLen = 6 + 2*255
+	msg := make([]byte, (bitLen+7)/8)
+	msg[0] = byte(level)
+	for i := 0; i < 2*255; i++ {
+		node, j := left, i
+		if i >= 255 {
+			node, j = right, i-255
+		}
+		msg[(6+i)/8] |= (node[j/8] >> (j % 8) & 1) << ((6 + i) % 8)
+	}
+	return saplingHasher.Hash(msg, bitLen)
+}
@@ -0,0 +1,128 @@
+package pedersenhash
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestBLAKE2s(t *testing.T) {
+	personalization := [8]byte{'Z', 'c', 'a', 's', 'h', '_', 'P', 'H'}
+	count := func(n int) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		return b
+	}
+	for _, tc := range []struct {
+		msg  []byte
+		want string
+	}{
+		{nil, "479cbfb374ecacb5f567b8185ddabb6d1ee703df40fc0dc9fe34d0c9f9e2b6b5"},
+		{[]byte("abc"), "08e45664fe334fb302d65e6072d9f343967a9670bf8ed31c1a44f89282a3bdbe"},
+		{count(64), "80eac167076fc215a0ae83ef83f9e04c59f2ae3d14c0c9afaa9d76a41791d902"},
+		{count(65), "678b252f546430aa10c7289a6078d5aaeab7f05b50f988ae31dc3d80a9819615"},
+		{make([]byte, 200), "2eb006896eff84702c1a8fd3dba51cef0c545a7bbd871b18e6fdeab913bdc9ff"},
+	} {
+		if got := hex.EncodeToString(blake2s(&personalization, tc.msg)); got != tc.want {
+			t.Errorf("BLAKE2s of %d bytes = %s, want %s", len(tc.msg), got, tc.want)
+		}
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	h, err := New("Zcash_PH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := h.tablesFor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "ca3c2432d4abbf7732464ec08b2e47f95edc7e836b16c979571b52d3a2879ea8"
+	if got := hex.EncodeToString(tables[0][0][0].Bytes()); got != want {
+		t.Errorf("first generator %s, want %s", got, want)
+	}
+}
+
+// The expected hashes were computed with an independent implementation of
+// the specification.
+func TestHash(t *testing.T) {
+	h, err := New("Zcash_PH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 50)
+	for i := range msg {
+		msg[i] = byte(i + 1)
+	}
+	for _, tc := range []struct {
+		bitLen int
+		want   string
+	}{
+		{1, "8ee44b684487e19d787e5b77cc51d7c5e665e57157c3357d48517f7c0f45ad5d"},
+		{7, "0c643bb585d40c205134c7c87f4e1c94a3a864bc6ba71823e09134fd1f706344"},
+		{189, "17d5ec5b36ed9542ebfd0a0ac1a9cd1dfa66a84bc73dcd3c8977caa10bbac601"},
+		{190, "5c1ea5d0b07c351844368a0fb91611bf07359adbb45614d28c0d2149d5043a05"},
+		{400, "9b205cfb341482f1ecd5561ef45a0cb319e84b1df96a0d06646880c4c92b9d68"},
+	} {
+		got, err := h.Hash(msg, tc.bitLen)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got) != tc.want {
+			t.Errorf("hash of %d bits = %x, want %s", tc.bitLen, got, tc.want)
+		}
+	}
+
+	other, err := New("Test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := other.Hash([]byte("abc"), 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "879e0c3684b9ee69b9c1df2abea7bca4e90574e1bc5b688121615c0b3b9f6f6a"; hex.EncodeToString(got) != want {
+		t.Errorf("hash with another personalization = %x, want %s", got, want)
+	}
+
+	for _, bitLen := range []int{0, -1, 401} {
+		if _, err := h.Hash(msg, bitLen); err == nil {
+			t.Errorf("message of %d bits accepted", bitLen)
+		}
+	}
+	if _, err := New("too long!"); err == nil {
+		t.Error("long personalization accepted")
+	}
+}
+
+// TestMerkleHash computes the roots of empty Sapling trees, which start from
+// the leaf 1, as computed by librustzcash.
+func TestMerkleHash(t *testing.T) {
+	node := make([]byte, 32)
+	node[0] = 1
+	for level, want := range []string{
+		"817de36ab2d57feb077634bca77819c8e0bd298c04f6fed0e6a83cc1356ca155",
+		"ffe9fc03f18b176c998806439ff0bb8ad193afdb27b2ccbc88856916dd804e34",
+		"d8283386ef2ef07ebdbb4383c12a739a953a4d6e0d6fb1139a4036d693bfbb6c",
+	} {
+		var err error
+		node, err = MerkleHash(level, node, node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(node); got != want {
+			t.Errorf("empty root of height %d = %s, want %s", level+1, got, want)
+		}
+	}
+
+	invalid := make([]byte, 32)
+	invalid[31] = 0x80
+	if _, err := MerkleHash(0, invalid, node); err == nil {
+		t.Error("node of 256 bits accepted")
+	}
+	if _, err := MerkleHash(MerkleDepth, node, node); err == nil {
+		t.Error("invalid level accepted")
+	}
+}
@@ -0,0 +1,186 @@
+// Package poly1305 implements the Poly1305 one-time authenticator, as
+// specified in RFC 8439, section 2.5.
+//
+// The arithmetic modulo 2^130 - 5 uses 64 bit limbs, with the full products
+// of math/bits, and no branches on secret data. Tags are compared in constant
+// time.
+//
+// A key must only be used to authenticate a single message: authenticating
+// two messages with the same key lets an attacker forge others.
+package poly1305
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	// KeySize is the size of a key, in bytes.
+	KeySize = 32
+	// TagSize is the size of a tag, in bytes.
+	TagSize = 16
+)
+
+const blockSize = 16
+
+// Sum writes the tag of m under key to out.
+func Sum(out *[TagSize]byte, m []byte, key *[KeySize]byte) {
+	h := New(key)
+	h.Write(m)
+	h.Sum(out[:0])
+}
+
+// Verify reports whether mac is the tag of m under key, in constant time.
+func Verify(mac *[TagSize]byte, m []byte, key *[KeySize]byte) bool {
+	var tag [TagSize]byte
+	Sum(&tag, m, key)
+	return subtle.ConstantTimeCompare(tag[:], mac[:]) == 1
+}
+
+// MAC computes a tag incrementally.
+//
+// It implements hash.Hash's Write and Sum, but a MAC must not be reused once
+// Sum or Verify has been called.
+type MAC struct {
+	// r and s are the two halves of the key, with r clamped.
+	r [2]uint64
+	s [2]uint64
+	// h is the accumulator, with a third limb of a few bits.
+	h [3]uint64
+
+	buf    [blockSize]byte
+	offset int
+	done   bool
+}
+
+// New returns a MAC computing a tag under key.
+func New(key *[KeySize]byte) *MAC {
+	m := new(MAC)
+	m.r[0] = binary.LittleEndian.Uint64(key[0:8]) & 0x0ffffffc0fffffff
+	m.r[1] = binary.LittleEndian.Uint64(key[8:16]) & 0x0ffffffc0ffffffc
+	m.s[0] = binary.LittleEndian.Uint64(key[16:24])
+	m.s[1] = binary.LittleEndian.Uint64(key[24:32])
+	return m
+}
+
+// Size returns the size of a tag, in bytes.
+func (m *MAC) Size() int {
+	return TagSize
+}
+
+// Write adds more data to the message. It never returns an error.
+func (m *MAC) Write(p []byte) (int, error) {
+	if m.done {
+		panic("poly1305: write to MAC after Sum or Verify")
+	}
+	n := len(p)
+	if m.offset > 0 {
+		k := copy(m.buf[m.offset:], p)
+		m.offset += k
+		p = p[k:]
+		if m.offset < blockSize {
+			return n, nil
+		}
+		m.block(m.buf[:], 1)
+		m.offset = 0
+	}
+	for len(p) >= blockSize {
+		m.block(p[:blockSize], 1)
+		p = p[blockSize:]
+	}
+	m.offset = copy(m.buf[:], p)
+	return n, nil
+}
+
+// Sum appends the tag of the message to b.
+func (m *MAC) Sum(b []byte) []byte {
+	var tag [TagSize]byte
+	m.finish(&tag)
+	return append(b, tag[:]...)
+}
+
+// Verify reports whether expected is the tag of the message, in constant
+// time.
+func (m *MAC) Verify(expected []byte) bool {
+	var tag [TagSize]byte
+	m.finish(&tag)
+	return subtle.ConstantTimeCompare(tag[:], expected) == 1
+}
+
+// block adds a 16 byte block to h, with the bit above the block set to hibit,
+// and multiplies h by r, modulo 2^130 - 5.
+func (m *MAC) block(b []byte, hibit uint64) {
+	h0, h1, h2 := m.h[0], m.h[1], m.h[2]
+	r0, r1 := m.r[0], m.r[1]
+
+	var c uint64
+	h0, c = bits.Add64(h0, binary.LittleEndian.Uint64(b[0:8]), 0)
+	h1, c = bits.Add64(h1, binary.LittleEndian.Uint64(b[8:16]), c)
+	h2 += c + hibit
+
+	// h2 is at most 7, and the top 4 bits of r0 and r1 are clamped to zero,
+	// so none of the sums of products below overflow.
+	h0r0hi, h0r0lo := bits.Mul64(h0, r0)
+	h1r0hi, h1r0lo := bits.Mul64(h1, r0)
+	h0r1hi, h0r1lo := bits.Mul64(h0, r1)
+	h1r1hi, h1r1lo := bits.Mul64(h1, r1)
+	h2r0 := h2 * r0
+	h2r1 := h2 * r1
+
+	m1lo, c := bits.Add64(h1r0lo, h0r1lo, 0)
+	m1hi, _ := bits.Add64(h1r0hi, h0r1hi, c)
+	m2lo, c := bits.Add64(h2r0, h1r1lo, 0)
+	m2hi, _ := bits.Add64(0, h1r1hi, c)
+
+	t0 := h0r0lo
+	t1, c := bits.Add64(m1lo, h0r0hi, 0)
+	t2, c := bits.Add64(m2lo, m1hi, c)
+	t3, _ := bits.Add64(h2r1, m2hi, c)
+
+	// The product is t = l + 2^130 * c, and 2^130 = 5 modulo p, so we add
+	// 4 * c and c to the low 130 bits.
+	h0, h1, h2 = t0, t1, t2&3
+	c0, c1 := t2&^3, t3
+	h0, c = bits.Add64(h0, c0, 0)
+	h1, c = bits.Add64(h1, c1, c)
+	h2 += c
+	c0, c1 = c0>>2|c1<<62, c1>>2
+	h0, c = bits.Add64(h0, c0, 0)
+	h1, c = bits.Add64(h1, c1, c)
+	h2 += c
+
+	m.h[0], m.h[1], m.h[2] = h0, h1, h2
+}
+
+// finish processes the last partial block, and writes the tag to out.
+func (m *MAC) finish(out *[TagSize]byte) {
+	if m.done {
+		panic("poly1305: MAC used after Sum or Verify")
+	}
+	m.done = true
+	if m.offset > 0 {
+		// A partial block is padded with a single 1 byte.
+		m.buf[m.offset] = 1
+		for i := m.offset + 1; i < blockSize; i++ {
+			m.buf[i] = 0
+		}
+		m.block(m.buf[:], 0)
+	}
+
+	// Reduce h fully, by subtracting p = 2^130 - 5 unless that borrows.
+	h0, h1, h2 := m.h[0], m.h[1], m.h[2]
+	t0, b := bits.Sub64(h0, 0xfffffffffffffffb, 0)
+	t1, b := bits.Sub64(h1, 0xffffffffffffffff, b)
+	_, b = bits.Sub64(h2, 3, b)
+	mask := b - 1
+	h0 = h0&^mask | t0&mask
+	h1 = h1&^mask | t1&mask
+
+	// The tag is h + s, modulo 2^128.
+	var c uint64
+	h0, c = bits.Add64(h0, m.s[0], 0)
+	h1, _ = bits.Add64(h1, m.s[1], c)
+	binary.LittleEndian.PutUint64(out[0:8], h0)
+	binary.LittleEndian.PutUint64(out[8:16], h1)
+}
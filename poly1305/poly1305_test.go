@@ -0,0 +1,75 @@
+package poly1305
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestSum(t *testing.T) {
+	// See RFC 8439, section 2.5.2.
+	var key [KeySize]byte
+	copy(key[:], mustHex("85d6be7857556d337f4452fe42d506a80103808afb0db2fd4abff6af4149f51b"))
+	msg := []byte("Cryptographic Forum Research Group")
+	expected := mustHex("a8061dc1305136c6c22b8baf0c0127a9")
+	var tag [TagSize]byte
+	Sum(&tag, msg, &key)
+	if !bytes.Equal(tag[:], expected) {
+		t.Errorf("Sum = %x, expected %x", tag, expected)
+	}
+	if !Verify(&tag, msg, &key) {
+		t.Error("Verify rejected a valid tag")
+	}
+	tag[0] ^= 1
+	if Verify(&tag, msg, &key) {
+		t.Error("Verify accepted an invalid tag")
+	}
+}
+
+func TestLargeAccumulator(t *testing.T) {
+	// The product (2^129 - 1) * 2 = 2^130 - 2 is only reduced to 3 by the
+	// final subtraction of p. See RFC 8439, appendix A.3.
+	var key [KeySize]byte
+	copy(key[:], mustHex("0200000000000000000000000000000000000000000000000000000000000000"))
+	msg := mustHex("ffffffffffffffffffffffffffffffff")
+	expected := mustHex("03000000000000000000000000000000")
+	var tag [TagSize]byte
+	Sum(&tag, msg, &key)
+	if !bytes.Equal(tag[:], expected) {
+		t.Errorf("Sum = %x, expected %x", tag, expected)
+	}
+}
+
+func TestWriteInChunks(t *testing.T) {
+	var key [KeySize]byte
+	for i := range key {
+		key[i] = byte(i)
+	}
+	msg := make([]byte, 100)
+	for i := range msg {
+		msg[i] = byte(3 * i)
+	}
+	var expected [TagSize]byte
+	Sum(&expected, msg, &key)
+	for _, chunk := range []int{1, 3, 15, 16, 17} {
+		m := New(&key)
+		for i := 0; i < len(msg); i += chunk {
+			end := i + chunk
+			if end > len(msg) {
+				end = len(msg)
+			}
+			m.Write(msg[i:end])
+		}
+		if !m.Verify(expected[:]) {
+			t.Errorf("chunks of %d: the tag differs", chunk)
+		}
+	}
+}
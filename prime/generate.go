@@ -0,0 +1,318 @@
+package prime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements the generation of primes with a special structure.
+//
+// Candidates are searched incrementally, from a random starting point, after
+// a sieve with small primes, as done by math/big, which makes the search fast,
+// but means that the number of candidates tested reveals a little information
+// about the residues of the result modulo small primes. The candidates are
+// then tested in constant-time.
+
+// minGenerateBits is the size of the smallest primes which can be generated,
+// so that they are bigger than the primes of the sieve.
+const minGenerateBits = 64
+
+// numRounds is the number of Miller-Rabin tests with random bases done on top
+// of the Baillie-PSW test of the primes generated.
+const numRounds = 4
+
+// maxSteps bounds the search from each random starting point.
+const maxSteps = 1 << 18
+
+// smallPrimes are the odd primes below 2^16, used to sieve candidates.
+var smallPrimes = func() []uint64 {
+	const limit = 1 << 16
+	composite := make([]bool, limit)
+	var primes []uint64
+	for i := 3; i < limit; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, uint64(i))
+		// i * i overflows an int on 32-bit platforms, past the square root.
+		if i > limit/i {
+			continue
+		}
+		for j := i * i; j < limit; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// progression enumerates the candidates x + k * step, for k >= 0, without a
+// small factor, and, if safe is set, such that 2c + 1 has no small factor.
+type progression struct {
+	x, step *big.Int
+	safe    bool
+	// xr and sr are the residues of x and step modulo the small primes.
+	xr, sr []uint64
+	k      uint64
+}
+
+func newProgression(x, step *big.Int, safe bool) *progression {
+	p := &progression{
+		x:    x,
+		step: step,
+		safe: safe,
+		xr:   make([]uint64, len(smallPrimes)),
+		sr:   make([]uint64, len(smallPrimes)),
+	}
+	r, m := new(big.Int), new(big.Int)
+	for i, prime := range smallPrimes {
+		m.SetUint64(prime)
+		p.xr[i] = r.Mod(x, m).Uint64()
+		p.sr[i] = r.Mod(step, m).Uint64()
+	}
+	return p
+}
+
+// next returns the next candidate, or nil after maxSteps steps.
+func (p *progression) next() *big.Int {
+search:
+	for ; p.k < maxSteps; p.k++ {
+		for i, prime := range smallPrimes {
+			m := (p.xr[i] + p.k*p.sr[i]) % prime
+			if m == 0 || p.safe && (2*m+1)%prime == 0 {
+				continue search
+			}
+		}
+		c := new(big.Int).SetUint64(p.k)
+		c.Mul(c, p.step).Add(c, p.x)
+		p.k++
+		return c
+	}
+	return nil
+}
+
+// generator holds the state shared by the searches of a generation.
+type generator struct {
+	ctx      context.Context
+	rand     io.Reader
+	progress func(candidates int)
+	tested   int
+}
+
+func newGenerator(ctx context.Context, rand io.Reader, progress func(int)) *generator {
+	return &generator{ctx: ctx, rand: randutil.Or(rand), progress: progress}
+}
+
+// tick is called before testing each candidate, and returns an error if the
+// generation was cancelled.
+func (g *generator) tick() error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+	g.tested++
+	if g.progress != nil {
+		g.progress(g.tested)
+	}
+	return nil
+}
+
+// random returns a random number of the given size, with its top bit set, and
+// with its low bits set to low.
+func (g *generator) random(bits int, low uint) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	if _, err := io.ReadFull(g.rand, buf); err != nil {
+		return nil, err
+	}
+	buf[0] &= byte(0xff >> (8*len(buf) - bits))
+	x := new(big.Int).SetBytes(buf)
+	x.SetBit(x, bits-1, 1)
+	for i := 0; low>>i != 0; i++ {
+		x.SetBit(x, i, low>>i&1)
+	}
+	return x, nil
+}
+
+// toNat converts a secret value found by a search to a Nat.
+func toNat(x *big.Int) *safenum.Nat {
+	b := x.Bytes()
+	return new(safenum.Nat).SetBytes(b[:len(b):len(b)])
+}
+
+// fermat reports whether n is a Fermat probable prime to base 2, which quickly
+// rejects most composites, in constant-time.
+func fermat(n *safenum.Nat) bool {
+	nBytes := n.Bytes()
+	nMinusOne := append([]byte{}, nBytes...)
+	nMinusOne[len(nMinusOne)-1] &^= 1
+	m := safenum.ModulusFromBytes(nBytes)
+	x := new(safenum.Nat).Exp(new(safenum.Nat).SetUint64(2), new(safenum.Nat).SetBytes(nMinusOne[:len(nMinusOne):len(nMinusOne)]), m)
+	return x.Cmp(new(safenum.Nat).SetUint64(1)) == 0
+}
+
+// isPrime tests a candidate which passed the Fermat test.
+func (g *generator) isPrime(n *safenum.Nat) (bool, error) {
+	return ProbablyPrime(g.rand, n, numRounds)
+}
+
+// prime returns a random prime of the given size.
+func (g *generator) prime(bits int) (*safenum.Nat, error) {
+	for {
+		x, err := g.random(bits, 1)
+		if err != nil {
+			return nil, err
+		}
+		prog := newProgression(x, big.NewInt(2), false)
+		for c := prog.next(); c != nil && c.BitLen() == bits; c = prog.next() {
+			if err := g.tick(); err != nil {
+				return nil, err
+			}
+			n := toNat(c)
+			if !fermat(n) {
+				continue
+			}
+			if ok, err := g.isPrime(n); err != nil || ok {
+				return n, err
+			}
+		}
+	}
+}
+
+// GenerateSafe returns a random safe prime p of the given size, such that
+// q = (p - 1) / 2 is also prime. Safe primes are used by finite field
+// Diffie-Hellman and SRP groups, and by some uses of Paillier encryption.
+//
+// The prime is also 7 mod 8, so that 2 is a quadratic residue, and generates
+// the subgroup of order q, as for the standard Diffie-Hellman groups.
+//
+// Randomness is read from rand, or from the health-tested Reader of the rand
+// package of this module if rand is nil. If progress isn't nil, it is called
+// with the number of candidates tested so far, before each test. The search
+// stops with the error of ctx if it is cancelled.
+//
+// This can take many seconds, or minutes for the largest sizes.
+func GenerateSafe(ctx context.Context, rand io.Reader, bits int, progress func(candidates int)) (*safenum.Nat, error) {
+	if bits < minGenerateBits {
+		return nil, errors.New("prime: size too small")
+	}
+	g := newGenerator(ctx, rand, progress)
+	for {
+		// q = 3 mod 4, so that p = 7 mod 8.
+		x, err := g.random(bits-1, 3)
+		if err != nil {
+			return nil, err
+		}
+		prog := newProgression(x, big.NewInt(4), true)
+		for c := prog.next(); c != nil && c.BitLen() == bits-1; c = prog.next() {
+			if err := g.tick(); err != nil {
+				return nil, err
+			}
+			p := new(big.Int).Lsh(c, 1)
+			p.SetBit(p, 0, 1)
+			q, pn := toNat(c), toNat(p)
+			if !fermat(q) || !fermat(pn) {
+				continue
+			}
+			if ok, err := g.isPrime(q); err != nil || !ok {
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if ok, err := g.isPrime(pn); err != nil || ok {
+				return pn, err
+			}
+		}
+	}
+}
+
+// auxiliaryBits returns the size of the auxiliary primes of a strong prime of
+// the given size: one more than the minimums of table B.1 of FIPS 186-4, and
+// of table A.1 of FIPS 186-5 for 4096-bit moduli.
+func auxiliaryBits(bits int) int {
+	switch {
+	case bits < 1024:
+		return 101
+	case bits < 1536:
+		return 141
+	case bits < 2048:
+		return 171
+	default:
+		return 201
+	}
+}
+
+// GenerateStrong returns a random strong prime p of the given size, for an
+// RSA modulus with public exponent e, as in FIPS 186-4, appendices B.3.6 and
+// C.9: p - 1 and p + 1 have large prime factors, and p - 1 is coprime to e.
+// The exponent must be odd and larger than 1.
+//
+// The two most significant bits of p are set, as for the primes of the rsa
+// package, so that the product of two such primes has twice their size.
+//
+// Randomness, progress, and cancellation are handled as in GenerateSafe.
+func GenerateStrong(ctx context.Context, rand io.Reader, bits, e int, progress func(candidates int)) (*safenum.Nat, error) {
+	if bits < 4*auxiliaryBits(bits) {
+		return nil, errors.New("prime: size too small")
+	}
+	if e < 3 || e%2 == 0 {
+		return nil, errors.New("prime: invalid public exponent")
+	}
+	g := newGenerator(ctx, rand, progress)
+	auxBits := auxiliaryBits(bits)
+	p1, err := g.prime(auxBits)
+	if err != nil {
+		return nil, err
+	}
+	r1 := new(big.Int).SetBytes(p1.Bytes())
+	r2 := new(big.Int)
+	// The auxiliary primes must be distinct.
+	for r2.Sign() == 0 || r2.Cmp(r1) == 0 {
+		p2, err := g.prime(auxBits)
+		if err != nil {
+			return nil, err
+		}
+		r2.SetBytes(p2.Bytes())
+	}
+	// R = 1 mod 2 p1, and R = -1 mod p2, so that p - 1 is a multiple of
+	// 2 p1, and p + 1 a multiple of p2.
+	twoR1 := new(big.Int).Lsh(r1, 1)
+	step := new(big.Int).Mul(twoR1, r2)
+	R := new(big.Int).ModInverse(r2, twoR1)
+	R.Mul(R, r2)
+	t := new(big.Int).ModInverse(twoR1, r2)
+	t.Mul(t, twoR1)
+	R.Sub(R, t).Mod(R, step)
+
+	E := big.NewInt(int64(e))
+	one := big.NewInt(1)
+	for {
+		// The top two bits of X are set, and Y = X + ((R - X) mod 2 p1 p2).
+		X, err := g.random(bits, 0)
+		if err != nil {
+			return nil, err
+		}
+		X.SetBit(X, bits-2, 1)
+		Y := new(big.Int).Sub(R, X)
+		Y.Mod(Y, step).Add(Y, X)
+		prog := newProgression(Y, step, false)
+		for c := prog.next(); c != nil && c.BitLen() == bits; c = prog.next() {
+			if new(big.Int).GCD(nil, nil, new(big.Int).Sub(c, one), E).Cmp(one) != 0 {
+				continue
+			}
+			if err := g.tick(); err != nil {
+				return nil, err
+			}
+			n := toNat(c)
+			if !fermat(n) {
+				continue
+			}
+			if ok, err := g.isPrime(n); err != nil || ok {
+				return n, err
+			}
+		}
+	}
+}
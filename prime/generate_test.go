@@ -0,0 +1,110 @@
+package prime
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// hasFactorAtLeast reports whether n has a prime factor of at least bits bits,
+// by removing its small factors.
+func hasFactorAtLeast(n *big.Int, bits int) bool {
+	n = new(big.Int).Set(n)
+	for _, p := range smallPrimes {
+		m := new(big.Int).SetUint64(p)
+		for new(big.Int).Mod(n, m).Sign() == 0 {
+			n.Div(n, m)
+		}
+	}
+	for n.Bit(0) == 0 {
+		n.Rsh(n, 1)
+	}
+	// What remains is prime, or has a prime factor of at least bits bits.
+	return n.BitLen() >= bits
+}
+
+func TestSmallPrimes(t *testing.T) {
+	// There are 6542 primes below 2^16, the largest being 65521.
+	if len(smallPrimes) != 6541 || smallPrimes[0] != 3 || smallPrimes[len(smallPrimes)-1] != 65521 {
+		t.Fatalf("%d small primes, from %d to %d", len(smallPrimes), smallPrimes[0], smallPrimes[len(smallPrimes)-1])
+	}
+	for _, p := range smallPrimes {
+		if !new(big.Int).SetUint64(p).ProbablyPrime(0) {
+			t.Errorf("%d isn't prime", p)
+		}
+	}
+}
+
+func TestGenerateSafe(t *testing.T) {
+	for _, bits := range []int{64, 256, 512} {
+		calls := 0
+		pn, err := GenerateSafe(context.Background(), rand.Reader, bits, func(candidates int) {
+			calls++
+			if candidates != calls {
+				t.Errorf("progress called with %d, after %d calls", candidates, calls)
+			}
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := new(big.Int).SetBytes(pn.Bytes())
+		q := new(big.Int).Rsh(p, 1)
+		if p.BitLen() != bits || !p.ProbablyPrime(20) || !q.ProbablyPrime(20) {
+			t.Errorf("%d: not a safe prime", bits)
+		}
+		if p.Uint64()%8 != 7 {
+			t.Errorf("%d: p isn't 7 mod 8", bits)
+		}
+		if calls == 0 {
+			t.Errorf("%d: progress not called", bits)
+		}
+	}
+	if _, err := GenerateSafe(context.Background(), nil, 32, nil); err == nil {
+		t.Error("small prime generated")
+	}
+}
+
+func TestGenerateStrong(t *testing.T) {
+	for _, e := range []int{3, 65537} {
+		pn, err := GenerateStrong(context.Background(), nil, 512, e, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := new(big.Int).SetBytes(pn.Bytes())
+		if p.BitLen() != 512 || p.Bit(510) != 1 || !p.ProbablyPrime(20) {
+			t.Errorf("e = %d: wrong prime", e)
+		}
+		pMinusOne := new(big.Int).Sub(p, big.NewInt(1))
+		pPlusOne := new(big.Int).Add(p, big.NewInt(1))
+		if !hasFactorAtLeast(pMinusOne, 101) || !hasFactorAtLeast(pPlusOne, 101) {
+			t.Errorf("e = %d: p - 1 or p + 1 has no large prime factor", e)
+		}
+		if new(big.Int).GCD(nil, nil, pMinusOne, big.NewInt(int64(e))).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("e = %d: p - 1 isn't coprime to e", e)
+		}
+	}
+	for _, e := range []int{1, 4} {
+		if _, err := GenerateStrong(context.Background(), nil, 512, e, nil); err == nil {
+			t.Errorf("exponent %d accepted", e)
+		}
+	}
+	if _, err := GenerateStrong(context.Background(), nil, 256, 3, nil); err == nil {
+		t.Error("small prime generated")
+	}
+}
+
+func TestGenerateCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := GenerateSafe(ctx, nil, 2048, func(candidates int) {
+		if candidates == 10 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if _, err := GenerateStrong(ctx, nil, 1024, 65537, nil); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,306 @@
+// Package prime tests the primality of secret numbers, like the factors of RSA
+// and Paillier moduli, in constant-time.
+//
+// ProbablyPrime runs the same tests as the ProbablyPrime method of math/big:
+// Miller-Rabin tests, and a strong Lucas test, which together form the
+// Baillie-PSW test. Unlike math/big, every test processes all the bits of the
+// number, selecting intermediate values with constant-time operations, so that
+// the running time only depends on the bit length of the number, and, for
+// composite numbers, on which test rejected them.
+package prime
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/safenum"
+)
+
+// minBits is the bit length under which numbers are tested with math/big.
+// The Lucas test requires numbers larger than the candidates for D, and such
+// small numbers can't hold much of a secret anyway.
+const minBits = 16
+
+// ProbablyPrime reports whether n is probably prime, applying the Miller-Rabin
+// test with rounds bases chosen at random, as well as a Baillie-PSW test.
+// Randomness is read from rand, or from the health-tested Reader of the rand
+// package of this module if rand is nil.
+//
+// As with math/big, ProbablyPrime is 100% accurate for inputs less than 2^64,
+// and the probability of wrongly accepting a composite chosen at random is at
+// most 4^-rounds. Even numbers, and numbers under 2^16, are rejected or tested
+// in variable-time.
+func ProbablyPrime(rand io.Reader, n *safenum.Nat, rounds int) (bool, error) {
+	if rounds < 0 {
+		return false, errors.New("prime: negative number of rounds")
+	}
+	nBytes := n.Bytes()
+	nBytes = nBytes[:len(nBytes):len(nBytes)]
+	if len(nBytes) == 0 || nBytes[len(nBytes)-1]&1 == 0 {
+		return new(big.Int).SetBytes(nBytes).Cmp(big.NewInt(2)) == 0, nil
+	}
+	nMod := safenum.ModulusFromBytes(nBytes)
+	bitLen := int(nMod.BitLen())
+	if bitLen < minBits {
+		return new(big.Int).SetBytes(nBytes).ProbablyPrime(rounds), nil
+	}
+	c := newContext(nMod, bitLen)
+	if c.millerRabin(c.smallNat(2)) != 1 || c.lucas() != 1 {
+		return false, nil
+	}
+	rand = randutil.Or(rand)
+	buf := make([]byte, c.byteLen+16)
+	for i := 0; i < rounds; i++ {
+		// The bias of the reduction is negligible, and so is the probability
+		// of the trivial bases 0, 1, and n - 1.
+		if _, err := io.ReadFull(rand, buf); err != nil {
+			return false, err
+		}
+		a := new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf[:len(buf):len(buf)]), c.n)
+		if c.millerRabin(a) != 1 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// tester holds the values derived from the number tested.
+type tester struct {
+	n       *safenum.Modulus
+	bitLen  int
+	byteLen int
+	// nMinusOne and nPlusOne are big-endian, nPlusOne having an extra byte.
+	nMinusOne []byte
+	nPlusOne  []byte
+	// nat is n, and minusOne is n - 1.
+	nat      *safenum.Nat
+	minusOne *safenum.Nat
+	// nMod4 is n mod 4.
+	nMod4 int
+}
+
+func newContext(n *safenum.Modulus, bitLen int) *tester {
+	byteLen := (bitLen + 7) / 8
+	nBytes := n.Bytes()
+	nBytes = nBytes[len(nBytes)-byteLen:]
+	nMinusOne := append([]byte{}, nBytes...)
+	nMinusOne[byteLen-1] &^= 1
+	nPlusOne := make([]byte, byteLen+1)
+	carry := 1
+	for i := byteLen - 1; i >= 0; i-- {
+		sum := int(nBytes[i]) + carry
+		nPlusOne[i+1] = byte(sum)
+		carry = sum >> 8
+	}
+	nPlusOne[0] = byte(carry)
+	return &tester{
+		n:         n,
+		bitLen:    bitLen,
+		byteLen:   byteLen,
+		nMinusOne: nMinusOne,
+		nPlusOne:  nPlusOne,
+		// SetBytes can modify its argument, if it has spare capacity.
+		nat:      new(safenum.Nat).SetBytes(nBytes[:byteLen:byteLen]),
+		minusOne: new(safenum.Nat).SetBytes(nMinusOne[:byteLen:byteLen]),
+		nMod4:    int(nBytes[byteLen-1] & 3),
+	}
+}
+
+// bytes returns the encoding of x, padded to the length of n.
+func (c *tester) bytes(x *safenum.Nat) []byte {
+	b := x.Bytes()
+	if len(b) < c.byteLen {
+		return append(make([]byte, c.byteLen-len(b)), b...)
+	}
+	return b[len(b)-c.byteLen:]
+}
+
+// choose returns x if v == 1, and y if v == 0.
+func (c *tester) choose(v int, x, y *safenum.Nat) *safenum.Nat {
+	out := c.bytes(y)
+	subtle.ConstantTimeCopy(v, out, c.bytes(x))
+	return new(safenum.Nat).SetBytes(out[:len(out):len(out)])
+}
+
+// smallNat returns v mod n, for a small, possibly negative, v.
+func (c *tester) smallNat(v int) *safenum.Nat {
+	neg := int(uint32(v) >> 31)
+	abs := new(safenum.Nat).SetUint64(uint64(subtle.ConstantTimeSelect(neg, -v, v)))
+	abs.Mod(abs, c.n)
+	return c.choose(neg, new(safenum.Nat).ModSub(new(safenum.Nat), abs, c.n), abs)
+}
+
+// eq returns 1 if x == y, and 0 otherwise.
+func eq(x, y *safenum.Nat) int {
+	return subtle.ConstantTimeEq(int32(x.Cmp(y)), 0)
+}
+
+// bit returns the bit i of the big-endian x.
+func bit(x []byte, i int) int {
+	return int(x[len(x)-1-i/8]>>(i%8)) & 1
+}
+
+// trailingZeros returns the number of trailing zero bits of the non-zero,
+// big-endian x.
+func trailingZeros(x []byte) int {
+	n, seen := 0, 0
+	for i := 0; i < 8*len(x); i++ {
+		seen |= bit(x, i)
+		n += 1 ^ seen
+	}
+	return n
+}
+
+// checkPoint returns whether index i of a ladder is one of the points at
+// which the strong tests inspect their values, where n ± 1 = d * 2^s: i == s,
+// for the value at d, and 0 < i < s, for the values at d * 2^r, 0 < r < s.
+func checkPoint(i, s int) (atD, belowD int) {
+	atD = subtle.ConstantTimeEq(int32(i), int32(s))
+	if i > 0 {
+		belowD = subtle.ConstantTimeLessOrEq(i+1, s)
+	}
+	return atD, belowD
+}
+
+// millerRabin returns 1 if n is a strong probable prime to base a, and 0
+// otherwise.
+//
+// With n - 1 = d * 2^s, this is the case if a^d = 1, or a^(d * 2^r) = -1 for
+// some 0 <= r < s. All the powers are computed by a single left-to-right
+// exponentiation by n - 1, whose intermediate values are the powers by the
+// prefixes of n - 1, d * 2^r being the prefix of length bitLen - (s - r).
+func (c *tester) millerRabin(a *safenum.Nat) int {
+	s := trailingZeros(c.nMinusOne)
+	one := c.smallNat(1)
+	x := c.smallNat(1)
+	pass := 0
+	for i := c.bitLen - 1; i >= 0; i-- {
+		x.ModMul(x, x, c.n)
+		x.ModMul(x, c.choose(bit(c.nMinusOne, i), a, one), c.n)
+		// x = a^((n - 1) >> i).
+		atD, belowD := checkPoint(i, s)
+		isMinusOne := eq(x, c.minusOne)
+		pass |= atD&(eq(x, one)|isMinusOne) | belowD&isMinusOne
+	}
+	return pass
+}
+
+// numCandidates is the number of values of D tried by the Lucas test. A prime
+// is rejected if none of them is a quadratic non-residue, which only happens
+// for a negligible fraction of primes.
+const numCandidates = 256
+
+var (
+	jacobiOnce sync.Once
+	// jacobiTables[k][r] is the Jacobi symbol (r/m), with m = 5 + 2k.
+	jacobiTables [numCandidates][]int8
+)
+
+func initJacobiTables() {
+	for k := range jacobiTables {
+		m := big.NewInt(int64(5 + 2*k))
+		table := make([]int8, 5+2*k)
+		for r := range table {
+			table[r] = int8(big.Jacobi(big.NewInt(int64(r)), m))
+		}
+		jacobiTables[k] = table
+	}
+}
+
+// candidate returns the candidate k for D, in the sequence of Selfridge's
+// method A: 5, -7, 9, -11, 13, and so on.
+func candidate(k int) int {
+	if k%2 == 1 {
+		return -(5 + 2*k)
+	}
+	return 5 + 2*k
+}
+
+// jacobi returns the Jacobi symbol (D/n) of the candidate k for D.
+func (c *tester) jacobi(k int) int {
+	m := 5 + 2*k
+	r := int(new(safenum.Nat).Mod(c.nat, safenum.ModulusFromUint64(uint64(m))).Uint64())
+	j := 0
+	for i, v := range jacobiTables[k] {
+		j = subtle.ConstantTimeSelect(subtle.ConstantTimeEq(int32(i), int32(r)), int(v), j)
+	}
+	// By quadratic reciprocity, (m/n) = (n/m) = (r/m), unless both m and n
+	// are 3 mod 4, and (-1/n) = 1 if and only if n is 1 mod 4.
+	flip := 0
+	if m%4 == 3 {
+		flip ^= subtle.ConstantTimeEq(int32(c.nMod4), 3)
+	}
+	if candidate(k) < 0 {
+		flip ^= subtle.ConstantTimeEq(int32(c.nMod4), 3)
+	}
+	return subtle.ConstantTimeSelect(flip, -j, j)
+}
+
+// lucas returns 1 if n is a strong Lucas probable prime with the parameters
+// of Selfridge's method A, and 0 otherwise.
+//
+// With n + 1 = d * 2^s, this is the case if U_d = 0, or V_(d * 2^r) = 0 for
+// some 0 <= r < s. As in millerRabin, the sequences are computed by a single
+// ladder over the bits of n + 1.
+func (c *tester) lucas() int {
+	jacobiOnce.Do(initJacobiTables)
+	// D is the first candidate with (D/n) = -1. If any (D/n) = 0, then n
+	// shares a factor with D, which is smaller than n, so all the candidates
+	// are checked, even after D is found.
+	index, found, shared := 0, 0, 0
+	for k := 0; k < numCandidates; k++ {
+		j := c.jacobi(k)
+		isNonResidue := subtle.ConstantTimeEq(int32(j), -1)
+		index = subtle.ConstantTimeSelect(isNonResidue&^found, k, index)
+		found |= isNonResidue
+		shared |= subtle.ConstantTimeEq(int32(j), 0)
+	}
+	if found&^shared != 1 {
+		return 0
+	}
+	// The candidates are all 1 mod 4, and P = 1.
+	d := subtle.ConstantTimeSelect(index&1, -(5 + 2*index), 5+2*index)
+	D, Q := c.smallNat(d), c.smallNat((1-d)/4)
+
+	// half is the inverse of 2, (n + 1) / 2.
+	half := make([]byte, len(c.nPlusOne))
+	for i := range half {
+		half[i] = c.nPlusOne[i] >> 1
+		if i > 0 {
+			half[i] |= c.nPlusOne[i-1] << 7
+		}
+	}
+	inv2 := new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(half[:len(half):len(half)]), c.n)
+
+	s := trailingZeros(c.nPlusOne)
+	zero := c.smallNat(0)
+	U, V, Qk := c.smallNat(0), c.smallNat(2), c.smallNat(1)
+	pass := 0
+	for i := 8*len(c.nPlusOne) - 1; i >= 0; i-- {
+		// U_2k = U_k V_k, V_2k = V_k^2 - 2 Q^k.
+		U.ModMul(U, V, c.n)
+		V.ModMul(V, V, c.n)
+		V.ModSub(V, Qk, c.n)
+		V.ModSub(V, Qk, c.n)
+		Qk.ModMul(Qk, Qk, c.n)
+		// U_k+1 = (U_k + V_k) / 2, V_k+1 = (D U_k + V_k) / 2.
+		U1 := new(safenum.Nat).ModAdd(U, V, c.n)
+		U1.ModMul(U1, inv2, c.n)
+		V1 := new(safenum.Nat).ModMul(D, U, c.n)
+		V1.ModAdd(V1, V, c.n)
+		V1.ModMul(V1, inv2, c.n)
+		b := bit(c.nPlusOne, i)
+		U = c.choose(b, U1, U)
+		V = c.choose(b, V1, V)
+		Qk = c.choose(b, new(safenum.Nat).ModMul(Qk, Q, c.n), Qk)
+		// The index is now (n + 1) >> i.
+		atD, belowD := checkPoint(i, s)
+		isZero := eq(V, zero)
+		pass |= atD&(eq(U, zero)|isZero) | belowD&isZero
+	}
+	return pass
+}
@@ -0,0 +1,121 @@
+package prime
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+func newTestContext(n *big.Int) *tester {
+	nMod := safenum.ModulusFromBytes(n.Bytes())
+	return newContext(nMod, int(nMod.BitLen()))
+}
+
+// isPrime is a slow, exact primality test.
+func isPrime(n int) bool {
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return n >= 2
+}
+
+func TestStrongTests(t *testing.T) {
+	// The strong pseudoprimes to base 2 under 20000, from OEIS A001262.
+	pseudoprimes := map[int]bool{
+		2047: true, 3277: true, 4033: true, 4681: true, 8321: true, 15841: true,
+	}
+	for n := 1025; n < 20000; n += 2 {
+		c := newTestContext(big.NewInt(int64(n)))
+		want := isPrime(n)
+		if got := c.millerRabin(c.smallNat(2)) == 1; got != (want || pseudoprimes[n]) {
+			t.Errorf("millerRabin(%d) = %v", n, got)
+		}
+		// The strong Lucas pseudoprimes in this range all have a factor among
+		// the candidates for D, which lucas detects.
+		if got := c.lucas() == 1; got != want {
+			t.Errorf("lucas(%d) = %v", n, got)
+		}
+	}
+	// The first strong Lucas pseudoprimes without such a factor.
+	for _, n := range []int64{324899, 510479, 622169} {
+		if c := newTestContext(big.NewInt(n)); c.lucas() != 1 {
+			t.Errorf("lucas(%d) = false", n)
+		}
+	}
+}
+
+func TestJacobi(t *testing.T) {
+	jacobiOnce.Do(initJacobiTables)
+	for _, n := range []int64{1031, 1033, 4095, 65537, 1<<31 - 1} {
+		c := newTestContext(big.NewInt(n))
+		for k := 0; k < numCandidates; k++ {
+			want := big.Jacobi(big.NewInt(int64(candidate(k))), big.NewInt(n))
+			if got := c.jacobi(k); got != want {
+				t.Errorf("(%d/%d) = %d, want %d", candidate(k), n, got, want)
+			}
+		}
+	}
+}
+
+func TestProbablyPrime(t *testing.T) {
+	check := func(n *big.Int) {
+		t.Helper()
+		got, err := ProbablyPrime(rand.Reader, new(safenum.Nat).SetBytes(n.Bytes()), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := n.ProbablyPrime(20); got != want {
+			t.Errorf("ProbablyPrime(%v) = %v", n, got)
+		}
+	}
+	for n := int64(0); n < 70000; n += 997 {
+		check(big.NewInt(n))
+	}
+	for _, bits := range []int{64, 127, 256, 1024} {
+		p, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(p)
+		check(new(big.Int).Add(p, big.NewInt(2)))
+		q, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(new(big.Int).Mul(p, q))
+		check(new(big.Int).Mul(p, p))
+	}
+	// A strong pseudoprime to base 2, which is a Carmichael number, from
+	// "Prime numbers: a computational perspective", by Crandall and Pomerance.
+	check(big.NewInt(3215031751))
+	// 2^89 - 1 is a Mersenne prime, for which n + 1 has many trailing zeros.
+	check(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 89), big.NewInt(1)))
+	// A Fermat number, composite, for which n - 1 has many trailing zeros.
+	check(new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)))
+
+	if _, err := ProbablyPrime(nil, new(safenum.Nat).SetUint64(7), -1); err == nil {
+		t.Error("negative rounds accepted")
+	}
+}
+
+func TestProbablyPrimeRandom(t *testing.T) {
+	buf := make([]byte, 24)
+	for i := 0; i < 300; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+		buf[len(buf)-1] |= 1
+		n := new(big.Int).SetBytes(buf)
+		got, err := ProbablyPrime(nil, new(safenum.Nat).SetBytes(buf), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := n.ProbablyPrime(0); got != want {
+			t.Errorf("ProbablyPrime(%v) = %v", n, got)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package rand
+
+import (
+	cryptorand "crypto/rand"
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/fips"
+)
+
+// EntropySource is a source of randomness whose output goes through the
+// continuous health tests of SP 800-90B, section 4.4, which detect a source
+// which got stuck, or lost most of its entropy.
+//
+// Once a test fails, every Read returns the error of that test, which Err
+// also returns.
+type EntropySource interface {
+	io.Reader
+	// Err returns the error of the health test which failed, or nil.
+	Err() error
+}
+
+var (
+	// ErrRepetitionCount is returned once a byte was repeated too many times
+	// in a row.
+	ErrRepetitionCount = errors.New("rand: entropy source failed the repetition count test")
+	// ErrAdaptiveProportion is returned once a byte was too frequent in a
+	// window of the output.
+	ErrAdaptiveProportion = errors.New("rand: entropy source failed the adaptive proportion test")
+)
+
+const (
+	// falsePositiveLog2 is -log2 of the probability of a test failing on a
+	// healthy source, for each byte, which is the lowest recommended by SP
+	// 800-90B.
+	falsePositiveLog2 = 40
+	// aptWindow is the window of the adaptive proportion test for
+	// non-binary sources.
+	aptWindow = 512
+)
+
+// rctCutoff returns the cutoff of the repetition count test, from SP
+// 800-90B, section 4.4.1, for a min-entropy of h bits per byte.
+func rctCutoff(h float64, alphaLog2 int) int {
+	return 1 + int(math.Ceil(float64(alphaLog2)/h))
+}
+
+// aptCutoff returns the cutoff of the adaptive proportion test, from SP
+// 800-90B, section 4.4.2, for a min-entropy of h bits per byte: the smallest
+// count which the most likely byte exceeds with probability at most alpha.
+func aptCutoff(h float64, alphaLog2 int) int {
+	p := math.Exp2(-h)
+	alpha := math.Exp2(-float64(alphaLog2))
+	lp, lq := math.Log(p), math.Log1p(-p)
+	lgW, _ := math.Lgamma(aptWindow + 1)
+	// The tail is summed from its end, which keeps the small terms precise.
+	var tail float64
+	for c := aptWindow; c >= 0; c-- {
+		lgC, _ := math.Lgamma(float64(c + 1))
+		lgRest, _ := math.Lgamma(float64(aptWindow - c + 1))
+		pmf := math.Exp(lgW - lgC - lgRest + float64(c)*lp + float64(aptWindow-c)*lq)
+		if tail+pmf > alpha {
+			return c + 1
+		}
+		tail += pmf
+	}
+	return 1
+}
+
+type healthTested struct {
+	r io.Reader
+
+	mu  sync.Mutex
+	err error
+
+	rctCutoff, aptCutoff int
+	// last is the previous byte, and run the length of its repetition.
+	last byte
+	run  int
+	// first is the first byte of the current window, seen the number of
+	// bytes of that window so far, and count the occurrences of first.
+	first       byte
+	seen, count int
+}
+
+// NewEntropySource returns an EntropySource reading from r, whose output is
+// assessed to have at least minEntropy bits of min-entropy per byte, between
+// 0 and 8.
+//
+// The cutoffs of the tests are set for a false positive rate of 2^-40 per
+// byte, so that a healthy source practically never fails.
+func NewEntropySource(r io.Reader, minEntropy float64) (EntropySource, error) {
+	if !(minEntropy > 0 && minEntropy <= 8) {
+		return nil, errors.New("rand: min-entropy must be between 0 and 8 bits per byte")
+	}
+	return &healthTested{
+		r:         r,
+		rctCutoff: rctCutoff(minEntropy, falsePositiveLog2),
+		aptCutoff: aptCutoff(minEntropy, falsePositiveLog2),
+	}, nil
+}
+
+func (s *healthTested) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *healthTested) Read(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.r.Read(b)
+	for _, x := range b[:n] {
+		if s.err = s.test(x); s.err != nil {
+			// Nothing read from a failing source is returned.
+			for i := range b[:n] {
+				b[i] = 0
+			}
+			return 0, s.err
+		}
+	}
+	return n, err
+}
+
+// test runs both tests on the next byte of the source.
+func (s *healthTested) test(x byte) error {
+	if s.run > 0 && x == s.last {
+		s.run++
+	} else {
+		s.last, s.run = x, 1
+	}
+	if s.run >= s.rctCutoff {
+		return ErrRepetitionCount
+	}
+
+	if s.seen == 0 {
+		s.first, s.count = x, 0
+	}
+	if x == s.first {
+		s.count++
+	}
+	s.seen++
+	if s.count >= s.aptCutoff {
+		return ErrAdaptiveProportion
+	}
+	if s.seen == aptWindow {
+		s.seen = 0
+	}
+	return nil
+}
+
+// Reader is the default EntropySource, reading from crypto/rand.Reader, with
+// full entropy. It is approved by the fips package.
+//
+// The packages of this module use it when generating keys or nonces with a
+// nil io.Reader.
+var Reader EntropySource
+
+func init() {
+	r, err := NewEntropySource(cryptorand.Reader, 8)
+	if err != nil {
+		panic(err)
+	}
+	Reader = r
+	fips.ApproveEntropySource(Reader)
+}
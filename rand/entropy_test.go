@@ -0,0 +1,95 @@
+package rand
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/fips"
+)
+
+// TestCutoffs checks the cutoffs for a false positive rate of 2^-20 against
+// SP 800-90B, section 4.4.
+func TestCutoffs(t *testing.T) {
+	for _, c := range []struct {
+		h        float64
+		rct, apt int
+	}{
+		{0.5, 41, 410},
+		{1, 21, 311},
+		{2, 11, 177},
+		{4, 6, 62},
+		{8, 4, 13},
+	} {
+		if got := rctCutoff(c.h, 20); got != c.rct {
+			t.Errorf("repetition count cutoff for H = %v: %d, expected %d", c.h, got, c.rct)
+		}
+		if got := aptCutoff(c.h, 20); got != c.apt {
+			t.Errorf("adaptive proportion cutoff for H = %v: %d, expected %d", c.h, got, c.apt)
+		}
+	}
+}
+
+func TestHealthySource(t *testing.T) {
+	b := make([]byte, 1<<20)
+	if _, err := io.ReadFull(Reader, b); err != nil {
+		t.Fatal(err)
+	}
+	if Reader.Err() != nil {
+		t.Fatal(Reader.Err())
+	}
+	if !fips.ApprovedEntropy(Reader) {
+		t.Error("Reader isn't approved")
+	}
+}
+
+func TestStuckSource(t *testing.T) {
+	s, err := NewEntropySource(bytes.NewReader(make([]byte, 64)), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 64)
+	if _, err := io.ReadFull(s, b); !errors.Is(err, ErrRepetitionCount) {
+		t.Fatalf("got %v, expected %v", err, ErrRepetitionCount)
+	}
+	if !bytes.Equal(b, make([]byte, 64)) || s.Err() != ErrRepetitionCount {
+		t.Error("failure wasn't recorded")
+	}
+	// The failure is permanent.
+	if _, err := s.Read(b); err != ErrRepetitionCount {
+		t.Errorf("got %v after a failure", err)
+	}
+}
+
+func TestBiasedSource(t *testing.T) {
+	// Every other byte is zero, which never repeats a byte more than twice in
+	// a row, but makes zero far too frequent.
+	b := make([]byte, 1024)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	for i := range b {
+		if i%2 == 0 {
+			b[i] = 0
+		} else {
+			b[i] |= 1
+		}
+	}
+	s, err := NewEntropySource(bytes.NewReader(b), 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(s, make([]byte, len(b))); err != ErrAdaptiveProportion {
+		t.Errorf("got %v, expected %v", err, ErrAdaptiveProportion)
+	}
+}
+
+func TestInvalidMinEntropy(t *testing.T) {
+	for _, h := range []float64{0, -1, 8.5} {
+		if _, err := NewEntropySource(rand.Reader, h); err == nil {
+			t.Errorf("min-entropy of %v accepted", h)
+		}
+	}
+}
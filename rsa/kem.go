@@ -0,0 +1,110 @@
+package rsa
+
+import (
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements RSA-KEM, as specified in ISO/IEC 18033-2, section
+// 11.5, with the key derivation function KDF2 of section 6.2.3, as used by
+// RFC 5990.
+//
+// Rather than padding a message, RSA-KEM encrypts a random integer, and
+// derives a key from it, which is then used with a symmetric cipher. Any
+// ciphertext decapsulates to some key, so there is no padding oracle.
+
+// kdf2 derives length bytes from x, as the concatenation of Hash(x || c), for
+// a 32 bit big-endian counter c starting from 1.
+func kdf2(hash hash.Hash, x []byte, length int) []byte {
+	out := make([]byte, 0, length+hash.Size())
+	var counter [4]byte
+	for len(out) < length {
+		incCounter(&counter)
+		hash.Reset()
+		hash.Write(x)
+		hash.Write(counter[:])
+		out = hash.Sum(out)
+	}
+	hash.Reset()
+	return out[:length]
+}
+
+// natBytes returns the encoding of x, padded to k bytes.
+func natBytes(x *safenum.Nat, k int) []byte {
+	b := x.Bytes()
+	if len(b) < k {
+		return append(make([]byte, k-len(b)), b...)
+	}
+	return b[len(b)-k:]
+}
+
+// EncapsulateKEM generates a random key of keyLen bytes, and encapsulates it
+// to pub with RSA-KEM, deriving it with KDF2 and hash. It returns the
+// ciphertext, which is as long as the modulus, and the key.
+//
+// The random parameter is used as a source of entropy, to pick an integer
+// uniformly below the modulus.
+func EncapsulateKEM(hash hash.Hash, random io.Reader, pub *PublicKey, keyLen int) (ciphertext, key []byte, err error) {
+	if err := checkPub(pub); err != nil {
+		return nil, nil, err
+	}
+	if err := checkFIPSKey(int(pub.N.BitLen())); err != nil {
+		return nil, nil, err
+	}
+	if err := fips.CheckEntropy(random); err != nil {
+		return nil, nil, err
+	}
+	if keyLen <= 0 {
+		return nil, nil, errors.New("crypto/rsa: invalid key length")
+	}
+	k := pub.Size()
+	buf := make([]byte, k)
+	r := new(safenum.Nat)
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, nil, err
+		}
+		buf[0] &= byte(0xff >> (8*k - int(pub.N.BitLen())))
+		// SetBytes can modify its argument, if it has spare capacity.
+		r.SetBytes(buf[:k:k])
+		// This only leaks whether a value was rejected, which happens with
+		// probability at most one half.
+		if r.CmpMod(pub.N) == -1 {
+			break
+		}
+	}
+	c := encrypt(new(safenum.Nat), pub, r)
+	return natBytes(c, k), kdf2(hash, buf, keyLen), nil
+}
+
+// DecapsulateKEM decapsulates a key of keyLen bytes from a ciphertext
+// produced by EncapsulateKEM, with the same hash.
+//
+// Only ciphertexts of the wrong length, or representing an integer which is
+// not below the modulus, are rejected. Other invalid ciphertexts decapsulate
+// to unrelated keys, which the symmetric cipher then rejects.
+func DecapsulateKEM(hash hash.Hash, priv *PrivateKey, ciphertext []byte, keyLen int) ([]byte, error) {
+	if err := checkPub(&priv.PublicKey); err != nil {
+		return nil, err
+	}
+	if keyLen <= 0 {
+		return nil, errors.New("crypto/rsa: invalid key length")
+	}
+	k := priv.Size()
+	if len(ciphertext) != k {
+		return nil, ErrDecryption
+	}
+	c := new(safenum.Nat).SetBytes(ciphertext[:k:k])
+	if c.CmpMod(priv.N) != -1 {
+		return nil, ErrDecryption
+	}
+	r, err := decryptAndCheck(priv, c)
+	if err != nil {
+		return nil, err
+	}
+	return kdf2(hash, natBytes(r, k), keyLen), nil
+}
@@ -0,0 +1,80 @@
+package rsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+)
+
+func TestKDF2(t *testing.T) {
+	x := []byte("shared secret")
+	block := func(counter byte) []byte {
+		h := sha256.Sum256(append(append([]byte{}, x...), 0, 0, 0, counter))
+		return h[:]
+	}
+	expected := append(block(1), block(2)...)[:40]
+	if out := kdf2(sha256.New(), x, 40); !bytes.Equal(out, expected) {
+		t.Errorf("got %x, want %x", out, expected)
+	}
+}
+
+func TestKEM(t *testing.T) {
+	pub := &test2048Key.PublicKey
+	ciphertext, key, err := EncapsulateKEM(sha256.New(), rand.Reader, pub, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext) != pub.Size() || len(key) != 32 {
+		t.Fatalf("wrong lengths %d and %d", len(ciphertext), len(key))
+	}
+	decapsulated, err := DecapsulateKEM(sha256.New(), test2048Key, ciphertext, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, decapsulated) {
+		t.Error("keys differ")
+	}
+
+	// The key is derived from r, which encrypts to the ciphertext.
+	r := new(big.Int).SetBytes(decryptBig(t, ciphertext))
+	if !bytes.Equal(key, kdf2(sha256.New(), r.FillBytes(make([]byte, pub.Size())), 32)) {
+		t.Error("wrong key derivation")
+	}
+
+	ciphertext[len(ciphertext)-1] ^= 1
+	other, err := DecapsulateKEM(sha256.New(), test2048Key, ciphertext, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key, other) {
+		t.Error("modified ciphertext decapsulated to the same key")
+	}
+}
+
+// decryptBig decrypts a ciphertext with math/big.
+func decryptBig(t *testing.T, ciphertext []byte) []byte {
+	t.Helper()
+	n := new(big.Int).SetBytes(test2048Key.N.Bytes())
+	d := new(big.Int).SetBytes(test2048Key.D.Bytes())
+	return new(big.Int).Exp(new(big.Int).SetBytes(ciphertext), d, n).Bytes()
+}
+
+func TestKEMInvalidCiphertexts(t *testing.T) {
+	k := test2048Key.Size()
+	n := test2048Key.N.Bytes()
+	for name, c := range map[string][]byte{
+		"short": make([]byte, k-1),
+		"long":  make([]byte, k+1),
+		"n":     n[len(n)-k:],
+		"max":   bytes.Repeat([]byte{0xff}, k),
+	} {
+		if _, err := DecapsulateKEM(sha256.New(), test2048Key, c, 32); err == nil {
+			t.Errorf("%s ciphertext accepted", name)
+		}
+	}
+	if _, _, err := EncapsulateKEM(sha256.New(), rand.Reader, &test2048Key.PublicKey, 0); err == nil {
+		t.Error("empty key accepted")
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"errors"
 	"io"
 
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/instrument"
 	"github.com/cronokirby/ctcrypto/internal/randutil"
 	"github.com/cronokirby/safenum"
 )
@@ -37,6 +39,10 @@ type PKCS1v15DecryptOptions struct {
 // WARNING: use of this function to encrypt plaintexts other than
 // session keys is dangerous. Use RSA OAEP in new protocols.
 func EncryptPKCS1v15(rand io.Reader, pub *PublicKey, msg []byte) ([]byte, error) {
+	// PKCS #1 v1.5 encryption is no longer approved for key transport.
+	if err := fips.Check(false, "RSAES-PKCS1-v1_5"); err != nil {
+		return nil, err
+	}
 	randutil.MaybeReadByte(rand)
 
 	if err := checkPub(pub); err != nil {
@@ -174,25 +180,20 @@ func decryptPKCS1v15(priv *PrivateKey, ciphertext []byte) (valid int, em []byte,
 }
 
 // nonZeroRandomBytes fills the given slice with non-zero random octets.
-func nonZeroRandomBytes(s []byte, rand io.Reader) (err error) {
-	_, err = io.ReadFull(rand, s)
-	if err != nil {
-		return
+//
+// Each octet is 1 + x mod 255, for a random 16-bit x, which is within 2^-16 of
+// uniform, and needs no rejection loop, so that a reader returning zeros, like
+// in tests, can't make it spin.
+func nonZeroRandomBytes(s []byte, rand io.Reader) error {
+	b := make([]byte, 2*len(s))
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return err
 	}
-
-	for i := 0; i < len(s); i++ {
-		for s[i] == 0 {
-			_, err = io.ReadFull(rand, s[i:i+1])
-			if err != nil {
-				return
-			}
-			// In tests, the PRNG may return all zeros so we do
-			// this to break the loop.
-			s[i] ^= 0x42
-		}
+	for i := range s {
+		x := uint32(b[2*i])<<8 | uint32(b[2*i+1])
+		s[i] = byte(1 + x%255)
 	}
-
-	return
+	return nil
 }
 
 // These are ASN1 DER structures:
@@ -224,7 +225,14 @@ var hashPrefixes = map[crypto.Hash][]byte{
 // messages is small, an attacker may be able to build a map from
 // messages to signatures and identify the signed messages. As ever,
 // signatures provide authenticity, not confidentiality.
+//
+// The signature is always verified before being returned, since a single
+// faulty signature would reveal the factorization of the modulus.
 func SignPKCS1v15(priv *PrivateKey, hash crypto.Hash, hashed []byte) ([]byte, error) {
+	defer instrument.Begin(instrument.Sign, "RSA PKCS #1 v1.5")()
+	if err := checkFIPSHash(hash, int(priv.N.BitLen())); err != nil {
+		return nil, err
+	}
 	hashLen, prefix, err := pkcs1v15HashInfo(hash, len(hashed))
 	if err != nil {
 		return nil, err
@@ -260,6 +268,7 @@ func SignPKCS1v15(priv *PrivateKey, hash crypto.Hash, hashed []byte) ([]byte, er
 // returning a nil error. If hash is zero then hashed is used directly. This
 // isn't advisable except for interoperability.
 func VerifyPKCS1v15(pub *PublicKey, hash crypto.Hash, hashed []byte, sig []byte) error {
+	defer instrument.Begin(instrument.Verify, "RSA PKCS #1 v1.5")()
 	hashLen, prefix, err := pkcs1v15HashInfo(hash, len(hashed))
 	if err != nil {
 		return err
@@ -279,6 +288,9 @@ func VerifyPKCS1v15(pub *PublicKey, hash crypto.Hash, hashed []byte, sig []byte)
 	}
 
 	c := new(safenum.Nat).SetBytes(sig)
+	if err := checkSignatureReduced(pub, c); err != nil {
+		return err
+	}
 	m := encrypt(new(safenum.Nat), pub, c)
 	em := m.FillBytes(make([]byte, k))
 	// EM = 0x00 || 0x01 || PS || 0x00 || T
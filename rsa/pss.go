@@ -13,6 +13,8 @@ import (
 	"hash"
 	"io"
 
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/instrument"
 	"github.com/cronokirby/safenum"
 )
 
@@ -263,9 +265,16 @@ func (opts *PSSOptions) saltLength() int {
 // function. The opts argument may be nil, in which case sensible defaults are
 // used. If opts.Hash is set, it overrides hash.
 func SignPSS(rand io.Reader, priv *PrivateKey, hash crypto.Hash, digest []byte, opts *PSSOptions) ([]byte, error) {
+	defer instrument.Begin(instrument.Sign, "RSA-PSS")()
 	if opts != nil && opts.Hash != 0 {
 		hash = opts.Hash
 	}
+	if err := checkFIPSHash(hash, int(priv.N.BitLen())); err != nil {
+		return nil, err
+	}
+	if err := fips.CheckEntropy(rand); err != nil {
+		return nil, err
+	}
 
 	saltLength := opts.saltLength()
 	switch saltLength {
@@ -289,10 +298,14 @@ func SignPSS(rand io.Reader, priv *PrivateKey, hash crypto.Hash, digest []byte,
 // argument may be nil, in which case sensible defaults are used. opts.Hash is
 // ignored.
 func VerifyPSS(pub *PublicKey, hash crypto.Hash, digest []byte, sig []byte, opts *PSSOptions) error {
+	defer instrument.Begin(instrument.Verify, "RSA-PSS")()
 	if len(sig) != pub.Size() {
 		return ErrVerification
 	}
 	s := new(safenum.Nat).SetBytes(sig)
+	if err := checkSignatureReduced(pub, s); err != nil {
+		return err
+	}
 	m := encrypt(new(safenum.Nat), pub, s)
 	emBits := int(pub.N.BitLen()) - 1
 	emLen := (emBits + 7) / 8
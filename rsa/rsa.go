@@ -23,6 +23,7 @@
 package rsa
 
 import (
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/subtle"
@@ -30,8 +31,12 @@ import (
 	"hash"
 	"io"
 	"math"
+	"strconv"
 
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/instrument"
 	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/strict"
 	"github.com/cronokirby/safenum"
 )
 
@@ -64,6 +69,9 @@ func (pub *PublicKey) Equal(x crypto.PublicKey) bool {
 type OAEPOptions struct {
 	// Hash is the hash function that will be used when generating the mask.
 	Hash crypto.Hash
+	// MGFHash is the hash function used by MGF1, if different from Hash, as
+	// some implementations use SHA-1 there regardless of Hash.
+	MGFHash crypto.Hash
 	// Label is an arbitrary byte string that must be equal to the value
 	// used when encrypting.
 	Label []byte
@@ -111,23 +119,24 @@ func (priv *PrivateKey) Public() crypto.PublicKey {
 
 // Equal reports whether priv and x have equivalent values. It ignores
 // Precomputed values.
+//
+// The private exponents and primes are compared in constant time, and the
+// result doesn't reveal which of them differ. Only the number of primes,
+// which is public, is compared first.
 func (priv *PrivateKey) Equal(x crypto.PrivateKey) bool {
 	xx, ok := x.(*PrivateKey)
-	if !ok {
-		return false
-	}
-	if !priv.PublicKey.Equal(&xx.PublicKey) || priv.D.Cmp(xx.D) != 0 {
+	if !ok || len(priv.Primes) != len(xx.Primes) {
 		return false
 	}
-	if len(priv.Primes) != len(xx.Primes) {
-		return false
+	eq := 0
+	if priv.PublicKey.Equal(&xx.PublicKey) {
+		eq = 1
 	}
+	eq &= subtle.ConstantTimeEq(int32(priv.D.Cmp(xx.D)), 0)
 	for i := range priv.Primes {
-		if priv.Primes[i].Cmp(xx.Primes[i]) != 0 {
-			return false
-		}
+		eq &= subtle.ConstantTimeEq(int32(priv.Primes[i].Cmp(xx.Primes[i])), 0)
 	}
-	return true
+	return eq == 1
 }
 
 // Sign signs digest with priv, reading randomness from rand. If opts is a
@@ -146,9 +155,17 @@ func (priv *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOp
 	return SignPKCS1v15(priv, opts.HashFunc(), digest)
 }
 
+var (
+	_ crypto.Signer    = (*PrivateKey)(nil)
+	_ crypto.Decrypter = (*PrivateKey)(nil)
+)
+
 // Decrypt decrypts ciphertext with priv. If opts is nil or of type
 // *PKCS1v15DecryptOptions then PKCS #1 v1.5 decryption is performed. Otherwise
 // opts must have type *OAEPOptions and OAEP decryption is done.
+//
+// This method implements crypto.Decrypter, so that keys of this package can be
+// used by code written against that interface, like TLS stacks.
 func (priv *PrivateKey) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) (plaintext []byte, err error) {
 	if opts == nil {
 		return DecryptPKCS1v15(priv, ciphertext)
@@ -156,7 +173,14 @@ func (priv *PrivateKey) Decrypt(rand io.Reader, ciphertext []byte, opts crypto.D
 
 	switch opts := opts.(type) {
 	case *OAEPOptions:
-		return DecryptOAEP(opts.Hash.New(), priv, ciphertext, opts.Label)
+		mgfHash := opts.MGFHash
+		if mgfHash == 0 {
+			mgfHash = opts.Hash
+		}
+		if !opts.Hash.Available() || !mgfHash.Available() {
+			return nil, errors.New("crypto/rsa: unavailable hash function for OAEP")
+		}
+		return decryptOAEP(opts.Hash.New(), mgfHash.New(), priv, ciphertext, opts.Label)
 
 	case *PKCS1v15DecryptOptions:
 		if l := opts.SessionKeyLen; l > 0 {
@@ -234,10 +258,45 @@ func (priv *PrivateKey) Validate() error {
 	return nil
 }
 
+// checkSignatureReduced returns an error if the signature representative s
+// isn't lower than the modulus, as required by RFC 8017, section 5.2.2, since
+// s and s + N would otherwise both be valid.
+func checkSignatureReduced(pub *PublicKey, s *safenum.Nat) error {
+	if s.CmpMod(pub.N) == -1 {
+		return nil
+	}
+	if err := strict.Check(false, "RSA signature", "not reduced modulo the modulus"); err != nil {
+		return err
+	}
+	return ErrVerification
+}
+
+// checkFIPSKey checks that the size of a key is approved, if the FIPS policy
+// is enforced.
+func checkFIPSKey(bits int) error {
+	return fips.Check(bits >= 2048, "RSA-"+strconv.Itoa(bits))
+}
+
+// checkFIPSHash checks that hash is approved for signatures with a key of the
+// given size, if the FIPS policy is enforced.
+func checkFIPSHash(hash crypto.Hash, bits int) error {
+	if err := checkFIPSKey(bits); err != nil {
+		return err
+	}
+	return fips.Check(fips.ApprovedHash(hash, fips.RSAStrength(bits)), "RSA-"+strconv.Itoa(bits)+" with "+hash.String())
+}
+
 // GenerateKey generates an RSA keypair of the given bit size using the
-// random source random (for example, crypto/rand.Reader).
+// random source random (for example, crypto/rand.Reader). If random is nil,
+// the health-tested Reader of the rand package of this module is used.
 func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
-	return GenerateMultiPrimeKey(random, 2, bits)
+	return GenerateMultiPrimeKeyContext(context.Background(), random, 2, bits)
+}
+
+// GenerateKeyContext is like GenerateKey, but stops early, returning
+// ctx.Err(), once ctx is done.
+func GenerateKeyContext(ctx context.Context, random io.Reader, bits int) (*PrivateKey, error) {
+	return GenerateMultiPrimeKeyContext(ctx, random, 2, bits)
 }
 
 // GenerateMultiPrimeKey generates a multi-prime RSA keypair of the given bit
@@ -252,6 +311,25 @@ func GenerateKey(random io.Reader, bits int) (*PrivateKey, error) {
 // [1] US patent 4405829 (1972, expired)
 // [2] http://www.cacr.math.uwaterloo.ca/techreports/2006/cacr2006-16.pdf
 func GenerateMultiPrimeKey(random io.Reader, nprimes int, bits int) (*PrivateKey, error) {
+	return GenerateMultiPrimeKeyContext(context.Background(), random, nprimes, bits)
+}
+
+// GenerateMultiPrimeKeyContext is like GenerateMultiPrimeKey, but stops early,
+// returning ctx.Err(), once ctx is done. The context is checked before
+// generating each prime, so a cancelled generation returns after at most one
+// more prime.
+func GenerateMultiPrimeKeyContext(ctx context.Context, random io.Reader, nprimes int, bits int) (*PrivateKey, error) {
+	defer instrument.Begin(instrument.GenerateKey, "RSA")()
+	if err := fips.Check(nprimes == 2, "multi-prime RSA"); err != nil {
+		return nil, err
+	}
+	if err := checkFIPSKey(bits); err != nil {
+		return nil, err
+	}
+	random = randutil.Or(random)
+	if err := fips.CheckEntropy(random); err != nil {
+		return nil, err
+	}
 	randutil.MaybeReadByte(random)
 
 	priv := new(PrivateKey)
@@ -296,6 +374,9 @@ NextSetOfPrimes:
 			todo += (nprimes - 2) / 5
 		}
 		for i := 0; i < nprimes; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 			primesIBig, err := rand.Prime(random, todo/(nprimes-i))
 			primes[i] = new(safenum.Nat).SetBytes(primesIBig.Bytes())
 			if err != nil {
@@ -329,17 +410,15 @@ NextSetOfPrimes:
 			continue NextSetOfPrimes
 		}
 
-		e := new(safenum.Nat).SetUint64(uint64(priv.E))
-		// NOTE: In case priv.E is badly chosen, this can silently fail
-		priv.D = new(safenum.Nat)
-		ok := priv.D.ModInverseEven(e, totient)
+		nMod := safenum.ModulusFromBytes(n.Bytes())
+		priv.D = invertExponent(uint64(priv.E), totient, nMod)
 
-		if ok != nil {
+		if priv.D != nil {
 			priv.Primes = make([]*safenum.Nat, len(primes))
 			for i := 0; i < len(primes); i++ {
 				priv.Primes[i] = new(safenum.Nat).SetBytes(primes[i].Bytes())
 			}
-			priv.N = safenum.ModulusFromBytes(n.Bytes())
+			priv.N = nMod
 			break
 		}
 	}
@@ -348,6 +427,26 @@ NextSetOfPrimes:
 	return priv, nil
 }
 
+// invertExponent returns e^-1 mod totient, or nil if e isn't invertible.
+//
+// It doesn't use ModInverseEven, which returns wrong results with 32-bit
+// limbs. Since e is small, d = (1 + totient * (-totient^-1 mod e)) / e, and
+// since d < n, with n odd, the exact division is a multiplication by e^-1 mod n.
+func invertExponent(e uint64, totient *safenum.Nat, n *safenum.Modulus) *safenum.Nat {
+	eMod := safenum.ModulusFromUint64(e)
+	one := new(safenum.Nat).SetUint64(1)
+	r := new(safenum.Nat).Mod(totient, eMod)
+	t := new(safenum.Nat).ModInverse(r, eMod)
+	if new(safenum.Nat).ModMul(r, t, eMod).Cmp(one) != 0 {
+		return nil
+	}
+	t.ModSub(new(safenum.Nat), t, eMod)
+	d := new(safenum.Nat).ModMul(totient, t, n)
+	d.ModAdd(d, one, n)
+	eInv := new(safenum.Nat).ModInverse(new(safenum.Nat).SetUint64(e), n)
+	return d.ModMul(d, eInv, n)
+}
+
 // incCounter increments a four byte, big-endian counter.
 func incCounter(c *[4]byte) {
 	if c[3]++; c[3] != 0 {
@@ -411,9 +510,20 @@ func encrypt(c *safenum.Nat, pub *PublicKey, m *safenum.Nat) *safenum.Nat {
 // The message must be no longer than the length of the public modulus minus
 // twice the hash length, minus a further 2.
 func EncryptOAEP(hash hash.Hash, random io.Reader, pub *PublicKey, msg []byte, label []byte) ([]byte, error) {
+	return encryptOAEP(hash, hash, random, pub, msg, label)
+}
+
+// encryptOAEP is EncryptOAEP, with a separate hash for MGF1.
+func encryptOAEP(hash, mgfHash hash.Hash, random io.Reader, pub *PublicKey, msg []byte, label []byte) ([]byte, error) {
 	if err := checkPub(pub); err != nil {
 		return nil, err
 	}
+	if err := checkFIPSKey(int(pub.N.BitLen())); err != nil {
+		return nil, err
+	}
+	if err := fips.CheckEntropy(random); err != nil {
+		return nil, err
+	}
 	hash.Reset()
 	k := pub.Size()
 	if len(msg) > k-2*hash.Size()-2 {
@@ -437,8 +547,8 @@ func EncryptOAEP(hash hash.Hash, random io.Reader, pub *PublicKey, msg []byte, l
 		return nil, err
 	}
 
-	mgf1XOR(db, hash, seed)
-	mgf1XOR(seed, hash, db)
+	mgf1XOR(db, mgfHash, seed)
+	mgf1XOR(seed, mgfHash, db)
 
 	m := new(safenum.Nat)
 	m.SetBytes(em)
@@ -555,6 +665,11 @@ func decryptAndCheck(priv *PrivateKey, c *safenum.Nat) (m *safenum.Nat, err erro
 // The label parameter must match the value given when encrypting. See
 // EncryptOAEP for details.
 func DecryptOAEP(hash hash.Hash, priv *PrivateKey, ciphertext []byte, label []byte) ([]byte, error) {
+	return decryptOAEP(hash, hash, priv, ciphertext, label)
+}
+
+// decryptOAEP is DecryptOAEP, with a separate hash for MGF1.
+func decryptOAEP(hash, mgfHash hash.Hash, priv *PrivateKey, ciphertext []byte, label []byte) ([]byte, error) {
 	if err := checkPub(&priv.PublicKey); err != nil {
 		return nil, err
 	}
@@ -582,8 +697,8 @@ func DecryptOAEP(hash hash.Hash, priv *PrivateKey, ciphertext []byte, label []by
 	seed := em[1 : hash.Size()+1]
 	db := em[hash.Size()+1:]
 
-	mgf1XOR(seed, hash, db)
-	mgf1XOR(db, hash, seed)
+	mgf1XOR(seed, mgfHash, db)
+	mgf1XOR(db, mgfHash, seed)
 
 	lHash2 := db[0:hash.Size()]
 
@@ -6,6 +6,7 @@ package rsa
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/sha1"
@@ -16,6 +17,28 @@ import (
 	"github.com/cronokirby/safenum"
 )
 
+func TestInvertExponent(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p, _ := rand.Prime(rand.Reader, 256)
+		q, _ := rand.Prime(rand.Reader, 256)
+		n := new(big.Int).Mul(p, q)
+		one := big.NewInt(1)
+		totient := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		for _, e := range []int64{3, 65537} {
+			want := new(big.Int).ModInverse(big.NewInt(e), totient)
+			d := invertExponent(uint64(e), new(safenum.Nat).SetBytes(totient.Bytes()), safenum.ModulusFromBytes(n.Bytes()))
+			switch {
+			case want == nil && d != nil:
+				t.Errorf("%d isn't invertible modulo %x, got %x", e, totient, d.Bytes())
+			case want != nil && d == nil:
+				t.Errorf("%d is invertible modulo %x", e, totient)
+			case want != nil && new(big.Int).SetBytes(d.Bytes()).Cmp(want) != 0:
+				t.Errorf("%d^-1 mod %x = %x, got %x", e, totient, want, d.Bytes())
+			}
+		}
+	}
+}
+
 func TestKeyGeneration(t *testing.T) {
 	size := 1024
 	if testing.Short() {
@@ -86,6 +109,14 @@ func TestImpossibleKeyGeneration(t *testing.T) {
 	}
 }
 
+func TestKeyGenerationCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateKeyContext(ctx, rand.Reader, 2048); err != context.Canceled {
+		t.Errorf("got %v, expected %v", err, context.Canceled)
+	}
+}
+
 func TestGnuTLSKey(t *testing.T) {
 	// This is a key generated by `certtool --generate-privkey --bits 128`.
 	// It's such that de ≢ 1 mod φ(n), but is congruent mod the order of
@@ -276,6 +307,41 @@ func TestDecryptOAEP(t *testing.T) {
 	}
 }
 
+func TestDecrypterOAEP(t *testing.T) {
+	var d crypto.Decrypter = rsaPrivateKey
+	msg := []byte("session key")
+	label := []byte("label")
+
+	ciphertext, err := EncryptOAEP(sha1.New(), rand.Reader, &rsaPrivateKey.PublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.Decrypt(nil, ciphertext, &OAEPOptions{Hash: crypto.SHA1, Label: label})
+	if err != nil || !bytes.Equal(out, msg) {
+		t.Errorf("Decrypt returned %q, %v", out, err)
+	}
+	if _, err := d.Decrypt(nil, ciphertext, &OAEPOptions{Hash: crypto.SHA1}); err == nil {
+		t.Error("Decrypt succeeded with the wrong label")
+	}
+
+	// SHA-1 for the label, and SHA-256 for MGF1.
+	ciphertext, err = encryptOAEP(sha1.New(), sha256.New(), rand.Reader, &rsaPrivateKey.PublicKey, msg, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = d.Decrypt(nil, ciphertext, &OAEPOptions{Hash: crypto.SHA1, MGFHash: crypto.SHA256, Label: label})
+	if err != nil || !bytes.Equal(out, msg) {
+		t.Errorf("Decrypt with MGFHash returned %q, %v", out, err)
+	}
+	if _, err := d.Decrypt(nil, ciphertext, &OAEPOptions{Hash: crypto.SHA1, Label: label}); err == nil {
+		t.Error("Decrypt succeeded with the wrong MGF1 hash")
+	}
+
+	if _, err := d.Decrypt(nil, ciphertext, &OAEPOptions{Hash: crypto.MD4}); err == nil {
+		t.Error("Decrypt succeeded with an unavailable hash")
+	}
+}
+
 // testEncryptOAEPData contains a subset of the vectors from RSA's "Test vectors for RSA-OAEP".
 var testEncryptOAEPData = []testEncryptOAEPStruct{
 	// Key 1
@@ -422,3 +488,25 @@ var testEncryptOAEPData = []testEncryptOAEPStruct{
 		},
 	},
 }
+
+func TestKeyEqual(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := &PrivateKey{PublicKey: priv.PublicKey, D: priv.D}
+	for _, p := range priv.Primes {
+		clone.Primes = append(clone.Primes, new(safenum.Nat).SetNat(p))
+	}
+	if !priv.Equal(clone) {
+		t.Error("a copy of a key isn't equal to it")
+	}
+	clone.Primes[1] = new(safenum.Nat).SetUint64(3)
+	if priv.Equal(clone) {
+		t.Error("keys with different primes are equal")
+	}
+	clone.Primes = clone.Primes[:1]
+	if priv.Equal(clone) {
+		t.Error("keys with different numbers of primes are equal")
+	}
+}
@@ -0,0 +1,84 @@
+package rsa
+
+import (
+	"context"
+	"crypto/sha512"
+	"errors"
+	"math/big"
+	"strconv"
+
+	"github.com/cronokirby/ctcrypto/drbg"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/prime"
+	"github.com/cronokirby/safenum"
+)
+
+// MinSeedSize is the size of the smallest seed accepted by
+// GenerateKeyFromSeed, which is the security strength of HMAC_DRBG with
+// SHA-512, in bytes.
+const MinSeedSize = 32
+
+// GenerateKeyFromSeed deterministically generates a 2-prime RSA keypair of the
+// given bit size from seed, which must hold at least MinSeedSize bytes of
+// entropy. The same seed and size always give the same key, so that a key can
+// be regenerated from a backup of its seed, for example by hardware wallets.
+//
+// The seed instantiates HMAC_DRBG with SHA-512, personalized with the size of
+// the key, from which the primes are searched as strong primes, as done by
+// GenerateStrong of the prime package of this module, for the exponent 65537.
+// This derivation is fixed, and doesn't depend on math/big, or crypto/rand,
+// unlike GenerateKey. The key size must be at least 1024 bits.
+//
+// Since the seed can't be checked to come from an approved source of
+// randomness, this function is rejected when the FIPS policy is enforced.
+func GenerateKeyFromSeed(seed []byte, bits int) (*PrivateKey, error) {
+	if err := fips.Check(false, "RSA key generation from a seed"); err != nil {
+		return nil, err
+	}
+	if len(seed) < MinSeedSize {
+		return nil, errors.New("crypto/rsa: seed too short")
+	}
+	if bits < 1024 {
+		return nil, errors.New("crypto/rsa: key size too small to be generated from a seed")
+	}
+	d, err := drbg.NewHMAC(sha512.New, seed, nil, []byte("ctcrypto RSA key "+strconv.Itoa(bits)))
+	if err != nil {
+		return nil, err
+	}
+
+	const e = 65537
+	// The primes have their top two bits set, so that n has exactly bits
+	// bits.
+	pBits, qBits := bits-bits/2, bits/2
+	// As required by FIPS 186-4, appendix B.3.1, |p - q| > 2^(bits/2 - 100).
+	minDiff := new(big.Int).Lsh(big.NewInt(1), uint(bits/2-100))
+	var p, q *safenum.Nat
+	for {
+		if p, err = prime.GenerateStrong(context.Background(), d, pBits, e, nil); err != nil {
+			return nil, err
+		}
+		if q, err = prime.GenerateStrong(context.Background(), d, qBits, e, nil); err != nil {
+			return nil, err
+		}
+		diff := new(big.Int).SetBytes(p.Bytes())
+		diff.Sub(diff, new(big.Int).SetBytes(q.Bytes()))
+		if diff.CmpAbs(minDiff) == 1 {
+			break
+		}
+	}
+
+	one := new(safenum.Nat).SetUint64(1)
+	n := new(safenum.Nat).Mul(p, q, uint(bits))
+	pminus1 := new(safenum.Nat).Sub(p, one, p.TrueLen())
+	qminus1 := new(safenum.Nat).Sub(q, one, q.TrueLen())
+	totient := new(safenum.Nat).Mul(pminus1, qminus1, uint(bits))
+
+	priv := new(PrivateKey)
+	priv.E = e
+	priv.N = safenum.ModulusFromBytes(n.Bytes())
+	// e is coprime to p - 1 and q - 1, by construction of the primes.
+	priv.D = invertExponent(uint64(e), totient, priv.N)
+	priv.Primes = []*safenum.Nat{p, q}
+	priv.Precompute()
+	return priv, nil
+}
@@ -0,0 +1,42 @@
+package rsa
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{0x42}, MinSeedSize)
+	priv, err := GenerateKeyFromSeed(seed, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bits := priv.N.BitLen(); bits != 1024 {
+		t.Errorf("key has %d bits, want 1024", bits)
+	}
+	testKeyBasics(t, priv)
+
+	again, err := GenerateKeyFromSeed(seed, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !priv.Equal(again) {
+		t.Error("the same seed gave different keys")
+	}
+
+	seed[0] ^= 1
+	other, err := GenerateKeyFromSeed(seed, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv.PublicKey.Equal(&other.PublicKey) {
+		t.Error("different seeds gave the same key")
+	}
+
+	if _, err := GenerateKeyFromSeed(seed[:MinSeedSize-1], 1024); err == nil {
+		t.Error("short seed accepted")
+	}
+	if _, err := GenerateKeyFromSeed(seed, 512); err == nil {
+		t.Error("small key generated")
+	}
+}
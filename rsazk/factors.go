@@ -0,0 +1,177 @@
+package rsazk
+
+import (
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// factorsDST is the domain separation tag of FactorsProof challenges.
+const factorsDST = "ctcrypto-rsazk-factors-v1"
+
+const (
+	// factorsL is the size of the challenges of a FactorsProof, in bits, and
+	// the bound on the size of the factors, called ℓ in CGGMP.
+	factorsL = 256
+	// factorsEpsilon is the slack used to hide the factors, called ε in CGGMP.
+	factorsEpsilon = 2 * factorsL
+)
+
+// FactorsProof is a proof that a modulus N0 = p q has no small factors, i.e.
+// that both of its factors are smaller than 2^(ℓ+ε) sqrt(N0), for ℓ = 256 and
+// ε = 512. For moduli of at least 2048 bits, this means that both factors are
+// larger than 2^ℓ.
+//
+// This is the proof Π^fac of CGGMP, figure 28, which relies on ring-Pedersen
+// parameters, chosen by the verifier.
+type FactorsProof struct {
+	// P, Q, A, B, and T are the commitments of the prover, modulo the
+	// ring-Pedersen modulus, and Sigma the randomness of R = s^N0 t^Sigma.
+	P, Q, A, B, T, Sigma *big.Int
+	// Z1, Z2, W1, W2, and V are the responses of the prover.
+	Z1, Z2, W1, W2, V *big.Int
+}
+
+// factorsBounds contains the bounds on the secret values used in the proof.
+type factorsBounds struct {
+	// ±2^(ℓ+ε) sqrt(N0), for α and β
+	alpha *big.Int
+	// ±2^ℓ N^, for μ and ν
+	mu *big.Int
+	// ±2^ℓ N0 N^, for σ
+	sigma *big.Int
+	// ±2^(ℓ+ε) N0 N^, for r
+	r *big.Int
+	// ±2^(ℓ+ε) N^, for x and y
+	x *big.Int
+}
+
+func newFactorsBounds(n0, nHat *big.Int) *factorsBounds {
+	sqrtN0 := new(big.Int).Sqrt(n0)
+	n0nHat := new(big.Int).Mul(n0, nHat)
+	return &factorsBounds{
+		alpha: new(big.Int).Lsh(sqrtN0, factorsL+factorsEpsilon),
+		mu:    new(big.Int).Lsh(nHat, factorsL),
+		sigma: new(big.Int).Lsh(n0nHat, factorsL),
+		r:     new(big.Int).Lsh(n0nHat, factorsL+factorsEpsilon),
+		x:     new(big.Int).Lsh(nHat, factorsL+factorsEpsilon),
+	}
+}
+
+// byteSize returns the number of bytes needed to hold integers of magnitude
+// at most bound.
+func byteSize(bound *big.Int) int {
+	return (bound.BitLen() + 7) / 8
+}
+
+func factorsChallenge(n0 *big.Int, rp *RingPedersen, proof *FactorsProof, context []byte) (*big.Int, error) {
+	t := newTranscript(factorsDST, context)
+	t.appendInt("N0", n0)
+	t.appendInt("N^", rp.N)
+	t.appendInt("s", rp.S)
+	t.appendInt("t", rp.T)
+	t.appendInt("P", proof.P)
+	t.appendInt("Q", proof.Q)
+	t.appendInt("A", proof.A)
+	t.appendInt("B", proof.B)
+	t.appendInt("T", proof.T)
+	t.appendInt("sigma", proof.Sigma)
+	return sampleSigned(t.reader(), new(big.Int).Lsh(big.NewInt(1), factorsL))
+}
+
+// ProveFactors proves that N0 = p q has no factors smaller than 2^ℓ, for the
+// ring-Pedersen parameters of the verifier.
+func ProveFactors(p, q *safenum.Nat, rp *RingPedersen, context []byte, rand io.Reader) (*FactorsProof, error) {
+	pBig, qBig := bigFromNat(p), bigFromNat(q)
+	n0 := new(big.Int).Mul(pBig, qBig)
+	bounds := newFactorsBounds(n0, rp.N)
+	var alpha, beta, mu, nu, sigma, r, x, y *big.Int
+	secrets := []struct {
+		out   **big.Int
+		bound *big.Int
+	}{
+		{&alpha, bounds.alpha}, {&beta, bounds.alpha},
+		{&mu, bounds.mu}, {&nu, bounds.mu},
+		{&sigma, bounds.sigma},
+		{&r, bounds.r},
+		{&x, bounds.x}, {&y, bounds.x},
+	}
+	for _, secret := range secrets {
+		v, err := sampleSigned(rand, secret.bound)
+		if err != nil {
+			return nil, err
+		}
+		*secret.out = v
+	}
+
+	nHat := safenum.ModulusFromNat(*natFromBig(rp.N))
+	// The exponents are all smaller than this bound, even after adding e
+	// times another exponent.
+	size := byteSize(bounds.r) + factorsL/8 + 1
+	proof := &FactorsProof{
+		P:     rp.commit(pBig, mu, size),
+		Q:     rp.commit(qBig, nu, size),
+		A:     rp.commit(alpha, x, size),
+		B:     rp.commit(beta, y, size),
+		Sigma: sigma,
+	}
+	proof.T = expSecret(proof.Q, alpha, nHat, size)
+	proof.T.Mul(proof.T, expSecret(rp.T, r, nHat, size))
+	proof.T.Mod(proof.T, rp.N)
+
+	e, err := factorsChallenge(n0, rp, proof, context)
+	if err != nil {
+		return nil, err
+	}
+	// σ^ = σ - ν p
+	sigmaHat := new(big.Int).Mul(nu, pBig)
+	sigmaHat.Sub(sigma, sigmaHat)
+	// z = a + e b
+	response := func(a, b *big.Int) *big.Int {
+		out := new(big.Int).Mul(e, b)
+		return out.Add(out, a)
+	}
+	proof.Z1 = response(alpha, pBig)
+	proof.Z2 = response(beta, qBig)
+	proof.W1 = response(x, mu)
+	proof.W2 = response(y, nu)
+	proof.V = response(r, sigmaHat)
+	return proof, nil
+}
+
+// VerifyFactors checks a proof that N0 has no small factors, for the
+// ring-Pedersen parameters of the verifier, created with the same context.
+//
+// The verifier should check that the parameters are valid beforehand, since
+// it generated them.
+func VerifyFactors(n0 *big.Int, rp *RingPedersen, proof *FactorsProof, context []byte) bool {
+	for _, x := range []*big.Int{proof.P, proof.Q, proof.A, proof.B, proof.T} {
+		if !inUnits(x, rp.N) {
+			return false
+		}
+	}
+	for _, x := range []*big.Int{proof.Sigma, proof.Z1, proof.Z2, proof.W1, proof.W2, proof.V} {
+		if x == nil {
+			return false
+		}
+	}
+	bounds := newFactorsBounds(n0, rp.N)
+	if proof.Z1.CmpAbs(bounds.alpha) > 0 || proof.Z2.CmpAbs(bounds.alpha) > 0 {
+		return false
+	}
+	e, err := factorsChallenge(n0, rp, proof, context)
+	if err != nil {
+		return false
+	}
+	// s^z1 t^w1 = A P^e, s^z2 t^w2 = B Q^e
+	if !rp.check(proof.Z1, proof.W1, proof.A, proof.P, e) || !rp.check(proof.Z2, proof.W2, proof.B, proof.Q, e) {
+		return false
+	}
+	// Q^z1 t^v = T R^e, with R = s^N0 t^σ
+	R := rp.commitPublic(n0, proof.Sigma)
+	if R == nil {
+		return false
+	}
+	return (&RingPedersen{N: rp.N, S: proof.Q, T: rp.T}).check(proof.Z1, proof.V, proof.T, R, e)
+}
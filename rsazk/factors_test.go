@@ -0,0 +1,49 @@
+package rsazk
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestFactorsProof(t *testing.T) {
+	rp, _ := ringPedersen(t)
+	sk := keys(t)[0]
+	p, q := sk.Primes()
+	n0 := new(big.Int).SetBytes(sk.N().Bytes())
+	proof, err := ProveFactors(p, q, rp, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyFactors(n0, rp, proof, testContext) {
+		t.Errorf("valid proof rejected")
+	}
+	if VerifyFactors(n0, rp, proof, []byte("other context")) {
+		t.Errorf("proof accepted in another context")
+	}
+	other := new(big.Int).SetBytes(keys(t)[1].N().Bytes())
+	if VerifyFactors(other, rp, proof, testContext) {
+		t.Errorf("proof accepted for another modulus")
+	}
+	proof.Z1 = new(big.Int).Add(proof.Z1, big.NewInt(1))
+	if VerifyFactors(n0, rp, proof, testContext) {
+		t.Errorf("tampered proof accepted")
+	}
+}
+
+func TestFactorsProofSmallFactor(t *testing.T) {
+	rp, _ := ringPedersen(t)
+	// N0 = 3 q, with q the product of two moduli, which makes N0 large enough
+	// for the bound on the factors to be meaningful.
+	n1 := new(big.Int).SetBytes(keys(t)[0].N().Bytes())
+	n2 := new(big.Int).SetBytes(keys(t)[1].N().Bytes())
+	q := new(big.Int).Mul(n1, n2)
+	n0 := new(big.Int).Mul(big.NewInt(3), q)
+	proof, err := ProveFactors(natFromBig(big.NewInt(3)), natFromBig(q), rp, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyFactors(n0, rp, proof, testContext) {
+		t.Errorf("proof accepted for a modulus with a small factor")
+	}
+}
@@ -0,0 +1,205 @@
+// Package rsazk implements non-interactive zero-knowledge proofs about RSA
+// and Paillier moduli, as used when setting up threshold ECDSA in the style
+// of CGGMP: that a modulus is a square-free Paillier-Blum modulus, that it
+// has no small factors, and that ring-Pedersen parameters are well formed.
+//
+// The proofs are made non-interactive with the Fiat-Shamir transform, with
+// challenges derived from a transcript of the statement, the commitments of
+// the prover, and a context string provided by the caller, which should
+// identify the protocol and session the proof is used in.
+//
+// Operations involving the factors of a modulus, or other secret exponents,
+// are done with safenum, while public values are handled with math/big.
+package rsazk
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// modulusDST is the domain separation tag of ModulusProof challenges.
+const modulusDST = "ctcrypto-rsazk-modulus-v1"
+
+// modulusRounds is the number of challenges of a ModulusProof, each of which
+// a cheating prover passes with probability at most 1/2.
+const modulusRounds = 80
+
+// ModulusProof is a proof that a modulus N is a Paillier-Blum modulus, i.e.
+// the product of two primes congruent to 3 mod 4, and that gcd(N, φ(N)) = 1,
+// which implies that N is square-free.
+//
+// This is the proof Π^mod of Canetti, Gennaro, Goldfeder, Makriyannis, and
+// Peled, "UC Non-Interactive, Proactive, Threshold ECDSA with Identifiable
+// Aborts", figure 16. For each challenge y_i, the prover gives an N-th root
+// z_i of y_i, and a fourth root x_i of (-1)^a_i w^b_i y_i.
+type ModulusProof struct {
+	// W is a value with Jacobi symbol -1 modulo N.
+	W *big.Int
+	// X and Z contain the roots, for each challenge.
+	X, Z []*big.Int
+	// A and B contain the exponents a_i and b_i, for each challenge.
+	A, B []bool
+}
+
+// modulusChallenges returns the challenges y_i, derived from N and w.
+func modulusChallenges(n, w *big.Int, context []byte) ([]*big.Int, error) {
+	t := newTranscript(modulusDST, context)
+	t.appendInt("N", n)
+	t.appendInt("w", w)
+	r := t.reader()
+	ys := make([]*big.Int, modulusRounds)
+	for i := range ys {
+		y, err := sampleMod(r, n)
+		if err != nil {
+			return nil, err
+		}
+		ys[i] = y
+	}
+	return ys, nil
+}
+
+// fourthRootExp returns ((p + 1) / 4)², such that x^e is a fourth root of x
+// modulo p, for x a square modulo p, and p ≡ 3 mod 4.
+func fourthRootExp(p *big.Int) *safenum.Nat {
+	e := new(big.Int).Add(p, big.NewInt(1))
+	e.Rsh(e, 2)
+	return natFromBig(e.Mul(e, e))
+}
+
+// isSquare returns true if x is a non-zero square modulo the prime p.
+func isSquare(x *safenum.Nat, p *safenum.Modulus, legendreExp *safenum.Nat) bool {
+	l := new(safenum.Nat).Exp(x, legendreExp, p)
+	return l.Cmp(new(safenum.Nat).Mod(new(safenum.Nat).SetUint64(1), p)) == 0
+}
+
+// ProveModulus proves that N = p q is a Paillier-Blum modulus, given the
+// primes p and q, which must be distinct, and congruent to 3 mod 4.
+//
+// The context should identify the protocol and session the proof is used in.
+func ProveModulus(p, q *safenum.Nat, context []byte, rand io.Reader) (*ModulusProof, error) {
+	pBig, qBig := bigFromNat(p), bigFromNat(q)
+	if pBig.Bit(0) != 1 || pBig.Bit(1) != 1 || qBig.Bit(0) != 1 || qBig.Bit(1) != 1 || pBig.Cmp(qBig) == 0 {
+		return nil, errors.New("rsazk: primes must be distinct, and 3 mod 4")
+	}
+	nBig := new(big.Int).Mul(pBig, qBig)
+	n := safenum.ModulusFromNat(*natFromBig(nBig))
+	pMod := safenum.ModulusFromNat(*natFromBig(pBig))
+	qMod := safenum.ModulusFromNat(*natFromBig(qBig))
+	one := big.NewInt(1)
+	pMinus1 := new(big.Int).Sub(pBig, one)
+	qMinus1 := new(big.Int).Sub(qBig, one)
+	phi := natFromBig(new(big.Int).Mul(pMinus1, qMinus1))
+	// N^-1 mod φ(N), to compute N-th roots.
+	nInv := new(safenum.Nat).ModInverseEven(natFromBig(nBig), phi)
+	pLegendre := natFromBig(new(big.Int).Rsh(pMinus1, 1))
+	qLegendre := natFromBig(new(big.Int).Rsh(qMinus1, 1))
+	pRoot, qRoot := fourthRootExp(pBig), fourthRootExp(qBig)
+	// q^-1 mod p, for the CRT.
+	qInv := new(safenum.Nat).ModInverse(new(safenum.Nat).Mod(q, pMod), pMod)
+
+	// w is a non-square with Jacobi symbol -1, which is public.
+	var w *big.Int
+	for {
+		var err error
+		w, err = sampleMod(rand, nBig)
+		if err != nil {
+			return nil, err
+		}
+		if big.Jacobi(w, nBig) == -1 {
+			break
+		}
+	}
+	ys, err := modulusChallenges(nBig, w, context)
+	if err != nil {
+		return nil, err
+	}
+
+	minusOne := new(big.Int).Sub(nBig, one)
+	proof := &ModulusProof{W: w}
+	for _, y := range ys {
+		yNat := natFromBig(y)
+		proof.Z = append(proof.Z, bigFromNat(new(safenum.Nat).Exp(yNat, nInv, n)))
+
+		// Exactly one of ±y, ±w y is a square modulo both p and q. Which one
+		// it is will be revealed in the proof anyway.
+		var a, b bool
+		var yPrime *safenum.Nat
+		for i := 0; i < 4; i++ {
+			a, b = i&1 == 1, i&2 == 2
+			candidate := new(big.Int).Set(y)
+			if a {
+				candidate.Mul(candidate, minusOne)
+			}
+			if b {
+				candidate.Mul(candidate, w)
+			}
+			yPrime = natFromBig(candidate.Mod(candidate, nBig))
+			if isSquare(new(safenum.Nat).Mod(yPrime, pMod), pMod, pLegendre) &&
+				isSquare(new(safenum.Nat).Mod(yPrime, qMod), qMod, qLegendre) {
+				break
+			}
+			yPrime = nil
+		}
+		if yPrime == nil {
+			return nil, errors.New("rsazk: N is not a Blum integer")
+		}
+		proof.A = append(proof.A, a)
+		proof.B = append(proof.B, b)
+
+		// x = xq + q ((xp - xq) q^-1 mod p)
+		xp := new(safenum.Nat).Exp(new(safenum.Nat).Mod(yPrime, pMod), pRoot, pMod)
+		xq := new(safenum.Nat).Exp(new(safenum.Nat).Mod(yPrime, qMod), qRoot, qMod)
+		h := new(safenum.Nat).ModSub(xp, new(safenum.Nat).Mod(xq, pMod), pMod)
+		h.ModMul(h, qInv, pMod)
+		x := new(safenum.Nat).ModMul(new(safenum.Nat).Mod(h, n), new(safenum.Nat).Mod(q, n), n)
+		x.ModAdd(x, new(safenum.Nat).Mod(xq, n), n)
+		proof.X = append(proof.X, bigFromNat(x))
+	}
+	return proof, nil
+}
+
+// VerifyModulus checks a proof that N is a Paillier-Blum modulus, created with
+// the same context.
+func VerifyModulus(n *big.Int, proof *ModulusProof, context []byte) bool {
+	if n.Sign() <= 0 || n.Bit(0) != 1 || n.ProbablyPrime(20) {
+		return false
+	}
+	if len(proof.X) != modulusRounds || len(proof.Z) != modulusRounds ||
+		len(proof.A) != modulusRounds || len(proof.B) != modulusRounds {
+		return false
+	}
+	if !inUnits(proof.W, n) || big.Jacobi(proof.W, n) != -1 {
+		return false
+	}
+	ys, err := modulusChallenges(n, proof.W, context)
+	if err != nil {
+		return false
+	}
+	four := big.NewInt(4)
+	for i, y := range ys {
+		x, z := proof.X[i], proof.Z[i]
+		if !inUnits(x, n) || !inUnits(z, n) {
+			return false
+		}
+		// z^N = y
+		if new(big.Int).Exp(z, n, n).Cmp(y) != 0 {
+			return false
+		}
+		// x^4 = (-1)^a w^b y
+		expected := new(big.Int).Set(y)
+		if proof.A[i] {
+			expected.Neg(expected)
+		}
+		if proof.B[i] {
+			expected.Mul(expected, proof.W)
+		}
+		expected.Mod(expected, n)
+		if new(big.Int).Exp(x, four, n).Cmp(expected) != 0 {
+			return false
+		}
+	}
+	return true
+}
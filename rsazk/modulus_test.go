@@ -0,0 +1,69 @@
+package rsazk
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/paillier"
+	"github.com/cronokirby/safenum"
+)
+
+var testContext = []byte("ctcrypto rsazk test")
+
+var (
+	testKeysOnce sync.Once
+	testKeys     []*paillier.PrivateKey
+)
+
+// keys returns Paillier keys shared between the tests.
+func keys(t *testing.T) []*paillier.PrivateKey {
+	testKeysOnce.Do(func() {
+		for i := 0; i < 2; i++ {
+			sk, err := paillier.GenerateKey(rand.Reader, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testKeys = append(testKeys, sk)
+		}
+	})
+	return testKeys
+}
+
+func TestModulusProof(t *testing.T) {
+	sk := keys(t)[0]
+	p, q := sk.Primes()
+	n := new(big.Int).SetBytes(sk.N().Bytes())
+	proof, err := ProveModulus(p, q, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyModulus(n, proof, testContext) {
+		t.Errorf("valid proof rejected")
+	}
+	if VerifyModulus(n, proof, []byte("other context")) {
+		t.Errorf("proof accepted in another context")
+	}
+	other := new(big.Int).SetBytes(keys(t)[1].N().Bytes())
+	if VerifyModulus(other, proof, testContext) {
+		t.Errorf("proof accepted for another modulus")
+	}
+	proof.A[0] = !proof.A[0]
+	if VerifyModulus(n, proof, testContext) {
+		t.Errorf("tampered proof accepted")
+	}
+}
+
+func TestModulusProofRejectsNonBlum(t *testing.T) {
+	// 5 * 7 has a factor congruent to 1 mod 4.
+	p, q := new(safenum.Nat).SetUint64(5), new(safenum.Nat).SetUint64(7)
+	if _, err := ProveModulus(p, q, testContext, rand.Reader); err == nil {
+		t.Errorf("proved a modulus with a prime 1 mod 4")
+	}
+	// p² is not square-free.
+	p = new(safenum.Nat).SetUint64(7)
+	if _, err := ProveModulus(p, p, testContext, rand.Reader); err == nil {
+		t.Errorf("proved a square modulus")
+	}
+}
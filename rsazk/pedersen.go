@@ -0,0 +1,181 @@
+package rsazk
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+)
+
+// paramsDST is the domain separation tag of ParamsProof challenges.
+const paramsDST = "ctcrypto-rsazk-params-v1"
+
+// paramsRounds is the number of challenges of a ParamsProof, each of which a
+// cheating prover passes with probability at most 1/2.
+const paramsRounds = 80
+
+// RingPedersen contains the parameters of ring-Pedersen commitments, used by
+// the verifier of a FactorsProof: a modulus N, and two squares s and t modulo
+// N, such that s = t^λ, for a secret λ.
+//
+// N should be the product of two safe primes, and its factorization should
+// only be known to the party who generated the parameters.
+type RingPedersen struct {
+	N, S, T *big.Int
+}
+
+// NewRingPedersen creates ring-Pedersen parameters from the factorization of
+// N = p q, where p and q should be distinct safe primes, returning the
+// parameters, and the secret λ, which is needed to prove that they're valid.
+func NewRingPedersen(p, q *safenum.Nat, rand io.Reader) (*RingPedersen, *big.Int, error) {
+	pBig, qBig := bigFromNat(p), bigFromNat(q)
+	if pBig.Cmp(qBig) == 0 {
+		return nil, nil, errors.New("rsazk: primes must be distinct")
+	}
+	nBig := new(big.Int).Mul(pBig, qBig)
+	n := safenum.ModulusFromNat(*natFromBig(nBig))
+	phi := ringPhi(pBig, qBig)
+	r, err := sampleMod(rand, nBig)
+	if err != nil {
+		return nil, nil, err
+	}
+	lambda, err := sampleMod(rand, phi)
+	if err != nil {
+		return nil, nil, err
+	}
+	rNat := natFromBig(r)
+	t := new(safenum.Nat).ModMul(rNat, rNat, n)
+	s := new(safenum.Nat).Exp(t, natFromBig(lambda), n)
+	return &RingPedersen{N: nBig, S: bigFromNat(s), T: bigFromNat(t)}, lambda, nil
+}
+
+// ringPhi returns φ(N) = (p - 1) (q - 1).
+func ringPhi(p, q *big.Int) *big.Int {
+	one := big.NewInt(1)
+	return new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+}
+
+// ParamsProof is a proof that ring-Pedersen parameters are well formed, i.e.
+// that s belongs to the group generated by t.
+//
+// This is the proof Π^prm of CGGMP, figure 17: for each challenge bit e_i, the
+// prover opens A_i = t^a_i as z_i = a_i + e_i λ mod φ(N).
+type ParamsProof struct {
+	A, Z []*big.Int
+}
+
+func paramsChallenges(rp *RingPedersen, as []*big.Int, context []byte) ([]bool, error) {
+	t := newTranscript(paramsDST, context)
+	t.appendInt("N", rp.N)
+	t.appendInt("s", rp.S)
+	t.appendInt("t", rp.T)
+	for _, a := range as {
+		t.appendInt("A", a)
+	}
+	var bits [(paramsRounds + 7) / 8]byte
+	if _, err := io.ReadFull(t.reader(), bits[:]); err != nil {
+		return nil, err
+	}
+	es := make([]bool, paramsRounds)
+	for i := range es {
+		es[i] = bits[i/8]>>(i%8)&1 == 1
+	}
+	return es, nil
+}
+
+// ProveParams proves that ring-Pedersen parameters, created by NewRingPedersen
+// from p and q, are well formed, using the secret λ such that s = t^λ.
+func ProveParams(rp *RingPedersen, p, q *safenum.Nat, lambda *big.Int, context []byte, rand io.Reader) (*ParamsProof, error) {
+	n := safenum.ModulusFromNat(*natFromBig(rp.N))
+	phiBig := ringPhi(bigFromNat(p), bigFromNat(q))
+	phi := safenum.ModulusFromNat(*natFromBig(phiBig))
+	size := (phiBig.BitLen() + 7) / 8
+	t := natFromBig(rp.T)
+	secrets := make([]*safenum.Nat, paramsRounds)
+	proof := &ParamsProof{A: make([]*big.Int, paramsRounds)}
+	for i := range secrets {
+		a, err := sampleMod(rand, phiBig)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		secrets[i] = new(safenum.Nat).SetBytes(a.FillBytes(buf))
+		proof.A[i] = bigFromNat(new(safenum.Nat).Exp(t, secrets[i], n))
+	}
+	es, err := paramsChallenges(rp, proof.A, context)
+	if err != nil {
+		return nil, err
+	}
+	lambdaNat := new(safenum.Nat).Mod(natFromBig(lambda), phi)
+	for i, e := range es {
+		// The challenge bits are public.
+		z := new(safenum.Nat).Mod(secrets[i], phi)
+		if e {
+			z.ModAdd(z, lambdaNat, phi)
+		}
+		proof.Z = append(proof.Z, bigFromNat(z))
+	}
+	return proof, nil
+}
+
+// VerifyParams checks a proof that ring-Pedersen parameters are well formed,
+// created with the same context.
+func VerifyParams(rp *RingPedersen, proof *ParamsProof, context []byte) bool {
+	if rp.N.Sign() <= 0 || rp.N.Bit(0) != 1 || !inUnits(rp.S, rp.N) || !inUnits(rp.T, rp.N) {
+		return false
+	}
+	if len(proof.A) != paramsRounds || len(proof.Z) != paramsRounds {
+		return false
+	}
+	es, err := paramsChallenges(rp, proof.A, context)
+	if err != nil {
+		return false
+	}
+	for i, e := range es {
+		if !inUnits(proof.A[i], rp.N) || proof.Z[i].Sign() < 0 || proof.Z[i].Cmp(rp.N) >= 0 {
+			return false
+		}
+		// t^z = A s^e
+		expected := new(big.Int).Set(proof.A[i])
+		if e {
+			expected.Mul(expected, rp.S)
+			expected.Mod(expected, rp.N)
+		}
+		if new(big.Int).Exp(rp.T, proof.Z[i], rp.N).Cmp(expected) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// commit returns s^x t^y mod N, for secret exponents x and y, which may be
+// negative, and whose absolute values are smaller than 2^(8 size).
+func (rp *RingPedersen) commit(x, y *big.Int, size int) *big.Int {
+	n := safenum.ModulusFromNat(*natFromBig(rp.N))
+	out := expSecret(rp.S, x, n, size)
+	out.Mul(out, expSecret(rp.T, y, n, size))
+	return out.Mod(out, rp.N)
+}
+
+// commitPublic returns s^x t^y mod N, for public exponents, or nil if one of
+// the bases isn't invertible.
+func (rp *RingPedersen) commitPublic(x, y *big.Int) *big.Int {
+	sX := new(big.Int).Exp(rp.S, x, rp.N)
+	tY := new(big.Int).Exp(rp.T, y, rp.N)
+	if sX == nil || tY == nil {
+		return nil
+	}
+	return sX.Mod(sX.Mul(sX, tY), rp.N)
+}
+
+// check returns true if s^x t^y = a b^e mod N, for public values.
+func (rp *RingPedersen) check(x, y, a, b, e *big.Int) bool {
+	lhs := rp.commitPublic(x, y)
+	rhs := new(big.Int).Exp(b, e, rp.N)
+	if lhs == nil || rhs == nil {
+		return false
+	}
+	rhs.Mul(rhs, a)
+	return lhs.Cmp(rhs.Mod(rhs, rp.N)) == 0
+}
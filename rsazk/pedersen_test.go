@@ -0,0 +1,35 @@
+package rsazk
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func ringPedersen(t *testing.T) (*RingPedersen, *ParamsProof) {
+	p, q := keys(t)[1].Primes()
+	rp, lambda, err := NewRingPedersen(p, q, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := ProveParams(rp, p, q, lambda, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp, proof
+}
+
+func TestParamsProof(t *testing.T) {
+	rp, proof := ringPedersen(t)
+	if !VerifyParams(rp, proof, testContext) {
+		t.Errorf("valid proof rejected")
+	}
+	if VerifyParams(rp, proof, []byte("other context")) {
+		t.Errorf("proof accepted in another context")
+	}
+	// s is no longer a power of t.
+	bad := &RingPedersen{N: rp.N, S: new(big.Int).Mod(new(big.Int).Mul(rp.S, big.NewInt(2)), rp.N), T: rp.T}
+	if VerifyParams(bad, proof, testContext) {
+		t.Errorf("proof accepted for other parameters")
+	}
+}
@@ -0,0 +1,117 @@
+package rsazk
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/sha3"
+)
+
+// transcript accumulates the public values of a proof, from which the
+// Fiat-Shamir challenges are derived.
+//
+// Each value is labelled, and prefixed by its length, so that distinct
+// transcripts can't encode to the same bytes.
+type transcript struct {
+	buf []byte
+}
+
+// newTranscript creates a transcript for a given proof system, identified by
+// its domain separation tag, binding the context of the caller.
+func newTranscript(dst string, context []byte) *transcript {
+	t := &transcript{}
+	t.appendBytes("dst", []byte(dst))
+	t.appendBytes("context", context)
+	return t
+}
+
+// appendBytes appends a labelled value to the transcript.
+func (t *transcript) appendBytes(label string, data []byte) {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(label)))
+	t.buf = append(append(t.buf, length[:]...), label...)
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data)))
+	t.buf = append(append(t.buf, length[:]...), data...)
+}
+
+// appendInt appends a labelled integer to the transcript, as a sign byte
+// followed by its absolute value.
+func (t *transcript) appendInt(label string, x *big.Int) {
+	sign := byte(0)
+	if x.Sign() < 0 {
+		sign = 1
+	}
+	t.appendBytes(label, append([]byte{sign}, x.Bytes()...))
+}
+
+// reader returns a stream of bytes derived from the transcript, using SHAKE256,
+// from which challenges are sampled.
+func (t *transcript) reader() io.Reader {
+	h := sha3.NewShake256()
+	h.Write(t.buf)
+	return h
+}
+
+// statisticalBits is the number of extra bits used when sampling integers, so
+// that their distribution is statistically close to the target one.
+const statisticalBits = 128
+
+// sampleMod returns an integer modulo n, read from r, with a negligible bias.
+func sampleMod(r io.Reader, n *big.Int) (*big.Int, error) {
+	buf := make([]byte, (n.BitLen()+statisticalBits+7)/8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(buf)
+	return x.Mod(x, n), nil
+}
+
+// sampleSigned returns a uniform integer in [-bound, bound], read from r.
+func sampleSigned(r io.Reader, bound *big.Int) (*big.Int, error) {
+	x, err := sampleMod(r, new(big.Int).Add(new(big.Int).Lsh(bound, 1), big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return x.Sub(x, bound), nil
+}
+
+// expSecret returns base^e mod m, for a secret exponent e, which may be
+// negative, and whose absolute value fits in size bytes.
+//
+// The exponentiation is done with safenum, with a size fixed by the bound on
+// the exponent, rather than the exponent itself.
+func expSecret(base, e *big.Int, m *safenum.Modulus, size int) *big.Int {
+	abs := make([]byte, size)
+	new(big.Int).Abs(e).FillBytes(abs)
+	b := new(safenum.Nat).SetBytes(base.Bytes())
+	out := new(safenum.Nat).Exp(b, new(safenum.Nat).SetBytes(abs), m)
+	inv := new(safenum.Nat).ModInverse(out, m)
+	outBytes := out.Bytes()
+	negative := 0
+	if e.Sign() < 0 {
+		negative = 1
+	}
+	subtle.ConstantTimeCopy(negative, outBytes, inv.Bytes())
+	return new(big.Int).SetBytes(outBytes)
+}
+
+// bigFromNat converts a public Nat to a big.Int.
+func bigFromNat(x *safenum.Nat) *big.Int {
+	return new(big.Int).SetBytes(x.Bytes())
+}
+
+// natFromBig converts a big.Int to a Nat.
+func natFromBig(x *big.Int) *safenum.Nat {
+	return new(safenum.Nat).SetBytes(x.Bytes())
+}
+
+// inUnits reports whether 0 < x < n, and gcd(x, n) = 1.
+func inUnits(x, n *big.Int) bool {
+	if x == nil || x.Sign() <= 0 || x.Cmp(n) >= 0 {
+		return false
+	}
+	return new(big.Int).GCD(nil, nil, x, n).Cmp(big.NewInt(1)) == 0
+}
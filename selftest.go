@@ -0,0 +1,468 @@
+package ctcrypto
+
+import (
+	"bytes"
+	"crypto"
+	stdelliptic "crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/dsa"
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"github.com/cronokirby/ctcrypto/mlkem"
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/ctcrypto/slhdsa"
+	"github.com/cronokirby/ctcrypto/xeddsa"
+	"github.com/cronokirby/safenum"
+)
+
+// SelfTestResult is the outcome of one of the tests run by SelfTest.
+type SelfTestResult struct {
+	// Algorithm names the algorithm tested, like "ECDH P-256".
+	Algorithm string
+	// Err is nil if the test passed.
+	Err error
+}
+
+// SelfTest runs known-answer tests in the style of the power-on self-tests of
+// FIPS 140, and returns the result of every test. It covers ECDH over P-256,
+// P-384, P-521, and X25519, Ed25519 key derivation, ECDSA, RSA PKCS #1 v1.5,
+// OAEP, and PSS, DSA, ML-KEM, SLH-DSA, and XEdDSA, but none of the other
+// packages of this module.
+//
+// Deterministic operations, like key agreement, decryption, or RSA PKCS #1 v1.5
+// signing, are checked against fixed outputs. Randomized signatures are checked
+// by verifying a fixed signature, and by signing and verifying a message, like
+// the pairwise consistency tests of FIPS 140.
+//
+// The error describes the first test which failed, if any, in which case the
+// module shouldn't be used.
+func SelfTest() ([]SelfTestResult, error) {
+	var results []SelfTestResult
+	var first error
+	record := func(algorithm string, err error) {
+		results = append(results, SelfTestResult{algorithm, err})
+		if err != nil && first == nil {
+			first = errors.New("ctcrypto: self-test of " + algorithm + " failed: " + err.Error())
+		}
+	}
+	for i := range ecdhKATs {
+		record("ECDH "+ecdhKATs[i].name, ecdhKATs[i].run())
+	}
+	record("X25519", x25519KAT())
+	record("Ed25519 key derivation", ed25519KAT())
+	record("ECDSA P-256", ecdsaKAT())
+	record("RSA PKCS #1 v1.5", rsaKAT())
+	record("RSA OAEP", rsaOAEPKAT())
+	record("RSA PSS", rsaPSSKAT())
+	record("DSA", dsaKAT())
+	record("ML-KEM-768", mlkemKAT())
+	record("SLH-DSA-SHA2-128f", slhdsaKAT())
+	record("XEdDSA", xeddsaPCT())
+	return results, first
+}
+
+var errWrongAnswer = errors.New("wrong answer")
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+type ecdhKAT struct {
+	name                    string
+	curve                   ecdh.Curve
+	priv, pub, peer, shared string
+}
+
+// ecdhKATs were generated with crypto/ecdh.
+var ecdhKATs = []ecdhKAT{
+	{
+		name:  "P-256",
+		curve: ecdh.P256(),
+		priv:  "49ffc0158a15dd2d039e9215b444600fda095781954f7e17581d4cf7de1a5132",
+		pub: "04a4ae935488e07f72496622dde0b971cb4f8793f6d0b37fc40704b8904fb453" +
+			"0b6313414178a810fc5c35b7e455d8718cd2f950f16924e10d56169d29a6bbea" +
+			"f5",
+		peer: "047f8aa6d5077b5f949bdc1b458498c0f394a939855301813178955cc6dc6d44" +
+			"080ed8d0be4abba406b08b3c120edd0c314e80e3df6eb19d606298f4ec563c4d" +
+			"0f",
+		shared: "0408d08c8702ba887239432c4851d06d0b345412117889057d7b9f9d02b3db9b",
+	},
+	{
+		name:  "P-384",
+		curve: ecdh.P384(),
+		priv: "5a0b1d9269f5d1ceb3febb18caf931468dc05239b8f01ad287a5fd28a7ffa626" +
+			"897640fb55a1d579631fefe8b504cfb7",
+		pub: "0457e8c8bc5e0e0a42f97a434e304a3072cbbdd10b3a22df470a3847ac4beffe" +
+			"8bd93670dfd3f0b462c73bfd4972e894f93cf9cb15166b0af7c3c98ad6ecb87e" +
+			"4da2ff04f2f0c13dda5178d7e4220377f44ddde77f8019b3559ef22a37638aa4" +
+			"44",
+		peer: "04016cf950132db2ed043b41683f0448df4f7e654e7d632a4251cd471c905b59" +
+			"0df4d60b656889cd63baa8d294bb1b799753c0d6ee4f7c0cf87e2923be8975cc" +
+			"2ea2ecc07190f3d2eb4d71717c370b29721472181d35288edfa58349ebb7517f" +
+			"3c",
+		shared: "9c410b900876bfc1f012c659f0f3a8c1bdb4455c7b1879bfed8cf6e065f5ce35" +
+			"c25ca1d9881759b5b546e3e72352e475",
+	},
+	{
+		name:  "P-521",
+		curve: ecdh.P521(),
+		priv: "00ad96956049b46753e16db041243b1f86a05becc253b0862791a2448c50daed" +
+			"88c7c4d31e639ac9b357642db2a968382dcce20c0a7fd83ec224efd17087a46d" +
+			"ca17",
+		pub: "040029f17f1d6dcab4683bb7387d5c9eaf4c4dd6ae3fa0a541744d0cc54c9001" +
+			"631cae214b620e491b944ba0fa226cd6763bf805a9edabe3d0f2ecea4fae432e" +
+			"c3e0e801f404630fb00a50e2b1e54dbf7a2e6d007ffdebfc95ef76040766aaaa" +
+			"3fa59db76f7c49f08f51d32497324bfe149a0314719abddbc17c75b63feb6b74" +
+			"f311e9fec0",
+		peer: "0401429771fd7a11c31129ad696a89ab302dca0be439de14b7a658c92fb5be13" +
+			"5291479a8619ce3d809112a61cae82efd7d58d6348c2122fbf300d6c577bc6ad" +
+			"521e3c01341d65f0c0623d42458c97ffd97fc07d98eb0344a02ad92d3f789980" +
+			"1556c300e4e0717268a0ef6b46da28fffa8f21b9e701003f336bcaf622d02443" +
+			"b60d93d97e",
+		shared: "003d369313fac584de155c60eca4a62983b906a5ad40c5f6fa1ddb974ef8fc07" +
+			"8387bf533d9e8070a97e21bf536118c92a75243761478504c6b898784bbe3836" +
+			"4bda",
+	},
+}
+
+func (c *ecdhKAT) run() error {
+	priv, err := c.curve.NewPrivateKey(mustHex(c.priv))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(priv.PublicKey().Bytes(), mustHex(c.pub)) {
+		return errWrongAnswer
+	}
+	peer, err := c.curve.NewPublicKey(mustHex(c.peer))
+	if err != nil {
+		return err
+	}
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(shared, mustHex(c.shared)) {
+		return errWrongAnswer
+	}
+	return nil
+}
+
+// x25519KAT uses the test vector of RFC 7748, section 6.1.
+func x25519KAT() error {
+	return (&ecdhKAT{
+		curve:  ecdh.X25519(),
+		priv:   "77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a",
+		pub:    "8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a",
+		peer:   "de9edb7d7b7dc1b4d35b61c2ece435373f8343c85b78674dadfc7e146f882b4f",
+		shared: "4a5d9d5ba4ce2de1728e3bf480350f25e07e21c947d19e3376f09b3c1e161742",
+	}).run()
+}
+
+// ed25519KAT derives the public key of the first test vector of RFC 8032,
+// section 7.1.
+func ed25519KAT() error {
+	h := sha512.Sum512(mustHex("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"))
+	s, err := edwards25519.ScalarFromClampedBytes(h[:32])
+	if err != nil {
+		return err
+	}
+	A := new(edwards25519.Point).ScalarBaseMult(s)
+	if !bytes.Equal(A.Bytes(), mustHex("d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")) {
+		return errWrongAnswer
+	}
+	return nil
+}
+
+// ecdsaKAT verifies a signature of SHA-256("abc") generated with
+// crypto/ecdsa, and then signs and verifies that digest.
+func ecdsaKAT() error {
+	priv := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: stdelliptic.P256(),
+			X:     new(big.Int).SetBytes(mustHex("bc8680b8890ff103a3cd5d7858487ef3af42105e6f26d1348fae1386d8bd7edb")),
+			Y:     new(big.Int).SetBytes(mustHex("2fe17f9f2009b3b3682738a7ac556985003a8574ee6a8c9d9e4edb6c5b725ae8")),
+		},
+		D: new(big.Int).SetBytes(mustHex("1dbfc8a6e1a35867c44ae581666a814647eaa20c043fe5fac9cf5150894dbfe3")),
+	}
+	digest := sha256.Sum256([]byte("abc"))
+	r := new(big.Int).SetBytes(mustHex("d29eb9c4aec4d91fdfedaaffe6f829019b71cca357aad93935bfdc40a97ca6af"))
+	s := new(big.Int).SetBytes(mustHex("2428350d2099fe5db8a462efa047cb4f97b9c169aa0dbb2f3a614703a4c1162a"))
+	if !ecdsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		return errWrongAnswer
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+	if err != nil {
+		return err
+	}
+	if !ecdsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		return errors.New("pairwise consistency test failed")
+	}
+	return nil
+}
+
+// selfTestRSAKey returns the 2048 bit key of the RSA tests.
+func selfTestRSAKey() *rsa.PrivateKey {
+	priv := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: safenum.ModulusFromBytes(mustHex(
+				"bec47fcaa91f282ccfef705c0075f914a6598f11faad7ec476469c830072b89a" +
+					"0ac77d3b496f155d544356c24befc7bea60d6ec4249724861971355cd518c073" +
+					"ab79e90820d5d4971570e78a63ad25dc4d2e555dba93bd13c28886d1434dfeb4" +
+					"d151c03de552620976ddd78b0ba4d55aa6515d8f07df70422043fc926c0de02d" +
+					"0156d0bbcd96127a05e841696fc03e167af2019ea8bbf9de587fc84edeacf825" +
+					"023294ef4f6805540043aeaac375556187e875c81a942118b63f5161e56d8cfe" +
+					"b5d184cd14a3c4918f72bcbed4c253743cefd918ef9d99fab68dbb26e55e66d6" +
+					"16432b5b516669913670bc12f4af2980caa2a3488ea328da037ee1c3d314ad79",
+			)),
+			E: 65537,
+		},
+		D: new(safenum.Nat).SetBytes(mustHex(
+			"3c222fd03fd868ef1b4ab445e4054c1354cea5593b064690026286c28ab91ef4" +
+				"bbd21f69396031415ea6ed69c8cb18514201b8f94e3970936ee0af3c08964950" +
+				"0ced1c666a074d80a48b17099a1b01f4bc458f3640f2822700eacd68988934f1" +
+				"658c1a07c8ca5bc9f4f4c0157e01a3197494f0637e4d52131e1da037bda4f015" +
+				"64166203d631fdb6efe21704032373b719822030e88898bb4c53643637b2e27d" +
+				"d4e1091073ea3a82a91941a8809d274ba6d1846aba3d9ea8599cfe0901263546" +
+				"368342a08a92e0f8514ec4a0dac5c2f4d97c43474275d636307e9e56c7ef2d07" +
+				"fa8d4d76facd935db4fb6bf8c5080551e27832439a3aaa6fad3451285090a8e3",
+		)),
+		Primes: []*safenum.Nat{
+			new(safenum.Nat).SetBytes(mustHex(
+				"ced42a2c199944b34b4ab0c08c7233ae3a953d93e8f2367069d439d16d799641" +
+					"a15789be1253cdc6ba2373dd388b19245ff1aa2c9301e891fdd91bf024230dd3" +
+					"c5be5251913a4af5a29f8e1907ba1d170abb4c2524aab2346eea565c325907df" +
+					"5b63a35688e18dfe9111116676cafeeb94bd918187529d4451a0ca57ce0d096f",
+			)),
+			new(safenum.Nat).SetBytes(mustHex(
+				"ec1ed4f44949d3ce4e3296147793537b7009ab36d64c5278b4d8c4b0f4f1e695" +
+					"ce5a5a837d9ed41d19aa198ee43c0acc18a9fe3837daf9b7c21dd4dc7766aeeb" +
+					"d724836601292762c1e44d73dff26fd17235623fcd638d7adda54ddc95711b7c" +
+					"caae8ed6444099bb0d4dc2c61b2132e1662c4a1ec1fd91008314e72a782d3397",
+			)),
+		},
+	}
+	priv.Precompute()
+	return priv
+}
+
+// rsaKAT signs SHA-256("abc") with a 2048 bit key, and compares the result
+// with that of crypto/rsa.
+func rsaKAT() error {
+	priv := selfTestRSAKey()
+	digest := sha256.Sum256([]byte("abc"))
+	sig, err := rsa.SignPKCS1v15(priv, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(sig, mustHex(
+		"54b2a9951e9bff7d6c840453eee5e725f00edf85221bee8a7fee78b74468c718"+
+			"63f48381be309583a8a7ae8324f168892fee8cbb61731749fa2c052cf42c3e7a"+
+			"fb4d51cc2c82cc7b7b96ae3e185c95ab02b0a44089597bdb39597fcadb5b9955"+
+			"5f516f80219ec0f61a742e2346ad55e67dcf522b2a316f8e29eea87289869c2b"+
+			"6d7b65587fa29cc489edff24a32a7d89af2cea799e0130bc841b41ea62412fee"+
+			"f493ce95d94420cb2adc15e3db5243c218a19b79120dbaf16c774b6dae61912c"+
+			"157909ca14f11fb16b15f96d04122a86261142f74b6d9d00f263e3724bfc3302"+
+			"97bbab14bf44fea3b3d0c300538fb6bfec9699bdfe7c8c7305715a3dd7af3f19",
+	)) {
+		return errWrongAnswer
+	}
+	return rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, digest[:], sig)
+}
+
+// rsaOAEPKAT decrypts "abc", encrypted with OAEP and SHA-256 by crypto/rsa.
+func rsaOAEPKAT() error {
+	plaintext, err := rsa.DecryptOAEP(sha256.New(), selfTestRSAKey(), mustHex(
+		"86a04a8901a2fe8fe0323a42b21938aba5f8041bff6b9c22509095056d695b2a"+
+			"2df26197fc7e5bebce1ffc7a8e9be1998171fc3533702561e819e13a95250f21"+
+			"8522ec7ae82a2a72bbb6e6d344c2f7178fff0d1606e800a77b2490041359f17b"+
+			"6e73e5b130fe94df083ffd62f182d3991e25ef13dcfbbcac132dc1c4c414c98f"+
+			"50d17e3f095e49363ad590be3871f6bfe4928b6f01f507528941502af5501ed4"+
+			"10b87356a79d87a804205e515c30d2707f00c1e5a518e2562a7e6beb6ab46117"+
+			"906f36efac040d07e5d4d3e0de53a82232d87cdd9bbf315c94d683dcfe26f717"+
+			"3f2479dc2d432e43e350c84a585962a2fe14a54c85c8a5c339dfa088dceda934",
+	), nil)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(plaintext, []byte("abc")) {
+		return errWrongAnswer
+	}
+	return nil
+}
+
+// rsaPSSKAT verifies a PSS signature of SHA-256("abc") generated with
+// crypto/rsa, and then signs and verifies that digest.
+func rsaPSSKAT() error {
+	priv := selfTestRSAKey()
+	digest := sha256.Sum256([]byte("abc"))
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+	if err := rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], mustHex(
+		"b0a5464c0286a1ad39d9aa31182b5d3a16988c1a97de7f375ae1f7a38bbe8def"+
+			"3aaecee6ae0419ffe1d255992387440424e60b2ef5c67823d54d51f84c008800"+
+			"fb6bae2f932bdec0311141ea54523c5026e4b88dbed52c429ca412c026028242"+
+			"cb7c1cd374baad952ed80f6ebafab4922a8fac5926d40bfdf29d46d1bd5c90ca"+
+			"2d65c93b99c1d218389149dfe3a7cf29fcffed66ded02093e2b492362d1f1bdd"+
+			"76fe07fda92dc7be05d8fc52ae9018d34be19aab47db87105daff3bbf0e27e37"+
+			"cc1bf0539291763be1d60284057ca0b04fcd247296ecf6ac910296cec24d3d6e"+
+			"2f0ae97940e926cea9e74ba2d59cc83e29b50b272b0581ae5430654363e94a68",
+	), opts); err != nil {
+		return err
+	}
+	sig, err := rsa.SignPSS(rand.Reader, priv, crypto.SHA256, digest[:], opts)
+	if err != nil {
+		return err
+	}
+	if rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, digest[:], sig, opts) != nil {
+		return errors.New("pairwise consistency test failed")
+	}
+	return nil
+}
+
+// dsaKAT verifies a signature of SHA-256("abc") generated with crypto/dsa,
+// with an L = 2048, N = 256 key, and then signs and verifies that digest
+// deterministically.
+func dsaKAT() error {
+	priv := &dsa.PrivateKey{
+		PublicKey: dsa.PublicKey{
+			Parameters: dsa.Parameters{
+				P: new(big.Int).SetBytes(mustHex(
+					"adbf65cc9846a8ae18981acc45df9ce05acd69ac62eb75a2d7ca21c0f0356eb4" +
+						"04af40581094468f9eb7ad3c802d7652ed43bb89775c6df37eb6a78018744254" +
+						"9cc9973975c54851d7b6a47d0606d8e969f98198a18f14c96f526833ac46173b" +
+						"54634acaeaea6760c3744ad03e40ee1ea5faad238a4c6397b8e9afb96da95ab9" +
+						"79889834f3ad4c3ebee15d10d763add3001b8db4fbd482079c7d2e57399038b7" +
+						"a5790705c913af25fcb1a2b67c896af6303d654d2ffa9094824880248890d5dc" +
+						"1f2d147b95bc8b7011540e914496850149e7f651db58eb5a8a6c1e489c5337f4" +
+						"355ba11cb408d9c1232b55bff3a93c8b79a3f0397f143bf6c8033ea69e4304a1",
+				)),
+				Q: new(big.Int).SetBytes(mustHex("b5182873a03b847953998c7d5a84c4cf82d8c68f66139b6f8d35d23ae75880f5")),
+				G: new(big.Int).SetBytes(mustHex(
+					"529af652f3b8ba85dcd28df50cda89527c8510570cbf38a6732b1727ca1cd199" +
+						"3fefe749638df4e47fc4041ee59ca19c23b7dce5d81b67144a81932776b8be49" +
+						"71ae174f213d79ab1aa467c591ad421552ab7e2bffeefd52201a97a903ac6232" +
+						"5e2f32b04f66598cd1c0e4e2dc3e0e435aa246b896c1fcba327658c3c40cfba4" +
+						"4d51187d0ebd02f907854247b615a2b88c1bfd1899c259214884e54039c9e554" +
+						"ce57a79fe7f557169e48497e96c094d274c288b2b03affffeca48eb21511243d" +
+						"c4f501a905288b1a8d136450ff93d4f7098a28530ad47f2d2cf04fdb0bed0551" +
+						"8c3536c259f0d1b1e01ceeee4ac5c19f0dff5b42b22f1221eb5c46ac1243fe59",
+				)),
+			},
+			Y: new(big.Int).SetBytes(mustHex(
+				"69e0ad42453dbd9038484b31033a1d8c526ff5a0f9c7888447ef61e5908b4d5a" +
+					"b6220b802955680ec5ac2c06d5266b3bdd9037379f31aaf6dbef16e536bdd0ad" +
+					"c55dd92f9ad4ddf3918c421b09e263cca8654284ff83dadec61a6e1ea8042fb7" +
+					"5abe928f2ed36bddf74fcd1b9079d92481cdd14f65b86060d9686559687b9380" +
+					"2fc0a1e0c91d2218d59d21bc5f079daf2f8553325c21e5b3d2e6b1991d0c15ae" +
+					"db2bd2c3a74cbf678c231f8aa35a813ac9490b5c08eea3ede5b1ab2e221a91d8" +
+					"853027f947612883f45da3d3db1286bda090eacfd6e6cade7c2b014ed126e454" +
+					"3addc475e3c5f37677b828b40c3ba74b339437df3b1ebf7f4ae2f414bf254674",
+			)),
+		},
+		X: new(safenum.Nat).SetBytes(mustHex("a785a3c72e1268a12998baba274e482be9e15fcc8215ed06544d256c4ed888d8")),
+	}
+	digest := sha256.Sum256([]byte("abc"))
+	r := new(big.Int).SetBytes(mustHex("247a8a75f464fc95578015d6853af9fb5b90e607d85758abe0a3cea28bc365ad"))
+	s := new(big.Int).SetBytes(mustHex("267feb9164a1e7ab28ca11fa9a78b7ef3a432c3974e43c52f10aa1ee5869b33b"))
+	if !dsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		return errWrongAnswer
+	}
+	r, s, err := dsa.SignDeterministic(priv, crypto.SHA256, digest[:])
+	if err != nil {
+		return err
+	}
+	if !dsa.Verify(&priv.PublicKey, digest[:], r, s) {
+		return errors.New("pairwise consistency test failed")
+	}
+	return nil
+}
+
+// mlkemKAT derives an ML-KEM-768 key from the seed 00 01 ... 3f, encapsulates
+// with the message 40 41 ... 5f, and decapsulates the result. The answers were
+// checked with crypto/mlkem.
+func mlkemKAT() error {
+	seed := make([]byte, 64)
+	m := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	for i := range m {
+		m[i] = byte(0x40 + i)
+	}
+	dk, err := mlkem.MLKEM768().NewDecapsulationKey(seed)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(dk.EncapsulationKey().Bytes())
+	if !bytes.Equal(h[:], mustHex("0b7934c83125c788995e2ba6bd761e33046b3e40571be53e023309a29f398cc9")) {
+		return errWrongAnswer
+	}
+	sharedKey, ciphertext, err := dk.EncapsulationKey().Encapsulate(bytes.NewReader(m))
+	if err != nil {
+		return err
+	}
+	h = sha256.Sum256(ciphertext)
+	if !bytes.Equal(h[:], mustHex("dbf4e9aa48b078ad46ec1c9c47bda8c2d2fec9d0e7a21bd48d2238a2abedb856")) ||
+		!bytes.Equal(sharedKey, mustHex("9cddd089ffe70e3996e76f7c8d06746df34d07e8657bc0fcf2bb0e1c3084aea1")) {
+		return errWrongAnswer
+	}
+	decapsulated, err := dk.Decapsulate(ciphertext)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(decapsulated, sharedKey) {
+		return errWrongAnswer
+	}
+	return nil
+}
+
+// slhdsaKAT signs deterministically with SLH-DSA-SHA2-128f, using a vector
+// generated with OpenSSL, from slhdsa/testdata.
+func slhdsaKAT() error {
+	p, err := slhdsa.ParamsByName("SLH-DSA-SHA2-128f")
+	if err != nil {
+		return err
+	}
+	// SK.seed || SK.prf || PK.seed || PK.root
+	priv, err := p.NewPrivateKey(mustHex(
+		"f49004a3f3b64df63cbdfebe0e485614d3f680746d1a26ee8547f57b2eb375c1" +
+			"f419eeae1d9a3a16da88b4e734aeb0fca22824a073ed146e29e3f6d06a655898",
+	))
+	if err != nil {
+		return err
+	}
+	message := mustHex("91095356d3d4dcc770ab6467dbff0ee776bb4ec5514f9eaab6af7b731eccc2305e")
+	sig, err := priv.Sign(nil, message, nil)
+	if err != nil {
+		return err
+	}
+	h := sha256.Sum256(sig)
+	if !bytes.Equal(h[:], mustHex("b2ff2739c77db1583e6c9dd3502d3cb95aed19f21ea8c14bf3ef6ce285d70cfc")) {
+		return errWrongAnswer
+	}
+	return priv.Public().Verify(message, sig, nil)
+}
+
+// xeddsaPCT signs and verifies a message, with the X25519 key of RFC 7748.
+func xeddsaPCT() error {
+	priv := mustHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a")
+	pub := mustHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a")
+	sig, err := xeddsa.Sign(priv, []byte("abc"), rand.Reader)
+	if err != nil {
+		return err
+	}
+	if !xeddsa.Verify(pub, []byte("abc"), sig) {
+		return errors.New("pairwise consistency test failed")
+	}
+	return nil
+}
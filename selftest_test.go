@@ -0,0 +1,36 @@
+package ctcrypto
+
+import "testing"
+
+func TestSelfTest(t *testing.T) {
+	results, err := SelfTest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(ecdhKATs)+10 {
+		t.Errorf("got %d results", len(results))
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Algorithm, r.Err)
+		}
+	}
+}
+
+func TestSelfTestFailure(t *testing.T) {
+	saved := ecdhKATs[0].shared
+	defer func() { ecdhKATs[0].shared = saved }()
+	ecdhKATs[0].shared = "00" + saved[2:]
+	results, err := SelfTest()
+	if err == nil {
+		t.Fatal("wrong answer not detected")
+	}
+	if results[0].Err != errWrongAnswer {
+		t.Errorf("first result has error %v", results[0].Err)
+	}
+	for _, r := range results[1:] {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Algorithm, r.Err)
+		}
+	}
+}
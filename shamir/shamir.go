@@ -0,0 +1,191 @@
+// Package shamir implements Shamir's secret sharing over the integers modulo
+// a prime, such as the order of an elliptic curve.
+//
+// All of the arithmetic on secret values is done with safenum, and none of the
+// functions of this package branch on secret data, so that the secret, or the
+// shares, can't be learned by timing share generation or reconstruction.
+//
+// The slip39 package shares the master seeds of wallets as mnemonics instead,
+// with Shamir's secret sharing over GF(256), for backups kept by people.
+package shamir
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/safenum"
+)
+
+// Share is the share of a participant, which is the evaluation of a secret
+// polynomial at the participant's identifier.
+type Share struct {
+	// ID is the identifier of the participant, which must not be 0.
+	ID uint32
+	// Value is the evaluation of the polynomial at ID, reduced modulo the prime.
+	Value *safenum.Nat
+}
+
+var (
+	errInvalidThreshold = errors.New("shamir: threshold must be between 1 and the number of participants")
+	errNoShares         = errors.New("shamir: no shares to combine")
+	errInvalidID        = errors.New("shamir: participant identifiers must be distinct and non-zero")
+)
+
+// randomNat returns a uniformly random integer modulo m.
+func randomNat(m *safenum.Modulus, rand io.Reader) (*safenum.Nat, error) {
+	// Reducing 128 more bits than the size of the modulus makes the bias negligible.
+	buf := make([]byte, (int(m.BitLen())+7)/8+16)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, err
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(buf), m), nil
+}
+
+// idNat returns the identifier of a participant, as an integer modulo m.
+func idNat(id uint32, m *safenum.Modulus) *safenum.Nat {
+	x := new(safenum.Nat).SetUint64(uint64(id))
+	return x.Mod(x, m)
+}
+
+// Polynomial is a secret polynomial with coefficients modulo a prime, whose
+// constant term is the shared secret.
+type Polynomial struct {
+	m            *safenum.Modulus
+	coefficients []*safenum.Nat
+}
+
+// RandomPolynomial returns a random polynomial with threshold coefficients,
+// whose constant term is secret, reading randomness from rand.
+//
+// Any threshold evaluations of this polynomial determine the secret, while
+// fewer evaluations reveal nothing about it.
+func RandomPolynomial(m *safenum.Modulus, secret *safenum.Nat, threshold int, rand io.Reader) (*Polynomial, error) {
+	if threshold < 1 {
+		return nil, errInvalidThreshold
+	}
+	coefficients := make([]*safenum.Nat, threshold)
+	coefficients[0] = new(safenum.Nat).Mod(secret, m)
+	for i := 1; i < threshold; i++ {
+		c, err := randomNat(m, rand)
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	return &Polynomial{m: m, coefficients: coefficients}, nil
+}
+
+// Coefficients returns the coefficients of the polynomial, starting with the
+// constant term.
+func (p *Polynomial) Coefficients() []*safenum.Nat {
+	return append([]*safenum.Nat{}, p.coefficients...)
+}
+
+// Evaluate returns the value of the polynomial at x.
+//
+// This uses Horner's method, whose execution time only depends on the number
+// of coefficients.
+func (p *Polynomial) Evaluate(x *safenum.Nat) *safenum.Nat {
+	m := p.m
+	x = new(safenum.Nat).Mod(x, m)
+	out := new(safenum.Nat).Mod(new(safenum.Nat), m)
+	for i := len(p.coefficients) - 1; i >= 0; i-- {
+		out.ModMul(out, x, m)
+		out.ModAdd(out, p.coefficients[i], m)
+	}
+	return out
+}
+
+// Share returns the share of the participant with a given identifier, which
+// must not be 0.
+func (p *Polynomial) Share(id uint32) Share {
+	return Share{ID: id, Value: p.Evaluate(idNat(id, p.m))}
+}
+
+// Split splits a secret into n shares, for participants with identifiers 1
+// through n, such that any threshold of them can reconstruct the secret.
+func Split(m *safenum.Modulus, secret *safenum.Nat, threshold, n int, rand io.Reader) ([]Share, error) {
+	if threshold < 1 || threshold > n || uint64(n) > 0xffffffff {
+		return nil, errInvalidThreshold
+	}
+	p, err := RandomPolynomial(m, secret, threshold, rand)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = p.Share(uint32(i + 1))
+	}
+	return shares, nil
+}
+
+// LagrangeCoefficients returns the Lagrange coefficients for interpolating
+// the value at x of a polynomial from its values at ids, i.e.
+//
+//	λ_i = ∏_{j ≠ i} (x - x_j) / (x_i - x_j)
+//
+// Multiplying the share of a participant by its coefficient converts it into
+// an additive share of the value at x, among the participants in ids. With
+// x = 0, this gives additive shares of the secret.
+//
+// An error is returned if the identifiers are not distinct and non-zero. This
+// condition is checked without branching on the identifiers, by noticing that
+// the product of all the identifiers and denominators vanishes in that case,
+// and the coefficients are computed in constant-time.
+func LagrangeCoefficients(m *safenum.Modulus, ids []uint32, x uint32) ([]*safenum.Nat, error) {
+	if len(ids) == 0 {
+		return nil, errNoShares
+	}
+	xs := make([]*safenum.Nat, len(ids))
+	for i, id := range ids {
+		xs[i] = idNat(id, m)
+	}
+	at := idNat(x, m)
+	one := idNat(1, m)
+	all := new(safenum.Nat).SetNat(one)
+	out := make([]*safenum.Nat, len(ids))
+	for i := range xs {
+		num := new(safenum.Nat).SetNat(one)
+		den := new(safenum.Nat).SetNat(one)
+		for j := range xs {
+			if j == i {
+				continue
+			}
+			num.ModMul(num, new(safenum.Nat).ModSub(at, xs[j], m), m)
+			den.ModMul(den, new(safenum.Nat).ModSub(xs[i], xs[j], m), m)
+		}
+		all.ModMul(all, xs[i], m)
+		all.ModMul(all, den, m)
+		out[i] = num.ModMul(num, new(safenum.Nat).ModInverse(den, m), m)
+	}
+	if all.EqZero() {
+		return nil, errInvalidID
+	}
+	return out, nil
+}
+
+// Combine reconstructs the secret from a set of shares, using Lagrange
+// interpolation.
+//
+// At least threshold shares are needed to recover the right secret. With fewer
+// shares, the output is unrelated to the secret, which can't be detected by
+// this function.
+func Combine(m *safenum.Modulus, shares []Share) (*safenum.Nat, error) {
+	if len(shares) == 0 {
+		return nil, errNoShares
+	}
+	ids := make([]uint32, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	lambdas, err := LagrangeCoefficients(m, ids, 0)
+	if err != nil {
+		return nil, err
+	}
+	secret := new(safenum.Nat).Mod(new(safenum.Nat), m)
+	for i, share := range shares {
+		term := new(safenum.Nat).ModMul(lambdas[i], share.Value, m)
+		secret.ModAdd(secret, term, m)
+	}
+	return secret, nil
+}
@@ -0,0 +1,134 @@
+package shamir
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/safenum"
+)
+
+// The order of P-256.
+var testModulus = safenum.ModulusFromBytes([]byte{
+	0xff, 0xff, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xbc, 0xe6, 0xfa, 0xad, 0xa7, 0x17, 0x9e, 0x84,
+	0xf3, 0xb9, 0xca, 0xc2, 0xfc, 0x63, 0x25, 0x51,
+})
+
+func TestSplitCombine(t *testing.T) {
+	secret, _ := randomNat(testModulus, rand.Reader)
+	shares, err := Split(testModulus, secret, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subsets := [][]Share{
+		shares[:3],
+		shares[2:],
+		{shares[4], shares[0], shares[2]},
+		shares,
+	}
+	for _, subset := range subsets {
+		out, err := Combine(testModulus, subset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Cmp(secret) != 0 {
+			t.Errorf("wrong secret reconstructed from %d shares", len(subset))
+		}
+	}
+	out, err := Combine(testModulus, shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) == 0 {
+		t.Errorf("secret reconstructed from too few shares")
+	}
+}
+
+func TestThresholdOne(t *testing.T) {
+	secret, _ := randomNat(testModulus, rand.Reader)
+	shares, err := Split(testModulus, secret, 1, 2, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Combine(testModulus, shares[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) != 0 {
+		t.Errorf("wrong secret reconstructed from a single share")
+	}
+}
+
+func TestCombineRejectsBadIDs(t *testing.T) {
+	secret, _ := randomNat(testModulus, rand.Reader)
+	shares, _ := Split(testModulus, secret, 2, 3, rand.Reader)
+	if _, err := Combine(testModulus, []Share{shares[1], shares[1]}); err == nil {
+		t.Errorf("accepted duplicate shares")
+	}
+	zero := Share{ID: 0, Value: shares[0].Value}
+	if _, err := Combine(testModulus, []Share{zero, shares[1]}); err == nil {
+		t.Errorf("accepted a share with identifier 0")
+	}
+	if _, err := Combine(testModulus, []Share{zero}); err == nil {
+		t.Errorf("accepted a single share with identifier 0")
+	}
+	if _, err := Combine(testModulus, nil); err == nil {
+		t.Errorf("accepted an empty set of shares")
+	}
+}
+
+func TestLagrangeCoefficients(t *testing.T) {
+	secret, _ := randomNat(testModulus, rand.Reader)
+	p, _ := RandomPolynomial(testModulus, secret, 3, rand.Reader)
+	ids := []uint32{2, 5, 7}
+	for _, x := range []uint32{0, 1, 5, 9} {
+		lambdas, err := LagrangeCoefficients(testModulus, ids, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := new(safenum.Nat).Mod(new(safenum.Nat), testModulus)
+		for i, id := range ids {
+			term := new(safenum.Nat).ModMul(lambdas[i], p.Share(id).Value, testModulus)
+			sum.ModAdd(sum, term, testModulus)
+		}
+		if sum.Cmp(p.Share(x).Value) != 0 {
+			t.Errorf("interpolating at %d doesn't match the polynomial", x)
+		}
+	}
+	if _, err := LagrangeCoefficients(testModulus, []uint32{3, 3}, 1); err == nil {
+		t.Errorf("accepted duplicate identifiers")
+	}
+	if _, err := LagrangeCoefficients(testModulus, []uint32{0, 1}, 1); err == nil {
+		t.Errorf("accepted identifier 0")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	secret := new(safenum.Nat).SetUint64(7)
+	p, err := RandomPolynomial(testModulus, secret, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Evaluate(new(safenum.Nat)).Cmp(new(safenum.Nat).Mod(secret, testModulus)) != 0 {
+		t.Errorf("polynomial doesn't evaluate to the secret at 0")
+	}
+	// p(1) is the sum of the coefficients
+	sum := new(safenum.Nat).Mod(new(safenum.Nat), testModulus)
+	for _, c := range p.Coefficients() {
+		sum.ModAdd(sum, c, testModulus)
+	}
+	if p.Share(1).Value.Cmp(sum) != 0 {
+		t.Errorf("p(1) isn't the sum of the coefficients")
+	}
+}
+
+func TestSplitInvalidThreshold(t *testing.T) {
+	secret := new(safenum.Nat).SetUint64(1)
+	if _, err := Split(testModulus, secret, 0, 3, rand.Reader); err == nil {
+		t.Errorf("accepted a threshold of 0")
+	}
+	if _, err := Split(testModulus, secret, 4, 3, rand.Reader); err == nil {
+		t.Errorf("accepted a threshold larger than the number of participants")
+	}
+}
@@ -0,0 +1,72 @@
+package slip39
+
+import "errors"
+
+// The shares are evaluations of polynomials over GF(256), represented as in
+// AES, modulo x^8 + x^4 + x^3 + x + 1. The reference implementation of SLIP-39
+// multiplies through tables of logarithms, which index memory with the secret
+// bytes, so the field is implemented here with shifts and masks instead.
+
+var errDuplicateIndex = errors.New("slip39: share indices must be distinct")
+
+// gfMul returns a * b in GF(256), in constant time.
+func gfMul(a, b byte) byte {
+	var out byte
+	for i := 0; i < 8; i++ {
+		out ^= a & -(b & 1)
+		b >>= 1
+		// Reduce by x^8 + x^4 + x^3 + x + 1 if the top bit was set.
+		a = a<<1 ^ 0x1b&-(a>>7)
+	}
+	return out
+}
+
+// gfInv returns the inverse of a in GF(256), a^254, or 0 if a is 0.
+func gfInv(a byte) byte {
+	// a^254 = a^(2 + 4 + 8 + 16 + 32 + 64 + 128).
+	out := byte(1)
+	sq := a
+	for i := 0; i < 7; i++ {
+		sq = gfMul(sq, sq)
+		out = gfMul(out, sq)
+	}
+	return out
+}
+
+// point is the value of a polynomial at x, for each byte of a share.
+type point struct {
+	x byte
+	y []byte
+}
+
+// interpolate returns the value at x of the polynomials of degree
+// len(points) - 1 going through points, which must have values of the same
+// length, and distinct x coordinates.
+//
+// The coordinates are public, and the values are only multiplied by the
+// Lagrange coefficients, in constant time.
+func interpolate(points []point, x byte) ([]byte, error) {
+	for i := range points {
+		for j := 0; j < i; j++ {
+			if points[i].x == points[j].x {
+				return nil, errDuplicateIndex
+			}
+		}
+	}
+	out := make([]byte, len(points[0].y))
+	for i, p := range points {
+		// λ_i = ∏_{j ≠ i} (x - x_j) / (x_i - x_j), subtraction being xor.
+		num, den := byte(1), byte(1)
+		for j, q := range points {
+			if j != i {
+				num = gfMul(num, x^q.x)
+				den = gfMul(den, p.x^q.x)
+			}
+		}
+		lambda := gfMul(num, gfInv(den))
+		for k := range out {
+			out[k] ^= gfMul(lambda, p.y[k])
+		}
+	}
+	return out, nil
+}
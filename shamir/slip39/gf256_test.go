@@ -0,0 +1,73 @@
+package slip39
+
+import (
+	"bytes"
+	"testing"
+)
+
+// slowMul multiplies by shifting and adding, branching on the operands.
+func slowMul(a, b byte) byte {
+	var out byte
+	for b != 0 {
+		if b&1 == 1 {
+			out ^= a
+		}
+		if a&0x80 != 0 {
+			a = a<<1 ^ 0x1b
+		} else {
+			a <<= 1
+		}
+		b >>= 1
+	}
+	return out
+}
+
+func TestGFMul(t *testing.T) {
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			if got, want := gfMul(byte(a), byte(b)), slowMul(byte(a), byte(b)); got != want {
+				t.Fatalf("%#x * %#x = %#x, want %#x", a, b, got, want)
+			}
+		}
+	}
+	// From FIPS 197, section 4.2.
+	if got := gfMul(0x57, 0x83); got != 0xc1 {
+		t.Errorf("0x57 * 0x83 = %#x, want 0xc1", got)
+	}
+}
+
+func TestGFInv(t *testing.T) {
+	if gfInv(0) != 0 {
+		t.Error("the inverse of 0 isn't 0")
+	}
+	for a := 1; a < 256; a++ {
+		if gfMul(byte(a), gfInv(byte(a))) != 1 {
+			t.Errorf("%#x * %#x isn't 1", a, gfInv(byte(a)))
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	// The polynomial 0x42 + 0x17 x + 0xa5 x^2, in each byte with another
+	// constant term.
+	eval := func(x byte) []byte {
+		y := make([]byte, 3)
+		for k := range y {
+			y[k] = byte(0x42+k) ^ gfMul(0x17, x) ^ gfMul(0xa5, gfMul(x, x))
+		}
+		return y
+	}
+	points := []point{{3, eval(3)}, {200, eval(200)}, {17, eval(17)}}
+	for _, x := range []byte{0, 1, 3, 254, 255} {
+		got, err := interpolate(points, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, eval(x)) {
+			t.Errorf("value at %d is %x, want %x", x, got, eval(x))
+		}
+	}
+	if _, err := interpolate(append(points, point{3, eval(3)}), 0); err != errDuplicateIndex {
+		t.Errorf("duplicate index returned %v", err)
+	}
+}
@@ -0,0 +1,561 @@
+// Package slip39 implements SLIP-39, which splits the master seed of a wallet
+// into mnemonic shares, lists of words which people can write down, with
+// Shamir's secret sharing over GF(256).
+//
+// Unlike the shamir package, which shares integers modulo a prime between the
+// participants of a protocol, this is meant for backups kept by people: the
+// shares are split in two levels, a threshold of groups being needed, each of
+// them recovered from a threshold of its members' shares, and every mnemonic
+// carries a checksum, which detects mistakes in copying it. The master secret
+// is encrypted with a passphrase before being split, and any passphrase
+// decrypts it, to a different secret, allowing for plausible deniability.
+//
+// The words of the mnemonics, and the values of the shares, are encoded and
+// decoded without branching on, or indexing memory with, secret data, but the
+// lengths of the words of a mnemonic are revealed by its own length.
+package slip39
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// radixBits is the number of bits encoded by a word.
+	radixBits = 10
+	// checksumWords is the number of words of the checksum.
+	checksumWords = 3
+	// headerWords is the number of words of the identifier, the iteration
+	// exponent, and the parameters of a share.
+	headerWords = 4
+	// minSecretSize is the size of the shortest master secret, of 128 bits.
+	minSecretSize = 16
+	// minWords is the length of the mnemonics of a 128 bit master secret.
+	minWords = headerWords + (8*minSecretSize+radixBits-1)/radixBits + checksumWords
+	// maxShares is the largest number of groups, or of members of a group.
+	maxShares = 16
+	// maxIterationExponent is the largest iteration exponent, of 4 bits.
+	maxIterationExponent = 15
+
+	// The values of the polynomials at secretIndex and digestIndex are the
+	// shared secret, and its digest.
+	secretIndex = 255
+	digestIndex = 254
+	digestSize  = 4
+
+	// The master secret is encrypted with a Feistel network of rounds
+	// rounds, using PBKDF2 with baseIterations << e iterations in total.
+	rounds         = 4
+	baseIterations = 10000
+)
+
+var (
+	errUnknownWord  = errors.New("slip39: unknown word in mnemonic")
+	errLength       = errors.New("slip39: invalid mnemonic length")
+	errChecksum     = errors.New("slip39: invalid mnemonic checksum")
+	errPadding      = errors.New("slip39: invalid mnemonic padding")
+	errParameters   = errors.New("slip39: invalid sharing parameters in mnemonic")
+	errMismatched   = errors.New("slip39: mnemonics don't belong to the same master secret")
+	errDigest       = errors.New("slip39: invalid digest of the shared secret")
+	errNoMnemonics  = errors.New("slip39: no mnemonics to combine")
+	errSecretSize   = errors.New("slip39: master secret must be at least 16 bytes, and of even length")
+	errPassphrase   = errors.New("slip39: passphrase must only contain printable ASCII characters")
+	errIterationExp = errors.New("slip39: iteration exponent must be between 0 and 15")
+)
+
+// Share is a share of a master secret, decoded from a mnemonic.
+type Share struct {
+	// Identifier is the random 15 bit identifier, common to all the shares of
+	// a master secret.
+	Identifier uint16
+	// Extendable reports whether the salt of the encryption of the master
+	// secret doesn't depend on the identifier, so that more shares of the same
+	// master secret, with another identifier, can be created later.
+	Extendable bool
+	// IterationExponent is e, the encryption using 10000 * 2^e iterations of
+	// PBKDF2.
+	IterationExponent int
+	// GroupIndex is the index of the group of the share, starting at 0.
+	GroupIndex int
+	// GroupThreshold is the number of groups needed to recover the secret.
+	GroupThreshold int
+	// GroupCount is the total number of groups.
+	GroupCount int
+	// MemberIndex is the index of the share within its group, starting at 0.
+	MemberIndex int
+	// MemberThreshold is the number of shares of the group needed to recover
+	// the share of the group.
+	MemberThreshold int
+	// Value is the value of the share, as long as the master secret.
+	Value []byte
+}
+
+// packedWords holds the words of wordlist, packed in little-endian words, to
+// be compared in constant time.
+var packedWords [len(wordlist)]uint64
+
+func init() {
+	for i, w := range wordlist {
+		for j := len(w) - 1; j >= 0; j-- {
+			packedWords[i] = packedWords[i]<<8 | uint64(w[j])
+		}
+	}
+}
+
+// ctEq64 returns 1 if a == b, and 0 otherwise.
+func ctEq64(a, b uint64) int {
+	d := a ^ b
+	return int(1 ^ (d|-d)>>63)
+}
+
+// wordIndex returns the index of word, ignoring case, and 1, or 0 and 0 if
+// word isn't in the list. Its time only depends on the length of word.
+func wordIndex(word string) (index, ok int) {
+	if len(word) < 4 || len(word) > 8 {
+		return 0, 0
+	}
+	var v uint64
+	for i := len(word) - 1; i >= 0; i-- {
+		c := word[i]
+		upper := subtle.ConstantTimeLessOrEq('A', int(c)) & subtle.ConstantTimeLessOrEq(int(c), 'Z')
+		v = v<<8 | uint64(c|byte(upper)<<5)
+	}
+	for i, p := range packedWords {
+		eq := ctEq64(p, v)
+		index |= i & -eq
+		ok |= eq
+	}
+	return index, ok
+}
+
+// word returns the word of the given index, selected among all the words in
+// constant time. Only the length of the word is revealed.
+func word(index int) string {
+	var v uint64
+	for i, p := range packedWords {
+		v |= p & -uint64(subtle.ConstantTimeEq(int32(i), int32(index)))
+	}
+	var b []byte
+	for ; v != 0; v >>= 8 {
+		b = append(b, byte(v))
+	}
+	return string(b)
+}
+
+// rsGenerator is the generator of the Reed-Solomon code over GF(1024) of the
+// checksum, as in the polymod function of SLIP-39.
+var rsGenerator = [10]uint32{
+	0xe0e040, 0x1c1c080, 0x3838100, 0x7070200, 0xe0e0009,
+	0x1c0c2412, 0x38086c24, 0x3090fc48, 0x21b1f890, 0x3f3f120,
+}
+
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i, g := range rsGenerator {
+			chk ^= g & -(b >> i & 1)
+		}
+	}
+	return chk
+}
+
+// customization returns the customization string of the checksum, which
+// tells extendable shares apart.
+func customization(extendable bool) []int {
+	s := "shamir"
+	if extendable {
+		s = "shamir_extendable"
+	}
+	values := make([]int, len(s))
+	for i := range s {
+		values[i] = int(s[i])
+	}
+	return values
+}
+
+// ParseShare decodes a mnemonic, of words separated by whitespace, checking
+// its checksum and its encoding, but not its value.
+func ParseShare(mnemonic string) (*Share, error) {
+	fields := strings.Fields(mnemonic)
+	if len(fields) < minWords {
+		return nil, errLength
+	}
+	words := make([]int, len(fields))
+	valid := 1
+	for i, f := range fields {
+		var ok int
+		words[i], ok = wordIndex(f)
+		valid &= ok
+	}
+	if valid != 1 {
+		return nil, errUnknownWord
+	}
+
+	extendable := words[1]>>4&1 == 1
+	if rs1024Polymod(append(customization(extendable), words...)) != 1 {
+		return nil, errChecksum
+	}
+	params := words[2]<<radixBits | words[3]
+	s := &Share{
+		Identifier:        uint16(words[0]<<5 | words[1]>>5),
+		Extendable:        extendable,
+		IterationExponent: words[1] & 15,
+		GroupIndex:        params >> 16,
+		GroupThreshold:    params>>12&15 + 1,
+		GroupCount:        params>>8&15 + 1,
+		MemberIndex:       params >> 4 & 15,
+		MemberThreshold:   params&15 + 1,
+	}
+	if s.GroupThreshold > s.GroupCount {
+		return nil, errParameters
+	}
+	value, err := decodeValue(words[headerWords : len(words)-checksumWords])
+	if err != nil {
+		return nil, err
+	}
+	s.Value = value
+	return s, nil
+}
+
+// decodeValue decodes the value of a share, whose bits are left-padded with
+// up to 8 zeros, to fill whole words.
+func decodeValue(words []int) ([]byte, error) {
+	bits := radixBits * len(words)
+	padding := bits % 16
+	if padding > 8 {
+		return nil, errLength
+	}
+	value := make([]byte, (bits-padding)/8)
+	var acc uint32
+	n, j := 0, 0
+	for i, w := range words {
+		acc = acc<<radixBits | uint32(w)
+		n += radixBits
+		if i == 0 {
+			if acc>>(radixBits-padding) != 0 {
+				return nil, errPadding
+			}
+			n -= padding
+		}
+		for ; n >= 8; j++ {
+			n -= 8
+			value[j] = byte(acc >> n)
+		}
+	}
+	return value, nil
+}
+
+// encodeValue appends the words encoding value to words.
+func encodeValue(words []int, value []byte) []int {
+	count := (8*len(value) + radixBits - 1) / radixBits
+	var acc uint32
+	// The padding is made of the leading zeros of acc.
+	n := radixBits*count - 8*len(value)
+	for _, b := range value {
+		acc = acc<<8 | uint32(b)
+		if n += 8; n >= radixBits {
+			n -= radixBits
+			words = append(words, int(acc>>n&(1<<radixBits-1)))
+		}
+	}
+	return words
+}
+
+// Mnemonic encodes the share as a mnemonic, of words separated by spaces. The
+// fields of the share must be valid, as returned by ParseShare or Split.
+func (s *Share) Mnemonic() string {
+	id := int(s.Identifier) & (1<<15 - 1)
+	ext := 0
+	if s.Extendable {
+		ext = 1
+	}
+	params := s.GroupIndex<<16 | (s.GroupThreshold-1)<<12 | (s.GroupCount-1)<<8 | s.MemberIndex<<4 | (s.MemberThreshold - 1)
+	words := []int{id >> 5, id&31<<5 | ext<<4 | s.IterationExponent&15, params >> radixBits, params & (1<<radixBits - 1)}
+	words = encodeValue(words, s.Value)
+	chk := rs1024Polymod(append(append(customization(s.Extendable), words...), 0, 0, 0)) ^ 1
+	words = append(words, int(chk>>20), int(chk>>10&1023), int(chk&1023))
+
+	mnemonic := make([]string, len(words))
+	for i, w := range words {
+		mnemonic[i] = word(w)
+	}
+	return strings.Join(mnemonic, " ")
+}
+
+// crypt encrypts or decrypts the master secret with the Feistel network of
+// SLIP-39, whose round function is PBKDF2 with HMAC-SHA-256, keyed by the
+// passphrase.
+func crypt(secret, passphrase []byte, s *Share, decrypt bool) []byte {
+	half := len(secret) / 2
+	l := append([]byte{}, secret[:half]...)
+	r := append([]byte{}, secret[half:]...)
+	var salt []byte
+	if !s.Extendable {
+		salt = []byte{'s', 'h', 'a', 'm', 'i', 'r', byte(s.Identifier >> 8), byte(s.Identifier)}
+	}
+	iterations := (baseIterations << s.IterationExponent) / rounds
+	for k := 0; k < rounds; k++ {
+		i := k
+		if decrypt {
+			i = rounds - 1 - k
+		}
+		password := append([]byte{byte(i)}, passphrase...)
+		f := pbkdf2.Key(password, append(append([]byte{}, salt...), r...), iterations, len(r), sha256.New)
+		for j := range l {
+			l[j] ^= f[j]
+		}
+		zero(f)
+		l, r = r, l
+	}
+	out := append(r, l...)
+	zero(l)
+	return out
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// digest returns the digest of secret, keyed by randomPart.
+func digest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:digestSize]
+}
+
+// splitSecret splits secret into count shares, with indices 0 to count - 1,
+// such that threshold of them recover it. Unless threshold is 1, the value of
+// the polynomial at digestIndex holds a digest of the secret, checked by
+// recoverSecret.
+func splitSecret(threshold, count int, secret []byte, rand io.Reader) ([]point, error) {
+	if threshold == 1 {
+		shares := make([]point, count)
+		for i := range shares {
+			shares[i] = point{byte(i), append([]byte{}, secret...)}
+		}
+		return shares, nil
+	}
+	// threshold - 2 random shares, and the digest and the secret, determine
+	// the polynomial.
+	random := make([]byte, (threshold-2)*len(secret)+len(secret)-digestSize)
+	if _, err := io.ReadFull(rand, random); err != nil {
+		return nil, err
+	}
+	shares := make([]point, 0, count)
+	for i := 0; i < threshold-2; i++ {
+		shares = append(shares, point{byte(i), random[i*len(secret) : (i+1)*len(secret)]})
+	}
+	randomPart := random[(threshold-2)*len(secret):]
+	base := append(shares[:threshold-2:threshold-2],
+		point{digestIndex, append(digest(randomPart, secret), randomPart...)},
+		point{secretIndex, secret})
+	for i := threshold - 2; i < count; i++ {
+		y, err := interpolate(base, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, point{byte(i), y})
+	}
+	return shares, nil
+}
+
+// recoverSecret recovers the secret of threshold shares from splitSecret,
+// checking its digest.
+func recoverSecret(threshold int, shares []point) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].y, nil
+	}
+	secret, err := interpolate(shares, secretIndex)
+	if err != nil {
+		return nil, err
+	}
+	d, err := interpolate(shares, digestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(d[:digestSize], digest(d[digestSize:], secret)) != 1 {
+		zero(secret)
+		return nil, errDigest
+	}
+	return secret, nil
+}
+
+// Group is a group of shares, of which Threshold are needed to recover the
+// share of the group.
+type Group struct {
+	Threshold, Count int
+}
+
+// Options are the options of Split.
+type Options struct {
+	// IterationExponent is e, between 0 and 15, the encryption of the master
+	// secret using 10000 * 2^e iterations of PBKDF2.
+	IterationExponent int
+	// Extendable makes the salt of the encryption independent of the
+	// identifier, so that the master secret can be split again later with
+	// the same passphrase, into shares which can't be mixed with these.
+	Extendable bool
+}
+
+// Split encrypts masterSecret with passphrase, and splits it into groups of
+// mnemonics, of which groupThreshold groups are needed to recover it, each
+// with the threshold of its members given by groups. The mnemonics of a
+// group are returned in the same order as groups. opts can be nil, for the
+// default options.
+//
+// The master secret must be at least 16 bytes long, and of even length, and
+// the passphrase, which can be empty, must be printable ASCII. There can be up
+// to 16 groups, of up to 16 members, and a group with a threshold of 1 must
+// have a single member, as its members would otherwise hold the same share.
+//
+// The identifier, and the random coefficients, are read from rand. If rand is
+// nil, the health-tested Reader of the rand package of this module is used.
+func Split(masterSecret, passphrase []byte, groupThreshold int, groups []Group, opts *Options, rand io.Reader) ([][]string, error) {
+	rand = randutil.Or(rand)
+	if opts == nil {
+		opts = &Options{}
+	}
+	if len(masterSecret) < minSecretSize || len(masterSecret)%2 != 0 {
+		return nil, errSecretSize
+	}
+	for _, c := range passphrase {
+		if c < 32 || c > 126 {
+			return nil, errPassphrase
+		}
+	}
+	if opts.IterationExponent < 0 || opts.IterationExponent > maxIterationExponent {
+		return nil, errIterationExp
+	}
+	if len(groups) > maxShares || groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, errors.New("slip39: group threshold must be between 1 and the number of groups, of at most 16")
+	}
+	for _, g := range groups {
+		if g.Count > maxShares || g.Threshold < 1 || g.Threshold > g.Count {
+			return nil, errors.New("slip39: member threshold must be between 1 and the number of members, of at most 16")
+		}
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, errors.New("slip39: a group with a member threshold of 1 must have a single member")
+		}
+	}
+
+	var id [2]byte
+	if _, err := io.ReadFull(rand, id[:]); err != nil {
+		return nil, err
+	}
+	template := Share{
+		Identifier:        (uint16(id[0])<<8 | uint16(id[1])) & (1<<15 - 1),
+		Extendable:        opts.Extendable,
+		IterationExponent: opts.IterationExponent,
+		GroupThreshold:    groupThreshold,
+		GroupCount:        len(groups),
+	}
+	encrypted := crypt(masterSecret, passphrase, &template, false)
+	defer zero(encrypted)
+	groupShares, err := splitSecret(groupThreshold, len(groups), encrypted, rand)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([][]string, len(groups))
+	for i, g := range groups {
+		memberShares, err := splitSecret(g.Threshold, g.Count, groupShares[i].y, rand)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range memberShares {
+			s := template
+			s.GroupIndex, s.MemberIndex, s.MemberThreshold, s.Value = i, int(m.x), g.Threshold, m.y
+			mnemonics[i] = append(mnemonics[i], s.Mnemonic())
+			zero(m.y)
+		}
+		zero(groupShares[i].y)
+	}
+	return mnemonics, nil
+}
+
+// Combine recovers the master secret from mnemonics, decrypting it with
+// passphrase. Exactly the threshold of groups must be given, each with
+// exactly the threshold of its members, as Split made them.
+//
+// A wrong passphrase can't be detected, and returns another secret, but
+// mnemonics from different splits, or with mistakes which the checksums
+// missed, are detected by the digest of the shared secrets, unless all the
+// thresholds are 1.
+func Combine(mnemonics []string, passphrase []byte) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, errNoMnemonics
+	}
+	shares := make([]*Share, len(mnemonics))
+	for i, m := range mnemonics {
+		s, err := ParseShare(m)
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = s
+	}
+	first := shares[0]
+	groups := make(map[int][]*Share)
+	var order []int
+	for _, s := range shares {
+		if s.Identifier != first.Identifier || s.Extendable != first.Extendable ||
+			s.IterationExponent != first.IterationExponent ||
+			s.GroupThreshold != first.GroupThreshold || s.GroupCount != first.GroupCount ||
+			len(s.Value) != len(first.Value) {
+			return nil, errMismatched
+		}
+		members := groups[s.GroupIndex]
+		if len(members) > 0 && members[0].MemberThreshold != s.MemberThreshold {
+			return nil, errMismatched
+		}
+		if members == nil {
+			order = append(order, s.GroupIndex)
+		}
+		groups[s.GroupIndex] = append(members, s)
+	}
+	if len(groups) != first.GroupThreshold {
+		return nil, fmt.Errorf("slip39: expected mnemonics from %d groups, got %d", first.GroupThreshold, len(groups))
+	}
+
+	groupShares := make([]point, 0, len(groups))
+	for _, index := range order {
+		members := groups[index]
+		if len(members) != members[0].MemberThreshold {
+			return nil, fmt.Errorf("slip39: expected %d mnemonics of group %d, got %d", members[0].MemberThreshold, index, len(members))
+		}
+		points := make([]point, len(members))
+		for i, s := range members {
+			points[i] = point{byte(s.MemberIndex), s.Value}
+		}
+		y, err := recoverSecret(len(members), points)
+		if err != nil {
+			return nil, err
+		}
+		groupShares = append(groupShares, point{byte(index), y})
+	}
+	encrypted, err := recoverSecret(first.GroupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+	secret := crypt(encrypted, passphrase, first, true)
+	// With thresholds of 1, the recovered values are those of the shares.
+	for _, s := range shares {
+		zero(s.Value)
+	}
+	for _, p := range groupShares {
+		zero(p.y)
+	}
+	zero(encrypted)
+	return secret, nil
+}
@@ -0,0 +1,249 @@
+package slip39
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestWordlist(t *testing.T) {
+	prefixes := make(map[string]bool)
+	for i, w := range wordlist {
+		if len(w) < 4 || len(w) > 8 || strings.ToLower(w) != w {
+			t.Errorf("invalid word %q", w)
+		}
+		if i > 0 && wordlist[i-1] >= w {
+			t.Errorf("%q isn't sorted", w)
+		}
+		prefixes[w[:4]] = true
+		if index, ok := wordIndex(w); ok != 1 || index != i {
+			t.Errorf("%q has index %d, %d, want %d", w, index, ok, i)
+		}
+		if index, ok := wordIndex(strings.ToUpper(w)); ok != 1 || index != i {
+			t.Errorf("%q isn't found in upper case", w)
+		}
+		if got := word(i); got != w {
+			t.Errorf("word %d is %q, want %q", i, got, w)
+		}
+	}
+	if len(prefixes) != len(wordlist) {
+		t.Error("the first 4 letters of the words aren't unique")
+	}
+	for _, w := range []string{"", "aca", "academi", "academics", "zeros", "acad\x00mic", "ACADEMIC\x00"} {
+		if _, ok := wordIndex(w); ok != 0 {
+			t.Errorf("%q found", w)
+		}
+	}
+}
+
+// The test vectors of SLIP-39, which all use the passphrase "TREZOR".
+var vectors = []struct {
+	name      string
+	mnemonics []string
+	secret    string
+}{
+	{
+		"1. Valid mnemonic without sharing (128 bits)",
+		[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+		"bb54aac4b89dc868ba37d9cc21b2cece",
+	},
+	{
+		"2. Mnemonic with invalid checksum (128 bits)",
+		[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+		"",
+	},
+	{
+		"4. Basic sharing 2-of-3 (128 bits)",
+		[]string{
+			"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+			"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+		},
+		"b43ceb7e57a0ea8766221624d01b0864",
+	},
+	{
+		"5. Basic sharing 2-of-3 (128 bits), with a single share",
+		[]string{"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed"},
+		"",
+	},
+	{
+		"22. Valid mnemonic without sharing (256 bits)",
+		[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+		"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+	},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		secret, err := Combine(v.mnemonics, []byte("TREZOR"))
+		if v.secret == "" {
+			if err == nil {
+				t.Errorf("%s: accepted", v.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", v.name, err)
+			continue
+		}
+		if got := hex.EncodeToString(secret); got != v.secret {
+			t.Errorf("%s: got %s, want %s", v.name, got, v.secret)
+		}
+		for _, m := range v.mnemonics {
+			s, err := ParseShare(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Mnemonic(); got != m {
+				t.Errorf("%s: encoded as %q", v.name, got)
+			}
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ012345")
+	passphrase := []byte("TREZOR")
+	for _, tc := range []struct {
+		groupThreshold int
+		groups         []Group
+	}{
+		{1, []Group{{1, 1}}},
+		{1, []Group{{3, 5}}},
+		{2, []Group{{1, 1}, {2, 3}, {3, 5}}},
+		{3, []Group{{2, 2}, {16, 16}, {1, 1}, {4, 7}}},
+	} {
+		for _, extendable := range []bool{false, true} {
+			opts := &Options{Extendable: extendable}
+			groups, err := Split(secret, passphrase, tc.groupThreshold, tc.groups, opts, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(groups) != len(tc.groups) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tc.groups))
+			}
+			// The last groups, with their last members.
+			var mnemonics []string
+			for i := len(groups) - tc.groupThreshold; i < len(groups); i++ {
+				if len(groups[i]) != tc.groups[i].Count {
+					t.Fatalf("got %d mnemonics, want %d", len(groups[i]), tc.groups[i].Count)
+				}
+				mnemonics = append(mnemonics, groups[i][tc.groups[i].Count-tc.groups[i].Threshold:]...)
+			}
+			got, err := Combine(mnemonics, passphrase)
+			if err != nil {
+				t.Fatalf("%d of %v: %v", tc.groupThreshold, tc.groups, err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Errorf("%d of %v: recovered %x", tc.groupThreshold, tc.groups, got)
+			}
+			if s, _ := ParseShare(mnemonics[0]); s.Extendable != extendable {
+				t.Error("wrong extendable flag")
+			}
+
+			// Another passphrase decrypts the secret to another value.
+			if got, err := Combine(mnemonics, []byte("other")); err != nil || bytes.Equal(got, secret) {
+				t.Errorf("%d of %v: other passphrase returned %x, %v", tc.groupThreshold, tc.groups, got, err)
+			}
+			if len(mnemonics) > 1 {
+				if _, err := Combine(mnemonics[1:], passphrase); err == nil {
+					t.Errorf("%d of %v: combined too few mnemonics", tc.groupThreshold, tc.groups)
+				}
+			}
+		}
+	}
+}
+
+func TestCombineErrors(t *testing.T) {
+	secret := make([]byte, 16)
+	a, err := Split(secret, nil, 2, []Group{{2, 3}, {2, 3}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Split(secret, nil, 2, []Group{{2, 3}, {2, 3}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, mnemonics := range map[string][]string{
+		"none":             nil,
+		"too few groups":   {a[0][0], a[0][1]},
+		"too many members": {a[0][0], a[0][1], a[0][2], a[1][0], a[1][1]},
+		"duplicate member": {a[0][0], a[0][0], a[1][0], a[1][1]},
+		"different splits": {a[0][0], a[0][1], b[1][0], b[1][1]},
+	} {
+		if _, err := Combine(mnemonics, nil); err == nil {
+			t.Errorf("%s: combined", name)
+		}
+	}
+
+	// A share whose value is changed, with a valid checksum, is detected by
+	// the digest.
+	s, err := ParseShare(a[0][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Value[0] ^= 1
+	if _, err := Combine([]string{s.Mnemonic(), a[0][1], a[1][0], a[1][1]}, nil); err != errDigest {
+		t.Errorf("altered share returned %v", err)
+	}
+}
+
+func TestParseShareErrors(t *testing.T) {
+	mnemonic := vectors[0].mnemonics[0]
+	words := strings.Fields(mnemonic)
+	for name, m := range map[string]string{
+		"short":        strings.Join(words[:minWords-1], " "),
+		"unknown word": strings.Replace(mnemonic, "fridge", "fridges", 1),
+		"swapped":      strings.Replace(mnemonic, "fridge kidney", "kidney fridge", 1),
+		"long":         mnemonic + " academic",
+	} {
+		if _, err := ParseShare(m); err == nil {
+			t.Errorf("%s: parsed", name)
+		}
+	}
+	if _, err := ParseShare(strings.ToUpper("  " + strings.Replace(mnemonic, " ", "\n", -1))); err != nil {
+		t.Errorf("mnemonic with other case and whitespace: %v", err)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	secret := make([]byte, 16)
+	for name, f := range map[string]func() error{
+		"short secret": func() error {
+			_, err := Split(secret[:14], nil, 1, []Group{{1, 1}}, nil, nil)
+			return err
+		},
+		"odd secret": func() error {
+			_, err := Split(make([]byte, 17), nil, 1, []Group{{1, 1}}, nil, nil)
+			return err
+		},
+		"passphrase": func() error {
+			_, err := Split(secret, []byte("é"), 1, []Group{{1, 1}}, nil, nil)
+			return err
+		},
+		"exponent": func() error {
+			_, err := Split(secret, nil, 1, []Group{{1, 1}}, &Options{IterationExponent: 16}, nil)
+			return err
+		},
+		"group threshold": func() error {
+			_, err := Split(secret, nil, 2, []Group{{1, 1}}, nil, nil)
+			return err
+		},
+		"member threshold": func() error {
+			_, err := Split(secret, nil, 1, []Group{{3, 2}}, nil, nil)
+			return err
+		},
+		"1 of 2": func() error {
+			_, err := Split(secret, nil, 1, []Group{{1, 2}}, nil, nil)
+			return err
+		},
+		"17 members": func() error {
+			_, err := Split(secret, nil, 1, []Group{{2, 17}}, nil, nil)
+			return err
+		},
+	} {
+		if f() == nil {
+			t.Errorf("%s: split", name)
+		}
+	}
+}
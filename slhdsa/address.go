@@ -0,0 +1,76 @@
+package slhdsa
+
+import "encoding/binary"
+
+// The types of addresses, from FIPS 205, section 4.2.
+const (
+	wotsHash  = 0
+	wotsPK    = 1
+	tree      = 2
+	forsTree  = 3
+	forsRoots = 4
+	wotsPRF   = 5
+	forsPRF   = 6
+)
+
+// address is ADRS, which separates the domains of every call to the hash
+// functions. It is made of the layer address, the tree address, the type,
+// and three words depending on the type.
+type address [32]byte
+
+func (a *address) setLayerAddress(layer uint32) {
+	binary.BigEndian.PutUint32(a[0:4], layer)
+}
+
+// setTreeAddress sets the 12 byte tree address, of which only 8 bytes are
+// ever needed.
+func (a *address) setTreeAddress(t uint64) {
+	binary.BigEndian.PutUint32(a[4:8], 0)
+	binary.BigEndian.PutUint64(a[8:16], t)
+}
+
+// setTypeAndClear sets the type, and clears the three following words.
+func (a *address) setTypeAndClear(typ uint32) {
+	binary.BigEndian.PutUint32(a[16:20], typ)
+	for i := 20; i < 32; i++ {
+		a[i] = 0
+	}
+}
+
+func (a *address) setKeyPairAddress(i uint32) {
+	binary.BigEndian.PutUint32(a[20:24], i)
+}
+
+func (a *address) keyPairAddress() uint32 {
+	return binary.BigEndian.Uint32(a[20:24])
+}
+
+func (a *address) setChainAddress(i uint32) {
+	binary.BigEndian.PutUint32(a[24:28], i)
+}
+
+func (a *address) setTreeHeight(z uint32) {
+	binary.BigEndian.PutUint32(a[24:28], z)
+}
+
+func (a *address) setHashAddress(i uint32) {
+	binary.BigEndian.PutUint32(a[28:32], i)
+}
+
+func (a *address) setTreeIndex(i uint32) {
+	binary.BigEndian.PutUint32(a[28:32], i)
+}
+
+func (a *address) treeIndex() uint32 {
+	return binary.BigEndian.Uint32(a[28:32])
+}
+
+// compressed returns ADRS^c, the 22 byte address used by the SHA-2
+// instantiations, from FIPS 205, section 11.2.
+func (a *address) compressed() []byte {
+	c := make([]byte, 0, 22)
+	c = append(c, a[3])
+	c = append(c, a[8:16]...)
+	c = append(c, a[19])
+	return append(c, a[20:32]...)
+}
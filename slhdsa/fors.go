@@ -0,0 +1,74 @@
+package slhdsa
+
+// FORS few-time signatures, from FIPS 205, section 8.
+
+// forsSecret is fors_skGen, from FIPS 205, Algorithm 14.
+func (p *Params) forsSecret(skSeed, pkSeed []byte, adrs *address, idx uint32) []byte {
+	skADRS := *adrs
+	skADRS.setTypeAndClear(forsPRF)
+	skADRS.setKeyPairAddress(adrs.keyPairAddress())
+	skADRS.setTreeIndex(idx)
+	return p.prf(pkSeed, skSeed, &skADRS)
+}
+
+// forsNode is Algorithm 15.
+func (p *Params) forsNode(skSeed []byte, i, z uint32, pkSeed []byte, adrs *address) []byte {
+	if z == 0 {
+		sk := p.forsSecret(skSeed, pkSeed, adrs, i)
+		adrs.setTreeHeight(0)
+		adrs.setTreeIndex(i)
+		return p.f(pkSeed, adrs, sk)
+	}
+	left := p.forsNode(skSeed, 2*i, z-1, pkSeed, adrs)
+	right := p.forsNode(skSeed, 2*i+1, z-1, pkSeed, adrs)
+	adrs.setTreeHeight(z)
+	adrs.setTreeIndex(i)
+	return p.t(pkSeed, adrs, left, right)
+}
+
+// forsSign is Algorithm 16, appending the signature to sig.
+func (p *Params) forsSign(sig, md, skSeed, pkSeed []byte, adrs *address) []byte {
+	a := uint(p.a)
+	for i, index := range base2b(md, a, p.k) {
+		base := uint32(i) << a
+		sig = append(sig, p.forsSecret(skSeed, pkSeed, adrs, base+index)...)
+		for j := uint(0); j < a; j++ {
+			s := (index >> j) ^ 1
+			sig = append(sig, p.forsNode(skSeed, uint32(i)<<(a-j)+s, uint32(j), pkSeed, adrs)...)
+		}
+	}
+	return sig
+}
+
+// forsSize is the size of a FORS signature.
+func (p *Params) forsSize() int {
+	return p.k * (p.a + 1) * p.n
+}
+
+// forsPKFromSig is Algorithm 17.
+func (p *Params) forsPKFromSig(sig, md, pkSeed []byte, adrs *address) []byte {
+	a := uint(p.a)
+	roots := make([][]byte, p.k)
+	for i, index := range base2b(md, a, p.k) {
+		part := sig[i*(p.a+1)*p.n : (i+1)*(p.a+1)*p.n]
+		adrs.setTreeHeight(0)
+		adrs.setTreeIndex(uint32(i)<<a + index)
+		node := p.f(pkSeed, adrs, part[:p.n])
+		for j := uint(0); j < a; j++ {
+			sibling := part[(j+1)*uint(p.n) : (j+2)*uint(p.n)]
+			adrs.setTreeHeight(uint32(j + 1))
+			if (index>>j)&1 == 0 {
+				adrs.setTreeIndex(adrs.treeIndex() / 2)
+				node = p.t(pkSeed, adrs, node, sibling)
+			} else {
+				adrs.setTreeIndex((adrs.treeIndex() - 1) / 2)
+				node = p.t(pkSeed, adrs, sibling, node)
+			}
+		}
+		roots[i] = node
+	}
+	pkADRS := *adrs
+	pkADRS.setTypeAndClear(forsRoots)
+	pkADRS.setKeyPairAddress(adrs.keyPairAddress())
+	return p.t(pkSeed, &pkADRS, roots...)
+}
@@ -0,0 +1,103 @@
+package slhdsa
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+
+	"github.com/cronokirby/ctcrypto/hmac"
+	"golang.org/x/crypto/sha3"
+)
+
+// The hash functions of FIPS 205, section 11, instantiated with either SHAKE,
+// or SHA-2, depending on the parameter set.
+
+func shake256(outLen int, parts ...[]byte) []byte {
+	h := sha3.NewShake256()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	out := make([]byte, outLen)
+	h.Read(out)
+	return out
+}
+
+// sha2Hash computes Trunc_n(SHA-x(PK.seed || toByte(0, blockSize - n) ||
+// ADRS^c || parts)), for F, H, T and PRF.
+func (p *Params) sha2Hash(newHash func() hash.Hash, pkSeed []byte, adrs *address, parts ...[]byte) []byte {
+	h := newHash()
+	h.Write(pkSeed)
+	h.Write(make([]byte, h.BlockSize()-p.n))
+	h.Write(adrs.compressed())
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)[:p.n]
+}
+
+// bigHash returns the hash function used by H, T, H_msg and PRF_msg with
+// SHA-2, which is SHA-512 above security category 1.
+func (p *Params) bigHash() func() hash.Hash {
+	if p.n == 16 {
+		return sha256.New
+	}
+	return sha512.New
+}
+
+// f is F, and prf is PRF, which have the same form.
+func (p *Params) f(pkSeed []byte, adrs *address, m []byte) []byte {
+	if p.sha2 {
+		return p.sha2Hash(sha256.New, pkSeed, adrs, m)
+	}
+	return shake256(p.n, pkSeed, adrs[:], m)
+}
+
+func (p *Params) prf(pkSeed, skSeed []byte, adrs *address) []byte {
+	return p.f(pkSeed, adrs, skSeed)
+}
+
+// t is T_l, which is also H for two nodes.
+func (p *Params) t(pkSeed []byte, adrs *address, m ...[]byte) []byte {
+	if p.sha2 {
+		return p.sha2Hash(p.bigHash(), pkSeed, adrs, m...)
+	}
+	return shake256(p.n, append([][]byte{pkSeed, adrs[:]}, m...)...)
+}
+
+func (p *Params) prfMsg(skPRF, optRand, msg []byte) []byte {
+	if p.sha2 {
+		h := hmac.New(p.bigHash(), skPRF)
+		h.Write(optRand)
+		h.Write(msg)
+		return h.Sum(nil)[:p.n]
+	}
+	return shake256(p.n, skPRF, optRand, msg)
+}
+
+func (p *Params) hMsg(r, pkSeed, pkRoot, msg []byte) []byte {
+	if !p.sha2 {
+		return shake256(p.m, r, pkSeed, pkRoot, msg)
+	}
+	h := p.bigHash()()
+	h.Write(r)
+	h.Write(pkSeed)
+	h.Write(pkRoot)
+	h.Write(msg)
+	seed := append(append(append([]byte(nil), r...), pkSeed...), h.Sum(nil)...)
+	return mgf1(p.bigHash(), seed, p.m)
+}
+
+// mgf1 is the mask generation function of RFC 8017, appendix B.2.1.
+func mgf1(newHash func() hash.Hash, seed []byte, length int) []byte {
+	var out []byte
+	var counter [4]byte
+	for i := uint32(0); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+		h := newHash()
+		h.Write(seed)
+		h.Write(counter[:])
+		out = h.Sum(out)
+	}
+	return out[:length]
+}
@@ -0,0 +1,286 @@
+// Package slhdsa implements SLH-DSA, the stateless hash-based digital
+// signature algorithm of FIPS 205, formerly known as SPHINCS+, with all of
+// its parameter sets.
+//
+// The security of SLH-DSA only rests on that of its hash functions, which
+// makes it a conservative choice against quantum computers, at the cost of
+// large and slow signatures. The "s" parameter sets have smaller signatures,
+// and the "f" ones faster signing.
+//
+// Signing only evaluates hash functions, and computes addresses from the
+// public digest of the message, so no branch or memory access depends on
+// secret data.
+package slhdsa
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+)
+
+// Params is an SLH-DSA parameter set.
+type Params struct {
+	name string
+	// n is the security parameter, h the height of the hypertree, d its
+	// number of layers, and hp the height of its XMSS trees.
+	n, h, d, hp int
+	// a is the height of the FORS trees, and k their number.
+	a, k int
+	// m is the size of the message digest.
+	m    int
+	sha2 bool
+}
+
+var allParams = []*Params{
+	{name: "SLH-DSA-SHA2-128s", n: 16, h: 63, d: 7, hp: 9, a: 12, k: 14, m: 30, sha2: true},
+	{name: "SLH-DSA-SHAKE-128s", n: 16, h: 63, d: 7, hp: 9, a: 12, k: 14, m: 30},
+	{name: "SLH-DSA-SHA2-128f", n: 16, h: 66, d: 22, hp: 3, a: 6, k: 33, m: 34, sha2: true},
+	{name: "SLH-DSA-SHAKE-128f", n: 16, h: 66, d: 22, hp: 3, a: 6, k: 33, m: 34},
+	{name: "SLH-DSA-SHA2-192s", n: 24, h: 63, d: 7, hp: 9, a: 14, k: 17, m: 39, sha2: true},
+	{name: "SLH-DSA-SHAKE-192s", n: 24, h: 63, d: 7, hp: 9, a: 14, k: 17, m: 39},
+	{name: "SLH-DSA-SHA2-192f", n: 24, h: 66, d: 22, hp: 3, a: 8, k: 33, m: 42, sha2: true},
+	{name: "SLH-DSA-SHAKE-192f", n: 24, h: 66, d: 22, hp: 3, a: 8, k: 33, m: 42},
+	{name: "SLH-DSA-SHA2-256s", n: 32, h: 64, d: 8, hp: 8, a: 14, k: 22, m: 47, sha2: true},
+	{name: "SLH-DSA-SHAKE-256s", n: 32, h: 64, d: 8, hp: 8, a: 14, k: 22, m: 47},
+	{name: "SLH-DSA-SHA2-256f", n: 32, h: 68, d: 17, hp: 4, a: 9, k: 35, m: 49, sha2: true},
+	{name: "SLH-DSA-SHAKE-256f", n: 32, h: 68, d: 17, hp: 4, a: 9, k: 35, m: 49},
+}
+
+// ParamsByName returns the parameter set with the given name, like
+// "SLH-DSA-SHA2-128s", or "SLH-DSA-SHAKE-256f".
+func ParamsByName(name string) (*Params, error) {
+	for _, p := range allParams {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return nil, errors.New("slhdsa: unknown parameter set " + name)
+}
+
+// AllParams returns every parameter set, in the order of FIPS 205, table 2.
+func AllParams() []*Params {
+	return append([]*Params(nil), allParams...)
+}
+
+// String returns the name of the parameter set, like "SLH-DSA-SHA2-128s".
+func (p *Params) String() string {
+	return p.name
+}
+
+// PublicKeySize returns the size of public keys, in bytes.
+func (p *Params) PublicKeySize() int {
+	return 2 * p.n
+}
+
+// PrivateKeySize returns the size of private keys, in bytes.
+func (p *Params) PrivateKeySize() int {
+	return 4 * p.n
+}
+
+// SignatureSize returns the size of signatures, in bytes.
+func (p *Params) SignatureSize() int {
+	return p.n + p.forsSize() + p.d*p.xmssSize()
+}
+
+// MaxContextSize is the maximum size of the context strings of Sign and
+// Verify.
+const MaxContextSize = 255
+
+var (
+	errInvalidPublicKey  = errors.New("slhdsa: invalid public key length")
+	errInvalidPrivateKey = errors.New("slhdsa: invalid private key length")
+	errInvalidPair       = errors.New("slhdsa: private key doesn't match its public key")
+	errContextTooLong    = errors.New("slhdsa: context longer than 255 bytes")
+	errVerification      = errors.New("slhdsa: verification error")
+)
+
+// PublicKey is an SLH-DSA public key, made of PK.seed and PK.root.
+type PublicKey struct {
+	params *Params
+	seed   []byte
+	root   []byte
+}
+
+// PrivateKey is an SLH-DSA private key, made of SK.seed and SK.prf, along
+// with the public key.
+type PrivateKey struct {
+	PublicKey
+	seed []byte
+	prf  []byte
+}
+
+// GenerateKey generates a new private key, reading randomness from rand.
+func (p *Params) GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	b := make([]byte, 3*p.n)
+	if _, err := io.ReadFull(rand, b); err != nil {
+		return nil, err
+	}
+	return p.keyGen(b[:p.n], b[p.n:2*p.n], b[2*p.n:]), nil
+}
+
+// keyGen is slh_keygen_internal, from FIPS 205, Algorithm 18.
+func (p *Params) keyGen(skSeed, skPRF, pkSeed []byte) *PrivateKey {
+	var adrs address
+	adrs.setLayerAddress(uint32(p.d - 1))
+	root := p.xmssNode(skSeed, 0, uint32(p.hp), pkSeed, &adrs)
+	return &PrivateKey{
+		PublicKey: PublicKey{params: p, seed: pkSeed, root: root},
+		seed:      skSeed,
+		prf:       skPRF,
+	}
+}
+
+// NewPrivateKey parses a private key, encoded as SK.seed || SK.prf ||
+// PK.seed || PK.root, the format returned by Bytes.
+//
+// The root is recomputed, and compared with the encoded one, which is as
+// slow as generating a key.
+func (p *Params) NewPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != p.PrivateKeySize() {
+		return nil, errInvalidPrivateKey
+	}
+	b = append([]byte(nil), b...)
+	n := p.n
+	priv := p.keyGen(b[:n], b[n:2*n], b[2*n:3*n])
+	if subtle.ConstantTimeCompare(priv.root, b[3*n:]) != 1 {
+		return nil, errInvalidPair
+	}
+	return priv, nil
+}
+
+// NewPublicKey parses a public key, encoded as PK.seed || PK.root, the format
+// returned by Bytes.
+func (p *Params) NewPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != p.PublicKeySize() {
+		return nil, errInvalidPublicKey
+	}
+	b = append([]byte(nil), b...)
+	return &PublicKey{params: p, seed: b[:p.n], root: b[p.n:]}, nil
+}
+
+// Params returns the parameter set of the key.
+func (pub *PublicKey) Params() *Params {
+	return pub.params
+}
+
+// Bytes returns the encoding of the public key.
+func (pub *PublicKey) Bytes() []byte {
+	return append(append([]byte(nil), pub.seed...), pub.root...)
+}
+
+// Public returns the public key matching the private key.
+func (priv *PrivateKey) Public() *PublicKey {
+	pub := priv.PublicKey
+	return &pub
+}
+
+// Bytes returns the encoding of the private key.
+func (priv *PrivateKey) Bytes() []byte {
+	b := append(append([]byte(nil), priv.seed...), priv.prf...)
+	return append(b, priv.PublicKey.Bytes()...)
+}
+
+// encodeMessage returns M' = toByte(0, 1) || toByte(|ctx|, 1) || ctx || M,
+// the message of the pure variant of SLH-DSA, from FIPS 205, Algorithm 22.
+func encodeMessage(message, context []byte) ([]byte, error) {
+	if len(context) > MaxContextSize {
+		return nil, errContextTooLong
+	}
+	m := make([]byte, 0, 2+len(context)+len(message))
+	m = append(m, 0, byte(len(context)))
+	m = append(m, context...)
+	return append(m, message...), nil
+}
+
+// Sign signs a message, with an optional context string of up to 255 bytes.
+//
+// If rand is nil, the signature is deterministic, as described in FIPS 205,
+// section 10.2. Otherwise, n bytes of randomness are read from rand, which
+// protects against fault and side-channel attacks on the hash functions.
+func (priv *PrivateKey) Sign(rand io.Reader, message, context []byte) ([]byte, error) {
+	m, err := encodeMessage(message, context)
+	if err != nil {
+		return nil, err
+	}
+	p := priv.params
+	optRand := priv.PublicKey.seed
+	if rand != nil {
+		optRand = make([]byte, p.n)
+		if _, err := io.ReadFull(rand, optRand); err != nil {
+			return nil, err
+		}
+	}
+	return priv.sign(m, optRand), nil
+}
+
+// digest splits H_msg into the message digest of FORS, and the indices of
+// the tree and leaf of the hypertree.
+func (p *Params) digest(r, pkSeed, pkRoot, m []byte) (md []byte, idxTree uint64, idxLeaf uint32) {
+	digest := p.hMsg(r, pkSeed, pkRoot, m)
+	mdLen := (p.k*p.a + 7) / 8
+	treeBits := uint(p.h - p.hp)
+	treeLen := int(treeBits+7) / 8
+	leafLen := (p.hp + 7) / 8
+	md = digest[:mdLen]
+	for _, b := range digest[mdLen : mdLen+treeLen] {
+		idxTree = idxTree<<8 | uint64(b)
+	}
+	if treeBits < 64 {
+		idxTree &= 1<<treeBits - 1
+	}
+	for _, b := range digest[mdLen+treeLen : mdLen+treeLen+leafLen] {
+		idxLeaf = idxLeaf<<8 | uint32(b)
+	}
+	idxLeaf &= 1<<uint(p.hp) - 1
+	return md, idxTree, idxLeaf
+}
+
+// sign is slh_sign_internal, from FIPS 205, Algorithm 19.
+func (priv *PrivateKey) sign(m, optRand []byte) []byte {
+	p := priv.params
+	pkSeed, pkRoot := priv.PublicKey.seed, priv.root
+	sig := make([]byte, 0, p.SignatureSize())
+	r := p.prfMsg(priv.prf, optRand, m)
+	sig = append(sig, r...)
+
+	md, idxTree, idxLeaf := p.digest(r, pkSeed, pkRoot, m)
+	var adrs address
+	adrs.setTreeAddress(idxTree)
+	adrs.setTypeAndClear(forsTree)
+	adrs.setKeyPairAddress(idxLeaf)
+	start := len(sig)
+	sig = p.forsSign(sig, md, priv.seed, pkSeed, &adrs)
+	pkFORS := p.forsPKFromSig(sig[start:], md, pkSeed, &adrs)
+	return p.htSign(sig, pkFORS, priv.seed, pkSeed, idxTree, idxLeaf)
+}
+
+// Verify checks a signature of a message, with the context used to sign it,
+// returning nil if it is valid.
+func (pub *PublicKey) Verify(message, sig, context []byte) error {
+	m, err := encodeMessage(message, context)
+	if err != nil {
+		return err
+	}
+	if !pub.verify(m, sig) {
+		return errVerification
+	}
+	return nil
+}
+
+// verify is slh_verify_internal, from FIPS 205, Algorithm 20.
+func (pub *PublicKey) verify(m, sig []byte) bool {
+	p := pub.params
+	if len(sig) != p.SignatureSize() {
+		return false
+	}
+	r, sig := sig[:p.n], sig[p.n:]
+	forsSig, htSig := sig[:p.forsSize()], sig[p.forsSize():]
+
+	md, idxTree, idxLeaf := p.digest(r, pub.seed, pub.root, m)
+	var adrs address
+	adrs.setTreeAddress(idxTree)
+	adrs.setTypeAndClear(forsTree)
+	adrs.setKeyPairAddress(idxLeaf)
+	pkFORS := p.forsPKFromSig(forsSig, md, pub.seed, &adrs)
+	return p.htVerify(pkFORS, htSig, pub.seed, idxTree, idxLeaf, pub.root)
+}
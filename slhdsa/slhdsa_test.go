@@ -0,0 +1,230 @@
+package slhdsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestSignatureSize(t *testing.T) {
+	// Table 2 of FIPS 205.
+	sizes := map[string]int{
+		"128s": 7856, "128f": 17088,
+		"192s": 16224, "192f": 35664,
+		"256s": 29792, "256f": 49856,
+	}
+	for _, p := range AllParams() {
+		name := p.String()
+		if got, want := p.SignatureSize(), sizes[name[len(name)-4:]]; got != want {
+			t.Errorf("%v: signature size %d, expected %d", p, got, want)
+		}
+	}
+}
+
+func TestParamsByName(t *testing.T) {
+	for _, p := range AllParams() {
+		q, err := ParamsByName(p.String())
+		if err != nil || q != p {
+			t.Errorf("%v: ParamsByName returned %v, %v", p, q, err)
+		}
+	}
+	if _, err := ParamsByName("SLH-DSA-SHA2-128x"); err == nil {
+		t.Error("unknown name accepted")
+	}
+}
+
+func TestBase2b(t *testing.T) {
+	got := base2b([]byte{0x12, 0x34, 0x56}, 6, 4)
+	want := []uint32{0x04, 0x23, 0x11, 0x16}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("base2b = %x, expected %x", got, want)
+		}
+	}
+}
+
+func testParams() []*Params {
+	var params []*Params
+	for _, p := range AllParams() {
+		name := p.String()
+		if name[len(name)-1] == 'f' && name[len(name)-4:len(name)-1] == "128" {
+			params = append(params, p)
+		}
+	}
+	if !testing.Short() {
+		p, _ := ParamsByName("SLH-DSA-SHAKE-128s")
+		params = append(params, p)
+	}
+	return params
+}
+
+func TestSignVerify(t *testing.T) {
+	message := []byte("hello, world")
+	context := []byte("context")
+	for _, p := range testParams() {
+		t.Run(p.String(), func(t *testing.T) {
+			priv, err := p.GenerateKey(rand.Reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sig, err := priv.Sign(rand.Reader, message, context)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sig) != p.SignatureSize() {
+				t.Fatalf("signature of %d bytes, expected %d", len(sig), p.SignatureSize())
+			}
+			pub := priv.Public()
+			if err := pub.Verify(message, sig, context); err != nil {
+				t.Fatal(err)
+			}
+			if err := pub.Verify(message, sig, nil); err == nil {
+				t.Error("signature verified with another context")
+			}
+			if err := pub.Verify([]byte("hello, world!"), sig, context); err == nil {
+				t.Error("signature verified for another message")
+			}
+			for _, i := range []int{0, p.n, p.n + p.forsSize(), len(sig) - 1} {
+				sig[i] ^= 1
+				if err := pub.Verify(message, sig, context); err == nil {
+					t.Errorf("signature with byte %d flipped verified", i)
+				}
+				sig[i] ^= 1
+			}
+			if err := pub.Verify(message, sig[:len(sig)-1], context); err == nil {
+				t.Error("truncated signature verified")
+			}
+		})
+	}
+}
+
+func TestDeterministicSign(t *testing.T) {
+	p, _ := ParamsByName("SLH-DSA-SHA2-128f")
+	priv, err := p.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig1, err := priv.Sign(nil, []byte("message"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig2, err := priv.Sign(nil, []byte("message"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sig1, sig2) {
+		t.Error("deterministic signatures differ")
+	}
+	if err := priv.Public().Verify([]byte("message"), sig1, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyEncoding(t *testing.T) {
+	p, _ := ParamsByName("SLH-DSA-SHAKE-128f")
+	priv, err := p.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := priv.Bytes()
+	if len(b) != p.PrivateKeySize() {
+		t.Fatalf("private key of %d bytes", len(b))
+	}
+	priv2, err := p.NewPrivateKey(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(priv2.Bytes(), b) {
+		t.Error("private key encoding doesn't round trip")
+	}
+	b[len(b)-1] ^= 1
+	if _, err := p.NewPrivateKey(b); err == nil {
+		t.Error("private key with the wrong root accepted")
+	}
+
+	pub, err := p.NewPublicKey(priv.Public().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := priv.Sign(rand.Reader, []byte("message"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pub.Verify([]byte("message"), sig, nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := p.NewPublicKey(b[:p.PublicKeySize()-1]); err == nil {
+		t.Error("short public key accepted")
+	}
+}
+
+func TestLongContext(t *testing.T) {
+	p, _ := ParamsByName("SLH-DSA-SHA2-128f")
+	priv, err := p.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.Sign(nil, nil, make([]byte, MaxContextSize+1)); err == nil {
+		t.Error("long context accepted")
+	}
+}
+
+func TestOpenSSLVectors(t *testing.T) {
+	// Generated with OpenSSL 3.5, deriving the keys from seeds, and signing
+	// deterministically, or with the additional randomness in addrnd, like
+	// the keyGen and sigGen tests of ACVP.
+	data, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Params          string `json:"params"`
+		Seed            string `json:"seed"`
+		PK              string `json:"pk"`
+		Message         string `json:"message"`
+		Context         string `json:"context"`
+		AddRnd          string `json:"addrnd"`
+		SignatureSHA256 string `json:"signature_sha256"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		p, err := ParamsByName(v.Params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seed, _ := hex.DecodeString(v.Seed)
+		message, _ := hex.DecodeString(v.Message)
+		context, _ := hex.DecodeString(v.Context)
+		addrnd, _ := hex.DecodeString(v.AddRnd)
+		priv := p.keyGen(seed[:p.n], seed[p.n:2*p.n], seed[2*p.n:])
+		if pk := hex.EncodeToString(priv.Public().Bytes()); pk != v.PK {
+			t.Errorf("%v: public key %s, expected %s", p, pk, v.PK)
+			continue
+		}
+		// Signing with the small parameter sets takes seconds.
+		if testing.Short() && v.Params[len(v.Params)-1] == 's' {
+			continue
+		}
+		var rand io.Reader
+		if len(addrnd) > 0 {
+			rand = bytes.NewReader(addrnd)
+		}
+		sig, err := priv.Sign(rand, message, context)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h := sha256.Sum256(sig); hex.EncodeToString(h[:]) != v.SignatureSHA256 {
+			t.Errorf("%v: wrong signature, with addrnd %q", p, v.AddRnd)
+		}
+		if err := priv.Public().Verify(message, sig, context); err != nil {
+			t.Errorf("%v: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package slhdsa
+
+// WOTS+ one-time signatures, from FIPS 205, section 5, with w = 16.
+
+const (
+	lgW = 4
+	w   = 1 << lgW
+	// len2 is the number of digits of the checksum, which is 3 for every
+	// parameter set.
+	len2 = 3
+)
+
+// len1 is the number of digits of the message.
+func (p *Params) len1() int {
+	return 2 * p.n
+}
+
+// wotsLen is the number of chains of a WOTS+ key.
+func (p *Params) wotsLen() int {
+	return p.len1() + len2
+}
+
+// base2b splits x into outLen integers of b bits, from FIPS 205, Algorithm 4.
+func base2b(x []byte, b uint, outLen int) []uint32 {
+	out := make([]uint32, outLen)
+	in := 0
+	var bits uint
+	var total uint64
+	for i := range out {
+		for bits < b {
+			total = total<<8 | uint64(x[in])
+			in++
+			bits += 8
+		}
+		bits -= b
+		out[i] = uint32(total>>bits) & (1<<b - 1)
+	}
+	return out
+}
+
+// chain applies F s times to x, starting at position i, from FIPS 205,
+// Algorithm 5.
+func (p *Params) chain(x []byte, i, s uint32, pkSeed []byte, adrs *address) []byte {
+	tmp := x
+	for j := i; j < i+s; j++ {
+		adrs.setHashAddress(j)
+		tmp = p.f(pkSeed, adrs, tmp)
+	}
+	return tmp
+}
+
+// wotsDigits returns the digits of the message, followed by those of the
+// checksum.
+func (p *Params) wotsDigits(m []byte) []uint32 {
+	msg := base2b(m, lgW, p.len1())
+	var csum uint32
+	for _, x := range msg {
+		csum += w - 1 - x
+	}
+	// The checksum is shifted to fill whole bytes, 12 bits in 2 bytes.
+	csum <<= 4
+	return append(msg, base2b([]byte{byte(csum >> 8), byte(csum)}, lgW, len2)...)
+}
+
+// wotsSecret returns the secret value at the start of chain i.
+func (p *Params) wotsSecret(skSeed, pkSeed []byte, adrs *address, i uint32) []byte {
+	skADRS := *adrs
+	skADRS.setTypeAndClear(wotsPRF)
+	skADRS.setKeyPairAddress(adrs.keyPairAddress())
+	skADRS.setChainAddress(i)
+	return p.prf(pkSeed, skSeed, &skADRS)
+}
+
+// wotsCompress hashes the ends of the chains into a public key.
+func (p *Params) wotsCompress(ends [][]byte, pkSeed []byte, adrs *address) []byte {
+	pkADRS := *adrs
+	pkADRS.setTypeAndClear(wotsPK)
+	pkADRS.setKeyPairAddress(adrs.keyPairAddress())
+	return p.t(pkSeed, &pkADRS, ends...)
+}
+
+// wotsPKGen is Algorithm 6.
+func (p *Params) wotsPKGen(skSeed, pkSeed []byte, adrs *address) []byte {
+	ends := make([][]byte, p.wotsLen())
+	for i := range ends {
+		sk := p.wotsSecret(skSeed, pkSeed, adrs, uint32(i))
+		adrs.setChainAddress(uint32(i))
+		ends[i] = p.chain(sk, 0, w-1, pkSeed, adrs)
+	}
+	return p.wotsCompress(ends, pkSeed, adrs)
+}
+
+// wotsSign is Algorithm 7, appending the signature to sig.
+func (p *Params) wotsSign(sig, m, skSeed, pkSeed []byte, adrs *address) []byte {
+	for i, d := range p.wotsDigits(m) {
+		sk := p.wotsSecret(skSeed, pkSeed, adrs, uint32(i))
+		adrs.setChainAddress(uint32(i))
+		sig = append(sig, p.chain(sk, 0, d, pkSeed, adrs)...)
+	}
+	return sig
+}
+
+// wotsPKFromSig is Algorithm 8.
+func (p *Params) wotsPKFromSig(sig, m, pkSeed []byte, adrs *address) []byte {
+	ends := make([][]byte, p.wotsLen())
+	for i, d := range p.wotsDigits(m) {
+		adrs.setChainAddress(uint32(i))
+		ends[i] = p.chain(sig[i*p.n:(i+1)*p.n], d, w-1-d, pkSeed, adrs)
+	}
+	return p.wotsCompress(ends, pkSeed, adrs)
+}
@@ -0,0 +1,102 @@
+package slhdsa
+
+import "crypto/subtle"
+
+// XMSS and the hypertree, from FIPS 205, sections 6 and 7.
+
+// xmssNode computes the node at height z and index i of an XMSS tree, from
+// FIPS 205, Algorithm 9.
+func (p *Params) xmssNode(skSeed []byte, i, z uint32, pkSeed []byte, adrs *address) []byte {
+	if z == 0 {
+		adrs.setTypeAndClear(wotsHash)
+		adrs.setKeyPairAddress(i)
+		return p.wotsPKGen(skSeed, pkSeed, adrs)
+	}
+	left := p.xmssNode(skSeed, 2*i, z-1, pkSeed, adrs)
+	right := p.xmssNode(skSeed, 2*i+1, z-1, pkSeed, adrs)
+	adrs.setTypeAndClear(tree)
+	adrs.setTreeHeight(z)
+	adrs.setTreeIndex(i)
+	return p.t(pkSeed, adrs, left, right)
+}
+
+// xmssSign is Algorithm 10, appending the signature to sig.
+func (p *Params) xmssSign(sig, m, skSeed []byte, idx uint32, pkSeed []byte, adrs *address) []byte {
+	auth := make([][]byte, p.hp)
+	for j := range auth {
+		k := (idx >> uint(j)) ^ 1
+		auth[j] = p.xmssNode(skSeed, k, uint32(j), pkSeed, adrs)
+	}
+	adrs.setTypeAndClear(wotsHash)
+	adrs.setKeyPairAddress(idx)
+	sig = p.wotsSign(sig, m, skSeed, pkSeed, adrs)
+	for _, node := range auth {
+		sig = append(sig, node...)
+	}
+	return sig
+}
+
+// xmssSize is the size of an XMSS signature.
+func (p *Params) xmssSize() int {
+	return (p.wotsLen() + p.hp) * p.n
+}
+
+// xmssPKFromSig is Algorithm 11.
+func (p *Params) xmssPKFromSig(idx uint32, sig, m, pkSeed []byte, adrs *address) []byte {
+	adrs.setTypeAndClear(wotsHash)
+	adrs.setKeyPairAddress(idx)
+	wotsSig, auth := sig[:p.wotsLen()*p.n], sig[p.wotsLen()*p.n:]
+	node := p.wotsPKFromSig(wotsSig, m, pkSeed, adrs)
+
+	adrs.setTypeAndClear(tree)
+	adrs.setTreeIndex(idx)
+	for k := 0; k < p.hp; k++ {
+		adrs.setTreeHeight(uint32(k + 1))
+		sibling := auth[k*p.n : (k+1)*p.n]
+		if (idx>>uint(k))&1 == 0 {
+			adrs.setTreeIndex(adrs.treeIndex() / 2)
+			node = p.t(pkSeed, adrs, node, sibling)
+		} else {
+			adrs.setTreeIndex((adrs.treeIndex() - 1) / 2)
+			node = p.t(pkSeed, adrs, sibling, node)
+		}
+	}
+	return node
+}
+
+// htSign is Algorithm 12, appending the signature to sig.
+func (p *Params) htSign(sig, m, skSeed, pkSeed []byte, idxTree uint64, idxLeaf uint32) []byte {
+	var adrs address
+	adrs.setTreeAddress(idxTree)
+	start := len(sig)
+	sig = p.xmssSign(sig, m, skSeed, idxLeaf, pkSeed, &adrs)
+	root := p.xmssPKFromSig(idxLeaf, sig[start:], m, pkSeed, &adrs)
+	for j := 1; j < p.d; j++ {
+		idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+		idxTree >>= uint(p.hp)
+		adrs.setLayerAddress(uint32(j))
+		adrs.setTreeAddress(idxTree)
+		start = len(sig)
+		sig = p.xmssSign(sig, root, skSeed, idxLeaf, pkSeed, &adrs)
+		if j < p.d-1 {
+			root = p.xmssPKFromSig(idxLeaf, sig[start:], root, pkSeed, &adrs)
+		}
+	}
+	return sig
+}
+
+// htVerify is Algorithm 13.
+func (p *Params) htVerify(m, sig, pkSeed []byte, idxTree uint64, idxLeaf uint32, pkRoot []byte) bool {
+	var adrs address
+	adrs.setTreeAddress(idxTree)
+	size := p.xmssSize()
+	node := p.xmssPKFromSig(idxLeaf, sig[:size], m, pkSeed, &adrs)
+	for j := 1; j < p.d; j++ {
+		idxLeaf = uint32(idxTree & (1<<uint(p.hp) - 1))
+		idxTree >>= uint(p.hp)
+		adrs.setLayerAddress(uint32(j))
+		adrs.setTreeAddress(idxTree)
+		node = p.xmssPKFromSig(idxLeaf, sig[j*size:(j+1)*size], node, pkSeed, &adrs)
+	}
+	return subtle.ConstantTimeCompare(node, pkRoot) == 1
+}
@@ -0,0 +1,275 @@
+// Package spake2 implements the SPAKE2 and SPAKE2+ password-authenticated key
+// exchanges, following RFC 9382 and RFC 9383.
+//
+// Both protocols let two parties sharing a low-entropy password derive a
+// strong shared key, without an eavesdropper, or an active attacker, being
+// able to run an offline dictionary attack against the password. In SPAKE2+,
+// the verifier only stores a value derived from the password, rather than the
+// password itself.
+//
+// The protocols run over the groups of this module, using the standard M and
+// N points for P-256, P-384, and P-521. Group elements are encoded with the
+// encoding of the group, which is compressed, so the transcripts differ from
+// those of implementations using uncompressed points.
+package spake2
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"sync"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/hkdf"
+	"github.com/cronokirby/ctcrypto/hmac"
+	"github.com/cronokirby/safenum"
+)
+
+// suite contains the parameters of the protocols over a given group.
+type suite struct {
+	g    group.Group
+	hash func() hash.Hash
+	m, n group.Element
+}
+
+// The M and N points for each group, as defined in RFC 9382, section 6.
+//
+// These points were generated by hashing a seed containing the OID of the curve,
+// so that nobody knows their discrete logarithm.
+var points = map[string]struct {
+	hash func() hash.Hash
+	m, n string
+}{
+	"P-256": {
+		sha256.New,
+		"02886e2f97ace46e55ba9dd7242579f2993b64e16ef3dcab95afd497333d8fa12f",
+		"03d8bbd6c639c62937b04d997f38c3770719c629d7014d49a24b4f98baa1292b49",
+	},
+	"P-384": {
+		sha512.New,
+		"030ff0895ae5ebf6187080a82d82b42e2765e3b2f8749c7e05eba366434b363d3dc36f15314739074d2eb8613fceec2853",
+		"02c72cf2e390853a1c1c4ad816a62fd15824f56078918f43f922ca21518f9c543bb252c5490214cf9aa3f0baab4b665c10",
+	},
+	"P-521": {
+		sha512.New,
+		"02003f06f38131b2ba2600791e82488e8d20ab889af753a41806c5db18d37d85608cfae06b82e4a72cd744c719193562a653ea1f119eef9356907edc9b56979962d7aa",
+		"0200c7924b9ec017f3094562894336a53c50167ba8c5963876880542bc669e494b2532d76c5b53dfb349fdf69154b9e0048c58a42e8ed04cef052a3bc349d95575cd25",
+	},
+}
+
+var (
+	suitesMu sync.Mutex
+	suites   = make(map[string]*suite)
+)
+
+// suiteFor returns the suite to use with a given group.
+func suiteFor(g group.Group) (*suite, error) {
+	suitesMu.Lock()
+	defer suitesMu.Unlock()
+	if s, ok := suites[g.Name()]; ok {
+		return s, nil
+	}
+	p, ok := points[g.Name()]
+	if !ok {
+		return nil, errors.New("spake2: unsupported group " + g.Name())
+	}
+	mBytes, _ := hex.DecodeString(p.m)
+	nBytes, _ := hex.DecodeString(p.n)
+	m, err := g.DecodeElement(mBytes)
+	if err != nil {
+		return nil, err
+	}
+	n, err := g.DecodeElement(nBytes)
+	if err != nil {
+		return nil, err
+	}
+	s := &suite{g: g, hash: p.hash, m: m, n: n}
+	suites[g.Name()] = s
+	return s, nil
+}
+
+// kdf derives length bytes from a secret, using HKDF with no salt.
+func (s *suite) kdf(secret, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(s.hash, secret, nil, info), out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func (s *suite) mac(key, data []byte) []byte {
+	h := hmac.New(s.hash, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// appendPrefixed appends data to a transcript, prefixed by its length as an
+// 8 byte little-endian integer.
+func appendPrefixed(transcript []byte, data []byte) []byte {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data)))
+	return append(append(transcript, length[:]...), data...)
+}
+
+// PasswordScalar derives the scalar w used by SPAKE2 from the output of a
+// memory-hard function applied to the password, by reducing it modulo the
+// order of the group.
+//
+// The output of the memory-hard function should be at least 8 bytes longer
+// than the scalars of the group, to make the bias from this reduction negligible.
+func PasswordScalar(g group.Group, mhfOutput []byte) *safenum.Nat {
+	// SetBytes can modify its argument, if it has spare capacity.
+	w := new(safenum.Nat).SetBytes(mhfOutput[:len(mhfOutput):len(mhfOutput)])
+	return w.Mod(w, g.Order())
+}
+
+// Role is the role of a party in SPAKE2.
+type Role int
+
+const (
+	// RoleA is the role of the party initiating the exchange, usually the client.
+	RoleA Role = iota
+	// RoleB is the role of the party responding to the exchange, usually the server.
+	RoleB
+)
+
+// Options contains the optional inputs of SPAKE2.
+//
+// The identities are bound to the shared key, and should be set whenever the
+// parties have identities. The additional authenticated data is bound to the
+// confirmation messages.
+type Options struct {
+	IdentityA, IdentityB []byte
+	AAD                  []byte
+}
+
+var (
+	errBadMessage      = errors.New("spake2: invalid peer message")
+	errBadConfirmation = errors.New("spake2: invalid confirmation")
+	errState           = errors.New("spake2: function called out of order")
+)
+
+// Party is one side of a SPAKE2 exchange.
+//
+// A party first sends the message returned by Message to its peer. Once it
+// receives the message of its peer, it calls Finish, and sends the returned
+// confirmation to its peer. Finally, Verify checks the confirmation received
+// from the peer, and returns the shared key.
+type Party struct {
+	suite *suite
+	role  Role
+	opts  Options
+	w, x  *safenum.Nat
+	msg   []byte
+
+	transcript  []byte
+	sharedKey   []byte
+	peerConfirm []byte
+	finished    bool
+}
+
+// New creates a new SPAKE2 party over a group, with a given role, password
+// scalar w, as returned by PasswordScalar, and options, which can be nil.
+// The ephemeral secret of the party is generated by reading from rand.
+//
+// Only the P-256, P-384, and P-521 groups are supported. SHA-256 is used with
+// P-256, and SHA-512 with the other groups.
+func New(g group.Group, role Role, w *safenum.Nat, opts *Options, rand io.Reader) (*Party, error) {
+	s, err := suiteFor(g)
+	if err != nil {
+		return nil, err
+	}
+	x, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	p := &Party{suite: s, role: role, w: new(safenum.Nat).Mod(w, g.Order()), x: x}
+	if opts != nil {
+		p.opts = *opts
+	}
+	// pA = x P + w M, and pB = y P + w N
+	blind := s.m
+	if role == RoleB {
+		blind = s.n
+	}
+	p.msg = g.ScalarBaseMult(x).Add(blind.ScalarMult(p.w)).Bytes()
+	return p, nil
+}
+
+// Message returns the message to send to the peer.
+func (p *Party) Message() []byte {
+	return append([]byte{}, p.msg...)
+}
+
+// Finish processes the message of the peer, returning the confirmation to send
+// to the peer.
+func (p *Party) Finish(peerMsg []byte) (confirmation []byte, err error) {
+	if p.finished {
+		return nil, errState
+	}
+	g := p.suite.g
+	peer, err := g.DecodeElement(peerMsg)
+	if err != nil {
+		return nil, errBadMessage
+	}
+	// K = x (pB - w N), or K = y (pA - w M). All of the supported groups have
+	// a cofactor of 1, so there's no need to multiply by it.
+	unblind := p.suite.n
+	pA, pB := p.msg, peerMsg
+	if p.role == RoleB {
+		unblind = p.suite.m
+		pA, pB = peerMsg, p.msg
+	}
+	K := group.Sub(peer, unblind.ScalarMult(p.w)).ScalarMult(p.x)
+	if K.IsIdentity() {
+		return nil, errBadMessage
+	}
+
+	var tt []byte
+	tt = appendPrefixed(tt, p.opts.IdentityA)
+	tt = appendPrefixed(tt, p.opts.IdentityB)
+	tt = appendPrefixed(tt, pA)
+	tt = appendPrefixed(tt, pB)
+	tt = appendPrefixed(tt, K.Bytes())
+	tt = appendPrefixed(tt, g.EncodeScalar(p.w))
+
+	// Ke || Ka = Hash(TT)
+	h := p.suite.hash()
+	h.Write(tt)
+	digest := h.Sum(nil)
+	half := len(digest) / 2
+	ke, ka := digest[:half], digest[half:]
+	// KcA || KcB = KDF(Ka, "ConfirmationKeys" || AAD)
+	kc := p.suite.kdf(ka, append([]byte("ConfirmationKeys"), p.opts.AAD...), len(digest))
+	kcA, kcB := kc[:half], kc[half:]
+
+	confirmA := p.suite.mac(kcA, tt)
+	confirmB := p.suite.mac(kcB, tt)
+	p.finished = true
+	p.sharedKey = ke
+	if p.role == RoleA {
+		p.peerConfirm = confirmB
+		return confirmA, nil
+	}
+	p.peerConfirm = confirmA
+	return confirmB, nil
+}
+
+// Verify checks the confirmation received from the peer, and returns the
+// shared key if it is valid.
+//
+// The shared key must not be used before the peer's confirmation has been
+// checked, since its value depends on the peer knowing the password.
+func (p *Party) Verify(peerConfirmation []byte) (sharedKey []byte, err error) {
+	if !p.finished {
+		return nil, errState
+	}
+	if !hmac.Equal(peerConfirmation, p.peerConfirm) {
+		return nil, errBadConfirmation
+	}
+	return append([]byte{}, p.sharedKey...), nil
+}
@@ -0,0 +1,82 @@
+package spake2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestSuites(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		s, err := suiteFor(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.m.Equal(s.n) != 0 || s.m.IsIdentity() {
+			t.Errorf("%s: invalid M and N points", g.Name())
+		}
+	}
+}
+
+func exchange(t *testing.T, g group.Group, wA, wB []byte, opts *Options) ([]byte, []byte, error) {
+	a, err := New(g, RoleA, PasswordScalar(g, wA), opts, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(g, RoleB, PasswordScalar(g, wB), opts, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmA, err := a.Finish(b.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmB, err := b.Finish(a.Message())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyB, err := b.Verify(confirmA)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyA, err := a.Verify(confirmB)
+	if err != nil {
+		return nil, nil, err
+	}
+	return keyA, keyB, nil
+}
+
+func TestSPAKE2(t *testing.T) {
+	opts := &Options{IdentityA: []byte("client"), IdentityB: []byte("server"), AAD: []byte("aad")}
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		keyA, keyB, err := exchange(t, g, []byte("password"), []byte("password"), opts)
+		if err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		if !bytes.Equal(keyA, keyB) || len(keyA) == 0 {
+			t.Errorf("%s: parties derived different keys", g.Name())
+		}
+	}
+}
+
+func TestSPAKE2WrongPassword(t *testing.T) {
+	if _, _, err := exchange(t, group.P256(), []byte("password"), []byte("passw0rd"), nil); err == nil {
+		t.Errorf("expected an error with mismatched passwords")
+	}
+}
+
+func TestSPAKE2BadMessage(t *testing.T) {
+	g := group.P256()
+	a, _ := New(g, RoleA, PasswordScalar(g, []byte("password")), nil, rand.Reader)
+	if _, err := a.Finish(g.Identity().Bytes()); err == nil {
+		t.Errorf("expected an error for the identity")
+	}
+	if _, err := a.Finish([]byte{2, 1, 2, 3}); err == nil {
+		t.Errorf("expected an error for an invalid element")
+	}
+	if _, err := a.Verify(nil); err == nil {
+		t.Errorf("expected an error when verifying before finishing")
+	}
+}
@@ -0,0 +1,213 @@
+package spake2
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/hmac"
+	"github.com/cronokirby/safenum"
+)
+
+// PlusPasswordScalars derives the scalars w0 and w1 used by SPAKE2+ from the
+// output of a memory-hard function applied to the password, as described in
+// RFC 9383, section 3.2.
+//
+// The output is split into two halves, each of which is reduced modulo the order
+// of the group. Each half must be at least 8 bytes longer than the scalars of
+// the group.
+func PlusPasswordScalars(g group.Group, mhfOutput []byte) (w0, w1 *safenum.Nat, err error) {
+	half := len(mhfOutput) / 2
+	if len(mhfOutput)%2 != 0 || half < g.ScalarSize()+8 {
+		return nil, nil, errors.New("spake2: memory-hard function output has a bad length")
+	}
+	return PasswordScalar(g, mhfOutput[:half]), PasswordScalar(g, mhfOutput[half:]), nil
+}
+
+// PlusVerifierRecord returns the value L = w1 P stored by the verifier in
+// SPAKE2+, along with w0, after the prover registers its password.
+func PlusVerifierRecord(g group.Group, w1 *safenum.Nat) []byte {
+	return g.ScalarBaseMult(w1).Bytes()
+}
+
+// PlusOptions contains the optional inputs of SPAKE2+.
+//
+// The context should identify the application, and the identities are those
+// of the prover and the verifier. All of them are bound to the shared key.
+type PlusOptions struct {
+	Context                          []byte
+	IdentityProver, IdentityVerifier []byte
+}
+
+// plusSchedule derives the confirmation MACs and the shared key from the
+// transcript of a SPAKE2+ exchange, as described in RFC 9383, section 3.4.
+func plusSchedule(s *suite, opts *PlusOptions, shareP, shareV []byte, Z, V group.Element, w0 *safenum.Nat) (confirmP, confirmV, sharedKey []byte) {
+	var tt []byte
+	tt = appendPrefixed(tt, opts.Context)
+	tt = appendPrefixed(tt, opts.IdentityProver)
+	tt = appendPrefixed(tt, opts.IdentityVerifier)
+	tt = appendPrefixed(tt, s.m.Bytes())
+	tt = appendPrefixed(tt, s.n.Bytes())
+	tt = appendPrefixed(tt, shareP)
+	tt = appendPrefixed(tt, shareV)
+	tt = appendPrefixed(tt, Z.Bytes())
+	tt = appendPrefixed(tt, V.Bytes())
+	tt = appendPrefixed(tt, s.g.EncodeScalar(w0))
+
+	h := s.hash()
+	h.Write(tt)
+	kMain := h.Sum(nil)
+	size := len(kMain)
+	kConfirm := s.kdf(kMain, []byte("ConfirmationKeys"), 2*size)
+	sharedKey = s.kdf(kMain, []byte("SharedKey"), size)
+	confirmP = s.mac(kConfirm[:size], shareV)
+	confirmV = s.mac(kConfirm[size:], shareP)
+	return
+}
+
+// Prover is the client side of a SPAKE2+ exchange, which knows the password.
+//
+// The prover sends the message returned by Message to the verifier, and passes
+// the response of the verifier to Finish, which returns the confirmation to send
+// back to the verifier.
+type Prover struct {
+	suite  *suite
+	opts   PlusOptions
+	w0, w1 *safenum.Nat
+	x      *safenum.Nat
+	shareP []byte
+	done   bool
+}
+
+// NewProver creates the prover side of a SPAKE2+ exchange, given the scalars
+// returned by PlusPasswordScalars, and options, which can be nil.
+//
+// Only the P-256, P-384, and P-521 groups are supported.
+func NewProver(g group.Group, w0, w1 *safenum.Nat, opts *PlusOptions, rand io.Reader) (*Prover, error) {
+	s, err := suiteFor(g)
+	if err != nil {
+		return nil, err
+	}
+	x, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	p := &Prover{
+		suite: s,
+		w0:    new(safenum.Nat).Mod(w0, g.Order()),
+		w1:    new(safenum.Nat).Mod(w1, g.Order()),
+		x:     x,
+	}
+	if opts != nil {
+		p.opts = *opts
+	}
+	// X = x P + w0 M
+	p.shareP = g.ScalarBaseMult(x).Add(s.m.ScalarMult(p.w0)).Bytes()
+	return p, nil
+}
+
+// Message returns the share of the prover, to send to the verifier.
+func (p *Prover) Message() []byte {
+	return append([]byte{}, p.shareP...)
+}
+
+// Finish processes the share and confirmation of the verifier, returning the
+// confirmation to send to the verifier, along with the shared key.
+func (p *Prover) Finish(shareV, confirmV []byte) (confirmP, sharedKey []byte, err error) {
+	if p.done {
+		return nil, nil, errState
+	}
+	Y, err := p.suite.g.DecodeElement(shareV)
+	if err != nil {
+		return nil, nil, errBadMessage
+	}
+	p.done = true
+	// Z = x (Y - w0 N), and V = w1 (Y - w0 N)
+	unblinded := group.Sub(Y, p.suite.n.ScalarMult(p.w0))
+	Z := unblinded.ScalarMult(p.x)
+	V := unblinded.ScalarMult(p.w1)
+	if Z.IsIdentity() || V.IsIdentity() {
+		return nil, nil, errBadMessage
+	}
+	confirmP, expected, sharedKey := plusSchedule(p.suite, &p.opts, p.shareP, shareV, Z, V, p.w0)
+	if !hmac.Equal(confirmV, expected) {
+		return nil, nil, errBadConfirmation
+	}
+	return confirmP, sharedKey, nil
+}
+
+// Verifier is the server side of a SPAKE2+ exchange, which only knows the
+// record derived from the password.
+//
+// The verifier passes the message of the prover to Respond, and sends back the
+// returned share and confirmation. It then checks the confirmation of the
+// prover with Verify, which returns the shared key.
+type Verifier struct {
+	suite     *suite
+	opts      PlusOptions
+	w0        *safenum.Nat
+	L         group.Element
+	expected  []byte
+	sharedKey []byte
+	state     int
+}
+
+// NewVerifier creates the verifier side of a SPAKE2+ exchange, given w0 and the
+// record returned by PlusVerifierRecord, and options, which can be nil.
+//
+// Only the P-256, P-384, and P-521 groups are supported.
+func NewVerifier(g group.Group, w0 *safenum.Nat, record []byte, opts *PlusOptions) (*Verifier, error) {
+	s, err := suiteFor(g)
+	if err != nil {
+		return nil, err
+	}
+	L, err := g.DecodeElement(record)
+	if err != nil {
+		return nil, errors.New("spake2: invalid verifier record")
+	}
+	v := &Verifier{suite: s, w0: new(safenum.Nat).Mod(w0, g.Order()), L: L}
+	if opts != nil {
+		v.opts = *opts
+	}
+	return v, nil
+}
+
+// Respond processes the share of the prover, returning the share and
+// confirmation of the verifier, reading randomness from rand.
+func (v *Verifier) Respond(shareP []byte, rand io.Reader) (shareV, confirmV []byte, err error) {
+	if v.state != 0 {
+		return nil, nil, errState
+	}
+	g := v.suite.g
+	X, err := g.DecodeElement(shareP)
+	if err != nil {
+		return nil, nil, errBadMessage
+	}
+	y, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	v.state = 1
+	// Y = y P + w0 N, Z = y (X - w0 M), and V = y L
+	shareV = g.ScalarBaseMult(y).Add(v.suite.n.ScalarMult(v.w0)).Bytes()
+	Z := group.Sub(X, v.suite.m.ScalarMult(v.w0)).ScalarMult(y)
+	V := v.L.ScalarMult(y)
+	if Z.IsIdentity() {
+		return nil, nil, errBadMessage
+	}
+	v.expected, confirmV, v.sharedKey = plusSchedule(v.suite, &v.opts, shareP, shareV, Z, V, v.w0)
+	return shareV, confirmV, nil
+}
+
+// Verify checks the confirmation of the prover, returning the shared key if
+// it is valid.
+func (v *Verifier) Verify(confirmP []byte) (sharedKey []byte, err error) {
+	if v.state != 1 {
+		return nil, errState
+	}
+	v.state = 2
+	if !hmac.Equal(confirmP, v.expected) {
+		return nil, errBadConfirmation
+	}
+	return v.sharedKey, nil
+}
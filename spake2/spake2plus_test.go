@@ -0,0 +1,84 @@
+package spake2
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+func plusExchange(t *testing.T, g group.Group, proverPassword, verifierPassword string) (keyP, keyV []byte, err error) {
+	opts := &PlusOptions{Context: []byte("test"), IdentityProver: []byte("client"), IdentityVerifier: []byte("server")}
+	// A real application would use a memory-hard function here.
+	mhf := func(password string) []byte {
+		return pbkdf2.Key([]byte(password), []byte("salt"), 1000, 2*(g.ScalarSize()+8), sha512.New)
+	}
+	w0, w1, err := PlusPasswordScalars(g, mhf(proverPassword))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v0, v1, _ := PlusPasswordScalars(g, mhf(verifierPassword))
+	record := PlusVerifierRecord(g, v1)
+
+	prover, err := NewProver(g, w0, w1, opts, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := NewVerifier(g, v0, record, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shareV, confirmV, err := verifier.Respond(prover.Message(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmP, keyP, err := prover.Finish(shareV, confirmV)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyV, err = verifier.Verify(confirmP)
+	return keyP, keyV, err
+}
+
+func TestSPAKE2Plus(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		keyP, keyV, err := plusExchange(t, g, "password", "password")
+		if err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		if !bytes.Equal(keyP, keyV) || len(keyP) == 0 {
+			t.Errorf("%s: parties derived different keys", g.Name())
+		}
+	}
+}
+
+func TestSPAKE2PlusWrongPassword(t *testing.T) {
+	if _, _, err := plusExchange(t, group.P256(), "password", "passw0rd"); err == nil {
+		t.Errorf("expected an error with mismatched passwords")
+	}
+}
+
+func TestPlusPasswordScalarsLength(t *testing.T) {
+	if _, _, err := PlusPasswordScalars(group.P256(), make([]byte, 64)); err == nil {
+		t.Errorf("expected an error for a short input")
+	}
+}
+
+func TestPlusPasswordScalarsHalves(t *testing.T) {
+	// For P-521, each half is 74 bytes, which isn't a whole number of limbs.
+	g := group.P521()
+	input := make([]byte, 2*(g.ScalarSize()+8))
+	rand.Read(input)
+	w0, w1, err := PlusPasswordScalars(g, append([]byte{}, input...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := len(input) / 2
+	if w0.Cmp(PasswordScalar(g, append([]byte{}, input[:half]...))) != 0 ||
+		w1.Cmp(PasswordScalar(g, append([]byte{}, input[half:]...))) != 0 {
+		t.Errorf("scalars don't match the halves of the input")
+	}
+}
@@ -0,0 +1,174 @@
+// Package streamsign signs and verifies messages which are streamed through
+// an io.Writer, and hashed as they are written, so that large payloads, like
+// files or archives, never need to be held in memory.
+//
+// Signatures are those of the keys of this module over the digest of the
+// message: ECDSA, in ASN.1, which truncates digests longer than the order of
+// the curve, RSA with PKCS #1 v1.5, or PSS if the options are *rsa.PSSOptions,
+// and Ed25519ph, from RFC 8032, section 5.1, which signs the SHA-512 digest of
+// the message. Ed25519ph signatures differ from Ed25519 ones, which hash the
+// message twice, and can't be computed from a stream.
+package streamsign
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+// Ed25519phOptions are the options of Ed25519ph, whose hash is SHA-512.
+type Ed25519phOptions struct {
+	// Context separates the signatures of different protocols, and holds at
+	// most 255 bytes.
+	Context string
+}
+
+// HashFunc returns crypto.SHA512, so that Ed25519phOptions implements
+// crypto.SignerOpts.
+func (opts *Ed25519phOptions) HashFunc() crypto.Hash {
+	return crypto.SHA512
+}
+
+// newHash returns a hash of the digest of opts, which must be available.
+func newHash(opts crypto.SignerOpts) (hash.Hash, error) {
+	if opts == nil || opts.HashFunc() == 0 || !opts.HashFunc().Available() {
+		return nil, errors.New("streamsign: a hash function is required")
+	}
+	return opts.HashFunc().New(), nil
+}
+
+// ed25519Context returns the context of Ed25519ph options, which are either
+// *Ed25519phOptions, or any options with SHA-512 and no context.
+func ed25519Context(opts crypto.SignerOpts) (string, error) {
+	if opts, ok := opts.(*Ed25519phOptions); ok {
+		if len(opts.Context) > 255 {
+			return "", errors.New("streamsign: Ed25519ph context too long")
+		}
+		return opts.Context, nil
+	}
+	if opts.HashFunc() != crypto.SHA512 {
+		return "", errors.New("streamsign: Ed25519ph requires SHA-512")
+	}
+	return "", nil
+}
+
+// Signer hashes the message written to it, and signs its digest. It is an
+// io.Writer, whose writes never fail.
+type Signer struct {
+	h    hash.Hash
+	sign func(rand io.Reader, digest []byte) ([]byte, error)
+}
+
+// NewSigner returns a Signer for priv, which is an *ecdsa.PrivateKey, an
+// *rsa.PrivateKey, or an ed25519.PrivateKey, hashing the message with
+// opts.HashFunc().
+func NewSigner(priv crypto.PrivateKey, opts crypto.SignerOpts) (*Signer, error) {
+	h, err := newHash(opts)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{h: h}
+	switch priv := priv.(type) {
+	case *ecdsa.PrivateKey:
+		s.sign = func(rand io.Reader, digest []byte) ([]byte, error) {
+			return priv.Sign(rand, digest, opts)
+		}
+	case *rsa.PrivateKey:
+		s.sign = func(rand io.Reader, digest []byte) ([]byte, error) {
+			return priv.Sign(rand, digest, opts)
+		}
+	case ed25519.PrivateKey:
+		context, err := ed25519Context(opts)
+		if err != nil {
+			return nil, err
+		}
+		s.sign = func(_ io.Reader, digest []byte) ([]byte, error) {
+			return edsig.SignPh(priv, digest, context)
+		}
+	default:
+		return nil, errors.New("streamsign: unsupported key type")
+	}
+	return s, nil
+}
+
+// Write adds p to the message.
+func (s *Signer) Write(p []byte) (int, error) {
+	return s.h.Write(p)
+}
+
+// Sum returns the digest of the message written so far.
+func (s *Signer) Sum() []byte {
+	return s.h.Sum(nil)
+}
+
+// Sign signs the message written so far, reading randomness from rand. If
+// rand is nil, the health-tested Reader of the rand package of this module is
+// used. Ed25519ph signatures are deterministic, and don't read from rand.
+func (s *Signer) Sign(rand io.Reader) ([]byte, error) {
+	return s.sign(randutil.Or(rand), s.Sum())
+}
+
+// Verifier hashes the message written to it, and verifies a signature of its
+// digest. It is an io.Writer, whose writes never fail.
+type Verifier struct {
+	h      hash.Hash
+	verify func(digest, sig []byte) bool
+}
+
+// NewVerifier returns a Verifier for pub, which is an *ecdsa.PublicKey, an
+// *rsa.PublicKey, or an ed25519.PublicKey, with the same options as the
+// Signer which produced the signatures.
+func NewVerifier(pub crypto.PublicKey, opts crypto.SignerOpts) (*Verifier, error) {
+	h, err := newHash(opts)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verifier{h: h}
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		v.verify = func(digest, sig []byte) bool {
+			return ecdsa.VerifyASN1(pub, digest, sig)
+		}
+	case *rsa.PublicKey:
+		v.verify = func(digest, sig []byte) bool {
+			if pss, ok := opts.(*rsa.PSSOptions); ok {
+				return rsa.VerifyPSS(pub, opts.HashFunc(), digest, sig, pss) == nil
+			}
+			return rsa.VerifyPKCS1v15(pub, opts.HashFunc(), digest, sig) == nil
+		}
+	case ed25519.PublicKey:
+		context, err := ed25519Context(opts)
+		if err != nil {
+			return nil, err
+		}
+		v.verify = func(digest, sig []byte) bool {
+			return edsig.VerifyPh(pub, digest, sig, context)
+		}
+	default:
+		return nil, errors.New("streamsign: unsupported key type")
+	}
+	return v, nil
+}
+
+// Write adds p to the message.
+func (v *Verifier) Write(p []byte) (int, error) {
+	return v.h.Write(p)
+}
+
+// Sum returns the digest of the message written so far.
+func (v *Verifier) Sum() []byte {
+	return v.h.Sum(nil)
+}
+
+// Verify reports whether sig is a valid signature of the message written so
+// far.
+func (v *Verifier) Verify(sig []byte) bool {
+	return v.verify(v.Sum(), sig)
+}
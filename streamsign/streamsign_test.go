@@ -0,0 +1,136 @@
+package streamsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+// stream writes message to w in small chunks.
+func stream(t *testing.T, w io.Writer, message []byte) {
+	t.Helper()
+	if _, err := io.CopyBuffer(w, bytes.NewReader(message), make([]byte, 7)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := bytes.Repeat([]byte("a large payload "), 1000)
+	for _, tc := range []struct {
+		name string
+		priv crypto.Signer
+		opts crypto.SignerOpts
+	}{
+		// The digest is longer than the order, and truncated.
+		{"ECDSA", ecKey, crypto.SHA512},
+		{"PKCS #1 v1.5", rsaKey, crypto.SHA256},
+		{"PSS", rsaKey, &rsa.PSSOptions{Hash: crypto.SHA256}},
+		{"Ed25519ph", edKey, crypto.SHA512},
+		{"Ed25519ph with a context", edKey, &Ed25519phOptions{Context: "test"}},
+	} {
+		s, err := NewSigner(tc.priv, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		stream(t, s, message)
+		sig, err := s.Sign(nil)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		v, err := NewVerifier(tc.priv.Public(), tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		stream(t, v, message)
+		if !bytes.Equal(v.Sum(), s.Sum()) {
+			t.Errorf("%s: digests differ", tc.name)
+		}
+		if !v.Verify(sig) {
+			t.Errorf("%s: signature rejected", tc.name)
+		}
+		v.Write([]byte("more"))
+		if v.Verify(sig) {
+			t.Errorf("%s: signature of another message accepted", tc.name)
+		}
+	}
+
+	// The signatures are those of the digest.
+	s, err := NewSigner(ecKey, crypto.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream(t, s, message)
+	sig, err := s.Sign(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(message)
+	if !ecdsa.VerifyASN1(&ecKey.PublicKey, digest[:], sig) {
+		t.Error("ECDSA signature differs from one over the digest")
+	}
+}
+
+// TestEd25519phVector uses the Ed25519ph test vector of RFC 8032, section
+// 7.3.
+func TestEd25519phVector(t *testing.T) {
+	seed, _ := hex.DecodeString("833fe62409237b9d62ec77587520911e9a759cec1d19755b7da901b96dca3d42")
+	want := "98a70222f0b8121aa9d30f813d683f809e462b469c7ff87639499bb94e6dae4131f85042463c2a355a2003d062adf5aaa10b8c61e636062aaad11c2a26083406"
+	s, err := NewSigner(ed25519.NewKeyFromSeed(seed), crypto.SHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Write([]byte("ab"))
+	s.Write([]byte("c"))
+	if digest := sha512.Sum512([]byte("abc")); !bytes.Equal(s.Sum(), digest[:]) {
+		t.Error("wrong digest")
+	}
+	sig, err := s.Sign(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(sig) != want {
+		t.Errorf("got %x, expected %s", sig, want)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSigner(edKey, crypto.Hash(0)); err == nil {
+		t.Error("pure Ed25519 accepted")
+	}
+	if _, err := NewSigner(edKey, crypto.SHA256); err == nil {
+		t.Error("Ed25519ph with SHA-256 accepted")
+	}
+	if _, err := NewVerifier(edKey.Public(), &Ed25519phOptions{Context: string(make([]byte, 256))}); err == nil {
+		t.Error("long context accepted")
+	}
+	if _, err := NewSigner("not a key", crypto.SHA256); err == nil {
+		t.Error("unsupported key accepted")
+	}
+}
@@ -0,0 +1,63 @@
+// Package strict implements a policy switch making the decoders of this module
+// reject every non-canonical encoding, for consensus-critical applications,
+// where two parties must agree on the validity of any input, and where
+// distinct encodings of the same value can't be tolerated.
+//
+// The policy is disabled by default, and the decoders accept what their
+// specifications allow. Once enabled with SetEnabled, the other packages of
+// the module reject, with a *NonCanonicalError, or by failing verification
+// when they only return a bool:
+//
+//   - X25519 public keys which aren't reduced, or have their top bit set,
+//     which RFC 7748 requires accepting;
+//   - ECDSA signatures whose s is larger than half the order, since (r, s)
+//     and (r, n - s) are both valid, which ecdsa.Sign then never produces;
+//   - RSA signatures which aren't reduced modulo the modulus, which are
+//     always rejected, but only reported as such with the policy enforced;
+//   - base64url encodings with non-zero trailing bits, in JWS.
+//
+// Other encodings, like those of points of edwards25519 and ristretto255,
+// scalars of Ed25519 signatures, or DER structures, are always required to be
+// canonical.
+package strict
+
+import "sync/atomic"
+
+// enabled is 1 if the policy is enforced.
+var enabled int32
+
+// SetEnabled sets whether the policy is enforced.
+func SetEnabled(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	atomic.StoreInt32(&enabled, v)
+}
+
+// Enabled reports whether the policy is enforced.
+func Enabled() bool {
+	return atomic.LoadInt32(&enabled) == 1
+}
+
+// NonCanonicalError is returned when a non-canonical encoding is decoded while
+// the policy is enforced.
+type NonCanonicalError struct {
+	// Encoding describes what was decoded, like "X25519 public key".
+	Encoding string
+	// Reason describes the check which failed, like "not reduced".
+	Reason string
+}
+
+func (e *NonCanonicalError) Error() string {
+	return "strict: non-canonical " + e.Encoding + ": " + e.Reason
+}
+
+// Check returns a *NonCanonicalError for encoding and reason if the policy is
+// enforced and canonical is false, and nil otherwise.
+func Check(canonical bool, encoding, reason string) error {
+	if canonical || !Enabled() {
+		return nil
+	}
+	return &NonCanonicalError{Encoding: encoding, Reason: reason}
+}
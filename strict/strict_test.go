@@ -0,0 +1,168 @@
+package strict_test
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/curve25519"
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/jose"
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/ctcrypto/strict"
+)
+
+// enable enforces the policy until the end of the test.
+func enable(t *testing.T) {
+	strict.SetEnabled(true)
+	t.Cleanup(func() { strict.SetEnabled(false) })
+}
+
+func nonCanonical(t *testing.T, what string, err error) {
+	t.Helper()
+	var e *strict.NonCanonicalError
+	if !errors.As(err, &e) {
+		t.Errorf("%s: got %v, expected a *NonCanonicalError", what, err)
+	}
+}
+
+func TestX25519(t *testing.T) {
+	scalar := make([]byte, curve25519.ScalarSize)
+	scalar[0] = 8
+	// The base point, with the top bit set, and its non-reduced equivalent
+	// p + 9.
+	topBit := make([]byte, curve25519.PointSize)
+	topBit[0], topBit[31] = 9, 0x80
+	unreduced := []byte{
+		0xf6, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+	}
+	for _, point := range [][]byte{topBit, unreduced} {
+		if _, err := curve25519.X25519(scalar, point); err != nil {
+			t.Errorf("%x rejected without the policy: %v", point, err)
+		}
+		if _, err := ecdh.X25519().NewPublicKey(point); err != nil {
+			t.Errorf("%x rejected without the policy: %v", point, err)
+		}
+	}
+
+	enable(t)
+	if _, err := curve25519.X25519(scalar, curve25519.Basepoint); err != nil {
+		t.Errorf("base point: %v", err)
+	}
+	for _, point := range [][]byte{topBit, unreduced} {
+		_, err := curve25519.X25519(scalar, point)
+		nonCanonical(t, "X25519", err)
+		_, err = ecdh.X25519().NewPublicKey(point)
+		nonCanonical(t, "NewPublicKey", err)
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("message"))
+	r, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	N := elliptic.P256().Params().N
+	half := new(big.Int).Rsh(N, 1)
+	if s.Cmp(half) <= 0 {
+		s.Sub(N, s)
+	}
+	if !ecdsa.Verify(&priv.PublicKey, hash[:], r, s) {
+		t.Error("high s rejected without the policy")
+	}
+
+	enable(t)
+	if ecdsa.Verify(&priv.PublicKey, hash[:], r, s) {
+		t.Error("high s accepted")
+	}
+	if !ecdsa.Verify(&priv.PublicKey, hash[:], r, new(big.Int).Sub(N, s)) {
+		t.Error("low s rejected")
+	}
+	for i := 0; i < 16; i++ {
+		_, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Cmp(half) > 0 {
+			t.Fatal("Sign produced a high s")
+		}
+	}
+}
+
+func TestRSA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("message"))
+	// N itself is the smallest representative which isn't reduced.
+	sig := priv.N.Bytes()
+	if err := rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hash[:], sig); !errors.Is(err, rsa.ErrVerification) {
+		t.Errorf("got %v, expected ErrVerification", err)
+	}
+
+	enable(t)
+	err = rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hash[:], sig)
+	nonCanonical(t, "VerifyPKCS1v15", err)
+	err = rsa.VerifyPSS(&priv.PublicKey, crypto.SHA256, hash[:], sig, nil)
+	nonCanonical(t, "VerifyPSS", err)
+
+	valid, err := rsa.SignPKCS1v15(priv, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hash[:], valid); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestJWS(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The token is signed with the policy enforced, so that its s is low.
+	enable(t)
+	// A payload of 2 bytes is encoded with 3 characters, whose last 2 bits are
+	// padding.
+	token, err := jose.SignCompact(rand.Reader, priv, nil, []byte("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := jose.VerifyCompact(&priv.PublicKey, token); err != nil {
+		t.Error(err)
+	}
+	parts := strings.Split(token, ".")
+	if parts[1] != base64.RawURLEncoding.EncodeToString([]byte("hi")) {
+		t.Fatalf("unexpected payload encoding %s", parts[1])
+	}
+	// "aGk" ends with a character of value 36, so "aGl" sets a padding bit.
+	parts[1] = "aGl"
+	tampered := strings.Join(parts, ".")
+	_, _, err = jose.VerifyCompact(&priv.PublicKey, tampered)
+	nonCanonical(t, "VerifyCompact", err)
+
+	// Without the policy, the signature is checked instead, over the
+	// signing input, which differs.
+	strict.SetEnabled(false)
+	_, _, err = jose.VerifyCompact(&priv.PublicKey, tampered)
+	var e *strict.NonCanonicalError
+	if err == nil || errors.As(err, &e) {
+		t.Errorf("got %v, expected an invalid signature", err)
+	}
+}
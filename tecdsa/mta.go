@@ -0,0 +1,65 @@
+package tecdsa
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/homomorphic"
+	"github.com/cronokirby/safenum"
+)
+
+// maskBits is the number of extra bits of the mask used by MtARespond, on top
+// of the size of the product it hides.
+const maskBits = 128
+
+// MtAStart starts a multiplicative-to-additive conversion, by encrypting the
+// secret a of the initiator under its own key, usually a Paillier key.
+//
+// The ciphertext is sent to the responder, and the initiator later passes the
+// reply to MtAFinish.
+func MtAStart(pk homomorphic.PublicKey, a *safenum.Nat, rand io.Reader) (homomorphic.Ciphertext, error) {
+	return pk.Encrypt(rand, a)
+}
+
+// MtARespond answers a conversion started by MtAStart, using the secret b of
+// the responder, and the order q of the group both secrets live in.
+//
+// This returns the reply to send to the initiator, and the share beta of the
+// responder, such that alpha + beta = a * b mod q, where alpha is the share
+// returned by MtAFinish.
+//
+// The plaintext modulus of the key must either be q itself, as with CL
+// encryption, or be large enough for a * b to be hidden by a random mask
+// without wrapping around, which is the case for Paillier keys of 2048 bits
+// with any of the NIST curves.
+func MtARespond(pk homomorphic.PublicKey, q *safenum.Modulus, cA homomorphic.Ciphertext, b *safenum.Nat, rand io.Reader) (homomorphic.Ciphertext, *safenum.Nat, error) {
+	size := 2*q.BitLen() + maskBits
+	if pk.PlaintextModulus().Cmp(q) != 0 && pk.PlaintextModulus().BitLen() < size+2 {
+		return nil, nil, errors.New("tecdsa: plaintext modulus is too small for this group")
+	}
+	buf := make([]byte, (size+7)/8)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return nil, nil, err
+	}
+	mask := new(safenum.Nat).SetBytes(buf)
+	encMask, err := pk.Encrypt(rand, mask)
+	if err != nil {
+		return nil, nil, err
+	}
+	cB := pk.Add(pk.ScalarMul(cA, new(safenum.Nat).Mod(b, q)), encMask)
+	beta := new(safenum.Nat).Mod(mask, q)
+	beta.ModSub(new(safenum.Nat), beta, q)
+	return cB, beta, nil
+}
+
+// MtAFinish completes a conversion, by decrypting the reply of the responder,
+// returning the share alpha of the initiator.
+func MtAFinish(sk homomorphic.PrivateKey, q *safenum.Modulus, cB homomorphic.Ciphertext) (*safenum.Nat, error) {
+	alpha, err := sk.Decrypt(cB)
+	if err != nil {
+		return nil, err
+	}
+	// The plaintext a * b + mask either doesn't wrap around the plaintext
+	// modulus, or is already reduced modulo q.
+	return alpha.Mod(alpha, q), nil
+}
@@ -0,0 +1,101 @@
+package tecdsa
+
+import (
+	"crypto/rand"
+	"sync"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/homomorphic"
+	"github.com/cronokirby/ctcrypto/paillier"
+)
+
+var (
+	testKeysOnce sync.Once
+	testKeys     []homomorphic.PrivateKey
+)
+
+// paillierKeys returns shared Paillier keys for the tests, since generating
+// them is slow.
+func paillierKeys(t *testing.T) []homomorphic.PrivateKey {
+	testKeysOnce.Do(func() {
+		for i := 0; i < 3; i++ {
+			sk, err := paillier.GenerateKey(rand.Reader, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testKeys = append(testKeys, homomorphic.PaillierPrivate(sk))
+		}
+	})
+	return testKeys
+}
+
+func TestMtA(t *testing.T) {
+	g := group.P256()
+	q := g.Order()
+	sk := paillierKeys(t)[0]
+	for i := 0; i < 5; i++ {
+		a, _ := g.RandomScalar(rand.Reader)
+		b, _ := g.RandomScalar(rand.Reader)
+		cA, err := MtAStart(sk.Public(), a, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cB, beta, err := MtARespond(sk.Public(), q, cA, b, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		alpha, err := MtAFinish(sk, q, cB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := alpha.ModAdd(alpha, beta, q)
+		if sum.Cmp(a.ModMul(a, b, q)) != 0 {
+			t.Errorf("alpha + beta != a * b")
+		}
+	}
+}
+
+func TestMtACL(t *testing.T) {
+	g := group.P256()
+	q := g.Order()
+	sk, err := homomorphic.GenerateCLKey(q, 600, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := g.RandomScalar(rand.Reader)
+	b, _ := g.RandomScalar(rand.Reader)
+	cA, _ := MtAStart(sk.Public(), a, rand.Reader)
+	cB, beta, err := MtARespond(sk.Public(), q, cA, b, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, err := MtAFinish(sk, q, cB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alpha.ModAdd(alpha, beta, q).Cmp(a.ModMul(a, b, q)) != 0 {
+		t.Errorf("alpha + beta != a * b")
+	}
+}
+
+func TestMtAModulusTooSmall(t *testing.T) {
+	g := group.P521()
+	sk := paillierKeys(t)[0]
+	a, _ := g.RandomScalar(rand.Reader)
+	cA, _ := MtAStart(sk.Public(), a, rand.Reader)
+	if _, _, err := MtARespond(sk.Public(), g.Order(), cA, a, rand.Reader); err == nil {
+		t.Errorf("accepted a Paillier modulus too small for P-521")
+	}
+
+	// ElGamal has the right plaintext modulus, but can't decrypt the reply.
+	e, _ := homomorphic.GenerateElGamalKey(group.P256(), 16, rand.Reader)
+	cA, _ = MtAStart(e.Public(), a, rand.Reader)
+	cB, _, err := MtARespond(e.Public(), group.P256().Order(), cA, a, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MtAFinish(e, group.P256().Order(), cB); err == nil {
+		t.Errorf("decrypted an ElGamal reply")
+	}
+}
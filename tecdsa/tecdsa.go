@@ -0,0 +1,207 @@
+// Package tecdsa implements the arithmetic at the core of threshold ECDSA
+// protocols, in the style of GG18 and CGGMP.
+//
+// Each participant i holds an additive share x_i of the secret key x, e.g. a
+// share of a distributed key multiplied by its Lagrange coefficient, as given
+// by shamir.LagrangeCoefficients. To presign, each participant samples a
+// Nonce, made of shares k_i and gamma_i, and broadcasts Gamma_i = gamma_i * G.
+// Then, for each pair of participants, the products k_i * gamma_j and
+// k_i * x_j are converted into additive shares with the multiplicative-to-
+// additive (MtA) conversion, using MtAStart, MtARespond and MtAFinish. Each
+// participant sums their shares with LocalShares, and broadcasts its share of
+// delta = k * gamma. NewPresignature then yields R = delta^-1 * Gamma =
+// k^-1 * G, along with the shares of k and chi = k * x needed to sign. Once
+// the message is known, each participant broadcasts SignShare, and anyone can
+// Combine these into a signature.
+//
+// This package only provides these building blocks: the zero-knowledge proofs
+// needed to protect against malicious participants, as well as the transport
+// of messages, are up to the protocol using them. A presignature must never be
+// used to sign more than one message.
+package tecdsa
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// checkGroup returns an error if the group isn't one of the NIST curves, whose
+// points have an x-coordinate, as ECDSA requires.
+func checkGroup(g group.Group) error {
+	switch g.Name() {
+	case "P-256", "P-384", "P-521":
+		return nil
+	}
+	return errors.New("tecdsa: ECDSA is not supported over " + g.Name())
+}
+
+// xCoordinate returns the x-coordinate of a point, reduced modulo the order.
+func xCoordinate(g group.Group, p group.Element) *safenum.Nat {
+	// Points are encoded in compressed form, i.e. a prefix byte followed by the
+	// x-coordinate.
+	x := p.Bytes()[1:]
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(x), g.Order())
+}
+
+// digestToScalar converts a message digest into a scalar, truncating it to
+// the size of the order, as described in SEC 1, section 4.1.3.
+func digestToScalar(g group.Group, digest []byte) *safenum.Nat {
+	orderBits := int(g.Order().BitLen())
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+	m := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - orderBits; excess > 0 {
+		m.Rsh(m, uint(excess))
+	}
+	return new(safenum.Nat).Mod(new(safenum.Nat).SetBytes(m.Bytes()), g.Order())
+}
+
+// Nonce is the contribution of a participant to the nonce of a signature.
+type Nonce struct {
+	// K is the share of the nonce k.
+	K *safenum.Nat
+	// Gamma is the share of the mask gamma, used to compute k^-1 * G.
+	Gamma *safenum.Nat
+	// GammaPoint is Gamma * G, which is broadcast to the other participants.
+	GammaPoint group.Element
+}
+
+// NewNonce samples a random Nonce, reading randomness from rand.
+func NewNonce(g group.Group, rand io.Reader) (*Nonce, error) {
+	if err := checkGroup(g); err != nil {
+		return nil, err
+	}
+	k, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	gamma, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &Nonce{K: k, Gamma: gamma, GammaPoint: g.ScalarBaseMult(gamma)}, nil
+}
+
+// LocalShares computes the shares of delta = k * gamma and chi = k * x of a
+// participant, from its nonce, its additive share x of the secret key, and the
+// MtA shares it obtained with every other participant.
+//
+// The MtA shares for delta are those converting k_i * gamma_j or k_j * gamma_i,
+// and those for chi the ones converting k_i * x_j or k_j * x_i, whichever side
+// of the conversion this participant was on.
+func LocalShares(g group.Group, nonce *Nonce, x *safenum.Nat, deltaMtA, chiMtA []*safenum.Nat) (delta, chi *safenum.Nat) {
+	q := g.Order()
+	delta = new(safenum.Nat).ModMul(nonce.K, nonce.Gamma, q)
+	for _, s := range deltaMtA {
+		delta.ModAdd(delta, s, q)
+	}
+	chi = new(safenum.Nat).ModMul(nonce.K, x, q)
+	for _, s := range chiMtA {
+		chi.ModAdd(chi, s, q)
+	}
+	return delta, chi
+}
+
+// Presignature is the state of a participant after presigning, which lets it
+// produce a share of a signature once the message is known.
+type Presignature struct {
+	g group.Group
+	// R = k^-1 * G, and its x-coordinate r
+	point group.Element
+	r     *safenum.Nat
+	// the shares of k and chi = k * x
+	k, chi *safenum.Nat
+}
+
+// NewPresignature creates a Presignature, from the local shares of a
+// participant, as well as the GammaPoint and delta share broadcast by every
+// participant, including itself.
+//
+// An error is returned if delta = 0, or if R has an x-coordinate of zero, in
+// which case presigning must be restarted with new nonces.
+func NewPresignature(g group.Group, nonce *Nonce, chi *safenum.Nat, gammaPoints []group.Element, deltas []*safenum.Nat) (*Presignature, error) {
+	if err := checkGroup(g); err != nil {
+		return nil, err
+	}
+	q := g.Order()
+	delta := new(safenum.Nat).Mod(new(safenum.Nat), q)
+	for _, d := range deltas {
+		delta.ModAdd(delta, d, q)
+	}
+	// delta is now public, so we can branch on it.
+	if delta.EqZero() {
+		return nil, errors.New("tecdsa: delta is zero")
+	}
+	gamma := g.Identity()
+	for _, p := range gammaPoints {
+		gamma = gamma.Add(p)
+	}
+	R := gamma.ScalarMult(new(safenum.Nat).ModInverse(delta, q))
+	if R.IsIdentity() {
+		return nil, errors.New("tecdsa: R is the identity")
+	}
+	r := xCoordinate(g, R)
+	if r.EqZero() {
+		return nil, errors.New("tecdsa: r is zero")
+	}
+	return &Presignature{g: g, point: R, r: r, k: nonce.K, chi: chi}, nil
+}
+
+// Point returns the point R = k^-1 * G of the signature, which is passed to
+// Combine.
+func (p *Presignature) Point() group.Element {
+	return p.point
+}
+
+// SignShare returns the share of this participant of the signature of a
+// message digest, s_i = m * k_i + r * chi_i.
+func (p *Presignature) SignShare(digest []byte) *safenum.Nat {
+	q := p.g.Order()
+	m := digestToScalar(p.g, digest)
+	s := new(safenum.Nat).ModMul(m, p.k, q)
+	return s.ModAdd(s, new(safenum.Nat).ModMul(p.r, p.chi, q), q)
+}
+
+// Combine combines the signature shares of all participants into an ECDSA
+// signature (r, s) of a message digest, for the given R and public key.
+//
+// The signature is verified before being returned, so that an invalid share
+// results in an error, rather than an invalid signature.
+func Combine(g group.Group, R, publicKey group.Element, digest []byte, shares []*safenum.Nat) (r, s *big.Int, err error) {
+	if err := checkGroup(g); err != nil {
+		return nil, nil, err
+	}
+	q := g.Order()
+	rNat := xCoordinate(g, R)
+	sNat := new(safenum.Nat).Mod(new(safenum.Nat), q)
+	for _, share := range shares {
+		sNat.ModAdd(sNat, share, q)
+	}
+	if !verify(g, publicKey, digest, rNat, sNat) {
+		return nil, nil, errors.New("tecdsa: invalid signature share")
+	}
+	return new(big.Int).SetBytes(rNat.Bytes()), new(big.Int).SetBytes(sNat.Bytes()), nil
+}
+
+// verify checks an ECDSA signature (r, s), following SEC 1, section 4.1.4.
+func verify(g group.Group, publicKey group.Element, digest []byte, r, s *safenum.Nat) bool {
+	if r.EqZero() || s.EqZero() {
+		return false
+	}
+	q := g.Order()
+	sInv := new(safenum.Nat).ModInverse(s, q)
+	u1 := new(safenum.Nat).ModMul(digestToScalar(g, digest), sInv, q)
+	u2 := new(safenum.Nat).ModMul(r, sInv, q)
+	p := g.ScalarBaseMult(u1).Add(publicKey.ScalarMult(u2))
+	if p.IsIdentity() {
+		return false
+	}
+	return subtle.ConstantTimeCompare(xCoordinate(g, p).Bytes(), r.Bytes()) == 1
+}
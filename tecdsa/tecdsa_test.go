@@ -0,0 +1,139 @@
+package tecdsa
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/elliptic"
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// verifyReference verifies an ECDSA signature with math/big, independently of
+// the verification done by Combine.
+func verifyReference(curve elliptic.Curve, publicKey, digest []byte, r, s *big.Int) bool {
+	n := new(big.Int).SetBytes(curve.Params().N.Bytes())
+	x, y := elliptic.UnmarshalCompressed(curve, publicKey)
+	if x == nil || r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	e := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - n.BitLen(); excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	w := new(big.Int).ModInverse(s, n)
+	u1 := new(big.Int).Mul(e, w)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(r, w)
+	u2.Mod(u2, n)
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(x, y, u2.Bytes())
+	x, _ = curve.Add(x1, y1, x2, y2)
+	return x.Mod(x, n).Cmp(r) == 0
+}
+
+// mta runs a conversion of a * b between an initiator i, and a responder.
+func mta(t *testing.T, g group.Group, i int, a, b *safenum.Nat) (alpha, beta *safenum.Nat) {
+	sk := paillierKeys(t)[i]
+	cA, err := MtAStart(sk.Public(), a, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cB, beta, err := MtARespond(sk.Public(), g.Order(), cA, b, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha, err = MtAFinish(sk, g.Order(), cB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return alpha, beta
+}
+
+// presign runs presigning between participants holding additive shares xs of
+// a secret key, returning their presignatures.
+func presign(t *testing.T, g group.Group, xs []*safenum.Nat) []*Presignature {
+	n := len(xs)
+	nonces := make([]*Nonce, n)
+	gammaPoints := make([]group.Element, n)
+	for i := range nonces {
+		nonce, err := NewNonce(g, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonces[i] = nonce
+		gammaPoints[i] = nonce.GammaPoint
+	}
+	deltaMtA := make([][]*safenum.Nat, n)
+	chiMtA := make([][]*safenum.Nat, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			alpha, beta := mta(t, g, i, nonces[i].K, nonces[j].Gamma)
+			deltaMtA[i] = append(deltaMtA[i], alpha)
+			deltaMtA[j] = append(deltaMtA[j], beta)
+			alpha, beta = mta(t, g, i, nonces[i].K, xs[j])
+			chiMtA[i] = append(chiMtA[i], alpha)
+			chiMtA[j] = append(chiMtA[j], beta)
+		}
+	}
+	deltas := make([]*safenum.Nat, n)
+	chis := make([]*safenum.Nat, n)
+	for i := range xs {
+		deltas[i], chis[i] = LocalShares(g, nonces[i], xs[i], deltaMtA[i], chiMtA[i])
+	}
+	presignatures := make([]*Presignature, n)
+	for i := range presignatures {
+		p, err := NewPresignature(g, nonces[i], chis[i], gammaPoints, deltas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		presignatures[i] = p
+	}
+	return presignatures
+}
+
+func TestSign(t *testing.T) {
+	g := group.P256()
+	xs := make([]*safenum.Nat, 3)
+	publicKey := g.Identity()
+	for i := range xs {
+		xs[i], _ = g.RandomScalar(rand.Reader)
+		publicKey = publicKey.Add(g.ScalarBaseMult(xs[i]))
+	}
+	presignatures := presign(t, g, xs)
+	for _, p := range presignatures[1:] {
+		if p.Point().Equal(presignatures[0].Point()) != 1 {
+			t.Fatalf("participants disagree on R")
+		}
+	}
+
+	digest := sha256.Sum256([]byte("threshold ECDSA"))
+	shares := make([]*safenum.Nat, len(presignatures))
+	for i, p := range presignatures {
+		shares[i] = p.SignShare(digest[:])
+	}
+	r, s, err := Combine(g, presignatures[0].Point(), publicKey, digest[:], shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !verifyReference(elliptic.P256(), publicKey.Bytes(), digest[:], r, s) {
+		t.Errorf("threshold signature doesn't verify")
+	}
+
+	shares[0] = shares[1]
+	if _, _, err := Combine(g, presignatures[0].Point(), publicKey, digest[:], shares); err == nil {
+		t.Errorf("Combine accepted an invalid share")
+	}
+}
+
+func TestUnsupportedGroup(t *testing.T) {
+	if _, err := NewNonce(group.Ristretto255(), rand.Reader); err == nil {
+		t.Errorf("NewNonce accepted ristretto255")
+	}
+}
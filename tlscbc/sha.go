@@ -0,0 +1,256 @@
+package tlscbc
+
+import (
+	"crypto"
+	"encoding/binary"
+	"math/bits"
+)
+
+// mdHash is a Merkle-Damgård hash function, exposing its compression function,
+// which the standard library hides.
+//
+// The state is held in 64 bit words, even for the hash functions with 32 bit
+// words.
+type mdHash struct {
+	size      int
+	blockSize int
+	// blockShift is log2(blockSize), to divide without a division instruction,
+	// whose time can depend on its operands.
+	blockShift uint
+	// lenSize is the size of the length at the end of the padding.
+	lenSize int
+	// wordSize is the size of the words of the output.
+	wordSize int
+	iv       [8]uint64
+	compress func(s *[8]uint64, block []byte)
+}
+
+var sha1Hash = &mdHash{
+	size:       20,
+	blockSize:  64,
+	blockShift: 6,
+	lenSize:    8,
+	wordSize:   4,
+	iv:         [8]uint64{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0},
+	compress:   compressSHA1,
+}
+
+var sha256Hash = &mdHash{
+	size:       32,
+	blockSize:  64,
+	blockShift: 6,
+	lenSize:    8,
+	wordSize:   4,
+	iv: [8]uint64{
+		0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+		0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+	},
+	compress: compressSHA256,
+}
+
+var sha384Hash = &mdHash{
+	size:       48,
+	blockSize:  128,
+	blockShift: 7,
+	lenSize:    16,
+	wordSize:   8,
+	iv: [8]uint64{
+		0xcbbb9d5dc1059ed8, 0x629a292a367cd507, 0x9159015a3070dd17, 0x152fecd8f70e5939,
+		0x67332667ffc00b31, 0x8eb44a8768581511, 0xdb0c2e0d64f98fa7, 0x47b5481dbefa4fa4,
+	},
+	compress: compressSHA512,
+}
+
+func hashFor(h crypto.Hash) *mdHash {
+	switch h {
+	case crypto.SHA1:
+		return sha1Hash
+	case crypto.SHA256:
+		return sha256Hash
+	case crypto.SHA384:
+		return sha384Hash
+	}
+	return nil
+}
+
+// output writes the digest held in s to out.
+func (md *mdHash) output(s *[8]uint64, out []byte) {
+	for i := 0; i < md.size/md.wordSize; i++ {
+		if md.wordSize == 4 {
+			binary.BigEndian.PutUint32(out[4*i:], uint32(s[i]))
+		} else {
+			binary.BigEndian.PutUint64(out[8*i:], s[i])
+		}
+	}
+}
+
+// sum returns the digest of msg, whose length is public.
+func (md *mdHash) sum(msg []byte) []byte {
+	s := md.iv
+	n := len(msg)
+	for len(msg) >= md.blockSize {
+		md.compress(&s, msg[:md.blockSize])
+		msg = msg[md.blockSize:]
+	}
+	block := make([]byte, 2*md.blockSize)
+	copy(block, msg)
+	block[len(msg)] = 0x80
+	end := md.blockSize
+	if len(msg)+1+md.lenSize > md.blockSize {
+		end = 2 * md.blockSize
+	}
+	binary.BigEndian.PutUint64(block[end-8:], uint64(n)*8)
+	for i := 0; i < end; i += md.blockSize {
+		md.compress(&s, block[i:i+md.blockSize])
+	}
+	out := make([]byte, md.size)
+	md.output(&s, out)
+	return out
+}
+
+// hmacKeys returns the key xored with the inner and outer pads of HMAC.
+func (md *mdHash) hmacKeys(key []byte) (ipad, opad []byte) {
+	if len(key) > md.blockSize {
+		key = md.sum(key)
+	}
+	ipad = make([]byte, md.blockSize)
+	opad = make([]byte, md.blockSize)
+	copy(ipad, key)
+	copy(opad, key)
+	for i := range ipad {
+		ipad[i] ^= 0x36
+		opad[i] ^= 0x5c
+	}
+	return ipad, opad
+}
+
+// hmac returns the HMAC of msg, whose length is public.
+func (md *mdHash) hmac(key, msg []byte) []byte {
+	ipad, opad := md.hmacKeys(key)
+	inner := md.sum(append(ipad, msg...))
+	return md.sum(append(opad, inner...))
+}
+
+func compressSHA1(s *[8]uint64, block []byte) {
+	var w [80]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	for i := 16; i < 80; i++ {
+		w[i] = bits.RotateLeft32(w[i-3]^w[i-8]^w[i-14]^w[i-16], 1)
+	}
+	a, b, c, d, e := uint32(s[0]), uint32(s[1]), uint32(s[2]), uint32(s[3]), uint32(s[4])
+	for i := 0; i < 80; i++ {
+		var f, k uint32
+		switch {
+		case i < 20:
+			f, k = b&c|^b&d, 0x5a827999
+		case i < 40:
+			f, k = b^c^d, 0x6ed9eba1
+		case i < 60:
+			f, k = b&c|b&d|c&d, 0x8f1bbcdc
+		default:
+			f, k = b^c^d, 0xca62c1d6
+		}
+		t := bits.RotateLeft32(a, 5) + f + e + k + w[i]
+		a, b, c, d, e = t, a, bits.RotateLeft32(b, 30), c, d
+	}
+	s[0] = uint64(uint32(s[0]) + a)
+	s[1] = uint64(uint32(s[1]) + b)
+	s[2] = uint64(uint32(s[2]) + c)
+	s[3] = uint64(uint32(s[3]) + d)
+	s[4] = uint64(uint32(s[4]) + e)
+}
+
+var k256 = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+func compressSHA256(s *[8]uint64, block []byte) {
+	var w [64]uint32
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint32(block[4*i:])
+	}
+	for i := 16; i < 64; i++ {
+		s0 := bits.RotateLeft32(w[i-15], -7) ^ bits.RotateLeft32(w[i-15], -18) ^ w[i-15]>>3
+		s1 := bits.RotateLeft32(w[i-2], -17) ^ bits.RotateLeft32(w[i-2], -19) ^ w[i-2]>>10
+		w[i] = w[i-16] + s0 + w[i-7] + s1
+	}
+	var v [8]uint32
+	for i := range v {
+		v[i] = uint32(s[i])
+	}
+	a, b, c, d, e, f, g, h := v[0], v[1], v[2], v[3], v[4], v[5], v[6], v[7]
+	for i := 0; i < 64; i++ {
+		s1 := bits.RotateLeft32(e, -6) ^ bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)
+		ch := e&f ^ ^e&g
+		t1 := h + s1 + ch + k256[i] + w[i]
+		s0 := bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^ bits.RotateLeft32(a, -22)
+		maj := a&b ^ a&c ^ b&c
+		t2 := s0 + maj
+		a, b, c, d, e, f, g, h = t1+t2, a, b, c, d+t1, e, f, g
+	}
+	for i, x := range [8]uint32{a, b, c, d, e, f, g, h} {
+		s[i] = uint64(v[i] + x)
+	}
+}
+
+var k512 = [80]uint64{
+	0x428a2f98d728ae22, 0x7137449123ef65cd, 0xb5c0fbcfec4d3b2f, 0xe9b5dba58189dbbc,
+	0x3956c25bf348b538, 0x59f111f1b605d019, 0x923f82a4af194f9b, 0xab1c5ed5da6d8118,
+	0xd807aa98a3030242, 0x12835b0145706fbe, 0x243185be4ee4b28c, 0x550c7dc3d5ffb4e2,
+	0x72be5d74f27b896f, 0x80deb1fe3b1696b1, 0x9bdc06a725c71235, 0xc19bf174cf692694,
+	0xe49b69c19ef14ad2, 0xefbe4786384f25e3, 0x0fc19dc68b8cd5b5, 0x240ca1cc77ac9c65,
+	0x2de92c6f592b0275, 0x4a7484aa6ea6e483, 0x5cb0a9dcbd41fbd4, 0x76f988da831153b5,
+	0x983e5152ee66dfab, 0xa831c66d2db43210, 0xb00327c898fb213f, 0xbf597fc7beef0ee4,
+	0xc6e00bf33da88fc2, 0xd5a79147930aa725, 0x06ca6351e003826f, 0x142929670a0e6e70,
+	0x27b70a8546d22ffc, 0x2e1b21385c26c926, 0x4d2c6dfc5ac42aed, 0x53380d139d95b3df,
+	0x650a73548baf63de, 0x766a0abb3c77b2a8, 0x81c2c92e47edaee6, 0x92722c851482353b,
+	0xa2bfe8a14cf10364, 0xa81a664bbc423001, 0xc24b8b70d0f89791, 0xc76c51a30654be30,
+	0xd192e819d6ef5218, 0xd69906245565a910, 0xf40e35855771202a, 0x106aa07032bbd1b8,
+	0x19a4c116b8d2d0c8, 0x1e376c085141ab53, 0x2748774cdf8eeb99, 0x34b0bcb5e19b48a8,
+	0x391c0cb3c5c95a63, 0x4ed8aa4ae3418acb, 0x5b9cca4f7763e373, 0x682e6ff3d6b2b8a3,
+	0x748f82ee5defb2fc, 0x78a5636f43172f60, 0x84c87814a1f0ab72, 0x8cc702081a6439ec,
+	0x90befffa23631e28, 0xa4506cebde82bde9, 0xbef9a3f7b2c67915, 0xc67178f2e372532b,
+	0xca273eceea26619c, 0xd186b8c721c0c207, 0xeada7dd6cde0eb1e, 0xf57d4f7fee6ed178,
+	0x06f067aa72176fba, 0x0a637dc5a2c898a6, 0x113f9804bef90dae, 0x1b710b35131c471b,
+	0x28db77f523047d84, 0x32caab7b40c72493, 0x3c9ebe0a15c9bebc, 0x431d67c49c100d4c,
+	0x4cc5d4becb3e42b6, 0x597f299cfc657e2a, 0x5fcb6fab3ad6faec, 0x6c44198c4a475817,
+}
+
+func compressSHA512(s *[8]uint64, block []byte) {
+	var w [80]uint64
+	for i := 0; i < 16; i++ {
+		w[i] = binary.BigEndian.Uint64(block[8*i:])
+	}
+	for i := 16; i < 80; i++ {
+		s0 := bits.RotateLeft64(w[i-15], -1) ^ bits.RotateLeft64(w[i-15], -8) ^ w[i-15]>>7
+		s1 := bits.RotateLeft64(w[i-2], -19) ^ bits.RotateLeft64(w[i-2], -61) ^ w[i-2]>>6
+		w[i] = w[i-16] + s0 + w[i-7] + s1
+	}
+	a, b, c, d, e, f, g, h := s[0], s[1], s[2], s[3], s[4], s[5], s[6], s[7]
+	for i := 0; i < 80; i++ {
+		s1 := bits.RotateLeft64(e, -14) ^ bits.RotateLeft64(e, -18) ^ bits.RotateLeft64(e, -41)
+		ch := e&f ^ ^e&g
+		t1 := h + s1 + ch + k512[i] + w[i]
+		s0 := bits.RotateLeft64(a, -28) ^ bits.RotateLeft64(a, -34) ^ bits.RotateLeft64(a, -39)
+		maj := a&b ^ a&c ^ b&c
+		t2 := s0 + maj
+		a, b, c, d, e, f, g, h = t1+t2, a, b, c, d+t1, e, f, g
+	}
+	s[0] += a
+	s[1] += b
+	s[2] += c
+	s[3] += d
+	s[4] += e
+	s[5] += f
+	s[6] += g
+	s[7] += h
+}
@@ -0,0 +1,212 @@
+// Package tlscbc implements the MAC-then-encrypt records of the CBC cipher
+// suites of TLS 1.0 to 1.2, resisting the Lucky Thirteen attack.
+//
+// A record is encrypted as CBC(fragment || MAC || padding), so the position of
+// the MAC, and the length of the data it covers, depend on the padding, which
+// is only known after decryption. Checking the padding, and then computing the
+// HMAC of a fragment of that length, takes a time depending on the padding,
+// through the number of calls to the compression function of the hash, which
+// AlFardan and Paterson exploited to recover plaintexts.
+//
+// Open checks the padding and the MAC of a decrypted record running the
+// compression function the same number of times for every valid or invalid
+// padding, following the approach of Langley's fix in OpenSSL. The hash
+// functions are implemented in this package, for access to their compression
+// functions.
+package tlscbc
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/ctutil"
+)
+
+var (
+	errUnsupportedHash = errors.New("tlscbc: unsupported hash function")
+	// errBadRecordMAC is returned for both padding and MAC failures, which
+	// TLS doesn't distinguish either.
+	errBadRecordMAC = errors.New("tlscbc: bad record MAC")
+)
+
+// maxPadding is the size of the largest padding, including its length byte.
+const maxPadding = 256
+
+// headerSize is the size of the data covered by the MAC before the fragment:
+// the sequence number, type, version, and length of the record.
+const headerSize = 13
+
+// header returns the data covered by the MAC before the fragment, without the
+// length.
+func header(seq uint64, recordType uint8, version uint16) []byte {
+	h := make([]byte, headerSize)
+	binary.BigEndian.PutUint64(h, seq)
+	h[8] = recordType
+	binary.BigEndian.PutUint16(h[9:], version)
+	return h
+}
+
+// Seal appends the MAC of fragment to it, with the given HMAC key, sequence
+// number, type and version, followed by the padding up to a multiple of
+// blockSize. The result is ready for CBC encryption.
+//
+// The hash must be crypto.SHA1, crypto.SHA256, or crypto.SHA384.
+func Seal(hash crypto.Hash, macKey []byte, seq uint64, recordType uint8, version uint16, fragment []byte, blockSize int) ([]byte, error) {
+	md := hashFor(hash)
+	if md == nil {
+		return nil, errUnsupportedHash
+	}
+	if blockSize < 1 || blockSize > maxPadding {
+		return nil, errors.New("tlscbc: invalid block size")
+	}
+	msg := header(seq, recordType, version)
+	binary.BigEndian.PutUint16(msg[11:], uint16(len(fragment)))
+	msg = append(msg, fragment...)
+	mac := md.hmac(macKey, msg)
+
+	out := append(append([]byte(nil), fragment...), mac...)
+	pad := blockSize - (len(out)+1)%blockSize
+	if pad == blockSize {
+		pad = 0
+	}
+	for i := 0; i <= pad; i++ {
+		out = append(out, byte(pad))
+	}
+	return out, nil
+}
+
+// Open checks the padding and the MAC of a decrypted record, and returns its
+// fragment, using the same HMAC key, sequence number, type and version as
+// Seal. With TLS 1.1 and 1.2, the explicit IV must be removed from payload
+// first.
+//
+// The time taken depends on len(payload), but not on the padding, nor on
+// whether the record is valid. Failures of the padding or of the MAC are
+// reported with the same error.
+func Open(hash crypto.Hash, macKey []byte, seq uint64, recordType uint8, version uint16, payload []byte) ([]byte, error) {
+	md := hashFor(hash)
+	if md == nil {
+		return nil, errUnsupportedHash
+	}
+	n := len(payload)
+	if n < md.size+1 {
+		return nil, errBadRecordMAC
+	}
+
+	// Check the padding. The last maxPadding bytes are read, whatever the
+	// padding length, and an invalid padding is replaced with an empty one,
+	// so that the rest proceeds with a plausible length.
+	pad := int(payload[n-1])
+	good := subtle.ConstantTimeLessOrEq(pad+1+md.size, n)
+	toCheck := maxPadding
+	if toCheck > n {
+		toCheck = n
+	}
+	for i := 0; i < toCheck; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, pad)
+		matches := subtle.ConstantTimeByteEq(payload[n-1-i], byte(pad))
+		good &= matches | (inPadding ^ 1)
+	}
+	pad = subtle.ConstantTimeSelect(good, pad, 0)
+
+	// The fragment is followed by the MAC, so it is between maxLen and minLen
+	// bytes long, depending on the padding.
+	length := n - md.size - 1 - pad
+	maxLen := n - md.size - 1
+	minLen := maxLen - (maxPadding - 1)
+	if minLen < 0 {
+		minLen = 0
+	}
+
+	mac := extractMAC(payload, length, minLen, maxLen, md.size)
+	expected := md.recordMAC(macKey, header(seq, recordType, version), payload, length, minLen, maxLen)
+	good &= subtle.ConstantTimeCompare(mac, expected)
+	if good != 1 {
+		return nil, errBadRecordMAC
+	}
+	return payload[:length], nil
+}
+
+// extractMAC returns the size bytes at offset length in payload, where length
+// is secret, and between minLen and maxLen.
+func extractMAC(payload []byte, length, minLen, maxLen, size int) []byte {
+	mac := make([]byte, size)
+	for i := minLen; i <= maxLen; i++ {
+		mask := byte(-subtle.ConstantTimeEq(int32(i), int32(length)))
+		for k := range mac {
+			mac[k] |= payload[i+k] & mask
+		}
+	}
+	return mac
+}
+
+// recordMAC returns the HMAC of hdr, the length, and the first length bytes
+// of payload, where length is secret, and between minLen and maxLen.
+//
+// The compression function is called on every block which the hash of the
+// longest message could need, and the state after the last block of the
+// actual message is selected. Blocks which only depend on public data are
+// compressed as usual.
+func (md *mdHash) recordMAC(key, hdr, payload []byte, length, minLen, maxLen int) []byte {
+	ipad, opad := md.hmacKeys(key)
+	bs := md.blockSize
+
+	// The inner hash covers ipad || hdr || length || fragment. The fragment
+	// starts at offset start, and the padding of the hash at offset end.
+	start := bs + headerSize
+	end := start + length
+	bitLen := uint64(end) * 8
+	// The last block is the one holding the length, after the 0x80 byte.
+	last := (end + md.lenSize) >> md.blockShift
+	maxLast := (start + maxLen + md.lenSize) >> md.blockShift
+
+	s := md.iv
+	md.compress(&s, ipad)
+	var result [8]uint64
+	block := make([]byte, bs)
+	for i := 1; i <= maxLast; i++ {
+		offset := i * bs
+		if i > 1 && offset+bs <= start+minLen {
+			md.compress(&s, payload[offset-start:offset-start+bs])
+			continue
+		}
+		isLast := subtle.ConstantTimeEq(int32(i), int32(last))
+		for t := range block {
+			j := offset + t
+			var b byte
+			switch {
+			case j < start-2:
+				b = hdr[j-bs]
+			case j == start-2:
+				b = byte(length >> 8)
+			case j == start-1:
+				b = byte(length)
+			default:
+				d := j - start
+				if d < len(payload) {
+					b = payload[d] & byte(-subtle.ConstantTimeLessOrEq(d+1, length))
+				}
+				b |= 0x80 & byte(-subtle.ConstantTimeEq(int32(d), int32(length)))
+			}
+			if e := bs - 1 - t; e < md.lenSize {
+				var lenByte byte
+				if e < 8 {
+					lenByte = byte(bitLen >> (8 * e))
+				}
+				mask := byte(-isLast)
+				b = b&^mask | lenByte&mask
+			}
+			block[t] = b
+		}
+		md.compress(&s, block)
+		for k := range result {
+			result[k] = ctutil.Select64(isLast, s[k], result[k])
+		}
+	}
+
+	inner := make([]byte, md.size)
+	md.output(&result, inner)
+	return md.sum(append(opad, inner...))
+}
@@ -0,0 +1,127 @@
+package tlscbc
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"testing"
+)
+
+var hashes = []crypto.Hash{crypto.SHA1, crypto.SHA256, crypto.SHA384}
+
+func TestHashes(t *testing.T) {
+	for _, h := range hashes {
+		md := hashFor(h)
+		for n := 0; n < 300; n++ {
+			msg := make([]byte, n)
+			rand.Read(msg)
+			expected := h.New()
+			expected.Write(msg)
+			if actual := md.sum(msg); !bytes.Equal(actual, expected.Sum(nil)) {
+				t.Fatalf("%v of %d bytes: %x, expected %x", h, n, actual, expected.Sum(nil))
+			}
+			key := msg[:n/2]
+			mac := hmac.New(h.New, key)
+			mac.Write(msg)
+			if actual := md.hmac(key, msg); !bytes.Equal(actual, mac.Sum(nil)) {
+				t.Fatalf("HMAC-%v of %d bytes: %x, expected %x", h, n, actual, mac.Sum(nil))
+			}
+		}
+	}
+}
+
+// withPadding replaces the padding of a sealed record by one of pad bytes.
+func withPadding(record []byte, fragmentLen, macLen, pad int) []byte {
+	out := append([]byte(nil), record[:fragmentLen+macLen]...)
+	for i := 0; i <= pad; i++ {
+		out = append(out, byte(pad))
+	}
+	return out
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := []byte("a key for the record MAC")
+	for _, h := range hashes {
+		for _, n := range []int{0, 1, 15, 16, 55, 56, 64, 200, 1000} {
+			fragment := make([]byte, n)
+			rand.Read(fragment)
+			record, err := Seal(h, key, 7, 23, 0x0303, fragment, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(record)%16 != 0 {
+				t.Fatalf("%v: record of length %d", h, len(record))
+			}
+			// Longer paddings are allowed, but they must still be checked
+			// and stripped.
+			for _, pad := range []int{int(record[len(record)-1]), 0, 17, 255} {
+				r := withPadding(record, n, h.Size(), pad)
+				out, err := Open(h, key, 7, 23, 0x0303, r)
+				if err != nil {
+					t.Fatalf("%v, %d bytes, padding %d: %v", h, n, pad, err)
+				}
+				if !bytes.Equal(out, fragment) {
+					t.Errorf("%v, %d bytes, padding %d: Open = %x", h, n, pad, out)
+				}
+			}
+		}
+	}
+}
+
+func TestOpenRejects(t *testing.T) {
+	key := []byte("a key for the record MAC")
+	fragment := []byte("GET / HTTP/1.1\r\n\r\n")
+	record, _ := Seal(crypto.SHA256, key, 1, 23, 0x0303, fragment, 16)
+
+	for i := range record {
+		tampered := append([]byte(nil), record...)
+		tampered[i] ^= 1
+		if _, err := Open(crypto.SHA256, key, 1, 23, 0x0303, tampered); err == nil {
+			t.Errorf("Open accepted a record with byte %d flipped", i)
+		}
+	}
+	if _, err := Open(crypto.SHA256, key, 2, 23, 0x0303, record); err == nil {
+		t.Error("Open accepted the wrong sequence number")
+	}
+	if _, err := Open(crypto.SHA256, key, 1, 22, 0x0303, record); err == nil {
+		t.Error("Open accepted the wrong record type")
+	}
+	if _, err := Open(crypto.SHA256, key, 1, 23, 0x0303, record[:20]); err == nil {
+		t.Error("Open accepted a truncated record")
+	}
+	if _, err := Open(crypto.MD5, key, 1, 23, 0x0303, record); err == nil {
+		t.Error("Open accepted MD5")
+	}
+}
+
+func TestUniformCompressions(t *testing.T) {
+	key := []byte("a key for the record MAC")
+	for _, h := range hashes {
+		md := *hashFor(h)
+		count := 0
+		compress := md.compress
+		md.compress = func(s *[8]uint64, block []byte) {
+			count++
+			compress(s, block)
+		}
+		hdr := header(0, 23, 0x0303)
+		payload := make([]byte, 512)
+		maxLen := len(payload) - md.size - 1
+		minLen := maxLen - 255
+		expected := -1
+		for length := minLen; length <= maxLen; length++ {
+			count = 0
+			md.recordMAC(key, hdr, payload, length, minLen, maxLen)
+			if expected == -1 {
+				expected = count
+			}
+			if count != expected {
+				t.Fatalf("%v: %d compressions with a fragment of %d bytes, and %d with %d bytes", h, count, length, expected, minLen)
+			}
+		}
+	}
+}
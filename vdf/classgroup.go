@@ -0,0 +1,116 @@
+package vdf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/classgroup"
+)
+
+type classGroup struct {
+	g *classgroup.Group
+}
+
+// ClassGroup returns the class group of a discriminant, which must be negative
+// and congruent to 1 modulo 4, and should be the opposite of a large prime,
+// for the order of the group to be unknown.
+func ClassGroup(discriminant *big.Int) (Group, error) {
+	g, err := classgroup.NewGroup(discriminant)
+	if err != nil {
+		return nil, err
+	}
+	return &classGroup{g}, nil
+}
+
+// hashToInt hashes data, with a tag and counter, to an integer of the given
+// size, with its top bit set.
+func hashToInt(tag string, data []byte, counter uint64, bits int) *big.Int {
+	var buf [8]byte
+	out := make([]byte, 0, (bits+7)/8+sha256.Size)
+	for i := uint32(0); len(out) < (bits+7)/8; i++ {
+		h := sha256.New()
+		h.Write([]byte(tag))
+		binary.BigEndian.PutUint64(buf[:], counter)
+		h.Write(buf[:])
+		binary.BigEndian.PutUint32(buf[:4], i)
+		h.Write(buf[:4])
+		h.Write(data)
+		out = h.Sum(out)
+	}
+	x := new(big.Int).SetBytes(out[:(bits+7)/8])
+	x.Rsh(x, uint(8*((bits+7)/8)-bits))
+	return x.SetBit(x, bits-1, 1)
+}
+
+// DiscriminantFromSeed derives a discriminant -p of the given size, in bits,
+// from a public seed, where p is a prime congruent to 3 modulo 4, so that the
+// order of its class group is unknown to everyone.
+//
+// The size should be at least 1024 bits.
+func DiscriminantFromSeed(seed []byte, bits int) (*big.Int, error) {
+	if bits < 16 {
+		return nil, errors.New("vdf: invalid discriminant size")
+	}
+	for i := uint64(0); ; i++ {
+		p := hashToInt("ctcrypto-vdf-discriminant-v1", seed, i, bits)
+		p.SetBit(p, 0, 1).SetBit(p, 1, 1)
+		if p.ProbablyPrime(20) {
+			return p.Neg(p), nil
+		}
+	}
+}
+
+// HashToElement hashes the input to a prime form (q, b, c), trying successive
+// primes q, until the discriminant is a square modulo q.
+func (g *classGroup) HashToElement(input []byte) Element {
+	for i := uint64(0); ; i++ {
+		q := hashToInt("ctcrypto-vdf-classgroup-v1", input, i, 128)
+		q.SetBit(q, 0, 1)
+		if !q.ProbablyPrime(20) {
+			continue
+		}
+		if f, err := g.g.PrimeForm(q); err == nil {
+			return &classElement{g.g, f}
+		}
+	}
+}
+
+func (g *classGroup) Identity() Element {
+	return &classElement{g.g, g.g.Identity()}
+}
+
+func (g *classGroup) DecodeElement(data []byte) (Element, error) {
+	f, err := g.g.DecodeForm(data)
+	if err != nil {
+		return nil, err
+	}
+	return &classElement{g.g, f}, nil
+}
+
+type classElement struct {
+	g *classgroup.Group
+	f *classgroup.Form
+}
+
+func (x *classElement) Mul(other Element) Element {
+	return &classElement{x.g, x.g.Compose(x.f, other.(*classElement).f)}
+}
+
+func (x *classElement) Square() Element {
+	return &classElement{x.g, x.g.Square(x.f)}
+}
+
+func (x *classElement) Exp(k *big.Int) Element {
+	return &classElement{x.g, x.g.Exp(x.f, k)}
+}
+
+func (x *classElement) Equal(other Element) bool {
+	y, ok := other.(*classElement)
+	return ok && x.f.Equal(y.f)
+}
+
+func (x *classElement) Bytes() []byte {
+	return x.g.Bytes(x.f)
+}
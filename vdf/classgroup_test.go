@@ -0,0 +1,25 @@
+package vdf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestClassGroup(t *testing.T) {
+	d, err := DiscriminantFromSeed([]byte("seed"), 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.BitLen() != 512 || new(big.Int).Mod(d, big.NewInt(4)).Int64() != 1 {
+		t.Errorf("invalid discriminant %v", d)
+	}
+	again, _ := DiscriminantFromSeed([]byte("seed"), 512)
+	if d.Cmp(again) != 0 {
+		t.Error("discriminant isn't deterministic")
+	}
+	g, err := ClassGroup(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testVDF(t, g)
+}
@@ -0,0 +1,92 @@
+package vdf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// rsaGroup is the group of integers modulo N, quotiented by {1, -1}, so that
+// -1, whose order is known, can't be used to forge proofs.
+type rsaGroup struct {
+	n *big.Int
+	// half is (N - 1) / 2, the largest canonical representative
+	half *big.Int
+}
+
+// RSAGroup returns the group of units modulo an RSA modulus N, whose
+// factorization must be unknown to everyone, such as the modulus of the RSA
+// Factoring Challenge, RSA-2048.
+func RSAGroup(n *big.Int) (Group, error) {
+	if n.Sign() <= 0 || n.Bit(0) != 1 || n.BitLen() < 64 {
+		return nil, errors.New("vdf: invalid modulus")
+	}
+	return &rsaGroup{n: new(big.Int).Set(n), half: new(big.Int).Rsh(n, 1)}, nil
+}
+
+type rsaElement struct {
+	g *rsaGroup
+	// x is the representative of ±x in [1, (N - 1) / 2]
+	x *big.Int
+}
+
+func (g *rsaGroup) element(x *big.Int) *rsaElement {
+	if x.Cmp(g.half) > 0 {
+		x.Sub(g.n, x)
+	}
+	return &rsaElement{g, x}
+}
+
+func (g *rsaGroup) Identity() Element {
+	return &rsaElement{g, big.NewInt(1)}
+}
+
+// HashToElement expands the input to the size of N, plus 128 bits, with
+// SHA-256 in counter mode, and reduces the result modulo N.
+func (g *rsaGroup) HashToElement(input []byte) Element {
+	size := (g.n.BitLen() + 128 + 7) / 8
+	buf := make([]byte, 0, size+sha256.Size)
+	var counter [4]byte
+	for i := uint32(0); len(buf) < size; i++ {
+		h := sha256.New()
+		h.Write([]byte("ctcrypto-vdf-rsa-v1"))
+		binary.BigEndian.PutUint32(counter[:], i)
+		h.Write(counter[:])
+		h.Write(input)
+		buf = h.Sum(buf)
+	}
+	x := new(big.Int).SetBytes(buf[:size])
+	return g.element(x.Mod(x, g.n))
+}
+
+func (g *rsaGroup) DecodeElement(data []byte) (Element, error) {
+	x := new(big.Int).SetBytes(data)
+	if len(data) != (g.n.BitLen()+7)/8 || x.Sign() <= 0 || x.Cmp(g.half) > 0 {
+		return nil, errors.New("vdf: invalid element encoding")
+	}
+	return &rsaElement{g, x}, nil
+}
+
+func (x *rsaElement) Mul(other Element) Element {
+	y := other.(*rsaElement)
+	z := new(big.Int).Mul(x.x, y.x)
+	return x.g.element(z.Mod(z, x.g.n))
+}
+
+func (x *rsaElement) Square() Element {
+	return x.Mul(x)
+}
+
+func (x *rsaElement) Exp(k *big.Int) Element {
+	return x.g.element(new(big.Int).Exp(x.x, k, x.g.n))
+}
+
+func (x *rsaElement) Equal(other Element) bool {
+	y, ok := other.(*rsaElement)
+	return ok && x.x.Cmp(y.x) == 0
+}
+
+func (x *rsaElement) Bytes() []byte {
+	return x.x.FillBytes(make([]byte, (x.g.n.BitLen()+7)/8))
+}
@@ -0,0 +1,40 @@
+package vdf
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func testModulus(t *testing.T) *big.Int {
+	p, err := rand.Prime(rand.Reader, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := rand.Prime(rand.Reader, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return new(big.Int).Mul(p, q)
+}
+
+func TestRSAGroup(t *testing.T) {
+	g, err := RSAGroup(testModulus(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testVDF(t, g)
+}
+
+func TestRSAElementSign(t *testing.T) {
+	n := testModulus(t)
+	g, _ := RSAGroup(n)
+	x := g.HashToElement([]byte("input"))
+	minusX := g.(*rsaGroup).element(new(big.Int).Sub(n, x.(*rsaElement).x))
+	if !x.Equal(minusX) {
+		t.Error("x and -x aren't equal")
+	}
+	if _, err := g.DecodeElement(new(big.Int).Sub(n, big.NewInt(1)).Bytes()); err == nil {
+		t.Error("non-canonical element accepted")
+	}
+}
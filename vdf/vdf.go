@@ -0,0 +1,141 @@
+// Package vdf implements Wesolowski's verifiable delay function, from
+// "Efficient verifiable delay functions".
+//
+// The output of the function on an input x, with a delay T, is y = x^(2^T), in
+// a group of unknown order, which takes T sequential squarings to compute,
+// while the short proof attached to it can be checked with two small
+// exponentiations. This makes it useful for randomness beacons, where the
+// output is revealed after a delay nobody can shorten, even by controlling the
+// last contribution to the input.
+//
+// The group can be that of the integers modulo an RSA modulus, whose
+// factorization must be unknown to everyone, or a class group, which doesn't
+// need a trusted setup, since its discriminant can be derived from a public
+// seed, with DiscriminantFromSeed.
+//
+// Nothing here is secret, so the arithmetic is done with math/big, and isn't
+// constant-time.
+package vdf
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+)
+
+// Element is an element of a group of unknown order.
+//
+// Elements are immutable, and operations on them return new elements.
+type Element interface {
+	// Mul returns the product of the element with another one, of the same
+	// group.
+	Mul(other Element) Element
+	// Square returns the square of the element.
+	Square() Element
+	// Exp returns the element raised to a non-negative exponent.
+	Exp(k *big.Int) Element
+	// Equal reports whether the element is equal to another one.
+	Equal(other Element) bool
+	// Bytes returns the canonical encoding of the element.
+	Bytes() []byte
+}
+
+// Group is a group of unknown order, in which the function is evaluated.
+type Group interface {
+	// Identity returns the identity element of the group.
+	Identity() Element
+	// HashToElement maps an input to an element of the group.
+	HashToElement(input []byte) Element
+	// DecodeElement decodes the canonical encoding of an element, as returned
+	// by Element.Bytes.
+	DecodeElement(data []byte) (Element, error)
+}
+
+// hashToPrime returns the prime challenge ℓ of a proof, of 256 bits, twice the
+// security level, by hashing its statement with an increasing counter, until
+// the result is prime.
+func hashToPrime(x, y Element, t uint64) *big.Int {
+	var buf [8]byte
+	h := sha256.New()
+	l := new(big.Int)
+	for i := uint64(0); ; i++ {
+		h.Reset()
+		h.Write([]byte("ctcrypto-vdf-wesolowski-v1"))
+		binary.BigEndian.PutUint64(buf[:], i)
+		h.Write(buf[:])
+		binary.BigEndian.PutUint64(buf[:], t)
+		h.Write(buf[:])
+		for _, e := range []Element{x, y} {
+			b := e.Bytes()
+			binary.BigEndian.PutUint64(buf[:], uint64(len(b)))
+			h.Write(buf[:])
+			h.Write(b)
+		}
+		digest := h.Sum(nil)
+		digest[0] |= 0x80
+		if l.SetBytes(digest).ProbablyPrime(20) {
+			return l
+		}
+	}
+}
+
+// Evaluate computes the output of the function on an input, with a delay of t
+// squarings, along with a proof that it's correct.
+//
+// This takes 2 t squarings: the proof is computed after the output, since it
+// depends on it.
+func Evaluate(g Group, input []byte, t uint64) (output, proof Element) {
+	x := g.HashToElement(input)
+	y := x
+	for i := uint64(0); i < t; i++ {
+		y = y.Square()
+	}
+	return y, prove(g, x, y, t)
+}
+
+// prove returns π = x^⌊2^t / ℓ⌋, computing the quotient bit by bit, with long
+// division, to avoid storing the t bits of 2^t.
+func prove(g Group, x, y Element, t uint64) Element {
+	l := hashToPrime(x, y, t)
+	pi := g.Identity()
+	r := big.NewInt(1)
+	for i := uint64(0); i < t; i++ {
+		// The next bit of the quotient is ⌊2r / ℓ⌋, and 2r mod ℓ the new
+		// remainder.
+		r.Lsh(r, 1)
+		pi = pi.Square()
+		if r.Cmp(l) >= 0 {
+			r.Sub(r, l)
+			pi = pi.Mul(x)
+		}
+	}
+	return pi
+}
+
+// Verify reports whether output is the output of the function on an input,
+// with a delay of t squarings, as shown by proof, which must both be elements
+// of g.
+func Verify(g Group, input []byte, t uint64, output, proof Element) bool {
+	x := g.HashToElement(input)
+	l := hashToPrime(x, output, t)
+	// π^ℓ x^(2^t mod ℓ) = x^(ℓ ⌊2^t / ℓ⌋ + 2^t mod ℓ) = x^(2^t)
+	r := new(big.Int).Exp(big.NewInt(2), new(big.Int).SetUint64(t), l)
+	return proof.Exp(l).Mul(x.Exp(r)).Equal(output)
+}
+
+// DecodeProof decodes the output and proof of an evaluation, from their
+// encodings, concatenated, which have the same size in the groups of this
+// package.
+func DecodeProof(g Group, data []byte) (output, proof Element, err error) {
+	if len(data)%2 != 0 {
+		return nil, nil, errors.New("vdf: invalid proof encoding")
+	}
+	if output, err = g.DecodeElement(data[:len(data)/2]); err != nil {
+		return nil, nil, err
+	}
+	if proof, err = g.DecodeElement(data[len(data)/2:]); err != nil {
+		return nil, nil, err
+	}
+	return output, proof, nil
+}
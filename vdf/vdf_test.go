@@ -0,0 +1,55 @@
+package vdf
+
+import (
+	"math/big"
+	"testing"
+)
+
+func testVDF(t *testing.T, g Group) {
+	input := []byte("beacon round 1")
+	const delay = 500
+	y, proof := Evaluate(g, input, delay)
+	x := g.HashToElement(input)
+	expected := x.Exp(new(big.Int).Lsh(big.NewInt(1), delay))
+	if !y.Equal(expected) {
+		t.Error("output isn't x^(2^T)")
+	}
+	l := hashToPrime(x, y, delay)
+	q := new(big.Int).Div(new(big.Int).Lsh(big.NewInt(1), delay), l)
+	if !proof.Equal(x.Exp(q)) {
+		t.Error("proof isn't x^⌊2^T / ℓ⌋")
+	}
+	if !Verify(g, input, delay, y, proof) {
+		t.Error("valid proof rejected")
+	}
+	if Verify(g, input, delay+1, y, proof) {
+		t.Error("proof accepted for another delay")
+	}
+	if Verify(g, []byte("beacon round 2"), delay, y, proof) {
+		t.Error("proof accepted for another input")
+	}
+	if Verify(g, input, delay, y.Mul(x), proof) {
+		t.Error("proof accepted for another output")
+	}
+	if Verify(g, input, delay, y, proof.Mul(x)) {
+		t.Error("invalid proof accepted")
+	}
+	output, decoded, err := DecodeProof(g, append(y.Bytes(), proof.Bytes()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !output.Equal(y) || !decoded.Equal(proof) {
+		t.Error("decoded proof differs")
+	}
+}
+
+func TestEvaluateZeroDelay(t *testing.T) {
+	g, _ := RSAGroup(testModulus(t))
+	y, proof := Evaluate(g, []byte("input"), 0)
+	if !y.Equal(g.HashToElement([]byte("input"))) {
+		t.Error("output with no delay differs from the input")
+	}
+	if !Verify(g, []byte("input"), 0, y, proof) {
+		t.Error("valid proof rejected")
+	}
+}
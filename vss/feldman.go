@@ -0,0 +1,48 @@
+package vss
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// FeldmanDeal splits a secret into n shares, for participants with identifiers
+// 1 through n, such that any threshold of them can reconstruct the secret.
+//
+// This also returns threshold commitments to the coefficients of the
+// polynomial used, the first one being secret * G, which must be broadcast to
+// all participants, for them to verify their shares with FeldmanVerify.
+func FeldmanDeal(g group.Group, secret *safenum.Nat, threshold, n int, rand io.Reader) ([]Share, []group.Element, error) {
+	if err := checkParams(threshold, n); err != nil {
+		return nil, nil, err
+	}
+	p, err := shamir.RandomPolynomial(g.Order(), secret, threshold, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = p.Share(uint32(i + 1))
+	}
+	return shares, commit(p, g.Generator()), nil
+}
+
+// FeldmanVerify checks that a share is consistent with the commitments of the
+// dealer, i.e. that share.Value * G is the commitment to the polynomial
+// evaluated at share.ID.
+func FeldmanVerify(g group.Group, share Share, commitments []group.Element) bool {
+	if share.ID == 0 || len(commitments) == 0 {
+		return false
+	}
+	expected := evaluateCommitments(g, commitments, share.ID)
+	return g.ScalarBaseMult(share.Value).Equal(expected) == 1
+}
+
+// FeldmanPublicShare returns share.Value * G for the participant with a given
+// identifier, computed from the commitments of the dealer, which is used to
+// verify what that participant later does with its share.
+func FeldmanPublicShare(g group.Group, commitments []group.Element, id uint32) group.Element {
+	return evaluateCommitments(g, commitments, id)
+}
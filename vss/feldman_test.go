@@ -0,0 +1,66 @@
+package vss
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func TestFeldmanVerify(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384()} {
+		secret, _ := g.RandomScalar(rand.Reader)
+		shares, commitments, err := FeldmanDeal(g, secret, 3, 5, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commitments) != 3 {
+			t.Errorf("%s: got %d commitments", g.Name(), len(commitments))
+		}
+		if commitments[0].Equal(g.ScalarBaseMult(secret)) != 1 {
+			t.Errorf("%s: first commitment isn't secret * G", g.Name())
+		}
+		for _, share := range shares {
+			if !FeldmanVerify(g, share, commitments) {
+				t.Errorf("%s: valid share %d rejected", g.Name(), share.ID)
+			}
+		}
+		bad := Share{ID: shares[0].ID, Value: new(safenum.Nat).ModAdd(shares[0].Value, new(safenum.Nat).SetUint64(1), g.Order())}
+		if FeldmanVerify(g, bad, commitments) {
+			t.Errorf("%s: invalid share accepted", g.Name())
+		}
+		swapped := Share{ID: shares[1].ID, Value: shares[0].Value}
+		if FeldmanVerify(g, swapped, commitments) {
+			t.Errorf("%s: share accepted for the wrong identifier", g.Name())
+		}
+	}
+}
+
+func TestFeldmanThresholdOne(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, err := FeldmanDeal(g, secret, 1, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		if share.Value.Cmp(secret) != 0 {
+			t.Errorf("share %d differs from the secret", share.ID)
+		}
+		if !FeldmanVerify(g, share, commitments) {
+			t.Errorf("valid share %d rejected", share.ID)
+		}
+	}
+}
+
+func TestFeldmanPublicShare(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, _ := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	for _, share := range shares {
+		if FeldmanPublicShare(g, commitments, share.ID).Equal(g.ScalarBaseMult(share.Value)) != 1 {
+			t.Errorf("wrong public share for %d", share.ID)
+		}
+	}
+}
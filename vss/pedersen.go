@@ -0,0 +1,76 @@
+package vss
+
+import (
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// PedersenShare is a share of Pedersen's scheme, containing a share of the
+// secret, along with a share of the blinding polynomial.
+type PedersenShare struct {
+	Share
+	// Blinding is the evaluation of the blinding polynomial at ID.
+	Blinding *safenum.Nat
+}
+
+// pedersenDST is the domain separation tag used to derive the second generator.
+var pedersenDST = []byte("ctcrypto-vss-pedersen-generator")
+
+// PedersenGenerator returns the second generator H used by Pedersen's scheme,
+// obtained by hashing to the group, so that nobody knows its discrete logarithm
+// with respect to the generator of the group.
+func PedersenGenerator(g group.Group) group.Element {
+	return g.HashToElement([]byte(g.Name()), pedersenDST)
+}
+
+// PedersenDeal splits a secret into n shares, for participants with
+// identifiers 1 through n, such that any threshold of them can reconstruct
+// the secret.
+//
+// This also returns threshold commitments, of the form a_k * G + b_k * H,
+// where a_k and b_k are the coefficients of the secret and blinding
+// polynomials. Unlike with Feldman's scheme, these commitments reveal nothing
+// about the secret.
+func PedersenDeal(g group.Group, secret *safenum.Nat, threshold, n int, rand io.Reader) ([]PedersenShare, []group.Element, error) {
+	if err := checkParams(threshold, n); err != nil {
+		return nil, nil, err
+	}
+	blindingSecret, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := shamir.RandomPolynomial(g.Order(), secret, threshold, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := shamir.RandomPolynomial(g.Order(), blindingSecret, threshold, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares := make([]PedersenShare, n)
+	for i := range shares {
+		id := uint32(i + 1)
+		shares[i] = PedersenShare{Share: p.Share(id), Blinding: b.Share(id).Value}
+	}
+	commitments := commit(p, g.Generator())
+	blindings := commit(b, PedersenGenerator(g))
+	for i := range commitments {
+		commitments[i] = commitments[i].Add(blindings[i])
+	}
+	return shares, commitments, nil
+}
+
+// PedersenVerify checks that a share is consistent with the commitments of the
+// dealer, i.e. that share.Value * G + share.Blinding * H is the commitment to
+// the polynomials evaluated at share.ID.
+func PedersenVerify(g group.Group, share PedersenShare, commitments []group.Element) bool {
+	if share.ID == 0 || len(commitments) == 0 {
+		return false
+	}
+	expected := evaluateCommitments(g, commitments, share.ID)
+	actual := g.ScalarBaseMult(share.Value).Add(PedersenGenerator(g).ScalarMult(share.Blinding))
+	return actual.Equal(expected) == 1
+}
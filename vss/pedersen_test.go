@@ -0,0 +1,47 @@
+package vss
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func TestPedersenVerify(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, err := PedersenDeal(g, secret, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if commitments[0].Equal(g.ScalarBaseMult(secret)) == 1 {
+		t.Errorf("commitment reveals secret * G")
+	}
+	for _, share := range shares {
+		if !PedersenVerify(g, share, commitments) {
+			t.Errorf("valid share %d rejected", share.ID)
+		}
+	}
+	bad := shares[0]
+	bad.Blinding = new(safenum.Nat).ModAdd(bad.Blinding, new(safenum.Nat).SetUint64(1), g.Order())
+	if PedersenVerify(g, bad, commitments) {
+		t.Errorf("share with an invalid blinding accepted")
+	}
+}
+
+func TestPedersenReconstruct(t *testing.T) {
+	g := group.P521()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, _, err := PedersenDeal(g, secret, 2, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Reconstruct(g, []Share{shares[2].Share, shares[0].Share})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) != 0 {
+		t.Errorf("wrong secret reconstructed")
+	}
+}
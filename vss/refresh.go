@@ -0,0 +1,121 @@
+package vss
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements proactive refreshing of shares, following Herzberg,
+// Jarecki, Krawczyk, and Yung, "Proactive secret sharing, or: how to cope with
+// perpetual leakage".
+//
+// Each participant deals shares of zero to the others, with Feldman's scheme,
+// and every participant adds the shares it receives to its own. The shares
+// end up on a new random polynomial with the same secret, so that the shares
+// leaked before the refresh are useless when combined with those leaked after.
+
+var (
+	errInvalidIDs      = errors.New("vss: participant identifiers must be distinct and non-zero")
+	errMismatchedShare = errors.New("vss: share doesn't belong to this participant")
+	errMismatchedSizes = errors.New("vss: commitments have different thresholds")
+)
+
+// checkIDs checks that ids are distinct and non-zero, and that threshold is
+// between 1 and the number of participants.
+func checkIDs(threshold int, ids []uint32) error {
+	if err := checkParams(threshold, len(ids)); err != nil {
+		return err
+	}
+	seen := make(map[uint32]bool, len(ids))
+	for _, id := range ids {
+		if id == 0 || seen[id] {
+			return errInvalidIDs
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// dealTo returns the shares of a polynomial for the participants in ids.
+func dealTo(p *shamir.Polynomial, ids []uint32) []Share {
+	shares := make([]Share, len(ids))
+	for i, id := range ids {
+		shares[i] = p.Share(id)
+	}
+	return shares
+}
+
+// RefreshDeal deals shares of zero to the participants in ids, with the same
+// threshold as the shares being refreshed.
+//
+// The commitments returned must be broadcast to all participants, and each
+// share sent privately to its recipient, who checks it with RefreshVerify.
+func RefreshDeal(g group.Group, threshold int, ids []uint32, rand io.Reader) ([]Share, []group.Element, error) {
+	if err := checkIDs(threshold, ids); err != nil {
+		return nil, nil, err
+	}
+	p, err := shamir.RandomPolynomial(g.Order(), new(safenum.Nat), threshold, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dealTo(p, ids), commit(p, g.Generator()), nil
+}
+
+// RefreshVerify checks that a share dealt by RefreshDeal is consistent with
+// the commitments of its dealer, and that these commit to a sharing of zero.
+func RefreshVerify(g group.Group, share Share, commitments []group.Element) bool {
+	return len(commitments) > 0 && commitments[0].IsIdentity() && FeldmanVerify(g, share, commitments)
+}
+
+// addCommitments returns the sum of the commitments to several polynomials,
+// each weighted by a scalar, which commits to the same combination of the
+// polynomials.
+func addCommitments(g group.Group, weights []*safenum.Nat, commitments [][]group.Element) ([]group.Element, error) {
+	out := make([]group.Element, len(commitments[0]))
+	for k := range out {
+		out[k] = g.Identity()
+	}
+	for i, c := range commitments {
+		if len(c) != len(out) {
+			return nil, errMismatchedSizes
+		}
+		for k := range c {
+			term := c[k]
+			if weights != nil {
+				term = term.ScalarMult(weights[i])
+			}
+			out[k] = out[k].Add(term)
+		}
+	}
+	return out, nil
+}
+
+// Refresh updates the share of a participant, by adding the shares of zero it
+// received from each dealer, whose commitments are given in the same order.
+// The updates should have been checked with RefreshVerify beforehand.
+//
+// This returns the new share, along with the new commitments to the shared
+// polynomial, obtained from the previous ones, commitments, which are the same
+// for all participants. In particular, the first commitment, to the secret,
+// doesn't change.
+func Refresh(g group.Group, share Share, commitments []group.Element, updates []Share, updateCommitments [][]group.Element) (Share, []group.Element, error) {
+	if len(updates) != len(updateCommitments) {
+		return Share{}, nil, errors.New("vss: mismatched number of updates and commitments")
+	}
+	value := new(safenum.Nat).Mod(share.Value, g.Order())
+	for _, u := range updates {
+		if u.ID != share.ID {
+			return Share{}, nil, errMismatchedShare
+		}
+		value.ModAdd(value, u.Value, g.Order())
+	}
+	newCommitments, err := addCommitments(g, nil, append([][]group.Element{commitments}, updateCommitments...))
+	if err != nil {
+		return Share{}, nil, err
+	}
+	return Share{ID: share.ID, Value: value}, newCommitments, nil
+}
@@ -0,0 +1,92 @@
+package vss
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+func TestRefresh(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, err := FeldmanDeal(g, secret, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []uint32{1, 2, 3, 4, 5}
+
+	// Each participant deals shares of zero to everyone.
+	updates := make([][]Share, len(ids))
+	updateCommitments := make([][]group.Element, len(ids))
+	for i := range ids {
+		updates[i], updateCommitments[i], err = RefreshDeal(g, 3, ids, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refreshed := make([]Share, len(shares))
+	var newCommitments []group.Element
+	for j, share := range shares {
+		received := make([]Share, len(ids))
+		for i := range ids {
+			received[i] = updates[i][j]
+			if !RefreshVerify(g, received[i], updateCommitments[i]) {
+				t.Errorf("valid update rejected")
+			}
+		}
+		refreshed[j], newCommitments, err = Refresh(g, share, commitments, received, updateCommitments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if refreshed[j].Value.Cmp(share.Value) == 0 {
+			t.Errorf("share %d didn't change", share.ID)
+		}
+		if !FeldmanVerify(g, refreshed[j], newCommitments) {
+			t.Errorf("refreshed share %d doesn't match the new commitments", share.ID)
+		}
+	}
+	if newCommitments[0].Equal(commitments[0]) != 1 {
+		t.Errorf("refresh changed the commitment to the secret")
+	}
+	out, err := Reconstruct(g, []Share{refreshed[4], refreshed[1], refreshed[2]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) != 0 {
+		t.Errorf("refreshed shares don't reconstruct the secret")
+	}
+	// Mixing old and new shares doesn't give the secret.
+	out, _ = Reconstruct(g, []Share{shares[0], refreshed[1], refreshed[2]})
+	if out.Cmp(secret) == 0 {
+		t.Errorf("old and new shares reconstructed the secret")
+	}
+}
+
+func TestRefreshVerifyRejectsNonZero(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, _ := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	if RefreshVerify(g, shares[0], commitments) {
+		t.Errorf("update dealing a non-zero secret accepted")
+	}
+	updates, updateCommitments, _ := RefreshDeal(g, 2, []uint32{1, 2, 3}, rand.Reader)
+	bad := Share{ID: updates[0].ID, Value: new(safenum.Nat).ModAdd(updates[0].Value, new(safenum.Nat).SetUint64(1), g.Order())}
+	if RefreshVerify(g, bad, updateCommitments) {
+		t.Errorf("invalid update accepted")
+	}
+	if _, _, err := Refresh(g, shares[0], commitments, updates[1:2], [][]group.Element{updateCommitments}); err == nil {
+		t.Errorf("update for another participant accepted")
+	}
+}
+
+func TestRefreshDealRejectsIDs(t *testing.T) {
+	g := group.P256()
+	for _, ids := range [][]uint32{{1, 2, 2}, {0, 1, 2}, {1}} {
+		if _, _, err := RefreshDeal(g, 2, ids, rand.Reader); err == nil {
+			t.Errorf("RefreshDeal accepted identifiers %v", ids)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package vss
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements the redistribution of a shared secret to a new set of
+// participants, with a new threshold, following Desmedt and Jajodia,
+// "Redistributing secret shares to new access structures and its
+// applications", with the verification of Wong, Wang, and Wing.
+//
+// At least threshold of the old participants each deal their share to the new
+// participants, with Feldman's scheme. Since the old shares are Lagrange
+// interpolated into the secret, the same combination of the sub-shares each
+// new participant receives is a share of the secret on a new polynomial.
+// The old participants should then erase their shares.
+
+// ReshareDeal deals the share of an old participant to the new participants
+// in ids, such that any threshold of them will be able to reconstruct the
+// secret, once they've received dealings from enough old participants.
+//
+// The commitments returned must be broadcast to the new participants, and
+// each share sent privately to its recipient, who checks it with
+// ReshareVerify.
+func ReshareDeal(g group.Group, share Share, threshold int, ids []uint32, rand io.Reader) ([]Share, []group.Element, error) {
+	if err := checkIDs(threshold, ids); err != nil {
+		return nil, nil, err
+	}
+	p, err := shamir.RandomPolynomial(g.Order(), share.Value, threshold, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dealTo(p, ids), commit(p, g.Generator()), nil
+}
+
+// ReshareVerify checks that a share dealt by the old participant dealer with
+// ReshareDeal is consistent with its commitments, and that these commit to
+// the dealer's old share, according to the commitments oldCommitments to the
+// old polynomial.
+func ReshareVerify(g group.Group, dealer uint32, share Share, commitments, oldCommitments []group.Element) bool {
+	if dealer == 0 || len(commitments) == 0 || len(oldCommitments) == 0 {
+		return false
+	}
+	expected := evaluateCommitments(g, oldCommitments, dealer)
+	return commitments[0].Equal(expected) == 1 && FeldmanVerify(g, share, commitments)
+}
+
+// Reshare combines the shares received by a new participant from the old
+// participants in dealers, along with their commitments, in the same order,
+// into its share of the secret. The shares should have been checked with
+// ReshareVerify beforehand.
+//
+// This also returns the commitments to the new polynomial, which are the same
+// for all new participants. An error is returned if these don't commit to the
+// same secret as oldCommitments, which happens if there are fewer dealers
+// than the old threshold.
+func Reshare(g group.Group, dealers []uint32, shares []Share, commitments [][]group.Element, oldCommitments []group.Element) (Share, []group.Element, error) {
+	if len(dealers) != len(shares) || len(dealers) != len(commitments) {
+		return Share{}, nil, errors.New("vss: mismatched number of dealers, shares, and commitments")
+	}
+	if len(shares) == 0 || len(oldCommitments) == 0 {
+		return Share{}, nil, errors.New("vss: no shares to combine")
+	}
+	lambdas, err := shamir.LagrangeCoefficients(g.Order(), dealers, 0)
+	if err != nil {
+		return Share{}, nil, err
+	}
+	id := shares[0].ID
+	value := new(safenum.Nat).Mod(new(safenum.Nat), g.Order())
+	for i, s := range shares {
+		if s.ID != id {
+			return Share{}, nil, errMismatchedShare
+		}
+		value.ModAdd(value, new(safenum.Nat).ModMul(lambdas[i], s.Value, g.Order()), g.Order())
+	}
+	newCommitments, err := addCommitments(g, lambdas, commitments)
+	if err != nil {
+		return Share{}, nil, err
+	}
+	if newCommitments[0].Equal(oldCommitments[0]) != 1 {
+		return Share{}, nil, errors.New("vss: too few dealers to reshare the secret")
+	}
+	return Share{ID: id, Value: value}, newCommitments, nil
+}
@@ -0,0 +1,101 @@
+package vss
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// reshare redistributes the shares old to the new participants in ids,
+// returning the new shares and commitments.
+func reshare(t *testing.T, g group.Group, old []Share, oldCommitments []group.Element, threshold int, ids []uint32) ([]Share, []group.Element, error) {
+	dealers := make([]uint32, len(old))
+	dealt := make([][]Share, len(old))
+	dealtCommitments := make([][]group.Element, len(old))
+	for i, share := range old {
+		dealers[i] = share.ID
+		var err error
+		dealt[i], dealtCommitments[i], err = ReshareDeal(g, share, threshold, ids, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := make([]Share, len(ids))
+	var commitments []group.Element
+	for j := range ids {
+		received := make([]Share, len(old))
+		for i := range old {
+			received[i] = dealt[i][j]
+			if !ReshareVerify(g, dealers[i], received[i], dealtCommitments[i], oldCommitments) {
+				t.Errorf("valid dealing from %d rejected", dealers[i])
+			}
+		}
+		var err error
+		out[j], commitments, err = Reshare(g, dealers, received, dealtCommitments, oldCommitments)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return out, commitments, nil
+}
+
+func TestReshare(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, err := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Move from 2 of 3, to 3 of 4, with different identifiers.
+	ids := []uint32{4, 5, 6, 7}
+	newShares, newCommitments, err := reshare(t, g, []Share{shares[2], shares[0]}, commitments, 3, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(newCommitments) != 3 || newCommitments[0].Equal(commitments[0]) != 1 {
+		t.Errorf("new commitments don't commit to the secret with the new threshold")
+	}
+	for _, share := range newShares {
+		if !FeldmanVerify(g, share, newCommitments) {
+			t.Errorf("new share %d doesn't match the new commitments", share.ID)
+		}
+	}
+	out, err := Reconstruct(g, newShares[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) != 0 {
+		t.Errorf("new shares don't reconstruct the secret")
+	}
+	out, _ = Reconstruct(g, newShares[:2])
+	if out.Cmp(secret) == 0 {
+		t.Errorf("secret reconstructed from fewer shares than the new threshold")
+	}
+}
+
+func TestReshareTooFewDealers(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, _ := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	if _, _, err := reshare(t, g, shares[:1], commitments, 2, []uint32{1, 2}); err == nil {
+		t.Errorf("resharing from fewer dealers than the threshold succeeded")
+	}
+}
+
+func TestReshareVerifyRejects(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, commitments, _ := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	// A dealer resharing a different value than its share is caught.
+	fake := Share{ID: shares[0].ID, Value: new(safenum.Nat).ModAdd(shares[0].Value, new(safenum.Nat).SetUint64(1), g.Order())}
+	dealt, dealtCommitments, _ := ReshareDeal(g, fake, 2, []uint32{1, 2}, rand.Reader)
+	if ReshareVerify(g, shares[0].ID, dealt[0], dealtCommitments, commitments) {
+		t.Errorf("dealing of the wrong share accepted")
+	}
+	dealt, dealtCommitments, _ = ReshareDeal(g, shares[0], 2, []uint32{1, 2}, rand.Reader)
+	if ReshareVerify(g, shares[1].ID, dealt[0], dealtCommitments, commitments) {
+		t.Errorf("dealing attributed to the wrong dealer accepted")
+	}
+}
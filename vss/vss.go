@@ -0,0 +1,71 @@
+// Package vss implements verifiable secret sharing over the scalars of a group,
+// with the schemes of Feldman and Pedersen.
+//
+// In both schemes, a dealer splits a secret scalar into shares with Shamir's
+// scheme, as implemented by the shamir package, so that any threshold of them
+// can reconstruct the secret, and publishes commitments to the coefficients of
+// the polynomial it used. This lets each participant check that its share is
+// consistent with the others, without learning anything else about the secret.
+//
+// Feldman's commitments reveal secret * G, which is what distributed key
+// generation needs, while Pedersen's commitments are perfectly hiding.
+//
+// Shares dealt with Feldman's scheme can later be refreshed, with RefreshDeal
+// and Refresh, or redistributed to a different set of participants, with a
+// different threshold, with ReshareDeal and Reshare, without ever
+// reconstructing the secret.
+package vss
+
+import (
+	"errors"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/ctcrypto/shamir"
+	"github.com/cronokirby/safenum"
+)
+
+// Share is the share of a participant, which is the evaluation of the dealer's
+// polynomial at the participant's identifier.
+type Share = shamir.Share
+
+var errInvalidThreshold = errors.New("vss: threshold must be between 1 and the number of participants")
+
+// commit returns coefficient * base, for each coefficient of a polynomial.
+func commit(p *shamir.Polynomial, base group.Element) []group.Element {
+	coefficients := p.Coefficients()
+	out := make([]group.Element, len(coefficients))
+	for i, c := range coefficients {
+		out[i] = base.ScalarMult(c)
+	}
+	return out
+}
+
+// evaluateCommitments returns the commitment to the evaluation of the
+// polynomial at the identifier of a participant, which is the sum of
+// commitments[k] * id^k.
+func evaluateCommitments(g group.Group, commitments []group.Element, id uint32) group.Element {
+	x := new(safenum.Nat).SetUint64(uint64(id))
+	x.Mod(x, g.Order())
+	out := g.Identity()
+	for i := len(commitments) - 1; i >= 0; i-- {
+		out = out.ScalarMult(x).Add(commitments[i])
+	}
+	return out
+}
+
+func checkParams(threshold, n int) error {
+	if threshold < 1 || threshold > n || uint64(n) > 0xffffffff {
+		return errInvalidThreshold
+	}
+	return nil
+}
+
+// Reconstruct recovers the secret from a set of shares, using Lagrange
+// interpolation.
+//
+// At least threshold shares are needed to recover the right secret. With fewer
+// shares, the output is unrelated to the secret, which can't be detected by
+// this function, but by checking it against the commitments of the dealer.
+func Reconstruct(g group.Group, shares []Share) (*safenum.Nat, error) {
+	return shamir.Combine(g.Order(), shares)
+}
@@ -0,0 +1,66 @@
+package vss
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestReconstruct(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, _, err := FeldmanDeal(g, secret, 3, 5, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subsets := [][]Share{
+		shares[:3],
+		shares[2:],
+		{shares[4], shares[0], shares[2]},
+		shares,
+	}
+	for _, subset := range subsets {
+		out, err := Reconstruct(g, subset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.Cmp(secret) != 0 {
+			t.Errorf("wrong secret reconstructed from %d shares", len(subset))
+		}
+	}
+	out, err := Reconstruct(g, shares[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Cmp(secret) == 0 {
+		t.Errorf("secret reconstructed from too few shares")
+	}
+}
+
+func TestReconstructRejectsBadIDs(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	shares, _, _ := FeldmanDeal(g, secret, 2, 3, rand.Reader)
+	if _, err := Reconstruct(g, []Share{shares[0], shares[0]}); err == nil {
+		t.Errorf("accepted duplicate shares")
+	}
+	zero := Share{ID: 0, Value: shares[0].Value}
+	if _, err := Reconstruct(g, []Share{zero, shares[1]}); err == nil {
+		t.Errorf("accepted a share with identifier 0")
+	}
+	if _, err := Reconstruct(g, nil); err == nil {
+		t.Errorf("accepted an empty set of shares")
+	}
+}
+
+func TestInvalidThreshold(t *testing.T) {
+	g := group.P256()
+	secret, _ := g.RandomScalar(rand.Reader)
+	if _, _, err := FeldmanDeal(g, secret, 0, 3, rand.Reader); err == nil {
+		t.Errorf("accepted a threshold of 0")
+	}
+	if _, _, err := PedersenDeal(g, secret, 4, 3, rand.Reader); err == nil {
+		t.Errorf("accepted a threshold larger than the number of participants")
+	}
+}
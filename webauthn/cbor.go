@@ -0,0 +1,129 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// This file implements the subset of CBOR, RFC 8949, which appears in COSE
+// keys: integers, byte and text strings, arrays, and maps, all of definite
+// length.
+
+// maxDepth bounds the nesting of arrays and maps.
+const maxDepth = 4
+
+var errMalformedCBOR = errors.New("webauthn: malformed CBOR")
+
+const (
+	majorUint = iota
+	majorNegint
+	majorBytes
+	majorText
+	majorArray
+	majorMap
+)
+
+// cborValue is a decoded CBOR item. Integers are in int, strings in bytes,
+// and the items of arrays and maps aren't kept, since COSE keys only need
+// their top-level entries.
+type cborValue struct {
+	major int
+	int   int64
+	bytes []byte
+}
+
+// readHead decodes the initial byte and argument of an item.
+func readHead(data []byte) (major int, arg uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, nil, errMalformedCBOR
+	}
+	major, info := int(data[0]>>5), data[0]&0x1f
+	data = data[1:]
+	switch {
+	case info < 24:
+		return major, uint64(info), data, nil
+	case info == 24 && len(data) >= 1:
+		return major, uint64(data[0]), data[1:], nil
+	case info == 25 && len(data) >= 2:
+		return major, uint64(binary.BigEndian.Uint16(data)), data[2:], nil
+	case info == 26 && len(data) >= 4:
+		return major, uint64(binary.BigEndian.Uint32(data)), data[4:], nil
+	case info == 27 && len(data) >= 8:
+		return major, binary.BigEndian.Uint64(data), data[8:], nil
+	}
+	// Indefinite lengths, and reserved or truncated arguments.
+	return 0, 0, nil, errMalformedCBOR
+}
+
+// readValue decodes an item from the start of data.
+func readValue(data []byte, depth int) (cborValue, []byte, error) {
+	major, arg, data, err := readHead(data)
+	if err != nil {
+		return cborValue{}, nil, err
+	}
+	v := cborValue{major: major}
+	switch major {
+	case majorUint, majorNegint:
+		if arg > 1<<63-1 {
+			return cborValue{}, nil, errMalformedCBOR
+		}
+		v.int = int64(arg)
+		if major == majorNegint {
+			v.int = -1 - v.int
+		}
+	case majorBytes, majorText:
+		if arg > uint64(len(data)) {
+			return cborValue{}, nil, errMalformedCBOR
+		}
+		v.bytes, data = data[:arg], data[arg:]
+	case majorArray, majorMap:
+		if depth >= maxDepth || arg > uint64(len(data)) {
+			return cborValue{}, nil, errMalformedCBOR
+		}
+		n := arg
+		if major == majorMap {
+			n *= 2
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, data, err = readValue(data, depth+1); err != nil {
+				return cborValue{}, nil, err
+			}
+		}
+	default:
+		return cborValue{}, nil, errMalformedCBOR
+	}
+	return v, data, nil
+}
+
+// readIntMap decodes a map with integer keys, which is the whole of data, as
+// used by COSE keys. Duplicate keys are rejected.
+func readIntMap(data []byte) (map[int64]cborValue, error) {
+	major, n, data, err := readHead(data)
+	if err != nil {
+		return nil, err
+	}
+	if major != majorMap || n > uint64(len(data)) {
+		return nil, errMalformedCBOR
+	}
+	m := make(map[int64]cborValue, n)
+	for i := uint64(0); i < n; i++ {
+		var key, value cborValue
+		if key, data, err = readValue(data, 1); err != nil {
+			return nil, err
+		}
+		if key.major != majorUint && key.major != majorNegint {
+			return nil, errMalformedCBOR
+		}
+		if _, ok := m[key.int]; ok {
+			return nil, errMalformedCBOR
+		}
+		if value, data, err = readValue(data, 1); err != nil {
+			return nil, err
+		}
+		m[key.int] = value
+	}
+	if len(data) != 0 {
+		return nil, errMalformedCBOR
+	}
+	return m, nil
+}
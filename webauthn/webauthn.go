@@ -0,0 +1,224 @@
+// Package webauthn verifies WebAuthn assertions, as specified in Web
+// Authentication Level 2, section 7.2, for the ECDSA and EdDSA algorithms.
+//
+// The public key of a credential is given in its COSE encoding, as found in
+// the attested credential data of the registration. Parsing clientDataJSON,
+// and checking its challenge and origin, is left to the caller, which only
+// passes its SHA-256 hash.
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	edsig "github.com/cronokirby/ctcrypto/internal/ed25519"
+)
+
+// Algorithm is a COSE algorithm identifier, from the IANA registry.
+type Algorithm int64
+
+const (
+	// ES256 is ECDSA with P-256 and SHA-256.
+	ES256 Algorithm = -7
+	// EdDSA is Ed25519.
+	EdDSA Algorithm = -8
+	// ES384 is ECDSA with P-384 and SHA-384.
+	ES384 Algorithm = -35
+	// ES512 is ECDSA with P-521 and SHA-512.
+	ES512 Algorithm = -36
+)
+
+// COSE key parameters, and their values, from RFC 9053.
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+
+	ktyOKP = 1
+	ktyEC2 = 2
+
+	crvP256    = 1
+	crvP384    = 2
+	crvP521    = 3
+	crvEd25519 = 6
+)
+
+var (
+	errInvalidKey       = errors.New("webauthn: invalid COSE key")
+	errInvalidSignature = errors.New("webauthn: invalid signature")
+)
+
+// PublicKey is the public key of a credential.
+type PublicKey struct {
+	// Algorithm is the algorithm the key is restricted to.
+	Algorithm Algorithm
+	// Key is an *ecdsa.PublicKey, or an ed25519.PublicKey.
+	Key crypto.PublicKey
+}
+
+// ecdsaParams returns the curve and the COSE curve identifier of an ECDSA
+// algorithm, or nil.
+func ecdsaParams(alg Algorithm) (elliptic.Curve, int64) {
+	switch alg {
+	case ES256:
+		return elliptic.P256(), crvP256
+	case ES384:
+		return elliptic.P384(), crvP384
+	case ES512:
+		return elliptic.P521(), crvP521
+	}
+	return nil, 0
+}
+
+// ParsePublicKey decodes a COSE key. The key must name its algorithm, which
+// must match its type and curve.
+func ParsePublicKey(cose []byte) (*PublicKey, error) {
+	m, err := readIntMap(cose)
+	if err != nil {
+		return nil, err
+	}
+	param := func(label int64, major int) (cborValue, bool) {
+		v, ok := m[label]
+		return v, ok && v.major == major
+	}
+	kty, ok1 := param(coseKty, majorUint)
+	alg, ok2 := m[coseAlg]
+	crv, ok3 := param(coseCrv, majorUint)
+	x, ok4 := param(coseX, majorBytes)
+	if !ok1 || !ok2 || !ok3 || !ok4 || (alg.major != majorUint && alg.major != majorNegint) {
+		return nil, errInvalidKey
+	}
+	pub := &PublicKey{Algorithm: Algorithm(alg.int)}
+
+	if pub.Algorithm == EdDSA {
+		if kty.int != ktyOKP || crv.int != crvEd25519 || len(x.bytes) != ed25519.PublicKeySize {
+			return nil, errInvalidKey
+		}
+		if _, ok := m[coseY]; ok {
+			return nil, errInvalidKey
+		}
+		pub.Key = ed25519.PublicKey(append([]byte{}, x.bytes...))
+		return pub, nil
+	}
+
+	curve, crvID := ecdsaParams(pub.Algorithm)
+	if curve == nil {
+		return nil, errors.New("webauthn: unsupported algorithm")
+	}
+	y, ok := param(coseY, majorBytes)
+	size := (curve.Params().BitSize + 7) / 8
+	if !ok || kty.int != ktyEC2 || crv.int != crvID || len(x.bytes) != size || len(y.bytes) != size {
+		return nil, errInvalidKey
+	}
+	X := new(big.Int).SetBytes(x.bytes)
+	Y := new(big.Int).SetBytes(y.bytes)
+	if !curve.IsOnCurve(X, Y) {
+		return nil, errInvalidKey
+	}
+	pub.Key = &ecdsa.PublicKey{Curve: curve, X: X, Y: Y}
+	return pub, nil
+}
+
+// Flags of authenticator data.
+const (
+	FlagUserPresent  = 0x01
+	FlagUserVerified = 0x04
+)
+
+// AuthenticatorData is the fixed prefix of authenticator data, as described
+// in section 6.1.
+type AuthenticatorData struct {
+	RPIDHash  [32]byte
+	Flags     byte
+	SignCount uint32
+}
+
+// ParseAuthenticatorData decodes the fixed prefix of authenticator data.
+// Extensions and attested credential data aren't decoded.
+func ParseAuthenticatorData(authData []byte) (*AuthenticatorData, error) {
+	if len(authData) < 37 {
+		return nil, errors.New("webauthn: authenticator data too short")
+	}
+	a := &AuthenticatorData{Flags: authData[32], SignCount: binary.BigEndian.Uint32(authData[33:])}
+	copy(a.RPIDHash[:], authData)
+	return a, nil
+}
+
+// UserVerified reports whether the authenticator verified the user, with a
+// PIN or biometrics for example.
+func (a *AuthenticatorData) UserVerified() bool {
+	return a.Flags&FlagUserVerified != 0
+}
+
+// VerifyAssertion verifies the signature of an assertion by pub, over the
+// authenticator data and the SHA-256 hash of clientDataJSON, and returns the
+// decoded authenticator data.
+//
+// This also checks that the authenticator data is scoped to rpID, and that
+// the user was present. Checking the user verification flag, and that the
+// signature counter increased, is left to the caller.
+//
+// ECDSA signatures are ASN.1 encoded, and EdDSA signatures are 64 bytes long.
+func VerifyAssertion(pub *PublicKey, authData, clientDataHash, sig []byte, rpID string) (*AuthenticatorData, error) {
+	a, err := ParseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if len(clientDataHash) != sha256.Size {
+		return nil, errors.New("webauthn: invalid client data hash")
+	}
+	signed := make([]byte, 0, len(authData)+len(clientDataHash))
+	signed = append(signed, authData...)
+	signed = append(signed, clientDataHash...)
+
+	switch key := pub.Key.(type) {
+	case *ecdsa.PublicKey:
+		curve, _ := ecdsaParams(pub.Algorithm)
+		if curve == nil || curve.Params().Name != key.Curve.Params().Name {
+			return nil, errInvalidKey
+		}
+		var digest []byte
+		switch pub.Algorithm {
+		case ES256:
+			d := sha256.Sum256(signed)
+			digest = d[:]
+		case ES384:
+			d := sha512.Sum384(signed)
+			digest = d[:]
+		default:
+			d := sha512.Sum512(signed)
+			digest = d[:]
+		}
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return nil, errInvalidSignature
+		}
+	case ed25519.PublicKey:
+		if pub.Algorithm != EdDSA {
+			return nil, errInvalidKey
+		}
+		if !edsig.Verify(key, signed, sig) {
+			return nil, errInvalidSignature
+		}
+	default:
+		return nil, errInvalidKey
+	}
+
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	if subtle.ConstantTimeCompare(a.RPIDHash[:], rpIDHash[:]) != 1 {
+		return nil, errors.New("webauthn: authenticator data is for another relying party")
+	}
+	if a.Flags&FlagUserPresent == 0 {
+		return nil, errors.New("webauthn: user wasn't present")
+	}
+	return a, nil
+}
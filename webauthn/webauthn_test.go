@@ -0,0 +1,160 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "crypto/sha512"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+)
+
+// ec2Key returns the COSE encoding of an EC2 key, as produced by
+// authenticators: {1: 2, 3: alg, -1: crv, -2: x, -3: y}.
+func ec2Key(alg []byte, crv byte, x, y []byte) []byte {
+	b := append([]byte{0xa5, 0x01, 0x02, 0x03}, alg...)
+	b = append(b, 0x20, crv)
+	b = append(b, 0x21, 0x58, byte(len(x)))
+	b = append(b, x...)
+	b = append(b, 0x22, 0x58, byte(len(y)))
+	return append(b, y...)
+}
+
+// okpKey returns the COSE encoding of an Ed25519 key, {1: 1, 3: -8, -1: 6,
+// -2: x}.
+func okpKey(x []byte) []byte {
+	b := []byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21, 0x58, byte(len(x))}
+	return append(b, x...)
+}
+
+func authData(rpID string, flags byte) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	return append(h[:], flags, 0, 0, 0, 42)
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	clientDataHash := sha256.Sum256([]byte(`{"type":"webauthn.get"}`))
+	data := authData("example.com", FlagUserPresent|FlagUserVerified)
+	signed := append(append([]byte{}, data...), clientDataHash[:]...)
+
+	for _, test := range []struct {
+		curve elliptic.Curve
+		alg   []byte // the CBOR encoding of the algorithm
+		crv   byte
+		hash  crypto.Hash
+	}{
+		{elliptic.P256(), []byte{0x26}, 1, crypto.SHA256},
+		{elliptic.P384(), []byte{0x38, 0x22}, 2, crypto.SHA384},
+		{elliptic.P521(), []byte{0x38, 0x23}, 3, crypto.SHA512},
+	} {
+		priv, err := ecdsa.GenerateKey(test.curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (test.curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		priv.X.FillBytes(x)
+		priv.Y.FillBytes(y)
+		pub, err := ParsePublicKey(ec2Key(test.alg, test.crv, x, y))
+		if err != nil {
+			t.Fatalf("%s: %v", test.curve.Params().Name, err)
+		}
+		h := test.hash.New()
+		h.Write(signed)
+		digest := h.Sum(nil)
+		sig, err := ecdsa.SignASN1(rand.Reader, priv, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := VerifyAssertion(pub, data, clientDataHash[:], sig, "example.com")
+		if err != nil {
+			t.Fatalf("%s: %v", test.curve.Params().Name, err)
+		}
+		if a.SignCount != 42 || !a.UserVerified() {
+			t.Errorf("%s: got %+v", test.curve.Params().Name, a)
+		}
+		if _, err := VerifyAssertion(pub, data, clientDataHash[:], sig, "example.org"); err == nil {
+			t.Errorf("%s: assertion accepted for another relying party", test.curve.Params().Name)
+		}
+		other := sha256.Sum256([]byte("other"))
+		if _, err := VerifyAssertion(pub, data, other[:], sig, "example.com"); err == nil {
+			t.Errorf("%s: assertion accepted for other client data", test.curve.Params().Name)
+		}
+	}
+}
+
+func TestVerifyAssertionEdDSA(t *testing.T) {
+	pubKey, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ParsePublicKey(okpKey(pubKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientDataHash := sha256.Sum256([]byte(`{"type":"webauthn.get"}`))
+	for _, flags := range []byte{FlagUserPresent, 0} {
+		data := authData("example.com", flags)
+		sig := ed25519.Sign(priv, append(append([]byte{}, data...), clientDataHash[:]...))
+		a, err := VerifyAssertion(pub, data, clientDataHash[:], sig, "example.com")
+		if flags == 0 {
+			if err == nil {
+				t.Error("assertion accepted without user presence")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.UserVerified() {
+			t.Error("user verification flag wrongly set")
+		}
+		sig[0] ^= 1
+		if _, err := VerifyAssertion(pub, data, clientDataHash[:], sig, "example.com"); err == nil {
+			t.Error("modified signature accepted")
+		}
+	}
+}
+
+func TestParsePublicKeyRejects(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := make([]byte, 32), make([]byte, 32)
+	priv.X.FillBytes(x)
+	priv.Y.FillBytes(y)
+	es256 := []byte{0x26}
+	valid := ec2Key(es256, 1, x, y)
+	if _, err := ParsePublicKey(valid); err != nil {
+		t.Fatal(err)
+	}
+	notOnCurve := append([]byte{}, y...)
+	notOnCurve[31] ^= 1
+	for name, cose := range map[string][]byte{
+		"wrong curve":    ec2Key(es256, 2, x, y),
+		"EdDSA with EC2": ec2Key([]byte{0x27}, 1, x, y),
+		"not on curve":   ec2Key(es256, 1, x, notOnCurve),
+		"short x":        ec2Key(es256, 1, x[1:], y),
+		"trailing data":  append(append([]byte{}, valid...), 0),
+		"truncated":      valid[:len(valid)-1],
+		"duplicate key":  append([]byte{0xa6, 0x01, 0x02}, valid[1:]...),
+		"Ed25519 size":   okpKey(x[1:]),
+	} {
+		if _, err := ParsePublicKey(cose); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	// Unknown parameters, like a key ID, are ignored.
+	withKid := append([]byte{0xa6}, valid[1:]...)
+	withKid = append(withKid, 0x02, 0x42, 0xaa, 0xbb)
+	if _, err := ParsePublicKey(withKid); err != nil {
+		t.Errorf("key with a kid rejected: %v", err)
+	}
+	if _, err := ParseAuthenticatorData(make([]byte, 36)); err == nil {
+		t.Error("short authenticator data accepted")
+	}
+}
@@ -0,0 +1,175 @@
+package wycheproof
+
+import (
+	"crypto"
+	"encoding/json"
+	"io"
+)
+
+// ECDHTest is a test case of ecdh_*_test.json.
+type ECDHTest struct {
+	Case
+	// Curve is the name of the curve, like "secp256r1".
+	Curve string
+	// Encoding is the encoding of Public: "asn" for a SubjectPublicKeyInfo,
+	// or "ecpoint" for a SEC 1 point.
+	Encoding string
+	// Public is the public key of the peer.
+	Public []byte
+	// Private is the private key, as a big-endian integer, which can have
+	// leading zeros, or be shorter than the order.
+	Private []byte
+	// Shared is the expected shared secret.
+	Shared []byte
+}
+
+// ParseECDH parses the ECDH test cases of r.
+func ParseECDH(r io.Reader) ([]ECDHTest, error) {
+	var tests []ECDHTest
+	err := parse(r, func(typ string, raw json.RawMessage) error {
+		if typ != "EcdhTest" && typ != "EcdhEcpointTest" {
+			return unexpectedGroup(typ)
+		}
+		var g struct {
+			Curve    string `json:"curve"`
+			Encoding string `json:"encoding"`
+			Tests    []struct {
+				Case
+				Public  hexBytes `json:"public"`
+				Private hexBytes `json:"private"`
+				Shared  hexBytes `json:"shared"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		for _, t := range g.Tests {
+			tests = append(tests, ECDHTest{t.Case, g.Curve, g.Encoding, t.Public, t.Private, t.Shared})
+		}
+		return nil
+	})
+	return tests, err
+}
+
+// SignatureFormat is the encoding of an ECDSA signature.
+type SignatureFormat int
+
+const (
+	// ASN1 signatures are DER encoded sequences of r and s.
+	ASN1 SignatureFormat = iota
+	// P1363 signatures are the concatenation of r and s, as fixed length
+	// big-endian integers.
+	P1363
+)
+
+// ECDSATest is a test case of ecdsa_*_test.json.
+type ECDSATest struct {
+	Case
+	// Curve is the name of the curve, like "secp256r1".
+	Curve string
+	Hash  crypto.Hash
+	// PublicKey is the SEC 1 uncompressed encoding of the public key.
+	PublicKey []byte
+	Format    SignatureFormat
+	// Msg is the message which was signed, before hashing.
+	Msg []byte
+	Sig []byte
+}
+
+type ecKey struct {
+	Curve        string   `json:"curve"`
+	Uncompressed hexBytes `json:"uncompressed"`
+}
+
+// ParseECDSA parses the ECDSA test cases of r.
+func ParseECDSA(r io.Reader) ([]ECDSATest, error) {
+	var tests []ECDSATest
+	err := parse(r, func(typ string, raw json.RawMessage) error {
+		var format SignatureFormat
+		switch typ {
+		case "EcdsaVerify":
+			format = ASN1
+		case "EcdsaP1363Verify":
+			format = P1363
+		default:
+			return unexpectedGroup(typ)
+		}
+		var g struct {
+			Key       *ecKey `json:"key"`
+			PublicKey *ecKey `json:"publicKey"`
+			SHA       string `json:"sha"`
+			Tests     []struct {
+				Case
+				Msg hexBytes `json:"msg"`
+				Sig hexBytes `json:"sig"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		key := g.PublicKey
+		if key == nil {
+			key = g.Key
+		}
+		if key == nil {
+			return errMissingKey
+		}
+		hash, err := parseHash(g.SHA)
+		if err != nil {
+			return err
+		}
+		for _, t := range g.Tests {
+			tests = append(tests, ECDSATest{t.Case, key.Curve, hash, key.Uncompressed, format, t.Msg, t.Sig})
+		}
+		return nil
+	})
+	return tests, err
+}
+
+// EdDSATest is a test case of ed25519_test.json or ed448_test.json.
+type EdDSATest struct {
+	Case
+	// Curve is the name of the curve, like "edwards25519".
+	Curve     string
+	PublicKey []byte
+	Msg       []byte
+	Sig       []byte
+}
+
+// ParseEdDSA parses the EdDSA test cases of r.
+func ParseEdDSA(r io.Reader) ([]EdDSATest, error) {
+	var tests []EdDSATest
+	err := parse(r, func(typ string, raw json.RawMessage) error {
+		if typ != "EddsaVerify" {
+			return unexpectedGroup(typ)
+		}
+		type edKey struct {
+			Curve string   `json:"curve"`
+			PK    hexBytes `json:"pk"`
+		}
+		var g struct {
+			Key       *edKey `json:"key"`
+			PublicKey *edKey `json:"publicKey"`
+			Tests     []struct {
+				Case
+				Msg hexBytes `json:"msg"`
+				Sig hexBytes `json:"sig"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		key := g.PublicKey
+		if key == nil {
+			key = g.Key
+		}
+		if key == nil {
+			return errMissingKey
+		}
+		for _, t := range g.Tests {
+			tests = append(tests, EdDSATest{t.Case, key.Curve, key.PK, t.Msg, t.Sig})
+		}
+		return nil
+	})
+	return tests, err
+}
@@ -0,0 +1,190 @@
+package wycheproof
+
+import (
+	"crypto"
+	"encoding/json"
+	"io"
+)
+
+// Padding is an RSA padding scheme.
+type Padding int
+
+const (
+	// PKCS1v15 is the padding of RSASSA-PKCS1-v1_5 and RSAES-PKCS1-v1_5.
+	PKCS1v15 Padding = iota
+	// PSS is the padding of RSASSA-PSS.
+	PSS
+	// OAEP is the padding of RSAES-OAEP.
+	OAEP
+)
+
+// RSASignatureTest is a test case of rsa_signature_*_test.json or
+// rsa_pss_*_test.json.
+type RSASignatureTest struct {
+	Case
+	// N is the modulus, as a big-endian integer.
+	N       []byte
+	E       int
+	Hash    crypto.Hash
+	Padding Padding
+	// MGFHash is the hash used by MGF1, for PSS.
+	MGFHash crypto.Hash
+	// SaltLength is the length of the salt, for PSS.
+	SaltLength int
+	// Msg is the message which was signed, before hashing.
+	Msg []byte
+	Sig []byte
+}
+
+// rsaKey holds an RSA key, in the format of the v1 schemas.
+type rsaKey struct {
+	Modulus         hexBytes `json:"modulus"`
+	PublicExponent  hexBytes `json:"publicExponent"`
+	PrivateExponent hexBytes `json:"privateExponent"`
+	Prime1          hexBytes `json:"prime1"`
+	Prime2          hexBytes `json:"prime2"`
+}
+
+// rsaGroup holds the fields of RSA test groups, in both formats.
+type rsaGroup struct {
+	N          hexBytes `json:"n"`
+	E          hexBytes `json:"e"`
+	D          hexBytes `json:"d"`
+	PKCS8      hexBytes `json:"privateKeyPkcs8"`
+	PublicKey  *rsaKey  `json:"publicKey"`
+	PrivateKey *rsaKey  `json:"privateKey"`
+	SHA        string   `json:"sha"`
+	MGFSHA     string   `json:"mgfSha"`
+	SLen       int      `json:"sLen"`
+}
+
+// key returns the key of the group, in the format of the v1 schemas.
+func (g *rsaGroup) key() *rsaKey {
+	if g.PrivateKey != nil {
+		return g.PrivateKey
+	}
+	if g.PublicKey != nil {
+		return g.PublicKey
+	}
+	return &rsaKey{Modulus: g.N, PublicExponent: g.E, PrivateExponent: g.D}
+}
+
+// ParseRSASignature parses the RSA signature test cases of r.
+func ParseRSASignature(r io.Reader) ([]RSASignatureTest, error) {
+	var tests []RSASignatureTest
+	err := parse(r, func(typ string, raw json.RawMessage) error {
+		var padding Padding
+		switch typ {
+		case "RsassaPkcs1Verify":
+			padding = PKCS1v15
+		case "RsassaPssVerify":
+			padding = PSS
+		default:
+			return unexpectedGroup(typ)
+		}
+		var g struct {
+			rsaGroup
+			Tests []struct {
+				Case
+				Msg hexBytes `json:"msg"`
+				Sig hexBytes `json:"sig"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		key := g.key()
+		e, err := hexInt(key.PublicExponent)
+		if err != nil {
+			return err
+		}
+		hash, err := parseHash(g.SHA)
+		if err != nil {
+			return err
+		}
+		mgfHash, err := parseHash(g.MGFSHA)
+		if err != nil {
+			return err
+		}
+		for _, t := range g.Tests {
+			tests = append(tests, RSASignatureTest{t.Case, key.Modulus, e, hash, padding, mgfHash, g.SLen, t.Msg, t.Sig})
+		}
+		return nil
+	})
+	return tests, err
+}
+
+// RSADecryptionTest is a test case of rsa_pkcs1_*_test.json or
+// rsa_oaep_*_test.json.
+type RSADecryptionTest struct {
+	Case
+	// N and D are the modulus and private exponent, as big-endian integers.
+	N []byte
+	E int
+	D []byte
+	// Primes are the prime factors of N, which are only given by the v1
+	// schemas, as big-endian integers.
+	Primes [][]byte
+	// PKCS8 is the private key, as PKCS #8 DER, which is only given by the
+	// original schemas.
+	PKCS8   []byte
+	Padding Padding
+	// Hash and MGFHash are the hashes used by OAEP.
+	Hash    crypto.Hash
+	MGFHash crypto.Hash
+	// Label is the label, for OAEP.
+	Label []byte
+	// Msg is the expected plaintext.
+	Msg []byte
+	Ct  []byte
+}
+
+// ParseRSADecryption parses the RSA decryption test cases of r.
+func ParseRSADecryption(r io.Reader) ([]RSADecryptionTest, error) {
+	var tests []RSADecryptionTest
+	err := parse(r, func(typ string, raw json.RawMessage) error {
+		var padding Padding
+		switch typ {
+		case "RsaesPkcs1Decrypt":
+			padding = PKCS1v15
+		case "RsaesOaepDecrypt":
+			padding = OAEP
+		default:
+			return unexpectedGroup(typ)
+		}
+		var g struct {
+			rsaGroup
+			Tests []struct {
+				Case
+				Msg   hexBytes `json:"msg"`
+				Ct    hexBytes `json:"ct"`
+				Label hexBytes `json:"label"`
+			} `json:"tests"`
+		}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		key := g.key()
+		e, err := hexInt(key.PublicExponent)
+		if err != nil {
+			return err
+		}
+		var primes [][]byte
+		if key.Prime1 != nil && key.Prime2 != nil {
+			primes = [][]byte{key.Prime1, key.Prime2}
+		}
+		hash, err := parseHash(g.SHA)
+		if err != nil {
+			return err
+		}
+		mgfHash, err := parseHash(g.MGFSHA)
+		if err != nil {
+			return err
+		}
+		for _, t := range g.Tests {
+			tests = append(tests, RSADecryptionTest{t.Case, key.Modulus, e, key.PrivateExponent, primes, g.PKCS8, padding, hash, mgfHash, t.Label, t.Msg, t.Ct})
+		}
+		return nil
+	})
+	return tests, err
+}
@@ -0,0 +1,160 @@
+// Package wycheproof parses the test vectors of Project Wycheproof, so that
+// the adversarial cases they cover can be run against this module, or against
+// the integrations built on top of it.
+//
+// Each Parse function reads one of the JSON files of the project, like
+// ecdh_secp256r1_test.json, and returns its test cases, flattened out of their
+// test groups. Both the original schemas, and the v1 ones, which nest public
+// and private keys in objects, are supported.
+//
+// See https://github.com/C2SP/wycheproof.
+package wycheproof
+
+import (
+	"crypto"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Result is the expected outcome of a test case.
+type Result int
+
+const (
+	// Valid cases must be accepted.
+	Valid Result = iota
+	// Invalid cases must be rejected.
+	Invalid
+	// Acceptable cases may be accepted or rejected, as they use legacy or
+	// weak, but not broken, parameters. Their flags give the reason.
+	Acceptable
+)
+
+func (r Result) String() string {
+	switch r {
+	case Valid:
+		return "valid"
+	case Invalid:
+		return "invalid"
+	case Acceptable:
+		return "acceptable"
+	}
+	return fmt.Sprintf("Result(%d)", int(r))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "valid":
+		*r = Valid
+	case "invalid":
+		*r = Invalid
+	case "acceptable":
+		*r = Acceptable
+	default:
+		return fmt.Errorf("wycheproof: unknown result %q", s)
+	}
+	return nil
+}
+
+// Case holds the fields shared by all test cases.
+type Case struct {
+	// ID is the tcId of the case, unique within its file.
+	ID int `json:"tcId"`
+	// Comment describes what the case tests.
+	Comment string `json:"comment"`
+	// Flags lists the notes of the file which apply to the case.
+	Flags  []string `json:"flags"`
+	Result Result   `json:"result"`
+}
+
+// hexBytes is a byte slice, encoded as a hex string.
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("wycheproof: invalid hex string: %v", err)
+	}
+	*h = b
+	return nil
+}
+
+// hexInt decodes a small big-endian hex integer, like an RSA exponent.
+func hexInt(b hexBytes) (int, error) {
+	x := new(big.Int).SetBytes(b)
+	if x.BitLen() > 31 {
+		return 0, errors.New("wycheproof: integer too large")
+	}
+	return int(x.Int64()), nil
+}
+
+// hashes maps the names of hash functions used by Wycheproof to their values.
+var hashes = map[string]crypto.Hash{
+	"SHA-1":       crypto.SHA1,
+	"SHA-224":     crypto.SHA224,
+	"SHA-256":     crypto.SHA256,
+	"SHA-384":     crypto.SHA384,
+	"SHA-512":     crypto.SHA512,
+	"SHA-512/224": crypto.SHA512_224,
+	"SHA-512/256": crypto.SHA512_256,
+	"SHA3-224":    crypto.SHA3_224,
+	"SHA3-256":    crypto.SHA3_256,
+	"SHA3-384":    crypto.SHA3_384,
+	"SHA3-512":    crypto.SHA3_512,
+}
+
+func parseHash(name string) (crypto.Hash, error) {
+	if name == "" {
+		return 0, nil
+	}
+	h, ok := hashes[name]
+	if !ok {
+		return 0, fmt.Errorf("wycheproof: unknown hash %q", name)
+	}
+	return h, nil
+}
+
+// group holds the fields shared by all test groups.
+type group struct {
+	Type  string          `json:"type"`
+	Tests json.RawMessage `json:"tests"`
+}
+
+// parse decodes a file, calling f with the type and contents of every test
+// group.
+func parse(r io.Reader, f func(typ string, raw json.RawMessage) error) error {
+	var file struct {
+		TestGroups []json.RawMessage `json:"testGroups"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return err
+	}
+	for _, raw := range file.TestGroups {
+		var g group
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return err
+		}
+		if err := f(g.Type, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errMissingKey = errors.New("wycheproof: test group without a key")
+
+func unexpectedGroup(typ string) error {
+	return fmt.Errorf("wycheproof: unexpected test group type %q", typ)
+}
@@ -0,0 +1,209 @@
+package wycheproof
+
+import (
+	"bytes"
+	"crypto"
+	stdelliptic "crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/ed25519"
+)
+
+func open(t *testing.T, name string) *os.File {
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// check fails the test if the outcome of c doesn't match its expected result.
+func check(t *testing.T, c Case, accepted bool) {
+	t.Helper()
+	if c.Result == Valid && !accepted {
+		t.Errorf("case %d (%s): valid input rejected", c.ID, c.Comment)
+	}
+	if c.Result == Invalid && accepted {
+		t.Errorf("case %d (%s): invalid input accepted", c.ID, c.Comment)
+	}
+}
+
+func TestECDH(t *testing.T) {
+	tests, err := ParseECDH(open(t, "ecdh_secp256r1_ecpoint_test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 4 {
+		t.Fatalf("parsed %d cases, expected 4", len(tests))
+	}
+	for _, c := range tests {
+		if c.Curve != "secp256r1" || c.Encoding != "ecpoint" {
+			t.Fatalf("case %d: curve %q, encoding %q", c.ID, c.Curve, c.Encoding)
+		}
+		private, err := ecdh.P256().NewPrivateKey(new(big.Int).SetBytes(c.Private).FillBytes(make([]byte, 32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		public, err := ecdh.P256().NewPublicKey(c.Public)
+		if err != nil {
+			check(t, c.Case, false)
+			continue
+		}
+		shared, err := private.ECDH(public)
+		check(t, c.Case, err == nil && bytes.Equal(shared, c.Shared))
+	}
+}
+
+func TestECDSA(t *testing.T) {
+	for _, name := range []string{"ecdsa_secp256r1_sha256_test.json", "ecdsa_secp256r1_sha256_p1363_test.json"} {
+		tests, err := ParseECDSA(open(t, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tests) == 0 {
+			t.Fatalf("%s: no cases", name)
+		}
+		for _, c := range tests {
+			if c.Curve != "secp256r1" || c.Hash != crypto.SHA256 {
+				t.Fatalf("case %d: curve %q, hash %v", c.ID, c.Curve, c.Hash)
+			}
+			if (c.Format == P1363) != strings.Contains(name, "p1363") {
+				t.Fatalf("case %d: wrong signature format", c.ID)
+			}
+			x, y := stdelliptic.Unmarshal(stdelliptic.P256(), c.PublicKey)
+			if x == nil {
+				t.Fatalf("case %d: invalid public key", c.ID)
+			}
+			pub := &ecdsa.PublicKey{Curve: stdelliptic.P256(), X: x, Y: y}
+			digest := sha256.Sum256(c.Msg)
+			var ok bool
+			switch c.Format {
+			case ASN1:
+				ok = ecdsa.VerifyASN1(pub, digest[:], c.Sig)
+			case P1363:
+				ok = len(c.Sig) == 64 && ecdsa.Verify(pub, digest[:],
+					new(big.Int).SetBytes(c.Sig[:32]), new(big.Int).SetBytes(c.Sig[32:]))
+			}
+			check(t, c.Case, ok)
+		}
+	}
+}
+
+func TestEdDSA(t *testing.T) {
+	tests, err := ParseEdDSA(open(t, "ed25519_test.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 3 {
+		t.Fatalf("parsed %d cases, expected 3", len(tests))
+	}
+	for _, c := range tests {
+		if c.Curve != "edwards25519" {
+			t.Fatalf("case %d: curve %q", c.ID, c.Curve)
+		}
+		check(t, c.Case, ed25519.Verify(c.PublicKey, c.Msg, c.Sig))
+	}
+}
+
+// modulus decodes a modulus, which Wycheproof encodes with a leading zero.
+func modulus(b []byte) *safenum.Modulus {
+	return safenum.ModulusFromBytes(new(big.Int).SetBytes(b).Bytes())
+}
+
+func TestRSASignature(t *testing.T) {
+	for _, name := range []string{"rsa_signature_2048_sha256_test.json", "rsa_pss_2048_sha256_mgf1_32_test.json"} {
+		tests, err := ParseRSASignature(open(t, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tests) == 0 {
+			t.Fatalf("%s: no cases", name)
+		}
+		for _, c := range tests {
+			if c.E != 65537 || c.Hash != crypto.SHA256 {
+				t.Fatalf("case %d: exponent %d, hash %v", c.ID, c.E, c.Hash)
+			}
+			pub := &rsa.PublicKey{N: modulus(c.N), E: c.E}
+			digest := sha256.Sum256(c.Msg)
+			switch c.Padding {
+			case PKCS1v15:
+				check(t, c.Case, rsa.VerifyPKCS1v15(pub, c.Hash, digest[:], c.Sig) == nil)
+			case PSS:
+				if c.MGFHash != crypto.SHA256 || c.SaltLength != 32 {
+					t.Fatalf("case %d: MGF1 hash %v, salt length %d", c.ID, c.MGFHash, c.SaltLength)
+				}
+				opts := &rsa.PSSOptions{SaltLength: c.SaltLength}
+				check(t, c.Case, rsa.VerifyPSS(pub, c.Hash, digest[:], c.Sig, opts) == nil)
+			default:
+				t.Fatalf("case %d: unexpected padding %v", c.ID, c.Padding)
+			}
+		}
+	}
+}
+
+func TestRSADecryption(t *testing.T) {
+	for _, name := range []string{"rsa_oaep_2048_sha256_mgf1sha256_test.json", "rsa_pkcs1_2048_test.json"} {
+		tests, err := ParseRSADecryption(open(t, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tests) == 0 {
+			t.Fatalf("%s: no cases", name)
+		}
+		for _, c := range tests {
+			priv := &rsa.PrivateKey{
+				PublicKey: rsa.PublicKey{N: modulus(c.N), E: c.E},
+				D:         new(safenum.Nat).SetBytes(c.D),
+			}
+			for _, p := range c.Primes {
+				priv.Primes = append(priv.Primes, new(safenum.Nat).SetBytes(p))
+			}
+			var msg []byte
+			var err error
+			switch c.Padding {
+			case OAEP:
+				if len(c.Primes) != 2 || c.PKCS8 != nil {
+					t.Fatalf("case %d: v1 key not parsed", c.ID)
+				}
+				priv.Precompute()
+				msg, err = rsa.DecryptOAEP(sha256.New(), priv, c.Ct, c.Label)
+			case PKCS1v15:
+				if c.Primes != nil || len(c.PKCS8) == 0 {
+					t.Fatalf("case %d: original key not parsed", c.ID)
+				}
+				msg, err = rsa.DecryptPKCS1v15(priv, c.Ct)
+			default:
+				t.Fatalf("case %d: unexpected padding %v", c.ID, c.Padding)
+			}
+			check(t, c.Case, err == nil && bytes.Equal(msg, c.Msg))
+		}
+	}
+}
+
+func TestUnexpectedGroup(t *testing.T) {
+	if _, err := ParseECDH(open(t, "ed25519_test.json")); err == nil {
+		t.Errorf("ParseECDH accepted EdDSA vectors")
+	}
+	if _, err := ParseRSASignature(open(t, "rsa_pkcs1_2048_test.json")); err == nil {
+		t.Errorf("ParseRSASignature accepted decryption vectors")
+	}
+}
+
+func TestResult(t *testing.T) {
+	var r Result
+	if err := r.UnmarshalJSON([]byte(`"acceptable"`)); err != nil || r != Acceptable {
+		t.Errorf("acceptable parsed as %v, %v", r, err)
+	}
+	if err := r.UnmarshalJSON([]byte(`"maybe"`)); err == nil {
+		t.Errorf("unknown result accepted")
+	}
+}
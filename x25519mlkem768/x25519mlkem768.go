@@ -0,0 +1,166 @@
+// Package x25519mlkem768 implements X25519MLKEM768, the hybrid key exchange
+// combining ML-KEM-768 and X25519, as used by TLS 1.3 and SSH.
+//
+// The shared secret is only compromised if both ML-KEM-768 and X25519 are,
+// so it protects recorded traffic against future quantum computers, while
+// keeping the assurance of X25519 should ML-KEM turn out to be weaker than
+// believed.
+//
+// The encodings follow draft-ietf-tls-ecdhe-mlkem: the ML-KEM part comes
+// first in key shares, ciphertexts and shared secrets, followed by the X25519
+// one.
+package x25519mlkem768
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/mlkem"
+)
+
+const (
+	x25519Size = 32
+
+	// PublicKeySize is the size of the client key share, made of an
+	// ML-KEM-768 encapsulation key, and an X25519 public key.
+	PublicKeySize = 1184 + x25519Size
+	// CiphertextSize is the size of the server key share, made of an
+	// ML-KEM-768 ciphertext, and an X25519 public key.
+	CiphertextSize = 1088 + x25519Size
+	// PrivateKeySize is the size of the encoding of private keys, made of the
+	// seed of the ML-KEM-768 decapsulation key, and an X25519 private key.
+	PrivateKeySize = mlkem.SeedSize + x25519Size
+	// SharedKeySize is the size of the shared secret, made of the ML-KEM-768
+	// shared key, and the X25519 shared secret.
+	SharedKeySize = mlkem.SharedKeySize + x25519Size
+)
+
+var (
+	errInvalidPublicKey  = errors.New("x25519mlkem768: invalid public key")
+	errInvalidPrivateKey = errors.New("x25519mlkem768: invalid private key length")
+	errInvalidLength     = errors.New("x25519mlkem768: invalid ciphertext length")
+)
+
+// PublicKey is the public key of a client, sent as its key share.
+type PublicKey struct {
+	mlkem  *mlkem.EncapsulationKey
+	x25519 *ecdh.PublicKey
+}
+
+// PrivateKey is the private key of a client.
+type PrivateKey struct {
+	mlkem  *mlkem.DecapsulationKey
+	x25519 *ecdh.PrivateKey
+}
+
+// GenerateKey generates a new private key, reading randomness from rand.
+func GenerateKey(rand io.Reader) (*PrivateKey, error) {
+	dk, err := mlkem.MLKEM768().GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{mlkem: dk, x25519: x}, nil
+}
+
+// NewPrivateKey parses a private key, in the format returned by Bytes.
+func NewPrivateKey(b []byte) (*PrivateKey, error) {
+	if len(b) != PrivateKeySize {
+		return nil, errInvalidPrivateKey
+	}
+	dk, err := mlkem.MLKEM768().NewDecapsulationKey(b[:mlkem.SeedSize])
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().NewPrivateKey(b[mlkem.SeedSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &PrivateKey{mlkem: dk, x25519: x}, nil
+}
+
+// Bytes returns the encoding of the private key, the 64 byte seed of the
+// ML-KEM-768 decapsulation key followed by the X25519 private key.
+func (priv *PrivateKey) Bytes() []byte {
+	return append(priv.mlkem.Bytes(), priv.x25519.Bytes()...)
+}
+
+// PublicKey returns the public key matching the private key.
+func (priv *PrivateKey) PublicKey() *PublicKey {
+	return &PublicKey{
+		mlkem:  priv.mlkem.EncapsulationKey(),
+		x25519: priv.x25519.PublicKey(),
+	}
+}
+
+// NewPublicKey parses a client key share.
+func NewPublicKey(b []byte) (*PublicKey, error) {
+	if len(b) != PublicKeySize {
+		return nil, errInvalidPublicKey
+	}
+	ek, err := mlkem.MLKEM768().NewEncapsulationKey(b[:PublicKeySize-x25519Size])
+	if err != nil {
+		return nil, err
+	}
+	x, err := ecdh.X25519().NewPublicKey(b[PublicKeySize-x25519Size:])
+	if err != nil {
+		return nil, err
+	}
+	return &PublicKey{mlkem: ek, x25519: x}, nil
+}
+
+// Bytes returns the encoding of the public key, the ML-KEM-768 encapsulation
+// key followed by the X25519 public key.
+func (pub *PublicKey) Bytes() []byte {
+	return append(pub.mlkem.Bytes(), pub.x25519.Bytes()...)
+}
+
+// Encapsulate generates a shared secret, and the server key share from which
+// the holder of the private key recovers it, reading randomness from rand.
+//
+// The X25519 exchange fails if the public key is of low order, in which case
+// an error is returned.
+func (pub *PublicKey) Encapsulate(rand io.Reader) (sharedKey, ciphertext []byte, err error) {
+	mlkemKey, mlkemCiphertext, err := pub.mlkem.Encapsulate(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	eph, err := ecdh.X25519().GenerateKey(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	x25519Key, err := eph.ECDH(pub.x25519)
+	if err != nil {
+		return nil, nil, err
+	}
+	sharedKey = append(append(make([]byte, 0, SharedKeySize), mlkemKey...), x25519Key...)
+	ciphertext = append(mlkemCiphertext, eph.PublicKey().Bytes()...)
+	return sharedKey, ciphertext, nil
+}
+
+// Decapsulate returns the shared secret of a server key share.
+//
+// Like ML-KEM, an invalid ML-KEM ciphertext yields a pseudorandom key instead
+// of an error. An X25519 public key of low order does return an error.
+func (priv *PrivateKey) Decapsulate(ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) != CiphertextSize {
+		return nil, errInvalidLength
+	}
+	mlkemKey, err := priv.mlkem.Decapsulate(ciphertext[:CiphertextSize-x25519Size])
+	if err != nil {
+		return nil, err
+	}
+	peer, err := ecdh.X25519().NewPublicKey(ciphertext[CiphertextSize-x25519Size:])
+	if err != nil {
+		return nil, err
+	}
+	x25519Key, err := priv.x25519.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]byte, 0, SharedKeySize), mlkemKey...), x25519Key...), nil
+}
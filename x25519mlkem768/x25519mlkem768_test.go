@@ -0,0 +1,121 @@
+package x25519mlkem768
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/mlkem"
+)
+
+func TestRoundTrip(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := priv.PublicKey().Bytes()
+	if len(share) != PublicKeySize {
+		t.Fatalf("public key of %d bytes", len(share))
+	}
+	pub, err := NewPublicKey(share)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedKey, ciphertext, err := pub.Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sharedKey) != SharedKeySize || len(ciphertext) != CiphertextSize {
+		t.Fatalf("shared key of %d bytes, and ciphertext of %d bytes", len(sharedKey), len(ciphertext))
+	}
+	got, err := priv.Decapsulate(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sharedKey) {
+		t.Error("shared keys differ")
+	}
+
+	priv2, err := NewPrivateKey(priv.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = priv2.Decapsulate(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, sharedKey) {
+		t.Error("shared keys differ after encoding the private key")
+	}
+}
+
+// TestOrdering checks that the ML-KEM parts come first, by recomputing both
+// halves with the underlying packages.
+func TestOrdering(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedKey, ciphertext, err := priv.PublicKey().Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := priv.Bytes()
+	dk, err := mlkem.MLKEM768().NewDecapsulationKey(b[:mlkem.SeedSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share := priv.PublicKey().Bytes(); !bytes.Equal(share[:1184], dk.EncapsulationKey().Bytes()) {
+		t.Error("key share doesn't start with the ML-KEM encapsulation key")
+	}
+	mlkemKey, err := dk.Decapsulate(ciphertext[:1088])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sharedKey[:mlkem.SharedKeySize], mlkemKey) {
+		t.Error("shared key doesn't start with the ML-KEM shared key")
+	}
+
+	x, err := ecdh.X25519().NewPrivateKey(b[mlkem.SeedSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := ecdh.X25519().NewPublicKey(ciphertext[1088:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	x25519Key, err := x.ECDH(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sharedKey[mlkem.SharedKeySize:], x25519Key) {
+		t.Error("shared key doesn't end with the X25519 shared secret")
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	priv, err := GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := priv.Decapsulate(make([]byte, CiphertextSize-1)); err == nil {
+		t.Error("short ciphertext accepted")
+	}
+	// An all-zero X25519 share is of low order.
+	_, ciphertext, err := priv.PublicKey().Encapsulate(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(ciphertext[1088:], make([]byte, 32))
+	if _, err := priv.Decapsulate(ciphertext); err == nil {
+		t.Error("low order X25519 share accepted")
+	}
+	if _, err := NewPublicKey(make([]byte, PublicKeySize+1)); err == nil {
+		t.Error("long public key accepted")
+	}
+	if _, err := NewPrivateKey(make([]byte, PrivateKeySize-1)); err == nil {
+		t.Error("short private key accepted")
+	}
+}
@@ -0,0 +1,188 @@
+// Package x3dh implements the Extended Triple Diffie-Hellman key agreement
+// protocol, as described in the X3DH specification published by Signal.
+//
+// X3DH establishes a shared secret between an initiator, Alice, and a
+// responder, Bob, who may be offline, using a bundle of prekeys Bob published
+// beforehand. All keys are X25519 keys, and the signature on Bob's signed
+// prekey is an XEdDSA signature by Bob's identity key.
+//
+// The curve is X25519, the hash SHA-256, and the encoding of public keys the
+// one used by Signal, which prefixes the u-coordinate with the byte 0x05.
+package x3dh
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+	"github.com/cronokirby/ctcrypto/hkdf"
+	"github.com/cronokirby/ctcrypto/xeddsa"
+)
+
+// SharedKeySize is the size of the shared key produced by the protocol.
+const SharedKeySize = 32
+
+// keyTypeX25519 is the byte prefixing encoded X25519 public keys.
+const keyTypeX25519 = 0x05
+
+var (
+	errWrongCurve      = errors.New("x3dh: keys must use X25519")
+	errBadSignature    = errors.New("x3dh: invalid signed prekey signature")
+	errOneTimeMismatch = errors.New("x3dh: one-time prekey doesn't match the initial message")
+)
+
+// EncodeKey encodes an X25519 public key, as the byte 0x05 followed by its
+// u-coordinate. This is the Encode function of the specification.
+func EncodeKey(key *ecdh.PublicKey) []byte {
+	return append([]byte{keyTypeX25519}, key.Bytes()...)
+}
+
+// PreKeyBundle is the set of public keys Bob publishes to a server, allowing
+// Alice to start a session while Bob is offline.
+type PreKeyBundle struct {
+	// IdentityKey is Bob's long-term identity key, IK_B.
+	IdentityKey *ecdh.PublicKey
+	// SignedPreKey is Bob's medium-term signed prekey, SPK_B.
+	SignedPreKey *ecdh.PublicKey
+	// SignedPreKeySignature is the signature of EncodeKey(SignedPreKey), by
+	// IdentityKey, as produced by SignPreKey.
+	SignedPreKeySignature []byte
+	// OneTimePreKey is one of Bob's one-time prekeys, OPK_B, which may be nil
+	// if the server ran out of them.
+	OneTimePreKey *ecdh.PublicKey
+}
+
+// InitialMessage contains the public information Alice sends to Bob alongside
+// the first message of the session.
+type InitialMessage struct {
+	// IdentityKey is Alice's identity key, IK_A.
+	IdentityKey *ecdh.PublicKey
+	// EphemeralKey is Alice's ephemeral key, EK_A.
+	EphemeralKey *ecdh.PublicKey
+	// OneTimePreKey identifies which of Bob's one-time prekeys was used, and is
+	// nil if none was.
+	OneTimePreKey *ecdh.PublicKey
+}
+
+// SignPreKey signs a prekey with an identity key, producing the signature
+// included in a PreKeyBundle. The rand parameter is used to randomize the
+// XEdDSA signature.
+func SignPreKey(identity *ecdh.PrivateKey, preKey *ecdh.PublicKey, rand io.Reader) ([]byte, error) {
+	if identity.Curve() != ecdh.X25519() || preKey.Curve() != ecdh.X25519() {
+		return nil, errWrongCurve
+	}
+	return xeddsa.Sign(identity.Bytes(), EncodeKey(preKey), rand)
+}
+
+// dhPair is a pair of keys whose Diffie-Hellman output is fed to the KDF.
+type dhPair struct {
+	private *ecdh.PrivateKey
+	public  *ecdh.PublicKey
+}
+
+// kdf derives the shared key from the concatenated DH outputs, as described in
+// section 2.2 of the specification.
+func kdf(pairs []dhPair, info []byte) ([]byte, error) {
+	// The input is prefixed with 32 0xFF bytes, for domain separation from
+	// XEdDSA, and the salt is made of 32 zero bytes.
+	ikm := make([]byte, 32, 32+len(pairs)*32)
+	for i := range ikm {
+		ikm[i] = 0xff
+	}
+	for _, pair := range pairs {
+		dh, err := pair.private.ECDH(pair.public)
+		if err != nil {
+			return nil, err
+		}
+		ikm = append(ikm, dh...)
+	}
+	salt := make([]byte, sha256.Size)
+	return hkdf.DeriveKey(ikm, salt, info, SharedKeySize)
+}
+
+// associatedData returns Encode(IK_A) || Encode(IK_B).
+func associatedData(alice, bob *ecdh.PublicKey) []byte {
+	return append(EncodeKey(alice), EncodeKey(bob)...)
+}
+
+// Initiate runs Alice's side of the protocol, using Alice's identity key and Bob's
+// prekey bundle, after verifying the signature on the signed prekey.
+//
+// The info parameter identifies the application, and must match the one used
+// by Bob. The ephemeral key is generated by reading from rand.
+//
+// This returns the shared key, the associated data which must be bound to the
+// initial ciphertext, and the message to send to Bob.
+func Initiate(identity *ecdh.PrivateKey, bundle *PreKeyBundle, info []byte, rand io.Reader) (sharedKey, ad []byte, msg *InitialMessage, err error) {
+	if identity.Curve() != ecdh.X25519() ||
+		bundle.IdentityKey.Curve() != ecdh.X25519() ||
+		bundle.SignedPreKey.Curve() != ecdh.X25519() ||
+		(bundle.OneTimePreKey != nil && bundle.OneTimePreKey.Curve() != ecdh.X25519()) {
+		return nil, nil, nil, errWrongCurve
+	}
+	if !xeddsa.Verify(bundle.IdentityKey.Bytes(), EncodeKey(bundle.SignedPreKey), bundle.SignedPreKeySignature) {
+		return nil, nil, nil, errBadSignature
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// DH1 = DH(IK_A, SPK_B), DH2 = DH(EK_A, IK_B), DH3 = DH(EK_A, SPK_B)
+	pairs := []dhPair{
+		{identity, bundle.SignedPreKey},
+		{ephemeral, bundle.IdentityKey},
+		{ephemeral, bundle.SignedPreKey},
+	}
+	// DH4 = DH(EK_A, OPK_B)
+	if bundle.OneTimePreKey != nil {
+		pairs = append(pairs, dhPair{ephemeral, bundle.OneTimePreKey})
+	}
+	if sharedKey, err = kdf(pairs, info); err != nil {
+		return nil, nil, nil, err
+	}
+	msg = &InitialMessage{
+		IdentityKey:   identity.PublicKey(),
+		EphemeralKey:  ephemeral.PublicKey(),
+		OneTimePreKey: bundle.OneTimePreKey,
+	}
+	return sharedKey, associatedData(msg.IdentityKey, bundle.IdentityKey), msg, nil
+}
+
+// Respond runs Bob's side of the protocol, using Bob's identity key, the signed
+// prekey, and the one-time prekey referenced by Alice's initial message, which
+// should be nil if the message doesn't reference any.
+//
+// This returns the shared key, and the associated data bound to the initial
+// ciphertext. After a successful call, Bob should delete the one-time prekey.
+func Respond(identity, signedPreKey, oneTimePreKey *ecdh.PrivateKey, msg *InitialMessage, info []byte) (sharedKey, ad []byte, err error) {
+	if identity.Curve() != ecdh.X25519() ||
+		signedPreKey.Curve() != ecdh.X25519() ||
+		(oneTimePreKey != nil && oneTimePreKey.Curve() != ecdh.X25519()) ||
+		msg.IdentityKey.Curve() != ecdh.X25519() ||
+		msg.EphemeralKey.Curve() != ecdh.X25519() {
+		return nil, nil, errWrongCurve
+	}
+	if (oneTimePreKey == nil) != (msg.OneTimePreKey == nil) {
+		return nil, nil, errOneTimeMismatch
+	}
+	if oneTimePreKey != nil && subtle.ConstantTimeCompare(oneTimePreKey.PublicKey().Bytes(), msg.OneTimePreKey.Bytes()) != 1 {
+		return nil, nil, errOneTimeMismatch
+	}
+
+	// The same DH outputs as Alice, with the roles swapped.
+	pairs := []dhPair{
+		{signedPreKey, msg.IdentityKey},
+		{identity, msg.EphemeralKey},
+		{signedPreKey, msg.EphemeralKey},
+	}
+	if oneTimePreKey != nil {
+		pairs = append(pairs, dhPair{oneTimePreKey, msg.EphemeralKey})
+	}
+	if sharedKey, err = kdf(pairs, info); err != nil {
+		return nil, nil, err
+	}
+	return sharedKey, associatedData(msg.IdentityKey, identity.PublicKey()), nil
+}
@@ -0,0 +1,128 @@
+package x3dh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdh"
+)
+
+var testInfo = []byte("ctcrypto x3dh test")
+
+type bob struct {
+	identity, signedPreKey, oneTimePreKey *ecdh.PrivateKey
+	bundle                                *PreKeyBundle
+}
+
+func newBob(t *testing.T, withOneTime bool) *bob {
+	curve := ecdh.X25519()
+	b := new(bob)
+	var err error
+	if b.identity, err = curve.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if b.signedPreKey, err = curve.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := SignPreKey(b.identity, b.signedPreKey.PublicKey(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.bundle = &PreKeyBundle{
+		IdentityKey:           b.identity.PublicKey(),
+		SignedPreKey:          b.signedPreKey.PublicKey(),
+		SignedPreKeySignature: sig,
+	}
+	if withOneTime {
+		if b.oneTimePreKey, err = curve.GenerateKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+		b.bundle.OneTimePreKey = b.oneTimePreKey.PublicKey()
+	}
+	return b
+}
+
+func testAgreement(t *testing.T, withOneTime bool) {
+	b := newBob(t, withOneTime)
+	alice, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skA, adA, msg, err := Initiate(alice, b.bundle, testInfo, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skB, adB, err := Respond(b.identity, b.signedPreKey, b.oneTimePreKey, msg, testInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(skA, skB) {
+		t.Errorf("shared keys differ")
+	}
+	if len(skA) != SharedKeySize {
+		t.Errorf("shared key has length %d", len(skA))
+	}
+	expectedAD := append(EncodeKey(alice.PublicKey()), EncodeKey(b.identity.PublicKey())...)
+	if !bytes.Equal(adA, expectedAD) || !bytes.Equal(adB, expectedAD) {
+		t.Errorf("unexpected associated data")
+	}
+	skOther, _, err := Respond(b.identity, b.signedPreKey, b.oneTimePreKey, msg, []byte("other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(skA, skOther) {
+		t.Errorf("info doesn't affect the shared key")
+	}
+}
+
+func TestAgreementWithOneTimePreKey(t *testing.T) {
+	testAgreement(t, true)
+}
+
+func TestAgreementWithoutOneTimePreKey(t *testing.T) {
+	testAgreement(t, false)
+}
+
+func TestInitiateRejectsBadSignature(t *testing.T) {
+	b := newBob(t, true)
+	b.bundle.SignedPreKeySignature[0] ^= 1
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, _, _, err := Initiate(alice, b.bundle, testInfo, rand.Reader); err == nil {
+		t.Errorf("accepted a bundle with an invalid signature")
+	}
+}
+
+func TestInitiateRejectsSwappedPreKey(t *testing.T) {
+	b := newBob(t, true)
+	// A server substituting the signed prekey must be caught.
+	b.bundle.SignedPreKey = b.oneTimePreKey.PublicKey()
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, _, _, err := Initiate(alice, b.bundle, testInfo, rand.Reader); err == nil {
+		t.Errorf("accepted a bundle with a substituted signed prekey")
+	}
+}
+
+func TestRespondRejectsMismatchedOneTimePreKey(t *testing.T) {
+	b := newBob(t, true)
+	alice, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	_, _, msg, err := Initiate(alice, b.bundle, testInfo, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Respond(b.identity, b.signedPreKey, nil, msg, testInfo); err == nil {
+		t.Errorf("accepted a missing one-time prekey")
+	}
+	other, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	if _, _, err := Respond(b.identity, b.signedPreKey, other, msg, testInfo); err == nil {
+		t.Errorf("accepted the wrong one-time prekey")
+	}
+}
+
+func TestRejectsOtherCurves(t *testing.T) {
+	b := newBob(t, false)
+	alice, _ := ecdh.P256().GenerateKey(rand.Reader)
+	if _, _, _, err := Initiate(alice, b.bundle, testInfo, rand.Reader); err == nil {
+		t.Errorf("accepted a P-256 identity key")
+	}
+}
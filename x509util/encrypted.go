@@ -0,0 +1,540 @@
+package x509util
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/aesgcm"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/ctcrypto/internal/der"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/padding"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted private keys are PKCS #8 EncryptedPrivateKeyInfo structures,
+// encrypted with PBES2, from RFC 8018: a key is derived from the password with
+// PBKDF2 or scrypt, and encrypts the DER encoding of the private key.
+//
+// Both KDFs only process the password through HMAC, or SHA-256 for scrypt,
+// whose running times depend on its length, but not on its contents, and the
+// padding of CBC is checked in constant time. AES-GCM goes through the aesgcm
+// package, which runs in constant time everywhere, while AES-CBC goes through
+// crypto/aes, which only does with hardware support, like AES-NI.
+
+var (
+	errMalformedEncryptedPKCS8 = errors.New("x509util: malformed encrypted PKCS #8 private key")
+	errUnsupportedEncryption   = errors.New("x509util: unsupported encryption of PKCS #8 private key")
+	// errDecryption is returned whether the tag or the padding is wrong, which
+	// is usually caused by an incorrect password.
+	errDecryption = errors.New("x509util: PKCS #8 private key decryption failed, the password may be incorrect")
+)
+
+// The encoded object identifiers of PBES2, and of its KDFs and ciphers.
+var (
+	// 1.2.840.113549.1.5.13
+	oidPBES2 = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x05, 0x0d}
+	// 1.2.840.113549.1.5.12
+	oidPBKDF2 = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x05, 0x0c}
+	// 1.3.6.1.4.1.11591.4.11
+	oidScrypt = []byte{0x2b, 0x06, 0x01, 0x04, 0x01, 0xda, 0x47, 0x04, 0x0b}
+
+	// 1.2.840.113549.2.7
+	oidHMACWithSHA1 = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x02, 0x07}
+	// 1.2.840.113549.2.9
+	oidHMACWithSHA256 = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x02, 0x09}
+	// 1.2.840.113549.2.11
+	oidHMACWithSHA512 = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x02, 0x0b}
+
+	// 2.16.840.1.101.3.4.1.2
+	oidAES128CBC = []byte{0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x02}
+	// 2.16.840.1.101.3.4.1.42
+	oidAES256CBC = []byte{0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x2a}
+	// 2.16.840.1.101.3.4.1.6
+	oidAES128GCM = []byte{0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x06}
+	// 2.16.840.1.101.3.4.1.46
+	oidAES256GCM = []byte{0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x01, 0x2e}
+)
+
+// The costs of the KDFs accepted when decrypting, which bound the time and
+// memory an encrypted key can make its parser spend.
+const (
+	maxPBKDF2Iterations = 1 << 24
+	// maxScryptMemory bounds N * r, the memory used by scrypt being 128 * N * r
+	// bytes, here 1 GiB.
+	maxScryptMemory      = 1 << 23
+	maxScryptParallelism = 16
+	maxSaltSize          = 1024
+)
+
+// The sizes of the salts written, and of the parameters of the ciphers.
+const (
+	saltSize   = 16
+	cbcIVSize  = aes.BlockSize
+	gcmTagSize = aesgcm.Overhead
+	// gcmDefaultTagSize is the tag size of GCMParameters when omitted.
+	gcmDefaultTagSize = 12
+)
+
+// PKCS8Cipher is a cipher encrypting a private key with PBES2.
+type PKCS8Cipher int
+
+const (
+	// AES256GCM is AES-256 in GCM mode, with a 16 byte tag, the default.
+	AES256GCM PKCS8Cipher = iota + 1
+	// AES128GCM is AES-128 in GCM mode, with a 16 byte tag.
+	AES128GCM
+	// AES256CBC is AES-256 in CBC mode. Unlike GCM, it can be read by OpenSSL.
+	AES256CBC
+	// AES128CBC is AES-128 in CBC mode.
+	AES128CBC
+)
+
+// pbesCipher describes a cipher of PBES2.
+type pbesCipher struct {
+	oid     []byte
+	keySize int
+	gcm     bool
+}
+
+var pbesCiphers = map[PKCS8Cipher]pbesCipher{
+	AES256GCM: {oidAES256GCM, 32, true},
+	AES128GCM: {oidAES128GCM, 16, true},
+	AES256CBC: {oidAES256CBC, 32, false},
+	AES128CBC: {oidAES128CBC, 16, false},
+}
+
+func cipherFromOID(oid []byte) (pbesCipher, bool) {
+	for _, c := range pbesCiphers {
+		if bytes.Equal(oid, c.oid) {
+			return c, true
+		}
+	}
+	return pbesCipher{}, false
+}
+
+// KDFOptions are the options of the KDF deriving the key of an encrypted
+// private key from its password, a *PBKDF2Options or a *ScryptOptions.
+type KDFOptions interface {
+	newKDF(salt []byte) (passwordKDF, error)
+}
+
+// PBKDF2Options are the options of PBKDF2, from RFC 8018.
+type PBKDF2Options struct {
+	// Iterations is the number of iterations, 600000 if zero, as recommended
+	// by OWASP for HMAC-SHA-256.
+	Iterations int
+	// Hash is the hash of the HMAC used as PRF, crypto.SHA256, the default if
+	// zero, or crypto.SHA512.
+	Hash crypto.Hash
+}
+
+func (opts *PBKDF2Options) newKDF(salt []byte) (passwordKDF, error) {
+	k := &pbkdf2KDF{salt: salt, iterations: opts.Iterations, hash: opts.Hash}
+	if k.iterations == 0 {
+		k.iterations = 600000
+	}
+	if k.hash == 0 {
+		k.hash = crypto.SHA256
+	}
+	if k.iterations < 0 || k.iterations > maxPBKDF2Iterations {
+		return nil, errors.New("x509util: invalid number of PBKDF2 iterations")
+	}
+	if k.hash != crypto.SHA256 && k.hash != crypto.SHA512 {
+		return nil, errors.New("x509util: unsupported PBKDF2 hash " + k.hash.String())
+	}
+	return k, nil
+}
+
+// ScryptOptions are the options of scrypt, from RFC 7914.
+type ScryptOptions struct {
+	// N is the CPU and memory cost, a power of two, 2^15 if zero.
+	N int
+	// R is the block size, 8 if zero.
+	R int
+	// P is the parallelization, 1 if zero.
+	P int
+}
+
+func (opts *ScryptOptions) newKDF(salt []byte) (passwordKDF, error) {
+	k := &scryptKDF{salt: salt, n: opts.N, r: opts.R, p: opts.P}
+	if k.n == 0 {
+		k.n = 1 << 15
+	}
+	if k.r == 0 {
+		k.r = 8
+	}
+	if k.p == 0 {
+		k.p = 1
+	}
+	if err := k.check(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// PKCS8EncryptionOptions are the options of MarshalEncryptedPKCS8PrivateKey.
+type PKCS8EncryptionOptions struct {
+	// Cipher is the cipher encrypting the key, AES256GCM if zero.
+	Cipher PKCS8Cipher
+	// KDF derives the key of the cipher from the password. If nil, PBKDF2 is
+	// used with the defaults of PBKDF2Options.
+	KDF KDFOptions
+}
+
+// passwordKDF is a KDF of PBES2, with its parameters.
+type passwordKDF interface {
+	// deriveKey derives a key of keySize bytes from password.
+	deriveKey(password []byte, keySize int) ([]byte, error)
+	// marshal appends the AlgorithmIdentifier of the KDF.
+	marshal(b *der.Builder)
+}
+
+type pbkdf2KDF struct {
+	salt       []byte
+	iterations int
+	hash       crypto.Hash
+}
+
+func (k *pbkdf2KDF) hashFunc() func() hash.Hash {
+	switch k.hash {
+	case crypto.SHA1:
+		return sha1.New
+	case crypto.SHA256:
+		return sha256.New
+	}
+	return sha512.New
+}
+
+func (k *pbkdf2KDF) deriveKey(password []byte, keySize int) ([]byte, error) {
+	if err := fips.Check(fips.ApprovedHash(k.hash, 0), "PBKDF2 with "+k.hash.String()); err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key(password, k.salt, k.iterations, keySize, k.hashFunc()), nil
+}
+
+func (k *pbkdf2KDF) marshal(b *der.Builder) {
+	b.AddSequence(func(b *der.Builder) {
+		b.AddElement(der.ObjectIdentifier, oidPBKDF2)
+		b.AddSequence(func(b *der.Builder) {
+			b.AddOctetString(k.salt)
+			b.AddSmallInteger(k.iterations)
+			prf := oidHMACWithSHA256
+			if k.hash == crypto.SHA512 {
+				prf = oidHMACWithSHA512
+			}
+			b.AddSequence(func(b *der.Builder) {
+				b.AddElement(der.ObjectIdentifier, prf)
+				b.AddNull()
+			})
+		})
+	})
+}
+
+type scryptKDF struct {
+	salt    []byte
+	n, r, p int
+}
+
+func (k *scryptKDF) check() error {
+	if k.n < 2 || k.n&(k.n-1) != 0 || k.r < 1 || k.p < 1 ||
+		k.n > maxScryptMemory/k.r || k.p > maxScryptParallelism {
+		return errors.New("x509util: invalid or too expensive scrypt parameters")
+	}
+	return nil
+}
+
+func (k *scryptKDF) deriveKey(password []byte, keySize int) ([]byte, error) {
+	if err := fips.Check(false, "scrypt"); err != nil {
+		return nil, err
+	}
+	return scrypt.Key(password, k.salt, k.n, k.r, k.p, keySize)
+}
+
+func (k *scryptKDF) marshal(b *der.Builder) {
+	b.AddSequence(func(b *der.Builder) {
+		b.AddElement(der.ObjectIdentifier, oidScrypt)
+		b.AddSequence(func(b *der.Builder) {
+			b.AddOctetString(k.salt)
+			b.AddSmallInteger(k.n)
+			b.AddSmallInteger(k.r)
+			b.AddSmallInteger(k.p)
+		})
+	})
+}
+
+// MarshalEncryptedPKCS8PrivateKey encodes a private key in the PKCS #8 form of
+// MarshalPKCS8PrivateKey, and encrypts it with password, returning the ASN.1
+// DER form of a PKCS #8 EncryptedPrivateKeyInfo, usually stored in a PEM
+// block of type "ENCRYPTED PRIVATE KEY".
+//
+// The key is encrypted with PBES2, from RFC 8018, with the cipher and KDF of
+// opts, which can be nil to use AES-256-GCM and PBKDF2 with HMAC-SHA-256. The
+// salt, IV, or nonce are read from rand. If rand is nil, the health-tested
+// Reader of the rand package of this module is used.
+//
+// OpenSSL can decrypt keys encrypted with AES-CBC, but not with AES-GCM.
+func MarshalEncryptedPKCS8PrivateKey(rand io.Reader, key crypto.PrivateKey, password []byte, opts *PKCS8EncryptionOptions) ([]byte, error) {
+	rand = randutil.Or(rand)
+	if opts == nil {
+		opts = &PKCS8EncryptionOptions{}
+	}
+	cipherChoice := opts.Cipher
+	if cipherChoice == 0 {
+		cipherChoice = AES256GCM
+	}
+	c, ok := pbesCiphers[cipherChoice]
+	if !ok {
+		return nil, errUnsupportedEncryption
+	}
+	kdfOpts := opts.KDF
+	if kdfOpts == nil {
+		kdfOpts = &PBKDF2Options{}
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return nil, err
+	}
+	kdf, err := kdfOpts.newKDF(salt)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, cbcIVSize)
+	if c.gcm {
+		iv = iv[:aesgcm.NonceSize]
+	}
+	if _, err := io.ReadFull(rand, iv); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(plaintext)
+	k, err := kdf.deriveKey(password, c.keySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(k)
+	var ciphertext []byte
+	if c.gcm {
+		aead, err := aesgcm.New(k)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext = aead.Seal(nil, iv, plaintext, nil)
+	} else {
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext = padding.PadPKCS7(append([]byte{}, plaintext...), aes.BlockSize)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	}
+
+	var b der.Builder
+	b.AddSequence(func(b *der.Builder) {
+		b.AddSequence(func(b *der.Builder) {
+			b.AddElement(der.ObjectIdentifier, oidPBES2)
+			b.AddSequence(func(b *der.Builder) {
+				kdf.marshal(b)
+				b.AddSequence(func(b *der.Builder) {
+					b.AddElement(der.ObjectIdentifier, c.oid)
+					if c.gcm {
+						b.AddSequence(func(b *der.Builder) {
+							b.AddOctetString(iv)
+							b.AddSmallInteger(gcmTagSize)
+						})
+					} else {
+						b.AddOctetString(iv)
+					}
+				})
+			})
+		})
+		b.AddOctetString(ciphertext)
+	})
+	return b.Bytes(), nil
+}
+
+// ParseEncryptedPKCS8PrivateKey decrypts a private key in the PKCS #8
+// EncryptedPrivateKeyInfo form with password, and parses it with
+// ParsePKCS8PrivateKey. The key can be in the ASN.1 DER form, or in a PEM block
+// of type "ENCRYPTED PRIVATE KEY", as written by "openssl pkcs8 -topk8".
+//
+// Keys encrypted with PBES2 are supported, with PBKDF2, using HMAC with SHA-1,
+// SHA-256, or SHA-512, or with scrypt, and with AES-128 or AES-256, in CBC or
+// GCM mode. The legacy schemes of PBES1 and PKCS #12 aren't. Keys whose KDF is
+// too expensive, with over 2^24 iterations of PBKDF2, or using over 1 GiB of
+// memory with scrypt, are rejected.
+//
+// The decrypted key, and the key derived from the password, are erased before
+// returning, and an incorrect password is reported in the same way whether it
+// is detected by the tag of GCM or by the padding of CBC.
+func ParseEncryptedPKCS8PrivateKey(data, password []byte) (crypto.PrivateKey, error) {
+	if typ, block, ok := decodePEM(data); ok {
+		if typ != "ENCRYPTED PRIVATE KEY" {
+			return nil, errors.New("x509util: unsupported PEM block type " + typ)
+		}
+		data = block
+	} else if typ != "" {
+		return nil, errors.New("x509util: malformed PEM block of type " + typ)
+	}
+
+	var (
+		seq, algorithm, oid, params, kdfAlgorithm, kdfOID, kdfParams, encAlgorithm, encOID der.Input
+		encrypted                                                                          []byte
+	)
+	input := der.Input(data)
+	if !input.ReadElement(&seq, der.Sequence) || !input.Empty() ||
+		!seq.ReadElement(&algorithm, der.Sequence) ||
+		!seq.ReadOctetString(&encrypted) || !seq.Empty() ||
+		!algorithm.ReadElement(&oid, der.ObjectIdentifier) {
+		return nil, errMalformedEncryptedPKCS8
+	}
+	if !bytes.Equal(oid, oidPBES2) {
+		return nil, errUnsupportedEncryption
+	}
+	if !algorithm.ReadElement(&params, der.Sequence) || !algorithm.Empty() ||
+		!params.ReadElement(&kdfAlgorithm, der.Sequence) ||
+		!params.ReadElement(&encAlgorithm, der.Sequence) || !params.Empty() ||
+		!kdfAlgorithm.ReadElement(&kdfOID, der.ObjectIdentifier) ||
+		!kdfAlgorithm.ReadElement(&kdfParams, der.Sequence) || !kdfAlgorithm.Empty() ||
+		!encAlgorithm.ReadElement(&encOID, der.ObjectIdentifier) {
+		return nil, errMalformedEncryptedPKCS8
+	}
+
+	c, ok := cipherFromOID(encOID)
+	if !ok {
+		return nil, errUnsupportedEncryption
+	}
+	var iv []byte
+	if c.gcm {
+		// GCMParameters, from RFC 5084, whose tag length defaults to 12.
+		var gcmParams der.Input
+		tagSize := gcmDefaultTagSize
+		if !encAlgorithm.ReadElement(&gcmParams, der.Sequence) || !encAlgorithm.Empty() ||
+			!gcmParams.ReadOctetString(&iv) ||
+			!gcmParams.Empty() && !gcmParams.ReadSmallInteger(&tagSize) || !gcmParams.Empty() {
+			return nil, errMalformedEncryptedPKCS8
+		}
+		if len(iv) != aesgcm.NonceSize || tagSize != gcmTagSize {
+			return nil, errUnsupportedEncryption
+		}
+	} else if !encAlgorithm.ReadOctetString(&iv) || !encAlgorithm.Empty() || len(iv) != cbcIVSize {
+		return nil, errMalformedEncryptedPKCS8
+	}
+
+	kdf, err := parseKDF(kdfOID, kdfParams, c.keySize)
+	if err != nil {
+		return nil, err
+	}
+	k, err := kdf.deriveKey(password, c.keySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(k)
+
+	var plaintext []byte
+	if c.gcm {
+		aead, err := aesgcm.New(k)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err = aead.Open(nil, iv, encrypted, nil)
+		if err != nil {
+			return nil, errDecryption
+		}
+		defer zero(plaintext)
+	} else {
+		if len(encrypted) == 0 || len(encrypted)%aes.BlockSize != 0 {
+			return nil, errMalformedEncryptedPKCS8
+		}
+		block, err := aes.NewCipher(k)
+		if err != nil {
+			return nil, err
+		}
+		plaintext = make([]byte, len(encrypted))
+		defer zero(plaintext)
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, encrypted)
+		n, ok := padding.CheckPKCS7(plaintext, aes.BlockSize)
+		if ok != 1 {
+			return nil, errDecryption
+		}
+		plaintext = plaintext[:len(plaintext)-n]
+	}
+	return ParsePKCS8PrivateKey(plaintext)
+}
+
+// parseKDF parses the parameters of the KDF of PBES2, deriving keys of keySize
+// bytes.
+func parseKDF(oid, params der.Input, keySize int) (passwordKDF, error) {
+	var salt []byte
+	if !params.ReadOctetString(&salt) || len(salt) == 0 || len(salt) > maxSaltSize {
+		return nil, errMalformedEncryptedPKCS8
+	}
+	// The key length is optional in both KDFs, and must match the cipher.
+	readKeyLength := func() bool {
+		if !params.PeekTag(der.Integer) {
+			return true
+		}
+		var keyLength int
+		return params.ReadSmallInteger(&keyLength) && keyLength == keySize
+	}
+	switch {
+	case bytes.Equal(oid, oidPBKDF2):
+		k := &pbkdf2KDF{salt: salt, hash: crypto.SHA1}
+		var prf, prfOID der.Input
+		var hasPRF bool
+		if !params.ReadSmallInteger(&k.iterations) || k.iterations == 0 ||
+			!readKeyLength() ||
+			!params.ReadOptionalElement(&prf, &hasPRF, der.Sequence) || !params.Empty() {
+			return nil, errMalformedEncryptedPKCS8
+		}
+		if k.iterations > maxPBKDF2Iterations {
+			return nil, errors.New("x509util: too many PBKDF2 iterations")
+		}
+		if hasPRF {
+			// The parameters of the PRF must be NULL, but are sometimes
+			// omitted.
+			if !prf.ReadElement(&prfOID, der.ObjectIdentifier) ||
+				!prf.Empty() && (!prf.ReadNull() || !prf.Empty()) {
+				return nil, errMalformedEncryptedPKCS8
+			}
+			switch {
+			case bytes.Equal(prfOID, oidHMACWithSHA1):
+				k.hash = crypto.SHA1
+			case bytes.Equal(prfOID, oidHMACWithSHA256):
+				k.hash = crypto.SHA256
+			case bytes.Equal(prfOID, oidHMACWithSHA512):
+				k.hash = crypto.SHA512
+			default:
+				return nil, errors.New("x509util: unsupported PBKDF2 PRF")
+			}
+		}
+		return k, nil
+	case bytes.Equal(oid, oidScrypt):
+		k := &scryptKDF{salt: salt}
+		if !params.ReadSmallInteger(&k.n) ||
+			!params.ReadSmallInteger(&k.r) ||
+			!params.ReadSmallInteger(&k.p) ||
+			!readKeyLength() || !params.Empty() {
+			return nil, errMalformedEncryptedPKCS8
+		}
+		if err := k.check(); err != nil {
+			return nil, err
+		}
+		return k, nil
+	}
+	return nil, errors.New("x509util: unsupported KDF in encrypted PKCS #8 private key")
+}
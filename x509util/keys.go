@@ -0,0 +1,419 @@
+package x509util
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/internal/der"
+	"github.com/cronokirby/ctcrypto/rsa"
+	"github.com/cronokirby/safenum"
+)
+
+// The private key codecs of this file replace those of crypto/x509, which
+// decode integers into big.Int values, with encoding/asn1, whose running time
+// depends on the values decoded. Here, the structure of a key, made of its
+// tags, and the lengths of its elements, is treated as public, as it only
+// depends on the type and size of the key, while its integers are decoded
+// into safenum.Nat values by the der package, and checked with constant-time
+// comparisons, so that keys decrypted from blobs at rest can be parsed next to
+// an attacker measuring timings, or sharing caches.
+
+var (
+	errMalformedPKCS1 = errors.New("x509util: malformed PKCS #1 private key")
+	errMalformedEC    = errors.New("x509util: malformed SEC 1 private key")
+	errMalformedPKCS8 = errors.New("x509util: malformed PKCS #8 private key")
+)
+
+// The encoded object identifiers of the algorithms and curves supported.
+var (
+	// 1.2.840.113549.1.1.1
+	oidRSA = []byte{0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x01, 0x01}
+	// 1.2.840.10045.2.1
+	oidECPublicKey = []byte{0x2a, 0x86, 0x48, 0xce, 0x3d, 0x02, 0x01}
+	// 1.3.101.112
+	oidEd25519 = []byte{0x2b, 0x65, 0x70}
+
+	// 1.3.132.0.33
+	oidP224 = []byte{0x2b, 0x81, 0x04, 0x00, 0x21}
+	// 1.2.840.10045.3.1.7
+	oidP256 = []byte{0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}
+	// 1.3.132.0.34
+	oidP384 = []byte{0x2b, 0x81, 0x04, 0x00, 0x22}
+	// 1.3.132.0.35
+	oidP521 = []byte{0x2b, 0x81, 0x04, 0x00, 0x23}
+)
+
+func oidFromCurve(c elliptic.Curve) ([]byte, bool) {
+	switch c {
+	case elliptic.P224():
+		return oidP224, true
+	case elliptic.P256():
+		return oidP256, true
+	case elliptic.P384():
+		return oidP384, true
+	case elliptic.P521():
+		return oidP521, true
+	}
+	return nil, false
+}
+
+func curveFromOID(oid []byte) elliptic.Curve {
+	switch {
+	case bytes.Equal(oid, oidP224):
+		return elliptic.P224()
+	case bytes.Equal(oid, oidP256):
+		return elliptic.P256()
+	case bytes.Equal(oid, oidP384):
+		return elliptic.P384()
+	case bytes.Equal(oid, oidP521):
+		return elliptic.P521()
+	}
+	return nil
+}
+
+// MarshalPKCS1PrivateKey encodes an RSA private key in the PKCS #1, ASN.1 DER
+// form, like x509.MarshalPKCS1PrivateKey. The values of key.Precomputed are
+// computed if missing.
+func MarshalPKCS1PrivateKey(key *rsa.PrivateKey) []byte {
+	key.Precompute()
+	version := 0
+	if len(key.Primes) > 2 {
+		version = 1
+	}
+	var b der.Builder
+	b.AddSequence(func(b *der.Builder) {
+		b.AddSmallInteger(version)
+		b.AddIntegerBytes(key.N.Bytes())
+		b.AddSmallInteger(key.E)
+		b.AddInteger(key.D)
+		b.AddInteger(key.Primes[0])
+		b.AddInteger(key.Primes[1])
+		b.AddInteger(key.Precomputed.Dp)
+		b.AddInteger(key.Precomputed.Dq)
+		b.AddInteger(key.Precomputed.Qinv)
+		if version == 1 {
+			b.AddSequence(func(b *der.Builder) {
+				for i, values := range key.Precomputed.CRTValues {
+					b.AddSequence(func(b *der.Builder) {
+						b.AddInteger(key.Primes[2+i])
+						b.AddInteger(values.Exp)
+						b.AddInteger(values.Coeff)
+					})
+				}
+			})
+		}
+	})
+	return b.Bytes()
+}
+
+// ParsePKCS1PrivateKey parses an RSA private key in the PKCS #1, ASN.1 DER
+// form, like x509.ParsePKCS1PrivateKey, without branching on the values of its
+// secret integers.
+//
+// The key is checked with Validate, and its CRT values are computed again,
+// rather than taken from the encoding.
+func ParsePKCS1PrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	var (
+		seq        der.Input
+		version, e int
+		n          []byte
+		unused     = new(safenum.Nat)
+	)
+	key := &rsa.PrivateKey{D: new(safenum.Nat), Primes: []*safenum.Nat{new(safenum.Nat), new(safenum.Nat)}}
+	input := der.Input(data)
+	// The CRT values are read into unused.
+	if !input.ReadElement(&seq, der.Sequence) || !input.Empty() ||
+		!seq.ReadSmallInteger(&version) || version > 1 ||
+		!seq.ReadIntegerBytes(&n) ||
+		!seq.ReadSmallInteger(&e) ||
+		!seq.ReadInteger(key.D) ||
+		!seq.ReadInteger(key.Primes[0]) ||
+		!seq.ReadInteger(key.Primes[1]) ||
+		!seq.ReadInteger(unused) ||
+		!seq.ReadInteger(unused) ||
+		!seq.ReadInteger(unused) {
+		return nil, errMalformedPKCS1
+	}
+	if version == 1 {
+		// Version 1 is used for keys with more than two primes, which are
+		// listed in a non-empty sequence of OtherPrimeInfo.
+		var others der.Input
+		if !seq.ReadElement(&others, der.Sequence) || others.Empty() {
+			return nil, errMalformedPKCS1
+		}
+		for !others.Empty() {
+			var info der.Input
+			prime := new(safenum.Nat)
+			if !others.ReadElement(&info, der.Sequence) ||
+				!info.ReadInteger(prime) ||
+				!info.ReadInteger(unused) ||
+				!info.ReadInteger(unused) ||
+				!info.Empty() {
+				return nil, errMalformedPKCS1
+			}
+			key.Primes = append(key.Primes, prime)
+		}
+	}
+	// The modulus is public, and a zero modulus would make safenum panic.
+	if !seq.Empty() || len(n) == 1 && n[0] == 0 {
+		return nil, errMalformedPKCS1
+	}
+	key.N = safenum.ModulusFromBytes(n)
+	key.E = e
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return key, nil
+}
+
+// MarshalECPrivateKey encodes an ECDSA private key in the SEC 1, ASN.1 DER
+// form, like x509.MarshalECPrivateKey.
+func MarshalECPrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	oid, ok := oidFromCurve(key.Curve)
+	if !ok {
+		return nil, errors.New("x509util: unsupported curve " + key.Curve.Params().Name)
+	}
+	return marshalECPrivateKey(key, oid), nil
+}
+
+// marshalECPrivateKey encodes key in the SEC 1 form, with the parameters oid,
+// or without parameters if oid is nil.
+func marshalECPrivateKey(key *ecdsa.PrivateKey, oid []byte) []byte {
+	size := (key.Curve.Params().N.BitLen() + 7) / 8
+	d := key.D.FillBytes(make([]byte, size))
+	var b der.Builder
+	b.AddSequence(func(b *der.Builder) {
+		b.AddSmallInteger(1)
+		b.AddOctetString(d)
+		if oid != nil {
+			b.AddConstructed(der.Explicit(0), func(b *der.Builder) {
+				b.AddElement(der.ObjectIdentifier, oid)
+			})
+		}
+		b.AddConstructed(der.Explicit(1), func(b *der.Builder) {
+			b.AddBitString(elliptic.Marshal(key.Curve, key.X, key.Y))
+		})
+	})
+	return b.Bytes()
+}
+
+// ParseECPrivateKey parses an ECDSA private key in the SEC 1, ASN.1 DER form,
+// like x509.ParseECPrivateKey, without branching on the value of the private
+// scalar. The curve must be P-224, P-256, P-384, or P-521.
+//
+// The public key is computed from the private key, rather than taken from the
+// encoding.
+func ParseECPrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	return parseECPrivateKey(nil, data)
+}
+
+// parseECPrivateKey parses a private key in the SEC 1 form, which can omit
+// its curve if curveOID, from the PKCS #8 algorithm identifier, isn't nil.
+func parseECPrivateKey(curveOID []byte, data []byte) (*ecdsa.PrivateKey, error) {
+	var (
+		seq, params, pub  der.Input
+		version           int
+		d                 []byte
+		hasParams, hasPub bool
+	)
+	input := der.Input(data)
+	if !input.ReadElement(&seq, der.Sequence) || !input.Empty() ||
+		!seq.ReadSmallInteger(&version) || version != 1 ||
+		!seq.ReadOctetString(&d) ||
+		!seq.ReadOptionalElement(&params, &hasParams, der.Explicit(0)) ||
+		!seq.ReadOptionalElement(&pub, &hasPub, der.Explicit(1)) ||
+		!seq.Empty() {
+		return nil, errMalformedEC
+	}
+	if hasParams {
+		var oid der.Input
+		if !params.ReadElement(&oid, der.ObjectIdentifier) || !params.Empty() {
+			return nil, errMalformedEC
+		}
+		if curveOID != nil && !bytes.Equal(oid, curveOID) {
+			return nil, errors.New("x509util: mismatched curves in PKCS #8 private key")
+		}
+		curveOID = oid
+	}
+	c := curveFromOID(curveOID)
+	if c == nil {
+		return nil, errors.New("x509util: unsupported curve in SEC 1 private key")
+	}
+
+	// The scalar is left-padded to the size of the order, and the position
+	// of the padding only depends on the length of its encoding.
+	size := (c.Params().N.BitLen() + 7) / 8
+	if len(d) > size {
+		return nil, errMalformedEC
+	}
+	k := make([]byte, size)
+	copy(k[size-len(d):], d)
+	defer zero(k)
+	kNat := new(safenum.Nat).SetBytes(k)
+	// 0 < k < n
+	order := safenum.ModulusFromBytes(c.Params().N.Bytes())
+	if kNat.EqZero() || kNat.CmpMod(order) != -1 {
+		return nil, errors.New("x509util: invalid ECDSA private key")
+	}
+	// math/big is only used to fill D, which ecdsa.PrivateKey requires, from
+	// a buffer of a fixed size.
+	priv := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(k)}
+	priv.Curve = c
+	priv.X, priv.Y = c.ScalarBaseMult(k)
+	return priv, nil
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// MarshalPKCS8PrivateKey encodes a private key in the PKCS #8, ASN.1 DER form,
+// like x509.MarshalPKCS8PrivateKey. The key can be an *rsa.PrivateKey, an
+// *ecdsa.PrivateKey, or an ed25519.PrivateKey.
+func MarshalPKCS8PrivateKey(key crypto.PrivateKey) ([]byte, error) {
+	var (
+		algorithm func(*der.Builder)
+		private   []byte
+	)
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		algorithm = func(b *der.Builder) {
+			b.AddElement(der.ObjectIdentifier, oidRSA)
+			b.AddNull()
+		}
+		private = MarshalPKCS1PrivateKey(key)
+	case *ecdsa.PrivateKey:
+		oid, ok := oidFromCurve(key.Curve)
+		if !ok {
+			return nil, errors.New("x509util: unsupported curve " + key.Curve.Params().Name)
+		}
+		algorithm = func(b *der.Builder) {
+			b.AddElement(der.ObjectIdentifier, oidECPublicKey)
+			b.AddElement(der.ObjectIdentifier, oid)
+		}
+		// The curve is only given by the algorithm identifier.
+		private = marshalECPrivateKey(key, nil)
+	case ed25519.PrivateKey:
+		algorithm = func(b *der.Builder) {
+			b.AddElement(der.ObjectIdentifier, oidEd25519)
+		}
+		var seed der.Builder
+		seed.AddOctetString(key.Seed())
+		private = seed.Bytes()
+	default:
+		return nil, errUnsupportedKey
+	}
+	var b der.Builder
+	b.AddSequence(func(b *der.Builder) {
+		b.AddSmallInteger(0)
+		b.AddSequence(algorithm)
+		b.AddOctetString(private)
+	})
+	return b.Bytes(), nil
+}
+
+// ParsePKCS8PrivateKey parses a private key in the PKCS #8, ASN.1 DER form,
+// like x509.ParsePKCS8PrivateKey, without branching on the values of its
+// secrets. It returns an *rsa.PrivateKey, an *ecdsa.PrivateKey, or an
+// ed25519.PrivateKey.
+func ParsePKCS8PrivateKey(data []byte) (crypto.PrivateKey, error) {
+	var (
+		seq, algorithm, oid, attributes, pub der.Input
+		version                              int
+		private                              []byte
+		hasAttributes, hasPub                bool
+	)
+	input := der.Input(data)
+	// The attributes are [0] IMPLICIT, and constructed, like an explicitly
+	// tagged element, and the public key of version 1, from RFC 5958, is [1]
+	// IMPLICIT, and primitive.
+	if !input.ReadElement(&seq, der.Sequence) || !input.Empty() ||
+		!seq.ReadSmallInteger(&version) || version > 1 ||
+		!seq.ReadElement(&algorithm, der.Sequence) ||
+		!algorithm.ReadElement(&oid, der.ObjectIdentifier) ||
+		!seq.ReadOctetString(&private) ||
+		!seq.ReadOptionalElement(&attributes, &hasAttributes, der.Explicit(0)) ||
+		!seq.ReadOptionalElement(&pub, &hasPub, der.Tag(0x81)) ||
+		!seq.Empty() || hasPub && version == 0 {
+		return nil, errMalformedPKCS8
+	}
+	switch {
+	case bytes.Equal(oid, oidRSA):
+		// The parameters must be NULL, but are sometimes omitted.
+		if !algorithm.Empty() && (!algorithm.ReadNull() || !algorithm.Empty()) {
+			return nil, errMalformedPKCS8
+		}
+		return ParsePKCS1PrivateKey(private)
+	case bytes.Equal(oid, oidECPublicKey):
+		var curve der.Input
+		if !algorithm.ReadElement(&curve, der.ObjectIdentifier) || !algorithm.Empty() {
+			return nil, errMalformedPKCS8
+		}
+		return parseECPrivateKey(curve, private)
+	case bytes.Equal(oid, oidEd25519):
+		var seed []byte
+		inner := der.Input(private)
+		if !algorithm.Empty() || !inner.ReadOctetString(&seed) || !inner.Empty() || len(seed) != ed25519.SeedSize {
+			return nil, errMalformedPKCS8
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	return nil, errors.New("x509util: unsupported algorithm in PKCS #8 private key")
+}
+
+// ImportPrivateKeyConstantTime parses a private key encoded in PKCS #8, SEC 1,
+// or PKCS #1, either in the ASN.1 DER form, or in a PEM block of type "PRIVATE
+// KEY", "EC PRIVATE KEY", or "RSA PRIVATE KEY", as produced by OpenSSL and
+// most tools, like data decrypted from a key stored at rest.
+//
+// The format is told apart from the structure of the key, which is public,
+// and the key is parsed without branching on, or indexing memory with, its
+// secret contents, unlike with encoding/pem and crypto/x509, whose base64 and
+// integer decoding leak through timings and caches. The decoded DER of a PEM
+// block is erased before returning.
+//
+// It returns an *rsa.PrivateKey, an *ecdsa.PrivateKey, or an
+// ed25519.PrivateKey. Encrypted keys are rejected, and are parsed by
+// ParseEncryptedPKCS8PrivateKey instead.
+func ImportPrivateKeyConstantTime(data []byte) (crypto.PrivateKey, error) {
+	if typ, block, ok := decodePEM(data); ok {
+		defer zero(block)
+		switch typ {
+		case "PRIVATE KEY":
+			return ParsePKCS8PrivateKey(block)
+		case "EC PRIVATE KEY":
+			return ParseECPrivateKey(block)
+		case "RSA PRIVATE KEY":
+			return ParsePKCS1PrivateKey(block)
+		}
+		return nil, errors.New("x509util: unsupported PEM block type " + typ)
+	} else if typ != "" {
+		return nil, errors.New("x509util: malformed PEM block of type " + typ)
+	}
+
+	// Each format starts with a SEQUENCE and a version, followed by an
+	// AlgorithmIdentifier in PKCS #8, the private key as an OCTET STRING in
+	// SEC 1, and the modulus in PKCS #1.
+	var seq der.Input
+	var version int
+	input := der.Input(data)
+	if input.ReadElement(&seq, der.Sequence) && seq.ReadSmallInteger(&version) {
+		switch {
+		case seq.PeekTag(der.Sequence):
+			return ParsePKCS8PrivateKey(data)
+		case seq.PeekTag(der.OctetString):
+			return ParseECPrivateKey(data)
+		case seq.PeekTag(der.Integer):
+			return ParsePKCS1PrivateKey(data)
+		}
+	}
+	return nil, errors.New("x509util: unrecognized private key format")
+}
@@ -0,0 +1,202 @@
+package x509util
+
+import (
+	"bytes"
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	stdrsa "crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+func TestPKCS1(t *testing.T) {
+	std, err := stdrsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := x509.MarshalPKCS1PrivateKey(std)
+	key, err := ParsePKCS1PrivateKey(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if new(big.Int).SetBytes(key.N.Bytes()).Cmp(std.N) != 0 ||
+		new(big.Int).SetBytes(key.D.Bytes()).Cmp(std.D) != 0 || key.E != std.E {
+		t.Error("parsed key differs from the encoded one")
+	}
+	if got := MarshalPKCS1PrivateKey(key); !bytes.Equal(got, encoded) {
+		t.Error("encoding differs from crypto/x509")
+	}
+	hash := sha256.Sum256([]byte("message"))
+	sig, err := rsa.SignPKCS1v15(key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stdrsa.VerifyPKCS1v15(&std.PublicKey, crypto.SHA256, hash[:], sig); err != nil {
+		t.Error(err)
+	}
+
+	multi, err := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePKCS1PrivateKey(MarshalPKCS1PrivateKey(multi))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.Equal(multi) {
+		t.Error("multi-prime key changed by a round trip")
+	}
+
+	// A key whose private exponent doesn't match is rejected.
+	std.D.Add(std.D, big.NewInt(2))
+	if _, err := ParsePKCS1PrivateKey(x509.MarshalPKCS1PrivateKey(std)); err == nil {
+		t.Error("inconsistent key accepted")
+	}
+	if _, err := ParsePKCS1PrivateKey(encoded[:len(encoded)-1]); err == nil {
+		t.Error("truncated key accepted")
+	}
+}
+
+func TestSEC1(t *testing.T) {
+	for _, c := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		std, err := stdecdsa.GenerateKey(c, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		encoded, err := x509.MarshalECPrivateKey(std)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ParseECPrivateKey(encoded)
+		if err != nil {
+			t.Fatalf("%s: %v", c.Params().Name, err)
+		}
+		if key.Curve != c || key.D.Cmp(std.D) != 0 || key.X.Cmp(std.X) != 0 || key.Y.Cmp(std.Y) != 0 {
+			t.Errorf("%s: parsed key differs from the encoded one", c.Params().Name)
+		}
+		if got, err := MarshalECPrivateKey(key); err != nil || !bytes.Equal(got, encoded) {
+			t.Errorf("%s: encoding differs from crypto/x509", c.Params().Name)
+		}
+	}
+
+	// The private scalar must be in [1, n - 1].
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []*big.Int{new(big.Int), elliptic.P256().Params().N} {
+		key.D = d
+		if _, err := ParseECPrivateKey(marshalECPrivateKey(key, oidP256)); err == nil {
+			t.Errorf("private scalar %d accepted", d)
+		}
+	}
+}
+
+func TestPKCS8(t *testing.T) {
+	rsaKey, err := stdrsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := stdecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, std := range []crypto.PrivateKey{rsaKey, ecKey, edKey} {
+		encoded, err := x509.MarshalPKCS8PrivateKey(std)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ParsePKCS8PrivateKey(encoded)
+		if err != nil {
+			t.Fatalf("%T: %v", std, err)
+		}
+		if got, err := MarshalPKCS8PrivateKey(key); err != nil || !bytes.Equal(got, encoded) {
+			t.Errorf("%T: encoding differs from crypto/x509", std)
+		}
+	}
+	if _, err := MarshalPKCS8PrivateKey("not a key"); err == nil {
+		t.Error("unsupported key encoded")
+	}
+}
+
+func TestImportPrivateKeyConstantTime(t *testing.T) {
+	rsaKey, err := stdrsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1 := x509.MarshalPKCS1PrivateKey(rsaKey)
+
+	for _, tc := range []struct {
+		typ     string
+		encoded []byte
+		isRSA   bool
+	}{
+		{"PRIVATE KEY", pkcs8, false},
+		{"EC PRIVATE KEY", sec1, false},
+		{"RSA PRIVATE KEY", pkcs1, true},
+	} {
+		block := pem.EncodeToMemory(&pem.Block{Type: tc.typ, Bytes: tc.encoded})
+		crlf := bytes.ReplaceAll(block, []byte("\n"), []byte("\r\n"))
+		for _, data := range [][]byte{tc.encoded, block, crlf, append([]byte("leading text\n"), block...)} {
+			key, err := ImportPrivateKeyConstantTime(data)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.typ, err)
+			}
+			switch key := key.(type) {
+			case *rsa.PrivateKey:
+				if !tc.isRSA || new(big.Int).SetBytes(key.D.Bytes()).Cmp(rsaKey.D) != 0 {
+					t.Errorf("%s: wrong key imported", tc.typ)
+				}
+			case *ecdsa.PrivateKey:
+				if tc.isRSA || key.D.Cmp(ecKey.D) != 0 {
+					t.Errorf("%s: wrong key imported", tc.typ)
+				}
+			default:
+				t.Errorf("%s: imported a %T", tc.typ, key)
+			}
+		}
+	}
+
+	block := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+	lines := strings.Split(block, "\n")
+	for name, data := range map[string]string{
+		"encrypted":     strings.Replace(block, "KEY-----\n", "KEY-----\nProc-Type: 4,ENCRYPTED\n\n", 1),
+		"invalid":       strings.Replace(block, lines[1][:4], lines[1][:3]+"*", 1),
+		"unpadded":      strings.Replace(block, "=", "", -1),
+		"truncated":     strings.Join(append(lines[:2:2], lines[len(lines)-2:]...), "\n"),
+		"missing end":   strings.Join(lines[:len(lines)-2], "\n"),
+		"wrong type":    strings.Replace(block, "EC PRIVATE KEY", "CERTIFICATE", -1),
+		"mismatched":    strings.Replace(block, "END EC", "END RSA", 1),
+		"no key at all": "hello",
+	} {
+		if _, err := ImportPrivateKeyConstantTime([]byte(data)); err == nil {
+			t.Errorf("%s PEM block accepted", name)
+		}
+	}
+}
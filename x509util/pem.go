@@ -0,0 +1,104 @@
+package x509util
+
+import (
+	"bytes"
+	"crypto/subtle"
+)
+
+var (
+	pemBegin  = []byte("-----BEGIN ")
+	pemEnd    = []byte("-----END ")
+	pemDashes = []byte("-----")
+)
+
+// decodePEM decodes the first PEM block of data, and returns its type and its
+// contents. If data holds no block, typ is empty, and if the block is
+// malformed, ok is false.
+//
+// Unlike encoding/pem, the base64 body is decoded without branching on its
+// characters, or indexing a table with them. Only whitespace, padding, and
+// the dashes of the end line are looked for, so that the layout of the block
+// is all that is revealed. Headers, which are only used by encrypted blocks,
+// aren't supported.
+func decodePEM(data []byte) (typ string, block []byte, ok bool) {
+	start := bytes.Index(data, pemBegin)
+	if start < 0 {
+		return "", nil, false
+	}
+	rest := data[start+len(pemBegin):]
+	i := bytes.Index(rest, pemDashes)
+	if i < 0 || bytes.IndexByte(rest[:i], '\n') >= 0 {
+		return "", nil, false
+	}
+	typ = string(rest[:i])
+	rest = bytes.TrimPrefix(rest[i+len(pemDashes):], []byte("\r"))
+	if len(rest) == 0 || rest[0] != '\n' {
+		return typ, nil, false
+	}
+	end := bytes.Index(rest, append(append(append([]byte{}, pemEnd...), typ...), pemDashes...))
+	if end < 0 {
+		return typ, nil, false
+	}
+	block, ok = decodeBase64(rest[:end])
+	return typ, block, ok
+}
+
+// inRange returns -1 if lo <= c <= hi, and 0 otherwise.
+func inRange(c, lo, hi int32) int32 {
+	return ((lo - 1 - c) & (c - hi - 1)) >> 31
+}
+
+// decodeChar returns the value of a character of the standard base64
+// alphabet, and 1, or 0 and 0 if c isn't in the alphabet, using arithmetic
+// instead of a lookup table.
+func decodeChar(c byte) (byte, int) {
+	x := int32(c)
+	upper := inRange(x, 'A', 'Z')
+	lower := inRange(x, 'a', 'z')
+	digit := inRange(x, '0', '9')
+	plus := inRange(x, '+', '+')
+	slash := inRange(x, '/', '/')
+	v := upper&(x-'A') | lower&(x-'a'+26) | digit&(x-'0'+52) | plus&62 | slash&63
+	return byte(v), int((upper | lower | digit | plus | slash) & 1)
+}
+
+// isSpace returns 1 if c is whitespace, and 0 otherwise.
+func isSpace(c byte) int {
+	return subtle.ConstantTimeByteEq(c, ' ') | subtle.ConstantTimeByteEq(c, '\t') |
+		subtle.ConstantTimeByteEq(c, '\r') | subtle.ConstantTimeByteEq(c, '\n')
+}
+
+// decodeBase64 decodes padded, standard base64, ignoring whitespace.
+func decodeBase64(body []byte) ([]byte, bool) {
+	out := make([]byte, 0, len(body)*3/4)
+	var acc uint32
+	bits, count, pad, valid := 0, 0, 0, 1
+	for _, c := range body {
+		// The positions of whitespace and padding are part of the layout.
+		if isSpace(c) == 1 {
+			continue
+		}
+		if subtle.ConstantTimeByteEq(c, '=') == 1 {
+			pad++
+			continue
+		}
+		if pad > 0 {
+			valid = 0
+		}
+		v, ok := decodeChar(c)
+		valid &= ok
+		acc = acc<<6 | uint32(v)
+		count++
+		if bits += 6; bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	// The characters, with the padding, come in groups of 4, of which at
+	// least 2 aren't padding.
+	if valid != 1 || (count+pad)%4 != 0 || pad > 2 {
+		zero(out)
+		return nil, false
+	}
+	return out, true
+}
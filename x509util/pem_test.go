@@ -0,0 +1,44 @@
+package x509util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDecodeChar(t *testing.T) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+	for c := 0; c < 256; c++ {
+		v, ok := decodeChar(byte(c))
+		i := strings.IndexByte(alphabet, byte(c))
+		if i < 0 && ok != 0 || i >= 0 && (ok != 1 || int(v) != i) {
+			t.Errorf("%q decoded as %d, %d", c, v, ok)
+		}
+	}
+}
+
+func TestDecodeBase64(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for n := 0; n < 200; n++ {
+		data := make([]byte, n)
+		r.Read(data)
+		encoded := base64.StdEncoding.EncodeToString(data)
+		// Line breaks are inserted every 64 characters, like in PEM.
+		var lines []string
+		for len(encoded) > 64 {
+			lines, encoded = append(lines, encoded[:64]), encoded[64:]
+		}
+		body := strings.Join(append(lines, encoded), "\n") + "\n"
+		got, ok := decodeBase64([]byte(body))
+		if !ok || !bytes.Equal(got, data) {
+			t.Fatalf("%x decoded as %x, %v", data, got, ok)
+		}
+	}
+	for _, body := range []string{"QQ", "QQ=", "QQ===", "Q===", "QQ==QQ==", "QQ-=", "QUJD\nRA=x"} {
+		if _, ok := decodeBase64([]byte(body)); ok {
+			t.Errorf("%q accepted", body)
+		}
+	}
+}
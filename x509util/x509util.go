@@ -0,0 +1,154 @@
+// Package x509util creates certificate signing requests and certificates with
+// the keys of this module, through crypto/x509.
+//
+// crypto/x509 only accepts the key types of the standard library, so the
+// public keys of the ecdsa and rsa packages of this module are converted to
+// those, and signing goes through their crypto.Signer implementations, or
+// through any other crypto.Signer holding such a key, like a key in an HSM.
+//
+// It also encodes and parses private keys in the PKCS #1, SEC 1, and PKCS #8
+// forms, without leaking them through timings, and ImportPrivateKeyConstantTime
+// parses any of these, in DER or PEM. Keys at rest can be protected with a
+// password, in the encrypted PKCS #8 form of MarshalEncryptedPKCS8PrivateKey
+// and ParseEncryptedPKCS8PrivateKey.
+package x509util
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	stdrsa "crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/internal/randutil"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+var errUnsupportedKey = errors.New("x509util: unsupported key type")
+
+// PublicKey converts a public key of this module to the matching type of the
+// standard library, which crypto/x509 can encode. Keys of the standard
+// library are returned as is.
+func PublicKey(pub crypto.PublicKey) (crypto.PublicKey, error) {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		return &stdecdsa.PublicKey{Curve: pub.Curve, X: pub.X, Y: pub.Y}, nil
+	case *rsa.PublicKey:
+		return &stdrsa.PublicKey{N: new(big.Int).SetBytes(pub.N.Bytes()), E: pub.E}, nil
+	case *stdecdsa.PublicKey, *stdrsa.PublicKey:
+		return pub, nil
+	}
+	return nil, errUnsupportedKey
+}
+
+// SignatureAlgorithm returns the signature algorithm to use with a public
+// key: ECDSA with the hash matching the strength of the curve, which is
+// SHA-256 for P-256, SHA-384 for P-384, and SHA-512 for P-521, or PKCS #1
+// v1.5 with SHA-256 for RSA.
+func SignatureAlgorithm(pub crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	pub, err := PublicKey(pub)
+	if err != nil {
+		return x509.UnknownSignatureAlgorithm, err
+	}
+	switch pub := pub.(type) {
+	case *stdecdsa.PublicKey:
+		switch pub.Curve.Params().Name {
+		case "P-256":
+			return x509.ECDSAWithSHA256, nil
+		case "P-384":
+			return x509.ECDSAWithSHA384, nil
+		case "P-521":
+			return x509.ECDSAWithSHA512, nil
+		}
+		return x509.UnknownSignatureAlgorithm, errors.New("x509util: unsupported curve " + pub.Curve.Params().Name)
+	case *stdrsa.PublicKey:
+		return x509.SHA256WithRSA, nil
+	}
+	return x509.UnknownSignatureAlgorithm, errUnsupportedKey
+}
+
+// signer presents a crypto.Signer holding a key of this module to
+// crypto/x509, as a key of the standard library.
+type signer struct {
+	crypto.Signer
+	pub crypto.PublicKey
+}
+
+func (s *signer) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	// crypto/x509 asks for PSS with the options type of crypto/rsa.
+	if pss, ok := opts.(*stdrsa.PSSOptions); ok {
+		opts = &rsa.PSSOptions{SaltLength: pss.SaltLength, Hash: pss.Hash}
+	}
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+// Signer adapts a crypto.Signer holding a key of this module, like an
+// *ecdsa.PrivateKey, so that it can be passed to crypto/x509 directly.
+// Signers holding a key of the standard library are returned as is.
+func Signer(key crypto.Signer) (crypto.Signer, error) {
+	switch key.Public().(type) {
+	case *stdecdsa.PublicKey, *stdrsa.PublicKey:
+		return key, nil
+	}
+	pub, err := PublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &signer{key, pub}, nil
+}
+
+// CreateCertificateRequest creates a certificate signing request, signed by
+// key, like x509.CreateCertificateRequest.
+//
+// If the template has no signature algorithm, the one returned by
+// SignatureAlgorithm is used.
+func CreateCertificateRequest(rand io.Reader, template *x509.CertificateRequest, key crypto.Signer) ([]byte, error) {
+	s, err := Signer(key)
+	if err != nil {
+		return nil, err
+	}
+	if template.SignatureAlgorithm == x509.UnknownSignatureAlgorithm {
+		t := *template
+		if t.SignatureAlgorithm, err = SignatureAlgorithm(s.Public()); err != nil {
+			return nil, err
+		}
+		template = &t
+	}
+	return x509.CreateCertificateRequest(randutil.Or(rand), template, s)
+}
+
+// CreateCertificate creates a certificate for pub, signed by the key of the
+// issuer, whose certificate is parent, like x509.CreateCertificate. For a
+// self-signed certificate, parent is template, and key holds pub.
+//
+// Both keys can be of this module, or of the standard library. If the
+// template has no signature algorithm, the one returned by SignatureAlgorithm
+// for the key of the issuer is used.
+func CreateCertificate(rand io.Reader, template, parent *x509.Certificate, pub crypto.PublicKey, key crypto.Signer) ([]byte, error) {
+	s, err := Signer(key)
+	if err != nil {
+		return nil, err
+	}
+	stdPub, err := PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if template.SignatureAlgorithm == x509.UnknownSignatureAlgorithm {
+		t := *template
+		if t.SignatureAlgorithm, err = SignatureAlgorithm(s.Public()); err != nil {
+			return nil, err
+		}
+		if parent == template {
+			parent = &t
+		}
+		template = &t
+	}
+	return x509.CreateCertificate(randutil.Or(rand), template, parent, stdPub, s)
+}
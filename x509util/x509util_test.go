@@ -0,0 +1,121 @@
+package x509util
+
+import (
+	"crypto"
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cronokirby/ctcrypto/ecdsa"
+	"github.com/cronokirby/ctcrypto/rsa"
+)
+
+func template(name string, ca bool) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  ca,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+}
+
+func TestSignatureAlgorithm(t *testing.T) {
+	for curve, want := range map[elliptic.Curve]x509.SignatureAlgorithm{
+		elliptic.P256(): x509.ECDSAWithSHA256,
+		elliptic.P384(): x509.ECDSAWithSHA384,
+		elliptic.P521(): x509.ECDSAWithSHA512,
+	} {
+		priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := SignatureAlgorithm(priv.Public()); got != want || err != nil {
+			t.Errorf("%s: got %v, %v, expected %v", curve.Params().Name, got, err, want)
+		}
+	}
+	if _, err := SignatureAlgorithm("not a key"); err == nil {
+		t.Error("unsupported key accepted")
+	}
+}
+
+func TestIssue(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caDER, err := CreateCertificate(rand.Reader, template("CA", true), template("CA", true), caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ca.SignatureAlgorithm != x509.ECDSAWithSHA384 {
+		t.Errorf("CA signed with %v", ca.SignatureAlgorithm)
+	}
+	if err := ca.CheckSignatureFrom(ca); err != nil {
+		t.Error(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdKey, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string]crypto.Signer{
+		"ECDSA P-256":  func() crypto.Signer { k, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader); return k }(),
+		"RSA":          rsaKey,
+		"crypto/ecdsa": stdKey,
+	} {
+		csrDER, err := CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: name}}, key)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := csr.CheckSignature(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		certDER, err := CreateCertificate(rand.Reader, template(name, false), ca, csr.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		cert, err := x509.ParseCertificate(certDER)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := cert.CheckSignatureFrom(ca); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// An RSA issuer, signing with PSS.
+	tmpl := template("RSA CA", true)
+	tmpl.SignatureAlgorithm = x509.SHA256WithRSAPSS
+	der, err := CreateCertificate(rand.Reader, tmpl, tmpl, rsaKey.Public(), rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.CheckSignatureFrom(cert); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,172 @@
+// Package xeddsa implements the XEdDSA signature scheme, as described in the
+// XEdDSA and VXEdDSA specification published by Signal.
+//
+// XEdDSA produces Ed25519-compatible signatures using X25519 key pairs, which
+// lets a single key be used both for Diffie-Hellman and for signing, as in the
+// X3DH key agreement protocol.
+package xeddsa
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"github.com/cronokirby/ctcrypto/ctgrind"
+	"github.com/cronokirby/ctcrypto/curve25519"
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"github.com/cronokirby/ctcrypto/fips"
+	"github.com/cronokirby/safenum"
+)
+
+const (
+	// SignatureSize is the size of a signature, in bytes.
+	SignatureSize = 64
+	// randomSize is the size of the random value Z mixed into the nonce.
+	randomSize = 64
+)
+
+// calculateKeyPair converts a Montgomery private key into an Edwards key pair
+// (A, a), such that the sign bit of A is 0.
+//
+// This is calculate_key_pair in section 2.3 of the specification.
+func calculateKeyPair(privateKey []byte) (*edwards25519.Point, *safenum.Nat, error) {
+	k, err := edwards25519.ScalarFromClampedBytes(privateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	E := new(edwards25519.Point).ScalarBaseMult(k)
+	// The sign bit is public, since it's part of the public key.
+	encoded := E.Bytes()
+	ctgrind.Declassify(encoded)
+	if encoded[31]>>7 == 1 {
+		k.ModSub(new(safenum.Nat), k, edwards25519.Order)
+		E.Negate(E)
+	}
+	return E, k, nil
+}
+
+// publicKey returns the Montgomery form of A, which is public.
+func publicKey(A *edwards25519.Point) []byte {
+	u := A.BytesMontgomery()
+	ctgrind.Declassify(u)
+	return u
+}
+
+// hash1 returns SHA-512(2^256 - 2 || x), reduced modulo the order, which is
+// hash_1 in section 2.5 of the specification.
+func hash1(parts ...[]byte) *safenum.Nat {
+	h := sha512.New()
+	prefix := make([]byte, 32)
+	prefix[0] = 0xfe
+	for i := 1; i < len(prefix); i++ {
+		prefix[i] = 0xff
+	}
+	h.Write(prefix)
+	for _, part := range parts {
+		h.Write(part)
+	}
+	s, _ := edwards25519.ScalarFromUniformBytes(h.Sum(nil))
+	return s
+}
+
+// challenge returns SHA-512(R || A || M), reduced modulo the order.
+func challenge(R, A, message []byte) *safenum.Nat {
+	h := sha512.New()
+	h.Write(R)
+	h.Write(A)
+	h.Write(message)
+	s, _ := edwards25519.ScalarFromUniformBytes(h.Sum(nil))
+	return s
+}
+
+// verifyAfterSign is 1 if signatures are verified before being returned.
+var verifyAfterSign int32
+
+// SetVerifyAfterSign sets whether Sign verifies every signature against the
+// public key before returning it, which is disabled by default.
+//
+// This protects against fault attacks, where a glitch during signing turns a
+// signature into a way to recover the private key, at the cost of a
+// verification per signature. The nonce also depends on 64 random bytes, which
+// already thwarts most of these attacks, but not if rand can be made to repeat
+// its output.
+func SetVerifyAfterSign(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&verifyAfterSign, v)
+}
+
+// VerifyAfterSign reports whether Sign verifies the signatures it produces.
+func VerifyAfterSign() bool {
+	return atomic.LoadInt32(&verifyAfterSign) == 1
+}
+
+// Sign signs message with an X25519 private key, reading 64 bytes of
+// randomness from rand.
+//
+// The resulting signature can be verified with Verify, using the X25519
+// public key corresponding to privateKey.
+func Sign(privateKey, message []byte, rand io.Reader) ([]byte, error) {
+	if err := fips.Check(false, "XEdDSA"); err != nil {
+		return nil, err
+	}
+	if len(privateKey) != curve25519.ScalarSize {
+		return nil, errors.New("xeddsa: invalid private key length")
+	}
+	privateKey = append([]byte{}, privateKey...)
+	ctgrind.MarkSecret(privateKey)
+	A, a, err := calculateKeyPair(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	Z := make([]byte, randomSize)
+	if _, err := io.ReadFull(rand, Z); err != nil {
+		return nil, err
+	}
+	ctgrind.MarkSecret(Z)
+	r := hash1(edwards25519.ScalarBytes(a), message, Z)
+	R := new(edwards25519.Point).ScalarBaseMult(r).Bytes()
+	h := challenge(R, A.Bytes(), message)
+	s := new(safenum.Nat).ModMul(h, a, edwards25519.Order)
+	s.ModAdd(s, r, edwards25519.Order)
+
+	sig := make([]byte, 0, SignatureSize)
+	sig = append(sig, R...)
+	sig = append(sig, edwards25519.ScalarBytes(s)...)
+	ctgrind.Declassify(sig)
+	if VerifyAfterSign() && !Verify(publicKey(A), message, sig) {
+		return nil, errors.New("xeddsa: signature failed to verify, signing was faulty")
+	}
+	return sig, nil
+}
+
+// Verify reports whether sig is a valid signature of message by the X25519
+// public key publicKey.
+//
+// Unlike the specification, which only bounds s by 2^253, this requires the
+// scalar of the signature to be reduced, which rules out malleable signatures.
+func Verify(publicKey, message, sig []byte) bool {
+	if len(publicKey) != curve25519.PointSize || len(sig) != SignatureSize {
+		return false
+	}
+	// The public key is converted with a sign bit of 0, matching the choice
+	// made by calculateKeyPair.
+	A, err := new(edwards25519.Point).SetBytesMontgomery(publicKey, 0)
+	if err != nil {
+		return false
+	}
+	s, err := edwards25519.ScalarFromCanonicalBytes(sig[32:])
+	if err != nil {
+		return false
+	}
+	h := challenge(sig[:32], A.Bytes(), message)
+	// R = s B - h A
+	R := new(edwards25519.Point).ScalarBaseMult(s)
+	R.Subtract(R, new(edwards25519.Point).ScalarMult(h, A))
+	// Everything here is public, so there's no need for a constant-time check.
+	return bytes.Equal(R.Bytes(), sig[:32])
+}
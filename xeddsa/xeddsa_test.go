@@ -0,0 +1,106 @@
+package xeddsa
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/curve25519"
+	"github.com/cronokirby/ctcrypto/edwards25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+func generateKey(t *testing.T) (privateKey, publicKey []byte) {
+	privateKey = make([]byte, curve25519.ScalarSize)
+	rand.Read(privateKey)
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, publicKey
+}
+
+func TestSignVerify(t *testing.T) {
+	message := []byte("hello world")
+	for i := 0; i < 4; i++ {
+		privateKey, publicKey := generateKey(t)
+		sig, err := Sign(privateKey, message, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Verify(publicKey, message, sig) {
+			t.Errorf("valid signature rejected")
+		}
+		if Verify(publicKey, []byte("hello world!"), sig) {
+			t.Errorf("signature accepted for the wrong message")
+		}
+		sig[5] ^= 1
+		if Verify(publicKey, message, sig) {
+			t.Errorf("tampered signature accepted")
+		}
+	}
+}
+
+func TestVerifyAfterSign(t *testing.T) {
+	defer SetVerifyAfterSign(VerifyAfterSign())
+	SetVerifyAfterSign(true)
+	message := []byte("hello world")
+	privateKey, publicKey := generateKey(t)
+	sig, err := Sign(privateKey, message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify(publicKey, message, sig) {
+		t.Errorf("valid signature rejected")
+	}
+}
+
+func TestVerifyWrongKey(t *testing.T) {
+	privateKey, _ := generateKey(t)
+	_, otherPublicKey := generateKey(t)
+	sig, err := Sign(privateKey, []byte("message"), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Verify(otherPublicKey, []byte("message"), sig) {
+		t.Errorf("signature accepted under the wrong key")
+	}
+}
+
+func TestCompatibleWithEd25519(t *testing.T) {
+	// XEdDSA signatures are Ed25519 signatures under the Edwards form of the
+	// public key, with a sign bit of 0.
+	message := []byte("compatibility")
+	privateKey, publicKey := generateKey(t)
+	sig, err := Sign(privateKey, message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	A, err := new(edwards25519.Point).SetBytesMontgomery(publicKey, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(A.Bytes(), message, sig) {
+		t.Errorf("signature rejected by Ed25519")
+	}
+}
+
+func TestVerifyRejectsUnreducedScalar(t *testing.T) {
+	message := []byte("malleability")
+	privateKey, publicKey := generateKey(t)
+	sig, err := Sign(privateKey, message, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Adding the order to s gives an equivalent, but unreduced, signature.
+	orderBytes := edwards25519.Order.Bytes()
+	orderBytes = orderBytes[len(orderBytes)-32:]
+	var carry int
+	for i := 0; i < 32; i++ {
+		sum := int(sig[32+i]) + int(orderBytes[31-i]) + carry
+		sig[32+i] = byte(sum)
+		carry = sum >> 8
+	}
+	if Verify(publicKey, message, sig) {
+		t.Errorf("signature with s >= l accepted")
+	}
+}
@@ -0,0 +1,310 @@
+package zk
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// This file implements proofs that the same secret underlies public keys in
+// two different groups, following Noether, "Discrete Logarithm Equality Across
+// Groups", as used by atomic swaps between chains using different curves.
+//
+// Since the groups have different orders, a Chaum-Pedersen proof doesn't
+// work: instead, the secret is decomposed into bits, each of which is
+// committed to in both groups, with Pedersen commitments b G + r H, whose
+// blinding factors are chosen so that the commitments add up to the public
+// keys. For each bit, a ring signature shows that both commitments open to 0,
+// or both open to 1, using the same challenges in both groups. These
+// challenges are small enough to be scalars of both groups, so that the
+// proofs are sound in each of them.
+
+// crossGroupDST is the domain separation tag of CrossGroupDLEQProof challenges.
+const crossGroupDST = "ctcrypto-zk-crossgroup-dleq-v1"
+
+// crossGroupChallengeSize is the size of the challenges of the proof of each
+// bit, which must be smaller than the orders of both groups.
+const crossGroupChallengeSize = 16
+
+// CrossGroupBits returns the number of bits of the secrets whose equality
+// across two groups can be proven, which is one less than the size of the
+// smallest order, so that the secret has the same value in both groups.
+func CrossGroupBits(g1, g2 group.Group) int {
+	bits := g1.Order().BitLen()
+	if b := g2.Order().BitLen(); b < bits {
+		bits = b
+	}
+	return int(bits) - 1
+}
+
+// crossGroupBase returns the second generator H of Pedersen commitments in a
+// group, whose discrete logarithm is unknown.
+func crossGroupBase(g group.Group) group.Element {
+	return g.HashToElement([]byte("H"), []byte(crossGroupDST))
+}
+
+// CrossGroupBitProof is the proof that the commitments to one bit of the
+// secret, in both groups, open to the same value.
+type CrossGroupBitProof struct {
+	// Commitment1 = b G1 + r H1, and Commitment2 = b G2 + s H2.
+	Commitment1, Commitment2 group.Element
+	// Challenges of the two branches of the ring signature, for b = 0 and
+	// b = 1, whose XOR is the Fiat-Shamir challenge.
+	Challenges [2][crossGroupChallengeSize]byte
+	// Responses of both branches, in the first and second group.
+	Responses1, Responses2 [2]*safenum.Nat
+}
+
+// CrossGroupDLEQProof is a proof that X = x G1 and Y = x G2 have the same
+// discrete logarithm x, in two groups of different orders, with respect to
+// their generators.
+type CrossGroupDLEQProof struct {
+	// Bits contains the proofs for the CrossGroupBits(g1, g2) bits of x,
+	// starting with the least significant one.
+	Bits []CrossGroupBitProof
+}
+
+func crossGroupChallenge(g1, g2 group.Group, X, Y group.Element, i int, bit *CrossGroupBitProof, R1, R2 [2]group.Element, context []byte) [crossGroupChallengeSize]byte {
+	t := newTranscript(g1, crossGroupDST, context)
+	t.appendBytes("group2", []byte(g2.Name()))
+	t.appendElement("X", X)
+	t.appendElement("Y", Y)
+	t.appendBytes("i", []byte{byte(i >> 8), byte(i)})
+	t.appendElement("C1", bit.Commitment1)
+	t.appendElement("C2", bit.Commitment2)
+	t.appendElement("R1[0]", R1[0])
+	t.appendElement("R1[1]", R1[1])
+	t.appendElement("R2[0]", R2[0])
+	t.appendElement("R2[1]", R2[1])
+	var out [crossGroupChallengeSize]byte
+	t.challengeBytes(out[:])
+	return out
+}
+
+// crossGroupBlinders returns random blinding factors rᵢ, such that
+// Σ 2^i rᵢ = 0, so that the commitments to the bits of x add up to x G.
+func crossGroupBlinders(g group.Group, bits int, rand io.Reader) ([]*safenum.Nat, error) {
+	q := g.Order()
+	out := make([]*safenum.Nat, bits)
+	sum := new(safenum.Nat).Mod(new(safenum.Nat), q)
+	pow := new(safenum.Nat).Mod(new(safenum.Nat).SetUint64(1), q)
+	for i := 0; i < bits-1; i++ {
+		r, err := g.RandomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = r
+		sum.ModAdd(sum, new(safenum.Nat).ModMul(pow, r, q), q)
+		pow.ModAdd(pow, pow, q)
+	}
+	// r_last = -Σ 2^i rᵢ / 2^(bits - 1)
+	last := new(safenum.Nat).ModSub(new(safenum.Nat).Mod(new(safenum.Nat), q), sum, q)
+	out[bits-1] = last.ModMul(last, new(safenum.Nat).ModInverse(pow, q), q)
+	return out, nil
+}
+
+// commitmentMinusBase returns C - j G, for j ∈ {0, 1}, which is the j-th key of the
+// ring signature of a bit.
+func commitmentMinusBase(g group.Group, C group.Element, j int) group.Element {
+	if j == 0 {
+		return C
+	}
+	return group.Sub(C, g.Generator())
+}
+
+// ProveCrossGroupDLEQ proves that X = x G1 and Y = x G2 have the same discrete
+// logarithm x, in two different groups, reading randomness from rand.
+//
+// The secret must be smaller than 2^CrossGroupBits(g1, g2), which requires
+// generating it specially, rather than with the RandomScalar method of either
+// group. An error is returned if it isn't.
+//
+// The proof contains the commitments, and ring signatures, of each bit of x,
+// which makes it hundreds of times larger than a DLEQProof, and slower to
+// create and verify, with about a dozen scalar multiplications per bit.
+func ProveCrossGroupDLEQ(g1, g2 group.Group, x *safenum.Nat, context []byte, rand io.Reader) (*CrossGroupDLEQProof, error) {
+	bits := CrossGroupBits(g1, g2)
+	xBytes := x.Bytes()
+	if len(xBytes)*8 < bits {
+		xBytes = append(make([]byte, (bits+7)/8-len(xBytes)), xBytes...)
+	}
+	bit := func(i int) int {
+		return int(xBytes[len(xBytes)-1-i/8]>>(i%8)) & 1
+	}
+	// Whether the secret is too large is the only thing leaked about it.
+	tooLarge := 0
+	for i := bits; i < len(xBytes)*8; i++ {
+		tooLarge |= bit(i)
+	}
+	if tooLarge != 0 {
+		return nil, errors.New("zk: secret too large for a cross-group proof")
+	}
+	q1, q2 := g1.Order(), g2.Order()
+	H1, H2 := crossGroupBase(g1), crossGroupBase(g2)
+	r, err := crossGroupBlinders(g1, bits, rand)
+	if err != nil {
+		return nil, err
+	}
+	s, err := crossGroupBlinders(g2, bits, rand)
+	if err != nil {
+		return nil, err
+	}
+	X := g1.ScalarBaseMult(x)
+	Y := g2.ScalarBaseMult(new(safenum.Nat).Mod(x, q2))
+	proof := &CrossGroupDLEQProof{Bits: make([]CrossGroupBitProof, bits)}
+	for i := range proof.Bits {
+		b := bit(i)
+		bNat := new(safenum.Nat).SetUint64(uint64(b))
+		p := &proof.Bits[i]
+		p.Commitment1 = g1.ScalarBaseMult(bNat).Add(H1.ScalarMult(r[i]))
+		p.Commitment2 = g2.ScalarBaseMult(bNat).Add(H2.ScalarMult(s[i]))
+		// Both branches are computed in the same way, to not leak which one
+		// is real: the simulated one gets a random challenge e, and the real
+		// one e = 0, which makes its commitments a H.
+		var e [crossGroupChallengeSize]byte
+		if _, err := io.ReadFull(rand, e[:]); err != nil {
+			return nil, err
+		}
+		var (
+			masks  [2]byte
+			reals  [2]*safenum.Nat
+			a1, a2 [2]*safenum.Nat
+			R1, R2 [2]group.Element
+		)
+		masks[1] = byte(-b)
+		masks[0] = ^masks[1]
+		reals[1] = bNat
+		reals[0] = new(safenum.Nat).SetUint64(uint64(1 - b))
+		for j := 0; j < 2; j++ {
+			for k := range e {
+				p.Challenges[j][k] = e[k] &^ masks[j]
+			}
+			if a1[j], err = g1.RandomScalar(rand); err != nil {
+				return nil, err
+			}
+			if a2[j], err = g2.RandomScalar(rand); err != nil {
+				return nil, err
+			}
+			c := new(safenum.Nat).SetBytes(p.Challenges[j][:])
+			R1[j] = group.Sub(H1.ScalarMult(a1[j]), commitmentMinusBase(g1, p.Commitment1, j).ScalarMult(new(safenum.Nat).Mod(c, q1)))
+			R2[j] = group.Sub(H2.ScalarMult(a2[j]), commitmentMinusBase(g2, p.Commitment2, j).ScalarMult(new(safenum.Nat).Mod(c, q2)))
+		}
+		c := crossGroupChallenge(g1, g2, X, Y, i, p, R1, R2, context)
+		for j := 0; j < 2; j++ {
+			// The real branch gets c ⊕ e, and the simulated one keeps e.
+			for k := range c {
+				p.Challenges[j][k] ^= masks[j] & (c[k] ^ e[k])
+			}
+			cj := new(safenum.Nat).SetBytes(p.Challenges[j][:])
+			// z = a + c r for the real branch, and z = a for the simulated one.
+			z1 := new(safenum.Nat).ModMul(new(safenum.Nat).Mod(cj, q1), r[i], q1)
+			z1.ModMul(z1, reals[j], q1)
+			p.Responses1[j] = z1.ModAdd(z1, a1[j], q1)
+			z2 := new(safenum.Nat).ModMul(new(safenum.Nat).Mod(cj, q2), s[i], q2)
+			z2.ModMul(z2, reals[j], q2)
+			p.Responses2[j] = z2.ModAdd(z2, a2[j], q2)
+		}
+	}
+	return proof, nil
+}
+
+// VerifyCrossGroupDLEQ checks a proof that X and Y have the same discrete
+// logarithm, in the groups g1 and g2, created with the same context.
+func VerifyCrossGroupDLEQ(g1, g2 group.Group, X, Y group.Element, proof *CrossGroupDLEQProof, context []byte) bool {
+	bits := CrossGroupBits(g1, g2)
+	if len(proof.Bits) != bits {
+		return false
+	}
+	q1, q2 := g1.Order(), g2.Order()
+	H1, H2 := crossGroupBase(g1), crossGroupBase(g2)
+	// Σ 2^i Cᵢ = X, and Σ 2^i Dᵢ = Y, with Horner's method, which is cheap
+	// enough to check before the proofs of the bits.
+	sum1, sum2 := g1.Identity(), g2.Identity()
+	for i := bits - 1; i >= 0; i-- {
+		sum1 = sum1.Add(sum1).Add(proof.Bits[i].Commitment1)
+		sum2 = sum2.Add(sum2).Add(proof.Bits[i].Commitment2)
+	}
+	if sum1.Equal(X) != 1 || sum2.Equal(Y) != 1 {
+		return false
+	}
+	for i := range proof.Bits {
+		p := &proof.Bits[i]
+		var R1, R2 [2]group.Element
+		for j := 0; j < 2; j++ {
+			c := new(safenum.Nat).SetBytes(p.Challenges[j][:])
+			// R = z H - c (C - j G)
+			R1[j] = group.Sub(H1.ScalarMult(p.Responses1[j]), commitmentMinusBase(g1, p.Commitment1, j).ScalarMult(new(safenum.Nat).Mod(c, q1)))
+			R2[j] = group.Sub(H2.ScalarMult(p.Responses2[j]), commitmentMinusBase(g2, p.Commitment2, j).ScalarMult(new(safenum.Nat).Mod(c, q2)))
+		}
+		c := crossGroupChallenge(g1, g2, X, Y, i, p, R1, R2, context)
+		// All of the values involved are public.
+		for k := range c {
+			if c[k] != p.Challenges[0][k]^p.Challenges[1][k] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Bytes encodes the proof, as the concatenation of the encodings of the proofs
+// of each bit, made of the commitments, the challenges, and the responses in
+// both groups.
+func (p *CrossGroupDLEQProof) Bytes(g1, g2 group.Group) []byte {
+	var out []byte
+	for _, b := range p.Bits {
+		out = append(out, b.Commitment1.Bytes()...)
+		out = append(out, b.Commitment2.Bytes()...)
+		out = append(out, b.Challenges[0][:]...)
+		out = append(out, b.Challenges[1][:]...)
+		for j := 0; j < 2; j++ {
+			out = append(out, g1.EncodeScalar(b.Responses1[j])...)
+		}
+		for j := 0; j < 2; j++ {
+			out = append(out, g2.EncodeScalar(b.Responses2[j])...)
+		}
+	}
+	return out
+}
+
+// DecodeCrossGroupDLEQProof decodes a proof produced by
+// CrossGroupDLEQProof.Bytes.
+func DecodeCrossGroupDLEQProof(g1, g2 group.Group, data []byte) (*CrossGroupDLEQProof, error) {
+	bits := CrossGroupBits(g1, g2)
+	size := g1.ElementSize() + g2.ElementSize() + 2*crossGroupChallengeSize + 2*g1.ScalarSize() + 2*g2.ScalarSize()
+	if len(data) != bits*size {
+		return nil, errors.New("zk: invalid proof length")
+	}
+	proof := &CrossGroupDLEQProof{Bits: make([]CrossGroupBitProof, bits)}
+	for i := range proof.Bits {
+		p := &proof.Bits[i]
+		var err error
+		if p.Commitment1, err = g1.DecodeElement(data[:g1.ElementSize()]); err != nil {
+			return nil, err
+		}
+		data = data[g1.ElementSize():]
+		if p.Commitment2, err = g2.DecodeElement(data[:g2.ElementSize()]); err != nil {
+			return nil, err
+		}
+		data = data[g2.ElementSize():]
+		for j := 0; j < 2; j++ {
+			copy(p.Challenges[j][:], data)
+			data = data[crossGroupChallengeSize:]
+		}
+		for j := 0; j < 2; j++ {
+			if p.Responses1[j], err = g1.DecodeScalar(data[:g1.ScalarSize()]); err != nil {
+				return nil, err
+			}
+			data = data[g1.ScalarSize():]
+		}
+		for j := 0; j < 2; j++ {
+			if p.Responses2[j], err = g2.DecodeScalar(data[:g2.ScalarSize()]); err != nil {
+				return nil, err
+			}
+			data = data[g2.ScalarSize():]
+		}
+	}
+	return proof, nil
+}
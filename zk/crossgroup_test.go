@@ -0,0 +1,74 @@
+package zk
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// crossGroupSecret returns a random secret small enough for a cross-group
+// proof between g1 and g2.
+func crossGroupSecret(t *testing.T, g1, g2 group.Group) *safenum.Nat {
+	bits := CrossGroupBits(g1, g2)
+	buf := make([]byte, (bits+7)/8)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	buf[0] &= byte(1<<(bits%8)) - 1
+	if bits%8 == 0 {
+		buf[0] = 0
+	}
+	return new(safenum.Nat).SetBytes(buf)
+}
+
+func TestCrossGroupDLEQProof(t *testing.T) {
+	testCrossGroupDLEQProof(t, group.P256(), group.P384())
+}
+
+func TestCrossGroupDLEQProofRistretto255(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping slow ristretto255 proof in short mode")
+	}
+	testCrossGroupDLEQProof(t, group.Ristretto255(), group.P256())
+}
+
+func testCrossGroupDLEQProof(t *testing.T, g1, g2 group.Group) {
+	x := crossGroupSecret(t, g1, g2)
+	X := g1.ScalarBaseMult(new(safenum.Nat).Mod(x, g1.Order()))
+	Y := g2.ScalarBaseMult(new(safenum.Nat).Mod(x, g2.Order()))
+	proof, err := ProveCrossGroupDLEQ(g1, g2, x, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyCrossGroupDLEQ(g1, g2, X, Y, proof, testContext) {
+		t.Error("valid proof rejected")
+	}
+	if VerifyCrossGroupDLEQ(g1, g2, X, Y, proof, []byte("other context")) {
+		t.Error("proof accepted in another context")
+	}
+	if VerifyCrossGroupDLEQ(g1, g2, X, Y.Add(g2.Generator()), proof, testContext) {
+		t.Error("proof accepted for unequal logarithms")
+	}
+	decoded, err := DecodeCrossGroupDLEQProof(g1, g2, proof.Bytes(g1, g2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded.Bytes(g1, g2), proof.Bytes(g1, g2)) {
+		t.Error("decoded proof differs")
+	}
+	decoded.Bits[3].Challenges[0][0] ^= 1
+	if VerifyCrossGroupDLEQ(g1, g2, X, Y, decoded, testContext) {
+		t.Error("proof with a modified challenge accepted")
+	}
+}
+
+func TestCrossGroupDLEQProofTooLarge(t *testing.T) {
+	g1, g2 := group.P384(), group.Ristretto255()
+	x := new(safenum.Nat).SetBytes([]byte{0x20, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	if _, err := ProveCrossGroupDLEQ(g1, g2, x, testContext, rand.Reader); err == nil {
+		t.Error("secret larger than 2^252 accepted")
+	}
+}
@@ -0,0 +1,87 @@
+package zk
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// dleqDST is the domain separation tag of DLEQProof challenges.
+const dleqDST = "ctcrypto-zk-dleq-v1"
+
+// DLEQProof is a Chaum-Pedersen proof that two elements X = x * A and
+// Y = x * B have the same discrete logarithm x, with respect to the bases A
+// and B.
+//
+// With A = G, this proves that (G, B, X, Y) is a Diffie-Hellman tuple, and that
+// Y was computed with the private key corresponding to X.
+type DLEQProof struct {
+	// Challenge is the Fiat-Shamir challenge c.
+	Challenge *safenum.Nat
+	// Response is s = r + c * x, where r is the secret nonce of the prover.
+	Response *safenum.Nat
+}
+
+func dleqChallenge(g group.Group, A, B, X, Y, R1, R2 group.Element, context []byte) *safenum.Nat {
+	t := newTranscript(g, dleqDST, context)
+	t.appendElement("A", A)
+	t.appendElement("B", B)
+	t.appendElement("X", X)
+	t.appendElement("Y", Y)
+	t.appendElement("R1", R1)
+	t.appendElement("R2", R2)
+	return t.challenge()
+}
+
+// ProveDLEQ proves that X = x * A and Y = x * B, for the same secret x,
+// reading the randomness of the commitments from rand.
+func ProveDLEQ(g group.Group, x *safenum.Nat, A, B, X, Y group.Element, context []byte, rand io.Reader) (*DLEQProof, error) {
+	r, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	R1 := A.ScalarMult(r)
+	R2 := B.ScalarMult(r)
+	c := dleqChallenge(g, A, B, X, Y, R1, R2, context)
+	s := new(safenum.Nat).ModMul(c, x, g.Order())
+	s.ModAdd(s, r, g.Order())
+	return &DLEQProof{Challenge: c, Response: s}, nil
+}
+
+// VerifyDLEQ checks a proof that X and Y have the same discrete logarithm
+// with respect to A and B, created with the same context.
+func VerifyDLEQ(g group.Group, A, B, X, Y group.Element, proof *DLEQProof, context []byte) bool {
+	if A.IsIdentity() || B.IsIdentity() {
+		return false
+	}
+	// R1 = s * A - c * X, R2 = s * B - c * Y
+	R1 := group.Sub(A.ScalarMult(proof.Response), X.ScalarMult(proof.Challenge))
+	R2 := group.Sub(B.ScalarMult(proof.Response), Y.ScalarMult(proof.Challenge))
+	c := dleqChallenge(g, A, B, X, Y, R1, R2, context)
+	// All of the values involved are public.
+	return c.Cmp(proof.Challenge) == 0
+}
+
+// Bytes encodes the proof, as the encoding of the challenge followed by the
+// encoding of the response.
+func (p *DLEQProof) Bytes(g group.Group) []byte {
+	return append(g.EncodeScalar(p.Challenge), g.EncodeScalar(p.Response)...)
+}
+
+// DecodeDLEQProof decodes a proof produced by DLEQProof.Bytes.
+func DecodeDLEQProof(g group.Group, data []byte) (*DLEQProof, error) {
+	if len(data) != 2*g.ScalarSize() {
+		return nil, errors.New("zk: invalid proof length")
+	}
+	c, err := g.DecodeScalar(data[:g.ScalarSize()])
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.DecodeScalar(data[g.ScalarSize():])
+	if err != nil {
+		return nil, err
+	}
+	return &DLEQProof{Challenge: c, Response: s}, nil
+}
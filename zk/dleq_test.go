@@ -0,0 +1,55 @@
+package zk
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+)
+
+func TestDLEQProof(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		x, _ := g.RandomScalar(rand.Reader)
+		A := g.Generator()
+		B := g.HashToElement([]byte("base"), []byte("ctcrypto zk test"))
+		X := A.ScalarMult(x)
+		Y := B.ScalarMult(x)
+		proof, err := ProveDLEQ(g, x, A, B, X, Y, testContext, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDLEQ(g, A, B, X, Y, proof, testContext) {
+			t.Errorf("%s: valid proof rejected", g.Name())
+		}
+		if VerifyDLEQ(g, A, B, X, Y, proof, []byte("other context")) {
+			t.Errorf("%s: proof accepted in another context", g.Name())
+		}
+		if VerifyDLEQ(g, A, B, X, Y.Add(B), proof, testContext) {
+			t.Errorf("%s: proof accepted for unequal logarithms", g.Name())
+		}
+		decoded, err := DecodeDLEQProof(g, proof.Bytes(g))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDLEQ(g, A, B, X, Y, decoded, testContext) {
+			t.Errorf("%s: decoded proof rejected", g.Name())
+		}
+	}
+}
+
+func TestDLEQProofUnequalLogarithms(t *testing.T) {
+	g := group.P256()
+	x, _ := g.RandomScalar(rand.Reader)
+	y, _ := g.RandomScalar(rand.Reader)
+	A := g.Generator()
+	B := g.HashToElement([]byte("base"), []byte("ctcrypto zk test"))
+	X := A.ScalarMult(x)
+	Y := B.ScalarMult(y)
+	proof, err := ProveDLEQ(g, x, A, B, X, Y, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyDLEQ(g, A, B, X, Y, proof, testContext) {
+		t.Errorf("proof accepted for unequal logarithms")
+	}
+}
@@ -0,0 +1,84 @@
+// Package zk implements non-interactive zero-knowledge proofs about discrete
+// logarithms in a group.
+//
+// The proofs are sigma protocols, made non-interactive with the Fiat-Shamir
+// transform. The challenge is derived from a transcript binding the group,
+// the statement, the commitments of the prover, and a context string provided
+// by the caller, which should identify the protocol and session the proof is
+// used in, so that proofs can't be replayed in another setting.
+package zk
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+// dlogDST is the domain separation tag of DLogProof challenges.
+const dlogDST = "ctcrypto-zk-dlog-v1"
+
+// DLogProof is a Schnorr proof of knowledge of the discrete logarithm x of a
+// public element X = x * G.
+type DLogProof struct {
+	// Commitment is the commitment R = r * G of the prover.
+	Commitment group.Element
+	// Response is s = r + c * x, where c is the challenge.
+	Response *safenum.Nat
+}
+
+func dlogChallenge(g group.Group, X, R group.Element, context []byte) *safenum.Nat {
+	t := newTranscript(g, dlogDST, context)
+	t.appendElement("X", X)
+	t.appendElement("R", R)
+	return t.challenge()
+}
+
+// ProveDLog proves knowledge of x, such that X = x * G, reading the randomness
+// of the commitment from rand.
+func ProveDLog(g group.Group, x *safenum.Nat, X group.Element, context []byte, rand io.Reader) (*DLogProof, error) {
+	r, err := g.RandomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	R := g.ScalarBaseMult(r)
+	c := dlogChallenge(g, X, R, context)
+	s := new(safenum.Nat).ModMul(c, x, g.Order())
+	s.ModAdd(s, r, g.Order())
+	return &DLogProof{Commitment: R, Response: s}, nil
+}
+
+// VerifyDLog checks a proof that the prover knows the discrete logarithm of X,
+// created with the same context.
+func VerifyDLog(g group.Group, X group.Element, proof *DLogProof, context []byte) bool {
+	if X.IsIdentity() || proof.Commitment.IsIdentity() {
+		return false
+	}
+	c := dlogChallenge(g, X, proof.Commitment, context)
+	// s * G = R + c * X
+	expected := proof.Commitment.Add(X.ScalarMult(c))
+	return g.ScalarBaseMult(proof.Response).Equal(expected) == 1
+}
+
+// Bytes encodes the proof, as the encoding of the commitment followed by the
+// encoding of the response.
+func (p *DLogProof) Bytes(g group.Group) []byte {
+	return append(p.Commitment.Bytes(), g.EncodeScalar(p.Response)...)
+}
+
+// DecodeDLogProof decodes a proof produced by DLogProof.Bytes.
+func DecodeDLogProof(g group.Group, data []byte) (*DLogProof, error) {
+	if len(data) != g.ElementSize()+g.ScalarSize() {
+		return nil, errors.New("zk: invalid proof length")
+	}
+	R, err := g.DecodeElement(data[:g.ElementSize()])
+	if err != nil {
+		return nil, err
+	}
+	s, err := g.DecodeScalar(data[g.ElementSize():])
+	if err != nil {
+		return nil, err
+	}
+	return &DLogProof{Commitment: R, Response: s}, nil
+}
@@ -0,0 +1,68 @@
+package zk
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+)
+
+var testContext = []byte("ctcrypto zk test")
+
+func TestDLogProof(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384(), group.P521()} {
+		x, _ := g.RandomScalar(rand.Reader)
+		X := g.ScalarBaseMult(x)
+		proof, err := ProveDLog(g, x, X, testContext, rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDLog(g, X, proof, testContext) {
+			t.Errorf("%s: valid proof rejected", g.Name())
+		}
+		if VerifyDLog(g, X, proof, []byte("other context")) {
+			t.Errorf("%s: proof accepted in another context", g.Name())
+		}
+		if VerifyDLog(g, X.Add(g.Generator()), proof, testContext) {
+			t.Errorf("%s: proof accepted for another statement", g.Name())
+		}
+		decoded, err := DecodeDLogProof(g, proof.Bytes(g))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !VerifyDLog(g, X, decoded, testContext) {
+			t.Errorf("%s: decoded proof rejected", g.Name())
+		}
+	}
+}
+
+func TestDLogProofWrongWitness(t *testing.T) {
+	g := group.P256()
+	x, _ := g.RandomScalar(rand.Reader)
+	X := g.ScalarBaseMult(x)
+	wrong := new(safenum.Nat).ModAdd(x, new(safenum.Nat).SetUint64(1), g.Order())
+	proof, err := ProveDLog(g, wrong, X, testContext, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyDLog(g, X, proof, testContext) {
+		t.Errorf("proof with the wrong witness accepted")
+	}
+}
+
+func TestDecodeDLogProofRejects(t *testing.T) {
+	g := group.P256()
+	if _, err := DecodeDLogProof(g, make([]byte, 10)); err == nil {
+		t.Errorf("accepted a short proof")
+	}
+	x, _ := g.RandomScalar(rand.Reader)
+	proof, _ := ProveDLog(g, x, g.ScalarBaseMult(x), testContext, rand.Reader)
+	data := proof.Bytes(g)
+	for i := g.ElementSize(); i < len(data); i++ {
+		data[i] = 0xff
+	}
+	if _, err := DecodeDLogProof(g, data); err == nil {
+		t.Errorf("accepted an unreduced response")
+	}
+}
@@ -0,0 +1,60 @@
+package zk
+
+import (
+	"encoding/binary"
+
+	"github.com/cronokirby/ctcrypto/group"
+	"github.com/cronokirby/safenum"
+	"golang.org/x/crypto/sha3"
+)
+
+// transcript accumulates the public values of a proof, from which the
+// Fiat-Shamir challenge is derived.
+//
+// Each value is labelled, and prefixed by its length, so that distinct
+// transcripts can't encode to the same bytes.
+type transcript struct {
+	g   group.Group
+	dst []byte
+	buf []byte
+}
+
+// newTranscript creates a transcript for a given proof system, identified by
+// its domain separation tag, binding the group and the context of the caller.
+func newTranscript(g group.Group, dst string, context []byte) *transcript {
+	t := &transcript{g: g, dst: []byte(dst)}
+	t.appendBytes("group", []byte(g.Name()))
+	t.appendBytes("context", context)
+	return t
+}
+
+// appendBytes appends a labelled value to the transcript.
+func (t *transcript) appendBytes(label string, data []byte) {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(label)))
+	t.buf = append(append(t.buf, length[:]...), label...)
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data)))
+	t.buf = append(append(t.buf, length[:]...), data...)
+}
+
+// appendElement appends a labelled element to the transcript.
+func (t *transcript) appendElement(label string, e group.Element) {
+	t.appendBytes(label, e.Bytes())
+}
+
+// challenge returns the challenge derived from the transcript.
+func (t *transcript) challenge() *safenum.Nat {
+	return t.g.HashToScalar(t.buf, t.dst)
+}
+
+// challengeBytes returns a challenge of len(out) bytes derived from the
+// transcript, rather than a scalar of its group, with SHAKE256.
+func (t *transcript) challengeBytes(out []byte) {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(t.dst)))
+	h := sha3.NewShake256()
+	h.Write(length[:])
+	h.Write(t.dst)
+	h.Write(t.buf)
+	h.Read(out)
+}